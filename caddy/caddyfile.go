@@ -0,0 +1,122 @@
+package caddybsv
+
+import (
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"github.com/caddyserver/caddy/v2/caddyconfig/httpcaddyfile"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+)
+
+func parseCaddyfile(h httpcaddyfile.Helper) (caddyhttp.MiddlewareHandler, error) {
+	var handler Handler
+	err := handler.UnmarshalCaddyfile(h.Dispenser)
+	return &handler, err
+}
+
+// UnmarshalCaddyfile sets up the handler from the bsv_auth Caddyfile directive:
+//
+//	bsv_auth {
+//		wallet <provider> {
+//			...
+//		}
+//		allow_unauthenticated
+//		payload_encryption
+//		idempotency_key_ttl <duration>
+//		replay_window <duration>
+//		route <pattern> exempt|allow_unauthenticated
+//	}
+func (h *Handler) UnmarshalCaddyfile(d *caddyfile.Dispenser) error {
+	d.Next() // consume directive name
+
+	if d.NextArg() {
+		return d.ArgErr()
+	}
+
+	for d.NextBlock(0) {
+		switch d.Val() {
+		case "wallet":
+			if !d.NextArg() {
+				return d.ArgErr()
+			}
+			provider := d.Val()
+			module, err := caddyfile.UnmarshalModule(d, "bsv.wallets."+provider)
+			if err != nil {
+				return err //nolint:wrapcheck // the dispenser errors carry the position in the Caddyfile
+			}
+			h.WalletRaw = caddyconfig.JSONModuleObject(module, "provider", provider, nil)
+
+		case "allow_unauthenticated":
+			if d.NextArg() {
+				return d.ArgErr()
+			}
+			h.AllowUnauthenticated = true
+
+		case "payload_encryption":
+			if d.NextArg() {
+				return d.ArgErr()
+			}
+			h.PayloadEncryption = true
+
+		case "idempotency_key_ttl":
+			duration, err := parseDuration(d)
+			if err != nil {
+				return err
+			}
+			h.IdempotencyKeyTTL = duration
+
+		case "replay_window":
+			duration, err := parseDuration(d)
+			if err != nil {
+				return err
+			}
+			h.ReplayWindow = duration
+
+		case "route":
+			route := Route{}
+			if !d.NextArg() {
+				return d.ArgErr()
+			}
+			route.Pattern = d.Val()
+			if !d.NextArg() {
+				return d.ArgErr()
+			}
+			switch d.Val() {
+			case "exempt":
+				route.Exempt = true
+			case "allow_unauthenticated":
+				route.AllowUnauthenticated = true
+			default:
+				return d.Errf("unknown route policy %q", d.Val())
+			}
+			if d.NextArg() {
+				return d.ArgErr()
+			}
+			h.Routes = append(h.Routes, route)
+
+		default:
+			return d.Errf("unknown subdirective %q", d.Val())
+		}
+	}
+
+	return nil
+}
+
+func parseDuration(d *caddyfile.Dispenser) (caddy.Duration, error) {
+	if !d.NextArg() {
+		return 0, d.ArgErr()
+	}
+	value := d.Val()
+	if d.NextArg() {
+		return 0, d.ArgErr()
+	}
+
+	duration, err := caddy.ParseDuration(value)
+	if err != nil {
+		return 0, d.Errf("invalid duration %q, %v", value, err)
+	}
+	return caddy.Duration(duration), nil
+}
+
+// Interface guard
+var _ caddyfile.Unmarshaler = (*Handler)(nil)
@@ -0,0 +1,154 @@
+// Package caddybsv exposes the auth middleware as the http.handlers.bsv_auth Caddy module, so operators can protect
+// arbitrary upstreams with BRC-103/104 mutual authentication declaratively in the Caddyfile.
+package caddybsv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig/httpcaddyfile"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"go.uber.org/zap"
+)
+
+func init() {
+	caddy.RegisterModule(Handler{})
+	httpcaddyfile.RegisterHandlerDirective("bsv_auth", parseCaddyfile)
+	httpcaddyfile.RegisterDirectiveOrder("bsv_auth", httpcaddyfile.Before, "basic_auth")
+}
+
+// WalletProvider is implemented by the modules of the bsv.wallets namespace, it provides the wallet the server
+// authenticates with. The wallet keys are loaded by the provider, so they never appear in the Caddy config.
+type WalletProvider interface {
+	Wallet() (wallet.WalletInterface, error)
+}
+
+// Route overrides the authentication behaviour for requests matching a net/http ServeMux pattern
+type Route struct {
+	Pattern              string `json:"pattern"`
+	Exempt               bool   `json:"exempt,omitempty"`
+	AllowUnauthenticated bool   `json:"allow_unauthenticated,omitempty"`
+}
+
+// Handler authenticates requests with the auth middleware before they are passed to the next handler
+// and signs the responses of the next handler
+type Handler struct {
+	// WalletRaw configures the bsv.wallets module providing the wallet of the server
+	WalletRaw json.RawMessage `json:"wallet,omitempty" caddy:"namespace=bsv.wallets inline_key=provider"`
+	// AllowUnauthenticated passes requests without auth headers to the next handler
+	AllowUnauthenticated bool `json:"allow_unauthenticated,omitempty"`
+	// PayloadEncryption enables encryption of general message bodies for peers which request it during the handshake
+	PayloadEncryption bool `json:"payload_encryption,omitempty"`
+	// IdempotencyKeyTTL enables the idempotency key extension and sets how long responses are cached for retries
+	IdempotencyKeyTTL caddy.Duration `json:"idempotency_key_ttl,omitempty"`
+	// ReplayWindow is the interval in which the request IDs of ended sessions are forgotten
+	ReplayWindow caddy.Duration `json:"replay_window,omitempty"`
+	// Routes override the authentication behaviour per route, the most specific matching pattern applies
+	Routes []Route `json:"routes,omitempty"`
+
+	middleware *auth.Middleware
+	logger     *zap.Logger
+}
+
+// CaddyModule returns the Caddy module information
+func (Handler) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID:  "http.handlers.bsv_auth",
+		New: func() caddy.Module { return new(Handler) },
+	}
+}
+
+// Provision loads the wallet provider and creates the auth middleware
+func (h *Handler) Provision(ctx caddy.Context) error {
+	h.logger = ctx.Logger()
+
+	if h.WalletRaw == nil {
+		return errors.New("wallet provider is required")
+	}
+	module, err := ctx.LoadModule(h, "WalletRaw")
+	if err != nil {
+		return fmt.Errorf("failed to load wallet provider, %w", err)
+	}
+	provider, ok := module.(WalletProvider)
+	if !ok {
+		return fmt.Errorf("module %T is not a wallet provider", module)
+	}
+	w, err := provider.Wallet()
+	if err != nil {
+		return fmt.Errorf("failed to provide wallet, %w", err)
+	}
+
+	var routePolicies map[string]auth.RoutePolicy
+	if len(h.Routes) > 0 {
+		routePolicies = make(map[string]auth.RoutePolicy, len(h.Routes))
+		for _, route := range h.Routes {
+			routePolicies[route.Pattern] = auth.RoutePolicy{
+				Exempt:               route.Exempt,
+				AllowUnauthenticated: route.AllowUnauthenticated,
+			}
+		}
+	}
+
+	h.middleware, err = auth.New(auth.Config{
+		Wallet:               w,
+		SessionManager:       session.NewMemoryManager(),
+		AllowUnauthenticated: h.AllowUnauthenticated,
+		Logger:               ctx.Slogger(),
+		EncryptPayloads:      h.PayloadEncryption,
+		ReplayWindow:         time.Duration(h.ReplayWindow),
+		IdempotencyKeyTTL:    time.Duration(h.IdempotencyKeyTTL),
+		RoutePolicies:        routePolicies,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create auth middleware, %w", err)
+	}
+	return nil
+}
+
+// Validate checks the route patterns are unique
+func (h *Handler) Validate() error {
+	patterns := make(map[string]struct{}, len(h.Routes))
+	for _, route := range h.Routes {
+		if route.Pattern == "" {
+			return errors.New("route pattern is required")
+		}
+		if _, ok := patterns[route.Pattern]; ok {
+			return fmt.Errorf("duplicate route pattern %q", route.Pattern)
+		}
+		patterns[route.Pattern] = struct{}{}
+	}
+	return nil
+}
+
+// ServeHTTP passes the request through the auth middleware to the next handler.
+// The middleware signs every response it passes, so an error of the next handler is written as a signed response
+// with the status of the error instead of being returned to the error routes of Caddy, which would answer unsigned.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request, next caddyhttp.Handler) error {
+	h.middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := next.ServeHTTP(w, r); err != nil {
+			status := http.StatusInternalServerError
+			var handlerErr caddyhttp.HandlerError
+			if errors.As(err, &handlerErr) && handlerErr.StatusCode != 0 {
+				status = handlerErr.StatusCode
+			}
+
+			h.logger.Error("next handler failed", zap.Int("status", status), zap.Error(err))
+			http.Error(w, http.StatusText(status), status)
+		}
+	})).ServeHTTP(w, r)
+	return nil
+}
+
+// Interface guards
+var (
+	_ caddy.Provisioner           = (*Handler)(nil)
+	_ caddy.Validator             = (*Handler)(nil)
+	_ caddyhttp.MiddlewareHandler = (*Handler)(nil)
+)
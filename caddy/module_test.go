@@ -0,0 +1,284 @@
+package caddybsv_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	caddybsv "github.com/bsv-blockchain/go-bsv-middleware/caddy"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	"github.com/caddyserver/caddy/v2/modules/caddyhttp"
+	"github.com/stretchr/testify/require"
+)
+
+// testWalletProvider provides the mock wallet of the server key
+type testWalletProvider struct {
+	Key string `json:"key"`
+}
+
+func (testWalletProvider) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID:  "bsv.wallets.test",
+		New: func() caddy.Module { return new(testWalletProvider) },
+	}
+}
+
+func (p *testWalletProvider) UnmarshalCaddyfile(d *caddyfile.Dispenser) error {
+	d.Next() // consume provider name
+	for d.NextBlock(0) {
+		if d.Val() != "key" || !d.NextArg() {
+			return d.ArgErr()
+		}
+		p.Key = d.Val()
+	}
+	return nil
+}
+
+func (p *testWalletProvider) Wallet() (wallet.WalletInterface, error) {
+	key, err := ec.PrivateKeyFromHex(p.Key)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // test provider
+	}
+	return mocks.CreateServerMockWallet(key), nil
+}
+
+func init() {
+	caddy.RegisterModule(testWalletProvider{})
+}
+
+func TestHandler_UnmarshalCaddyfile(t *testing.T) {
+	tests := map[string]struct {
+		input       string
+		expected    caddybsv.Handler
+		expectedErr string
+	}{
+		"all subdirectives": {
+			input: `bsv_auth {
+				wallet test {
+					key ` + wallettest.ServerPrivateKeyHex + `
+				}
+				allow_unauthenticated
+				payload_encryption
+				idempotency_key_ttl 5m
+				replay_window 1h
+				route "GET /health" exempt
+				route /public/ allow_unauthenticated
+			}`,
+			expected: caddybsv.Handler{
+				WalletRaw:            json.RawMessage(`{"key":"` + wallettest.ServerPrivateKeyHex + `","provider":"test"}`),
+				AllowUnauthenticated: true,
+				PayloadEncryption:    true,
+				IdempotencyKeyTTL:    caddy.Duration(5 * time.Minute),
+				ReplayWindow:         caddy.Duration(time.Hour),
+				Routes: []caddybsv.Route{
+					{Pattern: "GET /health", Exempt: true},
+					{Pattern: "/public/", AllowUnauthenticated: true},
+				},
+			},
+		},
+		"unknown subdirective": {
+			input: `bsv_auth {
+				unknown
+			}`,
+			expectedErr: `unknown subdirective "unknown"`,
+		},
+		"route without policy": {
+			input: `bsv_auth {
+				route /health
+			}`,
+			expectedErr: "wrong argument count",
+		},
+		"route with unknown policy": {
+			input: `bsv_auth {
+				route /health public
+			}`,
+			expectedErr: `unknown route policy "public"`,
+		},
+		"invalid duration": {
+			input: `bsv_auth {
+				replay_window soon
+			}`,
+			expectedErr: `invalid duration "soon"`,
+		},
+		"inline arguments": {
+			input:       `bsv_auth allow_unauthenticated`,
+			expectedErr: "wrong argument count",
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			var handler caddybsv.Handler
+
+			// when
+			err := handler.UnmarshalCaddyfile(caddyfile.NewTestDispenser(test.input))
+
+			// then
+			if test.expectedErr != "" {
+				require.ErrorContains(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, handler)
+		})
+	}
+}
+
+func TestCaddyfile_Adapt(t *testing.T) {
+	// given
+	input := strings.Join([]string{
+		":8080 {",
+		"\trespond \"Pong!\"",
+		"\tbsv_auth {",
+		"\t\twallet test {",
+		"\t\t\tkey " + wallettest.ServerPrivateKeyHex,
+		"\t\t}",
+		"\t\troute \"GET /health\" exempt",
+		"\t}",
+		"}",
+		"",
+	}, "\n")
+
+	// when
+	cfg, warnings, err := caddyconfig.GetAdapter("caddyfile").Adapt([]byte(input), nil)
+
+	// then
+	require.NoError(t, err)
+	require.Empty(t, warnings)
+	handlerIndex := strings.Index(string(cfg), `"handler":"bsv_auth"`)
+	require.NotEqual(t, -1, handlerIndex)
+	require.Less(t, handlerIndex, strings.Index(string(cfg), `"handler":"static_response"`), "bsv_auth must be ordered before the responding handlers")
+}
+
+func TestHandler_Provision(t *testing.T) {
+	t.Run("wallet provider is required", func(t *testing.T) {
+		// given
+		ctx, cancel := caddy.NewContext(caddy.Context{Context: context.Background()})
+		defer cancel()
+		handler := &caddybsv.Handler{}
+
+		// when
+		err := handler.Provision(ctx)
+
+		// then
+		require.ErrorContains(t, err, "wallet provider is required")
+	})
+
+	t.Run("duplicate route patterns are rejected", func(t *testing.T) {
+		// given
+		handler := &caddybsv.Handler{Routes: []caddybsv.Route{
+			{Pattern: "/health", Exempt: true},
+			{Pattern: "/health", AllowUnauthenticated: true},
+		}}
+
+		// when
+		err := handler.Validate()
+
+		// then
+		require.ErrorContains(t, err, `duplicate route pattern "/health"`)
+	})
+}
+
+func TestHandler_ServeHTTP(t *testing.T) {
+	next := caddyhttp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		switch r.URL.Path {
+		case "/forbidden":
+			return caddyhttp.Error(http.StatusForbidden, errors.New("forbidden by upstream"))
+		default:
+			_, err := w.Write([]byte("Pong!"))
+			return err //nolint:wrapcheck // test handler
+		}
+	})
+
+	server := newCaddyServer(t, &caddybsv.Handler{
+		Routes: []caddybsv.Route{{Pattern: "GET /health", Exempt: true}},
+	}, next)
+
+	t.Run("authenticated request reaches the next handler", func(t *testing.T) {
+		// given
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL+"/ping", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Do(request)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.Equal(t, "Pong!", string(body))
+	})
+
+	t.Run("error of the next handler is a signed response with its status", func(t *testing.T) {
+		// given
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL+"/forbidden", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Do(request)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, http.StatusForbidden, response.StatusCode)
+		require.NotEmpty(t, response.Header.Get("x-bsv-auth-signature"))
+	})
+
+	t.Run("unauthenticated request is rejected", func(t *testing.T) {
+		// when
+		response, err := http.Get(server.URL + "/ping")
+
+		// then
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+		require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	})
+
+	t.Run("exempt route passes unauthenticated requests", func(t *testing.T) {
+		// when
+		response, err := http.Get(server.URL + "/health")
+
+		// then
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+		require.Equal(t, http.StatusOK, response.StatusCode)
+	})
+}
+
+// newCaddyServer provisions the handler with the test wallet provider of the server key
+// and serves it in front of the next handler
+func newCaddyServer(t *testing.T, handler *caddybsv.Handler, next caddyhttp.Handler) *httptest.Server {
+	ctx, cancel := caddy.NewContext(caddy.Context{Context: context.Background()})
+	t.Cleanup(cancel)
+
+	handler.WalletRaw = caddyconfig.JSONModuleObject(testWalletProvider{Key: wallettest.ServerPrivateKeyHex}, "provider", "test", nil)
+	require.NoError(t, handler.Provision(ctx))
+	require.NoError(t, handler.Validate())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := handler.ServeHTTP(w, r, next); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
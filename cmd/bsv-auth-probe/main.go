@@ -0,0 +1,67 @@
+// Command bsv-auth-probe probes a BSV auth endpoint end to end, meant to run on a schedule, e.g. as a cron job or
+// a Kubernetes CronJob, against production deployments. It performs a full handshake, sends a signed request and
+// verifies the signed response, then prints a PASS or FAIL line with the timings of each step, or a JSON document
+// with -json for log pipelines. It exits with status 1 when the probe fails, so schedulers can alert on it.
+//
+// The probe authenticates with the private key in the BSV_AUTH_PROBE_KEY environment variable (hex), servers which
+// restrict their peers have to accept its identity. A throwaway key is used when the variable is not set:
+//
+//	go run ./cmd/bsv-auth-probe -url https://api.example.com -path /health -identity 02a1...
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/probe"
+)
+
+func main() {
+	baseURL := flag.String("url", "", "base URL of the server")
+	path := flag.String("path", "/", "path of the signed request, it has to respond with a status below 400")
+	method := flag.String("method", "GET", "method of the signed request")
+	identity := flag.String("identity", "", "comma separated identity keys the server has to authenticate with, any key is accepted when empty")
+	timeout := flag.Duration("timeout", probe.DefaultTimeout, "timeout of the whole probe")
+	jsonOutput := flag.Bool("json", false, "print the result as a JSON document")
+	flag.Parse()
+
+	if *baseURL == "" {
+		log.Fatalf("-url is required")
+	}
+
+	probeWallet, err := loadWallet(os.Getenv(keyEnv))
+	if err != nil {
+		log.Fatalf("failed to load probe key: %s", err)
+	}
+
+	cfg := probe.Config{
+		Wallet:  probeWallet,
+		BaseURL: strings.TrimSuffix(*baseURL, "/"),
+		Method:  *method,
+		Path:    *path,
+		Timeout: *timeout,
+	}
+	if *identity != "" {
+		cfg.PinnedIdentityKeys = strings.Split(*identity, ",")
+	}
+
+	result := probe.Run(context.Background(), cfg)
+
+	if *jsonOutput {
+		line, err := formatJSON(result)
+		if err != nil {
+			log.Fatalf("failed to encode result: %s", err)
+		}
+		fmt.Println(line)
+	} else {
+		fmt.Println(result)
+	}
+
+	if !result.Passed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authtest"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/probe"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatJSON(t *testing.T) {
+	started := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		result   probe.Result
+		expected string
+	}{
+		"passed": {
+			result: probe.Result{
+				Time:              started,
+				Target:            "https://api.example.com/health",
+				Passed:            true,
+				Status:            200,
+				ServerIdentityKey: wallettest.ServerIdentityKey,
+				Timings:           probe.Timings{Handshake: 12500 * time.Microsecond, Request: 8 * time.Millisecond, Verify: 250 * time.Microsecond, Total: 20750 * time.Microsecond},
+			},
+			expected: `{"time":"2026-10-15T12:00:00Z","target":"https://api.example.com/health","passed":true,"status":200,` +
+				`"server_identity_key":"` + wallettest.ServerIdentityKey + `","handshake_ms":12.5,"request_ms":8,"verify_ms":0.25,"total_ms":20.75}`,
+		},
+		"failed": {
+			result: probe.Result{
+				Time:       started,
+				Target:     "https://api.example.com/health",
+				FailedStep: probe.StepHandshake,
+				Err:        errors.New("connection refused"),
+				Timings:    probe.Timings{Handshake: 3 * time.Millisecond, Total: 3 * time.Millisecond},
+			},
+			expected: `{"time":"2026-10-15T12:00:00Z","target":"https://api.example.com/health","passed":false,"failed_step":"handshake",` +
+				`"error":"connection refused","handshake_ms":3,"request_ms":0,"verify_ms":0,"total_ms":3}`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// when
+			line, err := formatJSON(test.result)
+
+			// then
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, line)
+		})
+	}
+}
+
+func TestLoadWallet(t *testing.T) {
+	t.Run("probe authenticates with the configured key", func(t *testing.T) {
+		// given
+		server := authtest.NewServer(t, authtest.Options{})
+		probeWallet, err := loadWallet(wallettest.ClientPrivateKeyHex)
+		require.NoError(t, err)
+
+		// when
+		first := probe.Run(context.Background(), probe.Config{Wallet: probeWallet, BaseURL: server.URL})
+		second := probe.Run(context.Background(), probe.Config{Wallet: probeWallet, BaseURL: server.URL})
+
+		// then
+		require.True(t, first.Passed, first.String())
+		require.True(t, second.Passed, second.String())
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		// when
+		_, err := loadWallet("not-hex")
+
+		// then
+		require.ErrorContains(t, err, keyEnv)
+	})
+
+	t.Run("throwaway key without configured key", func(t *testing.T) {
+		// when
+		probeWallet, err := loadWallet("")
+
+		// then
+		require.NoError(t, err)
+		require.NotNil(t, probeWallet)
+	})
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/probe"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// keyEnv is the environment variable holding the hex private key of the probe identity
+const keyEnv = "BSV_AUTH_PROBE_KEY"
+
+// report is the JSON document of a probe result, timings are in milliseconds
+type report struct {
+	Time              time.Time `json:"time"`
+	Target            string    `json:"target"`
+	Passed            bool      `json:"passed"`
+	FailedStep        string    `json:"failed_step,omitempty"`
+	Error             string    `json:"error,omitempty"`
+	Status            int       `json:"status,omitempty"`
+	ServerIdentityKey string    `json:"server_identity_key,omitempty"`
+	HandshakeMillis   float64   `json:"handshake_ms"`
+	RequestMillis     float64   `json:"request_ms"`
+	VerifyMillis      float64   `json:"verify_ms"`
+	TotalMillis       float64   `json:"total_ms"`
+}
+
+// formatJSON formats the result as a single line JSON document
+func formatJSON(r probe.Result) (string, error) {
+	doc := report{
+		Time:              r.Time.UTC(),
+		Target:            r.Target,
+		Passed:            r.Passed,
+		FailedStep:        string(r.FailedStep),
+		Status:            r.Status,
+		ServerIdentityKey: r.ServerIdentityKey,
+		HandshakeMillis:   millis(r.Timings.Handshake),
+		RequestMillis:     millis(r.Timings.Request),
+		VerifyMillis:      millis(r.Timings.Verify),
+		TotalMillis:       millis(r.Timings.Total),
+	}
+	if r.Err != nil {
+		doc.Error = r.Err.Error()
+	}
+
+	line, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return string(line), nil
+}
+
+func millis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// loadWallet returns the wallet of the hex private key, or of a throwaway key when it is empty.
+// Its nonces are random, so consecutive runs against the same server never repeat a nonce.
+func loadWallet(keyHex string) (wallet.WalletInterface, error) {
+	if keyHex == "" {
+		key, err := wallettest.NewRandomPrivateKey(nil)
+		if err != nil {
+			return nil, err
+		}
+		return wallettest.NewRandomMockWallet(key, nil), nil
+	}
+
+	key, err := ec.PrivateKeyFromHex(keyHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s, %w", keyEnv, err)
+	}
+	return wallettest.NewRandomMockWallet(key, nil), nil
+}
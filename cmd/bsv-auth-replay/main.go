@@ -0,0 +1,53 @@
+// Command bsv-auth-replay replays recorded auth transcripts against a local middleware instance, to reproduce
+// auth failures reported by peers deterministically, e.g. as a regression test in CI.
+//
+// A transcript is a JSON document with the key and nonces of the recording server, its middleware setup and the
+// exchanged requests with the status and error code of their responses. The middleware is set up like the recording
+// server, every request is replayed in order and its outcome compared with the recorded one. The command exits
+// with status 1 when an outcome differs:
+//
+//	go run ./cmd/bsv-auth-replay -transcript incidents/bad-signature.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+)
+
+func main() {
+	path := flag.String("transcript", "", "path of the transcript to replay")
+	verbose := flag.Bool("v", false, "log the middleware at debug level")
+	flag.Parse()
+
+	if *path == "" {
+		log.Fatalf("-transcript is required")
+	}
+
+	t, err := readTranscript(*path)
+	if err != nil {
+		log.Fatalf("failed to read transcript: %s", err)
+	}
+
+	logger := slog.New(slog.DiscardHandler)
+	if *verbose {
+		logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+
+	results, err := replay(t, logger)
+	if err != nil {
+		log.Fatalf("failed to replay transcript: %s", err)
+	}
+
+	failed := false
+	for _, r := range results {
+		fmt.Println(r)
+		failed = failed || !r.matches()
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
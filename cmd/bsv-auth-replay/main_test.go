@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+// recordTranscript records a handshake, an authenticated request and a request with a wrong signature
+// with a middleware using the server key and nonces of the transcript
+func recordTranscript(t *testing.T) *transcript {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	recorded := &transcript{Server: server{PrivateKey: wallettest.ServerPrivateKeyHex, Nonces: wallettest.DefaultNonces[:4]}}
+
+	middleware, err := auth.New(auth.Config{
+		Wallet:         wallettest.NewMockWallet(key, recorded.Server.Nonces...),
+		SessionManager: session.NewMemoryManager(),
+		Logger:         slog.New(slog.DiscardHandler),
+	})
+	require.NoError(t, err)
+	handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	record := func(req request) []byte {
+		recorder := serve(handler, req)
+		body := recorder.Body.Bytes()
+		recorded.Exchanges = append(recorded.Exchanges, exchange{Request: req, Response: outcome(recorder.Result())})
+		return body
+	}
+
+	clientWallet := mocks.CreateClientMockWallet()
+	initialRequest, err := json.Marshal(mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+	require.NoError(t, err)
+
+	var authMessage transport.AuthMessage
+	require.NoError(t, json.Unmarshal(record(request{Method: http.MethodPost, URL: auth.HandshakePath, Body: initialRequest}), &authMessage))
+
+	for _, opts := range [][]func(m map[string]string){nil, {mocks.WithWrongSignature}} {
+		req, err := http.NewRequest(http.MethodGet, "http://localhost/ping", nil)
+		require.NoError(t, err)
+		require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, &authMessage, req, opts...))
+
+		header := make(map[string]string)
+		for name := range req.Header {
+			header[name] = req.Header.Get(name)
+		}
+		record(request{Method: http.MethodGet, URL: req.URL.String(), Header: header})
+	}
+
+	return recorded
+}
+
+func TestReplay(t *testing.T) {
+	t.Run("replayed transcript reproduces the recorded outcomes", func(t *testing.T) {
+		// given
+		path := filepath.Join(t.TempDir(), "transcript.json")
+		data, err := json.Marshal(recordTranscript(t))
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(path, data, 0o600))
+
+		recorded, err := readTranscript(path)
+		require.NoError(t, err)
+
+		// when
+		results, err := replay(recorded, slog.New(slog.DiscardHandler))
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, results, 3)
+		for _, r := range results {
+			require.True(t, r.matches(), r.String())
+		}
+		require.Equal(t, response{Status: http.StatusOK}, results[1].actual)
+		require.Equal(t, http.StatusUnauthorized, results[2].actual.Status)
+		require.NotEmpty(t, results[2].actual.Code)
+	})
+
+	t.Run("changed outcome is reported as mismatch", func(t *testing.T) {
+		// given
+		recorded := recordTranscript(t)
+		recorded.Exchanges[2].Response = response{Status: http.StatusOK}
+
+		// when
+		results, err := replay(recorded, slog.New(slog.DiscardHandler))
+
+		// then
+		require.NoError(t, err)
+		require.True(t, results[1].matches())
+		require.False(t, results[2].matches())
+		require.Contains(t, results[2].String(), "MISMATCH expected 200")
+	})
+
+	t.Run("transcript replayed with other nonces fails", func(t *testing.T) {
+		// given
+		recorded := recordTranscript(t)
+		recorded.Server.Nonces = wallettest.DefaultNonces[4:8]
+
+		// when
+		results, err := replay(recorded, slog.New(slog.DiscardHandler))
+
+		// then
+		require.NoError(t, err)
+		require.False(t, results[1].matches())
+	})
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// result is the outcome of a replayed exchange
+type result struct {
+	exchange int
+	request  request
+	expected response
+	actual   response
+}
+
+func (r result) matches() bool {
+	return r.expected == r.actual
+}
+
+func (r result) String() string {
+	outcome := "ok"
+	if !r.matches() {
+		outcome = fmt.Sprintf("MISMATCH expected %s", describe(r.expected))
+	}
+	return fmt.Sprintf("#%d %s %s: %s %s", r.exchange, r.request.Method, r.request.URL, describe(r.actual), outcome)
+}
+
+func describe(r response) string {
+	if r.Code == "" {
+		return fmt.Sprint(r.Status)
+	}
+	return fmt.Sprintf("%d %s", r.Status, r.Code)
+}
+
+// replay sends the requests of the transcript to a fresh middleware instance set up like the recording server,
+// requests passed to the handler are answered with an empty 200 response
+func replay(t *transcript, logger *slog.Logger) ([]result, error) {
+	key, err := ec.PrivateKeyFromHex(t.Server.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid server private key, %w", err)
+	}
+
+	middleware, err := auth.New(auth.Config{
+		Wallet:               wallettest.NewMockWallet(key, t.Server.Nonces...),
+		SessionManager:       session.NewMemoryManager(),
+		AllowUnauthenticated: t.Config.AllowUnauthenticated,
+		EncryptPayloads:      t.Config.EncryptPayloads,
+		PadPayloads:          t.Config.PadPayloads,
+		Logger:               logger,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create auth middleware, %w", err)
+	}
+	handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	results := make([]result, 0, len(t.Exchanges))
+	for i, exchange := range t.Exchanges {
+		results = append(results, result{
+			exchange: i,
+			request:  exchange.Request,
+			expected: exchange.Response,
+			actual:   outcome(serve(handler, exchange.Request).Result()),
+		})
+	}
+	return results, nil
+}
+
+// serve sends the recorded request to the handler
+func serve(handler http.Handler, r request) *httptest.ResponseRecorder {
+	req := httptest.NewRequestWithContext(context.Background(), r.Method, r.URL, bytes.NewReader(r.Body))
+	for name, value := range r.Header {
+		req.Header.Set(name, value)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	return recorder
+}
+
+// outcome returns the status and the error code of the response
+func outcome(res *http.Response) response {
+	defer func() { _ = res.Body.Close() }()
+
+	r := response{Status: res.StatusCode}
+	if res.StatusCode < http.StatusBadRequest {
+		return r
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return r
+	}
+	var errResponse transport.ErrorResponse
+	if json.Unmarshal(body, &errResponse) == nil {
+		r.Code = errResponse.Code
+	}
+	return r
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// transcript is a recorded exchange of a peer with the auth middleware. Replaying it is deterministic as long
+// as the server uses the recorded key and nonces: signatures are deterministic (RFC 6979) and nonces are
+// handed out in the recorded order, so the handshake reproduces the session the recorded requests are bound to.
+type transcript struct {
+	Server    server     `json:"server"`
+	Config    config     `json:"config"`
+	Exchanges []exchange `json:"exchanges"`
+}
+
+// server is the identity of the recording server, transcripts of production incidents have to be recorded
+// with a test key, e.g. by reproducing the exchange of the peer against a staging deployment
+type server struct {
+	PrivateKey string   `json:"privateKey"`
+	Nonces     []string `json:"nonces"`
+}
+
+// config is the middleware setup of the recording server
+type config struct {
+	AllowUnauthenticated bool `json:"allowUnauthenticated"`
+	EncryptPayloads      bool `json:"encryptPayloads"`
+	PadPayloads          bool `json:"padPayloads"`
+}
+
+type exchange struct {
+	Request  request  `json:"request"`
+	Response response `json:"response"`
+}
+
+type request struct {
+	Method string            `json:"method"`
+	URL    string            `json:"url"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   []byte            `json:"body,omitempty"`
+}
+
+// response is the outcome of the recorded request, Code is the error code of rejected requests
+type response struct {
+	Status int    `json:"status"`
+	Code   string `json:"code,omitempty"`
+}
+
+func readTranscript(path string) (*transcript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var t transcript
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("failed to decode %s, %w", path, err)
+	}
+	if t.Server.PrivateKey == "" {
+		return nil, fmt.Errorf("%s has no server private key", path)
+	}
+	return &t, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+)
+
+// errNoOpenAPIDocument is returned for servers which do not declare their routes
+var errNoOpenAPIDocument = errors.New("server does not publish an OpenAPI document")
+
+// fetchDocument fetches the discovery document of the server and the OpenAPI document it points to
+func fetchDocument(client *http.Client, baseURL string) (*auth.OpenAPIDocument, error) {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+
+	var discovery auth.DiscoveryDocument
+	if err := getJSON(client, baseURL+auth.DiscoveryPath, &discovery); err != nil {
+		return nil, fmt.Errorf("failed to fetch discovery document, %w", err)
+	}
+
+	if discovery.OpenAPIPath == "" {
+		return nil, errNoOpenAPIDocument
+	}
+
+	var document auth.OpenAPIDocument
+	if err := getJSON(client, baseURL+discovery.OpenAPIPath, &document); err != nil {
+		return nil, fmt.Errorf("failed to fetch OpenAPI document, %w", err)
+	}
+
+	if document.Extension.IdentityKey != "" && !strings.EqualFold(document.Extension.IdentityKey, discovery.IdentityKey) {
+		return nil, fmt.Errorf("identity key %s of the OpenAPI document differs from %s of the discovery document",
+			document.Extension.IdentityKey, discovery.IdentityKey)
+	}
+
+	return &document, nil
+}
+
+func getJSON(client *http.Client, url string, v any) error {
+	response, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s responded with status %d", url, response.StatusCode)
+	}
+
+	return json.NewDecoder(response.Body).Decode(v)
+}
+
+// readDocument reads an OpenAPI document saved from a server
+func readDocument(path string) (*auth.OpenAPIDocument, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var document auth.OpenAPIDocument
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to decode %s, %w", path, err)
+	}
+	return &document, nil
+}
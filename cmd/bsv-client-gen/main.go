@@ -0,0 +1,56 @@
+// Command bsv-client-gen generates a typed Go client of a server from its OpenAPI document (see auth.RouteRegistry).
+// Every operation becomes a method of the client, which pins the identity key of the server, prompts for the certificates
+// demanded by the endpoint and refuses payments above its declared price.
+//
+// It reads the document from a running server, following the discovery document, or from a file:
+//
+//	go run ./cmd/bsv-client-gen -url https://shop.example.com -package shop -out internal/shop/client.go
+//	go run ./cmd/bsv-client-gen -file openapi.json -package shop -out internal/shop/client.go
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+)
+
+const defaultPackage = "api"
+
+func main() {
+	baseURL := flag.String("url", "", "base URL of the server, the document is located with its discovery document")
+	file := flag.String("file", "", "path of the OpenAPI document, instead of fetching it from the server")
+	pkg := flag.String("package", defaultPackage, "package name of the generated client")
+	out := flag.String("out", "", "output path of the generated client")
+	flag.Parse()
+
+	if (*baseURL == "") == (*file == "") {
+		log.Fatalf("exactly one of -url and -file is required")
+	}
+	if *out == "" {
+		log.Fatalf("-out is required")
+	}
+
+	var (
+		document *auth.OpenAPIDocument
+		err      error
+	)
+	if *baseURL != "" {
+		document, err = fetchDocument(http.DefaultClient, *baseURL)
+	} else {
+		document, err = readDocument(*file)
+	}
+	if err != nil {
+		log.Fatalf("failed to load OpenAPI document: %s", err)
+	}
+
+	source, err := render(*pkg, document)
+	if err != nil {
+		log.Fatalf("failed to generate client: %s", err)
+	}
+
+	if err := writeFile(*out, source); err != nil {
+		log.Fatalf("failed to write client: %s", err)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+const certificateType = "9ZkJfGmbXcggy2CL1Eb8wX1hv2WwVnGf4TTZ1FHrPFU="
+
+func shopRoutes(t *testing.T) *auth.RouteRegistry {
+	routes := auth.NewRouteRegistry(auth.OpenAPIInfo{Title: "Shop", Version: "1.0.0"})
+	require.NoError(t, routes.Declare("GET /items/{id}", auth.RouteRequirements{Anonymous: true}))
+	require.NoError(t, routes.Declare("POST /orders", auth.RouteRequirements{
+		Certificates: transport.NewRequestedCertificateSet(wallettest.CertifierIdentityKey).AddType(certificateType, "age"),
+		Payment:      &auth.PaymentRequirement{Price: 100},
+	}))
+	require.NoError(t, routes.Declare("GET /files/{path...}", auth.RouteRequirements{Authentication: auth.AuthenticationNone}))
+	return routes
+}
+
+func TestFetchDocument(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	t.Run("document is fetched following the discovery document", func(t *testing.T) {
+		// given
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), mocks.WithRoutes(shopRoutes(t))).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+		defer server.Close()
+
+		// when
+		document, err := fetchDocument(http.DefaultClient, server.URL()+"/")
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, key.PubKey().ToDERHex(), document.Extension.IdentityKey)
+		require.Equal(t, 100, document.Paths["/orders"]["post"].Extension.Payment.Price)
+	})
+
+	t.Run("server without declared routes", func(t *testing.T) {
+		// given
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager()).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+		defer server.Close()
+
+		// when
+		_, err := fetchDocument(http.DefaultClient, server.URL())
+
+		// then
+		require.ErrorIs(t, err, errNoOpenAPIDocument)
+	})
+}
+
+func TestRender(t *testing.T) {
+	document := shopRoutes(t).OpenAPI(auth.OpenAPIAuthExtension{
+		IdentityKey:       wallettest.ServerIdentityKey,
+		PayloadEncryption: true,
+	}, nil)
+
+	t.Run("client has a method per operation with its requirements", func(t *testing.T) {
+		// when
+		source, err := render("shop", &document)
+
+		// then
+		require.NoError(t, err)
+		code := string(source)
+		require.Contains(t, code, "// Code generated by bsv-client-gen; DO NOT EDIT.\n\npackage shop\n")
+		require.Contains(t, code, `const ServerIdentityKey = "`+wallettest.ServerIdentityKey+`"`)
+		require.Contains(t, code, "cfg.PinnedIdentityKeys = []string{ServerIdentityKey}")
+		require.Contains(t, code, "cfg.PayloadEncryption = true")
+
+		require.Contains(t, code, `func (c *Client) GetItemsByID(ctx context.Context, id string, body io.Reader) (*http.Response, error) {
+	return c.Call(ctx, GetItemsByIDEndpoint, map[string]string{"id": id}, body)
+}`)
+		require.Contains(t, code, "func (c *Client) GetFilesByPath(ctx context.Context, path string, body io.Reader)")
+		require.Contains(t, code, "func (c *Client) PostOrders(ctx context.Context, body io.Reader)")
+		require.Contains(t, code, `Certificates:   &transport.RequestedCertificateSet{Certifiers: []string{"`+wallettest.CertifierIdentityKey+`"}`)
+		require.Contains(t, code, "Price:          100,")
+		require.Contains(t, code, "// It costs 100 satoshis, higher prices are refused.")
+		require.Contains(t, code, `Authentication: "none",`)
+	})
+
+	t.Run("rendering is deterministic", func(t *testing.T) {
+		// when
+		first, err := render("shop", &document)
+		require.NoError(t, err)
+		second, err := render("shop", &document)
+		require.NoError(t, err)
+
+		// then
+		require.Equal(t, string(first), string(second))
+	})
+
+	t.Run("operations with the same name are rejected", func(t *testing.T) {
+		// given
+		routes := auth.NewRouteRegistry(auth.OpenAPIInfo{Title: "Shop"})
+		require.NoError(t, routes.Declare("GET /order-items", auth.RouteRequirements{}))
+		require.NoError(t, routes.Declare("GET /order_items", auth.RouteRequirements{}))
+		document := routes.OpenAPI(auth.OpenAPIAuthExtension{}, nil)
+
+		// when
+		_, err := render("shop", &document)
+
+		// then
+		require.ErrorContains(t, err, "are both named GetOrderItems")
+	})
+}
+
+func TestParamIdent(t *testing.T) {
+	tests := map[string]string{
+		"id":      "id",
+		"item_id": "itemID",
+		"type":    "typeParam",
+		"body":    "bodyParam",
+		"2fa":     "param2fa",
+	}
+
+	for parameter, expected := range tests {
+		t.Run(parameter, func(t *testing.T) {
+			require.Equal(t, expected, paramIdent(parameter))
+		})
+	}
+}
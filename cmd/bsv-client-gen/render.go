@@ -0,0 +1,253 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+var clientTemplate = template.Must(template.New("client").Funcs(template.FuncMap{
+	"certificates": certificatesLiteral,
+}).Parse(`// Code generated by bsv-client-gen; DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+{{- if .Certificates}}
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+{{- end}}
+)
+
+// ServerIdentityKey is the identity key of the server the client was generated from
+const ServerIdentityKey = {{printf "%q" .IdentityKey}}
+
+// Endpoints of {{.Title}}, with the auth requirements published by the server
+var (
+{{- range .Operations}}
+	// {{.Name}}Endpoint is {{.Method}} {{.Path}}
+	{{.Name}}Endpoint = client.Endpoint{
+		Method:         {{printf "%q" .Method}},
+		Path:           {{printf "%q" .Path}},
+		Authentication: {{printf "%q" .Requirements.Authentication}},
+		Anonymous:      {{.Requirements.Anonymous}},
+{{- with .Requirements.Certificates}}
+		Certificates:   {{certificates .}},
+{{- end}}
+{{- with .Requirements.Payment}}
+		Price:          {{.Price}},
+		DynamicPrice:   {{.Dynamic}},
+{{- end}}
+	}
+{{- end}}
+)
+
+// Client is a client of {{.Title}}
+type Client struct {
+	*client.Client
+}
+
+// NewClient creates a client of the server.
+{{- if .IdentityKey}} The server identity key is pinned unless pinned keys or an identity store are configured.{{end}}
+{{- if .PayloadEncryption}} Payload encryption is requested, as the server encrypts payloads.{{end}}
+func NewClient(cfg client.Config) (*Client, error) {
+{{- if .IdentityKey}}
+	if len(cfg.PinnedIdentityKeys) == 0 && cfg.IdentityStore == nil {
+		cfg.PinnedIdentityKeys = []string{ServerIdentityKey}
+	}
+{{- end}}
+{{- if .PayloadEncryption}}
+	cfg.PayloadEncryption = true
+{{- end}}
+
+	authClient, err := client.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{Client: authClient}, nil
+}
+{{range .Operations}}
+// {{.Name}} calls {{.Method}} {{.Path}}.
+{{- range .Docs}}
+// {{.}}
+{{- end}}
+func (c *Client) {{.Name}}(ctx context.Context{{range .Params}}, {{.Ident}} string{{end}}, body io.Reader) (*http.Response, error) {
+	return c.Call(ctx, {{.Name}}Endpoint, {{if .Params}}map[string]string{ {{- range $i, $p := .Params}}{{if $i}}, {{end}}{{printf "%q" $p.Name}}: {{$p.Ident}}{{end -}} }{{else}}nil{{end}}, body)
+}
+{{end}}`))
+
+type clientData struct {
+	Package           string
+	Title             string
+	IdentityKey       string
+	PayloadEncryption bool
+	Certificates      bool
+	Operations        []operation
+}
+
+type operation struct {
+	Name         string
+	Method       string
+	Path         string
+	Params       []param
+	Requirements auth.RouteRequirements
+	Docs         []string
+}
+
+type param struct {
+	Name  string
+	Ident string
+}
+
+// render returns the source of the client of the operations of the document
+func render(pkg string, document *auth.OpenAPIDocument) ([]byte, error) {
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf("invalid package name %q", pkg)
+	}
+
+	data := clientData{
+		Package:           pkg,
+		Title:             strings.TrimSpace(document.Info.Title + " " + document.Info.Version),
+		IdentityKey:       document.Extension.IdentityKey,
+		PayloadEncryption: document.Extension.PayloadEncryption,
+	}
+	if data.Title == "" {
+		data.Title = "the server"
+	}
+
+	names := make(map[string]string)
+	for path, operations := range document.Paths {
+		for method, op := range operations {
+			o := newOperation(strings.ToUpper(method), path, op.Extension)
+			if other, ok := names[o.Name]; ok {
+				return nil, fmt.Errorf("operations %s and %s %s are both named %s", other, o.Method, o.Path, o.Name)
+			}
+			names[o.Name] = o.Method + " " + o.Path
+			data.Certificates = data.Certificates || o.Requirements.Certificates != nil
+			data.Operations = append(data.Operations, o)
+		}
+	}
+	sort.Slice(data.Operations, func(i, j int) bool { return data.Operations[i].Name < data.Operations[j].Name })
+
+	var buf bytes.Buffer
+	if err := clientTemplate.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render client, %w", err)
+	}
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format client, %w", err)
+	}
+	return source, nil
+}
+
+func newOperation(method, path string, requirements auth.RouteRequirements) operation {
+	o := operation{Method: method, Path: path, Requirements: requirements}
+	if o.Requirements.Authentication == "" {
+		o.Requirements.Authentication = auth.AuthenticationRequired
+	}
+
+	name := exportedName(strings.ToLower(method))
+	for _, segment := range strings.Split(path, "/") {
+		if parameter, ok := strings.CutPrefix(segment, "{"); ok {
+			parameter = strings.TrimSuffix(parameter, "}")
+			name += "By" + exportedName(parameter)
+			o.Params = append(o.Params, param{Name: parameter, Ident: paramIdent(parameter)})
+			continue
+		}
+		name += exportedName(segment)
+	}
+	o.Name = name
+
+	if o.Requirements.Authentication == auth.AuthenticationNone {
+		o.Docs = append(o.Docs, "It is called without authentication.")
+	}
+	if o.Requirements.Anonymous {
+		o.Docs = append(o.Docs, "It accepts anonymous sessions.")
+	}
+	if o.Requirements.Certificates != nil {
+		o.Docs = append(o.Docs, fmt.Sprintf("It demands the certificates of %sEndpoint, the certificate prompt of the client is called before the first call.", name))
+	}
+	if p := o.Requirements.Payment; p != nil {
+		if p.Dynamic {
+			o.Docs = append(o.Docs, "Its price depends on the request and is announced by the server.")
+		} else {
+			o.Docs = append(o.Docs, fmt.Sprintf("It costs %d satoshis, higher prices are refused.", p.Price))
+		}
+	} else if o.Requirements.Authentication != auth.AuthenticationNone {
+		o.Docs = append(o.Docs, "It is free, payments are refused.")
+	}
+
+	return o
+}
+
+// initialisms are written in upper case in generated names, following Go naming conventions
+var initialisms = map[string]string{"id": "ID", "url": "URL", "uri": "URI", "api": "API", "json": "JSON", "http": "HTTP", "uuid": "UUID"}
+
+var wordSeparator = regexp.MustCompile(`[^\pL\pN]+`)
+
+// exportedName converts a path segment or parameter, e.g. "order-items" or "item_id", to an exported Go name
+func exportedName(s string) string {
+	var name strings.Builder
+	for _, word := range wordSeparator.Split(s, -1) {
+		if word == "" {
+			continue
+		}
+		if initialism, ok := initialisms[strings.ToLower(word)]; ok {
+			name.WriteString(initialism)
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		name.WriteString(string(runes))
+	}
+	return name.String()
+}
+
+// paramIdent returns the argument name of a path parameter, avoiding keywords and the other arguments
+func paramIdent(parameter string) string {
+	name := []rune(exportedName(parameter))
+	if len(name) == 0 {
+		return "param"
+	}
+
+	ident := strings.ToLower(string(name[0])) + string(name[1:])
+	if initialism, ok := initialisms[strings.ToLower(string(name))]; ok && initialism == string(name) {
+		ident = strings.ToLower(ident)
+	}
+	switch {
+	case token.IsKeyword(ident) || ident == "c" || ident == "ctx" || ident == "body":
+		ident += "Param"
+	case !token.IsIdentifier(ident):
+		ident = "param" + string(name)
+	}
+	return ident
+}
+
+// certificatesLiteral returns the Go expression of the requested certificates
+func certificatesLiteral(certificates *transport.RequestedCertificateSet) string {
+	return fmt.Sprintf("&transport.RequestedCertificateSet{Certifiers: %#v, Types: %#v}", certificates.Certifiers, certificates.Types)
+}
+
+func writeFile(path string, source []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create directory, %w", err)
+	}
+
+	return os.WriteFile(path, source, 0o600)
+}
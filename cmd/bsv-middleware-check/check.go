@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// Severities of findings
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+)
+
+// finding is a misconfiguration of the deployment
+type finding struct {
+	severity string
+	message  string
+}
+
+func (f finding) String() string {
+	return f.severity + ": " + f.message
+}
+
+// check reports the misconfigurations of the deployment: errors make the middleware fail to start or a dependency
+// unreachable, warnings are settings which have no effect or are likely mistakes
+func check(ctx context.Context, cfg *deploymentConfig, client *http.Client) []finding {
+	var findings []finding
+	findings = append(findings, checkMiddleware(cfg)...)
+	findings = append(findings, checkRoutePolicies(cfg)...)
+	findings = append(findings, checkDependencies(ctx, cfg, client)...)
+	return findings
+}
+
+// checkMiddleware instantiates the middleware with a dry-run wallet, which signs with a throwaway key
+func checkMiddleware(cfg *deploymentConfig) []finding {
+	key, err := ec.NewPrivateKey()
+	if err != nil {
+		return []finding{{severityError, fmt.Sprintf("failed to create dry-run wallet: %s", err)}}
+	}
+
+	var findings []finding
+	if cfg.CertificatesToRequest != nil && !cfg.CertificatesCallback {
+		findings = append(findings, finding{severityError, "certificates are requested but no OnCertificatesReceived callback is registered"})
+	}
+	if cfg.CertificatesToRequest == nil && cfg.CertificatesCallback {
+		findings = append(findings, finding{severityError, "OnCertificatesReceived callback is registered but no certificates are requested"})
+	}
+
+	_, err = auth.New(cfg.authConfig(wallettest.NewMockWallet(key)))
+	switch {
+	case err == nil:
+	case errors.Is(err, auth.ErrCertificatesCallbackRequired), errors.Is(err, auth.ErrCertificatesNotRequested):
+		// reported above
+	default:
+		findings = append(findings, finding{severityError, fmt.Sprintf("middleware cannot be created: %s", err)})
+	}
+
+	if cfg.OriginBinding.Required && len(cfg.OriginBinding.Origins) == 0 {
+		findings = append(findings, finding{severityError, "origin binding is required but no origins are declared, every request is rejected"})
+	}
+
+	return findings
+}
+
+// checkRoutePolicies reports route policies which never apply or have no effect
+func checkRoutePolicies(cfg *deploymentConfig) []finding {
+	patterns := make([]string, 0, len(cfg.RoutePolicies))
+	for pattern := range cfg.RoutePolicies {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	var findings []finding
+	warn := func(format string, args ...any) {
+		findings = append(findings, finding{severityWarning, fmt.Sprintf(format, args...)})
+	}
+
+	for _, pattern := range patterns {
+		policy := cfg.RoutePolicies[pattern]
+		path := pattern
+		if _, p, ok := strings.Cut(pattern, " "); ok {
+			path = p
+		}
+
+		if path == auth.HandshakePath || path == auth.DiscoveryPath {
+			warn("route policy %q never applies, %s is always served by the middleware", pattern, path)
+		}
+		if policy.Exempt && (policy.AllowUnauthenticated || policy.DenyAnonymous) {
+			warn("route policy %q is exempt, its other settings have no effect", pattern)
+		}
+		if policy.AllowUnauthenticated && cfg.AllowUnauthenticated {
+			warn("route policy %q allows unauthenticated requests, which are allowed on every route", pattern)
+		}
+		if policy.DenyAnonymous && !cfg.AnonymousAccess {
+			warn("route policy %q denies anonymous sessions, which are not enabled", pattern)
+		}
+		if policy == (routePolicy{}) {
+			warn("route policy %q changes nothing", pattern)
+		}
+	}
+
+	return findings
+}
+
+// checkDependencies reports dependencies which cannot be reached or respond with a server error
+func checkDependencies(ctx context.Context, cfg *deploymentConfig, client *http.Client) []finding {
+	names := make([]string, 0, len(cfg.Dependencies))
+	for name := range cfg.Dependencies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var findings []finding
+	for _, name := range names {
+		if err := probe(ctx, client, cfg.Dependencies[name]); err != nil {
+			findings = append(findings, finding{severityError, fmt.Sprintf("dependency %s is unreachable: %s", name, err)})
+		}
+	}
+	return findings
+}
+
+func probe(ctx context.Context, client *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = response.Body.Close()
+
+	if response.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("responded with status %d", response.StatusCode)
+	}
+	return nil
+}
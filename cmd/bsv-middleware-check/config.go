@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/defs"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+)
+
+// configEnv holds the config document when no file is given, e.g. in container deployments
+const configEnv = "BSV_MIDDLEWARE_CONFIG"
+
+// deploymentConfig is the declarative part of the auth.Config of a deployment.
+// Callbacks cannot be declared in a file, the deployment declares which ones it registers instead.
+type deploymentConfig struct {
+	AllowUnauthenticated  bool                               `json:"allowUnauthenticated"`
+	EncryptPayloads       bool                               `json:"encryptPayloads"`
+	PadPayloads           bool                               `json:"padPayloads"`
+	CertificatesToRequest *transport.RequestedCertificateSet `json:"certificatesToRequest"`
+	// CertificatesCallback declares that the deployment registers OnCertificatesReceived
+	CertificatesCallback bool                        `json:"certificatesCallback"`
+	RoutePolicies        map[string]routePolicy      `json:"routePolicies"`
+	ReplayWindow         duration                    `json:"replayWindow"`
+	MinNonceSize         int                         `json:"minNonceSize"`
+	IdempotencyKeyTTL    duration                    `json:"idempotencyKeyTTL"`
+	AnonymousAccess      bool                        `json:"anonymousAccess"`
+	OriginBinding        originBinding               `json:"originBinding"`
+	Logging              map[string]subsystemLogging `json:"logging"`
+	// KnownPeers are pre-registered peers, e.g. the services of a mesh, whose sessions are pre-warmed
+	KnownPeers []transport.KnownPeer `json:"knownPeers"`
+	// Dependencies are the URLs of the stores and services the deployment relies on, e.g. a chain tracker,
+	// a policy engine or webhook sinks, they are checked for reachability
+	Dependencies map[string]string `json:"dependencies"`
+}
+
+type routePolicy struct {
+	Exempt               bool `json:"exempt"`
+	AllowUnauthenticated bool `json:"allowUnauthenticated"`
+	DenyAnonymous        bool `json:"denyAnonymous"`
+}
+
+type originBinding struct {
+	Origins  []string `json:"origins"`
+	Required bool     `json:"required"`
+}
+
+type subsystemLogging struct {
+	Level           string `json:"level"`
+	DebugSampleRate int    `json:"debugSampleRate"`
+}
+
+// duration decodes durations such as "10m" or "30s"
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"10m\", %w", err)
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(parsed)
+	return nil
+}
+
+// loadConfig reads the config document from the file, or from the configEnv variable when path is empty
+func loadConfig(path string) (*deploymentConfig, error) {
+	var data []byte
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+	} else {
+		data = []byte(os.Getenv(configEnv))
+		path = configEnv
+		if len(data) == 0 {
+			return nil, fmt.Errorf("no config file given and %s is not set", configEnv)
+		}
+	}
+
+	var cfg deploymentConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode %s, %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// authConfig returns the auth.Config of the deployment using the wallet, declared callbacks are no-ops
+func (c *deploymentConfig) authConfig(w wallet.WalletInterface) auth.Config {
+	cfg := auth.Config{
+		Wallet:                w,
+		SessionManager:        session.NewMemoryManager(),
+		AllowUnauthenticated:  c.AllowUnauthenticated,
+		EncryptPayloads:       c.EncryptPayloads,
+		PadPayloads:           c.PadPayloads,
+		CertificatesToRequest: c.CertificatesToRequest,
+		ReplayWindow:          time.Duration(c.ReplayWindow),
+		MinNonceSize:          c.MinNonceSize,
+		IdempotencyKeyTTL:     time.Duration(c.IdempotencyKeyTTL),
+		OriginBinding:         transport.OriginBinding{Origins: c.OriginBinding.Origins, Required: c.OriginBinding.Required},
+		KnownPeers:            c.KnownPeers,
+		SelfTest:              true,
+	}
+
+	if c.CertificatesCallback {
+		cfg.OnCertificatesReceived = func(_ string, _ *[]certificates.VerifiableCertificate, _ *http.Request, _ http.ResponseWriter, next func()) {
+			next()
+		}
+	}
+
+	if len(c.RoutePolicies) > 0 {
+		cfg.RoutePolicies = make(map[string]auth.RoutePolicy, len(c.RoutePolicies))
+		for pattern, policy := range c.RoutePolicies {
+			cfg.RoutePolicies[pattern] = auth.RoutePolicy(policy)
+		}
+	}
+
+	if c.AnonymousAccess {
+		cfg.AnonymousAccess = &auth.AnonymousPolicy{}
+	}
+
+	if len(c.Logging) > 0 {
+		cfg.Logging = make(defs.LogConfig, len(c.Logging))
+		for subsystem, logging := range c.Logging {
+			cfg.Logging[defs.LogSubsystem(subsystem)] = defs.SubsystemLogging{
+				Level:           defs.LogLevel(logging.Level),
+				DebugSampleRate: logging.DebugSampleRate,
+			}
+		}
+	}
+
+	return cfg
+}
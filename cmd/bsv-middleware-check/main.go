@@ -0,0 +1,47 @@
+// Command bsv-middleware-check validates the middleware setup of a deployment before it is deployed.
+// It loads the declarative config of the deployment, instantiates the auth middleware with a dry-run wallet
+// and reports misconfigurations, such as certificates requested without a callback, conflicting or ineffective
+// route policies and unreachable stores and services. It exits with status 1 when errors are found.
+//
+// The config is a JSON document read from a file or from the BSV_MIDDLEWARE_CONFIG environment variable:
+//
+//	go run ./cmd/bsv-middleware-check -config deploy/middleware.json
+//	BSV_MIDDLEWARE_CONFIG="$(cat deploy/middleware.json)" go run ./cmd/bsv-middleware-check
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+const defaultTimeout = 5 * time.Second
+
+func main() {
+	path := flag.String("config", "", "path of the config file, defaults to the document in "+configEnv)
+	timeout := flag.Duration("timeout", defaultTimeout, "timeout of each dependency check")
+	strict := flag.Bool("strict", false, "exit with status 1 on warnings as well")
+	flag.Parse()
+
+	cfg, err := loadConfig(*path)
+	if err != nil {
+		log.Fatalf("failed to load config: %s", err)
+	}
+
+	findings := check(context.Background(), cfg, &http.Client{Timeout: *timeout})
+
+	failed := false
+	for _, f := range findings {
+		fmt.Println(f)
+		failed = failed || f.severity == severityError || *strict
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+	fmt.Println("config OK")
+}
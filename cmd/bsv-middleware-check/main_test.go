@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/stretchr/testify/require"
+)
+
+const certificateType = "9ZkJfGmbXcggy2CL1Eb8wX1hv2WwVnGf4TTZ1FHrPFU="
+
+func TestCheck(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer healthy.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	certificates := transport.NewRequestedCertificateSet(wallettest.CertifierIdentityKey).AddType(certificateType, "age")
+
+	tests := map[string]struct {
+		config   deploymentConfig
+		expected []finding
+	}{
+		"valid config": {
+			config: deploymentConfig{
+				CertificatesToRequest: certificates,
+				CertificatesCallback:  true,
+				RoutePolicies:         map[string]routePolicy{"GET /health": {Exempt: true}},
+				Dependencies:          map[string]string{"chaintracker": healthy.URL},
+			},
+		},
+		"certificates requested without callback": {
+			config: deploymentConfig{CertificatesToRequest: certificates},
+			expected: []finding{
+				{severityError, "certificates are requested but no OnCertificatesReceived callback is registered"},
+			},
+		},
+		"callback without requested certificates": {
+			config: deploymentConfig{CertificatesCallback: true},
+			expected: []finding{
+				{severityError, "OnCertificatesReceived callback is registered but no certificates are requested"},
+			},
+		},
+		"conflicting route policies": {
+			config: deploymentConfig{RoutePolicies: map[string]routePolicy{
+				"GET /items/{id}":   {Exempt: true},
+				"GET /items/{name}": {AllowUnauthenticated: true},
+			}},
+			expected: []finding{{severityError, ""}},
+		},
+		"ineffective route policies": {
+			config: deploymentConfig{
+				AllowUnauthenticated: true,
+				RoutePolicies: map[string]routePolicy{
+					"/.well-known/auth": {Exempt: true},
+					"/admin":            {Exempt: true, DenyAnonymous: true},
+					"/public":           {AllowUnauthenticated: true},
+					"/orders":           {},
+				},
+			},
+			expected: []finding{
+				{severityWarning, `route policy "/.well-known/auth" never applies, /.well-known/auth is always served by the middleware`},
+				{severityWarning, `route policy "/admin" is exempt, its other settings have no effect`},
+				{severityWarning, `route policy "/admin" denies anonymous sessions, which are not enabled`},
+				{severityWarning, `route policy "/orders" changes nothing`},
+				{severityWarning, `route policy "/public" allows unauthenticated requests, which are allowed on every route`},
+			},
+		},
+		"required origin binding without origins": {
+			config: deploymentConfig{OriginBinding: originBinding{Required: true}},
+			expected: []finding{
+				{severityError, "origin binding is required but no origins are declared, every request is rejected"},
+			},
+		},
+		"invalid known peer": {
+			config:   deploymentConfig{KnownPeers: []transport.KnownPeer{{IdentityKey: "not a key"}}},
+			expected: []finding{{severityError, ""}},
+		},
+		"invalid log level": {
+			config:   deploymentConfig{Logging: map[string]subsystemLogging{"transport": {Level: "verbose"}}},
+			expected: []finding{{severityError, ""}},
+		},
+		"unreachable dependencies": {
+			config: deploymentConfig{Dependencies: map[string]string{
+				"opa":     failing.URL,
+				"webhook": "http://127.0.0.1:0/events",
+			}},
+			expected: []finding{{severityError, ""}, {severityError, ""}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// when
+			findings := check(context.Background(), &test.config, http.DefaultClient)
+
+			// then
+			require.Len(t, findings, len(test.expected), "findings: %v", findings)
+			for i, expected := range test.expected {
+				require.Equal(t, expected.severity, findings[i].severity)
+				if expected.message != "" {
+					require.Equal(t, expected.message, findings[i].message)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	document := `{"allowUnauthenticated": true, "replayWindow": "5m", "routePolicies": {"/health": {"exempt": true}}}`
+
+	t.Run("config is read from the file", func(t *testing.T) {
+		// given
+		path := filepath.Join(t.TempDir(), "middleware.json")
+		require.NoError(t, os.WriteFile(path, []byte(document), 0o600))
+
+		// when
+		cfg, err := loadConfig(path)
+
+		// then
+		require.NoError(t, err)
+		require.True(t, cfg.AllowUnauthenticated)
+		require.Equal(t, "5m0s", cfg.authConfig(nil).ReplayWindow.String())
+		require.True(t, cfg.RoutePolicies["/health"].Exempt)
+	})
+
+	t.Run("config is read from the environment without a file", func(t *testing.T) {
+		// given
+		t.Setenv(configEnv, document)
+
+		// when
+		cfg, err := loadConfig("")
+
+		// then
+		require.NoError(t, err)
+		require.True(t, cfg.AllowUnauthenticated)
+	})
+
+	t.Run("invalid duration", func(t *testing.T) {
+		// given
+		t.Setenv(configEnv, `{"replayWindow": 600}`)
+
+		// when
+		_, err := loadConfig("")
+
+		// then
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/fixtures"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// derive returns 32 bytes derived from the seed for the given label, identities and nonces are derived by the wallet package
+func derive(seed, label string) []byte {
+	mac := hmac.New(sha256.New, []byte(seed))
+	mac.Write([]byte(label))
+	return mac.Sum(nil)
+}
+
+func identity(key *ec.PrivateKey) fixtures.Identity {
+	return fixtures.Identity{
+		PrivateKey:  hex.EncodeToString(key.Serialize()),
+		IdentityKey: key.PubKey().ToDERHex(),
+	}
+}
+
+func generate(seed string, nonceCount int) (*fixtures.Golden, error) {
+	if nonceCount < 2 {
+		return nil, fmt.Errorf("at least 2 nonces are required, got %d", nonceCount)
+	}
+
+	serverKey := wallettest.SeededPrivateKey(seed, "server")
+	clientKey := wallettest.SeededPrivateKey(seed, "client")
+	certifierKey := wallettest.SeededPrivateKey(seed, "certifier")
+
+	golden := &fixtures.Golden{
+		Seed:         seed,
+		Server:       identity(serverKey),
+		Client:       identity(clientKey),
+		Certifier:    identity(certifierKey),
+		MockNonce:    base64.StdEncoding.EncodeToString(derive(seed, "nonce mock")),
+		ServerNonces: wallettest.SeededNonces(seed, "server", nonceCount),
+		ClientNonces: wallettest.SeededNonces(seed, "client", nonceCount),
+	}
+
+	certificate, err := issueCertificate(seed, wallettest.NewMockWallet(certifierKey), golden.Client.IdentityKey, map[string]any{
+		"age":     "21",
+		"country": "Switzerland",
+	})
+	if err != nil {
+		return nil, err
+	}
+	golden.Certificates = []certificates.VerifiableCertificate{{Certificate: *certificate, Keyring: map[string]string{}}}
+
+	certificatesPayload, err := authcore.CertificatesPayload(golden.Certificates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode certificates payload, %w", err)
+	}
+	golden.CertificatesPayload = string(certificatesPayload)
+
+	golden.CanonicalJSON, err = canonicalizeVectors()
+	if err != nil {
+		return nil, err
+	}
+
+	golden.URLs = canonicalizeURLs()
+
+	golden.Handshake, err = recordHandshake(wallettest.NewSeededMockWallet(seed, "server"), wallettest.NewSeededMockWallet(seed, "client"))
+	if err != nil {
+		return nil, err
+	}
+
+	golden.BodylessRequests, err = signBodylessRequests(seed, golden.Server.IdentityKey, golden.ServerNonces[0])
+	if err != nil {
+		return nil, err
+	}
+
+	golden.EmptyContentDigest, err = transport.DigestSHA256.ContentDigest(http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute empty content digest, %w", err)
+	}
+
+	return golden, nil
+}
+
+// issueCertificate creates a certificate of the subject signed by the certifier wallet
+func issueCertificate(seed string, certifier wallet.WalletInterface, subject string, fields map[string]any) (*certificates.Certificate, error) {
+	certifierKey, err := certifier.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get certifier identity key, %w", err)
+	}
+
+	certificate := &certificates.Certificate{
+		Type:               base64.StdEncoding.EncodeToString(derive(seed, "certificate type")),
+		Subject:            subject,
+		SerialNumber:       base64.StdEncoding.EncodeToString(derive(seed, "certificate serial 0")),
+		Certifier:          certifierKey.PublicKey.ToDERHex(),
+		RevocationOutpoint: hex.EncodeToString(derive(seed, "certificate revocation 0")) + ".0",
+		Fields:             fields,
+	}
+
+	data, err := json.Marshal(certificate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode certificate, %w", err)
+	}
+
+	signature, err := certifier.CreateSignature(&wallet.CreateSignatureArgs{
+		EncryptionArgs: wallet.EncryptionArgs{
+			ProtocolID:   wallet.CertificateSignatureProtocol,
+			KeyID:        certificate.Type + " " + certificate.SerialNumber,
+			Counterparty: wallet.Counterparty{Type: wallet.CounterpartyTypeAnyone},
+		},
+		Data: data,
+	}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign certificate, %w", err)
+	}
+
+	certificate.Signature = hex.EncodeToString(signature.Signature.Serialize())
+	return certificate, nil
+}
+
+// canonicalJSONVectors are the documents encoded by canonicalizeVectors
+var canonicalJSONVectors = []fixtures.CanonicalJSONVector{
+	{Name: "whitespace", Input: `{ "a" : [ 1 , 2 ] , "b" : { } }`},
+	{Name: "object keys", Input: `{"b":2,"a":1,"c":{"z":true,"y":null}}`},
+	{Name: "object keys in utf-16 order", Input: `{"\u20ac":1,"\r":2,"\ufb33":3,"1":4,"\ud83d\ude00":5,"\u0080":6,"\u00f6":7}`},
+	{Name: "numbers", Input: `[0,-0,1.0,-1.5,1e21,1e20,0.000001,1e-7,5e-324,1.7976931348623157e308,333333333.33333329,9007199254740993]`},
+	{Name: "strings", Input: `["<tag> & \u2028 \u00e9", "\u001f\/\"\\", "\b\f\n\r\t"]`},
+	{Name: "certificate fields", Input: `{"fields":{"score":9.50,"age":"21","nested":{"list":[3,2,1]}},"type":"dGVzdA=="}`},
+}
+
+// canonicalizeVectors encodes the documents of canonicalJSONVectors canonically
+func canonicalizeVectors() ([]fixtures.CanonicalJSONVector, error) {
+	vectors := make([]fixtures.CanonicalJSONVector, 0, len(canonicalJSONVectors))
+	for _, vector := range canonicalJSONVectors {
+		canonical, err := authcore.Canonicalize([]byte(vector.Input))
+		if err != nil {
+			return nil, fmt.Errorf("failed to canonicalize %s vector, %w", vector.Name, err)
+		}
+		vector.Canonical = string(canonical)
+		vectors = append(vectors, vector)
+	}
+	return vectors, nil
+}
+
+// urlVectors are the request targets encoded by canonicalizeURLs
+var urlVectors = []fixtures.URLVector{
+	{Name: "plain", Target: "/items/1?expand=all&limit=10"},
+	{Name: "encoded unreserved characters", Target: "/%7Euser/%41%2d?a=%61%5f"},
+	{Name: "lower-case hex digits", Target: "/caf%c3%a9?q=%e2%9c%93"},
+	{Name: "encoded delimiters", Target: "/a%2fb?q=a%26b%3dc"},
+	{Name: "non-ASCII characters", Target: "/caf\u00e9/\U0001F600?mood=\U0001F600"},
+	{Name: "decomposed characters", Target: "/cafe\u0301"},
+	{Name: "space and plus", Target: "/a b?q=a+b c"},
+	{Name: "reserved characters", Target: "/a!$&'()*+,;=:@b/[c]?next=/a?b#c"},
+	{Name: "percent sign which is not an octet", Target: "/100%?p=50%"},
+}
+
+// canonicalizeURLs encodes the paths and queries of urlVectors canonically
+func canonicalizeURLs() []fixtures.URLVector {
+	vectors := make([]fixtures.URLVector, 0, len(urlVectors))
+	for _, vector := range urlVectors {
+		path, query, _ := strings.Cut(vector.Target, "?")
+		vector.Path = authcore.CanonicalPath(path)
+		vector.Query = authcore.CanonicalQuery(query)
+		vectors = append(vectors, vector)
+	}
+	return vectors
+}
+
+// bodylessRequests are the requests signed by signBodylessRequests
+var bodylessRequests = []fixtures.BodylessRequest{
+	{Name: "get", Method: http.MethodGet, URL: "https://example.com/ping"},
+	{Name: "get with query", Method: http.MethodGet, URL: "https://example.com/items?expand=all"},
+	{Name: "get with encoded characters", Method: http.MethodGet, URL: "https://example.com/files/caf%c3%a9/%F0%9F%98%80/a%2Fb?q=a+b&tag=%e2%9c%93"},
+	{Name: "head", Method: http.MethodHead, URL: "https://example.com/ping"},
+	{Name: "delete", Method: http.MethodDelete, URL: "https://example.com/items/1"},
+	{Name: "delete with json content type", Method: http.MethodDelete, URL: "https://example.com/items/1", Headers: map[string]string{"Content-Type": "application/json"}},
+	{Name: "post with empty json body", Method: http.MethodPost, URL: "https://example.com/items", Headers: map[string]string{"Content-Type": "application/json"}},
+	{Name: "post with empty text body", Method: http.MethodPost, URL: "https://example.com/items", Headers: map[string]string{"Content-Type": "text/plain"}},
+}
+
+// signBodylessRequests signs the bodyless requests for the session with the server, request IDs are derived from the seed
+func signBodylessRequests(seed, serverIdentityKey, serverNonce string) ([]fixtures.BodylessRequest, error) {
+	session := &transport.AuthMessage{IdentityKey: serverIdentityKey, InitialNonce: serverNonce}
+
+	requests := make([]fixtures.BodylessRequest, 0, len(bodylessRequests))
+	for _, request := range bodylessRequests {
+		authHeaders, err := utils.PrepareGeneralRequestHeaders(wallettest.NewSeededMockWallet(seed, "client"), session, utils.RequestData{
+			Method:  request.Method,
+			URL:     request.URL,
+			Headers: request.Headers,
+			Random:  bytes.NewReader(derive(seed, "request id "+request.Name)),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign %s request, %w", request.Name, err)
+		}
+
+		u, err := url.Parse(request.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse URL of %s request, %w", request.Name, err)
+		}
+		requestID, err := base64.StdEncoding.DecodeString(authHeaders["x-bsv-auth-request-id"])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode request ID of %s request, %w", request.Name, err)
+		}
+		headers := make(map[string][]string, len(request.Headers))
+		for name, value := range request.Headers {
+			headers[name] = []string{value}
+		}
+
+		request.AuthHeaders = authHeaders
+		request.Payload = hex.EncodeToString(authcore.RequestPayload{
+			RequestID: requestID,
+			Method:    request.Method,
+			Path:      u.EscapedPath(),
+			Query:     u.RawQuery,
+			Headers:   authcore.SignedRequestHeaders(headers),
+		}.Bytes())
+		requests = append(requests, request)
+	}
+	return requests, nil
+}
+
+// recordHandshake performs the handshake of the client with the auth middleware of the server and records the exchanged messages
+func recordHandshake(serverWallet, clientWallet wallet.WalletInterface) (fixtures.Handshake, error) {
+	middleware, err := auth.New(auth.Config{
+		Wallet:         serverWallet,
+		SessionManager: session.NewMemoryManager(),
+		Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		return fixtures.Handshake{}, fmt.Errorf("failed to create auth middleware, %w", err)
+	}
+
+	initialRequest, err := json.Marshal(utils.PrepareInitialRequestBody(clientWallet))
+	if err != nil {
+		return fixtures.Handshake{}, fmt.Errorf("failed to encode initial request, %w", err)
+	}
+
+	req := httptest.NewRequestWithContext(context.Background(), http.MethodPost, "/.well-known/auth", bytes.NewReader(initialRequest))
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	middleware.Handler(http.NotFoundHandler()).ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusOK {
+		return fixtures.Handshake{}, fmt.Errorf("handshake failed with status %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	headers := make(map[string]string)
+	for name := range recorder.Header() {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-bsv-auth") {
+			headers[lower] = recorder.Header().Get(name)
+		}
+	}
+
+	return fixtures.Handshake{
+		InitialRequest:  initialRequest,
+		InitialResponse: bytes.TrimSpace(recorder.Body.Bytes()),
+		ResponseHeaders: headers,
+	}, nil
+}
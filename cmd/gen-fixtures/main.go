@@ -0,0 +1,45 @@
+// Command gen-fixtures generates deterministic test fixtures: identities, nonces, signed certificates
+// and a golden handshake transcript. Every value is derived from the seed, so the fixtures only change with the seed
+// or with the behaviour of the middleware.
+//
+// It writes the Go fixtures used by the tests and the golden JSON file shared with client implementations in other languages:
+//
+//	go run ./cmd/gen-fixtures -seed "go-bsv-middleware" -go pkg/wallet/wallettest/fixtures.go -json test/fixtures/golden.json
+package main
+
+import (
+	"flag"
+	"log"
+)
+
+const (
+	defaultSeed     = "go-bsv-middleware"
+	defaultNonces   = 20
+	defaultGoPath   = "pkg/wallet/wallettest/fixtures.go"
+	defaultJSONPath = "test/fixtures/golden.json"
+)
+
+func main() {
+	seed := flag.String("seed", defaultSeed, "seed all fixtures are derived from")
+	nonces := flag.Int("nonces", defaultNonces, "number of server and client nonces")
+	goPath := flag.String("go", defaultGoPath, "output path of the Go fixtures, empty to skip")
+	jsonPath := flag.String("json", defaultJSONPath, "output path of the golden JSON fixtures, empty to skip")
+	flag.Parse()
+
+	golden, err := generate(*seed, *nonces)
+	if err != nil {
+		log.Fatalf("failed to generate fixtures: %s", err)
+	}
+
+	if *goPath != "" {
+		if err := writeFile(*goPath, renderGo, golden); err != nil {
+			log.Fatalf("failed to write Go fixtures: %s", err)
+		}
+	}
+
+	if *jsonPath != "" {
+		if err := writeFile(*jsonPath, renderJSON, golden); err != nil {
+			log.Fatalf("failed to write golden fixtures: %s", err)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/fixtures"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate_CommittedFixturesAreUpToDate(t *testing.T) {
+	// given
+	golden, err := generate(defaultSeed, defaultNonces)
+	require.NoError(t, err)
+
+	for path, render := range map[string]func(*fixtures.Golden) ([]byte, error){
+		"../../" + defaultGoPath:   renderGo,
+		"../../" + defaultJSONPath: renderJSON,
+	} {
+		// when
+		expected, err := render(golden)
+		require.NoError(t, err)
+
+		// then
+		actual, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, string(expected), string(actual), "%s is outdated, run go generate ./pkg/wallet/wallettest", path)
+	}
+}
+
+func TestGenerate_Deterministic(t *testing.T) {
+	// when
+	first, err := generate("seed", 3)
+	require.NoError(t, err)
+	second, err := generate("seed", 3)
+	require.NoError(t, err)
+	other, err := generate("other seed", 3)
+	require.NoError(t, err)
+
+	// then
+	require.Equal(t, first, second)
+	require.NotEqual(t, first.Server, other.Server)
+	require.Len(t, first.ServerNonces, 3)
+	require.NotEqual(t, first.ServerNonces, first.ClientNonces)
+}
+
+func TestGenerate_CertificateSignature(t *testing.T) {
+	// given
+	golden, err := generate(defaultSeed, defaultNonces)
+	require.NoError(t, err)
+	certificate := golden.Certificates[0].Certificate
+
+	certifier, err := ec.PublicKeyFromString(certificate.Certifier)
+	require.NoError(t, err)
+
+	signatureBytes, err := hex.DecodeString(certificate.Signature)
+	require.NoError(t, err)
+	signature, err := ec.ParseSignature(signatureBytes)
+	require.NoError(t, err)
+
+	unsigned := certificate
+	unsigned.Signature = ""
+	data, err := json.Marshal(unsigned)
+	require.NoError(t, err)
+
+	anyone, _ := ec.PrivateKeyFromBytes([]byte{1})
+
+	// when
+	result, err := wallettest.NewMockWallet(anyone).VerifySignature(&wallet.VerifySignatureArgs{
+		EncryptionArgs: wallet.EncryptionArgs{
+			ProtocolID:   wallet.CertificateSignatureProtocol,
+			KeyID:        certificate.Type + " " + certificate.SerialNumber,
+			Counterparty: wallet.Counterparty{Type: wallet.CounterpartyTypeOther, Counterparty: certifier},
+		},
+		Data:      data,
+		Signature: *signature,
+	})
+
+	// then
+	require.NoError(t, err)
+	require.True(t, result.Valid)
+	require.Equal(t, golden.Client.IdentityKey, certificate.Subject)
+}
+
+func TestGenerate_HandshakeTranscript(t *testing.T) {
+	// when
+	golden, err := generate(defaultSeed, defaultNonces)
+	require.NoError(t, err)
+
+	// then
+	var response struct {
+		IdentityKey  string `json:"identityKey"`
+		InitialNonce string `json:"initialNonce"`
+		YourNonce    string `json:"yourNonce"`
+	}
+	require.NoError(t, json.Unmarshal(golden.Handshake.InitialResponse, &response))
+	require.Equal(t, golden.Server.IdentityKey, response.IdentityKey)
+	require.Equal(t, golden.ServerNonces[0], response.InitialNonce)
+	require.Equal(t, golden.ClientNonces[0], response.YourNonce)
+	require.Equal(t, golden.Server.IdentityKey, golden.Handshake.ResponseHeaders["x-bsv-auth-identity-key"])
+}
+
+func TestGenerate_BodylessRequests(t *testing.T) {
+	// given
+	golden, err := generate(defaultSeed, defaultNonces)
+	require.NoError(t, err)
+	serverWallet := wallettest.NewSeededMockWallet(golden.Seed, "server")
+	absent := "ffffffffffffffff"
+	emptyObject := "0200000000000000" + hex.EncodeToString([]byte("{}"))
+
+	expectedBodies := map[string]string{
+		"get":                           absent,
+		"get with query":                absent,
+		"get with encoded characters":   absent,
+		"head":                          absent,
+		"delete":                        absent,
+		"delete with json content type": emptyObject,
+		"post with empty json body":     emptyObject,
+		"post with empty text body":     absent,
+	}
+	require.Len(t, golden.BodylessRequests, len(expectedBodies))
+
+	for _, request := range golden.BodylessRequests {
+		t.Run(request.Name, func(t *testing.T) {
+			// when
+			payload, err := hex.DecodeString(request.Payload)
+			require.NoError(t, err)
+			err = authcore.VerifySignature(utils.NewSignatureVerifier(serverWallet), request.AuthHeaders["x-bsv-auth-identity-key"],
+				authcore.GeneralKeyID(request.AuthHeaders["x-bsv-auth-nonce"], request.AuthHeaders["x-bsv-auth-your-nonce"], ""),
+				payload, request.AuthHeaders["x-bsv-auth-signature"])
+
+			// then
+			require.NoError(t, err)
+			require.True(t, strings.HasSuffix(request.Payload, expectedBodies[request.Name]))
+			require.Equal(t, golden.ServerNonces[0], request.AuthHeaders["x-bsv-auth-your-nonce"])
+		})
+	}
+	require.Equal(t, "sha-256=:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU=:", golden.EmptyContentDigest)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/test/fixtures"
+)
+
+var goTemplate = template.Must(template.New("fixtures").Parse(`// Code generated by gen-fixtures; DO NOT EDIT.
+
+package wallettest
+
+// Constants for expected return values
+const (
+	// Seed is the seed the fixtures are derived from, seeded mock wallets of the "server" and "client" identities
+	// return the same keys and nonces as the fixtures
+	Seed = {{printf "%q" .Seed}}
+
+	// MockNonce is the expected nonce
+	MockNonce = "{{.MockNonce}}"
+)
+
+// Nonces and identities for testing, derived from Seed
+var (
+	DefaultNonces = []string{
+{{- range .ServerNonces}}
+		"{{.}}",
+{{- end}}
+	}
+
+	ClientNonces = []string{
+{{- range .ClientNonces}}
+		"{{.}}",
+{{- end}}
+	}
+
+	ServerIdentityKey    = "{{.Server.IdentityKey}}"
+	ClientIdentityKey    = "{{.Client.IdentityKey}}"
+	CertifierIdentityKey = "{{.Certifier.IdentityKey}}"
+
+	ServerPrivateKeyHex    = "{{.Server.PrivateKey}}"
+	ClientPrivateKeyHex    = "{{.Client.PrivateKey}}"
+	CertifierPrivateKeyHex = "{{.Certifier.PrivateKey}}"
+)
+`))
+
+func renderGo(golden *fixtures.Golden) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := goTemplate.Execute(&buf, golden); err != nil {
+		return nil, fmt.Errorf("failed to render Go fixtures, %w", err)
+	}
+
+	source, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format Go fixtures, %w", err)
+	}
+	return source, nil
+}
+
+func renderJSON(golden *fixtures.Golden) ([]byte, error) {
+	data, err := json.MarshalIndent(golden, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode golden fixtures, %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+func writeFile(path string, render func(*fixtures.Golden) ([]byte, error), golden *fixtures.Golden) error {
+	data, err := render(golden)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create directory, %w", err)
+	}
+
+	return os.WriteFile(path, data, 0o600)
+}
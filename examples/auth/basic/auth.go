@@ -10,10 +10,11 @@ import (
 	"time"
 
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet"
-	walletFixtures "github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet/test"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
 	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 	"github.com/go-resty/resty/v2"
 )
@@ -23,12 +24,12 @@ func main() {
 	logHandler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})
 	logger := slog.New(logHandler)
 
-	sPrivKey, err := ec.PrivateKeyFromHex(walletFixtures.ServerPrivateKeyHex)
+	sPrivKey, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
 	if err != nil {
 		panic(err)
 	}
 
-	serverMockedWallet := wallet.NewMockWallet(sPrivKey, walletFixtures.DefaultNonces...)
+	serverMockedWallet := wallettest.NewMockWallet(sPrivKey, wallettest.DefaultNonces...)
 	fmt.Println("✓ Server mockWallet created")
 
 	// Create authentication middleware with:
@@ -40,6 +41,7 @@ func main() {
 		AllowUnauthenticated: false,
 		Logger:               logger,
 		Wallet:               serverMockedWallet,
+		SessionManager:       session.NewMemoryManager(),
 	}
 	middleware, err := auth.New(opts)
 	if err != nil {
@@ -68,11 +70,11 @@ func main() {
 	fmt.Println("✓ HTTP Server started")
 
 	// Create mocked client wallet with predefined client nonces and client identity key
-	cPrivKey, err := ec.PrivateKeyFromHex(walletFixtures.ServerPrivateKeyHex)
+	cPrivKey, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
 	if err != nil {
 		panic(err)
 	}
-	mockedWallet := wallet.NewMockWallet(cPrivKey, walletFixtures.ClientNonces...)
+	mockedWallet := wallettest.NewMockWallet(cPrivKey, wallettest.ClientNonces...)
 	fmt.Println("✓ Client mockWallet created")
 
 	fmt.Println("\n📡 STEP 1: Sending non general request to /.well-known/auth endpoint")
@@ -12,18 +12,24 @@ import (
 	"strings"
 	"time"
 
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet"
-	walletFixtures "github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet/test"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
 	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
 	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 	"github.com/go-resty/resty/v2"
 )
 
 const serverAddress = "http://localhost:8080"
-const trustedCertifier = "02certifieridentitykey00000000000000000000000000000000000000000000000"
+const trustedCertifier = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+
+// ageVerificationType is the base64 type ID of the age verification certificates
+const ageVerificationType = "9ZkJfGmbXcggy2CL1Eb8wX1hv2WwVnGf4TTZ1FHrPFU="
 
 func main() {
 	// ========== Server Setup ==========
@@ -34,25 +40,25 @@ func main() {
 	logHandler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})
 	logger := slog.New(logHandler)
 
-	sPrivKey, err := ec.PrivateKeyFromHex(walletFixtures.ServerPrivateKeyHex)
+	sPrivKey, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
 	if err != nil {
 		panic(err)
 	}
 
-	serverMockedWallet := wallet.NewMockWallet(sPrivKey, walletFixtures.DefaultNonces...)
+	serverMockedWallet := wallettest.NewMockWallet(sPrivKey, wallettest.DefaultNonces...)
 
 	// Define the certificate types and certifier expected
 	certificateToRequest := transport.RequestedCertificateSet{
 		Certifiers: []string{trustedCertifier},
 		Types: map[string][]string{
-			"age-verification": {"age"},
+			ageVerificationType: {"age"},
 		},
 	}
 
 	// Middleware callback for processing received certificates
 	onCertificatesReceived := func(
 		senderPublicKey string,
-		certs *[]wallet.VerifiableCertificate,
+		certs *[]certificates.VerifiableCertificate,
 		req *http.Request,
 		res http.ResponseWriter,
 		next func()) {
@@ -81,7 +87,7 @@ func main() {
 				continue
 			}
 
-			if cert.Certificate.Type != "age-verification" {
+			if cert.Certificate.Type != ageVerificationType {
 				logger.Error("Unexpected certificate type")
 				continue
 			}
@@ -125,6 +131,7 @@ func main() {
 		AllowUnauthenticated:   false,
 		Logger:                 logger,
 		Wallet:                 serverMockedWallet,
+		SessionManager:         session.NewMemoryManager(),
 		CertificatesToRequest:  &certificateToRequest,
 		OnCertificatesReceived: onCertificatesReceived,
 	}
@@ -159,12 +166,12 @@ func main() {
 	fmt.Println("🧪 SIMULATING CLIENT AUTHENTICATION FLOW")
 	fmt.Println("============================================================")
 
-	cPrivKey, err := ec.PrivateKeyFromHex(walletFixtures.ClientPrivateKeyHex)
+	cPrivKey, err := ec.PrivateKeyFromHex(wallettest.ClientPrivateKeyHex)
 	if err != nil {
 		panic(err)
 	}
 
-	mockedWallet := wallet.NewMockWallet(cPrivKey, walletFixtures.DefaultNonces...)
+	mockedWallet := wallettest.NewMockWallet(cPrivKey, wallettest.DefaultNonces...)
 
 	fmt.Println("\n📡 STEP 1: Client initiates authentication handshake")
 	responseData := callInitialRequest(mockedWallet)
@@ -299,10 +306,10 @@ func sendCertificate(clientWallet wallet.WalletInterface, serverIdentityKey, pre
 		log.Fatalf("Failed to create nonce: %v", err)
 	}
 
-	certificates := []wallet.VerifiableCertificate{
+	certs := []certificates.VerifiableCertificate{
 		{
-			Certificate: wallet.Certificate{
-				Type:         "age-verification",
+			Certificate: certificates.Certificate{
+				Type:         ageVerificationType,
 				SerialNumber: "12345",
 				Subject:      identityKey,
 				Certifier:    trustedCertifier,
@@ -321,10 +328,10 @@ func sendCertificate(clientWallet wallet.WalletInterface, serverIdentityKey, pre
 		IdentityKey:  identityKey,
 		Nonce:        &nonce,
 		YourNonce:    &previousNonce,
-		Certificates: &certificates,
+		Certificates: &certs,
 	}
 
-	certBytes, err := json.Marshal(certificates)
+	certBytes, err := authcore.CertificatesPayload(certs)
 	if err != nil {
 		log.Fatalf("Failed to marshal certificates: %v", err)
 	}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/payment"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+)
+
+// billingFee is the price in satoshis of every invoice
+const billingFee = 5
+
+// Invoice is an invoice issued by the billing service
+type Invoice struct {
+	Amount        int    `json:"amount"`
+	Fee           int    `json:"fee"`
+	TransactionID string `json:"transactionId"`
+}
+
+// newBilling returns the billing service, which charges the billing fee for every invoice it issues
+func newBilling(logger *slog.Logger) (http.Handler, error) {
+	billingWallet := wallettest.NewMockPaymentWallet(privateKey(billingName))
+
+	authMiddleware, err := auth.New(auth.Config{
+		Logger:         logger.With(slog.String("service", billingName)),
+		Wallet:         billingWallet,
+		SessionManager: session.NewMemoryManager(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	paymentMiddleware, err := payment.New(payment.Options{
+		Wallet: billingWallet,
+		CalculateRequestPrice: func(*http.Request) (int, error) {
+			return billingFee, nil
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /invoices", func(w http.ResponseWriter, r *http.Request) {
+		amount, err := strconv.Atoi(r.URL.Query().Get("amount"))
+		if err != nil || amount <= 0 {
+			http.Error(w, "invalid amount", http.StatusBadRequest)
+			return
+		}
+
+		invoice := Invoice{Amount: amount}
+		if info, ok := payment.GetPaymentInfoFromContext(r.Context()); ok {
+			invoice.Fee, invoice.TransactionID = info.SatoshisPaid, info.TransactionID
+		}
+		logger.Info("invoice issued", slog.Int("amount", invoice.Amount), slog.Int("fee", invoice.Fee))
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(invoice)
+	})
+
+	return authMiddleware.Handler(paymentMiddleware.Handler(mux)), nil
+}
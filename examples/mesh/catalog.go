@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// Item is a product of the catalog
+type Item struct {
+	SKU   string `json:"sku"`
+	Name  string `json:"name"`
+	Price int    `json:"price"`
+}
+
+// newCatalog returns the catalog service, which requests a service certificate of the certifier in the handshake
+// and only serves peers whose certificate carries the gateway role
+func newCatalog(logger *slog.Logger) (http.Handler, error) {
+	authMiddleware, err := auth.New(auth.Config{
+		Logger:                logger.With(slog.String("service", catalogName)),
+		Wallet:                newWallet(catalogName),
+		SessionManager:        session.NewMemoryManager(),
+		CertificatesToRequest: transport.NewRequestedCertificateSet(identityKey(certifierName)).AddType(serviceCertificateType, "role"),
+		OnCertificatesReceived: func(senderPublicKey string, certs *[]certificates.VerifiableCertificate, _ *http.Request, w http.ResponseWriter, next func()) {
+			for _, cert := range *certs {
+				if cert.Type == serviceCertificateType && cert.Subject == senderPublicKey && cert.Fields["role"] == gatewayName {
+					next()
+					return
+				}
+			}
+			http.Error(w, "gateway role required", http.StatusForbidden)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items", func(w http.ResponseWriter, r *http.Request) {
+		caller, _ := auth.GetIdentityFromContext(r.Context())
+		logger.Info("catalog listed", slog.String("caller", caller))
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode([]Item{
+			{SKU: "tea-01", Name: "Green tea", Price: 10},
+			{SKU: "mug-01", Name: "Mug", Price: 25},
+		})
+	})
+
+	return authMiddleware.Handler(mux), nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/payment"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+)
+
+// Order is the response of the gateway, combining the catalog and an invoice of the billing service
+type Order struct {
+	Caller  string  `json:"caller"`
+	Items   []Item  `json:"items"`
+	Invoice Invoice `json:"invoice"`
+}
+
+// roundTripper sends the requests of an http.Client through the auth client, so code written against
+// net/http talks to other services of the mesh with mutually authenticated requests
+type roundTripper struct {
+	client *client.Client
+}
+
+// RoundTrip signs and sends the request, verifying the signature of the response
+func (rt roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return rt.client.Do(req.Clone(req.Context()))
+}
+
+// newServiceClient returns an http.Client authenticating to the service with the identity of the gateway,
+// the service has to respond with its known identity key
+func newServiceClient(baseURL, service string, gatewayWallet wallet.WalletInterface) (*http.Client, error) {
+	gatewayIdentityKey := identityKey(gatewayName)
+
+	authClient, err := client.New(client.Config{
+		Wallet:             gatewayWallet,
+		BaseURL:            baseURL,
+		PinnedIdentityKeys: []string{identityKey(service)},
+		CertificateProvider: client.CertificateProviderFunc(func(context.Context, transport.RequestedCertificateSet, string) ([]certificates.VerifiableCertificate, error) {
+			return []certificates.VerifiableCertificate{{
+				Certificate: certificates.Certificate{
+					Type:         serviceCertificateType,
+					SerialNumber: "gateway-1",
+					Subject:      gatewayIdentityKey,
+					Certifier:    identityKey(certifierName),
+					Fields:       map[string]any{"role": gatewayName},
+					Signature:    "mocksignature",
+				},
+				Keyring: map[string]string{"role": "mockkey"},
+			}}, nil
+		}),
+		Payer: client.PayerFunc(func(_ context.Context, terms payment.PaymentTerms, serverIdentityKey string) (*payment.Payment, error) {
+			return mocks.CreateMockPayment(gatewayWallet, terms, serverIdentityKey)
+		}),
+		PaymentLimits: client.PaymentLimits{MaxPerRequest: billingFee},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{Transport: roundTripper{client: authClient}}, nil
+}
+
+// newGateway returns the gateway service, which authenticates its callers and serves orders assembled
+// from the catalog and the billing service
+func newGateway(logger *slog.Logger, catalogURL, billingURL string) (http.Handler, error) {
+	gatewayWallet := newWallet(gatewayName)
+
+	catalog, err := newServiceClient(catalogURL, catalogName, gatewayWallet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create catalog client, %w", err)
+	}
+
+	billing, err := newServiceClient(billingURL, billingName, gatewayWallet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create billing client, %w", err)
+	}
+
+	authMiddleware, err := auth.New(auth.Config{
+		Logger:         logger.With(slog.String("service", gatewayName)),
+		Wallet:         gatewayWallet,
+		SessionManager: session.NewMemoryManager(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /order", func(w http.ResponseWriter, r *http.Request) {
+		order := Order{}
+		order.Caller, _ = auth.GetIdentityFromContext(r.Context())
+
+		if err := call(r.Context(), catalog, http.MethodGet, catalogURL+"/items", &order.Items); err != nil {
+			logger.Error("catalog request failed", slog.String("error", err.Error()))
+			http.Error(w, "catalog unavailable", http.StatusBadGateway)
+			return
+		}
+
+		amount := 0
+		for _, item := range order.Items {
+			amount += item.Price
+		}
+
+		if err := call(r.Context(), billing, http.MethodPost, fmt.Sprintf("%s/invoices?amount=%d", billingURL, amount), &order.Invoice); err != nil {
+			logger.Error("billing request failed", slog.String("error", err.Error()))
+			http.Error(w, "billing unavailable", http.StatusBadGateway)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(order)
+	})
+
+	return authMiddleware.Handler(mux), nil
+}
+
+// call sends the request with the client and decodes the JSON response into target
+func call(ctx context.Context, httpClient *http.Client, method, url string, target any) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", response.StatusCode)
+	}
+	return json.NewDecoder(response.Body).Decode(target)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// meshSeed derives the keys of every identity of the mesh, so the services know each other's identity keys upfront.
+// A real deployment gives every service its own wallet and distributes the identity keys as configuration.
+const meshSeed = "go-bsv-middleware mesh example"
+
+// Names of the identities of the mesh
+const (
+	certifierName = "certifier"
+	gatewayName   = "gateway"
+	catalogName   = "catalog"
+	billingName   = "billing"
+	callerName    = "caller"
+)
+
+// serviceCertificateType is the type of the certificates the certifier issues to the services of the mesh,
+// the catalog only serves services holding one with the gateway role
+var serviceCertificateType = func() string {
+	typeID := sha256.Sum256([]byte("mesh service"))
+	return base64.StdEncoding.EncodeToString(typeID[:])
+}()
+
+func identityKey(name string) string {
+	return wallettest.SeededPrivateKey(meshSeed, name).PubKey().ToDERHex()
+}
+
+func privateKey(name string) *ec.PrivateKey {
+	return wallettest.SeededPrivateKey(meshSeed, name)
+}
+
+func newWallet(name string) wallet.WalletInterface {
+	return wallettest.NewSeededMockWallet(meshSeed, name)
+}
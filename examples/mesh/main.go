@@ -0,0 +1,63 @@
+// Command mesh runs three services authenticating to each other with BRC-103/104 mutual authentication:
+// a gateway, a catalog requiring a certificate of the gateway and a billing service charging the gateway per invoice.
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+)
+
+func main() {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	mesh, err := StartMesh(logger)
+	if err != nil {
+		logger.Error("failed to start mesh", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	defer func() {
+		_ = mesh.Close()
+	}()
+
+	logger.Info("mesh started",
+		slog.String("gateway", mesh.GatewayURL),
+		slog.String("catalog", mesh.CatalogURL),
+		slog.String("billing", mesh.BillingURL))
+
+	caller, err := client.New(client.Config{
+		Wallet:             newWallet(callerName),
+		BaseURL:            mesh.GatewayURL,
+		PinnedIdentityKeys: []string{identityKey(gatewayName)},
+	})
+	if err != nil {
+		logger.Error("failed to create caller", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, mesh.GatewayURL+"/order", nil)
+	if err != nil {
+		logger.Error("failed to create request", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	response, err := caller.Do(req)
+	if err != nil {
+		logger.Error("order failed", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		logger.Error("failed to read order", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	logger.Info("order received", slog.Int("status", response.StatusCode), slog.String("body", string(body)))
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Mesh runs the services of the mesh on local ports
+type Mesh struct {
+	GatewayURL string
+	CatalogURL string
+	BillingURL string
+
+	servers []*http.Server
+}
+
+// StartMesh starts the catalog and the billing service, and the gateway in front of them
+func StartMesh(logger *slog.Logger) (*Mesh, error) {
+	m := &Mesh{}
+
+	catalog, err := newCatalog(logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create catalog, %w", err)
+	}
+	if m.CatalogURL, err = m.serve(logger, catalog); err != nil {
+		return nil, err
+	}
+
+	billing, err := newBilling(logger)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to create billing, %w", err), m.Close())
+	}
+	if m.BillingURL, err = m.serve(logger, billing); err != nil {
+		return nil, errors.Join(err, m.Close())
+	}
+
+	gateway, err := newGateway(logger, m.CatalogURL, m.BillingURL)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("failed to create gateway, %w", err), m.Close())
+	}
+	if m.GatewayURL, err = m.serve(logger, gateway); err != nil {
+		return nil, errors.Join(err, m.Close())
+	}
+
+	return m, nil
+}
+
+// Close shuts the services down
+func (m *Mesh) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var errs []error
+	for _, server := range m.servers {
+		errs = append(errs, server.Shutdown(ctx))
+	}
+	return errors.Join(errs...)
+}
+
+// serve serves the handler on a free local port and returns its base URL
+func (m *Mesh) serve(logger *slog.Logger, handler http.Handler) (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", fmt.Errorf("failed to listen, %w", err)
+	}
+
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: 5 * time.Second}
+	m.servers = append(m.servers, server)
+
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("server error", slog.String("error", err.Error()))
+		}
+	}()
+
+	return "http://" + listener.Addr().String(), nil
+}
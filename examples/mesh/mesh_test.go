@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMesh(t *testing.T) {
+	mesh, err := StartMesh(slog.New(slog.DiscardHandler))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = mesh.Close() })
+
+	newClient := func(t *testing.T, baseURL, service string) *client.Client {
+		authClient, err := client.New(client.Config{
+			Wallet:             newWallet(callerName),
+			BaseURL:            baseURL,
+			PinnedIdentityKeys: []string{identityKey(service)},
+		})
+		require.NoError(t, err)
+		return authClient
+	}
+
+	t.Run("gateway serves orders assembled from the catalog and a paid invoice", func(t *testing.T) {
+		// given
+		caller := newClient(t, mesh.GatewayURL, gatewayName)
+		req, err := http.NewRequest(http.MethodGet, mesh.GatewayURL+"/order", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := caller.Do(req)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		var order Order
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&order))
+		require.Equal(t, identityKey(callerName), order.Caller)
+		require.Len(t, order.Items, 2)
+		require.Equal(t, 35, order.Invoice.Amount)
+		require.Equal(t, billingFee, order.Invoice.Fee)
+		require.NotEmpty(t, order.Invoice.TransactionID)
+	})
+
+	t.Run("catalog rejects peers without a service certificate", func(t *testing.T) {
+		// given
+		caller := newClient(t, mesh.CatalogURL, catalogName)
+		req, err := http.NewRequest(http.MethodGet, mesh.CatalogURL+"/items", nil)
+		require.NoError(t, err)
+
+		// when
+		_, err = caller.Do(req)
+
+		// then
+		require.ErrorIs(t, err, client.ErrCertificateRequired)
+	})
+
+	t.Run("billing rejects peers which do not pay", func(t *testing.T) {
+		// given
+		caller := newClient(t, mesh.BillingURL, billingName)
+		req, err := http.NewRequest(http.MethodPost, mesh.BillingURL+"/invoices?amount=10", nil)
+		require.NoError(t, err)
+
+		// when
+		_, err = caller.Do(req)
+
+		// then
+		require.ErrorIs(t, err, client.ErrPaymentRequired)
+	})
+}
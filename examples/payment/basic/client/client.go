@@ -8,11 +8,12 @@ import (
 	"time"
 
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/payment"
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet"
-	walletFixtures "github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet/test"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
 	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/transaction"
 	"github.com/go-resty/resty/v2"
 )
 
@@ -25,7 +26,7 @@ func main() {
 		return
 	}
 
-	mockWallet := wallet.NewMockWallet(key, walletFixtures.DefaultNonces...)
+	mockWallet := wallettest.NewMockWallet(key, wallettest.DefaultNonces...)
 	fmt.Println("✓ Client mockWallet created")
 
 	time.Sleep(1 * time.Second)
@@ -43,7 +44,7 @@ func main() {
 	fmt.Printf("✓ 402 received: %d satoshis required\n", terms.SatoshisRequired)
 
 	fmt.Println("\n💰 STEP 4: CREATE PAYMENT")
-	mockPayment := createMockPayment(terms)
+	mockPayment := createMockPayment(mockWallet, auth.IdentityKey, terms)
 	fmt.Println("✓ Payment prepared")
 
 	fmt.Println("\n💳 STEP 5: ACCESS PREMIUM (with mockPayment)")
@@ -146,18 +147,44 @@ func requestPremium(wallet wallet.WalletInterface, auth *transport.AuthMessage)
 	return &terms
 }
 
-func createMockPayment(terms *payment.PaymentTerms) *payment.Payment {
+func createMockPayment(payerWallet wallet.WalletInterface, serverIdentityKey string, terms *payment.PaymentTerms) *payment.Payment {
+	// The terms are signed by the server, so a tampered price or derivation prefix is detected before paying
+	if err := terms.VerifySignature(payerWallet, serverIdentityKey); err != nil {
+		log.Fatalf("payment terms are not signed by the server: %s", err)
+	}
+
 	suffix := fmt.Sprintf("client-%d", time.Now().Unix())
-	// In a real scenario, you would construct a valid BSV transaction here
-	// using the details from 'terms' (like outputs, amounts).
-	mockTx := []byte{0x01, 0x02, 0x03, 0x04} // Placeholder transaction bytes
 
-	return &payment.Payment{
+	// The payment output has to pay the key derived from the server identity key with the derivation prefix and suffix
+	lockingScript, err := payment.DerivedLockingScript(payerWallet, serverIdentityKey, terms.DerivationPrefix, suffix, false)
+	if err != nil {
+		log.Fatalf("failed to derive payment locking script: %s", err)
+	}
+
+	// In a real scenario, the wallet would fund and sign the transaction (CreateAction/SignAction),
+	// here the input spends a placeholder transaction and is left unsigned
+	source := transaction.NewTransaction()
+	source.AddOutput(&transaction.TransactionOutput{Satoshis: uint64(terms.SatoshisRequired), LockingScript: lockingScript})
+
+	tx := transaction.NewTransaction()
+	tx.AddInputFromTx(source, 0, nil)
+	tx.AddOutput(&transaction.TransactionOutput{Satoshis: uint64(terms.SatoshisRequired), LockingScript: lockingScript})
+
+	beef, err := tx.AtomicBEEF(false)
+	if err != nil {
+		log.Fatalf("failed to encode payment transaction: %s", err)
+	}
+
+	mockPayment := &payment.Payment{
 		ModeID:           "bsv-direct",
 		DerivationPrefix: terms.DerivationPrefix,
 		DerivationSuffix: suffix,
-		Transaction:      mockTx,
+		Transaction:      beef,
 	}
+	// The signed terms are echoed, so the server can verify which terms are redeemed
+	mockPayment.AttachTerms(*terms)
+
+	return mockPayment
 }
 
 func payPremium(wallet wallet.WalletInterface, auth *transport.AuthMessage, pmt *payment.Payment) {
@@ -11,7 +11,8 @@ import (
 
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/payment"
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
 	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 )
 
@@ -24,11 +25,12 @@ func main() {
 		os.Exit(1)
 	}
 
-	paymentWallet := wallet.NewMockPaymentWallet(key)
+	paymentWallet := wallettest.NewMockPaymentWallet(key)
 	authMiddleware, err := auth.New(auth.Config{
 		AllowUnauthenticated: false,
 		Logger:               logger,
 		Wallet:               paymentWallet,
+		SessionManager:       session.NewMemoryManager(),
 	})
 	if err != nil {
 		logger.Error("create auth middleware failed", slog.String("error", err.Error()))
@@ -0,0 +1,280 @@
+package authcore_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateNonce(t *testing.T) {
+	tests := map[string]struct {
+		nonce string
+		valid bool
+	}{
+		"32 random bytes":    {nonce: base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef")), valid: true},
+		"not base64":         {nonce: "not base64!", valid: false},
+		"too short":          {nonce: base64.StdEncoding.EncodeToString([]byte("short")), valid: false},
+		"too long":           {nonce: base64.StdEncoding.EncodeToString(make([]byte, authcore.MaxNonceSize+1)), valid: false},
+		"repeats one byte":   {nonce: base64.StdEncoding.EncodeToString([]byte(strings.Repeat("a", 32))), valid: false},
+		"empty":              {nonce: "", valid: false},
+		"minimum size bytes": {nonce: base64.StdEncoding.EncodeToString([]byte("0123456789abcdef")), valid: true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// when
+			err := authcore.ValidateNonce(test.nonce, 0)
+
+			// then
+			if test.valid {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, authcore.ErrInvalidNonceFormat)
+				require.ErrorIs(t, err, transport.ErrInvalidNonceFormat)
+			}
+		})
+	}
+}
+
+func TestVerifyGeneralRequest(t *testing.T) {
+	// given
+	serverWallet := wallettest.NewSeededMockWallet(wallettest.Seed, "server")
+	clientWallet := wallettest.NewSeededMockWallet(wallettest.Seed, "client")
+
+	serverKey, err := serverWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+	serverNonce, err := serverWallet.CreateNonce(context.Background())
+	require.NoError(t, err)
+	session := &transport.AuthMessage{IdentityKey: serverKey.PublicKey.ToDERHex(), InitialNonce: serverNonce}
+
+	body := []byte(`{"amount":10}`)
+	headers, err := utils.PrepareGeneralRequestHeaders(clientWallet, session, utils.RequestData{
+		Method:  "POST",
+		URL:     "https://example.com/orders?expand=items",
+		Headers: map[string]string{"Content-Type": "application/json; charset=utf-8", "X-Bsv-Topic": "orders"},
+		Body:    body,
+	})
+	require.NoError(t, err)
+
+	request := func() authcore.GeneralRequest {
+		return authcore.GeneralRequest{
+			IdentityKey: headers["x-bsv-auth-identity-key"],
+			Nonce:       headers["x-bsv-auth-nonce"],
+			YourNonce:   headers["x-bsv-auth-your-nonce"],
+			RequestID:   headers["x-bsv-auth-request-id"],
+			Signature:   headers["x-bsv-auth-signature"],
+			Method:      "POST",
+			Path:        "/orders",
+			Query:       "expand=items",
+			Headers: map[string][]string{
+				"Content-Type": {"application/json; charset=utf-8"},
+				"X-Bsv-Topic":  {"orders"},
+				"Accept":       {"*/*"},
+			},
+			Body: body,
+		}
+	}
+
+	tests := map[string]struct {
+		tamper func(r *authcore.GeneralRequest)
+		err    error
+	}{
+		"signed request is verified": {
+			tamper: func(*authcore.GeneralRequest) {},
+		},
+		"tampered body is rejected": {
+			tamper: func(r *authcore.GeneralRequest) { r.Body = []byte(`{"amount":1}`) },
+			err:    authcore.ErrInvalidSignature,
+		},
+		"tampered signed header is rejected": {
+			tamper: func(r *authcore.GeneralRequest) { r.Headers["X-Bsv-Topic"] = []string{"refunds"} },
+			err:    authcore.ErrInvalidSignature,
+		},
+		"request bound to another origin is rejected": {
+			tamper: func(r *authcore.GeneralRequest) { r.Origin = "https://other.example.com" },
+			err:    authcore.ErrInvalidSignature,
+		},
+		"invalid nonce is rejected": {
+			tamper: func(r *authcore.GeneralRequest) { r.Nonce = "AAAA" },
+			err:    authcore.ErrInvalidNonceFormat,
+		},
+		"invalid request ID is rejected": {
+			tamper: func(r *authcore.GeneralRequest) { r.RequestID = "not base64!" },
+			err:    authcore.ErrInvalidRequestID,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			req := request()
+			test.tamper(&req)
+
+			// when
+			err := authcore.VerifyGeneralRequest(utils.NewSignatureVerifier(serverWallet), req, 0)
+
+			// then
+			if test.err == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestRequestPayload_WithoutBody(t *testing.T) {
+	absent := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	emptyObject := []byte{0x02, 0, 0, 0, 0, 0, 0, 0, '{', '}'}
+
+	tests := map[string]struct {
+		method      string
+		contentType string
+		body        []byte
+		expected    []byte
+	}{
+		"GET is signed with absent body":                       {method: "GET", expected: absent},
+		"HEAD is signed with absent body":                      {method: "HEAD", expected: absent},
+		"DELETE is signed with absent body":                    {method: "DELETE", expected: absent},
+		"GET with JSON content type keeps absent body":         {method: "GET", contentType: "application/json", expected: absent},
+		"DELETE with JSON content type is signed with {}":      {method: "DELETE", contentType: "application/json", expected: emptyObject},
+		"POST with empty JSON body is signed with {}":          {method: "POST", contentType: "application/json", body: []byte{}, expected: emptyObject},
+		"lower case method is defaulted like the TS SDK":       {method: "patch", contentType: "application/json", expected: emptyObject},
+		"POST with empty text body is signed with absent body": {method: "POST", contentType: "text/plain", body: []byte{}, expected: absent},
+		"POST with JSON body is signed with its body":          {method: "POST", contentType: "application/json", body: []byte("[]"), expected: []byte{0x02, 0, 0, 0, 0, 0, 0, 0, '[', ']'}},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			headers := map[string][]string{}
+			if test.contentType != "" {
+				headers["Content-Type"] = []string{test.contentType}
+			}
+
+			// when
+			payload := authcore.RequestPayload{
+				Method:  test.method,
+				Path:    "/items",
+				Headers: authcore.SignedRequestHeaders(headers),
+				Body:    test.body,
+			}.Bytes()
+
+			// then
+			require.Equal(t, test.expected, payload[len(payload)-len(test.expected):])
+		})
+	}
+}
+
+func TestSignedRequestHeaders_WebSocket(t *testing.T) {
+	tests := map[string]struct {
+		headers  map[string][]string
+		expected [][]string
+	}{
+		"upgrade headers are signed": {
+			headers: map[string][]string{
+				"Origin":                 {"https://app.example.com"},
+				"Sec-Websocket-Key":      {"dGhlIHNhbXBsZSBub25jZQ=="},
+				"Sec-Websocket-Protocol": {"chat", "superchat"},
+				"Sec-Websocket-Version":  {"13"},
+				"Upgrade":                {"websocket"},
+				"X-Bsv-Topic":            {"news"},
+			},
+			expected: [][]string{
+				{"origin", "https://app.example.com"},
+				{"sec-websocket-key", "dGhlIHNhbXBsZSBub25jZQ=="},
+				{"sec-websocket-protocol", "chat, superchat"},
+				{"sec-websocket-version", "13"},
+				{"x-bsv-topic", "news"},
+			},
+		},
+		"origin of other requests is not signed": {
+			headers: map[string][]string{
+				"Origin":      {"https://app.example.com"},
+				"X-Bsv-Topic": {"news"},
+			},
+			expected: [][]string{{"x-bsv-topic", "news"}},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// when
+			headers := authcore.SignedRequestHeaders(test.headers)
+
+			// then
+			require.Equal(t, test.expected, headers)
+		})
+	}
+}
+
+func TestResponsePayload_MatchesUtils(t *testing.T) {
+	// given
+	requestID := base64.StdEncoding.EncodeToString([]byte("0123456789abcdef0123456789abcdef"))
+
+	// when
+	expected, err := utils.BuildResponsePayload(requestID, 200, []byte("pong"))
+	require.NoError(t, err)
+	decoded, err := base64.StdEncoding.DecodeString(requestID)
+	require.NoError(t, err)
+	payload := authcore.ResponsePayload{RequestID: decoded, Status: 200, Body: []byte("pong")}.Bytes()
+
+	// then
+	require.Equal(t, expected, payload)
+}
+
+func TestResponsePayload_StreamedBody(t *testing.T) {
+	// given
+	bodyHash := sha256.Sum256([]byte("streamed body"))
+	payload := authcore.ResponsePayload{RequestID: []byte("id"), Status: 200, Body: []byte("ignored"), BodyHash: bodyHash[:]}
+
+	// when
+	data := payload.Bytes()
+
+	// then
+	streamed := append([]byte{0xfe, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, bodyHash[:]...)
+	require.Equal(t, streamed, data[len(data)-len(streamed):])
+	require.NotContains(t, string(data), "ignored")
+}
+
+func TestPackage_Dependencies(t *testing.T) {
+	// given
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not available")
+	}
+
+	// when
+	output, err := exec.Command(goTool, "list", "-deps", ".").Output()
+
+	// then
+	require.NoError(t, err)
+	for _, dependency := range strings.Fields(string(output)) {
+		require.NotEqual(t, "net/http", dependency, "the verification core has to build without net/http")
+		require.False(t, strings.HasPrefix(dependency, "github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"),
+			"the verification core has to build without the wallet implementations, it depends on %s", dependency)
+	}
+}
+
+func TestPackage_BuildsForWASM(t *testing.T) {
+	// given
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not available")
+	}
+	cmd := exec.Command(goTool, "build", "-o", os.DevNull, ".")
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+
+	// when
+	output, err := cmd.CombinedOutput()
+
+	// then
+	require.NoError(t, err, "the verification core has to build for wasip1/wasm: %s", output)
+}
@@ -0,0 +1,184 @@
+package authcore
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// ErrInvalidCanonicalJSON is returned for documents which cannot be encoded as canonical JSON,
+// e.g. invalid JSON or numbers which are not IEEE 754 doubles
+var ErrInvalidCanonicalJSON = errors.New("cannot encode canonical JSON")
+
+// CertificatesPayload returns the payload covered by the signature of a certificateResponse: the canonical JSON
+// encoding of the certificates, so signers in any language produce the same bytes for the same certificates.
+// The certificates are certificates.VerifiableCertificate values, the core takes any type so it depends on the SDK alone.
+func CertificatesPayload[T any](certificates []T) ([]byte, error) {
+	if certificates == nil {
+		certificates = []T{}
+	}
+	return CanonicalJSON(certificates)
+}
+
+// CanonicalJSON returns the canonical JSON encoding of the value, see Canonicalize
+func CanonicalJSON(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("%w, %w", ErrInvalidCanonicalJSON, err)
+	}
+	return Canonicalize(data)
+}
+
+// Canonicalize re-encodes the JSON document in the canonical form of RFC 8785 (JCS): object keys are sorted by their
+// UTF-16 code units, there is no whitespace, strings only escape quotes, backslashes and control characters,
+// and numbers are formatted like ECMAScript Number.prototype.toString, e.g. 1e21, 0.000001 and 1e-7.
+func Canonicalize(data []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, fmt.Errorf("%w, %w", ErrInvalidCanonicalJSON, err)
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w, data after the document", ErrInvalidCanonicalJSON)
+	}
+
+	return appendCanonical(make([]byte, 0, len(data)), value)
+}
+
+func appendCanonical(dst []byte, value any) ([]byte, error) {
+	var err error
+	switch v := value.(type) {
+	case nil:
+		dst = append(dst, "null"...)
+	case bool:
+		dst = strconv.AppendBool(dst, v)
+	case json.Number:
+		dst, err = appendCanonicalNumber(dst, v)
+	case string:
+		dst = appendCanonicalString(dst, v)
+	case []any:
+		dst = append(dst, '[')
+		for i, element := range v {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			if dst, err = appendCanonical(dst, element); err != nil {
+				return dst, err
+			}
+		}
+		dst = append(dst, ']')
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(i, j int) bool { return lessUTF16(keys[i], keys[j]) })
+
+		dst = append(dst, '{')
+		for i, key := range keys {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendCanonicalString(dst, key)
+			dst = append(dst, ':')
+			if dst, err = appendCanonical(dst, v[key]); err != nil {
+				return dst, err
+			}
+		}
+		dst = append(dst, '}')
+	default:
+		return dst, fmt.Errorf("%w, unexpected value of type %T", ErrInvalidCanonicalJSON, value)
+	}
+	return dst, err
+}
+
+// lessUTF16 orders the strings by their UTF-16 code units, which differs from the byte order of UTF-8
+// for characters above U+FFFF
+func lessUTF16(a, b string) bool {
+	return slices.Compare(utf16.Encode([]rune(a)), utf16.Encode([]rune(b))) < 0
+}
+
+// appendCanonicalNumber appends the number in the shortest form which round trips, formatted like ECMAScript:
+// integers below 1e21 without exponent, fractions down to 1e-6 with leading zeros, other numbers with an exponent
+func appendCanonicalNumber(dst []byte, number json.Number) ([]byte, error) {
+	f, err := strconv.ParseFloat(string(number), 64)
+	if err != nil {
+		return dst, fmt.Errorf("%w, number %s is not an IEEE 754 double", ErrInvalidCanonicalJSON, number)
+	}
+	if f == 0 {
+		return append(dst, '0'), nil
+	}
+	if f < 0 {
+		dst = append(dst, '-')
+		f = -f
+	}
+
+	// digits are the shortest decimal digits of the number, point is the position of the decimal point after them
+	mantissa, exponent, _ := strings.Cut(strconv.FormatFloat(f, 'e', -1, 64), "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	e, _ := strconv.Atoi(exponent)
+	point := e + 1
+
+	switch {
+	case len(digits) <= point && point <= 21:
+		dst = append(dst, digits...)
+		dst = append(dst, strings.Repeat("0", point-len(digits))...)
+	case 0 < point && point <= 21:
+		dst = append(dst, digits[:point]...)
+		dst = append(dst, '.')
+		dst = append(dst, digits[point:]...)
+	case -6 < point && point <= 0:
+		dst = append(dst, "0."...)
+		dst = append(dst, strings.Repeat("0", -point)...)
+		dst = append(dst, digits...)
+	default:
+		dst = append(dst, digits[0])
+		if len(digits) > 1 {
+			dst = append(dst, '.')
+			dst = append(dst, digits[1:]...)
+		}
+		dst = append(dst, 'e')
+		if point-1 > 0 {
+			dst = append(dst, '+')
+		}
+		dst = strconv.AppendInt(dst, int64(point-1), 10)
+	}
+	return dst, nil
+}
+
+// appendCanonicalString appends the string quoted, escaping only quotes, backslashes and control characters
+func appendCanonicalString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	for i := 0; i < len(s); i++ {
+		switch b := s[i]; {
+		case b == '"' || b == '\\':
+			dst = append(dst, '\\', b)
+		case b == '\b':
+			dst = append(dst, '\\', 'b')
+		case b == '\f':
+			dst = append(dst, '\\', 'f')
+		case b == '\n':
+			dst = append(dst, '\\', 'n')
+		case b == '\r':
+			dst = append(dst, '\\', 'r')
+		case b == '\t':
+			dst = append(dst, '\\', 't')
+		case b < 0x20:
+			dst = append(dst, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+		default:
+			dst = append(dst, b)
+		}
+	}
+	return append(dst, '"')
+}
+
+const hexDigits = "0123456789abcdef"
@@ -0,0 +1,111 @@
+package authcore_test
+
+import (
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/fixtures"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := map[string]struct {
+		input     string
+		canonical string
+	}{
+		"whitespace is removed":          {input: "{ \"a\" : [ 1 , true ] ,\n\"b\" : null }", canonical: `{"a":[1,true],"b":null}`},
+		"keys are sorted":                {input: `{"b":{"d":1,"c":2},"a":[]}`, canonical: `{"a":[],"b":{"c":2,"d":1}}`},
+		"keys are sorted by utf-16":      {input: `{"דּ":1,"😀":2,"€":3}`, canonical: "{\"€\":3,\"\U0001F600\":2,\"דּ\":1}"},
+		"integers":                       {input: `[0,-0,1.0,-42,9007199254740993]`, canonical: `[0,0,1,-42,9007199254740992]`},
+		"large numbers use exponents":    {input: `[1e20,1e21,1.7976931348623157e308]`, canonical: `[100000000000000000000,1e+21,1.7976931348623157e+308]`},
+		"small numbers use exponents":    {input: `[0.000001,1e-7,5e-324,-1.5e-10]`, canonical: `[0.000001,1e-7,5e-324,-1.5e-10]`},
+		"fractions are shortest":         {input: `[9.50,0.1,333333333.33333329]`, canonical: `[9.5,0.1,333333333.3333333]`},
+		"html is not escaped":            {input: `"<a href=\"x\">&</a>"`, canonical: `"<a href=\"x\">&</a>"`},
+		"unicode is not escaped":         {input: `"é \/"`, canonical: "\"é /\""},
+		"control characters are escaped": {input: `"\u0001\b\t\n\f\r\u001f"`, canonical: `"\u0001\b\t\n\f\r\u001f"`},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// when
+			canonical, err := authcore.Canonicalize([]byte(test.input))
+
+			// then
+			require.NoError(t, err)
+			require.Equal(t, test.canonical, string(canonical))
+		})
+	}
+}
+
+func TestCanonicalize_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"invalid json":            `{"a":`,
+		"data after the document": `{} {}`,
+		"number out of range":     `1e400`,
+	}
+
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			// when
+			_, err := authcore.Canonicalize([]byte(input))
+
+			// then
+			require.ErrorIs(t, err, authcore.ErrInvalidCanonicalJSON)
+		})
+	}
+}
+
+func TestCertificatesPayload(t *testing.T) {
+	t.Run("payload of the golden certificates", func(t *testing.T) {
+		// given
+		golden, err := fixtures.Load()
+		require.NoError(t, err)
+
+		// when
+		payload, err := authcore.CertificatesPayload(golden.Certificates)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, golden.CertificatesPayload, string(payload))
+	})
+
+	t.Run("golden canonical JSON vectors", func(t *testing.T) {
+		// given
+		golden, err := fixtures.Load()
+		require.NoError(t, err)
+		require.NotEmpty(t, golden.CanonicalJSON)
+
+		for _, vector := range golden.CanonicalJSON {
+			// when
+			canonical, err := authcore.Canonicalize([]byte(vector.Input))
+
+			// then
+			require.NoError(t, err, vector.Name)
+			require.Equal(t, vector.Canonical, string(canonical), vector.Name)
+		}
+	})
+
+	t.Run("fields are encoded independently of their order", func(t *testing.T) {
+		// given
+		certificate := func(fields map[string]any) []certificates.VerifiableCertificate {
+			return []certificates.VerifiableCertificate{{Certificate: certificates.Certificate{Type: "dGVzdA==", Fields: fields}}}
+		}
+
+		// when
+		payload, err := authcore.CertificatesPayload(certificate(map[string]any{"score": 9.5, "age": "21"}))
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, `[{"certifier":"","fields":{"age":"21","score":9.5},"keyring":null,"revocationOutpoint":"","serialNumber":"","signature":"","subject":"","type":"dGVzdA=="}]`, string(payload))
+	})
+
+	t.Run("nil certificates are an empty array", func(t *testing.T) {
+		// when
+		payload, err := authcore.CertificatesPayload[certificates.VerifiableCertificate](nil)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, "[]", string(payload))
+	})
+}
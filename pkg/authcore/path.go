@@ -0,0 +1,50 @@
+package authcore
+
+import (
+	"path"
+	"strings"
+)
+
+// PathNormalization defines how the path of a general request is normalized before it is signed and verified.
+// Proxies and frameworks often rewrite paths on their way to the server, e.g. collapse duplicate slashes or
+// redirect to the path without a trailing slash. A path signed by the client and verified as received by the server
+// then differs and the signature is rejected, normalizing the path on both sides keeps such requests verifiable.
+//
+// The path signed for each mode:
+//
+//	path as sent     PathStrict       PathLenient
+//	/a/b             /a/b             /a/b
+//	/a/b/            /a/b/            /a/b
+//	/a//b            /a//b            /a/b
+//	/a/./b/../c      /a/./b/../c      /a/c
+//	/..              /..              /
+//	(empty)          (empty)          /
+//	*                *                *
+//
+// Both modes sign the canonical percent-encoding of the path, see CanonicalPath, PathLenient resolves dot segments
+// after encoded dots are decoded. The path is normalized for the signature only, the request is routed with the path
+// it was sent with. The query and the case of the path are never normalized.
+type PathNormalization int
+
+const (
+	// PathStrict signs the path as it is sent, a request whose path is rewritten on its way to the server is rejected
+	PathStrict PathNormalization = iota
+	// PathLenient collapses duplicate slashes, resolves dot segments and drops a trailing slash,
+	// so rewrites between equivalent paths are not signature mismatches
+	PathLenient
+)
+
+// NormalizePath returns the path signed for the normalization, see PathNormalization
+func NormalizePath(p string, normalization PathNormalization) string {
+	if normalization != PathLenient {
+		return p
+	}
+	if p == "" {
+		return "/"
+	}
+	if !strings.HasPrefix(p, "/") {
+		// asterisk-form and other paths which are not absolute are signed as they are
+		return p
+	}
+	return path.Clean(CanonicalPath(p))
+}
@@ -0,0 +1,34 @@
+package authcore_test
+
+import (
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePath(t *testing.T) {
+	tests := map[string]struct {
+		path    string
+		strict  string
+		lenient string
+	}{
+		"normal path":          {path: "/a/b", strict: "/a/b", lenient: "/a/b"},
+		"root":                 {path: "/", strict: "/", lenient: "/"},
+		"trailing slash":       {path: "/a/b/", strict: "/a/b/", lenient: "/a/b"},
+		"duplicate slashes":    {path: "//a///b", strict: "//a///b", lenient: "/a/b"},
+		"dot segments":         {path: "/a/./b/../c", strict: "/a/./b/../c", lenient: "/a/c"},
+		"dot segments of root": {path: "/../a", strict: "/../a", lenient: "/a"},
+		"empty path":           {path: "", strict: "", lenient: "/"},
+		"asterisk-form":        {path: "*", strict: "*", lenient: "*"},
+		"case is kept":         {path: "/A//b/", strict: "/A//b/", lenient: "/A/b"},
+		"encoded dot segments": {path: "/a/%2e%2E/b", strict: "/a/%2e%2E/b", lenient: "/b"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.strict, authcore.NormalizePath(test.path, authcore.PathStrict))
+			require.Equal(t, test.lenient, authcore.NormalizePath(test.path, authcore.PathLenient))
+		})
+	}
+}
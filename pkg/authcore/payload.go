@@ -0,0 +1,207 @@
+// Package authcore is the verification core of the HTTP auth protocol: building the signed payloads,
+// validating nonces and checking signatures. It does not depend on net/http, so it builds for WebAssembly
+// with TinyGo or GOOS=wasip1, e.g. to verify requests at the edge in Cloudflare Workers.
+// The HTTP transport and the utils package build on it.
+//
+// Heavier subsystems of the transport are gated behind build tags for size constrained builds:
+// nox509 excludes the X.509 bridge and nocredentials the verifiable credential adapter,
+// which then reject the client certificates and credentials they would otherwise accept.
+package authcore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// AbsentLength is written instead of the length of an absent query, absent response headers or an absent body.
+// An empty body is absent, so a GET request without body and a POST request with an empty body are signed alike.
+const AbsentLength = -1
+
+// StreamedLength is written instead of the length of a response body streamed with its signature in a trailer,
+// it is followed by the SHA-256 hash of the body, see ResponsePayload.BodyHash
+const StreamedLength = -2
+
+// WriteVarInt writes the number as the little endian int64 used for lengths in the signed payloads,
+// AbsentLength marks an absent value
+func WriteVarInt(buf *bytes.Buffer, num int) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], uint64(int64(num))) //nolint:gosec // negative lengths are encoded in two's complement
+	buf.Write(b[:])
+}
+
+// RequestPayload is the part of a general request covered by the signature of the peer
+type RequestPayload struct {
+	// RequestID is the decoded request ID, it prefixes the payload
+	RequestID []byte
+	Method    string
+	// Path is the path as sent, it is written in its canonical percent-encoding, see CanonicalPath
+	Path string
+	// Query is the raw query without the question mark, it is written in its canonical percent-encoding
+	Query string
+	// Headers are the signed headers as returned by SignedRequestHeaders
+	Headers [][]string
+	Body    []byte
+}
+
+// WriteTo writes the payload into the buffer
+func (p RequestPayload) WriteTo(buf *bytes.Buffer) {
+	buf.Write(p.RequestID)
+
+	WriteVarInt(buf, len(p.Method))
+	buf.WriteString(p.Method)
+
+	path := CanonicalPath(p.Path)
+	WriteVarInt(buf, len(path))
+	buf.WriteString(path)
+
+	if len(p.Query) > 0 {
+		query := CanonicalQuery(p.Query)
+		WriteVarInt(buf, len(query))
+		buf.WriteString(query)
+	} else {
+		WriteVarInt(buf, AbsentLength)
+	}
+
+	WriteVarInt(buf, len(p.Headers))
+	for _, header := range p.Headers {
+		WriteVarInt(buf, len(header[0]))
+		buf.WriteString(header[0])
+		WriteVarInt(buf, len(header[1]))
+		buf.WriteString(header[1])
+	}
+
+	body := p.Body
+	if len(body) == 0 {
+		body = DefaultRequestBody(p.Method, signedHeader(p.Headers, "content-type"))
+	}
+	writeBody(buf, body)
+}
+
+// Bytes returns the payload
+func (p RequestPayload) Bytes() []byte {
+	var buf bytes.Buffer
+	p.WriteTo(&buf)
+	return buf.Bytes()
+}
+
+// ResponsePayload is the part of a general response covered by the signature of the server
+type ResponsePayload struct {
+	// RequestID is the decoded ID of the answered request, it prefixes the payload
+	RequestID []byte
+	Status    int
+	// Headers are the signed response headers, sorted by name
+	Headers [][]string
+	Body    []byte
+	// BodyHash is the SHA-256 hash of a streamed body, it is signed instead of Body when set
+	BodyHash []byte
+}
+
+// Bytes returns the payload
+func (p ResponsePayload) Bytes() []byte {
+	var buf bytes.Buffer
+	buf.Write(p.RequestID)
+
+	WriteVarInt(&buf, p.Status)
+
+	if len(p.Headers) > 0 {
+		WriteVarInt(&buf, len(p.Headers))
+		for _, header := range p.Headers {
+			WriteVarInt(&buf, len(header[0]))
+			buf.WriteString(header[0])
+			WriteVarInt(&buf, len(header[1]))
+			buf.WriteString(header[1])
+		}
+	} else {
+		WriteVarInt(&buf, AbsentLength)
+	}
+
+	if p.BodyHash != nil {
+		WriteVarInt(&buf, StreamedLength)
+		buf.Write(p.BodyHash)
+	} else {
+		writeBody(&buf, p.Body)
+	}
+	return buf.Bytes()
+}
+
+func writeBody(buf *bytes.Buffer, body []byte) {
+	if len(body) == 0 {
+		WriteVarInt(buf, AbsentLength)
+		return
+	}
+	WriteVarInt(buf, len(body))
+	buf.Write(body)
+}
+
+// DefaultRequestBody returns the body signed for a request sent without body. Like the TS SDK, POST, PUT, PATCH
+// and DELETE requests with a JSON content type are signed with the empty object "{}", so requests of TS clients
+// verify byte for byte. Every other request without body is signed with an absent body, nil is returned for them.
+// The default is only signed, the request is still sent without body.
+func DefaultRequestBody(method, contentType string) []byte {
+	switch strings.ToUpper(method) {
+	case "POST", "PUT", "PATCH", "DELETE":
+		if strings.Contains(contentType, "application/json") {
+			return []byte("{}")
+		}
+	}
+	return nil
+}
+
+// signedHeader returns the value of the signed header with the lower case name
+func signedHeader(headers [][]string, name string) string {
+	for _, header := range headers {
+		if header[0] == name {
+			return header[1]
+		}
+	}
+	return ""
+}
+
+// WebSocketSignedHeaders are the headers of WebSocket upgrade requests included in the signed payload besides the
+// headers of every request, so the signature binds the socket to the origin of the page, the offered subprotocols
+// and the key of the upgrade, and a valid session cannot be reused for a socket opened from another origin.
+// Their values are signed joined with ", " when a header is repeated.
+var WebSocketSignedHeaders = []string{"origin", "sec-websocket-key", "sec-websocket-protocol", "sec-websocket-version"}
+
+// SignedRequestHeaders returns the request headers included in the signed payload, sorted by name like the TS SDK does.
+// The content-type is included without its parameters, as clients and proxies may rewrite them (e.g. the charset),
+// the boundary of multipart bodies is signed with the body. Requests carrying a sec-websocket-key header are
+// WebSocket upgrades, their WebSocketSignedHeaders are included as well. An http.Header can be passed as is.
+func SignedRequestHeaders(headers map[string][]string) [][]string {
+	upgrade := false
+	for k := range headers {
+		if strings.EqualFold(k, "sec-websocket-key") {
+			upgrade = true
+		}
+	}
+
+	var includedHeaders [][]string
+	for k, v := range headers {
+		k = strings.ToLower(k)
+		switch {
+		case (strings.HasPrefix(k, "x-bsv-") || k == "content-type" || k == "authorization") &&
+			!strings.HasPrefix(k, "x-bsv-auth"):
+			value := v[0]
+			if k == "content-type" {
+				value = NormalizeContentType(value)
+			}
+			includedHeaders = append(includedHeaders, []string{k, value})
+		case upgrade && slices.Contains(WebSocketSignedHeaders, k):
+			includedHeaders = append(includedHeaders, []string{k, strings.Join(v, ", ")})
+		}
+	}
+	sort.Slice(includedHeaders, func(i, j int) bool {
+		return includedHeaders[i][0] < includedHeaders[j][0]
+	})
+	return includedHeaders
+}
+
+// NormalizeContentType returns the media type of the content-type header value without its parameters,
+// e.g. "multipart/form-data" for "multipart/form-data; boundary=X"
+func NormalizeContentType(value string) string {
+	mediaType, _, _ := strings.Cut(value, ";")
+	return strings.TrimSpace(mediaType)
+}
@@ -0,0 +1,89 @@
+package authcore
+
+import "strings"
+
+// CanonicalPath returns the canonical percent-encoding of the path. The path and the query of a general request
+// are signed in their canonical percent-encoding, so peers and intermediaries encoding the same URL differently
+// produce the same payload:
+//
+//   - percent-encoded unreserved characters (letters, digits, "-", ".", "_" and "~") are decoded, e.g. "%7E" is signed as "~"
+//   - other percent-encoded octets stay encoded with upper-case hex digits, e.g. "%2f" is signed as "%2F", so an
+//     encoded delimiter is never signed like the delimiter itself: "/a%2Fb" and "/a/b" are different paths
+//   - characters which are not allowed literally in the component are encoded as their UTF-8 octets with
+//     upper-case hex digits, e.g. a space as "%20", "é" as "%C3%A9" and "😀" as "%F0%9F%98%80"
+//   - a "%" which does not start an encoded octet is encoded as "%25"
+//   - "+" in the query is a literal plus, it is not decoded as a space
+//   - UTF-8 is not normalized to NFC or any other form: precomposed and decomposed characters may name different
+//     resources and are signed as sent, invalid UTF-8 is encoded octet by octet
+//
+// Paths allow unreserved characters, the sub-delimiters "!$&'()*+,;=", ":", "@" and "/" literally,
+// queries also allow "?". Builders of the payload pass the path as sent, e.g. url.URL.EscapedPath, not the decoded path.
+func CanonicalPath(p string) string {
+	return canonicalEncoding(p, false)
+}
+
+// CanonicalQuery returns the canonical percent-encoding of the raw query without the question mark, see CanonicalPath
+func CanonicalQuery(q string) string {
+	return canonicalEncoding(q, true)
+}
+
+const upperHex = "0123456789ABCDEF"
+
+func canonicalEncoding(s string, query bool) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]) {
+			decoded := unhex(s[i+1])<<4 | unhex(s[i+2])
+			if isUnreserved(decoded) {
+				b.WriteByte(decoded)
+			} else {
+				writeEncoded(&b, decoded)
+			}
+			i += 2
+			continue
+		}
+		if c != '%' && (isUnreserved(c) || isAllowedLiterally(c, query)) {
+			b.WriteByte(c)
+			continue
+		}
+		writeEncoded(&b, c)
+	}
+	return b.String()
+}
+
+func writeEncoded(b *strings.Builder, c byte) {
+	b.WriteByte('%')
+	b.WriteByte(upperHex[c>>4])
+	b.WriteByte(upperHex[c&0x0f])
+}
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func isAllowedLiterally(c byte, query bool) bool {
+	switch c {
+	case '!', '$', '&', '\'', '(', ')', '*', '+', ',', ';', '=', ':', '@', '/':
+		return true
+	case '?':
+		return query
+	}
+	return false
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
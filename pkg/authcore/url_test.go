@@ -0,0 +1,67 @@
+package authcore_test
+
+import (
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalPath(t *testing.T) {
+	tests := map[string]struct {
+		path      string
+		canonical string
+	}{
+		"plain path":                           {path: "/items/1", canonical: "/items/1"},
+		"encoded unreserved characters":        {path: "/%7Euser/%41%2d%5f", canonical: "/~user/A-_"},
+		"lower-case hex digits":                {path: "/caf%c3%a9", canonical: "/caf%C3%A9"},
+		"encoded delimiters stay encoded":      {path: "/a%2fb%3Fc%23d", canonical: "/a%2Fb%3Fc%23d"},
+		"space":                                {path: "/a b", canonical: "/a%20b"},
+		"non-ASCII characters":                 {path: "/caf\u00e9", canonical: "/caf%C3%A9"},
+		"emoji":                                {path: "/😀", canonical: "/%F0%9F%98%80"},
+		"encoded emoji":                        {path: "/%f0%9f%98%80", canonical: "/%F0%9F%98%80"},
+		"decomposed characters are kept":       {path: "/cafe\u0301", canonical: "/cafe%CC%81"},
+		"sub-delimiters":                       {path: "/a!$&'()*+,;=:@b", canonical: "/a!$&'()*+,;=:@b"},
+		"characters not allowed in paths":      {path: "/a?b#c[d]\"<>\\^`{|}", canonical: "/a%3Fb%23c%5Bd%5D%22%3C%3E%5C%5E%60%7B%7C%7D"},
+		"percent sign which is not an octet":   {path: "/100%/a%zz/%4", canonical: "/100%25/a%25zz/%254"},
+		"invalid UTF-8 is encoded per octet":   {path: "/\xff\xfe", canonical: "/%FF%FE"},
+		"canonical path is kept":               {path: "/%F0%9F%98%80/%2F", canonical: "/%F0%9F%98%80/%2F"},
+		"control characters":                   {path: "/a\tb\x00", canonical: "/a%09b%00"},
+		"encoded percent sign stays encoded":   {path: "/%25", canonical: "/%25"},
+		"encoded dots are decoded, not solved": {path: "/a/%2E%2E/b", canonical: "/a/../b"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// when
+			canonical := authcore.CanonicalPath(test.path)
+
+			// then
+			require.Equal(t, test.canonical, canonical)
+			require.Equal(t, canonical, authcore.CanonicalPath(canonical))
+		})
+	}
+}
+
+func TestCanonicalQuery(t *testing.T) {
+	tests := map[string]struct {
+		query     string
+		canonical string
+	}{
+		"plain query":                 {query: "expand=all&limit=10", canonical: "expand=all&limit=10"},
+		"plus is a literal plus":      {query: "q=a+b", canonical: "q=a+b"},
+		"encoded space stays encoded": {query: "q=a%20b", canonical: "q=a%20b"},
+		"space":                       {query: "q=a b", canonical: "q=a%20b"},
+		"encoded delimiters":          {query: "q=a%26b%3dc", canonical: "q=a%26b%3Dc"},
+		"question mark and slash":     {query: "next=/a?b", canonical: "next=/a?b"},
+		"emoji":                       {query: "mood=😀", canonical: "mood=%F0%9F%98%80"},
+		"encoded unreserved":          {query: "a=%61%62%7e", canonical: "a=ab~"},
+		"fragment delimiter":          {query: "a=b#c", canonical: "a=b%23c"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.canonical, authcore.CanonicalQuery(test.query))
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package authcore
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// Limits of peer nonces
+const (
+	// MaxNonceSize is the maximum size of a decoded nonce
+	MaxNonceSize = 256
+	// DefaultMinNonceSize is the minimum size of a decoded peer nonce when no minimum is configured,
+	// nonces of the TS and Go SDKs are 32 bytes
+	DefaultMinNonceSize = 16
+)
+
+// DirectHashThreshold is the size above which signed payloads are hashed once before the wallet is called,
+// they are passed as HashToDirectlySign and HashToDirectlyVerify so large bodies are not copied into the calls
+// of remote wallets. The signatures are the same, wallets hash Data with SHA-256 themselves.
+const DirectHashThreshold = 64 << 10
+
+// Errors of the verification, the transport package reports them with their error codes
+var (
+	ErrInvalidNonceFormat = errors.New("invalid nonce format")
+	ErrInvalidSignature   = errors.New("unable to verify signature")
+	ErrInvalidRequestID   = errors.New("invalid request ID")
+)
+
+// ValidateNonce checks the peer nonce is base64 encoded, its decoded size is within minSize and MaxNonceSize,
+// and it is not degenerate, i.e. a repetition of a single byte. A minSize of zero uses DefaultMinNonceSize.
+func ValidateNonce(nonce string, minSize int) error {
+	if minSize <= 0 {
+		minSize = DefaultMinNonceSize
+	}
+
+	if base64.StdEncoding.DecodedLen(len(nonce)) > MaxNonceSize {
+		return fmt.Errorf("%w: longer than %d bytes", ErrInvalidNonceFormat, MaxNonceSize)
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(nonce)
+	if err != nil {
+		return fmt.Errorf("%w: not base64", ErrInvalidNonceFormat)
+	}
+
+	if len(decoded) < minSize {
+		return fmt.Errorf("%w: shorter than %d bytes", ErrInvalidNonceFormat, minSize)
+	}
+
+	if bytes.Count(decoded, decoded[:1]) == len(decoded) {
+		return fmt.Errorf("%w: repeats a single byte", ErrInvalidNonceFormat)
+	}
+
+	return nil
+}
+
+// GeneralKeyID returns the key ID of the signature of a general request. A non empty origin is appended,
+// so a signature bound to one server origin cannot be replayed against another origin sharing the identity key.
+func GeneralKeyID(nonce, yourNonce, origin string) string {
+	if origin == "" {
+		return fmt.Sprintf("%s %s", nonce, yourNonce)
+	}
+	return fmt.Sprintf("%s %s %s", nonce, yourNonce, origin)
+}
+
+// SignatureVerifier verifies a signature of the auth protocol the counterparty created over data, with the key derived
+// for the key ID from the identity key of the server. It only takes key types of the SDK, so the core builds without
+// the wallet implementations, utils.NewSignatureVerifier adapts a wallet.
+type SignatureVerifier interface {
+	VerifyAuthSignature(counterparty *ec.PublicKey, keyID string, data []byte, signature *ec.Signature) (bool, error)
+}
+
+// SignatureVerifierFunc is a function implementing SignatureVerifier
+type SignatureVerifierFunc func(counterparty *ec.PublicKey, keyID string, data []byte, signature *ec.Signature) (bool, error)
+
+// VerifyAuthSignature calls f
+func (f SignatureVerifierFunc) VerifyAuthSignature(counterparty *ec.PublicKey, keyID string, data []byte, signature *ec.Signature) (bool, error) {
+	return f(counterparty, keyID, data, signature)
+}
+
+// GeneralRequest is a general request as carried by the auth headers and the request itself
+type GeneralRequest struct {
+	// IdentityKey, Nonce, YourNonce, RequestID and Signature are the values of the x-bsv-auth headers,
+	// the request ID is base64 and the signature hex encoded
+	IdentityKey string
+	Nonce       string
+	YourNonce   string
+	RequestID   string
+	Signature   string
+	// Origin is the value of the x-bsv-auth-origin header, empty for requests not bound to an origin
+	Origin string
+	Method string
+	// Path is the path as sent, e.g. url.URL.EscapedPath, and Query the raw query
+	Path  string
+	Query string
+	// PathNormalization is the normalization of the path agreed with the client, see PathNormalization
+	PathNormalization PathNormalization
+	// Headers are all headers of the request, the signed ones are selected with SignedRequestHeaders
+	Headers map[string][]string
+	Body    []byte
+}
+
+// Payload returns the payload covered by the signature of the request
+func (r GeneralRequest) Payload() (RequestPayload, error) {
+	requestID, err := base64.StdEncoding.DecodeString(r.RequestID)
+	if err != nil {
+		return RequestPayload{}, fmt.Errorf("%w, %w", ErrInvalidRequestID, err)
+	}
+
+	return RequestPayload{
+		RequestID: requestID,
+		Method:    r.Method,
+		Path:      NormalizePath(r.Path, r.PathNormalization),
+		Query:     r.Query,
+		Headers:   SignedRequestHeaders(r.Headers),
+		Body:      r.Body,
+	}, nil
+}
+
+// VerifyGeneralRequest checks the format of the nonces and the signature of the request, minNonceSize as for ValidateNonce.
+// It does not check that YourNonce was created by the server and belongs to a session of the peer,
+// which needs the nonce verification of the server wallet and the session store.
+func VerifyGeneralRequest(verifier SignatureVerifier, req GeneralRequest, minNonceSize int) error {
+	if err := ValidateNonce(req.Nonce, minNonceSize); err != nil {
+		return err
+	}
+	if err := ValidateNonce(req.YourNonce, minNonceSize); err != nil {
+		return err
+	}
+
+	payload, err := req.Payload()
+	if err != nil {
+		return err
+	}
+
+	return VerifySignature(verifier, req.IdentityKey, GeneralKeyID(req.Nonce, req.YourNonce, req.Origin), payload.Bytes(), req.Signature)
+}
+
+// VerifySignature verifies the hex encoded signature of the auth protocol the peer with the identity key created over data
+func VerifySignature(verifier SignatureVerifier, identityKey, keyID string, data []byte, signatureHex string) error {
+	key, err := ec.PublicKeyFromString(identityKey)
+	if err != nil {
+		return fmt.Errorf("%w, failed to parse identity key, %w", ErrInvalidSignature, err)
+	}
+
+	der, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		return fmt.Errorf("%w, signature is not hex encoded", ErrInvalidSignature)
+	}
+	signature, err := ec.ParseSignature(der)
+	if err != nil {
+		return fmt.Errorf("%w, failed to parse signature, %w", ErrInvalidSignature, err)
+	}
+
+	valid, err := verifier.VerifyAuthSignature(key, keyID, data, signature)
+	if err != nil {
+		return fmt.Errorf("%w, %w", ErrInvalidSignature, err)
+	}
+	if !valid {
+		return fmt.Errorf("%w, signature is not valid", ErrInvalidSignature)
+	}
+	return nil
+}
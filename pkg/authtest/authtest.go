@@ -0,0 +1,310 @@
+// Package authtest provides an in-process BSV auth server for tests of clients, like net/http/httptest does for HTTP.
+//
+// The server runs the auth middleware with deterministic wallets, so tests of downstream projects exercise
+// the real handshake, signatures and certificate exchange without copying the test mocks of this module:
+//
+//	server := authtest.NewServer(t, authtest.Options{})
+//	authClient, err := client.New(client.Config{Wallet: myWallet, BaseURL: server.URL})
+//
+// NewRequest and NewInvalidRequest mint requests signed by the client wallet of the server,
+// for tests of proxies and handlers which receive authenticated requests.
+package authtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+)
+
+// DefaultSeed derives the identities of servers without a configured seed
+const DefaultSeed = "authtest"
+
+// Names of the identities derived from the seed, see wallettest.SeededPrivateKey
+const (
+	ServerName    = "server"
+	ClientName    = "client"
+	CertifierName = "certifier"
+)
+
+// Flaw is a defect of a request minted by NewInvalidRequest
+type Flaw string
+
+// Flaws of invalid requests and the error codes the server rejects them with
+const (
+	// FlawUnsigned sends the request without auth headers, rejected with ERR_MISSING_REQUEST_ID
+	FlawUnsigned Flaw = "unsigned"
+	// FlawSignature replaces the signature with the signature of another request, rejected with ERR_INVALID_SIGNATURE
+	FlawSignature Flaw = "signature"
+	// FlawTamperedBody changes the body after the request was signed, rejected with ERR_INVALID_SIGNATURE
+	FlawTamperedBody Flaw = "tampered-body"
+	// FlawUnknownSession signs the request for a nonce of the server without a session, rejected with ERR_SESSION_NOT_FOUND
+	FlawUnknownSession Flaw = "unknown-session"
+	// FlawVersion declares an unsupported auth protocol version, rejected with ERR_UNSUPPORTED_VERSION
+	FlawVersion Flaw = "version"
+)
+
+// Options configures the test server
+type Options struct {
+	// Seed derives the keys and nonces of the server, client and certifier identities, defaults to DefaultSeed
+	Seed string
+	// Handler serves the authenticated requests, defaults to a handler responding with the identity key of the peer
+	Handler http.Handler
+	// CertificatesToRequest are requested from peers in the handshake, requests of peers which did not send them are rejected
+	CertificatesToRequest *transport.RequestedCertificateSet
+	// OnCertificatesReceived is called with the certificates of peers, defaults to accepting every certificate
+	OnCertificatesReceived transport.OnCertificatesReceivedFunc
+	// Configure adjusts the configuration of the auth middleware before the server is started
+	Configure func(cfg *auth.Config)
+}
+
+// Server is an httptest server running the auth middleware, it is closed when the test finishes
+type Server struct {
+	*httptest.Server
+	// Middleware is the auth middleware of the server
+	Middleware *auth.Middleware
+	// Wallet is the wallet of the server identity
+	Wallet wallet.WalletInterface
+	// ClientWallet is the wallet of the client identity signing the requests of NewRequest
+	ClientWallet wallet.WalletInterface
+
+	t                     testing.TB
+	seed                  string
+	certificatesToRequest *transport.RequestedCertificateSet
+
+	mu      sync.Mutex
+	session *transport.AuthMessage
+}
+
+// NewServer starts a test server, failing the test when the middleware cannot be created
+func NewServer(t testing.TB, opts Options) *Server {
+	t.Helper()
+
+	if opts.Seed == "" {
+		opts.Seed = DefaultSeed
+	}
+	if opts.Handler == nil {
+		opts.Handler = http.HandlerFunc(identityHandler)
+	}
+	if opts.CertificatesToRequest != nil && opts.OnCertificatesReceived == nil {
+		opts.OnCertificatesReceived = func(_ string, _ *[]certificates.VerifiableCertificate, _ *http.Request, _ http.ResponseWriter, next func()) {
+			next()
+		}
+	}
+
+	s := &Server{
+		Wallet:                wallettest.NewSeededMockWallet(opts.Seed, ServerName),
+		ClientWallet:          wallettest.NewSeededMockWallet(opts.Seed, ClientName),
+		t:                     t,
+		seed:                  opts.Seed,
+		certificatesToRequest: opts.CertificatesToRequest,
+	}
+
+	cfg := auth.Config{
+		Logger:                 slog.New(slog.DiscardHandler),
+		Wallet:                 s.Wallet,
+		SessionManager:         session.NewMemoryManager(),
+		CertificatesToRequest:  opts.CertificatesToRequest,
+		OnCertificatesReceived: opts.OnCertificatesReceived,
+	}
+	if opts.Configure != nil {
+		opts.Configure(&cfg)
+	}
+
+	middleware, err := auth.New(cfg)
+	if err != nil {
+		t.Fatalf("authtest: failed to create auth middleware: %v", err)
+	}
+	s.Middleware = middleware
+
+	s.Server = httptest.NewServer(middleware.Handler(opts.Handler))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// IdentityKey returns the identity key of the server
+func (s *Server) IdentityKey() string {
+	return wallettest.SeededPrivateKey(s.seed, ServerName).PubKey().ToDERHex()
+}
+
+// ClientIdentityKey returns the identity key of the client wallet
+func (s *Server) ClientIdentityKey() string {
+	return wallettest.SeededPrivateKey(s.seed, ClientName).PubKey().ToDERHex()
+}
+
+// CertifierIdentityKey returns the identity key of the certifier of the certificates of ClientCertificate
+func (s *Server) CertifierIdentityKey() string {
+	return wallettest.SeededPrivateKey(s.seed, CertifierName).PubKey().ToDERHex()
+}
+
+// ClientCertificate returns a certificate of the client identity issued by the certifier identity,
+// its keyring reveals every field to the server. The certificate is not signed, the mock wallets accept it.
+func (s *Server) ClientCertificate(typeID string, fields map[string]any) certificates.VerifiableCertificate {
+	keyring := make(map[string]string, len(fields))
+	for name := range fields {
+		keyring[name] = "mockkey"
+	}
+
+	return certificates.VerifiableCertificate{
+		Certificate: certificates.Certificate{
+			Type:         typeID,
+			SerialNumber: "authtest-" + typeID,
+			Subject:      s.ClientIdentityKey(),
+			Certifier:    s.CertifierIdentityKey(),
+			Fields:       fields,
+			Signature:    "mocksignature",
+		},
+		Keyring: keyring,
+	}
+}
+
+// Handshake performs a new handshake with the client wallet and returns the initial response of the server.
+// When the server requests certificates, the client sends a certificate of every requested type with the requested fields.
+func (s *Server) Handshake() *transport.AuthMessage {
+	s.t.Helper()
+
+	initialResponse := &transport.AuthMessage{}
+	s.postAuthMessage(utils.PrepareInitialRequestBody(s.ClientWallet), initialResponse)
+
+	if requested := s.certificatesToRequest; requested != nil && len(requested.Types) > 0 {
+		certs := make([]certificates.VerifiableCertificate, 0, len(requested.Types))
+		for typeID, fieldNames := range requested.Types {
+			fields := make(map[string]any, len(fieldNames))
+			for _, name := range fieldNames {
+				fields[name] = name
+			}
+			certificate := s.ClientCertificate(typeID, fields)
+			if len(requested.Certifiers) > 0 {
+				certificate.Certifier = requested.Certifiers[0]
+			}
+			certs = append(certs, certificate)
+		}
+
+		certificateResponse, err := utils.PrepareCertificateResponse(context.Background(), s.ClientWallet, initialResponse, certs)
+		if err != nil {
+			s.t.Fatalf("authtest: failed to prepare certificate response: %v", err)
+		}
+		s.postAuthMessage(*certificateResponse, nil)
+	}
+
+	s.mu.Lock()
+	s.session = initialResponse
+	s.mu.Unlock()
+
+	return initialResponse
+}
+
+// NewRequest returns a request to the path of the server signed by the client wallet,
+// the handshake is performed with the first request
+func (s *Server) NewRequest(method, path string, body io.Reader) *http.Request {
+	s.t.Helper()
+
+	req, err := http.NewRequest(method, s.URL+path, body)
+	if err != nil {
+		s.t.Fatalf("authtest: failed to create request: %v", err)
+	}
+
+	s.mu.Lock()
+	session := s.session
+	s.mu.Unlock()
+	if session == nil {
+		session = s.Handshake()
+	}
+
+	headers, err := utils.PrepareGeneralRequestHeaders(s.ClientWallet, session, utils.RequestData{Request: req})
+	if err != nil {
+		s.t.Fatalf("authtest: failed to sign request: %v", err)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	return req
+}
+
+// NewInvalidRequest returns a request like NewRequest with the flaw, which the server rejects
+func (s *Server) NewInvalidRequest(method, path string, body io.Reader, flaw Flaw) *http.Request {
+	s.t.Helper()
+
+	req := s.NewRequest(method, path, body)
+	switch flaw {
+	case FlawUnsigned:
+		for name := range req.Header {
+			if strings.HasPrefix(strings.ToLower(name), "x-bsv-auth-") {
+				req.Header.Del(name)
+			}
+		}
+	case FlawSignature:
+		req.Header.Set("x-bsv-auth-signature", s.NewRequest(method, path, nil).Header.Get("x-bsv-auth-signature"))
+	case FlawTamperedBody:
+		tampered, err := io.ReadAll(req.Body)
+		if err != nil {
+			s.t.Fatalf("authtest: failed to read request body: %v", err)
+		}
+		tampered = append(tampered, " tampered"...)
+		req.Body, req.ContentLength = io.NopCloser(bytes.NewReader(tampered)), int64(len(tampered))
+	case FlawUnknownSession:
+		unknown, err := s.Wallet.CreateNonce(context.Background())
+		if err != nil {
+			s.t.Fatalf("authtest: failed to create nonce: %v", err)
+		}
+		req.Header.Set("x-bsv-auth-your-nonce", unknown)
+	case FlawVersion:
+		req.Header.Set("x-bsv-auth-version", "0.0")
+	default:
+		s.t.Fatalf("authtest: unknown flaw %q", flaw)
+	}
+
+	return req
+}
+
+// postAuthMessage sends the message to the handshake endpoint and decodes the response into target unless it is nil
+func (s *Server) postAuthMessage(msg transport.AuthMessage, target any) {
+	s.t.Helper()
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		s.t.Fatalf("authtest: failed to encode %s: %v", msg.MessageType, err)
+	}
+
+	response, err := s.Client().Post(s.URL+auth.HandshakePath, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		s.t.Fatalf("authtest: failed to send %s: %v", msg.MessageType, err)
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(response.Body)
+		s.t.Fatalf("authtest: %s rejected with status %d: %s", msg.MessageType, response.StatusCode, body)
+	}
+
+	if target != nil {
+		if err := json.NewDecoder(response.Body).Decode(target); err != nil {
+			s.t.Fatalf("authtest: failed to decode response to %s: %v", msg.MessageType, err)
+		}
+	}
+}
+
+// identityHandler responds with the identity key of the peer
+func identityHandler(w http.ResponseWriter, r *http.Request) {
+	identityKey, _ := auth.GetIdentityFromContext(r.Context())
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte(identityKey))
+}
@@ -0,0 +1,145 @@
+package authtest_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authtest"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/stretchr/testify/require"
+)
+
+const ageVerificationType = "9ZkJfGmbXcggy2CL1Eb8wX1hv2WwVnGf4TTZ1FHrPFU="
+
+func TestServer(t *testing.T) {
+	t.Run("identities are derived from the seed", func(t *testing.T) {
+		// when
+		first := authtest.NewServer(t, authtest.Options{Seed: "seed"})
+		second := authtest.NewServer(t, authtest.Options{Seed: "seed"})
+		other := authtest.NewServer(t, authtest.Options{})
+
+		// then
+		require.Equal(t, first.IdentityKey(), second.IdentityKey())
+		require.Equal(t, first.ClientIdentityKey(), second.ClientIdentityKey())
+		require.NotEqual(t, first.IdentityKey(), other.IdentityKey())
+		require.NotEqual(t, first.IdentityKey(), first.ClientIdentityKey())
+	})
+
+	t.Run("signed request is served", func(t *testing.T) {
+		// given
+		server := authtest.NewServer(t, authtest.Options{})
+
+		// when
+		response, err := server.Client().Do(server.NewRequest(http.MethodPost, "/ping", strings.NewReader("ping")))
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.Equal(t, server.ClientIdentityKey(), string(body))
+	})
+
+	t.Run("client of the module authenticates to the server", func(t *testing.T) {
+		// given
+		server := authtest.NewServer(t, authtest.Options{})
+		authClient, err := client.New(client.Config{
+			Wallet:             wallettest.NewSeededMockWallet("downstream", "client"),
+			BaseURL:            server.URL,
+			PinnedIdentityKeys: []string{server.IdentityKey()},
+		})
+		require.NoError(t, err)
+		request, err := http.NewRequest(http.MethodGet, server.URL+"/ping", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Do(request)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+	})
+
+	t.Run("requests of a server requesting certificates carry them", func(t *testing.T) {
+		// given
+		var received []certificates.VerifiableCertificate
+		server := authtest.NewServer(t, authtest.Options{
+			CertificatesToRequest: transport.NewRequestedCertificateSet("0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798").
+				AddType(ageVerificationType, "age"),
+			OnCertificatesReceived: func(_ string, certs *[]certificates.VerifiableCertificate, _ *http.Request, _ http.ResponseWriter, next func()) {
+				received = *certs
+				next()
+			},
+		})
+
+		// when
+		response, err := server.Client().Do(server.NewRequest(http.MethodGet, "/ping", nil))
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Len(t, received, 1)
+		require.Equal(t, ageVerificationType, received[0].Type)
+		require.Equal(t, server.ClientIdentityKey(), received[0].Subject)
+	})
+
+	t.Run("certificate provider of the client is accepted", func(t *testing.T) {
+		// given
+		server := authtest.NewServer(t, authtest.Options{
+			CertificatesToRequest: transport.NewRequestedCertificateSet("0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798").
+				AddType(ageVerificationType, "age"),
+		})
+		authClient, err := client.New(client.Config{
+			Wallet:  server.ClientWallet,
+			BaseURL: server.URL,
+			CertificateProvider: client.CertificateProviderFunc(func(context.Context, transport.RequestedCertificateSet, string) ([]certificates.VerifiableCertificate, error) {
+				return []certificates.VerifiableCertificate{server.ClientCertificate(ageVerificationType, map[string]any{"age": "21"})}, nil
+			}),
+		})
+		require.NoError(t, err)
+		request, err := http.NewRequest(http.MethodGet, server.URL+"/ping", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Do(request)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+	})
+}
+
+func TestServer_NewInvalidRequest(t *testing.T) {
+	server := authtest.NewServer(t, authtest.Options{})
+
+	tests := map[authtest.Flaw]struct {
+		status int
+		code   string
+	}{
+		authtest.FlawUnsigned:       {http.StatusUnauthorized, transport.ErrCodeMissingRequestID},
+		authtest.FlawSignature:      {http.StatusUnauthorized, transport.ErrCodeInvalidSignature},
+		authtest.FlawTamperedBody:   {http.StatusUnauthorized, transport.ErrCodeInvalidSignature},
+		authtest.FlawUnknownSession: {http.StatusUnauthorized, transport.ErrCodeSessionNotFound},
+		authtest.FlawVersion:        {http.StatusUnauthorized, transport.ErrCodeUnsupportedVersion},
+	}
+	for flaw, test := range tests {
+		t.Run(string(flaw), func(t *testing.T) {
+			// when
+			response, err := server.Client().Do(server.NewInvalidRequest(http.MethodPost, "/ping", strings.NewReader("ping"), flaw))
+
+			// then
+			require.NoError(t, err)
+			require.Equal(t, test.status, response.StatusCode)
+			var errResponse transport.ErrorResponse
+			require.NoError(t, json.NewDecoder(response.Body).Decode(&errResponse))
+			require.Equal(t, test.code, errResponse.Code)
+		})
+	}
+}
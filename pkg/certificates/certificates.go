@@ -0,0 +1,39 @@
+// Package certificates is the supported API of the identity certificates peers exchange during the handshake.
+// It replaces the certificate types of pkg/temporary/wallet, which are deprecated aliases of these types kept
+// for compatibility. The certificates requested from peers are described by transport.RequestedCertificateSet.
+package certificates
+
+// Certificate is an identity certificate issued by a certifier to a subject
+type Certificate struct {
+	// Type is the type of certificate
+	Type string `json:"type"`
+	// Subject is the subject of the certificate
+	Subject string `json:"subject"`
+	// SerialNumber is the serial number of the certificate
+	SerialNumber string `json:"serialNumber"`
+	// Certifier is the certifier of the certificate
+	Certifier string `json:"certifier"`
+	// RevocationOutpoint is the revocation outpoint of the certificate
+	RevocationOutpoint string `json:"revocationOutpoint"`
+	// Fields is the map representing custom fields of the certificate (payload)
+	Fields map[string]any `json:"fields"`
+	// Signature is the signature of the certificate
+	Signature string `json:"signature"`
+}
+
+// VerifiableCertificate is a certificate with the keyring revealing its fields to a verifier,
+// it is what peers send and what OnCertificatesReceived callbacks receive
+type VerifiableCertificate struct {
+	Certificate
+	// Keyring is a map keys for specific fields
+	Keyring map[string]string `json:"keyring"`
+	// DecryptedFields is a map of decrypted fields
+	DecryptedFields *map[string]string `json:"decryptedFields,omitempty"`
+}
+
+// MasterCertificate is a certificate with the master keyring of all its fields, held by its subject
+type MasterCertificate struct {
+	Certificate
+	// MasterKeyring is a map of all keys for all fields
+	MasterKeyring map[string]string `json:"masterKeyring"`
+}
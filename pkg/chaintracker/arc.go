@@ -0,0 +1,82 @@
+package chaintracker
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/httpclient"
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+)
+
+// confirmationStateConfirmed is the state of merkle roots confirmed by the Block Headers Service
+const confirmationStateConfirmed = "CONFIRMED"
+
+// ARC is a chain tracker for ARC deployments. ARC does not expose block headers or UTXOs,
+// so merkle roots are verified with the Block Headers Service deployed alongside ARC
+// and the spent status of outputs is not supported.
+type ARC struct {
+	// HeadersURL is the base URL of the Block Headers Service
+	HeadersURL string
+	// APIKey is sent as a bearer token when set
+	APIKey string
+	// Client is the HTTP client used for the API calls, defaults to the shared retrying client of httpclient.Default
+	Client *http.Client
+}
+
+// NewARC creates an ARC chain tracker using the Block Headers Service at headersURL
+func NewARC(headersURL, apiKey string) *ARC {
+	return &ARC{
+		HeadersURL: headersURL,
+		APIKey:     apiKey,
+		Client:     httpclient.Default(),
+	}
+}
+
+// IsValidRootForHeight verifies the merkle root with the Block Headers Service
+func (a *ARC) IsValidRootForHeight(ctx context.Context, root *chainhash.Hash, height uint32) (bool, error) {
+	body, err := json.Marshal([]map[string]any{{"merkleRoot": root.String(), "blockHeight": height}})
+	if err != nil {
+		return false, fmt.Errorf("failed to encode merkle root verification request, %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.HeadersURL+"/api/v1/chain/merkleroot/verify", bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create merkle root verification request, %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if a.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+a.APIKey)
+	}
+
+	client := a.Client
+	if client == nil {
+		client = httpclient.Default()
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to verify merkle root for height %d, %w", height, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("failed to verify merkle root for height %d, unexpected response status %s", height, resp.Status)
+	}
+
+	var result struct {
+		ConfirmationState string `json:"confirmationState"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false, fmt.Errorf("failed to decode merkle root verification response, %w", err)
+	}
+
+	return result.ConfirmationState == confirmationStateConfirmed, nil
+}
+
+// IsOutputSpent is not supported, ARC does not index outputs
+func (a *ARC) IsOutputSpent(_ context.Context, _ *chainhash.Hash, _ uint32) (bool, error) {
+	return false, ErrNotSupported
+}
@@ -0,0 +1,175 @@
+package chaintracker_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/chaintracker"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	merkleRootHex = "4a5e1e4baab89f3a32518a88c31bc87f618f76673e2cc77ab2127b7afdeda33b"
+	txIDHex       = "0e3e2357e806b6cdb1f70b54c3a3a17b6714ee1f0e68bebb44a74b1efd512098"
+)
+
+var (
+	_ chaintracker.Interface = (*chaintracker.WhatsOnChain)(nil)
+	_ chaintracker.Interface = (*chaintracker.ARC)(nil)
+	_ chaintracker.Interface = (*chaintracker.InMemory)(nil)
+)
+
+func TestInMemory(t *testing.T) {
+	// given
+	root, err := chainhash.NewHashFromHex(merkleRootHex)
+	require.NoError(t, err)
+	txID, err := chainhash.NewHashFromHex(txIDHex)
+	require.NoError(t, err)
+
+	tracker := chaintracker.NewInMemory()
+	tracker.AddBlock(100, *root)
+	tracker.MarkSpent(*txID, 1)
+
+	// when
+	validRoot, err := tracker.IsValidRootForHeight(context.Background(), root, 100)
+	require.NoError(t, err)
+	otherHeight, err := tracker.IsValidRootForHeight(context.Background(), root, 101)
+	require.NoError(t, err)
+	spent, err := tracker.IsOutputSpent(context.Background(), txID, 1)
+	require.NoError(t, err)
+	unspent, err := tracker.IsOutputSpent(context.Background(), txID, 0)
+	require.NoError(t, err)
+
+	// then
+	require.True(t, validRoot)
+	require.False(t, otherHeight)
+	require.True(t, spent)
+	require.False(t, unspent)
+}
+
+func TestForSDK(t *testing.T) {
+	// given
+	root, err := chainhash.NewHashFromHex(merkleRootHex)
+	require.NoError(t, err)
+
+	tracker := chaintracker.NewInMemory()
+	tracker.AddBlock(100, *root)
+	adapted := chaintracker.ForSDK(context.Background(), tracker)
+
+	// when
+	validRoot, err := adapted.IsValidRootForHeight(root, 100)
+	require.NoError(t, err)
+	otherHeight, err := adapted.IsValidRootForHeight(root, 101)
+	require.NoError(t, err)
+
+	// then
+	require.True(t, validRoot)
+	require.False(t, otherHeight)
+}
+
+func TestWhatsOnChain(t *testing.T) {
+	root, err := chainhash.NewHashFromHex(merkleRootHex)
+	require.NoError(t, err)
+	txID, err := chainhash.NewHashFromHex(txIDHex)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "test-key", r.Header.Get("Authorization"))
+
+		switch r.URL.Path {
+		case "/block/100/header":
+			_ = json.NewEncoder(w).Encode(map[string]any{"height": 100, "merkleroot": merkleRootHex})
+		case "/tx/" + txIDHex + "/1/spent":
+			_ = json.NewEncoder(w).Encode(map[string]any{"txid": "spending", "vin": 0})
+		case "/block/500/header":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tracker, err := chaintracker.NewWhatsOnChain(wallet.NetworkMainnet, "test-key")
+	require.NoError(t, err)
+	tracker.BaseURL = server.URL
+
+	t.Run("validates merkle root against block header", func(t *testing.T) {
+		valid, err := tracker.IsValidRootForHeight(context.Background(), root, 100)
+		require.NoError(t, err)
+		require.True(t, valid)
+
+		valid, err = tracker.IsValidRootForHeight(context.Background(), txID, 100)
+		require.NoError(t, err)
+		require.False(t, valid)
+	})
+
+	t.Run("unknown block is not valid", func(t *testing.T) {
+		valid, err := tracker.IsValidRootForHeight(context.Background(), root, 200)
+		require.NoError(t, err)
+		require.False(t, valid)
+	})
+
+	t.Run("returns error on unexpected status", func(t *testing.T) {
+		_, err := tracker.IsValidRootForHeight(context.Background(), root, 500)
+		require.Error(t, err)
+	})
+
+	t.Run("reports spent status of outputs", func(t *testing.T) {
+		spent, err := tracker.IsOutputSpent(context.Background(), txID, 1)
+		require.NoError(t, err)
+		require.True(t, spent)
+
+		spent, err = tracker.IsOutputSpent(context.Background(), txID, 0)
+		require.NoError(t, err)
+		require.False(t, spent)
+	})
+
+	t.Run("rejects regtest", func(t *testing.T) {
+		_, err := chaintracker.NewWhatsOnChain(wallet.NetworkRegtest, "")
+		require.ErrorIs(t, err, chaintracker.ErrUnsupportedNetwork)
+	})
+}
+
+func TestARC(t *testing.T) {
+	// given
+	root, err := chainhash.NewHashFromHex(merkleRootHex)
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/chain/merkleroot/verify", r.URL.Path)
+		require.Equal(t, "Bearer test-key", r.Header.Get("Authorization"))
+
+		var body []struct {
+			MerkleRoot  string `json:"merkleRoot"`
+			BlockHeight uint32 `json:"blockHeight"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Len(t, body, 1)
+
+		state := "INVALID"
+		if body[0].MerkleRoot == merkleRootHex && body[0].BlockHeight == 100 {
+			state = "CONFIRMED"
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"confirmationState": state})
+	}))
+	defer server.Close()
+
+	tracker := chaintracker.NewARC(server.URL, "test-key")
+
+	// when
+	valid, err := tracker.IsValidRootForHeight(context.Background(), root, 100)
+	require.NoError(t, err)
+	invalid, err := tracker.IsValidRootForHeight(context.Background(), root, 101)
+	require.NoError(t, err)
+	_, spentErr := tracker.IsOutputSpent(context.Background(), root, 0)
+
+	// then
+	require.True(t, valid)
+	require.False(t, invalid)
+	require.ErrorIs(t, spentErr, chaintracker.ErrNotSupported)
+}
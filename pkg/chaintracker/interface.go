@@ -0,0 +1,23 @@
+package chaintracker
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+)
+
+// Errors returned by chain trackers
+var (
+	ErrNotSupported       = errors.New("operation not supported by the chain tracker")
+	ErrUnsupportedNetwork = errors.New("network not supported by the chain tracker")
+)
+
+// Interface provides the chain state needed for SPV payment verification and certificate revocation checking
+type Interface interface {
+	// IsValidRootForHeight checks whether the merkle root is the root of the block at the given height
+	IsValidRootForHeight(ctx context.Context, root *chainhash.Hash, height uint32) (bool, error)
+
+	// IsOutputSpent checks whether the output of the transaction was spent, e.g. the revocation outpoint of a certificate
+	IsOutputSpent(ctx context.Context, txID *chainhash.Hash, vout uint32) (bool, error)
+}
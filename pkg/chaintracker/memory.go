@@ -0,0 +1,58 @@
+package chaintracker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+)
+
+// InMemory is a chain tracker holding the chain state in memory, meant to be used in tests
+type InMemory struct {
+	mu    sync.RWMutex
+	roots map[uint32]chainhash.Hash
+	spent map[string]struct{}
+}
+
+// NewInMemory creates an empty in-memory chain tracker
+func NewInMemory() *InMemory {
+	return &InMemory{
+		roots: make(map[uint32]chainhash.Hash),
+		spent: make(map[string]struct{}),
+	}
+}
+
+// AddBlock registers the merkle root of the block at the given height
+func (m *InMemory) AddBlock(height uint32, root chainhash.Hash) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.roots[height] = root
+}
+
+// MarkSpent marks the output of the transaction as spent
+func (m *InMemory) MarkSpent(txID chainhash.Hash, vout uint32) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.spent[outpoint(&txID, vout)] = struct{}{}
+}
+
+// IsValidRootForHeight checks the merkle root against the registered blocks, unknown heights are not valid
+func (m *InMemory) IsValidRootForHeight(_ context.Context, root *chainhash.Hash, height uint32) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	known, ok := m.roots[height]
+	return ok && known.IsEqual(root), nil
+}
+
+// IsOutputSpent checks whether the output was marked as spent
+func (m *InMemory) IsOutputSpent(_ context.Context, txID *chainhash.Hash, vout uint32) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.spent[outpoint(txID, vout)]
+	return ok, nil
+}
+
+func outpoint(txID *chainhash.Hash, vout uint32) string {
+	return fmt.Sprintf("%s.%d", txID, vout)
+}
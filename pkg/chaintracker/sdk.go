@@ -0,0 +1,23 @@
+package chaintracker
+
+import (
+	"context"
+
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	sdk "github.com/bsv-blockchain/go-sdk/transaction/chaintracker"
+)
+
+// ForSDK adapts the chain tracker to the chain tracker of the go-sdk, e.g. to verify BEEF with Beef.Verify,
+// the merkle roots are checked with the given context
+func ForSDK(ctx context.Context, tracker Interface) sdk.ChainTracker {
+	return sdkTracker{ctx: ctx, tracker: tracker}
+}
+
+type sdkTracker struct {
+	ctx     context.Context //nolint:containedctx // the go-sdk chain tracker takes no context
+	tracker Interface
+}
+
+func (t sdkTracker) IsValidRootForHeight(root *chainhash.Hash, height uint32) (bool, error) {
+	return t.tracker.IsValidRootForHeight(t.ctx, root, height)
+}
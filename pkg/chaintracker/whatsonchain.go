@@ -0,0 +1,113 @@
+package chaintracker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/httpclient"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+)
+
+// WhatsOnChainBaseURL is the base URL of the WhatsOnChain API, without the network segment
+const WhatsOnChainBaseURL = "https://api.whatsonchain.com/v1/bsv"
+
+// WhatsOnChain is a chain tracker backed by the WhatsOnChain API
+type WhatsOnChain struct {
+	// BaseURL is the base URL of the API including the network segment
+	BaseURL string
+	// APIKey is sent in the Authorization header when set
+	APIKey string
+	// Client is the HTTP client used for the API calls, defaults to the shared retrying client of httpclient.Default
+	Client *http.Client
+}
+
+// NewWhatsOnChain creates a WhatsOnChain chain tracker for the network, regtest is not supported by WhatsOnChain
+func NewWhatsOnChain(network wallet.Network, apiKey string) (*WhatsOnChain, error) {
+	var segment string
+	switch network {
+	case wallet.NetworkMainnet:
+		segment = "main"
+	case wallet.NetworkTestnet:
+		segment = "test"
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedNetwork, network)
+	}
+
+	return &WhatsOnChain{
+		BaseURL: WhatsOnChainBaseURL + "/" + segment,
+		APIKey:  apiKey,
+		Client:  httpclient.Default(),
+	}, nil
+}
+
+// IsValidRootForHeight compares the merkle root with the root of the block header at the given height
+func (w *WhatsOnChain) IsValidRootForHeight(ctx context.Context, root *chainhash.Hash, height uint32) (bool, error) {
+	var header struct {
+		MerkleRoot string `json:"merkleroot"`
+	}
+
+	found, err := w.get(ctx, fmt.Sprintf("/block/%d/header", height), &header)
+	if err != nil {
+		return false, fmt.Errorf("failed to get block header for height %d, %w", height, err)
+	}
+	if !found {
+		return false, nil
+	}
+
+	merkleRoot, err := chainhash.NewHashFromHex(header.MerkleRoot)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse merkle root of block %d, %w", height, err)
+	}
+
+	return merkleRoot.IsEqual(root), nil
+}
+
+// IsOutputSpent checks the spent status of the output, WhatsOnChain responds with 404 for unspent outputs
+func (w *WhatsOnChain) IsOutputSpent(ctx context.Context, txID *chainhash.Hash, vout uint32) (bool, error) {
+	found, err := w.get(ctx, fmt.Sprintf("/tx/%s/%d/spent", txID, vout), nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to get spent status of %s, %w", outpoint(txID, vout), err)
+	}
+
+	return found, nil
+}
+
+// get calls the API and decodes the response into result, it reports false when the resource was not found
+func (w *WhatsOnChain) get(ctx context.Context, path string, result any) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.BaseURL+path, nil)
+	if err != nil {
+		return false, err
+	}
+	if w.APIKey != "" {
+		req.Header.Set("Authorization", w.APIKey)
+	}
+
+	client := w.Client
+	if client == nil {
+		client = httpclient.Default()
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode != http.StatusOK:
+		return false, fmt.Errorf("unexpected response status %s", resp.Status)
+	case result == nil:
+		return true, nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return false, fmt.Errorf("failed to decode response, %w", err)
+	}
+
+	return true, nil
+}
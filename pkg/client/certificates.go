@@ -0,0 +1,177 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+)
+
+// CertificateProvider returns the certificates disclosed to a server which requested certificates in the handshake.
+// The keyrings of the certificates have to reveal the requested fields to the server identity key.
+type CertificateProvider interface {
+	Certificates(ctx context.Context, requested transport.RequestedCertificateSet, serverIdentityKey string) ([]certificates.VerifiableCertificate, error)
+}
+
+// CertificateProviderFunc adapts an ordinary function to the CertificateProvider interface
+type CertificateProviderFunc func(ctx context.Context, requested transport.RequestedCertificateSet, serverIdentityKey string) ([]certificates.VerifiableCertificate, error)
+
+// Certificates calls f(ctx, requested, serverIdentityKey)
+func (f CertificateProviderFunc) Certificates(ctx context.Context, requested transport.RequestedCertificateSet, serverIdentityKey string) ([]certificates.VerifiableCertificate, error) {
+	return f(ctx, requested, serverIdentityKey)
+}
+
+// CertificateAcquirer is called when the server requests certificates the CertificateProvider cannot provide,
+// so interactive applications can launch an acquisition flow, e.g. redirect the user to a certifier.
+// The client resumes once it returns and requests the certificates from the CertificateProvider again,
+// the request fails with the returned error.
+type CertificateAcquirer interface {
+	AcquireCertificates(ctx context.Context, missing transport.RequestedCertificateSet, serverIdentityKey string) error
+}
+
+// CertificateAcquirerFunc adapts an ordinary function to the CertificateAcquirer interface
+type CertificateAcquirerFunc func(ctx context.Context, missing transport.RequestedCertificateSet, serverIdentityKey string) error
+
+// AcquireCertificates calls f(ctx, missing, serverIdentityKey)
+func (f CertificateAcquirerFunc) AcquireCertificates(ctx context.Context, missing transport.RequestedCertificateSet, serverIdentityKey string) error {
+	return f(ctx, missing, serverIdentityKey)
+}
+
+// sendCertificates sends the certificates of the provider in a certificate response for the session of the initial response,
+// servers which requested no certificates are sent none. Certificates the provider lacks are acquired with the acquirer first.
+func (c *Client) sendCertificates(ctx context.Context, initialResponse *transport.AuthMessage) error {
+	requested := initialResponse.RequestedCertificates
+	if len(requested.Types) == 0 {
+		return nil
+	}
+
+	certificates, err := c.provideCertificates(ctx, requested, initialResponse.IdentityKey)
+	if err != nil {
+		return err
+	}
+
+	if missing := missingCertificates(requested, certificates); c.certificateAcquirer != nil && len(missing.Types) > 0 {
+		c.logger.Debug("Acquiring missing certificates", slog.Int("types", len(missing.Types)))
+		if err := c.certificateAcquirer.AcquireCertificates(ctx, missing, initialResponse.IdentityKey); err != nil {
+			return fmt.Errorf("failed to acquire certificates, %w", err)
+		}
+
+		if certificates, err = c.provideCertificates(ctx, requested, initialResponse.IdentityKey); err != nil {
+			return err
+		}
+	}
+
+	if len(certificates) == 0 {
+		return nil
+	}
+
+	certificateResponse, err := utils.PrepareCertificateResponse(ctx, c.wallet, initialResponse, certificates)
+	if err != nil {
+		return err
+	}
+
+	if c.compression != nil && slices.Contains(initialResponse.Capabilities, transport.CompressionCapability(c.compression)) {
+		if err := certificateResponse.CompressCertificates(c.compression); err != nil {
+			return err
+		}
+	}
+
+	payload, err := json.Marshal(certificateResponse)
+	if err != nil {
+		return fmt.Errorf("failed to encode certificate response, %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+handshakePath, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create certificate response, %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	response, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send certificate response, %w", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		if err := decodeServerError(response); err != nil {
+			return c.verifyExchangeAborted(initialResponse, err)
+		}
+		return fmt.Errorf("certificate response failed with status %d", response.StatusCode)
+	}
+	return response.Body.Close()
+}
+
+// verifyExchangeAborted checks that the ExchangeAborted message of an aborted certificate exchange is signed by the server
+// of the session and sets the reason of the error, other errors are returned as they are
+func (c *Client) verifyExchangeAborted(initialResponse *transport.AuthMessage, err error) error {
+	var abortedErr *transport.ExchangeAbortedError
+	if !errors.As(err, &abortedErr) {
+		return err
+	}
+
+	if abortedErr.Message.IdentityKey != initialResponse.IdentityKey {
+		return ErrInvalidServerSignature
+	}
+	reason, verifyErr := transport.VerifyExchangeAborted(c.wallet, abortedErr.Message)
+	if verifyErr != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidServerSignature, verifyErr)
+	}
+
+	c.logger.Info("Certificate exchange aborted by the server", slog.String("reason", string(reason)))
+	abortedErr.Reason = reason
+	return abortedErr
+}
+
+// provideCertificates returns the certificates of the provider for the requested set, none without a provider
+func (c *Client) provideCertificates(ctx context.Context, requested transport.RequestedCertificateSet, serverIdentityKey string) ([]certificates.VerifiableCertificate, error) {
+	if c.certificateProvider == nil {
+		return nil, nil
+	}
+
+	certs, err := c.certificateProvider.Certificates(ctx, requested, serverIdentityKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to provide certificates, %w", err)
+	}
+	return certs, nil
+}
+
+// acquireAndRetry acquires the certificates the server rejected the request for and retries it once in a new session,
+// whose handshake sends the acquired certificates
+func (c *Client) acquireAndRetry(req *http.Request, session *transport.AuthMessage, body []byte, missing transport.RequestedCertificateSet) (*http.Response, error) {
+	if err := c.certificateAcquirer.AcquireCertificates(req.Context(), missing, session.IdentityKey); err != nil {
+		return nil, fmt.Errorf("failed to acquire certificates, %w", err)
+	}
+
+	c.logger.Debug("Retrying request with acquired certificates")
+	c.resetSession(session)
+
+	retryReq := req.Clone(req.Context())
+	retryReq.Body = io.NopCloser(bytes.NewReader(body))
+	retrySession, _, err := c.prepare(retryReq)
+	if err != nil {
+		return nil, err
+	}
+	return c.send(retryReq, retrySession, body)
+}
+
+// missingCertificates returns the requested types which none of the certificates of a requested certifier covers
+func missingCertificates(requested transport.RequestedCertificateSet, certs []certificates.VerifiableCertificate) transport.RequestedCertificateSet {
+	missing := transport.RequestedCertificateSet{Certifiers: requested.Certifiers, Types: transport.RequestedCertificateTypeIDAndFieldList{}}
+	for typeID, fields := range requested.Types {
+		covered := slices.ContainsFunc(certs, func(cert certificates.VerifiableCertificate) bool {
+			return cert.Type == typeID && (len(requested.Certifiers) == 0 || slices.Contains(requested.Certifiers, cert.Certifier))
+		})
+		if !covered {
+			missing.Types[typeID] = fields
+		}
+	}
+	return missing
+}
@@ -0,0 +1,786 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/internal/logging"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/payment"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+)
+
+const (
+	handshakePath     = "/.well-known/auth"
+	identityKeyHeader = "x-bsv-auth-identity-key"
+	nonceHeader       = "x-bsv-auth-nonce"
+	yourNonceHeader   = "x-bsv-auth-your-nonce"
+	signatureHeader   = "x-bsv-auth-signature"
+	requestIDHeader   = "x-bsv-auth-request-id"
+)
+
+// Config configures the auth client
+type Config struct {
+	Wallet     wallet.WalletInterface
+	BaseURL    string
+	HTTPClient *http.Client
+	Logger     *slog.Logger
+	// PayloadEncryption requests encryption of general message bodies during the handshake
+	PayloadEncryption bool
+	// PayloadPadding requests padding of general message bodies to size buckets during the handshake,
+	// so the sizes of requests and responses only reveal their bucket, see transport.PadPayload
+	PayloadPadding bool
+	// PinnedIdentityKeys restricts the accepted server identity keys, the handshake fails if the server responds with any other key
+	PinnedIdentityKeys []string
+	// TrustedCoSigners restricts the accepted co-signers of responses co-signed by a tenant identity of the server,
+	// co-signed responses of other identities are rejected with ErrCoSignerNotTrusted. Any co-signer is accepted when empty.
+	TrustedCoSigners []string
+	// Delegation is presented in the handshake when the Wallet holds an agent key, so the client authenticates on behalf
+	// of the principal which signed it, see transport.SignDelegation. The server has to accept delegations,
+	// see auth.Config.Delegations.
+	Delegation *transport.Delegation
+	// PathNormalization is the normalization of the signed path, it has to match auth.Config.PathNormalization of
+	// the server. authcore.PathLenient keeps requests verifiable when proxies rewrite the path to an equivalent one.
+	PathNormalization authcore.PathNormalization
+	// IdentityStore enables trust on first use, the server identity key is recorded on first contact and the handshake fails if it changes
+	IdentityStore IdentityStore
+	// OnIdentityChanged is called when the server responds with an identity key different from the recorded one
+	OnIdentityChanged func(host, previousIdentityKey, identityKey string)
+	// Payer constructs payments for requests rejected with 402 Payment Required, requests are not paid when nil
+	Payer Payer
+	// PaymentLimits caps the satoshis paid by the Payer
+	PaymentLimits PaymentLimits
+	// ApprovePayment is called before every payment within the limits, the payment is not made when it returns false
+	ApprovePayment func(ctx context.Context, terms payment.PaymentTerms) bool
+	// Clock returns the current time used to check the expiry of payment terms before paying, defaults to time.Now.
+	// The server checks the expiry again with its own clock, so terms expired on the server require a re-quote.
+	Clock func() time.Time
+	// Random is the entropy source of the request IDs, defaults to crypto/rand. A seeded source makes
+	// the request IDs deterministic in tests, nonces are created by the wallet.
+	Random io.Reader
+	// Interaction approves the origins the client signs requests for, every origin is signed for when nil
+	Interaction Interaction
+	// SeekPermission sets SeekPermission on the wallet calls signing and encrypting requests and passes the origin
+	// of the request as originator, so interactive wallets can prompt the user before signing for a new origin
+	SeekPermission bool
+	// BindOrigin binds the signature of every request to the origin of its URL, so a server cannot replay it
+	// against another origin sharing its identity key. The server has to accept the origin, see auth.Config.OriginBinding.
+	BindOrigin bool
+	// CertificatePrompt is called before the first Call of an endpoint demanding certificates
+	CertificatePrompt CertificatePrompt
+	// CertificateProvider provides the certificates sent to servers which request certificates in the handshake,
+	// requested certificates are not sent when nil
+	CertificateProvider CertificateProvider
+	// CertificateAcquirer is called when the server requests certificates the CertificateProvider lacks,
+	// the handshake or request resumes once the application acquired them
+	CertificateAcquirer CertificateAcquirer
+	// ContentDigest is the algorithm the server should compute Content-Digest headers of file responses with,
+	// transport.DigestBLAKE3 is offered in the handshake, the default transport.DigestSHA256 is always used otherwise
+	ContentDigest transport.DigestAlgorithm
+	// CertificateCompression compresses the certificates sent to servers which negotiated it in the handshake,
+	// e.g. transport.GzipCompression, certificates are sent uncompressed when nil
+	CertificateCompression transport.CertificateCompression
+}
+
+// Client is an HTTP client which authenticates requests with BRC-103/104 mutual authentication
+type Client struct {
+	wallet              wallet.WalletInterface
+	baseURL             string
+	httpClient          *http.Client
+	logger              *slog.Logger
+	payloadEncryption   bool
+	payloadPadding      bool
+	pinnedIdentityKeys  map[string]struct{}
+	trustedCoSigners    map[string]struct{}
+	delegation          *transport.Delegation
+	pathNormalization   authcore.PathNormalization
+	identityStore       IdentityStore
+	onIdentityChanged   func(host, previousIdentityKey, identityKey string)
+	host                string
+	payer               Payer
+	paymentLimits       PaymentLimits
+	approvePayment      func(ctx context.Context, terms payment.PaymentTerms) bool
+	clock               func() time.Time
+	random              io.Reader
+	interaction         Interaction
+	seekPermission      bool
+	bindOrigin          bool
+	certificatePrompt   CertificatePrompt
+	certificateProvider CertificateProvider
+	certificateAcquirer CertificateAcquirer
+	contentDigest       transport.DigestAlgorithm
+	compression         transport.CertificateCompression
+
+	approvedMu      sync.Mutex
+	approvedOrigins map[string]struct{}
+
+	mu      sync.Mutex
+	session *transport.AuthMessage
+
+	promptedMu        sync.Mutex
+	promptedEndpoints map[string]struct{}
+
+	spentMu sync.Mutex
+	spent   map[string]int
+}
+
+// New creates a new auth client
+func New(cfg Config) (*Client, error) {
+	if cfg.Wallet == nil {
+		return nil, ErrWalletRequired
+	}
+
+	if cfg.BaseURL == "" {
+		return nil, ErrBaseURLRequired
+	}
+
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+
+	if cfg.Logger == nil {
+		cfg.Logger = slog.New(slog.DiscardHandler)
+	}
+
+	if cfg.Clock == nil {
+		cfg.Clock = time.Now
+	}
+
+	if cfg.ContentDigest != "" {
+		if _, err := cfg.ContentDigest.NewHash(); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Random == nil {
+		cfg.Random = rand.Reader
+	}
+
+	baseURL, err := url.Parse(cfg.BaseURL)
+	if err != nil || baseURL.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", cfg.BaseURL)
+	}
+
+	return &Client{
+		wallet:              cfg.Wallet,
+		baseURL:             strings.TrimSuffix(cfg.BaseURL, "/"),
+		httpClient:          cfg.HTTPClient,
+		logger:              logging.Child(cfg.Logger, "auth-client"),
+		payloadEncryption:   cfg.PayloadEncryption,
+		payloadPadding:      cfg.PayloadPadding,
+		pinnedIdentityKeys:  identityKeySet(cfg.PinnedIdentityKeys),
+		trustedCoSigners:    identityKeySet(cfg.TrustedCoSigners),
+		delegation:          cfg.Delegation,
+		pathNormalization:   cfg.PathNormalization,
+		identityStore:       cfg.IdentityStore,
+		onIdentityChanged:   cfg.OnIdentityChanged,
+		host:                baseURL.Host,
+		payer:               cfg.Payer,
+		paymentLimits:       cfg.PaymentLimits,
+		approvePayment:      cfg.ApprovePayment,
+		clock:               cfg.Clock,
+		random:              cfg.Random,
+		interaction:         cfg.Interaction,
+		seekPermission:      cfg.SeekPermission,
+		bindOrigin:          cfg.BindOrigin,
+		certificatePrompt:   cfg.CertificatePrompt,
+		certificateProvider: cfg.CertificateProvider,
+		certificateAcquirer: cfg.CertificateAcquirer,
+		contentDigest:       cfg.ContentDigest,
+		compression:         cfg.CertificateCompression,
+		promptedEndpoints:   make(map[string]struct{}),
+		approvedOrigins:     make(map[string]struct{}),
+		spent:               make(map[string]int),
+	}, nil
+}
+
+// ServerIdentityKey returns the identity key of the server, empty until the handshake is completed
+func (c *Client) ServerIdentityKey() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session == nil {
+		return ""
+	}
+	return c.session.IdentityKey
+}
+
+// AcceptIdentityRotation records the new server identity key in the identity store, so the next handshake accepts it
+func (c *Client) AcceptIdentityRotation(identityKey string) error {
+	if c.identityStore == nil {
+		return ErrIdentityStoreNotConfigured
+	}
+
+	if err := c.identityStore.Set(c.host, strings.ToLower(identityKey)); err != nil {
+		return fmt.Errorf("failed to record server identity key, %w", err)
+	}
+	return nil
+}
+
+// Handshake performs the initial request to the server and stores the session for subsequent requests
+func (c *Client) Handshake(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.handshake(ctx)
+}
+
+// Do signs and sends the request, performing the handshake first if there is no session yet.
+// When the server requires a payment and a Payer is configured, the request is paid and retried once.
+// When the server requires certificates and a CertificateAcquirer is configured, they are acquired and the request is retried once.
+// The client can be used as the HTTPClient of Connect clients for unary calls, see auth.Middleware.RPCHandler.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	session, body, err := c.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := c.send(req, session, body)
+
+	var paymentErr *PaymentRequiredError
+	if c.payer != nil && errors.As(err, &paymentErr) {
+		return c.payAndRetry(req, session, body, paymentErr.Terms)
+	}
+
+	var certificateErr *CertificateRequiredError
+	if c.certificateAcquirer != nil && errors.As(err, &certificateErr) {
+		return c.acquireAndRetry(req, session, body, certificateErr.Missing)
+	}
+
+	return response, err
+}
+
+// Quote fetches the payment terms of the request without executing it, so the price can be approved before sending it.
+// Servers without quotes enabled respond to paid requests with 402 Payment Required, whose terms are returned as well,
+// and execute free requests, reported as ErrQuotesNotSupported.
+func (c *Client) Quote(req *http.Request) (*payment.PaymentTerms, error) {
+	session, body, err := c.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+
+	quoteReq := req.Clone(req.Context())
+	quoteReq.Header.Set(payment.HeaderQuote, "true")
+
+	response, err := c.send(quoteReq, session, body)
+	var paymentErr *PaymentRequiredError
+	if errors.As(err, &paymentErr) {
+		return &paymentErr.Terms, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	if response.Header.Get(payment.HeaderQuote) == "" {
+		return nil, ErrQuotesNotSupported
+	}
+
+	var terms payment.PaymentTerms
+	if err := json.NewDecoder(response.Body).Decode(&terms); err != nil {
+		return nil, fmt.Errorf("failed to decode quote, %w", err)
+	}
+
+	return &terms, nil
+}
+
+// prepare returns the session, performing the handshake first if there is no session yet, and reads the request body
+func (c *Client) prepare(req *http.Request) (*transport.AuthMessage, []byte, error) {
+	c.mu.Lock()
+	if c.session == nil {
+		if err := c.handshake(req.Context()); err != nil {
+			c.mu.Unlock()
+			return nil, nil, err
+		}
+	}
+	session := c.session
+	c.mu.Unlock()
+
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read request body, %w", err)
+		}
+	}
+
+	return session, body, nil
+}
+
+func (c *Client) payAndRetry(req *http.Request, session *transport.AuthMessage, body []byte, terms payment.PaymentTerms) (*http.Response, error) {
+	if err := terms.VerifySignature(c.wallet, session.IdentityKey); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidTermsSignature, err.Error())
+	}
+
+	if c.clock().Unix() > terms.ExpirationTimestamp {
+		return nil, fmt.Errorf("%w: %w", ErrRequoteRequired, ErrPaymentTermsExpired)
+	}
+
+	if err := checkExpectedPrice(req.Context(), terms.SatoshisRequired); err != nil {
+		return nil, err
+	}
+
+	if err := c.reserveSpend(req.URL.Host, terms.SatoshisRequired); err != nil {
+		return nil, err
+	}
+
+	if c.approvePayment != nil && !c.approvePayment(req.Context(), terms) {
+		c.releaseSpend(req.URL.Host, terms.SatoshisRequired)
+		return nil, ErrPaymentNotApproved
+	}
+
+	paymentData, err := c.payer.Pay(req.Context(), terms, session.IdentityKey)
+	if err != nil {
+		c.releaseSpend(req.URL.Host, terms.SatoshisRequired)
+		return nil, fmt.Errorf("failed to create payment, %w", err)
+	}
+	paymentData.AttachTerms(terms)
+
+	paymentHeader, err := json.Marshal(paymentData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payment, %w", err)
+	}
+
+	c.logger.Debug("Retrying request with payment", slog.Int("satoshis", terms.SatoshisRequired))
+
+	paidReq := req.Clone(req.Context())
+	paidReq.Header.Set(payment.HeaderPayment, string(paymentHeader))
+
+	response, err := c.send(paidReq, session, body)
+	if errors.Is(err, ErrRequoteRequired) {
+		// the payment was rejected before it was internalized by the server
+		c.releaseSpend(req.URL.Host, terms.SatoshisRequired)
+	}
+	return response, err
+}
+
+func (c *Client) send(req *http.Request, session *transport.AuthMessage, body []byte) (*http.Response, error) {
+	origin := requestOrigin(req.URL)
+	if err := c.approveOrigin(req.Context(), origin, session.IdentityKey); err != nil {
+		return nil, err
+	}
+	requestWallet := c.walletFor(origin)
+
+	if session.PayloadPadding {
+		body = transport.PadPayload(body)
+	}
+
+	requestData := utils.RequestData{
+		Method:            req.Method,
+		URL:               req.URL.String(),
+		Headers:           make(map[string]string, len(req.Header)),
+		Body:              body,
+		Random:            c.random,
+		PathNormalization: c.pathNormalization,
+	}
+	for key := range req.Header {
+		requestData.Headers[key] = req.Header.Get(key)
+	}
+	if c.bindOrigin {
+		requestData.Origin = origin
+	}
+
+	var headers map[string]string
+	var err error
+	if session.PayloadEncryption {
+		headers, body, err = utils.PrepareEncryptedGeneralRequest(requestWallet, session, requestData)
+	} else {
+		headers, err = utils.PrepareGeneralRequestHeaders(requestWallet, session, requestData)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare general request, %w", err)
+	}
+
+	// a request without body is sent without body, a non nil reader of unknown length would be sent chunked
+	req.Body, req.ContentLength = http.NoBody, 0
+	if len(body) > 0 {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	response, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request, %w", err)
+	}
+
+	// responses are verified before their body is decrypted, the signatures cover the sent body
+	if err := c.verifyResponse(utils.NewSignatureVerifier(requestWallet), response, session.IdentityKey, req.Header.Get(requestIDHeader)); err != nil {
+		_ = response.Body.Close()
+		return nil, err
+	}
+
+	// the body of upgraded connections is the stream of the new protocol
+	if session.PayloadEncryption && response.StatusCode != http.StatusSwitchingProtocols {
+		if err := decryptResponse(requestWallet, response); err != nil {
+			return nil, err
+		}
+	}
+	if session.PayloadPadding && response.StatusCode != http.StatusSwitchingProtocols {
+		if err := unpadResponse(response); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, streamed := response.Trailer[http.CanonicalHeaderKey(signatureHeader)]; streamed {
+		response.Body = &trailerVerifier{
+			body:              response.Body,
+			hash:              sha256.New(),
+			response:          response,
+			verifier:          utils.NewSignatureVerifier(requestWallet),
+			serverIdentityKey: session.IdentityKey,
+			requestID:         req.Header.Get(requestIDHeader),
+		}
+	}
+
+	if response.StatusCode >= http.StatusBadRequest {
+		if err := decodeServerError(response); err != nil {
+			if errors.Is(err, ErrSessionExpired) {
+				c.resetSession(session)
+			}
+			return nil, err
+		}
+	}
+
+	return response, nil
+}
+
+// Capabilities returns the optional protocol features negotiated with the server in the handshake,
+// nil before the handshake
+func (c *Client) Capabilities() []transport.Capability {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session == nil {
+		return nil
+	}
+	return slices.Clone(c.session.Capabilities)
+}
+
+// VerifyContentDigest checks the Content-Digest header of a complete file response is the digest of its content,
+// computed with the algorithm negotiated in the handshake. Digests of any other algorithm are rejected.
+func (c *Client) VerifyContentDigest(header http.Header, content []byte) error {
+	return transport.VerifyContentDigest(header.Get(utils.ContentDigestHeader), content, transport.NegotiatedDigestAlgorithm(c.Capabilities()))
+}
+
+// HandshakeExtension decodes the extension of the given name the server attached to the initialResponse into value,
+// it returns false before the handshake and when the server sent no such extension
+func (c *Client) HandshakeExtension(name string, value any) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session == nil {
+		return false, nil
+	}
+	return c.session.Extension(name, value)
+}
+
+// SessionKey returns the session key of the given label for the current session with the server,
+// the server derives the same key with auth.Middleware.SessionKey. The key changes with every handshake.
+func (c *Client) SessionKey(label string) (*transport.SessionKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session == nil || c.session.YourNonce == nil {
+		return nil, ErrNoSession
+	}
+
+	return transport.NewSessionKey(transport.SessionKeyConfig{
+		Wallet:       c.wallet,
+		Counterparty: c.session.IdentityKey,
+		ServerNonce:  c.session.InitialNonce,
+		ClientNonce:  *c.session.YourNonce,
+		Label:        label,
+	})
+}
+
+// offeredCapabilities returns the capabilities the client offers in the handshake
+func (c *Client) offeredCapabilities() []transport.Capability {
+	capabilities := []transport.Capability{transport.CapabilityHeartbeat, transport.CapabilityAllowlistExchange, transport.CapabilityTrailerSignature}
+	if c.payloadEncryption {
+		capabilities = append(capabilities, transport.CapabilityPayloadEncryption)
+	}
+	if c.payloadPadding {
+		capabilities = append(capabilities, transport.CapabilityPayloadPadding)
+	}
+	if c.contentDigest == transport.DigestBLAKE3 {
+		capabilities = append(capabilities, transport.CapabilityDigestBLAKE3)
+	}
+	if c.compression != nil {
+		capabilities = append(capabilities, transport.CompressionCapability(c.compression))
+	}
+	return capabilities
+}
+
+// resetSession drops the session, so the next request performs a new handshake
+func (c *Client) resetSession(session *transport.AuthMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.session == session {
+		c.session = nil
+	}
+}
+
+func (c *Client) handshake(ctx context.Context) error {
+	initialRequest := utils.PrepareInitialRequestBody(c.wallet)
+	initialRequest.SetCapabilities(c.offeredCapabilities())
+	if c.delegation != nil {
+		if err := initialRequest.SetExtension(transport.DelegationExtension, c.delegation); err != nil {
+			return fmt.Errorf("failed to attach delegation, %w", err)
+		}
+	}
+
+	payload, err := json.Marshal(initialRequest)
+	if err != nil {
+		return fmt.Errorf("failed to encode initial request, %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+handshakePath, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create initial request, %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	response, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send initial request, %w", err)
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("handshake failed with status %d", response.StatusCode)
+	}
+
+	var initialResponse transport.AuthMessage
+	if err := json.NewDecoder(response.Body).Decode(&initialResponse); err != nil {
+		return fmt.Errorf("failed to decode initial response, %w", err)
+	}
+
+	if initialResponse.MessageType != transport.InitialResponse {
+		return ErrUnexpectedMessageType
+	}
+
+	if err := c.verifyServerIdentity(&initialRequest, &initialResponse); err != nil {
+		return err
+	}
+
+	if err := c.sendCertificates(ctx, &initialResponse); err != nil {
+		return err
+	}
+
+	c.logger.Debug("Handshake completed", slog.String("serverIdentityKey", initialResponse.IdentityKey))
+	c.session = &initialResponse
+	return nil
+}
+
+func (c *Client) verifyServerIdentity(initialRequest, initialResponse *transport.AuthMessage) error {
+	if c.pinnedIdentityKeys != nil {
+		if _, ok := c.pinnedIdentityKeys[strings.ToLower(initialResponse.IdentityKey)]; !ok {
+			c.logger.Error("Server identity key is not pinned", slog.String("serverIdentityKey", initialResponse.IdentityKey))
+			return ErrServerIdentityNotPinned
+		}
+	}
+
+	err := utils.VerifyInitialResponse(c.wallet, initialRequest, initialResponse)
+	if errors.Is(err, utils.ErrPayloadEncryptionNotGranted) {
+		return err //nolint:wrapcheck // the client is configured to require encryption
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidServerSignature, err)
+	}
+
+	return c.trustOnFirstUse(initialResponse.IdentityKey)
+}
+
+func (c *Client) trustOnFirstUse(identityKey string) error {
+	if c.identityStore == nil {
+		return nil
+	}
+
+	identityKey = strings.ToLower(identityKey)
+	previous, ok := c.identityStore.Get(c.host)
+	if !ok {
+		if err := c.identityStore.Set(c.host, identityKey); err != nil {
+			return fmt.Errorf("failed to record server identity key, %w", err)
+		}
+		return nil
+	}
+
+	if previous != identityKey {
+		c.logger.Error("Server identity key changed", slog.String("previousIdentityKey", previous), slog.String("serverIdentityKey", identityKey))
+		if c.onIdentityChanged != nil {
+			c.onIdentityChanged(c.host, previous, identityKey)
+		}
+		return ErrServerIdentityChanged
+	}
+
+	return nil
+}
+
+// verifyResponse checks the response answers the request and carries a valid signature of the server over the
+// response payload. Error responses written by the middleware before the request was authenticated are not signed,
+// co-signed responses are verified by verifyCoSignedResponse and the bodies of streamed responses by trailerVerifier.
+func (c *Client) verifyResponse(verifier authcore.SignatureVerifier, response *http.Response, serverIdentityKey, requestID string) error {
+	if response.Header.Get(utils.CoSignerIdentityKeyHeader) != "" && response.StatusCode != http.StatusSwitchingProtocols {
+		return c.verifyCoSignedResponse(verifier, response, serverIdentityKey, requestID)
+	}
+	if _, streamed := response.Trailer[http.CanonicalHeaderKey(signatureHeader)]; streamed {
+		return nil
+	}
+
+	header := response.Header
+	signature := header.Get(signatureHeader)
+	if signature == "" {
+		if response.StatusCode >= http.StatusBadRequest {
+			return nil
+		}
+		return ErrResponseNotSigned
+	}
+	if header.Get(identityKeyHeader) != serverIdentityKey || header.Get(requestIDHeader) != requestID {
+		return fmt.Errorf("%w: response does not answer the request", ErrInvalidResponseSignature)
+	}
+
+	// the 101 response of an upgraded connection is signed without body, its body is the stream of the new protocol
+	var body []byte
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		var err error
+		body, err = io.ReadAll(response.Body)
+		_ = response.Body.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read response body, %w", err)
+		}
+		response.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	payload, err := utils.BuildSignedResponsePayload(requestID, response.StatusCode, header, body)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidResponseSignature, err)
+	}
+
+	keyID := header.Get(nonceHeader) + " " + header.Get(yourNonceHeader)
+	if err := authcore.VerifySignature(verifier, serverIdentityKey, keyID, payload, signature); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidResponseSignature, err)
+	}
+	return nil
+}
+
+// decodeServerError decodes structured error bodies into typed errors.
+// It returns nil and restores the body when the response does not carry a structured error.
+func decodeServerError(response *http.Response) error {
+	body, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read response body, %w", err)
+	}
+
+	if response.StatusCode == http.StatusPaymentRequired {
+		var terms payment.PaymentTerms
+		if err := json.Unmarshal(body, &terms); err == nil && terms.SatoshisRequired > 0 {
+			return &PaymentRequiredError{Terms: terms}
+		}
+	}
+
+	var errResponse transport.ErrorResponse
+	if err := json.Unmarshal(body, &errResponse); err == nil && errResponse.Status == "error" && errResponse.Code != "" {
+		if errResponse.Code == transport.ErrCodeExchangeAborted && errResponse.Aborted != nil {
+			return &transport.ExchangeAbortedError{Message: errResponse.Aborted}
+		}
+		if errResponse.Code == transport.ErrCodeCertificatesRequired {
+			certErr := &CertificateRequiredError{}
+			if errResponse.RequestedCertificates != nil {
+				certErr.Missing = *errResponse.RequestedCertificates
+			}
+			return certErr
+		}
+
+		serverErr := &ServerError{
+			StatusCode:   response.StatusCode,
+			Code:         errResponse.Code,
+			Description:  errResponse.Description,
+			Certificates: errResponse.Certificates,
+		}
+		if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			serverErr.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return serverErr
+	}
+
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	return nil
+}
+
+// unpadResponse removes the padding of signed responses, responses the server did not sign (e.g. of failed handshakes)
+// are not padded
+func unpadResponse(response *http.Response) error {
+	if response.Header.Get(signatureHeader) == "" {
+		return nil
+	}
+
+	body, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read response body, %w", err)
+	}
+
+	body, err = transport.UnpadPayload(body)
+	if err != nil {
+		return err
+	}
+
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	response.ContentLength = int64(len(body))
+	return nil
+}
+
+func decryptResponse(requestWallet wallet.WalletInterface, response *http.Response) error {
+	body, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read response body, %w", err)
+	}
+
+	if len(body) > 0 && response.Header.Get(signatureHeader) != "" {
+		body, err = utils.DecryptResponseBody(requestWallet, response.Header, body)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt response body, %w", err)
+		}
+	}
+
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	response.ContentLength = int64(len(body))
+	return nil
+}
+
+// identityKeySet returns the lower-case set of the identity keys, nil when there are none
+func identityKeySet(keys []string) map[string]struct{} {
+	if len(keys) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		set[strings.ToLower(key)] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,236 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	tests := map[string]struct {
+		cfg         client.Config
+		expectedErr error
+	}{
+		"missing wallet": {
+			cfg:         client.Config{BaseURL: "http://localhost"},
+			expectedErr: client.ErrWalletRequired,
+		},
+		"missing base URL": {
+			cfg:         client.Config{Wallet: newClientWallet(t)},
+			expectedErr: client.ErrBaseURLRequired,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// when
+			authClient, err := client.New(test.cfg)
+
+			// then
+			require.ErrorIs(t, err, test.expectedErr)
+			require.Nil(t, authClient)
+		})
+	}
+}
+
+func TestClient_Handshake(t *testing.T) {
+	serverKey, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	otherKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		cfg         client.Config
+		grant       bool
+		modify      func(initialResponse *transport.AuthMessage)
+		expectedErr error
+	}{
+		"signed initial response is accepted": {},
+		"granted encryption is accepted": {
+			cfg:   client.Config{PayloadEncryption: true},
+			grant: true,
+		},
+		"pinned server identity is accepted": {
+			cfg: client.Config{PinnedIdentityKeys: []string{serverKey.PubKey().ToDERHex()}},
+		},
+		"server identity which is not pinned is rejected": {
+			cfg:         client.Config{PinnedIdentityKeys: []string{otherKey.PubKey().ToDERHex()}},
+			expectedErr: client.ErrServerIdentityNotPinned,
+		},
+		"unexpected message type is rejected": {
+			modify: func(initialResponse *transport.AuthMessage) {
+				initialResponse.MessageType = transport.General
+			},
+			expectedErr: client.ErrUnexpectedMessageType,
+		},
+		"initial response with tampered nonce is rejected": {
+			modify: func(initialResponse *transport.AuthMessage) {
+				initialResponse.InitialNonce = wallettest.DefaultNonces[1]
+			},
+			expectedErr: client.ErrInvalidServerSignature,
+		},
+		"initial response to another request is rejected": {
+			modify: func(initialResponse *transport.AuthMessage) {
+				yourNonce := wallettest.DefaultNonces[1]
+				initialResponse.YourNonce = &yourNonce
+			},
+			expectedErr: client.ErrInvalidServerSignature,
+		},
+		"encryption which is not granted is rejected": {
+			cfg:         client.Config{PayloadEncryption: true},
+			expectedErr: utils.ErrPayloadEncryptionNotGranted,
+		},
+		"encryption which is not signed is rejected": {
+			cfg: client.Config{PayloadEncryption: true},
+			modify: func(initialResponse *transport.AuthMessage) {
+				initialResponse.PayloadEncryption = true
+			},
+			expectedErr: client.ErrInvalidServerSignature,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			server := newHandshakeServer(t, serverKey, test.grant, test.modify, nil)
+
+			cfg := test.cfg
+			cfg.Wallet = newClientWallet(t)
+			cfg.BaseURL = server.URL
+			authClient, err := client.New(cfg)
+			require.NoError(t, err)
+
+			// when
+			err = authClient.Handshake(context.Background())
+
+			// then
+			if test.expectedErr != nil {
+				require.ErrorIs(t, err, test.expectedErr)
+				require.Empty(t, authClient.ServerIdentityKey())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, serverKey.PubKey().ToDERHex(), authClient.ServerIdentityKey())
+		})
+	}
+}
+
+func TestClient_Do(t *testing.T) {
+	serverKey, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	t.Run("unsigned response is rejected", func(t *testing.T) {
+		// given
+		server := newHandshakeServer(t, serverKey, false, nil, func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("Pong!"))
+		})
+		authClient, err := client.New(client.Config{Wallet: newClientWallet(t), BaseURL: server.URL})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL+"/ping", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Do(request)
+
+		// then
+		require.ErrorIs(t, err, client.ErrResponseNotSigned)
+		require.Nil(t, response)
+	})
+
+	t.Run("unsigned error response is returned", func(t *testing.T) {
+		// given
+		server := newHandshakeServer(t, serverKey, false, nil, func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		})
+		authClient, err := client.New(client.Config{Wallet: newClientWallet(t), BaseURL: server.URL})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL+"/ping", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Do(request)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+		require.NoError(t, response.Body.Close())
+	})
+}
+
+func newClientWallet(t *testing.T) wallet.WalletInterface {
+	key, err := ec.PrivateKeyFromHex(wallettest.ClientPrivateKeyHex)
+	require.NoError(t, err)
+	return wallettest.NewMockWallet(key, wallettest.ClientNonces...)
+}
+
+// newHandshakeServer starts a server answering the initial requests with an initialResponse signed with the key,
+// modify changes the response after it was signed and general handles the other requests
+func newHandshakeServer(
+	t *testing.T,
+	key *ec.PrivateKey,
+	grantEncryption bool,
+	modify func(initialResponse *transport.AuthMessage),
+	general http.HandlerFunc,
+) *httptest.Server {
+	serverWallet := wallettest.NewMockWallet(key, wallettest.DefaultNonces...)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/auth", func(w http.ResponseWriter, req *http.Request) {
+		var initialRequest transport.AuthMessage
+		if err := json.NewDecoder(req.Body).Decode(&initialRequest); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		sessionNonce, err := serverWallet.CreateNonce(req.Context())
+		require.NoError(t, err)
+
+		initialResponse := transport.AuthMessage{
+			Version:           transport.AuthVersion,
+			MessageType:       transport.InitialResponse,
+			IdentityKey:       key.PubKey().ToDERHex(),
+			InitialNonce:      sessionNonce,
+			YourNonce:         &initialRequest.InitialNonce,
+			PayloadEncryption: grantEncryption && initialRequest.PayloadEncryption,
+		}
+
+		clientKey, err := ec.PublicKeyFromString(initialRequest.IdentityKey)
+		require.NoError(t, err)
+
+		signature, err := serverWallet.CreateSignature(&wallet.CreateSignatureArgs{
+			EncryptionArgs: wallet.EncryptionArgs{
+				ProtocolID:   wallet.DefaultAuthProtocol,
+				Counterparty: wallet.Counterparty{Type: wallet.CounterpartyTypeOther, Counterparty: clientKey},
+				KeyID:        initialRequest.InitialNonce + sessionNonce,
+			},
+			Data: transport.HandshakeSigningPayload(initialRequest.InitialNonce, sessionNonce, initialResponse.GrantedCapabilities()),
+		}, "")
+		require.NoError(t, err)
+
+		serialized := signature.Signature.Serialize()
+		initialResponse.Signature = &serialized
+		if modify != nil {
+			modify(&initialResponse)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(w).Encode(initialResponse))
+	})
+	if general != nil {
+		mux.HandleFunc("/", general)
+	}
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
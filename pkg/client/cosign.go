@@ -0,0 +1,56 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+)
+
+// verifyCoSignedResponse checks a response co-signed by a tenant identity answers the request and carries valid
+// signatures of both the server and the co-signer over the response payload. The server signature covers the
+// co-signer identity key, so a response cannot be re-attributed to another tenant. Once verified, the
+// utils.CoSignerIdentityKeyHeader of the response names the tenant which co-signed it.
+func (c *Client) verifyCoSignedResponse(verifier authcore.SignatureVerifier, response *http.Response, serverIdentityKey, requestID string) error {
+	header := response.Header
+	if header.Get(identityKeyHeader) != serverIdentityKey || header.Get(requestIDHeader) != requestID {
+		return fmt.Errorf("%w: response does not answer the request", ErrInvalidCoSignature)
+	}
+
+	coSignerKey := header.Get(utils.CoSignerIdentityKeyHeader)
+	if c.trustedCoSigners != nil {
+		if _, ok := c.trustedCoSigners[strings.ToLower(coSignerKey)]; !ok {
+			return fmt.Errorf("%w: %s", ErrCoSignerNotTrusted, coSignerKey)
+		}
+	}
+
+	signature, coSignature := header.Get(signatureHeader), header.Get(utils.CoSignatureHeader)
+	if signature == "" || coSignature == "" {
+		return fmt.Errorf("%w: signature is missing", ErrInvalidCoSignature)
+	}
+
+	body, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read response body, %w", err)
+	}
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	payload, err := utils.BuildSignedResponsePayload(requestID, response.StatusCode, header, body)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidCoSignature, err)
+	}
+
+	keyID := header.Get(nonceHeader) + " " + header.Get(yourNonceHeader)
+	if err := authcore.VerifySignature(verifier, serverIdentityKey, keyID, payload, signature); err != nil {
+		return fmt.Errorf("%w: server signature: %w", ErrInvalidCoSignature, err)
+	}
+	if err := authcore.VerifySignature(verifier, coSignerKey, keyID, payload, coSignature); err != nil {
+		return fmt.Errorf("%w: co-signature: %w", ErrInvalidCoSignature, err)
+	}
+	return nil
+}
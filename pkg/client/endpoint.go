@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+type contextKey string
+
+const expectedPriceKey contextKey = "expected-price"
+
+// Endpoint describes an endpoint of the server and its auth requirements, as published in the OpenAPI document
+// of the server (see auth.RouteRegistry). Clients generated by cmd/bsv-client-gen declare one per operation.
+type Endpoint struct {
+	// Method is the HTTP method of the endpoint
+	Method string
+	// Path is the OpenAPI path template of the endpoint, e.g. "/items/{id}"
+	Path string
+	// Authentication is "required", "optional" or "none", requests of "none" endpoints are sent without auth headers
+	Authentication string
+	// Anonymous declares whether the endpoint accepts anonymous sessions
+	Anonymous bool
+	// Certificates are the certificates the endpoint demands, nil when it demands none
+	Certificates *transport.RequestedCertificateSet
+	// Price is the price in satoshis of requests of the endpoint
+	Price int
+	// DynamicPrice declares a price which depends on the request, announced in the 402 response
+	DynamicPrice bool
+}
+
+// CertificatePrompt is called before the first call of an endpoint demanding certificates, so the application can prompt
+// the user to acquire them, the call fails with the returned error
+type CertificatePrompt func(ctx context.Context, endpoint Endpoint) error
+
+// Call sends a request to the endpoint, the params replace the "{name}" segments of its path.
+// Payments above the price declared by endpoints without a dynamic price are refused with ErrUnexpectedPrice.
+func (c *Client) Call(ctx context.Context, endpoint Endpoint, params map[string]string, body io.Reader) (*http.Response, error) {
+	if endpoint.Certificates != nil && c.certificatePrompt != nil {
+		if err := c.promptCertificates(ctx, endpoint); err != nil {
+			return nil, err
+		}
+	}
+
+	path := endpoint.Path
+	for name, value := range params {
+		path = strings.ReplaceAll(path, "{"+name+"}", url.PathEscape(value))
+	}
+
+	if !endpoint.DynamicPrice {
+		ctx = context.WithValue(ctx, expectedPriceKey, endpoint.Price)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, endpoint.Method, c.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request, %w", err)
+	}
+
+	if endpoint.Authentication == "none" {
+		return c.httpClient.Do(req)
+	}
+	return c.Do(req)
+}
+
+// promptCertificates calls the certificate prompt once per endpoint, failed prompts are repeated on the next call
+func (c *Client) promptCertificates(ctx context.Context, endpoint Endpoint) error {
+	key := endpoint.Method + " " + endpoint.Path
+
+	c.promptedMu.Lock()
+	defer c.promptedMu.Unlock()
+
+	if _, ok := c.promptedEndpoints[key]; ok {
+		return nil
+	}
+
+	if err := c.certificatePrompt(ctx, endpoint); err != nil {
+		return err
+	}
+
+	c.promptedEndpoints[key] = struct{}{}
+	return nil
+}
+
+// checkExpectedPrice refuses payments above the price declared by the endpoint called with Call
+func checkExpectedPrice(ctx context.Context, satoshis int) error {
+	expected, ok := ctx.Value(expectedPriceKey).(int)
+	if ok && satoshis > expected {
+		return fmt.Errorf("%w: %d satoshis requested, %d declared", ErrUnexpectedPrice, satoshis, expected)
+	}
+	return nil
+}
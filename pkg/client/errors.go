@@ -0,0 +1,120 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/payment"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// Client errors
+var (
+	ErrWalletRequired             = errors.New("wallet is required")
+	ErrBaseURLRequired            = errors.New("base URL is required")
+	ErrUnexpectedMessageType      = errors.New("unexpected handshake response message type")
+	ErrInvalidServerSignature     = errors.New("invalid server handshake signature")
+	ErrServerIdentityNotPinned    = errors.New("server identity key does not match pinned identity keys")
+	ErrServerIdentityChanged      = errors.New("server identity key differs from the one recorded on first use")
+	ErrIdentityStoreNotConfigured = errors.New("identity store is not configured")
+	ErrResponseNotSigned          = errors.New("response is not signed by the server")
+	ErrInvalidResponseSignature   = errors.New("invalid signature of response")
+	ErrPaymentLimitExceeded       = errors.New("payment limit exceeded")
+	ErrPaymentNotApproved         = errors.New("payment not approved")
+	ErrQuotesNotSupported         = errors.New("server does not support payment quotes")
+	ErrInvalidTermsSignature      = errors.New("payment terms are not signed by the server")
+	ErrOriginNotApproved          = errors.New("origin not approved")
+	ErrUnexpectedPrice            = errors.New("price exceeds the price declared by the endpoint")
+	ErrCapabilityNotNegotiated    = errors.New("capability not negotiated with the server")
+	ErrNoSession                  = errors.New("no session with the server, the handshake was not performed")
+	ErrInvalidTrailerSignature    = errors.New("invalid signature trailer of streamed response")
+	ErrInvalidCoSignature         = errors.New("invalid signature of co-signed response")
+	ErrCoSignerNotTrusted         = errors.New("co-signer identity key is not trusted")
+)
+
+// Errors decoded from the server error responses, use errors.Is to branch on them
+var (
+	ErrSessionExpired      = errors.New("session expired")
+	ErrPaymentRequired     = errors.New("payment required")
+	ErrCertificateRequired = errors.New("certificate required")
+	ErrServerMaintenance   = errors.New("server under maintenance")
+	ErrServerWalletTimeout = errors.New("server wallet timed out")
+	ErrServerReadTimeout   = errors.New("server did not receive the request in time")
+	ErrRateLimited         = errors.New("rate limited by the server")
+	ErrPaymentTermsExpired = errors.New("payment terms expired")
+	// ErrRequoteRequired is returned when payment terms expired, were redeemed or the price changed, new terms have to be requested
+	ErrRequoteRequired = errors.New("payment terms are no longer valid, request new terms")
+)
+
+// ServerError is a structured error returned by the server
+type ServerError struct {
+	StatusCode  int
+	Code        string
+	Description string
+	// RetryAfter is the delay requested by the server in the Retry-After header, zero when not set
+	RetryAfter time.Duration
+	// Certificates lists the rejected certificates with their reasons when the server rejected certificates
+	Certificates transport.CertificateErrors
+}
+
+// Error implements the error interface
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("server responded with status %d (%s): %s", e.StatusCode, e.Code, e.Description)
+}
+
+// Is reports whether the server error code matches the target error
+func (e *ServerError) Is(target error) bool {
+	switch e.Code {
+	case transport.ErrCodeSessionNotFound:
+		return target == ErrSessionExpired
+	case payment.ErrCodePaymentRequired:
+		return target == ErrPaymentRequired
+	case transport.ErrCodeMaintenance:
+		return target == ErrServerMaintenance
+	case transport.ErrCodeWalletTimeout:
+		return target == ErrServerWalletTimeout
+	case transport.ErrCodeReadTimeout:
+		return target == ErrServerReadTimeout
+	case transport.ErrCodeRateLimited:
+		return target == ErrRateLimited
+	case payment.ErrCodeTermsExpired:
+		return target == ErrPaymentTermsExpired || target == ErrRequoteRequired
+	case payment.ErrCodeTermsOutdated, payment.ErrCodeTermsRedeemed:
+		return target == ErrRequoteRequired
+	default:
+		return false
+	}
+}
+
+// CertificateRequiredError is returned when the server requires certificates which were not provided
+type CertificateRequiredError struct {
+	// Missing is the set of certificates requested by the server
+	Missing transport.RequestedCertificateSet
+}
+
+// Error implements the error interface
+func (e *CertificateRequiredError) Error() string {
+	return fmt.Sprintf("%s: certifiers %v", ErrCertificateRequired.Error(), e.Missing.Certifiers)
+}
+
+// Is reports whether the target is ErrCertificateRequired
+func (e *CertificateRequiredError) Is(target error) bool {
+	return target == ErrCertificateRequired
+}
+
+// PaymentRequiredError is returned when the server requires a payment for the request
+type PaymentRequiredError struct {
+	// Terms are the payment terms sent by the server
+	Terms payment.PaymentTerms
+}
+
+// Error implements the error interface
+func (e *PaymentRequiredError) Error() string {
+	return fmt.Sprintf("%s: %d satoshis", ErrPaymentRequired.Error(), e.Terms.SatoshisRequired)
+}
+
+// Is reports whether the target is ErrPaymentRequired
+func (e *PaymentRequiredError) Is(target error) bool {
+	return target == ErrPaymentRequired
+}
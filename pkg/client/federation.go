@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// ExchangeAllowlist sends the local allowlist of the registry to the server in a signed AllowlistExchange message
+// and pins the allowlist the server responds with, so both deployments trust each other's services afterwards.
+// The server has to be a partner of the registry, otherwise nothing is sent and ErrFederationNotAllowed is returned.
+// Servers which did not negotiate the allowlist exchange capability are reported with ErrCapabilityNotNegotiated.
+func (c *Client) ExchangeAllowlist(ctx context.Context, registry *transport.TrustRegistry) (*transport.FederationAllowlist, error) {
+	session, err := c.negotiatedSession(ctx, transport.CapabilityAllowlistExchange)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := registry.Partner(session.IdentityKey); !ok {
+		return nil, transport.ErrFederationNotAllowed
+	}
+
+	msg, err := transport.SignAllowlistExchange(ctx, c.wallet, session.IdentityKey, session.InitialNonce, registry.Local())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign allowlist exchange, %w", err)
+	}
+
+	reply, err := c.sendSessionMessage(ctx, session, msg, "allowlist exchange")
+	if err != nil {
+		return nil, err
+	}
+
+	if reply.MessageType != transport.AllowlistExchange {
+		return nil, ErrUnexpectedMessageType
+	}
+	if reply.IdentityKey != session.IdentityKey {
+		return nil, ErrInvalidServerSignature
+	}
+	allowlist, err := transport.VerifyAllowlistExchange(c.wallet, reply)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidServerSignature, err)
+	}
+
+	if err := registry.Pin(session.IdentityKey, *allowlist, c.clock()); err != nil {
+		return nil, err
+	}
+	return allowlist, nil
+}
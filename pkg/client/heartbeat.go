@@ -0,0 +1,158 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/websocket"
+)
+
+// Heartbeat refreshes the session on the server with a signed heartbeat, performing the handshake first if there is
+// no session yet. It is cheaper than a general request and keeps idle sessions from expiring.
+// Servers which did not negotiate the heartbeat capability are reported with ErrCapabilityNotNegotiated.
+func (c *Client) Heartbeat(ctx context.Context) error {
+	session, msg, err := c.signHeartbeat(ctx)
+	if err != nil {
+		return err
+	}
+
+	ack, err := c.sendSessionMessage(ctx, session, msg, "heartbeat")
+	if err != nil {
+		return err
+	}
+
+	return c.verifyHeartbeatAck(session, ack)
+}
+
+// sendSessionMessage posts the message signed with the session to the handshake endpoint and returns the response,
+// what names the message in errors. A session the server no longer knows is reset.
+func (c *Client) sendSessionMessage(ctx context.Context, session, msg *transport.AuthMessage, what string) (*transport.AuthMessage, error) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s, %w", what, err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+handshakePath, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s request, %w", what, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	response, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send %s, %w", what, err)
+	}
+	defer func() {
+		_ = response.Body.Close()
+	}()
+
+	if response.StatusCode != http.StatusOK {
+		if err := decodeServerError(response); err != nil {
+			if errors.Is(err, ErrSessionExpired) {
+				c.resetSession(session)
+			}
+			return nil, err
+		}
+		return nil, fmt.Errorf("%s failed with status %d", what, response.StatusCode)
+	}
+
+	var reply transport.AuthMessage
+	if err := json.NewDecoder(response.Body).Decode(&reply); err != nil {
+		return nil, fmt.Errorf("failed to decode %s response, %w", what, err)
+	}
+	return &reply, nil
+}
+
+// HeartbeatWebSocket sends a signed heartbeat over the socket, the server refreshes the session the socket is bound to.
+// The acknowledgement of the server is consumed by sockets dialed with DialWebSocket.
+func (c *Client) HeartbeatWebSocket(ctx context.Context, conn *websocket.Conn) error {
+	_, msg, err := c.signHeartbeat(ctx)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode heartbeat, %w", err)
+	}
+	return conn.WriteMessage(websocket.TextMessage, payload)
+}
+
+// KeepAlive sends a heartbeat every interval until the context is done or a heartbeat fails, over the socket when
+// conn is not nil, otherwise over HTTP. It returns the error of the failed heartbeat, or nil when the context is done.
+func (c *Client) KeepAlive(ctx context.Context, interval time.Duration, conn *websocket.Conn) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		var err error
+		if conn != nil {
+			err = c.HeartbeatWebSocket(ctx, conn)
+		} else {
+			err = c.Heartbeat(ctx)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// signHeartbeat returns the session, performing the handshake first if there is no session yet, and a heartbeat of it
+func (c *Client) signHeartbeat(ctx context.Context) (*transport.AuthMessage, *transport.AuthMessage, error) {
+	session, err := c.negotiatedSession(ctx, transport.CapabilityHeartbeat)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	msg, err := transport.SignHeartbeat(ctx, c.wallet, session.IdentityKey, session.InitialNonce, c.clock())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign heartbeat, %w", err)
+	}
+	return session, msg, nil
+}
+
+// negotiatedSession returns the session, performing the handshake first if there is no session yet,
+// sessions which did not negotiate the capability are reported with ErrCapabilityNotNegotiated
+func (c *Client) negotiatedSession(ctx context.Context, capability transport.Capability) (*transport.AuthMessage, error) {
+	c.mu.Lock()
+	if c.session == nil {
+		if err := c.handshake(ctx); err != nil {
+			c.mu.Unlock()
+			return nil, err
+		}
+	}
+	session := c.session
+	c.mu.Unlock()
+
+	if !slices.Contains(session.Capabilities, capability) {
+		return nil, fmt.Errorf("%w: %s", ErrCapabilityNotNegotiated, capability)
+	}
+	return session, nil
+}
+
+// verifyHeartbeatAck checks that the acknowledgement of a heartbeat is signed by the server of the session
+func (c *Client) verifyHeartbeatAck(session, ack *transport.AuthMessage) error {
+	if ack.MessageType != transport.Heartbeat {
+		return ErrUnexpectedMessageType
+	}
+	if ack.IdentityKey != session.IdentityKey {
+		return ErrInvalidServerSignature
+	}
+	if _, err := transport.VerifyHeartbeat(c.wallet, ack); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidServerSignature, err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package client
+
+import "sync"
+
+// IdentityStore records server identity keys on first contact, so later handshakes can detect identity changes (trust on first use)
+type IdentityStore interface {
+	// Get returns the identity key recorded for the host
+	Get(host string) (string, bool)
+	// Set records the identity key for the host
+	Set(host, identityKey string) error
+}
+
+// MemoryIdentityStore is an in-memory IdentityStore
+type MemoryIdentityStore struct {
+	mu   sync.RWMutex
+	keys map[string]string
+}
+
+// NewMemoryIdentityStore creates a new in-memory identity store
+func NewMemoryIdentityStore() *MemoryIdentityStore {
+	return &MemoryIdentityStore{keys: make(map[string]string)}
+}
+
+// Get returns the identity key recorded for the host
+func (s *MemoryIdentityStore) Get(host string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.keys[host]
+	return key, ok
+}
+
+// Set records the identity key for the host
+func (s *MemoryIdentityStore) Set(host, identityKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[host] = identityKey
+	return nil
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+)
+
+// Origin describes a server the client is about to sign requests for
+type Origin struct {
+	// Origin is the scheme and host of the requests, e.g. https://api.example.com
+	Origin string
+	// ServerIdentityKey is the identity key the server presented in the handshake
+	ServerIdentityKey string
+}
+
+// Interaction is implemented by interactive wallet integrations, e.g. desktop wallets, which let the user
+// approve the servers the client signs requests for
+type Interaction interface {
+	// ApproveOrigin is called before the first request to an origin is signed,
+	// the request is not sent unless it returns true. Approvals are remembered by the client.
+	ApproveOrigin(ctx context.Context, origin Origin) (bool, error)
+}
+
+// InteractionFunc is an adapter to use a function as an Interaction
+type InteractionFunc func(ctx context.Context, origin Origin) (bool, error)
+
+// ApproveOrigin calls f(ctx, origin)
+func (f InteractionFunc) ApproveOrigin(ctx context.Context, origin Origin) (bool, error) {
+	return f(ctx, origin)
+}
+
+// RevokeOrigin forgets the approval of the origin, so the next request to it is approved again
+func (c *Client) RevokeOrigin(origin string) {
+	c.approvedMu.Lock()
+	defer c.approvedMu.Unlock()
+
+	delete(c.approvedOrigins, origin)
+}
+
+// approveOrigin asks the interaction to approve the origin of the request, unless it was approved before
+func (c *Client) approveOrigin(ctx context.Context, origin, serverIdentityKey string) error {
+	if c.interaction == nil {
+		return nil
+	}
+
+	c.approvedMu.Lock()
+	defer c.approvedMu.Unlock()
+
+	if _, ok := c.approvedOrigins[origin]; ok {
+		return nil
+	}
+
+	approved, err := c.interaction.ApproveOrigin(ctx, Origin{Origin: origin, ServerIdentityKey: serverIdentityKey})
+	if err != nil {
+		return fmt.Errorf("%w, %w", ErrOriginNotApproved, err)
+	}
+	if !approved {
+		return ErrOriginNotApproved
+	}
+
+	c.approvedOrigins[origin] = struct{}{}
+	return nil
+}
+
+// requestOrigin returns the scheme and host of the request URL
+func requestOrigin(u *url.URL) string {
+	return u.Scheme + "://" + u.Host
+}
+
+// walletFor returns the wallet signing and encrypting requests to the origin,
+// which seeks permission for the origin when SeekPermission is enabled
+func (c *Client) walletFor(origin string) wallet.WalletInterface {
+	if !c.seekPermission {
+		return c.wallet
+	}
+	return &permissionWallet{WalletInterface: c.wallet, originator: origin}
+}
+
+// permissionWallet sets SeekPermission and the originator on the wallet operations of a request,
+// so interactive wallets can prompt the user for the origin themselves
+type permissionWallet struct {
+	wallet.WalletInterface
+	originator string
+}
+
+func (w *permissionWallet) CreateSignature(args *wallet.CreateSignatureArgs, _ string) (*wallet.CreateSignatureResult, error) {
+	seeking := *args
+	seeking.SeekPermission = true
+	return w.WalletInterface.CreateSignature(&seeking, w.originator) //nolint:wrapcheck // wallet errors are wrapped by the caller
+}
+
+func (w *permissionWallet) Encrypt(args *wallet.EncryptArgs, _ string) (*wallet.EncryptResult, error) {
+	seeking := *args
+	seeking.SeekPermission = true
+	return w.WalletInterface.Encrypt(&seeking, w.originator) //nolint:wrapcheck // wallet errors are wrapped by the caller
+}
+
+func (w *permissionWallet) Decrypt(args *wallet.DecryptArgs, _ string) (*wallet.DecryptResult, error) {
+	seeking := *args
+	seeking.SeekPermission = true
+	return w.WalletInterface.Decrypt(&seeking, w.originator) //nolint:wrapcheck // wallet errors are wrapped by the caller
+}
@@ -0,0 +1,31 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Get sends a signed GET request for the path, relative to the base URL of the client
+func (c *Client) Get(ctx context.Context, path string) (*http.Response, error) {
+	return c.sendWithoutBody(ctx, http.MethodGet, path)
+}
+
+// Head sends a signed HEAD request for the path, relative to the base URL of the client.
+// The server signs its response with an absent body, as HEAD responses carry none.
+func (c *Client) Head(ctx context.Context, path string) (*http.Response, error) {
+	return c.sendWithoutBody(ctx, http.MethodHead, path)
+}
+
+// Delete sends a signed DELETE request without body for the path, relative to the base URL of the client
+func (c *Client) Delete(ctx context.Context, path string) (*http.Response, error) {
+	return c.sendWithoutBody(ctx, http.MethodDelete, path)
+}
+
+func (c *Client) sendWithoutBody(ctx context.Context, method, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request, %w", err)
+	}
+	return c.Do(req)
+}
@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/payment"
+)
+
+// Payer constructs payments for requests which the server rejected with 402 Payment Required.
+// Implementations usually create and sign a transaction with the wallet (CreateAction/SignAction)
+// paying the required satoshis to the key derived from the derivation prefix in the terms.
+// payment.LockingScriptForKey and payment.FindPaymentOutputs help to build and check the payment output.
+type Payer interface {
+	Pay(ctx context.Context, terms payment.PaymentTerms, serverIdentityKey string) (*payment.Payment, error)
+}
+
+// PayerFunc adapts an ordinary function to the Payer interface
+type PayerFunc func(ctx context.Context, terms payment.PaymentTerms, serverIdentityKey string) (*payment.Payment, error)
+
+// Pay calls f(ctx, terms, serverIdentityKey)
+func (f PayerFunc) Pay(ctx context.Context, terms payment.PaymentTerms, serverIdentityKey string) (*payment.Payment, error) {
+	return f(ctx, terms, serverIdentityKey)
+}
+
+// PaymentLimits caps the satoshis the client pays automatically, so a malicious or misconfigured server can't drain the wallet
+type PaymentLimits struct {
+	// MaxPerRequest is the maximum number of satoshis paid for a single request, zero disables the limit
+	MaxPerRequest int
+	// MaxPerHost is the maximum number of satoshis paid to a single host over the client lifetime, zero disables the limit
+	MaxPerHost int
+}
+
+// SpentSatoshis returns the number of satoshis paid to the host
+func (c *Client) SpentSatoshis(host string) int {
+	c.spentMu.Lock()
+	defer c.spentMu.Unlock()
+
+	return c.spent[host]
+}
+
+// reserveSpend checks the payment against the limits and records it as spent
+func (c *Client) reserveSpend(host string, satoshis int) error {
+	if c.paymentLimits.MaxPerRequest > 0 && satoshis > c.paymentLimits.MaxPerRequest {
+		return fmt.Errorf("%w: %d satoshis requested, %d allowed per request", ErrPaymentLimitExceeded, satoshis, c.paymentLimits.MaxPerRequest)
+	}
+
+	c.spentMu.Lock()
+	defer c.spentMu.Unlock()
+
+	if c.paymentLimits.MaxPerHost > 0 && c.spent[host]+satoshis > c.paymentLimits.MaxPerHost {
+		return fmt.Errorf("%w: %d satoshis requested, %d of %d already spent on %s", ErrPaymentLimitExceeded, satoshis, c.spent[host], c.paymentLimits.MaxPerHost, host)
+	}
+
+	c.spent[host] += satoshis
+	return nil
+}
+
+// releaseSpend reverts a reserved payment which was not made
+func (c *Client) releaseSpend(host string, satoshis int) {
+	c.spentMu.Lock()
+	defer c.spentMu.Unlock()
+
+	c.spent[host] -= satoshis
+}
@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	wstransport "github.com/bsv-blockchain/go-bsv-middleware/pkg/transport/websocket"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/websocket"
+)
+
+// Socket is a WebSocket connection to a server authenticated by the BRC-103 handshake sent over the socket.
+// Requests are sent as signed general messages and multiplexed over the connection with correlation IDs,
+// the server answers them with websocket.ServeRequests over the connection accepted by its WebSocket transport.
+type Socket struct {
+	conn      *wstransport.Conn
+	requester *websocket.Requester
+}
+
+// DialSocket dials the WebSocket endpoint of the server at the URL (ws:// or wss://) and performs the handshake
+// with the identity of the wallet, the context bounds the dial and the handshake
+func DialSocket(ctx context.Context, url string, w wallet.WalletInterface) (*Socket, error) {
+	return DialSocketWithHeader(ctx, url, w, nil)
+}
+
+// DialSocketWithHeader is DialSocket sending the header along with the upgrade request, e.g. Origin
+func DialSocketWithHeader(ctx context.Context, url string, w wallet.WalletInterface, header http.Header) (*Socket, error) {
+	conn, err := wstransport.Connect(ctx, url, w, header)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the errors of the transport are returned as is
+	}
+
+	return &Socket{conn: conn, requester: websocket.NewRequester(conn)}, nil
+}
+
+// Request sends the payload and waits for the response of the server, the request is abandoned when the context
+// is done. A request the server failed is reported with websocket.ErrRequestFailed.
+func (s *Socket) Request(ctx context.Context, payload []byte) ([]byte, error) {
+	return s.requester.Request(ctx, payload) //nolint:wrapcheck // the errors of the requester are returned as is
+}
+
+// Heartbeat sends a signed heartbeat, which keeps the session of the socket alive on the server without a request
+func (s *Socket) Heartbeat() error {
+	return s.conn.Heartbeat() //nolint:wrapcheck // the errors of the transport are returned as is
+}
+
+// ServerIdentityKey returns the identity key of the server authenticated by the handshake
+func (s *Socket) ServerIdentityKey() string {
+	return s.conn.PeerIdentityKey()
+}
+
+// Close closes the connection, pending requests fail with websocket.ErrRequesterClosed
+func (s *Socket) Close() error {
+	return s.requester.Close() //nolint:wrapcheck // the error of the connection is returned as is
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+)
+
+// trailerVerifier hashes the body of a response streamed by the server and verifies its signature trailer once
+// the body was read completely, reading the end of a body whose signature is missing or invalid fails with
+// ErrInvalidTrailerSignature instead of io.EOF
+type trailerVerifier struct {
+	body              io.ReadCloser
+	hash              hash.Hash
+	response          *http.Response
+	verifier          authcore.SignatureVerifier
+	serverIdentityKey string
+	requestID         string
+	err               error
+}
+
+func (v *trailerVerifier) Read(p []byte) (int, error) {
+	if v.err != nil {
+		return 0, v.err
+	}
+
+	n, err := v.body.Read(p)
+	v.hash.Write(p[:n])
+	if errors.Is(err, io.EOF) {
+		if verifyErr := v.verify(); verifyErr != nil {
+			err = verifyErr
+		}
+		v.err = err
+	}
+	return n, err //nolint:wrapcheck // io.EOF is returned as is
+}
+
+func (v *trailerVerifier) Close() error {
+	return v.body.Close() //nolint:wrapcheck // the body is closed as is
+}
+
+// verify checks the streamed response answers the request and was signed by the server over the hash of its body
+func (v *trailerVerifier) verify() error {
+	header := v.response.Header
+	if header.Get(identityKeyHeader) != v.serverIdentityKey || header.Get(requestIDHeader) != v.requestID {
+		return fmt.Errorf("%w: response does not answer the request", ErrInvalidTrailerSignature)
+	}
+
+	signature := v.response.Trailer.Get(signatureHeader)
+	if signature == "" {
+		return fmt.Errorf("%w: signature trailer is missing", ErrInvalidTrailerSignature)
+	}
+
+	payload, err := utils.BuildStreamedResponsePayload(v.requestID, v.response.StatusCode, header, v.hash.Sum(nil))
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidTrailerSignature, err)
+	}
+
+	keyID := header.Get(nonceHeader) + " " + header.Get(yourNonceHeader)
+	if err := authcore.VerifySignature(v.verifier, v.serverIdentityKey, keyID, payload, signature); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidTrailerSignature, err)
+	}
+	return nil
+}
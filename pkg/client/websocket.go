@@ -0,0 +1,52 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/websocket"
+)
+
+// DialWebSocket sends a signed request upgrading the connection to WebSocket, performing the handshake first if there
+// is no session yet, so the socket is authenticated by the session of the REST requests of the client.
+// The signature of the 101 Switching Protocols response is verified like the one of every response.
+// Acknowledgements of heartbeats sent over the socket are not returned by ReadMessage.
+func (c *Client) DialWebSocket(ctx context.Context, path string) (*websocket.Conn, error) {
+	return c.DialWebSocketWithHeader(ctx, path, nil)
+}
+
+// DialWebSocketWithHeader is DialWebSocket sending the header along with the upgrade request, e.g. Origin or
+// Sec-WebSocket-Protocol, which are signed with the request, see authcore.WebSocketSignedHeaders.
+func (c *Client) DialWebSocketWithHeader(ctx context.Context, path string, header http.Header) (*websocket.Conn, error) {
+	// the signature covers a single value per header, repeated headers are sent as one joined line
+	joined := make(http.Header, len(header))
+	for name, values := range header {
+		joined.Set(name, strings.Join(values, ", "))
+	}
+
+	req, key, err := websocket.NewUpgradeRequest(ctx, c.baseURL+path, joined)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the errors of the websocket package are returned as is
+	}
+
+	response, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := websocket.ClientConn(ctx, response, key)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the errors of the websocket package are returned as is
+	}
+	conn.SetInterceptor(func(messageType int, message []byte) bool {
+		// acknowledgements of the heartbeats sent by HeartbeatWebSocket
+		if messageType != websocket.TextMessage {
+			return false
+		}
+		_, ok := transport.ParseHeartbeat(message)
+		return ok
+	})
+	return conn, nil
+}
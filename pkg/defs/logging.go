@@ -1,5 +1,7 @@
 package defs
 
+import "fmt"
+
 // LogLevel represents different log levels which can be configured.
 type LogLevel string
 
@@ -29,3 +31,53 @@ const (
 func ParseHandlerTypeStr(handlerType string) (LogHandler, error) {
 	return parseEnumCaseInsensitive(handlerType, JSONHandler, TextHandler)
 }
+
+// LogSubsystem represents a part of the middleware whose logs can be configured separately.
+type LogSubsystem string
+
+// Supported subsystems.
+const (
+	// LogSubsystemTransport covers handshakes and general requests handled by the HTTP transport
+	LogSubsystemTransport LogSubsystem = "transport"
+	// LogSubsystemSession covers creation, binding and authentication of peer sessions
+	LogSubsystemSession LogSubsystem = "session"
+	// LogSubsystemCertificates covers certificate requests and responses
+	LogSubsystemCertificates LogSubsystem = "certificates"
+	// LogSubsystemPayments covers payment processing and refunds of the payment middleware
+	LogSubsystemPayments LogSubsystem = "payments"
+)
+
+// ParseLogSubsystemStr parses a string into a LogSubsystem (case-insensitive).
+func ParseLogSubsystemStr(subsystem string) (LogSubsystem, error) {
+	return parseEnumCaseInsensitive(subsystem, LogSubsystemTransport, LogSubsystemSession, LogSubsystemCertificates, LogSubsystemPayments)
+}
+
+// SubsystemLogging configures the logs of a single subsystem.
+type SubsystemLogging struct {
+	// Level is the minimum level of logged events, empty keeps the level of the base logger.
+	// It can be lower than the level of the base logger for handlers which filter in Enabled, such as the slog text and JSON handlers.
+	Level LogLevel
+	// DebugSampleRate logs only every n-th occurrence of each debug message, zero or one logs every occurrence.
+	DebugSampleRate int
+}
+
+// LogConfig configures levels and sampling of logs per subsystem, subsystems without an entry log like the base logger.
+type LogConfig map[LogSubsystem]SubsystemLogging
+
+// Validate checks that only supported subsystems and levels are configured.
+func (c LogConfig) Validate() error {
+	for subsystem, cfg := range c {
+		if _, err := ParseLogSubsystemStr(string(subsystem)); err != nil {
+			return fmt.Errorf("invalid log subsystem, %w", err)
+		}
+		if cfg.Level != "" {
+			if _, err := ParseLogLevelStr(string(cfg.Level)); err != nil {
+				return fmt.Errorf("invalid log level of %s subsystem, %w", subsystem, err)
+			}
+		}
+		if cfg.DebugSampleRate < 0 {
+			return fmt.Errorf("invalid debug sample rate of %s subsystem: %d", subsystem, cfg.DebugSampleRate)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+// Package events is the event bus decoupling the subsystems of the middleware. The auth middleware, the HTTP transport
+// and the payment middleware publish what happened to the requests and sessions they handle, consumers such as metrics,
+// audit logs, webhooks and anomaly detection subscribe to the topics they need instead of being called by the publishers.
+package events
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Topic groups the events of a subsystem
+type Topic string
+
+// Topics of the events published by the middlewares
+const (
+	// TopicAuth carries the requests handled by the auth middleware and the reports of certificate exchanges
+	TopicAuth Topic = "auth"
+	// TopicSession carries the sessions created and authenticated by handshakes
+	TopicSession Topic = "session"
+	// TopicPayment carries the payments accepted and refunded by the payment middleware
+	TopicPayment Topic = "payment"
+)
+
+// Types of the events, the data of each type is documented along with it
+const (
+	// TypeRequest is published on TopicAuth once the auth middleware handled a request, its data is auth.RequestEvent
+	TypeRequest = "request"
+	// TypeVerificationReport is published on TopicAuth for the report of every certificate exchange when
+	// verification reports are enabled, its data is transport.VerificationReport
+	TypeVerificationReport = "verificationReport"
+	// TypeSessionCreated is published on TopicSession when an initial request created a session,
+	// its data is session.PeerSession
+	TypeSessionCreated = "created"
+	// TypeSessionAuthenticated is published on TopicSession when the certificates of a session were accepted,
+	// its data is session.PeerSession
+	TypeSessionAuthenticated = "authenticated"
+	// TypePaymentAccepted is published on TopicPayment when a payment was accepted, its data is payment.PaymentInfo
+	TypePaymentAccepted = "accepted"
+	// TypePaymentRefund is published on TopicPayment when a paid request failed and its refund was decided,
+	// its data is payment.Refund
+	TypePaymentRefund = "refund"
+)
+
+// Event is something that happened in a subsystem
+type Event struct {
+	Topic Topic
+	Type  string
+	// Time is when the event happened, set by Publish when zero
+	Time time.Time
+	// IdentityKey is the identity key of the peer the event is about, empty for unauthenticated requests
+	IdentityKey string
+	// Request is the request the event was published for, nil for events which are not published for a request
+	Request *http.Request
+	// Data is the data of the type of the event
+	Data any
+}
+
+// Redactor masks the personal data carried by events, e.g. the certificate fields of sessions, before they are delivered
+type Redactor interface {
+	// RedactEventData returns a redacted copy of the data of the event, data without personal data is returned unchanged
+	RedactEventData(data any) any
+}
+
+// Handler receives the events of the topics it subscribed to
+type Handler func(ctx context.Context, event Event)
+
+type subscriber struct {
+	id      uint64
+	handler Handler
+}
+
+// Bus delivers published events to the subscribers of their topic. Handlers are called synchronously in the order
+// they subscribed, on the goroutine of the publisher, so they have to return quickly and hand slow work, e.g. webhook
+// deliveries, off to their own goroutines. A bus shared by several middlewares delivers the events of all of them.
+// The data of every event is redacted in Publish, so no subscriber receives personal data the redactor masks.
+// It is safe for concurrent use, a nil bus drops every event.
+type Bus struct {
+	mu          sync.RWMutex
+	nextID      uint64
+	subscribers map[Topic][]subscriber
+	redactor    Redactor
+}
+
+// NewBus creates a bus without subscribers which redacts the data of events with the redactor,
+// e.g. auth.EventRedactor, the data is delivered unchanged when the redactor is nil
+func NewBus(redactor Redactor) *Bus {
+	return &Bus{subscribers: make(map[Topic][]subscriber), redactor: redactor}
+}
+
+// Subscribe calls the handler with the events published on the topic until the returned function is called
+func (b *Bus) Subscribe(topic Topic, handler Handler) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.nextID++
+	id := b.nextID
+	b.subscribers[topic] = append(b.subscribers[topic], subscriber{id: id, handler: handler})
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.subscribers[topic] = deleteSubscriber(b.subscribers[topic], id)
+	}
+}
+
+// Publish delivers the event with redacted data to the subscribers of its topic
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	subscribers := b.subscribers[event.Topic]
+	b.mu.RUnlock()
+	if len(subscribers) == 0 {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if b.redactor != nil {
+		event.Data = b.redactor.RedactEventData(event.Data)
+	}
+	for _, s := range subscribers {
+		s.handler(ctx, event)
+	}
+}
+
+// deleteSubscriber returns a copy of the subscribers without the one with the id,
+// so publishers iterating the previous slice are not affected
+func deleteSubscriber(subscribers []subscriber, id uint64) []subscriber {
+	kept := make([]subscriber, 0, len(subscribers))
+	for _, s := range subscribers {
+		if s.id != id {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,122 @@
+package events_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/events"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBus(t *testing.T) {
+	t.Run("events are delivered to the subscribers of their topic in order", func(t *testing.T) {
+		// given
+		bus := events.NewBus(nil)
+		var received []string
+		bus.Subscribe(events.TopicAuth, func(_ context.Context, event events.Event) {
+			received = append(received, "first "+event.Type)
+		})
+		bus.Subscribe(events.TopicAuth, func(_ context.Context, event events.Event) {
+			received = append(received, "second "+event.Type)
+		})
+		bus.Subscribe(events.TopicPayment, func(_ context.Context, event events.Event) {
+			received = append(received, "payment "+event.Type)
+		})
+
+		// when
+		bus.Publish(context.Background(), events.Event{Topic: events.TopicAuth, Type: events.TypeRequest})
+
+		// then
+		require.Equal(t, []string{"first request", "second request"}, received)
+	})
+
+	t.Run("publish sets the time of events without time", func(t *testing.T) {
+		// given
+		bus := events.NewBus(nil)
+		var received events.Event
+		bus.Subscribe(events.TopicSession, func(_ context.Context, event events.Event) {
+			received = event
+		})
+
+		// when
+		bus.Publish(context.Background(), events.Event{Topic: events.TopicSession, Type: events.TypeSessionCreated, Data: 1})
+
+		// then
+		require.False(t, received.Time.IsZero())
+		require.Equal(t, 1, received.Data)
+	})
+
+	t.Run("unsubscribed handlers receive no events", func(t *testing.T) {
+		// given
+		bus := events.NewBus(nil)
+		var received int
+		unsubscribe := bus.Subscribe(events.TopicAuth, func(context.Context, events.Event) {
+			received++
+		})
+		bus.Publish(context.Background(), events.Event{Topic: events.TopicAuth})
+
+		// when
+		unsubscribe()
+		bus.Publish(context.Background(), events.Event{Topic: events.TopicAuth})
+
+		// then
+		require.Equal(t, 1, received)
+	})
+
+	t.Run("handlers can unsubscribe while the event is delivered", func(t *testing.T) {
+		// given
+		bus := events.NewBus(nil)
+		var received []string
+		var unsubscribe func()
+		unsubscribe = bus.Subscribe(events.TopicAuth, func(context.Context, events.Event) {
+			received = append(received, "once")
+			unsubscribe()
+		})
+		bus.Subscribe(events.TopicAuth, func(context.Context, events.Event) {
+			received = append(received, "always")
+		})
+
+		// when
+		bus.Publish(context.Background(), events.Event{Topic: events.TopicAuth})
+		bus.Publish(context.Background(), events.Event{Topic: events.TopicAuth})
+
+		// then
+		require.Equal(t, []string{"once", "always", "always"}, received)
+	})
+
+	t.Run("nil bus drops events", func(t *testing.T) {
+		// given
+		var bus *events.Bus
+
+		// then
+		require.NotPanics(t, func() {
+			bus.Publish(context.Background(), events.Event{Topic: events.TopicAuth})
+		})
+	})
+}
+
+type upperRedactor struct{}
+
+func (upperRedactor) RedactEventData(data any) any {
+	if s, ok := data.(string); ok {
+		return strings.ToUpper(s)
+	}
+	return data
+}
+
+func TestBus_Redactor(t *testing.T) {
+	// given
+	bus := events.NewBus(upperRedactor{})
+	var received []any
+	bus.Subscribe(events.TopicSession, func(_ context.Context, event events.Event) {
+		received = append(received, event.Data)
+	})
+
+	// when
+	bus.Publish(context.Background(), events.Event{Topic: events.TopicSession, Type: events.TypeSessionCreated, Data: "alice"})
+	bus.Publish(context.Background(), events.Event{Topic: events.TopicSession, Type: events.TypeSessionCreated, Data: 1})
+
+	// then
+	require.Equal(t, []any{"ALICE", 1}, received)
+}
@@ -0,0 +1,305 @@
+// Package httpclient provides the resilient HTTP client shared by the outbound integrations of the middleware,
+// e.g. chain trackers, policy engines and usage webhooks, so that each of them retries the same way.
+package httpclient
+
+import (
+	"context"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults of the RetryPolicy
+const (
+	DefaultMaxAttempts    = 3
+	DefaultInitialBackoff = 100 * time.Millisecond
+	DefaultMaxBackoff     = 5 * time.Second
+	DefaultMultiplier     = 2.0
+	DefaultJitter         = 0.2
+)
+
+// maxDrainSize limits how much of a discarded response is read so that its connection can be reused
+const maxDrainSize = 4 << 10
+
+// RetryPolicy configures how failed requests are retried, zero values use the defaults
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first one, 1 disables retries
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between attempts, including delays requested with Retry-After
+	MaxBackoff time.Duration
+	// Multiplier grows the delay after every retry
+	Multiplier float64
+	// Jitter is the fraction of the delay which is randomized, e.g. 0.2 waits between 80% and 120% of the delay.
+	// A negative jitter waits exactly the delay.
+	Jitter float64
+	// Retryable decides whether an attempt is retried, defaults to RetryableResponse
+	Retryable func(resp *http.Response, err error) bool
+}
+
+// RetryableResponse retries transport errors and the 429, 502, 503 and 504 statuses
+func RetryableResponse(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = DefaultMaxAttempts
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = DefaultInitialBackoff
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = DefaultMaxBackoff
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = DefaultMultiplier
+	}
+	if p.Jitter == 0 {
+		p.Jitter = DefaultJitter
+	}
+	if p.Retryable == nil {
+		p.Retryable = RetryableResponse
+	}
+	return p
+}
+
+// backoff returns the delay before the given retry, starting at 1
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	delay := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(retry-1))
+	if p.Jitter > 0 {
+		delay *= 1 + p.Jitter*(2*rand.Float64()-1) //nolint:gosec // jitter needs no cryptographic randomness
+	}
+	return min(time.Duration(delay), p.MaxBackoff)
+}
+
+// Budget limits retries to a share of the requests, so that retries do not multiply the load of a struggling service.
+// Every request deposits ratio tokens up to the capacity and every retry withdraws one token,
+// retries are skipped while less than one token is left. A nil budget allows every retry.
+type Budget struct {
+	mu       sync.Mutex
+	tokens   float64
+	capacity float64
+	ratio    float64
+}
+
+// NewBudget creates a full budget allowing ratio retries per request with a burst of capacity retries
+func NewBudget(ratio float64, capacity int) *Budget {
+	return &Budget{tokens: float64(capacity), capacity: float64(capacity), ratio: ratio}
+}
+
+func (b *Budget) deposit() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.tokens = min(b.tokens+b.ratio, b.capacity)
+	b.mu.Unlock()
+}
+
+func (b *Budget) withdraw() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Config configures the client
+type Config struct {
+	// Retry configures the retries of failed requests
+	Retry RetryPolicy
+	// Budget limits the retries, nil allows every retry permitted by the policy
+	Budget *Budget
+	// Timeout limits each request including its retries, zero means no limit
+	Timeout time.Duration
+	// Transport sends the attempts, defaults to a transport with the connection pool configured by Pool
+	Transport http.RoundTripper
+	// Pool tunes the connection pool, it is ignored when Transport is set
+	Pool PoolConfig
+}
+
+// Stats are the metrics of a Transport
+type Stats struct {
+	// Requests counts the requests sent through the transport
+	Requests uint64
+	// Attempts counts the attempts including retries
+	Attempts uint64
+	// Retries counts the attempts after the first one
+	Retries uint64
+	// Failures counts the requests which failed or were answered with a retryable status after the last attempt
+	Failures uint64
+	// BudgetExhausted counts the retries skipped because the budget was exhausted
+	BudgetExhausted uint64
+	// ConnsReused counts the attempts sent on an idle connection of the pool
+	ConnsReused uint64
+	// ConnsCreated counts the attempts which needed a new connection
+	ConnsCreated uint64
+	// DNSLookups counts the DNS lookups and DNSLookupTime is their total duration
+	DNSLookups    uint64
+	DNSLookupTime time.Duration
+	// Connects counts the established TCP connections and ConnectTime is their total dial duration
+	Connects    uint64
+	ConnectTime time.Duration
+	// TLSHandshakes counts the completed TLS handshakes and TLSHandshakeTime is their total duration
+	TLSHandshakes    uint64
+	TLSHandshakeTime time.Duration
+}
+
+// Transport is an http.RoundTripper retrying failed attempts with exponential backoff and jitter.
+// Requests with a body are only retried when the body can be replayed with GetBody, which
+// http.NewRequest sets for in-memory bodies.
+type Transport struct {
+	base   http.RoundTripper
+	policy RetryPolicy
+	budget *Budget
+
+	requests        atomic.Uint64
+	attempts        atomic.Uint64
+	retries         atomic.Uint64
+	failures        atomic.Uint64
+	budgetExhausted atomic.Uint64
+	conns           connStats
+}
+
+// NewTransport creates a retrying transport
+func NewTransport(cfg Config) *Transport {
+	base := cfg.Transport
+	if base == nil {
+		base = newPooledTransport(cfg.Pool)
+	}
+
+	return &Transport{
+		base:   base,
+		policy: cfg.Retry.withDefaults(),
+		budget: cfg.Budget,
+	}
+}
+
+// New creates an HTTP client sending its requests through a retrying transport
+func New(cfg Config) *http.Client {
+	return &http.Client{Transport: NewTransport(cfg), Timeout: cfg.Timeout}
+}
+
+var defaultClient = New(Config{Budget: NewBudget(0.1, 10)})
+
+// Default returns the client shared by the outbound integrations which are not configured with a client.
+// It retries with the default policy within a budget of one retry per ten requests.
+func Default() *http.Client {
+	return defaultClient
+}
+
+// Stats returns the metrics of the transport
+func (t *Transport) Stats() Stats {
+	return Stats{
+		Requests:         t.requests.Load(),
+		Attempts:         t.attempts.Load(),
+		Retries:          t.retries.Load(),
+		Failures:         t.failures.Load(),
+		BudgetExhausted:  t.budgetExhausted.Load(),
+		ConnsReused:      t.conns.reused.Load(),
+		ConnsCreated:     t.conns.created.Load(),
+		DNSLookups:       t.conns.dnsLookups.Load(),
+		DNSLookupTime:    time.Duration(t.conns.dnsTime.Load()),
+		Connects:         t.conns.connects.Load(),
+		ConnectTime:      time.Duration(t.conns.connectTime.Load()),
+		TLSHandshakes:    t.conns.tlsHandshakes.Load(),
+		TLSHandshakeTime: time.Duration(t.conns.tlsTime.Load()),
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests.Add(1)
+	t.budget.deposit()
+
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	for attempt := 1; ; attempt++ {
+		attemptReq := req.WithContext(httptrace.WithClientTrace(req.Context(), t.conns.trace()))
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err //nolint:wrapcheck // the body error of the caller is returned as is
+			}
+			attemptReq = attemptReq.Clone(attemptReq.Context())
+			attemptReq.Body = body
+		}
+
+		t.attempts.Add(1)
+		resp, err := t.base.RoundTrip(attemptReq)
+		if req.Context().Err() != nil || !t.policy.Retryable(resp, err) {
+			return resp, err //nolint:wrapcheck // errors of the base transport are returned as is
+		}
+		if attempt >= t.policy.MaxAttempts || !replayable {
+			t.failures.Add(1)
+			return resp, err //nolint:wrapcheck // errors of the base transport are returned as is
+		}
+		if !t.budget.withdraw() {
+			t.budgetExhausted.Add(1)
+			t.failures.Add(1)
+			return resp, err //nolint:wrapcheck // errors of the base transport are returned as is
+		}
+
+		delay := t.policy.backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				delay = min(retryAfter, t.policy.MaxBackoff)
+			}
+			_, _ = io.CopyN(io.Discard, resp.Body, maxDrainSize)
+			_ = resp.Body.Close()
+		}
+
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+		t.retries.Add(1)
+	}
+}
+
+// parseRetryAfter parses the delay of a Retry-After header in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+// sleep waits for the delay, it returns the context error when the context is done first
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err() //nolint:wrapcheck // cancellation of the caller is returned as is
+	}
+}
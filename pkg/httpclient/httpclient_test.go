@@ -0,0 +1,232 @@
+package httpclient_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/httpclient"
+	"github.com/stretchr/testify/require"
+)
+
+var fastRetries = httpclient.RetryPolicy{InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond, Jitter: -1}
+
+// failingServer responds with the status to the first failures requests and with 200 afterwards
+func failingServer(t *testing.T, failures int32, status int, calls *atomic.Int32, bodies chan<- string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if bodies != nil {
+			body, _ := io.ReadAll(r.Body)
+			bodies <- string(body)
+		}
+		if calls.Add(1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestTransport_Retries(t *testing.T) {
+	tests := map[string]struct {
+		failures         int32
+		status           int
+		expectedStatus   int
+		expectedCalls    int32
+		expectedFailures uint64
+	}{
+		"succeeds without retry": {
+			failures:       0,
+			status:         http.StatusServiceUnavailable,
+			expectedStatus: http.StatusOK,
+			expectedCalls:  1,
+		},
+		"retries retryable statuses": {
+			failures:       2,
+			status:         http.StatusServiceUnavailable,
+			expectedStatus: http.StatusOK,
+			expectedCalls:  3,
+		},
+		"gives up after the max attempts": {
+			failures:         5,
+			status:           http.StatusBadGateway,
+			expectedStatus:   http.StatusBadGateway,
+			expectedCalls:    3,
+			expectedFailures: 1,
+		},
+		"does not retry client errors": {
+			failures:       5,
+			status:         http.StatusBadRequest,
+			expectedStatus: http.StatusBadRequest,
+			expectedCalls:  1,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			var calls atomic.Int32
+			server := failingServer(t, test.failures, test.status, &calls, nil)
+			transport := httpclient.NewTransport(httpclient.Config{Retry: fastRetries})
+			client := &http.Client{Transport: transport}
+
+			// when
+			response, err := client.Get(server.URL)
+
+			// then
+			require.NoError(t, err)
+			_ = response.Body.Close()
+			require.Equal(t, test.expectedStatus, response.StatusCode)
+			require.Equal(t, test.expectedCalls, calls.Load())
+
+			stats := transport.Stats()
+			require.Equal(t, uint64(1), stats.Requests)
+			require.Equal(t, uint64(test.expectedCalls), stats.Attempts)
+			require.Equal(t, uint64(test.expectedCalls-1), stats.Retries)
+			require.Equal(t, test.expectedFailures, stats.Failures)
+		})
+	}
+}
+
+func TestTransport_ReplaysBody(t *testing.T) {
+	t.Run("replayable body is sent with every attempt", func(t *testing.T) {
+		// given
+		var calls atomic.Int32
+		bodies := make(chan string, 2)
+		server := failingServer(t, 1, http.StatusServiceUnavailable, &calls, bodies)
+		client := httpclient.New(httpclient.Config{Retry: fastRetries})
+
+		// when
+		response, err := client.Post(server.URL, "text/plain", bytes.NewReader([]byte("usage")))
+
+		// then
+		require.NoError(t, err)
+		_ = response.Body.Close()
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, "usage", <-bodies)
+		require.Equal(t, "usage", <-bodies)
+	})
+
+	t.Run("streamed body is not retried", func(t *testing.T) {
+		// given
+		var calls atomic.Int32
+		server := failingServer(t, 1, http.StatusServiceUnavailable, &calls, nil)
+		client := httpclient.New(httpclient.Config{Retry: fastRetries})
+		body := io.NopCloser(bytes.NewReader([]byte("usage")))
+
+		// when
+		response, err := client.Post(server.URL, "text/plain", body)
+
+		// then
+		require.NoError(t, err)
+		_ = response.Body.Close()
+		require.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+		require.Equal(t, int32(1), calls.Load())
+	})
+}
+
+func TestTransport_Budget(t *testing.T) {
+	// given
+	var calls atomic.Int32
+	server := failingServer(t, 100, http.StatusServiceUnavailable, &calls, nil)
+	transport := httpclient.NewTransport(httpclient.Config{Retry: fastRetries, Budget: httpclient.NewBudget(0, 1)})
+	client := &http.Client{Transport: transport}
+
+	// when
+	for range 2 {
+		response, err := client.Get(server.URL)
+		require.NoError(t, err)
+		_ = response.Body.Close()
+	}
+
+	// then
+	require.Equal(t, int32(3), calls.Load())
+	stats := transport.Stats()
+	require.Equal(t, uint64(1), stats.Retries)
+	require.Equal(t, uint64(2), stats.BudgetExhausted)
+	require.Equal(t, uint64(2), stats.Failures)
+}
+
+func TestTransport_RetryAfter(t *testing.T) {
+	// given
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	policy := fastRetries
+	policy.MaxBackoff = 50 * time.Millisecond
+	client := httpclient.New(httpclient.Config{Retry: policy})
+
+	// when
+	started := time.Now()
+	response, err := client.Get(server.URL)
+
+	// then
+	require.NoError(t, err)
+	_ = response.Body.Close()
+	require.Equal(t, http.StatusOK, response.StatusCode)
+	require.GreaterOrEqual(t, time.Since(started), 50*time.Millisecond, "Retry-After capped by the max backoff should be waited")
+	require.Less(t, time.Since(started), time.Second, "Retry-After should be capped by the max backoff")
+}
+
+func TestTransport_ContextCanceledDuringBackoff(t *testing.T) {
+	// given
+	var calls atomic.Int32
+	server := failingServer(t, 100, http.StatusServiceUnavailable, &calls, nil)
+	client := httpclient.New(httpclient.Config{Retry: httpclient.RetryPolicy{InitialBackoff: time.Minute, MaxBackoff: time.Minute}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	// when
+	_, err = client.Do(request)
+
+	// then
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestTransport_ConnectionStats(t *testing.T) {
+	// given
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	serverTransport, ok := server.Client().Transport.(*http.Transport)
+	require.True(t, ok)
+	transport := httpclient.NewTransport(httpclient.Config{
+		Pool: httpclient.PoolConfig{MaxIdleConnsPerHost: 1, TLSConfig: serverTransport.TLSClientConfig},
+	})
+	client := &http.Client{Transport: transport}
+
+	// when
+	for range 3 {
+		response, err := client.Get(server.URL)
+		require.NoError(t, err)
+		_, _ = io.Copy(io.Discard, response.Body)
+		_ = response.Body.Close()
+	}
+
+	// then
+	stats := transport.Stats()
+	require.Equal(t, uint64(1), stats.ConnsCreated)
+	require.Equal(t, uint64(2), stats.ConnsReused)
+	require.Equal(t, uint64(1), stats.Connects)
+	require.Equal(t, uint64(1), stats.TLSHandshakes)
+	require.Positive(t, stats.TLSHandshakeTime)
+}
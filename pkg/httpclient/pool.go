@@ -0,0 +1,123 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+)
+
+// Defaults of the PoolConfig, chosen for a few upstream hosts called on the hot path of requests
+const (
+	DefaultMaxIdleConns        = 100
+	DefaultMaxIdleConnsPerHost = 16
+	DefaultIdleConnTimeout     = 90 * time.Second
+	DefaultDialTimeout         = 5 * time.Second
+	DefaultTLSHandshakeTimeout = 5 * time.Second
+)
+
+// PoolConfig tunes the connection pool of the transport created when Config.Transport is not set,
+// zero values use the defaults
+type PoolConfig struct {
+	// MaxIdleConns limits the idle connections to all hosts
+	MaxIdleConns int
+	// MaxIdleConnsPerHost limits the idle connections kept per host. The net/http default of 2 makes
+	// bursts of concurrent calls to a remote wallet open new connections, so it is raised by default.
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost limits the connections per host including active ones, zero means no limit
+	MaxConnsPerHost int
+	// IdleConnTimeout closes connections idle for longer
+	IdleConnTimeout time.Duration
+	// DialTimeout limits establishing a TCP connection
+	DialTimeout time.Duration
+	// TLSHandshakeTimeout limits the TLS handshake
+	TLSHandshakeTimeout time.Duration
+	// ResponseHeaderTimeout limits waiting for the response headers after the request was written, zero means no limit
+	ResponseHeaderTimeout time.Duration
+	// TLSConfig configures the TLS client, nil uses the default configuration
+	TLSConfig *tls.Config
+}
+
+// newPooledTransport creates a transport with the tuned connection pool
+func newPooledTransport(cfg PoolConfig) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone() //nolint:forcetypeassert // the default transport of net/http is an *http.Transport
+
+	transport.MaxIdleConns = positiveOr(cfg.MaxIdleConns, DefaultMaxIdleConns)
+	transport.MaxIdleConnsPerHost = positiveOr(cfg.MaxIdleConnsPerHost, DefaultMaxIdleConnsPerHost)
+	transport.MaxConnsPerHost = cfg.MaxConnsPerHost
+	transport.IdleConnTimeout = positiveOr(cfg.IdleConnTimeout, DefaultIdleConnTimeout)
+	transport.TLSHandshakeTimeout = positiveOr(cfg.TLSHandshakeTimeout, DefaultTLSHandshakeTimeout)
+	transport.ResponseHeaderTimeout = cfg.ResponseHeaderTimeout
+	transport.DialContext = (&net.Dialer{
+		Timeout:   positiveOr(cfg.DialTimeout, DefaultDialTimeout),
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	if cfg.TLSConfig != nil {
+		transport.TLSClientConfig = cfg.TLSConfig.Clone()
+	}
+
+	return transport
+}
+
+func positiveOr[T int | time.Duration](value, fallback T) T {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
+
+// connStats instruments the connections used by the attempts of a transport
+type connStats struct {
+	reused        atomic.Uint64
+	created       atomic.Uint64
+	dnsLookups    atomic.Uint64
+	dnsTime       atomic.Int64
+	connects      atomic.Uint64
+	connectTime   atomic.Int64
+	tlsHandshakes atomic.Uint64
+	tlsTime       atomic.Int64
+}
+
+// trace returns the client trace recording the connection of a single attempt
+func (s *connStats) trace() *httptrace.ClientTrace {
+	var dnsStart, tlsStart time.Time
+	// dual-stack dials connect to several addresses concurrently, the first start is measured
+	var connectStart atomic.Int64
+
+	return &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				s.reused.Add(1)
+			} else {
+				s.created.Add(1)
+			}
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			s.dnsLookups.Add(1)
+			s.dnsTime.Add(int64(time.Since(dnsStart)))
+		},
+		ConnectStart: func(string, string) {
+			connectStart.CompareAndSwap(0, time.Now().UnixNano())
+		},
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				s.connects.Add(1)
+				s.connectTime.Add(time.Now().UnixNano() - connectStart.Load())
+			}
+		},
+		TLSHandshakeStart: func() {
+			tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				s.tlsHandshakes.Add(1)
+				s.tlsTime.Add(int64(time.Since(tlsStart)))
+			}
+		},
+	}
+}
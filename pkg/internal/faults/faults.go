@@ -0,0 +1,103 @@
+// Package faults injects failures into the dependencies of the middleware on demand, so chaos tests can assert
+// the middleware degrades as its fail-open and fail-closed policies promise when the wallet, the session store
+// or the revocation store is slow or down. Dependencies are wrapped with Wallet, SessionManager and RevocationStore,
+// the wrappers pass every call through until a fault is injected for their target.
+package faults
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrInjected is the error of failing faults without an error of their own
+var ErrInjected = errors.New("injected fault")
+
+// Target is a dependency faults are injected into
+type Target string
+
+// Targets of faults
+const (
+	TargetWallet      Target = "wallet"
+	TargetSessions    Target = "sessions"
+	TargetRevocations Target = "revocations"
+)
+
+// Fault delays and fails the calls of a target
+type Fault struct {
+	// Delay is waited before the call, or until the context of calls taking one is done
+	Delay time.Duration
+	// Fail makes the call fail with Err, or with ErrInjected when Err is nil, after the delay
+	Fail bool
+	Err  error
+}
+
+type key struct {
+	target    Target
+	operation string
+}
+
+// Injector holds the faults injected into the wrapped dependencies, it is safe for concurrent use.
+// Faults can be injected and cleared while requests are served.
+type Injector struct {
+	mu     sync.RWMutex
+	faults map[key]Fault
+}
+
+// NewInjector creates an injector without faults
+func NewInjector() *Injector {
+	return &Injector{faults: make(map[key]Fault)}
+}
+
+// Inject injects the fault into the operation of the target, e.g. "CreateSignature" of TargetWallet,
+// or into every operation of the target when the operation is empty. Faults of an operation take precedence.
+func (i *Injector) Inject(target Target, operation string, fault Fault) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.faults[key{target, operation}] = fault
+}
+
+// Clear removes the fault of the operation of the target, faults of other operations stay injected
+func (i *Injector) Clear(target Target, operation string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	delete(i.faults, key{target, operation})
+}
+
+// Reset removes every fault
+func (i *Injector) Reset() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	clear(i.faults)
+}
+
+// apply waits the delay of the fault of the operation and returns its error, nil without a failing fault
+func (i *Injector) apply(ctx context.Context, target Target, operation string) error {
+	i.mu.RLock()
+	fault, ok := i.faults[key{target, operation}]
+	if !ok {
+		fault, ok = i.faults[key{target, ""}]
+	}
+	i.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	if fault.Delay > 0 {
+		timer := time.NewTimer(fault.Delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err() //nolint:wrapcheck // the caller sees the error of its context
+		}
+	}
+	if !fault.Fail {
+		return nil
+	}
+	if fault.Err != nil {
+		return fault.Err
+	}
+	return ErrInjected
+}
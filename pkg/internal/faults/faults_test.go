@@ -0,0 +1,83 @@
+package faults_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/internal/faults"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevocationStore(t *testing.T) {
+	errOutage := errors.New("outage")
+	subject := transport.RevocationSubject{Kind: transport.RevokedSession, Value: "nonce"}
+
+	tests := map[string]struct {
+		inject   func(injector *faults.Injector)
+		expected error
+	}{
+		"calls pass through without faults": {
+			inject: func(*faults.Injector) {},
+		},
+		"fault of every operation": {
+			inject: func(injector *faults.Injector) {
+				injector.Inject(faults.TargetRevocations, "", faults.Fault{Fail: true})
+			},
+			expected: faults.ErrInjected,
+		},
+		"fault of the operation takes precedence": {
+			inject: func(injector *faults.Injector) {
+				injector.Inject(faults.TargetRevocations, "", faults.Fault{Fail: true})
+				injector.Inject(faults.TargetRevocations, "Contains", faults.Fault{Fail: true, Err: errOutage})
+			},
+			expected: errOutage,
+		},
+		"fault of another operation": {
+			inject: func(injector *faults.Injector) {
+				injector.Inject(faults.TargetRevocations, "List", faults.Fault{Fail: true})
+			},
+		},
+		"fault of another target": {
+			inject: func(injector *faults.Injector) {
+				injector.Inject(faults.TargetWallet, "", faults.Fault{Fail: true})
+			},
+		},
+		"cleared fault": {
+			inject: func(injector *faults.Injector) {
+				injector.Inject(faults.TargetRevocations, "Contains", faults.Fault{Fail: true})
+				injector.Clear(faults.TargetRevocations, "Contains")
+			},
+		},
+		"delay ends with the context": {
+			inject: func(injector *faults.Injector) {
+				injector.Inject(faults.TargetRevocations, "Contains", faults.Fault{Delay: time.Hour})
+			},
+			expected: context.DeadlineExceeded,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			injector := faults.NewInjector()
+			store := faults.RevocationStore(transport.NewMemoryRevocationStore(), injector)
+			require.NoError(t, store.Add(context.Background(), transport.Revocation{RevocationSubject: subject}))
+			test.inject(injector)
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			// when
+			revoked, err := store.Contains(ctx, subject)
+
+			// then
+			if test.expected != nil {
+				require.ErrorIs(t, err, test.expected)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, revoked)
+		})
+	}
+}
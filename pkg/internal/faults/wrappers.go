@@ -0,0 +1,145 @@
+package faults
+
+import (
+	"context"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+)
+
+// Wallet wraps the wallet with the faults of TargetWallet, its operations are named after the methods of
+// wallet.WalletInterface, e.g. "CreateNonce" or "VerifySignature". Calls without a context are delayed in full.
+func Wallet(w wallet.WalletInterface, injector *Injector) wallet.WalletInterface {
+	return &faultyWallet{WalletInterface: w, injector: injector}
+}
+
+type faultyWallet struct {
+	wallet.WalletInterface
+	injector *Injector
+}
+
+func (w *faultyWallet) CreateSignature(args *wallet.CreateSignatureArgs, originator string) (*wallet.CreateSignatureResult, error) {
+	if err := w.injector.apply(context.Background(), TargetWallet, "CreateSignature"); err != nil {
+		return nil, err
+	}
+	return w.WalletInterface.CreateSignature(args, originator) //nolint:wrapcheck // passed through
+}
+
+func (w *faultyWallet) VerifySignature(args *wallet.VerifySignatureArgs) (*wallet.VerifySignatureResult, error) {
+	if err := w.injector.apply(context.Background(), TargetWallet, "VerifySignature"); err != nil {
+		return nil, err
+	}
+	return w.WalletInterface.VerifySignature(args) //nolint:wrapcheck // passed through
+}
+
+func (w *faultyWallet) Encrypt(args *wallet.EncryptArgs, originator string) (*wallet.EncryptResult, error) {
+	if err := w.injector.apply(context.Background(), TargetWallet, "Encrypt"); err != nil {
+		return nil, err
+	}
+	return w.WalletInterface.Encrypt(args, originator) //nolint:wrapcheck // passed through
+}
+
+func (w *faultyWallet) Decrypt(args *wallet.DecryptArgs, originator string) (*wallet.DecryptResult, error) {
+	if err := w.injector.apply(context.Background(), TargetWallet, "Decrypt"); err != nil {
+		return nil, err
+	}
+	return w.WalletInterface.Decrypt(args, originator) //nolint:wrapcheck // passed through
+}
+
+func (w *faultyWallet) CreateNonce(ctx context.Context) (string, error) {
+	if err := w.injector.apply(ctx, TargetWallet, "CreateNonce"); err != nil {
+		return "", err
+	}
+	return w.WalletInterface.CreateNonce(ctx) //nolint:wrapcheck // passed through
+}
+
+func (w *faultyWallet) VerifyNonce(ctx context.Context, nonce string) (bool, error) {
+	if err := w.injector.apply(ctx, TargetWallet, "VerifyNonce"); err != nil {
+		return false, err
+	}
+	return w.WalletInterface.VerifyNonce(ctx, nonce) //nolint:wrapcheck // passed through
+}
+
+// SessionManager wraps the session manager with the faults of TargetSessions, its operations are named after
+// the methods of session.Manager, e.g. "GetSession". The interface cannot report failures,
+// so a failing operation behaves like a store which lost the data: lookups find no session and writes are dropped.
+func SessionManager(sm session.Manager, injector *Injector) session.Manager {
+	return &faultySessionManager{Manager: sm, injector: injector}
+}
+
+type faultySessionManager struct {
+	session.Manager
+	injector *Injector
+}
+
+func (s *faultySessionManager) AddSession(peerSession session.PeerSession) {
+	if s.injector.apply(context.Background(), TargetSessions, "AddSession") == nil {
+		s.Manager.AddSession(peerSession)
+	}
+}
+
+func (s *faultySessionManager) UpdateSession(peerSession session.PeerSession) {
+	if s.injector.apply(context.Background(), TargetSessions, "UpdateSession") == nil {
+		s.Manager.UpdateSession(peerSession)
+	}
+}
+
+func (s *faultySessionManager) GetSession(identifier string) *session.PeerSession {
+	if s.injector.apply(context.Background(), TargetSessions, "GetSession") != nil {
+		return nil
+	}
+	return s.Manager.GetSession(identifier)
+}
+
+func (s *faultySessionManager) RemoveSession(peerSession session.PeerSession) {
+	if s.injector.apply(context.Background(), TargetSessions, "RemoveSession") == nil {
+		s.Manager.RemoveSession(peerSession)
+	}
+}
+
+func (s *faultySessionManager) HasSession(identifier string) bool {
+	if s.injector.apply(context.Background(), TargetSessions, "HasSession") != nil {
+		return false
+	}
+	return s.Manager.HasSession(identifier)
+}
+
+// RevocationStore wraps the store with the faults of TargetRevocations, its operations are named after the methods
+// of transport.RevocationStore, e.g. "Contains"
+func RevocationStore(store transport.RevocationStore, injector *Injector) transport.RevocationStore {
+	return &faultyRevocationStore{store: store, injector: injector}
+}
+
+type faultyRevocationStore struct {
+	store    transport.RevocationStore
+	injector *Injector
+}
+
+func (s *faultyRevocationStore) Add(ctx context.Context, revocation transport.Revocation) error {
+	if err := s.injector.apply(ctx, TargetRevocations, "Add"); err != nil {
+		return err
+	}
+	return s.store.Add(ctx, revocation) //nolint:wrapcheck // passed through
+}
+
+func (s *faultyRevocationStore) Remove(ctx context.Context, subject transport.RevocationSubject) (bool, error) {
+	if err := s.injector.apply(ctx, TargetRevocations, "Remove"); err != nil {
+		return false, err
+	}
+	return s.store.Remove(ctx, subject) //nolint:wrapcheck // passed through
+}
+
+func (s *faultyRevocationStore) Contains(ctx context.Context, subject transport.RevocationSubject) (bool, error) {
+	if err := s.injector.apply(ctx, TargetRevocations, "Contains"); err != nil {
+		return false, err
+	}
+	return s.store.Contains(ctx, subject) //nolint:wrapcheck // passed through
+}
+
+func (s *faultyRevocationStore) List(ctx context.Context) ([]transport.Revocation, error) {
+	if err := s.injector.apply(ctx, TargetRevocations, "List"); err != nil {
+		return nil, err
+	}
+	return s.store.List(ctx) //nolint:wrapcheck // passed through
+}
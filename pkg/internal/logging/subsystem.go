@@ -0,0 +1,89 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/defs"
+)
+
+// SubsystemKey is the attribute holding the subsystem of a log
+const SubsystemKey = "subsystem"
+
+// Subsystem returns a child of the logger for the given subsystem, with the level and debug sampling configured for it.
+func Subsystem(logger *slog.Logger, subsystem defs.LogSubsystem, cfg defs.LogConfig) *slog.Logger {
+	logger = DefaultIfNil(logger)
+
+	subsystemCfg, ok := cfg[subsystem]
+	if ok && (subsystemCfg.Level != "" || subsystemCfg.DebugSampleRate > 1) {
+		handler := &subsystemHandler{handler: logger.Handler()}
+		if subsystemCfg.Level != "" {
+			level := SlogLevel(subsystemCfg.Level)
+			handler.level = &level
+		}
+		if subsystemCfg.DebugSampleRate > 1 {
+			handler.sampler = &sampler{rate: uint64(subsystemCfg.DebugSampleRate)} //nolint:gosec // rate is greater than one
+		}
+		logger = slog.New(handler)
+	}
+
+	return logger.With(slog.String(SubsystemKey, string(subsystem)))
+}
+
+// SlogLevel converts the configured log level to the slog level, unknown levels are treated as info.
+func SlogLevel(level defs.LogLevel) slog.Level {
+	switch defs.LogLevel(strings.ToLower(string(level))) {
+	case defs.LogLevelDebug:
+		return slog.LevelDebug
+	case defs.LogLevelWarn:
+		return slog.LevelWarn
+	case defs.LogLevelError:
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// subsystemHandler overrides the level of the wrapped handler and samples its debug events
+type subsystemHandler struct {
+	handler slog.Handler
+	level   *slog.Level
+	sampler *sampler
+}
+
+func (h *subsystemHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.level != nil {
+		return level >= *h.level
+	}
+	return h.handler.Enabled(ctx, level)
+}
+
+func (h *subsystemHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level <= slog.LevelDebug && h.sampler != nil && !h.sampler.sample(r.Message) {
+		return nil
+	}
+	return h.handler.Handle(ctx, r) //nolint: wrapcheck // the handler is only decorated
+}
+
+func (h *subsystemHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &subsystemHandler{handler: h.handler.WithAttrs(attrs), level: h.level, sampler: h.sampler}
+}
+
+func (h *subsystemHandler) WithGroup(name string) slog.Handler {
+	return &subsystemHandler{handler: h.handler.WithGroup(name), level: h.level, sampler: h.sampler}
+}
+
+// sampler passes every n-th occurrence of each message, starting with the first one
+type sampler struct {
+	rate   uint64
+	counts sync.Map
+}
+
+func (s *sampler) sample(message string) bool {
+	count, _ := s.counts.LoadOrStore(message, &atomic.Uint64{})
+	n := count.(*atomic.Uint64).Add(1)
+	return (n-1)%s.rate == 0
+}
@@ -0,0 +1,84 @@
+package logging_test
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/defs"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/internal/logging"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubsystem(t *testing.T) {
+	newBase := func() (*slog.Logger, *logging.TestWriter) {
+		writer := &logging.TestWriter{}
+		return slog.New(slog.NewJSONHandler(writer, &slog.HandlerOptions{Level: slog.LevelInfo})), writer
+	}
+
+	t.Run("unconfigured subsystem logs like the base logger", func(t *testing.T) {
+		// given
+		base, writer := newBase()
+		logger := logging.Subsystem(base, defs.LogSubsystemTransport, nil)
+
+		// when
+		logger.Debug("debug event")
+		logger.Info("info event")
+
+		// then
+		require.NotContains(t, writer.String(), "debug event")
+		require.Contains(t, writer.String(), "info event")
+		require.Contains(t, writer.String(), `"subsystem":"transport"`)
+	})
+
+	t.Run("subsystem level lower than the base level enables debug logs of the subsystem only", func(t *testing.T) {
+		// given
+		base, writer := newBase()
+		cfg := defs.LogConfig{defs.LogSubsystemCertificates: {Level: defs.LogLevelDebug}}
+		certificates := logging.Subsystem(base, defs.LogSubsystemCertificates, cfg)
+		transport := logging.Subsystem(base, defs.LogSubsystemTransport, cfg)
+
+		// when
+		certificates.Debug("certificate event")
+		transport.Debug("general request event")
+
+		// then
+		require.Contains(t, writer.String(), "certificate event")
+		require.NotContains(t, writer.String(), "general request event")
+	})
+
+	t.Run("subsystem level higher than the base level silences the subsystem", func(t *testing.T) {
+		// given
+		base, writer := newBase()
+		logger := logging.Subsystem(base, defs.LogSubsystemPayments,
+			defs.LogConfig{defs.LogSubsystemPayments: {Level: defs.LogLevelError}})
+
+		// when
+		logger.Warn("warn event")
+		logger.With(slog.String("attr", "value")).Error("error event")
+
+		// then
+		require.NotContains(t, writer.String(), "warn event")
+		require.Contains(t, writer.String(), "error event")
+		require.Contains(t, writer.String(), `"attr":"value"`)
+	})
+
+	t.Run("debug events are sampled per message", func(t *testing.T) {
+		// given
+		base, writer := newBase()
+		logger := logging.Subsystem(base, defs.LogSubsystemTransport,
+			defs.LogConfig{defs.LogSubsystemTransport: {Level: defs.LogLevelDebug, DebugSampleRate: 3}})
+
+		// when
+		for range 7 {
+			logger.Debug("general request")
+			logger.Info("info event")
+		}
+		logger.Debug("other event")
+
+		// then
+		require.Equal(t, 3, strings.Count(writer.String(), "general request"))
+		require.Equal(t, 7, strings.Count(writer.String(), "info event"))
+		require.Equal(t, 1, strings.Count(writer.String(), "other event"))
+	})
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// Outcomes of requests reported in the access log
+const (
+	// AccessOutcomeDiscovery is a request for the discovery document
+	AccessOutcomeDiscovery = "discovery"
+	// AccessOutcomeHandshake is a successful handshake message
+	AccessOutcomeHandshake = "handshake"
+	// AccessOutcomeExempt is a request to a route exempt from authentication
+	AccessOutcomeExempt = "exempt"
+	// AccessOutcomeUnauthenticated is a request without auth headers which was allowed to pass
+	AccessOutcomeUnauthenticated = "unauthenticated"
+	// AccessOutcomeAuthenticated is a verified general request
+	AccessOutcomeAuthenticated = "authenticated"
+	// AccessOutcomeRejected is a handshake message or general request which failed verification
+	AccessOutcomeRejected = "rejected"
+	// AccessOutcomeReportOnly is a general request which failed verification and was passed to the handler
+	// because the middleware runs in report-only mode, see Config.ReportOnly
+	AccessOutcomeReportOnly = "reportOnly"
+	// AccessOutcomeTarpit is a request of an abusive peer answered by the tarpit, see TarpitPolicy
+	AccessOutcomeTarpit = "tarpit"
+	// AccessOutcomeCanceled is a request canceled by the peer before it was verified or its response was signed,
+	// the remaining wallet operations were skipped and no response was written
+	AccessOutcomeCanceled = "canceled"
+)
+
+// accessLog collects the attributes of the access log line of a request
+type accessLog struct {
+	start       time.Time
+	outcome     string
+	identityKey string
+	errorCode   string
+	err         error
+	verify      time.Duration
+	handler     time.Duration
+	sign        time.Duration
+	walletCalls *transport.WalletCallCounter
+	writer      *accessLogWriter
+}
+
+// accessLogWriter records the status and the number of bytes sent to the peer
+type accessLogWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *accessLogWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *accessLogWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController
+func (w *accessLogWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// startAccessLog starts the access log of a request, the returned writer has to be used for the response
+func (m *Middleware) startAccessLog(w http.ResponseWriter) (*accessLog, http.ResponseWriter) {
+	entry := &accessLog{start: time.Now()}
+	if m.accessLogger == nil {
+		return entry, w
+	}
+
+	entry.writer = &accessLogWriter{ResponseWriter: w}
+	return entry, entry.writer
+}
+
+// logAccess emits one structured line for the request
+func (m *Middleware) logAccess(entry *accessLog, req *http.Request) {
+	if m.accessLogger == nil {
+		return
+	}
+
+	status := entry.writer.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.Int("status", status),
+		slog.String("outcome", entry.outcome),
+		slog.Int64("bytesIn", max(req.ContentLength, 0)),
+		slog.Int("bytesOut", entry.writer.bytes),
+		slog.Duration("verify", entry.verify),
+		slog.Duration("handler", entry.handler),
+		slog.Duration("sign", entry.sign),
+		slog.Duration("total", time.Since(entry.start)),
+	}
+	if req.Pattern != "" {
+		attrs = append(attrs, slog.String("route", req.Pattern))
+	}
+	if entry.identityKey != "" {
+		attrs = append(attrs, slog.String("identityKey", entry.identityKey))
+	}
+	if entry.errorCode != "" {
+		attrs = append(attrs, slog.String("code", entry.errorCode))
+	}
+	if calls := entry.walletCalls.Calls(); calls.Total() > 0 {
+		attrs = append(attrs, slog.Group("wallet",
+			slog.Int64("noncesCreated", calls.NoncesCreated),
+			slog.Int64("noncesVerified", calls.NoncesVerified),
+			slog.Int64("signaturesCreated", calls.SignaturesCreated),
+			slog.Int64("signaturesVerified", calls.SignaturesVerified),
+			slog.Int64("encryptions", calls.Encryptions),
+			slog.Int64("decryptions", calls.Decryptions),
+		))
+	}
+
+	m.accessLogger.LogAttrs(req.Context(), slog.LevelInfo, "request", attrs...)
+}
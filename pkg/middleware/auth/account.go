@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Defaults of the account cache
+const (
+	// DefaultAccountCacheTTL is the time for which resolved accounts are cached when no TTL is configured
+	DefaultAccountCacheTTL = 5 * time.Minute
+	// DefaultAccountNegativeCacheTTL is the time for which identities without an account are cached when no TTL is configured
+	DefaultAccountNegativeCacheTTL = 30 * time.Second
+)
+
+type contextKey string
+
+const accountContextKey contextKey = "account"
+
+// AccountResolver maps the identity key of an authenticated peer to an account of the application, e.g. with a database lookup
+type AccountResolver interface {
+	// ResolveAccount returns the account of the identity key, or ErrAccountNotFound when the identity has no account
+	ResolveAccount(ctx context.Context, identityKey string) (any, error)
+}
+
+// AccountResolverFunc adapts a function to the AccountResolver interface
+type AccountResolverFunc func(ctx context.Context, identityKey string) (any, error)
+
+// ResolveAccount calls the function
+func (f AccountResolverFunc) ResolveAccount(ctx context.Context, identityKey string) (any, error) {
+	return f(ctx, identityKey)
+}
+
+// GetAccountFromContext retrieves the account resolved by the AccountResolver from the request context,
+// it reports false when no resolver is configured or the identity has no account
+func GetAccountFromContext(ctx context.Context) (any, bool) {
+	account := ctx.Value(accountContextKey)
+	return account, account != nil
+}
+
+type accountCacheEntry struct {
+	account   any
+	expiresAt time.Time
+}
+
+// accountCache caches the accounts returned by the resolver, identities without an account are cached
+// for the negative TTL and resolver failures are not cached
+type accountCache struct {
+	resolver    AccountResolver
+	ttl         time.Duration
+	negativeTTL time.Duration
+
+	mu        sync.Mutex
+	entries   map[string]accountCacheEntry
+	lastPrune time.Time
+}
+
+func newAccountCache(resolver AccountResolver, ttl, negativeTTL time.Duration) *accountCache {
+	if resolver == nil {
+		return nil
+	}
+
+	if ttl == 0 {
+		ttl = DefaultAccountCacheTTL
+	}
+	if negativeTTL == 0 {
+		negativeTTL = DefaultAccountNegativeCacheTTL
+	}
+
+	return &accountCache{
+		resolver:    resolver,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		entries:     make(map[string]accountCacheEntry),
+	}
+}
+
+// resolve returns the account of the identity key, nil when the identity has no account
+func (c *accountCache) resolve(ctx context.Context, identityKey string, now time.Time) (any, error) {
+	if entry, ok := c.lookup(identityKey, now); ok {
+		return entry.account, nil
+	}
+
+	account, err := c.resolver.ResolveAccount(ctx, identityKey)
+	switch {
+	case errors.Is(err, ErrAccountNotFound), err == nil && account == nil:
+		c.store(identityKey, nil, c.negativeTTL, now)
+		return nil, nil
+	case err != nil:
+		return nil, fmt.Errorf("%w, %w", ErrAccountUnavailable, err)
+	default:
+		c.store(identityKey, account, c.ttl, now)
+		return account, nil
+	}
+}
+
+func (c *accountCache) lookup(identityKey string, now time.Time) (accountCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[identityKey]
+	if !ok || !now.Before(entry.expiresAt) {
+		return accountCacheEntry{}, false
+	}
+	return entry, true
+}
+
+// store caches the result for the TTL, a negative TTL disables caching
+func (c *accountCache) store(identityKey string, account any, ttl time.Duration, now time.Time) {
+	if ttl < 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.lastPrune) >= max(c.ttl, c.negativeTTL) {
+		for key, entry := range c.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(c.entries, key)
+			}
+		}
+		c.lastPrune = now
+	}
+
+	c.entries[identityKey] = accountCacheEntry{account: account, expiresAt: now.Add(ttl)}
+}
+
+// invalidate drops the cached account of the identity key
+func (c *accountCache) invalidate(identityKey string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, identityKey)
+}
+
+// withAccount stores the account of the authenticated peer in the request context
+func (m *Middleware) withAccount(req *http.Request) (*http.Request, error) {
+	if m.accounts == nil {
+		return req, nil
+	}
+
+	identityKey, ok := GetIdentityFromContext(req.Context())
+	if !ok || identityKey == "" {
+		return req, nil
+	}
+
+	account, err := m.accounts.resolve(req.Context(), identityKey, time.Now())
+	if err != nil || account == nil {
+		return req, err
+	}
+
+	return req.WithContext(context.WithValue(req.Context(), accountContextKey, account)), nil
+}
+
+// InvalidateAccount drops the cached account of the identity key, so the next request resolves it again,
+// e.g. after the account was created or changed
+func (m *Middleware) InvalidateAccount(identityKey string) {
+	if m.accounts != nil {
+		m.accounts.invalidate(identityKey)
+	}
+}
@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/events"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// DefaultAnomalyWindow is the window in which verification failures are counted when none is configured
+const DefaultAnomalyWindow = time.Minute
+
+// Kinds of keys verification failures are tracked for
+const (
+	// AnomalyKindIdentity tracks failures by the identity key claimed in the request headers
+	AnomalyKindIdentity = "identity"
+	// AnomalyKindAddress tracks failures by the network address of the peer
+	AnomalyKindAddress = "address"
+)
+
+// AnomalyPolicy tracks streaks of verification failures, e.g. bad signatures or unknown nonces, per identity key
+// and per peer address. A key reaching its threshold within the window is reported to OnAnomaly and locked out:
+// handshakes and general requests of a locked out key are rejected with 429 and Retry-After until the lockout ends.
+// A verified request ends the streak of its identity key and address.
+//
+// The identity key of a rejected request is the one claimed in its headers, so anyone can make the failures
+// of an identity key reach its threshold. An identity lockout therefore also locks out its legitimate owner,
+// set IdentityLockout to zero to only report identity anomalies. Identity keys of known peers are never tracked.
+type AnomalyPolicy struct {
+	// IdentityThreshold is the number of failures per identity key within the window triggering an anomaly, zero disables it
+	IdentityThreshold int
+	// AddressThreshold is the number of failures per peer address within the window triggering an anomaly, zero disables it
+	AddressThreshold int
+	// Window is the time in which failures are counted, defaults to DefaultAnomalyWindow
+	Window time.Duration
+	// IdentityLockout is the cooldown of identity keys reaching the threshold, zero only reports the anomaly
+	IdentityLockout time.Duration
+	// AddressLockout is the cooldown of peer addresses reaching the threshold, zero only reports the anomaly
+	AddressLockout time.Duration
+	// OnAnomaly is called when a key reaches its threshold and returns its lockout, overriding the configured one,
+	// e.g. to escalate repeated anomalies or exempt addresses of trusted proxies
+	OnAnomaly func(anomaly Anomaly) time.Duration
+}
+
+// Anomaly is a key whose verification failures reached the threshold of the AnomalyPolicy
+type Anomaly struct {
+	// Kind is AnomalyKindIdentity or AnomalyKindAddress
+	Kind string
+	// Key is the identity key or the peer address
+	Key string
+	// Failures is the number of failures in the window
+	Failures int
+	// Lockout is the configured lockout of the kind
+	Lockout time.Duration
+}
+
+type failureStreak struct {
+	windowStart time.Time
+	failures    int
+	lockedUntil time.Time
+}
+
+// anomalyDetector tracks the failure streaks of an AnomalyPolicy
+type anomalyDetector struct {
+	policy     AnomalyPolicy
+	knownPeers *transport.KnownPeers
+
+	mu        sync.Mutex
+	streaks   map[anomalyKey]*failureStreak
+	lastSweep time.Time
+}
+
+type anomalyKey struct {
+	kind string
+	key  string
+}
+
+// newAnomalyDetector creates the detector of the policy, it tracks the outcomes of the requests published on the bus
+func newAnomalyDetector(policy *AnomalyPolicy, knownPeers *transport.KnownPeers, bus *events.Bus) *anomalyDetector {
+	if policy == nil || (policy.IdentityThreshold <= 0 && policy.AddressThreshold <= 0) {
+		return nil
+	}
+
+	p := *policy
+	if p.Window <= 0 {
+		p.Window = DefaultAnomalyWindow
+	}
+	d := &anomalyDetector{policy: p, knownPeers: knownPeers, streaks: make(map[anomalyKey]*failureStreak)}
+	requestEvents(bus, func(req *http.Request, event RequestEvent) {
+		switch {
+		case event.Outcome == AccessOutcomeRejected && event.Err != nil:
+			d.failure(req, event.Err)
+		case event.Outcome == AccessOutcomeAuthenticated:
+			d.success(req, event.IdentityKey)
+		}
+	})
+	return d
+}
+
+// lockedOut returns the remaining lockout of the identity key claimed by the request or of its peer address
+func (d *anomalyDetector) lockedOut(req *http.Request, now time.Time) time.Duration {
+	if d == nil {
+		return 0
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var remaining time.Duration
+	for _, key := range d.keys(req, req.Header.Get(identityKeyHeader)) {
+		if streak := d.streaks[key]; streak != nil {
+			remaining = max(remaining, streak.lockedUntil.Sub(now))
+		}
+	}
+	return remaining
+}
+
+// failure counts a failed verification of the request and reports the keys reaching their threshold
+func (d *anomalyDetector) failure(req *http.Request, err error) {
+	if d == nil || transport.ErrorStatus(err) >= http.StatusInternalServerError {
+		// failures of the server, e.g. wallet timeouts, are not the fault of the peer
+		return
+	}
+
+	now := time.Now()
+	var anomalies []Anomaly
+
+	d.mu.Lock()
+	d.sweep(now)
+	for _, key := range d.keys(req, req.Header.Get(identityKeyHeader)) {
+		threshold, lockout := d.policy.IdentityThreshold, d.policy.IdentityLockout
+		if key.kind == AnomalyKindAddress {
+			threshold, lockout = d.policy.AddressThreshold, d.policy.AddressLockout
+		}
+		if threshold <= 0 {
+			continue
+		}
+
+		streak := d.streaks[key]
+		if streak == nil {
+			streak = &failureStreak{}
+			d.streaks[key] = streak
+		}
+		if now.Sub(streak.windowStart) >= d.policy.Window {
+			streak.windowStart = now
+			streak.failures = 0
+		}
+
+		streak.failures++
+		if streak.failures >= threshold {
+			anomalies = append(anomalies, Anomaly{Kind: key.kind, Key: key.key, Failures: streak.failures, Lockout: lockout})
+			// the next streak starts with the anomaly, so failures after a short lockout are counted again
+			streak.windowStart = now
+			streak.failures = 0
+		}
+	}
+	d.mu.Unlock()
+
+	for _, anomaly := range anomalies {
+		lockout := anomaly.Lockout
+		if d.policy.OnAnomaly != nil {
+			lockout = d.policy.OnAnomaly(anomaly)
+		}
+		if lockout <= 0 {
+			continue
+		}
+
+		d.mu.Lock()
+		if streak := d.streaks[anomalyKey{kind: anomaly.Kind, key: anomaly.Key}]; streak != nil {
+			streak.lockedUntil = now.Add(lockout)
+		}
+		d.mu.Unlock()
+	}
+}
+
+// success ends the failure streaks of the identity key and the peer address of a verified request
+func (d *anomalyDetector) success(req *http.Request, identityKey string) {
+	if d == nil {
+		return
+	}
+
+	now := time.Now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, key := range d.keys(req, identityKey) {
+		if streak := d.streaks[key]; streak != nil && !streak.lockedUntil.After(now) {
+			delete(d.streaks, key)
+		}
+	}
+}
+
+// keys returns the keys of the identity key and the peer address of the request,
+// known peers are only tracked by their address as anyone can claim their identity key
+func (d *anomalyDetector) keys(req *http.Request, identityKey string) []anomalyKey {
+	keys := make([]anomalyKey, 0, 2)
+	if identityKey != "" && !d.knownPeers.Contains(identityKey) {
+		keys = append(keys, anomalyKey{kind: AnomalyKindIdentity, key: identityKey})
+	}
+	if addr, ok := peerAddr(req); ok {
+		keys = append(keys, anomalyKey{kind: AnomalyKindAddress, key: addr.String()})
+	}
+	return keys
+}
+
+// sweep drops the streaks whose window and lockout ended, at most once per window
+func (d *anomalyDetector) sweep(now time.Time) {
+	if now.Sub(d.lastSweep) < d.policy.Window {
+		return
+	}
+	d.lastSweep = now
+
+	for key, streak := range d.streaks {
+		if now.Sub(streak.windowStart) >= d.policy.Window && !streak.lockedUntil.After(now) {
+			delete(d.streaks, key)
+		}
+	}
+}
+
+// peerAddr returns the network address of the peer of the request
+func peerAddr(req *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
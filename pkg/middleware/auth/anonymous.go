@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// DefaultAnonymousRateLimitWindow is the window of the anonymous rate limit when no window is configured
+const DefaultAnonymousRateLimitWindow = time.Minute
+
+// AnonymousPolicy enables anonymous sessions of peers which authenticate with the well-known anyone key
+// (transport.AnyoneIdentityKey). Anonymous sessions are not asked for certificates, their requests are marked
+// in the context (see IsAnonymousFromContext) and share a single rate limit, as all anonymous peers share the identity.
+type AnonymousPolicy struct {
+	// RateLimit is the number of general requests all anonymous peers together may send per window, zero is unlimited
+	RateLimit int
+	// RateLimitWindow is the window of the rate limit, defaults to DefaultAnonymousRateLimitWindow
+	RateLimitWindow time.Duration
+}
+
+// IsAnonymousFromContext reports whether the request was authenticated by an anonymous session
+func IsAnonymousFromContext(ctx context.Context) bool {
+	anonymous, _ := ctx.Value(transport.Anonymous).(bool)
+	return anonymous
+}
+
+// rateLimitError is returned for requests over a rate limit, it wraps ErrAnonymousRateLimited or ErrTierRateLimited
+type rateLimitError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+func (e *rateLimitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *rateLimitError) Unwrap() error {
+	return e.err
+}
+
+// windowLimiter counts requests in fixed windows, e.g. the requests of all anonymous sessions
+type windowLimiter struct {
+	mu          sync.Mutex
+	limit       int
+	window      time.Duration
+	windowStart time.Time
+	count       int
+}
+
+func newAnonymousLimiter(policy *AnonymousPolicy) *windowLimiter {
+	if policy == nil || policy.RateLimit <= 0 {
+		return nil
+	}
+
+	window := policy.RateLimitWindow
+	if window <= 0 {
+		window = DefaultAnonymousRateLimitWindow
+	}
+
+	return &windowLimiter{limit: policy.RateLimit, window: window}
+}
+
+// allow counts the request and returns the time until the next window when the limit is exceeded
+func (l *windowLimiter) allow(now time.Time) (bool, time.Duration) {
+	if l == nil {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.windowStart) >= l.window {
+		l.windowStart = now
+		l.count = 0
+	}
+
+	if l.count >= l.limit {
+		return false, l.windowStart.Add(l.window).Sub(now)
+	}
+
+	l.count++
+	return true, 0
+}
+
+// expired reports whether the current window ended
+func (l *windowLimiter) expired(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return now.Sub(l.windowStart) >= l.window
+}
+
+// checkAnonymous applies the route policy and the rate limit to requests of anonymous sessions
+func (m *Middleware) checkAnonymous(req *http.Request) error {
+	if !IsAnonymousFromContext(req.Context()) {
+		return nil
+	}
+
+	if policy, ok := m.routePolicies.match(req); ok && policy.DenyAnonymous {
+		return ErrAnonymousNotAllowed
+	}
+
+	if ok, retryAfter := m.anonymousLimiter.allow(time.Now()); !ok {
+		return &rateLimitError{err: ErrAnonymousRateLimited, retryAfter: retryAfter}
+	}
+
+	return nil
+}
+
+// respondWithAnonymousError responds with 429 and Retry-After over the rate limit or with 403 otherwise, returning the error code
+func (m *Middleware) respondWithAnonymousError(w http.ResponseWriter, err error) string {
+	var limitErr *rateLimitError
+	if errors.As(err, &limitErr) {
+		return m.respondWithRateLimit(w, limitErr)
+	}
+
+	m.respondWithError(w, http.StatusForbidden, transport.ErrCodeAnonymousNotAllowed, err)
+	return transport.ErrCodeAnonymousNotAllowed
+}
+
+// respondWithRateLimit responds with 429 and Retry-After, returning the error code
+func (m *Middleware) respondWithRateLimit(w http.ResponseWriter, err *rateLimitError) string {
+	retryAfter := int((err.retryAfter + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	m.respondWithError(w, http.StatusTooManyRequests, transport.ErrCodeRateLimited, err)
+	return transport.ErrCodeRateLimited
+}
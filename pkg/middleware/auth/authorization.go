@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// Authorizer is an authorization decision point consulted for every request passed to the handler,
+// e.g. an external policy engine such as OPA (see OPAAuthorizer) or an embedded rego query wrapped in an AuthorizerFunc
+type Authorizer interface {
+	// Authorize returns the decision for the request, an error rejects the request with 503
+	Authorize(ctx context.Context, input AuthorizationInput) (Decision, error)
+}
+
+// AuthorizerFunc adapts a function to the Authorizer interface
+type AuthorizerFunc func(ctx context.Context, input AuthorizationInput) (Decision, error)
+
+// Authorize calls the function
+func (f AuthorizerFunc) Authorize(ctx context.Context, input AuthorizationInput) (Decision, error) {
+	return f(ctx, input)
+}
+
+// AuthorizationInput is the input of the Authorizer, it is encoded as the input document of policy engines
+type AuthorizationInput struct {
+	Auth    AuthResult        `json:"auth"`
+	Request RequestAttributes `json:"request"`
+}
+
+// AuthResult describes how the peer of a request was authenticated
+type AuthResult struct {
+	// Authenticated is false for requests without auth headers passed by AllowUnauthenticated or a route policy
+	Authenticated bool `json:"authenticated"`
+	// IdentityKey is the identity key of the authenticated peer, the agent key of peers acting for a principal
+	IdentityKey string `json:"identityKey,omitempty"`
+	// Principal is the identity key of the principal which delegated to the agent key of the peer,
+	// empty unless the peer authenticated with a delegation
+	Principal string `json:"principal,omitempty"`
+	// Anonymous marks requests of anonymous sessions
+	Anonymous bool `json:"anonymous"`
+	// Certificates are the certificates accepted from the peer
+	Certificates []certificates.VerifiableCertificate `json:"certificates,omitempty"`
+	// Account is the account resolved by the AccountResolver
+	Account any `json:"account,omitempty"`
+}
+
+// RequestAttributes are the attributes of the request passed to the Authorizer
+type RequestAttributes struct {
+	Method string              `json:"method"`
+	Path   string              `json:"path"`
+	Host   string              `json:"host"`
+	Query  map[string][]string `json:"query,omitempty"`
+}
+
+// Decision is the decision of the Authorizer
+type Decision struct {
+	// Allow passes the request to the handler
+	Allow bool `json:"allow"`
+	// Reason explains a denial, it is sent to the peer as the description of the error response
+	Reason string `json:"reason,omitempty"`
+}
+
+// deniedError is returned for requests denied by the Authorizer, its message is the reason of the policy
+type deniedError struct {
+	reason string
+}
+
+func (e *deniedError) Error() string {
+	if e.reason == "" {
+		return ErrAuthorizationDenied.Error()
+	}
+	return e.reason
+}
+
+func (e *deniedError) Unwrap() error {
+	return ErrAuthorizationDenied
+}
+
+// authorize consults the Authorizer for the request
+func (m *Middleware) authorize(req *http.Request) error {
+	if m.authorizer == nil {
+		return nil
+	}
+
+	decision, err := m.authorizer.Authorize(req.Context(), m.authorizationInput(req))
+	if err != nil {
+		return fmt.Errorf("%w, %w", ErrAuthorizationUnavailable, err)
+	}
+
+	if !decision.Allow {
+		return &deniedError{reason: decision.Reason}
+	}
+	return nil
+}
+
+func (m *Middleware) authorizationInput(req *http.Request) AuthorizationInput {
+	input := AuthorizationInput{
+		Request: RequestAttributes{
+			Method: req.Method,
+			Path:   req.URL.Path,
+			Host:   req.Host,
+			Query:  req.URL.Query(),
+		},
+	}
+
+	identityKey, ok := GetIdentityFromContext(req.Context())
+	if !ok || identityKey == "" {
+		return input
+	}
+
+	input.Auth = AuthResult{
+		Authenticated: true,
+		IdentityKey:   identityKey,
+		Anonymous:     IsAnonymousFromContext(req.Context()),
+	}
+	input.Auth.Principal, _ = GetPrincipalFromContext(req.Context())
+	input.Auth.Account, _ = GetAccountFromContext(req.Context())
+	if session := m.sessionManager.GetSession(req.Header.Get(yourNonceHeader)); session != nil {
+		input.Auth.Certificates = session.Certificates
+	}
+
+	return input
+}
+
+// respondWithAuthorizationError responds with 403 and the reason of the policy for denied requests
+// or with 503 when the policy could not be evaluated, returning the error code
+func (m *Middleware) respondWithAuthorizationError(w http.ResponseWriter, err error) string {
+	if errors.Is(err, ErrAuthorizationDenied) {
+		m.respondWithError(w, http.StatusForbidden, transport.ErrCodeForbidden, err)
+		return transport.ErrCodeForbidden
+	}
+
+	m.logger.Error("Failed to evaluate authorization policy", slog.String("error", err.Error()))
+	m.respondWithError(w, http.StatusServiceUnavailable, transport.ErrCodeAuthorizationUnavailable, err)
+	return transport.ErrCodeAuthorizationUnavailable
+}
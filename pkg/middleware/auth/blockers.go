@@ -0,0 +1,409 @@
+package auth
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/events"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// Reasons of block events
+const (
+	// BlockReasonVerificationFailures is a peer address whose requests failed verification, e.g. with bad signatures
+	BlockReasonVerificationFailures = "verification_failures"
+	// BlockReasonHandshakeFlood is a peer address sending handshakes at a rate no legitimate client needs
+	BlockReasonHandshakeFlood = "handshake_flood"
+)
+
+// BlockerProgram is the program name of the lines written by the fail2ban and CrowdSec sinks
+const BlockerProgram = "bsv-auth"
+
+// Fail2banFailRegex is the failregex of a fail2ban filter matching the lines of NewFail2banSink
+const Fail2banFailRegex = `^\S+ ` + BlockerProgram + `: block reason=\S+ src=<HOST>`
+
+// maxBlocklistSize limits the size of blocklist feeds replaced with the admin API
+const maxBlocklistSize = 16 << 20
+
+// BlockPolicy integrates the middleware with external blockers such as fail2ban or CrowdSec: peer addresses
+// reaching a threshold within the window are reported to the Sink once per window, so the blocker bans them at the
+// firewall, and the Blocklist fed back by the blocker is enforced by the middleware, for deployments whose
+// firewall does not see the address of the peer, e.g. behind a load balancer.
+type BlockPolicy struct {
+	// Sink receives the block-worthy events, e.g. NewFail2banSink writing to the log file of a fail2ban jail.
+	// It is called on the request path and must not block.
+	Sink BlockSink
+	// VerificationFailures is the number of failed verifications per peer address within the window
+	// reported as block-worthy, zero disables it
+	VerificationFailures int
+	// Handshakes is the number of handshakes per peer address within the window reported as a flood, zero disables it
+	Handshakes int
+	// Window is the time in which failures and handshakes are counted, defaults to DefaultAnomalyWindow
+	Window time.Duration
+	// Blocklist is the feed of blocked addresses and networks, requests of blocked peers are rejected with 403
+	// before they are verified. Defaults to an empty list, fed with Middleware.Blocklist or the BlocklistHandler.
+	Blocklist *Blocklist
+}
+
+// BlockEvent is a peer address whose behavior is worth blocking
+type BlockEvent struct {
+	// Time is the time the threshold was reached
+	Time time.Time `json:"time"`
+	// Reason is one of the BlockReason constants
+	Reason string `json:"reason"`
+	// SourceIP is the network address of the peer
+	SourceIP string `json:"source_ip"`
+	// IdentityKey is the identity key of the last request of the peer, empty for handshakes, whose identity key
+	// is only claimed in their body
+	IdentityKey string `json:"identity_key,omitempty"`
+	// Count is the number of failures or handshakes within the window
+	Count int `json:"count"`
+	// Window is the time in which they were counted
+	Window time.Duration `json:"-"`
+}
+
+// BlockSink receives the block-worthy events of a BlockPolicy
+type BlockSink interface {
+	Block(event BlockEvent)
+}
+
+// BlockSinkFunc adapts a function to a BlockSink
+type BlockSinkFunc func(event BlockEvent)
+
+// Block implements BlockSink
+func (f BlockSinkFunc) Block(event BlockEvent) {
+	f(event)
+}
+
+// writerSink writes a line per event, serialized so lines of concurrent events are not interleaved
+type writerSink struct {
+	mu     sync.Mutex
+	w      io.Writer
+	format func(event BlockEvent) []byte
+}
+
+func (s *writerSink) Block(event BlockEvent) {
+	line := s.format(event)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = s.w.Write(line)
+}
+
+// NewFail2banSink returns a sink writing a line per event to w, e.g. a log file watched by a fail2ban jail:
+//
+//	2026-01-02T15:04:05Z bsv-auth: block reason=verification_failures src=203.0.113.7 identity=02ab... count=10 window=60s
+//
+// Fail2banFailRegex is the failregex of the filter matching the lines.
+func NewFail2banSink(w io.Writer) BlockSink {
+	return &writerSink{w: w, format: func(event BlockEvent) []byte {
+		identity := event.IdentityKey
+		if identity == "" {
+			identity = "-"
+		}
+		return fmt.Appendf(nil, "%s %s: block reason=%s src=%s identity=%s count=%d window=%ds\n",
+			event.Time.UTC().Format(time.RFC3339), BlockerProgram, event.Reason, event.SourceIP, identity,
+			event.Count, int(event.Window/time.Second))
+	}}
+}
+
+// NewCrowdSecSink returns a sink writing a JSON line per event to w, e.g. a log file acquired by CrowdSec.
+// The line holds the fields of BlockEvent, the window in seconds and the program name, so a parser of the
+// program maps source_ip to the source of the alert.
+func NewCrowdSecSink(w io.Writer) BlockSink {
+	return &writerSink{w: w, format: func(event BlockEvent) []byte {
+		line, err := json.Marshal(struct {
+			Program string `json:"program"`
+			BlockEvent
+			WindowSeconds int `json:"window_seconds"`
+		}{Program: BlockerProgram, BlockEvent: event, WindowSeconds: int(event.Window / time.Second)})
+		if err != nil {
+			return nil
+		}
+		return append(line, '\n')
+	}}
+}
+
+// Blocklist is a replaceable list of blocked addresses and networks, fed by an external blocker.
+// It is safe for concurrent use, a nil list blocks nothing.
+type Blocklist struct {
+	mu       sync.RWMutex
+	addrs    map[netip.Addr]struct{}
+	prefixes []netip.Prefix
+}
+
+// NewBlocklist creates a list of the addresses and CIDR networks
+func NewBlocklist(entries ...string) (*Blocklist, error) {
+	b := &Blocklist{}
+	if err := b.Replace(entries); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Replace replaces the blocked addresses and networks, the list is unchanged when an entry is invalid
+func (b *Blocklist) Replace(entries []string) error {
+	addrs := make(map[netip.Addr]struct{}, len(entries))
+	var prefixes []netip.Prefix
+	for _, entry := range entries {
+		prefix, err := parseBlocklistEntry(entry)
+		if err != nil {
+			return err
+		}
+		if prefix.IsSingleIP() {
+			addrs[prefix.Addr()] = struct{}{}
+		} else {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.addrs, b.prefixes = addrs, prefixes
+	return nil
+}
+
+// Load replaces the list with a feed of an address or CIDR network per line, e.g. the decisions exported by
+// a blocker. Blank lines and comments starting with # are skipped, as is anything after the first field of a line.
+func (b *Blocklist) Load(r io.Reader) error {
+	var entries []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if fields := strings.Fields(line); len(fields) > 0 {
+			entries = append(entries, fields[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%w, failed to read feed, %w", ErrInvalidBlocklist, err)
+	}
+	return b.Replace(entries)
+}
+
+// Contains reports whether the address is blocked
+func (b *Blocklist) Contains(addr netip.Addr) bool {
+	if b == nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if _, ok := b.addrs[addr]; ok {
+		return true
+	}
+	for _, prefix := range b.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// List returns the blocked addresses and networks, sorted
+func (b *Blocklist) List() []netip.Prefix {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.RLock()
+	list := make([]netip.Prefix, 0, len(b.addrs)+len(b.prefixes))
+	for addr := range b.addrs {
+		list = append(list, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	list = append(list, b.prefixes...)
+	b.mu.RUnlock()
+
+	slices.SortFunc(list, func(a, b netip.Prefix) int {
+		if c := a.Addr().Compare(b.Addr()); c != 0 {
+			return c
+		}
+		return a.Bits() - b.Bits()
+	})
+	return list
+}
+
+// parseBlocklistEntry parses an address or a CIDR network, IPv4-mapped addresses are unmapped
+func parseBlocklistEntry(entry string) (netip.Prefix, error) {
+	if strings.Contains(entry, "/") {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("%w, %q is not a CIDR network", ErrInvalidBlocklist, entry)
+		}
+		if prefix.Addr().Is4In6() && prefix.Bits() >= 96 {
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		return prefix.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(entry)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("%w, %q is not an address", ErrInvalidBlocklist, entry)
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+// Blocklist returns the blocklist enforced by the middleware, see BlockPolicy.Blocklist
+func (m *Middleware) Blocklist() *Blocklist {
+	return m.blocklist
+}
+
+// BlocklistHandler serves the admin API of the blocklist: GET lists the blocked addresses and networks a line each,
+// PUT replaces them with the feed of the body, see Blocklist.Load. The handler does not authenticate its callers,
+// it has to be served on an internal listener or behind the access control of the operator.
+func (m *Middleware) BlocklistHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			for _, prefix := range m.blocklist.List() {
+				if _, err := fmt.Fprintln(w, blocklistEntry(prefix)); err != nil {
+					m.logger.Error("Failed to write blocklist", slog.String("error", err.Error()))
+					return
+				}
+			}
+		case http.MethodPut:
+			if err := m.blocklist.Load(http.MaxBytesReader(w, req.Body, maxBlocklistSize)); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			m.logger.Info("Blocklist replaced", slog.Int("entries", len(m.blocklist.List())))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// blocklistEntry formats single addresses without their prefix length, as they are fed
+func blocklistEntry(prefix netip.Prefix) string {
+	if prefix.IsSingleIP() {
+		return prefix.Addr().String()
+	}
+	return prefix.String()
+}
+
+// blocked rejects the request with 403 when its peer address is on the blocklist, it returns the error code
+func (m *Middleware) blocked(w http.ResponseWriter, req *http.Request) (string, bool) {
+	addr, ok := peerAddr(req)
+	if !ok || !m.blocklist.Contains(addr) {
+		return "", false
+	}
+	m.respondWithError(w, http.StatusForbidden, transport.ErrCodeBlocked, ErrBlocked)
+	return transport.ErrCodeBlocked, true
+}
+
+type blockCounter struct {
+	windowStart time.Time
+	count       int
+}
+
+type blockKey struct {
+	reason string
+	addr   netip.Addr
+}
+
+// blockReporter counts the verification failures and handshakes of peer addresses and reports those reaching
+// the thresholds of a BlockPolicy to its sink
+type blockReporter struct {
+	policy BlockPolicy
+
+	mu        sync.Mutex
+	counters  map[blockKey]*blockCounter
+	lastSweep time.Time
+}
+
+// reportBlocks subscribes a reporter of the policy to the requests published on the bus
+func reportBlocks(policy *BlockPolicy, bus *events.Bus) {
+	if policy == nil || policy.Sink == nil || (policy.VerificationFailures <= 0 && policy.Handshakes <= 0) {
+		return
+	}
+
+	p := *policy
+	if p.Window <= 0 {
+		p.Window = DefaultAnomalyWindow
+	}
+	r := &blockReporter{policy: p, counters: make(map[blockKey]*blockCounter)}
+	requestEvents(bus, func(req *http.Request, event RequestEvent) {
+		identityKey := event.IdentityKey
+		if identityKey == "" {
+			identityKey = req.Header.Get(identityKeyHeader)
+		}
+		if req.Method == http.MethodPost && req.URL.Path == HandshakePath {
+			r.count(req, BlockReasonHandshakeFlood, r.policy.Handshakes, identityKey)
+		}
+		// failures of the server, e.g. wallet timeouts, are not the fault of the peer
+		if event.Outcome == AccessOutcomeRejected && event.Err != nil && transport.ErrorStatus(event.Err) < http.StatusInternalServerError {
+			r.count(req, BlockReasonVerificationFailures, r.policy.VerificationFailures, identityKey)
+		}
+	})
+}
+
+// count counts a request of the peer address for the reason and reports the address reaching the threshold
+func (r *blockReporter) count(req *http.Request, reason string, threshold int, identityKey string) {
+	if threshold <= 0 {
+		return
+	}
+	addr, ok := peerAddr(req)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	key := blockKey{reason: reason, addr: addr}
+
+	r.mu.Lock()
+	r.sweep(now)
+	counter := r.counters[key]
+	if counter == nil {
+		counter = &blockCounter{}
+		r.counters[key] = counter
+	}
+	if now.Sub(counter.windowStart) >= r.policy.Window {
+		counter.windowStart = now
+		counter.count = 0
+	}
+	counter.count++
+	count := counter.count
+	if count >= threshold {
+		// the next window starts with the report, so a peer keeping on is reported again
+		counter.windowStart = now
+		counter.count = 0
+	}
+	r.mu.Unlock()
+
+	if count >= threshold {
+		r.policy.Sink.Block(BlockEvent{
+			Time:        now,
+			Reason:      reason,
+			SourceIP:    addr.String(),
+			IdentityKey: identityKey,
+			Count:       count,
+			Window:      r.policy.Window,
+		})
+	}
+}
+
+// sweep drops the counters whose window ended, at most once per window
+func (r *blockReporter) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < r.policy.Window {
+		return
+	}
+	r.lastSweep = now
+
+	for key, counter := range r.counters {
+		if now.Sub(counter.windowStart) >= r.policy.Window {
+			delete(r.counters, key)
+		}
+	}
+}
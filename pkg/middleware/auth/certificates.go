@@ -0,0 +1,32 @@
+package auth
+
+import "github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+
+// UpdateCertificateRequirements replaces the certificates requested from peers at runtime.
+// New sessions are always challenged with the new requirements, the mode defines how existing sessions are treated:
+// CertificateUpgradeIgnore keeps them authenticated, CertificateUpgradeRechallenge answers their next request
+// with the new certificate request and CertificateUpgradeRevoke ends them on their next request.
+// Except with CertificateUpgradeIgnore, certificate exchanges in progress are aborted with transport.AbortReasonPolicyChanged.
+// Requirements can only be set when the middleware was created with an OnCertificatesReceived callback,
+// nil removes the requirements.
+func (m *Middleware) UpdateCertificateRequirements(requirements *transport.RequestedCertificateSet, mode transport.CertificateUpgradeMode) error {
+	if requirements != nil {
+		if !m.certificatesCallback {
+			return ErrCertificatesCallbackRequired
+		}
+		if err := requirements.Validate(); err != nil {
+			return err
+		}
+	}
+
+	m.certificatesToRequest.Store(requirements)
+	m.transport.UpdateCertificateRequirements(requirements, mode)
+	return nil
+}
+
+// Shutdown aborts the certificate exchanges in progress, so their peers receive a signed ExchangeAborted message
+// with transport.AbortReasonShutdown in response to their certificates instead of timing out or finding no session.
+// It is meant to be registered with http.Server.RegisterOnShutdown, it returns the number of aborted exchanges.
+func (m *Middleware) Shutdown() int {
+	return m.transport.AbortCertificateExchanges(transport.AbortReasonShutdown)
+}
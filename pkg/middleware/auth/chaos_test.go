@@ -0,0 +1,170 @@
+package auth_test
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/internal/faults"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+// TestChaos asserts the middleware fails closed when a dependency is down and the request cannot be authenticated,
+// and keeps serving when a dependency is slow or the request can be authenticated without it
+func TestChaos(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	clientKey, err := ec.PrivateKeyFromHex(wallettest.ClientPrivateKeyHex)
+	require.NoError(t, err)
+
+	newServer := func(t *testing.T, injector *faults.Injector) (*auth.Middleware, string, *atomic.Int64) {
+		middleware, err := auth.New(auth.WithFaults(auth.Config{
+			Wallet:         wallettest.NewRandomMockWallet(key, nil),
+			SessionManager: session.NewMemoryManager(),
+			Logger:         slog.New(slog.DiscardHandler),
+			WalletTimeouts: transport.WalletTimeouts{CreateSignature: 100 * time.Millisecond},
+		}, injector))
+		require.NoError(t, err)
+
+		var served atomic.Int64
+		server := httptest.NewServer(middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			served.Add(1)
+			w.WriteHeader(http.StatusOK)
+		})))
+		t.Cleanup(server.Close)
+		return middleware, server.URL, &served
+	}
+
+	newClient := func(t *testing.T, url string, w wallet.WalletInterface) *client.Client {
+		authClient, err := client.New(client.Config{Wallet: w, BaseURL: url})
+		require.NoError(t, err)
+		require.NoError(t, authClient.Handshake(context.Background()))
+		return authClient
+	}
+
+	ping := func(t *testing.T, authClient *client.Client, url string) (*http.Response, error) {
+		request, err := http.NewRequest(http.MethodGet, url+"/ping", nil)
+		require.NoError(t, err)
+		response, err := authClient.Do(request)
+		if err == nil {
+			require.NoError(t, response.Body.Close())
+		}
+		return response, err
+	}
+
+	requireRejected := func(t *testing.T, err error, code string) {
+		var serverErr *client.ServerError
+		require.ErrorAs(t, err, &serverErr)
+		require.Equal(t, code, serverErr.Code)
+	}
+
+	tests := map[string]struct {
+		target    faults.Target
+		operation string
+		fault     faults.Fault
+		// revokeClient revokes the identity of the client before the fault is injected
+		revokeClient bool
+		// handled reports whether the handler runs, responses are signed after the handler ran
+		handled      bool
+		expectedCode string
+		minLatency   time.Duration
+	}{
+		"wallet signing slower than its timeout withholds the response": {
+			target:       faults.TargetWallet,
+			operation:    "CreateSignature",
+			fault:        faults.Fault{Delay: 500 * time.Millisecond},
+			handled:      true,
+			expectedCode: transport.ErrCodeWalletTimeout,
+		},
+		"wallet failing to verify signatures fails closed": {
+			target:       faults.TargetWallet,
+			operation:    "VerifySignature",
+			fault:        faults.Fault{Fail: true},
+			expectedCode: transport.ErrCodeInvalidSignature,
+		},
+		"session store losing sessions fails closed": {
+			target:       faults.TargetSessions,
+			operation:    "GetSession",
+			fault:        faults.Fault{Fail: true},
+			expectedCode: transport.ErrCodeSessionNotFound,
+		},
+		"slow session store delays requests": {
+			target:     faults.TargetSessions,
+			operation:  "GetSession",
+			fault:      faults.Fault{Delay: 50 * time.Millisecond},
+			handled:    true,
+			minLatency: 50 * time.Millisecond,
+		},
+		"revocation store outage does not affect peers which were never revoked": {
+			target:  faults.TargetRevocations,
+			fault:   faults.Fault{Fail: true},
+			handled: true,
+		},
+		"revocation store outage fails closed for revoked peers": {
+			target:       faults.TargetRevocations,
+			fault:        faults.Fault{Fail: true},
+			revokeClient: true,
+			expectedCode: transport.ErrCodeRevocationUnavailable,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			injector := faults.NewInjector()
+			middleware, url, served := newServer(t, injector)
+			clientWallet := wallettest.NewRandomMockWallet(clientKey, nil)
+			authClient := newClient(t, url, clientWallet)
+
+			// a peer which was never revoked keeps the revocation list consulted for other peers
+			otherKey, err := ec.NewPrivateKey()
+			require.NoError(t, err)
+			require.NoError(t, middleware.Revocations().Revoke(context.Background(), transport.Revocation{
+				RevocationSubject: transport.RevocationSubject{Kind: transport.RevokedIdentity, Value: otherKey.PubKey().ToDERHex()},
+			}))
+			if test.revokeClient {
+				identity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+				require.NoError(t, err)
+				require.NoError(t, middleware.Revocations().Revoke(context.Background(), transport.Revocation{
+					RevocationSubject: transport.RevocationSubject{Kind: transport.RevokedIdentity, Value: identity.PublicKey.ToDERHex()},
+				}))
+			}
+
+			// when
+			injector.Inject(test.target, test.operation, test.fault)
+			start := time.Now()
+			_, err = ping(t, authClient, url)
+			latency := time.Since(start)
+
+			// then
+			if test.expectedCode != "" {
+				requireRejected(t, err, test.expectedCode)
+			} else {
+				require.NoError(t, err)
+				require.GreaterOrEqual(t, latency, test.minLatency)
+			}
+			require.Equal(t, test.handled, served.Load() == 1, "handler reached")
+
+			if test.revokeClient {
+				return
+			}
+
+			// when
+			injector.Reset()
+			_, err = ping(t, newClient(t, url, wallettest.NewRandomMockWallet(clientKey, nil)), url)
+
+			// then
+			require.NoError(t, err, "middleware did not recover once the fault was cleared")
+		})
+	}
+}
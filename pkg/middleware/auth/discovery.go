@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+const (
+	// HandshakePath is the path of the BRC-104 handshake endpoint.
+	HandshakePath = "/.well-known/auth"
+	// DiscoveryPath is the path of the discovery document endpoint.
+	DiscoveryPath = "/.well-known/bsv-auth-configuration"
+)
+
+// DiscoveryDocument describes the auth configuration of the server, so clients can bootstrap automatically
+type DiscoveryDocument struct {
+	IdentityKey           string                             `json:"identityKey"`
+	AuthVersions          []string                           `json:"authVersions"`
+	HandshakePath         string                             `json:"handshakePath"`
+	AllowUnauthenticated  bool                               `json:"allowUnauthenticated"`
+	PayloadEncryption     bool                               `json:"payloadEncryption"`
+	PayloadPadding        bool                               `json:"payloadPadding"`
+	Capabilities          []transport.Capability             `json:"capabilities"`
+	IdempotencyKeys       bool                               `json:"idempotencyKeys"`
+	RequestedCertificates *transport.RequestedCertificateSet `json:"requestedCertificates,omitempty"`
+	Payment               *PaymentHints                      `json:"payment,omitempty"`
+	// OpenAPIPath is the path of the OpenAPI document with the requirements of the declared routes
+	OpenAPIPath string `json:"openapiPath,omitempty"`
+}
+
+// PaymentHints advertises the payment requirements of the server in the discovery document
+type PaymentHints struct {
+	Version string `json:"version"`
+	Network string `json:"network"`
+	// DefaultPrice is the price in satoshis charged for requests without custom pricing
+	DefaultPrice int `json:"defaultPrice,omitempty"`
+}
+
+func (m *Middleware) serveDiscoveryDocument(w http.ResponseWriter) {
+	identity, err := m.wallet.GetPublicKey(m.privilegedKeys.IdentityKeyArgs(), "")
+	if err != nil {
+		m.logger.Error("Failed to get identity key for discovery document", slog.String("error", err.Error()))
+		http.Error(w, fmt.Sprintf("failed to get identity key, %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	document := DiscoveryDocument{
+		IdentityKey:           identity.PublicKey.ToDERHex(),
+		AuthVersions:          []string{transport.AuthVersion},
+		HandshakePath:         HandshakePath,
+		AllowUnauthenticated:  m.allowUnauthenticated,
+		PayloadEncryption:     m.encryptPayloads,
+		PayloadPadding:        m.padPayloads,
+		Capabilities:          m.transport.Capabilities(),
+		IdempotencyKeys:       m.idempotency != nil,
+		RequestedCertificates: m.certificatesToRequest.Load(),
+		Payment:               m.paymentHints,
+	}
+	if m.routes != nil {
+		document.OpenAPIPath = OpenAPIPath
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(document); err != nil {
+		m.logger.Error("Failed to write discovery document", slog.String("error", err.Error()))
+	}
+}
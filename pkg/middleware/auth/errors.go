@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// Errors returned by New and in the error responses of the middleware, matchable with errors.Is
+var (
+	ErrWalletRequired               = errors.New("wallet is required")
+	ErrSessionManagerRequired       = errors.New("session manager is required")
+	ErrCertificatesCallbackRequired = errors.New("OnCertificatesReceived callback is required when certificates are requested")
+	ErrCertificatesNotRequested     = errors.New("OnCertificatesReceived callback is set but no certificates are requested")
+	ErrInvalidRoutePolicy           = errors.New("invalid route policy pattern")
+	ErrInvalidUpstreamHeader        = errors.New("invalid upstream header")
+	ErrMissingForwardedRequest      = errors.New("missing forwarded method or URI header")
+	ErrInvalidForwardedURI          = errors.New("invalid forwarded URI header")
+	ErrIdempotencyKeyInProgress     = errors.New("request with the idempotency key is still processed")
+	ErrIdempotencyKeyMismatch       = errors.New("idempotency key was used for a different request")
+	ErrMaintenance                  = errors.New("server is under maintenance")
+	ErrInvalidLogConfig             = errors.New("invalid log config")
+	ErrSelfTestFailed               = errors.New("wallet self-test failed")
+	ErrAccountNotFound              = errors.New("account not found")
+	ErrAccountUnavailable           = errors.New("failed to resolve account")
+	ErrAnonymousNotAllowed          = errors.New("anonymous sessions are not allowed on this route")
+	ErrAnonymousRateLimited         = errors.New("rate limit of anonymous sessions exceeded")
+	ErrInvalidTierPolicy            = errors.New("invalid tier policy")
+	ErrTierRateLimited              = errors.New("rate limit of the tier exceeded")
+	ErrAuthorizationDenied          = errors.New("request denied by authorization policy")
+	ErrAuthorizationUnavailable     = errors.New("failed to evaluate authorization policy")
+	ErrInvalidTokenMinting          = errors.New("invalid token minting config")
+	ErrInvalidRouteDeclaration      = errors.New("invalid route declaration")
+	ErrUnsupportedRPCProtocol       = errors.New("gRPC over HTTP/2 is not supported, use Connect, gRPC-Web or grpc-gateway")
+	ErrLockedOut                    = errors.New("too many failed verifications, temporarily locked out")
+	ErrInvalidRollout               = errors.New("invalid enforcement rollout")
+	ErrUnknownProfile               = errors.New("unknown profile")
+	ErrProfileViolation             = errors.New("config violates profile")
+	ErrBlocked                      = errors.New("peer address is blocked")
+	ErrInvalidBlocklist             = errors.New("invalid blocklist")
+)
+
+func (m *Middleware) respondWithError(w http.ResponseWriter, status int, code string, err error) {
+	resp := transport.ErrorResponse{
+		Status:      "error",
+		Code:        code,
+		Description: err.Error(),
+	}
+
+	if errors.Is(err, transport.ErrCertificatesRequired) {
+		resp.RequestedCertificates = m.certificatesToRequest.Load()
+	}
+
+	// the requested fields are sent along, so the peer can fix the keyring of the certificate
+	var disclosureErr *transport.DisclosureError
+	if errors.As(err, &disclosureErr) {
+		resp.RequestedCertificates = m.certificatesToRequest.Load()
+		resp.Disclosure = disclosureErr
+	}
+
+	var certErrs transport.CertificateErrors
+	if errors.As(err, &certErrs) {
+		resp.Certificates = certErrs
+	}
+
+	var abortedErr *transport.ExchangeAbortedError
+	if errors.As(err, &abortedErr) {
+		resp.Aborted = abortedErr.Message
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		m.logger.Error("Failed to write error response", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/events"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// RequestEvent is the data of the events.TypeRequest events the middleware publishes on events.TopicAuth
+// once it handled a request, with the outcome and the latencies of the access log
+type RequestEvent struct {
+	// Outcome is one of the AccessOutcome constants
+	Outcome string
+	// IdentityKey is the identity key of authenticated requests, the key claimed by other requests is in their headers
+	IdentityKey string
+	// ErrorCode is the error code the request was rejected with, empty for requests which were not rejected
+	ErrorCode string
+	// Err is the verification error of handshake messages and general requests rejected by the transport
+	Err error
+	// Verify, Handler and Sign are the latencies of the stages of the request
+	Verify  time.Duration
+	Handler time.Duration
+	Sign    time.Duration
+	// WalletCalls are the wallet operations performed for the request
+	WalletCalls transport.WalletCalls
+}
+
+// EventRedactor returns the events.Redactor masking the certificates of the sessions of session events with the policy,
+// the middleware creates its default bus with it, buses shared with other middlewares are created with
+// events.NewBus(auth.EventRedactor(policy))
+func EventRedactor(policy *transport.CertificateRedactionPolicy) events.Redactor {
+	return eventRedactor{policy: policy}
+}
+
+type eventRedactor struct {
+	policy *transport.CertificateRedactionPolicy
+}
+
+// RedactEventData implements events.Redactor
+func (r eventRedactor) RedactEventData(data any) any {
+	if session, ok := data.(session.PeerSession); ok {
+		session.Certificates = r.policy.RedactCertificates(session.Certificates)
+		return session
+	}
+	return data
+}
+
+// Events returns the bus the middleware publishes its events on, see Config.Events
+func (m *Middleware) Events() *events.Bus {
+	return m.events
+}
+
+// publishRequest publishes the events.TypeRequest event of the handled request
+func (m *Middleware) publishRequest(entry *accessLog, req *http.Request) {
+	m.events.Publish(req.Context(), events.Event{
+		Topic:       events.TopicAuth,
+		Type:        events.TypeRequest,
+		IdentityKey: entry.identityKey,
+		Request:     req,
+		Data: RequestEvent{
+			Outcome:     entry.outcome,
+			IdentityKey: entry.identityKey,
+			ErrorCode:   entry.errorCode,
+			Err:         entry.err,
+			Verify:      entry.verify,
+			Handler:     entry.handler,
+			Sign:        entry.sign,
+			WalletCalls: entry.walletCalls.Calls(),
+		},
+	})
+}
+
+// requestEvents calls handle with the requests of the events.TypeRequest events published on the bus
+func requestEvents(bus *events.Bus, handle func(req *http.Request, event RequestEvent)) (unsubscribe func()) {
+	return bus.Subscribe(events.TopicAuth, func(_ context.Context, event events.Event) {
+		if data, ok := event.Data.(RequestEvent); ok && event.Type == events.TypeRequest && event.Request != nil {
+			handle(event.Request, data)
+		}
+	})
+}
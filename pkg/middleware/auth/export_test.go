@@ -0,0 +1,9 @@
+package auth
+
+import "github.com/bsv-blockchain/go-bsv-middleware/pkg/internal/faults"
+
+// WithFaults returns the config with the faults of the injector injected into the dependencies of the middleware
+func WithFaults(cfg Config, injector *faults.Injector) Config {
+	cfg.faults = injector
+	return cfg
+}
@@ -0,0 +1,23 @@
+package auth
+
+import (
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/internal/faults"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// injectFaults wraps the dependencies of the middleware with the faults of the chaos tests, it does nothing
+// unless the tests configured an injector
+func (c *Config) injectFaults() {
+	if c.faults == nil {
+		return
+	}
+
+	c.Wallet = faults.Wallet(c.Wallet, c.faults)
+	if c.SessionManager != nil {
+		c.SessionManager = faults.SessionManager(c.SessionManager, c.faults)
+	}
+	if c.RevocationStore == nil {
+		c.RevocationStore = transport.NewMemoryRevocationStore()
+	}
+	c.RevocationStore = faults.RevocationStore(c.RevocationStore, c.faults)
+}
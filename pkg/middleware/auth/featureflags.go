@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// maxFlagRuleSize limits the size of flag rules set with the admin API
+const maxFlagRuleSize = 16 << 10
+
+// FeatureFlags returns the feature flags of the middleware, changed rules apply to the sessions of the following handshakes
+func (m *Middleware) FeatureFlags() *transport.FeatureFlags {
+	return m.featureFlags
+}
+
+// FeatureFlagsHandler serves the admin API of the feature flags: GET lists the rules keyed by flag, PUT sets the rule
+// of the JSON body for the flag of the flag query parameter and DELETE removes the rule of the flag, enabling it again.
+// The handler does not authenticate its callers, it has to be served on an internal listener or behind the access
+// control of the operator.
+func (m *Middleware) FeatureFlagsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		flag := transport.Flag(req.URL.Query().Get("flag"))
+
+		switch req.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(m.featureFlags.Rules()); err != nil {
+				m.logger.Error("Failed to write feature flags", slog.String("error", err.Error()))
+			}
+		case http.MethodPut:
+			var rule transport.FlagRule
+			if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxFlagRuleSize)).Decode(&rule); err != nil {
+				http.Error(w, "invalid flag rule", http.StatusBadRequest)
+				return
+			}
+			if err := m.featureFlags.Set(flag, rule); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			m.logger.Info("Feature flag rule set", slog.String("flag", string(flag)),
+				slog.Int("percent", rule.Percent), slog.Int("cohort", len(rule.Cohort)))
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodDelete:
+			if !m.featureFlags.Remove(flag) {
+				http.Error(w, "flag has no rule", http.StatusNotFound)
+				return
+			}
+			m.logger.Info("Feature flag rule removed", slog.String("flag", string(flag)))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+)
+
+// FileServer returns a handler serving files of the file system, like http.FileServer, which cooperates with response signing.
+// Responses are written in a single write with the Content-Length left to the middleware, as the signed body may be transformed.
+// Complete and ranged responses of files carry the signed Content-Digest header with the digest of the complete file,
+// computed with the algorithm negotiated for the session (see transport.NegotiatedDigestAlgorithm).
+// Digests are computed once per file and algorithm and recomputed when its size or modification time changes.
+//
+// A single range is answered with 206 Partial Content, the signature covers the partial body and the Content-Range header.
+// Requests for multiple ranges are answered with the complete file, as multipart bodies cannot be verified part by part.
+func FileServer(fsys http.FileSystem) http.Handler {
+	return &fileServer{
+		fsys:    fsys,
+		files:   http.FileServer(fsys),
+		digests: make(map[string]fileDigest),
+	}
+}
+
+// ServeContent replies with the content like http.ServeContent, with the signing semantics of FileServer.
+// The digest of the content is computed on every call, use FileServer to serve files with cached digests.
+func ServeContent(w http.ResponseWriter, req *http.Request, name string, modtime time.Time, content io.ReadSeeker) {
+	digest, err := contentDigest(content, digestAlgorithm(req))
+	if err != nil {
+		http.Error(w, "failed to read content", http.StatusInternalServerError)
+		return
+	}
+
+	singleRange(req)
+	buffered := newBufferedResponse(w)
+	http.ServeContent(buffered, req, name, modtime, content)
+	buffered.flush(digest)
+}
+
+type fileServer struct {
+	fsys    http.FileSystem
+	files   http.Handler
+	mu      sync.Mutex
+	digests map[string]fileDigest
+}
+
+type fileDigest struct {
+	size    int64
+	modTime time.Time
+	digest  string
+}
+
+// ServeHTTP implements http.Handler
+func (s *fileServer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	singleRange(req)
+	buffered := newBufferedResponse(w)
+	s.files.ServeHTTP(buffered, req)
+	buffered.flush(s.digest(req.URL.Path, digestAlgorithm(req)))
+}
+
+// digest returns the Content-Digest of a regular file, empty for directories and files which cannot be read
+func (s *fileServer) digest(name string, algorithm transport.DigestAlgorithm) string {
+	name = path.Clean("/" + name)
+	cacheKey := string(algorithm) + " " + name
+
+	file, err := s.fsys.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		return ""
+	}
+
+	s.mu.Lock()
+	cached, ok := s.digests[cacheKey]
+	s.mu.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.digest
+	}
+
+	digest, err := contentDigest(file, algorithm)
+	if err != nil {
+		return ""
+	}
+
+	s.mu.Lock()
+	s.digests[cacheKey] = fileDigest{size: info.Size(), modTime: info.ModTime(), digest: digest}
+	s.mu.Unlock()
+
+	return digest
+}
+
+// contentDigest returns the Content-Digest header value of the complete content and rewinds it
+func contentDigest(content io.ReadSeeker, algorithm transport.DigestAlgorithm) (string, error) {
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	digest, err := algorithm.ContentDigest(content)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	return digest, nil
+}
+
+// digestAlgorithm returns the digest algorithm negotiated for the session of the request
+func digestAlgorithm(req *http.Request) transport.DigestAlgorithm {
+	capabilities, _ := GetCapabilitiesFromContext(req.Context())
+	return transport.NegotiatedDigestAlgorithm(capabilities)
+}
+
+// singleRange drops Range headers requesting multiple ranges, so the complete content is served instead of a multipart body
+func singleRange(req *http.Request) {
+	if strings.Contains(req.Header.Get("Range"), ",") {
+		req.Header.Del("Range")
+	}
+}
+
+// bufferedResponse collects the response of http.ServeContent, which writes the body in chunks,
+// so it is passed to the middleware in a single write
+type bufferedResponse struct {
+	w      http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferedResponse(w http.ResponseWriter) *bufferedResponse {
+	return &bufferedResponse{w: w, status: http.StatusOK}
+}
+
+// Header returns the headers of the underlying response
+func (b *bufferedResponse) Header() http.Header {
+	return b.w.Header()
+}
+
+// WriteHeader records the status code
+func (b *bufferedResponse) WriteHeader(code int) {
+	b.status = code
+}
+
+// Write appends to the buffered body
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+// flush writes the buffered response, successful responses carry the given digest
+func (b *bufferedResponse) flush(digest string) {
+	header := b.w.Header()
+	header.Del("Content-Length")
+	if digest != "" && (b.status == http.StatusOK || b.status == http.StatusPartialContent) {
+		header.Set(utils.ContentDigestHeader, digest)
+	}
+
+	b.w.WriteHeader(b.status)
+	if b.body.Len() > 0 {
+		_, _ = b.w.Write(b.body.Bytes())
+	}
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// Headers of the ForwardAuth mode
+const (
+	// ForwardedMethodHeader carries the method of the original request, set by Traefik
+	ForwardedMethodHeader = "X-Forwarded-Method"
+	// ForwardedURIHeader carries the path and query of the original request, set by Traefik
+	ForwardedURIHeader = "X-Forwarded-Uri"
+	// OriginalMethodHeader carries the method of the original request, set by NGINX auth_request configurations
+	OriginalMethodHeader = "X-Original-Method"
+	// OriginalURIHeader carries the path and query of the original request, set by NGINX auth_request configurations
+	OriginalURIHeader = "X-Original-URI"
+	// ForwardAuthIdentityKeyHeader carries the identity key of the authenticated peer when no upstream headers are declared
+	ForwardAuthIdentityKeyHeader = "X-Bsv-Identity-Key"
+)
+
+// ForwardAuthHandler returns a handler compatible with Traefik ForwardAuth and NGINX auth_request.
+// It verifies the auth headers of the original request, reconstructed from the forwarded method and URI headers,
+// and responds with 200 and the upstream headers declared in Config.ForwardAuth when the request is authenticated,
+// or with 401 otherwise. Requests of addresses on the Blocklist are rejected with 403 and failed verifications count
+// towards the lockouts of the AnomalyPolicy like in Handler, and authenticated requests are subject to the same policies:
+// maintenance, the tarpit, anonymous access, tiers, account resolution and the Authorizer.
+// The body of the original request is only verified when the proxy forwards it (e.g. Traefik forwardBody),
+// and responses of the upstream are not signed. The handshake endpoint has to be routed to the Handler of the middleware.
+func (m *Middleware) ForwardAuthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		original, err := forwardedRequest(req)
+		if err != nil {
+			m.respondWithError(w, http.StatusUnauthorized, transport.ErrCodeUnauthorized, err)
+			return
+		}
+
+		if _, blocked := m.blocked(w, original); blocked {
+			return
+		}
+
+		if remaining := m.anomalies.lockedOut(original, time.Now()); remaining > 0 {
+			if m.tarpit.lockedOut() {
+				m.serveTarpit(w, original, TarpitReasonLockedOut)
+			} else {
+				m.respondWithRateLimit(w, &rateLimitError{err: ErrLockedOut, retryAfter: remaining})
+			}
+			return
+		}
+
+		if policy, ok := m.routePolicies.match(original); ok {
+			if policy.Exempt || (policy.AllowUnauthenticated && original.Header.Get(requestIDHeader) == "") {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+		}
+
+		authReq, _, err := m.transport.HandleGeneralRequest(original, w)
+		if errors.Is(err, transport.ErrRequestCanceled) {
+			return
+		}
+		if err != nil && !errors.Is(err, transport.ErrReadTimeout) && !m.enforces(original.Header.Get(identityKeyHeader)) {
+			m.logReportOnly(original, err)
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if err != nil {
+			m.anomalies.failure(original, err)
+			m.logger.Debug("Forwarded request not authenticated", slog.String("error", err.Error()))
+			m.respondWithError(w, transport.ErrorStatus(err), transport.ErrorCode(err), err)
+			return
+		}
+
+		if authReq != nil {
+			original = authReq
+			identityKey, _ := GetIdentityFromContext(authReq.Context())
+			m.anomalies.success(original, identityKey)
+		}
+		policyReq, _, _ := m.applyPolicies(w, original)
+		if policyReq == nil {
+			return
+		}
+
+		if authReq != nil {
+			identityKey, _ := authReq.Context().Value(transport.IdentityKey).(string)
+			session := m.sessionManager.GetSession(original.Header.Get(yourNonceHeader))
+			m.forwardAuth.setUpstreamHeaders(w.Header(), identityKey, session)
+
+			if token, ok := GetTokenFromContext(policyReq.Context()); ok && m.tokenMinting.Header != "" {
+				w.Header().Set(m.tokenMinting.Header, "Bearer "+token)
+			}
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// forwardedRequest reconstructs the original request from the headers set by the proxy
+func forwardedRequest(req *http.Request) (*http.Request, error) {
+	method := req.Header.Get(ForwardedMethodHeader)
+	if method == "" {
+		method = req.Header.Get(OriginalMethodHeader)
+	}
+
+	uri := req.Header.Get(ForwardedURIHeader)
+	if uri == "" {
+		uri = req.Header.Get(OriginalURIHeader)
+	}
+
+	if method == "" || uri == "" {
+		return nil, ErrMissingForwardedRequest
+	}
+
+	originalURL, err := url.ParseRequestURI(uri)
+	if err != nil {
+		return nil, ErrInvalidForwardedURI
+	}
+
+	original := req.Clone(req.Context())
+	original.Method = method
+	original.URL = originalURL
+	original.RequestURI = uri
+	if host := req.Header.Get("X-Forwarded-Host"); host != "" {
+		original.Host = host
+	}
+
+	return original, nil
+}
@@ -0,0 +1,185 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+const (
+	// IdempotencyKeyHeader carries the idempotency key of a request. It is covered by the request signature.
+	// Retries of a request are signed with a fresh request ID and the same idempotency key,
+	// the server then responds with the result of the first attempt instead of processing the request again.
+	IdempotencyKeyHeader = "x-bsv-idempotency-key"
+	// IdempotentReplayHeader is set on responses served from the idempotency cache.
+	IdempotentReplayHeader = "x-bsv-idempotent-replay"
+)
+
+type idempotentResponse struct {
+	fingerprint [sha256.Size]byte
+	done        bool
+	status      int
+	header      http.Header
+	body        []byte
+	expiresAt   time.Time
+}
+
+// idempotencyStore caches responses of requests sent with an idempotency key, per peer identity key
+type idempotencyStore struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[string]*idempotentResponse
+	lastPrune time.Time
+}
+
+func newIdempotencyStore(ttl time.Duration) *idempotencyStore {
+	return &idempotencyStore{
+		ttl:     ttl,
+		entries: make(map[string]*idempotentResponse),
+	}
+}
+
+// begin returns the cached response for the key, or reserves the key and returns nil when the request has to be processed
+func (s *idempotencyStore) begin(key string, fingerprint [sha256.Size]byte, now time.Time) (*idempotentResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastPrune) >= s.ttl {
+		s.prune(now)
+	}
+
+	entry, ok := s.entries[key]
+	if !ok || entry.expired(now) {
+		s.entries[key] = &idempotentResponse{fingerprint: fingerprint}
+		return nil, nil
+	}
+
+	if entry.fingerprint != fingerprint {
+		return nil, ErrIdempotencyKeyMismatch
+	}
+
+	if !entry.done {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+
+	return entry, nil
+}
+
+// prune removes the expired responses, reservations of requests in progress are removed by release
+func (s *idempotencyStore) prune(now time.Time) {
+	for k, entry := range s.entries {
+		if entry.expired(now) {
+			delete(s.entries, k)
+		}
+	}
+	s.lastPrune = now
+}
+
+func (e *idempotentResponse) expired(now time.Time) bool {
+	return e.done && now.After(e.expiresAt)
+}
+
+// release removes the reservation of a request which did not complete, e.g. because its handler panicked,
+// so it can be retried
+func (s *idempotencyStore) release(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+}
+
+// complete caches the response for the key, server errors are not cached so the request can be retried
+func (s *idempotencyStore) complete(key string, status int, header http.Header, body []byte, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if status >= http.StatusInternalServerError {
+		delete(s.entries, key)
+		return
+	}
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return
+	}
+
+	entry.done = true
+	entry.status = status
+	entry.header = header
+	entry.body = bytes.Clone(body)
+	entry.expiresAt = now.Add(s.ttl)
+}
+
+// serveIdempotent processes the request once per idempotency key and writes the cached response for retries
+func (m *Middleware) serveIdempotent(recorder *responseRecorder, req *http.Request, next http.Handler, idempotencyKey string) {
+	identityKey, _ := req.Context().Value(transport.IdentityKey).(string)
+	key := identityKey + " " + idempotencyKey
+
+	fingerprint, err := requestFingerprint(req)
+	if err != nil {
+		m.respondWithError(recorder, http.StatusBadRequest, transport.ErrCodeInternal, err)
+		return
+	}
+
+	cached, err := m.idempotency.begin(key, fingerprint, time.Now())
+	switch {
+	case errors.Is(err, ErrIdempotencyKeyInProgress):
+		m.respondWithError(recorder, http.StatusConflict, transport.ErrCodeIdempotencyKeyInProgress, err)
+		return
+	case errors.Is(err, ErrIdempotencyKeyMismatch):
+		m.respondWithError(recorder, http.StatusUnprocessableEntity, transport.ErrCodeIdempotencyKeyMismatch, err)
+		return
+	}
+
+	if cached != nil {
+		m.logger.Debug("Serving cached response for idempotency key", slog.String("idempotencyKey", idempotencyKey))
+		for k, v := range cached.header {
+			recorder.Header()[k] = v
+		}
+		recorder.Header().Set(IdempotentReplayHeader, "true")
+		recorder.WriteHeader(cached.status)
+		_, _ = recorder.Write(cached.body)
+		return
+	}
+
+	// the reservation is released when the handler panics, retries would be rejected as in progress otherwise
+	completed := false
+	defer func() {
+		if !completed {
+			m.idempotency.release(key)
+		}
+	}()
+
+	// the response is cached for retries, so it is buffered even when the handler flushes it
+	recorder.startStream = nil
+	next.ServeHTTP(recorder, req)
+	m.idempotency.complete(key, recorder.statusCode, recorder.Header().Clone(), recorder.body.Bytes(), time.Now())
+	completed = true
+}
+
+// requestFingerprint identifies the request content, so an idempotency key cannot be reused for a different request
+func requestFingerprint(req *http.Request) ([sha256.Size]byte, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return [sha256.Size]byte{}, errors.New("failed to read request body")
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(req.Method + " " + req.URL.RequestURI() + "\n"))
+	hash.Write(body)
+
+	var fingerprint [sha256.Size]byte
+	copy(fingerprint[:], hash.Sum(nil))
+	return fingerprint, nil
+}
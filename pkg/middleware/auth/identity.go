@@ -16,3 +16,16 @@ func GetIdentityFromContext(ctx context.Context) (string, bool) {
 	identityKey, ok := value.(string)
 	return identityKey, ok
 }
+
+// GetPrincipalFromContext retrieves the identity key of the principal the peer acts for from the request context,
+// it is only set for peers authenticated with an agent key and a delegation of the principal
+func GetPrincipalFromContext(ctx context.Context) (string, bool) {
+	principal, ok := ctx.Value(transport.Principal).(string)
+	return principal, ok
+}
+
+// GetCapabilitiesFromContext retrieves the capabilities negotiated for the session of the request from the request context
+func GetCapabilitiesFromContext(ctx context.Context) ([]transport.Capability, bool) {
+	capabilities, ok := ctx.Value(transport.NegotiatedCapabilities).([]transport.Capability)
+	return capabilities, ok
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// maxKnownPeerSize limits the size of peers registered with the admin API
+const maxKnownPeerSize = 4 << 10
+
+// KnownPeers returns the pre-registered peers of the middleware, peers added to the set are pre-warmed from their next handshake
+func (m *Middleware) KnownPeers() *transport.KnownPeers {
+	return m.knownPeers
+}
+
+// KnownPeersHandler serves the admin API of the known peers: GET lists them, PUT registers the peer of the JSON body
+// and DELETE removes the peer of the identityKey query parameter. The handler does not authenticate its callers,
+// it has to be served on an internal listener or behind the access control of the operator.
+func (m *Middleware) KnownPeersHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(m.knownPeers.List()); err != nil {
+				m.logger.Error("Failed to write known peers", slog.String("error", err.Error()))
+			}
+		case http.MethodPut:
+			var peer transport.KnownPeer
+			if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxKnownPeerSize)).Decode(&peer); err != nil {
+				http.Error(w, "invalid known peer", http.StatusBadRequest)
+				return
+			}
+			if err := m.knownPeers.Add(peer); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			m.logger.Info("Known peer registered", slog.String("identityKey", peer.IdentityKey), slog.String("name", peer.Name))
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodDelete:
+			identityKey := req.URL.Query().Get("identityKey")
+			if !m.knownPeers.Remove(identityKey) {
+				http.Error(w, "unknown peer", http.StatusNotFound)
+				return
+			}
+			m.logger.Info("Known peer removed", slog.String("identityKey", identityKey))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
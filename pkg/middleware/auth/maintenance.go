@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// StartMaintenance makes the middleware respond with signed 503 Service Unavailable responses to all protected routes
+// for the given duration. The handshake and discovery endpoints keep working, so peers keep their sessions warm.
+func (m *Middleware) StartMaintenance(duration time.Duration) {
+	until := time.Now().Add(duration)
+	m.maintenanceUntil.Store(until.UnixNano())
+	m.logger.Info("Maintenance mode started", slog.Time("until", until))
+}
+
+// StopMaintenance ends the maintenance mode before its deadline
+func (m *Middleware) StopMaintenance() {
+	m.maintenanceUntil.Store(0)
+	m.logger.Info("Maintenance mode stopped")
+}
+
+// MaintenanceRemaining returns the remaining time of the maintenance mode, zero when it is not active
+func (m *Middleware) MaintenanceRemaining() time.Duration {
+	until := m.maintenanceUntil.Load()
+	if until == 0 {
+		return 0
+	}
+
+	remaining := time.Until(time.Unix(0, until))
+	if remaining <= 0 {
+		return 0
+	}
+	return remaining
+}
+
+func (m *Middleware) respondWithMaintenance(w http.ResponseWriter, remaining time.Duration) {
+	retryAfter := int((remaining + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	m.respondWithError(w, http.StatusServiceUnavailable, transport.ErrCodeMaintenance, ErrMaintenance)
+}
@@ -2,25 +2,57 @@ package auth
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
 	"errors"
+	"fmt"
+	"hash"
 	"log/slog"
 	"net/http"
-	"strings"
+	"sync/atomic"
+	"time"
 
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/events"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/internal/logging"
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/sessionmanager"
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
 	httptransport "github.com/bsv-blockchain/go-bsv-middleware/pkg/transport/http"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
 )
 
 // Middleware implements BRC-103/104 authentication
 type Middleware struct {
-	wallet               wallet.WalletInterface
-	sessionManager       sessionmanager.SessionManagerInterface
-	transport            transport.TransportInterface
-	allowUnauthenticated bool
-	logger               *slog.Logger
+	wallet                wallet.WalletInterface
+	privilegedKeys        transport.PrivilegedKeys
+	sessionManager        session.Manager
+	transport             transport.TransportInterface
+	allowUnauthenticated  bool
+	reportOnly            bool
+	rollout               *EnforcementRollout
+	encryptPayloads       bool
+	padPayloads           bool
+	certificatesToRequest atomic.Pointer[transport.RequestedCertificateSet]
+	certificatesCallback  bool
+	paymentHints          *PaymentHints
+	idempotency           *idempotencyStore
+	routePolicies         *routePolicies
+	forwardAuth           ForwardAuthConfig
+	maintenanceUntil      atomic.Int64
+	logger                *slog.Logger
+	accessLogger          *slog.Logger
+	events                *events.Bus
+	anomalies             *anomalyDetector
+	knownPeers            *transport.KnownPeers
+	featureFlags          *transport.FeatureFlags
+	revocations           *transport.RevocationList
+	blocklist             *Blocklist
+	tarpit                *tarpit
+	accounts              *accountCache
+	anonymousLimiter      *windowLimiter
+	authorizer            Authorizer
+	tiers                 *tiers
+	tokenMinting          *TokenMinting
+	routes                *RouteRegistry
 }
 
 // ResponseRecorder is a custom ResponseWriter to capture response body and status
@@ -28,7 +60,17 @@ type responseRecorder struct {
 	http.ResponseWriter
 	statusCode int
 	body       *bytes.Buffer
-	written    bool
+	signed     bool
+	// signSwitch signs the 101 Switching Protocols response of an upgraded connection
+	signSwitch func() error
+	// heartbeat handles the heartbeats received over an upgraded connection
+	heartbeat func(msg *transport.AuthMessage) (*transport.AuthMessage, error)
+	hijacked  bool
+	// startStream sets up the headers of a streamed response, see Flush
+	startStream func(status int) (func(bodyHash []byte) error, error)
+	// signTrailer signs the streamed response once the handler returns, nil while the response is buffered
+	signTrailer func(bodyHash []byte) error
+	bodyHash    hash.Hash
 }
 
 func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
@@ -44,10 +86,12 @@ func (r *responseRecorder) WriteHeader(code int) {
 	r.statusCode = code
 }
 
-// Write writes response body to internal buffer
+// Write appends to the response body in the internal buffer, handlers may write the body in chunks
+// (e.g. http.ServeContent), the complete body is signed once the handler returns
 func (r *responseRecorder) Write(b []byte) (int, error) {
-	if r.written {
-		return 0, errors.New("response already written")
+	if r.signTrailer != nil {
+		r.bodyHash.Write(b)
+		return r.ResponseWriter.Write(b) //nolint:wrapcheck // the body is streamed as written by the handler
 	}
 
 	n, err := r.body.Write(b)
@@ -55,15 +99,67 @@ func (r *responseRecorder) Write(b []byte) (int, error) {
 		return 0, errors.New("failed to write response")
 	}
 
-	r.written = true
 	return n, nil
 }
 
+// replaceBody replaces the captured body with the one covered by the response signature.
+// The Content-Length set by the handler is dropped, as the signed body may be transformed (e.g. encrypted).
+// Partial responses are signed like complete ones: the signature covers the served range bytes and the
+// Content-Range header, so a client detects a range which does not match the one it receives.
+func (r *responseRecorder) replaceBody(body []byte) {
+	r.body.Reset()
+	r.body.Write(body)
+	r.signed = true
+	r.Header().Del("Content-Length")
+}
+
+// SetReadDeadline sets the read deadline of the connection for http.ResponseController,
+// the writer is not unwrapped for it as flushes would bypass the signing of the response
+func (r *responseRecorder) SetReadDeadline(deadline time.Time) error {
+	return http.NewResponseController(r.ResponseWriter).SetReadDeadline(deadline)
+}
+
+// Flush streams the response when the session negotiated transport.CapabilityTrailerSignature: the headers and
+// the body written so far are sent, further writes are passed through and the signature is sent in a trailer over
+// the hash of the body once the handler returns. Otherwise the response stays buffered and the flush has no effect.
+func (r *responseRecorder) Flush() {
+	if r.signTrailer == nil {
+		if r.startStream == nil {
+			return
+		}
+		sign, err := r.startStream(r.statusCode)
+		r.startStream = nil
+		if err != nil || sign == nil {
+			return
+		}
+
+		r.signTrailer, r.bodyHash = sign, sha256.New()
+		r.ResponseWriter.WriteHeader(r.statusCode)
+		buffered := r.body.Bytes()
+		r.body = &bytes.Buffer{}
+		if _, err := r.Write(buffered); err != nil {
+			return
+		}
+	}
+	_ = http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+// discardBody drops the captured body, so an error response can replace the response of the handler
+func (r *responseRecorder) discardBody() {
+	r.body.Reset()
+	r.Header().Del("Content-Length")
+}
+
 // Finalize writes the captured headers and body
 func (r *responseRecorder) Finalize() error {
 	r.ResponseWriter.WriteHeader(r.statusCode)
-	body := strings.TrimSpace(r.body.String())
-	_, err := r.ResponseWriter.Write([]byte(body))
+
+	body := r.body.Bytes()
+	if !r.signed {
+		body = bytes.TrimSpace(body)
+	}
+
+	_, err := r.ResponseWriter.Write(body)
 	if err != nil {
 		return errors.New("failed to write response")
 	}
@@ -73,12 +169,12 @@ func (r *responseRecorder) Finalize() error {
 
 // New creates a new auth middleware
 func New(opts Config) (*Middleware, error) {
-	if opts.SessionManager == nil {
-		opts.SessionManager = sessionmanager.NewSessionManager()
+	if err := applyProfile(&opts); err != nil {
+		return nil, err
 	}
 
 	if opts.Wallet == nil {
-		return nil, errors.New("wallet is required")
+		return nil, ErrWalletRequired
 	}
 
 	if opts.Logger == nil {
@@ -88,61 +184,416 @@ func New(opts Config) (*Middleware, error) {
 	middlewareLogger := logging.Child(opts.Logger, "auth-middleware")
 
 	if opts.OnCertificatesReceived == nil && opts.CertificatesToRequest != nil {
-		return nil, errors.New("OnCertificatesReceived callback is required when certificates are requested")
+		return nil, ErrCertificatesCallbackRequired
 	}
 
 	if opts.OnCertificatesReceived != nil && opts.CertificatesToRequest == nil {
-		return nil, errors.New("OnCertificatesReceived callback is set but no certificates are requested")
+		return nil, ErrCertificatesNotRequested
+	}
+
+	if opts.CertificatesToRequest != nil {
+		if err := opts.CertificatesToRequest.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := opts.Logging.Validate(); err != nil {
+		return nil, fmt.Errorf("%w, %w", ErrInvalidLogConfig, err)
+	}
+
+	routePolicies, err := newRoutePolicies(opts.RoutePolicies)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := opts.ForwardAuth.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := opts.TokenMinting.validate(); err != nil {
+		return nil, err
+	}
+
+	if err := opts.Rollout.validate(); err != nil {
+		return nil, err
+	}
+
+	tiers, err := newTiers(opts.Tiers)
+	if err != nil {
+		return nil, err
+	}
+
+	knownPeers, err := transport.NewKnownPeers(opts.KnownPeers...)
+	if err != nil {
+		return nil, err
+	}
+
+	featureFlags, err := transport.NewFeatureFlags(opts.FeatureFlags)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.injectFaults()
+
+	revocations, err := transport.NewRevocationList(context.Background(), opts.RevocationStore)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.SelfTest {
+		if err := selfTest(opts.Wallet, opts.PrivilegedKeys); err != nil {
+			return nil, err
+		}
+		middlewareLogger.Debug("Wallet self-test passed")
+	}
+
+	if opts.SessionManager == nil && opts.strictProduction() {
+		return nil, fmt.Errorf("%w, the in-memory session manager is not used in strict production mode", ErrSessionManagerRequired)
+	}
+
+	if opts.SessionManager == nil {
+		opts.SessionManager = session.NewMemoryManager()
 	}
 
 	middlewareLogger.Debug(" Creating new auth middleware")
 
-	t := httptransport.New(opts.Wallet, opts.SessionManager, opts.AllowUnauthenticated, opts.Logger, opts.CertificatesToRequest, opts.OnCertificatesReceived)
+	var serverInfo string
+	if opts.ServerInfoHeader {
+		serverInfo = ServerInfo()
+	}
+
+	redactionPolicy := opts.RedactionPolicy
+	if redactionPolicy == nil {
+		redactionPolicy = transport.DefaultCertificateRedactionPolicy()
+	}
+
+	bus := opts.Events
+	if bus == nil {
+		bus = events.NewBus(EventRedactor(redactionPolicy))
+	}
+	if opts.Telemetry != nil {
+		opts.Telemetry.Subscribe(bus)
+	}
+	reportBlocks(opts.Blocking, bus)
+
+	blocklist := &Blocklist{}
+	if opts.Blocking != nil && opts.Blocking.Blocklist != nil {
+		blocklist = opts.Blocking.Blocklist
+	}
+
+	t := httptransport.New(httptransport.Config{
+		Wallet:                  opts.Wallet,
+		SessionManager:          opts.SessionManager,
+		AllowUnauthenticated:    opts.AllowUnauthenticated,
+		Logger:                  opts.Logger,
+		CertificatesToRequest:   opts.CertificatesToRequest,
+		OnCertificatesReceived:  opts.OnCertificatesReceived,
+		EncryptPayloads:         opts.EncryptPayloads,
+		PadPayloads:             opts.PadPayloads,
+		RedactionPolicy:         redactionPolicy,
+		ReplayWindow:            opts.ReplayWindow,
+		RevocationTracker:       opts.RevocationTracker,
+		MinNonceSize:            opts.MinNonceSize,
+		Logging:                 opts.Logging,
+		WalletTimeouts:          opts.WalletTimeouts,
+		ReadTimeouts:            opts.ReadTimeouts,
+		PrivilegedKeys:          opts.PrivilegedKeys,
+		OriginBinding:           opts.OriginBinding,
+		ServerInfo:              serverInfo,
+		AnonymousSessions:       opts.AnonymousAccess != nil,
+		X509Bridge:              opts.X509Bridge,
+		CredentialAdapter:       opts.CredentialAdapter,
+		StrictDisclosure:        opts.StrictDisclosure,
+		KnownPeers:              knownPeers,
+		FeatureFlags:            featureFlags,
+		TrustRegistry:           opts.TrustRegistry,
+		CertificateCompressions: opts.CertificateCompressions,
+		MaxCertificatesSize:     opts.MaxCertificatesSize,
+		Events:                  bus,
+		OnVerificationReport:    newVerificationReporter(opts.VerificationReports, middlewareLogger, bus),
+		OnInitialResponse:       opts.OnInitialResponse,
+		CoSigner:                opts.CoSigner,
+		Delegations:             opts.Delegations,
+		Revocations:             revocations,
+		PathNormalization:       opts.PathNormalization,
+		WebSocketOrigins:        opts.WebSocketOrigins,
+	})
 
 	middlewareLogger.Debug(" transport created")
 
-	return &Middleware{
+	var idempotency *idempotencyStore
+	if opts.IdempotencyKeyTTL > 0 {
+		idempotency = newIdempotencyStore(opts.IdempotencyKeyTTL)
+	}
+
+	m := &Middleware{
 		wallet:               opts.Wallet,
+		privilegedKeys:       opts.PrivilegedKeys,
 		sessionManager:       opts.SessionManager,
 		transport:            t,
 		allowUnauthenticated: opts.AllowUnauthenticated,
+		reportOnly:           opts.ReportOnly,
+		rollout:              opts.Rollout,
+		encryptPayloads:      opts.EncryptPayloads,
+		padPayloads:          opts.PadPayloads,
+		certificatesCallback: opts.OnCertificatesReceived != nil,
+		paymentHints:         opts.PaymentHints,
+		idempotency:          idempotency,
+		routePolicies:        routePolicies,
+		forwardAuth:          opts.ForwardAuth,
 		logger:               middlewareLogger,
-	}, nil
+		accessLogger:         opts.AccessLogger,
+		events:               bus,
+		anomalies:            newAnomalyDetector(opts.Anomalies, knownPeers, bus),
+		blocklist:            blocklist,
+		knownPeers:           knownPeers,
+		featureFlags:         featureFlags,
+		revocations:          revocations,
+		tarpit:               newTarpit(opts.Tarpit, middlewareLogger),
+		anonymousLimiter:     newAnonymousLimiter(opts.AnonymousAccess),
+		authorizer:           opts.Authorizer,
+		tiers:                tiers,
+		tokenMinting:         opts.TokenMinting,
+		routes:               opts.Routes,
+		accounts:             newAccountCache(opts.AccountResolver, opts.AccountCacheTTL, opts.AccountNegativeCacheTTL),
+	}
+	m.certificatesToRequest.Store(opts.CertificatesToRequest)
+
+	return m, nil
 }
 
 // Handler returns standard http middleware
 func (m *Middleware) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		access, w := m.startAccessLog(w)
+		ctx, walletCalls := transport.WithWalletCallCounter(req.Context())
+		req = req.WithContext(ctx)
+		access.walletCalls = walletCalls
+		defer func() {
+			m.logAccess(access, req)
+			m.publishRequest(access, req)
+		}()
+
 		recorder := newResponseRecorder(w)
-		if req.Method == http.MethodPost && req.URL.Path == "/.well-known/auth" {
+		if errorCode, blocked := m.blocked(recorder, req); blocked {
+			access.outcome, access.errorCode = AccessOutcomeRejected, errorCode
+			createResponse(recorder)
+			return
+		}
+
+		if req.Method == http.MethodGet && req.URL.Path == DiscoveryPath {
+			access.outcome = AccessOutcomeDiscovery
+			m.serveDiscoveryDocument(w)
+			return
+		}
+
+		if req.Method == http.MethodGet && req.URL.Path == OpenAPIPath && m.routes != nil {
+			access.outcome = AccessOutcomeDiscovery
+			m.serveOpenAPIDocument(w)
+			return
+		}
+
+		if remaining := m.anomalies.lockedOut(req, time.Now()); remaining > 0 {
+			if m.tarpit.lockedOut() {
+				access.outcome = AccessOutcomeTarpit
+				access.errorCode = m.serveTarpit(recorder, req, TarpitReasonLockedOut)
+			} else {
+				access.outcome = AccessOutcomeRejected
+				access.errorCode = m.respondWithRateLimit(recorder, &rateLimitError{err: ErrLockedOut, retryAfter: remaining})
+			}
+			createResponse(recorder)
+			return
+		}
+
+		if req.Method == http.MethodPost && req.URL.Path == HandshakePath {
+			access.outcome = AccessOutcomeHandshake
+			verifyStart := time.Now()
 			err := m.transport.HandleNonGeneralRequest(req, recorder)
+			access.verify = time.Since(verifyStart)
+			if errors.Is(err, transport.ErrRequestCanceled) {
+				access.outcome, access.errorCode = AccessOutcomeCanceled, transport.ErrCodeRequestCanceled
+				return
+			}
 			if err != nil {
-				http.Error(recorder, err.Error(), http.StatusUnauthorized)
+				access.outcome, access.errorCode, access.err = AccessOutcomeRejected, transport.ErrorCode(err), err
+				m.respondWithError(recorder, transport.ErrorStatus(err), transport.ErrorCode(err), err)
 			}
 			createResponse(recorder)
 			return
 		}
 
-		req, authMsg, err := m.transport.HandleGeneralRequest(req, recorder)
+		if policy, ok := m.routePolicies.match(req); ok {
+			if policy.Exempt || (policy.AllowUnauthenticated && req.Header.Get(requestIDHeader) == "") {
+				access.outcome = AccessOutcomeUnauthenticated
+				if policy.Exempt {
+					access.outcome = AccessOutcomeExempt
+				}
+				handlerStart := time.Now()
+				next.ServeHTTP(w, req)
+				access.handler = time.Since(handlerStart)
+				return
+			}
+		}
+
+		verifyStart := time.Now()
+		authReq, authMsg, err := m.transport.HandleGeneralRequest(req, recorder)
+		access.verify = time.Since(verifyStart)
+		if errors.Is(err, transport.ErrRequestCanceled) {
+			// the peer is gone, neither the handler nor the wallet are busied with the request
+			access.outcome, access.errorCode = AccessOutcomeCanceled, transport.ErrCodeRequestCanceled
+			return
+		}
+		// requests which were not received completely cannot be passed in report-only mode
+		if err != nil && !errors.Is(err, transport.ErrReadTimeout) && !m.enforces(req.Header.Get(identityKeyHeader)) {
+			access.outcome, access.errorCode = AccessOutcomeReportOnly, transport.ErrorCode(err)
+			m.logReportOnly(req, err)
+			handlerStart := time.Now()
+			next.ServeHTTP(w, req)
+			access.handler = time.Since(handlerStart)
+			return
+		}
 		if err != nil {
-			http.Error(recorder, err.Error(), http.StatusUnauthorized)
+			access.outcome, access.errorCode, access.err = AccessOutcomeRejected, transport.ErrorCode(err), err
+			m.respondWithError(recorder, transport.ErrorStatus(err), transport.ErrorCode(err), err)
 			createResponse(recorder)
 			return
 		}
 
-		next.ServeHTTP(recorder, req)
+		access.outcome = AccessOutcomeUnauthenticated
+		if authReq != nil {
+			req = authReq
+			access.outcome = AccessOutcomeAuthenticated
+			access.identityKey, _ = GetIdentityFromContext(req.Context())
+		}
+
+		recorder.signSwitch = func() error {
+			_, err := m.transport.HandleResponse(req, recorder, nil, http.StatusSwitchingProtocols, authMsg)
+			return err
+		}
+		recorder.heartbeat = m.handleHeartbeat
+		recorder.startStream = func(status int) (func(bodyHash []byte) error, error) {
+			sign, err := m.transport.StreamResponse(req, recorder, status, authMsg)
+			if err != nil {
+				m.logger.Error("Failed to stream response, the response is buffered", slog.String("error", err.Error()))
+			}
+			return sign, err
+		}
 
-		err = m.transport.HandleResponse(req, recorder, recorder.body.Bytes(), recorder.statusCode, authMsg)
+		handlerStart := time.Now()
+		if policyReq, outcome, errorCode := m.applyPolicies(recorder, req); policyReq == nil {
+			if outcome != "" {
+				access.outcome = outcome
+			}
+			access.errorCode = errorCode
+		} else if idempotencyKey := req.Header.Get(IdempotencyKeyHeader); m.idempotency != nil && idempotencyKey != "" {
+			m.serveIdempotent(recorder, policyReq, next, idempotencyKey)
+		} else {
+			next.ServeHTTP(recorder, policyReq)
+		}
+		access.handler = time.Since(handlerStart)
+
+		if recorder.hijacked {
+			// the handler upgraded the connection, the signed 101 response was written before it took over
+			if access.writer != nil {
+				access.writer.status = http.StatusSwitchingProtocols
+			}
+			return
+		}
+
+		if recorder.signTrailer != nil {
+			// the status and body were streamed, a response missing its signature trailer is rejected by the client
+			signStart := time.Now()
+			if err := recorder.signTrailer(recorder.bodyHash.Sum(nil)); err != nil {
+				access.errorCode = transport.ErrCodeInternal
+				m.logger.Error("Failed to sign streamed response", slog.String("error", err.Error()))
+			}
+			access.sign = time.Since(signStart)
+			return
+		}
+
+		signStart := time.Now()
+		body, err := m.transport.HandleResponse(req, recorder, recorder.body.Bytes(), recorder.statusCode, authMsg)
+		access.sign = time.Since(signStart)
+		if errors.Is(err, transport.ErrRequestCanceled) {
+			access.outcome, access.errorCode = AccessOutcomeCanceled, transport.ErrCodeRequestCanceled
+			return
+		}
+		if errors.Is(err, transport.ErrWalletTimeout) {
+			access.errorCode = transport.ErrCodeWalletTimeout
+			recorder.discardBody()
+			m.respondWithError(recorder, http.StatusServiceUnavailable, transport.ErrCodeWalletTimeout, err)
+			createResponse(recorder)
+			return
+		}
 		if err != nil {
-			http.Error(recorder, err.Error(), http.StatusInternalServerError)
+			access.errorCode = transport.ErrCodeInternal
+			recorder.discardBody()
+			m.respondWithError(recorder, http.StatusInternalServerError, transport.ErrCodeInternal, err)
 			createResponse(recorder)
 			return
 		}
+		recorder.replaceBody(body)
 
 		createResponse(recorder)
 	})
 }
 
+// applyPolicies applies the policies of the middleware to a verified request: maintenance, the tarpit, anonymous
+// access, tiers, account resolution, the Authorizer and token minting. It returns the request carrying the
+// resolved tier, account and token, or nil when a policy denied the request and answered it on w, along with the
+// access outcome of the denial when it differs from the outcome of the verification and its error code.
+func (m *Middleware) applyPolicies(w http.ResponseWriter, req *http.Request) (*http.Request, string, string) {
+	if remaining := m.MaintenanceRemaining(); remaining > 0 {
+		m.respondWithMaintenance(w, remaining)
+		return nil, "", ""
+	}
+	if m.tarpit.abusive(req) {
+		return nil, AccessOutcomeTarpit, m.serveTarpit(w, req, TarpitReasonAbusive)
+	}
+	if err := m.checkAnonymous(req); err != nil {
+		return nil, "", m.respondWithAnonymousError(w, err)
+	}
+
+	tierReq, err := m.withTier(req)
+	if err != nil {
+		var limitErr *rateLimitError
+		errors.As(err, &limitErr)
+		return nil, "", m.respondWithRateLimit(w, limitErr)
+	}
+
+	accountReq, err := m.withAccount(tierReq)
+	if err != nil {
+		m.logger.Error("Failed to resolve account", slog.String("error", err.Error()))
+		m.respondWithError(w, http.StatusServiceUnavailable, transport.ErrCodeAccountUnavailable, err)
+		return nil, "", transport.ErrCodeAccountUnavailable
+	}
+
+	if err := m.authorize(accountReq); err != nil {
+		if m.tarpit.denied() && errors.Is(err, ErrAuthorizationDenied) {
+			return nil, AccessOutcomeTarpit, m.serveTarpit(w, accountReq, TarpitReasonDenied)
+		}
+		return nil, "", m.respondWithAuthorizationError(w, err)
+	}
+
+	tokenReq, err := m.withToken(accountReq)
+	if err != nil {
+		m.logger.Error("Failed to mint token", slog.String("error", err.Error()))
+		m.respondWithError(w, http.StatusInternalServerError, transport.ErrCodeInternal, err)
+		return nil, "", transport.ErrCodeInternal
+	}
+	return tokenReq, "", ""
+}
+
+// logReportOnly logs the verdict of a request which failed verification in report-only mode
+func (m *Middleware) logReportOnly(req *http.Request, err error) {
+	m.logger.Warn("Request failed verification, passed in report-only mode",
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.String("identityKey", req.Header.Get(identityKeyHeader)),
+		slog.String("code", transport.ErrorCode(err)),
+		slog.String("error", err.Error()))
+}
+
 func createResponse(recorder *responseRecorder) {
 	err := recorder.Finalize()
 	if err != nil {
@@ -1,24 +1,28 @@
 package auth_test
 
 import (
+	"context"
 	"errors"
+	"log/slog"
 	"net/http"
+	"strings"
 	"testing"
 
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/defs"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/sessionmanager"
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet"
-	walletFixtures "github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet/test"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
 	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-var (
-	errWalletRequired       = errors.New("wallet is required")
-	errMissingCertsCallback = errors.New("OnCertificatesReceived callback is required when certificates are requested")
-	errMissingCertsRequest  = errors.New("OnCertificatesReceived callback is set but no certificates are requested")
+const (
+	testCertifier       = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	testCertificateType = "rJeOY/Jzup7hEyv+zBA8dnxSUS/VsGXMDuIk+exJ1qI="
 )
 
 // SETUP-1: Missing Wallet Instance
@@ -31,30 +35,58 @@ func TestNew_MissingWallet(t *testing.T) {
 	// then
 	assert.Nil(t, middleware)
 	assert.Error(t, err)
-	assert.Equal(t, errWalletRequired.Error(), err.Error())
-	assert.True(t, errors.Is(err, errWalletRequired) || err.Error() == errWalletRequired.Error())
+	assert.Equal(t, auth.ErrWalletRequired.Error(), err.Error())
+	assert.ErrorIs(t, err, auth.ErrWalletRequired)
 }
 
 // SETUP-2: Default Session Manager Creation
 func TestNew_DefaultSessionManager(t *testing.T) {
-	t.Run("creates default session manager when none provided", func(t *testing.T) {
-		// given
-		sPrivKey, err := ec.PrivateKeyFromHex(walletFixtures.ServerPrivateKeyHex)
-		if err != nil {
-			panic(err)
-		}
+	sPrivKey, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	serverMockedWallet := wallettest.NewMockWallet(sPrivKey, wallettest.DefaultNonces...)
 
-		serverMockedWallet := wallet.NewMockWallet(sPrivKey, walletFixtures.DefaultNonces...)
+	t.Run("creates default session manager when none provided outside strict production mode", func(t *testing.T) {
+		// given
+		strictProduction := false
 
 		// when
 		middleware, err := auth.New(auth.Config{
-			Wallet: serverMockedWallet,
+			Wallet:           serverMockedWallet,
+			StrictProduction: &strictProduction,
 		})
 
 		// then
 		require.NoError(t, err)
 		assert.NotNil(t, middleware)
 	})
+
+	t.Run("error without session manager in strict production mode", func(t *testing.T) {
+		// given
+		strictProduction := true
+
+		// when
+		middleware, err := auth.New(auth.Config{
+			Wallet:           serverMockedWallet,
+			StrictProduction: &strictProduction,
+		})
+
+		// then
+		assert.Nil(t, middleware)
+		require.ErrorIs(t, err, auth.ErrSessionManagerRequired)
+	})
+
+	t.Run("strict production mode is the default without the dev build tag", func(t *testing.T) {
+		// when
+		middleware, err := auth.New(auth.Config{Wallet: serverMockedWallet})
+
+		// then
+		if auth.StrictProductionDefault {
+			require.ErrorIs(t, err, auth.ErrSessionManagerRequired)
+		} else {
+			require.NoError(t, err)
+			require.NotNil(t, middleware)
+		}
+	})
 }
 
 // SETUP-3: Default Logger Creation
@@ -62,11 +94,12 @@ func TestNew_DefaultLogger(t *testing.T) {
 	// given
 	key, err := ec.NewPrivateKey()
 	require.NoError(t, err)
-	mockWallet := wallet.NewMockWallet(key)
+	mockWallet := wallettest.NewMockWallet(key)
 
 	// when
 	middleware, err := auth.New(auth.Config{
-		Wallet: mockWallet,
+		Wallet:         mockWallet,
+		SessionManager: session.NewMemoryManager(),
 		// No logger provided
 	})
 
@@ -80,12 +113,13 @@ func TestNew_AllowUnauthenticatedFlag(t *testing.T) {
 	// given
 	key, err := ec.NewPrivateKey()
 	require.NoError(t, err)
-	mockWallet := wallet.NewMockWallet(key)
+	mockWallet := wallettest.NewMockWallet(key)
 
 	t.Run("Flag set to true", func(t *testing.T) {
 		// when
 		middleware, err := auth.New(auth.Config{
 			Wallet:               mockWallet,
+			SessionManager:       session.NewMemoryManager(),
 			AllowUnauthenticated: true,
 		})
 
@@ -104,6 +138,7 @@ func TestNew_AllowUnauthenticatedFlag(t *testing.T) {
 		// when
 		middleware, err := auth.New(auth.Config{
 			Wallet:               mockWallet,
+			SessionManager:       session.NewMemoryManager(),
 			AllowUnauthenticated: false,
 		})
 
@@ -123,15 +158,15 @@ func TestNew_AllowUnauthenticatedFlag(t *testing.T) {
 func TestNew_InconsistentCertificateConfig(t *testing.T) {
 	t.Run("error with OnCertificatesReceived but no CertificatesToRequest", func(t *testing.T) {
 		// given
-		sPrivKey, err := ec.PrivateKeyFromHex(walletFixtures.ServerPrivateKeyHex)
+		sPrivKey, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
 		if err != nil {
 			panic(err)
 		}
 
-		serverMockedWallet := wallet.NewMockWallet(sPrivKey, walletFixtures.DefaultNonces...)
-		mockSessionManager := sessionmanager.NewSessionManager()
+		serverMockedWallet := wallettest.NewMockWallet(sPrivKey, wallettest.DefaultNonces...)
+		mockSessionManager := session.NewMemoryManager()
 
-		onCertificatesReceived := func(senderPublicKey string, certs *[]wallet.VerifiableCertificate, req *http.Request, res http.ResponseWriter, next func()) {
+		onCertificatesReceived := func(senderPublicKey string, certs *[]certificates.VerifiableCertificate, req *http.Request, res http.ResponseWriter, next func()) {
 		}
 
 		// when
@@ -145,26 +180,22 @@ func TestNew_InconsistentCertificateConfig(t *testing.T) {
 		// then
 		require.Error(t, err)
 		assert.Nil(t, middleware)
-		assert.Equal(t, errMissingCertsRequest.Error(), err.Error())
-		assert.True(t, errors.Is(err, errMissingCertsRequest) || err.Error() == errMissingCertsRequest.Error())
+		assert.Equal(t, auth.ErrCertificatesNotRequested.Error(), err.Error())
+		assert.ErrorIs(t, err, auth.ErrCertificatesNotRequested)
 	})
 
 	t.Run("error with CertificatesToRequest but no OnCertificatesReceived", func(t *testing.T) {
 		// given
-		sPrivKey, err := ec.PrivateKeyFromHex(walletFixtures.ServerPrivateKeyHex)
+		sPrivKey, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
 		if err != nil {
 			panic(err)
 		}
 
-		serverMockedWallet := wallet.NewMockWallet(sPrivKey, walletFixtures.DefaultNonces...)
-		mockSessionManager := sessionmanager.NewSessionManager()
+		serverMockedWallet := wallettest.NewMockWallet(sPrivKey, wallettest.DefaultNonces...)
+		mockSessionManager := session.NewMemoryManager()
 
-		certificatesToRequest := &transport.RequestedCertificateSet{
-			Certifiers: []string{"certifier-key"},
-			Types: map[string][]string{
-				"test-cert": {"field1", "field2"},
-			},
-		}
+		certificatesToRequest := transport.NewRequestedCertificateSet(testCertifier).
+			AddType(testCertificateType, "field1", "field2")
 
 		// when
 		middleware, err := auth.New(auth.Config{
@@ -177,30 +208,26 @@ func TestNew_InconsistentCertificateConfig(t *testing.T) {
 		// then
 		require.Error(t, err)
 		assert.Nil(t, middleware)
-		assert.Equal(t, errMissingCertsCallback.Error(), err.Error())
-		assert.True(t, errors.Is(err, errMissingCertsCallback) || err.Error() == errMissingCertsCallback.Error())
+		assert.Equal(t, auth.ErrCertificatesCallbackRequired.Error(), err.Error())
+		assert.ErrorIs(t, err, auth.ErrCertificatesCallbackRequired)
 	})
 }
 
 func TestNew_ValidCertificateConfig(t *testing.T) {
 	t.Run("success with valid certificate configuration", func(t *testing.T) {
 		// given
-		sPrivKey, err := ec.PrivateKeyFromHex(walletFixtures.ServerPrivateKeyHex)
+		sPrivKey, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
 		if err != nil {
 			panic(err)
 		}
 
-		serverMockedWallet := wallet.NewMockWallet(sPrivKey, walletFixtures.DefaultNonces...)
-		mockSessionManager := sessionmanager.NewSessionManager()
+		serverMockedWallet := wallettest.NewMockWallet(sPrivKey, wallettest.DefaultNonces...)
+		mockSessionManager := session.NewMemoryManager()
 
-		certificatesToRequest := &transport.RequestedCertificateSet{
-			Certifiers: []string{"certifier-key"},
-			Types: map[string][]string{
-				"test-cert": {"field1", "field2"},
-			},
-		}
+		certificatesToRequest := transport.NewRequestedCertificateSet(testCertifier).
+			AddType(testCertificateType, "field1", "field2")
 
-		onCertificatesReceived := func(senderPublicKey string, certs *[]wallet.VerifiableCertificate, req *http.Request, res http.ResponseWriter, next func()) {
+		onCertificatesReceived := func(senderPublicKey string, certs *[]certificates.VerifiableCertificate, req *http.Request, res http.ResponseWriter, next func()) {
 		}
 
 		// when
@@ -220,8 +247,8 @@ func TestNew_ValidCertificateConfig(t *testing.T) {
 		// given
 		key, err := ec.NewPrivateKey()
 		require.NoError(t, err)
-		mockWallet := wallet.NewMockWallet(key)
-		mockSessionManager := sessionmanager.NewSessionManager()
+		mockWallet := wallettest.NewMockWallet(key)
+		mockSessionManager := session.NewMemoryManager()
 
 		// when
 		middleware, err := auth.New(auth.Config{
@@ -236,3 +263,348 @@ func TestNew_ValidCertificateConfig(t *testing.T) {
 		assert.NotNil(t, middleware)
 	})
 }
+
+func TestNew_InvalidCertificatesToRequest(t *testing.T) {
+	// given
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	// when
+	middleware, err := auth.New(auth.Config{
+		Wallet:                 wallettest.NewMockWallet(key),
+		CertificatesToRequest:  transport.NewRequestedCertificateSet(testCertifier).AddType("age-verification", "age"),
+		OnCertificatesReceived: func(string, *[]certificates.VerifiableCertificate, *http.Request, http.ResponseWriter, func()) {},
+	})
+
+	// then
+	require.Nil(t, middleware)
+	require.ErrorIs(t, err, transport.ErrInvalidRequestedCertificates)
+}
+
+func TestNew_InvalidRoutePolicyPattern(t *testing.T) {
+	// given
+	sPrivKey, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	// when
+	middleware, err := auth.New(auth.Config{
+		Wallet: wallettest.NewMockWallet(sPrivKey, wallettest.DefaultNonces...),
+		RoutePolicies: map[string]auth.RoutePolicy{
+			"GET /items/{id": {Exempt: true},
+		},
+	})
+
+	// then
+	require.Nil(t, middleware)
+	require.ErrorIs(t, err, auth.ErrInvalidRoutePolicy)
+}
+
+func TestNew_InvalidForwardAuthAttribute(t *testing.T) {
+	// given
+	sPrivKey, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	// when
+	middleware, err := auth.New(auth.Config{
+		Wallet: wallettest.NewMockWallet(sPrivKey, wallettest.DefaultNonces...),
+		ForwardAuth: auth.ForwardAuthConfig{
+			Headers: []auth.UpstreamHeader{{Name: "X-User-Country", Attribute: "certificate.country"}},
+		},
+	})
+
+	// then
+	require.Nil(t, middleware)
+	require.ErrorIs(t, err, auth.ErrInvalidUpstreamHeader)
+	require.ErrorContains(t, err, "unsupported attribute")
+}
+
+func TestNew_InvalidLogConfig(t *testing.T) {
+	// given
+	sPrivKey, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	// when
+	middleware, err := auth.New(auth.Config{
+		Wallet: wallettest.NewMockWallet(sPrivKey, wallettest.DefaultNonces...),
+		Logging: defs.LogConfig{
+			defs.LogSubsystemCertificates: {Level: "verbose"},
+		},
+	})
+
+	// then
+	require.Nil(t, middleware)
+	require.ErrorIs(t, err, auth.ErrInvalidLogConfig)
+	require.ErrorContains(t, err, "certificates")
+}
+
+// selfTestWallet breaks a single operation of the wallet used by the self-test
+type selfTestWallet struct {
+	wallet.WalletInterface
+	brokenIdentityKey bool
+	brokenNonce       bool
+	brokenSignature   bool
+}
+
+func (w *selfTestWallet) GetPublicKey(args *wallet.GetPublicKeyArgs, originator string) (*wallet.GetPublicKeyResult, error) {
+	if w.brokenIdentityKey {
+		return nil, errors.New("key store unavailable")
+	}
+	return w.WalletInterface.GetPublicKey(args, originator)
+}
+
+func (w *selfTestWallet) VerifyNonce(ctx context.Context, nonce string) (bool, error) {
+	if w.brokenNonce {
+		return false, nil
+	}
+	return w.WalletInterface.VerifyNonce(ctx, nonce)
+}
+
+func (w *selfTestWallet) VerifySignature(args *wallet.VerifySignatureArgs) (*wallet.VerifySignatureResult, error) {
+	if w.brokenSignature {
+		return &wallet.VerifySignatureResult{Valid: false}, nil
+	}
+	return w.WalletInterface.VerifySignature(args)
+}
+
+func TestNew_SelfTest(t *testing.T) {
+	sPrivKey, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		wallet *selfTestWallet
+		err    string
+	}{
+		"working wallet":      {wallet: &selfTestWallet{}},
+		"broken identity key": {wallet: &selfTestWallet{brokenIdentityKey: true}, err: "failed to derive identity key"},
+		"broken nonce":        {wallet: &selfTestWallet{brokenNonce: true}, err: "wallet rejected its own nonce"},
+		"broken signature":    {wallet: &selfTestWallet{brokenSignature: true}, err: "wallet rejected its own signature"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			test.wallet.WalletInterface = wallettest.NewMockWallet(sPrivKey, wallettest.DefaultNonces...)
+
+			// when
+			middleware, err := auth.New(auth.Config{Wallet: test.wallet, SessionManager: session.NewMemoryManager(), SelfTest: true})
+
+			// then
+			if test.err == "" {
+				require.NoError(t, err)
+				require.NotNil(t, middleware)
+				return
+			}
+			require.Nil(t, middleware)
+			require.ErrorIs(t, err, auth.ErrSelfTestFailed)
+			require.ErrorContains(t, err, test.err)
+		})
+	}
+
+	t.Run("broken wallet is not detected without the self-test", func(t *testing.T) {
+		// given
+		brokenWallet := &selfTestWallet{
+			WalletInterface: wallettest.NewMockWallet(sPrivKey, wallettest.DefaultNonces...),
+			brokenSignature: true,
+		}
+
+		// when
+		middleware, err := auth.New(auth.Config{Wallet: brokenWallet, SessionManager: session.NewMemoryManager()})
+
+		// then
+		require.NoError(t, err)
+		require.NotNil(t, middleware)
+	})
+
+	t.Run("privileged keys of a wallet with a privileged keyring", func(t *testing.T) {
+		// given
+		privilegedKey, err := ec.NewPrivateKey()
+		require.NoError(t, err)
+		privilegedWallet := wallettest.NewPrivilegedMockWallet(sPrivKey, privilegedKey, wallettest.DefaultNonces...)
+
+		// when
+		middleware, err := auth.New(auth.Config{
+			Wallet:         privilegedWallet,
+			SessionManager: session.NewMemoryManager(),
+			SelfTest:       true,
+			PrivilegedKeys: transport.PrivilegedKeys{Enabled: true},
+		})
+
+		// then
+		require.NoError(t, err)
+		require.NotNil(t, middleware)
+	})
+
+	t.Run("privileged keys of a wallet without a privileged keyring", func(t *testing.T) {
+		// when
+		middleware, err := auth.New(auth.Config{
+			Wallet:         wallettest.NewMockWallet(sPrivKey, wallettest.DefaultNonces...),
+			SelfTest:       true,
+			PrivilegedKeys: transport.PrivilegedKeys{Enabled: true},
+		})
+
+		// then
+		require.Nil(t, middleware)
+		require.ErrorIs(t, err, auth.ErrSelfTestFailed)
+		require.ErrorIs(t, err, wallet.ErrPrivilegedKeyUnavailable)
+	})
+}
+
+func TestServerInfo(t *testing.T) {
+	// when
+	serverInfo := auth.ServerInfo()
+
+	// then
+	require.Equal(t, auth.ImplementationName+"/"+auth.Version(), serverInfo)
+	require.NotEmpty(t, auth.Version())
+}
+
+func TestNew_InvalidRollout(t *testing.T) {
+	sPrivKey, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	tests := map[string]auth.EnforcementRollout{
+		"negative percent":          {Percent: -1},
+		"percent above 100":         {Percent: 101},
+		"cohort with invalid key":   {Percent: 10, Cohort: []string{"not a key"}},
+		"cohort with truncated key": {Cohort: []string{transport.AnyoneIdentityKey[:10]}},
+	}
+	for name, rollout := range tests {
+		t.Run(name, func(t *testing.T) {
+			// when
+			middleware, err := auth.New(auth.Config{
+				Wallet:     wallettest.NewMockWallet(sPrivKey, wallettest.DefaultNonces...),
+				ReportOnly: true,
+				Rollout:    &rollout,
+			})
+
+			// then
+			require.Nil(t, middleware)
+			require.ErrorIs(t, err, auth.ErrInvalidRollout)
+		})
+	}
+}
+
+func TestEnforcementRollout_Enforces(t *testing.T) {
+	identityKeys := make([]string, 0, 1000)
+	for i := range 1000 {
+		key, err := ec.NewPrivateKey()
+		require.NoError(t, err, i)
+		identityKeys = append(identityKeys, key.PubKey().ToDERHex())
+	}
+
+	t.Run("share of enforced identities follows the percent", func(t *testing.T) {
+		// given
+		rollout := &auth.EnforcementRollout{Percent: 20}
+
+		// when
+		enforced := 0
+		for _, identityKey := range identityKeys {
+			if rollout.Enforces(identityKey) {
+				enforced++
+			}
+		}
+
+		// then
+		require.InDelta(t, 200, enforced, 60)
+	})
+
+	t.Run("identities stay enforced when the percent is raised", func(t *testing.T) {
+		// given
+		lower := &auth.EnforcementRollout{Percent: 10}
+		higher := &auth.EnforcementRollout{Percent: 50}
+
+		// then
+		for _, identityKey := range identityKeys {
+			if lower.Enforces(identityKey) {
+				require.True(t, higher.Enforces(identityKey))
+				require.True(t, lower.Enforces(strings.ToUpper(identityKey)))
+			}
+		}
+	})
+
+	t.Run("cohort is always enforced", func(t *testing.T) {
+		// given
+		rollout := &auth.EnforcementRollout{Cohort: []string{strings.ToUpper(identityKeys[0])}}
+
+		// then
+		require.True(t, rollout.Enforces(identityKeys[0]))
+		require.False(t, rollout.Enforces(identityKeys[1]))
+	})
+
+	t.Run("requests without identity are only enforced at 100 percent", func(t *testing.T) {
+		require.False(t, (&auth.EnforcementRollout{Percent: 99}).Enforces(""))
+		require.True(t, (&auth.EnforcementRollout{Percent: 100}).Enforces(""))
+		require.False(t, (*auth.EnforcementRollout)(nil).Enforces(identityKeys[0]))
+	})
+}
+
+// vendorWallet reports the version of a wallet which is not a mock wallet
+type vendorWallet struct {
+	wallet.WalletInterface
+}
+
+func (w vendorWallet) GetVersion(context.Context, string) (*wallet.GetVersionResult, error) {
+	return &wallet.GetVersionResult{Version: "vendor-1.0.0"}, nil
+}
+
+func TestNew_Profile(t *testing.T) {
+	sPrivKey, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	mockWallet := wallettest.NewMockWallet(sPrivKey, wallettest.DefaultNonces...)
+	strict, relaxed := true, false
+
+	tests := map[string]struct {
+		config      auth.Config
+		expectedErr error
+	}{
+		"development profile creates a wallet outside strict production mode": {
+			config: auth.Config{Profile: auth.ProfileDevelopment, StrictProduction: &relaxed, Logger: slog.New(slog.DiscardHandler)},
+		},
+		"development profile does not create a wallet in strict production mode": {
+			config:      auth.Config{Profile: auth.ProfileDevelopment, StrictProduction: &strict, Logger: slog.New(slog.DiscardHandler)},
+			expectedErr: auth.ErrWalletRequired,
+		},
+		"staging profile rejects mock wallet": {
+			config:      auth.Config{Profile: auth.ProfileStaging, Wallet: mockWallet},
+			expectedErr: auth.ErrProfileViolation,
+		},
+		"production profile rejects mock wallet": {
+			config:      auth.Config{Profile: auth.ProfileProduction, Wallet: mockWallet},
+			expectedErr: auth.ErrProfileViolation,
+		},
+		"production profile rejects unauthenticated requests": {
+			config:      auth.Config{Profile: auth.ProfileProduction, Wallet: vendorWallet{mockWallet}, AllowUnauthenticated: true},
+			expectedErr: auth.ErrProfileViolation,
+		},
+		"production profile rejects report-only mode": {
+			config:      auth.Config{Profile: auth.ProfileProduction, Wallet: vendorWallet{mockWallet}, ReportOnly: true},
+			expectedErr: auth.ErrProfileViolation,
+		},
+		"production profile accepts vendor wallet": {
+			config: auth.Config{Profile: auth.ProfileProduction, Wallet: vendorWallet{mockWallet}, SessionManager: session.NewMemoryManager()},
+		},
+		"production profile still requires a wallet": {
+			config:      auth.Config{Profile: auth.ProfileProduction},
+			expectedErr: auth.ErrWalletRequired,
+		},
+		"unknown profile": {
+			config:      auth.Config{Profile: "qa", Wallet: mockWallet},
+			expectedErr: auth.ErrUnknownProfile,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// when
+			middleware, err := auth.New(test.config)
+
+			// then
+			if test.expectedErr != nil {
+				require.Nil(t, middleware)
+				require.ErrorIs(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, middleware)
+		})
+	}
+}
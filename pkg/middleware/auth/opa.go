@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/httpclient"
+)
+
+// maxOPAResponseSize limits the size of decision documents read from the policy engine
+const maxOPAResponseSize = 1 << 20
+
+// OPAAuthorizer consults the data API of an Open Policy Agent server, e.g. http://localhost:8181/v1/data/httpapi/authz.
+// The AuthorizationInput is posted as the input document, the result may be a boolean or an object with
+// the allow and reason fields of Decision. An undefined result denies the request.
+type OPAAuthorizer struct {
+	// URL is the URL of the data API document of the policy
+	URL string
+	// Client sends the queries, defaults to the shared retrying client of httpclient.Default
+	Client *http.Client
+}
+
+// Authorize implements Authorizer
+func (a *OPAAuthorizer) Authorize(ctx context.Context, input AuthorizationInput) (Decision, error) {
+	body, err := json.Marshal(map[string]any{"input": input})
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to encode policy input, %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL, bytes.NewReader(body))
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to create policy query, %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := a.Client
+	if client == nil {
+		client = httpclient.Default()
+	}
+
+	response, err := client.Do(req)
+	if err != nil {
+		return Decision{}, fmt.Errorf("failed to query policy, %w", err)
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode != http.StatusOK {
+		return Decision{}, fmt.Errorf("policy query failed with status %d", response.StatusCode)
+	}
+
+	var result struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(io.LimitReader(response.Body, maxOPAResponseSize)).Decode(&result); err != nil {
+		return Decision{}, fmt.Errorf("failed to decode policy result, %w", err)
+	}
+
+	if len(result.Result) == 0 {
+		return Decision{}, nil
+	}
+
+	var allow bool
+	if err := json.Unmarshal(result.Result, &allow); err == nil {
+		return Decision{Allow: allow}, nil
+	}
+
+	var decision Decision
+	if err := json.Unmarshal(result.Result, &decision); err != nil {
+		return Decision{}, fmt.Errorf("unexpected policy result, %w", err)
+	}
+	return decision, nil
+}
@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+const (
+	// OpenAPIPath is the path of the OpenAPI document with the auth requirements of the declared routes
+	OpenAPIPath = "/.well-known/bsv-auth-openapi.json"
+	// OpenAPIExtension is the OpenAPI extension carrying the auth requirements of the document and its operations
+	OpenAPIExtension = "x-bsv-auth"
+)
+
+// Authentication modes of routes
+const (
+	// AuthenticationRequired routes reject requests without auth headers
+	AuthenticationRequired = "required"
+	// AuthenticationOptional routes pass requests without auth headers, requests with auth headers are verified
+	AuthenticationOptional = "optional"
+	// AuthenticationNone routes are exempt from authentication and their responses are not signed
+	AuthenticationNone = "none"
+)
+
+// RouteRequirements declares what an endpoint demands from its callers
+type RouteRequirements struct {
+	// Authentication is AuthenticationRequired, AuthenticationOptional or AuthenticationNone, defaults to AuthenticationRequired
+	Authentication string `json:"authentication"`
+	// Anonymous declares whether anonymous sessions are accepted
+	Anonymous bool `json:"anonymous"`
+	// Certificates are the certificates the endpoint demands, defaults to the certificates requested by the server
+	Certificates *transport.RequestedCertificateSet `json:"certificates,omitempty"`
+	// Payment declares the payment demanded for requests of the endpoint, nil for free endpoints
+	Payment *PaymentRequirement `json:"payment,omitempty"`
+}
+
+// PaymentRequirement declares the payment demanded for requests of an endpoint
+type PaymentRequirement struct {
+	// Price is the price in satoshis, the price of requests with a dynamic price is announced in the 402 response
+	Price int `json:"price,omitempty"`
+	// Dynamic declares a price which depends on the request
+	Dynamic bool `json:"dynamic,omitempty"`
+}
+
+// RouteRegistry collects the auth requirements declared by routes, to publish them in an OpenAPI document.
+// Routes are net/http ServeMux patterns with a method, e.g. "GET /items/{id}".
+type RouteRegistry struct {
+	info   OpenAPIInfo
+	mu     sync.RWMutex
+	routes map[string]declaredRoute
+}
+
+type declaredRoute struct {
+	method       string
+	path         string
+	requirements RouteRequirements
+}
+
+// NewRouteRegistry creates an empty route registry, the info is published in the OpenAPI document
+func NewRouteRegistry(info OpenAPIInfo) *RouteRegistry {
+	return &RouteRegistry{info: info, routes: make(map[string]declaredRoute)}
+}
+
+// Declare records the requirements of the route, declaring a route again replaces its requirements
+func (r *RouteRegistry) Declare(pattern string, requirements RouteRequirements) error {
+	method, path, ok := strings.Cut(pattern, " ")
+	path = strings.TrimSpace(path)
+	if !ok || method == "" || !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("%w: pattern %q has to be a method and a path", ErrInvalidRouteDeclaration, pattern)
+	}
+
+	switch requirements.Authentication {
+	case "":
+		requirements.Authentication = AuthenticationRequired
+	case AuthenticationRequired, AuthenticationOptional, AuthenticationNone:
+	default:
+		return fmt.Errorf("%w: unknown authentication %q of %s", ErrInvalidRouteDeclaration, requirements.Authentication, pattern)
+	}
+
+	if requirements.Certificates != nil {
+		if err := requirements.Certificates.Validate(); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidRouteDeclaration, err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.routes[strings.ToUpper(method)+" "+path] = declaredRoute{method: strings.ToLower(method), path: openAPIPath(path), requirements: requirements}
+
+	return nil
+}
+
+// Handle registers the handler on the mux and declares the requirements of its route
+func (r *RouteRegistry) Handle(mux *http.ServeMux, pattern string, handler http.Handler, requirements RouteRequirements) error {
+	if err := r.Declare(pattern, requirements); err != nil {
+		return err
+	}
+	mux.Handle(pattern, handler)
+	return nil
+}
+
+// wildcardPattern matches the remaining ("{name...}") and end ("{$}") wildcards of ServeMux patterns
+var wildcardPattern = regexp.MustCompile(`\{(\w*)(\.\.\.|\$)\}`)
+
+// openAPIPath converts the path of a ServeMux pattern to an OpenAPI path template
+func openAPIPath(path string) string {
+	return wildcardPattern.ReplaceAllStringFunc(path, func(wildcard string) string {
+		if wildcard == "{$}" {
+			return ""
+		}
+		return strings.Replace(wildcard, "...", "", 1)
+	})
+}
+
+// OpenAPIInfo is the info object of the OpenAPI document
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// OpenAPIDocument is an OpenAPI 3.1 document listing the declared routes, the auth requirements are carried
+// by the x-bsv-auth extension of the document (the handshake) and of every operation (the route requirements)
+type OpenAPIDocument struct {
+	OpenAPI   string                                 `json:"openapi"`
+	Info      OpenAPIInfo                            `json:"info"`
+	Paths     map[string]map[string]OpenAPIOperation `json:"paths"`
+	Extension OpenAPIAuthExtension                   `json:"x-bsv-auth"`
+}
+
+// OpenAPIOperation is an operation of the OpenAPI document
+type OpenAPIOperation struct {
+	Responses map[string]OpenAPIResponse `json:"responses"`
+	Extension RouteRequirements          `json:"x-bsv-auth"`
+}
+
+// OpenAPIResponse is a response of an OpenAPI operation
+type OpenAPIResponse struct {
+	Description string `json:"description"`
+}
+
+// OpenAPIAuthExtension describes how peers authenticate to the server
+type OpenAPIAuthExtension struct {
+	IdentityKey       string   `json:"identityKey,omitempty"`
+	AuthVersions      []string `json:"authVersions"`
+	HandshakePath     string   `json:"handshakePath"`
+	DiscoveryPath     string   `json:"discoveryPath"`
+	PayloadEncryption bool     `json:"payloadEncryption"`
+}
+
+// OpenAPI returns the OpenAPI document of the declared routes, routes without certificates declare the given defaults
+func (r *RouteRegistry) OpenAPI(extension OpenAPIAuthExtension, defaultCertificates *transport.RequestedCertificateSet) OpenAPIDocument {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	paths := make(map[string]map[string]OpenAPIOperation)
+	for _, route := range r.routes {
+		requirements := route.requirements
+		if requirements.Certificates == nil && requirements.Authentication != AuthenticationNone {
+			requirements.Certificates = defaultCertificates
+		}
+
+		if paths[route.path] == nil {
+			paths[route.path] = make(map[string]OpenAPIOperation)
+		}
+		paths[route.path][route.method] = OpenAPIOperation{
+			Responses: openAPIResponses(requirements),
+			Extension: requirements,
+		}
+	}
+
+	return OpenAPIDocument{
+		OpenAPI:   "3.1.0",
+		Info:      r.info,
+		Paths:     paths,
+		Extension: extension,
+	}
+}
+
+// openAPIResponses lists the responses of the middlewares an operation with the requirements may receive
+func openAPIResponses(requirements RouteRequirements) map[string]OpenAPIResponse {
+	responses := map[string]OpenAPIResponse{"default": {Description: "Response of the endpoint"}}
+	if requirements.Authentication != AuthenticationNone {
+		responses["401"] = OpenAPIResponse{Description: "Authentication failed or certificates are required"}
+	}
+	if requirements.Payment != nil {
+		responses["402"] = OpenAPIResponse{Description: "Payment required"}
+	}
+	return responses
+}
+
+func (m *Middleware) serveOpenAPIDocument(w http.ResponseWriter) {
+	identity, err := m.wallet.GetPublicKey(m.privilegedKeys.IdentityKeyArgs(), "")
+	if err != nil {
+		m.logger.Error("Failed to get identity key for OpenAPI document", slog.String("error", err.Error()))
+		http.Error(w, fmt.Sprintf("failed to get identity key, %s", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	document := m.routes.OpenAPI(OpenAPIAuthExtension{
+		IdentityKey:       identity.PublicKey.ToDERHex(),
+		AuthVersions:      []string{transport.AuthVersion},
+		HandshakePath:     HandshakePath,
+		DiscoveryPath:     DiscoveryPath,
+		PayloadEncryption: m.encryptPayloads,
+	}, m.certificatesToRequest.Load())
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(document); err != nil {
+		m.logger.Error("Failed to write OpenAPI document", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+)
+
+// Profile selects the defaults and guards of the middleware for a deployment environment (see Config.Profile)
+type Profile string
+
+const (
+	// ProfileDevelopment creates a mock wallet with a random key when no wallet is configured, unless in strict
+	// production mode (see Config.StrictProduction), and logs at debug level to stderr when no logger is configured
+	ProfileDevelopment Profile = "dev"
+	// ProfileStaging runs the wallet self-test and rejects mock wallets
+	ProfileStaging Profile = "staging"
+	// ProfileProduction runs the wallet self-test, requires certificates to disclose exactly the requested fields
+	// and rejects mock wallets, unauthenticated requests and report-only mode
+	ProfileProduction Profile = "prod"
+)
+
+// mockWalletVendor is the vendor of the versions reported by the mock wallets
+const mockWalletVendor = "mock-"
+
+// applyProfile sets the defaults of the profile and rejects configs which violate it
+func applyProfile(opts *Config) error {
+	switch opts.Profile {
+	case "":
+		return nil
+	case ProfileDevelopment:
+		if opts.Logger == nil {
+			opts.Logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+		}
+		if opts.Wallet == nil && opts.strictProduction() {
+			return fmt.Errorf("%w, the mock wallet of the %s profile is not used in strict production mode", ErrWalletRequired, opts.Profile)
+		}
+		if opts.Wallet == nil {
+			key, err := wallettest.NewRandomPrivateKey(opts.Random)
+			if err != nil {
+				return fmt.Errorf("failed to create key of the development wallet, %w", err)
+			}
+			opts.Wallet = wallettest.NewRandomMockWallet(key, opts.Random)
+			opts.Logger.Warn("No wallet configured, using a mock wallet with a random key", slog.String("profile", string(opts.Profile)))
+		}
+		return nil
+	case ProfileStaging:
+		opts.SelfTest = true
+		return rejectMockWallet(opts)
+	case ProfileProduction:
+		opts.SelfTest = true
+		opts.StrictDisclosure = true
+		if opts.AllowUnauthenticated {
+			return fmt.Errorf("%w %s, unauthenticated requests are allowed", ErrProfileViolation, opts.Profile)
+		}
+		if opts.ReportOnly {
+			return fmt.Errorf("%w %s, verification is not enforced in report-only mode", ErrProfileViolation, opts.Profile)
+		}
+		return rejectMockWallet(opts)
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownProfile, opts.Profile)
+	}
+}
+
+// strictProduction reports whether defaulting to mock components is an error, see Config.StrictProduction
+func (c *Config) strictProduction() bool {
+	if c.StrictProduction == nil {
+		return StrictProductionDefault
+	}
+	return *c.StrictProduction
+}
+
+// rejectMockWallet rejects the mock wallets of the wallet package, recognized by the version they report
+func rejectMockWallet(opts *Config) error {
+	if opts.Wallet == nil {
+		return nil
+	}
+
+	version, err := opts.Wallet.GetVersion(context.Background(), "")
+	if err != nil {
+		return fmt.Errorf("%w %s, failed to get wallet version, %w", ErrProfileViolation, opts.Profile, err)
+	}
+	if strings.HasPrefix(version.Version, mockWalletVendor) {
+		return fmt.Errorf("%w %s, mock wallet %s is not allowed", ErrProfileViolation, opts.Profile, version.Version)
+	}
+	return nil
+}
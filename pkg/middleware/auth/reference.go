@@ -0,0 +1,340 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// Reference is the auth contract of the deployment generated from its effective configuration:
+// how peers authenticate, the requirements and prices of the routes and the rate limits applied to peers
+type Reference struct {
+	GeneratedAt          time.Time              `json:"generatedAt"`
+	IdentityKey          string                 `json:"identityKey"`
+	AuthVersions         []string               `json:"authVersions"`
+	HandshakePath        string                 `json:"handshakePath"`
+	DiscoveryPath        string                 `json:"discoveryPath"`
+	AllowUnauthenticated bool                   `json:"allowUnauthenticated"`
+	PayloadEncryption    bool                   `json:"payloadEncryption"`
+	PayloadPadding       bool                   `json:"payloadPadding"`
+	IdempotencyKeys      bool                   `json:"idempotencyKeys"`
+	Capabilities         []transport.Capability `json:"capabilities"`
+	// RequestedCertificates are the certificates requested from every peer in the handshake
+	RequestedCertificates *transport.RequestedCertificateSet `json:"requestedCertificates,omitempty"`
+	Payment               *PaymentHints                      `json:"payment,omitempty"`
+	// Routes are the routes declared in the RouteRegistry, sorted by path and method
+	Routes []ReferenceRoute `json:"routes"`
+	// RoutePolicies are the route policies overriding the authentication, sorted by pattern
+	RoutePolicies []ReferenceRoutePolicy `json:"routePolicies"`
+	// AnonymousRateLimit is the rate limit shared by all anonymous peers, nil when unlimited
+	AnonymousRateLimit *ReferenceRateLimit `json:"anonymousRateLimit,omitempty"`
+	// Tiers are the tiers of the TierPolicy, sorted by name
+	Tiers       []ReferenceTier `json:"tiers"`
+	DefaultTier string          `json:"defaultTier,omitempty"`
+}
+
+// ReferenceRoute is a declared route with its effective requirements,
+// routes without certificates of their own list the certificates requested by the server
+type ReferenceRoute struct {
+	Method       string            `json:"method"`
+	Path         string            `json:"path"`
+	Requirements RouteRequirements `json:"requirements"`
+}
+
+// ReferenceRoutePolicy is a route policy and the pattern it applies to
+type ReferenceRoutePolicy struct {
+	Pattern              string `json:"pattern"`
+	Exempt               bool   `json:"exempt"`
+	AllowUnauthenticated bool   `json:"allowUnauthenticated"`
+	DenyAnonymous        bool   `json:"denyAnonymous"`
+}
+
+// ReferenceRateLimit is a number of requests per window
+type ReferenceRateLimit struct {
+	Requests      int `json:"requests"`
+	WindowSeconds int `json:"windowSeconds"`
+}
+
+// ReferenceTier is a tier with its rate limit and free requests, nil when unlimited or disabled
+type ReferenceTier struct {
+	Name         string              `json:"name"`
+	RateLimit    *ReferenceRateLimit `json:"rateLimit,omitempty"`
+	FreeRequests *ReferenceRateLimit `json:"freeRequests,omitempty"`
+}
+
+// Reference generates the reference of the current configuration, it follows changes made at runtime,
+// e.g. the requested certificates and routes declared after the middleware was created
+func (m *Middleware) Reference() (Reference, error) {
+	identity, err := m.wallet.GetPublicKey(m.privilegedKeys.IdentityKeyArgs(), "")
+	if err != nil {
+		return Reference{}, fmt.Errorf("failed to get identity key, %w", err)
+	}
+
+	certificates := m.certificatesToRequest.Load()
+	reference := Reference{
+		GeneratedAt:           time.Now().UTC(),
+		IdentityKey:           identity.PublicKey.ToDERHex(),
+		AuthVersions:          []string{transport.AuthVersion},
+		HandshakePath:         HandshakePath,
+		DiscoveryPath:         DiscoveryPath,
+		AllowUnauthenticated:  m.allowUnauthenticated,
+		PayloadEncryption:     m.encryptPayloads,
+		PayloadPadding:        m.padPayloads,
+		IdempotencyKeys:       m.idempotency != nil,
+		Capabilities:          m.transport.Capabilities(),
+		RequestedCertificates: certificates,
+		Payment:               m.paymentHints,
+		Routes:                m.routes.reference(certificates),
+		RoutePolicies:         m.routePolicies.reference(),
+		AnonymousRateLimit:    newReferenceRateLimit(m.anonymousLimiter.limitAndWindow()),
+		Tiers:                 m.tiers.reference(),
+	}
+	if m.tiers != nil {
+		reference.DefaultTier = m.tiers.policy.DefaultTier
+	}
+
+	return reference, nil
+}
+
+// reference lists the declared routes with their effective requirements, see RouteRegistry.OpenAPI
+func (r *RouteRegistry) reference(defaultCertificates *transport.RequestedCertificateSet) []ReferenceRoute {
+	routes := []ReferenceRoute{}
+	if r == nil {
+		return routes
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, route := range r.routes {
+		requirements := route.requirements
+		if requirements.Certificates == nil && requirements.Authentication != AuthenticationNone {
+			requirements.Certificates = defaultCertificates
+		}
+		routes = append(routes, ReferenceRoute{Method: strings.ToUpper(route.method), Path: route.path, Requirements: requirements})
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+func (r *routePolicies) reference() []ReferenceRoutePolicy {
+	policies := []ReferenceRoutePolicy{}
+	if r == nil {
+		return policies
+	}
+
+	for pattern, policy := range r.policies {
+		policies = append(policies, ReferenceRoutePolicy{
+			Pattern:              pattern,
+			Exempt:               policy.Exempt,
+			AllowUnauthenticated: policy.AllowUnauthenticated,
+			DenyAnonymous:        policy.DenyAnonymous,
+		})
+	}
+
+	sort.Slice(policies, func(i, j int) bool { return policies[i].Pattern < policies[j].Pattern })
+	return policies
+}
+
+func (t *tiers) reference() []ReferenceTier {
+	tiers := []ReferenceTier{}
+	if t == nil {
+		return tiers
+	}
+
+	for _, tier := range t.byName {
+		reference := ReferenceTier{Name: tier.Name, RateLimit: newReferenceRateLimit(tier.RateLimit, tier.RateLimitWindow)}
+		if tier.FreeRequests > 0 {
+			reference.FreeRequests = newReferenceRateLimit(tier.FreeRequests, tier.FreeRequestsWindow)
+		}
+		tiers = append(tiers, reference)
+	}
+
+	sort.Slice(tiers, func(i, j int) bool { return tiers[i].Name < tiers[j].Name })
+	return tiers
+}
+
+// limitAndWindow returns the limit and the window of the limiter, zero for a nil limiter
+func (l *windowLimiter) limitAndWindow() (int, time.Duration) {
+	if l == nil {
+		return 0, 0
+	}
+	return l.limit, l.window
+}
+
+// newReferenceRateLimit returns nil for unlimited requests
+func newReferenceRateLimit(requests int, window time.Duration) *ReferenceRateLimit {
+	if requests <= 0 {
+		return nil
+	}
+	return &ReferenceRateLimit{Requests: requests, WindowSeconds: int(window / time.Second)}
+}
+
+// ReferenceHandler serves the reference of the current configuration, see Reference: as JSON by default,
+// as an HTML page for browsers (Accept: text/html) or with the format=html query parameter.
+// The handler does not authenticate its callers, it has to be served on an internal listener
+// or behind the access control of the operator.
+func (m *Middleware) ReferenceHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		reference, err := m.Reference()
+		if err != nil {
+			m.logger.Error("Failed to generate reference", slog.String("error", err.Error()))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Add("Vary", "Accept")
+		if !wantsHTML(req) {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(reference); err != nil {
+				m.logger.Error("Failed to write reference", slog.String("error", err.Error()))
+			}
+			return
+		}
+
+		// the page is rendered before it is written, so a failing template responds with an error instead of a partial page
+		var page bytes.Buffer
+		if err := referenceTemplate.Execute(&page, reference); err != nil {
+			m.logger.Error("Failed to render reference", slog.String("error", err.Error()))
+			http.Error(w, "failed to render reference", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if _, err := page.WriteTo(w); err != nil {
+			m.logger.Error("Failed to write reference", slog.String("error", err.Error()))
+		}
+	})
+}
+
+// wantsHTML reports whether the request asks for the HTML page rather than the JSON document
+func wantsHTML(req *http.Request) bool {
+	switch req.URL.Query().Get("format") {
+	case "html":
+		return true
+	case "json":
+		return false
+	}
+
+	for _, accepted := range strings.Split(req.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err == nil && mediaType == "text/html" {
+			return true
+		}
+	}
+	return false
+}
+
+var referenceTemplate = template.Must(template.New("reference").Funcs(template.FuncMap{
+	"yesno": func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	},
+	"join": func(values any) string {
+		switch values := values.(type) {
+		case []string:
+			return strings.Join(values, ", ")
+		case []transport.Capability:
+			names := make([]string, len(values))
+			for i, capability := range values {
+				names[i] = string(capability)
+			}
+			return strings.Join(names, ", ")
+		}
+		return fmt.Sprint(values)
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>BSV auth reference</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; vertical-align: top; }
+code { word-break: break-all; }
+</style>
+</head>
+<body>
+<h1>BSV auth reference</h1>
+<p>Generated from the configuration of the deployment at {{.GeneratedAt.Format "2006-01-02 15:04:05 MST"}}.
+The <a href="{{.DiscoveryPath}}">discovery document</a> describes the same contract for clients.</p>
+
+<h2>Authentication</h2>
+<table>
+<tr><th>Identity key</th><td><code>{{.IdentityKey}}</code></td></tr>
+<tr><th>Auth versions</th><td>{{join .AuthVersions}}</td></tr>
+<tr><th>Handshake</th><td><code>POST {{.HandshakePath}}</code></td></tr>
+<tr><th>Unauthenticated requests</th><td>{{if .AllowUnauthenticated}}allowed{{else}}rejected{{end}}</td></tr>
+<tr><th>Payload encryption</th><td>{{yesno .PayloadEncryption}}</td></tr>
+<tr><th>Payload padding</th><td>{{yesno .PayloadPadding}}</td></tr>
+<tr><th>Idempotency keys</th><td>{{yesno .IdempotencyKeys}}</td></tr>
+<tr><th>Capabilities</th><td>{{join .Capabilities}}</td></tr>
+</table>
+
+<h2>Requested certificates</h2>
+{{template "certificates" .RequestedCertificates}}
+
+<h2>Routes</h2>
+{{if .Routes}}<table>
+<tr><th>Method</th><th>Path</th><th>Authentication</th><th>Anonymous</th><th>Certificates</th><th>Price</th></tr>
+{{range .Routes}}<tr>
+<td>{{.Method}}</td><td><code>{{.Path}}</code></td><td>{{.Requirements.Authentication}}</td><td>{{yesno .Requirements.Anonymous}}</td>
+<td>{{template "certificates" .Requirements.Certificates}}</td>
+<td>{{with .Requirements.Payment}}{{if .Dynamic}}dynamic{{else}}{{.Price}} satoshis{{end}}{{else}}free{{end}}</td>
+</tr>
+{{end}}</table>{{else}}<p>No routes are declared.</p>{{end}}
+
+<h2>Route policies</h2>
+{{if .RoutePolicies}}<table>
+<tr><th>Pattern</th><th>Exempt</th><th>Unauthenticated requests</th><th>Anonymous sessions</th></tr>
+{{range .RoutePolicies}}<tr>
+<td><code>{{.Pattern}}</code></td><td>{{yesno .Exempt}}</td><td>{{if .AllowUnauthenticated}}allowed{{else}}default{{end}}</td>
+<td>{{if .DenyAnonymous}}denied{{else}}default{{end}}</td>
+</tr>
+{{end}}</table>{{else}}<p>No route policies are configured.</p>{{end}}
+
+<h2>Payment</h2>
+{{with .Payment}}<table>
+<tr><th>Version</th><td>{{.Version}}</td></tr>
+<tr><th>Network</th><td>{{.Network}}</td></tr>
+<tr><th>Default price</th><td>{{.DefaultPrice}} satoshis</td></tr>
+</table>{{else}}<p>No payment is advertised.</p>{{end}}
+
+<h2>Rate limits</h2>
+<table>
+<tr><th>Peers</th><th>Rate limit</th><th>Free requests</th></tr>
+<tr><td>anonymous</td><td>{{template "rateLimit" .AnonymousRateLimit}}</td><td>-</td></tr>
+{{range .Tiers}}<tr>
+<td>tier {{.Name}}{{if eq .Name $.DefaultTier}} (default){{end}}</td>
+<td>{{template "rateLimit" .RateLimit}}</td><td>{{with .FreeRequests}}{{template "rateLimit" .}}{{else}}none{{end}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+{{define "certificates"}}{{if .}}<p>Certifiers: {{range $i, $certifier := .Certifiers}}{{if $i}}, {{end}}<code>{{$certifier}}</code>{{end}}</p>
+<ul>{{range $type, $fields := .Types}}<li><code>{{$type}}</code>: {{join $fields}}</li>{{end}}</ul>{{else}}none{{end}}{{end}}
+{{define "rateLimit"}}{{if .}}{{.Requests}} requests per {{.WindowSeconds}}s{{else}}unlimited{{end}}{{end}}
+`))
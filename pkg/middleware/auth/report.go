@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/events"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// VerificationReportPolicy publishes a transport.VerificationReport for every certificate exchange, listing
+// which certificate fields were requested, disclosed and decrypted and which checks passed, as evidence
+// of data minimization, e.g. for GDPR audits. Reports name the fields of the certificates but never their values.
+type VerificationReportPolicy struct {
+	// OnReport is called with the report of every certificate exchange, e.g. to store it with the records of the application
+	OnReport func(ctx context.Context, report transport.VerificationReport)
+	// AuditLogger receives every report at info level, defaults to the logger of the middleware
+	AuditLogger *slog.Logger
+}
+
+// newVerificationReporter returns the receiver of the reports of the transport, nil when reports are disabled
+func newVerificationReporter(policy *VerificationReportPolicy, logger *slog.Logger, bus *events.Bus) func(req *http.Request, report *transport.VerificationReport) {
+	if policy == nil {
+		return nil
+	}
+
+	auditLogger := policy.AuditLogger
+	if auditLogger == nil {
+		auditLogger = logger
+	}
+
+	return func(req *http.Request, report *transport.VerificationReport) {
+		auditLogger.LogAttrs(req.Context(), slog.LevelInfo, "Certificate exchange verified",
+			slog.String("identityKey", report.IdentityKey),
+			slog.String("outcome", report.Outcome),
+			slog.Any("report", report))
+
+		if policy.OnReport != nil {
+			policy.OnReport(req.Context(), *report)
+		}
+		bus.Publish(req.Context(), events.Event{
+			Topic: events.TopicAuth, Type: events.TypeVerificationReport, IdentityKey: report.IdentityKey, Request: req, Data: *report,
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// maxRevocationSize limits the size of revocations added with the admin API
+const maxRevocationSize = 4 << 10
+
+// Revocations returns the revocation list of the middleware, revoked identity keys and sessions are rejected
+// from their next request
+func (m *Middleware) Revocations() *transport.RevocationList {
+	return m.revocations
+}
+
+// RevocationsHandler serves the admin API of the revocation list: GET lists the revocations, PUT revokes the subject
+// of the JSON body and DELETE lifts the revocation of the kind and value query parameters. Sessions cut off by a
+// revocation are removed, peers whose revocation was lifted have to handshake again. The handler does not
+// authenticate its callers, it has to be served on an internal listener or behind the access control of the operator.
+func (m *Middleware) RevocationsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.Method {
+		case http.MethodGet:
+			revocations, err := m.revocations.List(req.Context())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(revocations); err != nil {
+				m.logger.Error("Failed to write revocations", slog.String("error", err.Error()))
+			}
+		case http.MethodPut:
+			var revocation transport.Revocation
+			if err := json.NewDecoder(http.MaxBytesReader(w, req.Body, maxRevocationSize)).Decode(&revocation); err != nil {
+				http.Error(w, "invalid revocation", http.StatusBadRequest)
+				return
+			}
+			if err := m.revocations.Revoke(req.Context(), revocation); err != nil {
+				http.Error(w, err.Error(), revocationErrorStatus(err))
+				return
+			}
+			m.logger.Info("Revoked", slog.String("kind", string(revocation.Kind)), slog.String("value", revocation.Value),
+				slog.String("reason", revocation.Reason))
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodDelete:
+			subject := transport.RevocationSubject{
+				Kind:  transport.RevocationKind(req.URL.Query().Get("kind")),
+				Value: req.URL.Query().Get("value"),
+			}
+			restored, err := m.revocations.Restore(req.Context(), subject)
+			if err != nil {
+				http.Error(w, err.Error(), revocationErrorStatus(err))
+				return
+			}
+			if !restored {
+				http.Error(w, "subject is not revoked", http.StatusNotFound)
+				return
+			}
+			m.logger.Info("Revocation lifted", slog.String("kind", string(subject.Kind)), slog.String("value", subject.Value))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Header().Set("Allow", "GET, PUT, DELETE")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+// revocationErrorStatus returns 400 for invalid subjects and 503 when the store failed
+func revocationErrorStatus(err error) int {
+	if errors.Is(err, transport.ErrInvalidRevocation) {
+		return http.StatusBadRequest
+	}
+	return http.StatusServiceUnavailable
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// EnforcementRollout enforces verification for a share of the identities while the middleware runs
+// in report-only mode, so enforcement can be rolled out gradually on high-traffic APIs (see Config.Rollout).
+// Identities are bucketed deterministically by their identity key, an identity enforced at a percentage
+// stays enforced when the percentage is raised.
+type EnforcementRollout struct {
+	// Percent is the share of identities verification is enforced for, from 0 to 100
+	Percent int
+	// Cohort lists identity keys (hex) verification is always enforced for, e.g. internal clients
+	Cohort []string
+}
+
+func (r *EnforcementRollout) validate() error {
+	if r == nil {
+		return nil
+	}
+
+	if r.Percent < 0 || r.Percent > 100 {
+		return fmt.Errorf("%w, percent %d is not between 0 and 100", ErrInvalidRollout, r.Percent)
+	}
+
+	for _, identityKey := range r.Cohort {
+		if _, err := ec.PublicKeyFromString(identityKey); err != nil {
+			return fmt.Errorf("%w, cohort identity key %q is not a public key", ErrInvalidRollout, identityKey)
+		}
+	}
+
+	return nil
+}
+
+// Enforces reports whether verification is enforced for the identity key, requests without
+// an identity key are only enforced at 100 percent
+func (r *EnforcementRollout) Enforces(identityKey string) bool {
+	if r == nil {
+		return false
+	}
+
+	if r.Percent >= 100 {
+		return true
+	}
+	if identityKey == "" {
+		return false
+	}
+
+	for _, member := range r.Cohort {
+		if strings.EqualFold(member, identityKey) {
+			return true
+		}
+	}
+
+	return rolloutBucket(identityKey) < r.Percent
+}
+
+// rolloutBucket maps the identity key to one of 100 buckets
+func rolloutBucket(identityKey string) int {
+	sum := sha256.Sum256([]byte(strings.ToLower(identityKey)))
+	return int(binary.BigEndian.Uint64(sum[:8]) % 100)
+}
+
+// enforces reports whether failed verification of the request is enforced, in report-only mode
+// only requests of identities in the rollout are rejected
+func (m *Middleware) enforces(identityKey string) bool {
+	return !m.reportOnly || m.rollout.Enforces(identityKey)
+}
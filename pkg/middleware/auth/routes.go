@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+)
+
+const (
+	requestIDHeader   = "x-bsv-auth-request-id"
+	yourNonceHeader   = "x-bsv-auth-your-nonce"
+	identityKeyHeader = "x-bsv-auth-identity-key"
+)
+
+// RoutePolicy overrides the authentication behaviour for requests matching a route pattern
+type RoutePolicy struct {
+	// Exempt skips authentication, requests are passed to the handler without verification and responses are not signed
+	Exempt bool
+	// AllowUnauthenticated passes requests without auth headers to the handler,
+	// requests with auth headers are still verified and their responses signed
+	AllowUnauthenticated bool
+	// DenyAnonymous rejects requests of anonymous sessions with 403, when AnonymousAccess is enabled
+	DenyAnonymous bool
+}
+
+// routePolicies matches requests against net/http ServeMux patterns (e.g. "GET /items/{id}"),
+// so route policies follow the same matching and precedence rules as the router of the application
+type routePolicies struct {
+	mux      *http.ServeMux
+	policies map[string]RoutePolicy
+}
+
+func newRoutePolicies(policies map[string]RoutePolicy) (r *routePolicies, err error) {
+	if len(policies) == 0 {
+		return nil, nil
+	}
+
+	defer func() {
+		// ServeMux panics on invalid or conflicting patterns
+		if rec := recover(); rec != nil {
+			r, err = nil, fmt.Errorf("%w, %v", ErrInvalidRoutePolicy, rec)
+		}
+	}()
+
+	mux := http.NewServeMux()
+	for pattern := range policies {
+		mux.Handle(pattern, http.NotFoundHandler())
+	}
+
+	return &routePolicies{mux: mux, policies: policies}, nil
+}
+
+// match returns the policy of the most specific pattern matching the request
+func (r *routePolicies) match(req *http.Request) (RoutePolicy, bool) {
+	if r == nil {
+		return RoutePolicy{}, false
+	}
+
+	_, pattern := r.mux.Handler(req)
+	policy, ok := r.policies[pattern]
+	return policy, ok
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// RPC protocols recognised by RPCProtocol
+const (
+	// RPCProtocolConnect is the Connect protocol, unary requests with application/proto or application/json bodies
+	// and streams with application/connect+proto or application/connect+json bodies
+	RPCProtocolConnect = "connect"
+	// RPCProtocolGRPCWeb is gRPC-Web, with application/grpc-web and application/grpc-web-text bodies
+	RPCProtocolGRPCWeb = "grpc-web"
+	// RPCProtocolGRPC is gRPC over HTTP/2, which carries its status in trailers
+	RPCProtocolGRPC = "grpc"
+)
+
+// GatewayMetadataPrefix prefixes the headers grpc-gateway forwards as gRPC metadata with its default header matcher,
+// the prefix is removed from the metadata key
+const GatewayMetadataPrefix = "Grpc-Metadata-"
+
+// RPCProtocol returns the RPC protocol of the request derived from its content type, or an empty string for plain
+// HTTP requests, such as the JSON requests of grpc-gateway
+func RPCProtocol(req *http.Request) string {
+	if req.Header.Get("Connect-Protocol-Version") != "" {
+		return RPCProtocolConnect
+	}
+
+	mediaType, _, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		return ""
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "application/grpc-web"):
+		return RPCProtocolGRPCWeb
+	case mediaType == "application/grpc" || strings.HasPrefix(mediaType, "application/grpc+"):
+		return RPCProtocolGRPC
+	case strings.HasPrefix(mediaType, "application/connect+"):
+		return RPCProtocolConnect
+	default:
+		return ""
+	}
+}
+
+// RPCHandler returns the handler of the middleware in front of a grpc-gateway mux or Connect handlers.
+//
+// Bodies are signed as sent on the wire: the signature covers the exact protobuf (or JSON) bytes, including
+// the envelopes of Connect streams and gRPC-Web and bodies compressed with Connect-Content-Encoding or grpc-encoding.
+// Protobuf encoding is not canonical, so messages are never re-encoded for verification and clients have to sign
+// the bytes they send. Content-Type and the x-bsv-* headers are signed, the Connect-*, Grpc-* and Te headers are not.
+// The middleware buffers request and response bodies, unary calls and client or server streams which end before
+// the response is read are supported, bidirectional streams are not. gRPC over HTTP/2 is rejected with
+// 415 Unsupported Media Type, as its status is sent in trailers which response signatures do not cover.
+//
+// Peer attributes are passed to the RPC handlers in the upstream headers declared in Config.ForwardAuth (signed with
+// its HMAC key when set), so gRPC services behind grpc-gateway receive them as metadata. Each header is set under
+// its name and prefixed with GatewayMetadataPrefix, which the default header matcher of grpc-gateway forwards,
+// values sent by the peer are dropped. Connect handlers run in the request context and can use GetIdentityFromContext.
+func (m *Middleware) RPCHandler(next http.Handler) http.Handler {
+	upstream := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		req = req.Clone(req.Context())
+		names := m.forwardAuth.upstreamHeaderNames()
+		for _, name := range names {
+			req.Header.Del(name)
+			req.Header.Del(GatewayMetadataPrefix + name)
+		}
+
+		if identityKey, ok := GetIdentityFromContext(req.Context()); ok {
+			session := m.sessionManager.GetSession(req.Header.Get(yourNonceHeader))
+			m.forwardAuth.setUpstreamHeaders(req.Header, identityKey, session)
+
+			for _, name := range names {
+				if values := req.Header.Values(name); len(values) > 0 {
+					req.Header[http.CanonicalHeaderKey(GatewayMetadataPrefix+name)] = values
+				}
+			}
+		}
+
+		next.ServeHTTP(w, req)
+	})
+
+	handler := m.Handler(upstream)
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if RPCProtocol(req) == RPCProtocolGRPC {
+			m.respondWithError(w, http.StatusUnsupportedMediaType, transport.ErrCodeInvalidHeader, ErrUnsupportedRPCProtocol)
+			return
+		}
+
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// HeaderFromMetadata returns the gRPC metadata (e.g. metadata.MD) forwarded by grpc-gateway as headers,
+// so services can read the upstream headers of RPCHandler and verify them with ForwardAuthConfig.VerifyUpstreamHeaders
+func HeaderFromMetadata(md map[string][]string) http.Header {
+	header := make(http.Header, len(md))
+	for key, values := range md {
+		header[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+	}
+	return header
+}
+
+// upstreamHeaderNames returns the names of the upstream headers and of their signature headers
+func (c ForwardAuthConfig) upstreamHeaderNames() []string {
+	names := []string{UpstreamTimestampHeader, UpstreamSignatureHeader}
+	for _, h := range c.headers() {
+		names = append(names, h.Name)
+	}
+	return names
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+)
+
+// selfTestKeyID is the key ID of the signature created by the self-test
+const selfTestKeyID = "self-test"
+
+// Golden payloads of the self-test request and response, a change of the payload encoding breaks every peer
+const (
+	selfTestRequestPayload = "0000000000000000000000000000000000000000000000000000000000000000" +
+		"0400000000000000504f53540a000000000000002f73656c662d746573740700000000000000636865636b" +
+		"3d3101000000000000000c00000000000000636f6e74656e742d7479706510000000000000006170706c69" +
+		"636174696f6e2f6a736f6e0f000000000000007b2273656c66223a2274657374227d"
+	selfTestResponsePayload = "0000000000000000000000000000000000000000000000000000000000000000" +
+		"c800000000000000ffffffffffffffff02000000000000006f6b"
+)
+
+// selfTest exercises the signing pipeline with the wallet, so a broken wallet is reported by New
+// instead of on the first request. The wallet is called with the privileged keys the middleware will use.
+func selfTest(w wallet.WalletInterface, privilegedKeys transport.PrivilegedKeys) error {
+	ctx := context.Background()
+
+	identityKey, err := w.GetPublicKey(privilegedKeys.IdentityKeyArgs(), "")
+	if err != nil {
+		return fmt.Errorf("%w: failed to derive identity key, %w", ErrSelfTestFailed, err)
+	}
+	if identityKey == nil || identityKey.PublicKey == nil {
+		return fmt.Errorf("%w: wallet returned no identity key", ErrSelfTestFailed)
+	}
+
+	nonce, err := w.CreateNonce(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: failed to create nonce, %w", ErrSelfTestFailed, err)
+	}
+	valid, err := w.VerifyNonce(ctx, nonce)
+	if err != nil {
+		return fmt.Errorf("%w: failed to verify nonce, %w", ErrSelfTestFailed, err)
+	}
+	if !valid {
+		return fmt.Errorf("%w: wallet rejected its own nonce", ErrSelfTestFailed)
+	}
+
+	requestPayload, err := selfTestPayload()
+	if err != nil {
+		return fmt.Errorf("%w: failed to build request payload, %w", ErrSelfTestFailed, err)
+	}
+	if got := hex.EncodeToString(requestPayload); got != selfTestRequestPayload {
+		return fmt.Errorf("%w: request payload does not match golden bytes, got %s", ErrSelfTestFailed, got)
+	}
+
+	responsePayload, err := utils.BuildResponsePayload(strings.Repeat("A", 43)+"=", http.StatusOK, []byte("ok"))
+	if err != nil {
+		return fmt.Errorf("%w: failed to build response payload, %w", ErrSelfTestFailed, err)
+	}
+	if got := hex.EncodeToString(responsePayload); got != selfTestResponsePayload {
+		return fmt.Errorf("%w: response payload does not match golden bytes, got %s", ErrSelfTestFailed, got)
+	}
+
+	encryptionArgs := privilegedKeys.Apply(wallet.EncryptionArgs{
+		ProtocolID:   wallet.DefaultAuthProtocol,
+		KeyID:        selfTestKeyID,
+		Counterparty: wallet.Counterparty{Type: wallet.CounterpartyTypeSelf},
+	})
+	signature, err := w.CreateSignature(&wallet.CreateSignatureArgs{EncryptionArgs: encryptionArgs, Data: requestPayload}, "")
+	if err != nil {
+		return fmt.Errorf("%w: failed to create signature, %w", ErrSelfTestFailed, err)
+	}
+	result, err := w.VerifySignature(&wallet.VerifySignatureArgs{
+		EncryptionArgs: encryptionArgs,
+		Signature:      signature.Signature,
+		Data:           requestPayload,
+		ForSelf:        true,
+	})
+	if err != nil {
+		return fmt.Errorf("%w: failed to verify signature, %w", ErrSelfTestFailed, err)
+	}
+	if !result.Valid {
+		return fmt.Errorf("%w: wallet rejected its own signature", ErrSelfTestFailed)
+	}
+
+	return nil
+}
+
+// selfTestPayload builds the payload of the self-test request prefixed with an all zero request ID
+func selfTestPayload() ([]byte, error) {
+	req, err := http.NewRequest(http.MethodPost, "https://self-test.local/self-test?check=1", strings.NewReader(`{"self":"test"}`))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request, %w", err)
+	}
+	// a single signed header, so the payload does not depend on the header order
+	req.Header.Set("content-type", "application/json")
+
+	var writer bytes.Buffer
+	writer.Write(make([]byte, 32))
+	if err := utils.WriteRequestData(req, &writer); err != nil {
+		return nil, fmt.Errorf("failed to write request data, %w", err)
+	}
+	return writer.Bytes(), nil
+}
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// SessionKey returns the session key of the given label for the session which authenticated the request,
+// handlers use it to encrypt their own data end-to-end for the peer, which derives the same key with client.Client.SessionKey.
+// Requests without session and requests of anonymous sessions have no session key.
+func (m *Middleware) SessionKey(req *http.Request, label string) (*transport.SessionKey, error) {
+	if IsAnonymousFromContext(req.Context()) {
+		return nil, fmt.Errorf("%w: anonymous sessions have no session key", transport.ErrInvalidSessionKey)
+	}
+
+	sessionNonce, _ := req.Context().Value(transport.SessionNonce).(string)
+	session := m.sessionManager.GetSession(sessionNonce)
+	if sessionNonce == "" || session == nil || !session.IsAuthenticated || session.PeerIdentityKey == nil || session.PeerNonce == nil {
+		return nil, transport.ErrSessionNotAuthenticated
+	}
+
+	return transport.NewSessionKey(transport.SessionKeyConfig{
+		Wallet:         m.wallet,
+		Counterparty:   *session.PeerIdentityKey,
+		ServerNonce:    sessionNonce,
+		ClientNonce:    *session.PeerNonce,
+		Label:          label,
+		PrivilegedKeys: m.privilegedKeys,
+	})
+}
@@ -0,0 +1,6 @@
+//go:build !dev
+
+package auth
+
+// StrictProductionDefault is used when Config.StrictProduction is not set, it is false in builds with the dev build tag
+const StrictProductionDefault = true
@@ -0,0 +1,160 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// Defaults of the tarpit
+const (
+	// DefaultTarpitDelay is the time tarpitted requests are held before the tarpit handler responds
+	DefaultTarpitDelay = 10 * time.Second
+	// DefaultTarpitConcurrency is the number of requests held in the tarpit at the same time
+	DefaultTarpitConcurrency = 100
+)
+
+// Reasons of requests sent to the tarpit, reported in the audit log
+const (
+	// TarpitReasonAbusive is a request of an identity key reported by TarpitPolicy.IsAbusive
+	TarpitReasonAbusive = "abusive"
+	// TarpitReasonLockedOut is a request of an identity key or address locked out by the AnomalyPolicy
+	TarpitReasonLockedOut = "lockedOut"
+	// TarpitReasonDenied is a request denied by the Authorizer
+	TarpitReasonDenied = "denied"
+)
+
+// TarpitPolicy sends requests of abusive peers to a tarpit instead of rejecting them, to slow down automated scanners.
+// Tarpitted requests are held for the delay and answered by the tarpit handler, responses to authenticated requests
+// are signed like any other response, so peers cannot tell the tarpit from the real handler. Every tarpitted request
+// is logged to the audit logger. Requests over the concurrency of the tarpit are rejected right away, so the tarpit
+// cannot be used to exhaust the connections of the server.
+type TarpitPolicy struct {
+	// IsAbusive reports whether the authenticated identity key is blocked or abusive, e.g. from a blocklist
+	IsAbusive func(ctx context.Context, identityKey string) bool
+	// LockedOut sends requests locked out by the AnomalyPolicy to the tarpit instead of responding with 429
+	LockedOut bool
+	// Denied sends requests denied by the Authorizer to the tarpit instead of responding with 403
+	Denied bool
+	// Handler responds to tarpitted requests, defaults to TarpitHandler
+	Handler http.Handler
+	// Delay is the time requests are held before the handler responds, defaults to DefaultTarpitDelay
+	Delay time.Duration
+	// Concurrency is the number of requests held at the same time, defaults to DefaultTarpitConcurrency
+	Concurrency int
+	// AuditLogger receives a warning for every tarpitted request, defaults to the logger of the middleware
+	AuditLogger *slog.Logger
+}
+
+// TarpitHandler responds with an empty JSON object, the default response of the tarpit
+func TarpitHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte("{}"))
+	})
+}
+
+// tarpit holds the requests of a TarpitPolicy
+type tarpit struct {
+	policy TarpitPolicy
+	slots  chan struct{}
+}
+
+func newTarpit(policy *TarpitPolicy, logger *slog.Logger) *tarpit {
+	if policy == nil {
+		return nil
+	}
+
+	p := *policy
+	if p.Handler == nil {
+		p.Handler = TarpitHandler()
+	}
+	if p.Delay <= 0 {
+		p.Delay = DefaultTarpitDelay
+	}
+	if p.Concurrency <= 0 {
+		p.Concurrency = DefaultTarpitConcurrency
+	}
+	if p.AuditLogger == nil {
+		p.AuditLogger = logger
+	}
+
+	return &tarpit{policy: p, slots: make(chan struct{}, p.Concurrency)}
+}
+
+// abusive reports whether the authenticated peer of the request is sent to the tarpit
+func (t *tarpit) abusive(req *http.Request) bool {
+	if t == nil || t.policy.IsAbusive == nil {
+		return false
+	}
+
+	identityKey, ok := GetIdentityFromContext(req.Context())
+	return ok && identityKey != "" && t.policy.IsAbusive(req.Context(), identityKey)
+}
+
+// lockedOut reports whether locked out requests are sent to the tarpit
+func (t *tarpit) lockedOut() bool {
+	return t != nil && t.policy.LockedOut
+}
+
+// denied reports whether denied requests are sent to the tarpit
+func (t *tarpit) denied() bool {
+	return t != nil && t.policy.Denied
+}
+
+// serve holds the request for the delay and responds with the tarpit handler, it reports false without responding
+// when the tarpit is full
+func (t *tarpit) serve(w http.ResponseWriter, req *http.Request, reason string) bool {
+	select {
+	case t.slots <- struct{}{}:
+		defer func() { <-t.slots }()
+	default:
+		t.audit(req, reason, slog.Bool("full", true))
+		return false
+	}
+
+	t.audit(req, reason)
+
+	timer := time.NewTimer(t.policy.Delay)
+	defer timer.Stop()
+	select {
+	case <-req.Context().Done():
+		return true
+	case <-timer.C:
+	}
+
+	t.policy.Handler.ServeHTTP(w, req)
+	return true
+}
+
+func (t *tarpit) audit(req *http.Request, reason string, attrs ...slog.Attr) {
+	attrs = append([]slog.Attr{
+		slog.String("reason", reason),
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.String("remoteAddr", req.RemoteAddr),
+	}, attrs...)
+
+	identityKey, ok := GetIdentityFromContext(req.Context())
+	if !ok || identityKey == "" {
+		identityKey = req.Header.Get(identityKeyHeader)
+	}
+	if identityKey != "" {
+		attrs = append(attrs, slog.String("identityKey", identityKey))
+	}
+
+	t.policy.AuditLogger.LogAttrs(req.Context(), slog.LevelWarn, "Request sent to tarpit", attrs...)
+}
+
+// serveTarpit sends the request to the tarpit, or responds with 403 when the tarpit is full
+func (m *Middleware) serveTarpit(w http.ResponseWriter, req *http.Request, reason string) string {
+	if m.tarpit.serve(w, req, reason) {
+		return ""
+	}
+
+	m.respondWithError(w, http.StatusForbidden, transport.ErrCodeForbidden, ErrAuthorizationDenied)
+	return transport.ErrCodeForbidden
+}
@@ -0,0 +1,386 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/netip"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/events"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/internal/logging"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// Defaults of the session telemetry
+const (
+	// DefaultTelemetryPeriod is the period of the session snapshots when none is configured
+	DefaultTelemetryPeriod = 5 * time.Minute
+	// DefaultTelemetryIdentityPrefix is the number of hex characters of the identity keys kept in the prefix aggregates
+	DefaultTelemetryIdentityPrefix = 6
+)
+
+// TelemetryOptions configures the session telemetry
+type TelemetryOptions struct {
+	// Period is the time between session snapshots exported by Run, defaults to DefaultTelemetryPeriod
+	Period time.Duration
+	// Sinks receive the session snapshots on Flush, e.g. a SIEM or fraud detection system
+	Sinks []TelemetrySink
+	// IdentityPrefix is the number of hex characters of the identity keys the prefix aggregates are keyed by,
+	// defaults to DefaultTelemetryIdentityPrefix
+	IdentityPrefix int
+	// Salt is mixed into the hashes of the identity keys in the per identity aggregates, so they can only be
+	// correlated with identity keys by those knowing it. Without a salt the hashes of known keys can be recomputed.
+	Salt []byte
+	// Locate maps the network address of peers to a location, e.g. a country code from a GeoIP database,
+	// the location aggregates are left empty when it is not set
+	Locate func(addr netip.Addr) string
+	// Logger is used for export failures, defaults to the package logger
+	Logger *slog.Logger
+}
+
+// TelemetryCount is the number of requests, failed requests and wallet operations of an aggregate of a session snapshot
+type TelemetryCount struct {
+	Key         string  `json:"key"`
+	Requests    int64   `json:"requests"`
+	Failures    int64   `json:"failures"`
+	FailureRate float64 `json:"failureRate"`
+	WalletCalls int64   `json:"walletCalls"`
+}
+
+// SessionSnapshot is an anonymized summary of the authentication traffic in a period. Identity keys are reduced
+// to prefixes or salted hashes and peer addresses to their /24 (IPv4) or /48 (IPv6) network.
+type SessionSnapshot struct {
+	PeriodStart time.Time `json:"periodStart"`
+	PeriodEnd   time.Time `json:"periodEnd"`
+	// Requests counts the handshake messages and general requests, failures those rejected by the middleware
+	Requests int64 `json:"requests"`
+	Failures int64 `json:"failures"`
+	// Handshakes counts the successful handshakes
+	Handshakes int64 `json:"handshakes"`
+	// WalletCalls counts the wallet operations of the requests by operation, e.g. to attribute the cost of remote wallets
+	WalletCalls transport.WalletCalls `json:"walletCalls"`
+	// IdentityPrefixes aggregates the requests by prefix of the identity keys
+	IdentityPrefixes []TelemetryCount `json:"identityPrefixes"`
+	// Identities aggregates the requests by salted hash of the identity keys, rejected requests are counted
+	// for the identity key claimed in their headers
+	Identities []TelemetryCount `json:"identities"`
+	// Networks aggregates the requests by network of the peer address
+	Networks []TelemetryCount `json:"networks"`
+	// Locations aggregates the requests by location of the peer address, see TelemetryOptions.Locate
+	Locations []TelemetryCount `json:"locations,omitempty"`
+}
+
+// TelemetrySink exports session snapshots
+type TelemetrySink interface {
+	ExportSnapshot(ctx context.Context, snapshot SessionSnapshot) error
+}
+
+// TelemetrySinkFunc adapts an ordinary function to the TelemetrySink interface
+type TelemetrySinkFunc func(ctx context.Context, snapshot SessionSnapshot) error
+
+// ExportSnapshot calls f(ctx, snapshot)
+func (f TelemetrySinkFunc) ExportSnapshot(ctx context.Context, snapshot SessionSnapshot) error {
+	return f(ctx, snapshot)
+}
+
+// JSONTelemetrySink writes each snapshot as one line of JSON, e.g. to a file shipped to a SIEM
+type JSONTelemetrySink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONTelemetrySink creates a sink writing JSON lines to w
+func NewJSONTelemetrySink(w io.Writer) *JSONTelemetrySink {
+	return &JSONTelemetrySink{w: w}
+}
+
+// ExportSnapshot writes the snapshot followed by a newline
+func (s *JSONTelemetrySink) ExportSnapshot(_ context.Context, snapshot SessionSnapshot) error {
+	line, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode session snapshot, %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write session snapshot, %w", err)
+	}
+	return nil
+}
+
+type telemetryCounts struct {
+	requests    int64
+	failures    int64
+	walletCalls int64
+}
+
+// SessionTelemetry aggregates the requests handled by the auth middleware (see Config.Telemetry)
+// and periodically exports anonymized snapshots to its sinks
+type SessionTelemetry struct {
+	period         time.Duration
+	sinks          []TelemetrySink
+	identityPrefix int
+	salt           []byte
+	locate         func(addr netip.Addr) string
+	logger         *slog.Logger
+
+	mu          sync.Mutex
+	periodStart time.Time
+	total       telemetryCounts
+	handshakes  int64
+	walletCalls transport.WalletCalls
+	prefixes    map[string]*telemetryCounts
+	identities  map[string]*telemetryCounts
+	networks    map[string]*telemetryCounts
+	locations   map[string]*telemetryCounts
+}
+
+// NewSessionTelemetry creates a session telemetry
+func NewSessionTelemetry(opts TelemetryOptions) (*SessionTelemetry, error) {
+	if opts.Period < 0 {
+		return nil, errors.New("telemetry period must not be negative")
+	}
+	if opts.Period == 0 {
+		opts.Period = DefaultTelemetryPeriod
+	}
+	if opts.IdentityPrefix < 0 {
+		return nil, errors.New("telemetry identity prefix must not be negative")
+	}
+	if opts.IdentityPrefix == 0 {
+		opts.IdentityPrefix = DefaultTelemetryIdentityPrefix
+	}
+
+	t := &SessionTelemetry{
+		period:         opts.Period,
+		sinks:          opts.Sinks,
+		identityPrefix: opts.IdentityPrefix,
+		salt:           opts.Salt,
+		locate:         opts.Locate,
+		logger:         logging.Child(opts.Logger, "session-telemetry"),
+	}
+	t.reset(time.Now())
+	return t, nil
+}
+
+// Snapshot returns the snapshot of the requests recorded since the last flush
+func (t *SessionTelemetry) Snapshot() SessionSnapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.snapshot(time.Now())
+}
+
+// Flush exports the snapshot of the requests recorded since the last flush to the sinks and starts a new period.
+// The recorded requests are kept for the next flush when a sink fails.
+func (t *SessionTelemetry) Flush(ctx context.Context) error {
+	now := time.Now()
+
+	t.mu.Lock()
+	snapshot := t.snapshot(now)
+	t.mu.Unlock()
+
+	for _, sink := range t.sinks {
+		if err := sink.ExportSnapshot(ctx, snapshot); err != nil {
+			return fmt.Errorf("failed to export session snapshot, %w", err)
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.drop(snapshot)
+	return nil
+}
+
+// Run flushes a snapshot once per period until the context is done
+func (t *SessionTelemetry) Run(ctx context.Context) {
+	ticker := time.NewTicker(t.period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := t.Flush(ctx); err != nil {
+				t.logger.Error("Failed to flush session telemetry", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// Subscribe records the requests published on the bus until the returned function is called,
+// the middleware subscribes the telemetry of Config.Telemetry to its bus
+func (t *SessionTelemetry) Subscribe(bus *events.Bus) (unsubscribe func()) {
+	return requestEvents(bus, t.record)
+}
+
+// record records a request with its outcome
+func (t *SessionTelemetry) record(req *http.Request, event RequestEvent) {
+	var failed bool
+	identityKey := event.IdentityKey
+	switch event.Outcome {
+	case AccessOutcomeAuthenticated:
+	case AccessOutcomeHandshake:
+	case AccessOutcomeRejected, AccessOutcomeReportOnly:
+		failed = true
+		if identityKey == "" {
+			identityKey = req.Header.Get(identityKeyHeader)
+		}
+	default:
+		return
+	}
+
+	network, location := t.network(req)
+	walletCalls := event.WalletCalls
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts := []*telemetryCounts{&t.total, t.entry(t.networks, network)}
+	if location != "" {
+		counts = append(counts, t.entry(t.locations, location))
+	}
+	if identityKey != "" {
+		counts = append(counts, t.entry(t.prefixes, t.prefix(identityKey)), t.entry(t.identities, t.hash(identityKey)))
+	}
+	for _, c := range counts {
+		c.requests++
+		c.walletCalls += walletCalls.Total()
+		if failed {
+			c.failures++
+		}
+	}
+
+	if event.Outcome == AccessOutcomeHandshake {
+		t.handshakes++
+	}
+	t.walletCalls = t.walletCalls.Add(walletCalls)
+}
+
+// network returns the network and location of the peer address of the request
+func (t *SessionTelemetry) network(req *http.Request) (string, string) {
+	addr, ok := peerAddr(req)
+	if !ok {
+		return "unknown", ""
+	}
+
+	bits := 48
+	if addr.Is4() {
+		bits = 24
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return "unknown", ""
+	}
+
+	var location string
+	if t.locate != nil {
+		location = t.locate(addr)
+	}
+	return prefix.String(), location
+}
+
+func (t *SessionTelemetry) prefix(identityKey string) string {
+	if len(identityKey) <= t.identityPrefix {
+		return identityKey
+	}
+	return identityKey[:t.identityPrefix]
+}
+
+func (t *SessionTelemetry) hash(identityKey string) string {
+	h := sha256.New()
+	h.Write(t.salt)
+	h.Write([]byte(identityKey))
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+func (t *SessionTelemetry) entry(counts map[string]*telemetryCounts, key string) *telemetryCounts {
+	if counts[key] == nil {
+		counts[key] = &telemetryCounts{}
+	}
+	return counts[key]
+}
+
+func (t *SessionTelemetry) reset(periodStart time.Time) {
+	t.periodStart = periodStart
+	t.total = telemetryCounts{}
+	t.handshakes = 0
+	t.walletCalls = transport.WalletCalls{}
+	t.prefixes = make(map[string]*telemetryCounts)
+	t.identities = make(map[string]*telemetryCounts)
+	t.networks = make(map[string]*telemetryCounts)
+	t.locations = make(map[string]*telemetryCounts)
+}
+
+// drop removes the exported counts, keeping the requests recorded during the export for the next period
+func (t *SessionTelemetry) drop(exported SessionSnapshot) {
+	t.periodStart = exported.PeriodEnd
+	t.total.requests -= exported.Requests
+	t.total.failures -= exported.Failures
+	t.handshakes -= exported.Handshakes
+	t.walletCalls = t.walletCalls.Sub(exported.WalletCalls)
+	t.total.walletCalls -= exported.WalletCalls.Total()
+
+	dropCounts(t.prefixes, exported.IdentityPrefixes)
+	dropCounts(t.identities, exported.Identities)
+	dropCounts(t.networks, exported.Networks)
+	dropCounts(t.locations, exported.Locations)
+}
+
+func dropCounts(counts map[string]*telemetryCounts, exported []TelemetryCount) {
+	for _, c := range exported {
+		entry := counts[c.Key]
+		entry.requests -= c.Requests
+		entry.failures -= c.Failures
+		entry.walletCalls -= c.WalletCalls
+		if entry.requests == 0 {
+			delete(counts, c.Key)
+		}
+	}
+}
+
+func (t *SessionTelemetry) snapshot(periodEnd time.Time) SessionSnapshot {
+	return SessionSnapshot{
+		PeriodStart:      t.periodStart.UTC(),
+		PeriodEnd:        periodEnd.UTC(),
+		Requests:         t.total.requests,
+		Failures:         t.total.failures,
+		Handshakes:       t.handshakes,
+		WalletCalls:      t.walletCalls,
+		IdentityPrefixes: telemetryAggregate(t.prefixes),
+		Identities:       telemetryAggregate(t.identities),
+		Networks:         telemetryAggregate(t.networks),
+		Locations:        telemetryAggregate(t.locations),
+	}
+}
+
+// telemetryAggregate returns the counts ordered by requests, most requests first, and key
+func telemetryAggregate(counts map[string]*telemetryCounts) []TelemetryCount {
+	aggregate := make([]TelemetryCount, 0, len(counts))
+	for key, c := range counts {
+		aggregate = append(aggregate, TelemetryCount{
+			Key:         key,
+			Requests:    c.requests,
+			Failures:    c.failures,
+			FailureRate: float64(c.failures) / float64(c.requests),
+			WalletCalls: c.walletCalls,
+		})
+	}
+	sort.Slice(aggregate, func(i, j int) bool {
+		if aggregate[i].Requests != aggregate[j].Requests {
+			return aggregate[i].Requests > aggregate[j].Requests
+		}
+		return aggregate[i].Key < aggregate[j].Key
+	})
+	return aggregate
+}
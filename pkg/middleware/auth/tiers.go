@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+)
+
+// Defaults of the quota tiers
+const (
+	// DefaultTierRateLimitWindow is the window of the rate limit of a tier when no window is configured
+	DefaultTierRateLimitWindow = time.Minute
+	// DefaultFreeRequestsWindow is the window of the free requests of a tier when no window is configured
+	DefaultFreeRequestsWindow = 24 * time.Hour
+)
+
+const tierContextKey contextKey = "tier"
+
+// Tier is a named quota tier assigned to authenticated peers, e.g. from the plan field of their certificates
+type Tier struct {
+	// Name identifies the tier, it is stored on the session of the peer
+	Name string
+	// RateLimit is the number of general requests a peer of the tier may send per window, zero is unlimited
+	RateLimit int
+	// RateLimitWindow is the window of the rate limit, defaults to DefaultTierRateLimitWindow
+	RateLimitWindow time.Duration
+	// FreeRequests is the number of requests per window the payment middleware serves without payment, zero disables it
+	FreeRequests int
+	// FreeRequestsWindow is the window of the free requests, defaults to DefaultFreeRequestsWindow
+	FreeRequestsWindow time.Duration
+}
+
+// TierResolver assigns the name of a tier to an authenticated peer
+type TierResolver interface {
+	// ResolveTier returns the tier name of the peer from the certificates accepted during the handshake,
+	// an empty name assigns the default tier
+	ResolveTier(identityKey string, certificates []certificates.VerifiableCertificate) string
+}
+
+// TierResolverFunc adapts a function to the TierResolver interface
+type TierResolverFunc func(identityKey string, certificates []certificates.VerifiableCertificate) string
+
+// ResolveTier calls the function
+func (f TierResolverFunc) ResolveTier(identityKey string, certs []certificates.VerifiableCertificate) string {
+	return f(identityKey, certs)
+}
+
+// CertificateFieldTiers returns a resolver which assigns tiers by the value of a field of certificates of the given type,
+// e.g. tiersByValue {"enterprise": "enterprise"} for certificates with the field plan: enterprise.
+// Decrypted fields (e.g. set by the OnCertificatesReceived callback) take precedence over plain fields.
+func CertificateFieldTiers(certificateType, field string, tiersByValue map[string]string) TierResolver {
+	return TierResolverFunc(func(_ string, certs []certificates.VerifiableCertificate) string {
+		for _, cert := range certs {
+			if cert.Type != certificateType {
+				continue
+			}
+			if tier, ok := tiersByValue[certificateField(cert, field)]; ok {
+				return tier
+			}
+		}
+		return ""
+	})
+}
+
+// certificateField returns the decrypted value of the field, or its plain value when it was not decrypted
+func certificateField(cert certificates.VerifiableCertificate, field string) string {
+	if cert.DecryptedFields != nil {
+		if value, ok := (*cert.DecryptedFields)[field]; ok {
+			return value
+		}
+	}
+	value, _ := cert.Fields[field].(string)
+	return value
+}
+
+// TierPolicy derives quota tiers of authenticated peers, the tier is resolved once per session,
+// and again after the peer sent new certificates. Anonymous sessions are limited by the AnonymousPolicy instead.
+type TierPolicy struct {
+	// Tiers are the available tiers
+	Tiers []Tier
+	// Resolver assigns tier names to peers
+	Resolver TierResolver
+	// DefaultTier is the name of the tier of peers the resolver assigns no tier to, empty leaves them without tier
+	DefaultTier string
+}
+
+// GetTierFromContext retrieves the tier of the authenticated peer from the request context,
+// it reports false when no tier policy is configured or the peer has no tier
+func GetTierFromContext(ctx context.Context) (Tier, bool) {
+	tier, ok := ctx.Value(tierContextKey).(Tier)
+	return tier, ok
+}
+
+// tiers resolves the tiers of sessions and applies their rate limits per identity
+type tiers struct {
+	policy *TierPolicy
+	byName map[string]Tier
+
+	mu        sync.Mutex
+	windows   map[string]*windowLimiter
+	lastPrune time.Time
+}
+
+func newTiers(policy *TierPolicy) (*tiers, error) {
+	if policy == nil {
+		return nil, nil
+	}
+
+	if policy.Resolver == nil {
+		return nil, fmt.Errorf("%w: resolver is required", ErrInvalidTierPolicy)
+	}
+
+	byName := make(map[string]Tier, len(policy.Tiers))
+	for _, tier := range policy.Tiers {
+		if tier.Name == "" {
+			return nil, fmt.Errorf("%w: tier without name", ErrInvalidTierPolicy)
+		}
+		if _, ok := byName[tier.Name]; ok {
+			return nil, fmt.Errorf("%w: duplicate tier %s", ErrInvalidTierPolicy, tier.Name)
+		}
+		if tier.RateLimitWindow <= 0 {
+			tier.RateLimitWindow = DefaultTierRateLimitWindow
+		}
+		if tier.FreeRequestsWindow <= 0 {
+			tier.FreeRequestsWindow = DefaultFreeRequestsWindow
+		}
+		byName[tier.Name] = tier
+	}
+
+	if _, ok := byName[policy.DefaultTier]; policy.DefaultTier != "" && !ok {
+		return nil, fmt.Errorf("%w: unknown default tier %s", ErrInvalidTierPolicy, policy.DefaultTier)
+	}
+
+	return &tiers{policy: policy, byName: byName, windows: make(map[string]*windowLimiter)}, nil
+}
+
+// allow applies the rate limit of the tier to the identity
+func (t *tiers) allow(tier Tier, identityKey string, now time.Time) (bool, time.Duration) {
+	if tier.RateLimit <= 0 {
+		return true, 0
+	}
+
+	t.mu.Lock()
+	key := tier.Name + " " + identityKey
+	limiter, ok := t.windows[key]
+	if !ok {
+		if now.Sub(t.lastPrune) >= DefaultTierRateLimitWindow {
+			for k, l := range t.windows {
+				if l.expired(now) {
+					delete(t.windows, k)
+				}
+			}
+			t.lastPrune = now
+		}
+		limiter = &windowLimiter{limit: tier.RateLimit, window: tier.RateLimitWindow}
+		t.windows[key] = limiter
+	}
+	t.mu.Unlock()
+
+	return limiter.allow(now)
+}
+
+// withTier stores the tier of the authenticated peer in the request context and applies its rate limit.
+// The tier is resolved on the first request of the session and stored on it.
+func (m *Middleware) withTier(req *http.Request) (*http.Request, error) {
+	if m.tiers == nil || IsAnonymousFromContext(req.Context()) {
+		return req, nil
+	}
+
+	identityKey, ok := GetIdentityFromContext(req.Context())
+	if !ok || identityKey == "" {
+		return req, nil
+	}
+
+	session := m.sessionManager.GetSession(req.Header.Get(yourNonceHeader))
+	if session == nil {
+		return req, nil
+	}
+
+	if session.Tier == "" {
+		session.Tier = m.tiers.policy.Resolver.ResolveTier(identityKey, session.Certificates)
+		if session.Tier == "" {
+			session.Tier = m.tiers.policy.DefaultTier
+		}
+		m.sessionManager.UpdateSession(*session)
+	}
+
+	tier, ok := m.tiers.byName[session.Tier]
+	if !ok {
+		if session.Tier != "" {
+			m.logger.Warn("Resolved tier is not configured", slog.String("tier", session.Tier))
+		}
+		return req, nil
+	}
+
+	if ok, retryAfter := m.tiers.allow(tier, identityKey, time.Now()); !ok {
+		return nil, &rateLimitError{err: ErrTierRateLimited, retryAfter: retryAfter}
+	}
+
+	return req.WithContext(context.WithValue(req.Context(), tierContextKey, tier)), nil
+}
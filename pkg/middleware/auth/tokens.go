@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+)
+
+// DefaultTokenTTL is the lifetime of minted tokens when no TTL is configured
+const DefaultTokenTTL = 5 * time.Minute
+
+const tokenContextKey contextKey = "token"
+
+// TokenSigner signs the JWTs minted for downstream services
+type TokenSigner interface {
+	// Algorithm returns the JWS algorithm of the signatures, e.g. "ES256"
+	Algorithm() string
+	// KeyID returns the key ID set in the kid header, empty omits it
+	KeyID() string
+	// Sign returns the JWS signature of the signing input
+	Sign(signingInput []byte) ([]byte, error)
+}
+
+// HS256Signer returns a signer of HMAC SHA-256 signatures, for downstream services sharing the key
+func HS256Signer(key []byte, keyID string) TokenSigner {
+	return &tokenSigner{alg: "HS256", keyID: keyID, sign: func(input []byte) ([]byte, error) {
+		mac := hmac.New(sha256.New, key)
+		mac.Write(input)
+		return mac.Sum(nil), nil
+	}}
+}
+
+// ES256Signer returns a signer of ECDSA P-256 signatures
+func ES256Signer(key *ecdsa.PrivateKey, keyID string) TokenSigner {
+	return &tokenSigner{alg: "ES256", keyID: keyID, sign: func(input []byte) ([]byte, error) {
+		hash := sha256.Sum256(input)
+		r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+		if err != nil {
+			return nil, err
+		}
+		return append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...), nil
+	}}
+}
+
+// EdDSASigner returns a signer of Ed25519 signatures
+func EdDSASigner(key ed25519.PrivateKey, keyID string) TokenSigner {
+	return &tokenSigner{alg: "EdDSA", keyID: keyID, sign: func(input []byte) ([]byte, error) {
+		return ed25519.Sign(key, input), nil
+	}}
+}
+
+type tokenSigner struct {
+	alg   string
+	keyID string
+	sign  func(input []byte) ([]byte, error)
+}
+
+func (s *tokenSigner) Algorithm() string                 { return s.alg }
+func (s *tokenSigner) KeyID() string                     { return s.keyID }
+func (s *tokenSigner) Sign(input []byte) ([]byte, error) { return s.sign(input) }
+
+// TokenClaimsFunc derives claims of minted tokens from the authentication result of the peer, e.g. roles from certificate fields
+type TokenClaimsFunc func(result AuthResult) map[string]any
+
+// TokenMinting mints a short-lived JWT for every authenticated request, so internal services which only understand JWTs
+// can be called on behalf of the peer without authenticating it again. The token carries the identity key of the peer
+// as subject, and is available to handlers with GetTokenFromContext.
+type TokenMinting struct {
+	// Signer signs the tokens
+	Signer TokenSigner
+	// Issuer is the iss claim, empty omits it
+	Issuer string
+	// Audience is the aud claim, empty omits it
+	Audience string
+	// TTL is the lifetime of the tokens, defaults to DefaultTokenTTL
+	TTL time.Duration
+	// Attributes maps claim names to peer attributes, AttributeIdentityKey or "certificate.<type>.<field>" as in ForwardAuth
+	Attributes map[string]string
+	// Claims derives further claims from the authentication result, the sub, iat, exp and jti claims cannot be overridden
+	Claims TokenClaimsFunc
+	// Header sets the token as bearer token in the header (e.g. "Authorization") of the request passed to the handler,
+	// so reverse proxies forward it, and of successful ForwardAuth responses, empty sets no header
+	Header string
+}
+
+func (c *TokenMinting) validate() error {
+	if c == nil {
+		return nil
+	}
+
+	if c.Signer == nil {
+		return fmt.Errorf("%w, signer is required", ErrInvalidTokenMinting)
+	}
+
+	for claim, attribute := range c.Attributes {
+		if !validAttribute(attribute) {
+			return fmt.Errorf("%w, unsupported attribute %q for claim %s", ErrInvalidTokenMinting, attribute, claim)
+		}
+	}
+
+	return nil
+}
+
+// GetTokenFromContext retrieves the token minted for the authenticated peer from the request context
+func GetTokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(tokenContextKey).(string)
+	return token, ok
+}
+
+// withToken mints the token of the authenticated peer, anonymous and unauthenticated requests get no token
+func (m *Middleware) withToken(req *http.Request) (*http.Request, error) {
+	if m.tokenMinting == nil || IsAnonymousFromContext(req.Context()) {
+		return req, nil
+	}
+
+	result := m.authorizationInput(req).Auth
+	if !result.Authenticated {
+		return req, nil
+	}
+
+	token, err := m.tokenMinting.mint(result, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to mint token, %w", err)
+	}
+
+	if m.tokenMinting.Header != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(m.tokenMinting.Header, "Bearer "+token)
+	}
+
+	return req.WithContext(context.WithValue(req.Context(), tokenContextKey, token)), nil
+}
+
+// mint returns the signed JWT of the authentication result
+func (c *TokenMinting) mint(result AuthResult, now time.Time) (string, error) {
+	ttl := c.TTL
+	if ttl <= 0 {
+		ttl = DefaultTokenTTL
+	}
+
+	claims := map[string]any{}
+	if c.Claims != nil {
+		maps.Copy(claims, c.Claims(result))
+	}
+
+	peerSession := &session.PeerSession{Certificates: result.Certificates}
+	for claim, attribute := range c.Attributes {
+		if value := resolveAttribute(attribute, result.IdentityKey, peerSession); value != "" {
+			claims[claim] = value
+		}
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	claims["sub"] = result.IdentityKey
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(ttl).Unix()
+	claims["jti"] = hex.EncodeToString(id)
+	if c.Issuer != "" {
+		claims["iss"] = c.Issuer
+	}
+	if c.Audience != "" {
+		claims["aud"] = c.Audience
+	}
+
+	header := map[string]string{"alg": c.Signer.Algorithm(), "typ": "JWT"}
+	if kid := c.Signer.KeyID(); kid != "" {
+		header["kid"] = kid
+	}
+
+	encodedHeader, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	encodedClaims, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	input := base64.RawURLEncoding.EncodeToString(encodedHeader) + "." + base64.RawURLEncoding.EncodeToString(encodedClaims)
+	signature, err := c.Signer.Sign([]byte(input))
+	if err != nil {
+		return "", err
+	}
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
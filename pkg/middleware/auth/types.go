@@ -1,26 +1,202 @@
 package auth
 
 import (
+	"context"
+	"io"
 	"log/slog"
 	"net/http"
+	"time"
 
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/sessionmanager"
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/chaintracker"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/defs"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/events"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/internal/faults"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
 )
 
 // Config configures the auth middleware
 type Config struct {
+	// Profile applies the defaults and guards of a deployment environment, e.g. ProfileProduction rejects mock wallets
+	// and report-only mode, New fails with ErrProfileViolation when the config violates the profile
+	Profile Profile
+	// StrictProduction makes New fail with ErrSessionManagerRequired or ErrWalletRequired instead of defaulting
+	// to the in-memory session manager or, in the development profile, to a mock wallet.
+	// Nil uses StrictProductionDefault, which enables it unless the binary is built with the dev build tag.
+	StrictProduction *bool
+	// Random is the entropy source of the key and nonces of the mock wallet of the development profile,
+	// e.g. a hardware RNG or a seeded source for deterministic tests. Nil uses crypto/rand.
+	Random                 io.Reader
 	Wallet                 wallet.WalletInterface
-	SessionManager         sessionmanager.SessionManagerInterface
+	SessionManager         session.Manager
 	AllowUnauthenticated   bool
 	Logger                 *slog.Logger
 	CertificatesToRequest  *transport.RequestedCertificateSet
 	OnCertificatesReceived func(
 		senderPublicKey string,
-		certs *[]wallet.VerifiableCertificate,
+		certs *[]certificates.VerifiableCertificate,
 		req *http.Request,
 		res http.ResponseWriter,
 		next func(),
 	)
+	// EncryptPayloads enables encryption of general message bodies for peers which request it during the handshake
+	EncryptPayloads bool
+	// PadPayloads enables padding of general message bodies to size buckets for peers which request it during the handshake,
+	// so the sizes of requests and responses of sensitive endpoints only reveal their bucket, see transport.PadPayload
+	PadPayloads bool
+	// RedactionPolicy declares which certificate fields are masked in logs and events, defaults to hashing every field with a random key
+	RedactionPolicy *transport.CertificateRedactionPolicy
+	// PaymentHints are advertised in the discovery document, when the server also uses the payment middleware
+	PaymentHints *PaymentHints
+	// ReplayWindow is the interval in which the request IDs remembered to reject replayed requests are pruned, the IDs
+	// are kept until their session ends. Defaults to 10 minutes.
+	ReplayWindow time.Duration
+	// RevocationTracker rejects certificates whose revocation outpoint was spent, nil disables the check
+	RevocationTracker chaintracker.Interface
+	// MinNonceSize is the minimum size of decoded peer nonces, shorter nonces are rejected, defaults to 16 bytes
+	MinNonceSize int
+	// IdempotencyKeyTTL enables the idempotency key extension and sets how long responses are cached for retries, zero disables it
+	IdempotencyKeyTTL time.Duration
+	// RoutePolicies overrides the authentication behaviour per route, keys are net/http ServeMux patterns
+	// such as "GET /items/{id}" or "/health", the most specific matching pattern applies
+	RoutePolicies map[string]RoutePolicy
+	// ForwardAuth declares the peer attributes passed to upstreams in the ForwardAuth mode
+	ForwardAuth ForwardAuthConfig
+	// AccessLogger enables the access log, one structured line is logged at info level per request with the identity,
+	// route, auth outcome, error code, latencies of the verify, handler and sign stages, bytes received and sent,
+	// and the wallet operations performed for the request
+	AccessLogger *slog.Logger
+	// WalletTimeouts limits how long CreateNonce, CreateSignature and VerifySignature calls of the wallet may take,
+	// messages whose wallet operation times out are rejected with 503 and ERR_WALLET_TIMEOUT
+	WalletTimeouts transport.WalletTimeouts
+	// ReadTimeouts limits how long peers may take to send the headers and the body of authenticated requests,
+	// independent of the timeouts of the http.Server, late requests are rejected with 408 and ERR_READ_TIMEOUT
+	ReadTimeouts transport.ReadTimeouts
+	// OriginBinding lists the origins of the server general requests may be bound to (see client.Config.BindOrigin),
+	// so signatures of a peer for another server sharing the identity key are not accepted. Required rejects unbound requests.
+	OriginBinding transport.OriginBinding
+	// PrivilegedKeys makes the middleware use the privileged keyring of the wallet for its identity key,
+	// handshake and response signatures and payload encryption, for wallets which distinguish privileged operations
+	PrivilegedKeys transport.PrivilegedKeys
+	// ServerInfoHeader adds the x-bsv-auth-server header with ServerInfo to general responses and includes it
+	// in the signed payload, peers verifying the responses have to include the header in their payload as well
+	ServerInfoHeader bool
+	// AnonymousAccess enables anonymous sessions for peers authenticating with the anyone key,
+	// without it the anyone key is handled like any other identity
+	AnonymousAccess *AnonymousPolicy
+	// AccountResolver maps identity keys of authenticated peers to accounts of the application,
+	// the account is available to handlers with GetAccountFromContext
+	AccountResolver AccountResolver
+	// AccountCacheTTL is the time for which resolved accounts are cached, defaults to DefaultAccountCacheTTL,
+	// a negative TTL disables caching
+	AccountCacheTTL time.Duration
+	// AccountNegativeCacheTTL is the time for which identities without an account are cached,
+	// defaults to DefaultAccountNegativeCacheTTL, a negative TTL disables caching
+	AccountNegativeCacheTTL time.Duration
+	// Tiers derives quota tiers of authenticated peers from their certificates, the tier limits the requests of the peer
+	// and is available to handlers and the payment middleware with GetTierFromContext
+	Tiers *TierPolicy
+	// Authorizer is consulted after authentication with the AuthResult and the attributes of every request passed
+	// to the handler, denied requests are rejected with 403 and the reason of the policy, e.g. OPAAuthorizer
+	Authorizer Authorizer
+	// X509Bridge adds the verified X.509 client certificate of mTLS connections to the certificates peers send,
+	// so OnCertificatesReceived, tiers and the Authorizer can combine PKI attributes with the identity key of the peer
+	X509Bridge *transport.X509Bridge
+	// CredentialAdapter accepts W3C verifiable credentials sent as certificates of the VerifiableCredentialType,
+	// their signatures are verified with the keys of the issuers resolved by its DID resolver
+	CredentialAdapter *transport.CredentialAdapter
+	// StrictDisclosure requires the keyring of every certificate to reveal exactly the fields CertificatesToRequest
+	// requests from its type, certificates disclosing fewer or more fields are rejected with ERR_CERTIFICATE_UNDER_DISCLOSED
+	// or ERR_CERTIFICATE_OVER_DISCLOSED and the disclosure of the error response lists the offending fields
+	StrictDisclosure bool
+	// OnInitialResponse is called with the session created by the handshake and the initialResponse before it is sent,
+	// so applications can attach negotiated data such as feature flags or tenant hints with AuthMessage.SetExtension.
+	// Only the extensions are taken from the message, they are not covered by the signature of the initialResponse.
+	OnInitialResponse func(ctx context.Context, session session.PeerSession, msg *transport.AuthMessage)
+	// VerificationReports publishes a machine readable report of every certificate exchange to the application
+	// and the audit log, with the requested, disclosed and decrypted fields and the checks which passed
+	VerificationReports *VerificationReportPolicy
+	// TokenMinting mints a short-lived JWT for authenticated requests, for downstream services which only understand JWTs
+	TokenMinting *TokenMinting
+	// Routes publishes the auth, certificate and payment requirements declared by the routes in an OpenAPI document
+	// served at OpenAPIPath, so API consumers and client generators know what each endpoint demands
+	Routes *RouteRegistry
+	// SelfTest makes New derive the identity key, create and verify a nonce and a signature with the wallet
+	// and check the payload encoding against golden bytes, New fails with ErrSelfTestFailed when any step fails
+	SelfTest bool
+	// Telemetry aggregates the handshakes and general requests into anonymized session snapshots exported
+	// to security analytics, the snapshots are exported by SessionTelemetry.Run
+	Telemetry *SessionTelemetry
+	// Events is the bus the middleware and its transport publish their auth and session events on, so metrics,
+	// audit logs, webhooks and other consumers subscribe to it instead of being configured here. Telemetry and
+	// Anomalies are subscribed to it as well. Defaults to a bus of the middleware redacting the certificates of session
+	// events with the RedactionPolicy, available with Middleware.Events. Create shared buses with EventRedactor.
+	Events *events.Bus
+	// Anomalies tracks streaks of verification failures per identity key and peer address
+	// and locks out keys reaching the thresholds of the policy for a cooldown
+	Anomalies *AnomalyPolicy
+	// Blocking reports peer addresses failing verifications or flooding handshakes to an external blocker such as
+	// fail2ban or CrowdSec, and enforces the blocklist the blocker feeds back. It is subscribed to Events.
+	Blocking *BlockPolicy
+	// KnownPeers pre-registers peers such as the services of a mesh: their handshakes are authenticated without
+	// certificate requests and their identity keys are exempt from the identity lockouts of Anomalies.
+	// The set can be changed while the server runs with Middleware.KnownPeers or the KnownPeersHandler admin API.
+	KnownPeers []transport.KnownPeer
+	// FeatureFlags gate experimental protocol features, e.g. transport.FlagHeartbeat, for a percentage of the identities
+	// and a cohort of identity keys. The capability of a flag is only negotiated with the identities it is enabled for,
+	// flags without a rule are enabled. The rules can be changed while the server runs with Middleware.FeatureFlags
+	// or the FeatureFlagsHandler admin API, they apply to the sessions of the following handshakes.
+	FeatureFlags map[transport.Flag]transport.FlagRule
+	// TrustRegistry enables allowlist exchanges with the federation partners registered in it: partners send their
+	// service identity keys and certificate policy in a signed AllowlistExchange message and receive those of the
+	// deployment. Partners and the service keys they pinned are treated like known peers. Nil rejects allowlist exchanges.
+	TrustRegistry *transport.TrustRegistry
+	// RevocationStore is the authoritative store of the revocation list, which cuts off revoked identity keys and
+	// sessions on their next request. Revocations are made with Middleware.Revocations or the RevocationsHandler
+	// admin API, they are kept in memory when nil. Deployments of several instances sharing a store reload the list
+	// of each instance periodically, see transport.RevocationList.Reload.
+	RevocationStore transport.RevocationStore
+	// Delegations accepts clients authenticating with an agent key on behalf of a principal identity: the agent presents
+	// a transport.Delegation signed by the principal in the handshake and its requests carry the identity key of the
+	// principal along, see GetPrincipalFromContext. Presented delegations are ignored when nil.
+	Delegations *transport.DelegationPolicy
+	// PathNormalization is the normalization of the path signed by clients, see authcore.PathNormalization.
+	// authcore.PathLenient tolerates proxies collapsing slashes, resolving dot segments or dropping trailing slashes,
+	// clients have to sign with the same normalization, signatures over the path as received are accepted as well.
+	PathNormalization authcore.PathNormalization
+	// WebSocketOrigins lists the origins of the pages allowed to open WebSockets, see WebSocketHandler.
+	// The Origin header of upgrades is signed by clients, so a socket opened by the page of a foreign origin cannot
+	// ride on a valid session. Upgrades of every origin are accepted when empty.
+	WebSocketOrigins []string
+	// CoSigner returns the wallet of the tenant identity a response is co-signed by, e.g. the merchant a marketplace
+	// responds for. Co-signed responses carry the identity key and signature of the co-signer next to the signature
+	// of the server, see utils.CoSignerIdentityKeyHeader. Responses are only signed by the server when it returns nil.
+	CoSigner func(req *http.Request) wallet.WalletInterface
+	// CertificateCompressions are the compressions accepted for the certificates of certificate responses, e.g.
+	// transport.GzipCompression, negotiated with peers offering them. Compressed certificates are rejected when empty.
+	CertificateCompressions []transport.CertificateCompression
+	// MaxCertificatesSize limits the size of decompressed certificates, so decompression bombs are rejected
+	// before they are held in memory, defaults to transport.DefaultMaxCertificatesSize
+	MaxCertificatesSize int
+	// Tarpit holds requests of abusive identity keys and answers them with fake responses
+	// instead of rejecting them, to slow down automated scanners
+	Tarpit *TarpitPolicy
+	// ReportOnly runs signature and certificate verification without enforcing it, e.g. to canary the middleware
+	// on production traffic: general requests which fail verification are logged with the error code they would be
+	// rejected with and passed to the handler unauthenticated, and do not count towards anomaly lockouts.
+	// Handshake messages are still rejected, they are never sent by peers which do not authenticate.
+	// Rollout enforces verification gradually for a share of the identities.
+	ReportOnly bool
+	// Rollout enforces verification in report-only mode for a percentage of the identities and a cohort
+	// of identity keys, requests of the other identities are handled as in report-only mode
+	Rollout *EnforcementRollout
+	// Logging configures levels and debug sampling per subsystem, e.g. debug logs of certificates only
+	// while general requests are logged at the level of Logger
+	Logging defs.LogConfig
+
+	// faults are injected into the wallet, session manager and revocation store by the chaos tests of the module
+	faults *faults.Injector
 }
@@ -0,0 +1,172 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+)
+
+// Peer attributes which can be injected into upstream headers
+const (
+	// AttributeIdentityKey is the identity key of the authenticated peer
+	AttributeIdentityKey = "identityKey"
+	// AttributeCertificatePrefix prefixes certificate field attributes in the form "certificate.<type>.<field>",
+	// the value is taken from the accepted certificate of the given type
+	AttributeCertificatePrefix = "certificate."
+)
+
+// Headers carrying the HMAC of the upstream headers
+const (
+	UpstreamSignatureHeader = "X-Bsv-Upstream-Signature"
+	UpstreamTimestampHeader = "X-Bsv-Upstream-Timestamp"
+)
+
+// Errors returned by VerifyUpstreamHeaders
+var (
+	ErrInvalidUpstreamSignature = errors.New("invalid upstream headers signature")
+	ErrExpiredUpstreamSignature = errors.New("upstream headers signature expired")
+)
+
+// UpstreamHeader declares a header set on successful ForwardAuth responses and the peer attribute injected into it
+type UpstreamHeader struct {
+	Name      string
+	Attribute string
+}
+
+// ForwardAuthConfig configures the headers passed to upstreams in the ForwardAuth mode.
+// The proxy has to copy the declared headers (and the signature headers) to the upstream request,
+// e.g. with Traefik authResponseHeaders or NGINX auth_request_set.
+type ForwardAuthConfig struct {
+	// Headers declares the upstream headers, defaults to the identity key in X-Bsv-Identity-Key
+	Headers []UpstreamHeader
+	// HMACKey enables signing of the upstream headers, so the upstream can verify they were set by the middleware
+	HMACKey []byte
+}
+
+func (c ForwardAuthConfig) validate() error {
+	for _, header := range c.Headers {
+		if header.Name == "" {
+			return fmt.Errorf("%w, name is required", ErrInvalidUpstreamHeader)
+		}
+
+		if !validAttribute(header.Attribute) {
+			return fmt.Errorf("%w, unsupported attribute %q for upstream header %s", ErrInvalidUpstreamHeader, header.Attribute, header.Name)
+		}
+	}
+
+	return nil
+}
+
+// validAttribute reports whether the attribute is AttributeIdentityKey or a certificate field attribute
+func validAttribute(attribute string) bool {
+	if attribute == AttributeIdentityKey {
+		return true
+	}
+
+	certType, field, ok := strings.Cut(strings.TrimPrefix(attribute, AttributeCertificatePrefix), ".")
+	return strings.HasPrefix(attribute, AttributeCertificatePrefix) && ok && certType != "" && field != ""
+}
+
+func (c ForwardAuthConfig) headers() []UpstreamHeader {
+	if len(c.Headers) == 0 {
+		return []UpstreamHeader{{Name: ForwardAuthIdentityKeyHeader, Attribute: AttributeIdentityKey}}
+	}
+	return c.Headers
+}
+
+// VerifyUpstreamHeaders verifies the HMAC of the upstream headers, it is meant to be used by upstreams behind the proxy
+func (c ForwardAuthConfig) VerifyUpstreamHeaders(header http.Header, maxAge time.Duration) error {
+	timestamp, err := strconv.ParseInt(header.Get(UpstreamTimestampHeader), 10, 64)
+	if err != nil {
+		return ErrInvalidUpstreamSignature
+	}
+
+	signature, err := hex.DecodeString(header.Get(UpstreamSignatureHeader))
+	if err != nil {
+		return ErrInvalidUpstreamSignature
+	}
+
+	values := make([]string, 0, len(c.headers()))
+	for _, h := range c.headers() {
+		values = append(values, header.Get(h.Name))
+	}
+
+	if !hmac.Equal(signature, c.sign(timestamp, values)) {
+		return ErrInvalidUpstreamSignature
+	}
+
+	if maxAge > 0 && time.Since(time.Unix(timestamp, 0)) > maxAge {
+		return ErrExpiredUpstreamSignature
+	}
+
+	return nil
+}
+
+// setUpstreamHeaders sets the declared upstream headers and their signature, on the ForwardAuth response
+// or on the request passed to the RPC handler
+func (c ForwardAuthConfig) setUpstreamHeaders(header http.Header, identityKey string, peerSession *session.PeerSession) {
+	headers := c.headers()
+	values := make([]string, 0, len(headers))
+	for _, h := range headers {
+		value := resolveAttribute(h.Attribute, identityKey, peerSession)
+		values = append(values, value)
+		if value != "" {
+			header.Set(h.Name, value)
+		}
+	}
+
+	if len(c.HMACKey) == 0 {
+		return
+	}
+
+	timestamp := time.Now().Unix()
+	header.Set(UpstreamTimestampHeader, strconv.FormatInt(timestamp, 10))
+	header.Set(UpstreamSignatureHeader, hex.EncodeToString(c.sign(timestamp, values)))
+}
+
+// sign computes the HMAC over the timestamp and the upstream header names and values, in the declared order
+func (c ForwardAuthConfig) sign(timestamp int64, values []string) []byte {
+	mac := hmac.New(sha256.New, c.HMACKey)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "\n"))
+	for i, h := range c.headers() {
+		mac.Write([]byte(strings.ToLower(h.Name) + ":" + values[i] + "\n"))
+	}
+	return mac.Sum(nil)
+}
+
+func resolveAttribute(attribute, identityKey string, peerSession *session.PeerSession) string {
+	if attribute == AttributeIdentityKey {
+		return identityKey
+	}
+
+	if peerSession == nil {
+		return ""
+	}
+
+	certType, field, _ := strings.Cut(strings.TrimPrefix(attribute, AttributeCertificatePrefix), ".")
+	for _, cert := range peerSession.Certificates {
+		if cert.Type != certType {
+			continue
+		}
+
+		if cert.DecryptedFields != nil {
+			if value, ok := (*cert.DecryptedFields)[field]; ok {
+				return value
+			}
+		}
+
+		if value, ok := cert.Fields[field]; ok {
+			return fmt.Sprint(value)
+		}
+	}
+
+	return ""
+}
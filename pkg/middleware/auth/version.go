@@ -0,0 +1,38 @@
+package auth
+
+import "runtime/debug"
+
+// ImplementationName identifies this middleware in the x-bsv-auth-server header
+const ImplementationName = "go-bsv-middleware"
+
+const modulePath = "github.com/bsv-blockchain/go-bsv-middleware"
+
+// Version returns the version of the middleware module the binary was built with,
+// or "(devel)" when it is built from a local checkout
+func Version() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(devel)"
+	}
+
+	if info.Main.Path == modulePath && info.Main.Version != "" {
+		return info.Main.Version
+	}
+
+	for _, dep := range info.Deps {
+		if dep.Path != modulePath {
+			continue
+		}
+		if dep.Replace != nil && dep.Replace.Version != "" {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+
+	return "(devel)"
+}
+
+// ServerInfo returns the value of the x-bsv-auth-server header, the implementation name and version
+func ServerInfo() string {
+	return ImplementationName + "/" + Version()
+}
@@ -0,0 +1,18 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// GetWalletCallsFromContext retrieves the number of wallet operations performed for the request so far.
+// Handlers see the operations of the verification of the request, the access log and telemetry
+// also count those of signing the response.
+func GetWalletCallsFromContext(ctx context.Context) (transport.WalletCalls, bool) {
+	counter := transport.WalletCallCounterFromContext(ctx)
+	if counter == nil {
+		return transport.WalletCalls{}, false
+	}
+	return counter.Calls(), true
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"bufio"
+	"encoding/json"
+	"log/slog"
+	"net"
+	"net/http"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/websocket"
+)
+
+// UpgradeWebSocket upgrades the connection of the request to WebSocket. Behind the middleware the 101 Switching Protocols
+// response is signed like every response of the session, and the context of the connection carries the identity,
+// certificates and the other values the middleware added to the request, so the message loop knows its peer.
+// Messages are not signed individually, the socket is bound to the session by the signed upgrade, serve it over TLS.
+// Heartbeats of the peer are answered by the connection and not returned by ReadMessage.
+func UpgradeWebSocket(w http.ResponseWriter, req *http.Request) (*websocket.Conn, error) {
+	socket, err := websocket.Upgrade(w, req)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the upgrade error is returned as is
+	}
+
+	if recorder, ok := w.(*responseRecorder); ok && recorder.heartbeat != nil {
+		socket.SetInterceptor(heartbeatInterceptor(socket, req, recorder.heartbeat))
+	}
+	return socket, nil
+}
+
+// heartbeatInterceptor answers the heartbeats of the peer of the socket, refreshing its session.
+// The socket is bound to the session, so it is closed when a heartbeat of its peer fails.
+func heartbeatInterceptor(socket *websocket.Conn, req *http.Request, heartbeat func(msg *transport.AuthMessage) (*transport.AuthMessage, error)) func(int, []byte) bool {
+	identityKey, _ := GetIdentityFromContext(req.Context())
+
+	return func(messageType int, message []byte) bool {
+		if messageType != websocket.TextMessage {
+			return false
+		}
+		msg, ok := transport.ParseHeartbeat(message)
+		if !ok {
+			return false
+		}
+
+		ack, err := heartbeat(msg)
+		if err == nil && msg.IdentityKey != identityKey {
+			err = transport.ErrIdentityKeyMismatch
+		}
+		if err != nil {
+			_ = socket.Close()
+			return true
+		}
+
+		data, err := json.Marshal(ack)
+		if err != nil {
+			_ = socket.Close()
+			return true
+		}
+		_ = socket.WriteMessage(websocket.TextMessage, data)
+		return true
+	}
+}
+
+// handleHeartbeat refreshes the session of a heartbeat received over an upgraded connection
+func (m *Middleware) handleHeartbeat(msg *transport.AuthMessage) (*transport.AuthMessage, error) {
+	ack, err := m.transport.HandleHeartbeat(msg)
+	if err != nil {
+		m.logger.Warn("Rejected heartbeat", slog.String("identityKey", msg.IdentityKey), slog.String("error", err.Error()))
+		return nil, err
+	}
+	return ack, nil
+}
+
+// WebSocketHandler returns a handler which upgrades requests to WebSocket and serves the connection,
+// requests which do not ask for an upgrade are rejected with 426 Upgrade Required
+func WebSocketHandler(serve func(conn *websocket.Conn)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !websocket.IsUpgradeRequest(req) {
+			w.Header().Set("Upgrade", "websocket")
+			http.Error(w, websocket.ErrNotUpgrade.Error(), http.StatusUpgradeRequired)
+			return
+		}
+
+		conn, err := UpgradeWebSocket(w, req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		serve(conn)
+	})
+}
+
+// Hijack signs the 101 Switching Protocols response of an upgrade and takes over the connection,
+// the middleware does not write a response once the handler returns
+func (r *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if r.signSwitch != nil {
+		if err := r.signSwitch(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	conn, brw, err := http.NewResponseController(r.ResponseWriter).Hijack()
+	if err != nil {
+		return nil, nil, err //nolint:wrapcheck // the error of the underlying writer is returned as is
+	}
+	r.statusCode = http.StatusSwitchingProtocols
+	r.hijacked = true
+	return conn, brw, nil
+}
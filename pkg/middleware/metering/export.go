@@ -0,0 +1,138 @@
+package metering
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/httpclient"
+)
+
+// CSVExporter writes the usage as CSV rows, the header is written before the first export
+type CSVExporter struct {
+	w             io.Writer
+	mu            sync.Mutex
+	headerWritten bool
+}
+
+// NewCSVExporter creates a CSV exporter writing to w
+func NewCSVExporter(w io.Writer) *CSVExporter {
+	return &CSVExporter{w: w}
+}
+
+// Export writes one row per identity key and period
+func (e *CSVExporter) Export(_ context.Context, usage []Usage) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	writer := csv.NewWriter(e.w)
+	if !e.headerWritten {
+		if err := writer.Write([]string{"identity_key", "period_start", "period_end", "requests", "satoshis_paid"}); err != nil {
+			return fmt.Errorf("failed to write CSV header, %w", err)
+		}
+		e.headerWritten = true
+	}
+
+	for _, u := range usage {
+		record := []string{
+			u.IdentityKey,
+			u.PeriodStart.Format(time.RFC3339),
+			u.PeriodEnd.Format(time.RFC3339),
+			strconv.FormatInt(u.Requests, 10),
+			strconv.FormatInt(u.SatoshisPaid, 10),
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV record, %w", err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to flush CSV records, %w", err)
+	}
+	return nil
+}
+
+// WebhookExporter posts the usage as a JSON array to a URL
+type WebhookExporter struct {
+	// URL receives the usage
+	URL string
+	// Header is added to the webhook requests, e.g. for authorization
+	Header http.Header
+	// Client is the HTTP client used for the webhook requests, defaults to the shared retrying client of httpclient.Default
+	Client *http.Client
+}
+
+// Export posts the usage, any non-2xx response fails the export
+func (e *WebhookExporter) Export(ctx context.Context, usage []Usage) error {
+	body, err := json.Marshal(usage)
+	if err != nil {
+		return fmt.Errorf("failed to encode usage, %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request, %w", err)
+	}
+	for key, values := range e.Header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := e.Client
+	if client == nil {
+		client = httpclient.Default()
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook request, %w", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("webhook responded with status %s", resp.Status)
+	}
+	return nil
+}
+
+// PrometheusHandler serves the lifetime totals per identity key in the Prometheus text exposition format.
+// Every identity key is a separate label value up to Options.MaxIdentities, further identity keys share the
+// OtherIdentities label value.
+func (m *Meter) PrometheusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		m.mu.Lock()
+		identityKeys := make([]string, 0, len(m.totals))
+		for identityKey := range m.totals {
+			identityKeys = append(identityKeys, identityKey)
+		}
+		sort.Strings(identityKeys)
+
+		var b strings.Builder
+		b.WriteString("# HELP bsv_metering_requests_total Requests handled per identity key.\n")
+		b.WriteString("# TYPE bsv_metering_requests_total counter\n")
+		for _, identityKey := range identityKeys {
+			fmt.Fprintf(&b, "bsv_metering_requests_total{identity_key=%q} %d\n", identityKey, m.totals[identityKey].requests)
+		}
+		b.WriteString("# HELP bsv_metering_satoshis_paid_total Satoshis paid per identity key.\n")
+		b.WriteString("# TYPE bsv_metering_satoshis_paid_total counter\n")
+		for _, identityKey := range identityKeys {
+			fmt.Fprintf(&b, "bsv_metering_satoshis_paid_total{identity_key=%q} %d\n", identityKey, m.totals[identityKey].satoshisPaid)
+		}
+		m.mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = io.WriteString(w, b.String())
+	})
+}
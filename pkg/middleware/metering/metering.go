@@ -0,0 +1,266 @@
+package metering
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/events"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/internal/logging"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/payment"
+)
+
+// DefaultPeriod is the metering period used when none is configured
+const DefaultPeriod = time.Hour
+
+// DefaultMaxIdentities bounds the identity keys with lifetime totals when no bound is configured
+const DefaultMaxIdentities = 10000
+
+// OtherIdentities is the identity key label of the lifetime totals of identity keys beyond the bound,
+// identity keys are free to create, so a peer could grow the totals without limit otherwise
+const OtherIdentities = "other"
+
+// Options configures the metering middleware
+type Options struct {
+	// Period is the length of the metering periods, defaults to DefaultPeriod
+	Period time.Duration
+	// Exporters receive the usage of closed periods on Flush
+	Exporters []Exporter
+	// Logger is used for export failures, defaults to the package logger
+	Logger *slog.Logger
+	// MaxIdentities bounds the identity keys with lifetime totals served by PrometheusHandler, defaults to
+	// DefaultMaxIdentities. Further identity keys are totaled under OtherIdentities, their usage per period is exported as usual.
+	MaxIdentities int
+}
+
+// Usage is the usage of a single identity key in a metering period
+type Usage struct {
+	IdentityKey  string    `json:"identityKey"`
+	PeriodStart  time.Time `json:"periodStart"`
+	PeriodEnd    time.Time `json:"periodEnd"`
+	Requests     int64     `json:"requests"`
+	SatoshisPaid int64     `json:"satoshisPaid"`
+}
+
+// Exporter exports the usage of closed metering periods, e.g. for billing reconciliation
+type Exporter interface {
+	Export(ctx context.Context, usage []Usage) error
+}
+
+// ExporterFunc adapts an ordinary function to the Exporter interface
+type ExporterFunc func(ctx context.Context, usage []Usage) error
+
+// Export calls f(ctx, usage)
+func (f ExporterFunc) Export(ctx context.Context, usage []Usage) error {
+	return f(ctx, usage)
+}
+
+type usageKey struct {
+	identityKey string
+	periodStart int64
+}
+
+type totals struct {
+	requests     int64
+	satoshisPaid int64
+}
+
+// Meter tracks request counts and satoshis paid per identity key and period
+type Meter struct {
+	period        time.Duration
+	exporters     []Exporter
+	logger        *slog.Logger
+	maxIdentities int
+
+	mu      sync.Mutex
+	periods map[usageKey]*totals
+	totals  map[string]*totals
+}
+
+// New creates a new meter
+func New(opts Options) (*Meter, error) {
+	if opts.Period < 0 {
+		return nil, errors.New("metering period must not be negative")
+	}
+	if opts.Period == 0 {
+		opts.Period = DefaultPeriod
+	}
+	if opts.MaxIdentities < 0 {
+		return nil, errors.New("metering max identities must not be negative")
+	}
+	if opts.MaxIdentities == 0 {
+		opts.MaxIdentities = DefaultMaxIdentities
+	}
+
+	return &Meter{
+		period:        opts.Period,
+		exporters:     opts.Exporters,
+		logger:        logging.Child(opts.Logger, "metering-middleware"),
+		maxIdentities: opts.MaxIdentities,
+		periods:       make(map[usageKey]*totals),
+		totals:        make(map[string]*totals),
+	}, nil
+}
+
+// Handler returns a middleware recording the requests handled by next.
+// It has to be executed after the auth middleware, and after the payment middleware to record the satoshis paid.
+// Requests without an authenticated identity are not recorded.
+func (m *Meter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+
+		identityKey, ok := auth.GetIdentityFromContext(r.Context())
+		if !ok || identityKey == "" {
+			return
+		}
+
+		var satoshis int
+		if info, ok := payment.GetPaymentInfoFromContext(r.Context()); ok {
+			satoshis = info.SatoshisPaid
+		}
+
+		m.Record(identityKey, satoshis)
+	})
+}
+
+// Subscribe records the authenticated requests and the accepted payments published on the bus until the returned
+// function is called, in place of Handler. The bus is usually that of the auth middleware, passed to the payment
+// middleware as well, see auth.Middleware.Events.
+func (m *Meter) Subscribe(bus *events.Bus) (unsubscribe func()) {
+	unsubscribeAuth := bus.Subscribe(events.TopicAuth, func(_ context.Context, event events.Event) {
+		if data, ok := event.Data.(auth.RequestEvent); ok && event.Type == events.TypeRequest &&
+			data.Outcome == auth.AccessOutcomeAuthenticated && event.IdentityKey != "" {
+			m.add(event.IdentityKey, 1, 0)
+		}
+	})
+	unsubscribePayment := bus.Subscribe(events.TopicPayment, func(_ context.Context, event events.Event) {
+		if info, ok := event.Data.(payment.PaymentInfo); ok && event.Type == events.TypePaymentAccepted && event.IdentityKey != "" {
+			m.add(event.IdentityKey, 0, info.SatoshisPaid)
+		}
+	})
+
+	return func() {
+		unsubscribeAuth()
+		unsubscribePayment()
+	}
+}
+
+// Record records a request of the identity key paying the satoshis in the current period
+func (m *Meter) Record(identityKey string, satoshisPaid int) {
+	m.add(identityKey, 1, satoshisPaid)
+}
+
+func (m *Meter) add(identityKey string, requests int64, satoshisPaid int) {
+	key := usageKey{identityKey: identityKey, periodStart: time.Now().Truncate(m.period).UnixNano()}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	totalsKey := identityKey
+	if _, ok := m.totals[identityKey]; !ok && len(m.totals) >= m.maxIdentities {
+		totalsKey = OtherIdentities
+	}
+
+	for _, t := range []*totals{entry(m.periods, key), entry(m.totals, totalsKey)} {
+		t.requests += requests
+		t.satoshisPaid += int64(satoshisPaid)
+	}
+}
+
+// Usage returns the usage of all periods which were not flushed yet, ordered by period and identity key
+func (m *Meter) Usage() []Usage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	usage := make([]Usage, 0, len(m.periods))
+	for key, t := range m.periods {
+		usage = append(usage, m.usage(key, t))
+	}
+	sortUsage(usage)
+
+	return usage
+}
+
+// Flush exports the usage of closed periods to the exporters and drops it.
+// The usage is kept for the next flush when an exporter fails.
+func (m *Meter) Flush(ctx context.Context) error {
+	currentPeriod := time.Now().Truncate(m.period).UnixNano()
+
+	m.mu.Lock()
+	closed := make([]Usage, 0)
+	for key, t := range m.periods {
+		if key.periodStart < currentPeriod {
+			closed = append(closed, m.usage(key, t))
+		}
+	}
+	m.mu.Unlock()
+
+	if len(closed) == 0 {
+		return nil
+	}
+	sortUsage(closed)
+
+	for _, exporter := range m.exporters {
+		if err := exporter.Export(ctx, closed); err != nil {
+			return fmt.Errorf("failed to export usage, %w", err)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, u := range closed {
+		delete(m.periods, usageKey{identityKey: u.IdentityKey, periodStart: u.PeriodStart.UnixNano()})
+	}
+
+	return nil
+}
+
+// Run flushes the closed periods once per period until the context is done
+func (m *Meter) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.period)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.Flush(ctx); err != nil {
+				m.logger.Error("Failed to flush usage", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+func entry[K comparable](entries map[K]*totals, key K) *totals {
+	if entries[key] == nil {
+		entries[key] = &totals{}
+	}
+	return entries[key]
+}
+
+func (m *Meter) usage(key usageKey, t *totals) Usage {
+	start := time.Unix(0, key.periodStart).UTC()
+	return Usage{
+		IdentityKey:  key.identityKey,
+		PeriodStart:  start,
+		PeriodEnd:    start.Add(m.period),
+		Requests:     t.requests,
+		SatoshisPaid: t.satoshisPaid,
+	}
+}
+
+func sortUsage(usage []Usage) {
+	sort.Slice(usage, func(i, j int) bool {
+		if !usage[i].PeriodStart.Equal(usage[j].PeriodStart) {
+			return usage[i].PeriodStart.Before(usage[j].PeriodStart)
+		}
+		return usage[i].IdentityKey < usage[j].IdentityKey
+	})
+}
@@ -0,0 +1,229 @@
+package metering_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/events"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/metering"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/payment"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	alice = "02aaaa"
+	bob   = "02bbbb"
+)
+
+func serve(t *testing.T, meter *metering.Meter, identityKey string, satoshisPaid int) {
+	t.Helper()
+
+	handler := meter.Handler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx := req.Context()
+	if identityKey != "" {
+		ctx = context.WithValue(ctx, transport.IdentityKey, identityKey)
+	}
+	if satoshisPaid > 0 {
+		ctx = context.WithValue(ctx, payment.PaymentKey, &payment.PaymentInfo{SatoshisPaid: satoshisPaid, Accepted: true})
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(ctx))
+}
+
+func TestMeter_Handler(t *testing.T) {
+	// given
+	meter, err := metering.New(metering.Options{})
+	require.NoError(t, err)
+
+	// when
+	serve(t, meter, alice, 100)
+	serve(t, meter, alice, 0)
+	serve(t, meter, bob, 50)
+	serve(t, meter, "", 0)
+
+	// then
+	usage := meter.Usage()
+	require.Len(t, usage, 2)
+	require.Equal(t, alice, usage[0].IdentityKey)
+	require.Equal(t, int64(2), usage[0].Requests)
+	require.Equal(t, int64(100), usage[0].SatoshisPaid)
+	require.Equal(t, bob, usage[1].IdentityKey)
+	require.Equal(t, int64(1), usage[1].Requests)
+	require.Equal(t, int64(50), usage[1].SatoshisPaid)
+	require.Equal(t, metering.DefaultPeriod, usage[0].PeriodEnd.Sub(usage[0].PeriodStart))
+}
+
+func TestMeter_Subscribe(t *testing.T) {
+	// given
+	meter, err := metering.New(metering.Options{})
+	require.NoError(t, err)
+	bus := events.NewBus(nil)
+	unsubscribe := meter.Subscribe(bus)
+
+	request := func(identityKey, outcome string) events.Event {
+		return events.Event{Topic: events.TopicAuth, Type: events.TypeRequest, IdentityKey: identityKey,
+			Data: auth.RequestEvent{Outcome: outcome, IdentityKey: identityKey}}
+	}
+	paid := func(identityKey string, satoshis int) events.Event {
+		return events.Event{Topic: events.TopicPayment, Type: events.TypePaymentAccepted, IdentityKey: identityKey,
+			Data: payment.PaymentInfo{SatoshisPaid: satoshis, Accepted: true}}
+	}
+
+	// when
+	bus.Publish(context.Background(), request(alice, auth.AccessOutcomeAuthenticated))
+	bus.Publish(context.Background(), paid(alice, 100))
+	bus.Publish(context.Background(), request(bob, auth.AccessOutcomeRejected))
+	bus.Publish(context.Background(), request("", auth.AccessOutcomeHandshake))
+	unsubscribe()
+	bus.Publish(context.Background(), request(alice, auth.AccessOutcomeAuthenticated))
+
+	// then
+	usage := meter.Usage()
+	require.Len(t, usage, 1)
+	require.Equal(t, alice, usage[0].IdentityKey)
+	require.Equal(t, int64(1), usage[0].Requests)
+	require.Equal(t, int64(100), usage[0].SatoshisPaid)
+}
+
+func TestMeter_Flush(t *testing.T) {
+	t.Run("exports closed periods as CSV and to webhook", func(t *testing.T) {
+		// given
+		var received []metering.Usage
+		webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		}))
+		defer webhook.Close()
+
+		var csvOutput bytes.Buffer
+		meter, err := metering.New(metering.Options{
+			Period: 10 * time.Millisecond,
+			Exporters: []metering.Exporter{
+				metering.NewCSVExporter(&csvOutput),
+				&metering.WebhookExporter{URL: webhook.URL, Header: http.Header{"Authorization": {"Bearer token"}}},
+			},
+		})
+		require.NoError(t, err)
+
+		serve(t, meter, alice, 100)
+		time.Sleep(20 * time.Millisecond)
+
+		// when
+		err = meter.Flush(context.Background())
+
+		// then
+		require.NoError(t, err)
+		require.Empty(t, meter.Usage())
+
+		lines := strings.Split(strings.TrimSpace(csvOutput.String()), "\n")
+		require.Len(t, lines, 2)
+		require.Equal(t, "identity_key,period_start,period_end,requests,satoshis_paid", lines[0])
+		require.True(t, strings.HasPrefix(lines[1], alice+","))
+		require.True(t, strings.HasSuffix(lines[1], ",1,100"))
+
+		require.Len(t, received, 1)
+		require.Equal(t, alice, received[0].IdentityKey)
+		require.Equal(t, int64(100), received[0].SatoshisPaid)
+	})
+
+	t.Run("keeps current period", func(t *testing.T) {
+		// given
+		var exported bool
+		meter, err := metering.New(metering.Options{
+			Exporters: []metering.Exporter{metering.ExporterFunc(func(context.Context, []metering.Usage) error {
+				exported = true
+				return nil
+			})},
+		})
+		require.NoError(t, err)
+		serve(t, meter, alice, 0)
+
+		// when
+		err = meter.Flush(context.Background())
+
+		// then
+		require.NoError(t, err)
+		require.False(t, exported)
+		require.Len(t, meter.Usage(), 1)
+	})
+
+	t.Run("keeps usage when export fails", func(t *testing.T) {
+		// given
+		meter, err := metering.New(metering.Options{
+			Period: 10 * time.Millisecond,
+			Exporters: []metering.Exporter{metering.ExporterFunc(func(context.Context, []metering.Usage) error {
+				return errors.New("export failed")
+			})},
+		})
+		require.NoError(t, err)
+		serve(t, meter, alice, 0)
+		time.Sleep(20 * time.Millisecond)
+
+		// when
+		err = meter.Flush(context.Background())
+
+		// then
+		require.Error(t, err)
+		require.Len(t, meter.Usage(), 1)
+	})
+}
+
+func TestMeter_PrometheusHandler(t *testing.T) {
+	// given
+	meter, err := metering.New(metering.Options{Period: 10 * time.Millisecond})
+	require.NoError(t, err)
+	serve(t, meter, alice, 100)
+	serve(t, meter, bob, 0)
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, meter.Flush(context.Background()))
+	serve(t, meter, alice, 20)
+
+	w := httptest.NewRecorder()
+
+	// when
+	meter.PrometheusHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// then
+	body, err := io.ReadAll(w.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `bsv_metering_requests_total{identity_key="02aaaa"} 2`)
+	require.Contains(t, string(body), `bsv_metering_requests_total{identity_key="02bbbb"} 1`)
+	require.Contains(t, string(body), `bsv_metering_satoshis_paid_total{identity_key="02aaaa"} 120`)
+}
+
+func TestMeter_MaxIdentities(t *testing.T) {
+	// given
+	meter, err := metering.New(metering.Options{MaxIdentities: 2})
+	require.NoError(t, err)
+	serve(t, meter, alice, 10)
+	serve(t, meter, bob, 0)
+
+	// when
+	serve(t, meter, "02cccc", 5)
+	serve(t, meter, "02dddd", 1)
+	serve(t, meter, alice, 10)
+
+	// then
+	w := httptest.NewRecorder()
+	meter.PrometheusHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := w.Body.String()
+	require.Contains(t, body, `bsv_metering_requests_total{identity_key="02aaaa"} 2`)
+	require.Contains(t, body, `bsv_metering_requests_total{identity_key="other"} 2`)
+	require.Contains(t, body, `bsv_metering_satoshis_paid_total{identity_key="other"} 6`)
+	require.NotContains(t, body, "02cccc")
+	require.Len(t, meter.Usage(), 4, "usage per period is kept per identity key")
+}
@@ -25,6 +25,9 @@ const (
 
 	// HeaderDerivationPrefix is the header for the derivation prefix
 	HeaderDerivationPrefix = "X-BSV-Payment-Derivation-Prefix"
+
+	// HeaderQuote requests the payment terms of a request without executing it, quote responses echo it
+	HeaderQuote = "X-BSV-Payment-Quote"
 )
 
 // Error codes
@@ -47,6 +50,24 @@ const (
 	// ErrCodePaymentFailed indicates a payment processing failure
 	ErrCodePaymentFailed = "ERR_PAYMENT_FAILED"
 
+	// ErrCodeInvalidProof indicates a payment transaction without valid merkle proofs for the chain of the server
+	ErrCodeInvalidProof = "ERR_INVALID_PAYMENT_PROOF"
+
 	// ErrCodePaymentNotFound indicates a payment identifier was not found
 	ErrCodePaymentNotFound = "ERR_PAYMENT_NOT_FOUND"
+
+	// ErrCodeNetworkMismatch indicates a payment made on a different network than the required one
+	ErrCodeNetworkMismatch = "ERR_NETWORK_MISMATCH"
+
+	// ErrCodeInvalidTerms indicates a payment redeeming terms which were not signed by the server
+	ErrCodeInvalidTerms = "ERR_INVALID_PAYMENT_TERMS"
+
+	// ErrCodeTermsExpired indicates a payment redeeming expired terms, the client has to request new terms
+	ErrCodeTermsExpired = "ERR_PAYMENT_TERMS_EXPIRED"
+
+	// ErrCodeTermsRedeemed indicates a payment redeeming terms which already paid for a request, the client has to request new terms
+	ErrCodeTermsRedeemed = "ERR_PAYMENT_TERMS_REDEEMED"
+
+	// ErrCodeTermsOutdated indicates a payment redeeming terms quoting less than the current price, the client has to request new terms
+	ErrCodeTermsOutdated = "ERR_PAYMENT_TERMS_OUTDATED"
 )
@@ -0,0 +1,41 @@
+package payment
+
+import (
+	"fmt"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+)
+
+// KeyID returns the key ID of the BRC-29 payment key for the derivation prefix and suffix
+func KeyID(derivationPrefix, derivationSuffix string) string {
+	return derivationPrefix + " " + derivationSuffix
+}
+
+// DerivedLockingScript derives the locking script of a BRC-29 payment output.
+// The payer derives it with the identity key of the recipient as counterparty,
+// the recipient with the identity key of the payer and forSelf set, both resulting in the same key.
+func DerivedLockingScript(w wallet.WalletInterface, counterpartyIdentityKey, derivationPrefix, derivationSuffix string, forSelf bool) (*script.Script, error) {
+	counterparty, err := ec.PublicKeyFromString(counterpartyIdentityKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid counterparty identity key, %w", err)
+	}
+
+	result, err := w.GetPublicKey(&wallet.GetPublicKeyArgs{
+		EncryptionArgs: wallet.EncryptionArgs{
+			ProtocolID: wallet.PaymentProtocol,
+			KeyID:      KeyID(derivationPrefix, derivationSuffix),
+			Counterparty: wallet.Counterparty{
+				Type:         wallet.CounterpartyTypeOther,
+				Counterparty: counterparty,
+			},
+		},
+		ForSelf: forSelf,
+	}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive payment key, %w", err)
+	}
+
+	return LockingScriptForKey(result.PublicKey)
+}
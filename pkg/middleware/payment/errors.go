@@ -8,4 +8,46 @@ var (
 
 	// ErrAuthMiddlewareMissing is returned when auth middleware did not run before payment middleware
 	ErrAuthMiddlewareMissing = errors.New("the payment middleware must be executed after the Auth middleware")
+
+	// ErrUnknownNetwork is returned when the configured network is not mainnet, testnet or regtest
+	ErrUnknownNetwork = errors.New("unknown network")
+
+	// ErrNetworkMismatch is returned when the wallet or the payment is on a different network than the configured one
+	ErrNetworkMismatch = errors.New("network mismatch")
+
+	// ErrInvalidLogConfig is returned when the log config contains unsupported subsystems or levels
+	ErrInvalidLogConfig = errors.New("invalid log config")
+)
+
+var (
+	// ErrInvalidDerivationPrefix is returned when the derivation prefix of the payment was not issued by the server
+	ErrInvalidDerivationPrefix = errors.New("invalid derivation prefix")
+
+	// ErrInvalidTransaction is returned when the payment transaction is not a valid BEEF, EF or raw transaction
+	ErrInvalidTransaction = errors.New("invalid payment transaction")
+
+	// ErrInvalidProof is returned when the merkle proofs of the payment transaction are missing or not valid for the chain
+	ErrInvalidProof = errors.New("invalid payment transaction proof")
+
+	// ErrPaymentOutputNotFound is returned when the payment transaction has no output paying the expected locking script
+	ErrPaymentOutputNotFound = errors.New("payment output not found")
+)
+
+var (
+	// ErrInsufficientPayment is returned when the payment outputs pay less than the price of the request
+	ErrInsufficientPayment = errors.New("insufficient payment")
+)
+
+var (
+	// ErrInvalidTermsSignature is returned when the payment terms are not signed by the server
+	ErrInvalidTermsSignature = errors.New("invalid payment terms signature")
+
+	// ErrTermsExpired is returned when the redeemed payment terms expired
+	ErrTermsExpired = errors.New("payment terms expired")
+
+	// ErrTermsRedeemed is returned when the payment terms were already redeemed by another payment
+	ErrTermsRedeemed = errors.New("payment terms already redeemed")
+
+	// ErrTermsOutdated is returned when the redeemed payment terms quote less than the current price
+	ErrTermsOutdated = errors.New("payment terms outdated")
 )
@@ -0,0 +1,62 @@
+package payment
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+)
+
+// freeRequests counts the requests served without payment per identity and tier in fixed windows,
+// peers get the FreeRequests of the tier assigned to them by the auth middleware (see auth.TierPolicy)
+type freeRequests struct {
+	mu        sync.Mutex
+	windows   map[string]*freeRequestsWindow
+	lastPrune time.Time
+}
+
+type freeRequestsWindow struct {
+	end  time.Time
+	used int
+}
+
+func newFreeRequests() *freeRequests {
+	return &freeRequests{windows: make(map[string]*freeRequestsWindow)}
+}
+
+// use counts a free request of the identity, it reports false when the tier has no free requests left in the window
+func (f *freeRequests) use(identityKey string, tier auth.Tier, now time.Time) bool {
+	if tier.FreeRequests <= 0 {
+		return false
+	}
+
+	window := tier.FreeRequestsWindow
+	if window <= 0 {
+		window = auth.DefaultFreeRequestsWindow
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if now.Sub(f.lastPrune) >= window {
+		for key, w := range f.windows {
+			if !now.Before(w.end) {
+				delete(f.windows, key)
+			}
+		}
+		f.lastPrune = now
+	}
+
+	key := tier.Name + " " + identityKey
+	w, ok := f.windows[key]
+	if !ok || !now.Before(w.end) {
+		w = &freeRequestsWindow{end: now.Add(window)}
+		f.windows[key] = w
+	}
+
+	if w.used >= tier.FreeRequests {
+		return false
+	}
+	w.used++
+	return true
+}
@@ -0,0 +1,146 @@
+package payment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Errors returned by ledgers
+var (
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	ErrInvalidAmount       = errors.New("amount must be positive")
+)
+
+// EntryKind is the direction of a ledger entry
+type EntryKind string
+
+// Ledger entry kinds
+const (
+	EntryKindCredit EntryKind = "credit"
+	EntryKindDebit  EntryKind = "debit"
+)
+
+// LedgerEntry is a single movement of satoshis on the account of an identity key
+type LedgerEntry struct {
+	ID          string    `json:"id"`
+	IdentityKey string    `json:"identityKey"`
+	Kind        EntryKind `json:"kind"`
+	Satoshis    int64     `json:"satoshis"`
+	// Reference links the entry to its cause, e.g. the transaction ID of a payment or refund
+	Reference string    `json:"reference"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Ledger keeps the accounts of identity keys. The payment middleware credits received payments and debits refunds,
+// so the balance is the amount held on behalf of the identity. Implementations must be safe for concurrent use.
+type Ledger interface {
+	// Credit adds the satoshis to the account of the identity key. A credit with the reference of an earlier credit
+	// is a no-op returning the earlier entry, so a payment transaction is never credited twice.
+	Credit(ctx context.Context, identityKey string, satoshis int64, reference string) (LedgerEntry, error)
+	// Debit removes the satoshis from the account of the identity key, failing with ErrInsufficientBalance
+	// when the balance is lower than the amount
+	Debit(ctx context.Context, identityKey string, satoshis int64, reference string) (LedgerEntry, error)
+	// Balance returns the balance of the identity key
+	Balance(ctx context.Context, identityKey string) (int64, error)
+	// History returns the entries of the identity key in the order they were recorded
+	History(ctx context.Context, identityKey string) ([]LedgerEntry, error)
+}
+
+// MemoryLedger is a Ledger keeping the accounts in memory
+type MemoryLedger struct {
+	mu       sync.Mutex
+	balances map[string]int64
+	entries  map[string][]LedgerEntry
+	credits  map[string]LedgerEntry
+}
+
+// NewMemoryLedger creates an empty in-memory ledger
+func NewMemoryLedger() *MemoryLedger {
+	return &MemoryLedger{
+		balances: make(map[string]int64),
+		entries:  make(map[string][]LedgerEntry),
+		credits:  make(map[string]LedgerEntry),
+	}
+}
+
+// Credit implements Ledger
+func (l *MemoryLedger) Credit(_ context.Context, identityKey string, satoshis int64, reference string) (LedgerEntry, error) {
+	return l.record(identityKey, EntryKindCredit, satoshis, reference)
+}
+
+// Debit implements Ledger
+func (l *MemoryLedger) Debit(_ context.Context, identityKey string, satoshis int64, reference string) (LedgerEntry, error) {
+	return l.record(identityKey, EntryKindDebit, satoshis, reference)
+}
+
+// Balance implements Ledger
+func (l *MemoryLedger) Balance(_ context.Context, identityKey string) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.balances[identityKey], nil
+}
+
+// History implements Ledger
+func (l *MemoryLedger) History(_ context.Context, identityKey string) ([]LedgerEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]LedgerEntry(nil), l.entries[identityKey]...), nil
+}
+
+func (l *MemoryLedger) record(identityKey string, kind EntryKind, satoshis int64, reference string) (LedgerEntry, error) {
+	entry, err := newLedgerEntry(identityKey, kind, satoshis, reference)
+	if err != nil {
+		return LedgerEntry{}, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if kind == EntryKindCredit && reference != "" {
+		if credited, ok := l.credits[reference]; ok {
+			return credited, nil
+		}
+		l.credits[reference] = entry
+	}
+
+	balance := l.balances[identityKey]
+	if kind == EntryKindDebit {
+		if balance < satoshis {
+			return LedgerEntry{}, fmt.Errorf("%w: balance %d, debit %d", ErrInsufficientBalance, balance, satoshis)
+		}
+		balance -= satoshis
+	} else {
+		balance += satoshis
+	}
+
+	l.balances[identityKey] = balance
+	l.entries[identityKey] = append(l.entries[identityKey], entry)
+	return entry, nil
+}
+
+func newLedgerEntry(identityKey string, kind EntryKind, satoshis int64, reference string) (LedgerEntry, error) {
+	if satoshis <= 0 {
+		return LedgerEntry{}, ErrInvalidAmount
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return LedgerEntry{}, fmt.Errorf("failed to generate ledger entry ID, %w", err)
+	}
+
+	return LedgerEntry{
+		ID:          hex.EncodeToString(id),
+		IdentityKey: identityKey,
+		Kind:        kind,
+		Satoshis:    satoshis,
+		Reference:   reference,
+		CreatedAt:   time.Now().UTC(),
+	}, nil
+}
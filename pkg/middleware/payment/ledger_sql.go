@@ -0,0 +1,308 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultLedgerTable is the table of SQLLedger when none is configured
+const DefaultLedgerTable = "payment_ledger"
+
+const (
+	defaultDebitRetries = 5
+	debitRetryBackoff   = 10 * time.Millisecond
+)
+
+var validTableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// SQLLedgerOptions configures the SQL ledger
+type SQLLedgerOptions struct {
+	// Table is the name of the ledger table, defaults to DefaultLedgerTable
+	Table string
+	// NumberedPlaceholders uses $1, $2... placeholders (PostgreSQL) instead of ? (MySQL, SQLite)
+	NumberedPlaceholders bool
+	// DebitRetries is how many times a debit or referenced credit conflicting with a concurrent transaction is retried,
+	// defaults to 5
+	DebitRetries int
+	// IsSerializationFailure reports whether a debit failed because of a concurrent transaction and can be retried,
+	// defaults to IsSerializationFailure, which recognizes PostgreSQL and SQLite. Set it for other databases,
+	// e.g. to match the deadlock error 1213 of MySQL.
+	IsSerializationFailure func(err error) bool
+}
+
+// SQLLedger is a Ledger storing the entries in a SQL database through database/sql, the driver is chosen by the caller.
+// Balances are computed from the entries, debits are checked and recorded in one serializable transaction,
+// so concurrent debits cannot overdraw the account. Referenced credits are checked and recorded the same way,
+// so a payment is never credited twice. SQLite databases should be opened with immediate transactions,
+// e.g. the _txlock=immediate parameter of modernc.org/sqlite, as deferred ones conflict when they commit.
+type SQLLedger struct {
+	db                     *sql.DB
+	table                  string
+	numbered               bool
+	debitRetries           int
+	isSerializationFailure func(err error) bool
+}
+
+// NewSQLLedger creates a SQL ledger, CreateTable creates its table
+func NewSQLLedger(db *sql.DB, opts SQLLedgerOptions) (*SQLLedger, error) {
+	if db == nil {
+		return nil, errors.New("database is required")
+	}
+
+	table := opts.Table
+	if table == "" {
+		table = DefaultLedgerTable
+	}
+	if !validTableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid ledger table name %q", table)
+	}
+
+	if opts.DebitRetries <= 0 {
+		opts.DebitRetries = defaultDebitRetries
+	}
+	if opts.IsSerializationFailure == nil {
+		opts.IsSerializationFailure = IsSerializationFailure
+	}
+
+	return &SQLLedger{
+		db:                     db,
+		table:                  table,
+		numbered:               opts.NumberedPlaceholders,
+		debitRetries:           opts.DebitRetries,
+		isSerializationFailure: opts.IsSerializationFailure,
+	}, nil
+}
+
+// IsSerializationFailure reports whether the error is a serialization failure or deadlock of PostgreSQL
+// (SQLSTATE 40001 and 40P01, drivers exposing SQLState like pgx and lib/pq) or a busy SQLite database
+// (drivers exposing the result code with Code like modernc.org/sqlite)
+func IsSerializationFailure(err error) bool {
+	var sqlState interface{ SQLState() string }
+	if errors.As(err, &sqlState) {
+		state := sqlState.SQLState()
+		return state == "40001" || state == "40P01"
+	}
+
+	var sqliteErr interface{ Code() int }
+	if errors.As(err, &sqliteErr) {
+		const sqliteBusy, sqliteLocked = 5, 6
+		code := sqliteErr.Code() & 0xff // primary result code of extended ones like SQLITE_BUSY_SNAPSHOT
+		return code == sqliteBusy || code == sqliteLocked
+	}
+
+	return false
+}
+
+// CreateTable creates the ledger table if it does not exist
+func (l *SQLLedger) CreateTable(ctx context.Context) error {
+	_, err := l.db.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id VARCHAR(32) PRIMARY KEY,
+	identity_key VARCHAR(66) NOT NULL,
+	kind VARCHAR(6) NOT NULL,
+	satoshis BIGINT NOT NULL,
+	reference VARCHAR(255) NOT NULL,
+	created_at TIMESTAMP NOT NULL
+)`, l.table))
+	if err != nil {
+		return fmt.Errorf("failed to create ledger table, %w", err)
+	}
+	return nil
+}
+
+// Credit implements Ledger, a referenced credit is checked for an earlier credit and recorded in one serializable
+// transaction, which is retried when it conflicts with a concurrent one
+func (l *SQLLedger) Credit(ctx context.Context, identityKey string, satoshis int64, reference string) (LedgerEntry, error) {
+	entry, err := newLedgerEntry(identityKey, EntryKindCredit, satoshis, reference)
+	if err != nil {
+		return LedgerEntry{}, err
+	}
+
+	if reference == "" {
+		if err := l.insert(ctx, l.db, entry); err != nil {
+			return LedgerEntry{}, err
+		}
+		return entry, nil
+	}
+
+	err = l.retry(ctx, func() error {
+		entry, err = l.credit(ctx, entry)
+		return err
+	})
+	if err != nil {
+		return LedgerEntry{}, err
+	}
+	return entry, nil
+}
+
+// Debit implements Ledger, the debit is retried when its transaction conflicts with a concurrent one
+func (l *SQLLedger) Debit(ctx context.Context, identityKey string, satoshis int64, reference string) (LedgerEntry, error) {
+	entry, err := newLedgerEntry(identityKey, EntryKindDebit, satoshis, reference)
+	if err != nil {
+		return LedgerEntry{}, err
+	}
+
+	if err := l.retry(ctx, func() error { return l.debit(ctx, entry) }); err != nil {
+		return LedgerEntry{}, err
+	}
+	return entry, nil
+}
+
+// retry runs the transaction until it does not fail with a serialization failure, at most DebitRetries times more
+func (l *SQLLedger) retry(ctx context.Context, transaction func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := transaction()
+		if err == nil {
+			return nil
+		}
+		if attempt > l.debitRetries || !l.isSerializationFailure(err) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("ledger transaction aborted while retrying, %w", ctx.Err())
+		case <-time.After(time.Duration(attempt) * debitRetryBackoff):
+		}
+	}
+}
+
+// credit records the entry in a serializable transaction unless a credit with its reference exists,
+// in which case the earlier entry is returned
+func (l *SQLLedger) credit(ctx context.Context, entry LedgerEntry) (LedgerEntry, error) {
+	tx, err := l.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return LedgerEntry{}, fmt.Errorf("failed to begin ledger transaction, %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var credited LedgerEntry
+	var kind string
+	err = tx.QueryRowContext(ctx, l.query(
+		"SELECT id, identity_key, kind, satoshis, reference, created_at FROM %s WHERE kind = 'credit' AND reference = ? ORDER BY created_at, id LIMIT 1"),
+		entry.Reference).Scan(&credited.ID, &credited.IdentityKey, &kind, &credited.Satoshis, &credited.Reference, &credited.CreatedAt)
+	switch {
+	case err == nil:
+		credited.Kind = EntryKind(kind)
+		return credited, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return LedgerEntry{}, fmt.Errorf("failed to query ledger credit, %w", err)
+	}
+
+	if err := l.insert(ctx, tx, entry); err != nil {
+		return LedgerEntry{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return LedgerEntry{}, fmt.Errorf("failed to commit ledger transaction, %w", err)
+	}
+	return entry, nil
+}
+
+// debit checks the balance and records the entry in a serializable transaction
+func (l *SQLLedger) debit(ctx context.Context, entry LedgerEntry) error {
+	tx, err := l.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return fmt.Errorf("failed to begin ledger transaction, %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	balance, err := l.balance(ctx, tx, entry.IdentityKey)
+	if err != nil {
+		return err
+	}
+	if balance < entry.Satoshis {
+		return fmt.Errorf("%w: balance %d, debit %d", ErrInsufficientBalance, balance, entry.Satoshis)
+	}
+
+	if err := l.insert(ctx, tx, entry); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit ledger transaction, %w", err)
+	}
+	return nil
+}
+
+// Balance implements Ledger
+func (l *SQLLedger) Balance(ctx context.Context, identityKey string) (int64, error) {
+	return l.balance(ctx, l.db, identityKey)
+}
+
+// History implements Ledger
+func (l *SQLLedger) History(ctx context.Context, identityKey string) ([]LedgerEntry, error) {
+	rows, err := l.db.QueryContext(ctx, l.query(
+		"SELECT id, identity_key, kind, satoshis, reference, created_at FROM %s WHERE identity_key = ? ORDER BY created_at, id"),
+		identityKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ledger history, %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var entries []LedgerEntry
+	for rows.Next() {
+		var entry LedgerEntry
+		var kind string
+		if err := rows.Scan(&entry.ID, &entry.IdentityKey, &kind, &entry.Satoshis, &entry.Reference, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ledger entry, %w", err)
+		}
+		entry.Kind = EntryKind(kind)
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ledger history, %w", err)
+	}
+
+	return entries, nil
+}
+
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func (l *SQLLedger) balance(ctx context.Context, q queryer, identityKey string) (int64, error) {
+	var balance int64
+	err := q.QueryRowContext(ctx, l.query(
+		"SELECT COALESCE(SUM(CASE WHEN kind = 'credit' THEN satoshis ELSE -satoshis END), 0) FROM %s WHERE identity_key = ?"),
+		identityKey).Scan(&balance)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query ledger balance, %w", err)
+	}
+	return balance, nil
+}
+
+func (l *SQLLedger) insert(ctx context.Context, q queryer, entry LedgerEntry) error {
+	_, err := q.ExecContext(ctx, l.query(
+		"INSERT INTO %s (id, identity_key, kind, satoshis, reference, created_at) VALUES (?, ?, ?, ?, ?, ?)"),
+		entry.ID, entry.IdentityKey, string(entry.Kind), entry.Satoshis, entry.Reference, entry.CreatedAt.Truncate(time.Microsecond))
+	if err != nil {
+		return fmt.Errorf("failed to insert ledger entry, %w", err)
+	}
+	return nil
+}
+
+// query formats the statement with the table name and rewrites the placeholders for the configured dialect
+func (l *SQLLedger) query(format string) string {
+	query := fmt.Sprintf(format, l.table)
+	if !l.numbered {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			fmt.Fprintf(&b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,134 @@
+package payment_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/payment"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryLedger(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("credits and debits update balance and history", func(t *testing.T) {
+		// given
+		ledger := payment.NewMemoryLedger()
+
+		// when
+		_, err := ledger.Credit(ctx, "alice", 100, "payment-1")
+		require.NoError(t, err)
+		_, err = ledger.Debit(ctx, "alice", 30, "refund-1")
+		require.NoError(t, err)
+		_, err = ledger.Credit(ctx, "bob", 5, "payment-2")
+		require.NoError(t, err)
+
+		// then
+		balance, err := ledger.Balance(ctx, "alice")
+		require.NoError(t, err)
+		require.Equal(t, int64(70), balance)
+
+		history, err := ledger.History(ctx, "alice")
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		require.Equal(t, payment.EntryKindCredit, history[0].Kind)
+		require.Equal(t, "payment-1", history[0].Reference)
+		require.Equal(t, payment.EntryKindDebit, history[1].Kind)
+		require.Equal(t, int64(30), history[1].Satoshis)
+		require.NotEqual(t, history[0].ID, history[1].ID)
+	})
+
+	t.Run("credit of an already credited reference is a no-op", func(t *testing.T) {
+		// given
+		ledger := payment.NewMemoryLedger()
+		first, err := ledger.Credit(ctx, "alice", 100, "payment-1")
+		require.NoError(t, err)
+
+		// when
+		second, err := ledger.Credit(ctx, "alice", 100, "payment-1")
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, first.ID, second.ID)
+		balance, err := ledger.Balance(ctx, "alice")
+		require.NoError(t, err)
+		require.Equal(t, int64(100), balance)
+		history, err := ledger.History(ctx, "alice")
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+	})
+
+	t.Run("rejects debit above balance", func(t *testing.T) {
+		// given
+		ledger := payment.NewMemoryLedger()
+		_, err := ledger.Credit(ctx, "alice", 10, "payment-1")
+		require.NoError(t, err)
+
+		// when
+		_, err = ledger.Debit(ctx, "alice", 11, "refund-1")
+
+		// then
+		require.ErrorIs(t, err, payment.ErrInsufficientBalance)
+		balance, err := ledger.Balance(ctx, "alice")
+		require.NoError(t, err)
+		require.Equal(t, int64(10), balance)
+	})
+
+	t.Run("rejects non-positive amounts", func(t *testing.T) {
+		ledger := payment.NewMemoryLedger()
+
+		_, err := ledger.Credit(ctx, "alice", 0, "payment-1")
+
+		require.ErrorIs(t, err, payment.ErrInvalidAmount)
+	})
+}
+
+func TestMiddleware_Ledger(t *testing.T) {
+	// given
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	sender, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	mockWallet := wallettest.NewMockPaymentWallet(key)
+	mockWalletSetup(t, mockWallet, wallettest.MockNonce)
+	ledger := payment.NewMemoryLedger()
+
+	middleware, err := payment.New(payment.Options{
+		Wallet:       mockWallet,
+		Ledger:       ledger,
+		RefundPolicy: payment.RefundPolicyAuto,
+	})
+	require.NoError(t, err)
+
+	handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+
+	paymentJSON, err := json.Marshal(preparePayment(t, sender, key, 100))
+	require.NoError(t, err)
+	req := httptest.NewRequest("GET", "/", nil)
+	req = addIdentityToContext(req, sender.PubKey().ToDERHex())
+	req.Header.Set(payment.HeaderPayment, string(paymentJSON))
+
+	// when
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// then
+	history, err := ledger.History(context.Background(), sender.PubKey().ToDERHex())
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.Equal(t, payment.EntryKindCredit, history[0].Kind)
+	require.Equal(t, middleware.Refunds()[0].ID, history[0].Reference)
+	require.Equal(t, payment.EntryKindDebit, history[1].Kind)
+	require.Equal(t, wallettest.MockCreateActionTxID, history[1].Reference)
+
+	balance, err := ledger.Balance(context.Background(), sender.PubKey().ToDERHex())
+	require.NoError(t, err)
+	require.Zero(t, balance)
+}
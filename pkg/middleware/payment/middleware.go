@@ -7,10 +7,15 @@ import (
 	"fmt"
 	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/chaintracker"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/defs"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/events"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/internal/logging"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-sdk/transaction"
 )
 
 // Middleware is the payment middleware handler that implements Direct Payment Protocol (DPP) for HTTP-based micropayments
@@ -18,6 +23,17 @@ type Middleware struct {
 	logger                *slog.Logger
 	wallet                wallet.PaymentInterface
 	calculateRequestPrice func(r *http.Request) (int, error)
+	termsTTL              time.Duration
+	network               wallet.Network
+	chainTracker          chaintracker.Interface
+	refundPolicy          RefundPolicy
+	refunds               *refundLedger
+	quotes                bool
+	ledger                Ledger
+	redemptions           *redemptions
+	clock                 func() time.Time
+	freeRequests          *freeRequests
+	events                *events.Bus
 }
 
 // New creates a new payment middleware
@@ -30,15 +46,63 @@ func New(opts Options) (*Middleware, error) {
 		opts.CalculateRequestPrice = DefaultPriceFunc
 	}
 
-	logger := logging.Child(nil, "payment-middleware")
+	if opts.TermsTTL <= 0 {
+		opts.TermsTTL = defaultTermsTTL
+	}
+
+	if opts.Clock == nil {
+		opts.Clock = time.Now
+	}
+
+	if err := opts.Logging.Validate(); err != nil {
+		return nil, fmt.Errorf("%w, %w", ErrInvalidLogConfig, err)
+	}
+
+	logger := logging.Subsystem(logging.Child(opts.Logger, "payment-middleware"), defs.LogSubsystemPayments, opts.Logging)
+
+	if opts.Network != "" {
+		if err := validateNetwork(opts.Wallet, opts.Network); err != nil {
+			return nil, err
+		}
+	}
 
 	return &Middleware{
 		logger:                logger,
 		wallet:                opts.Wallet,
 		calculateRequestPrice: opts.CalculateRequestPrice,
+		termsTTL:              opts.TermsTTL,
+		network:               opts.Network,
+		chainTracker:          opts.ChainTracker,
+		refundPolicy:          opts.RefundPolicy,
+		refunds:               newRefundLedger(),
+		quotes:                opts.EnableQuotes,
+		ledger:                opts.Ledger,
+		redemptions:           newRedemptions(),
+		clock:                 opts.Clock,
+		freeRequests:          newFreeRequests(),
+		events:                opts.Events,
 	}, nil
 }
 
+func validateNetwork(walletInstance wallet.PaymentInterface, network wallet.Network) error {
+	switch network {
+	case wallet.NetworkMainnet, wallet.NetworkTestnet, wallet.NetworkRegtest:
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownNetwork, network)
+	}
+
+	result, err := walletInstance.GetNetwork(context.Background(), "")
+	if err != nil {
+		return fmt.Errorf("failed to get wallet network, %w", err)
+	}
+
+	if result.Network != network {
+		return fmt.Errorf("%w: configured %s, wallet on %s", ErrNetworkMismatch, network, result.Network)
+	}
+
+	return nil
+}
+
 // Handler returns a middleware handler function that processes payments
 func (m *Middleware) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -57,11 +121,24 @@ func (m *Middleware) Handler(next http.Handler) http.Handler {
 			return
 		}
 
+		if m.quotes && r.Header.Get(HeaderQuote) != "" {
+			w.Header().Set(HeaderQuote, "true")
+			m.sendPaymentTerms(w, r, identityKey, price, http.StatusOK)
+			return
+		}
+
 		if price == 0 {
 			proceedWithoutPayment(w, r, next)
 			return
 		}
 
+		if tier, ok := auth.GetTierFromContext(r.Context()); ok && r.Header.Get(HeaderPayment) == "" &&
+			m.freeRequests.use(identityKey, tier, m.clock()) {
+			m.logger.Debug("Request served from free requests of the tier", slog.String("identityKey", identityKey), slog.String("tier", tier.Name))
+			proceedWithoutPayment(w, r, next)
+			return
+		}
+
 		paymentData, err := extractPaymentData(r)
 		if err != nil {
 			m.logger.Error("Error extracting payment data", slog.String("error", err.Error()))
@@ -70,19 +147,63 @@ func (m *Middleware) Handler(next http.Handler) http.Handler {
 		}
 
 		if paymentData == nil {
-			requestPayment(w, r, m.wallet, price)
+			m.logger.Debug("Payment required", slog.String("identityKey", identityKey), slog.Int("price", price))
+			m.sendPaymentTerms(w, r, identityKey, price, http.StatusPaymentRequired)
+			return
+		}
+
+		// the chain is covered by the signature of the redeemed terms, verified below
+		if m.network != "" && paymentData.Chain != m.network {
+			m.logger.Error("Payment made on different network", slog.String("network", string(paymentData.Chain)))
+			respondWithError(w, http.StatusBadRequest, ErrCodeNetworkMismatch,
+				fmt.Sprintf("Payment must be made on %s, got %q", m.network, paymentData.Chain))
+			return
+		}
+
+		if err := verifyRedeemedTerms(m.wallet, paymentData, identityKey); err != nil {
+			m.logger.Error("Payment redeems terms not signed by the server", slog.String("error", err.Error()))
+			respondWithError(w, http.StatusBadRequest, ErrCodeInvalidTerms, err.Error())
 			return
 		}
 
-		paymentInfo, err := processPayment(r.Context(), m.wallet, paymentData, identityKey, price)
+		now := m.clock()
+		if now.Unix() > paymentData.ExpirationTimestamp {
+			respondWithError(w, http.StatusBadRequest, ErrCodeTermsExpired, ErrTermsExpired.Error())
+			return
+		}
+
+		if paymentData.SatoshisRequired < price {
+			respondWithError(w, http.StatusBadRequest, ErrCodeTermsOutdated,
+				fmt.Sprintf("%s: quoted %d satoshis, current price is %d", ErrTermsOutdated.Error(), paymentData.SatoshisRequired, price))
+			return
+		}
+
+		if !m.redemptions.claim(paymentData.DerivationPrefix, paymentData.ExpirationTimestamp, now) {
+			respondWithError(w, http.StatusBadRequest, ErrCodeTermsRedeemed, ErrTermsRedeemed.Error())
+			return
+		}
+
+		paymentInfo, err := processPayment(r.Context(), m.wallet, m.chainTracker, paymentData, identityKey, price)
 		if err != nil {
+			m.redemptions.release(paymentData.DerivationPrefix)
 			m.logger.Error("Error processing payment", slog.String("error", err.Error()))
-			respondWithError(w, http.StatusBadRequest, ErrCodePaymentFailed,
-				fmt.Sprintf("Payment failed: %s", err.Error()))
+			code := ErrCodePaymentFailed
+			if errors.Is(err, ErrInvalidProof) {
+				code = ErrCodeInvalidProof
+			}
+			respondWithError(w, http.StatusBadRequest, code, fmt.Sprintf("Payment failed: %s", err.Error()))
 			return
 		}
 
-		proceedWithSuccessfulPayment(w, r, next, paymentInfo)
+		m.logger.Debug("Payment accepted", slog.String("identityKey", identityKey), slog.String("txid", paymentInfo.TransactionID))
+
+		if m.ledger != nil {
+			if _, err := m.ledger.Credit(r.Context(), identityKey, int64(paymentInfo.SatoshisPaid), paymentInfo.TransactionID); err != nil {
+				m.logger.Error("Failed to record payment in ledger", slog.String("error", err.Error()))
+			}
+		}
+
+		m.proceedWithSuccessfulPayment(w, r, next, paymentInfo, identityKey)
 	})
 }
 
@@ -91,10 +212,19 @@ func proceedWithoutPayment(w http.ResponseWriter, r *http.Request, next http.Han
 	next.ServeHTTP(w, r.WithContext(ctx))
 }
 
-func proceedWithSuccessfulPayment(w http.ResponseWriter, r *http.Request, next http.Handler, paymentInfo *PaymentInfo) {
+func (m *Middleware) proceedWithSuccessfulPayment(w http.ResponseWriter, r *http.Request, next http.Handler, paymentInfo *PaymentInfo, identityKey string) {
 	ctx := context.WithValue(r.Context(), PaymentKey, paymentInfo)
 	sendPaymentAcknowledgment(w, paymentInfo)
-	next.ServeHTTP(w, r.WithContext(ctx))
+	m.events.Publish(ctx, events.Event{
+		Topic: events.TopicPayment, Type: events.TypePaymentAccepted, IdentityKey: identityKey, Request: r, Data: *paymentInfo,
+	})
+
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	next.ServeHTTP(recorder, r.WithContext(ctx))
+
+	if recorder.status >= http.StatusInternalServerError {
+		m.handleFailedPaidRequest(context.WithoutCancel(r.Context()), identityKey, paymentInfo, recorder.status)
+	}
 }
 
 func extractPaymentData(r *http.Request) (*Payment, error) {
@@ -111,18 +241,30 @@ func extractPaymentData(r *http.Request) (*Payment, error) {
 	return &payment, nil
 }
 
-func requestPayment(w http.ResponseWriter, r *http.Request, walletInstance wallet.PaymentInterface, price int) {
-	derivationPrefix, err := walletInstance.CreateNonce(r.Context())
+// sendPaymentTerms responds with fresh payment terms, with 402 when the payment is required or 200 for quotes,
+// the terms are signed for the requesting identity, so they can be verified by the client and on redemption
+func (m *Middleware) sendPaymentTerms(w http.ResponseWriter, r *http.Request, identityKey string, price int, status int) {
+	derivationPrefix, err := m.wallet.CreateNonce(r.Context())
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, ErrCodePaymentInternal,
 			fmt.Sprintf("Error creating nonce: %s", err.Error()))
 		return
 	}
 
+	now := m.clock()
 	terms := NewPaymentTerms(price, derivationPrefix, r.URL.String())
+	terms.CreationTimestamp = now.Unix()
+	terms.ExpirationTimestamp = now.Add(m.termsTTL).Unix()
+	terms.Chain = m.network
+
+	if err := SignTerms(m.wallet, &terms, identityKey); err != nil {
+		respondWithError(w, http.StatusInternalServerError, ErrCodePaymentInternal,
+			fmt.Sprintf("Error signing payment terms: %s", err.Error()))
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusPaymentRequired)
+	w.WriteHeader(status)
 	err = json.NewEncoder(w).Encode(terms)
 	if err != nil {
 		return
@@ -132,32 +274,52 @@ func requestPayment(w http.ResponseWriter, r *http.Request, walletInstance walle
 func processPayment(
 	ctx context.Context,
 	walletInstance wallet.PaymentInterface,
+	tracker chaintracker.Interface,
 	paymentData *Payment,
 	identityKey string,
 	price int,
 ) (*PaymentInfo, error) {
 	valid, err := walletInstance.VerifyNonce(ctx, paymentData.DerivationPrefix)
 	if err != nil {
-		return nil, fmt.Errorf("error verifying nonce: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDerivationPrefix, err)
 	}
 
 	if !valid {
-		return nil, errors.New("invalid derivation prefix")
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDerivationPrefix, wallet.ErrNonceInvalid)
 	}
 
-	result, err := walletInstance.InternalizeAction(ctx, wallet.InternalizeActionArgs{
-		Tx: paymentData.Transaction,
-		Outputs: []wallet.InternalizeOutput{
-			{
-				OutputIndex: 0,
-				Protocol:    "wallet payment",
-				PaymentRemittance: &wallet.PaymentRemittance{
-					DerivationPrefix:  paymentData.DerivationPrefix,
-					DerivationSuffix:  paymentData.DerivationSuffix,
-					SenderIdentityKey: identityKey,
-				},
+	tx, err := ParseTransaction(paymentData.Transaction)
+	if err != nil {
+		return nil, err
+	}
+
+	if tracker != nil {
+		if err := VerifyTransaction(ctx, paymentData.Transaction, tracker); err != nil {
+			return nil, err
+		}
+	}
+
+	outputs, err := verifyPaymentOutputs(walletInstance, tx, paymentData, identityKey, price)
+	if err != nil {
+		return nil, err
+	}
+
+	internalizeOutputs := make([]wallet.InternalizeOutput, 0, len(outputs))
+	for _, output := range outputs {
+		internalizeOutputs = append(internalizeOutputs, wallet.InternalizeOutput{
+			OutputIndex: int(output.Vout),
+			Protocol:    "wallet payment",
+			PaymentRemittance: &wallet.PaymentRemittance{
+				DerivationPrefix:  paymentData.DerivationPrefix,
+				DerivationSuffix:  paymentData.DerivationSuffix,
+				SenderIdentityKey: identityKey,
 			},
-		},
+		})
+	}
+
+	result, err := walletInstance.InternalizeAction(ctx, wallet.InternalizeActionArgs{
+		Tx:          paymentData.Transaction,
+		Outputs:     internalizeOutputs,
 		Description: "Payment for request",
 	})
 
@@ -165,21 +327,40 @@ func processPayment(
 		return nil, fmt.Errorf("payment processing failed: %w", err)
 	}
 
-	var txid string
-	if len(paymentData.Transaction) >= 4 {
-		txid = fmt.Sprintf("tx-%x", paymentData.Transaction[:4])
-	} else {
-		txid = fmt.Sprintf("tx-%x", paymentData.Transaction)
-	}
-
 	return &PaymentInfo{
-		SatoshisPaid:  price,
+		SatoshisPaid:  int(PaidSatoshis(outputs)), //nolint:gosec // paid amount is checked against the price
 		Accepted:      result.Accepted,
 		Tx:            paymentData.Transaction,
-		TransactionID: txid,
+		TransactionID: tx.TxID().String(),
 	}, nil
 }
 
+// verifyPaymentOutputs finds the outputs paying the key derived from the server identity with the derivation prefix
+// and suffix of the payment, and checks they pay at least the price
+func verifyPaymentOutputs(
+	walletInstance wallet.PaymentInterface,
+	tx *transaction.Transaction,
+	paymentData *Payment,
+	identityKey string,
+	price int,
+) ([]PaymentOutput, error) {
+	lockingScript, err := DerivedLockingScript(walletInstance, identityKey, paymentData.DerivationPrefix, paymentData.DerivationSuffix, true)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs, err := FindPaymentOutputs(tx, lockingScript)
+	if err != nil {
+		return nil, err
+	}
+
+	if paid := PaidSatoshis(outputs); paid < uint64(price) { //nolint:gosec // price is never negative
+		return nil, fmt.Errorf("%w: paid %d satoshis, %d required", ErrInsufficientPayment, paid, price)
+	}
+
+	return outputs, nil
+}
+
 func sendPaymentAcknowledgment(w http.ResponseWriter, paymentInfo *PaymentInfo) {
 	w.Header().Set(HeaderSatoshisPaid, fmt.Sprintf("%d", paymentInfo.SatoshisPaid))
 }
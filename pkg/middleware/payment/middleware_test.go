@@ -7,16 +7,23 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/chaintracker"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/payment"
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet"
-	fixtures "github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet/test"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-sdk/chainhash"
 	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/transaction"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// testIdentityKey is a valid identity key, payment terms are signed for the requesting identity
+const testIdentityKey = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+
 func addIdentityToContext(r *http.Request, identityKey string) *http.Request {
 	ctx := context.WithValue(r.Context(), transport.IdentityKey, identityKey)
 	return r.WithContext(ctx)
@@ -35,6 +42,45 @@ func mockWalletSetup(t *testing.T, w wallet.WalletInterface, expectedNonce strin
 	require.True(t, valid, "Mock wallet should recognize MockNonce as valid")
 }
 
+// preparePayment creates a payment of the sender paying the satoshis to the key derived from the server identity key
+func preparePayment(t *testing.T, sender, server *ec.PrivateKey, satoshis uint64) payment.Payment {
+	t.Helper()
+
+	lockingScript, err := payment.DerivedLockingScript(wallettest.NewMockWallet(sender), server.PubKey().ToDERHex(), wallettest.MockNonce, "test-suffix", false)
+	require.NoError(t, err)
+
+	source := transaction.NewTransaction()
+	source.AddOutput(&transaction.TransactionOutput{Satoshis: satoshis, LockingScript: lockingScript})
+
+	tx := transaction.NewTransaction()
+	tx.AddInputFromTx(source, 0, nil)
+	tx.AddOutput(&transaction.TransactionOutput{Satoshis: satoshis, LockingScript: lockingScript})
+
+	beef, err := tx.AtomicBEEF(false)
+	require.NoError(t, err)
+
+	paymentData := payment.Payment{
+		ModeID:           "bsv-direct",
+		DerivationPrefix: wallettest.MockNonce,
+		DerivationSuffix: "test-suffix",
+		Transaction:      beef,
+	}
+	attachSignedTerms(t, &paymentData, sender, server, int(satoshis), time.Now().Add(time.Minute)) //nolint:gosec // test amounts are small
+
+	return paymentData
+}
+
+// attachSignedTerms attaches terms signed by the server for the sender to the payment
+func attachSignedTerms(t *testing.T, paymentData *payment.Payment, sender, server *ec.PrivateKey, price int, expiration time.Time) {
+	t.Helper()
+
+	terms := payment.NewPaymentTerms(price, paymentData.DerivationPrefix, "/")
+	terms.ExpirationTimestamp = expiration.Unix()
+	require.NoError(t, payment.SignTerms(wallettest.NewMockWallet(server), &terms, sender.PubKey().ToDERHex()))
+
+	paymentData.AttachTerms(terms)
+}
+
 func TestNewMiddleware(t *testing.T) {
 	t.Run("Returns error with no wallet", func(t *testing.T) {
 		options := payment.Options{}
@@ -51,7 +97,7 @@ func TestNewMiddleware(t *testing.T) {
 			require.NoError(t, err)
 		}
 
-		mockWallet := wallet.NewMockPaymentWallet(key)
+		mockWallet := wallettest.NewMockPaymentWallet(key)
 		options := payment.Options{
 			Wallet: mockWallet,
 		}
@@ -68,7 +114,7 @@ func TestMiddleware_Handler_MissingAuthContext(t *testing.T) {
 	if err != nil {
 		require.NoError(t, err)
 	}
-	mockWallet := wallet.NewMockPaymentWallet(key)
+	mockWallet := wallettest.NewMockPaymentWallet(key)
 	middleware, err := payment.New(payment.Options{
 		Wallet: mockWallet,
 	})
@@ -99,7 +145,7 @@ func TestMiddleware_Handler_FreeAccess(t *testing.T) {
 	if err != nil {
 		require.NoError(t, err)
 	}
-	mockWallet := wallet.NewMockPaymentWallet(key)
+	mockWallet := wallettest.NewMockPaymentWallet(key)
 	middleware, err := payment.New(payment.Options{
 		Wallet: mockWallet,
 		CalculateRequestPrice: func(r *http.Request) (int, error) {
@@ -119,7 +165,7 @@ func TestMiddleware_Handler_FreeAccess(t *testing.T) {
 	}))
 
 	req := httptest.NewRequest("GET", "/", nil)
-	req = addIdentityToContext(req, "test-identity-key")
+	req = addIdentityToContext(req, testIdentityKey)
 	w := httptest.NewRecorder()
 
 	handler.ServeHTTP(w, req)
@@ -132,7 +178,7 @@ func TestMiddleware_Handler_PaymentRequired(t *testing.T) {
 	if err != nil {
 		require.NoError(t, err)
 	}
-	mockWallet := wallet.NewMockPaymentWallet(key)
+	mockWallet := wallettest.NewMockPaymentWallet(key)
 	middleware, err := payment.New(payment.Options{
 		Wallet: mockWallet,
 		CalculateRequestPrice: func(r *http.Request) (int, error) {
@@ -147,7 +193,7 @@ func TestMiddleware_Handler_PaymentRequired(t *testing.T) {
 	}))
 
 	req := httptest.NewRequest("GET", "/", nil)
-	req = addIdentityToContext(req, "test-identity-key")
+	req = addIdentityToContext(req, testIdentityKey)
 	w := httptest.NewRecorder()
 
 	handler.ServeHTTP(w, req)
@@ -170,7 +216,7 @@ func TestMiddleware_Handler_InvalidPaymentData(t *testing.T) {
 	if err != nil {
 		require.NoError(t, err)
 	}
-	mockWallet := wallet.NewMockPaymentWallet(key)
+	mockWallet := wallettest.NewMockPaymentWallet(key)
 	middleware, err := payment.New(payment.Options{
 		Wallet: mockWallet,
 	})
@@ -182,7 +228,7 @@ func TestMiddleware_Handler_InvalidPaymentData(t *testing.T) {
 	}))
 
 	req := httptest.NewRequest("GET", "/", nil)
-	req = addIdentityToContext(req, "test-identity-key")
+	req = addIdentityToContext(req, testIdentityKey)
 	req.Header.Set(payment.HeaderPayment, "invalid-json-data")
 
 	w := httptest.NewRecorder()
@@ -205,8 +251,8 @@ func TestMiddleware_Handler_ProcessPayment(t *testing.T) {
 		if err != nil {
 			require.NoError(t, err)
 		}
-		mockWallet := wallet.NewMockPaymentWallet(key)
-		mockWalletSetup(t, mockWallet, fixtures.MockNonce)
+		mockWallet := wallettest.NewMockPaymentWallet(key)
+		mockWalletSetup(t, mockWallet, wallettest.MockNonce)
 
 		mockWallet.SetInternalizeActionResult(wallet.InternalizeActionResult{
 			Accepted: true,
@@ -231,17 +277,14 @@ func TestMiddleware_Handler_ProcessPayment(t *testing.T) {
 			assert.True(t, info.Accepted)
 		}))
 
-		paymentData := payment.Payment{
-			ModeID:           "bsv-direct",
-			DerivationPrefix: fixtures.MockNonce,
-			DerivationSuffix: "test-suffix",
-			Transaction:      []byte{1, 2, 3, 4},
-		}
+		sender, err := ec.NewPrivateKey()
+		require.NoError(t, err)
+		paymentData := preparePayment(t, sender, key, 100)
 		paymentJSON, err := json.Marshal(paymentData)
 		require.NoError(t, err)
 
 		req := httptest.NewRequest("GET", "/", nil)
-		req = addIdentityToContext(req, "test-identity-key")
+		req = addIdentityToContext(req, sender.PubKey().ToDERHex())
 		req.Header.Set(payment.HeaderPayment, string(paymentJSON))
 
 		w := httptest.NewRecorder()
@@ -255,9 +298,9 @@ func TestMiddleware_Handler_ProcessPayment(t *testing.T) {
 		assert.True(t, mockWallet.InternalizeActionCalled)
 		require.NotEmpty(t, mockWallet.InternalizeActionArgs.Outputs)
 		require.NotNil(t, mockWallet.InternalizeActionArgs.Outputs[0].PaymentRemittance)
-		assert.Equal(t, fixtures.MockNonce, mockWallet.InternalizeActionArgs.Outputs[0].PaymentRemittance.DerivationPrefix)
+		assert.Equal(t, wallettest.MockNonce, mockWallet.InternalizeActionArgs.Outputs[0].PaymentRemittance.DerivationPrefix)
 		assert.Equal(t, "test-suffix", mockWallet.InternalizeActionArgs.Outputs[0].PaymentRemittance.DerivationSuffix)
-		assert.Equal(t, "test-identity-key", mockWallet.InternalizeActionArgs.Outputs[0].PaymentRemittance.SenderIdentityKey)
+		assert.Equal(t, sender.PubKey().ToDERHex(), mockWallet.InternalizeActionArgs.Outputs[0].PaymentRemittance.SenderIdentityKey)
 	})
 
 	t.Run("wallet returns error", func(t *testing.T) {
@@ -266,8 +309,8 @@ func TestMiddleware_Handler_ProcessPayment(t *testing.T) {
 		if err != nil {
 			require.NoError(t, err)
 		}
-		mockWallet := wallet.NewMockPaymentWallet(key)
-		mockWalletSetup(t, mockWallet, fixtures.MockNonce)
+		mockWallet := wallettest.NewMockPaymentWallet(key)
+		mockWalletSetup(t, mockWallet, wallettest.MockNonce)
 		mockWallet.SetInternalizeActionError(expectedError)
 
 		middleware, err := payment.New(payment.Options{
@@ -280,17 +323,14 @@ func TestMiddleware_Handler_ProcessPayment(t *testing.T) {
 			handlerCalled = true
 		}))
 
-		paymentData := payment.Payment{
-			ModeID:           "bsv-direct",
-			DerivationPrefix: fixtures.MockNonce,
-			DerivationSuffix: "test-suffix",
-			Transaction:      []byte{1, 2, 3, 4},
-		}
+		sender, err := ec.NewPrivateKey()
+		require.NoError(t, err)
+		paymentData := preparePayment(t, sender, key, 100)
 		paymentJSON, err := json.Marshal(paymentData)
 		require.NoError(t, err)
 
 		req := httptest.NewRequest("GET", "/", nil)
-		req = addIdentityToContext(req, "test-identity-key")
+		req = addIdentityToContext(req, sender.PubKey().ToDERHex())
 		req.Header.Set(payment.HeaderPayment, string(paymentJSON))
 
 		w := httptest.NewRecorder()
@@ -307,4 +347,432 @@ func TestMiddleware_Handler_ProcessPayment(t *testing.T) {
 		assert.Equal(t, payment.ErrCodePaymentFailed, resp["code"])
 		assert.Contains(t, resp["description"].(string), expectedError.Error())
 	})
+
+	t.Run("derivation prefix not issued by the wallet", func(t *testing.T) {
+		// given
+		key, err := ec.NewPrivateKey()
+		require.NoError(t, err)
+		mockWallet := wallettest.NewMockPaymentWallet(key)
+
+		middleware, err := payment.New(payment.Options{
+			Wallet: mockWallet,
+		})
+		require.NoError(t, err)
+
+		var handlerCalled bool
+		handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlerCalled = true
+		}))
+
+		sender, err := ec.NewPrivateKey()
+		require.NoError(t, err)
+		paymentJSON, err := json.Marshal(preparePayment(t, sender, key, 100))
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req = addIdentityToContext(req, sender.PubKey().ToDERHex())
+		req.Header.Set(payment.HeaderPayment, string(paymentJSON))
+		w := httptest.NewRecorder()
+
+		// when
+		handler.ServeHTTP(w, req)
+
+		// then
+		assert.False(t, handlerCalled)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var resp map[string]any
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(t, payment.ErrCodePaymentFailed, resp["code"])
+		assert.Contains(t, resp["description"].(string), payment.ErrInvalidDerivationPrefix.Error())
+		assert.Contains(t, resp["description"].(string), wallet.ErrNonceInvalid.Error())
+	})
+}
+
+func TestMiddleware_Handler_TermsRedemption(t *testing.T) {
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	sender, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	sendPayment := func(handler http.Handler, paymentData payment.Payment) *httptest.ResponseRecorder {
+		paymentJSON, err := json.Marshal(paymentData)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req = addIdentityToContext(req, sender.PubKey().ToDERHex())
+		req.Header.Set(payment.HeaderPayment, string(paymentJSON))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("terms pay for one request only", func(t *testing.T) {
+		// given
+		mockWallet := wallettest.NewMockPaymentWallet(key)
+		mockWalletSetup(t, mockWallet, wallettest.MockNonce)
+		ledger := payment.NewMemoryLedger()
+
+		middleware, err := payment.New(payment.Options{Wallet: mockWallet, Ledger: ledger})
+		require.NoError(t, err)
+
+		var handlerCalls int
+		handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handlerCalls++
+		}))
+
+		paymentData := preparePayment(t, sender, key, 100)
+		require.Equal(t, http.StatusOK, sendPayment(handler, paymentData).Code)
+
+		// when
+		w := sendPayment(handler, paymentData)
+
+		// then
+		assert.Equal(t, 1, handlerCalls)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var resp map[string]any
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(t, payment.ErrCodeTermsRedeemed, resp["code"])
+
+		balance, err := ledger.Balance(context.Background(), sender.PubKey().ToDERHex())
+		require.NoError(t, err)
+		assert.Equal(t, int64(100), balance)
+	})
+
+	t.Run("terms of a failed payment can be redeemed again", func(t *testing.T) {
+		// given
+		mockWallet := wallettest.NewMockPaymentWallet(key)
+		mockWalletSetup(t, mockWallet, wallettest.MockNonce)
+		mockWallet.SetInternalizeActionError(errors.New("wallet unavailable"))
+
+		middleware, err := payment.New(payment.Options{Wallet: mockWallet})
+		require.NoError(t, err)
+
+		handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		paymentData := preparePayment(t, sender, key, 100)
+		require.Equal(t, http.StatusBadRequest, sendPayment(handler, paymentData).Code)
+		mockWallet.SetInternalizeActionError(nil)
+
+		// when
+		w := sendPayment(handler, paymentData)
+
+		// then
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestNewMiddleware_Network(t *testing.T) {
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	t.Run("Accepts wallet on configured network", func(t *testing.T) {
+		mockWallet := wallettest.NewMockPaymentWallet(key)
+		mockWallet.SetNetwork(wallet.NetworkTestnet)
+
+		middleware, err := payment.New(payment.Options{Wallet: mockWallet, Network: wallet.NetworkTestnet})
+
+		require.NoError(t, err)
+		assert.NotNil(t, middleware)
+	})
+
+	t.Run("Returns error when wallet is on different network", func(t *testing.T) {
+		mockWallet := wallettest.NewMockPaymentWallet(key)
+
+		_, err := payment.New(payment.Options{Wallet: mockWallet, Network: wallet.NetworkTestnet})
+
+		assert.ErrorIs(t, err, payment.ErrNetworkMismatch)
+	})
+
+	t.Run("Returns error for unknown network", func(t *testing.T) {
+		mockWallet := wallettest.NewMockPaymentWallet(key)
+
+		_, err := payment.New(payment.Options{Wallet: mockWallet, Network: "signet"})
+
+		assert.ErrorIs(t, err, payment.ErrUnknownNetwork)
+	})
+}
+
+func TestMiddleware_Handler_Network(t *testing.T) {
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	newHandler := func(t *testing.T, mockWallet *wallettest.MockPaymentWallet, handlerCalled *bool) http.Handler {
+		middleware, err := payment.New(payment.Options{
+			Wallet:  mockWallet,
+			Network: wallet.NetworkMainnet,
+			CalculateRequestPrice: func(r *http.Request) (int, error) {
+				return 100, nil
+			},
+		})
+		require.NoError(t, err)
+
+		return middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*handlerCalled = true
+		}))
+	}
+
+	t.Run("Payment terms contain configured network", func(t *testing.T) {
+		var handlerCalled bool
+		handler := newHandler(t, wallettest.NewMockPaymentWallet(key), &handlerCalled)
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req = addIdentityToContext(req, testIdentityKey)
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusPaymentRequired, w.Code)
+
+		var terms payment.PaymentTerms
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&terms))
+		assert.Equal(t, wallet.NetworkMainnet, terms.Chain)
+	})
+
+	t.Run("Rejects payment made on different network", func(t *testing.T) {
+		mockWallet := wallettest.NewMockPaymentWallet(key)
+		mockWalletSetup(t, mockWallet, wallettest.MockNonce)
+
+		var handlerCalled bool
+		handler := newHandler(t, mockWallet, &handlerCalled)
+
+		paymentJSON, err := json.Marshal(payment.Payment{
+			ModeID:           "bsv-direct",
+			DerivationPrefix: wallettest.MockNonce,
+			DerivationSuffix: "test-suffix",
+			Transaction:      []byte{1, 2, 3, 4},
+			Chain:            wallet.NetworkTestnet,
+		})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req = addIdentityToContext(req, testIdentityKey)
+		req.Header.Set(payment.HeaderPayment, string(paymentJSON))
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.False(t, handlerCalled)
+		assert.False(t, mockWallet.InternalizeActionCalled)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var resp map[string]any
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(t, payment.ErrCodeNetworkMismatch, resp["code"])
+	})
+
+	t.Run("Rejects terms of another network relabeled as the configured one", func(t *testing.T) {
+		mockWallet := wallettest.NewMockPaymentWallet(key)
+		mockWalletSetup(t, mockWallet, wallettest.MockNonce)
+
+		var handlerCalled bool
+		handler := newHandler(t, mockWallet, &handlerCalled)
+
+		terms := payment.NewPaymentTerms(100, wallettest.MockNonce, "/")
+		terms.ExpirationTimestamp = time.Now().Add(time.Minute).Unix()
+		terms.Chain = wallet.NetworkTestnet
+		require.NoError(t, payment.SignTerms(wallettest.NewMockWallet(key), &terms, testIdentityKey))
+
+		paymentData := payment.Payment{
+			ModeID:           "bsv-direct",
+			DerivationPrefix: wallettest.MockNonce,
+			DerivationSuffix: "test-suffix",
+			Transaction:      []byte{1, 2, 3, 4},
+		}
+		paymentData.AttachTerms(terms)
+		paymentData.Chain = wallet.NetworkMainnet
+		paymentJSON, err := json.Marshal(paymentData)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req = addIdentityToContext(req, testIdentityKey)
+		req.Header.Set(payment.HeaderPayment, string(paymentJSON))
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+
+		assert.False(t, handlerCalled)
+		assert.False(t, mockWallet.InternalizeActionCalled)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var resp map[string]any
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(t, payment.ErrCodeInvalidTerms, resp["code"])
+	})
+}
+
+func TestMiddleware_Handler_VerifyPaymentOutput(t *testing.T) {
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	sender, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	otherServer, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		payment     func(t *testing.T) payment.Payment
+		description string
+	}{
+		{
+			name: "output pays key derived from different identity",
+			payment: func(t *testing.T) payment.Payment {
+				paymentData := preparePayment(t, sender, otherServer, 100)
+				attachSignedTerms(t, &paymentData, sender, key, 100, time.Now().Add(time.Minute))
+				return paymentData
+			},
+			description: payment.ErrPaymentOutputNotFound.Error(),
+		},
+		{
+			name: "output derived with different suffix",
+			payment: func(t *testing.T) payment.Payment {
+				paymentData := preparePayment(t, sender, key, 100)
+				paymentData.DerivationSuffix = "other-suffix"
+				return paymentData
+			},
+			description: payment.ErrPaymentOutputNotFound.Error(),
+		},
+		{
+			name: "output pays less than price",
+			payment: func(t *testing.T) payment.Payment {
+				paymentData := preparePayment(t, sender, key, 99)
+				attachSignedTerms(t, &paymentData, sender, key, 100, time.Now().Add(time.Minute))
+				return paymentData
+			},
+			description: payment.ErrInsufficientPayment.Error(),
+		},
+		{
+			name: "transaction is not parseable",
+			payment: func(t *testing.T) payment.Payment {
+				paymentData := preparePayment(t, sender, key, 100)
+				paymentData.Transaction = []byte{1, 2, 3, 4}
+				return paymentData
+			},
+			description: payment.ErrInvalidTransaction.Error(),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// given
+			mockWallet := wallettest.NewMockPaymentWallet(key)
+			mockWalletSetup(t, mockWallet, wallettest.MockNonce)
+
+			middleware, err := payment.New(payment.Options{
+				Wallet: mockWallet,
+				CalculateRequestPrice: func(r *http.Request) (int, error) {
+					return 100, nil
+				},
+			})
+			require.NoError(t, err)
+
+			var handlerCalled bool
+			handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlerCalled = true
+			}))
+
+			paymentJSON, err := json.Marshal(test.payment(t))
+			require.NoError(t, err)
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req = addIdentityToContext(req, sender.PubKey().ToDERHex())
+			req.Header.Set(payment.HeaderPayment, string(paymentJSON))
+			w := httptest.NewRecorder()
+
+			// when
+			handler.ServeHTTP(w, req)
+
+			// then
+			assert.False(t, handlerCalled)
+			assert.False(t, mockWallet.InternalizeActionCalled)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var resp map[string]any
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			assert.Equal(t, payment.ErrCodePaymentFailed, resp["code"])
+			assert.Contains(t, resp["description"], test.description)
+		})
+	}
+}
+
+func TestMiddleware_Handler_ChainTracker(t *testing.T) {
+	const height = 100
+
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	sender, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		blocks      func(tracker *chaintracker.InMemory, root chainhash.Hash)
+		wantStatus  int
+		wantCode    string
+		internalize bool
+	}{
+		"payment with a valid proof is accepted": {
+			blocks:      func(tracker *chaintracker.InMemory, root chainhash.Hash) { tracker.AddBlock(height, root) },
+			wantStatus:  http.StatusOK,
+			internalize: true,
+		},
+		"payment with an invalid proof is rejected": {
+			blocks:     func(tracker *chaintracker.InMemory, root chainhash.Hash) {},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   payment.ErrCodeInvalidProof,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			mockWallet := wallettest.NewMockPaymentWallet(key)
+			mockWalletSetup(t, mockWallet, wallettest.MockNonce)
+
+			paymentData := preparePayment(t, sender, key, 100)
+			tx, err := transaction.NewTransactionFromBEEF(paymentData.Transaction)
+			require.NoError(t, err)
+			source := tx.Inputs[0].SourceTransaction
+			proveAtHeight(height)(source)
+			paymentData.Transaction, err = tx.AtomicBEEF(false)
+			require.NoError(t, err)
+
+			tracker := chaintracker.NewInMemory()
+			test.blocks(tracker, *source.TxID())
+
+			middleware, err := payment.New(payment.Options{
+				Wallet:       mockWallet,
+				ChainTracker: tracker,
+				CalculateRequestPrice: func(r *http.Request) (int, error) {
+					return 100, nil
+				},
+			})
+			require.NoError(t, err)
+
+			var handlerCalled bool
+			handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlerCalled = true
+			}))
+
+			paymentJSON, err := json.Marshal(paymentData)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req = addIdentityToContext(req, sender.PubKey().ToDERHex())
+			req.Header.Set(payment.HeaderPayment, string(paymentJSON))
+			w := httptest.NewRecorder()
+
+			// when
+			handler.ServeHTTP(w, req)
+
+			// then
+			assert.Equal(t, test.wantStatus, w.Code)
+			assert.Equal(t, test.internalize, handlerCalled)
+			assert.Equal(t, test.internalize, mockWallet.InternalizeActionCalled)
+			if test.wantCode != "" {
+				var resp map[string]any
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+				assert.Equal(t, test.wantCode, resp["code"])
+			}
+		})
+	}
 }
@@ -1,13 +1,19 @@
 package payment
 
 import (
+	"log/slog"
 	"net/http"
+	"time"
 
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/chaintracker"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/defs"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/events"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
 )
 
 const (
-	defaultPrice = 100
+	defaultPrice    = 100
+	defaultTermsTTL = 15 * time.Minute
 )
 
 // Options configures the payment middleware
@@ -17,6 +23,44 @@ type Options struct {
 
 	// CalculateRequestPrice determines the cost in satoshis for a request
 	CalculateRequestPrice func(r *http.Request) (int, error)
+
+	// TermsTTL is how long payment terms can be redeemed after they were issued, defaults to 15 minutes.
+	// Terms quoting less than the current price of the request are rejected even before they expire.
+	TermsTTL time.Duration
+
+	// Network is the BSV network payments have to be made on, it is validated against the wallet network
+	// and embedded in payment terms. Payments are not checked against a network when empty.
+	Network wallet.Network
+
+	// ChainTracker verifies the merkle proofs of payment transactions, which have to be encoded as BEEF,
+	// against the chain, e.g. chaintracker.NewWhatsOnChain of the configured Network. Proofs are not verified when nil,
+	// leaving it to the wallet internalizing the payment.
+	ChainTracker chaintracker.Interface
+
+	// RefundPolicy defines what happens with a payment when the paid handler responds with a 5xx status,
+	// defaults to RefundPolicyNone. Refund decisions are available from Middleware.Refunds.
+	RefundPolicy RefundPolicy
+
+	// EnableQuotes lets clients fetch the payment terms of a request without executing it by sending the HeaderQuote header.
+	// Quotes are responded with 200, so the auth middleware signs them like any other response.
+	EnableQuotes bool
+
+	// Ledger records accepted payments as credits and refunds as debits on the account of the sender, optional
+	Ledger Ledger
+
+	// Clock returns the current time used to issue payment terms and to check their expiry, defaults to time.Now.
+	// Terms can be redeemed until the end of the second of their expiration timestamp.
+	Clock func() time.Time
+
+	// Events receives the accepted payments and the refunds of failed paid requests, see events.TopicPayment,
+	// usually the bus of the auth middleware (auth.Middleware.Events)
+	Events *events.Bus
+
+	// Logger is used for payment processing and refunds, defaults to the package logger
+	Logger *slog.Logger
+
+	// Logging configures the level and debug sampling of the payments subsystem
+	Logging defs.LogConfig
 }
 
 // DefaultPriceFunc returns a basic pricing function that applies a flat rate
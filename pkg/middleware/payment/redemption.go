@@ -0,0 +1,58 @@
+package payment
+
+import (
+	"sync"
+	"time"
+)
+
+// redemptionPruneInterval is how often the derivation prefixes of expired terms are forgotten
+const redemptionPruneInterval = time.Minute
+
+// redemptions remembers the derivation prefixes of redeemed payment terms until the terms expire, so one payment
+// cannot pay for more than one request. Expired terms are rejected anyway, so their prefixes can be forgotten.
+type redemptions struct {
+	mu        sync.Mutex
+	prefixes  map[string]int64
+	lastPrune time.Time
+}
+
+func newRedemptions() *redemptions {
+	return &redemptions{prefixes: make(map[string]int64)}
+}
+
+// claim marks the derivation prefix of terms expiring at the expiration timestamp as redeemed,
+// it returns false when the prefix was already redeemed
+func (r *redemptions) claim(derivationPrefix string, expirationTimestamp int64, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune(now)
+
+	if _, ok := r.prefixes[derivationPrefix]; ok {
+		return false
+	}
+	r.prefixes[derivationPrefix] = expirationTimestamp
+	return true
+}
+
+// release forgets the derivation prefix of a payment which was not accepted, so the terms can be redeemed
+// with a corrected payment
+func (r *redemptions) release(derivationPrefix string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.prefixes, derivationPrefix)
+}
+
+func (r *redemptions) prune(now time.Time) {
+	if now.Sub(r.lastPrune) < redemptionPruneInterval {
+		return
+	}
+	r.lastPrune = now
+
+	for prefix, expirationTimestamp := range r.prefixes {
+		if now.Unix() > expirationTimestamp {
+			delete(r.prefixes, prefix)
+		}
+	}
+}
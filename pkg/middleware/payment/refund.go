@@ -0,0 +1,282 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/events"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+)
+
+// RefundPolicy defines what happens with an internalized payment when the paid handler responds with a 5xx status
+type RefundPolicy int
+
+const (
+	// RefundPolicyNone keeps the payment, the decision is still recorded
+	RefundPolicyNone RefundPolicy = iota
+	// RefundPolicyAuto refunds the payment to the sender right after the handler failed
+	RefundPolicyAuto
+	// RefundPolicyManual queues the refund until it is approved or rejected by the operator
+	RefundPolicyManual
+)
+
+// RefundStatus is the state of a refund decision
+type RefundStatus string
+
+// Refund statuses
+const (
+	RefundStatusSkipped    RefundStatus = "skipped"
+	RefundStatusQueued     RefundStatus = "queued"
+	RefundStatusProcessing RefundStatus = "processing"
+	RefundStatusRefunded   RefundStatus = "refunded"
+	RefundStatusRejected   RefundStatus = "rejected"
+	RefundStatusFailed     RefundStatus = "failed"
+)
+
+// refundDerivationSuffix is the derivation suffix of refund outputs, the prefix is a fresh nonce
+const refundDerivationSuffix = "refund"
+
+// Errors returned by the manual refund operations
+var (
+	ErrRefundNotFound  = errors.New("refund not found")
+	ErrRefundNotQueued = errors.New("refund is not queued")
+)
+
+// Refund records the refund decision for a payment whose handler failed after the payment was internalized
+type Refund struct {
+	// ID identifies the refund, it is the transaction ID of the payment
+	ID string
+	// SenderIdentityKey is the identity key of the peer which paid, the refund pays a key derived from it
+	SenderIdentityKey string
+	// Satoshis is the refunded amount
+	Satoshis int
+	// HandlerStatus is the status the paid handler responded with
+	HandlerStatus int
+	// Status is the state of the refund
+	Status RefundStatus
+	// DerivationPrefix and DerivationSuffix derive the key of the refund output (BRC-29), so the sender can internalize it
+	DerivationPrefix string
+	DerivationSuffix string
+	// RefundTransactionID is the transaction ID of the refund
+	RefundTransactionID string
+	// Error is the reason of a failed refund
+	Error string
+	// CreatedAt is the time of the handler failure
+	CreatedAt time.Time
+}
+
+// refundLedger records refund decisions in memory
+type refundLedger struct {
+	mu      sync.Mutex
+	refunds []*Refund
+	byID    map[string]*Refund
+}
+
+func newRefundLedger() *refundLedger {
+	return &refundLedger{byID: make(map[string]*Refund)}
+}
+
+func (l *refundLedger) add(refund *Refund) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refunds = append(l.refunds, refund)
+	l.byID[refund.ID] = refund
+}
+
+// transition moves the queued refund to the given status
+func (l *refundLedger) transition(id string, status RefundStatus) (*Refund, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	refund, ok := l.byID[id]
+	if !ok {
+		return nil, ErrRefundNotFound
+	}
+	if refund.Status != RefundStatusQueued {
+		return nil, fmt.Errorf("%w: %s", ErrRefundNotQueued, refund.Status)
+	}
+
+	refund.Status = status
+	return refund, nil
+}
+
+func (l *refundLedger) update(refund *Refund, update func(r *Refund)) Refund {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	update(refund)
+	return *refund
+}
+
+// snapshot returns a copy of the refund
+func (l *refundLedger) snapshot(refund *Refund) Refund {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return *refund
+}
+
+func (l *refundLedger) list() []Refund {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	refunds := make([]Refund, 0, len(l.refunds))
+	for _, refund := range l.refunds {
+		refunds = append(refunds, *refund)
+	}
+	return refunds
+}
+
+// Refunds returns the refund decisions recorded for failed paid requests
+func (m *Middleware) Refunds() []Refund {
+	return m.refunds.list()
+}
+
+// ApproveRefund refunds a payment queued by RefundPolicyManual
+func (m *Middleware) ApproveRefund(ctx context.Context, id string) (Refund, error) {
+	refund, err := m.refunds.transition(id, RefundStatusProcessing)
+	if err != nil {
+		return Refund{}, err
+	}
+
+	return m.refund(ctx, refund), nil
+}
+
+// RejectRefund rejects a payment refund queued by RefundPolicyManual
+func (m *Middleware) RejectRefund(id string) (Refund, error) {
+	refund, err := m.refunds.transition(id, RefundStatusRejected)
+	if err != nil {
+		return Refund{}, err
+	}
+
+	return m.refunds.update(refund, func(*Refund) {}), nil
+}
+
+// handleFailedPaidRequest records the refund decision for a payment whose handler failed and applies the refund policy
+func (m *Middleware) handleFailedPaidRequest(ctx context.Context, identityKey string, paymentInfo *PaymentInfo, status int) {
+	refund := &Refund{
+		ID:                paymentInfo.TransactionID,
+		SenderIdentityKey: identityKey,
+		Satoshis:          paymentInfo.SatoshisPaid,
+		HandlerStatus:     status,
+		CreatedAt:         time.Now(),
+	}
+
+	switch m.refundPolicy {
+	case RefundPolicyAuto:
+		refund.Status = RefundStatusProcessing
+		m.refunds.add(refund)
+		m.refund(ctx, refund)
+	case RefundPolicyManual:
+		refund.Status = RefundStatusQueued
+		m.refunds.add(refund)
+		m.logger.Info("Refund queued for failed paid request", slog.String("refund", refund.ID), slog.Int("status", status))
+	default:
+		refund.Status = RefundStatusSkipped
+		m.refunds.add(refund)
+	}
+
+	m.events.Publish(ctx, events.Event{
+		Topic: events.TopicPayment, Type: events.TypePaymentRefund, IdentityKey: identityKey, Data: m.refunds.snapshot(refund),
+	})
+}
+
+// refund pays the refunded amount back to a key derived from the sender identity key
+func (m *Middleware) refund(ctx context.Context, refund *Refund) Refund {
+	txID, prefix, err := m.createRefundAction(ctx, refund)
+	if err != nil {
+		m.logger.Error("Refund failed", slog.String("refund", refund.ID), slog.String("error", err.Error()))
+		return m.refunds.update(refund, func(r *Refund) {
+			r.Status = RefundStatusFailed
+			r.Error = err.Error()
+		})
+	}
+
+	if m.ledger != nil {
+		if _, err := m.ledger.Debit(ctx, refund.SenderIdentityKey, int64(refund.Satoshis), txID); err != nil {
+			m.logger.Error("Failed to record refund in ledger", slog.String("refund", refund.ID), slog.String("error", err.Error()))
+		}
+	}
+
+	return m.refunds.update(refund, func(r *Refund) {
+		r.Status = RefundStatusRefunded
+		r.DerivationPrefix = prefix
+		r.DerivationSuffix = refundDerivationSuffix
+		r.RefundTransactionID = txID
+		r.Error = ""
+	})
+}
+
+func (m *Middleware) createRefundAction(ctx context.Context, refund *Refund) (txID, derivationPrefix string, err error) {
+	derivationPrefix, err = m.wallet.CreateNonce(ctx)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create derivation prefix, %w", err)
+	}
+
+	lockingScript, err := DerivedLockingScript(m.wallet, refund.SenderIdentityKey, derivationPrefix, refundDerivationSuffix, false)
+	if err != nil {
+		return "", "", err
+	}
+
+	instructions, err := json.Marshal(map[string]string{
+		"derivationPrefix": derivationPrefix,
+		"derivationSuffix": refundDerivationSuffix,
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode refund instructions, %w", err)
+	}
+
+	result, err := m.wallet.CreateAction(ctx, wallet.CreateActionArgs{
+		Description: "Refund for failed request",
+		Outputs: []wallet.CreateActionOutput{
+			{
+				LockingScript:      lockingScript.String(),
+				Satoshis:           uint64(refund.Satoshis), //nolint:gosec // paid amount is never negative
+				OutputDescription:  "Refund of payment " + refund.ID,
+				CustomInstructions: string(instructions),
+			},
+		},
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to create refund action, %w", err)
+	}
+
+	return result.TxID, derivationPrefix, nil
+}
+
+// statusRecorder captures the status written by the paid handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+// WriteHeader records the status and writes it
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Write writes the body, recording the implicit 200 status
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if !r.wroteHeader {
+		r.status = http.StatusOK
+		r.wroteHeader = true
+	}
+	return r.ResponseWriter.Write(b) //nolint:wrapcheck // the writer is only wrapped to capture the status
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,149 @@
+package payment_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/payment"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware_Refund(t *testing.T) {
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	sender, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	serve := func(t *testing.T, policy payment.RefundPolicy, handlerStatus int) (*payment.Middleware, *wallettest.MockPaymentWallet) {
+		t.Helper()
+
+		mockWallet := wallettest.NewMockPaymentWallet(key)
+		mockWalletSetup(t, mockWallet, wallettest.MockNonce)
+
+		middleware, err := payment.New(payment.Options{
+			Wallet:       mockWallet,
+			RefundPolicy: policy,
+			CalculateRequestPrice: func(r *http.Request) (int, error) {
+				return 100, nil
+			},
+		})
+		require.NoError(t, err)
+
+		handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(handlerStatus)
+		}))
+
+		paymentJSON, err := json.Marshal(preparePayment(t, sender, key, 100))
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req = addIdentityToContext(req, sender.PubKey().ToDERHex())
+		req.Header.Set(payment.HeaderPayment, string(paymentJSON))
+		w := httptest.NewRecorder()
+
+		handler.ServeHTTP(w, req)
+		require.Equal(t, handlerStatus, w.Code)
+
+		return middleware, mockWallet
+	}
+
+	t.Run("successful handler records no refund", func(t *testing.T) {
+		middleware, mockWallet := serve(t, payment.RefundPolicyAuto, http.StatusOK)
+
+		assert.Empty(t, middleware.Refunds())
+		assert.False(t, mockWallet.CreateActionCalled)
+	})
+
+	t.Run("none policy records skipped refund", func(t *testing.T) {
+		middleware, mockWallet := serve(t, payment.RefundPolicyNone, http.StatusInternalServerError)
+
+		refunds := middleware.Refunds()
+		require.Len(t, refunds, 1)
+		assert.Equal(t, payment.RefundStatusSkipped, refunds[0].Status)
+		assert.Equal(t, http.StatusInternalServerError, refunds[0].HandlerStatus)
+		assert.False(t, mockWallet.CreateActionCalled)
+	})
+
+	t.Run("auto policy refunds to key derived from sender", func(t *testing.T) {
+		middleware, mockWallet := serve(t, payment.RefundPolicyAuto, http.StatusBadGateway)
+
+		refunds := middleware.Refunds()
+		require.Len(t, refunds, 1)
+		refund := refunds[0]
+		assert.Equal(t, payment.RefundStatusRefunded, refund.Status)
+		assert.Equal(t, wallettest.MockCreateActionTxID, refund.RefundTransactionID)
+		assert.Equal(t, sender.PubKey().ToDERHex(), refund.SenderIdentityKey)
+		assert.Equal(t, 100, refund.Satoshis)
+
+		require.True(t, mockWallet.CreateActionCalled)
+		require.Len(t, mockWallet.CreateActionArgs.Outputs, 1)
+		output := mockWallet.CreateActionArgs.Outputs[0]
+		assert.Equal(t, uint64(100), output.Satoshis)
+
+		senderScript, err := payment.DerivedLockingScript(wallettest.NewMockWallet(sender), key.PubKey().ToDERHex(), refund.DerivationPrefix, refund.DerivationSuffix, true)
+		require.NoError(t, err)
+		assert.Equal(t, senderScript.String(), output.LockingScript)
+	})
+
+	t.Run("auto policy records failed refund", func(t *testing.T) {
+		mockWallet := wallettest.NewMockPaymentWallet(key)
+		mockWallet.SetCreateActionError(errors.New("insufficient funds"))
+		mockWalletSetup(t, mockWallet, wallettest.MockNonce)
+
+		middleware, err := payment.New(payment.Options{Wallet: mockWallet, RefundPolicy: payment.RefundPolicyAuto})
+		require.NoError(t, err)
+
+		handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+
+		paymentJSON, err := json.Marshal(preparePayment(t, sender, key, 100))
+		require.NoError(t, err)
+		req := httptest.NewRequest("GET", "/", nil)
+		req = addIdentityToContext(req, sender.PubKey().ToDERHex())
+		req.Header.Set(payment.HeaderPayment, string(paymentJSON))
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		refunds := middleware.Refunds()
+		require.Len(t, refunds, 1)
+		assert.Equal(t, payment.RefundStatusFailed, refunds[0].Status)
+		assert.Contains(t, refunds[0].Error, "insufficient funds")
+	})
+
+	t.Run("manual policy queues refund until approved", func(t *testing.T) {
+		middleware, mockWallet := serve(t, payment.RefundPolicyManual, http.StatusInternalServerError)
+
+		refunds := middleware.Refunds()
+		require.Len(t, refunds, 1)
+		assert.Equal(t, payment.RefundStatusQueued, refunds[0].Status)
+		assert.False(t, mockWallet.CreateActionCalled)
+
+		refund, err := middleware.ApproveRefund(context.Background(), refunds[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, payment.RefundStatusRefunded, refund.Status)
+		assert.True(t, mockWallet.CreateActionCalled)
+
+		_, err = middleware.ApproveRefund(context.Background(), refunds[0].ID)
+		assert.ErrorIs(t, err, payment.ErrRefundNotQueued)
+	})
+
+	t.Run("manual policy refund can be rejected", func(t *testing.T) {
+		middleware, mockWallet := serve(t, payment.RefundPolicyManual, http.StatusInternalServerError)
+
+		refund, err := middleware.RejectRefund(middleware.Refunds()[0].ID)
+		require.NoError(t, err)
+		assert.Equal(t, payment.RefundStatusRejected, refund.Status)
+		assert.False(t, mockWallet.CreateActionCalled)
+
+		_, err = middleware.RejectRefund("unknown")
+		assert.ErrorIs(t, err, payment.ErrRefundNotFound)
+	})
+}
@@ -0,0 +1,89 @@
+package payment
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// termsSigningPayload returns the data covered by the signature of payment terms
+func termsSigningPayload(satoshisRequired int, derivationPrefix string, expirationTimestamp int64, chain wallet.Network) []byte {
+	return fmt.Appendf(nil, "%d\n%s\n%d\n%s", satoshisRequired, derivationPrefix, expirationTimestamp, chain)
+}
+
+// SignTerms signs the price, derivation prefix, expiration and network of the terms for the peer with the given identity key
+func SignTerms(w wallet.WalletInterface, terms *PaymentTerms, counterpartyIdentityKey string) error {
+	counterparty, err := ec.PublicKeyFromString(counterpartyIdentityKey)
+	if err != nil {
+		return fmt.Errorf("invalid counterparty identity key, %w", err)
+	}
+
+	result, err := w.CreateSignature(&wallet.CreateSignatureArgs{
+		EncryptionArgs: termsEncryptionArgs(terms.DerivationPrefix, counterparty),
+		Data:           termsSigningPayload(terms.SatoshisRequired, terms.DerivationPrefix, terms.ExpirationTimestamp, terms.Chain),
+	}, "")
+	if err != nil {
+		return fmt.Errorf("failed to sign payment terms, %w", err)
+	}
+
+	terms.Signature = hex.EncodeToString(result.Signature.Serialize())
+	return nil
+}
+
+// VerifySignature verifies the terms were signed by the server with the given identity key, using the wallet of the client
+func (t PaymentTerms) VerifySignature(w wallet.WalletInterface, serverIdentityKey string) error {
+	return verifyTermsSignature(w, serverIdentityKey,
+		termsSigningPayload(t.SatoshisRequired, t.DerivationPrefix, t.ExpirationTimestamp, t.Chain), t.DerivationPrefix, t.Signature, false)
+}
+
+// verifyRedeemedTerms verifies the terms echoed in the payment were signed by the server for the sender
+func verifyRedeemedTerms(w wallet.WalletInterface, p *Payment, senderIdentityKey string) error {
+	return verifyTermsSignature(w, senderIdentityKey,
+		termsSigningPayload(p.SatoshisRequired, p.DerivationPrefix, p.ExpirationTimestamp, p.Chain), p.DerivationPrefix, p.TermsSignature, true)
+}
+
+func verifyTermsSignature(w wallet.WalletInterface, counterpartyIdentityKey string, payload []byte, derivationPrefix, signatureHex string, forSelf bool) error {
+	if signatureHex == "" {
+		return fmt.Errorf("%w: missing signature", ErrInvalidTermsSignature)
+	}
+
+	counterparty, err := ec.PublicKeyFromString(counterpartyIdentityKey)
+	if err != nil {
+		return fmt.Errorf("%w: invalid counterparty identity key", ErrInvalidTermsSignature)
+	}
+
+	signatureBytes, err := hex.DecodeString(signatureHex)
+	if err != nil {
+		return fmt.Errorf("%w: invalid signature encoding", ErrInvalidTermsSignature)
+	}
+
+	signature, err := ec.ParseSignature(signatureBytes)
+	if err != nil {
+		return fmt.Errorf("%w: invalid signature encoding", ErrInvalidTermsSignature)
+	}
+
+	result, err := w.VerifySignature(&wallet.VerifySignatureArgs{
+		EncryptionArgs: termsEncryptionArgs(derivationPrefix, counterparty),
+		Data:           payload,
+		Signature:      *signature,
+		ForSelf:        forSelf,
+	})
+	if err != nil || !result.Valid {
+		return ErrInvalidTermsSignature
+	}
+
+	return nil
+}
+
+func termsEncryptionArgs(derivationPrefix string, counterparty *ec.PublicKey) wallet.EncryptionArgs {
+	return wallet.EncryptionArgs{
+		ProtocolID: wallet.PaymentTermsProtocol,
+		KeyID:      derivationPrefix,
+		Counterparty: wallet.Counterparty{
+			Type:         wallet.CounterpartyTypeOther,
+			Counterparty: counterparty,
+		},
+	}
+}
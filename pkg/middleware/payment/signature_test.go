@@ -0,0 +1,312 @@
+package payment_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/payment"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentTerms_VerifySignature(t *testing.T) {
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	sender, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	middleware, err := payment.New(payment.Options{
+		Wallet: wallettest.NewMockPaymentWallet(key),
+		CalculateRequestPrice: func(r *http.Request) (int, error) {
+			return 100, nil
+		},
+	})
+	require.NoError(t, err)
+
+	requestTerms := func(t *testing.T) payment.PaymentTerms {
+		req := httptest.NewRequest("GET", "/", nil)
+		req = addIdentityToContext(req, sender.PubKey().ToDERHex())
+		w := httptest.NewRecorder()
+
+		middleware.Handler(http.NotFoundHandler()).ServeHTTP(w, req)
+		require.Equal(t, http.StatusPaymentRequired, w.Code)
+
+		var terms payment.PaymentTerms
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&terms))
+		require.NotEmpty(t, terms.Signature)
+		return terms
+	}
+
+	t.Run("terms signed by the server are valid", func(t *testing.T) {
+		// given
+		terms := requestTerms(t)
+
+		// when
+		err := terms.VerifySignature(wallettest.NewMockWallet(sender), key.PubKey().ToDERHex())
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("tampered terms are invalid", func(t *testing.T) {
+		tamper := map[string]func(terms *payment.PaymentTerms){
+			"price":      func(terms *payment.PaymentTerms) { terms.SatoshisRequired = 1 },
+			"prefix":     func(terms *payment.PaymentTerms) { terms.DerivationPrefix = "other-prefix" },
+			"expiration": func(terms *payment.PaymentTerms) { terms.ExpirationTimestamp += 3600 },
+			"chain":      func(terms *payment.PaymentTerms) { terms.Chain = wallet.NetworkTestnet },
+			"signature":  func(terms *payment.PaymentTerms) { terms.Signature = "" },
+		}
+
+		for name, modify := range tamper {
+			t.Run(name, func(t *testing.T) {
+				// given
+				terms := requestTerms(t)
+				modify(&terms)
+
+				// when
+				err := terms.VerifySignature(wallettest.NewMockWallet(sender), key.PubKey().ToDERHex())
+
+				// then
+				require.ErrorIs(t, err, payment.ErrInvalidTermsSignature)
+			})
+		}
+	})
+
+	t.Run("terms signed by another server are invalid", func(t *testing.T) {
+		// given
+		terms := requestTerms(t)
+		otherServer, err := ec.NewPrivateKey()
+		require.NoError(t, err)
+
+		// when
+		err = terms.VerifySignature(wallettest.NewMockWallet(sender), otherServer.PubKey().ToDERHex())
+
+		// then
+		require.ErrorIs(t, err, payment.ErrInvalidTermsSignature)
+	})
+}
+
+func TestMiddleware_Handler_RedeemedTerms(t *testing.T) {
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	sender, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		payment func(t *testing.T) payment.Payment
+		code    string
+	}{
+		{
+			name: "tampered price",
+			payment: func(t *testing.T) payment.Payment {
+				paymentData := preparePayment(t, sender, key, 100)
+				paymentData.SatoshisRequired = 1
+				return paymentData
+			},
+			code: payment.ErrCodeInvalidTerms,
+		},
+		{
+			name: "extended expiration",
+			payment: func(t *testing.T) payment.Payment {
+				paymentData := preparePayment(t, sender, key, 100)
+				paymentData.ExpirationTimestamp += 3600
+				return paymentData
+			},
+			code: payment.ErrCodeInvalidTerms,
+		},
+		{
+			name: "missing terms",
+			payment: func(t *testing.T) payment.Payment {
+				paymentData := preparePayment(t, sender, key, 100)
+				paymentData.AttachTerms(payment.PaymentTerms{})
+				return paymentData
+			},
+			code: payment.ErrCodeInvalidTerms,
+		},
+		{
+			name: "terms signed for another identity",
+			payment: func(t *testing.T) payment.Payment {
+				other, err := ec.NewPrivateKey()
+				require.NoError(t, err)
+				paymentData := preparePayment(t, sender, key, 100)
+				attachSignedTerms(t, &paymentData, other, key, 100, time.Now().Add(time.Minute))
+				return paymentData
+			},
+			code: payment.ErrCodeInvalidTerms,
+		},
+		{
+			name: "terms quoting less than current price",
+			payment: func(t *testing.T) payment.Payment {
+				paymentData := preparePayment(t, sender, key, 100)
+				attachSignedTerms(t, &paymentData, sender, key, 50, time.Now().Add(time.Minute))
+				return paymentData
+			},
+			code: payment.ErrCodeTermsOutdated,
+		},
+		{
+			name: "expired terms",
+			payment: func(t *testing.T) payment.Payment {
+				paymentData := preparePayment(t, sender, key, 100)
+				attachSignedTerms(t, &paymentData, sender, key, 100, time.Now().Add(-time.Minute))
+				return paymentData
+			},
+			code: payment.ErrCodeTermsExpired,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// given
+			mockWallet := wallettest.NewMockPaymentWallet(key)
+			mockWalletSetup(t, mockWallet, wallettest.MockNonce)
+
+			middleware, err := payment.New(payment.Options{
+				Wallet: mockWallet,
+				CalculateRequestPrice: func(r *http.Request) (int, error) {
+					return 100, nil
+				},
+			})
+			require.NoError(t, err)
+
+			var handlerCalled bool
+			handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handlerCalled = true
+			}))
+
+			paymentJSON, err := json.Marshal(test.payment(t))
+			require.NoError(t, err)
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req = addIdentityToContext(req, sender.PubKey().ToDERHex())
+			req.Header.Set(payment.HeaderPayment, string(paymentJSON))
+			w := httptest.NewRecorder()
+
+			// when
+			handler.ServeHTTP(w, req)
+
+			// then
+			assert.False(t, handlerCalled)
+			assert.False(t, mockWallet.InternalizeActionCalled)
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var resp map[string]any
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			assert.Equal(t, test.code, resp["code"])
+		})
+	}
+}
+
+func TestMiddleware_Handler_TermsTTL(t *testing.T) {
+	// given
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	middleware, err := payment.New(payment.Options{
+		Wallet:   wallettest.NewMockPaymentWallet(key),
+		TermsTTL: 30 * time.Second,
+		CalculateRequestPrice: func(r *http.Request) (int, error) {
+			return 100, nil
+		},
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req = addIdentityToContext(req, testIdentityKey)
+	w := httptest.NewRecorder()
+
+	// when
+	middleware.Handler(http.NotFoundHandler()).ServeHTTP(w, req)
+
+	// then
+	require.Equal(t, http.StatusPaymentRequired, w.Code)
+
+	var terms payment.PaymentTerms
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&terms))
+	assert.Equal(t, int64(30), terms.ExpirationTimestamp-terms.CreationTimestamp)
+}
+
+func TestMiddleware_Handler_TermsExpiryBoundary(t *testing.T) {
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	sender, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	const ttl = 30 * time.Second
+	issuedAt := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		redeemAt time.Duration
+		status   int
+	}{
+		{name: "one second before expiration", redeemAt: ttl - time.Second, status: http.StatusOK},
+		{name: "exactly at expiration", redeemAt: ttl, status: http.StatusOK},
+		{name: "within the second of expiration", redeemAt: ttl + 999*time.Millisecond, status: http.StatusOK},
+		{name: "one second after expiration", redeemAt: ttl + time.Second, status: http.StatusBadRequest},
+		{name: "server clock set back before issuance", redeemAt: -time.Hour, status: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// given
+			now := issuedAt
+			mockWallet := wallettest.NewMockPaymentWallet(key)
+			mockWalletSetup(t, mockWallet, wallettest.MockNonce)
+			mockWallet.SetInternalizeActionResult(wallet.InternalizeActionResult{Accepted: true})
+
+			middleware, err := payment.New(payment.Options{
+				Wallet:   mockWallet,
+				TermsTTL: ttl,
+				Clock:    func() time.Time { return now },
+				CalculateRequestPrice: func(r *http.Request) (int, error) {
+					return 100, nil
+				},
+			})
+			require.NoError(t, err)
+			handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest("GET", "/", nil)
+			req = addIdentityToContext(req, sender.PubKey().ToDERHex())
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			require.Equal(t, http.StatusPaymentRequired, w.Code)
+
+			var terms payment.PaymentTerms
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&terms))
+			require.Equal(t, issuedAt.Unix(), terms.CreationTimestamp)
+			require.Equal(t, issuedAt.Add(ttl).Unix(), terms.ExpirationTimestamp)
+
+			paymentData := preparePayment(t, sender, key, 100)
+			attachSignedTerms(t, &paymentData, sender, key, 100, time.Unix(terms.ExpirationTimestamp, 0))
+			paymentJSON, err := json.Marshal(paymentData)
+			require.NoError(t, err)
+
+			req = httptest.NewRequest("GET", "/", nil)
+			req = addIdentityToContext(req, sender.PubKey().ToDERHex())
+			req.Header.Set(payment.HeaderPayment, string(paymentJSON))
+			w = httptest.NewRecorder()
+
+			// when
+			now = issuedAt.Add(test.redeemAt)
+			handler.ServeHTTP(w, req)
+
+			// then
+			require.Equal(t, test.status, w.Code)
+			if test.status == http.StatusBadRequest {
+				var resp map[string]any
+				require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+				assert.Equal(t, payment.ErrCodeTermsExpired, resp["code"])
+				assert.False(t, mockWallet.InternalizeActionCalled)
+			}
+		})
+	}
+}
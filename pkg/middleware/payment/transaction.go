@@ -0,0 +1,171 @@
+package payment
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/chaintracker"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/bsv-blockchain/go-sdk/transaction/template/p2pkh"
+)
+
+// PaymentOutput is an output of the payment transaction paying the expected locking script
+type PaymentOutput struct { //nolint: revive // Ignore that struct starts with package name
+	// Vout is the index of the output in the transaction
+	Vout uint32
+	// Satoshis is the amount of the output
+	Satoshis uint64
+}
+
+// ParseTransaction parses a payment transaction encoded as Atomic BEEF (BRC-95), BEEF (BRC-62/BRC-96),
+// Extended Format (BRC-30) or a raw transaction. For BEEF without a subject transaction,
+// the only transaction which is not spent by other transactions of the BEEF is the payment.
+func ParseTransaction(data []byte) (*transaction.Transaction, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("%w: too short", ErrInvalidTransaction)
+	}
+
+	switch binary.LittleEndian.Uint32(data) {
+	case transaction.ATOMIC_BEEF, transaction.BEEF_V1:
+		tx, err := transaction.NewTransactionFromBEEF(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidTransaction, err)
+		}
+		if tx == nil {
+			return nil, fmt.Errorf("%w: subject transaction not found in BEEF", ErrInvalidTransaction)
+		}
+		return tx, nil
+	case transaction.BEEF_V2:
+		beef, err := transaction.NewBeefFromBytes(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidTransaction, err)
+		}
+		return beefSubject(beef)
+	}
+
+	tx, err := transaction.NewTransactionFromBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidTransaction, err)
+	}
+
+	return tx, nil
+}
+
+// VerifyTransaction verifies the merkle proofs of a payment transaction encoded as Atomic BEEF or BEEF with the
+// chain tracker, every transaction of the BEEF has to be mined in a block known to the tracker or spend only
+// transactions of the BEEF. Scripts of the inputs are left to the wallet internalizing the payment. Raw transactions and Extended Format carry no proofs and are not valid.
+func VerifyTransaction(ctx context.Context, data []byte, tracker chaintracker.Interface) error {
+	if len(data) < 4 {
+		return fmt.Errorf("%w: too short", ErrInvalidTransaction)
+	}
+
+	switch binary.LittleEndian.Uint32(data) {
+	case transaction.ATOMIC_BEEF:
+		// the atomic prefix is followed by the txid of the subject transaction and the BEEF
+		const atomicPrefixLength = 4 + 32
+		if len(data) < atomicPrefixLength {
+			return fmt.Errorf("%w: too short", ErrInvalidTransaction)
+		}
+		data = data[atomicPrefixLength:]
+	case transaction.BEEF_V1, transaction.BEEF_V2:
+	default:
+		return fmt.Errorf("%w: the transaction is not encoded as BEEF", ErrInvalidProof)
+	}
+
+	beef, err := transaction.NewBeefFromBytes(data)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidTransaction, err)
+	}
+
+	valid, err := beef.Verify(chaintracker.ForSDK(ctx, tracker), false)
+	if err != nil {
+		return fmt.Errorf("failed to verify payment transaction proof, %w", err)
+	}
+	if !valid {
+		return ErrInvalidProof
+	}
+
+	// transactions without inputs are valid BEEF when not proven, but not valid on chain
+	for _, beefTx := range beef.Transactions {
+		if beefTx.Transaction != nil && beefTx.Transaction.MerklePath == nil && len(beefTx.Transaction.Inputs) == 0 {
+			return fmt.Errorf("%w: unproven transaction without inputs", ErrInvalidProof)
+		}
+	}
+
+	return nil
+}
+
+// beefSubject returns the transaction of the BEEF which is not spent by any other transaction of the BEEF
+func beefSubject(beef *transaction.Beef) (*transaction.Transaction, error) {
+	spent := make(map[string]struct{})
+	for _, beefTx := range beef.Transactions {
+		if beefTx.Transaction == nil {
+			continue
+		}
+		for _, input := range beefTx.Transaction.Inputs {
+			spent[input.SourceTXID.String()] = struct{}{}
+		}
+	}
+
+	var subject *transaction.Transaction
+	for txid, beefTx := range beef.Transactions {
+		if _, ok := spent[txid]; ok || beefTx.Transaction == nil {
+			continue
+		}
+		if subject != nil {
+			return nil, fmt.Errorf("%w: BEEF contains more than one unspent transaction", ErrInvalidTransaction)
+		}
+		subject = beefTx.Transaction
+	}
+
+	if subject == nil {
+		return nil, fmt.Errorf("%w: subject transaction not found in BEEF", ErrInvalidTransaction)
+	}
+
+	return subject, nil
+}
+
+// LockingScriptForKey returns the P2PKH locking script paying the key, payments are made to keys derived from
+// the identity key of the server with the derivation prefix and suffix
+func LockingScriptForKey(key *ec.PublicKey) (*script.Script, error) {
+	address, err := script.NewAddressFromPublicKey(key, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create address, %w", err)
+	}
+
+	lockingScript, err := p2pkh.Lock(address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create locking script, %w", err)
+	}
+
+	return lockingScript, nil
+}
+
+// FindPaymentOutputs returns the outputs of the transaction paying the locking script
+func FindPaymentOutputs(tx *transaction.Transaction, lockingScript *script.Script) ([]PaymentOutput, error) {
+	var outputs []PaymentOutput
+	for vout, output := range tx.Outputs {
+		if output.LockingScript == nil || !output.LockingScript.Equals(lockingScript) {
+			continue
+		}
+		outputs = append(outputs, PaymentOutput{Vout: uint32(vout), Satoshis: output.Satoshis}) //nolint:gosec // output count fits in uint32
+	}
+
+	if len(outputs) == 0 {
+		return nil, ErrPaymentOutputNotFound
+	}
+
+	return outputs, nil
+}
+
+// PaidSatoshis returns the total amount of the outputs
+func PaidSatoshis(outputs []PaymentOutput) uint64 {
+	var total uint64
+	for _, output := range outputs {
+		total += output.Satoshis
+	}
+	return total
+}
@@ -0,0 +1,186 @@
+package payment_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/chaintracker"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/payment"
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/bsv-blockchain/go-sdk/script"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTransaction(t *testing.T) {
+	tx, payeeScript := preparePaymentTransaction(t)
+
+	beef, err := tx.BEEF()
+	require.NoError(t, err)
+	atomicBEEF, err := tx.AtomicBEEF(false)
+	require.NoError(t, err)
+	ef, err := tx.EF()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "raw transaction", data: tx.Bytes()},
+		{name: "extended format", data: ef},
+		{name: "BEEF", data: beef},
+		{name: "atomic BEEF", data: atomicBEEF},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// when
+			parsed, err := payment.ParseTransaction(test.data)
+
+			// then
+			require.NoError(t, err)
+			require.Equal(t, tx.TxID().String(), parsed.TxID().String())
+
+			outputs, err := payment.FindPaymentOutputs(parsed, payeeScript)
+			require.NoError(t, err)
+			require.Equal(t, []payment.PaymentOutput{{Vout: 0, Satoshis: 300}, {Vout: 2, Satoshis: 200}}, outputs)
+			require.Equal(t, uint64(500), payment.PaidSatoshis(outputs))
+		})
+	}
+
+	t.Run("invalid data", func(t *testing.T) {
+		_, err := payment.ParseTransaction([]byte{1, 2, 3, 4})
+		require.ErrorIs(t, err, payment.ErrInvalidTransaction)
+	})
+}
+
+func TestFindPaymentOutputs_NotFound(t *testing.T) {
+	// given
+	tx, _ := preparePaymentTransaction(t)
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	otherScript, err := payment.LockingScriptForKey(key.PubKey())
+	require.NoError(t, err)
+
+	// when
+	_, err = payment.FindPaymentOutputs(tx, otherScript)
+
+	// then
+	require.ErrorIs(t, err, payment.ErrPaymentOutputNotFound)
+}
+
+func TestVerifyTransaction(t *testing.T) {
+	const height = 100
+
+	tests := map[string]struct {
+		encode  func(t *testing.T, tx *transaction.Transaction) []byte
+		blocks  func(tracker *chaintracker.InMemory, root chainhash.Hash)
+		source  func(source *transaction.Transaction)
+		wantErr error
+	}{
+		"BEEF mined in a known block": {
+			encode: encodeBEEF,
+			blocks: func(tracker *chaintracker.InMemory, root chainhash.Hash) { tracker.AddBlock(height, root) },
+			source: proveAtHeight(height),
+		},
+		"atomic BEEF mined in a known block": {
+			encode: encodeAtomicBEEF,
+			blocks: func(tracker *chaintracker.InMemory, root chainhash.Hash) { tracker.AddBlock(height, root) },
+			source: proveAtHeight(height),
+		},
+		"proof with a root unknown to the chain": {
+			encode:  encodeAtomicBEEF,
+			blocks:  func(tracker *chaintracker.InMemory, root chainhash.Hash) {},
+			source:  proveAtHeight(height),
+			wantErr: payment.ErrInvalidProof,
+		},
+		"proof with the root of another height": {
+			encode:  encodeAtomicBEEF,
+			blocks:  func(tracker *chaintracker.InMemory, root chainhash.Hash) { tracker.AddBlock(height+1, root) },
+			source:  proveAtHeight(height),
+			wantErr: payment.ErrInvalidProof,
+		},
+		"source transaction without proof": {
+			encode:  encodeAtomicBEEF,
+			source:  func(source *transaction.Transaction) {},
+			blocks:  func(tracker *chaintracker.InMemory, root chainhash.Hash) { tracker.AddBlock(height, root) },
+			wantErr: payment.ErrInvalidProof,
+		},
+		"raw transaction": {
+			encode:  func(t *testing.T, tx *transaction.Transaction) []byte { return tx.Bytes() },
+			blocks:  func(tracker *chaintracker.InMemory, root chainhash.Hash) { tracker.AddBlock(height, root) },
+			source:  proveAtHeight(height),
+			wantErr: payment.ErrInvalidProof,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			tx, _ := preparePaymentTransaction(t)
+			source := tx.Inputs[0].SourceTransaction
+			test.source(source)
+			tx.Inputs[0].SourceTXID = source.TxID()
+
+			tracker := chaintracker.NewInMemory()
+			test.blocks(tracker, *source.TxID())
+
+			// when
+			err := payment.VerifyTransaction(context.Background(), test.encode(t, tx), tracker)
+
+			// then
+			if test.wantErr != nil {
+				require.ErrorIs(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+// proveAtHeight gives the transaction a merkle path of the block at the height it is the only transaction of,
+// so the merkle root of the block is the txid
+func proveAtHeight(height uint32) func(tx *transaction.Transaction) {
+	return func(tx *transaction.Transaction) {
+		isTxID := true
+		tx.MerklePath = transaction.NewMerklePath(height, [][]*transaction.PathElement{{{Offset: 0, Hash: tx.TxID(), Txid: &isTxID}}})
+	}
+}
+
+func encodeBEEF(t *testing.T, tx *transaction.Transaction) []byte {
+	beef, err := tx.BEEF()
+	require.NoError(t, err)
+	return beef
+}
+
+func encodeAtomicBEEF(t *testing.T, tx *transaction.Transaction) []byte {
+	beef, err := tx.AtomicBEEF(false)
+	require.NoError(t, err)
+	return beef
+}
+
+func preparePaymentTransaction(t *testing.T) (*transaction.Transaction, *script.Script) {
+	t.Helper()
+
+	payerKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	payeeKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	payerScript, err := payment.LockingScriptForKey(payerKey.PubKey())
+	require.NoError(t, err)
+	payeeScript, err := payment.LockingScriptForKey(payeeKey.PubKey())
+	require.NoError(t, err)
+
+	source := transaction.NewTransaction()
+	source.AddOutput(&transaction.TransactionOutput{Satoshis: 1000, LockingScript: payerScript})
+
+	tx := transaction.NewTransaction()
+	tx.AddInputFromTx(source, 0, nil)
+	tx.AddOutput(&transaction.TransactionOutput{Satoshis: 300, LockingScript: payeeScript})
+	tx.AddOutput(&transaction.TransactionOutput{Satoshis: 490, LockingScript: payerScript})
+	tx.AddOutput(&transaction.TransactionOutput{Satoshis: 200, LockingScript: payeeScript})
+
+	return tx, payeeScript
+}
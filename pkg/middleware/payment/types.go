@@ -3,6 +3,8 @@ package payment
 import (
 	"context"
 	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
 )
 
 // PaymentMode represents a payment method option in the DPP protocol
@@ -49,6 +51,10 @@ type PaymentTerms struct { //nolint: revive // Ignore that struct starts with pa
 	DerivationPrefix string `json:"derivationPrefix"`
 	// DerivationSuffix is the suffix for the payment address
 	SatoshisRequired int `json:"satoshisRequired"`
+	// Chain is the BSV network (mainnet, testnet or regtest) the payment has to be made on
+	Chain wallet.Network `json:"chain,omitempty"`
+	// Signature is the hex encoded signature of the server over the price, derivation prefix, expiration and chain
+	Signature string `json:"signature,omitempty"`
 }
 
 // Payment represents the client payment data sent by the payer
@@ -61,6 +67,20 @@ type Payment struct {
 	DerivationSuffix string `json:"derivationSuffix"`
 	// Transaction is the payment transaction data
 	Transaction []byte `json:"transaction"`
+	// Chain, SatoshisRequired, ExpirationTimestamp and TermsSignature echo the signed terms the payment redeems,
+	// the chain is the BSV network the payment transaction has to be made on
+	Chain               wallet.Network `json:"chain,omitempty"`
+	SatoshisRequired    int            `json:"satoshisRequired,omitempty"`
+	ExpirationTimestamp int64          `json:"expirationTimestamp,omitempty"`
+	TermsSignature      string         `json:"termsSignature,omitempty"`
+}
+
+// AttachTerms echoes the signed terms in the payment, so the server can verify which terms are redeemed
+func (p *Payment) AttachTerms(terms PaymentTerms) {
+	p.Chain = terms.Chain
+	p.SatoshisRequired = terms.SatoshisRequired
+	p.ExpirationTimestamp = terms.ExpirationTimestamp
+	p.TermsSignature = terms.Signature
 }
 
 // PaymentACK represents the payment acknowledgment sent back to the client
@@ -0,0 +1,198 @@
+// Package probe checks BSV auth endpoints end to end, meant to run on a schedule against production deployments
+// to alert when authentication breaks, e.g. after a key rotation, a proxy change or a wallet outage.
+//
+// A probe performs a full handshake with the server, sends a signed request and verifies the signature of the
+// response, each step is timed:
+//
+//	result := probe.Run(ctx, probe.Config{Wallet: probeWallet, BaseURL: "https://api.example.com", Path: "/health"})
+//	if !result.Passed {
+//		alert(result)
+//	}
+package probe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+)
+
+// DefaultTimeout bounds probes without a configured timeout
+const DefaultTimeout = 10 * time.Second
+
+const requestIDHeader = "x-bsv-auth-request-id"
+
+// Probe errors
+var (
+	ErrResponseNotSigned        = client.ErrResponseNotSigned
+	ErrInvalidResponseSignature = client.ErrInvalidResponseSignature
+	ErrUnexpectedStatus         = errors.New("unexpected response status")
+)
+
+// Step is a step of a probe
+type Step string
+
+// Steps of a probe in the order they are performed
+const (
+	StepHandshake Step = "handshake"
+	StepRequest   Step = "request"
+	StepVerify    Step = "verify"
+)
+
+// Config configures a probe
+type Config struct {
+	// Wallet is the identity of the probe, servers restricting their peers have to accept it
+	Wallet wallet.WalletInterface
+	// BaseURL is the URL of the server, e.g. https://api.example.com
+	BaseURL string
+	// Method of the signed request, defaults to GET
+	Method string
+	// Path of the signed request, defaults to /. It has to respond with a status below 400.
+	Path string
+	// PinnedIdentityKeys restricts the accepted server identity keys, the probe fails the handshake on any other key
+	PinnedIdentityKeys []string
+	// HTTPClient sends the requests of the probe, defaults to http.DefaultClient
+	HTTPClient *http.Client
+	// Timeout bounds the whole probe, defaults to DefaultTimeout
+	Timeout time.Duration
+}
+
+// Timings are the durations of the steps of a probe, steps which were not performed are zero
+type Timings struct {
+	Handshake time.Duration
+	Request   time.Duration
+	Verify    time.Duration
+	Total     time.Duration
+}
+
+// Result is the outcome of a probe
+type Result struct {
+	// Time the probe started
+	Time time.Time
+	// Target is the URL of the signed request
+	Target string
+	// Passed reports whether every step succeeded
+	Passed bool
+	// FailedStep is the step which failed, empty when passed
+	FailedStep Step
+	// Err is the error of the failed step
+	Err error
+	// Status of the response to the signed request, zero when no response was received
+	Status int
+	// ServerIdentityKey is the identity key the server authenticated with in the handshake
+	ServerIdentityKey string
+	Timings           Timings
+}
+
+// String formats the result as a single log line, e.g. for alerting on the output of scheduled runs
+func (r Result) String() string {
+	timings := fmt.Sprintf("handshake=%s request=%s verify=%s total=%s",
+		r.Timings.Handshake.Round(time.Millisecond), r.Timings.Request.Round(time.Millisecond),
+		r.Timings.Verify.Round(time.Millisecond), r.Timings.Total.Round(time.Millisecond))
+	if r.Passed {
+		return fmt.Sprintf("PASS %s status=%d %s", r.Target, r.Status, timings)
+	}
+	return fmt.Sprintf("FAIL %s step=%s status=%d %s error=%q", r.Target, r.FailedStep, r.Status, timings, r.Err)
+}
+
+// Run performs the probe, failures are reported in the result
+func Run(ctx context.Context, cfg Config) Result {
+	if cfg.Method == "" {
+		cfg.Method = http.MethodGet
+	}
+	if cfg.Path == "" {
+		cfg.Path = "/"
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
+
+	result := Result{Time: time.Now(), Target: cfg.BaseURL + cfg.Path}
+	result.FailedStep, result.Err = run(ctx, cfg, &result)
+	result.Passed = result.Err == nil
+	result.Timings.Total = time.Since(result.Time)
+	return result
+}
+
+// run performs the steps of the probe, recording their outcome in the result, and returns the step which failed
+func run(ctx context.Context, cfg Config, result *Result) (Step, error) {
+	httpClient := http.Client{}
+	if cfg.HTTPClient != nil {
+		httpClient = *cfg.HTTPClient
+	}
+	transport := httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	httpClient.Transport = statusRecorder{next: transport, status: &result.Status}
+
+	authClient, err := client.New(client.Config{
+		Wallet:             cfg.Wallet,
+		BaseURL:            cfg.BaseURL,
+		HTTPClient:         &httpClient,
+		PinnedIdentityKeys: cfg.PinnedIdentityKeys,
+	})
+	if err != nil {
+		return StepHandshake, err
+	}
+
+	start := time.Now()
+	err = authClient.Handshake(ctx)
+	result.Timings.Handshake = time.Since(start)
+	if err != nil {
+		return StepHandshake, err
+	}
+	result.ServerIdentityKey = authClient.ServerIdentityKey()
+
+	req, err := http.NewRequestWithContext(ctx, cfg.Method, result.Target, nil)
+	if err != nil {
+		return StepRequest, fmt.Errorf("failed to create request, %w", err)
+	}
+
+	// the client verifies the signature of the response before returning it
+	start = time.Now()
+	response, err := authClient.Do(req)
+	result.Timings.Request = time.Since(start)
+	if errors.Is(err, client.ErrResponseNotSigned) || errors.Is(err, client.ErrInvalidResponseSignature) {
+		return StepVerify, err
+	}
+	if err != nil {
+		return StepRequest, err
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	// streamed responses are verified by the client while the body is read
+	start = time.Now()
+	_, err = io.Copy(io.Discard, response.Body)
+	result.Timings.Verify = time.Since(start)
+	if err != nil {
+		return StepVerify, err
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		return StepRequest, fmt.Errorf("%w: %d", ErrUnexpectedStatus, response.StatusCode)
+	}
+	return "", nil
+}
+
+// statusRecorder records the status of the responses to the general requests sent by the client of the probe,
+// the client does not return the responses it rejects
+type statusRecorder struct {
+	next   http.RoundTripper
+	status *int
+}
+
+func (r statusRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	response, err := r.next.RoundTrip(req)
+	if err == nil && req.Header.Get(requestIDHeader) != "" {
+		*r.status = response.StatusCode
+	}
+	return response, err //nolint:wrapcheck // the response of the transport is returned as is
+}
@@ -0,0 +1,117 @@
+package probe_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authtest"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/probe"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	server := authtest.NewServer(t, authtest.Options{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/broken" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write([]byte("ok"))
+		}),
+	})
+
+	// proxy rewrites the responses of the server after they were signed
+	proxy := func(modify func(response *http.Response)) string {
+		target, err := url.Parse(server.URL)
+		require.NoError(t, err)
+		reverseProxy := httputil.NewSingleHostReverseProxy(target)
+		reverseProxy.ModifyResponse = func(response *http.Response) error {
+			if response.Request.URL.Path != "/.well-known/auth" {
+				modify(response)
+			}
+			return nil
+		}
+		proxyServer := httptest.NewServer(reverseProxy)
+		t.Cleanup(proxyServer.Close)
+		return proxyServer.URL
+	}
+
+	tests := map[string]struct {
+		baseURL            string
+		path               string
+		pinnedIdentityKeys []string
+		failedStep         probe.Step
+		expectedErr        error
+		expectedStatus     int
+	}{
+		"healthy endpoint passes": {
+			path:           "/health",
+			expectedStatus: http.StatusOK,
+		},
+		"server identity not pinned": {
+			pinnedIdentityKeys: []string{authtest.NewServer(t, authtest.Options{Seed: "other"}).IdentityKey()},
+			failedStep:         probe.StepHandshake,
+			expectedErr:        client.ErrServerIdentityNotPinned,
+		},
+		"server error response": {
+			path:           "/broken",
+			failedStep:     probe.StepRequest,
+			expectedErr:    probe.ErrUnexpectedStatus,
+			expectedStatus: http.StatusInternalServerError,
+		},
+		"response signature stripped": {
+			baseURL: proxy(func(response *http.Response) {
+				response.Header.Del("x-bsv-auth-signature")
+			}),
+			failedStep:     probe.StepVerify,
+			expectedErr:    probe.ErrResponseNotSigned,
+			expectedStatus: http.StatusOK,
+		},
+		"response status rewritten": {
+			baseURL: proxy(func(response *http.Response) {
+				response.StatusCode = http.StatusAccepted
+			}),
+			failedStep:     probe.StepVerify,
+			expectedErr:    probe.ErrInvalidResponseSignature,
+			expectedStatus: http.StatusAccepted,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			baseURL := test.baseURL
+			if baseURL == "" {
+				baseURL = server.URL
+			}
+
+			// when
+			result := probe.Run(context.Background(), probe.Config{
+				Wallet:             wallettest.NewSeededMockWallet(authtest.DefaultSeed, "probe"),
+				BaseURL:            baseURL,
+				Path:               test.path,
+				PinnedIdentityKeys: test.pinnedIdentityKeys,
+			})
+
+			// then
+			require.Equal(t, test.failedStep == "", result.Passed, result.String())
+			require.Equal(t, test.failedStep, result.FailedStep)
+			require.Equal(t, test.expectedStatus, result.Status)
+			if test.expectedErr != nil {
+				require.ErrorIs(t, result.Err, test.expectedErr)
+			}
+			require.Positive(t, result.Timings.Total)
+			if result.Passed {
+				require.Equal(t, server.IdentityKey(), result.ServerIdentityKey)
+				require.Positive(t, result.Timings.Handshake)
+				require.Positive(t, result.Timings.Request)
+				require.Positive(t, result.Timings.Verify)
+			}
+		})
+	}
+}
@@ -1,7 +1,16 @@
-package sessionmanager
+// Package session is the supported API of the session stores of the middleware: the store interface,
+// the session of a peer, the in-memory store and the migration between stores.
+// It replaces pkg/temporary/sessionmanager, which is deprecated and kept for compatibility.
+// Identifiers repeating the package name were shortened:
+//
+//	sessionmanager.SessionManagerInterface -> session.Manager
+//	sessionmanager.SessionManager          -> session.MemoryManager
+//	sessionmanager.NewSessionManager       -> session.NewMemoryManager
+//	sessionmanager.SessionLister           -> session.Lister
+package session
 
-// SessionManagerInterface is an interface for managing peer sessions.
-type SessionManagerInterface interface { //nolint:revive // This is an interface, so it's fine to use the name "SessionManagerInterface".
+// Manager stores the sessions of peers, it is implemented by the session store backends
+type Manager interface {
 	// AddSession adds a session to the manager, associating it with its sessionNonce,
 	// and also with its peerIdentityKey (if any). This does NOT overwrite existing
 	// sessions for the same peerIdentityKey, allowing multiple concurrent sessions.
@@ -1,11 +1,11 @@
-package sessionmanager
+package session
 
 import (
 	"sync"
 )
 
-// SessionManager is a mock implementation of the SessionManager interface.
-type SessionManager struct {
+// MemoryManager is the in-memory session store, sessions are lost when the process exits
+type MemoryManager struct {
 	mu sync.Mutex
 	// sessions is a map of sessionNonce to a Session
 	sessions map[string]PeerSession
@@ -13,16 +13,16 @@ type SessionManager struct {
 	identityKeyToSessions map[string][]string
 }
 
-// NewSessionManager creates a new SessionManager.
-func NewSessionManager() *SessionManager {
-	return &SessionManager{
+// NewMemoryManager creates an empty in-memory session store
+func NewMemoryManager() *MemoryManager {
+	return &MemoryManager{
 		sessions:              make(map[string]PeerSession),
 		identityKeyToSessions: make(map[string][]string),
 	}
 }
 
 // AddSession adds a session to the manager, associating it with its sessionNonce and also with its peerIdentityKey.
-func (m *SessionManager) AddSession(session PeerSession) {
+func (m *MemoryManager) AddSession(session PeerSession) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -37,7 +37,7 @@ func (m *SessionManager) AddSession(session PeerSession) {
 
 // addSessionByIdentityKey adds a session nonce to the manager by associating it with its peerIdentityKey.
 // This does NOT overwrite existing sessions for the same peerIdentityKey, allowing multiple concurrent sessions.
-func (m *SessionManager) addSessionByIdentityKey(session PeerSession) {
+func (m *MemoryManager) addSessionByIdentityKey(session PeerSession) {
 	sessionNonces, exists := m.identityKeyToSessions[*session.PeerIdentityKey]
 	if exists {
 		// append sessionNonce to existing list
@@ -51,7 +51,7 @@ func (m *SessionManager) addSessionByIdentityKey(session PeerSession) {
 }
 
 // GetSession retrieves a "best" session based on a given identifier, which can be a sessionNonce or a peerIdentityKey.
-func (m *SessionManager) GetSession(identifier string) *PeerSession {
+func (m *MemoryManager) GetSession(identifier string) *PeerSession {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -74,7 +74,7 @@ func (m *SessionManager) GetSession(identifier string) *PeerSession {
 
 // getBestSession retrieves the "best" session from a list of sessionNonces.
 // The "best" session is the most recent one, or the most recent authenticated one if there are multiple.
-func (m *SessionManager) getBestSession(sessionNonces []string) *PeerSession {
+func (m *MemoryManager) getBestSession(sessionNonces []string) *PeerSession {
 	var bestSession *PeerSession
 	for _, sessionNonce := range sessionNonces {
 		session, exists := m.sessions[sessionNonce]
@@ -103,7 +103,7 @@ func (m *SessionManager) getBestSession(sessionNonces []string) *PeerSession {
 }
 
 // RemoveSession removes a session from the manager by clearing all associated identifiers.
-func (m *SessionManager) RemoveSession(session PeerSession) {
+func (m *MemoryManager) RemoveSession(session PeerSession) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -131,7 +131,7 @@ func (m *SessionManager) RemoveSession(session PeerSession) {
 }
 
 // HasSession checks if a session exists for a given identifier (either sessionNonce or identityKey).
-func (m *SessionManager) HasSession(identifier string) bool {
+func (m *MemoryManager) HasSession(identifier string) bool {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -150,8 +150,20 @@ func (m *SessionManager) HasSession(identifier string) bool {
 	return len(nonces) > 0
 }
 
+// Sessions returns a snapshot of all sessions of the manager.
+func (m *MemoryManager) Sessions() []PeerSession {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	sessions := make([]PeerSession, 0, len(m.sessions))
+	for _, session := range m.sessions {
+		sessions = append(sessions, session)
+	}
+	return sessions
+}
+
 // UpdateSession updates a session in the manager.
-func (m *SessionManager) UpdateSession(session PeerSession) {
+func (m *MemoryManager) UpdateSession(session PeerSession) {
 	m.AddSession(session)
 }
 
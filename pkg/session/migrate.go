@@ -0,0 +1,127 @@
+package session
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ErrMigrationInconsistent is returned by Migrate when sessions read back from the target differ from the source
+var ErrMigrationInconsistent = errors.New("migrated sessions are inconsistent")
+
+// Lister is implemented by session managers which can enumerate their sessions, it is required
+// of the source of Migrate
+type Lister interface {
+	Manager
+	// Sessions returns a snapshot of all sessions of the manager
+	Sessions() []PeerSession
+}
+
+// MigrationOptions configures Migrate
+type MigrationOptions struct {
+	// Overwrite replaces sessions which already exist in the target with a different state,
+	// they are reported as conflicts and kept otherwise
+	Overwrite bool
+	// DryRun only compares the source with the target without writing to the target
+	DryRun bool
+}
+
+// MigrationReport summarizes a migration between session managers
+type MigrationReport struct {
+	// Copied is the number of sessions written to the target
+	Copied int
+	// Unchanged is the number of sessions which already existed in the target with the same state
+	Unchanged int
+	// Skipped is the number of sessions without a session nonce, they cannot be addressed in any backend
+	Skipped int
+	// Conflicts lists the session nonces which exist in the target with a different state and were not overwritten
+	Conflicts []string
+	// Inconsistent lists the session nonces which differ from the source when read back from the target
+	Inconsistent []string
+}
+
+// Migrate copies the sessions, with their nonces and certificates, from one session manager to another,
+// so a deployment can change its store backend without forcing every peer to handshake again.
+// Every copied session is read back from the target by its nonce and its identity key, Migrate returns
+// ErrMigrationInconsistent along with the report when any of them differs.
+// Sessions created or updated in the source after its snapshot are not migrated, so the source should keep
+// serving until traffic has moved to the target, and Migrate can be run again to copy the remaining sessions.
+func Migrate(from Lister, to Manager, opts MigrationOptions) (MigrationReport, error) {
+	var report MigrationReport
+	if from == nil || to == nil {
+		return report, errors.New("source and target session managers are required")
+	}
+
+	sessions := from.Sessions()
+	// sessions are copied oldest first, so the most recent session of a peer is also the most recent in the target
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].LastUpdate.Before(sessions[j].LastUpdate)
+	})
+
+	var copied []PeerSession
+	for _, session := range sessions {
+		if session.SessionNonce == nil {
+			report.Skipped++
+			continue
+		}
+
+		existing := to.GetSession(*session.SessionNonce)
+		switch {
+		case existing == nil:
+		case sameSession(*existing, session):
+			report.Unchanged++
+			continue
+		case !opts.Overwrite:
+			report.Conflicts = append(report.Conflicts, *session.SessionNonce)
+			continue
+		case !opts.DryRun:
+			to.RemoveSession(*existing)
+		}
+
+		report.Copied++
+		if opts.DryRun {
+			continue
+		}
+		to.AddSession(session)
+		copied = append(copied, session)
+	}
+
+	for _, session := range copied {
+		if !consistent(to, session) {
+			report.Inconsistent = append(report.Inconsistent, *session.SessionNonce)
+		}
+	}
+
+	if len(report.Inconsistent) > 0 {
+		return report, fmt.Errorf("%w, %d of %d sessions differ", ErrMigrationInconsistent, len(report.Inconsistent), len(copied))
+	}
+	return report, nil
+}
+
+// consistent reports whether the target returns the session by its nonce and knows its identity key
+func consistent(to Manager, session PeerSession) bool {
+	stored := to.GetSession(*session.SessionNonce)
+	if stored == nil || !sameSession(*stored, session) {
+		return false
+	}
+	return session.PeerIdentityKey == nil || to.HasSession(*session.PeerIdentityKey)
+}
+
+// sameSession compares sessions independent of the representation of a backend, which may drop
+// the monotonic clock and location of timestamps or return empty slices as nil
+func sameSession(a, b PeerSession) bool {
+	return reflect.DeepEqual(normalizeSession(a), normalizeSession(b))
+}
+
+func normalizeSession(session PeerSession) PeerSession {
+	session.LastUpdate = session.LastUpdate.UTC().Round(0)
+	session.AuthenticatedAt = session.AuthenticatedAt.UTC().Round(0)
+	if len(session.Capabilities) == 0 {
+		session.Capabilities = nil
+	}
+	if len(session.Certificates) == 0 {
+		session.Certificates = nil
+	}
+	return session
+}
@@ -0,0 +1,57 @@
+package session
+
+import "time"
+
+// DefaultReauthenticationTimeout is the time a peer has to answer a reauthentication challenge when the policy
+// does not set one
+const DefaultReauthenticationTimeout = 30 * time.Second
+
+// ReauthenticationPolicy defines when a connection-scoped session has to be re-challenged.
+// Connection-scoped sessions are used by non-HTTP transports (e.g. WebSocket, NATS), where
+// the mutual authentication is bound to the connection lifetime and no per-message nonce
+// exchange happens after the handshake.
+type ReauthenticationPolicy struct {
+	// Interval is the maximum time since the last authentication, zero disables the check.
+	Interval time.Duration
+	// MaxMessages is the maximum number of messages since the last authentication, zero disables the check.
+	MaxMessages int
+	// Timeout is the time the peer has to answer a challenge before its connection is closed,
+	// DefaultReauthenticationTimeout when zero.
+	Timeout time.Duration
+}
+
+// RequiresReauthentication reports whether the session must be re-challenged before further messages are processed.
+// Sessions which are not connection-scoped are never re-challenged, as they exchange nonces on every message.
+func (p ReauthenticationPolicy) RequiresReauthentication(session PeerSession, now time.Time) bool {
+	if !session.ConnectionScoped || !session.IsAuthenticated {
+		return false
+	}
+
+	if p.Interval > 0 && now.Sub(session.AuthenticatedAt) >= p.Interval {
+		return true
+	}
+
+	return p.MaxMessages > 0 && session.MessageCount >= p.MaxMessages
+}
+
+// ChallengeTimeout returns the time the peer has to answer a challenge
+func (p ReauthenticationPolicy) ChallengeTimeout() time.Duration {
+	if p.Timeout <= 0 {
+		return DefaultReauthenticationTimeout
+	}
+	return p.Timeout
+}
+
+// RecordMessage counts a message exchanged over the session connection.
+func (s *PeerSession) RecordMessage(now time.Time) {
+	s.MessageCount++
+	s.LastUpdate = now
+}
+
+// MarkAuthenticated marks the session as (re)authenticated and resets the message counter.
+func (s *PeerSession) MarkAuthenticated(now time.Time) {
+	s.IsAuthenticated = true
+	s.AuthenticatedAt = now
+	s.MessageCount = 0
+	s.LastUpdate = now
+}
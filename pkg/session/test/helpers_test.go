@@ -0,0 +1,62 @@
+package auth_test
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/stretchr/testify/require"
+)
+
+// newPeerSession creates a new PeerSession with random values.
+func newPeerSession(t *testing.T) session.PeerSession {
+	sNonce, err := randomHex(32)
+	require.NoError(t, err)
+	pNonce, err := randomHex(32)
+	require.NoError(t, err)
+	pIdentityKey, err := randomHex(66)
+	require.NoError(t, err)
+
+	return session.PeerSession{
+		IsAuthenticated: false,
+		SessionNonce:    &sNonce,
+		PeerNonce:       &pNonce,
+		PeerIdentityKey: &pIdentityKey,
+		LastUpdate:      time.Now(),
+	}
+}
+
+// newPeerSessionsForThisSameIdentityKey creates a slice of PeerSessions with the same PeerIdentityKey.
+func newPeerSessionsForThisSameIdentityKey(t *testing.T, count int) []session.PeerSession {
+	pIdentityKey, err := randomHex(66)
+	require.NoError(t, err)
+
+	sessions := make([]session.PeerSession, count)
+	for i := 0; i < count; i++ {
+		sNonce, err := randomHex(32)
+		require.NoError(t, err)
+		pNonce, err := randomHex(32)
+		require.NoError(t, err)
+
+		sessions[i] = session.PeerSession{
+			IsAuthenticated: false,
+			SessionNonce:    &sNonce,
+			PeerNonce:       &pNonce,
+			PeerIdentityKey: &pIdentityKey,
+			LastUpdate:      time.Now(),
+		}
+	}
+
+	return sessions
+}
+
+func randomHex(n uint) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error during creating random hex: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,114 @@
+package auth_test
+
+import (
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrate(t *testing.T) {
+	newSource := func(t *testing.T) (*session.MemoryManager, []session.PeerSession) {
+		source := session.NewMemoryManager()
+		sessions := newPeerSessionsForThisSameIdentityKey(t, 2)
+		sessions = append(sessions, newPeerSession(t))
+		sessions[0].IsAuthenticated = true
+		sessions[0].Certificates = []certificates.VerifiableCertificate{{}}
+		for _, peerSession := range sessions {
+			source.AddSession(peerSession)
+		}
+		return source, sessions
+	}
+
+	t.Run("copies the sessions to the target", func(t *testing.T) {
+		// given
+		source, sessions := newSource(t)
+		target := session.NewMemoryManager()
+
+		// when
+		report, err := session.Migrate(source, target, session.MigrationOptions{})
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, session.MigrationReport{Copied: 3}, report)
+		for _, peerSession := range sessions {
+			require.Equal(t, source.GetSession(*peerSession.SessionNonce), target.GetSession(*peerSession.SessionNonce))
+			require.Equal(t, source.GetSession(*peerSession.PeerIdentityKey), target.GetSession(*peerSession.PeerIdentityKey))
+		}
+	})
+
+	t.Run("running again leaves the target unchanged", func(t *testing.T) {
+		// given
+		source, _ := newSource(t)
+		target := session.NewMemoryManager()
+		_, err := session.Migrate(source, target, session.MigrationOptions{})
+		require.NoError(t, err)
+
+		// when
+		report, err := session.Migrate(source, target, session.MigrationOptions{})
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, session.MigrationReport{Unchanged: 3}, report)
+	})
+
+	t.Run("changed sessions in the target are conflicts unless overwritten", func(t *testing.T) {
+		// given
+		source, sessions := newSource(t)
+		target := session.NewMemoryManager()
+		changed := sessions[1]
+		changed.IsAuthenticated = true
+		target.AddSession(changed)
+
+		// when
+		kept, err := session.Migrate(source, target, session.MigrationOptions{})
+		require.NoError(t, err)
+		keptSession := target.GetSession(*changed.SessionNonce)
+		overwritten, err := session.Migrate(source, target, session.MigrationOptions{Overwrite: true})
+		require.NoError(t, err)
+
+		// then
+		require.Equal(t, session.MigrationReport{Copied: 2, Conflicts: []string{*changed.SessionNonce}}, kept)
+		require.True(t, keptSession.IsAuthenticated)
+		require.Equal(t, session.MigrationReport{Copied: 1, Unchanged: 2}, overwritten)
+		require.False(t, target.GetSession(*changed.SessionNonce).IsAuthenticated)
+	})
+
+	t.Run("dry run does not write to the target", func(t *testing.T) {
+		// given
+		source, sessions := newSource(t)
+		target := session.NewMemoryManager()
+
+		// when
+		report, err := session.Migrate(source, target, session.MigrationOptions{DryRun: true})
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, 3, report.Copied)
+		require.False(t, target.HasSession(*sessions[0].SessionNonce))
+	})
+
+	t.Run("sessions which differ when read back are inconsistent", func(t *testing.T) {
+		// given
+		source, sessions := newSource(t)
+		target := &lossyTarget{MemoryManager: session.NewMemoryManager()}
+
+		// when
+		report, err := session.Migrate(source, target, session.MigrationOptions{})
+
+		// then
+		require.ErrorIs(t, err, session.ErrMigrationInconsistent)
+		require.Equal(t, []string{*sessions[0].SessionNonce}, report.Inconsistent)
+	})
+}
+
+// lossyTarget is a session store which drops the certificates of the sessions
+type lossyTarget struct {
+	*session.MemoryManager
+}
+
+func (l *lossyTarget) AddSession(peerSession session.PeerSession) {
+	peerSession.Certificates = nil
+	l.MemoryManager.AddSession(peerSession)
+}
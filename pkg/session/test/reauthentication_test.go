@@ -0,0 +1,107 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReauthenticationPolicy_RequiresReauthentication(t *testing.T) {
+	authenticatedAt := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		policy       session.ReauthenticationPolicy
+		scoped       bool
+		messageCount int
+		now          time.Time
+		expected     bool
+	}{
+		{
+			name:     "per-message session is never re-challenged",
+			policy:   session.ReauthenticationPolicy{Interval: time.Minute, MaxMessages: 1},
+			scoped:   false,
+			now:      authenticatedAt.Add(time.Hour),
+			expected: false,
+		},
+		{
+			name:     "empty policy never re-challenges",
+			policy:   session.ReauthenticationPolicy{},
+			scoped:   true,
+			now:      authenticatedAt.Add(time.Hour),
+			expected: false,
+		},
+		{
+			name:     "interval not elapsed",
+			policy:   session.ReauthenticationPolicy{Interval: time.Minute},
+			scoped:   true,
+			now:      authenticatedAt.Add(59 * time.Second),
+			expected: false,
+		},
+		{
+			name:     "interval elapsed",
+			policy:   session.ReauthenticationPolicy{Interval: time.Minute},
+			scoped:   true,
+			now:      authenticatedAt.Add(time.Minute),
+			expected: true,
+		},
+		{
+			name:         "message count below limit",
+			policy:       session.ReauthenticationPolicy{MaxMessages: 10},
+			scoped:       true,
+			messageCount: 9,
+			now:          authenticatedAt,
+			expected:     false,
+		},
+		{
+			name:         "message count reached limit",
+			policy:       session.ReauthenticationPolicy{MaxMessages: 10},
+			scoped:       true,
+			messageCount: 10,
+			now:          authenticatedAt,
+			expected:     true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			peerSession := newPeerSession(t)
+			peerSession.ConnectionScoped = tc.scoped
+			peerSession.MarkAuthenticated(authenticatedAt)
+			peerSession.MessageCount = tc.messageCount
+
+			// when
+			result := tc.policy.RequiresReauthentication(peerSession, tc.now)
+
+			// then
+			require.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestPeerSession_MarkAuthenticatedResetsMessageCount(t *testing.T) {
+	// given
+	policy := session.ReauthenticationPolicy{MaxMessages: 2}
+	now := time.Now()
+	peerSession := newPeerSession(t)
+	peerSession.ConnectionScoped = true
+	peerSession.MarkAuthenticated(now)
+	peerSession.RecordMessage(now)
+	peerSession.RecordMessage(now)
+	require.True(t, policy.RequiresReauthentication(peerSession, now))
+
+	// when
+	peerSession.MarkAuthenticated(now)
+
+	// then
+	require.False(t, policy.RequiresReauthentication(peerSession, now))
+	require.Equal(t, 0, peerSession.MessageCount)
+}
+
+func TestReauthenticationPolicy_ChallengeTimeout(t *testing.T) {
+	require.Equal(t, session.DefaultReauthenticationTimeout, session.ReauthenticationPolicy{}.ChallengeTimeout())
+	require.Equal(t, time.Second, session.ReauthenticationPolicy{Timeout: time.Second}.ChallengeTimeout())
+}
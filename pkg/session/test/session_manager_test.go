@@ -3,33 +3,33 @@ package auth_test
 import (
 	"testing"
 
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/sessionmanager"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
 	"github.com/stretchr/testify/require"
 )
 
 func TestSessionManager_HappyPath(t *testing.T) {
-	sessionManager := sessionmanager.NewSessionManager()
+	sessionManager := session.NewMemoryManager()
 
 	t.Run("Add and get session by both keys", func(t *testing.T) {
 		// given
-		session := sessionmanager.NewPeerSession(t)
+		peerSession := newPeerSession(t)
 
 		// when
-		sessionManager.AddSession(session)
+		sessionManager.AddSession(peerSession)
 
 		// then
-		retrievedSession := sessionManager.GetSession(*session.SessionNonce)
+		retrievedSession := sessionManager.GetSession(*peerSession.SessionNonce)
 		require.NotNil(t, retrievedSession)
-		require.Equal(t, session, *retrievedSession)
+		require.Equal(t, peerSession, *retrievedSession)
 
-		retrievedSession = sessionManager.GetSession(*session.PeerIdentityKey)
+		retrievedSession = sessionManager.GetSession(*peerSession.PeerIdentityKey)
 		require.NotNil(t, retrievedSession)
-		require.Equal(t, session, *retrievedSession)
+		require.Equal(t, peerSession, *retrievedSession)
 	})
 
 	t.Run("Correctly get best session by both keys", func(t *testing.T) {
 		// given
-		sessions := sessionmanager.NewPeerSessionsForThisSameIdentityKey(t, 5)
+		sessions := newPeerSessionsForThisSameIdentityKey(t, 5)
 		identityKey := *sessions[0].PeerIdentityKey
 
 		// when
@@ -77,38 +77,38 @@ func TestSessionManager_HappyPath(t *testing.T) {
 
 	t.Run("Update session", func(t *testing.T) {
 		// given
-		session := sessionmanager.NewPeerSession(t)
-		sessionManager.AddSession(session)
+		peerSession := newPeerSession(t)
+		sessionManager.AddSession(peerSession)
 
 		// when
-		session.IsAuthenticated = true
-		sessionManager.UpdateSession(session)
+		peerSession.IsAuthenticated = true
+		sessionManager.UpdateSession(peerSession)
 
 		// then
-		retrievedSession := sessionManager.GetSession(*session.SessionNonce)
+		retrievedSession := sessionManager.GetSession(*peerSession.SessionNonce)
 		require.NotNil(t, retrievedSession)
-		require.Equal(t, session, *retrievedSession)
+		require.Equal(t, peerSession, *retrievedSession)
 	})
 
 	t.Run("Remove session", func(t *testing.T) {
 		// given
-		session := sessionmanager.NewPeerSession(t)
-		sessionManager.AddSession(session)
+		peerSession := newPeerSession(t)
+		sessionManager.AddSession(peerSession)
 
 		// when
-		sessionManager.RemoveSession(session)
+		sessionManager.RemoveSession(peerSession)
 
 		// then
-		retrievedSession := sessionManager.GetSession(*session.SessionNonce)
+		retrievedSession := sessionManager.GetSession(*peerSession.SessionNonce)
 		require.Nil(t, retrievedSession)
 
-		retrievedSession = sessionManager.GetSession(*session.PeerIdentityKey)
+		retrievedSession = sessionManager.GetSession(*peerSession.PeerIdentityKey)
 		require.Nil(t, retrievedSession)
 	})
 }
 
 func TestSessionManager_ErrorPath(t *testing.T) {
-	sessionManager := sessionmanager.NewSessionManager()
+	sessionManager := session.NewMemoryManager()
 
 	t.Run("Get non-existent session", func(t *testing.T) {
 		// given
@@ -123,26 +123,26 @@ func TestSessionManager_ErrorPath(t *testing.T) {
 
 	t.Run("Remove non-existent session", func(t *testing.T) {
 		// given
-		session := sessionmanager.NewPeerSession(t)
+		peerSession := newPeerSession(t)
 
 		// when
-		sessionManager.RemoveSession(session)
+		sessionManager.RemoveSession(peerSession)
 
 		// then
-		retrievedSession := sessionManager.GetSession(*session.SessionNonce)
+		retrievedSession := sessionManager.GetSession(*peerSession.SessionNonce)
 		require.Nil(t, retrievedSession)
 	})
 
 	t.Run("Update non-existent session", func(t *testing.T) {
 		// given
-		session := sessionmanager.NewPeerSession(t)
+		peerSession := newPeerSession(t)
 
 		// when
-		sessionManager.UpdateSession(session)
+		sessionManager.UpdateSession(peerSession)
 
 		// then
-		retrievedSession := sessionManager.GetSession(*session.SessionNonce)
+		retrievedSession := sessionManager.GetSession(*peerSession.SessionNonce)
 		require.NotNil(t, retrievedSession)
-		require.Equal(t, session, *retrievedSession)
+		require.Equal(t, peerSession, *retrievedSession)
 	})
 }
@@ -0,0 +1,39 @@
+package session
+
+import (
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// PeerSession holds the session information for a peer
+type PeerSession struct {
+	IsAuthenticated bool
+	SessionNonce    *string
+	PeerNonce       *string
+	PeerIdentityKey *string
+	LastUpdate      time.Time
+	// PayloadEncryption marks sessions which negotiated encryption of general message bodies.
+	PayloadEncryption bool
+	// PayloadPadding marks sessions which negotiated padding of general message bodies to size buckets.
+	PayloadPadding bool
+	// Capabilities are the optional protocol features negotiated in the handshake, enabled for both sides.
+	Capabilities []transport.Capability
+	// ConnectionScoped marks sessions bound to a connection lifetime instead of per-message nonce exchange.
+	ConnectionScoped bool
+	// AuthenticatedAt is the time of the last successful (re)authentication of a connection-scoped session.
+	AuthenticatedAt time.Time
+	// MessageCount is the number of messages exchanged since the last (re)authentication.
+	MessageCount int
+	// Certificates are the certificates accepted from the peer during the certificate exchange.
+	Certificates []certificates.VerifiableCertificate
+	// Anonymous marks sessions of peers authenticated with the well-known "anyone" key.
+	Anonymous bool
+	// Tier is the name of the quota tier resolved for the peer, it is cleared when the peer sends new certificates.
+	Tier string
+	// CertificateGeneration is the generation of the certificate requirements the session was authenticated under.
+	CertificateGeneration uint64
+	// Delegation is the verified delegation of peers authenticated with an agent key on behalf of a principal identity.
+	Delegation *transport.Delegation
+}
@@ -37,4 +37,8 @@ type Peer interface {
 
 	// StopListeningForCertificatesRequested removes a certificate request listener.
 	StopListeningForCertificatesRequested(callbackID int)
+
+	// QueueStats returns the metrics of the incoming and outgoing message queues of the counterparty,
+	// bounded by the QueueConfig of the peer.
+	QueueStats(identityKey string) (incoming, outgoing QueueStats)
 }
@@ -0,0 +1,180 @@
+package peer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// DefaultQueueCapacity is the capacity of message queues when no capacity is configured
+const DefaultQueueCapacity = 256
+
+// ErrQueueFull is returned by Push of a queue with the OverflowReject policy when the queue is full
+var ErrQueueFull = errors.New("message queue is full")
+
+// OverflowPolicy defines what happens to a message pushed to a full queue
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits until the counterparty makes room or the context is done, applying backpressure to the producer
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest queued message to make room for the new one
+	OverflowDropOldest
+	// OverflowDropNewest discards the new message
+	OverflowDropNewest
+	// OverflowReject returns ErrQueueFull for the new message
+	OverflowReject
+)
+
+// QueueConfig bounds the message queues of a peer, so a slow counterparty cannot make them grow without limit
+type QueueConfig struct {
+	// Incoming bounds the messages received from the counterparty which were not processed yet
+	Incoming QueueOptions
+	// Outgoing bounds the messages waiting to be sent to the counterparty
+	Outgoing QueueOptions
+}
+
+// QueueOptions configures a message queue
+type QueueOptions struct {
+	// Capacity is the maximum number of queued messages, defaults to DefaultQueueCapacity
+	Capacity int
+	// Overflow is the policy for messages pushed to a full queue, defaults to OverflowBlock
+	Overflow OverflowPolicy
+}
+
+// QueueStats are the metrics of a message queue
+type QueueStats struct {
+	// Depth is the number of queued messages
+	Depth int
+	// Capacity is the maximum number of queued messages
+	Capacity int
+	// HighWatermark is the largest depth the queue has reached
+	HighWatermark int
+	// Enqueued counts the messages accepted by the queue
+	Enqueued uint64
+	// Dequeued counts the messages taken from the queue
+	Dequeued uint64
+	// Dropped counts the messages discarded by the OverflowDropOldest and OverflowDropNewest policies
+	Dropped uint64
+	// Rejected counts the messages refused by the OverflowReject policy
+	Rejected uint64
+}
+
+// MessageQueue is a bounded FIFO queue of auth messages between a peer and its transport
+type MessageQueue struct {
+	messages chan transport.AuthMessage
+	overflow OverflowPolicy
+	// pushMu serializes pushes which drop the oldest message, so the freed slot goes to the pushed message
+	pushMu sync.Mutex
+
+	enqueued      atomic.Uint64
+	dequeued      atomic.Uint64
+	dropped       atomic.Uint64
+	rejected      atomic.Uint64
+	highWatermark atomic.Int64
+}
+
+// NewMessageQueue creates a message queue with the given options
+func NewMessageQueue(opts QueueOptions) *MessageQueue {
+	capacity := opts.Capacity
+	if capacity <= 0 {
+		capacity = DefaultQueueCapacity
+	}
+
+	return &MessageQueue{
+		messages: make(chan transport.AuthMessage, capacity),
+		overflow: opts.Overflow,
+	}
+}
+
+// Push queues the message according to the overflow policy of the queue.
+// Only OverflowBlock waits for room, it returns the context error when the context is done first.
+func (q *MessageQueue) Push(ctx context.Context, message transport.AuthMessage) error {
+	switch q.overflow {
+	case OverflowDropOldest:
+		q.pushMu.Lock()
+		defer q.pushMu.Unlock()
+		for {
+			select {
+			case q.messages <- message:
+				q.accepted()
+				return nil
+			default:
+			}
+			select {
+			case <-q.messages:
+				q.dropped.Add(1)
+			default:
+			}
+		}
+	case OverflowDropNewest:
+		select {
+		case q.messages <- message:
+			q.accepted()
+		default:
+			q.dropped.Add(1)
+		}
+		return nil
+	case OverflowReject:
+		select {
+		case q.messages <- message:
+			q.accepted()
+			return nil
+		default:
+			q.rejected.Add(1)
+			return ErrQueueFull
+		}
+	default:
+		select {
+		case q.messages <- message:
+			q.accepted()
+			return nil
+		case <-ctx.Done():
+			return ctx.Err() //nolint:wrapcheck // cancellation of the producer is returned as is
+		}
+	}
+}
+
+// Pop takes the oldest message from the queue, waiting until a message is queued or the context is done
+func (q *MessageQueue) Pop(ctx context.Context) (transport.AuthMessage, error) {
+	select {
+	case message := <-q.messages:
+		q.dequeued.Add(1)
+		return message, nil
+	case <-ctx.Done():
+		return transport.AuthMessage{}, ctx.Err() //nolint:wrapcheck // cancellation of the consumer is returned as is
+	}
+}
+
+// Len returns the number of queued messages
+func (q *MessageQueue) Len() int {
+	return len(q.messages)
+}
+
+// Stats returns the metrics of the queue
+func (q *MessageQueue) Stats() QueueStats {
+	return QueueStats{
+		Depth:         len(q.messages),
+		Capacity:      cap(q.messages),
+		HighWatermark: int(q.highWatermark.Load()),
+		Enqueued:      q.enqueued.Load(),
+		Dequeued:      q.dequeued.Load(),
+		Dropped:       q.dropped.Load(),
+		Rejected:      q.rejected.Load(),
+	}
+}
+
+func (q *MessageQueue) accepted() {
+	q.enqueued.Add(1)
+
+	depth := int64(len(q.messages))
+	for {
+		current := q.highWatermark.Load()
+		if depth <= current || q.highWatermark.CompareAndSwap(current, depth) {
+			return
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package peer_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/peer"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func message(nonce string) transport.AuthMessage {
+	return transport.AuthMessage{MessageType: transport.General, InitialNonce: nonce}
+}
+
+// drain pops every queued message and returns their nonces
+func drain(t *testing.T, q *peer.MessageQueue) []string {
+	var nonces []string
+	for q.Len() > 0 {
+		m, err := q.Pop(context.Background())
+		require.NoError(t, err)
+		nonces = append(nonces, m.InitialNonce)
+	}
+	return nonces
+}
+
+func TestMessageQueue_Overflow(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("drop oldest keeps the newest messages", func(t *testing.T) {
+		// given
+		q := peer.NewMessageQueue(peer.QueueOptions{Capacity: 2, Overflow: peer.OverflowDropOldest})
+
+		// when
+		for _, nonce := range []string{"1", "2", "3"} {
+			require.NoError(t, q.Push(ctx, message(nonce)))
+		}
+
+		// then
+		require.Equal(t, []string{"2", "3"}, drain(t, q))
+		stats := q.Stats()
+		require.Equal(t, uint64(3), stats.Enqueued)
+		require.Equal(t, uint64(1), stats.Dropped)
+	})
+
+	t.Run("drop newest keeps the oldest messages", func(t *testing.T) {
+		// given
+		q := peer.NewMessageQueue(peer.QueueOptions{Capacity: 2, Overflow: peer.OverflowDropNewest})
+
+		// when
+		for _, nonce := range []string{"1", "2", "3"} {
+			require.NoError(t, q.Push(ctx, message(nonce)))
+		}
+
+		// then
+		require.Equal(t, []string{"1", "2"}, drain(t, q))
+		stats := q.Stats()
+		require.Equal(t, uint64(2), stats.Enqueued)
+		require.Equal(t, uint64(1), stats.Dropped)
+	})
+
+	t.Run("reject returns an error for a full queue", func(t *testing.T) {
+		// given
+		q := peer.NewMessageQueue(peer.QueueOptions{Capacity: 1, Overflow: peer.OverflowReject})
+		require.NoError(t, q.Push(ctx, message("1")))
+
+		// when
+		err := q.Push(ctx, message("2"))
+
+		// then
+		require.ErrorIs(t, err, peer.ErrQueueFull)
+		require.Equal(t, uint64(1), q.Stats().Rejected)
+		require.Equal(t, []string{"1"}, drain(t, q))
+	})
+
+	t.Run("block waits for room until the context is done", func(t *testing.T) {
+		// given
+		q := peer.NewMessageQueue(peer.QueueOptions{Capacity: 1})
+		require.NoError(t, q.Push(ctx, message("1")))
+		timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+		defer cancel()
+
+		// when
+		err := q.Push(timeoutCtx, message("2"))
+
+		// then
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Equal(t, 1, q.Len())
+	})
+
+	t.Run("block resumes once the consumer makes room", func(t *testing.T) {
+		// given
+		q := peer.NewMessageQueue(peer.QueueOptions{Capacity: 1})
+		require.NoError(t, q.Push(ctx, message("1")))
+		pushed := make(chan error, 1)
+
+		// when
+		go func() { pushed <- q.Push(ctx, message("2")) }()
+		first, err := q.Pop(ctx)
+		require.NoError(t, err)
+
+		// then
+		require.NoError(t, <-pushed)
+		require.Equal(t, "1", first.InitialNonce)
+		require.Equal(t, []string{"2"}, drain(t, q))
+	})
+}
+
+func TestMessageQueue_Stats(t *testing.T) {
+	// given
+	ctx := context.Background()
+	q := peer.NewMessageQueue(peer.QueueOptions{})
+
+	// when
+	for _, nonce := range []string{"1", "2", "3"} {
+		require.NoError(t, q.Push(ctx, message(nonce)))
+	}
+	_, err := q.Pop(ctx)
+	require.NoError(t, err)
+
+	// then
+	require.Equal(t, peer.QueueStats{
+		Depth:         2,
+		Capacity:      peer.DefaultQueueCapacity,
+		HighWatermark: 3,
+		Enqueued:      3,
+		Dequeued:      1,
+	}, q.Stats())
+}
+
+func TestMessageQueue_PopWaitsForMessage(t *testing.T) {
+	// given
+	q := peer.NewMessageQueue(peer.QueueOptions{})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// when
+	_, err := q.Pop(ctx)
+
+	// then
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,47 @@
+// Package sessionmanager is deprecated: its definitions moved to pkg/session, where identifiers repeating
+// the package name were shortened. The identifiers below are aliases of them, kept so that existing imports
+// keep compiling.
+//
+// Deprecated: import github.com/bsv-blockchain/go-bsv-middleware/pkg/session instead.
+package sessionmanager
+
+import (
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+)
+
+// SessionManagerInterface is an alias of session.Manager.
+type SessionManagerInterface = session.Manager
+
+// SessionLister is an alias of session.Lister.
+type SessionLister = session.Lister
+
+// SessionManager is an alias of session.MemoryManager.
+type SessionManager = session.MemoryManager
+
+// PeerSession is an alias of session.PeerSession.
+type PeerSession = session.PeerSession
+
+// ReauthenticationPolicy is an alias of session.ReauthenticationPolicy.
+type ReauthenticationPolicy = session.ReauthenticationPolicy
+
+// MigrationOptions is an alias of session.MigrationOptions.
+type MigrationOptions = session.MigrationOptions
+
+// MigrationReport is an alias of session.MigrationReport.
+type MigrationReport = session.MigrationReport
+
+// DefaultReauthenticationTimeout is session.DefaultReauthenticationTimeout.
+const DefaultReauthenticationTimeout = session.DefaultReauthenticationTimeout
+
+// ErrMigrationInconsistent is session.ErrMigrationInconsistent.
+var ErrMigrationInconsistent = session.ErrMigrationInconsistent
+
+// NewSessionManager calls session.NewMemoryManager.
+func NewSessionManager() *SessionManager {
+	return session.NewMemoryManager()
+}
+
+// Migrate calls session.Migrate.
+func Migrate(from SessionLister, to SessionManagerInterface, opts MigrationOptions) (MigrationReport, error) {
+	return session.Migrate(from, to, opts)
+}
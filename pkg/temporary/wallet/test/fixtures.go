@@ -1,61 +1,27 @@
+// Package wallet is deprecated: the fixtures moved to pkg/wallet/wallettest. The identifiers below refer to them,
+// kept so that existing imports keep compiling.
+//
+// Deprecated: import github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest instead.
 package wallet
 
-// Constants for expected return values
+import (
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+)
+
 const (
-	// MockNonce is the expected nonce
-	MockNonce = "VIKeYfx4YAoDnlEx87/f4/32ytl+R+dN6Qm8oEB6Hw="
+	Seed      = wallettest.Seed
+	MockNonce = wallettest.MockNonce
 )
 
-// Nonces for testing, used real Nonces values to see if the process is working correctly
 var (
-	DefaultNonces = []string{
-		"Euvsm51YUZoJRAMawyQqj3ae7q6RP/YRicWxcgu4n6o=",
-		"Siqq09M49vYwdn3N1UUXGvhT5g8BwrX6QEZp8qnVV/4=",
-		"EaLXOsTrYSLBCZGaBaGrlqnNAlvSW5n93Uu7yCAftKE=",
-		"WwaxgRMmna15rt/oo3f+RMPFplZ4fgJpnPHZkVe+QCc=",
-		"BaotwKldh8209pyszYHLyfiWsKXYngkwyTPwmG/ruVE=",
-		"Puh8gDPO9Ys7yNnr7+TQm29BEXKaHXT0Szq1nNnwsgQ=",
-		"37b0So7c7eQRKj0bQcMa6FZby9kTC6oeevJKnZRjFd4=",
-		"TpaxO1La2/ts+dHGO5MLPIVbAZjYeuLevANI+Ro9zKk=",
-		"3pj5u9jMfcn5XZfdMAhkyXG4L954pN/VH0lwt+lrz/w=",
-		"RjjQFx38LCwmotBxW6D4ThIdd0UWzPwekw2Qs40ShBo=",
-		"01+JXB54SEcYezAVRHYCrx1ctgSQUnQnSDW35puv0HQ=",
-		"AmaDyFBKplNbYCuQi8b8RyukSsk1AyMihHO2Ga3x1RA=",
-		"M8CdgkNRAfH2qIVK68IlF8EqqX9gHRqr0Xb170YAiuE=",
-		"/To/qjsXoEfW3KTNV6Os8JhtwilYU/GC/xltUezusyA=",
-		"XFsMxcW3h6190glNhjfuZFNz1pFmdSeOeUoQtj80PAk=",
-		"qzpruKKejqkKUvs+XsRpQNwdRBPQLFCB9+JuK5F8Jo8=",
-		"AjH0fY1ZDg5fmjKbJGZXAEvf8Bg2yYdC1xJX3znMzoI=",
-		"TGstRlgdoid50mztsM1x5se0L18d1Va0qFa6jTFsFaU=",
-		"KvORkTu1HcTW6dQoZD8A/VyHSXRnoOUoA8UZUYQV4wY=",
-		"zy3Jkf802hTqOe5wppHDlWsgwuFxgEtsG+lin/zvBhc=",
-	}
-
-	ClientNonces = []string{
-		"D9TBw7kg+YTu1obv1tJwBQNMZdZRrb+Uw81EGXhtKzU=",
-		"cK1WXWSYs9yC8XTY6P7lIbLW8o3EUluEnB3WCacCl2E=",
-		"qXp9B8yt6V1a4xgtNCsoMnP011jZWbuz836wMquRqDs=",
-		"oL0aNQQtNtu0LT77IJq3UZemT9s5tqKqEP3G2OlXj28=",
-		"0sXaGVLawqJDIepMrOlYL72dbGXFv2VO/Qnuqv5Bk6w=",
-		"r2uQJ7pK2h80JmrmUW6XiFCf14n+N3MInjYmK2Zohr8=",
-		"+S5ygXq3mpnuPCWsPcqF1lCMgX5sNBu1YBdcnEEZijg=",
-		"KE3BvLICCRpPICuAu/q/qtTBYqqSA6w3XSLREIhWhYU=",
-		"CSGx7fKvKpZVD54B/NpTqrbH7bAU2/nX1j7HYg5fMgU=",
-		"i+D5TZYGIs2nK7RVKs494a/TLKV79u/flXOhjkEMu54=",
-		"YAuNhEW85vZdUj/XGC+EQOs7207i17v+Lq/owExtZB0=",
-		"zb/Q6rXfIh84+VZvJ4cnMP9+1gWextnaqH3KwZb63MU=",
-		"i0BQi1KhpzM3m6tNBUGxMk42IOuE0NS6wn4jn3ipkus=",
-		"O7lmI0U1ERY9fOTzL6Vx/FjAHiWpL5073jwj0uVHm58=",
-		"PD6Fmid20aSQmTslIQgiCrIf6qt9m3+p7yGP6pCz1Xg=",
-		"N7bHmHXMaN2j84kmjGszu1RYdjz9ox4rwGbvD9v3o5c=",
-		"R0G43BQ20dEyKIpe8yRoTu5M/qZzwgYxUlDbiF/W0d4=",
-		"2esYw+J71bqGyHPLAUV/VxXQdc4ZCJFLsWkHeiJQAio=",
-		"NZcxTWEweAbDpG/aH1OgdEV/B4IvMr/fTziMT6680eQ=",
-		"OuYi1TJLoUF2MVT8HYKm6SF3iZypyvjysaE3iCl7OR4=",
-	}
+	DefaultNonces = wallettest.DefaultNonces
+	ClientNonces  = wallettest.ClientNonces
 
-	ServerIdentityKey = "022b0020d72601948e798eadc6376d94f395d80deb57f62d91dafa5003ec0db6b0"
+	ServerIdentityKey    = wallettest.ServerIdentityKey
+	ClientIdentityKey    = wallettest.ClientIdentityKey
+	CertifierIdentityKey = wallettest.CertifierIdentityKey
 
-	ServerPrivateKeyHex = "02c6047f9441ed7d6d3045406e95c07cd85c778e4b8cef3ca7abac09b95c709ee5"
-	ClientPrivateKeyHex = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	ServerPrivateKeyHex    = wallettest.ServerPrivateKeyHex
+	ClientPrivateKeyHex    = wallettest.ClientPrivateKeyHex
+	CertifierPrivateKeyHex = wallettest.CertifierPrivateKeyHex
 )
@@ -0,0 +1,235 @@
+// Package wallet is deprecated: its definitions moved to pkg/wallet, the certificate types to pkg/certificates
+// and the mock wallets to pkg/wallet/wallettest. The identifiers below are aliases of them, kept so that existing imports keep compiling.
+//
+// Deprecated: import github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet instead.
+package wallet
+
+import (
+	"io"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// WalletInterface is an alias of wallet.WalletInterface.
+type WalletInterface = wallet.WalletInterface
+
+// PaymentInterface is an alias of wallet.PaymentInterface.
+type PaymentInterface = wallet.PaymentInterface
+
+// Wallet is an alias of wallettest.Wallet.
+type Wallet = wallettest.Wallet
+
+// MockPaymentWallet is an alias of wallettest.MockPaymentWallet.
+type MockPaymentWallet = wallettest.MockPaymentWallet
+
+// PermissionFunc is an alias of wallettest.PermissionFunc.
+type PermissionFunc = wallettest.PermissionFunc
+
+// KeyDeriver is an alias of wallet.KeyDeriver.
+type KeyDeriver = wallet.KeyDeriver
+
+// Network is an alias of wallet.Network.
+type Network = wallet.Network
+
+// SecurityLevel is an alias of wallet.SecurityLevel.
+type SecurityLevel = wallet.SecurityLevel
+
+// Protocol is an alias of wallet.Protocol.
+type Protocol = wallet.Protocol
+
+// CounterpartyType is an alias of wallet.CounterpartyType.
+type CounterpartyType = wallet.CounterpartyType
+
+// Counterparty is an alias of wallet.Counterparty.
+type Counterparty = wallet.Counterparty
+
+// Certificate is an alias of certificates.Certificate.
+type Certificate = certificates.Certificate
+
+// VerifiableCertificate is an alias of certificates.VerifiableCertificate.
+type VerifiableCertificate = certificates.VerifiableCertificate
+
+// MasterCertificate is an alias of certificates.MasterCertificate.
+type MasterCertificate = certificates.MasterCertificate
+
+// CounterpartyLinkageProof is an alias of wallet.CounterpartyLinkageProof.
+type CounterpartyLinkageProof = wallet.CounterpartyLinkageProof
+
+// SpecificLinkageProof is an alias of wallet.SpecificLinkageProof.
+type SpecificLinkageProof = wallet.SpecificLinkageProof
+
+// Argument and result types of the wallet methods.
+type (
+	CreateActionArgs                   = wallet.CreateActionArgs
+	CreateActionOutput                 = wallet.CreateActionOutput
+	CreateActionResult                 = wallet.CreateActionResult
+	CreateSignatureArgs                = wallet.CreateSignatureArgs
+	CreateSignatureResult              = wallet.CreateSignatureResult
+	DecryptArgs                        = wallet.DecryptArgs
+	DecryptResult                      = wallet.DecryptResult
+	EncryptArgs                        = wallet.EncryptArgs
+	EncryptResult                      = wallet.EncryptResult
+	EncryptionArgs                     = wallet.EncryptionArgs
+	GetHeightResult                    = wallet.GetHeightResult
+	GetNetworkResult                   = wallet.GetNetworkResult
+	GetPublicKeyArgs                   = wallet.GetPublicKeyArgs
+	GetPublicKeyOptions                = wallet.GetPublicKeyOptions
+	GetPublicKeyResult                 = wallet.GetPublicKeyResult
+	GetVersionResult                   = wallet.GetVersionResult
+	InternalizeActionArgs              = wallet.InternalizeActionArgs
+	InternalizeActionResult            = wallet.InternalizeActionResult
+	InternalizeOutput                  = wallet.InternalizeOutput
+	PaymentRemittance                  = wallet.PaymentRemittance
+	RevealCounterpartyKeyLinkageArgs   = wallet.RevealCounterpartyKeyLinkageArgs
+	RevealCounterpartyKeyLinkageResult = wallet.RevealCounterpartyKeyLinkageResult
+	RevealSpecificKeyLinkageArgs       = wallet.RevealSpecificKeyLinkageArgs
+	RevealSpecificKeyLinkageResult     = wallet.RevealSpecificKeyLinkageResult
+	VerifySignatureArgs                = wallet.VerifySignatureArgs
+	VerifySignatureResult              = wallet.VerifySignatureResult
+)
+
+const (
+	MockCreateActionTxID = wallettest.MockCreateActionTxID
+	MockWalletVersion    = wallettest.MockWalletVersion
+
+	NetworkMainnet = wallet.NetworkMainnet
+	NetworkTestnet = wallet.NetworkTestnet
+	NetworkRegtest = wallet.NetworkRegtest
+
+	CounterpartyUninitialized = wallet.CounterpartyUninitialized
+	CounterpartyTypeAnyone    = wallet.CounterpartyTypeAnyone
+	CounterpartyTypeSelf      = wallet.CounterpartyTypeSelf
+	CounterpartyTypeOther     = wallet.CounterpartyTypeOther
+
+	SpecificLinkageProofNone    = wallet.SpecificLinkageProofNone
+	SpecificLinkageProofSchnorr = wallet.SpecificLinkageProofSchnorr
+)
+
+var (
+	SecurityLevelSilent                  = wallet.SecurityLevelSilent
+	SecurityLevelEveryApp                = wallet.SecurityLevelEveryApp
+	SecurityLevelEveryAppAndCounterparty = wallet.SecurityLevelEveryAppAndCounterparty
+
+	DefaultAuthProtocol                   = wallet.DefaultAuthProtocol
+	DefaultEncryptionProtocol             = wallet.DefaultEncryptionProtocol
+	CounterpartyLinkageRevelationProtocol = wallet.CounterpartyLinkageRevelationProtocol
+	PaymentProtocol                       = wallet.PaymentProtocol
+	CertificateSignatureProtocol          = wallet.CertificateSignatureProtocol
+	PaymentTermsProtocol                  = wallet.PaymentTermsProtocol
+
+	ErrArgsRequired             = wallet.ErrArgsRequired
+	ErrSignatureInvalid         = wallet.ErrSignatureInvalid
+	ErrSignatureArgsInvalid     = wallet.ErrSignatureArgsInvalid
+	ErrNonceInvalid             = wallet.ErrNonceInvalid
+	ErrPrivilegedKeyUnavailable = wallet.ErrPrivilegedKeyUnavailable
+	ErrPermissionDenied         = wallet.ErrPermissionDenied
+	ErrInvalidLinkageProof      = wallet.ErrInvalidLinkageProof
+)
+
+// NewKeyDeriver calls wallet.NewKeyDeriver.
+func NewKeyDeriver(privateKey *ec.PrivateKey) *KeyDeriver {
+	return wallet.NewKeyDeriver(privateKey)
+}
+
+// SpecificLinkageRevelationProtocol calls wallet.SpecificLinkageRevelationProtocol.
+func SpecificLinkageRevelationProtocol(protocol Protocol) Protocol {
+	return wallet.SpecificLinkageRevelationProtocol(protocol)
+}
+
+// AnyoneKey calls wallet.AnyoneKey.
+func AnyoneKey() (*ec.PrivateKey, *ec.PublicKey) {
+	return wallet.AnyoneKey()
+}
+
+// ParseCounterpartyLinkageProof calls wallet.ParseCounterpartyLinkageProof.
+func ParseCounterpartyLinkageProof(data []byte) (*CounterpartyLinkageProof, error) {
+	return wallet.ParseCounterpartyLinkageProof(data)
+}
+
+// ProveCounterpartyLinkage calls wallet.ProveCounterpartyLinkage.
+func ProveCounterpartyLinkage(prover *ec.PrivateKey, counterparty, sharedSecret *ec.PublicKey) (*CounterpartyLinkageProof, error) {
+	return wallet.ProveCounterpartyLinkage(prover, counterparty, sharedSecret)
+}
+
+// VerifyCounterpartyLinkage calls wallet.VerifyCounterpartyLinkage.
+func VerifyCounterpartyLinkage(prover, counterparty, sharedSecret *ec.PublicKey, proof *CounterpartyLinkageProof) bool {
+	return wallet.VerifyCounterpartyLinkage(prover, counterparty, sharedSecret, proof)
+}
+
+// ParseSpecificLinkageProof calls wallet.ParseSpecificLinkageProof.
+func ParseSpecificLinkageProof(data []byte) (*SpecificLinkageProof, error) {
+	return wallet.ParseSpecificLinkageProof(data)
+}
+
+// ProveSpecificLinkage calls wallet.ProveSpecificLinkage.
+func ProveSpecificLinkage(derived *ec.PrivateKey, context []byte) (*SpecificLinkageProof, error) {
+	return wallet.ProveSpecificLinkage(derived, context)
+}
+
+// VerifySpecificLinkage calls wallet.VerifySpecificLinkage.
+func VerifySpecificLinkage(prover *ec.PublicKey, linkage []byte, context []byte, proof *SpecificLinkageProof) bool {
+	return wallet.VerifySpecificLinkage(prover, linkage, context, proof)
+}
+
+// VerifyCounterpartyKeyLinkage calls wallet.VerifyCounterpartyKeyLinkage.
+func VerifyCounterpartyKeyLinkage(verifier WalletInterface, revelation *RevealCounterpartyKeyLinkageResult) (*ec.PublicKey, error) {
+	return wallet.VerifyCounterpartyKeyLinkage(verifier, revelation)
+}
+
+// VerifySpecificKeyLinkage calls wallet.VerifySpecificKeyLinkage.
+func VerifySpecificKeyLinkage(verifier WalletInterface, revelation *RevealSpecificKeyLinkageResult) ([]byte, error) {
+	return wallet.VerifySpecificKeyLinkage(verifier, revelation)
+}
+
+// NewRandomPrivateKey calls wallettest.NewRandomPrivateKey.
+func NewRandomPrivateKey(random io.Reader) (*ec.PrivateKey, error) {
+	return wallettest.NewRandomPrivateKey(random)
+}
+
+// SeededPrivateKey calls wallettest.SeededPrivateKey.
+func SeededPrivateKey(seed, name string) *ec.PrivateKey {
+	return wallettest.SeededPrivateKey(seed, name)
+}
+
+// SeededNonce calls wallettest.SeededNonce.
+func SeededNonce(seed, name string, i int) string {
+	return wallettest.SeededNonce(seed, name, i)
+}
+
+// SeededNonces calls wallettest.SeededNonces.
+func SeededNonces(seed, name string, count int) []string {
+	return wallettest.SeededNonces(seed, name, count)
+}
+
+// NewMockWallet calls wallettest.NewMockWallet.
+func NewMockWallet(privateKey *ec.PrivateKey, nonces ...string) WalletInterface {
+	return wallettest.NewMockWallet(privateKey, nonces...)
+}
+
+// NewInteractiveMockWallet calls wallettest.NewInteractiveMockWallet.
+func NewInteractiveMockWallet(privateKey *ec.PrivateKey, permission PermissionFunc, nonces ...string) WalletInterface {
+	return wallettest.NewInteractiveMockWallet(privateKey, permission, nonces...)
+}
+
+// NewPrivilegedMockWallet calls wallettest.NewPrivilegedMockWallet.
+func NewPrivilegedMockWallet(privateKey, privilegedKey *ec.PrivateKey, nonces ...string) WalletInterface {
+	return wallettest.NewPrivilegedMockWallet(privateKey, privilegedKey, nonces...)
+}
+
+// NewRandomMockWallet calls wallettest.NewRandomMockWallet.
+func NewRandomMockWallet(privateKey *ec.PrivateKey, random io.Reader) WalletInterface {
+	return wallettest.NewRandomMockWallet(privateKey, random)
+}
+
+// NewSeededMockWallet calls wallettest.NewSeededMockWallet.
+func NewSeededMockWallet(seed, name string) WalletInterface {
+	return wallettest.NewSeededMockWallet(seed, name)
+}
+
+// NewMockPaymentWallet calls wallettest.NewMockPaymentWallet.
+func NewMockPaymentWallet(key *ec.PrivateKey, nonces ...string) *MockPaymentWallet {
+	return wallettest.NewMockPaymentWallet(key, nonces...)
+}
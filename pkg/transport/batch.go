@@ -0,0 +1,184 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// MaxBatchMessages is the maximum number of general messages in a batch
+const MaxBatchMessages = 1024
+
+// EncodeBatch concatenates the messages into the payload of a GeneralBatch message.
+// The payload is the number of messages followed by every message prefixed with its length,
+// numbers are encoded like in the general message payload (8 bytes little endian).
+func EncodeBatch(messages [][]byte) ([]byte, error) {
+	if len(messages) == 0 || len(messages) > MaxBatchMessages {
+		return nil, fmt.Errorf("%w: batch has to contain 1 to %d messages", ErrInvalidBatch, MaxBatchMessages)
+	}
+
+	var payload bytes.Buffer
+	writeBatchNumber(&payload, len(messages))
+	for _, message := range messages {
+		writeBatchNumber(&payload, len(message))
+		payload.Write(message)
+	}
+	return payload.Bytes(), nil
+}
+
+// DecodeBatch splits the payload of a GeneralBatch message into its messages
+func DecodeBatch(payload []byte) ([][]byte, error) {
+	reader := bytes.NewReader(payload)
+
+	count, err := readBatchNumber(reader)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 || count > MaxBatchMessages {
+		return nil, fmt.Errorf("%w: batch has to contain 1 to %d messages", ErrInvalidBatch, MaxBatchMessages)
+	}
+
+	messages := make([][]byte, 0, count)
+	for range count {
+		length, err := readBatchNumber(reader)
+		if err != nil {
+			return nil, err
+		}
+		if length > uint64(reader.Len()) {
+			return nil, fmt.Errorf("%w: message exceeds the payload", ErrInvalidBatch)
+		}
+
+		message := make([]byte, length)
+		_, _ = reader.Read(message)
+		messages = append(messages, message)
+	}
+
+	if reader.Len() != 0 {
+		return nil, fmt.Errorf("%w: trailing bytes after the last message", ErrInvalidBatch)
+	}
+	return messages, nil
+}
+
+// SignBatch creates a GeneralBatch message carrying the messages under a single signature of the wallet,
+// so a chatty peer signs once per batch instead of once per message.
+// The signature is bound to the session like a general message, yourNonce is the session nonce of the peer.
+func SignBatch(ctx context.Context, w wallet.WalletInterface, peerIdentityKey, yourNonce string, messages [][]byte) (*AuthMessage, error) {
+	payload, err := EncodeBatch(messages)
+	if err != nil {
+		return nil, err
+	}
+
+	return signSessionMessage(ctx, w, GeneralBatch, peerIdentityKey, yourNonce, payload)
+}
+
+// VerifyBatch verifies the signature of a GeneralBatch message and returns its messages.
+// Checking that YourNonce belongs to a session of the sender is left to the transport, like for general messages.
+func VerifyBatch(w wallet.WalletInterface, msg *AuthMessage) ([][]byte, error) {
+	if msg.MessageType != GeneralBatch {
+		return nil, fmt.Errorf("%w: %s is not a batch", ErrUnsupportedMessageType, msg.MessageType)
+	}
+	if msg.Nonce == nil || msg.YourNonce == nil || msg.Payload == nil || msg.Signature == nil {
+		return nil, fmt.Errorf("%w: batch requires nonce, your nonce, payload and signature", ErrMalformedMessage)
+	}
+
+	if err := verifySessionMessage(w, msg); err != nil {
+		return nil, err
+	}
+
+	return DecodeBatch(*msg.Payload)
+}
+
+// signSessionMessage creates a message of the type carrying the payload, signed like a general message of the session
+func signSessionMessage(ctx context.Context, w wallet.WalletInterface, messageType MessageType, peerIdentityKey, yourNonce string, payload []byte) (*AuthMessage, error) {
+	peerKey, err := ec.PublicKeyFromString(peerIdentityKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w, %w", ErrInvalidIdentityKey, err)
+	}
+
+	identityKey, err := w.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve identity key, %w", err)
+	}
+
+	nonce, err := w.CreateNonce(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create nonce, %w", err)
+	}
+
+	signature, err := w.CreateSignature(&wallet.CreateSignatureArgs{
+		EncryptionArgs: SessionMessageEncryptionArgs(peerKey, nonce, yourNonce),
+		Data:           payload,
+	}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signature, %w", err)
+	}
+	serialized := signature.Signature.Serialize()
+
+	return &AuthMessage{
+		Version:     AuthVersion,
+		MessageType: messageType,
+		IdentityKey: identityKey.PublicKey.ToDERHex(),
+		Nonce:       &nonce,
+		YourNonce:   &yourNonce,
+		Payload:     &payload,
+		Signature:   &serialized,
+	}, nil
+}
+
+// verifySessionMessage verifies the signature of a message signed with signSessionMessage
+func verifySessionMessage(w wallet.WalletInterface, msg *AuthMessage) error {
+	peerKey, err := ec.PublicKeyFromString(msg.IdentityKey)
+	if err != nil {
+		return fmt.Errorf("%w, %w", ErrInvalidIdentityKey, err)
+	}
+
+	signature, err := ec.ParseSignature(*msg.Signature)
+	if err != nil {
+		return fmt.Errorf("%w, %w", ErrInvalidSignature, err)
+	}
+
+	result, err := w.VerifySignature(&wallet.VerifySignatureArgs{
+		EncryptionArgs: SessionMessageEncryptionArgs(peerKey, *msg.Nonce, *msg.YourNonce),
+		Signature:      *signature,
+		Data:           *msg.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("%w, %w", ErrInvalidSignature, err)
+	}
+	if !result.Valid {
+		return fmt.Errorf("%w, %w", ErrInvalidSignature, wallet.ErrSignatureInvalid)
+	}
+	return nil
+}
+
+// SessionMessageEncryptionArgs returns the signature arguments of batches and heartbeats, derived like the signature
+// of a general message
+func SessionMessageEncryptionArgs(peerKey *ec.PublicKey, nonce, yourNonce string) wallet.EncryptionArgs {
+	return wallet.EncryptionArgs{
+		ProtocolID: wallet.DefaultAuthProtocol,
+		KeyID:      fmt.Sprintf("%s %s", nonce, yourNonce),
+		Counterparty: wallet.Counterparty{
+			Type:         wallet.CounterpartyTypeOther,
+			Counterparty: peerKey,
+		},
+	}
+}
+
+func writeBatchNumber(payload *bytes.Buffer, n int) {
+	_ = binary.Write(payload, binary.LittleEndian, int64(n))
+}
+
+func readBatchNumber(reader *bytes.Reader) (uint64, error) {
+	var n int64
+	if err := binary.Read(reader, binary.LittleEndian, &n); err != nil {
+		return 0, fmt.Errorf("%w: truncated payload", ErrInvalidBatch)
+	}
+	if n < 0 {
+		return 0, fmt.Errorf("%w: negative length", ErrInvalidBatch)
+	}
+	return uint64(n), nil
+}
@@ -0,0 +1,124 @@
+package transport_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatch_Encoding(t *testing.T) {
+	t.Run("messages survive a round trip", func(t *testing.T) {
+		// given
+		messages := [][]byte{[]byte("first"), {}, []byte("third")}
+
+		// when
+		payload, err := transport.EncodeBatch(messages)
+		require.NoError(t, err)
+		decoded, err := transport.DecodeBatch(payload)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, messages, decoded)
+	})
+
+	t.Run("invalid batches", func(t *testing.T) {
+		payload, err := transport.EncodeBatch([][]byte{[]byte("message")})
+		require.NoError(t, err)
+
+		tests := map[string][]byte{
+			"empty payload":      {},
+			"truncated count":    payload[:4],
+			"truncated message":  payload[:len(payload)-1],
+			"trailing bytes":     append(append([]byte(nil), payload...), 0x00),
+			"zero messages":      make([]byte, 8),
+			"negative length":    append(append([]byte(nil), payload[:8]...), 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff),
+			"length beyond data": append(append([]byte(nil), payload[:8]...), 0xff, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00),
+		}
+
+		for name, payload := range tests {
+			t.Run(name, func(t *testing.T) {
+				// when
+				_, err := transport.DecodeBatch(payload)
+
+				// then
+				require.ErrorIs(t, err, transport.ErrInvalidBatch)
+			})
+		}
+	})
+
+	t.Run("batch size is limited", func(t *testing.T) {
+		// when
+		_, emptyErr := transport.EncodeBatch(nil)
+		_, oversizedErr := transport.EncodeBatch(make([][]byte, transport.MaxBatchMessages+1))
+
+		// then
+		require.ErrorIs(t, emptyErr, transport.ErrInvalidBatch)
+		require.ErrorIs(t, oversizedErr, transport.ErrInvalidBatch)
+	})
+}
+
+func TestBatch_Signature(t *testing.T) {
+	sender := wallettest.NewSeededMockWallet(wallettest.Seed, "client")
+	receiver := wallettest.NewSeededMockWallet(wallettest.Seed, "server")
+	receiverKey := wallettest.SeededPrivateKey(wallettest.Seed, "server").PubKey().ToDERHex()
+	messages := [][]byte{[]byte(`{"op":"subscribe"}`), []byte(`{"op":"ping"}`)}
+
+	sign := func(t *testing.T) *transport.AuthMessage {
+		msg, err := transport.SignBatch(context.Background(), sender, receiverKey, "session-nonce", messages)
+		require.NoError(t, err)
+		return msg
+	}
+
+	t.Run("signed batch is verified", func(t *testing.T) {
+		// given
+		msg := sign(t)
+
+		// when
+		verified, err := transport.VerifyBatch(receiver, msg)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, transport.GeneralBatch, msg.MessageType)
+		require.Equal(t, messages, verified)
+	})
+
+	t.Run("tampered message fails the signature", func(t *testing.T) {
+		// given
+		msg := sign(t)
+		(*msg.Payload)[len(*msg.Payload)-2] ^= 0x01
+
+		// when
+		_, err := transport.VerifyBatch(receiver, msg)
+
+		// then
+		require.ErrorIs(t, err, transport.ErrInvalidSignature)
+	})
+
+	t.Run("batch replayed to another session fails the signature", func(t *testing.T) {
+		// given
+		msg := sign(t)
+		otherSession := "other-session-nonce"
+		msg.YourNonce = &otherSession
+
+		// when
+		_, err := transport.VerifyBatch(receiver, msg)
+
+		// then
+		require.ErrorIs(t, err, transport.ErrInvalidSignature)
+	})
+
+	t.Run("general message is not a batch", func(t *testing.T) {
+		// given
+		msg := sign(t)
+		msg.MessageType = transport.General
+
+		// when
+		_, err := transport.VerifyBatch(receiver, msg)
+
+		// then
+		require.ErrorIs(t, err, transport.ErrUnsupportedMessageType)
+	})
+}
@@ -0,0 +1,58 @@
+package transport
+
+import "slices"
+
+// Capability is an optional protocol feature negotiated in the handshake. The peer offers its capabilities
+// in the initialRequest, the server responds with the offered capabilities it supports, which are stored
+// in the session and enabled for both sides. Unknown capabilities are ignored, so peers can offer features
+// servers of older versions do not know.
+type Capability string
+
+// Capabilities of this version of the protocol
+const (
+	// CapabilityPayloadEncryption encrypts general message bodies, see utils.PrepareEncryptedGeneralRequest
+	CapabilityPayloadEncryption Capability = "payloadEncryption"
+	// CapabilityPayloadPadding pads general message bodies to size buckets, see PadPayload
+	CapabilityPayloadPadding Capability = "payloadPadding"
+	// CapabilityHeartbeat accepts Heartbeat messages refreshing the session, see SignHeartbeat
+	CapabilityHeartbeat Capability = "heartbeat"
+	// CapabilityDigestBLAKE3 computes the Content-Digest of file responses with BLAKE3 instead of SHA-256,
+	// see NegotiatedDigestAlgorithm
+	CapabilityDigestBLAKE3 Capability = "digestBlake3"
+	// CapabilityAllowlistExchange accepts AllowlistExchange messages of federation partners, see SignAllowlistExchange
+	CapabilityAllowlistExchange Capability = "allowlistExchange"
+	// CapabilityTrailerSignature lets handlers stream responses by flushing them, the signature is sent in the
+	// X-Bsv-Auth-Signature trailer over the SHA-256 hash of the body, see authcore.StreamedLength
+	CapabilityTrailerSignature Capability = "trailerSignature"
+)
+
+// OfferedCapabilities returns the capabilities offered in the handshake message, including those of peers which
+// only set the PayloadEncryption and PayloadPadding flags of earlier versions
+func (m *AuthMessage) OfferedCapabilities() []Capability {
+	offered := slices.Clone(m.Capabilities)
+	if m.PayloadEncryption && !slices.Contains(offered, CapabilityPayloadEncryption) {
+		offered = append(offered, CapabilityPayloadEncryption)
+	}
+	if m.PayloadPadding && !slices.Contains(offered, CapabilityPayloadPadding) {
+		offered = append(offered, CapabilityPayloadPadding)
+	}
+	return offered
+}
+
+// SetCapabilities sets the capabilities of the handshake message, along with the flags read by peers of earlier versions
+func (m *AuthMessage) SetCapabilities(capabilities []Capability) {
+	m.Capabilities = capabilities
+	m.PayloadEncryption = slices.Contains(capabilities, CapabilityPayloadEncryption)
+	m.PayloadPadding = slices.Contains(capabilities, CapabilityPayloadPadding)
+}
+
+// NegotiateCapabilities returns the offered capabilities which are supported, in the order of supported
+func NegotiateCapabilities(offered, supported []Capability) []Capability {
+	negotiated := make([]Capability, 0, len(supported))
+	for _, capability := range supported {
+		if slices.Contains(offered, capability) && !slices.Contains(negotiated, capability) {
+			negotiated = append(negotiated, capability)
+		}
+	}
+	return negotiated
+}
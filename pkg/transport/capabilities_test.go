@@ -0,0 +1,62 @@
+package transport_test
+
+import (
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapabilities(t *testing.T) {
+	t.Run("negotiated capabilities are the offered ones which are supported", func(t *testing.T) {
+		tests := map[string]struct {
+			offered   []transport.Capability
+			supported []transport.Capability
+			expected  []transport.Capability
+		}{
+			"common capabilities in the order of the server": {
+				offered:   []transport.Capability{transport.CapabilityPayloadPadding, transport.CapabilityHeartbeat},
+				supported: []transport.Capability{transport.CapabilityHeartbeat, transport.CapabilityPayloadEncryption, transport.CapabilityPayloadPadding},
+				expected:  []transport.Capability{transport.CapabilityHeartbeat, transport.CapabilityPayloadPadding},
+			},
+			"unknown capabilities are ignored": {
+				offered:   []transport.Capability{"compressionAwareSigning", transport.CapabilityHeartbeat},
+				supported: []transport.Capability{transport.CapabilityHeartbeat},
+				expected:  []transport.Capability{transport.CapabilityHeartbeat},
+			},
+			"nothing offered": {
+				supported: []transport.Capability{transport.CapabilityHeartbeat},
+				expected:  []transport.Capability{},
+			},
+		}
+
+		for name, test := range tests {
+			t.Run(name, func(t *testing.T) {
+				require.Equal(t, test.expected, transport.NegotiateCapabilities(test.offered, test.supported))
+			})
+		}
+	})
+
+	t.Run("flags of earlier versions are offered as capabilities", func(t *testing.T) {
+		// given
+		msg := transport.AuthMessage{PayloadEncryption: true, Capabilities: []transport.Capability{transport.CapabilityHeartbeat}}
+
+		// when
+		offered := msg.OfferedCapabilities()
+
+		// then
+		require.Equal(t, []transport.Capability{transport.CapabilityHeartbeat, transport.CapabilityPayloadEncryption}, offered)
+	})
+
+	t.Run("capabilities set the flags read by earlier versions", func(t *testing.T) {
+		// given
+		msg := transport.AuthMessage{PayloadEncryption: true}
+
+		// when
+		msg.SetCapabilities([]transport.Capability{transport.CapabilityPayloadPadding})
+
+		// then
+		require.False(t, msg.PayloadEncryption)
+		require.True(t, msg.PayloadPadding)
+	})
+}
@@ -0,0 +1,185 @@
+package transport
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// CertificateTypeIDSize is the size of a decoded certificate type ID
+const CertificateTypeIDSize = 32
+
+// RequestedCertificateTypeIDAndFieldList maps base64 certificate type IDs to the fields requested
+// from certificates of the type, it matches the type of the same name in the TS SDK.
+type RequestedCertificateTypeIDAndFieldList map[string][]string
+
+// RequestedCertificateSet represents the set of certificates requested by a peer.
+// It is encoded like the TS SDK does, so empty certifiers, types and field lists are sent as [] and {} instead of null.
+type RequestedCertificateSet struct {
+	// Certifiers are the compressed public keys (hex) of the certifiers trusted by the peer
+	Certifiers []string `json:"certifiers"`
+	// Types are the requested certificate types and their fields
+	Types RequestedCertificateTypeIDAndFieldList `json:"types"`
+}
+
+// NewRequestedCertificateSet creates a set of certificates requested from the given certifiers,
+// types are added with AddType
+func NewRequestedCertificateSet(certifiers ...string) *RequestedCertificateSet {
+	return &RequestedCertificateSet{
+		Certifiers: append([]string{}, certifiers...),
+		Types:      RequestedCertificateTypeIDAndFieldList{},
+	}
+}
+
+// AddType requests the fields of certificates of the given base64 type ID, fields already requested are not duplicated
+func (s *RequestedCertificateSet) AddType(typeID string, fields ...string) *RequestedCertificateSet {
+	if s.Types == nil {
+		s.Types = RequestedCertificateTypeIDAndFieldList{}
+	}
+
+	requested := s.Types[typeID]
+	if requested == nil {
+		requested = []string{}
+	}
+	for _, field := range fields {
+		if !slices.Contains(requested, field) {
+			requested = append(requested, field)
+		}
+	}
+	s.Types[typeID] = requested
+
+	return s
+}
+
+// Validate checks the certifiers are compressed public keys and the types are base64 type IDs with requested fields
+func (s *RequestedCertificateSet) Validate() error {
+	if len(s.Certifiers) == 0 {
+		return fmt.Errorf("%w: no certifiers", ErrInvalidRequestedCertificates)
+	}
+
+	for _, certifier := range s.Certifiers {
+		if len(certifier) != 2*ec.PubKeyBytesLenCompressed {
+			return fmt.Errorf("%w: certifier %q is not a compressed public key", ErrInvalidRequestedCertificates, certifier)
+		}
+		if _, err := ec.PublicKeyFromString(certifier); err != nil {
+			return fmt.Errorf("%w: certifier %q is not a valid public key", ErrInvalidRequestedCertificates, certifier)
+		}
+	}
+
+	if len(s.Types) == 0 {
+		return fmt.Errorf("%w: no certificate types", ErrInvalidRequestedCertificates)
+	}
+
+	for typeID, fields := range s.Types {
+		decoded, err := base64.StdEncoding.DecodeString(typeID)
+		if err != nil || len(decoded) != CertificateTypeIDSize {
+			return fmt.Errorf("%w: certificate type %q is not a base64 encoded %d byte type ID",
+				ErrInvalidRequestedCertificates, typeID, CertificateTypeIDSize)
+		}
+
+		if len(fields) == 0 {
+			return fmt.Errorf("%w: no fields requested for certificate type %s", ErrInvalidRequestedCertificates, typeID)
+		}
+		if slices.Contains(fields, "") {
+			return fmt.Errorf("%w: empty field name for certificate type %s", ErrInvalidRequestedCertificates, typeID)
+		}
+	}
+
+	return nil
+}
+
+// CheckDisclosure checks the keyring of every certificate reveals exactly the fields requested from its type,
+// certificates of types which were not requested must not reveal any field. It returns CertificateErrors
+// with a DisclosureError for every certificate revealing too few or too many fields. Verifiable credentials
+// are not checked, they carry the whole credential in a single field.
+func (s *RequestedCertificateSet) CheckDisclosure(certs []certificates.VerifiableCertificate) error {
+	var errs CertificateErrors
+	for i, cert := range certs {
+		if cert.Type == VerifiableCredentialType {
+			continue
+		}
+
+		requested := s.Types[cert.Type]
+		var missing, unrequested []string
+		for _, field := range requested {
+			if _, ok := cert.Keyring[field]; !ok {
+				missing = append(missing, field)
+			}
+		}
+		for field := range cert.Keyring {
+			if !slices.Contains(requested, field) {
+				unrequested = append(unrequested, field)
+			}
+		}
+
+		if len(missing) > 0 || len(unrequested) > 0 {
+			sort.Strings(unrequested)
+			disclosureErr := &DisclosureError{Type: cert.Type, SerialNumber: cert.SerialNumber, Missing: missing, Unrequested: unrequested}
+			errs = append(errs, NewCertificateError(i, cert.SerialNumber, cert.Type, disclosureErr))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// requestedCertificateSetJSON has the fields of RequestedCertificateSet without its JSON methods
+type requestedCertificateSetJSON RequestedCertificateSet
+
+// MarshalJSON encodes nil certifiers, types and field lists as empty JSON arrays and objects
+func (s RequestedCertificateSet) MarshalJSON() ([]byte, error) {
+	encoded := requestedCertificateSetJSON{
+		Certifiers: s.Certifiers,
+		Types:      make(RequestedCertificateTypeIDAndFieldList, len(s.Types)),
+	}
+	if encoded.Certifiers == nil {
+		encoded.Certifiers = []string{}
+	}
+	for typeID, fields := range s.Types {
+		if fields == nil {
+			fields = []string{}
+		}
+		encoded.Types[typeID] = fields
+	}
+
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON decodes the set, null certifiers and types are decoded as empty ones
+func (s *RequestedCertificateSet) UnmarshalJSON(data []byte) error {
+	var decoded requestedCertificateSetJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	if decoded.Certifiers == nil {
+		decoded.Certifiers = []string{}
+	}
+	if decoded.Types == nil {
+		decoded.Types = RequestedCertificateTypeIDAndFieldList{}
+	}
+
+	*s = RequestedCertificateSet(decoded)
+	return nil
+}
+
+// CertificateUpgradeMode defines how sessions authenticated under previous certificate requirements
+// are treated when the requirements change at runtime
+type CertificateUpgradeMode int
+
+const (
+	// CertificateUpgradeIgnore applies the new requirements to new sessions only
+	CertificateUpgradeIgnore CertificateUpgradeMode = iota
+	// CertificateUpgradeRechallenge flags existing sessions, their next request is answered with the new certificate request
+	// and they are authenticated again once the peer sends matching certificates
+	CertificateUpgradeRechallenge
+	// CertificateUpgradeRevoke ends existing sessions on their next request, the peers have to repeat the handshake
+	CertificateUpgradeRevoke
+)
@@ -0,0 +1,220 @@
+package transport_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	certifier       = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	certificateType = "9ZkJfGmbXcggy2CL1Eb8wX1hv2WwVnGf4TTZ1FHrPFU="
+	identityKey     = "02f9308a019258c31049344f85f89d5229b531c845836f99b08601f113bce036f9"
+)
+
+func TestRequestedCertificateSet_JSON(t *testing.T) {
+	t.Run("encodes like the TS SDK", func(t *testing.T) {
+		// given
+		set := transport.NewRequestedCertificateSet(certifier).AddType(certificateType, "age", "country")
+
+		// when
+		encoded, err := json.Marshal(set)
+
+		// then
+		require.NoError(t, err)
+		require.JSONEq(t, `{"certifiers":["`+certifier+`"],"types":{"`+certificateType+`":["age","country"]}}`, string(encoded))
+	})
+
+	t.Run("encodes empty set without nulls", func(t *testing.T) {
+		// when
+		encoded, err := json.Marshal(transport.RequestedCertificateSet{
+			Types: transport.RequestedCertificateTypeIDAndFieldList{certificateType: nil},
+		})
+
+		// then
+		require.NoError(t, err)
+		require.JSONEq(t, `{"certifiers":[],"types":{"`+certificateType+`":[]}}`, string(encoded))
+	})
+
+	t.Run("decodes the TS SDK encoding losslessly", func(t *testing.T) {
+		// given
+		data := `{"certifiers":["` + certifier + `"],"types":{"` + certificateType + `":["age"]}}`
+
+		// when
+		var set transport.RequestedCertificateSet
+		require.NoError(t, json.Unmarshal([]byte(data), &set))
+		encoded, err := json.Marshal(set)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, []string{certifier}, set.Certifiers)
+		require.Equal(t, []string{"age"}, set.Types[certificateType])
+		require.JSONEq(t, data, string(encoded))
+	})
+
+	t.Run("decodes nulls as empty set", func(t *testing.T) {
+		// when
+		var set transport.RequestedCertificateSet
+		require.NoError(t, json.Unmarshal([]byte(`{"certifiers":null,"types":null}`), &set))
+
+		// then
+		require.NotNil(t, set.Certifiers)
+		require.NotNil(t, set.Types)
+		require.Empty(t, set.Certifiers)
+		require.Empty(t, set.Types)
+	})
+}
+
+func TestRequestedCertificateSet_AddType(t *testing.T) {
+	// given
+	set := &transport.RequestedCertificateSet{}
+
+	// when
+	set.AddType(certificateType, "age").AddType(certificateType, "age", "country")
+
+	// then
+	require.Equal(t, []string{"age", "country"}, set.Types[certificateType])
+}
+
+func TestRequestedCertificateSet_Validate(t *testing.T) {
+	tests := map[string]struct {
+		set   *transport.RequestedCertificateSet
+		valid bool
+	}{
+		"valid set": {
+			set:   transport.NewRequestedCertificateSet(certifier).AddType(certificateType, "age"),
+			valid: true,
+		},
+		"no certifiers": {
+			set: transport.NewRequestedCertificateSet().AddType(certificateType, "age"),
+		},
+		"certifier which is not hex": {
+			set: transport.NewRequestedCertificateSet("certifier-key").AddType(certificateType, "age"),
+		},
+		"uncompressed certifier": {
+			set: transport.NewRequestedCertificateSet("04"+certifier[2:]+certifier[2:]).AddType(certificateType, "age"),
+		},
+		"no types": {
+			set: transport.NewRequestedCertificateSet(certifier),
+		},
+		"type ID which is not base64": {
+			set: transport.NewRequestedCertificateSet(certifier).AddType("age-verification", "age"),
+		},
+		"type ID of wrong length": {
+			set: transport.NewRequestedCertificateSet(certifier).AddType("YWdl", "age"),
+		},
+		"type without fields": {
+			set: transport.NewRequestedCertificateSet(certifier).AddType(certificateType),
+		},
+		"empty field name": {
+			set: transport.NewRequestedCertificateSet(certifier).AddType(certificateType, ""),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// when
+			err := test.set.Validate()
+
+			// then
+			if test.valid {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, transport.ErrInvalidRequestedCertificates)
+			}
+		})
+	}
+}
+
+func TestRequestedCertificateSet_CheckDisclosure(t *testing.T) {
+	set := transport.NewRequestedCertificateSet(certifier).AddType(certificateType, "age", "country")
+
+	certificate := func(typeID string, fields ...string) certificates.VerifiableCertificate {
+		keyring := make(map[string]string, len(fields))
+		for _, field := range fields {
+			keyring[field] = "mockkey"
+		}
+		return certificates.VerifiableCertificate{
+			Certificate: certificates.Certificate{Type: typeID, SerialNumber: "serial-1"},
+			Keyring:     keyring,
+		}
+	}
+
+	tests := map[string]struct {
+		cert     certificates.VerifiableCertificate
+		expected *transport.DisclosureError
+	}{
+		"exactly the requested fields": {
+			cert: certificate(certificateType, "age", "country"),
+		},
+		"requested field missing": {
+			cert:     certificate(certificateType, "age"),
+			expected: &transport.DisclosureError{Type: certificateType, SerialNumber: "serial-1", Missing: []string{"country"}},
+		},
+		"unrequested fields revealed": {
+			cert:     certificate(certificateType, "age", "country", "name", "address"),
+			expected: &transport.DisclosureError{Type: certificateType, SerialNumber: "serial-1", Unrequested: []string{"address", "name"}},
+		},
+		"unrequested type without revealed fields": {
+			cert: certificate("YWdl"),
+		},
+		"unrequested type with revealed fields": {
+			cert:     certificate("YWdl", "age"),
+			expected: &transport.DisclosureError{Type: "YWdl", SerialNumber: "serial-1", Unrequested: []string{"age"}},
+		},
+		"verifiable credential": {
+			cert: certificate(transport.VerifiableCredentialType, transport.VerifiableCredentialField),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// when
+			err := set.CheckDisclosure([]certificates.VerifiableCertificate{test.cert})
+
+			// then
+			if test.expected == nil {
+				require.NoError(t, err)
+				return
+			}
+			var disclosureErr *transport.DisclosureError
+			require.ErrorAs(t, err, &disclosureErr)
+			require.Equal(t, test.expected, disclosureErr)
+		})
+	}
+
+	t.Run("under and over disclosure match both sentinels", func(t *testing.T) {
+		// when
+		err := set.CheckDisclosure([]certificates.VerifiableCertificate{certificate(certificateType, "age", "name")})
+
+		// then
+		require.ErrorIs(t, err, transport.ErrCertificateUnderDisclosed)
+		require.ErrorIs(t, err, transport.ErrCertificateOverDisclosed)
+		require.Equal(t, transport.ErrCodeCertificateUnderDisclosed, transport.ErrorCode(err))
+	})
+
+	t.Run("every failing certificate is listed", func(t *testing.T) {
+		// given
+		under, exact, over := certificate(certificateType, "age"), certificate(certificateType, "age", "country"),
+			certificate("YWdl", "age")
+		over.SerialNumber = "serial-3"
+
+		// when
+		err := set.CheckDisclosure([]certificates.VerifiableCertificate{under, exact, over})
+
+		// then
+		var certErrs transport.CertificateErrors
+		require.ErrorAs(t, err, &certErrs)
+		require.Len(t, certErrs, 2)
+		require.Equal(t, 0, certErrs[0].Index)
+		require.Equal(t, transport.ErrCodeCertificateUnderDisclosed, certErrs[0].Code)
+		require.Equal(t, 2, certErrs[1].Index)
+		require.Equal(t, "serial-3", certErrs[1].SerialNumber)
+		require.Equal(t, transport.ErrCodeCertificateOverDisclosed, certErrs[1].Code)
+		require.ErrorIs(t, err, transport.ErrCertificateUnderDisclosed)
+		require.ErrorIs(t, err, transport.ErrCertificateOverDisclosed)
+	})
+}
@@ -0,0 +1,164 @@
+package transport
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+)
+
+// DefaultMaxCertificatesSize is the maximum size of decompressed certificates when no maximum is configured,
+// the size of uncompressed certificates is limited by the maximum size of the message
+const DefaultMaxCertificatesSize = 4 << 20
+
+// certificateCompressionCapability prefixes the encoding in the capability of a CertificateCompression
+const certificateCompressionCapability = "certificateCompression:"
+
+// CertificateCompression compresses the certificates of certificateResponse messages, so large certificate sets stay
+// below the size limits of the message. Compressions are negotiated in the handshake with CompressionCapability,
+// GzipCompression and DeflateCompression are built in, other encodings can be plugged in by implementing the interface.
+type CertificateCompression interface {
+	// Encoding names the compression in CompressedCertificates, e.g. "gzip"
+	Encoding() string
+	// Compress returns the compressed data
+	Compress(data []byte) ([]byte, error)
+	// Decompress returns a reader of the decompressed data
+	Decompress(r io.Reader) (io.ReadCloser, error)
+}
+
+// Built-in certificate compressions
+var (
+	// GzipCompression compresses certificates with gzip (RFC 1952)
+	GzipCompression CertificateCompression = gzipCompression{}
+	// DeflateCompression compresses certificates with raw deflate (RFC 1951)
+	DeflateCompression CertificateCompression = deflateCompression{}
+)
+
+// CompressionCapability returns the capability negotiating the compression, e.g. "certificateCompression:gzip"
+func CompressionCapability(compression CertificateCompression) Capability {
+	return Capability(certificateCompressionCapability + compression.Encoding())
+}
+
+// CompressedCertificates carries the JSON encoded certificates of a certificateResponse compressed with a negotiated
+// CertificateCompression, the signature of the message still covers the canonical JSON encoding of the certificates,
+// see authcore.CertificatesPayload
+type CompressedCertificates struct {
+	Encoding string `json:"encoding"`
+	// Data is the compressed JSON array of the certificates, base64 encoded in the message
+	Data []byte `json:"data"`
+}
+
+// CompressCertificates moves the certificates of the message into CompressedCertificates, compressed with the compression
+func (m *AuthMessage) CompressCertificates(compression CertificateCompression) error {
+	if m.Certificates == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(*m.Certificates)
+	if err != nil {
+		return fmt.Errorf("failed to encode certificates, %w", err)
+	}
+	compressed, err := compression.Compress(data)
+	if err != nil {
+		return fmt.Errorf("failed to compress certificates, %w", err)
+	}
+
+	m.CompressedCertificates = &CompressedCertificates{Encoding: compression.Encoding(), Data: compressed}
+	m.Certificates = nil
+	return nil
+}
+
+// DecompressCertificates replaces the CompressedCertificates of the message with the certificates they carry.
+// Encodings other than those of the compressions are rejected with ErrUnsupportedCompression. At most maxSize bytes
+// are decompressed, so decompression bombs are rejected with ErrMessageTooLarge before they are held in memory,
+// the decompressed certificates are held to the limits of CheckMessageLimits like the message carrying them.
+func (m *AuthMessage) DecompressCertificates(compressions []CertificateCompression, maxSize int) error {
+	if m.CompressedCertificates == nil {
+		return nil
+	}
+
+	index := slices.IndexFunc(compressions, func(c CertificateCompression) bool {
+		return c.Encoding() == m.CompressedCertificates.Encoding
+	})
+	if index < 0 {
+		return fmt.Errorf("%w: %q", ErrUnsupportedCompression, m.CompressedCertificates.Encoding)
+	}
+
+	reader, err := compressions[index].Decompress(bytes.NewReader(m.CompressedCertificates.Data))
+	if err != nil {
+		return fmt.Errorf("%w: failed to decompress certificates, %w", ErrMalformedMessage, err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(reader, int64(maxSize)+1))
+	if err != nil {
+		return fmt.Errorf("%w: failed to decompress certificates, %w", ErrMalformedMessage, err)
+	}
+	if len(data) > maxSize {
+		return fmt.Errorf("%w: decompressed certificates exceed %d bytes", ErrMessageTooLarge, maxSize)
+	}
+	if err := CheckMessageLimits(data); err != nil {
+		return err
+	}
+
+	var certs []certificates.VerifiableCertificate
+	if err := json.Unmarshal(data, &certs); err != nil {
+		return fmt.Errorf("%w: failed to decode decompressed certificates, %w", ErrMalformedMessage, err)
+	}
+
+	m.Certificates = &certs
+	m.CompressedCertificates = nil
+	return nil
+}
+
+type gzipCompression struct{}
+
+func (gzipCompression) Encoding() string {
+	return "gzip"
+}
+
+func (gzipCompression) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err //nolint:wrapcheck // wrapped by CompressCertificates
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err //nolint:wrapcheck // wrapped by CompressCertificates
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCompression) Decompress(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r) //nolint:wrapcheck // wrapped by DecompressCertificates
+}
+
+type deflateCompression struct{}
+
+func (deflateCompression) Encoding() string {
+	return "deflate"
+}
+
+func (deflateCompression) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer, err := flate.NewWriter(&buf, flate.DefaultCompression)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // wrapped by CompressCertificates
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, err //nolint:wrapcheck // wrapped by CompressCertificates
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err //nolint:wrapcheck // wrapped by CompressCertificates
+	}
+	return buf.Bytes(), nil
+}
+
+func (deflateCompression) Decompress(r io.Reader) (io.ReadCloser, error) {
+	return flate.NewReader(r), nil
+}
@@ -0,0 +1,108 @@
+package transport_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMessage_CompressCertificates(t *testing.T) {
+	certs := []certificates.VerifiableCertificate{{
+		Certificate: certificates.Certificate{Type: "type", SerialNumber: "serial", Fields: map[string]any{"age": "21"}},
+		Keyring:     map[string]string{"age": "key"},
+	}}
+
+	for _, compression := range []transport.CertificateCompression{transport.GzipCompression, transport.DeflateCompression} {
+		t.Run(compression.Encoding(), func(t *testing.T) {
+			// given
+			msg := &transport.AuthMessage{MessageType: transport.CertificateResponse, Certificates: &certs}
+
+			// when
+			require.NoError(t, msg.CompressCertificates(compression))
+			data, err := json.Marshal(msg)
+			require.NoError(t, err)
+			var decoded transport.AuthMessage
+			require.NoError(t, json.Unmarshal(data, &decoded))
+			err = decoded.DecompressCertificates([]transport.CertificateCompression{transport.GzipCompression, transport.DeflateCompression}, transport.DefaultMaxCertificatesSize)
+
+			// then
+			require.NoError(t, err)
+			require.Nil(t, msg.Certificates)
+			require.Equal(t, compression.Encoding(), msg.CompressedCertificates.Encoding)
+			require.Nil(t, decoded.CompressedCertificates)
+			require.Equal(t, certs, *decoded.Certificates)
+		})
+	}
+}
+
+func TestAuthMessage_DecompressCertificates(t *testing.T) {
+	compressed := func(t *testing.T, compression transport.CertificateCompression, data []byte) *transport.CompressedCertificates {
+		t.Helper()
+		out, err := compression.Compress(data)
+		require.NoError(t, err)
+		return &transport.CompressedCertificates{Encoding: compression.Encoding(), Data: out}
+	}
+
+	tests := map[string]struct {
+		certificates func(t *testing.T) *transport.CompressedCertificates
+		maxSize      int
+		err          error
+	}{
+		"certificates within the limit are decompressed": {
+			certificates: func(t *testing.T) *transport.CompressedCertificates {
+				return compressed(t, transport.GzipCompression, []byte("[]"))
+			},
+			maxSize: 2,
+		},
+		"decompression bomb is rejected": {
+			certificates: func(t *testing.T) *transport.CompressedCertificates {
+				return compressed(t, transport.GzipCompression, bytes.Repeat([]byte(" "), 8<<20))
+			},
+			maxSize: 1 << 20,
+			err:     transport.ErrMessageTooLarge,
+		},
+		"encoding which was not negotiated is rejected": {
+			certificates: func(t *testing.T) *transport.CompressedCertificates {
+				return compressed(t, transport.DeflateCompression, []byte("[]"))
+			},
+			maxSize: transport.DefaultMaxCertificatesSize,
+			err:     transport.ErrUnsupportedCompression,
+		},
+		"corrupt data is rejected": {
+			certificates: func(*testing.T) *transport.CompressedCertificates {
+				return &transport.CompressedCertificates{Encoding: "gzip", Data: []byte("not gzip")}
+			},
+			maxSize: transport.DefaultMaxCertificatesSize,
+			err:     transport.ErrMalformedMessage,
+		},
+		"decompressed data which is not a certificate array is rejected": {
+			certificates: func(t *testing.T) *transport.CompressedCertificates {
+				return compressed(t, transport.GzipCompression, []byte(`{"type":"x"}`))
+			},
+			maxSize: transport.DefaultMaxCertificatesSize,
+			err:     transport.ErrMalformedMessage,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			msg := &transport.AuthMessage{CompressedCertificates: test.certificates(t)}
+
+			// when
+			err := msg.DecompressCertificates([]transport.CertificateCompression{transport.GzipCompression}, test.maxSize)
+
+			// then
+			if test.err == nil {
+				require.NoError(t, err)
+				require.NotNil(t, msg.Certificates)
+			} else {
+				require.ErrorIs(t, err, test.err)
+				require.Nil(t, msg.Certificates)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package transport
+
+import (
+	"context"
+	"time"
+)
+
+// VerifiableCredentialType is the base64 type ID of certificates carrying a W3C verifiable credential,
+// the SHA-256 hash of "w3c-verifiable-credential"
+const VerifiableCredentialType = "9j3VtXXZqkmcKN40YsepQcqGGW+XlHJ0Si80E21b488="
+
+// VerifiableCredentialField is the field of certificates of the VerifiableCredentialType which carries the credential
+// as a compact JWT (VC-JWT), the field is sent unencrypted
+const VerifiableCredentialField = "credential"
+
+// Fields added to certificates adapted from verifiable credentials, besides the claims of the credential subject
+const (
+	// CredentialFieldIssuer is the DID of the issuer of the credential
+	CredentialFieldIssuer = "issuer"
+	// CredentialFieldTypes are the types of the credential, joined with commas
+	CredentialFieldTypes = "credentialTypes"
+)
+
+// DIDResolver resolves DIDs of credential issuers to their DID documents
+type DIDResolver interface {
+	// ResolveDID returns the DID document of the DID
+	ResolveDID(ctx context.Context, did string) (*DIDDocument, error)
+}
+
+// DIDResolverFunc adapts a function to the DIDResolver interface
+type DIDResolverFunc func(ctx context.Context, did string) (*DIDDocument, error)
+
+// ResolveDID calls the function
+func (f DIDResolverFunc) ResolveDID(ctx context.Context, did string) (*DIDDocument, error) {
+	return f(ctx, did)
+}
+
+// DIDDocument is the part of a DID document used to verify credentials
+type DIDDocument struct {
+	ID                 string               `json:"id"`
+	VerificationMethod []VerificationMethod `json:"verificationMethod"`
+}
+
+// VerificationMethod is a verification method of a DID document with a JWK public key
+type VerificationMethod struct {
+	ID           string `json:"id"`
+	Type         string `json:"type"`
+	Controller   string `json:"controller"`
+	PublicKeyJwk *JWK   `json:"publicKeyJwk"`
+}
+
+// JWK is a public JSON Web Key of the EC (P-256, secp256k1) or OKP (Ed25519) key types
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y,omitempty"`
+}
+
+// CredentialAdapter verifies W3C verifiable credentials presented in the certificate exchange as certificates
+// of the VerifiableCredentialType, so issuers outside the BSV certificate ecosystem can satisfy certificate requirements.
+// The JWT signature is verified with the key of the issuer resolved by the DID resolver, the ES256, ES256K and EdDSA
+// algorithms are supported. Verified credentials are mapped into certificates whose fields are the claims of the
+// credential subject, set as plain and as decrypted fields, and which are passed to the OnCertificatesReceived callback.
+type CredentialAdapter struct {
+	// Resolver resolves the DID documents of issuers
+	Resolver DIDResolver
+	// TrustedIssuers are the DIDs of the issuers whose credentials are accepted
+	TrustedIssuers []string
+	// Certifier is the compressed public key (hex) set as certifier of adapted certificates,
+	// defaults to the identity key of the server, which vouches for the credentials it verified
+	Certifier string
+	// RequireSubjectBinding rejects credentials whose subject is not the identity URI of the peer,
+	// see X509IdentityURIPrefix. Without it any identity may present a credential it obtained.
+	RequireSubjectBinding bool
+	// Clock returns the time expiration and activation of credentials are checked against, defaults to time.Now
+	Clock func() time.Time
+	// Concurrency is the number of credentials of a certificate response verified at the same time,
+	// defaults to DefaultCredentialConcurrency
+	Concurrency int
+}
+
+// DefaultCredentialConcurrency is the number of credentials verified at the same time when CredentialAdapter.Concurrency is not set
+const DefaultCredentialConcurrency = 8
@@ -0,0 +1,289 @@
+//go:build !nocredentials
+
+package transport
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+type credentialHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type credentialClaims struct {
+	Iss string `json:"iss"`
+	Sub string `json:"sub"`
+	Nbf int64  `json:"nbf"`
+	Exp int64  `json:"exp"`
+	VC  struct {
+		Type              []string       `json:"type"`
+		CredentialSubject map[string]any `json:"credentialSubject"`
+	} `json:"vc"`
+}
+
+// AdaptAll verifies the credentials among the certificates concurrently, so peers presenting several credentials
+// wait for the slowest DID resolution instead of all of them in turn. It returns the certificates with every
+// credential replaced by its adapted certificate, or CertificateErrors listing every credential which failed.
+func (a *CredentialAdapter) AdaptAll(ctx context.Context, certs []certificates.VerifiableCertificate, identityKey, certifier string) ([]certificates.VerifiableCertificate, error) {
+	concurrency := a.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultCredentialConcurrency
+	}
+
+	adapted := slices.Clone(certs)
+	errs := make([]error, len(certs))
+	slots := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, cert := range certs {
+		if cert.Type != VerifiableCredentialType {
+			continue
+		}
+
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			adapted[i], errs[i] = a.Adapt(ctx, cert, identityKey, certifier)
+		}()
+	}
+	wg.Wait()
+
+	var certErrs CertificateErrors
+	for i, err := range errs {
+		if err != nil {
+			certErrs = append(certErrs, NewCertificateError(i, certs[i].SerialNumber, certs[i].Type, err))
+		}
+	}
+	if len(certErrs) > 0 {
+		return nil, certErrs
+	}
+	return adapted, nil
+}
+
+// Adapt verifies the credential of the certificate and returns the certificate mapped from it,
+// with the peer as subject and the given certifier unless the adapter has its own.
+// It fails with ErrInvalidCredential when the credential cannot be verified.
+func (a *CredentialAdapter) Adapt(ctx context.Context, cert certificates.VerifiableCertificate, identityKey, certifier string) (certificates.VerifiableCertificate, error) {
+	if a.Resolver == nil {
+		return cert, fmt.Errorf("%w: no DID resolver configured", ErrInvalidCredential)
+	}
+
+	token, _ := cert.Fields[VerifiableCredentialField].(string)
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return cert, fmt.Errorf("%w: credential is not a compact JWT", ErrInvalidCredential)
+	}
+
+	var header credentialHeader
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return cert, fmt.Errorf("%w: invalid header, %w", ErrInvalidCredential, err)
+	}
+
+	var claims credentialClaims
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return cert, fmt.Errorf("%w: invalid claims, %w", ErrInvalidCredential, err)
+	}
+
+	if !slices.Contains(a.TrustedIssuers, claims.Iss) {
+		return cert, fmt.Errorf("%w: issuer %s is not trusted", ErrInvalidCredential, claims.Iss)
+	}
+
+	now := time.Now()
+	if a.Clock != nil {
+		now = a.Clock()
+	}
+	if claims.Exp != 0 && !now.Before(time.Unix(claims.Exp, 0)) {
+		return cert, fmt.Errorf("%w: credential expired", ErrInvalidCredential)
+	}
+	if claims.Nbf != 0 && now.Before(time.Unix(claims.Nbf, 0)) {
+		return cert, fmt.Errorf("%w: credential not yet valid", ErrInvalidCredential)
+	}
+
+	subject := claims.Sub
+	if id, ok := claims.VC.CredentialSubject["id"].(string); ok && subject == "" {
+		subject = id
+	}
+	if a.RequireSubjectBinding && !strings.EqualFold(subject, X509IdentityURIPrefix+identityKey) {
+		return cert, fmt.Errorf("%w: credential subject %s is not the identity of the peer", ErrInvalidCredential, subject)
+	}
+
+	document, err := a.Resolver.ResolveDID(ctx, claims.Iss)
+	if err != nil {
+		return cert, fmt.Errorf("%w: failed to resolve issuer %s, %w", ErrInvalidCredential, claims.Iss, err)
+	}
+
+	key, err := document.verificationKey(header.Kid)
+	if err != nil {
+		return cert, fmt.Errorf("%w: %w", ErrInvalidCredential, err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return cert, fmt.Errorf("%w: invalid signature encoding", ErrInvalidCredential)
+	}
+
+	if err := verifyJWS(header.Alg, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return cert, fmt.Errorf("%w: %w", ErrInvalidCredential, err)
+	}
+
+	if a.Certifier != "" {
+		certifier = a.Certifier
+	}
+
+	return credentialCertificate(token, claims, identityKey, certifier), nil
+}
+
+// credentialCertificate maps the verified credential into a certificate, the serial number is the base64 SHA-256 hash
+// of the credential, so credentials of different issuers never conflict
+func credentialCertificate(token string, claims credentialClaims, identityKey, certifier string) certificates.VerifiableCertificate {
+	values := make(map[string]string, len(claims.VC.CredentialSubject)+2)
+	for name, value := range claims.VC.CredentialSubject {
+		if name == "id" {
+			continue
+		}
+		if s, ok := value.(string); ok {
+			values[name] = s
+			continue
+		}
+		encoded, _ := json.Marshal(value)
+		values[name] = string(encoded)
+	}
+	values[CredentialFieldIssuer] = claims.Iss
+	values[CredentialFieldTypes] = strings.Join(claims.VC.Type, ",")
+
+	fields := make(map[string]any, len(values)+1)
+	decrypted := make(map[string]string, len(values))
+	for name, value := range values {
+		fields[name] = value
+		decrypted[name] = value
+	}
+	fields[VerifiableCredentialField] = token
+
+	hash := sha256.Sum256([]byte(token))
+	signature := token[strings.LastIndex(token, ".")+1:]
+
+	return certificates.VerifiableCertificate{
+		Certificate: certificates.Certificate{
+			Type:         VerifiableCredentialType,
+			SerialNumber: base64.StdEncoding.EncodeToString(hash[:]),
+			Subject:      identityKey,
+			Certifier:    certifier,
+			Fields:       fields,
+			Signature:    signature,
+		},
+		Keyring:         map[string]string{},
+		DecryptedFields: &decrypted,
+	}
+}
+
+// verificationKey returns the JWK of the verification method with the key ID, or of the only method when kid is empty
+func (d *DIDDocument) verificationKey(kid string) (*JWK, error) {
+	if kid == "" {
+		if len(d.VerificationMethod) != 1 || d.VerificationMethod[0].PublicKeyJwk == nil {
+			return nil, fmt.Errorf("credential without key ID and issuer without single JWK verification method")
+		}
+		return d.VerificationMethod[0].PublicKeyJwk, nil
+	}
+
+	for _, method := range d.VerificationMethod {
+		if method.ID == kid || d.ID+method.ID == kid || method.ID == d.ID+kid {
+			if method.PublicKeyJwk == nil {
+				return nil, fmt.Errorf("verification method %s has no JWK", kid)
+			}
+			return method.PublicKeyJwk, nil
+		}
+	}
+
+	return nil, fmt.Errorf("verification method %s not found", kid)
+}
+
+// verifyJWS verifies the JWS signature of the signing input with the key of the algorithm
+func verifyJWS(alg string, key *JWK, signingInput, signature []byte) error {
+	switch alg {
+	case "EdDSA":
+		if key.Kty != "OKP" || key.Crv != "Ed25519" {
+			return fmt.Errorf("key type %s %s does not match algorithm %s", key.Kty, key.Crv, alg)
+		}
+		public, err := base64.RawURLEncoding.DecodeString(key.X)
+		if err != nil || len(public) != ed25519.PublicKeySize {
+			return fmt.Errorf("invalid Ed25519 key")
+		}
+		if !ed25519.Verify(public, signingInput, signature) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+
+	case "ES256", "ES256K":
+		public, err := ecdsaKey(alg, key)
+		if err != nil {
+			return err
+		}
+		if len(signature) != 64 {
+			return fmt.Errorf("invalid signature length")
+		}
+		hash := sha256.Sum256(signingInput)
+		r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+		if !ecdsa.Verify(public, hash[:], r, s) {
+			return fmt.Errorf("invalid signature")
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unsupported algorithm %q", alg)
+	}
+}
+
+// ecdsaKey decodes the EC JWK of the P-256 (ES256) or secp256k1 (ES256K) curve
+func ecdsaKey(alg string, key *JWK) (*ecdsa.PublicKey, error) {
+	crv := map[string]string{"ES256": "P-256", "ES256K": "secp256k1"}[alg]
+	if key.Kty != "EC" || key.Crv != crv {
+		return nil, fmt.Errorf("key type %s %s does not match algorithm %s", key.Kty, key.Crv, alg)
+	}
+
+	x, errX := base64.RawURLEncoding.DecodeString(key.X)
+	y, errY := base64.RawURLEncoding.DecodeString(key.Y)
+	if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+		return nil, fmt.Errorf("invalid %s key", crv)
+	}
+
+	if alg == "ES256K" {
+		public, err := ec.ParsePubKey(append(append([]byte{0x04}, x...), y...))
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s key, %w", crv, err)
+		}
+		return public.ToECDSA(), nil
+	}
+
+	public := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if !public.Curve.IsOnCurve(public.X, public.Y) {
+		return nil, fmt.Errorf("invalid %s key", crv)
+	}
+	return public, nil
+}
+
+func decodeJWTPart(part string, v any) error {
+	decoded, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(decoded, v)
+}
@@ -0,0 +1,27 @@
+//go:build nocredentials
+
+package transport
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+)
+
+var errCredentialsExcluded = fmt.Errorf("%w: %w: verifiable credentials, see the nocredentials build tag", ErrInvalidCredential, ErrExcludedFromBuild)
+
+// AdaptAll rejects certificates carrying verifiable credentials in builds with the nocredentials build tag,
+// other certificates are returned unchanged
+func (a *CredentialAdapter) AdaptAll(_ context.Context, certs []certificates.VerifiableCertificate, _, _ string) ([]certificates.VerifiableCertificate, error) {
+	if slices.ContainsFunc(certs, func(cert certificates.VerifiableCertificate) bool { return cert.Type == VerifiableCredentialType }) {
+		return nil, errCredentialsExcluded
+	}
+	return certs, nil
+}
+
+// Adapt rejects every credential in builds with the nocredentials build tag
+func (a *CredentialAdapter) Adapt(_ context.Context, cert certificates.VerifiableCertificate, _, _ string) (certificates.VerifiableCertificate, error) {
+	return cert, errCredentialsExcluded
+}
@@ -0,0 +1,29 @@
+//go:build nocredentials
+
+package transport_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCredentialAdapter_ExcludedFromBuild(t *testing.T) {
+	// given
+	adapter := transport.CredentialAdapter{}
+	certs := []certificates.VerifiableCertificate{{Certificate: certificates.Certificate{Type: certificateType}}}
+	credential := certificates.VerifiableCertificate{Certificate: certificates.Certificate{Type: transport.VerifiableCredentialType}}
+
+	// when
+	adapted, err := adapter.AdaptAll(context.Background(), certs, identityKey, certifier)
+	_, credentialErr := adapter.AdaptAll(context.Background(), append(certs, credential), identityKey, certifier)
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, certs, adapted)
+	require.ErrorIs(t, credentialErr, transport.ErrExcludedFromBuild)
+	require.ErrorIs(t, credentialErr, transport.ErrInvalidCredential)
+}
@@ -0,0 +1,294 @@
+//go:build !nocredentials
+
+package transport_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+const issuerDID = "did:example:university"
+
+func TestCredentialAdapter_Adapt(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	claims := map[string]any{
+		"iss": issuerDID,
+		"sub": transport.X509IdentityURIPrefix + identityKey,
+		"exp": now.Add(time.Hour).Unix(),
+		"vc": map[string]any{
+			"type":              []string{"VerifiableCredential", "DegreeCredential"},
+			"credentialSubject": map[string]any{"degree": "MSc", "graduated": 2020},
+		},
+	}
+
+	signers := map[string]func(t *testing.T) (transport.JWK, func(input []byte) []byte){
+		"ES256": func(t *testing.T) (transport.JWK, func(input []byte) []byte) {
+			key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+			require.NoError(t, err)
+			jwk := transport.JWK{Kty: "EC", Crv: "P-256", X: b64(key.X.FillBytes(make([]byte, 32))), Y: b64(key.Y.FillBytes(make([]byte, 32)))}
+			return jwk, func(input []byte) []byte {
+				hash := sha256.Sum256(input)
+				r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+				require.NoError(t, err)
+				return append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+			}
+		},
+		"ES256K": func(t *testing.T) (transport.JWK, func(input []byte) []byte) {
+			key, err := ec.NewPrivateKey()
+			require.NoError(t, err)
+			uncompressed := key.PubKey().Uncompressed()
+			jwk := transport.JWK{Kty: "EC", Crv: "secp256k1", X: b64(uncompressed[1:33]), Y: b64(uncompressed[33:])}
+			return jwk, func(input []byte) []byte {
+				hash := sha256.Sum256(input)
+				signature, err := key.Sign(hash[:])
+				require.NoError(t, err)
+				return append(signature.R.FillBytes(make([]byte, 32)), signature.S.FillBytes(make([]byte, 32))...)
+			}
+		},
+		"EdDSA": func(t *testing.T) (transport.JWK, func(input []byte) []byte) {
+			public, private, err := ed25519.GenerateKey(rand.Reader)
+			require.NoError(t, err)
+			return transport.JWK{Kty: "OKP", Crv: "Ed25519", X: b64(public)}, func(input []byte) []byte {
+				return ed25519.Sign(private, input)
+			}
+		},
+	}
+
+	// setup returns an adapter trusting the issuer with the key of the algorithm and a certificate carrying the signed claims
+	setup := func(t *testing.T, alg string, claims map[string]any) (*transport.CredentialAdapter, certificates.VerifiableCertificate) {
+		jwk, sign := signers[alg](t)
+		resolver := transport.DIDResolverFunc(func(_ context.Context, did string) (*transport.DIDDocument, error) {
+			if did != issuerDID {
+				return nil, errors.New("unknown DID")
+			}
+			return &transport.DIDDocument{
+				ID:                 issuerDID,
+				VerificationMethod: []transport.VerificationMethod{{ID: issuerDID + "#key-1", PublicKeyJwk: &jwk}},
+			}, nil
+		})
+
+		header, err := json.Marshal(map[string]string{"alg": alg, "kid": "#key-1", "typ": "JWT"})
+		require.NoError(t, err)
+		payload, err := json.Marshal(claims)
+		require.NoError(t, err)
+		input := b64(header) + "." + b64(payload)
+		token := input + "." + b64(sign([]byte(input)))
+
+		adapter := &transport.CredentialAdapter{
+			Resolver:              resolver,
+			TrustedIssuers:        []string{issuerDID},
+			RequireSubjectBinding: true,
+			Clock:                 func() time.Time { return now },
+		}
+		cert := certificates.VerifiableCertificate{Certificate: certificates.Certificate{
+			Type:         transport.VerifiableCredentialType,
+			SerialNumber: "chosen-by-peer",
+			Fields:       map[string]any{transport.VerifiableCredentialField: token},
+		}}
+		return adapter, cert
+	}
+
+	for alg := range signers {
+		t.Run("credential signed with "+alg+" is mapped into a certificate", func(t *testing.T) {
+			// given
+			adapter, cert := setup(t, alg, claims)
+
+			// when
+			adapted, err := adapter.Adapt(context.Background(), cert, identityKey, certifier)
+
+			// then
+			require.NoError(t, err)
+			require.Equal(t, transport.VerifiableCredentialType, adapted.Type)
+			require.Equal(t, identityKey, adapted.Subject)
+			require.Equal(t, certifier, adapted.Certifier)
+			require.NotEqual(t, "chosen-by-peer", adapted.SerialNumber)
+			require.Equal(t, "MSc", adapted.Fields["degree"])
+			require.Equal(t, "2020", (*adapted.DecryptedFields)["graduated"])
+			require.Equal(t, issuerDID, adapted.Fields[transport.CredentialFieldIssuer])
+			require.Equal(t, "VerifiableCredential,DegreeCredential", adapted.Fields[transport.CredentialFieldTypes])
+		})
+	}
+
+	tests := map[string]struct {
+		modify func(adapter *transport.CredentialAdapter, cert *certificates.VerifiableCertificate)
+		claims func(claims map[string]any) map[string]any
+	}{
+		"untrusted issuer": {
+			modify: func(adapter *transport.CredentialAdapter, _ *certificates.VerifiableCertificate) {
+				adapter.TrustedIssuers = []string{"did:example:other"}
+			},
+		},
+		"expired credential": {
+			modify: func(adapter *transport.CredentialAdapter, _ *certificates.VerifiableCertificate) {
+				adapter.Clock = func() time.Time { return now.Add(2 * time.Hour) }
+			},
+		},
+		"credential of another identity": {
+			claims: func(claims map[string]any) map[string]any {
+				claims["sub"] = transport.X509IdentityURIPrefix + certifier
+				return claims
+			},
+		},
+		"tampered signature": {
+			modify: func(_ *transport.CredentialAdapter, cert *certificates.VerifiableCertificate) {
+				token := cert.Fields[transport.VerifiableCredentialField].(string)
+				cert.Fields[transport.VerifiableCredentialField] = token[:len(token)-4] + "AAAA"
+			},
+		},
+		"issuer which cannot be resolved": {
+			modify: func(adapter *transport.CredentialAdapter, _ *certificates.VerifiableCertificate) {
+				adapter.Resolver = transport.DIDResolverFunc(func(context.Context, string) (*transport.DIDDocument, error) {
+					return nil, errors.New("resolver unavailable")
+				})
+			},
+		},
+		"certificate without credential": {
+			modify: func(_ *transport.CredentialAdapter, cert *certificates.VerifiableCertificate) {
+				cert.Fields = map[string]any{"degree": "MSc"}
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name+" is rejected", func(t *testing.T) {
+			// given
+			credentialClaims := map[string]any{}
+			for k, v := range claims {
+				credentialClaims[k] = v
+			}
+			if test.claims != nil {
+				credentialClaims = test.claims(credentialClaims)
+			}
+			adapter, cert := setup(t, "ES256", credentialClaims)
+			if test.modify != nil {
+				test.modify(adapter, &cert)
+			}
+
+			// when
+			_, err := adapter.Adapt(context.Background(), cert, identityKey, certifier)
+
+			// then
+			require.ErrorIs(t, err, transport.ErrInvalidCredential)
+			require.Equal(t, transport.ErrCodeInvalidCredential, transport.ErrorCode(err))
+		})
+	}
+}
+
+func TestCredentialAdapter_AdaptAll(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	jwk := transport.JWK{Kty: "EC", Crv: "P-256", X: b64(key.X.FillBytes(make([]byte, 32))), Y: b64(key.Y.FillBytes(make([]byte, 32)))}
+
+	credential := func(t *testing.T, serialNumber string) certificates.VerifiableCertificate {
+		header, err := json.Marshal(map[string]string{"alg": "ES256", "kid": "#key-1", "typ": "JWT"})
+		require.NoError(t, err)
+		payload, err := json.Marshal(map[string]any{
+			"iss": issuerDID,
+			"exp": now.Add(time.Hour).Unix(),
+			"vc":  map[string]any{"type": []string{"VerifiableCredential"}, "credentialSubject": map[string]any{"serial": serialNumber}},
+		})
+		require.NoError(t, err)
+		input := b64(header) + "." + b64(payload)
+		hash := sha256.Sum256([]byte(input))
+		r, s, err := ecdsa.Sign(rand.Reader, key, hash[:])
+		require.NoError(t, err)
+		token := input + "." + b64(append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...))
+
+		return certificates.VerifiableCertificate{Certificate: certificates.Certificate{
+			Type:         transport.VerifiableCredentialType,
+			SerialNumber: serialNumber,
+			Fields:       map[string]any{transport.VerifiableCredentialField: token},
+		}}
+	}
+
+	// newAdapter returns an adapter whose resolver records the highest number of concurrent resolutions
+	newAdapter := func(concurrency int, maxActive *atomic.Int32) *transport.CredentialAdapter {
+		var active atomic.Int32
+		return &transport.CredentialAdapter{
+			Resolver: transport.DIDResolverFunc(func(context.Context, string) (*transport.DIDDocument, error) {
+				current := active.Add(1)
+				defer active.Add(-1)
+				for {
+					highest := maxActive.Load()
+					if current <= highest || maxActive.CompareAndSwap(highest, current) {
+						break
+					}
+				}
+				time.Sleep(20 * time.Millisecond)
+				return &transport.DIDDocument{
+					ID:                 issuerDID,
+					VerificationMethod: []transport.VerificationMethod{{ID: issuerDID + "#key-1", PublicKeyJwk: &jwk}},
+				}, nil
+			}),
+			TrustedIssuers: []string{issuerDID},
+			Clock:          func() time.Time { return now },
+			Concurrency:    concurrency,
+		}
+	}
+
+	t.Run("credentials are verified concurrently up to the limit", func(t *testing.T) {
+		// given
+		var maxActive atomic.Int32
+		adapter := newAdapter(2, &maxActive)
+		plain := certificates.VerifiableCertificate{Certificate: certificates.Certificate{Type: "YWdl", SerialNumber: "plain"}}
+		certs := []certificates.VerifiableCertificate{credential(t, "1"), plain, credential(t, "2"), credential(t, "3"), credential(t, "4")}
+
+		// when
+		adapted, err := adapter.AdaptAll(context.Background(), certs, identityKey, certifier)
+
+		// then
+		require.NoError(t, err)
+		require.Len(t, adapted, len(certs))
+		require.Equal(t, plain, adapted[1])
+		for i, serialNumber := range map[int]string{0: "1", 2: "2", 3: "3", 4: "4"} {
+			require.Equal(t, serialNumber, adapted[i].Fields["serial"])
+			require.NotNil(t, adapted[i].DecryptedFields)
+		}
+		require.EqualValues(t, 2, maxActive.Load())
+	})
+
+	t.Run("every failing credential is listed", func(t *testing.T) {
+		// given
+		var maxActive atomic.Int32
+		adapter := newAdapter(0, &maxActive)
+		tampered := func(cert certificates.VerifiableCertificate) certificates.VerifiableCertificate {
+			token := cert.Fields[transport.VerifiableCredentialField].(string)
+			cert.Fields[transport.VerifiableCredentialField] = token[:len(token)-4] + "AAAA"
+			return cert
+		}
+		certs := []certificates.VerifiableCertificate{credential(t, "1"), tampered(credential(t, "2")), credential(t, "3"), tampered(credential(t, "4"))}
+
+		// when
+		_, err := adapter.AdaptAll(context.Background(), certs, identityKey, certifier)
+
+		// then
+		var certErrs transport.CertificateErrors
+		require.ErrorAs(t, err, &certErrs)
+		require.Len(t, certErrs, 2)
+		require.Equal(t, 1, certErrs[0].Index)
+		require.Equal(t, "2", certErrs[0].SerialNumber)
+		require.Equal(t, 3, certErrs[1].Index)
+		require.Equal(t, transport.ErrCodeInvalidCredential, certErrs[1].Code)
+		require.ErrorIs(t, err, transport.ErrInvalidCredential)
+	})
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -0,0 +1,163 @@
+package transport
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// DelegationExtension is the extension of the initialRequest carrying the Delegation of a peer authenticating
+// with an agent key
+const DelegationExtension = "delegation"
+
+// DelegationProtocol is the protocol principals sign delegations with, the key ID is the identity key of the agent
+var DelegationProtocol = wallet.Protocol{SecurityLevel: wallet.SecurityLevelEveryAppAndCounterparty, Protocol: "auth delegation"}
+
+// Delegation is a lightweight certificate authorizing an agent key to authenticate on behalf of a principal identity,
+// e.g. an automation agent holding a key of its own which the principal can revoke without rotating its identity key.
+// The principal signs it for anyone, so every server can verify it without a session with the principal.
+type Delegation struct {
+	// Principal is the identity key (hex) of the delegating identity
+	Principal string `json:"principal"`
+	// Agent is the identity key (hex) the agent authenticates with
+	Agent string `json:"agent"`
+	// Expiry is when the delegation expires, it is signed with a precision of seconds
+	Expiry time.Time `json:"expiry"`
+	// Signature is the DER signature of the principal over the Payload of the delegation
+	Signature []byte `json:"signature"`
+}
+
+// delegationClaims are the signed fields of a Delegation
+type delegationClaims struct {
+	Principal string `json:"principal"`
+	Agent     string `json:"agent"`
+	Expiry    int64  `json:"expiry"`
+}
+
+// Payload returns the data signed by the principal: the canonical JSON of the principal, the agent
+// and the expiry in Unix seconds
+func (d *Delegation) Payload() ([]byte, error) {
+	return authcore.CanonicalJSON(delegationClaims{
+		Principal: strings.ToLower(d.Principal),
+		Agent:     strings.ToLower(d.Agent),
+		Expiry:    d.Expiry.Unix(),
+	})
+}
+
+// SignDelegation creates a delegation of the identity of the wallet to the agent identity key, valid until the expiry
+func SignDelegation(ctx context.Context, w wallet.WalletInterface, agentIdentityKey string, expiry time.Time) (*Delegation, error) {
+	if _, err := ec.PublicKeyFromString(agentIdentityKey); err != nil {
+		return nil, fmt.Errorf("%w, %w", ErrInvalidIdentityKey, err)
+	}
+
+	identity, err := w.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get principal identity key, %w", err)
+	}
+
+	delegation := &Delegation{
+		Principal: identity.PublicKey.ToDERHex(),
+		Agent:     strings.ToLower(agentIdentityKey),
+		Expiry:    expiry.Truncate(time.Second),
+	}
+	payload, err := delegation.Payload()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("failed to sign delegation, %w", err)
+	}
+	result, err := w.CreateSignature(&wallet.CreateSignatureArgs{
+		EncryptionArgs: wallet.EncryptionArgs{
+			ProtocolID:   DelegationProtocol,
+			KeyID:        delegation.Agent,
+			Counterparty: wallet.Counterparty{Type: wallet.CounterpartyTypeAnyone},
+		},
+		Data: payload,
+	}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign delegation, %w", err)
+	}
+
+	delegation.Signature = result.Signature.Serialize()
+	return delegation, nil
+}
+
+// Verify checks the delegation authorizes the agent identity key at the time and is signed by its principal,
+// it fails with ErrInvalidDelegation
+func (d *Delegation) Verify(agentIdentityKey string, now time.Time) error {
+	if !strings.EqualFold(d.Agent, agentIdentityKey) {
+		return fmt.Errorf("%w: delegation is not issued to %s", ErrInvalidDelegation, agentIdentityKey)
+	}
+	if !now.Before(d.Expiry) {
+		return fmt.Errorf("%w: delegation expired at %s", ErrInvalidDelegation, d.Expiry.UTC().Format(time.RFC3339))
+	}
+
+	principal, err := ec.PublicKeyFromString(d.Principal)
+	if err != nil {
+		return fmt.Errorf("%w: invalid principal identity key, %w", ErrInvalidDelegation, err)
+	}
+	signature, err := ec.ParseSignature(d.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: failed to parse signature, %w", ErrInvalidDelegation, err)
+	}
+	payload, err := d.Payload()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidDelegation, err)
+	}
+
+	// the principal signed for anyone, so the signing key is derived from the anyone key with the principal as counterparty
+	key, err := wallet.NewKeyDeriver(nil).DerivePublicKey(DelegationProtocol, strings.ToLower(d.Agent),
+		wallet.Counterparty{Type: wallet.CounterpartyTypeOther, Counterparty: principal}, false)
+	if err != nil {
+		return fmt.Errorf("%w: failed to derive signing key, %w", ErrInvalidDelegation, err)
+	}
+
+	hash := sha256.Sum256(payload)
+	if !signature.Verify(hash[:], key) {
+		return fmt.Errorf("%w: signature of the principal is invalid", ErrInvalidDelegation)
+	}
+	return nil
+}
+
+// DelegationOf returns the delegation carried in the DelegationExtension of the message, without verifying it,
+// nil when the message carries none
+func DelegationOf(msg *AuthMessage) (*Delegation, error) {
+	var delegation Delegation
+	ok, err := msg.Extension(DelegationExtension, &delegation)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDelegation, err)
+	}
+	if !ok {
+		return nil, nil
+	}
+	return &delegation, nil
+}
+
+// DelegationPolicy accepts peers authenticating with an agent key on behalf of a principal identity, which present
+// a Delegation in the DelegationExtension of their initialRequest. The session is established for the agent key,
+// its requests carry the identity key of the principal along, see Principal.
+type DelegationPolicy struct {
+	// IsRevoked reports whether the delegation was revoked, e.g. by looking up the agent key in a revocation list.
+	// It is consulted in the handshake and for every request of the session, so revoking a delegation rejects the
+	// requests of its agent right away. Delegations only end with their expiry when nil.
+	IsRevoked func(ctx context.Context, delegation Delegation) bool
+}
+
+// Check checks a verified delegation has neither expired nor been revoked at the time
+func (p *DelegationPolicy) Check(ctx context.Context, delegation Delegation, now time.Time) error {
+	if !now.Before(delegation.Expiry) {
+		return fmt.Errorf("%w: delegation expired at %s", ErrInvalidDelegation, delegation.Expiry.UTC().Format(time.RFC3339))
+	}
+	if p.IsRevoked != nil && p.IsRevoked(ctx, delegation) {
+		return fmt.Errorf("%w: delegation was revoked", ErrInvalidDelegation)
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package transport_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelegation_Verify(t *testing.T) {
+	principalKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	agentKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	otherKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	agentIdentityKey := agentKey.PubKey().ToDERHex()
+	now := time.Now()
+
+	tests := map[string]struct {
+		modify      func(d *transport.Delegation)
+		agent       string
+		now         time.Time
+		expectedErr error
+	}{
+		"valid delegation": {
+			agent: agentIdentityKey,
+			now:   now,
+		},
+		"delegation of another agent": {
+			agent:       otherKey.PubKey().ToDERHex(),
+			now:         now,
+			expectedErr: transport.ErrInvalidDelegation,
+		},
+		"expired delegation": {
+			agent:       agentIdentityKey,
+			now:         now.Add(2 * time.Hour),
+			expectedErr: transport.ErrInvalidDelegation,
+		},
+		"extended expiry": {
+			modify:      func(d *transport.Delegation) { d.Expiry = d.Expiry.Add(24 * time.Hour) },
+			agent:       agentIdentityKey,
+			now:         now,
+			expectedErr: transport.ErrInvalidDelegation,
+		},
+		"delegation claimed for another principal": {
+			modify:      func(d *transport.Delegation) { d.Principal = otherKey.PubKey().ToDERHex() },
+			agent:       agentIdentityKey,
+			now:         now,
+			expectedErr: transport.ErrInvalidDelegation,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			delegation, err := transport.SignDelegation(context.Background(), wallettest.NewMockWallet(principalKey), agentIdentityKey, now.Add(time.Hour))
+			require.NoError(t, err)
+			if test.modify != nil {
+				test.modify(delegation)
+			}
+
+			// when
+			err = delegation.Verify(test.agent, test.now)
+
+			// then
+			if test.expectedErr != nil {
+				require.ErrorIs(t, err, test.expectedErr)
+				require.Equal(t, transport.ErrCodeInvalidDelegation, transport.ErrorCode(err))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, principalKey.PubKey().ToDERHex(), delegation.Principal)
+		})
+	}
+}
+
+func TestDelegationOf(t *testing.T) {
+	t.Run("delegation survives encoding of the message", func(t *testing.T) {
+		// given
+		principalKey, err := ec.NewPrivateKey()
+		require.NoError(t, err)
+		agentKey, err := ec.NewPrivateKey()
+		require.NoError(t, err)
+		delegation, err := transport.SignDelegation(context.Background(), wallettest.NewMockWallet(principalKey), agentKey.PubKey().ToDERHex(), time.Now().Add(time.Hour))
+		require.NoError(t, err)
+
+		msg := &transport.AuthMessage{Version: transport.AuthVersion, MessageType: transport.InitialRequest, IdentityKey: agentKey.PubKey().ToDERHex()}
+		require.NoError(t, msg.SetExtension(transport.DelegationExtension, delegation))
+		data, err := json.Marshal(msg)
+		require.NoError(t, err)
+
+		var decoded transport.AuthMessage
+		require.NoError(t, json.Unmarshal(data, &decoded))
+
+		// when
+		received, err := transport.DelegationOf(&decoded)
+
+		// then
+		require.NoError(t, err)
+		require.NoError(t, received.Verify(decoded.IdentityKey, time.Now()))
+	})
+
+	t.Run("message without delegation", func(t *testing.T) {
+		// when
+		received, err := transport.DelegationOf(&transport.AuthMessage{})
+
+		// then
+		require.NoError(t, err)
+		require.Nil(t, received)
+	})
+}
@@ -0,0 +1,93 @@
+package transport
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"slices"
+	"strings"
+
+	"lukechampine.com/blake3"
+)
+
+// DigestAlgorithm is a hash algorithm of Content-Digest headers (RFC 9530), named as in the header value.
+// The name is part of the signed header, so a digest cannot be passed off as one of another algorithm.
+type DigestAlgorithm string
+
+// Digest algorithms of Content-Digest headers
+const (
+	// DigestSHA256 is the default algorithm, used for sessions which did not negotiate another one
+	DigestSHA256 DigestAlgorithm = "sha-256"
+	// DigestBLAKE3 is used for sessions which negotiated CapabilityDigestBLAKE3, it hashes large files faster
+	DigestBLAKE3 DigestAlgorithm = "blake3"
+)
+
+// Errors of content digests
+var (
+	ErrUnsupportedDigest     = errors.New("unsupported digest algorithm")
+	ErrContentDigestMismatch = errors.New("content digest mismatch")
+)
+
+// NewHash returns a new hash of the algorithm
+func (a DigestAlgorithm) NewHash() (hash.Hash, error) {
+	switch a {
+	case DigestSHA256:
+		return sha256.New(), nil
+	case DigestBLAKE3:
+		return blake3.New(32, nil), nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnsupportedDigest, a)
+	}
+}
+
+// ContentDigest returns the Content-Digest header value of the content, e.g. "sha-256=:<base64>:"
+func (a DigestAlgorithm) ContentDigest(content io.Reader) (string, error) {
+	h, err := a.NewHash()
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(h, content); err != nil {
+		return "", err
+	}
+	return string(a) + "=:" + base64.StdEncoding.EncodeToString(h.Sum(nil)) + ":", nil
+}
+
+// NegotiatedDigestAlgorithm returns the digest algorithm of a session with the negotiated capabilities
+func NegotiatedDigestAlgorithm(capabilities []Capability) DigestAlgorithm {
+	if slices.Contains(capabilities, CapabilityDigestBLAKE3) {
+		return DigestBLAKE3
+	}
+	return DigestSHA256
+}
+
+// VerifyContentDigest checks the Content-Digest header value is the digest of the content with the expected algorithm,
+// the algorithm negotiated for the session. A digest of any other algorithm is rejected, so a peer
+// cannot be downgraded to a weaker algorithm than it negotiated.
+func VerifyContentDigest(value string, content []byte, expected DigestAlgorithm) error {
+	name, encoded, ok := strings.Cut(value, "=")
+	if !ok || !strings.HasPrefix(encoded, ":") || !strings.HasSuffix(encoded, ":") || len(encoded) < 2 {
+		return fmt.Errorf("%w: malformed content digest %q", ErrContentDigestMismatch, value)
+	}
+	if DigestAlgorithm(name) != expected {
+		return fmt.Errorf("%w: content digest of algorithm %s, expected %s", ErrContentDigestMismatch, name, expected)
+	}
+
+	digest, err := base64.StdEncoding.DecodeString(encoded[1 : len(encoded)-1])
+	if err != nil {
+		return fmt.Errorf("%w: malformed content digest %q", ErrContentDigestMismatch, value)
+	}
+
+	h, err := expected.NewHash()
+	if err != nil {
+		return err
+	}
+	h.Write(content)
+	if !bytes.Equal(h.Sum(nil), digest) {
+		return fmt.Errorf("%w: content does not match the %s digest", ErrContentDigestMismatch, expected)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package transport_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentDigest(t *testing.T) {
+	content := []byte("content of the file")
+
+	t.Run("SHA-256 digest matches RFC 9530", func(t *testing.T) {
+		// when
+		digest, err := transport.DigestSHA256.ContentDigest(bytes.NewReader(content))
+
+		// then
+		require.NoError(t, err)
+		sum := sha256.Sum256(content)
+		require.Equal(t, "sha-256=:"+base64.StdEncoding.EncodeToString(sum[:])+":", digest)
+	})
+
+	t.Run("BLAKE3 digest of the empty content", func(t *testing.T) {
+		// when
+		digest, err := transport.DigestBLAKE3.ContentDigest(bytes.NewReader(nil))
+
+		// then
+		require.NoError(t, err)
+		// test vector of the BLAKE3 reference implementation
+		require.Equal(t, "blake3=:rxNJufX5oaagQE3qNtzJSZvLJcmtwRK3zJqTyuQfMmI=:", digest)
+	})
+
+	t.Run("unknown algorithm is not supported", func(t *testing.T) {
+		// when
+		_, err := transport.DigestAlgorithm("md5").ContentDigest(bytes.NewReader(content))
+
+		// then
+		require.ErrorIs(t, err, transport.ErrUnsupportedDigest)
+	})
+}
+
+func TestVerifyContentDigest(t *testing.T) {
+	content := []byte("content of the file")
+	sha256Digest, err := transport.DigestSHA256.ContentDigest(bytes.NewReader(content))
+	require.NoError(t, err)
+	blake3Digest, err := transport.DigestBLAKE3.ContentDigest(bytes.NewReader(content))
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		digest   string
+		content  []byte
+		expected transport.DigestAlgorithm
+		valid    bool
+	}{
+		"SHA-256 digest": {
+			digest: sha256Digest, content: content, expected: transport.DigestSHA256, valid: true,
+		},
+		"BLAKE3 digest": {
+			digest: blake3Digest, content: content, expected: transport.DigestBLAKE3, valid: true,
+		},
+		"digest of other content": {
+			digest: blake3Digest, content: []byte("other content"), expected: transport.DigestBLAKE3,
+		},
+		"downgrade to SHA-256": {
+			digest: sha256Digest, content: content, expected: transport.DigestBLAKE3,
+		},
+		"BLAKE3 digest labeled as SHA-256": {
+			digest: "sha-256" + blake3Digest[len("blake3"):], content: content, expected: transport.DigestSHA256,
+		},
+		"malformed digest": {
+			digest: "sha-256=abc", content: content, expected: transport.DigestSHA256,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// when
+			err := transport.VerifyContentDigest(test.digest, test.content, test.expected)
+
+			// then
+			if test.valid {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, transport.ErrContentDigestMismatch)
+		})
+	}
+}
+
+func TestNegotiatedDigestAlgorithm(t *testing.T) {
+	require.Equal(t, transport.DigestSHA256, transport.NegotiatedDigestAlgorithm(nil))
+	require.Equal(t, transport.DigestBLAKE3, transport.NegotiatedDigestAlgorithm([]transport.Capability{
+		transport.CapabilityHeartbeat, transport.CapabilityDigestBLAKE3,
+	}))
+}
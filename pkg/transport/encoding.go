@@ -0,0 +1,259 @@
+package transport
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxPooledBufferSize is the capacity above which encoding buffers are dropped instead of returned to the pool,
+// so a single large message does not pin its buffer for the lifetime of the process
+const maxPooledBufferSize = 64 << 10
+
+// messageBuffers pools the buffers messages are encoded into by WriteAuthMessage
+var messageBuffers = sync.Pool{New: func() any {
+	buf := make([]byte, 0, 1<<10)
+	return &buf
+}}
+
+// valueEncoder is a pooled encoder of the values AppendJSON leaves to encoding/json, e.g. certificates
+type valueEncoder struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+var valueEncoders = sync.Pool{New: func() any {
+	e := &valueEncoder{}
+	e.enc = json.NewEncoder(&e.buf)
+	return e
+}}
+
+// WriteAuthMessage encodes the message into a pooled buffer and writes it to w in a single write,
+// the bytes written are those of json.Marshal
+func WriteAuthMessage(w io.Writer, m *AuthMessage) error {
+	buf, _ := messageBuffers.Get().(*[]byte)
+	defer func() {
+		if cap(*buf) <= maxPooledBufferSize {
+			*buf = (*buf)[:0]
+			messageBuffers.Put(buf)
+		}
+	}()
+
+	data, err := m.AppendJSON((*buf)[:0])
+	*buf = data
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write auth message, %w", err)
+	}
+	return nil
+}
+
+// AppendJSON appends the JSON encoding of the message to dst, the result is byte-for-byte that of json.Marshal.
+// Messages without Extensions are encoded without reflection, which is what the handshake endpoint sends.
+func (m *AuthMessage) AppendJSON(dst []byte) ([]byte, error) {
+	if len(m.Extensions) > 0 {
+		data, err := m.MarshalJSON()
+		if err != nil {
+			return dst, err
+		}
+		return append(dst, data...), nil
+	}
+	return m.appendFields(dst)
+}
+
+// appendFields encodes the known fields of the message in the order and with the omitempty rules of their tags
+func (m *AuthMessage) appendFields(dst []byte) ([]byte, error) {
+	dst = append(dst, `{"version":`...)
+	dst = appendString(dst, m.Version)
+	dst = append(dst, `,"messageType":`...)
+	dst = appendString(dst, string(m.MessageType))
+	dst = append(dst, `,"identityKey":`...)
+	dst = appendString(dst, m.IdentityKey)
+	if m.Nonce != nil {
+		dst = append(dst, `,"nonce":`...)
+		dst = appendString(dst, *m.Nonce)
+	}
+	dst = append(dst, `,"initialNonce":`...)
+	dst = appendString(dst, m.InitialNonce)
+	if m.YourNonce != nil {
+		dst = append(dst, `,"yourNonce":`...)
+		dst = appendString(dst, *m.YourNonce)
+	}
+	if m.Payload != nil {
+		dst = append(dst, `,"payload":`...)
+		dst = appendBytes(dst, *m.Payload)
+	}
+	if m.Signature != nil {
+		dst = append(dst, `,"signature":`...)
+		dst = appendBytes(dst, *m.Signature)
+	}
+
+	dst = append(dst, `,"certificates":`...)
+	if m.Certificates == nil {
+		dst = append(dst, "null"...)
+	} else {
+		var err error
+		if dst, err = appendValue(dst, *m.Certificates); err != nil {
+			return dst, err
+		}
+	}
+
+	dst = append(dst, `,"requestedCertificates":`...)
+	dst = m.RequestedCertificates.appendJSON(dst)
+
+	if m.ConnectionScoped {
+		dst = append(dst, `,"connectionScoped":true`...)
+	}
+	if c := m.CompressedCertificates; c != nil {
+		dst = append(dst, `,"compressedCertificates":{"encoding":`...)
+		dst = appendString(dst, c.Encoding)
+		dst = append(dst, `,"data":`...)
+		dst = appendBytes(dst, c.Data)
+		dst = append(dst, '}')
+	}
+	if m.PayloadEncryption {
+		dst = append(dst, `,"payloadEncryption":true`...)
+	}
+	if m.PayloadPadding {
+		dst = append(dst, `,"payloadPadding":true`...)
+	}
+	if len(m.Capabilities) > 0 {
+		dst = append(dst, `,"capabilities":[`...)
+		for i, capability := range m.Capabilities {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendString(dst, string(capability))
+		}
+		dst = append(dst, ']')
+	}
+	return append(dst, '}'), nil
+}
+
+// appendJSON appends the encoding of RequestedCertificateSet.MarshalJSON
+func (s RequestedCertificateSet) appendJSON(dst []byte) []byte {
+	dst = append(dst, `{"certifiers":`...)
+	dst = appendStrings(dst, s.Certifiers)
+	dst = append(dst, `,"types":{`...)
+	typeIDs := make([]string, 0, len(s.Types))
+	for typeID := range s.Types {
+		typeIDs = append(typeIDs, typeID)
+	}
+	slices.Sort(typeIDs)
+	for i, typeID := range typeIDs {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendString(dst, typeID)
+		dst = append(dst, ':')
+		dst = appendStrings(dst, s.Types[typeID])
+	}
+	return append(dst, "}}"...)
+}
+
+// appendValue appends the encoding/json encoding of the value with a pooled encoder
+func appendValue(dst []byte, v any) ([]byte, error) {
+	e, _ := valueEncoders.Get().(*valueEncoder)
+	defer func() {
+		if e.buf.Cap() <= maxPooledBufferSize {
+			e.buf.Reset()
+			valueEncoders.Put(e)
+		}
+	}()
+
+	if err := e.enc.Encode(v); err != nil {
+		return dst, fmt.Errorf("failed to encode auth message, %w", err)
+	}
+	return append(dst, bytes.TrimSuffix(e.buf.Bytes(), []byte("\n"))...), nil
+}
+
+// appendStrings appends the strings as JSON array, nil is encoded as an empty array
+func appendStrings(dst []byte, values []string) []byte {
+	dst = append(dst, '[')
+	for i, value := range values {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = appendString(dst, value)
+	}
+	return append(dst, ']')
+}
+
+// appendBytes appends the bytes base64 encoded like encoding/json, nil is encoded as null
+func appendBytes(dst, data []byte) []byte {
+	if data == nil {
+		return append(dst, "null"...)
+	}
+	dst = append(dst, '"')
+	dst = base64.StdEncoding.AppendEncode(dst, data)
+	return append(dst, '"')
+}
+
+const hexDigits = "0123456789abcdef"
+
+// invalidUTF8 is what encoding/json replaces invalid UTF-8 with, Go releases differ in escaping U+FFFD
+var invalidUTF8 = func() string {
+	data, _ := json.Marshal("\xff")
+	return string(data[1 : len(data)-1])
+}()
+
+// appendString appends the string quoted and escaped like encoding/json with HTML escaping,
+// invalid UTF-8 is replaced with U+FFFD like encoding/json and U+2028 and U+2029 are escaped
+func appendString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '\\', '"':
+				dst = append(dst, '\\', b)
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, invalidUTF8...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
@@ -0,0 +1,115 @@
+package transport_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/fixtures"
+	"github.com/stretchr/testify/require"
+)
+
+// reflectedAuthMessage has the fields of AuthMessage without its JSON methods, so it is encoded by reflection
+type reflectedAuthMessage transport.AuthMessage
+
+func TestAuthMessage_AppendJSON(t *testing.T) {
+	nonce, yourNonce := "bm9uY2U=", "eW91ciBub25jZQ=="
+	payload, signature, empty := []byte("payload"), []byte{0x30, 0x44, 0xff}, []byte{}
+	certs := []certificates.VerifiableCertificate{{
+		Certificate: certificates.Certificate{
+			Type:         "age",
+			SerialNumber: "serial-1",
+			Fields:       map[string]any{"age": "21", "note": "<b>&</b>"},
+			Signature:    "signature",
+		},
+		Keyring: map[string]string{"age": "key"},
+	}}
+	var nilPayload []byte
+
+	tests := map[string]transport.AuthMessage{
+		"empty message": {},
+		"initial request": {
+			Version: "0.1", MessageType: transport.InitialRequest, IdentityKey: "02abc", InitialNonce: nonce,
+			PayloadEncryption: true, PayloadPadding: true,
+			Capabilities: []transport.Capability{transport.CapabilityTrailerSignature, "certificateCompression:gzip"},
+		},
+		"initial response": {
+			Version: "0.1", MessageType: transport.InitialResponse, IdentityKey: "03def", Nonce: &nonce,
+			InitialNonce: nonce, YourNonce: &yourNonce, Signature: &signature, ConnectionScoped: true,
+			RequestedCertificates: *transport.NewRequestedCertificateSet("03cert", "02cert").
+				AddType("type-b", "name", "age").AddType("type-a"),
+		},
+		"certificate response": {
+			Version: "0.1", MessageType: transport.CertificateResponse, Nonce: &nonce, YourNonce: &yourNonce,
+			Payload: &payload, Certificates: &certs, Signature: &signature,
+		},
+		"compressed certificates": {
+			MessageType:            transport.CertificateResponse,
+			CompressedCertificates: &transport.CompressedCertificates{Encoding: "gzip", Data: []byte{0x1f, 0x8b}},
+		},
+		"empty and nil payloads": {
+			Payload: &empty, Signature: &nilPayload, CompressedCertificates: &transport.CompressedCertificates{},
+		},
+		"strings which are escaped": {
+			Version:      "\"quoted\" \\ <script>&</script>",
+			IdentityKey:  "\b\f\n\r\t\x00\x1f\x7f",
+			InitialNonce: "invalid \xff utf-8, line \u2028 and paragraph \u2029 separators, emoji \U0001F600",
+			Capabilities: []transport.Capability{"é"},
+		},
+		"extensions": {
+			Version:    "0.1",
+			Extensions: map[string]json.RawMessage{"sessionTTL": json.RawMessage(`3600`)},
+		},
+	}
+	for name, msg := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			expected, err := json.Marshal(reflectedAuthMessage(msg))
+			require.NoError(t, err)
+			if len(msg.Extensions) > 0 {
+				expected, err = msg.MarshalJSON()
+				require.NoError(t, err)
+			}
+
+			// when
+			appended, err := msg.AppendJSON([]byte("prefix"))
+			require.NoError(t, err)
+			marshaled, err := json.Marshal(msg)
+			require.NoError(t, err)
+			var written bytes.Buffer
+			require.NoError(t, transport.WriteAuthMessage(&written, &msg))
+
+			// then
+			require.Equal(t, "prefix"+string(expected), string(appended))
+			require.Equal(t, string(expected), string(marshaled))
+			require.Equal(t, string(expected), written.String())
+		})
+	}
+}
+
+func TestAuthMessage_AppendJSON_GoldenHandshake(t *testing.T) {
+	// given
+	golden, err := fixtures.Load()
+	require.NoError(t, err)
+
+	for name, raw := range map[string]json.RawMessage{
+		"initial request":  golden.Handshake.InitialRequest,
+		"initial response": golden.Handshake.InitialResponse,
+	} {
+		t.Run(name, func(t *testing.T) {
+			var expected bytes.Buffer
+			require.NoError(t, json.Compact(&expected, raw))
+			var msg transport.AuthMessage
+			require.NoError(t, json.Unmarshal(raw, &msg))
+
+			// when
+			encoded, err := msg.AppendJSON(nil)
+
+			// then
+			require.NoError(t, err)
+			require.Equal(t, expected.String(), string(encoded))
+		})
+	}
+}
@@ -0,0 +1,460 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
+)
+
+// Errors returned by transports, mapped to error codes in the error responses
+var (
+	ErrMissingRequestID          = errors.New("missing request ID")
+	ErrUnsupportedVersion        = errors.New("unsupported version")
+	ErrSessionNotFound           = errors.New("session not found")
+	ErrIdentityKeyMismatch       = errors.New("identity key does not match session")
+	ErrSessionNotAuthenticated   = errors.New("session not authenticated")
+	ErrCertificatesRequired      = errors.New("no certificates provided")
+	ErrRequestReplayed           = errors.New("request ID already used")
+	ErrCertificateRevoked        = errors.New("certificate revoked")
+	ErrMalformedMessage          = errors.New("malformed auth message")
+	ErrMessageTooLarge           = errors.New("auth message too large")
+	ErrMissingRequiredFields     = errors.New("missing required fields in initial request")
+	ErrInvalidIdentityKey        = errors.New("invalid identity key")
+	ErrInvalidNonceFormat        = authcore.ErrInvalidNonceFormat
+	ErrInvalidNonce              = errors.New("unable to verify nonce")
+	ErrInvalidSignature          = authcore.ErrInvalidSignature
+	ErrUnsupportedMessageType    = errors.New("unsupported message type")
+	ErrMissingHeader             = errors.New("missing auth header")
+	ErrInvalidHeader             = errors.New("invalid auth header")
+	ErrCertificateConflict       = errors.New("certificate serial number already used with different contents")
+	ErrWalletTimeout             = errors.New("wallet operation timed out")
+	ErrRequestCanceled           = errors.New("request canceled")
+	ErrReadTimeout               = errors.New("request was not received in time")
+	ErrOriginNotAccepted         = errors.New("request bound to an origin not accepted by the server")
+	ErrOriginBindingRequired     = errors.New("request is not bound to an origin")
+	ErrInvalidBatch              = errors.New("invalid message batch")
+	ErrClientCertificateNotBound = errors.New("client certificate is not bound to the identity key")
+	ErrInvalidCredential         = errors.New("verifiable credential cannot be verified")
+	ErrStaleHeartbeat            = errors.New("heartbeat was not sent within the accepted clock skew")
+	ErrInvalidPadding            = errors.New("invalid payload padding")
+	ErrCertificateUnderDisclosed = errors.New("certificate does not disclose every requested field")
+	ErrCertificateOverDisclosed  = errors.New("certificate discloses fields which were not requested")
+	ErrExcludedFromBuild         = errors.New("subsystem excluded from the build by a build tag")
+	ErrInvalidAllowlist          = errors.New("invalid federation allowlist")
+	ErrFederationNotAllowed      = errors.New("peer is not a federation partner")
+	ErrExchangeAborted           = errors.New("certificate exchange aborted by the server")
+	ErrUnsupportedCompression    = errors.New("certificates compressed with an unsupported encoding")
+	ErrMessageTooComplex         = errors.New("auth message nested too deeply or has too many fields")
+	ErrInvalidDelegation         = errors.New("invalid delegation of the agent key")
+	ErrRevoked                   = errors.New("identity or session revoked")
+	ErrRevocationUnavailable     = errors.New("revocation list unavailable")
+	ErrWebSocketOriginNotAllowed = errors.New("websocket upgrade from an origin not allowed by the server")
+)
+
+// ErrInvalidRequestedCertificates is returned by RequestedCertificateSet.Validate
+var ErrInvalidRequestedCertificates = errors.New("invalid requested certificates")
+
+// HeaderError describes an auth header which is missing or has an invalid format,
+// it matches ErrMissingHeader or ErrInvalidHeader, and the cause in Err, with errors.Is
+type HeaderError struct {
+	// Header is the name of the header, e.g. "your nonce"
+	Header string
+	// Missing is true when the header was not sent
+	Missing bool
+	// Err is the reason an invalid header was rejected, e.g. ErrInvalidNonceFormat
+	Err error
+}
+
+func (e *HeaderError) Error() string {
+	if e.Missing {
+		return fmt.Sprintf("missing %s header", e.Header)
+	}
+	return fmt.Sprintf("invalid %s header", e.Header)
+}
+
+// Unwrap returns ErrMissingHeader or ErrInvalidHeader and the cause
+func (e *HeaderError) Unwrap() []error {
+	if e.Missing {
+		return []error{ErrMissingHeader}
+	}
+	if e.Err == nil {
+		return []error{ErrInvalidHeader}
+	}
+	return []error{ErrInvalidHeader, e.Err}
+}
+
+// DisclosureError describes a certificate whose keyring does not reveal exactly the requested fields of its type,
+// it matches ErrCertificateUnderDisclosed when fields are missing and ErrCertificateOverDisclosed when fields were not requested
+type DisclosureError struct {
+	// Type is the base64 type ID of the certificate
+	Type string `json:"type"`
+	// SerialNumber is the serial number of the certificate
+	SerialNumber string `json:"serialNumber"`
+	// Missing are the requested fields the keyring does not reveal
+	Missing []string `json:"missing,omitempty"`
+	// Unrequested are the fields the keyring reveals without being requested
+	Unrequested []string `json:"unrequested,omitempty"`
+}
+
+func (e *DisclosureError) Error() string {
+	switch {
+	case len(e.Missing) > 0 && len(e.Unrequested) > 0:
+		return fmt.Sprintf("certificate %s of type %s does not disclose the requested fields %s and discloses the unrequested fields %s",
+			e.SerialNumber, e.Type, strings.Join(e.Missing, ", "), strings.Join(e.Unrequested, ", "))
+	case len(e.Missing) > 0:
+		return fmt.Sprintf("certificate %s of type %s does not disclose the requested fields %s",
+			e.SerialNumber, e.Type, strings.Join(e.Missing, ", "))
+	default:
+		return fmt.Sprintf("certificate %s of type %s discloses the unrequested fields %s",
+			e.SerialNumber, e.Type, strings.Join(e.Unrequested, ", "))
+	}
+}
+
+// Unwrap returns ErrCertificateUnderDisclosed, ErrCertificateOverDisclosed or both
+func (e *DisclosureError) Unwrap() []error {
+	var errs []error
+	if len(e.Missing) > 0 {
+		errs = append(errs, ErrCertificateUnderDisclosed)
+	}
+	if len(e.Unrequested) > 0 {
+		errs = append(errs, ErrCertificateOverDisclosed)
+	}
+	return errs
+}
+
+// CertificateError describes why a certificate of a certificate response failed validation
+type CertificateError struct {
+	// Index is the position of the certificate in the certificate response
+	Index int `json:"index"`
+	// SerialNumber is the serial number of the certificate
+	SerialNumber string `json:"serialNumber"`
+	// Type is the base64 type ID of the certificate
+	Type string `json:"type"`
+	// Code is the error code of the reason, e.g. ErrCodeCertificateConflict
+	Code string `json:"code"`
+	// Reason describes the failure
+	Reason string `json:"reason"`
+	// Err is the cause of the failure
+	Err error `json:"-"`
+}
+
+// NewCertificateError creates the error of the certificate at the index of a certificate response
+func NewCertificateError(index int, serialNumber, certificateType string, err error) *CertificateError {
+	return &CertificateError{
+		Index:        index,
+		SerialNumber: serialNumber,
+		Type:         certificateType,
+		Code:         ErrorCode(err),
+		Reason:       err.Error(),
+		Err:          err,
+	}
+}
+
+func (e *CertificateError) Error() string {
+	return fmt.Sprintf("certificate %d: %s", e.Index, e.Reason)
+}
+
+// Unwrap returns the cause of the failure
+func (e *CertificateError) Unwrap() error {
+	return e.Err
+}
+
+// CertificateErrors aggregates the failures of all certificates of a certificate response, ordered by index,
+// so the peer sees every rejected certificate instead of only the first. A certificate failing several
+// checks has an error for each of them. It matches the causes of all failures with errors.Is and errors.As.
+type CertificateErrors []*CertificateError
+
+func (e CertificateErrors) Error() string {
+	if len(e) == 1 {
+		return e[0].Reason
+	}
+
+	reasons := make([]string, len(e))
+	for i, certErr := range e {
+		reasons[i] = certErr.Error()
+	}
+	return fmt.Sprintf("%d certificate checks failed: %s", len(e), strings.Join(reasons, "; "))
+}
+
+// Unwrap returns the errors of the certificates
+func (e CertificateErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, certErr := range e {
+		errs[i] = certErr
+	}
+	return errs
+}
+
+// WalletTimeoutError describes a wallet operation which exceeded its timeout from WalletTimeouts,
+// it matches ErrWalletTimeout and context.DeadlineExceeded with errors.Is
+type WalletTimeoutError struct {
+	// Operation is the name of the wallet method, e.g. "CreateSignature"
+	Operation string
+	// Timeout is the configured timeout of the operation
+	Timeout time.Duration
+}
+
+func (e *WalletTimeoutError) Error() string {
+	return fmt.Sprintf("wallet %s timed out after %s", e.Operation, e.Timeout)
+}
+
+// Unwrap returns ErrWalletTimeout and context.DeadlineExceeded
+func (e *WalletTimeoutError) Unwrap() []error {
+	return []error{ErrWalletTimeout, context.DeadlineExceeded}
+}
+
+// ReadTimeoutError describes a part of a request which was not received within its timeout from ReadTimeouts,
+// it matches ErrReadTimeout and os.ErrDeadlineExceeded with errors.Is
+type ReadTimeoutError struct {
+	// Stage is the part of the request, ReadStageHeader or ReadStageBody
+	Stage string
+	// Timeout is the configured timeout of the stage
+	Timeout time.Duration
+}
+
+func (e *ReadTimeoutError) Error() string {
+	return fmt.Sprintf("request %s was not received within %s", e.Stage, e.Timeout)
+}
+
+// Unwrap returns ErrReadTimeout and os.ErrDeadlineExceeded
+func (e *ReadTimeoutError) Unwrap() []error {
+	return []error{ErrReadTimeout, os.ErrDeadlineExceeded}
+}
+
+// Error codes sent in the error responses
+const (
+	// ErrCodeUnauthorized is the default code of authentication failures
+	ErrCodeUnauthorized = "ERR_UNAUTHORIZED"
+	// ErrCodeMissingRequestID indicates a request without auth headers
+	ErrCodeMissingRequestID = "ERR_MISSING_REQUEST_ID"
+	// ErrCodeUnsupportedVersion indicates an unsupported auth protocol version
+	ErrCodeUnsupportedVersion = "ERR_UNSUPPORTED_VERSION"
+	// ErrCodeSessionNotFound indicates the session expired or never existed, the peer should repeat the handshake
+	ErrCodeSessionNotFound = "ERR_SESSION_NOT_FOUND"
+	// ErrCodeIdentityKeyMismatch indicates a message whose identity key differs from the peer which established the session
+	ErrCodeIdentityKeyMismatch = "ERR_IDENTITY_KEY_MISMATCH"
+	// ErrCodeSessionNotAuthenticated indicates the handshake was not completed
+	ErrCodeSessionNotAuthenticated = "ERR_SESSION_NOT_AUTHENTICATED"
+	// ErrCodeCertificatesRequired indicates the peer has to send the requested certificates
+	ErrCodeCertificatesRequired = "ERR_CERTIFICATES_REQUIRED"
+	// ErrCodeRequestReplayed indicates a request ID which was already used, retries must be signed with a fresh request ID
+	ErrCodeRequestReplayed = "ERR_REQUEST_REPLAYED"
+	// ErrCodeCertificateRevoked indicates a certificate whose revocation outpoint was spent
+	ErrCodeCertificateRevoked = "ERR_CERTIFICATE_REVOKED"
+	// ErrCodeMalformedMessage indicates a handshake body which is not a valid auth message
+	ErrCodeMalformedMessage = "ERR_MALFORMED_MESSAGE"
+	// ErrCodeMessageTooLarge indicates a handshake body exceeding MaxAuthMessageSize or carrying more than MaxCertificates certificates
+	ErrCodeMessageTooLarge = "ERR_MESSAGE_TOO_LARGE"
+	// ErrCodeMissingRequiredFields indicates an initial request without identity key or initial nonce
+	ErrCodeMissingRequiredFields = "ERR_MISSING_REQUIRED_FIELDS"
+	// ErrCodeInvalidIdentityKey indicates an identity key which is not a valid public key
+	ErrCodeInvalidIdentityKey = "ERR_INVALID_IDENTITY_KEY"
+	// ErrCodeInvalidNonce indicates a nonce which is not valid base64, exceeds MaxNonceSize or was not created by the server
+	ErrCodeInvalidNonce = "ERR_INVALID_NONCE"
+	// ErrCodeInvalidSignature indicates a message whose signature does not match its payload
+	ErrCodeInvalidSignature = "ERR_INVALID_SIGNATURE"
+	// ErrCodeUnsupportedMessageType indicates a message type the server does not handle
+	ErrCodeUnsupportedMessageType = "ERR_UNSUPPORTED_MESSAGE_TYPE"
+	// ErrCodeMissingHeader indicates a general request without one of the required auth headers
+	ErrCodeMissingHeader = "ERR_MISSING_HEADER"
+	// ErrCodeInvalidHeader indicates an auth header with an invalid format
+	ErrCodeInvalidHeader = "ERR_INVALID_HEADER"
+	// ErrCodeCertificateConflict indicates a certificate whose serial number was accepted with different contents
+	ErrCodeCertificateConflict = "ERR_CERTIFICATE_CONFLICT"
+	// ErrCodeOriginNotAccepted indicates a request bound to an origin which is not an origin of the server
+	ErrCodeOriginNotAccepted = "ERR_ORIGIN_NOT_ACCEPTED"
+	// ErrCodeOriginBindingRequired indicates a request which has to be bound to the origin of the server
+	ErrCodeOriginBindingRequired = "ERR_ORIGIN_BINDING_REQUIRED"
+	// ErrCodeClientCertificateNotBound indicates an X.509 client certificate without the URI SAN of the identity key of the peer
+	ErrCodeClientCertificateNotBound = "ERR_CLIENT_CERTIFICATE_NOT_BOUND"
+	// ErrCodeInvalidCredential indicates a verifiable credential whose issuer is not trusted or whose signature, validity period
+	// or subject cannot be verified
+	ErrCodeInvalidCredential = "ERR_INVALID_CREDENTIAL"
+	// ErrCodeStaleHeartbeat indicates a heartbeat whose time differs from the clock of the server by more than MaxHeartbeatSkew
+	ErrCodeStaleHeartbeat = "ERR_STALE_HEARTBEAT"
+	// ErrCodeInvalidPadding indicates a body of a session with payload padding which is not padded
+	ErrCodeInvalidPadding = "ERR_INVALID_PADDING"
+	// ErrCodeInvalidBatch indicates a batch of general messages which cannot be decoded
+	ErrCodeInvalidBatch = "ERR_INVALID_BATCH"
+	// ErrCodeCertificateUnderDisclosed indicates a certificate whose keyring does not reveal every requested field,
+	// the missing fields are listed in the disclosure of the error response
+	ErrCodeCertificateUnderDisclosed = "ERR_CERTIFICATE_UNDER_DISCLOSED"
+	// ErrCodeCertificateOverDisclosed indicates a certificate whose keyring reveals fields which were not requested,
+	// the unrequested fields are listed in the disclosure of the error response
+	ErrCodeCertificateOverDisclosed = "ERR_CERTIFICATE_OVER_DISCLOSED"
+	// ErrCodeInvalidAllowlist indicates an allowlist exchange whose service keys or certificate policy are invalid
+	ErrCodeInvalidAllowlist = "ERR_INVALID_ALLOWLIST"
+	// ErrCodeFederationNotAllowed indicates an allowlist exchange of a peer which is not registered as federation partner
+	ErrCodeFederationNotAllowed = "ERR_FEDERATION_NOT_ALLOWED"
+	// ErrCodeExchangeAborted indicates a certificate exchange the server aborted on shutdown or a change of the certificate
+	// requirements, the signed ExchangeAborted message is sent along in the aborted field of the error response
+	ErrCodeExchangeAborted = "ERR_EXCHANGE_ABORTED"
+	// ErrCodeUnsupportedCompression indicates certificates compressed with an encoding the server did not negotiate
+	ErrCodeUnsupportedCompression = "ERR_UNSUPPORTED_COMPRESSION"
+	// ErrCodeMessageTooComplex indicates a handshake body exceeding MaxMessageDepth or MaxMessageFields
+	ErrCodeMessageTooComplex = "ERR_MESSAGE_TOO_COMPLEX"
+	// ErrCodeInvalidDelegation indicates a delegation of an agent key which is not signed by its principal,
+	// is issued to another key, expired or was revoked
+	ErrCodeInvalidDelegation = "ERR_INVALID_DELEGATION"
+	// ErrCodeRevoked indicates the identity key or the session of the peer was revoked by the operator
+	ErrCodeRevoked = "ERR_REVOKED"
+	// ErrCodeRevocationUnavailable indicates the revocation list could not be consulted, the peer may retry later
+	ErrCodeRevocationUnavailable = "ERR_REVOCATION_UNAVAILABLE"
+	// ErrCodeWebSocketOriginNotAllowed indicates a WebSocket upgrade sent from a page of an origin the server does not allow
+	ErrCodeWebSocketOriginNotAllowed = "ERR_WEBSOCKET_ORIGIN_NOT_ALLOWED"
+	// ErrCodeIdempotencyKeyInProgress indicates a retry sent while the original request is still processed
+	ErrCodeIdempotencyKeyInProgress = "ERR_IDEMPOTENCY_KEY_IN_PROGRESS"
+	// ErrCodeIdempotencyKeyMismatch indicates an idempotency key reused for a different request
+	ErrCodeIdempotencyKeyMismatch = "ERR_IDEMPOTENCY_KEY_MISMATCH"
+	// ErrCodeMaintenance indicates the server is under maintenance, the peer should retry after the Retry-After delay
+	ErrCodeMaintenance = "ERR_MAINTENANCE"
+	// ErrCodeAnonymousNotAllowed indicates a route which requires an identity, the peer has to authenticate with its own key
+	ErrCodeAnonymousNotAllowed = "ERR_ANONYMOUS_NOT_ALLOWED"
+	// ErrCodeBlocked indicates the network address of the peer is on the blocklist of the server
+	ErrCodeBlocked = "ERR_BLOCKED"
+	// ErrCodeRateLimited indicates the peer exceeded its rate limit, it should retry after the Retry-After delay
+	ErrCodeRateLimited = "ERR_RATE_LIMITED"
+	// ErrCodeForbidden indicates a request denied by the authorization policy of the server
+	ErrCodeForbidden = "ERR_FORBIDDEN"
+	// ErrCodeAuthorizationUnavailable indicates the authorization policy could not be evaluated, the peer may retry later
+	ErrCodeAuthorizationUnavailable = "ERR_AUTHORIZATION_UNAVAILABLE"
+	// ErrCodeAccountUnavailable indicates the account of the peer could not be resolved, the peer may retry later
+	ErrCodeAccountUnavailable = "ERR_ACCOUNT_UNAVAILABLE"
+	// ErrCodeWalletTimeout indicates the wallet of the server did not respond in time, the peer may retry later
+	ErrCodeWalletTimeout = "ERR_WALLET_TIMEOUT"
+	// ErrCodeReadTimeout indicates the peer did not send the headers or the body of the request in time
+	ErrCodeReadTimeout = "ERR_READ_TIMEOUT"
+	// ErrCodeRequestCanceled indicates the peer disconnected before the message was processed, it is only logged
+	ErrCodeRequestCanceled = "ERR_REQUEST_CANCELED"
+	// ErrCodeInternal indicates the server failed to process the message
+	ErrCodeInternal = "ERR_INTERNAL"
+)
+
+// ErrorResponse is the body of error responses
+type ErrorResponse struct {
+	Status                string                   `json:"status"`
+	Code                  string                   `json:"code"`
+	Description           string                   `json:"description"`
+	RequestedCertificates *RequestedCertificateSet `json:"requestedCertificates,omitempty"`
+	// Disclosure describes the certificate rejected with ErrCodeCertificateUnderDisclosed or ErrCodeCertificateOverDisclosed
+	Disclosure *DisclosureError `json:"disclosure,omitempty"`
+	// Certificates lists every certificate of a rejected certificate response with the reason it failed
+	Certificates CertificateErrors `json:"certificates,omitempty"`
+	// Aborted is the signed ExchangeAborted message of a certificate exchange rejected with ErrCodeExchangeAborted
+	Aborted *AuthMessage `json:"aborted,omitempty"`
+}
+
+// ErrorCode returns the error code for the transport error
+func ErrorCode(err error) string {
+	switch {
+	// header errors are reported as such, regardless of their cause
+	case errors.Is(err, ErrMissingHeader):
+		return ErrCodeMissingHeader
+	case errors.Is(err, ErrInvalidHeader):
+		return ErrCodeInvalidHeader
+	case errors.Is(err, ErrWalletTimeout):
+		return ErrCodeWalletTimeout
+	case errors.Is(err, ErrRequestCanceled):
+		return ErrCodeRequestCanceled
+	case errors.Is(err, ErrReadTimeout):
+		return ErrCodeReadTimeout
+	case errors.Is(err, ErrMissingRequestID):
+		return ErrCodeMissingRequestID
+	case errors.Is(err, ErrUnsupportedVersion):
+		return ErrCodeUnsupportedVersion
+	case errors.Is(err, ErrSessionNotFound):
+		return ErrCodeSessionNotFound
+	case errors.Is(err, ErrIdentityKeyMismatch):
+		return ErrCodeIdentityKeyMismatch
+	case errors.Is(err, ErrSessionNotAuthenticated):
+		return ErrCodeSessionNotAuthenticated
+	case errors.Is(err, ErrCertificatesRequired):
+		return ErrCodeCertificatesRequired
+	case errors.Is(err, ErrRequestReplayed):
+		return ErrCodeRequestReplayed
+	case errors.Is(err, ErrCertificateRevoked):
+		return ErrCodeCertificateRevoked
+	case errors.Is(err, ErrMalformedMessage):
+		return ErrCodeMalformedMessage
+	case errors.Is(err, ErrMessageTooLarge):
+		return ErrCodeMessageTooLarge
+	case errors.Is(err, ErrMissingRequiredFields):
+		return ErrCodeMissingRequiredFields
+	case errors.Is(err, ErrInvalidIdentityKey):
+		return ErrCodeInvalidIdentityKey
+	case errors.Is(err, ErrInvalidNonceFormat), errors.Is(err, ErrInvalidNonce):
+		return ErrCodeInvalidNonce
+	case errors.Is(err, ErrInvalidSignature):
+		return ErrCodeInvalidSignature
+	case errors.Is(err, ErrUnsupportedMessageType):
+		return ErrCodeUnsupportedMessageType
+	case errors.Is(err, ErrCertificateConflict):
+		return ErrCodeCertificateConflict
+	case errors.Is(err, ErrOriginNotAccepted):
+		return ErrCodeOriginNotAccepted
+	case errors.Is(err, ErrOriginBindingRequired):
+		return ErrCodeOriginBindingRequired
+	case errors.Is(err, ErrInvalidBatch):
+		return ErrCodeInvalidBatch
+	case errors.Is(err, ErrClientCertificateNotBound):
+		return ErrCodeClientCertificateNotBound
+	case errors.Is(err, ErrInvalidCredential):
+		return ErrCodeInvalidCredential
+	case errors.Is(err, ErrStaleHeartbeat):
+		return ErrCodeStaleHeartbeat
+	case errors.Is(err, ErrInvalidPadding):
+		return ErrCodeInvalidPadding
+	// under disclosure takes precedence, the peer has to send the missing fields before anything else
+	case errors.Is(err, ErrCertificateUnderDisclosed):
+		return ErrCodeCertificateUnderDisclosed
+	case errors.Is(err, ErrCertificateOverDisclosed):
+		return ErrCodeCertificateOverDisclosed
+	case errors.Is(err, ErrInvalidAllowlist):
+		return ErrCodeInvalidAllowlist
+	case errors.Is(err, ErrFederationNotAllowed):
+		return ErrCodeFederationNotAllowed
+	case errors.Is(err, ErrExchangeAborted):
+		return ErrCodeExchangeAborted
+	case errors.Is(err, ErrUnsupportedCompression):
+		return ErrCodeUnsupportedCompression
+	case errors.Is(err, ErrMessageTooComplex):
+		return ErrCodeMessageTooComplex
+	case errors.Is(err, ErrInvalidDelegation):
+		return ErrCodeInvalidDelegation
+	case errors.Is(err, ErrRevoked):
+		return ErrCodeRevoked
+	case errors.Is(err, ErrRevocationUnavailable):
+		return ErrCodeRevocationUnavailable
+	case errors.Is(err, ErrWebSocketOriginNotAllowed):
+		return ErrCodeWebSocketOriginNotAllowed
+	default:
+		return ErrCodeUnauthorized
+	}
+}
+
+// ErrorStatus returns the HTTP status for the transport error,
+// messages, batches, padded bodies and allowlists which cannot be parsed, messages exceeding the limits of their structure
+// and unsupported compressions are rejected as bad requests,
+// wallet timeouts, aborted certificate exchanges and unavailable revocation lists are reported as unavailability,
+// requests not received in time as request timeouts and every other failure as unauthorized
+func ErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrWalletTimeout), errors.Is(err, ErrExchangeAborted), errors.Is(err, ErrRevocationUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, ErrReadTimeout):
+		return http.StatusRequestTimeout
+	case errors.Is(err, ErrMalformedMessage), errors.Is(err, ErrInvalidBatch), errors.Is(err, ErrInvalidPadding),
+		errors.Is(err, ErrInvalidAllowlist), errors.Is(err, ErrUnsupportedCompression), errors.Is(err, ErrMessageTooComplex):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrMessageTooLarge):
+		return http.StatusRequestEntityTooLarge
+	default:
+		return http.StatusUnauthorized
+	}
+}
@@ -0,0 +1,141 @@
+package transport_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrors_WrappedSentinelsKeepCodeAndStatus(t *testing.T) {
+	tests := map[string]struct {
+		err    error
+		code   string
+		status int
+	}{
+		"missing request ID":           {transport.ErrMissingRequestID, transport.ErrCodeMissingRequestID, http.StatusUnauthorized},
+		"unsupported version":          {transport.ErrUnsupportedVersion, transport.ErrCodeUnsupportedVersion, http.StatusUnauthorized},
+		"session not found":            {transport.ErrSessionNotFound, transport.ErrCodeSessionNotFound, http.StatusUnauthorized},
+		"identity key mismatch":        {transport.ErrIdentityKeyMismatch, transport.ErrCodeIdentityKeyMismatch, http.StatusUnauthorized},
+		"session not authenticated":    {transport.ErrSessionNotAuthenticated, transport.ErrCodeSessionNotAuthenticated, http.StatusUnauthorized},
+		"certificates required":        {transport.ErrCertificatesRequired, transport.ErrCodeCertificatesRequired, http.StatusUnauthorized},
+		"request replayed":             {transport.ErrRequestReplayed, transport.ErrCodeRequestReplayed, http.StatusUnauthorized},
+		"malformed message":            {transport.ErrMalformedMessage, transport.ErrCodeMalformedMessage, http.StatusBadRequest},
+		"message too large":            {transport.ErrMessageTooLarge, transport.ErrCodeMessageTooLarge, http.StatusRequestEntityTooLarge},
+		"missing required fields":      {transport.ErrMissingRequiredFields, transport.ErrCodeMissingRequiredFields, http.StatusUnauthorized},
+		"invalid identity key":         {transport.ErrInvalidIdentityKey, transport.ErrCodeInvalidIdentityKey, http.StatusUnauthorized},
+		"invalid nonce format":         {transport.ErrInvalidNonceFormat, transport.ErrCodeInvalidNonce, http.StatusUnauthorized},
+		"invalid nonce":                {transport.ErrInvalidNonce, transport.ErrCodeInvalidNonce, http.StatusUnauthorized},
+		"invalid signature":            {transport.ErrInvalidSignature, transport.ErrCodeInvalidSignature, http.StatusUnauthorized},
+		"unsupported message type":     {transport.ErrUnsupportedMessageType, transport.ErrCodeUnsupportedMessageType, http.StatusUnauthorized},
+		"missing header":               {transport.ErrMissingHeader, transport.ErrCodeMissingHeader, http.StatusUnauthorized},
+		"invalid header":               {transport.ErrInvalidHeader, transport.ErrCodeInvalidHeader, http.StatusUnauthorized},
+		"wallet timeout":               {transport.ErrWalletTimeout, transport.ErrCodeWalletTimeout, http.StatusServiceUnavailable},
+		"read timeout":                 {transport.ErrReadTimeout, transport.ErrCodeReadTimeout, http.StatusRequestTimeout},
+		"origin not accepted":          {transport.ErrOriginNotAccepted, transport.ErrCodeOriginNotAccepted, http.StatusUnauthorized},
+		"origin binding required":      {transport.ErrOriginBindingRequired, transport.ErrCodeOriginBindingRequired, http.StatusUnauthorized},
+		"invalid batch":                {transport.ErrInvalidBatch, transport.ErrCodeInvalidBatch, http.StatusBadRequest},
+		"stale heartbeat":              {transport.ErrStaleHeartbeat, transport.ErrCodeStaleHeartbeat, http.StatusUnauthorized},
+		"invalid padding":              {transport.ErrInvalidPadding, transport.ErrCodeInvalidPadding, http.StatusBadRequest},
+		"certificate under disclosed":  {transport.ErrCertificateUnderDisclosed, transport.ErrCodeCertificateUnderDisclosed, http.StatusUnauthorized},
+		"certificate over disclosed":   {transport.ErrCertificateOverDisclosed, transport.ErrCodeCertificateOverDisclosed, http.StatusUnauthorized},
+		"invalid allowlist":            {transport.ErrInvalidAllowlist, transport.ErrCodeInvalidAllowlist, http.StatusBadRequest},
+		"federation not allowed":       {transport.ErrFederationNotAllowed, transport.ErrCodeFederationNotAllowed, http.StatusUnauthorized},
+		"exchange aborted":             {transport.ErrExchangeAborted, transport.ErrCodeExchangeAborted, http.StatusServiceUnavailable},
+		"unsupported compression":      {transport.ErrUnsupportedCompression, transport.ErrCodeUnsupportedCompression, http.StatusBadRequest},
+		"message too complex":          {transport.ErrMessageTooComplex, transport.ErrCodeMessageTooComplex, http.StatusBadRequest},
+		"invalid delegation":           {transport.ErrInvalidDelegation, transport.ErrCodeInvalidDelegation, http.StatusUnauthorized},
+		"revoked":                      {transport.ErrRevoked, transport.ErrCodeRevoked, http.StatusUnauthorized},
+		"revocation unavailable":       {transport.ErrRevocationUnavailable, transport.ErrCodeRevocationUnavailable, http.StatusServiceUnavailable},
+		"websocket origin not allowed": {transport.ErrWebSocketOriginNotAllowed, transport.ErrCodeWebSocketOriginNotAllowed, http.StatusUnauthorized},
+		"unknown error":                {errors.New("unknown"), transport.ErrCodeUnauthorized, http.StatusUnauthorized},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			wrapped := fmt.Errorf("failed to handle message, %w", test.err)
+
+			// then
+			require.ErrorIs(t, wrapped, test.err)
+			require.Equal(t, test.code, transport.ErrorCode(wrapped))
+			require.Equal(t, test.status, transport.ErrorStatus(wrapped))
+		})
+	}
+}
+
+func TestHeaderError(t *testing.T) {
+	t.Run("missing header matches ErrMissingHeader", func(t *testing.T) {
+		// given
+		err := fmt.Errorf("failed to check headers, %w", &transport.HeaderError{Header: "nonce", Missing: true})
+
+		// when
+		var headerErr *transport.HeaderError
+		ok := errors.As(err, &headerErr)
+
+		// then
+		require.True(t, ok)
+		require.Equal(t, "nonce", headerErr.Header)
+		require.ErrorIs(t, err, transport.ErrMissingHeader)
+		require.NotErrorIs(t, err, transport.ErrInvalidHeader)
+		require.EqualError(t, headerErr, "missing nonce header")
+	})
+
+	t.Run("invalid header matches ErrInvalidHeader", func(t *testing.T) {
+		// given
+		err := fmt.Errorf("failed to check headers, %w", &transport.HeaderError{Header: "signature"})
+
+		// when
+		var headerErr *transport.HeaderError
+		ok := errors.As(err, &headerErr)
+
+		// then
+		require.True(t, ok)
+		require.Equal(t, "signature", headerErr.Header)
+		require.ErrorIs(t, err, transport.ErrInvalidHeader)
+		require.NotErrorIs(t, err, transport.ErrMissingHeader)
+		require.EqualError(t, headerErr, "invalid signature header")
+	})
+}
+
+func TestWalletTimeoutError(t *testing.T) {
+	// given
+	err := fmt.Errorf("failed to create nonce, %w", &transport.WalletTimeoutError{Operation: "CreateNonce", Timeout: time.Second})
+
+	// when
+	var timeoutErr *transport.WalletTimeoutError
+	ok := errors.As(err, &timeoutErr)
+
+	// then
+	require.True(t, ok)
+	require.Equal(t, "CreateNonce", timeoutErr.Operation)
+	require.ErrorIs(t, err, transport.ErrWalletTimeout)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.EqualError(t, timeoutErr, "wallet CreateNonce timed out after 1s")
+	require.Equal(t, transport.ErrCodeWalletTimeout, transport.ErrorCode(err))
+	require.Equal(t, http.StatusServiceUnavailable, transport.ErrorStatus(err))
+}
+
+func TestReadTimeoutError(t *testing.T) {
+	// given
+	err := fmt.Errorf("failed to read message, %w", &transport.ReadTimeoutError{Stage: transport.ReadStageBody, Timeout: time.Second})
+
+	// when
+	var timeoutErr *transport.ReadTimeoutError
+	ok := errors.As(err, &timeoutErr)
+
+	// then
+	require.True(t, ok)
+	require.Equal(t, transport.ReadStageBody, timeoutErr.Stage)
+	require.ErrorIs(t, err, transport.ErrReadTimeout)
+	require.ErrorIs(t, err, os.ErrDeadlineExceeded)
+	require.EqualError(t, timeoutErr, "request body was not received within 1s")
+	require.Equal(t, transport.ErrCodeReadTimeout, transport.ErrorCode(err))
+	require.Equal(t, http.StatusRequestTimeout, transport.ErrorStatus(err))
+}
@@ -0,0 +1,52 @@
+package transport
+
+import (
+	"fmt"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+)
+
+// AbortReason is the reason the server aborted the certificate exchange of a peer
+type AbortReason string
+
+// Reasons of aborted certificate exchanges
+const (
+	// AbortReasonShutdown is sent when the server shuts down, the peer should repeat the handshake with another instance
+	// or once the server is back
+	AbortReasonShutdown AbortReason = "shutdown"
+	// AbortReasonPolicyChanged is sent when the certificate requirements changed, the peer should repeat the handshake
+	// to receive the new certificate request
+	AbortReasonPolicyChanged AbortReason = "policyChanged"
+)
+
+// ExchangeAbortedError is returned for certificate responses of exchanges the server aborted, it matches ErrExchangeAborted.
+// Message is the ExchangeAborted message of the server, signed with the session of the exchange, so the peer can tell
+// it apart from an error of a middle-box.
+type ExchangeAbortedError struct {
+	Reason  AbortReason
+	Message *AuthMessage
+}
+
+func (e *ExchangeAbortedError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrExchangeAborted.Error(), e.Reason)
+}
+
+// Unwrap returns ErrExchangeAborted
+func (e *ExchangeAbortedError) Unwrap() error {
+	return ErrExchangeAborted
+}
+
+// VerifyExchangeAborted verifies the signature of an ExchangeAborted message and returns the reason the exchange was aborted
+func VerifyExchangeAborted(w wallet.WalletInterface, msg *AuthMessage) (AbortReason, error) {
+	if msg.MessageType != ExchangeAborted {
+		return "", fmt.Errorf("%w: %s is not an aborted exchange", ErrUnsupportedMessageType, msg.MessageType)
+	}
+	if msg.Nonce == nil || msg.YourNonce == nil || msg.Payload == nil || msg.Signature == nil {
+		return "", fmt.Errorf("%w: aborted exchange requires nonce, your nonce, payload and signature", ErrMalformedMessage)
+	}
+
+	if err := verifySessionMessage(w, msg); err != nil {
+		return "", err
+	}
+	return AbortReason(*msg.Payload), nil
+}
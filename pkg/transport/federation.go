@@ -0,0 +1,219 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// FederationAllowlist is what a deployment offers to a federation partner in an AllowlistExchange message
+type FederationAllowlist struct {
+	// Organization names the deployment in logs and listings, e.g. "acme payments"
+	Organization string `json:"organization,omitempty"`
+	// ServiceKeys are the identity keys (compressed public keys, hex) of the services the deployment runs,
+	// the partner pins them and pre-warms their sessions like those of known peers
+	ServiceKeys []string `json:"serviceKeys"`
+	// CertificatePolicy are the certificates the deployment requests from services of the partner,
+	// so the partner can acquire them before its first request
+	CertificatePolicy *RequestedCertificateSet `json:"certificatePolicy,omitempty"`
+}
+
+// Validate checks the service keys are public keys and the certificate policy is valid
+func (a *FederationAllowlist) Validate() error {
+	for _, key := range a.ServiceKeys {
+		if _, err := ec.PublicKeyFromString(key); err != nil {
+			return fmt.Errorf("%w: service key %q, %w", ErrInvalidAllowlist, key, err)
+		}
+	}
+	if a.CertificatePolicy != nil {
+		if err := a.CertificatePolicy.Validate(); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidAllowlist, err)
+		}
+	}
+	return nil
+}
+
+// FederationPartner is a deployment of another organization registered in the TrustRegistry
+type FederationPartner struct {
+	// IdentityKey is the identity key the partner signs the AllowlistExchange message with
+	IdentityKey string `json:"identityKey"`
+	// Allowlist is the allowlist pinned in the last exchange, nil until the partner exchanged its allowlist
+	Allowlist *FederationAllowlist `json:"allowlist,omitempty"`
+	// PinnedAt is the time of the last exchange
+	PinnedAt time.Time `json:"pinnedAt,omitzero"`
+}
+
+// TrustRegistry holds the allowlist of the deployment and the allowlists pinned from its federation partners.
+// Only partners registered by the operator can exchange allowlists, a partner exchanging again replaces its allowlist.
+// It is safe for concurrent use, a nil registry trusts no service.
+type TrustRegistry struct {
+	mu       sync.RWMutex
+	local    FederationAllowlist
+	partners map[string]FederationPartner
+}
+
+// NewTrustRegistry creates a registry offering the local allowlist to the partners with the identity keys
+func NewTrustRegistry(local FederationAllowlist, partners ...string) (*TrustRegistry, error) {
+	if err := local.Validate(); err != nil {
+		return nil, err
+	}
+
+	r := &TrustRegistry{local: local, partners: make(map[string]FederationPartner, len(partners))}
+	for _, partner := range partners {
+		if err := r.AddPartner(partner); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Local returns the allowlist offered to the partners
+func (r *TrustRegistry) Local() FederationAllowlist {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.local
+}
+
+// AddPartner allows the deployment with the identity key to exchange allowlists, a registered partner is kept
+func (r *TrustRegistry) AddPartner(identityKey string) error {
+	if _, err := ec.PublicKeyFromString(identityKey); err != nil {
+		return fmt.Errorf("%w, %w", ErrInvalidIdentityKey, err)
+	}
+	identityKey = strings.ToLower(identityKey)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.partners[identityKey]; !ok {
+		r.partners[identityKey] = FederationPartner{IdentityKey: identityKey}
+	}
+	return nil
+}
+
+// RemovePartner removes the partner along with its pinned allowlist, it reports whether the partner was registered
+func (r *TrustRegistry) RemovePartner(identityKey string) bool {
+	identityKey = strings.ToLower(identityKey)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.partners[identityKey]
+	delete(r.partners, identityKey)
+	return ok
+}
+
+// Pin stores the allowlist exchanged by the partner, partners not registered with AddPartner are rejected
+// with ErrFederationNotAllowed
+func (r *TrustRegistry) Pin(identityKey string, allowlist FederationAllowlist, at time.Time) error {
+	if err := allowlist.Validate(); err != nil {
+		return err
+	}
+	identityKey = strings.ToLower(identityKey)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.partners[identityKey]; !ok {
+		return ErrFederationNotAllowed
+	}
+	r.partners[identityKey] = FederationPartner{IdentityKey: identityKey, Allowlist: &allowlist, PinnedAt: at}
+	return nil
+}
+
+// Partner returns the partner with the identity key
+func (r *TrustRegistry) Partner(identityKey string) (FederationPartner, bool) {
+	if r == nil {
+		return FederationPartner{}, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	partner, ok := r.partners[strings.ToLower(identityKey)]
+	return partner, ok
+}
+
+// Partners returns the registered partners sorted by identity key
+func (r *TrustRegistry) Partners() []FederationPartner {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	partners := make([]FederationPartner, 0, len(r.partners))
+	for _, partner := range r.partners {
+		partners = append(partners, partner)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(partners, func(i, j int) bool { return partners[i].IdentityKey < partners[j].IdentityKey })
+	return partners
+}
+
+// Trusts reports whether a partner pinned the service key in its allowlist
+func (r *TrustRegistry) Trusts(serviceKey string) bool {
+	if r == nil || serviceKey == "" {
+		return false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, partner := range r.partners {
+		if partner.Allowlist == nil {
+			continue
+		}
+		for _, key := range partner.Allowlist.ServiceKeys {
+			if strings.EqualFold(key, serviceKey) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SignAllowlistExchange creates an AllowlistExchange message carrying the allowlist, the server responds with its own
+// allowlist in a message of the same type, so both deployments pin each other in one round trip.
+// The signature is bound to the session like a general message, yourNonce is the session nonce of the peer.
+func SignAllowlistExchange(ctx context.Context, w wallet.WalletInterface, peerIdentityKey, yourNonce string, allowlist FederationAllowlist) (*AuthMessage, error) {
+	payload, err := json.Marshal(allowlist)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode allowlist, %w", err)
+	}
+	return signSessionMessage(ctx, w, AllowlistExchange, peerIdentityKey, yourNonce, payload)
+}
+
+// AllowlistOf returns the allowlist carried by the AllowlistExchange message, without verifying its signature
+func AllowlistOf(msg *AuthMessage) (*FederationAllowlist, error) {
+	if msg.MessageType != AllowlistExchange {
+		return nil, fmt.Errorf("%w: %s is not an allowlist exchange", ErrUnsupportedMessageType, msg.MessageType)
+	}
+	if msg.Nonce == nil || msg.YourNonce == nil || msg.Payload == nil || msg.Signature == nil {
+		return nil, fmt.Errorf("%w: allowlist exchange requires nonce, your nonce, payload and signature", ErrMalformedMessage)
+	}
+
+	var allowlist FederationAllowlist
+	if err := json.Unmarshal(*msg.Payload, &allowlist); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidAllowlist, err)
+	}
+	if err := allowlist.Validate(); err != nil {
+		return nil, err
+	}
+	return &allowlist, nil
+}
+
+// VerifyAllowlistExchange verifies the signature of an AllowlistExchange message and returns its allowlist.
+// Checking that YourNonce belongs to a session of the sender is left to the transport, like for general messages.
+func VerifyAllowlistExchange(w wallet.WalletInterface, msg *AuthMessage) (*FederationAllowlist, error) {
+	allowlist, err := AllowlistOf(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := verifySessionMessage(w, msg); err != nil {
+		return nil, err
+	}
+	return allowlist, nil
+}
@@ -0,0 +1,132 @@
+package transport_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrustRegistry(t *testing.T) {
+	partnerKey := wallettest.SeededPrivateKey(wallettest.Seed, "partner").PubKey().ToDERHex()
+	serviceKey := wallettest.SeededPrivateKey(wallettest.Seed, "service").PubKey().ToDERHex()
+	pinnedAt := time.UnixMilli(1760000000123)
+
+	t.Run("allowlist of a partner is pinned", func(t *testing.T) {
+		// given
+		registry, err := transport.NewTrustRegistry(transport.FederationAllowlist{}, partnerKey)
+		require.NoError(t, err)
+
+		// when
+		err = registry.Pin(partnerKey, transport.FederationAllowlist{ServiceKeys: []string{serviceKey}}, pinnedAt)
+
+		// then
+		require.NoError(t, err)
+		require.True(t, registry.Trusts(serviceKey))
+		require.False(t, registry.Trusts(partnerKey))
+
+		partner, ok := registry.Partner(partnerKey)
+		require.True(t, ok)
+		require.Equal(t, pinnedAt, partner.PinnedAt)
+	})
+
+	t.Run("allowlist of a peer which is not a partner is rejected", func(t *testing.T) {
+		// given
+		registry, err := transport.NewTrustRegistry(transport.FederationAllowlist{})
+		require.NoError(t, err)
+
+		// when
+		err = registry.Pin(partnerKey, transport.FederationAllowlist{ServiceKeys: []string{serviceKey}}, pinnedAt)
+
+		// then
+		require.ErrorIs(t, err, transport.ErrFederationNotAllowed)
+		require.False(t, registry.Trusts(serviceKey))
+	})
+
+	t.Run("removed partner is no longer trusted", func(t *testing.T) {
+		// given
+		registry, err := transport.NewTrustRegistry(transport.FederationAllowlist{}, partnerKey)
+		require.NoError(t, err)
+		require.NoError(t, registry.Pin(partnerKey, transport.FederationAllowlist{ServiceKeys: []string{serviceKey}}, pinnedAt))
+
+		// when
+		removed := registry.RemovePartner(partnerKey)
+
+		// then
+		require.True(t, removed)
+		require.False(t, registry.Trusts(serviceKey))
+		require.Empty(t, registry.Partners())
+	})
+
+	t.Run("allowlist with an invalid service key is rejected", func(t *testing.T) {
+		// when
+		_, err := transport.NewTrustRegistry(transport.FederationAllowlist{ServiceKeys: []string{"not a key"}})
+
+		// then
+		require.ErrorIs(t, err, transport.ErrInvalidAllowlist)
+	})
+
+	t.Run("nil registry trusts no service", func(t *testing.T) {
+		// given
+		var registry *transport.TrustRegistry
+
+		// then
+		require.False(t, registry.Trusts(serviceKey))
+	})
+}
+
+func TestAllowlistExchange(t *testing.T) {
+	sender := wallettest.NewSeededMockWallet(wallettest.Seed, "client")
+	receiver := wallettest.NewSeededMockWallet(wallettest.Seed, "server")
+	receiverKey := wallettest.SeededPrivateKey(wallettest.Seed, "server").PubKey().ToDERHex()
+	serviceKey := wallettest.SeededPrivateKey(wallettest.Seed, "service").PubKey().ToDERHex()
+	allowlist := transport.FederationAllowlist{Organization: "acme", ServiceKeys: []string{serviceKey}}
+
+	sign := func(t *testing.T) *transport.AuthMessage {
+		msg, err := transport.SignAllowlistExchange(context.Background(), sender, receiverKey, "session-nonce", allowlist)
+		require.NoError(t, err)
+		return msg
+	}
+
+	t.Run("signed allowlist is verified", func(t *testing.T) {
+		// given
+		msg := sign(t)
+
+		// when
+		verified, err := transport.VerifyAllowlistExchange(receiver, msg)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, transport.AllowlistExchange, msg.MessageType)
+		require.Equal(t, allowlist, *verified)
+	})
+
+	t.Run("tampered allowlist fails the signature", func(t *testing.T) {
+		// given
+		msg := sign(t)
+		payload := []byte(`{"serviceKeys":["` + receiverKey + `"]}`)
+		msg.Payload = &payload
+
+		// when
+		_, err := transport.VerifyAllowlistExchange(receiver, msg)
+
+		// then
+		require.ErrorIs(t, err, transport.ErrInvalidSignature)
+	})
+
+	t.Run("undecodable allowlist is rejected", func(t *testing.T) {
+		// given
+		msg := sign(t)
+		payload := []byte("not json")
+		msg.Payload = &payload
+
+		// when
+		_, err := transport.VerifyAllowlistExchange(receiver, msg)
+
+		// then
+		require.ErrorIs(t, err, transport.ErrInvalidAllowlist)
+	})
+}
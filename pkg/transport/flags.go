@@ -0,0 +1,162 @@
+package transport
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// ErrInvalidFlagRule is returned when a feature flag is set with a rule which is not valid
+var ErrInvalidFlagRule = errors.New("invalid feature flag rule")
+
+// Flag names an experimental protocol feature gated by FeatureFlags. A flag named after a Capability gates its
+// negotiation, so the capability is only negotiated with the identities the flag is enabled for.
+type Flag string
+
+// Flags of the experimental capabilities of this version of the protocol
+const (
+	// FlagHeartbeat gates CapabilityHeartbeat
+	FlagHeartbeat = Flag(CapabilityHeartbeat)
+	// FlagDigestBLAKE3 gates CapabilityDigestBLAKE3
+	FlagDigestBLAKE3 = Flag(CapabilityDigestBLAKE3)
+	// FlagTrailerSignature gates CapabilityTrailerSignature
+	FlagTrailerSignature = Flag(CapabilityTrailerSignature)
+)
+
+// FlagRule enables a flag for a share of the identities and a cohort. Identities are bucketed deterministically
+// by their identity key and the flag, an identity enabled at a percentage stays enabled when the percentage is raised.
+type FlagRule struct {
+	// Percent is the share of identities the flag is enabled for, from 0 to 100
+	Percent int `json:"percent"`
+	// Cohort lists identity keys (hex) the flag is always enabled for, e.g. internal clients
+	Cohort []string `json:"cohort,omitempty"`
+}
+
+func (r FlagRule) validate() error {
+	if r.Percent < 0 || r.Percent > 100 {
+		return fmt.Errorf("%w, percent %d is not between 0 and 100", ErrInvalidFlagRule, r.Percent)
+	}
+
+	for _, identityKey := range r.Cohort {
+		if _, err := ec.PublicKeyFromString(identityKey); err != nil {
+			return fmt.Errorf("%w, cohort identity key %q is not a public key", ErrInvalidFlagRule, identityKey)
+		}
+	}
+
+	return nil
+}
+
+// enables reports whether the rule enables the flag for the identity key,
+// peers without an identity key are only enabled at 100 percent
+func (r FlagRule) enables(flag Flag, identityKey string) bool {
+	if r.Percent >= 100 {
+		return true
+	}
+	if identityKey == "" {
+		return false
+	}
+
+	for _, member := range r.Cohort {
+		if strings.EqualFold(member, identityKey) {
+			return true
+		}
+	}
+
+	return flagBucket(flag, identityKey) < r.Percent
+}
+
+// flagBucket maps the identity key to one of 100 buckets of the flag,
+// so the identities a flag is rolled out to first differ between flags
+func flagBucket(flag Flag, identityKey string) int {
+	sum := sha256.Sum256([]byte(string(flag) + ":" + strings.ToLower(identityKey)))
+	return int(binary.BigEndian.Uint64(sum[:8]) % 100)
+}
+
+// FeatureFlags gates experimental protocol features per deployment and identity cohort, so protocol evolution can
+// be tested on a share of the peers in production. Flags without a rule are enabled, a rule with Percent 0 and no
+// cohort disables its flag. Rules can be set and removed while the server runs, the capabilities of a session are
+// negotiated in its handshake, so changed rules apply to the sessions of the following handshakes.
+// It is safe for concurrent use, a nil set enables every flag.
+type FeatureFlags struct {
+	mu    sync.RWMutex
+	rules map[Flag]FlagRule
+}
+
+// NewFeatureFlags creates a set of the given rules
+func NewFeatureFlags(rules map[Flag]FlagRule) (*FeatureFlags, error) {
+	f := &FeatureFlags{rules: make(map[Flag]FlagRule, len(rules))}
+	for flag, rule := range rules {
+		if err := f.Set(flag, rule); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Set sets the rule of the flag, a rule set before is replaced
+func (f *FeatureFlags) Set(flag Flag, rule FlagRule) error {
+	if flag == "" {
+		return fmt.Errorf("%w, flag name is empty", ErrInvalidFlagRule)
+	}
+	if err := rule.validate(); err != nil {
+		return fmt.Errorf("flag %q: %w", flag, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules[flag] = rule
+	return nil
+}
+
+// Remove removes the rule of the flag, enabling it for every identity. It reports whether the flag had a rule.
+func (f *FeatureFlags) Remove(flag Flag) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.rules[flag]
+	delete(f.rules, flag)
+	return ok
+}
+
+// Enabled reports whether the flag is enabled for the identity key
+func (f *FeatureFlags) Enabled(flag Flag, identityKey string) bool {
+	if f == nil {
+		return true
+	}
+
+	f.mu.RLock()
+	rule, ok := f.rules[flag]
+	f.mu.RUnlock()
+	return !ok || rule.enables(flag, identityKey)
+}
+
+// Capabilities returns the capabilities whose flags are enabled for the identity key
+func (f *FeatureFlags) Capabilities(capabilities []Capability, identityKey string) []Capability {
+	if f == nil {
+		return capabilities
+	}
+
+	enabled := make([]Capability, 0, len(capabilities))
+	for _, capability := range capabilities {
+		if f.Enabled(Flag(capability), identityKey) {
+			enabled = append(enabled, capability)
+		}
+	}
+	return enabled
+}
+
+// Rules returns the rules of the flags, keyed by flag
+func (f *FeatureFlags) Rules() map[Flag]FlagRule {
+	if f == nil {
+		return nil
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return maps.Clone(f.rules)
+}
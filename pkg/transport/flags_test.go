@@ -0,0 +1,136 @@
+package transport_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeatureFlags(t *testing.T) {
+	identityKeys := make([]string, 200)
+	for i := range identityKeys {
+		key, err := ec.NewPrivateKey()
+		require.NoError(t, err)
+		identityKeys[i] = key.PubKey().ToDERHex()
+	}
+
+	t.Run("flags without a rule are enabled", func(t *testing.T) {
+		// given
+		flags, err := transport.NewFeatureFlags(nil)
+		require.NoError(t, err)
+
+		// then
+		require.True(t, flags.Enabled(transport.FlagHeartbeat, identityKeys[0]))
+		require.True(t, flags.Enabled(transport.FlagHeartbeat, ""))
+		require.True(t, (*transport.FeatureFlags)(nil).Enabled(transport.FlagHeartbeat, identityKeys[0]))
+	})
+
+	t.Run("flag is enabled for a share of the identities", func(t *testing.T) {
+		// given
+		flags, err := transport.NewFeatureFlags(map[transport.Flag]transport.FlagRule{transport.FlagHeartbeat: {Percent: 20}})
+		require.NoError(t, err)
+
+		// when
+		enabled := 0
+		for _, identityKey := range identityKeys {
+			if flags.Enabled(transport.FlagHeartbeat, identityKey) {
+				enabled++
+			}
+		}
+
+		// then
+		require.InDelta(t, 40, enabled, 25)
+		require.False(t, flags.Enabled(transport.FlagHeartbeat, ""))
+	})
+
+	t.Run("identities stay enabled when the percentage is raised", func(t *testing.T) {
+		// given
+		flags, err := transport.NewFeatureFlags(map[transport.Flag]transport.FlagRule{transport.FlagHeartbeat: {Percent: 10}})
+		require.NoError(t, err)
+		var enabled []string
+		for _, identityKey := range identityKeys {
+			if flags.Enabled(transport.FlagHeartbeat, identityKey) {
+				enabled = append(enabled, identityKey)
+			}
+		}
+
+		// when
+		require.NoError(t, flags.Set(transport.FlagHeartbeat, transport.FlagRule{Percent: 50}))
+
+		// then
+		for _, identityKey := range enabled {
+			require.True(t, flags.Enabled(transport.FlagHeartbeat, identityKey))
+		}
+	})
+
+	t.Run("flag is enabled for its cohort", func(t *testing.T) {
+		// given
+		flags, err := transport.NewFeatureFlags(map[transport.Flag]transport.FlagRule{
+			transport.FlagTrailerSignature: {Cohort: []string{strings.ToUpper(identityKeys[0])}},
+		})
+		require.NoError(t, err)
+
+		// then
+		require.True(t, flags.Enabled(transport.FlagTrailerSignature, identityKeys[0]))
+		require.False(t, flags.Enabled(transport.FlagTrailerSignature, identityKeys[1]))
+		require.True(t, flags.Enabled(transport.FlagHeartbeat, identityKeys[1]))
+	})
+
+	t.Run("removed rule enables the flag", func(t *testing.T) {
+		// given
+		flags, err := transport.NewFeatureFlags(map[transport.Flag]transport.FlagRule{transport.FlagHeartbeat: {}})
+		require.NoError(t, err)
+		require.False(t, flags.Enabled(transport.FlagHeartbeat, identityKeys[0]))
+
+		// when
+		removed := flags.Remove(transport.FlagHeartbeat)
+
+		// then
+		require.True(t, removed)
+		require.False(t, flags.Remove(transport.FlagHeartbeat))
+		require.True(t, flags.Enabled(transport.FlagHeartbeat, identityKeys[0]))
+		require.Empty(t, flags.Rules())
+	})
+
+	t.Run("capabilities of disabled flags are removed", func(t *testing.T) {
+		// given
+		flags, err := transport.NewFeatureFlags(map[transport.Flag]transport.FlagRule{
+			transport.FlagHeartbeat:    {},
+			transport.FlagDigestBLAKE3: {Percent: 100},
+		})
+		require.NoError(t, err)
+		capabilities := []transport.Capability{transport.CapabilityHeartbeat, transport.CapabilityDigestBLAKE3, transport.CapabilityPayloadPadding}
+
+		// when
+		enabled := flags.Capabilities(capabilities, identityKeys[0])
+
+		// then
+		require.Equal(t, []transport.Capability{transport.CapabilityDigestBLAKE3, transport.CapabilityPayloadPadding}, enabled)
+		require.Equal(t, capabilities, (*transport.FeatureFlags)(nil).Capabilities(capabilities, identityKeys[0]))
+	})
+
+	t.Run("invalid rules are rejected", func(t *testing.T) {
+		tests := map[string]struct {
+			flag transport.Flag
+			rule transport.FlagRule
+		}{
+			"negative percent":      {flag: transport.FlagHeartbeat, rule: transport.FlagRule{Percent: -1}},
+			"percent above 100":     {flag: transport.FlagHeartbeat, rule: transport.FlagRule{Percent: 101}},
+			"cohort of invalid key": {flag: transport.FlagHeartbeat, rule: transport.FlagRule{Cohort: []string{"not a key"}}},
+			"empty flag":            {rule: transport.FlagRule{Percent: 100}},
+		}
+
+		for name, test := range tests {
+			t.Run(name, func(t *testing.T) {
+				// when
+				_, err := transport.NewFeatureFlags(map[transport.Flag]transport.FlagRule{test.flag: test.rule})
+
+				// then
+				require.ErrorIs(t, err, transport.ErrInvalidFlagRule)
+			})
+		}
+	})
+}
@@ -0,0 +1,24 @@
+package transport
+
+import "encoding/base64"
+
+// GrantedCapabilities returns the names of the capabilities set in a handshake message, in the order they are sent
+func (m *AuthMessage) GrantedCapabilities() []string {
+	var capabilities []string
+	for _, capability := range m.OfferedCapabilities() {
+		capabilities = append(capabilities, string(capability))
+	}
+	return capabilities
+}
+
+// HandshakeSigningPayload returns the data signed in the handshake responses of the server: the base64 encoded nonces
+// of the peers followed by the granted capabilities, one per line. The capabilities are covered by the signature, so
+// they cannot be stripped from an initialResponse to downgrade the session. Without capabilities it is the payload
+// of BRC-103.
+func HandshakeSigningPayload(initialNonce, sessionNonce string, capabilities []string) []byte {
+	payload := base64.StdEncoding.EncodeToString([]byte(initialNonce + sessionNonce))
+	for _, capability := range capabilities {
+		payload += "\n" + capability
+	}
+	return []byte(payload)
+}
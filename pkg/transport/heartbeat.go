@@ -0,0 +1,70 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+)
+
+// MaxHeartbeatSkew is the maximum difference between the time a heartbeat was sent and the clock of its receiver
+const MaxHeartbeatSkew = 2 * time.Minute
+
+// SignHeartbeat creates a Heartbeat message, which refreshes the session of the peer without a general request,
+// so long-lived sessions (e.g. of WebSocket connections) are kept alive cheaply. The payload is the time it was sent,
+// the signature is bound to the session like a general message, yourNonce is the session nonce of the peer.
+func SignHeartbeat(ctx context.Context, w wallet.WalletInterface, peerIdentityKey, yourNonce string, sentAt time.Time) (*AuthMessage, error) {
+	return signSessionMessage(ctx, w, Heartbeat, peerIdentityKey, yourNonce, HeartbeatPayload(sentAt))
+}
+
+// HeartbeatPayload returns the payload of a heartbeat sent at the time, for transports signing heartbeats themselves
+func HeartbeatPayload(sentAt time.Time) []byte {
+	return binary.LittleEndian.AppendUint64(nil, uint64(sentAt.UnixMilli())) //nolint:gosec // times before 1970 are not sent
+}
+
+// HeartbeatTime returns the time the heartbeat was sent, without verifying its signature
+func HeartbeatTime(msg *AuthMessage) (time.Time, error) {
+	if msg.MessageType != Heartbeat {
+		return time.Time{}, fmt.Errorf("%w: %s is not a heartbeat", ErrUnsupportedMessageType, msg.MessageType)
+	}
+	if msg.Nonce == nil || msg.YourNonce == nil || msg.Payload == nil || msg.Signature == nil {
+		return time.Time{}, fmt.Errorf("%w: heartbeat requires nonce, your nonce, payload and signature", ErrMalformedMessage)
+	}
+	if len(*msg.Payload) != 8 {
+		return time.Time{}, fmt.Errorf("%w: heartbeat payload has to be a timestamp", ErrMalformedMessage)
+	}
+
+	return time.UnixMilli(int64(binary.LittleEndian.Uint64(*msg.Payload))), nil //nolint:gosec // checked against the clock
+}
+
+// VerifyHeartbeat verifies the signature of a Heartbeat message and returns the time it was sent.
+// Checking that YourNonce belongs to a session of the sender and the freshness of the time is left to the transport.
+func VerifyHeartbeat(w wallet.WalletInterface, msg *AuthMessage) (time.Time, error) {
+	sentAt, err := HeartbeatTime(msg)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if err := verifySessionMessage(w, msg); err != nil {
+		return time.Time{}, err
+	}
+	return sentAt, nil
+}
+
+// ParseHeartbeat returns the Heartbeat message encoded in the data, so connections carrying application messages
+// can tell heartbeats apart
+func ParseHeartbeat(data []byte) (*AuthMessage, bool) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		return nil, false
+	}
+
+	var msg AuthMessage
+	if err := json.Unmarshal(data, &msg); err != nil || msg.MessageType != Heartbeat || msg.Version == "" {
+		return nil, false
+	}
+	return &msg, true
+}
@@ -0,0 +1,114 @@
+package transport_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeartbeat(t *testing.T) {
+	sender := wallettest.NewSeededMockWallet(wallettest.Seed, "client")
+	receiver := wallettest.NewSeededMockWallet(wallettest.Seed, "server")
+	receiverKey := wallettest.SeededPrivateKey(wallettest.Seed, "server").PubKey().ToDERHex()
+	sentAt := time.UnixMilli(1760000000123)
+
+	sign := func(t *testing.T) *transport.AuthMessage {
+		msg, err := transport.SignHeartbeat(context.Background(), sender, receiverKey, "session-nonce", sentAt)
+		require.NoError(t, err)
+		return msg
+	}
+
+	t.Run("signed heartbeat is verified", func(t *testing.T) {
+		// given
+		msg := sign(t)
+
+		// when
+		verified, err := transport.VerifyHeartbeat(receiver, msg)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, transport.Heartbeat, msg.MessageType)
+		require.True(t, sentAt.Equal(verified))
+	})
+
+	t.Run("heartbeat with another time fails the signature", func(t *testing.T) {
+		// given
+		msg := sign(t)
+		(*msg.Payload)[0] ^= 0x01
+
+		// when
+		_, err := transport.VerifyHeartbeat(receiver, msg)
+
+		// then
+		require.ErrorIs(t, err, transport.ErrInvalidSignature)
+	})
+
+	t.Run("heartbeat replayed to another session fails the signature", func(t *testing.T) {
+		// given
+		msg := sign(t)
+		otherSession := "other-session-nonce"
+		msg.YourNonce = &otherSession
+
+		// when
+		_, err := transport.VerifyHeartbeat(receiver, msg)
+
+		// then
+		require.ErrorIs(t, err, transport.ErrInvalidSignature)
+	})
+
+	t.Run("malformed heartbeats", func(t *testing.T) {
+		tests := map[string]struct {
+			modify   func(msg *transport.AuthMessage)
+			expected error
+		}{
+			"general message": {
+				modify:   func(msg *transport.AuthMessage) { msg.MessageType = transport.General },
+				expected: transport.ErrUnsupportedMessageType,
+			},
+			"payload is not a timestamp": {
+				modify:   func(msg *transport.AuthMessage) { *msg.Payload = (*msg.Payload)[:4] },
+				expected: transport.ErrMalformedMessage,
+			},
+			"missing nonce": {
+				modify:   func(msg *transport.AuthMessage) { msg.Nonce = nil },
+				expected: transport.ErrMalformedMessage,
+			},
+		}
+
+		for name, test := range tests {
+			t.Run(name, func(t *testing.T) {
+				// given
+				msg := sign(t)
+				test.modify(msg)
+
+				// when
+				_, err := transport.VerifyHeartbeat(receiver, msg)
+
+				// then
+				require.ErrorIs(t, err, test.expected)
+			})
+		}
+	})
+
+	t.Run("heartbeats are told apart from application messages", func(t *testing.T) {
+		// given
+		encoded, err := json.Marshal(sign(t))
+		require.NoError(t, err)
+
+		// when
+		parsed, ok := transport.ParseHeartbeat(encoded)
+		_, general := transport.ParseHeartbeat([]byte(`{"messageType":"general","version":"0.1"}`))
+		_, text := transport.ParseHeartbeat([]byte("heartbeat"))
+
+		// then
+		require.True(t, ok)
+		require.Equal(t, transport.Heartbeat, parsed.MessageType)
+		require.False(t, general)
+		require.False(t, text)
+	})
+}
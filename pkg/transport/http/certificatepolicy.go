@@ -0,0 +1,80 @@
+package httptransport
+
+import (
+	"log/slog"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// certificatePolicy is a generation of the certificate requirements.
+// Sessions authenticated under a generation older than rechallengeFrom are re-challenged or revoked on their next request.
+type certificatePolicy struct {
+	requirements    *transport.RequestedCertificateSet
+	generation      uint64
+	rechallengeFrom uint64
+	mode            transport.CertificateUpgradeMode
+}
+
+// UpdateCertificateRequirements implements TransportInterface
+func (t *Transport) UpdateCertificateRequirements(requirements *transport.RequestedCertificateSet, mode transport.CertificateUpgradeMode) {
+	t.certificatePolicyMu.Lock()
+	defer t.certificatePolicyMu.Unlock()
+
+	previous := t.certificatePolicy.Load()
+	next := &certificatePolicy{
+		requirements:    requirements,
+		generation:      previous.generation + 1,
+		rechallengeFrom: previous.rechallengeFrom,
+		mode:            previous.mode,
+	}
+	if mode != transport.CertificateUpgradeIgnore {
+		next.rechallengeFrom = next.generation
+		next.mode = mode
+	}
+
+	t.certificatePolicy.Store(next)
+	t.certificatesLogger.Info("Certificate requirements updated", slog.Uint64("generation", next.generation), slog.Int("mode", int(mode)))
+
+	// peers in the middle of the exchange were requested the previous certificates, they are told to start over
+	if mode != transport.CertificateUpgradeIgnore {
+		t.AbortCertificateExchanges(transport.AbortReasonPolicyChanged)
+	}
+}
+
+func (t *Transport) certificateRequirements() *transport.RequestedCertificateSet {
+	return t.certificatePolicy.Load().requirements
+}
+
+// upgradeSession applies the current certificate requirements to a session authenticated under previous requirements.
+// Re-challenged sessions are marked as not authenticated until the peer sends the requested certificates,
+// revoked sessions are removed. Sessions of known peers are not re-challenged.
+func (t *Transport) upgradeSession(peerSession *session.PeerSession) error {
+	policy := t.certificatePolicy.Load()
+	if !peerSession.IsAuthenticated || peerSession.Anonymous || peerSession.CertificateGeneration >= policy.rechallengeFrom {
+		return nil
+	}
+
+	if t.isKnownPeer(*peerSession.PeerIdentityKey) {
+		peerSession.CertificateGeneration = policy.generation
+		t.sessionManager.UpdateSession(*peerSession)
+		return nil
+	}
+
+	if policy.mode == transport.CertificateUpgradeRevoke {
+		t.sessionManager.RemoveSession(*peerSession)
+		t.sessionLogger.Info("Session revoked after certificate requirements changed", slog.String("identityKey", *peerSession.PeerIdentityKey))
+		return transport.ErrSessionNotFound
+	}
+
+	if policy.requirements == nil {
+		peerSession.CertificateGeneration = policy.generation
+		t.sessionManager.UpdateSession(*peerSession)
+		return nil
+	}
+
+	peerSession.IsAuthenticated = false
+	t.sessionManager.UpdateSession(*peerSession)
+	t.sessionLogger.Info("Session re-challenged after certificate requirements changed", slog.String("identityKey", *peerSession.PeerIdentityKey))
+	return nil
+}
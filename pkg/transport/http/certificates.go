@@ -0,0 +1,204 @@
+package httptransport
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// certificatePruneInterval is how often the certificates of subjects without a session are forgotten
+const certificatePruneInterval = time.Minute
+
+// certificateRegistry remembers the contents of accepted certificates by subject and serial number,
+// so a peer cannot re-present a modified certificate under a serial number which was already accepted.
+// The keyring is not part of the contents, it is encrypted for each verifier.
+// The certificates of a subject are remembered for as long as it has a session, like the certificates stored
+// in its sessions, and are dropped in the first prune after its last session ended.
+type certificateRegistry struct {
+	mu        sync.Mutex
+	sessions  sessionChecker
+	accepted  map[string]map[string][sha256.Size]byte
+	lastPrune time.Time
+}
+
+func newCertificateRegistry(sessions sessionChecker) *certificateRegistry {
+	return &certificateRegistry{
+		sessions: sessions,
+		accepted: make(map[string]map[string][sha256.Size]byte),
+	}
+}
+
+// check reports whether all certificates were already accepted with the same contents, it fails with
+// transport.CertificateErrors listing every serial number accepted or submitted twice with different contents
+func (r *certificateRegistry) check(certs []certificates.VerifiableCertificate) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, known, err := r.digests(certs)
+	return known, err
+}
+
+// accept records the certificates, it fails without recording any of them on a conflicting serial number
+func (r *certificateRegistry) accept(certs []certificates.VerifiableCertificate, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now.Sub(r.lastPrune) >= certificatePruneInterval {
+		r.prune(now)
+	}
+
+	digests, _, err := r.digests(certs)
+	if err != nil {
+		return err
+	}
+
+	for i, cert := range certs {
+		serials, ok := r.accepted[cert.Subject]
+		if !ok {
+			serials = make(map[string][sha256.Size]byte)
+			r.accepted[cert.Subject] = serials
+		}
+		serials[cert.SerialNumber] = digests[i]
+	}
+
+	return nil
+}
+
+func (r *certificateRegistry) prune(now time.Time) {
+	for subject := range r.accepted {
+		if !r.sessions.HasSession(subject) {
+			delete(r.accepted, subject)
+		}
+	}
+	r.lastPrune = now
+}
+
+func (r *certificateRegistry) digests(certs []certificates.VerifiableCertificate) ([][sha256.Size]byte, bool, error) {
+	digests := make([][sha256.Size]byte, len(certs))
+	submitted := make(map[string][sha256.Size]byte, len(certs))
+	known := true
+	var errs transport.CertificateErrors
+
+	for i, cert := range certs {
+		contents, err := json.Marshal(cert.Certificate)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to encode certificate, %w", err)
+		}
+		digests[i] = sha256.Sum256(contents)
+
+		key := cert.Subject + " " + cert.SerialNumber
+		if digest, ok := submitted[key]; ok && digest != digests[i] {
+			err := fmt.Errorf("%w: serial number %s submitted twice", transport.ErrCertificateConflict, cert.SerialNumber)
+			errs = append(errs, transport.NewCertificateError(i, cert.SerialNumber, cert.Type, err))
+			continue
+		}
+		submitted[key] = digests[i]
+
+		digest, ok := r.accepted[cert.Subject][cert.SerialNumber]
+		if !ok {
+			known = false
+			continue
+		}
+		if digest != digests[i] {
+			err := fmt.Errorf("%w: serial number %s", transport.ErrCertificateConflict, cert.SerialNumber)
+			errs = append(errs, transport.NewCertificateError(i, cert.SerialNumber, cert.Type, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, false, errs
+	}
+	return digests, known, nil
+}
+
+// joinCertificateErrors merges the transport.CertificateErrors of the checks of a certificate response
+// ordered by the index of the certificates, it returns the first error when it has no certificate errors
+func joinCertificateErrors(first error, others ...error) error {
+	var joined transport.CertificateErrors
+	if !errors.As(first, &joined) {
+		return first
+	}
+	joined = slices.Clone(joined)
+
+	for _, err := range others {
+		var certErrs transport.CertificateErrors
+		if errors.As(err, &certErrs) {
+			joined = append(joined, certErrs...)
+		}
+	}
+
+	slices.SortStableFunc(joined, func(a, b *transport.CertificateError) int {
+		return a.Index - b.Index
+	})
+	return joined
+}
+
+// errCertificatesNotAccepted is reported in verification reports when OnCertificatesReceived does not accept the certificates
+var errCertificatesNotAccepted = errors.New("certificates were not accepted by OnCertificatesReceived")
+
+// bridgeClientCertificate adds the verified X.509 client certificate of the connection to the certificates of the message
+func (t *Transport) bridgeClientCertificate(msg *transport.AuthMessage, req *http.Request, identityKey string, report *transport.VerificationReport) error {
+	if t.x509Bridge == nil || req.TLS == nil || len(req.TLS.VerifiedChains) == 0 {
+		return nil
+	}
+
+	certifier, err := t.serverIdentityKey()
+	if err != nil {
+		return err
+	}
+
+	bridged, err := t.x509Bridge.Bridge(req.TLS, identityKey, certifier)
+	if report.Check(transport.CheckClientCertificate, err) != nil || bridged == nil {
+		return err
+	}
+
+	certificates := append(slices.Clone(*msg.Certificates), *bridged)
+	msg.Certificates = &certificates
+	t.certificatesLogger.Debug("Client certificate bridged", slog.String("identityKey", identityKey), slog.String("serialNumber", bridged.SerialNumber))
+
+	return nil
+}
+
+// adaptCredentials replaces the certificates carrying verifiable credentials with the certificates mapped from them,
+// the message is rejected when one of the credentials cannot be verified
+func (t *Transport) adaptCredentials(msg *transport.AuthMessage, req *http.Request, identityKey string, report *transport.VerificationReport) error {
+	if t.credentialAdapter == nil || !slices.ContainsFunc(*msg.Certificates, isCredential) {
+		return nil
+	}
+
+	certifier, err := t.serverIdentityKey()
+	if err != nil {
+		return err
+	}
+
+	certificates, err := t.credentialAdapter.AdaptAll(req.Context(), *msg.Certificates, identityKey, certifier)
+	if err != nil {
+		return report.Check(transport.CheckCredentials, err)
+	}
+	_ = report.Check(transport.CheckCredentials, nil)
+
+	msg.Certificates = &certificates
+	return nil
+}
+
+func isCredential(cert certificates.VerifiableCertificate) bool {
+	return cert.Type == transport.VerifiableCredentialType
+}
+
+// serverIdentityKey returns the identity key of the server, the default certifier of bridged and adapted certificates
+func (t *Transport) serverIdentityKey() (string, error) {
+	identityKey, err := t.wallet.GetPublicKey(t.privilegedKeys.IdentityKeyArgs(), "")
+	if err != nil {
+		return "", fmt.Errorf("failed to retrieve identity key, %w", err)
+	}
+	return identityKey.PublicKey.ToDERHex(), nil
+}
@@ -0,0 +1,122 @@
+package httptransport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertificateRegistry(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	certificate := func(subject, serialNumber, age, keyring string) certificates.VerifiableCertificate {
+		return certificates.VerifiableCertificate{
+			Certificate: certificates.Certificate{
+				Subject:      subject,
+				SerialNumber: serialNumber,
+				Fields:       map[string]any{"age": age},
+			},
+			Keyring: map[string]string{"age": keyring},
+		}
+	}
+
+	t.Run("certificate with a keyring for another verifier is the same certificate", func(t *testing.T) {
+		// given
+		registry := newCertificateRegistry(sessionSet{"alice": true, "bob": true})
+		require.NoError(t, registry.accept([]certificates.VerifiableCertificate{certificate("alice", "1", "21", "key-a")}, start))
+
+		// when
+		known, err := registry.check([]certificates.VerifiableCertificate{certificate("alice", "1", "21", "key-b")})
+
+		// then
+		require.NoError(t, err)
+		require.True(t, known)
+	})
+
+	t.Run("serial numbers are tracked per subject", func(t *testing.T) {
+		// given
+		registry := newCertificateRegistry(sessionSet{"alice": true, "bob": true})
+		require.NoError(t, registry.accept([]certificates.VerifiableCertificate{certificate("alice", "1", "21", "key")}, start))
+
+		// when
+		known, err := registry.check([]certificates.VerifiableCertificate{certificate("bob", "1", "17", "key")})
+
+		// then
+		require.NoError(t, err)
+		require.False(t, known)
+	})
+
+	t.Run("conflicting submission is not recorded", func(t *testing.T) {
+		// given
+		registry := newCertificateRegistry(sessionSet{"alice": true, "bob": true})
+		require.NoError(t, registry.accept([]certificates.VerifiableCertificate{certificate("alice", "1", "21", "key")}, start))
+
+		// when
+		err := registry.accept([]certificates.VerifiableCertificate{
+			certificate("alice", "2", "21", "key"),
+			certificate("alice", "1", "17", "key"),
+		}, start)
+
+		// then
+		require.ErrorIs(t, err, transport.ErrCertificateConflict)
+		known, err := registry.check([]certificates.VerifiableCertificate{certificate("alice", "2", "21", "key")})
+		require.NoError(t, err)
+		require.False(t, known)
+	})
+
+	t.Run("certificates of subjects without a session are pruned", func(t *testing.T) {
+		// given
+		sessions := sessionSet{"alice": true, "bob": true}
+		registry := newCertificateRegistry(sessions)
+		require.NoError(t, registry.accept([]certificates.VerifiableCertificate{certificate("alice", "1", "21", "key")}, start))
+		require.NoError(t, registry.accept([]certificates.VerifiableCertificate{certificate("bob", "1", "17", "key")}, start))
+		delete(sessions, "bob")
+
+		// when
+		require.NoError(t, registry.accept([]certificates.VerifiableCertificate{certificate("alice", "2", "21", "key")}, start.Add(certificatePruneInterval)))
+
+		// then
+		require.Len(t, registry.accepted, 1)
+		require.Contains(t, registry.accepted, "alice")
+		require.Len(t, registry.accepted["alice"], 2)
+	})
+
+	t.Run("certificates of subjects without a session are kept until the next prune", func(t *testing.T) {
+		// given
+		sessions := sessionSet{"bob": true}
+		registry := newCertificateRegistry(sessions)
+		require.NoError(t, registry.accept([]certificates.VerifiableCertificate{certificate("bob", "1", "17", "key")}, start))
+		delete(sessions, "bob")
+
+		// when
+		known, err := registry.check([]certificates.VerifiableCertificate{certificate("bob", "1", "17", "key")})
+
+		// then
+		require.NoError(t, err)
+		require.True(t, known)
+	})
+
+	t.Run("every conflicting serial number is listed", func(t *testing.T) {
+		// given
+		registry := newCertificateRegistry(sessionSet{"alice": true})
+		require.NoError(t, registry.accept([]certificates.VerifiableCertificate{certificate("alice", "1", "21", "key")}, start))
+
+		// when
+		_, err := registry.check([]certificates.VerifiableCertificate{
+			certificate("alice", "1", "17", "key"),
+			certificate("alice", "2", "21", "key"),
+			certificate("alice", "2", "18", "key"),
+		})
+
+		// then
+		var certErrs transport.CertificateErrors
+		require.ErrorAs(t, err, &certErrs)
+		require.Len(t, certErrs, 2)
+		require.Equal(t, 0, certErrs[0].Index)
+		require.Equal(t, "1", certErrs[0].SerialNumber)
+		require.Equal(t, 2, certErrs[1].Index)
+		require.Equal(t, transport.ErrCodeCertificateConflict, certErrs[1].Code)
+	})
+}
@@ -0,0 +1,44 @@
+package httptransport
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// coSignerFor returns the wallet co-signing the response to the request, nil when the response is only signed by the server
+func (t *Transport) coSignerFor(req *http.Request) wallet.WalletInterface {
+	if t.coSigner == nil {
+		return nil
+	}
+	return t.coSigner(req)
+}
+
+// coSign signs the response payload with the wallet of the co-signer for the peer, with the key ID of the server
+// signature, so the peer verifies the co-signature like the signature of the server
+func (t *Transport) coSign(ctx context.Context, coSigner wallet.WalletInterface, identityKey, keyID string, payload []byte) ([]byte, error) {
+	key, err := ec.PublicKeyFromString(identityKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse identity key, %w", err)
+	}
+
+	result, err := t.walletSignWith(ctx, coSigner, &wallet.CreateSignatureArgs{
+		EncryptionArgs: wallet.EncryptionArgs{
+			ProtocolID: wallet.DefaultAuthProtocol,
+			Counterparty: wallet.Counterparty{
+				Type:         wallet.CounterpartyTypeOther,
+				Counterparty: key,
+			},
+			KeyID: keyID,
+		},
+		Data: payload,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create co-signature, %w", err)
+	}
+
+	return result.Signature.Serialize(), nil
+}
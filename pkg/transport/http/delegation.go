@@ -0,0 +1,43 @@
+package httptransport
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// verifyDelegation returns the delegation presented in the initial request once it is verified for the identity key
+// of the peer, nil when the peer presented none or delegations are not accepted
+func (t *Transport) verifyDelegation(ctx context.Context, msg *transport.AuthMessage) (*transport.Delegation, error) {
+	if t.delegations == nil {
+		return nil, nil
+	}
+
+	delegation, err := transport.DelegationOf(msg)
+	if err != nil || delegation == nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := delegation.Verify(msg.IdentityKey, now); err != nil {
+		return nil, err
+	}
+	if err := t.delegations.Check(ctx, *delegation, now); err != nil {
+		return nil, err
+	}
+
+	t.sessionLogger.Debug("Delegation accepted", slog.String("identityKey", msg.IdentityKey),
+		slog.String("principal", delegation.Principal), slog.Time("expiry", delegation.Expiry))
+	return delegation, nil
+}
+
+// checkDelegation rejects requests of delegated sessions whose delegation expired or was revoked since the handshake
+func (t *Transport) checkDelegation(ctx context.Context, peerSession *session.PeerSession) error {
+	if peerSession.Delegation == nil || t.delegations == nil {
+		return nil
+	}
+	return t.delegations.Check(ctx, *peerSession.Delegation, time.Now())
+}
@@ -0,0 +1,94 @@
+package httptransport
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// abortedExchangeRetention is how long aborted certificate exchanges are remembered,
+// peers sending their certificates later find no session
+const abortedExchangeRetention = 10 * time.Minute
+
+// abortedExchange is a certificate exchange aborted by the server, the session is kept to sign the ExchangeAborted message
+type abortedExchange struct {
+	session   session.PeerSession
+	reason    transport.AbortReason
+	abortedAt time.Time
+}
+
+// abortedExchanges remembers aborted certificate exchanges by session nonce, so the certificate responses of their peers
+// are answered with the reason instead of an unknown session
+type abortedExchanges struct {
+	mu        sync.Mutex
+	exchanges map[string]abortedExchange
+	lastPrune time.Time
+}
+
+func newAbortedExchanges() *abortedExchanges {
+	return &abortedExchanges{exchanges: make(map[string]abortedExchange)}
+}
+
+func (a *abortedExchanges) add(peerSession session.PeerSession, reason transport.AbortReason, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if now.Sub(a.lastPrune) >= abortedExchangeRetention {
+		for nonce, exchange := range a.exchanges {
+			if now.Sub(exchange.abortedAt) >= abortedExchangeRetention {
+				delete(a.exchanges, nonce)
+			}
+		}
+		a.lastPrune = now
+	}
+
+	a.exchanges[*peerSession.SessionNonce] = abortedExchange{session: peerSession, reason: reason, abortedAt: now}
+}
+
+func (a *abortedExchanges) get(sessionNonce string, now time.Time) (abortedExchange, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	exchange, ok := a.exchanges[sessionNonce]
+	if !ok || now.Sub(exchange.abortedAt) >= abortedExchangeRetention {
+		return abortedExchange{}, false
+	}
+	return exchange, true
+}
+
+// AbortCertificateExchanges implements TransportInterface. Pending sessions can only be enumerated in session managers
+// implementing session.Lister, otherwise only the exchanges being processed are aborted.
+func (t *Transport) AbortCertificateExchanges(reason transport.AbortReason) int {
+	lister, ok := t.sessionManager.(session.Lister)
+	if !ok {
+		t.sessionLogger.Warn("Session manager cannot list sessions, pending certificate exchanges are not aborted")
+		return 0
+	}
+
+	now := time.Now()
+	aborted := 0
+	for _, peerSession := range lister.Sessions() {
+		if peerSession.IsAuthenticated || peerSession.SessionNonce == nil || peerSession.PeerIdentityKey == nil {
+			continue
+		}
+		t.abortedExchanges.add(peerSession, reason, now)
+		t.sessionManager.RemoveSession(peerSession)
+		aborted++
+	}
+
+	t.sessionLogger.Info("Certificate exchanges aborted", slog.String("reason", string(reason)), slog.Int("count", aborted))
+	return aborted
+}
+
+// abortedExchangeError returns the error answering a certificate response of an aborted exchange,
+// carrying the ExchangeAborted message signed with the session of the exchange
+func (t *Transport) abortedExchangeError(exchange abortedExchange) error {
+	msg, err := t.signSessionMessage(&exchange.session, transport.ExchangeAborted, []byte(exchange.reason))
+	if err != nil {
+		return err
+	}
+	return &transport.ExchangeAbortedError{Reason: exchange.reason, Message: msg}
+}
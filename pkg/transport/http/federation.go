@@ -0,0 +1,45 @@
+package httptransport
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// handleAllowlistExchange pins the allowlist of a federation partner in the trust registry
+// and responds with the allowlist of the deployment
+func (t *Transport) handleAllowlistExchange(msg *transport.AuthMessage) (*transport.AuthMessage, error) {
+	if t.trustRegistry == nil {
+		return nil, fmt.Errorf("%w: %s", transport.ErrUnsupportedMessageType, msg.MessageType)
+	}
+
+	allowlist, err := transport.AllowlistOf(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := t.trustRegistry.Partner(msg.IdentityKey); !ok {
+		return nil, transport.ErrFederationNotAllowed
+	}
+
+	now := time.Now()
+	session, err := t.verifySessionMessage(msg, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.trustRegistry.Pin(*session.PeerIdentityKey, *allowlist, now); err != nil {
+		return nil, err
+	}
+	t.logger.Info("Pinned allowlist of federation partner", slog.String("identityKey", *session.PeerIdentityKey),
+		slog.String("organization", allowlist.Organization), slog.Int("serviceKeys", len(allowlist.ServiceKeys)))
+
+	payload, err := json.Marshal(t.trustRegistry.Local())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode allowlist, %w", err)
+	}
+	return t.signSessionMessage(session, transport.AllowlistExchange, payload)
+}
@@ -0,0 +1,116 @@
+package httptransport
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// HandleHeartbeat implements TransportInterface
+func (t *Transport) HandleHeartbeat(msg *transport.AuthMessage) (*transport.AuthMessage, error) {
+	if msg.Version != transport.AuthVersion {
+		return nil, transport.ErrUnsupportedVersion
+	}
+
+	sentAt, err := transport.HeartbeatTime(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if skew := now.Sub(sentAt); skew > transport.MaxHeartbeatSkew || skew < -transport.MaxHeartbeatSkew {
+		return nil, transport.ErrStaleHeartbeat
+	}
+
+	session, err := t.verifySessionMessage(msg, now)
+	if err != nil {
+		return nil, err
+	}
+
+	session.LastUpdate = now
+	t.sessionManager.UpdateSession(*session)
+
+	return t.signSessionMessage(session, transport.Heartbeat, *msg.Payload)
+}
+
+// verifySessionMessage verifies a message signed with the session like a heartbeat and returns the session of its sender,
+// the nonce of the message is remembered to reject replays
+func (t *Transport) verifySessionMessage(msg *transport.AuthMessage, now time.Time) (*session.PeerSession, error) {
+	if err := t.verifyNonce(context.Background(), *msg.YourNonce); err != nil {
+		return nil, err
+	}
+
+	peerSession, err := t.getBoundSession(*msg.YourNonce, msg.IdentityKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if !peerSession.IsAuthenticated && !t.allowUnauthenticated {
+		return nil, transport.ErrSessionNotAuthenticated
+	}
+
+	signature, err := ec.ParseSignature(*msg.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("%w, %w", transport.ErrInvalidSignature, err)
+	}
+
+	key, err := ec.PublicKeyFromString(*peerSession.PeerIdentityKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse identity key, %w", err)
+	}
+
+	if err := t.verifySignature(context.Background(), &wallet.VerifySignatureArgs{
+		EncryptionArgs: t.privilegedKeys.Apply(transport.SessionMessageEncryptionArgs(key, *msg.Nonce, *msg.YourNonce)),
+		Signature:      *signature,
+		Data:           *msg.Payload,
+	}); err != nil {
+		return nil, err
+	}
+
+	if t.replayGuard.record(*peerSession.SessionNonce, *msg.Nonce, now) {
+		t.logger.Warn("Rejected replayed session message", slog.String("messageType", string(msg.MessageType)), slog.String("nonce", *msg.Nonce))
+		return nil, transport.ErrRequestReplayed
+	}
+
+	return peerSession, nil
+}
+
+// signSessionMessage creates the response of the type to a message verified with verifySessionMessage,
+// signed with the session like the message
+func (t *Transport) signSessionMessage(peerSession *session.PeerSession, messageType transport.MessageType, payload []byte) (*transport.AuthMessage, error) {
+	identityKey, err := t.wallet.GetPublicKey(t.privilegedKeys.IdentityKeyArgs(), "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve identity key, %w", err)
+	}
+
+	nonce, err := t.createNonce(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create nonce, %w", err)
+	}
+
+	peerNonce := ""
+	if peerSession.PeerNonce != nil {
+		peerNonce = *peerSession.PeerNonce
+	}
+
+	signature, err := t.createSignature(context.Background(), *peerSession.PeerIdentityKey, fmt.Sprintf("%s %s", nonce, peerNonce), payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return &transport.AuthMessage{
+		Version:     transport.AuthVersion,
+		MessageType: messageType,
+		IdentityKey: identityKey.PublicKey.ToDERHex(),
+		Nonce:       &nonce,
+		YourNonce:   &peerNonce,
+		Payload:     &payload,
+		Signature:   &signature,
+	}, nil
+}
@@ -0,0 +1,32 @@
+package httptransport
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+)
+
+// verifyRequestSignature verifies the signature of a general request over its payload. A lenient transport whose
+// normalization changed the path verifies an invalid signature again over the path as received, so clients which
+// sign it strictly are accepted as long as their path is not rewritten.
+func (t *Transport) verifyRequestSignature(req *http.Request, args *wallet.VerifySignatureArgs) error {
+	err := t.verifySignature(req.Context(), args)
+	if err == nil || !errors.Is(err, transport.ErrInvalidSignature) || t.pathNormalization == authcore.PathStrict ||
+		authcore.NormalizePath(req.URL.Path, t.pathNormalization) == req.URL.Path {
+		return err
+	}
+
+	strict, buildErr := buildAuthMessageFromRequest(req, authcore.PathStrict)
+	if buildErr != nil {
+		return err
+	}
+	strictArgs := *args
+	strictArgs.Data = *strict.Payload
+	if t.verifySignature(req.Context(), &strictArgs) != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package httptransport
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// checkHeaderTimeout rejects requests which took longer than the header timeout to arrive with their headers
+func (t *Transport) checkHeaderTimeout(req *http.Request) error {
+	timeout, tracker := t.readTimeouts.Header, t.readTimeouts.Tracker
+	if timeout <= 0 || tracker == nil {
+		return nil
+	}
+
+	arrival, ok := tracker.Arrival(req)
+	if !ok || time.Since(arrival) <= timeout {
+		return nil
+	}
+	return &transport.ReadTimeoutError{Stage: transport.ReadStageHeader, Timeout: timeout}
+}
+
+// withBodyTimeout runs read with the read deadline of the connection set to the body timeout. The deadline is
+// lifted afterwards, so the handler is not limited by it. Writers which cannot set read deadlines leave the body unlimited.
+func (t *Transport) withBodyTimeout(res http.ResponseWriter, read func() error) error {
+	timeout := t.readTimeouts.Body
+	if timeout <= 0 {
+		return read()
+	}
+
+	controller := http.NewResponseController(res)
+	if err := controller.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		t.logger.Debug("Read deadline cannot be set, body timeout is not enforced", slog.String("error", err.Error()))
+		return read()
+	}
+	defer func() {
+		_ = controller.SetReadDeadline(time.Time{})
+	}()
+
+	err := read()
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return &transport.ReadTimeoutError{Stage: transport.ReadStageBody, Timeout: timeout}
+	}
+	return err
+}
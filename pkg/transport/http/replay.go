@@ -0,0 +1,81 @@
+package httptransport
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultReplayWindow is the interval in which request IDs of ended sessions are forgotten when no window is configured
+const DefaultReplayWindow = 10 * time.Minute
+
+// replayGuard remembers the request IDs of verified general requests, so a captured request cannot be sent again.
+// Every attempt of a request, including retries, must use a fresh request ID.
+// Requests are signed for the nonce of their session, so their IDs are remembered for as long as the session exists
+// and a request cannot be replayed once it is forgotten. The IDs of a session are dropped in the first prune
+// after the session ended, at least window after they were seen.
+type replayGuard struct {
+	mu        sync.Mutex
+	window    time.Duration
+	sessions  sessionChecker
+	seen      map[replayKey]time.Time
+	lastPrune time.Time
+}
+
+// sessionChecker reports whether the session of the nonce still exists
+type sessionChecker interface {
+	HasSession(identifier string) bool
+}
+
+type replayKey struct {
+	sessionNonce string
+	requestID    string
+}
+
+func newReplayGuard(window time.Duration, sessions sessionChecker) *replayGuard {
+	if window <= 0 {
+		window = DefaultReplayWindow
+	}
+
+	return &replayGuard{
+		window:   window,
+		sessions: sessions,
+		seen:     make(map[replayKey]time.Time),
+	}
+}
+
+// record stores the request ID signed for the session and reports whether it was already seen
+func (g *replayGuard) record(sessionNonce, requestID string, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if now.Sub(g.lastPrune) >= g.window {
+		g.prune(now)
+	}
+
+	key := replayKey{sessionNonce: sessionNonce, requestID: requestID}
+	if _, ok := g.seen[key]; ok {
+		return true
+	}
+
+	g.seen[key] = now
+	return false
+}
+
+func (g *replayGuard) prune(now time.Time) {
+	ended := make(map[string]bool)
+	for key, seenAt := range g.seen {
+		if now.Sub(seenAt) < g.window {
+			continue
+		}
+
+		gone, checked := ended[key.sessionNonce]
+		if !checked {
+			gone = !g.sessions.HasSession(key.sessionNonce)
+			ended[key.sessionNonce] = gone
+		}
+		if gone {
+			delete(g.seen, key)
+		}
+	}
+	g.lastPrune = now
+}
@@ -0,0 +1,104 @@
+package httptransport
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type sessionSet map[string]bool
+
+func (s sessionSet) HasSession(identifier string) bool {
+	return s[identifier]
+}
+
+func TestReplayGuard(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	window := 10 * time.Minute
+
+	t.Run("request ID is rejected when sent again", func(t *testing.T) {
+		// given
+		guard := newReplayGuard(window, sessionSet{"session": true})
+		require.False(t, guard.record("session", "request", start))
+
+		// when
+		replayed := guard.record("session", "request", start.Add(time.Second))
+
+		// then
+		require.True(t, replayed)
+	})
+
+	t.Run("request ID is rejected after the window while the session exists", func(t *testing.T) {
+		// given
+		guard := newReplayGuard(window, sessionSet{"session": true})
+		require.False(t, guard.record("session", "request", start))
+
+		// when
+		replayed := guard.record("session", "request", start.Add(3*window))
+
+		// then
+		require.True(t, replayed)
+	})
+
+	t.Run("request ID of another session is accepted", func(t *testing.T) {
+		// given
+		guard := newReplayGuard(window, sessionSet{"session": true, "other": true})
+		require.False(t, guard.record("session", "request", start))
+
+		// when
+		replayed := guard.record("other", "request", start.Add(time.Second))
+
+		// then
+		require.False(t, replayed)
+	})
+
+	t.Run("request IDs of ended sessions are pruned", func(t *testing.T) {
+		// given
+		sessions := sessionSet{"session": true, "ended": true}
+		guard := newReplayGuard(window, sessions)
+		require.False(t, guard.record("session", "request", start))
+		require.False(t, guard.record("ended", "request", start))
+		delete(sessions, "ended")
+
+		// when
+		require.False(t, guard.record("session", "next", start.Add(window)))
+
+		// then
+		require.Len(t, guard.seen, 2)
+		require.Contains(t, guard.seen, replayKey{sessionNonce: "session", requestID: "request"})
+		require.NotContains(t, guard.seen, replayKey{sessionNonce: "ended", requestID: "request"})
+	})
+}
+
+func TestReplayGuard_WindowBoundary(t *testing.T) {
+	const window = time.Minute
+	seenAt := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		replayAt time.Duration
+		replayed bool
+	}{
+		{name: "replay of an ended session within the window", replayAt: window - time.Nanosecond, replayed: true},
+		{name: "replay of an ended session exactly at the end of the window", replayAt: window, replayed: false},
+		{name: "replay of an ended session after the window", replayAt: window + time.Second, replayed: false},
+		{name: "replay with the clock set back", replayAt: -time.Hour, replayed: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// given
+			sessions := sessionSet{"session": true}
+			guard := newReplayGuard(window, sessions)
+			require.False(t, guard.record("session", "request-id", seenAt))
+			delete(sessions, "session")
+
+			// when
+			replayed := guard.record("session", "request-id", seenAt.Add(test.replayAt))
+
+			// then
+			require.Equal(t, test.replayed, replayed)
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package httptransport
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// checkRevoked rejects the subject with ErrRevoked when it was revoked
+func (t *Transport) checkRevoked(ctx context.Context, subject transport.RevocationSubject) error {
+	revoked, err := t.revocations.IsRevoked(ctx, subject)
+	if err != nil {
+		return err //nolint:wrapcheck // the error of the revocation list keeps its code
+	}
+	if revoked {
+		return transport.ErrRevoked
+	}
+	return nil
+}
+
+// checkSessionRevoked rejects requests of sessions whose identity key or session nonce was revoked,
+// the session is removed so it cannot be used again once the revocation is lifted
+func (t *Transport) checkSessionRevoked(ctx context.Context, peerSession *session.PeerSession) error {
+	if t.revocations == nil {
+		return nil
+	}
+
+	err := t.checkRevoked(ctx, transport.RevocationSubject{Kind: transport.RevokedIdentity, Value: *peerSession.PeerIdentityKey})
+	if err == nil && peerSession.SessionNonce != nil {
+		err = t.checkRevoked(ctx, transport.RevocationSubject{Kind: transport.RevokedSession, Value: *peerSession.SessionNonce})
+	}
+	if errors.Is(err, transport.ErrRevoked) {
+		t.sessionManager.RemoveSession(*peerSession)
+		t.sessionLogger.Info("Revoked session removed", slog.String("identityKey", *peerSession.PeerIdentityKey))
+	}
+	return err
+}
@@ -0,0 +1,64 @@
+package httptransport
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-sdk/chainhash"
+)
+
+// checkRevocation rejects the certificates whose revocation outpoint was spent, with transport.CertificateErrors
+// listing every rejected certificate. The check fails closed, a certificate whose outpoint cannot be parsed or
+// looked up is not accepted.
+func (t *Transport) checkRevocation(ctx context.Context, certificates []certificates.VerifiableCertificate) error {
+	if t.revocationTracker == nil {
+		return nil
+	}
+
+	var errs transport.CertificateErrors
+	for i, certificate := range certificates {
+		txID, vout, err := parseOutpoint(certificate.Certificate.RevocationOutpoint)
+		if err != nil {
+			err = fmt.Errorf("invalid revocation outpoint, %w", err)
+			errs = append(errs, transport.NewCertificateError(i, certificate.Certificate.SerialNumber, certificate.Certificate.Type, err))
+			continue
+		}
+
+		spent, err := t.revocationTracker.IsOutputSpent(ctx, txID, vout)
+		if err != nil {
+			return fmt.Errorf("failed to check revocation outpoint of certificate %s, %w", certificate.Certificate.SerialNumber, err)
+		}
+		if spent {
+			errs = append(errs, transport.NewCertificateError(i, certificate.Certificate.SerialNumber, certificate.Certificate.Type, transport.ErrCertificateRevoked))
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// parseOutpoint parses an outpoint in the txid.vout format
+func parseOutpoint(outpoint string) (*chainhash.Hash, uint32, error) {
+	txIDHex, voutText, ok := strings.Cut(outpoint, ".")
+	if !ok {
+		return nil, 0, fmt.Errorf("outpoint %q is not in the txid.vout format", outpoint)
+	}
+
+	txID, err := chainhash.NewHashFromHex(txIDHex)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse txid, %w", err)
+	}
+
+	vout, err := strconv.ParseUint(voutText, 10, 32)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to parse vout, %w", err)
+	}
+
+	return txID, uint32(vout), nil
+}
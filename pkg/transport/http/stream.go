@@ -0,0 +1,71 @@
+package httptransport
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+)
+
+// StreamResponse implements TransportInterface. Responses of sessions with payload encryption or padding
+// and responses to HEAD requests are not streamed, as their bodies are transformed or dropped before they are sent.
+func (t *Transport) StreamResponse(req *http.Request, res http.ResponseWriter, status int, msg *transport.AuthMessage) (func(bodyHash []byte) error, error) {
+	if t.allowUnauthenticated || req.Method == http.MethodHead {
+		return nil, nil
+	}
+
+	identityKey, requestID, err := getValuesFromContext(req)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionNonce, _ := req.Context().Value(transport.SessionNonce).(string)
+	session, err := t.getBoundSession(sessionNonce, identityKey)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.Contains(session.Capabilities, transport.CapabilityTrailerSignature) || session.PayloadEncryption || session.PayloadPadding ||
+		t.coSignerFor(req) != nil {
+		return nil, nil
+	}
+
+	nonce, err := t.createNonce(req.Context())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create nonce, %w", err)
+	}
+
+	peerNonce := ""
+	if session.PeerNonce != nil {
+		peerNonce = *session.PeerNonce
+	}
+	signatureKey := fmt.Sprintf("%s %s", nonce, peerNonce)
+
+	if t.serverInfo != "" {
+		res.Header().Set(utils.ServerInfoHeader, t.serverInfo)
+	}
+
+	msg.Nonce = &nonce
+	msg.Signature = nil
+	setupHeaders(res, msg, requestID)
+	res.Header().Del("Content-Length")
+	res.Header().Set("Trailer", signatureHeader)
+	header := res.Header().Clone()
+
+	return func(bodyHash []byte) error {
+		payload, err := utils.BuildStreamedResponsePayload(requestID, status, header, bodyHash)
+		if err != nil {
+			return err
+		}
+
+		signature, err := t.createSignature(req.Context(), identityKey, signatureKey, payload)
+		if err != nil {
+			return err
+		}
+
+		res.Header().Set(signatureHeader, hex.EncodeToString(signature))
+		return nil
+	}, nil
+}
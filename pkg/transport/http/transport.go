@@ -8,15 +8,23 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/chaintracker"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/defs"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/events"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/internal/logging"
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/sessionmanager"
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
 	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 )
 
@@ -32,46 +40,207 @@ const (
 	messageTypeHeader = authHeaderPrefix + "message-type"
 )
 
+// Limits of non general messages, larger messages are rejected before they are processed
+const (
+	// MaxAuthMessageSize is the maximum size of a non general message body
+	MaxAuthMessageSize = 1 << 20
+	// MaxNonceSize is the maximum size of a decoded nonce
+	MaxNonceSize = authcore.MaxNonceSize
+	// DefaultMinNonceSize is the minimum size of a decoded peer nonce when no minimum is configured
+	DefaultMinNonceSize = authcore.DefaultMinNonceSize
+	// MaxAuthMessageDepth is the maximum nesting depth of the objects and arrays of a non general message
+	MaxAuthMessageDepth = transport.MaxMessageDepth
+	// MaxAuthMessageFields is the maximum number of object fields of a non general message
+	MaxAuthMessageFields = transport.MaxMessageFields
+	// MaxCertificates is the maximum number of certificates of a certificate response, compressed or not
+	MaxCertificates = transport.MaxCertificates
+)
+
+// Config configures the HTTP transport
+type Config struct {
+	Wallet                 wallet.WalletInterface
+	SessionManager         session.Manager
+	AllowUnauthenticated   bool
+	Logger                 *slog.Logger
+	CertificatesToRequest  *transport.RequestedCertificateSet
+	OnCertificatesReceived transport.OnCertificatesReceivedFunc
+	// EncryptPayloads enables encryption of general message bodies for sessions which requested it during the handshake
+	EncryptPayloads bool
+	// PadPayloads enables padding of general message bodies to size buckets for sessions which requested it during the handshake
+	PadPayloads bool
+	// RedactionPolicy masks certificate fields before messages are logged, defaults to hashing every field with a random key
+	RedactionPolicy *transport.CertificateRedactionPolicy
+	// ReplayWindow is the interval in which the request IDs remembered to reject replayed requests are pruned, the IDs
+	// are kept until their session ends. Defaults to DefaultReplayWindow.
+	ReplayWindow time.Duration
+	// RevocationTracker rejects certificates whose revocation outpoint was spent, nil disables the check
+	RevocationTracker chaintracker.Interface
+	// MinNonceSize is the minimum size of decoded peer nonces, defaults to DefaultMinNonceSize
+	MinNonceSize int
+	// AnonymousSessions marks sessions of peers authenticating with the anyone key as anonymous,
+	// they are authenticated without certificate requests and their requests are marked in the context
+	AnonymousSessions bool
+	// ServerInfo is sent in the signed x-bsv-auth-server header of general responses, empty omits the header
+	ServerInfo string
+	// WalletTimeouts limits the wallet operations, timed out messages are rejected with ErrWalletTimeout
+	WalletTimeouts transport.WalletTimeouts
+	// ReadTimeouts limits how long peers may take to send messages, late messages are rejected with ErrReadTimeout
+	ReadTimeouts transport.ReadTimeouts
+	// OriginBinding configures the origins general requests may be bound to, requests are not bound when it is empty
+	OriginBinding transport.OriginBinding
+	// PrivilegedKeys makes every wallet call of the auth protocol use the privileged keyring of the wallet
+	PrivilegedKeys transport.PrivilegedKeys
+	// X509Bridge adds the verified X.509 client certificate of mTLS connections to the certificates sent by peers
+	X509Bridge *transport.X509Bridge
+	// CredentialAdapter verifies verifiable credentials sent as certificates and maps them into certificates
+	CredentialAdapter *transport.CredentialAdapter
+	// StrictDisclosure rejects certificates whose keyring does not reveal exactly the requested fields of their type
+	StrictDisclosure bool
+	// KnownPeers are authenticated in the handshake without certificate requests
+	KnownPeers *transport.KnownPeers
+	// TrustRegistry accepts allowlist exchanges of federation partners, the partners and the service keys they pin
+	// are treated as known peers
+	TrustRegistry *transport.TrustRegistry
+	// FeatureFlags gate the negotiation of experimental capabilities per identity, every capability is negotiated when nil
+	FeatureFlags *transport.FeatureFlags
+	// CertificateCompressions are the compressions accepted for the certificates of certificate responses,
+	// negotiated with peers offering them in the handshake. Compressed certificates are rejected when empty.
+	CertificateCompressions []transport.CertificateCompression
+	// MaxCertificatesSize limits the size of decompressed certificates, defaults to transport.DefaultMaxCertificatesSize
+	MaxCertificatesSize int
+	// OnInitialResponse is called with the created session and the initialResponse before it is sent,
+	// only the extensions it sets are added to the handshake response
+	OnInitialResponse func(ctx context.Context, session session.PeerSession, msg *transport.AuthMessage)
+	// Events receives the session events of the handshakes, see events.TopicSession
+	Events *events.Bus
+	// OnVerificationReport receives the verification report of every certificate response of an established session
+	OnVerificationReport func(req *http.Request, report *transport.VerificationReport)
+	// Revocations are checked for the identity key of every handshake and the identity key and session nonce of every
+	// general request, so revoked peers are cut off right away. Nothing is revoked when nil.
+	Revocations *transport.RevocationList
+	// PathNormalization is the normalization of the path in the signed payload of general requests.
+	// Lenient transports also accept signatures over the path as received, so clients signing it strictly keep working.
+	PathNormalization authcore.PathNormalization
+	// WebSocketOrigins lists the origins of the pages allowed to open WebSockets, upgrades signed with another Origin
+	// header are rejected with ErrWebSocketOriginNotAllowed. Upgrades without Origin, i.e. not sent by browsers,
+	// and upgrades of every origin are accepted when empty.
+	WebSocketOrigins []string
+	// Delegations accepts peers authenticating with an agent key on behalf of a principal identity,
+	// delegations presented in the handshake are ignored when nil
+	Delegations *transport.DelegationPolicy
+	// CoSigner returns the wallet of the tenant identity co-signing the response to the request, responses are only
+	// signed by the server when nil or when it returns nil. Co-signed responses are not streamed.
+	CoSigner func(req *http.Request) wallet.WalletInterface
+	// Logging configures levels and debug sampling of the transport, session and certificates subsystems
+	Logging defs.LogConfig
+}
+
 // Transport implements the HTTP transport
 type Transport struct {
-	wallet                  wallet.WalletInterface
-	sessionManager          sessionmanager.SessionManagerInterface
-	allowUnauthenticated    bool
-	logger                  *slog.Logger
-	certificateRequirements *transport.RequestedCertificateSet
-	onCertificatesReceived  func(
-		senderPublicKey string,
-		certs *[]wallet.VerifiableCertificate,
-		req *http.Request,
-		res http.ResponseWriter,
-		next func(),
-	)
+	wallet                 wallet.WalletInterface
+	sessionManager         session.Manager
+	allowUnauthenticated   bool
+	encryptPayloads        bool
+	padPayloads            bool
+	logger                 *slog.Logger
+	sessionLogger          *slog.Logger
+	certificatesLogger     *slog.Logger
+	certificatePolicy      atomic.Pointer[certificatePolicy]
+	certificatePolicyMu    sync.Mutex
+	onCertificatesReceived transport.OnCertificatesReceivedFunc
+	redactionPolicy        *transport.CertificateRedactionPolicy
+	replayGuard            *replayGuard
+	revocationTracker      chaintracker.Interface
+	certificateRegistry    *certificateRegistry
+	minNonceSize           int
+	walletTimeouts         transport.WalletTimeouts
+	readTimeouts           transport.ReadTimeouts
+	privilegedKeys         transport.PrivilegedKeys
+	originBinding          transport.OriginBinding
+	serverInfo             string
+	anonymousSessions      bool
+	x509Bridge             *transport.X509Bridge
+	credentialAdapter      *transport.CredentialAdapter
+	strictDisclosure       bool
+	knownPeers             *transport.KnownPeers
+	trustRegistry          *transport.TrustRegistry
+	featureFlags           *transport.FeatureFlags
+	abortedExchanges       *abortedExchanges
+	compressions           []transport.CertificateCompression
+	maxCertificatesSize    int
+	events                 *events.Bus
+	onVerificationReport   func(req *http.Request, report *transport.VerificationReport)
+	coSigner               func(req *http.Request) wallet.WalletInterface
+	delegations            *transport.DelegationPolicy
+	revocations            *transport.RevocationList
+	pathNormalization      authcore.PathNormalization
+	webSocketOrigins       []string
+	onInitialResponse      func(ctx context.Context, session session.PeerSession, msg *transport.AuthMessage)
 }
 
 // New creates a new HTTP transport
-func New(
-	wallet wallet.WalletInterface,
-	sessionManager sessionmanager.SessionManagerInterface,
-	allowUnauthenticated bool, logger *slog.Logger,
-	reqCerts *transport.RequestedCertificateSet,
-	OnCertificatesReceived func(
-		senderPublicKey string,
-		certs *[]wallet.VerifiableCertificate,
-		req *http.Request,
-		res http.ResponseWriter,
-		next func(),
-	)) transport.TransportInterface {
-	transportLogger := logging.Child(logger, "http-transport")
-	transportLogger.Info(fmt.Sprintf("Creating HTTP transport with allowUnauthenticated = %t", allowUnauthenticated))
-
-	return &Transport{
-		wallet:                  wallet,
-		sessionManager:          sessionManager,
-		allowUnauthenticated:    allowUnauthenticated,
-		logger:                  transportLogger,
-		certificateRequirements: reqCerts,
-		onCertificatesReceived:  OnCertificatesReceived,
-	}
+func New(cfg Config) transport.TransportInterface {
+	serviceLogger := logging.Child(cfg.Logger, "http-transport")
+	transportLogger := logging.Subsystem(serviceLogger, defs.LogSubsystemTransport, cfg.Logging)
+	transportLogger.Info(fmt.Sprintf("Creating HTTP transport with allowUnauthenticated = %t", cfg.AllowUnauthenticated))
+
+	redactionPolicy := cfg.RedactionPolicy
+	if redactionPolicy == nil {
+		redactionPolicy = transport.DefaultCertificateRedactionPolicy()
+	}
+
+	minNonceSize := cfg.MinNonceSize
+	if minNonceSize <= 0 {
+		minNonceSize = DefaultMinNonceSize
+	}
+
+	maxCertificatesSize := cfg.MaxCertificatesSize
+	if maxCertificatesSize <= 0 {
+		maxCertificatesSize = transport.DefaultMaxCertificatesSize
+	}
+
+	t := &Transport{
+		wallet:                 cfg.Wallet,
+		sessionManager:         cfg.SessionManager,
+		allowUnauthenticated:   cfg.AllowUnauthenticated,
+		encryptPayloads:        cfg.EncryptPayloads,
+		padPayloads:            cfg.PadPayloads,
+		strictDisclosure:       cfg.StrictDisclosure,
+		knownPeers:             cfg.KnownPeers,
+		trustRegistry:          cfg.TrustRegistry,
+		featureFlags:           cfg.FeatureFlags,
+		events:                 cfg.Events,
+		onVerificationReport:   cfg.OnVerificationReport,
+		coSigner:               cfg.CoSigner,
+		delegations:            cfg.Delegations,
+		revocations:            cfg.Revocations,
+		pathNormalization:      cfg.PathNormalization,
+		webSocketOrigins:       cfg.WebSocketOrigins,
+		onInitialResponse:      cfg.OnInitialResponse,
+		logger:                 transportLogger,
+		sessionLogger:          logging.Subsystem(serviceLogger, defs.LogSubsystemSession, cfg.Logging),
+		certificatesLogger:     logging.Subsystem(serviceLogger, defs.LogSubsystemCertificates, cfg.Logging),
+		onCertificatesReceived: cfg.OnCertificatesReceived,
+		redactionPolicy:        redactionPolicy,
+		replayGuard:            newReplayGuard(cfg.ReplayWindow, cfg.SessionManager),
+		revocationTracker:      cfg.RevocationTracker,
+		certificateRegistry:    newCertificateRegistry(cfg.SessionManager),
+		abortedExchanges:       newAbortedExchanges(),
+		compressions:           cfg.CertificateCompressions,
+		maxCertificatesSize:    maxCertificatesSize,
+		minNonceSize:           minNonceSize,
+		walletTimeouts:         cfg.WalletTimeouts,
+		readTimeouts:           cfg.ReadTimeouts,
+		privilegedKeys:         cfg.PrivilegedKeys,
+		originBinding:          cfg.OriginBinding,
+		serverInfo:             cfg.ServerInfo,
+		anonymousSessions:      cfg.AnonymousSessions,
+		x509Bridge:             cfg.X509Bridge,
+		credentialAdapter:      cfg.CredentialAdapter,
+	}
+	t.certificatePolicy.Store(&certificatePolicy{requirements: cfg.CertificatesToRequest})
+
+	return t
 }
 
 // OnData implement Transport TransportInterface
@@ -86,13 +255,27 @@ func (t *Transport) Send(_ transport.AuthMessage) {
 
 // HandleNonGeneralRequest handles incoming non general requests
 func (t *Transport) HandleNonGeneralRequest(req *http.Request, res http.ResponseWriter) error {
-	requestData, err := parseAuthMessage(req)
+	if err := t.checkHeaderTimeout(req); err != nil {
+		t.logger.Error("Request headers received too late", slog.String("error", err.Error()))
+		return err
+	}
+
+	req.Body = http.MaxBytesReader(res, req.Body, MaxAuthMessageSize)
+	var requestData *transport.AuthMessage
+	err := t.withBodyTimeout(res, func() (err error) {
+		requestData, err = parseAuthMessage(req)
+		return err
+	})
 	if err != nil {
 		t.logger.Error("Invalid request body", slog.String("error", err.Error()))
 		return err
 	}
 
-	t.logger.Debug("Received non general request request", slog.Any("data", requestData))
+	t.logger.Debug("Received non general request request", slog.Any("data", t.redactionPolicy.RedactAuthMessage(requestData)))
+
+	if requestData.MessageType == transport.General {
+		return fmt.Errorf("%w: general messages are sent in the auth headers of requests", transport.ErrUnsupportedMessageType)
+	}
 
 	requestID := req.Header.Get(requestIDHeader)
 	if requestID == "" {
@@ -125,21 +308,35 @@ func (t *Transport) HandleGeneralRequest(req *http.Request, res http.ResponseWri
 		}
 		t.logger.Debug("Missing request ID and unauthenticated requests are not allowed")
 
-		return nil, nil, errors.New("missing request ID")
+		return nil, nil, transport.ErrMissingRequestID
 	}
 
 	t.logger.Debug("Received general request", slog.String("requestID", requestID))
 
-	err := checkHeaders(req)
+	if err := t.checkHeaderTimeout(req); err != nil {
+		return nil, nil, err
+	}
+
+	err := t.checkHeaders(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var body []byte
+	err = t.withBodyTimeout(res, func() (err error) {
+		body, err = bufferRequestBody(req)
+		return err
+	})
 	if err != nil {
 		return nil, nil, err
 	}
 
-	requestData, err := buildAuthMessageFromRequest(req)
+	requestData, err := buildAuthMessageFromRequest(req, t.pathNormalization)
 	if err != nil {
 		t.logger.Error("Failed to build request data", slog.String("error", err.Error()))
 		return nil, nil, err
 	}
+	resetRequestBody(req, body)
 
 	response, err := t.handleIncomingMessage(requestData, req, res)
 	if err != nil {
@@ -148,34 +345,44 @@ func (t *Transport) HandleGeneralRequest(req *http.Request, res http.ResponseWri
 	}
 
 	req = setupContext(req, requestData, requestID)
+	if session := t.sessionManager.GetSession(*requestData.YourNonce); session != nil {
+		req = req.WithContext(context.WithValue(req.Context(), transport.NegotiatedCapabilities, session.Capabilities))
+		if session.Delegation != nil {
+			req = req.WithContext(context.WithValue(req.Context(), transport.Principal, session.Delegation.Principal))
+		}
+	}
+	if t.anonymousSessions && transport.IsAnyoneIdentityKey(requestData.IdentityKey) {
+		req = req.WithContext(context.WithValue(req.Context(), transport.Anonymous, true))
+	}
 
 	return req, response, nil
 }
 
-// HandleResponse sets up auth headers in the response object and generate signature for whole response
-func (t *Transport) HandleResponse(req *http.Request, res http.ResponseWriter, body []byte, status int, msg *transport.AuthMessage) error {
+// HandleResponse sets up auth headers in the response object and generate signature for whole response.
+// It returns the response body which should be sent to the peer. Responses to HEAD requests are signed with
+// an absent body, as the body written by the handler never reaches the peer.
+func (t *Transport) HandleResponse(req *http.Request, res http.ResponseWriter, body []byte, status int, msg *transport.AuthMessage) ([]byte, error) {
 	if t.allowUnauthenticated {
-		return nil
+		return body, nil
+	}
+	if req.Method == http.MethodHead {
+		body = nil
 	}
 
 	identityKey, requestID, err := getValuesFromContext(req)
 	if err != nil {
-		return err
-	}
-
-	session := t.sessionManager.GetSession(identityKey)
-	if session == nil {
-		return errors.New("session not found")
+		return nil, err
 	}
 
-	payload, err := buildResponsePayload(requestID, status, body)
+	sessionNonce, _ := req.Context().Value(transport.SessionNonce).(string)
+	session, err := t.getBoundSession(sessionNonce, identityKey)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	nonce, err := t.wallet.CreateNonce(req.Context())
+	nonce, err := t.createNonce(req.Context())
 	if err != nil {
-		return fmt.Errorf("failed to create nonce, %w", err)
+		return nil, fmt.Errorf("failed to create nonce, %w", err)
 	}
 
 	peerNonce := ""
@@ -184,25 +391,82 @@ func (t *Transport) HandleResponse(req *http.Request, res http.ResponseWriter, b
 	}
 	signatureKey := fmt.Sprintf("%s %s", nonce, peerNonce)
 
-	signature, err := t.createSignature(identityKey, signatureKey, payload)
+	if session.PayloadPadding {
+		body = transport.PadPayload(body)
+	}
+
+	if session.PayloadEncryption {
+		body, err = t.encryptBody(req.Context(), identityKey, signatureKey, body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if t.serverInfo != "" {
+		res.Header().Set(utils.ServerInfoHeader, t.serverInfo)
+	}
+
+	coSigner := t.coSignerFor(req)
+	if coSigner != nil {
+		coSignerKey, err := coSigner.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+		if err != nil {
+			return nil, fmt.Errorf("failed to get co-signer identity key, %w", err)
+		}
+		res.Header().Set(utils.CoSignerIdentityKeyHeader, coSignerKey.PublicKey.ToDERHex())
+	}
+
+	payload, err := utils.BuildSignedResponsePayload(requestID, status, res.Header(), body)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	signature, err := t.createSignature(req.Context(), identityKey, signatureKey, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	if coSigner != nil {
+		coSignature, err := t.coSign(req.Context(), coSigner, identityKey, signatureKey, payload)
+		if err != nil {
+			return nil, err
+		}
+		res.Header().Set(utils.CoSignatureHeader, hex.EncodeToString(coSignature))
 	}
 
+	msg.Nonce = &nonce
 	msg.Signature = &signature
 
 	setupHeaders(res, msg, requestID)
-	return nil
+	return body, nil
+}
+
+// Capabilities implements TransportInterface, heartbeats, BLAKE3 digests and trailer signatures are always accepted,
+// payload encryption, padding, allowlist exchanges and certificate compressions when enabled
+func (t *Transport) Capabilities() []transport.Capability {
+	capabilities := []transport.Capability{transport.CapabilityHeartbeat, transport.CapabilityDigestBLAKE3, transport.CapabilityTrailerSignature}
+	if t.encryptPayloads {
+		capabilities = append(capabilities, transport.CapabilityPayloadEncryption)
+	}
+	if t.padPayloads {
+		capabilities = append(capabilities, transport.CapabilityPayloadPadding)
+	}
+	if t.trustRegistry != nil {
+		capabilities = append(capabilities, transport.CapabilityAllowlistExchange)
+	}
+	for _, compression := range t.compressions {
+		capabilities = append(capabilities, transport.CompressionCapability(compression))
+	}
+	return capabilities
 }
 
 func (t *Transport) handleIncomingMessage(msg *transport.AuthMessage, req *http.Request, res http.ResponseWriter) (*transport.AuthMessage, error) {
 	if msg.Version != transport.AuthVersion {
-		return nil, errors.New("unsupported version")
+		return nil, transport.ErrUnsupportedVersion
 	}
 
 	switch msg.MessageType {
 	case transport.InitialRequest:
-		return t.handleInitialRequest(msg)
+		return t.handleInitialRequest(req, msg)
 	case transport.CertificateResponse:
 		result, err := t.handleCertificateResponse(msg, req, res)
 		if err == nil && result == nil {
@@ -212,43 +476,72 @@ func (t *Transport) handleIncomingMessage(msg *transport.AuthMessage, req *http.
 		return result, err
 
 	case transport.InitialResponse, transport.CertificateRequest:
-		return nil, errors.New("not implemented")
+		return nil, fmt.Errorf("%w: %s not implemented", transport.ErrUnsupportedMessageType, msg.MessageType)
 	case transport.General:
 		return t.handleGeneralRequest(msg, req, res)
+	case transport.Heartbeat:
+		return t.HandleHeartbeat(msg)
+	case transport.AllowlistExchange:
+		return t.handleAllowlistExchange(msg)
 	default:
-		return nil, errors.New("unsupported message type")
+		return nil, fmt.Errorf("%w: %s", transport.ErrUnsupportedMessageType, msg.MessageType)
 	}
 }
 
-func (t *Transport) handleInitialRequest(msg *transport.AuthMessage) (*transport.AuthMessage, error) {
-	if msg.IdentityKey == "" && msg.InitialNonce == "" {
-		return nil, errors.New("missing required fields in initial request")
+func (t *Transport) handleInitialRequest(req *http.Request, msg *transport.AuthMessage) (*transport.AuthMessage, error) {
+	ctx := req.Context()
+	if msg.IdentityKey == "" || msg.InitialNonce == "" {
+		return nil, transport.ErrMissingRequiredFields
 	}
 
-	sessionNonce, err := t.wallet.CreateNonce(context.Background())
-	if err != nil {
-		return nil, fmt.Errorf("failed to create session nonce, %w", err)
+	if _, err := ec.PublicKeyFromString(msg.IdentityKey); err != nil {
+		return nil, transport.ErrInvalidIdentityKey
 	}
 
-	authenticated := false
-	if t.certificateRequirements == nil {
-		authenticated = true
+	if err := t.validateNonce(msg.InitialNonce); err != nil {
+		return nil, err
 	}
-	session := sessionmanager.PeerSession{
-		IsAuthenticated: authenticated,
-		SessionNonce:    &sessionNonce,
-		PeerNonce:       &msg.InitialNonce,
-		PeerIdentityKey: &msg.IdentityKey,
-		LastUpdate:      time.Now(),
+
+	if err := t.checkRevoked(ctx, transport.RevocationSubject{Kind: transport.RevokedIdentity, Value: msg.IdentityKey}); err != nil {
+		return nil, err
 	}
-	t.sessionManager.AddSession(session)
 
-	signature, err := t.createNonGeneralAuthSignature(msg.InitialNonce, sessionNonce, msg.IdentityKey)
+	delegation, err := t.verifyDelegation(ctx, msg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create signature, %w", err)
+		return nil, err
 	}
 
-	identityKey, err := t.wallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	sessionNonce, err := t.createNonce(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session nonce, %w", err)
+	}
+
+	anonymous := t.anonymousSessions && transport.IsAnyoneIdentityKey(msg.IdentityKey)
+	known := t.isKnownPeer(msg.IdentityKey)
+	capabilities := t.featureFlags.Capabilities(transport.NegotiateCapabilities(msg.OfferedCapabilities(), t.Capabilities()), msg.IdentityKey)
+	policy := t.certificatePolicy.Load()
+	authenticated := policy.requirements == nil || anonymous || known
+	peerSession := session.PeerSession{
+		IsAuthenticated:       authenticated,
+		SessionNonce:          &sessionNonce,
+		PeerNonce:             &msg.InitialNonce,
+		PeerIdentityKey:       &msg.IdentityKey,
+		LastUpdate:            time.Now(),
+		Capabilities:          capabilities,
+		PayloadEncryption:     slices.Contains(capabilities, transport.CapabilityPayloadEncryption),
+		PayloadPadding:        slices.Contains(capabilities, transport.CapabilityPayloadPadding),
+		Anonymous:             anonymous,
+		CertificateGeneration: policy.generation,
+		Delegation:            delegation,
+	}
+	t.sessionManager.AddSession(peerSession)
+	t.sessionLogger.Debug("Session created", slog.String("identityKey", msg.IdentityKey),
+		slog.Bool("authenticated", authenticated), slog.Bool("anonymous", anonymous), slog.Bool("known", known))
+	t.events.Publish(ctx, events.Event{
+		Topic: events.TopicSession, Type: events.TypeSessionCreated, IdentityKey: msg.IdentityKey, Request: req, Data: peerSession,
+	})
+
+	identityKey, err := t.wallet.GetPublicKey(t.privilegedKeys.IdentityKeyArgs(), "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve identity key, %w", err)
 	}
@@ -259,20 +552,52 @@ func (t *Transport) handleInitialRequest(msg *transport.AuthMessage) (*transport
 		IdentityKey:  identityKey.PublicKey.ToDERHex(),
 		InitialNonce: sessionNonce,
 		YourNonce:    &msg.InitialNonce,
-		Signature:    &signature,
 	}
+	initialResponseMessage.SetCapabilities(capabilities)
 
-	if t.certificateRequirements != nil {
-		initialResponseMessage.RequestedCertificates = *t.certificateRequirements
+	signature, err := t.createNonGeneralAuthSignature(ctx, msg.InitialNonce, sessionNonce, msg.IdentityKey, initialResponseMessage.GrantedCapabilities())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signature, %w", err)
+	}
+	initialResponseMessage.Signature = &signature
+
+	if policy.requirements != nil && !anonymous && !known {
+		initialResponseMessage.RequestedCertificates = *policy.requirements
+	}
+
+	// only the extensions set by the hook are sent, the negotiated fields of the handshake cannot be changed
+	if t.onInitialResponse != nil {
+		augmented := initialResponseMessage
+		t.onInitialResponse(ctx, peerSession, &augmented)
+		initialResponseMessage.Extensions = augmented.Extensions
 	}
 
 	return &initialResponseMessage, nil
 }
 
-func (t *Transport) handleCertificateResponse(msg *transport.AuthMessage, req *http.Request, res http.ResponseWriter) (*transport.AuthMessage, error) {
-	valid, err := t.wallet.VerifyNonce(context.Background(), *msg.YourNonce)
-	if err != nil || !valid {
-		return nil, fmt.Errorf("unable to verify nonce, %w", err)
+// isKnownPeer reports whether the peer was registered as known peer or federation partner, or pinned by a partner
+func (t *Transport) isKnownPeer(identityKey string) bool {
+	if t.knownPeers.Contains(identityKey) || t.trustRegistry.Trusts(identityKey) {
+		return true
+	}
+	_, partner := t.trustRegistry.Partner(identityKey)
+	return partner
+}
+
+func (t *Transport) handleCertificateResponse(msg *transport.AuthMessage, req *http.Request, res http.ResponseWriter) (_ *transport.AuthMessage, err error) {
+	if msg.YourNonce == nil || msg.Signature == nil {
+		return nil, fmt.Errorf("%w: certificate response requires your nonce and signature", transport.ErrMalformedMessage)
+	}
+
+	if err := t.verifyNonce(req.Context(), *msg.YourNonce); err != nil {
+		return nil, err
+	}
+
+	if err := msg.DecompressCertificates(t.compressions, t.maxCertificatesSize); err != nil {
+		return nil, err
+	}
+	if err := msg.CheckCertificateCount(); err != nil {
+		return nil, err
 	}
 
 	if msg.Certificates == nil {
@@ -283,23 +608,38 @@ func (t *Transport) handleCertificateResponse(msg *transport.AuthMessage, req *h
 		return nil, fmt.Errorf("failed to retrieve nonce")
 	}
 
-	payload, err := json.Marshal(*msg.Certificates)
+	if err := t.validateNonce(*msg.Nonce); err != nil {
+		return nil, err
+	}
+
+	payload, err := authcore.CertificatesPayload(*msg.Certificates)
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode certificates, %w", err)
+		return nil, fmt.Errorf("%w: failed to encode certificates, %w", transport.ErrMalformedMessage, err)
 	}
 
-	session := t.sessionManager.GetSession(msg.IdentityKey)
-	if session == nil {
-		return nil, fmt.Errorf("no session found for identity key")
+	session, err := t.getBoundSession(*msg.YourNonce, msg.IdentityKey)
+	if errors.Is(err, transport.ErrSessionNotFound) {
+		if exchange, ok := t.abortedExchanges.get(*msg.YourNonce, time.Now()); ok && *exchange.session.PeerIdentityKey == msg.IdentityKey {
+			return nil, t.abortedExchangeError(exchange)
+		}
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	if session.PeerIdentityKey == nil {
-		return nil, fmt.Errorf("failed to retrieve peer identity key")
+	var report *transport.VerificationReport
+	outcome := transport.VerificationAccepted
+	if t.onVerificationReport != nil {
+		report = transport.NewVerificationReport(*session.PeerIdentityKey, time.Now())
+		defer func() {
+			report.Complete(*msg.Certificates, t.certificateRequirements(), outcome, err)
+			t.onVerificationReport(req, report)
+		}()
 	}
 
 	signatureToVerify, err := ec.ParseSignature(*msg.Signature)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse signature, %w", err)
+		return nil, report.Check(transport.CheckSignature, fmt.Errorf("failed to parse signature, %w", err))
 	}
 
 	key, err := ec.PublicKeyFromString(*session.PeerIdentityKey)
@@ -307,67 +647,127 @@ func (t *Transport) handleCertificateResponse(msg *transport.AuthMessage, req *h
 		return nil, fmt.Errorf("failed to parse identity key, %w", err)
 	}
 
-	baseArgs := wallet.EncryptionArgs{
+	baseArgs := t.privilegedKeys.Apply(wallet.EncryptionArgs{
 		ProtocolID: wallet.DefaultAuthProtocol,
 		KeyID:      fmt.Sprintf("%s %s", *msg.Nonce, *msg.YourNonce),
 		Counterparty: wallet.Counterparty{
 			Type:         wallet.CounterpartyTypeOther,
 			Counterparty: key,
 		},
-	}
+	})
 	verifySignatureArgs := &wallet.VerifySignatureArgs{
 		EncryptionArgs: baseArgs,
 		Signature:      *signatureToVerify,
 		Data:           payload,
 	}
 
-	result, err := t.wallet.VerifySignature(verifySignatureArgs)
-	if err != nil || !result.Valid {
-		return nil, fmt.Errorf("unable to verify signature, %w", err)
+	if err := report.Check(transport.CheckSignature, t.verifySignature(req.Context(), verifySignatureArgs)); err != nil {
+		return nil, err
 	}
 
-	var sessionAuthenticated bool
-	var authenticationDone bool
+	if t.revocationTracker != nil {
+		if err := report.Check(transport.CheckRevocation, t.checkRevocation(req.Context(), *msg.Certificates)); err != nil {
+			t.certificatesLogger.Warn("Rejected revoked certificate", slog.String("error", err.Error()))
+			// the serial numbers are checked as well, so the peer sees every rejected certificate at once
+			_, serialErr := t.certificateRegistry.check(*msg.Certificates)
+			return nil, joinCertificateErrors(err, serialErr)
+		}
+	}
 
-	if t.onCertificatesReceived != nil {
-		authCallback := func() {
-			sessionAuthenticated = true
-			authenticationDone = true
+	// disclosure is checked on the certificates sent by the peer, before the bridged and adapted certificates are added
+	if requirements := t.certificateRequirements(); t.strictDisclosure && requirements != nil {
+		if err := report.Check(transport.CheckDisclosure, requirements.CheckDisclosure(*msg.Certificates)); err != nil {
+			t.certificatesLogger.Warn("Rejected certificate disclosure", slog.String("error", err.Error()))
+			// the serial numbers are checked as well, so the peer sees every rejected certificate at once
+			_, serialErr := t.certificateRegistry.check(*msg.Certificates)
+			return nil, joinCertificateErrors(err, serialErr)
 		}
+	}
 
-		t.onCertificatesReceived(*session.PeerIdentityKey,
-			msg.Certificates,
-			req,
-			res,
-			authCallback,
-		)
+	// the client certificate is bridged after the checks of the sent certificates, it is not part of the signed
+	// payload and has no revocation outpoint
+	if err := t.bridgeClientCertificate(msg, req, *session.PeerIdentityKey, report); err != nil {
+		t.certificatesLogger.Warn("Rejected client certificate", slog.String("error", err.Error()))
+		return nil, err
+	}
+	if err := t.adaptCredentials(msg, req, *session.PeerIdentityKey, report); err != nil {
+		t.certificatesLogger.Warn("Rejected verifiable credential", slog.String("error", err.Error()))
+		return nil, err
+	}
 
-		if !authenticationDone {
-			return nil, nil
+	alreadyAccepted, err := t.certificateRegistry.check(*msg.Certificates)
+	if report.Check(transport.CheckSerialNumbers, err) != nil {
+		t.certificatesLogger.Warn("Rejected conflicting certificate", slog.String("error", err.Error()))
+		return nil, err
+	}
+
+	// a repeated identical submission is answered without running the callback again
+	if !alreadyAccepted || !session.IsAuthenticated {
+		var sessionAuthenticated bool
+		var authenticationDone bool
+
+		if t.onCertificatesReceived != nil {
+			authCallback := func() {
+				sessionAuthenticated = true
+				authenticationDone = true
+			}
+
+			t.certificatesLogger.Debug("Certificates received", slog.String("identityKey", *session.PeerIdentityKey), slog.Int("count", len(*msg.Certificates)))
+			t.onCertificatesReceived(*session.PeerIdentityKey,
+				msg.Certificates,
+				req,
+				res,
+				authCallback,
+			)
+
+			if !authenticationDone {
+				outcome = transport.VerificationPending
+				_ = report.Check(transport.CheckCallback, errCertificatesNotAccepted)
+				return nil, nil
+			}
+			_ = report.Check(transport.CheckCallback, nil)
+
+		} else {
+			sessionAuthenticated = true
 		}
 
-	} else {
-		sessionAuthenticated = true
-	}
+		// the exchange may have been aborted while the certificates were verified, its session must not be restored
+		if exchange, ok := t.abortedExchanges.get(*session.SessionNonce, time.Now()); ok {
+			return nil, t.abortedExchangeError(exchange)
+		}
+
+		if sessionAuthenticated {
+			if err := t.certificateRegistry.accept(*msg.Certificates, time.Now()); err != nil {
+				return nil, err
+			}
 
-	if sessionAuthenticated {
-		session.IsAuthenticated = true
-		session.LastUpdate = time.Now()
-		t.sessionManager.UpdateSession(*session)
-		t.logger.Debug("Certificate verification successful")
+			session.IsAuthenticated = true
+			session.Certificates = *msg.Certificates
+			session.Tier = ""
+			session.CertificateGeneration = t.certificatePolicy.Load().generation
+			session.LastUpdate = time.Now()
+			t.sessionManager.UpdateSession(*session)
+			t.certificatesLogger.Debug("Certificate verification successful")
+			t.events.Publish(req.Context(), events.Event{
+				Topic: events.TopicSession, Type: events.TypeSessionAuthenticated, IdentityKey: *session.PeerIdentityKey,
+				Request: req, Data: *session,
+			})
+		}
+	} else {
+		t.certificatesLogger.Debug("Certificates already accepted, skipping callback", slog.String("identityKey", *session.PeerIdentityKey))
 	}
 
-	nonce, err := t.wallet.CreateNonce(context.Background())
+	nonce, err := t.createNonce(req.Context())
 	if err != nil {
-		return nil, fmt.Errorf("failed to create nonce")
+		return nil, fmt.Errorf("failed to create nonce, %w", err)
 	}
 
-	signature, err := t.createNonGeneralAuthSignature(msg.InitialNonce, *session.SessionNonce, msg.IdentityKey)
+	signature, err := t.createNonGeneralAuthSignature(req.Context(), msg.InitialNonce, *session.SessionNonce, msg.IdentityKey, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create signature, %w", err)
 	}
 
-	identityKey, err := t.wallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	identityKey, err := t.wallet.GetPublicKey(t.privilegedKeys.IdentityKeyArgs(), "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve identity key, %w", err)
 	}
@@ -383,23 +783,29 @@ func (t *Transport) handleCertificateResponse(msg *transport.AuthMessage, req *h
 	return response, nil
 }
 
-func (t *Transport) handleGeneralRequest(msg *transport.AuthMessage, _ *http.Request, _ http.ResponseWriter) (*transport.AuthMessage, error) {
-	valid, err := t.wallet.VerifyNonce(context.Background(), *msg.YourNonce)
-	if err != nil || !valid {
-		return nil, fmt.Errorf("unable to verify nonce, %w", err)
+func (t *Transport) handleGeneralRequest(msg *transport.AuthMessage, req *http.Request, _ http.ResponseWriter) (*transport.AuthMessage, error) {
+	if err := t.verifyNonce(req.Context(), *msg.YourNonce); err != nil {
+		return nil, err
+	}
+
+	session, err := t.getBoundSession(*msg.YourNonce, msg.IdentityKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.checkSessionRevoked(req.Context(), session); err != nil {
+		return nil, err
 	}
 
-	session := t.sessionManager.GetSession(*msg.YourNonce)
-	if session == nil {
-		return nil, errors.New("session not found")
+	if err := t.checkDelegation(req.Context(), session); err != nil {
+		return nil, err
 	}
 
 	if !session.IsAuthenticated && !t.allowUnauthenticated {
-		if t.certificateRequirements != nil {
-			// TODO code response should be set to 401
-			return nil, errors.New("no certificates provided")
+		if t.certificateRequirements() != nil {
+			return nil, transport.ErrCertificatesRequired
 		}
-		return nil, errors.New("session not authenticated")
+		return nil, transport.ErrSessionNotAuthenticated
 	}
 
 	signature, err := ec.ParseSignature(*msg.Signature)
@@ -412,34 +818,63 @@ func (t *Transport) handleGeneralRequest(msg *transport.AuthMessage, _ *http.Req
 		return nil, fmt.Errorf("failed to parse identity key, %w", err)
 	}
 
-	baseArgs := wallet.EncryptionArgs{
+	keyID, err := t.generalKeyID(*msg.Nonce, *msg.YourNonce, req.Header.Get(utils.OriginHeader))
+	if err != nil {
+		return nil, err
+	}
+
+	baseArgs := t.privilegedKeys.Apply(wallet.EncryptionArgs{
 		ProtocolID: wallet.DefaultAuthProtocol,
-		KeyID:      fmt.Sprintf("%s %s", *msg.Nonce, *msg.YourNonce),
+		KeyID:      keyID,
 		Counterparty: wallet.Counterparty{
 			Type:         wallet.CounterpartyTypeOther,
 			Counterparty: key,
 		},
-	}
+	})
 	verifySignatureArgs := &wallet.VerifySignatureArgs{
 		EncryptionArgs: baseArgs,
 		Signature:      *signature,
 		Data:           *msg.Payload,
 	}
 
-	result, err := t.wallet.VerifySignature(verifySignatureArgs)
-	if err != nil || !result.Valid {
-		return nil, fmt.Errorf("unable to verify signature, %w", err)
+	if err := t.verifyRequestSignature(req, verifySignatureArgs); err != nil {
+		return nil, err
 	}
 
-	session.LastUpdate = time.Now()
-	t.sessionManager.UpdateSession(*session)
+	if err := t.checkWebSocketOrigin(req); err != nil {
+		return nil, err
+	}
 
-	nonce, err := t.wallet.CreateNonce(context.Background())
-	if err != nil {
-		return nil, fmt.Errorf("failed to create nonce, %w", err)
+	if t.replayGuard.record(*session.SessionNonce, req.Header.Get(requestIDHeader), time.Now()) {
+		t.logger.Warn("Rejected replayed request", slog.String("requestID", req.Header.Get(requestIDHeader)))
+		return nil, transport.ErrRequestReplayed
+	}
+
+	// sessions are upgraded after the signature is verified, so only the peer itself can trigger its re-challenge
+	if err := t.upgradeSession(session); err != nil {
+		return nil, err
+	}
+	if !session.IsAuthenticated && !t.allowUnauthenticated {
+		return nil, transport.ErrCertificatesRequired
 	}
 
-	identityKey, err := t.wallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	if session.PayloadEncryption {
+		err = t.decryptRequestBody(req, *session.PeerIdentityKey, baseArgs.KeyID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if session.PayloadPadding {
+		if err := unpadRequestBody(req); err != nil {
+			return nil, err
+		}
+	}
+
+	session.LastUpdate = time.Now()
+	t.sessionManager.UpdateSession(*session)
+
+	identityKey, err := t.wallet.GetPublicKey(t.privilegedKeys.IdentityKeyArgs(), "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve identity key, %w", err)
 	}
@@ -448,18 +883,51 @@ func (t *Transport) handleGeneralRequest(msg *transport.AuthMessage, _ *http.Req
 		Version:     transport.AuthVersion,
 		MessageType: "general",
 		IdentityKey: identityKey.PublicKey.ToDERHex(),
-		Nonce:       &nonce,
 		YourNonce:   session.PeerNonce,
 	}
 
 	return response, nil
 }
 
-func (t *Transport) createNonGeneralAuthSignature(initialNonce, sessionNonce, identityKey string) ([]byte, error) {
+// generalKeyID returns the key ID of the signature of a general request, bound to the origin sent by the peer.
+// Origins which are not origins of the server are rejected, as are unbound requests when binding is required.
+func (t *Transport) generalKeyID(nonce, yourNonce, origin string) (string, error) {
+	if origin == "" {
+		if t.originBinding.Required {
+			return "", transport.ErrOriginBindingRequired
+		}
+		return utils.GeneralKeyID(nonce, yourNonce, ""), nil
+	}
+
+	if !t.originBinding.Accepts(origin) {
+		t.logger.Warn("Rejected request bound to another origin", slog.String("origin", origin))
+		return "", transport.ErrOriginNotAccepted
+	}
+	return utils.GeneralKeyID(nonce, yourNonce, origin), nil
+}
+
+// getBoundSession returns the session established with the given nonce, which has to belong to the given identity key.
+// Binding the lookup to both values prevents a peer from using the nonce of another peer's session
+// or claiming another identity over its own session.
+func (t *Transport) getBoundSession(sessionNonce, identityKey string) (*session.PeerSession, error) {
+	peerSession := t.sessionManager.GetSession(sessionNonce)
+	if peerSession == nil || peerSession.SessionNonce == nil || *peerSession.SessionNonce != sessionNonce {
+		return nil, transport.ErrSessionNotFound
+	}
+
+	if peerSession.PeerIdentityKey == nil || *peerSession.PeerIdentityKey != identityKey {
+		t.sessionLogger.Warn("Rejected message with identity key not matching its session", slog.String("identityKey", identityKey))
+		return nil, transport.ErrIdentityKeyMismatch
+	}
+
+	return peerSession, nil
+}
+
+func (t *Transport) createNonGeneralAuthSignature(ctx context.Context, initialNonce, sessionNonce, identityKey string, capabilities []string) ([]byte, error) {
 	combined := initialNonce + sessionNonce
-	base64Data := base64.StdEncoding.EncodeToString([]byte(combined))
+	payload := transport.HandshakeSigningPayload(initialNonce, sessionNonce, capabilities)
 
-	signature, err := t.createSignature(identityKey, combined, []byte(base64Data))
+	signature, err := t.createSignature(ctx, identityKey, combined, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -467,26 +935,26 @@ func (t *Transport) createNonGeneralAuthSignature(initialNonce, sessionNonce, id
 	return signature, nil
 }
 
-func (t *Transport) createSignature(identityKey, keyID string, data []byte) ([]byte, error) {
+func (t *Transport) createSignature(ctx context.Context, identityKey, keyID string, data []byte) ([]byte, error) {
 	key, err := ec.PublicKeyFromString(identityKey)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse identity key, %w", err)
 	}
 
-	baseArgs := wallet.EncryptionArgs{
+	baseArgs := t.privilegedKeys.Apply(wallet.EncryptionArgs{
 		ProtocolID: wallet.DefaultAuthProtocol,
 		Counterparty: wallet.Counterparty{
 			Type:         wallet.CounterpartyTypeOther,
 			Counterparty: key,
 		},
 		KeyID: keyID,
-	}
+	})
 	createSignatureArgs := &wallet.CreateSignatureArgs{
 		EncryptionArgs: baseArgs,
 		Data:           data,
 	}
 
-	signature, err := t.wallet.CreateSignature(createSignatureArgs, "")
+	signature, err := t.walletSign(ctx, createSignatureArgs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create signature, %w", err)
 	}
@@ -494,75 +962,90 @@ func (t *Transport) createSignature(identityKey, keyID string, data []byte) ([]b
 	return signature.Signature.Serialize(), nil
 }
 
-// buildResponsePayload constructs the response payload for signing
-// The payload is constructed as follows:
-// - Request ID (Base64)
-// - Response status
-// - Number of headers
-// - Headers (key length, key, value length, value)
-// - Body length and content
-func buildResponsePayload(
-	requestID string,
-	responseStatus int,
-	responseBody []byte,
-) ([]byte, error) {
-	var writer bytes.Buffer
+func (t *Transport) encryptBody(ctx context.Context, identityKey, keyID string, body []byte) ([]byte, error) {
+	if len(body) == 0 {
+		return body, nil
+	}
 
-	requestIDBytes, err := base64.StdEncoding.DecodeString(requestID)
+	key, err := ec.PublicKeyFromString(identityKey)
 	if err != nil {
-		return nil, errors.New("failed to decode request ID")
+		return nil, fmt.Errorf("failed to parse identity key, %w", err)
 	}
-	writer.Write(requestIDBytes)
 
-	err = utils.WriteVarIntNum(&writer, responseStatus)
+	if err := requestCanceled(ctx); err != nil {
+		return nil, err
+	}
+	transport.WalletCallCounterFromContext(ctx).Encrypted()
+	result, err := t.wallet.Encrypt(&wallet.EncryptArgs{
+		EncryptionArgs: t.privilegedKeys.Apply(wallet.EncryptionArgs{
+			ProtocolID: wallet.DefaultEncryptionProtocol,
+			KeyID:      keyID,
+			Counterparty: wallet.Counterparty{
+				Type:         wallet.CounterpartyTypeOther,
+				Counterparty: key,
+			},
+		}),
+		Plaintext: body,
+	}, "")
 	if err != nil {
-		return nil, errors.New("failed to write response status")
+		return nil, fmt.Errorf("failed to encrypt payload, %w", err)
 	}
 
-	// TODO: #14 - Collect and sort headers
-	includedHeaders := make([][]string, 0)
-	//includedHeaders := utils.FilterAndSortHeaders(responseHeaders)
+	return result.Ciphertext, nil
+}
 
-	if len(includedHeaders) > 0 {
-		err = utils.WriteVarIntNum(&writer, len(includedHeaders))
-		if err != nil {
-			return nil, errors.New("failed to write headers length")
-		}
+func (t *Transport) decryptRequestBody(req *http.Request, identityKey, keyID string) error {
+	body, err := bufferRequestBody(req)
+	if err != nil {
+		return err
+	}
 
-		for _, header := range includedHeaders {
-			err = utils.WriteVarIntNum(&writer, len(header[0]))
-			if err != nil {
-				return nil, errors.New("failed to write header key length")
-			}
-			writer.WriteString(header[0])
+	if len(body) == 0 {
+		return nil
+	}
 
-			err = utils.WriteVarIntNum(&writer, len(header[1]))
-			if err != nil {
-				return nil, errors.New("failed to write header value length")
-			}
-			writer.WriteString(header[1])
-		}
-	} else {
-		err = utils.WriteVarIntNum(&writer, -1)
-		if err != nil {
-			return nil, errors.New("failed to write -1 as headers length")
-		}
+	key, err := ec.PublicKeyFromString(identityKey)
+	if err != nil {
+		return fmt.Errorf("failed to parse identity key, %w", err)
 	}
 
-	if len(responseBody) > 0 {
-		err = utils.WriteVarIntNum(&writer, len(responseBody))
-		if err != nil {
-			return nil, errors.New("failed to write body length")
-		}
-		writer.Write(responseBody)
-	} else {
-		err = utils.WriteVarIntNum(&writer, -1)
-		if err != nil {
-			return nil, errors.New("failed to write -1 as body length")
-		}
+	if err := requestCanceled(req.Context()); err != nil {
+		return err
+	}
+	transport.WalletCallCounterFromContext(req.Context()).Decrypted()
+	result, err := t.wallet.Decrypt(&wallet.DecryptArgs{
+		EncryptionArgs: t.privilegedKeys.Apply(wallet.EncryptionArgs{
+			ProtocolID: wallet.DefaultEncryptionProtocol,
+			KeyID:      keyID,
+			Counterparty: wallet.Counterparty{
+				Type:         wallet.CounterpartyTypeOther,
+				Counterparty: key,
+			},
+		}),
+		Ciphertext: body,
+	}, "")
+	if err != nil {
+		return fmt.Errorf("failed to decrypt payload, %w", err)
 	}
 
-	return writer.Bytes(), nil
+	resetRequestBody(req, result.Plaintext)
+	return nil
+}
+
+// unpadRequestBody removes the padding of the body of a session which negotiated payload padding
+func unpadRequestBody(req *http.Request) error {
+	body, err := bufferRequestBody(req)
+	if err != nil {
+		return err
+	}
+
+	body, err = transport.UnpadPayload(body)
+	if err != nil {
+		return err
+	}
+
+	resetRequestBody(req, body)
+	return nil
 }
 
 func setupHeaders(w http.ResponseWriter, response *transport.AuthMessage, requestID string) {
@@ -596,20 +1079,12 @@ func setupHeaders(w http.ResponseWriter, response *transport.AuthMessage, reques
 func setupContent(w http.ResponseWriter, response *transport.AuthMessage) {
 	w.Header().Set("Content-Type", "application/json")
 
-	b, err := json.Marshal(response)
-	if err != nil {
-		http.Error(w, "failed to marshal response", http.StatusInternalServerError)
-		return
-	}
-
-	_, err = w.Write(b)
-	if err != nil {
+	if err := transport.WriteAuthMessage(w, response); err != nil {
 		http.Error(w, "failed to write response", http.StatusInternalServerError)
-		return
 	}
 }
 
-func buildAuthMessageFromRequest(req *http.Request) (*transport.AuthMessage, error) {
+func buildAuthMessageFromRequest(req *http.Request, normalization authcore.PathNormalization) (*transport.AuthMessage, error) {
 	var writer bytes.Buffer
 
 	requestNonce := req.Header.Get(requestIDHeader)
@@ -620,7 +1095,7 @@ func buildAuthMessageFromRequest(req *http.Request) (*transport.AuthMessage, err
 
 	writer.Write(requestNonceBytes)
 
-	err := utils.WriteRequestData(req, &writer)
+	err := utils.WriteNormalizedRequestData(req, &writer, normalization)
 	if err != nil {
 		return nil, errors.New("failed to write request data")
 	}
@@ -654,17 +1129,63 @@ func buildAuthMessageFromRequest(req *http.Request) (*transport.AuthMessage, err
 	return authMessage, nil
 }
 
+// parseAuthMessage decodes the non general message of the body, the body is scanned for the limits of its structure
+// before it is decoded, so deeply nested or sprawling messages are rejected without allocating them
 func parseAuthMessage(req *http.Request) (*transport.AuthMessage, error) {
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, transport.ErrMessageTooLarge
+		}
+		return nil, fmt.Errorf("%w, %w", transport.ErrMalformedMessage, err)
+	}
+
+	if err := transport.CheckMessageLimits(body); err != nil {
+		return nil, err
+	}
+
 	var requestData transport.AuthMessage
-	if err := json.NewDecoder(req.Body).Decode(&requestData); err != nil {
-		return nil, errors.New("failed to decode request body")
+	if err := json.NewDecoder(bytes.NewReader(body)).Decode(&requestData); err != nil {
+		return nil, fmt.Errorf("%w, %w", transport.ErrMalformedMessage, err)
+	}
+
+	if err := requestData.CheckCertificateCount(); err != nil {
+		return nil, err
 	}
 	return &requestData, nil
 }
 
+// bufferRequestBody reads the whole request body and replaces it with a re-readable copy
+func bufferRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+
+	resetRequestBody(req, body)
+	return body, nil
+}
+
+func resetRequestBody(req *http.Request, body []byte) {
+	if req.Body == nil {
+		return
+	}
+
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+}
+
 func setupContext(req *http.Request, requestData *transport.AuthMessage, requestID string) *http.Request {
 	ctx := context.WithValue(req.Context(), transport.IdentityKey, requestData.IdentityKey)
 	ctx = context.WithValue(ctx, transport.RequestID, requestID)
+	if requestData.YourNonce != nil {
+		ctx = context.WithValue(ctx, transport.SessionNonce, *requestData.YourNonce)
+	}
 	req = req.WithContext(ctx)
 	return req
 }
@@ -683,47 +1204,61 @@ func getValuesFromContext(req *http.Request) (string, string, error) {
 	return identityKey, requestID, nil
 }
 
-func checkHeaders(req *http.Request) error {
+// verifyNonce checks the nonce was created by the wallet of the server
+func (t *Transport) verifyNonce(ctx context.Context, nonce string) error {
+	if err := requestCanceled(ctx); err != nil {
+		return err
+	}
+	transport.WalletCallCounterFromContext(ctx).NonceVerified()
+	valid, err := t.wallet.VerifyNonce(ctx, nonce)
+	if err != nil && ctx.Err() != nil {
+		return requestCanceled(ctx)
+	}
+	if err != nil {
+		return fmt.Errorf("%w, %w", transport.ErrInvalidNonce, err)
+	}
+	if !valid {
+		return fmt.Errorf("%w, %w", transport.ErrInvalidNonce, wallet.ErrNonceInvalid)
+	}
+	return nil
+}
+
+func (t *Transport) checkHeaders(req *http.Request) error {
 	if req.Header.Get(versionHeader) == "" {
-		return errors.New("missing version header")
+		return &transport.HeaderError{Header: "version", Missing: true}
 	}
 
 	if req.Header.Get(identityKeyHeader) == "" {
-		return errors.New("missing identity key header")
+		return &transport.HeaderError{Header: "identity key", Missing: true}
 	}
 
 	if req.Header.Get(nonceHeader) == "" {
-		return errors.New("missing nonce header")
-	} else {
-		if err := validateBase64(req.Header.Get(nonceHeader)); err != nil {
-			return errors.New("invalid nonce header")
-		}
+		return &transport.HeaderError{Header: "nonce", Missing: true}
+	}
+	if err := t.validateNonce(req.Header.Get(nonceHeader)); err != nil {
+		return &transport.HeaderError{Header: "nonce", Err: err}
 	}
 
 	if req.Header.Get(yourNonceHeader) == "" {
-		return errors.New("missing your nonce header")
-	} else {
-		if err := validateBase64(req.Header.Get(yourNonceHeader)); err != nil {
-			return errors.New("invalid your nonce header")
-		}
+		return &transport.HeaderError{Header: "your nonce", Missing: true}
+	}
+	if err := t.validateNonce(req.Header.Get(yourNonceHeader)); err != nil {
+		return &transport.HeaderError{Header: "your nonce", Err: err}
 	}
 
 	if req.Header.Get(signatureHeader) == "" {
-		return errors.New("missing signature header")
-	} else {
-		if !isHex(req.Header.Get(signatureHeader)) {
-			return errors.New("invalid signature header")
-		}
+		return &transport.HeaderError{Header: "signature", Missing: true}
+	}
+	if !isHex(req.Header.Get(signatureHeader)) {
+		return &transport.HeaderError{Header: "signature"}
 	}
+
 	return nil
 }
 
-func validateBase64(input string) error {
-	_, err := base64.StdEncoding.DecodeString(input)
-	if err != nil {
-		return fmt.Errorf("invalid base64 string: %w", err)
-	}
-	return nil
+// validateNonce checks the format of the peer nonce with the configured minimum size, see authcore.ValidateNonce
+func (t *Transport) validateNonce(nonce string) error {
+	return authcore.ValidateNonce(nonce, t.minNonceSize) //nolint:wrapcheck // the error matches transport.ErrInvalidNonceFormat
 }
 
 func isHex(s string) bool {
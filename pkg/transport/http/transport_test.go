@@ -2,15 +2,22 @@ package httptransport
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -140,7 +147,7 @@ func TestBuildResponsePayload(t *testing.T) {
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			// when
-			payload, err := buildResponsePayload(tc.requestID, tc.responseStatus, tc.responseBody)
+			payload, err := utils.BuildResponsePayload(tc.requestID, tc.responseStatus, tc.responseBody)
 
 			// then
 			if tc.expectErr {
@@ -185,6 +192,50 @@ func TestBuildResponsePayload(t *testing.T) {
 	}
 }
 
+func TestBuildSignedResponsePayload(t *testing.T) {
+	// given
+	requestID := base64.StdEncoding.EncodeToString([]byte("test-request-id"))
+	headers := http.Header{}
+	headers.Set(utils.ServerInfoHeader, "go-bsv-middleware/v1.0.0")
+	headers.Set("Content-Type", "text/plain")
+	headers.Set("x-bsv-auth-nonce", "nonce")
+
+	// when
+	payload, err := utils.BuildSignedResponsePayload(requestID, http.StatusOK, headers, []byte("ok"))
+
+	// then
+	require.NoError(t, err)
+
+	reader := bytes.NewReader(payload)
+	_, err = reader.Seek(int64(len("test-request-id")), io.SeekStart)
+	require.NoError(t, err)
+
+	_, err = utils.ReadVarIntNum(reader)
+	require.NoError(t, err)
+
+	headerCount, err := utils.ReadVarIntNum(reader)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), headerCount, "only the server info header is signed")
+
+	keyLength, err := utils.ReadVarIntNum(reader)
+	require.NoError(t, err)
+	key := make([]byte, keyLength)
+	_, err = reader.Read(key)
+	require.NoError(t, err)
+	require.Equal(t, utils.ServerInfoHeader, string(key))
+
+	valueLength, err := utils.ReadVarIntNum(reader)
+	require.NoError(t, err)
+	value := make([]byte, valueLength)
+	_, err = reader.Read(value)
+	require.NoError(t, err)
+	require.Equal(t, "go-bsv-middleware/v1.0.0", string(value))
+
+	unsigned, err := utils.BuildResponsePayload(requestID, http.StatusOK, []byte("ok"))
+	require.NoError(t, err)
+	require.NotEqual(t, unsigned, payload)
+}
+
 func TestTransport_SetupContent(t *testing.T) {
 	// given
 	exampleContent := &transport.AuthMessage{
@@ -224,7 +275,7 @@ func TestTransport_BuildAuthMessageFromRequest(t *testing.T) {
 	req.Header.Set("X-Bsv-Auth-Identity-Key", identityKey)
 
 	// when
-	authMsg, err := buildAuthMessageFromRequest(req)
+	authMsg, err := buildAuthMessageFromRequest(req, authcore.PathStrict)
 
 	// then
 	assert.NoError(t, err)
@@ -240,6 +291,117 @@ func TestTransport_BuildAuthMessageFromRequest(t *testing.T) {
 	assert.NotEmpty(t, authMsg.Payload)
 }
 
+func TestTransport_VerifyNonce(t *testing.T) {
+	// given
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	w := wallettest.NewMockWallet(key)
+	nonce, err := w.CreateNonce(context.Background())
+	require.NoError(t, err)
+	tr := &Transport{wallet: w}
+
+	t.Run("nonce created by the wallet", func(t *testing.T) {
+		// then
+		require.NoError(t, tr.verifyNonce(context.Background(), nonce))
+	})
+
+	t.Run("unknown nonce", func(t *testing.T) {
+		// when
+		err := tr.verifyNonce(context.Background(), base64.StdEncoding.EncodeToString([]byte("unknown")))
+
+		// then
+		require.ErrorIs(t, err, transport.ErrInvalidNonce)
+		require.ErrorIs(t, err, wallet.ErrNonceInvalid)
+		require.Equal(t, transport.ErrCodeInvalidNonce, transport.ErrorCode(err))
+	})
+}
+
+func TestCheckHeaders_HeaderError(t *testing.T) {
+	tests := map[string]struct {
+		modify  func(h http.Header)
+		header  string
+		missing bool
+	}{
+		"missing version":      {func(h http.Header) { h.Del(versionHeader) }, "version", true},
+		"missing identity key": {func(h http.Header) { h.Del(identityKeyHeader) }, "identity key", true},
+		"missing nonce":        {func(h http.Header) { h.Del(nonceHeader) }, "nonce", true},
+		"invalid nonce":        {func(h http.Header) { h.Set(nonceHeader, "not base64!") }, "nonce", false},
+		"missing your nonce":   {func(h http.Header) { h.Del(yourNonceHeader) }, "your nonce", true},
+		"invalid your nonce":   {func(h http.Header) { h.Set(yourNonceHeader, "not base64!") }, "your nonce", false},
+		"short nonce":          {func(h http.Header) { h.Set(nonceHeader, base64.StdEncoding.EncodeToString([]byte("x"))) }, "nonce", false},
+		"degenerate your nonce": {func(h http.Header) {
+			h.Set(yourNonceHeader, base64.StdEncoding.EncodeToString(make([]byte, 32)))
+		}, "your nonce", false},
+		"missing signature": {func(h http.Header) { h.Del(signatureHeader) }, "signature", true},
+		"invalid signature": {func(h http.Header) { h.Set(signatureHeader, "not hex") }, "signature", false},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(versionHeader, "0.1")
+			req.Header.Set(identityKeyHeader, "identity-key")
+			req.Header.Set(nonceHeader, wallettest.DefaultNonces[0])
+			req.Header.Set(yourNonceHeader, wallettest.DefaultNonces[1])
+			req.Header.Set(signatureHeader, hex.EncodeToString([]byte("signature")))
+			test.modify(req.Header)
+
+			// when
+			err := (&Transport{minNonceSize: DefaultMinNonceSize}).checkHeaders(req)
+
+			// then
+			var headerErr *transport.HeaderError
+			require.ErrorAs(t, err, &headerErr)
+			require.Equal(t, test.header, headerErr.Header)
+			require.Equal(t, test.missing, headerErr.Missing)
+			if test.missing {
+				require.ErrorIs(t, err, transport.ErrMissingHeader)
+			} else {
+				require.ErrorIs(t, err, transport.ErrInvalidHeader)
+			}
+			if test.header == "nonce" || test.header == "your nonce" {
+				require.Equal(t, !test.missing, errors.Is(err, transport.ErrInvalidNonceFormat))
+			}
+		})
+	}
+}
+
+func TestTransport_ValidateNonce(t *testing.T) {
+	tests := map[string]struct {
+		nonce        string
+		minNonceSize int
+		valid        bool
+	}{
+		"SDK nonce":            {nonce: wallettest.DefaultNonces[0], minNonceSize: DefaultMinNonceSize, valid: true},
+		"empty":                {nonce: "", minNonceSize: DefaultMinNonceSize},
+		"single byte":          {nonce: base64.StdEncoding.EncodeToString([]byte{0x7f}), minNonceSize: DefaultMinNonceSize},
+		"shorter than minimum": {nonce: base64.StdEncoding.EncodeToString([]byte("0123456789abcde")), minNonceSize: DefaultMinNonceSize},
+		"exactly minimum":      {nonce: base64.StdEncoding.EncodeToString([]byte("0123456789abcdef")), minNonceSize: DefaultMinNonceSize, valid: true},
+		"configured minimum":   {nonce: wallettest.DefaultNonces[0], minNonceSize: 64},
+		"repeated single byte": {nonce: base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0xaa}, 32)), minNonceSize: DefaultMinNonceSize},
+		"not base64":           {nonce: "not base64!", minNonceSize: DefaultMinNonceSize},
+		"longer than maximum":  {nonce: base64.StdEncoding.EncodeToString(make([]byte, MaxNonceSize+1)), minNonceSize: DefaultMinNonceSize},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			tr := &Transport{minNonceSize: test.minNonceSize}
+
+			// when
+			err := tr.validateNonce(test.nonce)
+
+			// then
+			if test.valid {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, transport.ErrInvalidNonceFormat)
+			}
+		})
+	}
+}
+
 func stringPtr(s string) *string {
 	return &s
 }
@@ -0,0 +1,136 @@
+package httptransport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+)
+
+// Names of the wallet operations reported in WalletTimeoutError
+const (
+	walletCreateNonce     = "CreateNonce"
+	walletCreateSignature = "CreateSignature"
+	walletVerifySignature = "VerifySignature"
+)
+
+// requestCanceled returns transport.ErrRequestCanceled once the context of the request is done, e.g. when the peer
+// disconnected, so no wallet operations are spent on messages nobody waits for
+func requestCanceled(ctx context.Context) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+	return fmt.Errorf("%w, %w", transport.ErrRequestCanceled, context.Cause(ctx))
+}
+
+// withWalletTimeout runs the wallet operation and gives up with a WalletTimeoutError once the timeout elapses,
+// or with transport.ErrRequestCanceled once the request is canceled. The operation receives a context with
+// the deadline, a wallet which ignores it keeps running in the background until it returns, but the request
+// is no longer held up by it. A zero timeout runs the operation without a limit.
+func withWalletTimeout[T any](ctx context.Context, operation string, timeout time.Duration, call func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	if err := requestCanceled(ctx); err != nil {
+		return zero, err
+	}
+
+	if timeout <= 0 {
+		value, err := call(ctx)
+		if err != nil && ctx.Err() != nil {
+			return zero, requestCanceled(ctx)
+		}
+		return value, err
+	}
+
+	parent := ctx
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		value T
+		err   error
+	}
+	done := make(chan result, 1)
+	go func() {
+		value, err := call(ctx)
+		done <- result{value: value, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		if r.err != nil && parent.Err() != nil {
+			return zero, requestCanceled(parent)
+		}
+		if r.err != nil && errors.Is(r.err, context.DeadlineExceeded) && ctx.Err() != nil {
+			return zero, &transport.WalletTimeoutError{Operation: operation, Timeout: timeout}
+		}
+		return r.value, r.err
+	case <-ctx.Done():
+		if parent.Err() != nil {
+			return zero, requestCanceled(parent)
+		}
+		return zero, &transport.WalletTimeoutError{Operation: operation, Timeout: timeout}
+	}
+}
+
+// createNonce creates a nonce with the wallet, limited by the CreateNonce timeout.
+// Wallet calls are counted by the WalletCallCounter of the context, calls skipped for canceled requests are not.
+func (t *Transport) createNonce(ctx context.Context) (string, error) {
+	if err := requestCanceled(ctx); err != nil {
+		return "", err
+	}
+	transport.WalletCallCounterFromContext(ctx).NonceCreated()
+	return withWalletTimeout(ctx, walletCreateNonce, t.walletTimeouts.CreateNonce, t.wallet.CreateNonce)
+}
+
+// walletSign signs with the wallet of the server, see walletSignWith
+func (t *Transport) walletSign(ctx context.Context, args *wallet.CreateSignatureArgs) (*wallet.CreateSignatureResult, error) {
+	return t.walletSignWith(ctx, t.wallet, args)
+}
+
+// walletSignWith creates the signature with the wallet, limited by the CreateSignature timeout.
+// Payloads above authcore.DirectHashThreshold are hashed before the wallet is called.
+func (t *Transport) walletSignWith(ctx context.Context, w wallet.WalletInterface, args *wallet.CreateSignatureArgs) (*wallet.CreateSignatureResult, error) {
+	if err := requestCanceled(ctx); err != nil {
+		return nil, err
+	}
+	if len(args.Data) > authcore.DirectHashThreshold {
+		args = args.Hashed()
+	}
+	transport.WalletCallCounterFromContext(ctx).SignatureCreated()
+	return withWalletTimeout(ctx, walletCreateSignature, t.walletTimeouts.CreateSignature,
+		func(context.Context) (*wallet.CreateSignatureResult, error) {
+			return w.CreateSignature(args, "")
+		})
+}
+
+// verifySignature verifies the peer signature with the wallet, limited by the VerifySignature timeout.
+// Timeouts and cancellations are returned as is, so they are not reported as invalid signatures.
+// Payloads above authcore.DirectHashThreshold are hashed before the wallet is called.
+func (t *Transport) verifySignature(ctx context.Context, args *wallet.VerifySignatureArgs) error {
+	if err := requestCanceled(ctx); err != nil {
+		return err
+	}
+	if len(args.Data) > authcore.DirectHashThreshold {
+		args = args.Hashed()
+	}
+	transport.WalletCallCounterFromContext(ctx).SignatureVerified()
+	result, err := withWalletTimeout(ctx, walletVerifySignature, t.walletTimeouts.VerifySignature,
+		func(context.Context) (*wallet.VerifySignatureResult, error) {
+			return t.wallet.VerifySignature(args)
+		})
+	if errors.Is(err, transport.ErrWalletTimeout) || errors.Is(err, transport.ErrRequestCanceled) {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("%w, %w", transport.ErrInvalidSignature, err)
+	}
+	if !result.Valid {
+		return fmt.Errorf("%w, %w", transport.ErrInvalidSignature, wallet.ErrSignatureInvalid)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package httptransport
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithWalletTimeout(t *testing.T) {
+	t.Run("operation within the timeout returns its result", func(t *testing.T) {
+		// when
+		value, err := withWalletTimeout(context.Background(), walletCreateNonce, time.Second,
+			func(context.Context) (string, error) { return "nonce", nil })
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, "nonce", value)
+	})
+
+	t.Run("operation error is returned as is", func(t *testing.T) {
+		// given
+		walletErr := errors.New("wallet failure")
+
+		// when
+		_, err := withWalletTimeout(context.Background(), walletCreateNonce, time.Second,
+			func(context.Context) (string, error) { return "", walletErr })
+
+		// then
+		require.ErrorIs(t, err, walletErr)
+		require.NotErrorIs(t, err, transport.ErrWalletTimeout)
+	})
+
+	t.Run("operation ignoring the context is abandoned after the timeout", func(t *testing.T) {
+		// given
+		release := make(chan struct{})
+		defer close(release)
+
+		// when
+		start := time.Now()
+		_, err := withWalletTimeout(context.Background(), walletCreateSignature, 10*time.Millisecond,
+			func(context.Context) (string, error) {
+				<-release
+				return "signature", nil
+			})
+
+		// then
+		require.Less(t, time.Since(start), time.Second)
+		var timeoutErr *transport.WalletTimeoutError
+		require.ErrorAs(t, err, &timeoutErr)
+		require.Equal(t, walletCreateSignature, timeoutErr.Operation)
+		require.Equal(t, 10*time.Millisecond, timeoutErr.Timeout)
+	})
+
+	t.Run("operation failing with the deadline of its context is reported as timeout", func(t *testing.T) {
+		// when
+		_, err := withWalletTimeout(context.Background(), walletCreateNonce, 10*time.Millisecond,
+			func(ctx context.Context) (string, error) {
+				<-ctx.Done()
+				return "", ctx.Err()
+			})
+
+		// then
+		require.ErrorIs(t, err, transport.ErrWalletTimeout)
+	})
+
+	t.Run("zero timeout runs the operation without a limit", func(t *testing.T) {
+		// when
+		value, err := withWalletTimeout(context.Background(), walletVerifySignature, 0,
+			func(ctx context.Context) (bool, error) {
+				_, hasDeadline := ctx.Deadline()
+				return hasDeadline, nil
+			})
+
+		// then
+		require.NoError(t, err)
+		require.False(t, value)
+	})
+
+	t.Run("canceled request skips the operation", func(t *testing.T) {
+		// given
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		called := false
+
+		// when
+		_, err := withWalletTimeout(ctx, walletCreateSignature, time.Second,
+			func(context.Context) (string, error) {
+				called = true
+				return "signature", nil
+			})
+
+		// then
+		require.ErrorIs(t, err, transport.ErrRequestCanceled)
+		require.ErrorIs(t, err, context.Canceled)
+		require.False(t, called)
+	})
+
+	t.Run("request canceled during the operation abandons it without a timeout", func(t *testing.T) {
+		// given
+		release := make(chan struct{})
+		defer close(release)
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+
+		// when
+		_, err := withWalletTimeout(ctx, walletVerifySignature, time.Minute,
+			func(context.Context) (bool, error) {
+				<-release
+				return true, nil
+			})
+
+		// then
+		require.ErrorIs(t, err, transport.ErrRequestCanceled)
+		require.NotErrorIs(t, err, transport.ErrWalletTimeout)
+	})
+}
@@ -0,0 +1,29 @@
+package httptransport
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+)
+
+// checkWebSocketOrigin rejects WebSocket upgrades sent from the page of an origin which is not allowed.
+// It is checked after the signature, which covers the Origin header of upgrades, see authcore.WebSocketSignedHeaders.
+func (t *Transport) checkWebSocketOrigin(req *http.Request) error {
+	if len(t.webSocketOrigins) == 0 || req.Header.Get("Sec-WebSocket-Key") == "" {
+		return nil
+	}
+
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		// clients other than browsers do not send an origin, they cannot be lured into opening a socket
+		return nil
+	}
+	for _, allowed := range t.webSocketOrigins {
+		if strings.EqualFold(allowed, origin) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", transport.ErrWebSocketOriginNotAllowed, origin)
+}
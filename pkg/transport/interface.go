@@ -18,5 +18,28 @@ type TransportInterface interface { //nolint:revive // This is an interface, so
 	HandleGeneralRequest(req *http.Request, res http.ResponseWriter) (*http.Request, *AuthMessage, error)
 
 	// HandleResponse sets up auth headers in the response object and generate signature for whole response.
-	HandleResponse(req *http.Request, res http.ResponseWriter, body []byte, status int, msg *AuthMessage) error
+	// It returns the response body which should be sent to the peer, as it may be transformed (e.g. encrypted).
+	HandleResponse(req *http.Request, res http.ResponseWriter, body []byte, status int, msg *AuthMessage) ([]byte, error)
+
+	// StreamResponse sets up the auth headers of a response streamed to the peer and announces its signature as
+	// a trailer, the headers of res have to be complete. The returned function signs the status, the headers and
+	// the SHA-256 hash of the streamed body and sets the signature trailer. It returns a nil function when the
+	// response cannot be streamed to the peer, e.g. the session did not negotiate CapabilityTrailerSignature.
+	StreamResponse(req *http.Request, res http.ResponseWriter, status int, msg *AuthMessage) (func(bodyHash []byte) error, error)
+
+	// UpdateCertificateRequirements replaces the certificates requested from peers,
+	// the mode defines how sessions authenticated under the previous requirements are treated.
+	UpdateCertificateRequirements(requirements *RequestedCertificateSet, mode CertificateUpgradeMode)
+
+	// HandleHeartbeat verifies a Heartbeat message, refreshes the session of its sender and returns the signed acknowledgement,
+	// heartbeats are sent to the handshake endpoint or over connections upgraded from the session
+	HandleHeartbeat(msg *AuthMessage) (*AuthMessage, error)
+
+	// AbortCertificateExchanges aborts the certificate exchanges of peers whose sessions are not authenticated yet
+	// and removes their sessions, their certificate responses are answered with a signed ExchangeAborted message.
+	// It returns the number of aborted exchanges.
+	AbortCertificateExchanges(reason AbortReason) int
+
+	// Capabilities returns the optional protocol features the transport supports, negotiated with every peer in the handshake
+	Capabilities() []Capability
 }
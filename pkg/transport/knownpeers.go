@@ -0,0 +1,95 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// ErrInvalidKnownPeer is returned when a known peer is registered with an identity key which is not a public key
+var ErrInvalidKnownPeer = errors.New("invalid known peer identity key")
+
+// KnownPeer is a peer pre-registered by the operator, e.g. a service of the same mesh
+type KnownPeer struct {
+	// IdentityKey is the compressed public key (hex) of the peer
+	IdentityKey string `json:"identityKey"`
+	// Name describes the peer in logs and listings, e.g. "billing"
+	Name string `json:"name,omitempty"`
+}
+
+// KnownPeers is the set of pre-registered peers whose sessions are pre-warmed: their handshakes are authenticated
+// without certificate requests and their identity keys are not locked out by the anomaly policy.
+// Peers can be registered and removed while the server runs, removing a peer does not end its sessions.
+// It is safe for concurrent use, a nil set knows no peers.
+type KnownPeers struct {
+	mu    sync.RWMutex
+	peers map[string]KnownPeer
+}
+
+// NewKnownPeers creates a set of the given peers
+func NewKnownPeers(peers ...KnownPeer) (*KnownPeers, error) {
+	k := &KnownPeers{peers: make(map[string]KnownPeer, len(peers))}
+	for _, peer := range peers {
+		if err := k.Add(peer); err != nil {
+			return nil, err
+		}
+	}
+	return k, nil
+}
+
+// Add registers the peer, a peer registered before is replaced
+func (k *KnownPeers) Add(peer KnownPeer) error {
+	if _, err := ec.PublicKeyFromString(peer.IdentityKey); err != nil {
+		return fmt.Errorf("%w %q, %w", ErrInvalidKnownPeer, peer.IdentityKey, err)
+	}
+	peer.IdentityKey = strings.ToLower(peer.IdentityKey)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.peers[peer.IdentityKey] = peer
+	return nil
+}
+
+// Remove unregisters the peer with the identity key, it reports whether the peer was registered
+func (k *KnownPeers) Remove(identityKey string) bool {
+	identityKey = strings.ToLower(identityKey)
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	_, ok := k.peers[identityKey]
+	delete(k.peers, identityKey)
+	return ok
+}
+
+// Contains reports whether the peer with the identity key is registered
+func (k *KnownPeers) Contains(identityKey string) bool {
+	if k == nil || identityKey == "" {
+		return false
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	_, ok := k.peers[strings.ToLower(identityKey)]
+	return ok
+}
+
+// List returns the registered peers sorted by identity key
+func (k *KnownPeers) List() []KnownPeer {
+	if k == nil {
+		return nil
+	}
+
+	k.mu.RLock()
+	peers := make([]KnownPeer, 0, len(k.peers))
+	for _, peer := range k.peers {
+		peers = append(peers, peer)
+	}
+	k.mu.RUnlock()
+
+	sort.Slice(peers, func(i, j int) bool { return peers[i].IdentityKey < peers[j].IdentityKey })
+	return peers
+}
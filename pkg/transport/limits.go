@@ -0,0 +1,60 @@
+package transport
+
+import "fmt"
+
+// Limits of decoded auth messages, hostile messages are rejected before decoding allocates them
+const (
+	// MaxMessageDepth is the maximum nesting depth of the objects and arrays of a message
+	MaxMessageDepth = 32
+	// MaxMessageFields is the maximum number of object fields of a message, counted over all of its objects
+	MaxMessageFields = 4096
+	// MaxCertificates is the maximum number of certificates of a certificateResponse
+	MaxCertificates = 64
+)
+
+// CheckMessageLimits scans the JSON encoded message without decoding it, messages nested deeper than MaxMessageDepth
+// or with more than MaxMessageFields fields are rejected with ErrMessageTooComplex.
+// Invalid JSON is not reported, it is left to the decoder.
+func CheckMessageLimits(data []byte) error {
+	depth, fields := 0, 0
+	inString, escaped := false, false
+	for _, c := range data {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > MaxMessageDepth {
+				return fmt.Errorf("%w: nested deeper than %d", ErrMessageTooComplex, MaxMessageDepth)
+			}
+		case '}', ']':
+			depth--
+		case ':':
+			fields++
+			if fields > MaxMessageFields {
+				return fmt.Errorf("%w: more than %d fields", ErrMessageTooComplex, MaxMessageFields)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckCertificateCount rejects messages carrying more than MaxCertificates certificates with ErrMessageTooLarge
+func (m *AuthMessage) CheckCertificateCount() error {
+	if m.Certificates != nil && len(*m.Certificates) > MaxCertificates {
+		return fmt.Errorf("%w: %d certificates exceed %d", ErrMessageTooLarge, len(*m.Certificates), MaxCertificates)
+	}
+	return nil
+}
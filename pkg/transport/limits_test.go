@@ -0,0 +1,94 @@
+package transport_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckMessageLimits(t *testing.T) {
+	fields := func(n int) string {
+		var b strings.Builder
+		b.WriteString("{")
+		for i := range n {
+			if i > 0 {
+				b.WriteString(",")
+			}
+			fmt.Fprintf(&b, `"f%d":1`, i)
+		}
+		b.WriteString("}")
+		return b.String()
+	}
+
+	tests := map[string]struct {
+		data string
+		err  error
+	}{
+		"message within the limits": {
+			data: `{"version":"0.1","certificates":[{"fields":{"age":"21"}}]}`,
+		},
+		"nesting at the maximum depth": {
+			data: strings.Repeat("[", transport.MaxMessageDepth) + strings.Repeat("]", transport.MaxMessageDepth),
+		},
+		"nesting beyond the maximum depth": {
+			data: strings.Repeat("[", transport.MaxMessageDepth+1) + strings.Repeat("]", transport.MaxMessageDepth+1),
+			err:  transport.ErrMessageTooComplex,
+		},
+		"fields at the maximum": {
+			data: fields(transport.MaxMessageFields),
+		},
+		"fields beyond the maximum": {
+			data: fields(transport.MaxMessageFields + 1),
+			err:  transport.ErrMessageTooComplex,
+		},
+		"brackets and colons in strings are not counted": {
+			data: `{"nonce":"` + strings.Repeat(`[{:\"`, transport.MaxMessageFields) + `"}`,
+		},
+		"invalid JSON is left to the decoder": {
+			data: `{"version":`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// when
+			err := transport.CheckMessageLimits([]byte(test.data))
+
+			// then
+			if test.err == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, test.err)
+			}
+		})
+	}
+}
+
+func TestAuthMessage_CheckCertificateCount(t *testing.T) {
+	t.Run("certificates at the maximum are accepted", func(t *testing.T) {
+		// given
+		certs := make([]certificates.VerifiableCertificate, transport.MaxCertificates)
+		msg := transport.AuthMessage{Certificates: &certs}
+
+		// when
+		err := msg.CheckCertificateCount()
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("certificates beyond the maximum are rejected", func(t *testing.T) {
+		// given
+		certs := make([]certificates.VerifiableCertificate, transport.MaxCertificates+1)
+		msg := transport.AuthMessage{Certificates: &certs}
+
+		// when
+		err := msg.CheckCertificateCount()
+
+		// then
+		require.ErrorIs(t, err, transport.ErrMessageTooLarge)
+	})
+}
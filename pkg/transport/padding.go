@@ -0,0 +1,63 @@
+package transport
+
+import (
+	"fmt"
+)
+
+// Size buckets of padded payloads, bodies are padded to the next power of two from MinPaddedSize up to MaxPaddingBucket,
+// larger bodies to the next multiple of MaxPaddingBucket
+const (
+	MinPaddedSize    = 256
+	MaxPaddingBucket = 64 * 1024
+)
+
+// paddingMarker separates the body from the zero bytes of the padding (ISO/IEC 7816-4)
+const paddingMarker = 0x80
+
+// PaddedSize returns the size of the bucket a body of the given size is padded to
+func PaddedSize(size int) int {
+	// the marker is always appended
+	size++
+	if size > MaxPaddingBucket {
+		return (size + MaxPaddingBucket - 1) / MaxPaddingBucket * MaxPaddingBucket
+	}
+
+	bucket := MinPaddedSize
+	for bucket < size {
+		bucket *= 2
+	}
+	return bucket
+}
+
+// PadPayload pads the body of a general message to its size bucket, so only the bucket of its size is observable.
+// Sessions which negotiated payload padding pad bodies before they are encrypted and signed,
+// empty bodies are not padded.
+func PadPayload(body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+
+	padded := make([]byte, PaddedSize(len(body)))
+	copy(padded, body)
+	padded[len(body)] = paddingMarker
+	return padded
+}
+
+// UnpadPayload removes the padding added by PadPayload
+func UnpadPayload(padded []byte) ([]byte, error) {
+	if len(padded) == 0 {
+		return padded, nil
+	}
+	if size := len(padded); size < MinPaddedSize || (size%MaxPaddingBucket != 0 && size&(size-1) != 0) {
+		return nil, fmt.Errorf("%w: size %d is not a padding bucket", ErrInvalidPadding, size)
+	}
+
+	end := len(padded) - 1
+	for end >= 0 && padded[end] == 0 {
+		end--
+	}
+	if end < 0 || padded[end] != paddingMarker {
+		return nil, fmt.Errorf("%w: missing padding marker", ErrInvalidPadding)
+	}
+	return padded[:end], nil
+}
@@ -0,0 +1,74 @@
+package transport_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPadding(t *testing.T) {
+	t.Run("bodies are padded to their bucket and restored", func(t *testing.T) {
+		tests := map[string]struct {
+			size     int
+			expected int
+		}{
+			"single byte":                 {size: 1, expected: transport.MinPaddedSize},
+			"body filling the bucket":     {size: transport.MinPaddedSize, expected: 2 * transport.MinPaddedSize},
+			"body within a larger bucket": {size: 3000, expected: 4096},
+			"largest power of two bucket": {size: transport.MaxPaddingBucket - 1, expected: transport.MaxPaddingBucket},
+			"body above the buckets":      {size: transport.MaxPaddingBucket + 10, expected: 2 * transport.MaxPaddingBucket},
+		}
+
+		for name, test := range tests {
+			t.Run(name, func(t *testing.T) {
+				// given
+				body := bytes.Repeat([]byte("x"), test.size)
+
+				// when
+				padded := transport.PadPayload(body)
+				unpadded, err := transport.UnpadPayload(padded)
+
+				// then
+				require.NoError(t, err)
+				require.Len(t, padded, test.expected)
+				require.Equal(t, body, unpadded)
+			})
+		}
+	})
+
+	t.Run("body ending with the marker and zero bytes is restored", func(t *testing.T) {
+		// given
+		body := []byte{'x', 0x80, 0x00, 0x00}
+
+		// when
+		unpadded, err := transport.UnpadPayload(transport.PadPayload(body))
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, body, unpadded)
+	})
+
+	t.Run("empty body is not padded", func(t *testing.T) {
+		require.Empty(t, transport.PadPayload(nil))
+	})
+
+	t.Run("invalid padding", func(t *testing.T) {
+		tests := map[string][]byte{
+			"unpadded body":       []byte("body"),
+			"missing marker":      make([]byte, transport.MinPaddedSize),
+			"size between bucket": append(transport.PadPayload([]byte("body")), 0),
+		}
+
+		for name, padded := range tests {
+			t.Run(name, func(t *testing.T) {
+				// when
+				_, err := transport.UnpadPayload(padded)
+
+				// then
+				require.ErrorIs(t, err, transport.ErrInvalidPadding)
+			})
+		}
+	})
+}
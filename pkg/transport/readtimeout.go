@@ -0,0 +1,114 @@
+package transport
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stages of a request reported in ReadTimeoutError
+const (
+	ReadStageHeader = "header"
+	ReadStageBody   = "body"
+)
+
+// ReadTimeouts limits how long peers may take to send the parts of authenticated requests, independent of
+// the timeouts of the http.Server, so a slow peer cannot hold the buffering of its payload open. A zero timeout
+// leaves the part unlimited.
+type ReadTimeouts struct {
+	// Header limits the time from the first byte of a request until it was received with all its headers,
+	// it is only enforced for connections accepted through the listener of the Tracker
+	Header time.Duration
+	// Body limits the time the body of a message may take to arrive while it is buffered for verification
+	Body time.Duration
+	// Tracker records the arrival of requests on the connections of the server for the Header timeout
+	Tracker *RequestTracker
+}
+
+// RequestTracker records when the first byte of each request arrived on the connections of a server.
+// Wrap the listener of the server with Listener and set ConnState as its hook:
+//
+//	tracker := transport.NewRequestTracker()
+//	server := &http.Server{Handler: handler, ConnState: tracker.ConnState}
+//	err := server.Serve(tracker.Listener(listener))
+//
+// The first request of a TLS connection arrives with the first byte of its handshake.
+type RequestTracker struct {
+	conns sync.Map
+}
+
+// NewRequestTracker creates a request tracker without connections
+func NewRequestTracker() *RequestTracker {
+	return &RequestTracker{}
+}
+
+// Listener wraps the listener so the requests of the connections it accepts are tracked
+func (rt *RequestTracker) Listener(l net.Listener) net.Listener {
+	return &trackedListener{Listener: l, tracker: rt}
+}
+
+// ConnState resets the arrival of idle connections, so the next request is tracked from its first byte
+func (rt *RequestTracker) ConnState(c net.Conn, state http.ConnState) {
+	if state != http.StateIdle {
+		return
+	}
+	if tlsConn, ok := c.(*tls.Conn); ok {
+		c = tlsConn.NetConn()
+	}
+	if conn, ok := c.(*trackedConn); ok {
+		conn.arrival.Store(0)
+	}
+}
+
+// Arrival returns when the first byte of the request arrived, false when the connection of the request is not tracked
+func (rt *RequestTracker) Arrival(req *http.Request) (time.Time, bool) {
+	value, ok := rt.conns.Load(req.RemoteAddr)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	arrival := value.(*trackedConn).arrival.Load()
+	if arrival == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, arrival), true
+}
+
+type trackedListener struct {
+	net.Listener
+	tracker *RequestTracker
+}
+
+func (l *trackedListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &trackedConn{Conn: c, tracker: l.tracker, key: c.RemoteAddr().String()}
+	l.tracker.conns.Store(conn.key, conn)
+	return conn, nil
+}
+
+type trackedConn struct {
+	net.Conn
+	tracker *RequestTracker
+	key     string
+	arrival atomic.Int64
+}
+
+func (c *trackedConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.arrival.CompareAndSwap(0, time.Now().UnixNano())
+	}
+	return n, err
+}
+
+func (c *trackedConn) Close() error {
+	c.tracker.conns.CompareAndDelete(c.key, c)
+	return c.Conn.Close()
+}
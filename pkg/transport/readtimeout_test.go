@@ -0,0 +1,87 @@
+package transport_test
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestTracker(t *testing.T) {
+	setup := func(t *testing.T) (*transport.RequestTracker, net.Conn, net.Conn, *http.Request) {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = listener.Close() })
+
+		tracker := transport.NewRequestTracker()
+		tracked := tracker.Listener(listener)
+
+		client, err := net.Dial("tcp", listener.Addr().String())
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = client.Close() })
+
+		server, err := tracked.Accept()
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = server.Close() })
+
+		return tracker, client, server, &http.Request{RemoteAddr: client.LocalAddr().String()}
+	}
+
+	exchange := func(t *testing.T, client, server net.Conn) {
+		_, err := client.Write([]byte("GET"))
+		require.NoError(t, err)
+		_, err = server.Read(make([]byte, 3))
+		require.NoError(t, err)
+	}
+
+	t.Run("request is tracked from its first byte", func(t *testing.T) {
+		// given
+		tracker, client, server, req := setup(t)
+		_, ok := tracker.Arrival(req)
+		require.False(t, ok)
+
+		// when
+		before := time.Now()
+		exchange(t, client, server)
+		time.Sleep(10 * time.Millisecond)
+		exchange(t, client, server)
+
+		// then
+		arrival, ok := tracker.Arrival(req)
+		require.True(t, ok)
+		require.WithinDuration(t, before, arrival, 5*time.Millisecond)
+	})
+
+	t.Run("idle connection tracks the next request", func(t *testing.T) {
+		// given
+		tracker, client, server, req := setup(t)
+		exchange(t, client, server)
+		first, _ := tracker.Arrival(req)
+
+		// when
+		tracker.ConnState(server, http.StateIdle)
+		time.Sleep(10 * time.Millisecond)
+		exchange(t, client, server)
+
+		// then
+		next, ok := tracker.Arrival(req)
+		require.True(t, ok)
+		require.True(t, next.After(first))
+	})
+
+	t.Run("closed connection is forgotten", func(t *testing.T) {
+		// given
+		tracker, client, server, req := setup(t)
+		exchange(t, client, server)
+
+		// when
+		require.NoError(t, server.Close())
+
+		// then
+		_, ok := tracker.Arrival(req)
+		require.False(t, ok)
+	})
+}
@@ -0,0 +1,134 @@
+package transport
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+)
+
+// RedactionMode defines how a certificate field value is masked.
+type RedactionMode int
+
+const (
+	// RedactionNone keeps the field value as is.
+	RedactionNone RedactionMode = iota
+	// RedactionHash replaces the field value with its HMAC-SHA256 under the key of the policy, so equal values can
+	// still be correlated by the holders of the key, but low-entropy values cannot be brute-forced back without it.
+	RedactionHash
+	// RedactionTruncate keeps only the first characters of the field value.
+	RedactionTruncate
+	// RedactionRemove replaces the field value with a fixed placeholder.
+	RedactionRemove
+)
+
+const (
+	redactedPlaceholder = "[REDACTED]"
+	truncatedLength     = 4
+)
+
+// CertificateRedactionPolicy declares which certificate fields are PII and how they are masked
+// before certificates leave the middleware in logs, audit events or webhook payloads.
+// Keyrings are always removed, as they allow decrypting the certificate fields.
+type CertificateRedactionPolicy struct {
+	// Fields maps certificate field names to the redaction mode applied to them.
+	Fields map[string]RedactionMode
+	// Default is the redaction mode applied to fields not listed in Fields.
+	Default RedactionMode
+	// Key is the HMAC key of RedactionHash, configure the same secret key on every replica to correlate their hashes.
+	// Fields are removed instead of hashed without a key.
+	Key []byte
+}
+
+// DefaultCertificateRedactionPolicy returns a policy which hashes every certificate field with a random key,
+// so hashes can be correlated within the process only.
+func DefaultCertificateRedactionPolicy() *CertificateRedactionPolicy {
+	key := make([]byte, sha256.Size)
+	_, _ = rand.Read(key)
+	return &CertificateRedactionPolicy{Default: RedactionHash, Key: key}
+}
+
+// RedactAuthMessage returns a copy of the message with redacted certificates, the original message is not modified.
+func (p *CertificateRedactionPolicy) RedactAuthMessage(msg *AuthMessage) *AuthMessage {
+	if msg == nil || msg.Certificates == nil {
+		return msg
+	}
+
+	redacted := *msg
+	certificates := p.RedactCertificates(*msg.Certificates)
+	redacted.Certificates = &certificates
+
+	return &redacted
+}
+
+// RedactCertificates returns copies of the certificates with redacted fields, the originals are not modified.
+func (p *CertificateRedactionPolicy) RedactCertificates(certs []certificates.VerifiableCertificate) []certificates.VerifiableCertificate {
+	if certs == nil {
+		return nil
+	}
+
+	redacted := make([]certificates.VerifiableCertificate, len(certs))
+	for i, cert := range certs {
+		redacted[i] = p.redactCertificate(cert)
+	}
+
+	return redacted
+}
+
+func (p *CertificateRedactionPolicy) redactCertificate(cert certificates.VerifiableCertificate) certificates.VerifiableCertificate {
+	if cert.Fields != nil {
+		fields := make(map[string]any, len(cert.Fields))
+		for name, value := range cert.Fields {
+			fields[name] = p.redactValue(name, fmt.Sprint(value))
+		}
+		cert.Fields = fields
+	}
+
+	if cert.DecryptedFields != nil {
+		decrypted := make(map[string]string, len(*cert.DecryptedFields))
+		for name, value := range *cert.DecryptedFields {
+			decrypted[name] = p.redactValue(name, value)
+		}
+		cert.DecryptedFields = &decrypted
+	}
+
+	if cert.Keyring != nil {
+		keyring := make(map[string]string, len(cert.Keyring))
+		for name := range cert.Keyring {
+			keyring[name] = redactedPlaceholder
+		}
+		cert.Keyring = keyring
+	}
+
+	return cert
+}
+
+func (p *CertificateRedactionPolicy) redactValue(field, value string) string {
+	mode, ok := p.Fields[field]
+	if !ok {
+		mode = p.Default
+	}
+
+	switch mode {
+	case RedactionNone:
+		return value
+	case RedactionHash:
+		if len(p.Key) == 0 {
+			return redactedPlaceholder
+		}
+		mac := hmac.New(sha256.New, p.Key)
+		mac.Write([]byte(value))
+		return "hmac-sha256:" + hex.EncodeToString(mac.Sum(nil))
+	case RedactionTruncate:
+		runes := []rune(value)
+		if len(runes) <= truncatedLength {
+			return redactedPlaceholder
+		}
+		return string(runes[:truncatedLength]) + "..."
+	default:
+		return redactedPlaceholder
+	}
+}
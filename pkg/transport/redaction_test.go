@@ -0,0 +1,98 @@
+package transport_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertificateRedactionPolicy_RedactCertificates(t *testing.T) {
+	decrypted := map[string]string{"email": "alice@example.com", "country": "PL"}
+	certificate := certificates.VerifiableCertificate{
+		Certificate: certificates.Certificate{
+			Type:   "age-verification",
+			Fields: map[string]any{"email": "encrypted-email", "country": "encrypted-country"},
+		},
+		Keyring:         map[string]string{"email": "key"},
+		DecryptedFields: &decrypted,
+	}
+
+	tests := []struct {
+		name     string
+		policy   *transport.CertificateRedactionPolicy
+		field    string
+		expected string
+	}{
+		{
+			name:     "none keeps value",
+			policy:   &transport.CertificateRedactionPolicy{Default: transport.RedactionNone},
+			field:    "email",
+			expected: "alice@example.com",
+		},
+		{
+			name:     "hash replaces value with keyed digest",
+			policy:   &transport.CertificateRedactionPolicy{Default: transport.RedactionHash, Key: []byte("secret")},
+			field:    "country",
+			expected: "hmac-sha256:ef3132574f81ad710c9f9da555bbcd20464c7c1acda2743878a02b2e2bd94b5b",
+		},
+		{
+			name:     "hash without key removes value",
+			policy:   &transport.CertificateRedactionPolicy{Default: transport.RedactionHash},
+			field:    "country",
+			expected: "[REDACTED]",
+		},
+		{
+			name:     "truncate keeps prefix",
+			policy:   &transport.CertificateRedactionPolicy{Fields: map[string]transport.RedactionMode{"email": transport.RedactionTruncate}},
+			field:    "email",
+			expected: "alic...",
+		},
+		{
+			name:     "truncate removes short value",
+			policy:   &transport.CertificateRedactionPolicy{Default: transport.RedactionTruncate},
+			field:    "country",
+			expected: "[REDACTED]",
+		},
+		{
+			name:     "field mode overrides default",
+			policy:   &transport.CertificateRedactionPolicy{Fields: map[string]transport.RedactionMode{"email": transport.RedactionRemove}},
+			field:    "email",
+			expected: "[REDACTED]",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// when
+			redacted := tc.policy.RedactCertificates([]certificates.VerifiableCertificate{certificate})
+
+			// then
+			require.Len(t, redacted, 1)
+			require.Equal(t, tc.expected, (*redacted[0].DecryptedFields)[tc.field])
+			require.Equal(t, "[REDACTED]", redacted[0].Keyring["email"])
+		})
+	}
+
+	t.Run("default policies hash with their own random key", func(t *testing.T) {
+		// when
+		first := transport.DefaultCertificateRedactionPolicy().RedactCertificates([]certificates.VerifiableCertificate{certificate})
+		second := transport.DefaultCertificateRedactionPolicy().RedactCertificates([]certificates.VerifiableCertificate{certificate})
+
+		// then
+		require.True(t, strings.HasPrefix((*first[0].DecryptedFields)["country"], "hmac-sha256:"))
+		require.NotEqual(t, (*first[0].DecryptedFields)["country"], (*second[0].DecryptedFields)["country"])
+	})
+
+	t.Run("original certificate is not modified", func(t *testing.T) {
+		// when
+		transport.DefaultCertificateRedactionPolicy().RedactCertificates([]certificates.VerifiableCertificate{certificate})
+
+		// then
+		require.Equal(t, "alice@example.com", decrypted["email"])
+		require.Equal(t, "encrypted-email", certificate.Fields["email"])
+		require.Equal(t, "key", certificate.Keyring["email"])
+	})
+}
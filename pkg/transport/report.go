@@ -0,0 +1,159 @@
+package transport
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+)
+
+// Names of the checks recorded in verification reports
+const (
+	// CheckSignature is the verification of the signature of the certificate response
+	CheckSignature = "signature"
+	// CheckDisclosure is the comparison of the fields revealed by the keyring with the requested fields
+	CheckDisclosure = "disclosure"
+	// CheckRevocation is the lookup of the revocation outpoints of the certificates, when a tracker is configured
+	CheckRevocation = "revocation"
+	// CheckCertifier is the comparison of the certifier with the requested certifiers
+	CheckCertifier = "certifier"
+	// CheckClientCertificate is the binding of the X.509 client certificate to the identity key
+	CheckClientCertificate = "clientCertificate"
+	// CheckCredentials is the verification of the verifiable credentials sent as certificates
+	CheckCredentials = "credentials"
+	// CheckSerialNumbers is the comparison of the serial numbers with previously accepted certificates
+	CheckSerialNumbers = "serialNumbers"
+	// CheckCallback is the decision of the OnCertificatesReceived callback
+	CheckCallback = "callback"
+)
+
+// Outcomes of certificate exchanges
+const (
+	// VerificationAccepted indicates the certificates were accepted and the session is authenticated
+	VerificationAccepted = "accepted"
+	// VerificationRejected indicates the certificate response was rejected with an error
+	VerificationRejected = "rejected"
+	// VerificationPending indicates the OnCertificatesReceived callback did not accept the certificates
+	VerificationPending = "pending"
+)
+
+// VerificationReport is the machine readable record of a certificate exchange, it lists which fields were requested,
+// disclosed and decrypted and which checks passed, as evidence of data minimization. Field values are never included.
+type VerificationReport struct {
+	// IdentityKey is the identity key of the peer
+	IdentityKey string `json:"identityKey"`
+	// Time is the time the certificate response was received
+	Time time.Time `json:"time"`
+	// Outcome is VerificationAccepted, VerificationRejected or VerificationPending
+	Outcome string `json:"outcome"`
+	// Error is the reason the certificate response was rejected
+	Error string `json:"error,omitempty"`
+	// CertificateErrors lists every certificate of a rejected certificate response with the reason it failed
+	CertificateErrors CertificateErrors `json:"certificateErrors,omitempty"`
+	// Checks are the checks of the exchange in the order they ran
+	Checks []VerificationCheck `json:"checks"`
+	// Certificates are the disclosures of the certificates of the exchange
+	Certificates []CertificateDisclosure `json:"certificates"`
+}
+
+// VerificationCheck is the result of a check of a verification report
+type VerificationCheck struct {
+	// Name is the name of the check, e.g. CheckSignature
+	Name string `json:"name"`
+	// Passed is true when the check succeeded
+	Passed bool `json:"passed"`
+	// Error is the reason the check failed
+	Error string `json:"error,omitempty"`
+}
+
+// CertificateDisclosure lists the requested, disclosed and decrypted fields of a certificate of a verification report
+type CertificateDisclosure struct {
+	Type         string `json:"type"`
+	SerialNumber string `json:"serialNumber"`
+	Certifier    string `json:"certifier"`
+	// Requested are the fields requested from certificates of the type
+	Requested []string `json:"requested"`
+	// Disclosed are the fields revealed by the keyring of the certificate
+	Disclosed []string `json:"disclosed"`
+	// Decrypted are the fields decrypted by the middleware, e.g. of bridged X.509 certificates and adapted credentials
+	Decrypted []string `json:"decrypted"`
+	// Checks are the certifier check and, for certificates disclosed with a keyring, the disclosure check of the certificate
+	Checks []VerificationCheck `json:"checks"`
+}
+
+// NewVerificationReport creates the report of a certificate exchange with the peer
+func NewVerificationReport(identityKey string, now time.Time) *VerificationReport {
+	return &VerificationReport{
+		IdentityKey:  identityKey,
+		Time:         now,
+		Checks:       []VerificationCheck{},
+		Certificates: []CertificateDisclosure{},
+	}
+}
+
+// Check records the result of the named check and returns its error, checks are not recorded on a nil report
+func (r *VerificationReport) Check(name string, err error) error {
+	if r != nil {
+		r.Checks = append(r.Checks, newVerificationCheck(name, err))
+	}
+	return err
+}
+
+// Complete records the disclosures of the certificates and the outcome of the exchange,
+// err is the error the certificate response was rejected with
+func (r *VerificationReport) Complete(certs []certificates.VerifiableCertificate, requested *RequestedCertificateSet, outcome string, err error) {
+	r.Outcome = outcome
+	if err != nil {
+		r.Outcome = VerificationRejected
+		r.Error = err.Error()
+		errors.As(err, &r.CertificateErrors)
+	}
+
+	if requested == nil {
+		requested = &RequestedCertificateSet{}
+	}
+	for _, cert := range certs {
+		disclosure := CertificateDisclosure{
+			Type:         cert.Type,
+			SerialNumber: cert.SerialNumber,
+			Certifier:    cert.Certifier,
+			Requested:    append([]string{}, requested.Types[cert.Type]...),
+			Disclosed:    fieldNames(cert.Keyring),
+			Decrypted:    []string{},
+		}
+
+		var certifierErr error
+		if !slices.Contains(requested.Certifiers, cert.Certifier) {
+			certifierErr = errCertifierNotRequested
+		}
+		disclosure.Checks = []VerificationCheck{newVerificationCheck(CheckCertifier, certifierErr)}
+
+		// certificates decrypted by the middleware were not disclosed with a keyring
+		if cert.DecryptedFields != nil {
+			disclosure.Decrypted = fieldNames(*cert.DecryptedFields)
+		} else {
+			disclosure.Checks = append(disclosure.Checks,
+				newVerificationCheck(CheckDisclosure, requested.CheckDisclosure([]certificates.VerifiableCertificate{cert})))
+		}
+
+		r.Certificates = append(r.Certificates, disclosure)
+	}
+}
+
+// errCertifierNotRequested is reported for certificates of certifiers which were not requested
+var errCertifierNotRequested = errors.New("certifier was not requested")
+
+// fieldNames returns the sorted field names of the keyring or the decrypted fields, without their values
+func fieldNames(fields map[string]string) []string {
+	return append([]string{}, slices.Sorted(maps.Keys(fields))...)
+}
+
+func newVerificationCheck(name string, err error) VerificationCheck {
+	check := VerificationCheck{Name: name, Passed: err == nil}
+	if err != nil {
+		check.Error = err.Error()
+	}
+	return check
+}
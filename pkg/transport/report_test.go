@@ -0,0 +1,69 @@
+package transport_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerificationReport(t *testing.T) {
+	requested := transport.NewRequestedCertificateSet(certifier).AddType(certificateType, "age")
+
+	t.Run("decrypted certificate lists its fields without disclosure check", func(t *testing.T) {
+		// given
+		report := transport.NewVerificationReport("identity", time.Now())
+		decrypted := map[string]string{"country": "CH", "age": "21"}
+
+		// when
+		report.Complete([]certificates.VerifiableCertificate{{
+			Certificate:     certificates.Certificate{Type: certificateType, SerialNumber: "serial-1", Certifier: "other"},
+			DecryptedFields: &decrypted,
+		}}, requested, transport.VerificationAccepted, nil)
+
+		// then
+		require.Equal(t, transport.VerificationAccepted, report.Outcome)
+		require.Equal(t, []transport.CertificateDisclosure{{
+			Type:         certificateType,
+			SerialNumber: "serial-1",
+			Certifier:    "other",
+			Requested:    []string{"age"},
+			Disclosed:    []string{},
+			Decrypted:    []string{"age", "country"},
+			Checks: []transport.VerificationCheck{
+				{Name: transport.CheckCertifier, Passed: false, Error: "certifier was not requested"},
+			},
+		}}, report.Certificates)
+	})
+
+	t.Run("error rejects the exchange", func(t *testing.T) {
+		// given
+		report := transport.NewVerificationReport("identity", time.Now())
+		err := report.Check(transport.CheckSignature, transport.ErrInvalidSignature)
+
+		// when
+		report.Complete(nil, nil, transport.VerificationAccepted, err)
+
+		// then
+		require.Equal(t, transport.VerificationRejected, report.Outcome)
+		require.Equal(t, transport.ErrInvalidSignature.Error(), report.Error)
+		require.Equal(t, []transport.VerificationCheck{
+			{Name: transport.CheckSignature, Error: transport.ErrInvalidSignature.Error()},
+		}, report.Checks)
+		require.Empty(t, report.Certificates)
+	})
+
+	t.Run("checks are not recorded on a nil report", func(t *testing.T) {
+		// given
+		var report *transport.VerificationReport
+
+		// when
+		err := report.Check(transport.CheckSignature, errors.New("failed"))
+
+		// then
+		require.EqualError(t, err, "failed")
+	})
+}
@@ -0,0 +1,322 @@
+package transport
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// ErrInvalidRevocation is returned when a revocation names no subject or an identity key which is not a public key
+var ErrInvalidRevocation = errors.New("invalid revocation")
+
+// RevocationKind is the kind of subject a revocation cuts off
+type RevocationKind string
+
+// Kinds of revocations
+const (
+	// RevokedIdentity revokes every session of an identity key and rejects its handshakes
+	RevokedIdentity RevocationKind = "identity"
+	// RevokedSession revokes the session of a session nonce
+	RevokedSession RevocationKind = "session"
+)
+
+// RevocationSubject is the identity key or session nonce a revocation cuts off
+type RevocationSubject struct {
+	Kind  RevocationKind `json:"kind"`
+	Value string         `json:"value"`
+}
+
+// normalize validates the subject and lower-cases identity keys, session nonces are case-sensitive
+func (s RevocationSubject) normalize() (RevocationSubject, error) {
+	switch s.Kind {
+	case RevokedIdentity:
+		if _, err := ec.PublicKeyFromString(s.Value); err != nil {
+			return s, fmt.Errorf("%w, identity key %q is not a public key", ErrInvalidRevocation, s.Value)
+		}
+		s.Value = strings.ToLower(s.Value)
+	case RevokedSession:
+		if s.Value == "" {
+			return s, fmt.Errorf("%w, session nonce is empty", ErrInvalidRevocation)
+		}
+	default:
+		return s, fmt.Errorf("%w, unknown kind %q", ErrInvalidRevocation, s.Kind)
+	}
+	return s, nil
+}
+
+// Revocation cuts off an identity key or a session nonce
+type Revocation struct {
+	RevocationSubject
+	// Reason is recorded for the operators, it is not sent to the peer
+	Reason string `json:"reason,omitempty"`
+	// RevokedAt is the time of the revocation, set by RevocationList.Revoke when zero
+	RevokedAt time.Time `json:"revokedAt"`
+}
+
+// RevocationStore is the authoritative store of revocations, e.g. a database shared by the instances of a deployment.
+// Subjects are passed normalized, identity keys lower-cased.
+type RevocationStore interface {
+	// Add stores the revocation, a revocation of the same subject is replaced
+	Add(ctx context.Context, revocation Revocation) error
+	// Remove deletes the revocation of the subject, it reports whether the subject was revoked
+	Remove(ctx context.Context, subject RevocationSubject) (bool, error)
+	// Contains reports whether the subject is revoked
+	Contains(ctx context.Context, subject RevocationSubject) (bool, error)
+	// List returns every revocation
+	List(ctx context.Context) ([]Revocation, error)
+}
+
+// MemoryRevocationStore is an in-memory RevocationStore, for deployments of a single instance
+type MemoryRevocationStore struct {
+	mu          sync.RWMutex
+	revocations map[RevocationSubject]Revocation
+}
+
+// NewMemoryRevocationStore creates an empty store
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{revocations: make(map[RevocationSubject]Revocation)}
+}
+
+// Add implements RevocationStore
+func (s *MemoryRevocationStore) Add(_ context.Context, revocation Revocation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revocations[revocation.RevocationSubject] = revocation
+	return nil
+}
+
+// Remove implements RevocationStore
+func (s *MemoryRevocationStore) Remove(_ context.Context, subject RevocationSubject) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.revocations[subject]
+	delete(s.revocations, subject)
+	return ok, nil
+}
+
+// Contains implements RevocationStore
+func (s *MemoryRevocationStore) Contains(_ context.Context, subject RevocationSubject) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.revocations[subject]
+	return ok, nil
+}
+
+// List implements RevocationStore
+func (s *MemoryRevocationStore) List(_ context.Context) ([]Revocation, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	revocations := make([]Revocation, 0, len(s.revocations))
+	for _, revocation := range s.revocations {
+		revocations = append(revocations, revocation)
+	}
+	return revocations, nil
+}
+
+// RevocationList checks the identity keys and session nonces of every request against the revocations of a
+// RevocationStore, so a revoked peer is cut off on its next request instead of when its session expires.
+// A bloom filter of the revoked subjects answers checks of subjects which are not revoked without a store lookup,
+// only subjects matching the filter are looked up in the store.
+// Revocations made through the list apply right away, revocations added to the store by other instances of a
+// deployment apply once the filter is rebuilt with Reload, e.g. periodically.
+// It is safe for concurrent use, a nil list revokes nothing.
+type RevocationList struct {
+	store RevocationStore
+	// writeMu serializes revocations and reloads, so a reload cannot drop a revocation added while it lists the store
+	writeMu sync.Mutex
+	mu      sync.RWMutex
+	filter  *revocationFilter
+}
+
+// NewRevocationList creates a list of the revocations of the store, an in-memory store is used when nil
+func NewRevocationList(ctx context.Context, store RevocationStore) (*RevocationList, error) {
+	if store == nil {
+		store = NewMemoryRevocationStore()
+	}
+
+	l := &RevocationList{store: store}
+	if err := l.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Revoke stores the revocation, requests of its subject are rejected with ErrRevoked from now on
+func (l *RevocationList) Revoke(ctx context.Context, revocation Revocation) error {
+	subject, err := revocation.normalize()
+	if err != nil {
+		return err
+	}
+	revocation.RevocationSubject = subject
+	if revocation.RevokedAt.IsZero() {
+		revocation.RevokedAt = time.Now()
+	}
+
+	l.writeMu.Lock()
+	defer l.writeMu.Unlock()
+	if err := l.store.Add(ctx, revocation); err != nil {
+		return fmt.Errorf("%w, failed to store revocation, %w", ErrRevocationUnavailable, err)
+	}
+
+	l.mu.RLock()
+	full := l.filter.full()
+	l.mu.RUnlock()
+	if full {
+		// the filter is rebuilt with the capacity for the grown list, so it stays selective
+		return l.reload(ctx)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.filter.add(subject)
+	return nil
+}
+
+// Restore removes the revocation of the subject, it reports whether the subject was revoked
+func (l *RevocationList) Restore(ctx context.Context, subject RevocationSubject) (bool, error) {
+	subject, err := subject.normalize()
+	if err != nil {
+		return false, err
+	}
+
+	l.writeMu.Lock()
+	defer l.writeMu.Unlock()
+	removed, err := l.store.Remove(ctx, subject)
+	if err != nil {
+		return false, fmt.Errorf("%w, failed to remove revocation, %w", ErrRevocationUnavailable, err)
+	}
+	if !removed {
+		return false, nil
+	}
+	// a bloom filter cannot forget a subject, it is rebuilt without it
+	return true, l.reload(ctx)
+}
+
+// IsRevoked reports whether the subject is revoked, store failures are returned as ErrRevocationUnavailable
+func (l *RevocationList) IsRevoked(ctx context.Context, subject RevocationSubject) (bool, error) {
+	if l == nil {
+		return false, nil
+	}
+	if subject.Kind == RevokedIdentity {
+		subject.Value = strings.ToLower(subject.Value)
+	}
+
+	l.mu.RLock()
+	candidate := l.filter.mayContain(subject)
+	l.mu.RUnlock()
+	if !candidate {
+		return false, nil
+	}
+
+	revoked, err := l.store.Contains(ctx, subject)
+	if err != nil {
+		return false, fmt.Errorf("%w, %w", ErrRevocationUnavailable, err)
+	}
+	return revoked, nil
+}
+
+// List returns the revocations of the store, sorted by kind and subject
+func (l *RevocationList) List(ctx context.Context) ([]Revocation, error) {
+	if l == nil {
+		return nil, nil
+	}
+
+	revocations, err := l.store.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w, failed to list revocations, %w", ErrRevocationUnavailable, err)
+	}
+	sort.Slice(revocations, func(i, j int) bool {
+		if revocations[i].Kind != revocations[j].Kind {
+			return revocations[i].Kind < revocations[j].Kind
+		}
+		return revocations[i].Value < revocations[j].Value
+	})
+	return revocations, nil
+}
+
+// Reload rebuilds the filter from the revocations of the store, sized for their number
+func (l *RevocationList) Reload(ctx context.Context) error {
+	l.writeMu.Lock()
+	defer l.writeMu.Unlock()
+	return l.reload(ctx)
+}
+
+// reload rebuilds the filter, the caller holds writeMu
+func (l *RevocationList) reload(ctx context.Context) error {
+	revocations, err := l.store.List(ctx)
+	if err != nil {
+		return fmt.Errorf("%w, failed to load revocations, %w", ErrRevocationUnavailable, err)
+	}
+
+	filter := newRevocationFilter(len(revocations))
+	for _, revocation := range revocations {
+		filter.add(revocation.RevocationSubject)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.filter = filter
+	return nil
+}
+
+// Bloom filter parameters: 10 bits and 7 hashes per subject keep false positives below 1%
+const (
+	revocationFilterBitsPerSubject = 10
+	revocationFilterHashes         = 7
+	revocationFilterMinCapacity    = 1024
+)
+
+// revocationFilter is a bloom filter of revoked subjects, it never misses a subject added to it
+type revocationFilter struct {
+	bits     []uint64
+	capacity int
+	count    int
+}
+
+func newRevocationFilter(subjects int) *revocationFilter {
+	capacity := max(2*subjects, revocationFilterMinCapacity)
+	return &revocationFilter{
+		bits:     make([]uint64, (capacity*revocationFilterBitsPerSubject+63)/64),
+		capacity: capacity,
+	}
+}
+
+func (f *revocationFilter) full() bool {
+	return f.count >= f.capacity
+}
+
+func (f *revocationFilter) add(subject RevocationSubject) {
+	h1, h2 := revocationHashes(subject)
+	size := uint64(len(f.bits) * 64)
+	for i := uint64(0); i < revocationFilterHashes; i++ {
+		bit := (h1 + i*h2) % size
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+	f.count++
+}
+
+func (f *revocationFilter) mayContain(subject RevocationSubject) bool {
+	h1, h2 := revocationHashes(subject)
+	size := uint64(len(f.bits) * 64)
+	for i := uint64(0); i < revocationFilterHashes; i++ {
+		bit := (h1 + i*h2) % size
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// revocationHashes returns the two hashes of the subject the bit positions are derived from (double hashing)
+func revocationHashes(subject RevocationSubject) (uint64, uint64) {
+	sum := sha256.Sum256([]byte(string(subject.Kind) + ":" + subject.Value))
+	return binary.BigEndian.Uint64(sum[:8]), binary.BigEndian.Uint64(sum[8:16]) | 1
+}
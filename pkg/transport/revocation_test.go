@@ -0,0 +1,160 @@
+package transport_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+// countingRevocationStore counts the lookups of the store and fails them when err is set
+type countingRevocationStore struct {
+	*transport.MemoryRevocationStore
+	lookups atomic.Int64
+	err     error
+}
+
+func (s *countingRevocationStore) Contains(ctx context.Context, subject transport.RevocationSubject) (bool, error) {
+	s.lookups.Add(1)
+	if s.err != nil {
+		return false, s.err
+	}
+	return s.MemoryRevocationStore.Contains(ctx, subject)
+}
+
+func TestRevocationList(t *testing.T) {
+	ctx := context.Background()
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	identity := transport.RevocationSubject{Kind: transport.RevokedIdentity, Value: key.PubKey().ToDERHex()}
+
+	t.Run("revoked subjects are revoked until the revocation is lifted", func(t *testing.T) {
+		// given
+		list, err := transport.NewRevocationList(ctx, nil)
+		require.NoError(t, err)
+		session := transport.RevocationSubject{Kind: transport.RevokedSession, Value: "c2Vzc2lvbg=="}
+
+		// when
+		require.NoError(t, list.Revoke(ctx, transport.Revocation{RevocationSubject: identity, Reason: "compromised"}))
+		require.NoError(t, list.Revoke(ctx, transport.Revocation{RevocationSubject: session}))
+
+		// then
+		for _, subject := range []transport.RevocationSubject{identity, session, {Kind: identity.Kind, Value: strings.ToUpper(identity.Value)}} {
+			revoked, err := list.IsRevoked(ctx, subject)
+			require.NoError(t, err)
+			require.True(t, revoked, subject)
+		}
+		revocations, err := list.List(ctx)
+		require.NoError(t, err)
+		require.Len(t, revocations, 2)
+		require.Equal(t, "compromised", revocations[0].Reason)
+		require.False(t, revocations[0].RevokedAt.IsZero())
+
+		// when
+		restored, err := list.Restore(ctx, identity)
+
+		// then
+		require.NoError(t, err)
+		require.True(t, restored)
+		revoked, err := list.IsRevoked(ctx, identity)
+		require.NoError(t, err)
+		require.False(t, revoked)
+	})
+
+	t.Run("subjects which are not revoked are not looked up in the store", func(t *testing.T) {
+		// given
+		store := &countingRevocationStore{MemoryRevocationStore: transport.NewMemoryRevocationStore()}
+		list, err := transport.NewRevocationList(ctx, store)
+		require.NoError(t, err)
+		require.NoError(t, list.Revoke(ctx, transport.Revocation{RevocationSubject: identity}))
+
+		// when
+		for i := range 1000 {
+			revoked, err := list.IsRevoked(ctx, transport.RevocationSubject{Kind: transport.RevokedSession, Value: fmt.Sprintf("nonce-%d", i)})
+			require.NoError(t, err)
+			require.False(t, revoked)
+		}
+
+		// then
+		require.Less(t, store.lookups.Load(), int64(20))
+	})
+
+	t.Run("list grows beyond the capacity of its filter", func(t *testing.T) {
+		// given
+		list, err := transport.NewRevocationList(ctx, nil)
+		require.NoError(t, err)
+
+		// when
+		for i := range 3000 {
+			require.NoError(t, list.Revoke(ctx, transport.Revocation{
+				RevocationSubject: transport.RevocationSubject{Kind: transport.RevokedSession, Value: fmt.Sprintf("nonce-%d", i)},
+			}))
+		}
+
+		// then
+		for i := range 3000 {
+			revoked, err := list.IsRevoked(ctx, transport.RevocationSubject{Kind: transport.RevokedSession, Value: fmt.Sprintf("nonce-%d", i)})
+			require.NoError(t, err)
+			require.True(t, revoked)
+		}
+	})
+
+	t.Run("revocations of other instances apply after a reload", func(t *testing.T) {
+		// given
+		store := transport.NewMemoryRevocationStore()
+		list, err := transport.NewRevocationList(ctx, store)
+		require.NoError(t, err)
+		require.NoError(t, store.Add(ctx, transport.Revocation{RevocationSubject: identity}))
+
+		// when
+		require.NoError(t, list.Reload(ctx))
+
+		// then
+		revoked, err := list.IsRevoked(ctx, identity)
+		require.NoError(t, err)
+		require.True(t, revoked)
+	})
+
+	t.Run("store failure is reported as unavailability", func(t *testing.T) {
+		// given
+		store := &countingRevocationStore{MemoryRevocationStore: transport.NewMemoryRevocationStore()}
+		list, err := transport.NewRevocationList(ctx, store)
+		require.NoError(t, err)
+		require.NoError(t, list.Revoke(ctx, transport.Revocation{RevocationSubject: identity}))
+		store.err = errors.New("store down")
+
+		// when
+		_, err = list.IsRevoked(ctx, identity)
+
+		// then
+		require.ErrorIs(t, err, transport.ErrRevocationUnavailable)
+		require.Equal(t, transport.ErrCodeRevocationUnavailable, transport.ErrorCode(err))
+	})
+
+	t.Run("invalid subjects are rejected", func(t *testing.T) {
+		list, err := transport.NewRevocationList(ctx, nil)
+		require.NoError(t, err)
+
+		for name, subject := range map[string]transport.RevocationSubject{
+			"identity key is not a public key": {Kind: transport.RevokedIdentity, Value: "key"},
+			"empty session nonce":              {Kind: transport.RevokedSession},
+			"unknown kind":                     {Kind: "device", Value: "laptop"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				require.ErrorIs(t, list.Revoke(ctx, transport.Revocation{RevocationSubject: subject}), transport.ErrInvalidRevocation)
+			})
+		}
+	})
+
+	t.Run("nil list revokes nothing", func(t *testing.T) {
+		revoked, err := (*transport.RevocationList)(nil).IsRevoked(ctx, identity)
+		require.NoError(t, err)
+		require.False(t, revoked)
+	})
+}
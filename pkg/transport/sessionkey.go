@@ -0,0 +1,95 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// SessionKeyProtocolPrefix prefixes the labels of session keys in the protocol IDs passed to the wallet,
+// so keys of applications never collide with the keys of the auth protocol
+const SessionKeyProtocolPrefix = "session key "
+
+// ErrInvalidSessionKey is returned by NewSessionKey for incomplete sessions and invalid labels
+var ErrInvalidSessionKey = errors.New("invalid session key")
+
+// sessionKeyLabel matches labels accepted in protocol IDs by the wallet
+var sessionKeyLabel = regexp.MustCompile(`^[a-z0-9]+( [a-z0-9]+)*$`)
+
+// SessionKeyConfig identifies a session key. Both peers derive the same symmetric key from the identity key
+// of the other peer, the nonces of the session and the label, the server and client nonces are passed
+// in the same order on both sides.
+type SessionKeyConfig struct {
+	Wallet wallet.WalletInterface
+	// Counterparty is the identity key (hex) of the other peer of the session
+	Counterparty string
+	// ServerNonce is the session nonce created by the server in the initialResponse
+	ServerNonce string
+	// ClientNonce is the initial nonce sent by the client in the initialRequest
+	ClientNonce string
+	// Label separates the keys of a session by purpose, e.g. "chat messages", lowercase letters, numbers and single spaces
+	Label string
+	// PrivilegedKeys derives the key from the privileged keyring of the wallet
+	PrivilegedKeys PrivilegedKeys
+}
+
+// SessionKey encrypts application data end-to-end for the other peer of an authenticated session,
+// with a symmetric key the wallets of both peers derive for the session and label
+type SessionKey struct {
+	wallet wallet.WalletInterface
+	args   wallet.EncryptionArgs
+}
+
+// NewSessionKey creates the session key identified by the config
+func NewSessionKey(cfg SessionKeyConfig) (*SessionKey, error) {
+	if cfg.Wallet == nil {
+		return nil, fmt.Errorf("%w: no wallet", ErrInvalidSessionKey)
+	}
+	if cfg.ServerNonce == "" || cfg.ClientNonce == "" {
+		return nil, fmt.Errorf("%w: session nonces are missing", ErrInvalidSessionKey)
+	}
+	if !sessionKeyLabel.MatchString(cfg.Label) {
+		return nil, fmt.Errorf("%w: label %q has to consist of lowercase letters, numbers and single spaces", ErrInvalidSessionKey, cfg.Label)
+	}
+
+	counterparty, err := ec.PublicKeyFromString(cfg.Counterparty)
+	if err != nil {
+		return nil, fmt.Errorf("%w: counterparty is not a valid public key", ErrInvalidSessionKey)
+	}
+
+	return &SessionKey{
+		wallet: cfg.Wallet,
+		args: cfg.PrivilegedKeys.Apply(wallet.EncryptionArgs{
+			ProtocolID: wallet.Protocol{
+				SecurityLevel: wallet.SecurityLevelEveryAppAndCounterparty,
+				Protocol:      SessionKeyProtocolPrefix + cfg.Label,
+			},
+			KeyID: fmt.Sprintf("%s %s", cfg.ServerNonce, cfg.ClientNonce),
+			Counterparty: wallet.Counterparty{
+				Type:         wallet.CounterpartyTypeOther,
+				Counterparty: counterparty,
+			},
+		}),
+	}, nil
+}
+
+// Encrypt encrypts the plaintext for the other peer of the session
+func (k *SessionKey) Encrypt(plaintext []byte) ([]byte, error) {
+	result, err := k.wallet.Encrypt(&wallet.EncryptArgs{EncryptionArgs: k.args, Plaintext: plaintext}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt with session key, %w", err)
+	}
+	return result.Ciphertext, nil
+}
+
+// Decrypt decrypts a ciphertext encrypted by the other peer of the session with the session key of the same label
+func (k *SessionKey) Decrypt(ciphertext []byte) ([]byte, error) {
+	result, err := k.wallet.Decrypt(&wallet.DecryptArgs{EncryptionArgs: k.args, Ciphertext: ciphertext}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt with session key, %w", err)
+	}
+	return result.Plaintext, nil
+}
@@ -1,9 +1,15 @@
 package transport
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
 )
 
 type contextKey string
@@ -15,8 +21,25 @@ const (
 	IdentityKey contextKey = "identity"
 	// RequestID is the key used to store the request ID in the context.
 	RequestID contextKey = "requestID"
+	// SessionNonce is the key used to store the nonce of the session which authenticated the request in the context.
+	SessionNonce contextKey = "sessionNonce"
+	// Anonymous is the key used to mark requests of anonymous sessions in the context.
+	Anonymous contextKey = "anonymous"
+	// NegotiatedCapabilities is the key used to store the capabilities negotiated for the session of the request in the context.
+	NegotiatedCapabilities contextKey = "capabilities"
+	// Principal is the key used to store the identity key of the principal an agent key authenticated for in the context.
+	Principal contextKey = "principal"
 )
 
+// AnyoneIdentityKey is the identity key of the well-known "anyone" private key (1),
+// used by peers which sign their messages without an identity of their own
+const AnyoneIdentityKey = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+
+// IsAnyoneIdentityKey reports whether the identity key is the well-known "anyone" key
+func IsAnyoneIdentityKey(identityKey string) bool {
+	return strings.EqualFold(identityKey, AnyoneIdentityKey)
+}
+
 // Definition of the Message Types used in the authentication process.
 const (
 	// InitialRequest is the first message sent by the client to the server.
@@ -29,6 +52,19 @@ const (
 	CertificateResponse MessageType = "certificateResponse"
 	// General is a normal endpoint authorized by middleware.
 	General MessageType = "general"
+	// ReauthenticationRequest is the challenge sent by the server over a connection-scoped session.
+	ReauthenticationRequest MessageType = "reauthenticationRequest"
+	// ReauthenticationResponse is the answer of the peer to the reauthentication request.
+	ReauthenticationResponse MessageType = "reauthenticationResponse"
+	// GeneralBatch carries several general messages under a single signature, used by non-HTTP transports.
+	GeneralBatch MessageType = "generalBatch"
+	// Heartbeat refreshes the session of the peer without a general request, used by long-lived connections.
+	Heartbeat MessageType = "heartbeat"
+	// AllowlistExchange exchanges the allowlists of federated deployments, which pin each other in their trust registries.
+	AllowlistExchange MessageType = "allowlistExchange"
+	// ExchangeAborted is sent in place of the certificateResponse when the server aborted the certificate exchange,
+	// its payload is the AbortReason.
+	ExchangeAborted MessageType = "exchangeAborted"
 )
 
 // MessageType represents the type of message sent between peers during the authentication process.
@@ -36,28 +72,132 @@ type MessageType string
 
 // AuthMessage represents a type message sent between peers during the authentication process.
 type AuthMessage struct {
-	Version               string                          `json:"version"`
-	MessageType           MessageType                     `json:"messageType"`
-	IdentityKey           string                          `json:"identityKey"`
-	Nonce                 *string                         `json:"nonce,omitempty"`
-	InitialNonce          string                          `json:"initialNonce"`
-	YourNonce             *string                         `json:"yourNonce,omitempty"`
-	Payload               *[]byte                         `json:"payload,omitempty"`
-	Signature             *[]byte                         `json:"signature,omitempty"`
-	Certificates          *[]wallet.VerifiableCertificate `json:"certificates"`
-	RequestedCertificates RequestedCertificateSet         `json:"requestedCertificates"`
+	Version               string                                `json:"version"`
+	MessageType           MessageType                           `json:"messageType"`
+	IdentityKey           string                                `json:"identityKey"`
+	Nonce                 *string                               `json:"nonce,omitempty"`
+	InitialNonce          string                                `json:"initialNonce"`
+	YourNonce             *string                               `json:"yourNonce,omitempty"`
+	Payload               *[]byte                               `json:"payload,omitempty"`
+	Signature             *[]byte                               `json:"signature,omitempty"`
+	Certificates          *[]certificates.VerifiableCertificate `json:"certificates"`
+	RequestedCertificates RequestedCertificateSet               `json:"requestedCertificates"`
+	// ConnectionScoped is set in the initial response of transports binding the session to the connection.
+	ConnectionScoped bool `json:"connectionScoped,omitempty"`
+	// CompressedCertificates carries the certificates of a certificateResponse in place of Certificates,
+	// compressed with a CertificateCompression negotiated in the handshake
+	CompressedCertificates *CompressedCertificates `json:"compressedCertificates,omitempty"`
+	// PayloadEncryption is set in the handshake to negotiate encryption of general message bodies.
+	PayloadEncryption bool `json:"payloadEncryption,omitempty"`
+	// PayloadPadding is set in the handshake to negotiate padding of general message bodies to size buckets, see PadPayload.
+	PayloadPadding bool `json:"payloadPadding,omitempty"`
+	// Capabilities are offered in the initialRequest and negotiated in the initialResponse, see Capability.
+	Capabilities []Capability `json:"capabilities,omitempty"`
+	// Extensions holds the fields unknown to this version of the protocol, they are kept when decoding
+	// and re-emitted when encoding, so messages forwarded by middle-boxes keep future protocol extensions.
+	Extensions map[string]json.RawMessage `json:"-"`
+}
+
+// authMessageFields are the JSON fields known to this version of AuthMessage
+var authMessageFields = map[string]struct{}{
+	"version": {}, "messageType": {}, "identityKey": {}, "nonce": {}, "initialNonce": {}, "yourNonce": {},
+	"payload": {}, "signature": {}, "certificates": {}, "requestedCertificates": {}, "payloadEncryption": {},
+	"payloadPadding": {}, "capabilities": {}, "compressedCertificates": {}, "connectionScoped": {},
 }
 
-// RequestedCertificateSet represents the set of certificates requested by a peer.
-type RequestedCertificateSet struct {
-	Certifiers []string            `json:"certifiers"`
-	Types      map[string][]string `json:"types"`
+// authMessageJSON has the fields of AuthMessage without its JSON methods
+type authMessageJSON AuthMessage
+
+// UnmarshalJSON decodes the message and retains unknown fields in Extensions
+func (m *AuthMessage) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, (*authMessageJSON)(m)); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	m.Extensions = nil
+	for name, value := range fields {
+		if _, known := authMessageFields[name]; known {
+			continue
+		}
+		if m.Extensions == nil {
+			m.Extensions = make(map[string]json.RawMessage)
+		}
+		m.Extensions[name] = value
+	}
+
+	return nil
+}
+
+// MarshalJSON encodes the message together with its Extensions, known fields take precedence over extensions.
+// Messages without extensions take the fast path of AppendJSON.
+func (m AuthMessage) MarshalJSON() ([]byte, error) {
+	if len(m.Extensions) == 0 {
+		return m.appendFields(nil)
+	}
+
+	data, err := json.Marshal(authMessageJSON(m))
+	if err != nil || len(m.Extensions) == 0 {
+		return data, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	for name, value := range m.Extensions {
+		if _, known := authMessageFields[name]; !known {
+			fields[name] = value
+		}
+	}
+
+	return json.Marshal(fields)
+}
+
+// ErrReservedExtension is returned by AuthMessage.SetExtension for names of fields of the protocol
+var ErrReservedExtension = errors.New("extension name is a field of the auth message")
+
+// SetExtension encodes the value as JSON and sets it as the extension field of the given name,
+// names of the fields of the protocol are rejected with ErrReservedExtension
+func (m *AuthMessage) SetExtension(name string, value any) error {
+	if _, known := authMessageFields[name]; known {
+		return fmt.Errorf("%w: %s", ErrReservedExtension, name)
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode extension %s, %w", name, err)
+	}
+
+	if m.Extensions == nil {
+		m.Extensions = make(map[string]json.RawMessage)
+	}
+	m.Extensions[name] = encoded
+	return nil
+}
+
+// Extension decodes the extension field of the given name into value, it returns false when the message has no such extension
+func (m *AuthMessage) Extension(name string, value any) (bool, error) {
+	encoded, ok := m.Extensions[name]
+	if !ok {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(encoded, value); err != nil {
+		return true, fmt.Errorf("failed to decode extension %s, %w", name, err)
+	}
+	return true, nil
 }
 
 // OnCertificatesReceivedFunc callback type for handling received certificates
 type OnCertificatesReceivedFunc func(
 	senderPublicKey string,
-	certs *[]wallet.VerifiableCertificate,
+	certs *[]certificates.VerifiableCertificate,
 	req *http.Request,
 	res http.ResponseWriter,
 	next func(),
@@ -70,3 +210,66 @@ type MessageCallback func(message AuthMessage) error
 func (m *MessageType) String() string {
 	return string(*m)
 }
+
+// OriginBinding configures which origins general requests may be bound to, see utils.GeneralKeyID
+type OriginBinding struct {
+	// Origins are the origins of the server accepted in the x-bsv-auth-origin header, e.g. https://api.example.com.
+	// Requests bound to any other origin are rejected.
+	Origins []string
+	// Required rejects requests which are not bound to an origin
+	Required bool
+}
+
+// Accepts reports whether the origin is one of the origins of the server, ignoring case and a trailing slash
+func (b OriginBinding) Accepts(origin string) bool {
+	origin = strings.TrimSuffix(origin, "/")
+	for _, accepted := range b.Origins {
+		if strings.EqualFold(strings.TrimSuffix(accepted, "/"), origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultPrivilegedReason is passed to the wallet with privileged operations when no reason is configured
+const DefaultPrivilegedReason = "Mutual authentication of HTTP requests"
+
+// PrivilegedKeys selects the privileged keyring of the wallet for the identity key, signatures and payload encryption,
+// for deployments whose wallet keeps the server keys apart from its everyday keys
+type PrivilegedKeys struct {
+	// Enabled sets Privileged in the arguments of every wallet call of the auth protocol
+	Enabled bool
+	// Reason is passed as PrivilegedReason, wallets which ask for approval show it to the operator.
+	// Defaults to DefaultPrivilegedReason
+	Reason string
+}
+
+// Apply sets the privileged fields of the wallet arguments when privileged keys are enabled
+func (p PrivilegedKeys) Apply(args wallet.EncryptionArgs) wallet.EncryptionArgs {
+	if !p.Enabled {
+		return args
+	}
+
+	args.Privileged = true
+	args.PrivilegedReason = p.Reason
+	if args.PrivilegedReason == "" {
+		args.PrivilegedReason = DefaultPrivilegedReason
+	}
+	return args
+}
+
+// IdentityKeyArgs returns the wallet arguments of the identity key, privileged when privileged keys are enabled
+func (p PrivilegedKeys) IdentityKeyArgs() *wallet.GetPublicKeyArgs {
+	return &wallet.GetPublicKeyArgs{EncryptionArgs: p.Apply(wallet.EncryptionArgs{}), IdentityKey: true}
+}
+
+// WalletTimeouts limits how long wallet operations may take while a message is processed,
+// a zero timeout leaves the operation unlimited
+type WalletTimeouts struct {
+	// CreateNonce limits the creation of session and response nonces
+	CreateNonce time.Duration
+	// CreateSignature limits the signing of handshake messages and responses
+	CreateSignature time.Duration
+	// VerifySignature limits the verification of peer signatures
+	VerifySignature time.Duration
+}
@@ -0,0 +1,173 @@
+package transport_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMessage_UnknownFields(t *testing.T) {
+	t.Run("unknown fields are retained and re-emitted", func(t *testing.T) {
+		// given
+		data := []byte(`{"version":"0.1","messageType":"initialRequest","identityKey":"key","initialNonce":"nonce",` +
+			`"sessionTTL":3600,"encodings":{"compression":["gzip"]}}`)
+
+		// when
+		var msg transport.AuthMessage
+		require.NoError(t, json.Unmarshal(data, &msg))
+		encoded, err := json.Marshal(msg)
+		require.NoError(t, err)
+
+		// then
+		require.Equal(t, transport.InitialRequest, msg.MessageType)
+		require.Equal(t, "key", msg.IdentityKey)
+		require.Equal(t, map[string]json.RawMessage{
+			"sessionTTL": json.RawMessage(`3600`),
+			"encodings":  json.RawMessage(`{"compression":["gzip"]}`),
+		}, msg.Extensions)
+
+		var fields map[string]any
+		require.NoError(t, json.Unmarshal(encoded, &fields))
+		require.Equal(t, "initialRequest", fields["messageType"])
+		require.InDelta(t, 3600, fields["sessionTTL"], 0)
+		require.Equal(t, map[string]any{"compression": []any{"gzip"}}, fields["encodings"])
+	})
+
+	t.Run("message without unknown fields has no extensions", func(t *testing.T) {
+		// given
+		msg := transport.AuthMessage{
+			Version:               transport.AuthVersion,
+			MessageType:           transport.General,
+			IdentityKey:           "key",
+			RequestedCertificates: *transport.NewRequestedCertificateSet(),
+		}
+
+		// when
+		encoded, err := json.Marshal(msg)
+		require.NoError(t, err)
+		var decoded transport.AuthMessage
+		require.NoError(t, json.Unmarshal(encoded, &decoded))
+
+		// then
+		require.Nil(t, decoded.Extensions)
+		require.Equal(t, msg, decoded)
+	})
+
+	t.Run("extensions do not override known fields", func(t *testing.T) {
+		// given
+		msg := transport.AuthMessage{
+			Version:     transport.AuthVersion,
+			MessageType: transport.General,
+			Extensions:  map[string]json.RawMessage{"messageType": json.RawMessage(`"initialRequest"`)},
+		}
+
+		// when
+		encoded, err := json.Marshal(msg)
+		require.NoError(t, err)
+		var decoded transport.AuthMessage
+		require.NoError(t, json.Unmarshal(encoded, &decoded))
+
+		// then
+		require.Equal(t, transport.General, decoded.MessageType)
+		require.Nil(t, decoded.Extensions)
+	})
+
+	t.Run("pointer and value encode the same", func(t *testing.T) {
+		// given
+		msg := transport.AuthMessage{Version: transport.AuthVersion, Extensions: map[string]json.RawMessage{"x": json.RawMessage(`1`)}}
+
+		// when
+		fromValue, err := json.Marshal(msg)
+		require.NoError(t, err)
+		fromPointer, err := json.Marshal(&msg)
+		require.NoError(t, err)
+
+		// then
+		require.JSONEq(t, string(fromValue), string(fromPointer))
+	})
+}
+
+func TestIsAnyoneIdentityKey(t *testing.T) {
+	// given
+	_, anyone := wallet.AnyoneKey()
+	identityKey := anyone.ToDERHex()
+
+	// then
+	require.Equal(t, transport.AnyoneIdentityKey, identityKey)
+	require.True(t, transport.IsAnyoneIdentityKey(identityKey))
+	require.True(t, transport.IsAnyoneIdentityKey(strings.ToUpper(identityKey)))
+	require.False(t, transport.IsAnyoneIdentityKey("03"+identityKey[2:]))
+}
+
+func TestPrivilegedKeys_Apply(t *testing.T) {
+	args := wallet.EncryptionArgs{ProtocolID: wallet.DefaultAuthProtocol, KeyID: "1"}
+
+	tests := map[string]struct {
+		privilegedKeys transport.PrivilegedKeys
+		privileged     bool
+		reason         string
+	}{
+		"disabled":               {},
+		"enabled with reason":    {privilegedKeys: transport.PrivilegedKeys{Enabled: true, Reason: "reason"}, privileged: true, reason: "reason"},
+		"enabled without reason": {privilegedKeys: transport.PrivilegedKeys{Enabled: true}, privileged: true, reason: transport.DefaultPrivilegedReason},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// when
+			applied := test.privilegedKeys.Apply(args)
+			identityKeyArgs := test.privilegedKeys.IdentityKeyArgs()
+
+			// then
+			require.Equal(t, args.KeyID, applied.KeyID)
+			require.Equal(t, test.privileged, applied.Privileged)
+			require.Equal(t, test.reason, applied.PrivilegedReason)
+			require.True(t, identityKeyArgs.IdentityKey)
+			require.Equal(t, test.privileged, identityKeyArgs.Privileged)
+		})
+	}
+}
+
+func TestAuthMessage_Extension(t *testing.T) {
+	t.Run("extension survives encoding", func(t *testing.T) {
+		// given
+		msg := transport.AuthMessage{Version: transport.AuthVersion}
+		require.NoError(t, msg.SetExtension("tenant", "acme"))
+
+		encoded, err := json.Marshal(msg)
+		require.NoError(t, err)
+
+		var decoded transport.AuthMessage
+		require.NoError(t, json.Unmarshal(encoded, &decoded))
+
+		// when
+		var tenant string
+		found, err := decoded.Extension("tenant", &tenant)
+
+		// then
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, "acme", tenant)
+	})
+
+	t.Run("missing extension is not found", func(t *testing.T) {
+		// when
+		found, err := (&transport.AuthMessage{}).Extension("tenant", new(string))
+
+		// then
+		require.NoError(t, err)
+		require.False(t, found)
+	})
+
+	t.Run("field of the protocol cannot be set as extension", func(t *testing.T) {
+		// when
+		err := (&transport.AuthMessage{}).SetExtension("identityKey", "key")
+
+		// then
+		require.ErrorIs(t, err, transport.ErrReservedExtension)
+	})
+}
@@ -0,0 +1,107 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// ErrTransportRequired is returned by VerifyRequest when no transport is configured
+var ErrTransportRequired = errors.New("transport is required to verify requests")
+
+// AuthResult is the outcome of a general request verified with VerifyRequest
+type AuthResult struct {
+	// Request is the verified request, its context carries the identity key, request ID and session values
+	// and its body is decrypted and unpadded
+	Request *http.Request
+	// IdentityKey is the identity key of the peer, empty for unauthenticated requests
+	IdentityKey string
+	// Principal is the identity key of the principal the agent key of the peer acts for, see DelegationPolicy
+	Principal string
+	// RequestID is the ID of the request the response has to be signed for
+	RequestID string
+	// Authenticated is false for requests without auth headers let through by a transport allowing unauthenticated requests
+	Authenticated bool
+
+	transport TransportInterface
+	message   *AuthMessage
+}
+
+// VerifyOptions configures VerifyRequest
+type VerifyOptions struct {
+	// Transport verifies the requests and signs the responses, e.g. an HTTP transport created with httptransport.New
+	Transport TransportInterface
+}
+
+// VerifyRequest verifies a general request outside the auth middleware, e.g. in Lambda handlers or custom servers.
+// Handshake messages are still handled by the HandleNonGeneralRequest method of the transport.
+// The response to an authenticated request has to be signed with SignResponse before it is sent.
+func VerifyRequest(ctx context.Context, req *http.Request, opts VerifyOptions) (*AuthResult, error) {
+	if opts.Transport == nil {
+		return nil, ErrTransportRequired
+	}
+
+	writer := &headerWriter{header: http.Header{}}
+	authReq, msg, err := opts.Transport.HandleGeneralRequest(req.WithContext(ctx), writer)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // errors of the transport keep their codes
+	}
+	if authReq == nil {
+		return &AuthResult{Request: req.WithContext(ctx), transport: opts.Transport}, nil
+	}
+
+	identityKey, _ := authReq.Context().Value(IdentityKey).(string)
+	requestID, _ := authReq.Context().Value(RequestID).(string)
+	principal, _ := authReq.Context().Value(Principal).(string)
+	return &AuthResult{
+		Request:       authReq,
+		IdentityKey:   identityKey,
+		Principal:     principal,
+		RequestID:     requestID,
+		Authenticated: true,
+		transport:     opts.Transport,
+		message:       msg,
+	}, nil
+}
+
+// SignResponse signs the response to a request verified with VerifyRequest. The auth headers are added to header,
+// which has to hold every other header of the response already. It returns the body which has to be sent,
+// as it may be padded or encrypted for the session. Responses to unauthenticated requests are returned as they are.
+func SignResponse(ctx context.Context, result *AuthResult, status int, header http.Header, body []byte) ([]byte, error) {
+	if !result.Authenticated {
+		return body, nil
+	}
+
+	writer := &headerWriter{header: header}
+	req := result.Request.WithContext(valuesContext{Context: ctx, values: result.Request.Context()})
+	return result.transport.HandleResponse(req, writer, body, status, result.message) //nolint:wrapcheck // errors of the transport keep their codes
+}
+
+// headerWriter is the response writer of the transport outside of net/http servers, only its header is used
+type headerWriter struct {
+	header http.Header
+}
+
+func (w *headerWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *headerWriter) Write(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func (w *headerWriter) WriteHeader(int) {}
+
+// valuesContext takes cancellation from its context and looks values up in the values context first,
+// so the response is signed with the values of the verified request under the context of the caller
+type valuesContext struct {
+	context.Context //nolint:containedctx // the context is only wrapped
+	values          context.Context
+}
+
+func (c valuesContext) Value(key any) any {
+	if value := c.values.Value(key); value != nil {
+		return value
+	}
+	return c.Context.Value(key)
+}
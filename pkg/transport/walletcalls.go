@@ -0,0 +1,129 @@
+package transport
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// WalletCallsKey is the key used to store the WalletCallCounter of a request in the context.
+const WalletCallsKey contextKey = "walletCalls"
+
+// WalletCalls is the number of wallet operations performed for a request,
+// e.g. to attribute the cost of remote or KMS wallets billed per operation to routes and identities
+type WalletCalls struct {
+	NoncesCreated      int64 `json:"noncesCreated"`
+	NoncesVerified     int64 `json:"noncesVerified"`
+	SignaturesCreated  int64 `json:"signaturesCreated"`
+	SignaturesVerified int64 `json:"signaturesVerified"`
+	Encryptions        int64 `json:"encryptions"`
+	Decryptions        int64 `json:"decryptions"`
+}
+
+// Total returns the number of wallet operations
+func (c WalletCalls) Total() int64 {
+	return c.NoncesCreated + c.NoncesVerified + c.SignaturesCreated + c.SignaturesVerified + c.Encryptions + c.Decryptions
+}
+
+// Add returns the sum of the wallet operations of both
+func (c WalletCalls) Add(other WalletCalls) WalletCalls {
+	return WalletCalls{
+		NoncesCreated:      c.NoncesCreated + other.NoncesCreated,
+		NoncesVerified:     c.NoncesVerified + other.NoncesVerified,
+		SignaturesCreated:  c.SignaturesCreated + other.SignaturesCreated,
+		SignaturesVerified: c.SignaturesVerified + other.SignaturesVerified,
+		Encryptions:        c.Encryptions + other.Encryptions,
+		Decryptions:        c.Decryptions + other.Decryptions,
+	}
+}
+
+// Sub returns the wallet operations of c without those of other
+func (c WalletCalls) Sub(other WalletCalls) WalletCalls {
+	return WalletCalls{
+		NoncesCreated:      c.NoncesCreated - other.NoncesCreated,
+		NoncesVerified:     c.NoncesVerified - other.NoncesVerified,
+		SignaturesCreated:  c.SignaturesCreated - other.SignaturesCreated,
+		SignaturesVerified: c.SignaturesVerified - other.SignaturesVerified,
+		Encryptions:        c.Encryptions - other.Encryptions,
+		Decryptions:        c.Decryptions - other.Decryptions,
+	}
+}
+
+// WalletCallCounter counts the wallet operations of a request, it is safe for concurrent use.
+// Operations are not counted on a nil counter.
+type WalletCallCounter struct {
+	noncesCreated      atomic.Int64
+	noncesVerified     atomic.Int64
+	signaturesCreated  atomic.Int64
+	signaturesVerified atomic.Int64
+	encryptions        atomic.Int64
+	decryptions        atomic.Int64
+}
+
+// WithWalletCallCounter returns a context carrying a new counter of wallet operations
+func WithWalletCallCounter(ctx context.Context) (context.Context, *WalletCallCounter) {
+	counter := &WalletCallCounter{}
+	return context.WithValue(ctx, WalletCallsKey, counter), counter
+}
+
+// WalletCallCounterFromContext returns the counter of the context, or nil when the context has none
+func WalletCallCounterFromContext(ctx context.Context) *WalletCallCounter {
+	counter, _ := ctx.Value(WalletCallsKey).(*WalletCallCounter)
+	return counter
+}
+
+// NonceCreated counts a CreateNonce call
+func (c *WalletCallCounter) NonceCreated() {
+	if c != nil {
+		c.noncesCreated.Add(1)
+	}
+}
+
+// NonceVerified counts a VerifyNonce call
+func (c *WalletCallCounter) NonceVerified() {
+	if c != nil {
+		c.noncesVerified.Add(1)
+	}
+}
+
+// SignatureCreated counts a CreateSignature call
+func (c *WalletCallCounter) SignatureCreated() {
+	if c != nil {
+		c.signaturesCreated.Add(1)
+	}
+}
+
+// SignatureVerified counts a VerifySignature call
+func (c *WalletCallCounter) SignatureVerified() {
+	if c != nil {
+		c.signaturesVerified.Add(1)
+	}
+}
+
+// Encrypted counts an Encrypt call
+func (c *WalletCallCounter) Encrypted() {
+	if c != nil {
+		c.encryptions.Add(1)
+	}
+}
+
+// Decrypted counts a Decrypt call
+func (c *WalletCallCounter) Decrypted() {
+	if c != nil {
+		c.decryptions.Add(1)
+	}
+}
+
+// Calls returns the wallet operations counted so far
+func (c *WalletCallCounter) Calls() WalletCalls {
+	if c == nil {
+		return WalletCalls{}
+	}
+	return WalletCalls{
+		NoncesCreated:      c.noncesCreated.Load(),
+		NoncesVerified:     c.noncesVerified.Load(),
+		SignaturesCreated:  c.signaturesCreated.Load(),
+		SignaturesVerified: c.signaturesVerified.Load(),
+		Encryptions:        c.encryptions.Load(),
+		Decryptions:        c.decryptions.Load(),
+	}
+}
@@ -0,0 +1,68 @@
+package transport_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletCallCounter(t *testing.T) {
+	t.Run("counts the wallet calls of the context", func(t *testing.T) {
+		// given
+		ctx, counter := transport.WithWalletCallCounter(context.Background())
+
+		// when
+		var wg sync.WaitGroup
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				fromContext := transport.WalletCallCounterFromContext(ctx)
+				fromContext.NonceCreated()
+				fromContext.SignatureCreated()
+			}()
+		}
+		wg.Wait()
+		counter.NonceVerified()
+		counter.SignatureVerified()
+		counter.Encrypted()
+		counter.Decrypted()
+
+		// then
+		calls := counter.Calls()
+		require.Equal(t, transport.WalletCalls{
+			NoncesCreated: 10, NoncesVerified: 1, SignaturesCreated: 10, SignaturesVerified: 1, Encryptions: 1, Decryptions: 1,
+		}, calls)
+		require.EqualValues(t, 24, calls.Total())
+	})
+
+	t.Run("context without counter ignores wallet calls", func(t *testing.T) {
+		// given
+		counter := transport.WalletCallCounterFromContext(context.Background())
+
+		// when
+		counter.NonceCreated()
+		counter.SignatureVerified()
+
+		// then
+		require.Nil(t, counter)
+		require.Equal(t, transport.WalletCalls{}, counter.Calls())
+	})
+}
+
+func TestWalletCalls_AddSub(t *testing.T) {
+	// given
+	a := transport.WalletCalls{NoncesCreated: 2, SignaturesCreated: 2, SignaturesVerified: 1}
+	b := transport.WalletCalls{NoncesCreated: 1, NoncesVerified: 1, Encryptions: 1}
+
+	// when
+	sum := a.Add(b)
+
+	// then
+	require.Equal(t, transport.WalletCalls{NoncesCreated: 3, NoncesVerified: 1, SignaturesCreated: 2, SignaturesVerified: 1, Encryptions: 1}, sum)
+	require.Equal(t, a, sum.Sub(b))
+	require.Equal(t, a.Total()+b.Total(), sum.Total())
+}
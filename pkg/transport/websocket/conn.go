@@ -0,0 +1,452 @@
+package wstransport
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/websocket"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// Conn is a WebSocket connection authenticated by the handshake. Messages are sent as signed general messages and
+// messages of the peer are verified the same way, so the connection can be used wherever a websocket.MessageConn
+// is expected. Messages are read as binary messages. A connection which receives an invalid message is closed.
+//
+// Messages of per-message sessions are signed with a fresh nonce and the nonce of the peer, like the auth headers
+// of HTTP. Messages of connection-scoped sessions carry no nonces, they are signed over their sequence number on the
+// connection with the nonces of the handshake, and the server re-challenges the peer according to its
+// ReauthenticationPolicy. ReadMessage answers the challenges of the server on the client.
+//
+// Heartbeats sent by the client refresh the session on the server without a general message, the server
+// acknowledges them with a signed heartbeat which ReadMessage verifies and drops.
+type Conn struct {
+	conn            *websocket.Conn
+	ctx             context.Context
+	wallet          wallet.WalletInterface
+	identityKey     string
+	peerIdentityKey *ec.PublicKey
+	sessionNonce    string
+	peerNonce       string
+	sessionManager  session.Manager
+	scoped          bool
+	// reauthentication is the policy of the server re-challenging the peer of a connection-scoped session
+	reauthentication *session.ReauthenticationPolicy
+	// privilegedKeys makes the server use the privileged keyring of the wallet for the messages of the connection
+	privilegedKeys transport.PrivilegedKeys
+
+	// walletMu serializes the nonces and signatures of messages read and written concurrently
+	walletMu sync.Mutex
+	// writeMu keeps the signed messages in the order of their sequence numbers
+	writeMu sync.Mutex
+	sent    uint64
+	// received is only used by the goroutine reading the connection
+	received uint64
+
+	challengeMu sync.Mutex
+	challenge   string
+	deadline    *time.Timer
+
+	closeOnce sync.Once
+}
+
+// newConn creates the authenticated connection, the session manager is set for connections accepted by the server,
+// whose session has to exist for every message received
+func newConn(
+	conn *websocket.Conn,
+	w wallet.WalletInterface,
+	identityKey, peerIdentityKey *ec.PublicKey,
+	sessionNonce, peerNonce string,
+	sessionManager session.Manager,
+	scoped bool,
+) *Conn {
+	return &Conn{
+		conn:            conn,
+		ctx:             context.WithValue(conn.Context(), transport.IdentityKey, peerIdentityKey.ToDERHex()),
+		wallet:          w,
+		identityKey:     identityKey.ToDERHex(),
+		peerIdentityKey: peerIdentityKey,
+		sessionNonce:    sessionNonce,
+		peerNonce:       peerNonce,
+		sessionManager:  sessionManager,
+		scoped:          scoped,
+	}
+}
+
+// Context returns the context of the connection, it carries the identity key of the peer under transport.IdentityKey
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+// PeerIdentityKey returns the identity key of the peer authenticated by the handshake
+func (c *Conn) PeerIdentityKey() string {
+	return c.peerIdentityKey.ToDERHex()
+}
+
+// ConnectionScoped reports whether the session of the connection is bound to the connection
+func (c *Conn) ConnectionScoped() bool {
+	return c.scoped
+}
+
+// SetReadLimit sets the maximum size of received messages, including the fields of the general message
+func (c *Conn) SetReadLimit(limit int) {
+	c.conn.SetReadLimit(limit)
+}
+
+// ReadMessage returns the payload of the next general message of the peer once its signature is verified
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return 0, nil, err //nolint:wrapcheck // the error of the connection is returned as is
+		}
+
+		payload, err := c.handleMessage(data)
+		if err != nil {
+			_ = c.Close()
+			return 0, nil, err
+		}
+		if payload != nil {
+			return websocket.BinaryMessage, payload, nil
+		}
+	}
+}
+
+// WriteMessage sends the data as the payload of a signed general message, the message type is not preserved
+func (c *Conn) WriteMessage(_ int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	message, err := c.sign(data)
+	if err != nil {
+		return err
+	}
+	return writeAuthMessage(c.conn, *message)
+}
+
+// Heartbeat sends a signed heartbeat, which keeps the session of the connection alive on the server
+func (c *Conn) Heartbeat() error {
+	return c.writeHeartbeat(transport.HeartbeatPayload(time.Now()))
+}
+
+// Close closes the connection, the server removes connection-scoped sessions along with their connection
+func (c *Conn) Close() error {
+	c.closeOnce.Do(func() {
+		c.challengeMu.Lock()
+		if c.deadline != nil {
+			c.deadline.Stop()
+		}
+		c.challengeMu.Unlock()
+
+		if c.scoped && c.sessionManager != nil {
+			if session := c.sessionManager.GetSession(c.sessionNonce); session != nil {
+				c.sessionManager.RemoveSession(*session)
+			}
+		}
+	})
+	return c.conn.Close() //nolint:wrapcheck // the error of the connection is returned as is
+}
+
+// handleMessage returns the payload of a general message, or nil for reauthentication and heartbeat messages it handled
+func (c *Conn) handleMessage(data []byte) ([]byte, error) {
+	var message transport.AuthMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode message", ErrInvalidMessage)
+	}
+	if message.Version != transport.AuthVersion {
+		return nil, fmt.Errorf("%w: unsupported version", ErrInvalidMessage)
+	}
+	if message.IdentityKey != c.peerIdentityKey.ToDERHex() {
+		return nil, fmt.Errorf("%w: identity key does not match the peer", ErrInvalidMessage)
+	}
+
+	switch {
+	case message.MessageType == transport.General:
+		payload, err := c.verify(&message)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.recordMessage(); err != nil {
+			return nil, err
+		}
+		return payload, nil
+	case message.MessageType == transport.ReauthenticationRequest && c.scoped && c.sessionManager == nil:
+		return nil, c.answerChallenge(&message)
+	case message.MessageType == transport.ReauthenticationResponse && c.reauthentication != nil:
+		return nil, c.verifyChallengeAnswer(&message)
+	case message.MessageType == transport.Heartbeat && c.sessionManager != nil:
+		return nil, c.acknowledgeHeartbeat(&message)
+	case message.MessageType == transport.Heartbeat:
+		return nil, c.verifyHeartbeat(&message)
+	default:
+		return nil, fmt.Errorf("%w: unexpected %s message", ErrInvalidMessage, message.MessageType)
+	}
+}
+
+func (c *Conn) verify(message *transport.AuthMessage) ([]byte, error) {
+	if message.Payload == nil || message.Signature == nil {
+		return nil, fmt.Errorf("%w: missing required fields", ErrInvalidMessage)
+	}
+
+	c.walletMu.Lock()
+	defer c.walletMu.Unlock()
+
+	if c.scoped {
+		data := binary.BigEndian.AppendUint64(nil, c.received)
+		c.received++
+		keyID := fmt.Sprintf("%s %s", c.peerNonce, c.sessionNonce)
+		if err := verifySignature(c.wallet, c.privilegedKeys, c.peerIdentityKey, keyID, append(data, *message.Payload...), *message.Signature); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidMessage, err)
+		}
+		return *message.Payload, nil
+	}
+
+	if message.Nonce == nil || message.YourNonce == nil {
+		return nil, fmt.Errorf("%w: missing required fields", ErrInvalidMessage)
+	}
+	if *message.YourNonce != c.sessionNonce {
+		return nil, fmt.Errorf("%w: your nonce does not match the session", ErrInvalidMessage)
+	}
+
+	valid, err := c.wallet.VerifyNonce(c.ctx, *message.YourNonce)
+	if err != nil || !valid {
+		return nil, fmt.Errorf("%w: unable to verify nonce", ErrInvalidMessage)
+	}
+
+	keyID := fmt.Sprintf("%s %s", *message.Nonce, *message.YourNonce)
+	if err := verifySignature(c.wallet, c.privilegedKeys, c.peerIdentityKey, keyID, *message.Payload, *message.Signature); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMessage, err)
+	}
+	return *message.Payload, nil
+}
+
+func (c *Conn) sign(payload []byte) (*transport.AuthMessage, error) {
+	c.walletMu.Lock()
+	defer c.walletMu.Unlock()
+
+	message := &transport.AuthMessage{
+		Version:     transport.AuthVersion,
+		MessageType: transport.General,
+		IdentityKey: c.identityKey,
+		Payload:     &payload,
+	}
+
+	if c.scoped {
+		data := binary.BigEndian.AppendUint64(nil, c.sent)
+		c.sent++
+		signature, err := createSignature(c.wallet, c.privilegedKeys, c.peerIdentityKey, fmt.Sprintf("%s %s", c.sessionNonce, c.peerNonce), append(data, payload...))
+		if err != nil {
+			return nil, err
+		}
+		message.Signature = &signature
+		return message, nil
+	}
+
+	nonce, err := c.wallet.CreateNonce(c.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create nonce, %w", err)
+	}
+
+	signature, err := createSignature(c.wallet, c.privilegedKeys, c.peerIdentityKey, fmt.Sprintf("%s %s", nonce, c.peerNonce), payload)
+	if err != nil {
+		return nil, err
+	}
+
+	message.Nonce = &nonce
+	message.YourNonce = &c.peerNonce
+	message.Signature = &signature
+	return message, nil
+}
+
+// writeHeartbeat sends a heartbeat with the payload, signed with a fresh nonce and the nonce of the peer in both
+// session modes, so heartbeats do not take a sequence number of the connection
+func (c *Conn) writeHeartbeat(payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	c.walletMu.Lock()
+	nonce, err := c.wallet.CreateNonce(c.ctx)
+	var signature []byte
+	if err == nil {
+		signature, err = createSignature(c.wallet, c.privilegedKeys, c.peerIdentityKey, fmt.Sprintf("%s %s", nonce, c.peerNonce), payload)
+	}
+	c.walletMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to sign heartbeat, %w", err)
+	}
+
+	return writeAuthMessage(c.conn, transport.AuthMessage{
+		Version:     transport.AuthVersion,
+		MessageType: transport.Heartbeat,
+		IdentityKey: c.identityKey,
+		Nonce:       &nonce,
+		YourNonce:   &c.peerNonce,
+		Payload:     &payload,
+		Signature:   &signature,
+	})
+}
+
+// verifyHeartbeat verifies the signature of a heartbeat of the peer and that it was sent recently
+func (c *Conn) verifyHeartbeat(heartbeat *transport.AuthMessage) error {
+	sentAt, err := transport.HeartbeatTime(heartbeat)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidMessage, err)
+	}
+	if *heartbeat.YourNonce != c.sessionNonce {
+		return fmt.Errorf("%w: heartbeat is not bound to the session", ErrInvalidMessage)
+	}
+	if skew := time.Since(sentAt); skew > transport.MaxHeartbeatSkew || skew < -transport.MaxHeartbeatSkew {
+		return transport.ErrStaleHeartbeat
+	}
+
+	c.walletMu.Lock()
+	keyID := fmt.Sprintf("%s %s", *heartbeat.Nonce, c.sessionNonce)
+	err = verifySignature(c.wallet, c.privilegedKeys, c.peerIdentityKey, keyID, *heartbeat.Payload, *heartbeat.Signature)
+	c.walletMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidMessage, err)
+	}
+	return nil
+}
+
+// acknowledgeHeartbeat refreshes the session of a heartbeat received by the server and echoes the heartbeat
+func (c *Conn) acknowledgeHeartbeat(heartbeat *transport.AuthMessage) error {
+	if err := c.verifyHeartbeat(heartbeat); err != nil {
+		return err
+	}
+
+	session := c.sessionManager.GetSession(c.sessionNonce)
+	if session == nil {
+		return ErrSessionNotFound
+	}
+	session.LastUpdate = time.Now()
+	c.sessionManager.UpdateSession(*session)
+
+	return c.writeHeartbeat(*heartbeat.Payload)
+}
+
+// recordMessage refreshes the session of a message received by the server and challenges the peer of a
+// connection-scoped session when the policy requires it
+func (c *Conn) recordMessage() error {
+	if c.sessionManager == nil {
+		return nil
+	}
+
+	session := c.sessionManager.GetSession(c.sessionNonce)
+	if session == nil {
+		return ErrSessionNotFound
+	}
+	now := time.Now()
+	session.RecordMessage(now)
+	c.sessionManager.UpdateSession(*session)
+
+	if c.reauthentication == nil || !c.reauthentication.RequiresReauthentication(*session, now) {
+		return nil
+	}
+	return c.sendChallenge()
+}
+
+// sendChallenge sends a reauthentication request with a fresh nonce, the peer has to sign it before the timeout of
+// the policy. Messages received while the challenge is pending are processed.
+func (c *Conn) sendChallenge() error {
+	c.challengeMu.Lock()
+	defer c.challengeMu.Unlock()
+	if c.challenge != "" {
+		return nil
+	}
+
+	c.walletMu.Lock()
+	nonce, err := c.wallet.CreateNonce(c.ctx)
+	c.walletMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to create challenge nonce, %w", err)
+	}
+
+	c.writeMu.Lock()
+	err = writeAuthMessage(c.conn, transport.AuthMessage{
+		Version:     transport.AuthVersion,
+		MessageType: transport.ReauthenticationRequest,
+		IdentityKey: c.identityKey,
+		Nonce:       &nonce,
+	})
+	c.writeMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	c.challenge = nonce
+	c.deadline = time.AfterFunc(c.reauthentication.ChallengeTimeout(), func() { _ = c.Close() })
+	return nil
+}
+
+// answerChallenge signs the nonce of the reauthentication request of the server with a fresh nonce
+func (c *Conn) answerChallenge(challenge *transport.AuthMessage) error {
+	if challenge.Nonce == nil {
+		return fmt.Errorf("%w: missing challenge nonce", ErrInvalidMessage)
+	}
+
+	c.walletMu.Lock()
+	nonce, err := c.wallet.CreateNonce(c.ctx)
+	var signature []byte
+	if err == nil {
+		signature, err = createSignature(c.wallet, c.privilegedKeys, c.peerIdentityKey, fmt.Sprintf("%s %s", nonce, *challenge.Nonce), []byte(*challenge.Nonce))
+	}
+	c.walletMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to answer reauthentication request, %w", err)
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return writeAuthMessage(c.conn, transport.AuthMessage{
+		Version:     transport.AuthVersion,
+		MessageType: transport.ReauthenticationResponse,
+		IdentityKey: c.identityKey,
+		Nonce:       &nonce,
+		YourNonce:   challenge.Nonce,
+		Signature:   &signature,
+	})
+}
+
+// verifyChallengeAnswer verifies the signature of the pending challenge and marks the session reauthenticated
+func (c *Conn) verifyChallengeAnswer(answer *transport.AuthMessage) error {
+	c.challengeMu.Lock()
+	defer c.challengeMu.Unlock()
+
+	if c.challenge == "" || answer.YourNonce == nil || *answer.YourNonce != c.challenge {
+		return fmt.Errorf("%w: reauthentication response does not answer the challenge", ErrInvalidMessage)
+	}
+	if answer.Nonce == nil || answer.Signature == nil {
+		return fmt.Errorf("%w: missing required fields", ErrInvalidMessage)
+	}
+
+	c.walletMu.Lock()
+	valid, err := c.wallet.VerifyNonce(c.ctx, c.challenge)
+	if err == nil && valid {
+		keyID := fmt.Sprintf("%s %s", *answer.Nonce, c.challenge)
+		err = verifySignature(c.wallet, c.privilegedKeys, c.peerIdentityKey, keyID, []byte(c.challenge), *answer.Signature)
+	} else if err == nil {
+		err = fmt.Errorf("unable to verify nonce")
+	}
+	c.walletMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidMessage, err)
+	}
+
+	c.deadline.Stop()
+	c.challenge, c.deadline = "", nil
+
+	session := c.sessionManager.GetSession(c.sessionNonce)
+	if session == nil {
+		return ErrSessionNotFound
+	}
+	session.MarkAuthenticated(time.Now())
+	c.sessionManager.UpdateSession(*session)
+	return nil
+}
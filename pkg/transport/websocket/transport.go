@@ -0,0 +1,285 @@
+package wstransport
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/internal/logging"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/websocket"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// WebSocket transport errors
+var (
+	ErrHandshakeFailed = errors.New("websocket handshake failed")
+	ErrInvalidMessage  = errors.New("invalid websocket message")
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// Config configures the WebSocket transport
+type Config struct {
+	Wallet         wallet.WalletInterface
+	SessionManager session.Manager
+	Logger         *slog.Logger
+	// ConnectionScoped binds the sessions to their connection: after the handshake messages are signed over their
+	// sequence number instead of exchanging nonces, and the session is removed when the connection closes
+	ConnectionScoped bool
+	// Reauthentication defines when the peers of connection-scoped sessions are re-challenged
+	Reauthentication session.ReauthenticationPolicy
+	// PrivilegedKeys makes the transport use the privileged keyring of the wallet
+	PrivilegedKeys transport.PrivilegedKeys
+}
+
+// Transport authenticates WebSocket connections with the BRC-103 handshake sent over the socket.
+// The peer sends its initial request as the first message and is answered with the signed initial response,
+// afterwards every message is a general message signed with the nonces of the peers, like the auth headers of HTTP,
+// or over its sequence number when the sessions are connection-scoped. Certificates are not requested over the socket.
+type Transport struct {
+	wallet           wallet.WalletInterface
+	sessionManager   session.Manager
+	logger           *slog.Logger
+	connectionScoped bool
+	reauthentication session.ReauthenticationPolicy
+	privilegedKeys   transport.PrivilegedKeys
+}
+
+// New creates a new WebSocket transport
+func New(cfg Config) *Transport {
+	if cfg.Logger == nil {
+		cfg.Logger = slog.New(slog.DiscardHandler)
+	}
+
+	return &Transport{
+		wallet:           cfg.Wallet,
+		sessionManager:   cfg.SessionManager,
+		logger:           logging.Child(cfg.Logger, "websocket-transport"),
+		connectionScoped: cfg.ConnectionScoped,
+		reauthentication: cfg.Reauthentication,
+		privilegedKeys:   cfg.PrivilegedKeys,
+	}
+}
+
+// Accept upgrades the request to WebSocket and performs the handshake of the peer, its session is added to the
+// session manager. The context of the returned connection carries the identity key of the peer.
+func (t *Transport) Accept(w http.ResponseWriter, req *http.Request) (*Conn, error) {
+	conn, err := websocket.Upgrade(w, req)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the upgrade error is returned as is
+	}
+
+	authenticated, err := t.handshake(conn)
+	if err != nil {
+		t.logger.Debug("WebSocket handshake failed", logging.Error(err))
+		_ = conn.Close()
+		return nil, err
+	}
+	return authenticated, nil
+}
+
+func (t *Transport) handshake(conn *websocket.Conn) (*Conn, error) {
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHandshakeFailed, err)
+	}
+
+	var initialRequest transport.AuthMessage
+	if err := json.Unmarshal(data, &initialRequest); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode initial request", ErrHandshakeFailed)
+	}
+	if initialRequest.Version != transport.AuthVersion || initialRequest.MessageType != transport.InitialRequest {
+		return nil, fmt.Errorf("%w: expected initial request", ErrHandshakeFailed)
+	}
+	if initialRequest.IdentityKey == "" || initialRequest.InitialNonce == "" {
+		return nil, fmt.Errorf("%w: missing required fields in initial request", ErrHandshakeFailed)
+	}
+
+	peerIdentityKey, err := ec.PublicKeyFromString(initialRequest.IdentityKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse identity key, %w", ErrHandshakeFailed, err)
+	}
+
+	identityKey, err := t.wallet.GetPublicKey(t.privilegedKeys.IdentityKeyArgs(), "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve identity key, %w", err)
+	}
+
+	sessionNonce, err := t.wallet.CreateNonce(conn.Context())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session nonce, %w", err)
+	}
+
+	combined := initialRequest.InitialNonce + sessionNonce
+	signature, err := createSignature(t.wallet, t.privilegedKeys, peerIdentityKey, combined, []byte(base64.StdEncoding.EncodeToString([]byte(combined))))
+	if err != nil {
+		return nil, err
+	}
+
+	session := session.PeerSession{
+		SessionNonce:     &sessionNonce,
+		PeerNonce:        &initialRequest.InitialNonce,
+		PeerIdentityKey:  &initialRequest.IdentityKey,
+		ConnectionScoped: t.connectionScoped,
+	}
+	session.MarkAuthenticated(time.Now())
+	t.sessionManager.AddSession(session)
+
+	if err := writeAuthMessage(conn, transport.AuthMessage{
+		Version:          transport.AuthVersion,
+		MessageType:      transport.InitialResponse,
+		IdentityKey:      identityKey.PublicKey.ToDERHex(),
+		InitialNonce:     sessionNonce,
+		YourNonce:        &initialRequest.InitialNonce,
+		Signature:        &signature,
+		ConnectionScoped: t.connectionScoped,
+	}); err != nil {
+		t.sessionManager.RemoveSession(session)
+		return nil, err
+	}
+
+	t.logger.Debug("WebSocket handshake completed",
+		slog.String("identityKey", initialRequest.IdentityKey), slog.Bool("connectionScoped", t.connectionScoped))
+
+	authenticated := newConn(conn, t.wallet, identityKey.PublicKey, peerIdentityKey, sessionNonce, initialRequest.InitialNonce, t.sessionManager, t.connectionScoped)
+	authenticated.privilegedKeys = t.privilegedKeys
+	if t.connectionScoped {
+		authenticated.reauthentication = &t.reauthentication
+	}
+	return authenticated, nil
+}
+
+// Connect dials the WebSocket endpoint at the URL and performs the handshake with the identity of the wallet.
+// The context bounds the upgrade and the handshake, the context of the returned connection carries the identity key
+// of the server.
+func Connect(ctx context.Context, url string, w wallet.WalletInterface, header http.Header) (*Conn, error) {
+	conn, err := websocket.Dial(ctx, url, header)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the dial error is returned as is
+	}
+
+	// the handshake reads the connection, which is closed when the context is done before it completes
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	authenticated, err := connect(ctx, conn, w)
+	if !stop() {
+		_ = conn.Close()
+		return nil, fmt.Errorf("%w: %w", ErrHandshakeFailed, ctx.Err())
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return authenticated, nil
+}
+
+func connect(ctx context.Context, conn *websocket.Conn, w wallet.WalletInterface) (*Conn, error) {
+	identityKey, err := w.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve identity key, %w", err)
+	}
+
+	initialNonce, err := w.CreateNonce(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create initial nonce, %w", err)
+	}
+
+	if err := writeAuthMessage(conn, transport.AuthMessage{
+		Version:      transport.AuthVersion,
+		MessageType:  transport.InitialRequest,
+		IdentityKey:  identityKey.PublicKey.ToDERHex(),
+		InitialNonce: initialNonce,
+	}); err != nil {
+		return nil, err
+	}
+
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHandshakeFailed, err)
+	}
+
+	var initialResponse transport.AuthMessage
+	if err := json.Unmarshal(data, &initialResponse); err != nil {
+		return nil, fmt.Errorf("%w: failed to decode initial response", ErrHandshakeFailed)
+	}
+	if initialResponse.Version != transport.AuthVersion || initialResponse.MessageType != transport.InitialResponse {
+		return nil, fmt.Errorf("%w: expected initial response", ErrHandshakeFailed)
+	}
+	if initialResponse.YourNonce == nil || *initialResponse.YourNonce != initialNonce || initialResponse.InitialNonce == "" {
+		return nil, fmt.Errorf("%w: initial response does not answer the initial request", ErrHandshakeFailed)
+	}
+	if initialResponse.Signature == nil {
+		return nil, fmt.Errorf("%w: missing signature", ErrHandshakeFailed)
+	}
+
+	serverIdentityKey, err := ec.PublicKeyFromString(initialResponse.IdentityKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to parse identity key, %w", ErrHandshakeFailed, err)
+	}
+
+	combined := initialNonce + initialResponse.InitialNonce
+	if err := verifySignature(w, transport.PrivilegedKeys{}, serverIdentityKey, combined, []byte(base64.StdEncoding.EncodeToString([]byte(combined))), *initialResponse.Signature); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrHandshakeFailed, err)
+	}
+
+	return newConn(conn, w, identityKey.PublicKey, serverIdentityKey, initialNonce, initialResponse.InitialNonce, nil, initialResponse.ConnectionScoped), nil
+}
+
+func writeAuthMessage(conn *websocket.Conn, message transport.AuthMessage) error {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s message, %w", message.MessageType, err)
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+		return fmt.Errorf("failed to send %s message, %w", message.MessageType, err)
+	}
+	return nil
+}
+
+func createSignature(w wallet.WalletInterface, privilegedKeys transport.PrivilegedKeys, counterparty *ec.PublicKey, keyID string, data []byte) ([]byte, error) {
+	signature, err := w.CreateSignature(&wallet.CreateSignatureArgs{
+		EncryptionArgs: privilegedKeys.Apply(encryptionArgs(counterparty, keyID)),
+		Data:           data,
+	}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signature, %w", err)
+	}
+	return signature.Signature.Serialize(), nil
+}
+
+func verifySignature(w wallet.WalletInterface, privilegedKeys transport.PrivilegedKeys, counterparty *ec.PublicKey, keyID string, data, signature []byte) error {
+	parsed, err := ec.ParseSignature(signature)
+	if err != nil {
+		return fmt.Errorf("failed to parse signature, %w", err)
+	}
+
+	result, err := w.VerifySignature(&wallet.VerifySignatureArgs{
+		EncryptionArgs: privilegedKeys.Apply(encryptionArgs(counterparty, keyID)),
+		Signature:      *parsed,
+		Data:           data,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to verify signature, %w", err)
+	}
+	if !result.Valid {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+func encryptionArgs(counterparty *ec.PublicKey, keyID string) wallet.EncryptionArgs {
+	return wallet.EncryptionArgs{
+		ProtocolID: wallet.DefaultAuthProtocol,
+		KeyID:      keyID,
+		Counterparty: wallet.Counterparty{
+			Type:         wallet.CounterpartyTypeOther,
+			Counterparty: counterparty,
+		},
+	}
+}
@@ -0,0 +1,41 @@
+package transport
+
+// X509CertificateType is the base64 type ID of certificates bridged from X.509 client certificates,
+// the SHA-256 hash of "x509-client-certificate", it can be requested like any other certificate type
+const X509CertificateType = "1Dz+AXAV9jWSzSbxgNywk/HoQcZdCEwpAgzJffqFJ90="
+
+// X509IdentityURIPrefix prefixes the identity key in the URI SAN which binds an X.509 client certificate to a BSV identity,
+// e.g. bsv:identity:02a1...
+const X509IdentityURIPrefix = "bsv:identity:"
+
+// Fields of certificates bridged from X.509 client certificates, values with several entries are joined with commas
+const (
+	X509FieldCommonName         = "commonName"
+	X509FieldOrganization       = "organization"
+	X509FieldOrganizationalUnit = "organizationalUnit"
+	X509FieldCountry            = "country"
+	X509FieldProvince           = "province"
+	X509FieldLocality           = "locality"
+	X509FieldEmailAddresses     = "emailAddresses"
+	X509FieldDNSNames           = "dnsNames"
+	X509FieldURIs               = "uris"
+	X509FieldSerialNumber       = "serialNumber"
+	X509FieldIssuer             = "issuer"
+	X509FieldAuthorityKeyID     = "authorityKeyId"
+	X509FieldNotAfter           = "notAfter"
+)
+
+// X509Bridge wraps the verified X.509 client certificate of an mTLS connection into a VerifiableCertificate,
+// which is added to the certificates the peer sends over the connection. The bridged certificate passes
+// the same pipeline as BSV certificates: the OnCertificatesReceived callback, the session and the policies reading it.
+//
+// Only chains verified by the TLS server are bridged, the tls.Config has to verify client certificates
+// (tls.VerifyClientCertIfGiven or tls.RequireAndVerifyClientCert) against the trusted CAs.
+type X509Bridge struct {
+	// Certifier is the compressed public key (hex) set as certifier of bridged certificates,
+	// defaults to the identity key of the server, which vouches for the chains verified by its TLS stack
+	Certifier string
+	// RequireIdentityBinding rejects client certificates without a URI SAN binding them to the identity key of the peer,
+	// see X509IdentityURIPrefix. Without it any identity may present the client certificate of the connection.
+	RequireIdentityBinding bool
+}
@@ -0,0 +1,95 @@
+//go:build !nox509
+
+package transport
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+)
+
+// Bridge returns the bridged certificate of the verified client certificate of the connection,
+// or nil when the peer presented no verified client certificate
+func (b *X509Bridge) Bridge(state *tls.ConnectionState, identityKey, certifier string) (*certificates.VerifiableCertificate, error) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+
+	leaf := state.VerifiedChains[0][0]
+	if b.RequireIdentityBinding && !X509BoundTo(leaf, identityKey) {
+		return nil, fmt.Errorf("%w: %s", ErrClientCertificateNotBound, leaf.Subject.CommonName)
+	}
+
+	if b.Certifier != "" {
+		certifier = b.Certifier
+	}
+
+	cert := X509Certificate(leaf, identityKey, certifier)
+	return &cert, nil
+}
+
+// X509BoundTo reports whether the certificate carries the URI SAN binding it to the identity key
+func X509BoundTo(cert *x509.Certificate, identityKey string) bool {
+	for _, uri := range cert.URIs {
+		if strings.EqualFold(uri.String(), X509IdentityURIPrefix+identityKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// X509Certificate wraps the X.509 certificate into a certificate of the X509CertificateType with the given subject and certifier.
+// The serial number is the base64 SHA-256 fingerprint of the X.509 certificate, so certificates of different CAs never conflict,
+// the serial number of the X.509 certificate is kept in the serialNumber field. The fields are not encrypted,
+// they are set as plain and as decrypted fields, so policies reading either find them.
+func X509Certificate(cert *x509.Certificate, subject, certifier string) certificates.VerifiableCertificate {
+	fingerprint := sha256.Sum256(cert.Raw)
+
+	uris := make([]string, 0, len(cert.URIs))
+	for _, uri := range cert.URIs {
+		uris = append(uris, uri.String())
+	}
+
+	values := map[string]string{
+		X509FieldCommonName:         cert.Subject.CommonName,
+		X509FieldOrganization:       strings.Join(cert.Subject.Organization, ","),
+		X509FieldOrganizationalUnit: strings.Join(cert.Subject.OrganizationalUnit, ","),
+		X509FieldCountry:            strings.Join(cert.Subject.Country, ","),
+		X509FieldProvince:           strings.Join(cert.Subject.Province, ","),
+		X509FieldLocality:           strings.Join(cert.Subject.Locality, ","),
+		X509FieldEmailAddresses:     strings.Join(cert.EmailAddresses, ","),
+		X509FieldDNSNames:           strings.Join(cert.DNSNames, ","),
+		X509FieldURIs:               strings.Join(uris, ","),
+		X509FieldSerialNumber:       cert.SerialNumber.Text(16),
+		X509FieldIssuer:             cert.Issuer.String(),
+		X509FieldAuthorityKeyID:     hex.EncodeToString(cert.AuthorityKeyId),
+		X509FieldNotAfter:           cert.NotAfter.UTC().Format(time.RFC3339),
+	}
+
+	fields := make(map[string]any, len(values))
+	decrypted := make(map[string]string, len(values))
+	for name, value := range values {
+		fields[name] = value
+		decrypted[name] = value
+	}
+
+	return certificates.VerifiableCertificate{
+		Certificate: certificates.Certificate{
+			Type:         X509CertificateType,
+			SerialNumber: base64.StdEncoding.EncodeToString(fingerprint[:]),
+			Subject:      subject,
+			Certifier:    certifier,
+			Fields:       fields,
+			Signature:    hex.EncodeToString(cert.Signature),
+		},
+		Keyring:         map[string]string{},
+		DecryptedFields: &decrypted,
+	}
+}
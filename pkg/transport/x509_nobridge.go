@@ -0,0 +1,19 @@
+//go:build nox509
+
+package transport
+
+import (
+	"crypto/tls"
+	"fmt"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+)
+
+// Bridge fails for every verified client certificate in builds with the nox509 build tag,
+// connections without a client certificate are not affected
+func (b *X509Bridge) Bridge(state *tls.ConnectionState, _, _ string) (*certificates.VerifiableCertificate, error) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("%w: the X.509 bridge, see the nox509 build tag", ErrExcludedFromBuild)
+}
@@ -0,0 +1,28 @@
+//go:build nox509
+
+package transport_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestX509Bridge_ExcludedFromBuild(t *testing.T) {
+	// given
+	bridge := transport.X509Bridge{}
+	state := &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{}}}}
+
+	// when
+	bridged, err := bridge.Bridge(state, identityKey, certifier)
+	withoutCertificate, withoutErr := bridge.Bridge(&tls.ConnectionState{}, identityKey, certifier)
+
+	// then
+	require.ErrorIs(t, err, transport.ErrExcludedFromBuild)
+	require.Nil(t, bridged)
+	require.NoError(t, withoutErr)
+	require.Nil(t, withoutCertificate)
+}
@@ -0,0 +1,126 @@
+//go:build !nox509
+
+package transport_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/stretchr/testify/require"
+)
+
+func TestX509Certificate(t *testing.T) {
+	// given
+	cert := clientCertificate(t, identityKey)
+
+	// when
+	bridged := transport.X509Certificate(cert, identityKey, certifier)
+
+	// then
+	require.Equal(t, transport.X509CertificateType, bridged.Type)
+	require.Equal(t, identityKey, bridged.Subject)
+	require.Equal(t, certifier, bridged.Certifier)
+	require.NotEqual(t, cert.SerialNumber.String(), bridged.SerialNumber)
+	require.Equal(t, "alice", bridged.Fields[transport.X509FieldCommonName])
+	require.Equal(t, "ACME,ACME Labs", bridged.Fields[transport.X509FieldOrganization])
+	require.Equal(t, "2a", bridged.Fields[transport.X509FieldSerialNumber])
+	require.Equal(t, "alice", (*bridged.DecryptedFields)[transport.X509FieldCommonName])
+	require.Empty(t, bridged.Keyring)
+}
+
+func TestX509Bridge_Bridge(t *testing.T) {
+	verified := func(t *testing.T, boundTo string) *tls.ConnectionState {
+		return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{clientCertificate(t, boundTo)}}}
+	}
+
+	tests := map[string]struct {
+		bridge      transport.X509Bridge
+		state       func(t *testing.T) *tls.ConnectionState
+		expectNil   bool
+		expectError error
+		certifier   string
+	}{
+		"verified client certificate is bridged with the certifier of the server": {
+			state:     func(t *testing.T) *tls.ConnectionState { return verified(t, "") },
+			certifier: certifier,
+		},
+		"configured certifier takes precedence": {
+			bridge:    transport.X509Bridge{Certifier: identityKey},
+			state:     func(t *testing.T) *tls.ConnectionState { return verified(t, "") },
+			certifier: identityKey,
+		},
+		"connection without verified client certificate is not bridged": {
+			state: func(t *testing.T) *tls.ConnectionState {
+				return &tls.ConnectionState{PeerCertificates: []*x509.Certificate{clientCertificate(t, "")}}
+			},
+			expectNil: true,
+		},
+		"client certificate bound to the identity key is bridged": {
+			bridge:    transport.X509Bridge{RequireIdentityBinding: true},
+			state:     func(t *testing.T) *tls.ConnectionState { return verified(t, identityKey) },
+			certifier: certifier,
+		},
+		"client certificate bound to another identity key is rejected": {
+			bridge:      transport.X509Bridge{RequireIdentityBinding: true},
+			state:       func(t *testing.T) *tls.ConnectionState { return verified(t, certifier) },
+			expectError: transport.ErrClientCertificateNotBound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// when
+			bridged, err := test.bridge.Bridge(test.state(t), identityKey, certifier)
+
+			// then
+			if test.expectError != nil {
+				require.ErrorIs(t, err, test.expectError)
+				require.Equal(t, transport.ErrCodeClientCertificateNotBound, transport.ErrorCode(err))
+				return
+			}
+			require.NoError(t, err)
+			if test.expectNil {
+				require.Nil(t, bridged)
+				return
+			}
+			require.NotNil(t, bridged)
+			require.Equal(t, test.certifier, bridged.Certifier)
+			require.Equal(t, identityKey, bridged.Subject)
+		})
+	}
+}
+
+// clientCertificate creates a self-signed client certificate, bound to the identity key when it is not empty
+func clientCertificate(t *testing.T, boundTo string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "alice", Organization: []string{"ACME", "ACME Labs"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	if boundTo != "" {
+		uri, err := url.Parse(transport.X509IdentityURIPrefix + boundTo)
+		require.NoError(t, err)
+		template.URIs = []*url.URL{uri}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert
+}
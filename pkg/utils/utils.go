@@ -11,13 +11,17 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
 	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 )
 
+// ErrPayloadEncryptionNotGranted is returned when the server did not grant the payload encryption requested by the client
+var ErrPayloadEncryptionNotGranted = errors.New("payload encryption was requested but not granted")
+
 // RequestData holds the request information used to create auth headers
 type RequestData struct {
 	Method  string
@@ -25,6 +29,13 @@ type RequestData struct {
 	Headers map[string]string
 	Body    []byte
 	Request *http.Request
+	// Origin binds the signature to the origin of the server (e.g. https://api.example.com), see GeneralKeyID.
+	// Empty signs the request without origin binding.
+	Origin string
+	// Random is the entropy source of the request ID, defaults to crypto/rand
+	Random io.Reader
+	// PathNormalization is the normalization of the signed path, it has to match the one of the server
+	PathNormalization authcore.PathNormalization
 }
 
 // PrepareInitialRequestBody prepares the initial request body
@@ -50,23 +61,181 @@ func PrepareInitialRequestBody(walletInstance wallet.WalletInterface) transport.
 	return initialRequest
 }
 
+// VerifyInitialResponse verifies the signature of the server over the nonces and the capabilities granted in the
+// initialResponse to the initialRequest. It fails when payload encryption was requested but not granted, so a session
+// cannot be downgraded to plaintext without the client noticing.
+func VerifyInitialResponse(walletInstance wallet.WalletInterface, initialRequest, initialResponse *transport.AuthMessage) error {
+	if initialResponse.YourNonce == nil || *initialResponse.YourNonce != initialRequest.InitialNonce {
+		return errors.New("initial response does not answer the initial request")
+	}
+	if initialResponse.Signature == nil {
+		return errors.New("initial response is not signed")
+	}
+	if initialRequest.PayloadEncryption && !initialResponse.PayloadEncryption {
+		return ErrPayloadEncryptionNotGranted
+	}
+
+	serverIdentityKey, err := ec.PublicKeyFromString(initialResponse.IdentityKey)
+	if err != nil {
+		return fmt.Errorf("failed to parse identity key, %w", err)
+	}
+
+	signature, err := ec.ParseSignature(*initialResponse.Signature)
+	if err != nil {
+		return fmt.Errorf("failed to parse signature, %w", err)
+	}
+
+	result, err := walletInstance.VerifySignature(&wallet.VerifySignatureArgs{
+		EncryptionArgs: wallet.EncryptionArgs{
+			ProtocolID: wallet.DefaultAuthProtocol,
+			Counterparty: wallet.Counterparty{
+				Type:         wallet.CounterpartyTypeOther,
+				Counterparty: serverIdentityKey,
+			},
+			KeyID: initialRequest.InitialNonce + initialResponse.InitialNonce,
+		},
+		Signature: *signature,
+		Data:      transport.HandshakeSigningPayload(initialRequest.InitialNonce, initialResponse.InitialNonce, initialResponse.GrantedCapabilities()),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to verify signature, %w", err)
+	}
+	if !result.Valid {
+		return errors.New("invalid initial response signature")
+	}
+	return nil
+}
+
+// PrepareCertificateResponse prepares a certificate response with the certificates for the session of the initial response,
+// signed for the server identity key
+func PrepareCertificateResponse(ctx context.Context, walletInstance wallet.WalletInterface, initialResponse *transport.AuthMessage, certs []certificates.VerifiableCertificate) (*transport.AuthMessage, error) {
+	nonce, err := walletInstance.CreateNonce(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create nonce, %w", err)
+	}
+
+	identityKey, err := walletInstance.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get identity key, %w", err)
+	}
+
+	serverKey, err := ec.PublicKeyFromString(initialResponse.IdentityKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse server identity key, %w", err)
+	}
+
+	data, err := authcore.CertificatesPayload(certs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode certificates, %w", err)
+	}
+
+	signature, err := walletInstance.CreateSignature(&wallet.CreateSignatureArgs{
+		EncryptionArgs: wallet.EncryptionArgs{
+			ProtocolID: wallet.DefaultAuthProtocol,
+			KeyID:      fmt.Sprintf("%s %s", nonce, initialResponse.InitialNonce),
+			Counterparty: wallet.Counterparty{
+				Type:         wallet.CounterpartyTypeOther,
+				Counterparty: serverKey,
+			},
+		},
+		Data: data,
+	}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign certificate response, %w", err)
+	}
+	signatureBytes := signature.Signature.Serialize()
+
+	return &transport.AuthMessage{
+		Version:      transport.AuthVersion,
+		MessageType:  transport.CertificateResponse,
+		IdentityKey:  identityKey.PublicKey.ToDERHex(),
+		Nonce:        &nonce,
+		YourNonce:    &initialResponse.InitialNonce,
+		Certificates: &certs,
+		Signature:    &signatureBytes,
+	}, nil
+}
+
 // PrepareGeneralRequestHeaders prepares the general request headers
 func PrepareGeneralRequestHeaders(walletInstance wallet.WalletInterface, previousResponse *transport.AuthMessage, requestData RequestData) (map[string]string, error) {
+	headers, _, err := prepareGeneralRequest(walletInstance, previousResponse, requestData, false)
+	return headers, err
+}
+
+// PrepareEncryptedGeneralRequest encrypts the request body for a session which negotiated payload encryption
+// and prepares the general request headers signing the encrypted body.
+// It returns the headers and the encrypted body which should be sent to the server.
+func PrepareEncryptedGeneralRequest(walletInstance wallet.WalletInterface, previousResponse *transport.AuthMessage, requestData RequestData) (map[string]string, []byte, error) {
+	return prepareGeneralRequest(walletInstance, previousResponse, requestData, true)
+}
+
+// DecryptResponseBody decrypts the body of a general response for a session which negotiated payload encryption.
+func DecryptResponseBody(walletInstance wallet.WalletInterface, headers http.Header, body []byte) ([]byte, error) {
+	if len(body) == 0 {
+		return body, nil
+	}
+
+	serverIdentityKey, err := ec.PublicKeyFromString(headers.Get("x-bsv-auth-identity-key"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse identity key, %w", err)
+	}
+
+	result, err := walletInstance.Decrypt(&wallet.DecryptArgs{
+		EncryptionArgs: wallet.EncryptionArgs{
+			ProtocolID: wallet.DefaultEncryptionProtocol,
+			Counterparty: wallet.Counterparty{
+				Type:         wallet.CounterpartyTypeOther,
+				Counterparty: serverIdentityKey,
+			},
+			KeyID: fmt.Sprintf("%s %s", headers.Get("x-bsv-auth-nonce"), headers.Get("x-bsv-auth-your-nonce")),
+		},
+		Ciphertext: body,
+	}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt response body, %w", err)
+	}
+
+	return result.Plaintext, nil
+}
+
+func prepareGeneralRequest(walletInstance wallet.WalletInterface, previousResponse *transport.AuthMessage, requestData RequestData, encrypt bool) (map[string]string, []byte, error) {
 	serverIdentityKey := previousResponse.IdentityKey
 	serverNonce := previousResponse.InitialNonce
 
 	opts := wallet.GetPublicKeyArgs{IdentityKey: true}
 	clientIdentityKey, err := walletInstance.GetPublicKey(&opts, "")
 	if err != nil {
-		return nil, errors.New("failed to get client identity key")
+		return nil, nil, errors.New("failed to get client identity key")
 	}
 
-	requestID := generateRandom()
+	requestID, err := generateRandom(requestData.Random)
+	if err != nil {
+		return nil, nil, err
+	}
 	encodedRequestID := base64.StdEncoding.EncodeToString(requestID)
 
 	newNonce, err := walletInstance.CreateNonce(context.Background())
 	if err != nil {
-		return nil, errors.New("failed to create new nonce")
+		return nil, nil, errors.New("failed to create new nonce")
+	}
+
+	key, err := ec.PublicKeyFromString(serverIdentityKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse identity key, %w", err)
+	}
+
+	counterparty := wallet.Counterparty{
+		Type:         wallet.CounterpartyTypeOther,
+		Counterparty: key,
+	}
+	keyID := GeneralKeyID(newNonce, serverNonce, requestData.Origin)
+
+	var body []byte
+	if encrypt {
+		requestData, body, err = encryptRequestData(walletInstance, requestData, counterparty, keyID)
+		if err != nil {
+			return nil, nil, err
+		}
 	}
 
 	var writer bytes.Buffer
@@ -74,32 +243,27 @@ func PrepareGeneralRequestHeaders(walletInstance wallet.WalletInterface, previou
 	writer.Write(requestID)
 
 	request := getOrPrepareTempRequest(requestData)
-	err = WriteRequestData(request, &writer)
+	err = WriteNormalizedRequestData(request, &writer, requestData.PathNormalization)
 	if err != nil {
-		return nil, err
-	}
-
-	key, err := ec.PublicKeyFromString(serverIdentityKey)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse identity key, %w", err)
+		return nil, nil, err
 	}
 
 	baseArgs := wallet.EncryptionArgs{
-		ProtocolID: wallet.DefaultAuthProtocol,
-		Counterparty: wallet.Counterparty{
-			Type:         wallet.CounterpartyTypeOther,
-			Counterparty: key,
-		},
-		KeyID: fmt.Sprintf("%s %s", newNonce, serverNonce),
+		ProtocolID:   wallet.DefaultAuthProtocol,
+		Counterparty: counterparty,
+		KeyID:        keyID,
 	}
 	createSignatureArgs := &wallet.CreateSignatureArgs{
 		EncryptionArgs: baseArgs,
 		Data:           writer.Bytes(),
 	}
+	if writer.Len() > authcore.DirectHashThreshold {
+		createSignatureArgs = createSignatureArgs.Hashed()
+	}
 
 	signature, err := walletInstance.CreateSignature(createSignatureArgs, "")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create signature, %w", err)
+		return nil, nil, fmt.Errorf("failed to create signature, %w", err)
 	}
 
 	headers := map[string]string{
@@ -110,76 +274,203 @@ func PrepareGeneralRequestHeaders(walletInstance wallet.WalletInterface, previou
 		"x-bsv-auth-signature":    hex.EncodeToString(signature.Signature.Serialize()),
 		"x-bsv-auth-request-id":   encodedRequestID,
 	}
+	if requestData.Origin != "" {
+		headers[OriginHeader] = requestData.Origin
+	}
 
-	return headers, nil
+	return headers, body, nil
 }
 
-// WriteRequestData writes the request data into a buffer
-func WriteRequestData(request *http.Request, writer *bytes.Buffer) error {
-	err := WriteVarIntNum(writer, len(request.Method))
-	if err != nil {
-		return errors.New("failed to write method length")
+// encryptRequestData returns a copy of the request data with the body replaced by its encrypted form
+func encryptRequestData(walletInstance wallet.WalletInterface, requestData RequestData, counterparty wallet.Counterparty, keyID string) (RequestData, []byte, error) {
+	encrypted := RequestData{
+		Method:            requestData.Method,
+		URL:               requestData.URL,
+		Headers:           requestData.Headers,
+		Body:              requestData.Body,
+		Origin:            requestData.Origin,
+		PathNormalization: requestData.PathNormalization,
 	}
-	writer.Write([]byte(request.Method))
 
-	err = WriteVarIntNum(writer, len(request.URL.Path))
-	if err != nil {
-		return errors.New("failed to write path length")
-	}
-	writer.Write([]byte(request.URL.Path))
-
-	query := request.URL.RawQuery
-	if len(query) > 0 {
-		searchAsArray := []byte(query)
-		err = WriteVarIntNum(writer, len(searchAsArray))
-		if err != nil {
-			return errors.New("failed to write query length")
+	if requestData.Request != nil {
+		encrypted.Method = requestData.Request.Method
+		encrypted.URL = requestData.Request.URL.String()
+		encrypted.Headers = make(map[string]string, len(requestData.Request.Header))
+		for k := range requestData.Request.Header {
+			encrypted.Headers[k] = requestData.Request.Header.Get(k)
 		}
-		writer.Write([]byte(query))
-	} else {
-		err = WriteVarIntNum(writer, -1)
-		if err != nil {
-			return errors.New("failed to write -1 as query length")
+
+		if requestData.Request.Body != nil {
+			body, err := io.ReadAll(requestData.Request.Body)
+			if err != nil {
+				return RequestData{}, nil, errors.New("failed to read request body")
+			}
+			encrypted.Body = body
 		}
 	}
 
-	includedHeaders := ExtractHeaders(request.Header)
-	err = WriteVarIntNum(writer, len(includedHeaders))
+	if len(encrypted.Body) == 0 {
+		return encrypted, encrypted.Body, nil
+	}
+
+	result, err := walletInstance.Encrypt(&wallet.EncryptArgs{
+		EncryptionArgs: wallet.EncryptionArgs{
+			ProtocolID:   wallet.DefaultEncryptionProtocol,
+			Counterparty: counterparty,
+			KeyID:        keyID,
+		},
+		Plaintext: encrypted.Body,
+	}, "")
 	if err != nil {
-		return errors.New("failed to write headers length")
+		return RequestData{}, nil, fmt.Errorf("failed to encrypt request body, %w", err)
 	}
 
-	for _, header := range includedHeaders {
-		headerKeyBytes := []byte(header[0])
-		err = WriteVarIntNum(writer, len(headerKeyBytes))
-		if err != nil {
-			return errors.New("failed to write header key length")
+	encrypted.Body = result.Ciphertext
+	return encrypted, encrypted.Body, nil
+}
+
+// WriteRequestData writes the request data into a buffer, with the path as it is
+func WriteRequestData(request *http.Request, writer *bytes.Buffer) error {
+	return WriteNormalizedRequestData(request, writer, authcore.PathStrict)
+}
+
+// WriteNormalizedRequestData writes the request data into a buffer, with the path normalized, see authcore.PathNormalization
+func WriteNormalizedRequestData(request *http.Request, writer *bytes.Buffer, normalization authcore.PathNormalization) error {
+	body, err := readRequestBody(request)
+	if err != nil {
+		return errors.New("failed to write request body")
+	}
+
+	authcore.RequestPayload{
+		Method:  request.Method,
+		Path:    authcore.NormalizePath(request.URL.EscapedPath(), normalization),
+		Query:   request.URL.RawQuery,
+		Headers: ExtractHeaders(request.Header),
+		Body:    body,
+	}.WriteTo(writer)
+
+	return nil
+}
+
+// OriginHeader carries the origin a general request is bound to, the origin is part of the key ID of its signature
+const OriginHeader = "x-bsv-auth-origin"
+
+// GeneralKeyID returns the key ID of the signature of a general request. A non empty origin is appended,
+// so a signature bound to one server origin cannot be replayed against another origin sharing the identity key.
+func GeneralKeyID(nonce, yourNonce, origin string) string {
+	return authcore.GeneralKeyID(nonce, yourNonce, origin)
+}
+
+// NewSignatureVerifier returns the authcore.SignatureVerifier verifying signatures of the auth protocol with the wallet
+func NewSignatureVerifier(walletInstance wallet.WalletInterface) authcore.SignatureVerifier {
+	return authcore.SignatureVerifierFunc(func(counterparty *ec.PublicKey, keyID string, data []byte, signature *ec.Signature) (bool, error) {
+		args := &wallet.VerifySignatureArgs{
+			EncryptionArgs: wallet.EncryptionArgs{
+				ProtocolID: wallet.DefaultAuthProtocol,
+				KeyID:      keyID,
+				Counterparty: wallet.Counterparty{
+					Type:         wallet.CounterpartyTypeOther,
+					Counterparty: counterparty,
+				},
+			},
+			Signature: *signature,
+			Data:      data,
+		}
+		if len(data) > authcore.DirectHashThreshold {
+			args = args.Hashed()
 		}
-		writer.Write(headerKeyBytes)
 
-		headerValueBytes := []byte(header[1])
-		err = WriteVarIntNum(writer, len(headerValueBytes))
+		result, err := walletInstance.VerifySignature(args)
 		if err != nil {
-			return errors.New("failed to write header value length")
+			return false, err
 		}
-		writer.Write(headerValueBytes)
+		return result.Valid, nil
+	})
+}
+
+// ServerInfoHeader carries the implementation name and version of the server, it is signed with the response
+const ServerInfoHeader = "x-bsv-auth-server"
+
+// Headers of responses co-signed by a tenant identity the server responds on behalf of, e.g. a merchant of a marketplace.
+// The identity key of the co-signer is signed by the server with the response, the co-signer signs the same payload
+// with the key ID of the server signature, so the client verifies both signatures against one payload.
+const (
+	// CoSignerIdentityKeyHeader carries the identity key (hex) of the co-signer
+	CoSignerIdentityKeyHeader = "x-bsv-auth-cosigner-identity-key"
+	// CoSignatureHeader carries the signature (hex) of the co-signer
+	CoSignatureHeader = "x-bsv-auth-cosignature"
+)
+
+// Headers of ranged and file responses, they are signed with the response when present
+const (
+	// ContentDigestHeader carries the digest of the complete content of a file (RFC 9530), so a client can verify
+	// content assembled from ranged responses. The digest is SHA-256 unless the session negotiated another algorithm,
+	// see transport.NegotiatedDigestAlgorithm
+	ContentDigestHeader = "content-digest"
+	// ContentRangeHeader carries the position of a partial body in the complete content
+	ContentRangeHeader = "content-range"
+)
+
+// BuildResponsePayload constructs the general response payload signed by the server for a response without signed headers
+func BuildResponsePayload(
+	requestID string,
+	responseStatus int,
+	responseBody []byte,
+) ([]byte, error) {
+	return BuildSignedResponsePayload(requestID, responseStatus, nil, responseBody)
+}
+
+// BuildSignedResponsePayload constructs the general response payload signed by the server
+// The payload is constructed as follows:
+// - Request ID (Base64)
+// - Response status
+// - Number of headers
+// - Headers (key length, key, value length, value)
+// - Body length and content
+func BuildSignedResponsePayload(
+	requestID string,
+	responseStatus int,
+	responseHeaders http.Header,
+	responseBody []byte,
+) ([]byte, error) {
+	requestIDBytes, err := base64.StdEncoding.DecodeString(requestID)
+	if err != nil {
+		return nil, errors.New("failed to decode request ID")
 	}
 
-	err = WriteBodyToBuffer(request, writer)
+	return authcore.ResponsePayload{
+		RequestID: requestIDBytes,
+		Status:    responseStatus,
+		Headers:   SignedResponseHeaders(responseHeaders),
+		Body:      responseBody,
+	}.Bytes(), nil
+}
+
+// BuildStreamedResponsePayload constructs the payload signed by the server in the trailer of a streamed response,
+// the SHA-256 hash of the streamed body is signed instead of the body, see authcore.StreamedLength
+func BuildStreamedResponsePayload(
+	requestID string,
+	responseStatus int,
+	responseHeaders http.Header,
+	bodyHash []byte,
+) ([]byte, error) {
+	requestIDBytes, err := base64.StdEncoding.DecodeString(requestID)
 	if err != nil {
-		return errors.New("failed to write request body")
+		return nil, errors.New("failed to decode request ID")
 	}
 
-	return nil
+	return authcore.ResponsePayload{
+		RequestID: requestIDBytes,
+		Status:    responseStatus,
+		Headers:   SignedResponseHeaders(responseHeaders),
+		BodyHash:  bodyHash,
+	}.Bytes(), nil
 }
 
 // WriteVarIntNum writes a variable-length integer to a buffer
 // integer is converted to fixed size int64
 func WriteVarIntNum(writer *bytes.Buffer, num int) error {
-	err := binary.Write(writer, binary.LittleEndian, int64(num))
-	if err != nil {
-		return errors.New("failed to write varint number")
-	}
+	authcore.WriteVarInt(writer, num)
 	return nil
 }
 
@@ -194,57 +485,73 @@ func ReadVarIntNum(reader *bytes.Reader) (int64, error) {
 	return intByte, nil
 }
 
-// ExtractHeaders extracts required headers based on conditions
-func ExtractHeaders(headers http.Header) [][]string {
+// SignedResponseHeaders returns the response headers included in the signed payload in their signing order,
+// headers added to the list later are appended so payloads of responses without them are unchanged
+func SignedResponseHeaders(headers http.Header) [][]string {
 	var includedHeaders [][]string
-	for k, v := range headers {
-		k = strings.ToLower(k)
-		if (strings.HasPrefix(k, "x-bsv-") || k == "content-type" || k == "authorization") &&
-			!strings.HasPrefix(k, "x-bsv-auth") {
-			includedHeaders = append(includedHeaders, []string{k, v[0]})
+	for _, name := range []string{ContentDigestHeader, ContentRangeHeader, ServerInfoHeader, CoSignerIdentityKeyHeader} {
+		if value := headers.Get(name); value != "" {
+			includedHeaders = append(includedHeaders, []string{name, value})
 		}
 	}
 	return includedHeaders
 }
 
-// WriteBodyToBuffer writes the request body into a buffer
-func WriteBodyToBuffer(req *http.Request, buf *bytes.Buffer) error {
-	if req.Body == nil {
-		err := WriteVarIntNum(buf, -1)
-		if err != nil {
-			return errors.New("failed to write -1 for empty body")
-		}
-		return nil
-	}
+// ExtractHeaders returns the request headers included in the signed payload, sorted by name like the TS SDK does.
+// The content-type is included without its parameters, as clients and proxies may rewrite them (e.g. the charset),
+// the boundary of multipart bodies is signed with the body.
+func ExtractHeaders(headers http.Header) [][]string {
+	return authcore.SignedRequestHeaders(headers)
+}
 
-	body, err := io.ReadAll(req.Body)
+// NormalizeContentType returns the media type of the content-type header value without its parameters,
+// e.g. "multipart/form-data" for "multipart/form-data; boundary=X"
+func NormalizeContentType(value string) string {
+	return authcore.NormalizeContentType(value)
+}
+
+// WriteBodyToBuffer writes the request body into a buffer, the body is replaced with a re-readable copy,
+// so the request can still be sent after it was signed
+func WriteBodyToBuffer(req *http.Request, buf *bytes.Buffer) error {
+	body, err := readRequestBody(req)
 	if err != nil {
 		return errors.New("failed to read request body")
 	}
 
 	if len(body) > 0 {
-		err = WriteVarIntNum(buf, len(body))
-		if err != nil {
-			return errors.New("failed to write body length")
-		}
+		authcore.WriteVarInt(buf, len(body))
 		buf.Write(body)
 		return nil
 	}
 
-	err = WriteVarIntNum(buf, -1)
+	authcore.WriteVarInt(buf, authcore.AbsentLength)
+	return nil
+}
+
+// readRequestBody reads the request body and replaces it with a re-readable copy
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
 	if err != nil {
-		return errors.New("failed to write -1 for empty body")
+		return nil, err //nolint:wrapcheck // callers describe the failed read
 	}
-	return nil
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
 }
 
-func generateRandom() []byte {
+func generateRandom(random io.Reader) ([]byte, error) {
+	if random == nil {
+		random = rand.Reader
+	}
+
 	b := make([]byte, 32)
-	_, err := rand.Read(b)
-	if err != nil {
-		panic(err)
+	if _, err := io.ReadFull(random, b); err != nil {
+		return nil, fmt.Errorf("failed to read random bytes of request ID, %w", err)
 	}
-	return b
+	return b, nil
 }
 
 func getOrPrepareTempRequest(requestData RequestData) *http.Request {
@@ -0,0 +1,19 @@
+package wallet
+
+import "errors"
+
+// Errors returned by the wallet, matchable with errors.Is
+var (
+	// ErrArgsRequired is returned when a wallet method is called without args
+	ErrArgsRequired = errors.New("args must be provided")
+	// ErrSignatureInvalid is returned when a signature does not match the data and the derived key
+	ErrSignatureInvalid = errors.New("signature is not valid")
+	// ErrSignatureArgsInvalid is returned when signature args do not set exactly one of the data and a SHA-256 hash
+	ErrSignatureArgsInvalid = errors.New("signature args must set either data or a SHA-256 hash")
+	// ErrNonceInvalid is returned when a nonce was not created by the wallet or was already consumed
+	ErrNonceInvalid = errors.New("nonce is not valid")
+	// ErrPrivilegedKeyUnavailable is returned for privileged operations of a wallet without a privileged keyring
+	ErrPrivilegedKeyUnavailable = errors.New("wallet has no privileged keyring")
+	// ErrPermissionDenied is returned when the user of an interactive wallet denies the operation
+	ErrPermissionDenied = errors.New("permission denied by the user")
+)
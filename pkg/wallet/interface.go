@@ -0,0 +1,62 @@
+// Package wallet is the supported API of the wallet the middleware authenticates with: the wallet interfaces,
+// their arguments and results, the protocols of the keys the middleware derives and the errors of wallets.
+// It replaces pkg/temporary/wallet, which is deprecated and kept for compatibility, the identifiers are the same
+// so migrating only changes the import path, except for the certificate types which moved to pkg/certificates
+// and the mock wallets and fixtures which moved to pkg/wallet/wallettest.
+//
+//	middleware, err := auth.New(auth.Config{Wallet: wallettest.NewMockWallet(key), SessionManager: session.NewMemoryManager()})
+package wallet
+
+import (
+	"context"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+)
+
+// WalletInterface defines the core functionality needed for authentication
+type WalletInterface interface { //nolint:revive // WalletInterface will be adopted from GO-SDK in the future.
+	// GetPublicKey returns a public key
+	GetPublicKey(args *GetPublicKeyArgs, originator string) (*GetPublicKeyResult, error)
+
+	// CreateSignature signs with the key derived for the protocol and key ID. The signature is created over the SHA-256
+	// hash of args.Data or over args.HashToDirectlySign as is, as returned by CreateSignatureArgs.Hash,
+	// like the createSignature of BRC-100 wallets.
+	CreateSignature(args *CreateSignatureArgs, originator string) (*CreateSignatureResult, error)
+
+	// VerifySignature verifies a signature over the SHA-256 hash of args.Data or over args.HashToDirectlyVerify,
+	// as returned by VerifySignatureArgs.Hash
+	VerifySignature(args *VerifySignatureArgs) (*VerifySignatureResult, error)
+
+	// Encrypt encrypts data with a symmetric key derived for the given protocol, key ID and counterparty
+	Encrypt(args *EncryptArgs, originator string) (*EncryptResult, error)
+
+	// Decrypt decrypts data with a symmetric key derived for the given protocol, key ID and counterparty
+	Decrypt(args *DecryptArgs, originator string) (*DecryptResult, error)
+
+	// RevealCounterpartyKeyLinkage reveals the linkage of all keys derived for the counterparty to the verifier
+	RevealCounterpartyKeyLinkage(args *RevealCounterpartyKeyLinkageArgs, originator string) (*RevealCounterpartyKeyLinkageResult, error)
+
+	// RevealSpecificKeyLinkage reveals the linkage of a single derived key to the verifier
+	RevealSpecificKeyLinkage(args *RevealSpecificKeyLinkageArgs, originator string) (*RevealSpecificKeyLinkageResult, error)
+
+	// CreateNonce creates a nonce for challenge-response authentication
+	CreateNonce(ctx context.Context) (string, error)
+
+	// VerifyNonce verifies a nonce that was previously created
+	VerifyNonce(ctx context.Context, nonce string) (bool, error)
+
+	// GetHeight returns the current height of the blockchain known to the wallet
+	GetHeight(ctx context.Context, originator string) (*GetHeightResult, error)
+
+	// GetNetwork returns the network the wallet operates on
+	GetNetwork(ctx context.Context, originator string) (*GetNetworkResult, error)
+
+	// GetVersion returns the version of the wallet implementation
+	GetVersion(ctx context.Context, originator string) (*GetVersionResult, error)
+
+	// ListCertificates is a stub for future certificate functionality
+	ListCertificates(ctx context.Context, certifiers []string, types []string) ([]certificates.Certificate, error)
+
+	// ProveCertificate is a stub for future certificate functionality
+	ProveCertificate(ctx context.Context, certificate certificates.Certificate, verifier string, fieldsToReveal []string) (map[string]string, error)
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	crypto "github.com/bsv-blockchain/go-sdk/primitives/hash"
 )
 
 // KeyDeriver is responsible for deriving public and private keys based on a root key.
@@ -73,6 +74,73 @@ func (kd *KeyDeriver) DerivePrivateKey(protocol Protocol, keyID string, counterp
 	return k, nil
 }
 
+// DeriveSymmetricKey creates a symmetric key based on protocol ID, key ID, and counterparty.
+// The key is the x coordinate of the shared secret between the derived private key of the root key
+// and the derived public key of the counterparty, so both parties derive the same key (BRC-42).
+func (kd *KeyDeriver) DeriveSymmetricKey(protocol Protocol, keyID string, counterparty Counterparty) (*ec.SymmetricKey, error) {
+	derivedPublicKey, err := kd.DerivePublicKey(protocol, keyID, counterparty, false)
+	if err != nil {
+		return nil, err
+	}
+
+	derivedPrivateKey, err := kd.DerivePrivateKey(protocol, keyID, counterparty)
+	if err != nil {
+		return nil, err
+	}
+
+	sharedSecret, err := derivedPrivateKey.DeriveSharedSecret(derivedPublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive shared secret: %w", err)
+	}
+
+	return ec.NewSymmetricKey(sharedSecret.X.FillBytes(make([]byte, 32))), nil
+}
+
+// IdentityKey returns the public key of the root key
+func (kd *KeyDeriver) IdentityKey() *ec.PublicKey {
+	return kd.rootKey.PubKey()
+}
+
+// RevealCounterpartySecret returns the shared secret between the root key and the counterparty.
+// Revealing it proves the linkage of all keys derived for the counterparty (BRC-69).
+func (kd *KeyDeriver) RevealCounterpartySecret(counterparty Counterparty) (*ec.PublicKey, error) {
+	if counterparty.Type == CounterpartyTypeSelf {
+		return nil, errors.New("counterparty secrets cannot be revealed for counterparty=self")
+	}
+
+	counterpartyKey, err := kd.normalizeCounterparty(counterparty)
+	if err != nil {
+		return nil, err
+	}
+	if counterpartyKey.IsEqual(kd.rootKey.PubKey()) {
+		return nil, errors.New("counterparty secrets cannot be revealed if counterparty key is self")
+	}
+
+	sharedSecret, err := kd.rootKey.DeriveSharedSecret(counterpartyKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive shared secret: %w", err)
+	}
+	return sharedSecret, nil
+}
+
+// RevealSpecificSecret returns the BRC-42 offset of a single derived key.
+// Revealing it proves the linkage of that key only, without exposing other keys derived for the counterparty (BRC-69).
+func (kd *KeyDeriver) RevealSpecificSecret(counterparty Counterparty, protocol Protocol, keyID string) ([]byte, error) {
+	counterpartyKey, err := kd.normalizeCounterparty(counterparty)
+	if err != nil {
+		return nil, err
+	}
+	sharedSecret, err := kd.rootKey.DeriveSharedSecret(counterpartyKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive shared secret: %w", err)
+	}
+	invoiceNumber, err := kd.computeInvoiceNumber(protocol, keyID)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Sha256HMAC([]byte(invoiceNumber), sharedSecret.Compressed()), nil
+}
+
 // normalizeCounterparty converts the counterparty parameter into a standard public key format.
 // It handles special cases like 'self' and 'anyone' by converting them to their corresponding public keys.
 func (kd *KeyDeriver) normalizeCounterparty(counterparty Counterparty) (*ec.PublicKey, error) {
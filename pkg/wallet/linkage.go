@@ -0,0 +1,257 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	crypto "github.com/bsv-blockchain/go-sdk/primitives/hash"
+)
+
+// Proof types of revealed specific key linkages
+const (
+	// SpecificLinkageProofNone reveals the linkage without a proof
+	SpecificLinkageProofNone byte = 0
+	// SpecificLinkageProofSchnorr proves with a Schnorr proof that the prover holds the private key derived
+	// with the revealed linkage
+	SpecificLinkageProofSchnorr byte = 1
+)
+
+// ErrInvalidLinkageProof is returned when the proof of a revealed key linkage does not verify
+var ErrInvalidLinkageProof = errors.New("invalid linkage proof")
+
+const (
+	compressedPointLength = 33
+	scalarLength          = 32
+)
+
+// CounterpartyLinkageProof is the Schnorr zero-knowledge proof of BRC-69 that a revealed shared secret S is the one of
+// the prover and the counterparty, S = a·B for the identity key A = a·G of the prover and the key B of the counterparty,
+// without revealing a. R = r·G and SPrime = r·B are the commitments of the nonce r and Z = r + e·a is the response
+// to the challenge e = H(A, B, S, SPrime, R).
+type CounterpartyLinkageProof struct {
+	R      *ec.PublicKey
+	SPrime *ec.PublicKey
+	Z      *big.Int
+}
+
+// Bytes serializes the proof as the compressed R and SPrime followed by the 32-byte Z
+func (p *CounterpartyLinkageProof) Bytes() []byte {
+	data := make([]byte, 0, 2*compressedPointLength+scalarLength)
+	data = append(data, p.R.Compressed()...)
+	data = append(data, p.SPrime.Compressed()...)
+	return append(data, p.Z.FillBytes(make([]byte, scalarLength))...)
+}
+
+// ParseCounterpartyLinkageProof parses a proof serialized by Bytes
+func ParseCounterpartyLinkageProof(data []byte) (*CounterpartyLinkageProof, error) {
+	if len(data) != 2*compressedPointLength+scalarLength {
+		return nil, fmt.Errorf("%w: unexpected length %d", ErrInvalidLinkageProof, len(data))
+	}
+
+	r, err := ec.ParsePubKey(data[:compressedPointLength])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidLinkageProof, err)
+	}
+	sPrime, err := ec.ParsePubKey(data[compressedPointLength : 2*compressedPointLength])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidLinkageProof, err)
+	}
+
+	return &CounterpartyLinkageProof{R: r, SPrime: sPrime, Z: new(big.Int).SetBytes(data[2*compressedPointLength:])}, nil
+}
+
+// ProveCounterpartyLinkage creates the proof that the shared secret is the one of the prover key and the counterparty
+func ProveCounterpartyLinkage(prover *ec.PrivateKey, counterparty, sharedSecret *ec.PublicKey) (*CounterpartyLinkageProof, error) {
+	nonce, err := ec.NewPrivateKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create proof nonce: %w", err)
+	}
+
+	r := nonce.PubKey()
+	sPrime := counterparty.Mul(nonce.D)
+	e := linkageChallenge(nil, prover.PubKey(), counterparty, sharedSecret, sPrime, r)
+
+	z := new(big.Int).Mul(e, prover.D)
+	z.Add(z, nonce.D)
+	z.Mod(z, ec.S256().N)
+
+	return &CounterpartyLinkageProof{R: r, SPrime: sPrime, Z: z}, nil
+}
+
+// ProveCounterpartySecret creates the proof of a shared secret revealed with RevealCounterpartySecret
+func (kd *KeyDeriver) ProveCounterpartySecret(counterparty, sharedSecret *ec.PublicKey) (*CounterpartyLinkageProof, error) {
+	return ProveCounterpartyLinkage(kd.rootKey, counterparty, sharedSecret)
+}
+
+// VerifyCounterpartyLinkage verifies the proof that the shared secret is the one of the prover and the counterparty,
+// it checks Z·G = R + e·A and Z·B = SPrime + e·S
+func VerifyCounterpartyLinkage(prover, counterparty, sharedSecret *ec.PublicKey, proof *CounterpartyLinkageProof) bool {
+	e := linkageChallenge(nil, prover, counterparty, sharedSecret, proof.SPrime, proof.R)
+
+	if !addPoints(proof.R, prover.Mul(e)).IsEqual(baseMul(proof.Z)) {
+		return false
+	}
+	return addPoints(proof.SPrime, sharedSecret.Mul(e)).IsEqual(counterparty.Mul(proof.Z))
+}
+
+// SpecificLinkageProof is the Schnorr proof of knowledge of the private key x = a + o derived with a revealed specific
+// linkage o, for the derived key P = A + o·G of the prover. R = r·G is the commitment of the nonce r and Z = r + e·x
+// is the response to the challenge e = H(P, R, context), the context binds the proof to the revelation.
+// The proof does not reveal the shared secret with the counterparty, so it cannot show that o was computed with it.
+type SpecificLinkageProof struct {
+	R *ec.PublicKey
+	Z *big.Int
+}
+
+// Bytes serializes the proof as the compressed R followed by the 32-byte Z
+func (p *SpecificLinkageProof) Bytes() []byte {
+	data := make([]byte, 0, compressedPointLength+scalarLength)
+	data = append(data, p.R.Compressed()...)
+	return append(data, p.Z.FillBytes(make([]byte, scalarLength))...)
+}
+
+// ParseSpecificLinkageProof parses a proof serialized by Bytes
+func ParseSpecificLinkageProof(data []byte) (*SpecificLinkageProof, error) {
+	if len(data) != compressedPointLength+scalarLength {
+		return nil, fmt.Errorf("%w: unexpected length %d", ErrInvalidLinkageProof, len(data))
+	}
+
+	r, err := ec.ParsePubKey(data[:compressedPointLength])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidLinkageProof, err)
+	}
+
+	return &SpecificLinkageProof{R: r, Z: new(big.Int).SetBytes(data[compressedPointLength:])}, nil
+}
+
+// ProveSpecificLinkage creates the proof of knowledge of the derived private key, bound to the context
+func ProveSpecificLinkage(derived *ec.PrivateKey, context []byte) (*SpecificLinkageProof, error) {
+	nonce, err := ec.NewPrivateKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create proof nonce: %w", err)
+	}
+
+	r := nonce.PubKey()
+	e := linkageChallenge(context, derived.PubKey(), r)
+
+	z := new(big.Int).Mul(e, derived.D)
+	z.Add(z, nonce.D)
+	z.Mod(z, ec.S256().N)
+
+	return &SpecificLinkageProof{R: r, Z: z}, nil
+}
+
+// ProveSpecificSecret creates the proof of a specific secret revealed with RevealSpecificSecret, bound to the
+// revelation to the verifier
+func (kd *KeyDeriver) ProveSpecificSecret(counterparty Counterparty, protocol Protocol, keyID string, verifier *ec.PublicKey) (*SpecificLinkageProof, error) {
+	derived, err := kd.DerivePrivateKey(protocol, keyID, counterparty)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive private key: %w", err)
+	}
+	return ProveSpecificLinkage(derived, specificLinkageContext(verifier, protocol, keyID))
+}
+
+// VerifySpecificLinkage verifies the proof of knowledge of the private key derived from the prover identity key with
+// the linkage, it checks Z·G = R + e·P for P = A + linkage·G
+func VerifySpecificLinkage(prover *ec.PublicKey, linkage []byte, context []byte, proof *SpecificLinkageProof) bool {
+	derived := addPoints(prover, baseMul(new(big.Int).SetBytes(linkage)))
+	e := linkageChallenge(context, derived, proof.R)
+
+	return addPoints(proof.R, derived.Mul(e)).IsEqual(baseMul(proof.Z))
+}
+
+// VerifyCounterpartyKeyLinkage decrypts a revealed counterparty linkage with the wallet of the verifier and verifies
+// its proof, it returns the shared secret of the prover and the counterparty
+func VerifyCounterpartyKeyLinkage(verifier WalletInterface, revelation *RevealCounterpartyKeyLinkageResult) (*ec.PublicKey, error) {
+	encryption := EncryptionArgs{
+		ProtocolID:   CounterpartyLinkageRevelationProtocol,
+		KeyID:        revelation.RevelationTime,
+		Counterparty: Counterparty{Type: CounterpartyTypeOther, Counterparty: revelation.Prover},
+	}
+
+	linkage, err := verifier.Decrypt(&DecryptArgs{EncryptionArgs: encryption, Ciphertext: revelation.EncryptedLinkage}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt linkage: %w", err)
+	}
+	sharedSecret, err := ec.ParsePubKey(linkage.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse linkage: %w", err)
+	}
+
+	decrypted, err := verifier.Decrypt(&DecryptArgs{EncryptionArgs: encryption, Ciphertext: revelation.EncryptedLinkageProof}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt linkage proof: %w", err)
+	}
+	proof, err := ParseCounterpartyLinkageProof(decrypted.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	if !VerifyCounterpartyLinkage(revelation.Prover, revelation.Counterparty, sharedSecret, proof) {
+		return nil, ErrInvalidLinkageProof
+	}
+	return sharedSecret, nil
+}
+
+// VerifySpecificKeyLinkage decrypts a revealed specific linkage with the wallet of the verifier and verifies its proof,
+// it returns the linkage of the derived key. Linkages revealed without a proof are rejected.
+func VerifySpecificKeyLinkage(verifier WalletInterface, revelation *RevealSpecificKeyLinkageResult) ([]byte, error) {
+	if revelation.ProofType != SpecificLinkageProofSchnorr {
+		return nil, fmt.Errorf("%w: unsupported proof type %d", ErrInvalidLinkageProof, revelation.ProofType)
+	}
+
+	encryption := EncryptionArgs{
+		ProtocolID:   SpecificLinkageRevelationProtocol(revelation.ProtocolID),
+		KeyID:        revelation.KeyID,
+		Counterparty: Counterparty{Type: CounterpartyTypeOther, Counterparty: revelation.Prover},
+	}
+
+	linkage, err := verifier.Decrypt(&DecryptArgs{EncryptionArgs: encryption, Ciphertext: revelation.EncryptedLinkage}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt linkage: %w", err)
+	}
+
+	decrypted, err := verifier.Decrypt(&DecryptArgs{EncryptionArgs: encryption, Ciphertext: revelation.EncryptedLinkageProof}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt linkage proof: %w", err)
+	}
+	proof, err := ParseSpecificLinkageProof(decrypted.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	context := specificLinkageContext(revelation.Verifier, revelation.ProtocolID, revelation.KeyID)
+	if !VerifySpecificLinkage(revelation.Prover, linkage.Plaintext, context, proof) {
+		return nil, ErrInvalidLinkageProof
+	}
+	return linkage.Plaintext, nil
+}
+
+// specificLinkageContext returns the revelation a specific linkage proof is bound to
+func specificLinkageContext(verifier *ec.PublicKey, protocol Protocol, keyID string) []byte {
+	return []byte(fmt.Sprintf("%s %d %s %s", verifier.ToDERHex(), protocol.SecurityLevel, protocol.Protocol, keyID))
+}
+
+// linkageChallenge hashes the compressed points followed by the context into a scalar
+func linkageChallenge(context []byte, points ...*ec.PublicKey) *big.Int {
+	var data []byte
+	for _, point := range points {
+		data = append(data, point.Compressed()...)
+	}
+	data = append(data, context...)
+
+	e := new(big.Int).SetBytes(crypto.Sha256(data))
+	return e.Mod(e, ec.S256().N)
+}
+
+func baseMul(k *big.Int) *ec.PublicKey {
+	x, y := ec.S256().ScalarBaseMult(k.Bytes())
+	return &ec.PublicKey{Curve: ec.S256(), X: x, Y: y}
+}
+
+func addPoints(p, q *ec.PublicKey) *ec.PublicKey {
+	x, y := ec.S256().Add(p.X, p.Y, q.X, q.Y)
+	return &ec.PublicKey{Curve: ec.S256(), X: x, Y: y}
+}
@@ -8,4 +8,7 @@ type PaymentInterface interface {
 
 	// InternalizeAction processes a received payment transaction
 	InternalizeAction(ctx context.Context, args InternalizeActionArgs) (InternalizeActionResult, error)
+
+	// CreateAction creates and broadcasts a transaction funded by the wallet, e.g. to refund a payment
+	CreateAction(ctx context.Context, args CreateActionArgs) (CreateActionResult, error)
 }
@@ -0,0 +1,61 @@
+package wallet
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// Hash returns the SHA-256 hash the signature is created over: the hash of Data, or HashToDirectlySign as is.
+// Args setting both or neither of them, or a HashToDirectlySign which is not a SHA-256 hash, are rejected
+// with ErrSignatureArgsInvalid.
+func (a *CreateSignatureArgs) Hash() ([]byte, error) {
+	return signedHash(a.Data, a.HashToDirectlySign)
+}
+
+// Hash returns the SHA-256 hash the signature is verified over: the hash of Data, or HashToDirectlyVerify as is.
+// Args setting both or neither of them, or a HashToDirectlyVerify which is not a SHA-256 hash, are rejected
+// with ErrSignatureArgsInvalid.
+func (a *VerifySignatureArgs) Hash() ([]byte, error) {
+	return signedHash(a.Data, a.HashToDirectlyVerify)
+}
+
+func signedHash(data, hash []byte) ([]byte, error) {
+	switch {
+	case len(data) > 0 && len(hash) > 0:
+		return nil, fmt.Errorf("%w, both are set", ErrSignatureArgsInvalid)
+	case len(hash) > 0:
+		if len(hash) != sha256.Size {
+			return nil, fmt.Errorf("%w, hash of %d bytes is not a SHA-256 hash", ErrSignatureArgsInvalid, len(hash))
+		}
+		return hash, nil
+	case len(data) > 0:
+		sum := sha256.Sum256(data)
+		return sum[:], nil
+	default:
+		return nil, fmt.Errorf("%w, neither is set", ErrSignatureArgsInvalid)
+	}
+}
+
+// Hashed returns a copy of the args with the SHA-256 hash of Data as HashToDirectlySign, which produces the same
+// signature without passing Data to the wallet. Args without Data are returned as is.
+func (a *CreateSignatureArgs) Hashed() *CreateSignatureArgs {
+	if len(a.Data) == 0 {
+		return a
+	}
+	sum := sha256.Sum256(a.Data)
+	hashed := *a
+	hashed.Data, hashed.HashToDirectlySign = nil, sum[:]
+	return &hashed
+}
+
+// Hashed returns a copy of the args with the SHA-256 hash of Data as HashToDirectlyVerify, which verifies the same
+// signature without passing Data to the wallet. Args without Data are returned as is.
+func (a *VerifySignatureArgs) Hashed() *VerifySignatureArgs {
+	if len(a.Data) == 0 {
+		return a
+	}
+	sum := sha256.Sum256(a.Data)
+	hashed := *a
+	hashed.Data, hashed.HashToDirectlyVerify = nil, sum[:]
+	return &hashed
+}
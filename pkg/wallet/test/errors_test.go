@@ -0,0 +1,46 @@
+package wallet_test
+
+import (
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockWallet_Errors(t *testing.T) {
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	w := wallettest.NewMockWallet(key)
+
+	t.Run("missing args", func(t *testing.T) {
+		// when
+		_, signErr := w.CreateSignature(nil, "")
+		_, verifyErr := w.VerifySignature(nil)
+
+		// then
+		require.ErrorIs(t, signErr, wallet.ErrArgsRequired)
+		require.ErrorIs(t, verifyErr, wallet.ErrArgsRequired)
+	})
+
+	t.Run("signature over different data", func(t *testing.T) {
+		// given
+		args := wallet.EncryptionArgs{
+			ProtocolID: wallet.DefaultAuthProtocol,
+			KeyID:      "1",
+		}
+		signature, err := w.CreateSignature(&wallet.CreateSignatureArgs{EncryptionArgs: args, Data: []byte("data")}, "")
+		require.NoError(t, err)
+
+		// when
+		_, err = w.VerifySignature(&wallet.VerifySignatureArgs{
+			EncryptionArgs: args,
+			Signature:      signature.Signature,
+			Data:           []byte("tampered"),
+		})
+
+		// then
+		require.ErrorIs(t, err, wallet.ErrSignatureInvalid)
+	})
+}
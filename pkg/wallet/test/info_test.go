@@ -0,0 +1,64 @@
+package wallet_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockWallet_InformationalMethods(t *testing.T) {
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	t.Run("returns defaults", func(t *testing.T) {
+		// given
+		w := wallettest.NewMockWallet(key)
+
+		// when
+		height, heightErr := w.GetHeight(context.Background(), "")
+		network, networkErr := w.GetNetwork(context.Background(), "")
+		version, versionErr := w.GetVersion(context.Background(), "")
+
+		// then
+		require.NoError(t, heightErr)
+		require.NoError(t, networkErr)
+		require.NoError(t, versionErr)
+		require.Zero(t, height.Height)
+		require.Equal(t, wallet.NetworkMainnet, network.Network)
+		require.Equal(t, wallettest.MockWalletVersion, version.Version)
+	})
+
+	t.Run("returns configured height and network", func(t *testing.T) {
+		// given
+		w := wallettest.NewMockWallet(key).(*wallettest.Wallet)
+		w.SetHeight(880000)
+		w.SetNetwork(wallet.NetworkTestnet)
+
+		// when
+		height, heightErr := w.GetHeight(context.Background(), "")
+		network, networkErr := w.GetNetwork(context.Background(), "")
+
+		// then
+		require.NoError(t, heightErr)
+		require.NoError(t, networkErr)
+		require.Equal(t, uint32(880000), height.Height)
+		require.Equal(t, wallet.NetworkTestnet, network.Network)
+	})
+
+	t.Run("fails on cancelled context", func(t *testing.T) {
+		// given
+		w := wallettest.NewMockWallet(key)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		// when
+		_, err := w.GetHeight(ctx, "")
+
+		// then
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,253 @@
+package wallet_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWallet_RevealKeyLinkage(t *testing.T) {
+	proverKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	counterpartyKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	verifierKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	prover := wallettest.NewMockWallet(proverKey)
+	verifier := wallettest.NewMockWallet(verifierKey)
+
+	t.Run("counterparty linkage can be decrypted by verifier", func(t *testing.T) {
+		// given
+		args := &wallet.RevealCounterpartyKeyLinkageArgs{
+			Counterparty: counterpartyKey.PubKey(),
+			Verifier:     verifierKey.PubKey(),
+		}
+
+		// when
+		result, err := prover.RevealCounterpartyKeyLinkage(args, "")
+
+		// then
+		require.NoError(t, err)
+		require.True(t, result.Prover.IsEqual(proverKey.PubKey()))
+
+		decrypted, err := verifier.Decrypt(&wallet.DecryptArgs{
+			EncryptionArgs: wallet.EncryptionArgs{
+				ProtocolID:   wallet.CounterpartyLinkageRevelationProtocol,
+				KeyID:        result.RevelationTime,
+				Counterparty: wallet.Counterparty{Type: wallet.CounterpartyTypeOther, Counterparty: result.Prover},
+			},
+			Ciphertext: result.EncryptedLinkage,
+		}, "")
+		require.NoError(t, err)
+
+		expected, err := counterpartyKey.DeriveSharedSecret(proverKey.PubKey())
+		require.NoError(t, err)
+		require.Equal(t, expected.Compressed(), decrypted.Plaintext)
+	})
+
+	t.Run("specific linkage links prover identity to derived key", func(t *testing.T) {
+		// given
+		args := &wallet.RevealSpecificKeyLinkageArgs{
+			EncryptionArgs: wallet.EncryptionArgs{
+				ProtocolID:   wallet.DefaultAuthProtocol,
+				KeyID:        "key 1",
+				Counterparty: wallet.Counterparty{Type: wallet.CounterpartyTypeOther, Counterparty: counterpartyKey.PubKey()},
+			},
+			Verifier: verifierKey.PubKey(),
+		}
+
+		// when
+		result, err := prover.RevealSpecificKeyLinkage(args, "")
+
+		// then
+		require.NoError(t, err)
+
+		decrypted, err := verifier.Decrypt(&wallet.DecryptArgs{
+			EncryptionArgs: wallet.EncryptionArgs{
+				ProtocolID:   wallet.SpecificLinkageRevelationProtocol(result.ProtocolID),
+				KeyID:        result.KeyID,
+				Counterparty: wallet.Counterparty{Type: wallet.CounterpartyTypeOther, Counterparty: result.Prover},
+			},
+			Ciphertext: result.EncryptedLinkage,
+		}, "")
+		require.NoError(t, err)
+
+		derived, err := prover.GetPublicKey(&wallet.GetPublicKeyArgs{
+			EncryptionArgs: args.EncryptionArgs,
+			ForSelf:        true,
+		}, "")
+		require.NoError(t, err)
+
+		offset := new(big.Int).SetBytes(decrypted.Plaintext)
+		linked := new(big.Int).Add(proverKey.D, offset)
+		linked.Mod(linked, ec.S256().N)
+		linkedKey, _ := ec.PrivateKeyFromBytes(linked.Bytes())
+		require.True(t, derived.PublicKey.IsEqual(linkedKey.PubKey()))
+	})
+
+	t.Run("counterparty linkage cannot be revealed for self", func(t *testing.T) {
+		// given
+		args := &wallet.RevealCounterpartyKeyLinkageArgs{
+			Counterparty: proverKey.PubKey(),
+			Verifier:     verifierKey.PubKey(),
+		}
+
+		// when
+		result, err := prover.RevealCounterpartyKeyLinkage(args, "")
+
+		// then
+		require.Error(t, err)
+		require.Nil(t, result)
+	})
+
+	t.Run("missing verifier", func(t *testing.T) {
+		// when
+		result, err := prover.RevealCounterpartyKeyLinkage(&wallet.RevealCounterpartyKeyLinkageArgs{
+			Counterparty: counterpartyKey.PubKey(),
+		}, "")
+
+		// then
+		require.Error(t, err)
+		require.Nil(t, result)
+	})
+
+	t.Run("counterparty linkage proof is verified by verifier", func(t *testing.T) {
+		// given
+		result, err := prover.RevealCounterpartyKeyLinkage(&wallet.RevealCounterpartyKeyLinkageArgs{
+			Counterparty: counterpartyKey.PubKey(),
+			Verifier:     verifierKey.PubKey(),
+		}, "")
+		require.NoError(t, err)
+
+		// when
+		sharedSecret, err := wallet.VerifyCounterpartyKeyLinkage(verifier, result)
+
+		// then
+		require.NoError(t, err)
+		expected, err := counterpartyKey.DeriveSharedSecret(proverKey.PubKey())
+		require.NoError(t, err)
+		require.True(t, expected.IsEqual(sharedSecret))
+	})
+
+	t.Run("counterparty linkage proof does not verify for another counterparty", func(t *testing.T) {
+		// given
+		result, err := prover.RevealCounterpartyKeyLinkage(&wallet.RevealCounterpartyKeyLinkageArgs{
+			Counterparty: counterpartyKey.PubKey(),
+			Verifier:     verifierKey.PubKey(),
+		}, "")
+		require.NoError(t, err)
+		result.Counterparty = verifierKey.PubKey()
+
+		// when
+		_, err = wallet.VerifyCounterpartyKeyLinkage(verifier, result)
+
+		// then
+		require.ErrorIs(t, err, wallet.ErrInvalidLinkageProof)
+	})
+
+	t.Run("specific linkage proof is verified by verifier", func(t *testing.T) {
+		// given
+		result, err := prover.RevealSpecificKeyLinkage(&wallet.RevealSpecificKeyLinkageArgs{
+			EncryptionArgs: wallet.EncryptionArgs{
+				ProtocolID:   wallet.DefaultAuthProtocol,
+				KeyID:        "key 1",
+				Counterparty: wallet.Counterparty{Type: wallet.CounterpartyTypeOther, Counterparty: counterpartyKey.PubKey()},
+			},
+			Verifier: verifierKey.PubKey(),
+		}, "")
+		require.NoError(t, err)
+		require.Equal(t, wallet.SpecificLinkageProofSchnorr, result.ProofType)
+
+		// when
+		linkage, err := wallet.VerifySpecificKeyLinkage(verifier, result)
+
+		// then
+		require.NoError(t, err)
+		require.NotEmpty(t, linkage)
+	})
+
+	t.Run("specific linkage proof does not verify for another key ID", func(t *testing.T) {
+		// given
+		result, err := prover.RevealSpecificKeyLinkage(&wallet.RevealSpecificKeyLinkageArgs{
+			EncryptionArgs: wallet.EncryptionArgs{
+				ProtocolID:   wallet.DefaultAuthProtocol,
+				KeyID:        "key 1",
+				Counterparty: wallet.Counterparty{Type: wallet.CounterpartyTypeOther, Counterparty: counterpartyKey.PubKey()},
+			},
+			Verifier: verifierKey.PubKey(),
+		}, "")
+		require.NoError(t, err)
+		result.Prover = counterpartyKey.PubKey()
+
+		// when
+		_, err = wallet.VerifySpecificKeyLinkage(verifier, result)
+
+		// then
+		require.Error(t, err)
+	})
+}
+
+func TestLinkageProofs(t *testing.T) {
+	proverKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	counterpartyKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	sharedSecret, err := counterpartyKey.PubKey().DeriveSharedSecret(proverKey)
+	require.NoError(t, err)
+
+	t.Run("counterparty linkage proof round trips", func(t *testing.T) {
+		// given
+		proof, err := wallet.ProveCounterpartyLinkage(proverKey, counterpartyKey.PubKey(), sharedSecret)
+		require.NoError(t, err)
+
+		// when
+		parsed, err := wallet.ParseCounterpartyLinkageProof(proof.Bytes())
+
+		// then
+		require.NoError(t, err)
+		require.True(t, wallet.VerifyCounterpartyLinkage(proverKey.PubKey(), counterpartyKey.PubKey(), sharedSecret, parsed))
+	})
+
+	t.Run("counterparty linkage proof does not verify a forged secret", func(t *testing.T) {
+		// given
+		forged, err := ec.NewPrivateKey()
+		require.NoError(t, err)
+		proof, err := wallet.ProveCounterpartyLinkage(proverKey, counterpartyKey.PubKey(), forged.PubKey())
+		require.NoError(t, err)
+
+		// then
+		require.False(t, wallet.VerifyCounterpartyLinkage(proverKey.PubKey(), counterpartyKey.PubKey(), forged.PubKey(), proof))
+	})
+
+	t.Run("specific linkage proof is bound to its context", func(t *testing.T) {
+		// given
+		linkage := []byte{0x01, 0x02, 0x03}
+		derived := new(big.Int).Add(proverKey.D, new(big.Int).SetBytes(linkage))
+		derived.Mod(derived, ec.S256().N)
+		derivedKey, _ := ec.PrivateKeyFromBytes(derived.Bytes())
+		proof, err := wallet.ProveSpecificLinkage(derivedKey, []byte("context"))
+		require.NoError(t, err)
+
+		// when
+		parsed, err := wallet.ParseSpecificLinkageProof(proof.Bytes())
+
+		// then
+		require.NoError(t, err)
+		require.True(t, wallet.VerifySpecificLinkage(proverKey.PubKey(), linkage, []byte("context"), parsed))
+		require.False(t, wallet.VerifySpecificLinkage(proverKey.PubKey(), linkage, []byte("other context"), parsed))
+		require.False(t, wallet.VerifySpecificLinkage(proverKey.PubKey(), []byte{0x04}, []byte("context"), parsed))
+	})
+
+	t.Run("truncated proof is rejected", func(t *testing.T) {
+		// when
+		_, err := wallet.ParseCounterpartyLinkageProof(make([]byte, 10))
+
+		// then
+		require.ErrorIs(t, err, wallet.ErrInvalidLinkageProof)
+	})
+}
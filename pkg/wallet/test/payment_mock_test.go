@@ -5,7 +5,8 @@ import (
 	"errors"
 	"testing"
 
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
 	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -18,11 +19,11 @@ func TestMockPaymentWallet(t *testing.T) {
 	}
 
 	t.Run("Implements PaymentInterface", func(t *testing.T) {
-		var _ wallet.PaymentInterface = &wallet.MockPaymentWallet{}
+		var _ wallet.PaymentInterface = &wallettest.MockPaymentWallet{}
 	})
 
 	t.Run("Records InternalizeAction arguments", func(t *testing.T) {
-		mock := wallet.NewMockPaymentWallet(key)
+		mock := wallettest.NewMockPaymentWallet(key)
 		args := wallet.InternalizeActionArgs{
 			Tx: []byte{1, 2, 3},
 			Outputs: []wallet.InternalizeOutput{
@@ -48,7 +49,7 @@ func TestMockPaymentWallet(t *testing.T) {
 	})
 
 	t.Run("Returns configured error", func(t *testing.T) {
-		mock := wallet.NewMockPaymentWallet(key)
+		mock := wallettest.NewMockPaymentWallet(key)
 		expectedErr := errors.New("payment error")
 		mock.SetInternalizeActionError(expectedErr)
 
@@ -59,7 +60,7 @@ func TestMockPaymentWallet(t *testing.T) {
 	})
 
 	t.Run("Returns configured result", func(t *testing.T) {
-		mock := wallet.NewMockPaymentWallet(key)
+		mock := wallettest.NewMockPaymentWallet(key)
 		expectedResult := wallet.InternalizeActionResult{Accepted: false}
 		mock.SetInternalizeActionResult(expectedResult)
 
@@ -0,0 +1,71 @@
+package wallet_test
+
+import (
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockWallet_Privileged(t *testing.T) {
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	privilegedKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	privileged := wallet.EncryptionArgs{Privileged: true, PrivilegedReason: "test"}
+
+	t.Run("privileged identity key is the privileged key", func(t *testing.T) {
+		// given
+		w := wallettest.NewPrivilegedMockWallet(key, privilegedKey)
+
+		// when
+		everyday, err := w.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+		require.NoError(t, err)
+		identity, err := w.GetPublicKey(&wallet.GetPublicKeyArgs{EncryptionArgs: privileged, IdentityKey: true}, "")
+		require.NoError(t, err)
+
+		// then
+		require.Equal(t, key.PubKey().ToDERHex(), everyday.PublicKey.ToDERHex())
+		require.Equal(t, privilegedKey.PubKey().ToDERHex(), identity.PublicKey.ToDERHex())
+	})
+
+	t.Run("privileged signature is verified only with the privileged keyring", func(t *testing.T) {
+		// given
+		w := wallettest.NewPrivilegedMockWallet(key, privilegedKey)
+		args := privileged
+		args.ProtocolID = wallet.DefaultAuthProtocol
+		args.KeyID = "1"
+		args.Counterparty = wallet.Counterparty{Type: wallet.CounterpartyTypeSelf}
+		signature, err := w.CreateSignature(&wallet.CreateSignatureArgs{EncryptionArgs: args, Data: []byte("data")}, "")
+		require.NoError(t, err)
+
+		// when
+		result, err := w.VerifySignature(&wallet.VerifySignatureArgs{
+			EncryptionArgs: args, Signature: signature.Signature, Data: []byte("data"), ForSelf: true,
+		})
+		require.NoError(t, err)
+		everyday := args
+		everyday.Privileged = false
+		_, everydayErr := w.VerifySignature(&wallet.VerifySignatureArgs{
+			EncryptionArgs: everyday, Signature: signature.Signature, Data: []byte("data"), ForSelf: true,
+		})
+
+		// then
+		require.True(t, result.Valid)
+		require.ErrorIs(t, everydayErr, wallet.ErrSignatureInvalid)
+	})
+
+	t.Run("privileged operation of a wallet without a privileged keyring", func(t *testing.T) {
+		// given
+		w := wallettest.NewMockWallet(key)
+
+		// when
+		_, err := w.GetPublicKey(&wallet.GetPublicKeyArgs{EncryptionArgs: privileged, IdentityKey: true}, "")
+
+		// then
+		require.ErrorIs(t, err, wallet.ErrPrivilegedKeyUnavailable)
+	})
+}
@@ -0,0 +1,83 @@
+package wallet_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/rand/v2"
+	"testing"
+	"testing/iotest"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRandomMockWallet(t *testing.T) {
+	t.Run("same random source produces the same key and nonces", func(t *testing.T) {
+		// given
+		seed := [32]byte{1}
+		first, second := rand.NewChaCha8(seed), rand.NewChaCha8(seed)
+
+		// when
+		firstKey, err := wallettest.NewRandomPrivateKey(first)
+		require.NoError(t, err)
+		secondKey, err := wallettest.NewRandomPrivateKey(second)
+		require.NoError(t, err)
+
+		firstWallet := wallettest.NewRandomMockWallet(firstKey, first)
+		secondWallet := wallettest.NewRandomMockWallet(secondKey, second)
+		var firstNonces, secondNonces []string
+		for range 5 {
+			firstNonces = append(firstNonces, createNonce(t, firstWallet))
+			secondNonces = append(secondNonces, createNonce(t, secondWallet))
+		}
+
+		// then
+		require.Equal(t, firstKey.Serialize(), secondKey.Serialize())
+		require.Equal(t, firstNonces, secondNonces)
+		require.Len(t, uniq(firstNonces), len(firstNonces))
+	})
+
+	t.Run("created nonces are verified", func(t *testing.T) {
+		// given
+		key, err := wallettest.NewRandomPrivateKey(nil)
+		require.NoError(t, err)
+		w := wallettest.NewRandomMockWallet(key, nil)
+		nonce := createNonce(t, w)
+
+		// when
+		valid, err := w.VerifyNonce(context.Background(), nonce)
+
+		// then
+		require.NoError(t, err)
+		require.True(t, valid)
+	})
+
+	t.Run("bytes outside the curve order are skipped", func(t *testing.T) {
+		// given
+		random := bytes.NewReader(append(bytes.Repeat([]byte{0xff}, 32), append(make([]byte, 31), 1)...))
+
+		// when
+		key, err := wallettest.NewRandomPrivateKey(random)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, append(make([]byte, 31), 1), key.Serialize())
+	})
+
+	t.Run("failing random source fails", func(t *testing.T) {
+		// given
+		failure := errors.New("entropy exhausted")
+		key, err := wallettest.NewRandomPrivateKey(rand.NewChaCha8([32]byte{}))
+		require.NoError(t, err)
+		w := wallettest.NewRandomMockWallet(key, iotest.ErrReader(failure))
+
+		// when
+		_, keyErr := wallettest.NewRandomPrivateKey(iotest.ErrReader(failure))
+		_, nonceErr := w.CreateNonce(context.Background())
+
+		// then
+		require.ErrorIs(t, keyErr, failure)
+		require.ErrorIs(t, nonceErr, failure)
+	})
+}
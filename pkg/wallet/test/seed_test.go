@@ -0,0 +1,109 @@
+package wallet_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeededMockWallet(t *testing.T) {
+	t.Run("matches the generated fixtures", func(t *testing.T) {
+		// given
+		w := wallettest.NewSeededMockWallet(wallettest.Seed, "client")
+
+		// when
+		identity, err := w.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+		require.NoError(t, err)
+
+		// then
+		require.Equal(t, wallettest.ClientIdentityKey, identity.PublicKey.ToDERHex())
+		for _, expected := range wallettest.ClientNonces {
+			nonce, err := w.CreateNonce(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, expected, nonce)
+		}
+	})
+
+	t.Run("same seed and name produce the same sequence", func(t *testing.T) {
+		// given
+		first := wallettest.NewSeededMockWallet("seed", "alice")
+		second := wallettest.NewSeededMockWallet("seed", "alice")
+		other := wallettest.NewSeededMockWallet("seed", "bob")
+
+		// when
+		var firstNonces, secondNonces, otherNonces []string
+		for range len(wallettest.ClientNonces) + 5 {
+			firstNonces = append(firstNonces, createNonce(t, first))
+			secondNonces = append(secondNonces, createNonce(t, second))
+			otherNonces = append(otherNonces, createNonce(t, other))
+		}
+
+		// then
+		require.Equal(t, firstNonces, secondNonces)
+		require.NotEqual(t, firstNonces, otherNonces)
+		require.Len(t, uniq(firstNonces), len(firstNonces))
+	})
+
+	t.Run("created nonces are verified", func(t *testing.T) {
+		// given
+		w := wallettest.NewSeededMockWallet("seed", "alice")
+		nonce := createNonce(t, w)
+
+		// when
+		valid, err := w.VerifyNonce(context.Background(), nonce)
+
+		// then
+		require.NoError(t, err)
+		require.True(t, valid)
+	})
+
+	t.Run("signatures are verifiable by the counterparty", func(t *testing.T) {
+		// given
+		alice := wallettest.NewSeededMockWallet("seed", "alice")
+		bob := wallettest.NewSeededMockWallet("seed", "bob")
+		data := []byte("payload")
+
+		signature, err := alice.CreateSignature(&wallet.CreateSignatureArgs{
+			EncryptionArgs: wallet.EncryptionArgs{
+				ProtocolID:   wallet.DefaultAuthProtocol,
+				KeyID:        "key",
+				Counterparty: wallet.Counterparty{Type: wallet.CounterpartyTypeOther, Counterparty: wallettest.SeededPrivateKey("seed", "bob").PubKey()},
+			},
+			Data: data,
+		}, "")
+		require.NoError(t, err)
+
+		// when
+		result, err := bob.VerifySignature(&wallet.VerifySignatureArgs{
+			EncryptionArgs: wallet.EncryptionArgs{
+				ProtocolID:   wallet.DefaultAuthProtocol,
+				KeyID:        "key",
+				Counterparty: wallet.Counterparty{Type: wallet.CounterpartyTypeOther, Counterparty: wallettest.SeededPrivateKey("seed", "alice").PubKey()},
+			},
+			Data:      data,
+			Signature: signature.Signature,
+		})
+
+		// then
+		require.NoError(t, err)
+		require.True(t, result.Valid)
+	})
+}
+
+func createNonce(t *testing.T, w wallet.WalletInterface) string {
+	t.Helper()
+	nonce, err := w.CreateNonce(context.Background())
+	require.NoError(t, err)
+	return nonce
+}
+
+func uniq(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		set[v] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,93 @@
+package wallet_test
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockWallet_DirectHash(t *testing.T) {
+	key, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	w := wallettest.NewMockWallet(key)
+
+	args := wallet.EncryptionArgs{
+		ProtocolID:   wallet.DefaultAuthProtocol,
+		KeyID:        "1",
+		Counterparty: wallet.Counterparty{Type: wallet.CounterpartyTypeSelf},
+	}
+	data := []byte("payload hashed once by the caller")
+	hash := sha256.Sum256(data)
+
+	t.Run("signature over the hash is the signature over the data", func(t *testing.T) {
+		// when
+		overData, err := w.CreateSignature(&wallet.CreateSignatureArgs{EncryptionArgs: args, Data: data}, "")
+		require.NoError(t, err)
+		overHash, err := w.CreateSignature(&wallet.CreateSignatureArgs{EncryptionArgs: args, HashToDirectlySign: hash[:]}, "")
+		require.NoError(t, err)
+
+		// then
+		require.Equal(t, overData.Signature.Serialize(), overHash.Signature.Serialize())
+	})
+
+	t.Run("signature over the data is verified over the hash", func(t *testing.T) {
+		// given
+		signature, err := w.CreateSignature(&wallet.CreateSignatureArgs{EncryptionArgs: args, Data: data}, "")
+		require.NoError(t, err)
+
+		// when
+		result, err := w.VerifySignature(&wallet.VerifySignatureArgs{
+			EncryptionArgs:       args,
+			Signature:            signature.Signature,
+			HashToDirectlyVerify: hash[:],
+		})
+
+		// then
+		require.NoError(t, err)
+		require.True(t, result.Valid)
+	})
+
+	t.Run("hashed args replace the data with its hash", func(t *testing.T) {
+		// given
+		createArgs := &wallet.CreateSignatureArgs{EncryptionArgs: args, Data: data}
+		verifyArgs := &wallet.VerifySignatureArgs{EncryptionArgs: args, Data: data}
+
+		// when
+		hashedCreate := createArgs.Hashed()
+		hashedVerify := verifyArgs.Hashed()
+
+		// then
+		require.Nil(t, hashedCreate.Data)
+		require.Equal(t, hash[:], hashedCreate.HashToDirectlySign)
+		require.Nil(t, hashedVerify.Data)
+		require.Equal(t, hash[:], hashedVerify.HashToDirectlyVerify)
+		require.Equal(t, data, createArgs.Data, "the args are copied")
+	})
+
+	t.Run("args which do not set exactly one of data and hash are rejected", func(t *testing.T) {
+		tests := map[string]struct {
+			data []byte
+			hash []byte
+		}{
+			"neither":                  {},
+			"both":                     {data: data, hash: hash[:]},
+			"hash is not SHA-256 size": {hash: hash[:20]},
+		}
+
+		for name, test := range tests {
+			t.Run(name, func(t *testing.T) {
+				// when
+				_, signErr := w.CreateSignature(&wallet.CreateSignatureArgs{EncryptionArgs: args, Data: test.data, HashToDirectlySign: test.hash}, "")
+				_, verifyErr := w.VerifySignature(&wallet.VerifySignatureArgs{EncryptionArgs: args, Data: test.data, HashToDirectlyVerify: test.hash})
+
+				// then
+				require.ErrorIs(t, signErr, wallet.ErrSignatureArgsInvalid)
+				require.ErrorIs(t, verifyErr, wallet.ErrSignatureArgsInvalid)
+			})
+		}
+	})
+}
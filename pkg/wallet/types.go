@@ -0,0 +1,276 @@
+package wallet
+
+import (
+	"fmt"
+	"regexp"
+
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// GetPublicKeyOptions defines parameters for GetPublicKey
+type GetPublicKeyOptions struct {
+	// IdentityKey is a flag to return the identity key
+	IdentityKey bool `json:"identityKey"`
+	// ProtocolID is the protocol ID for the key
+	ProtocolID any `json:"protocolID,omitempty"`
+	// KeyID is the key ID for the key
+	KeyID string `json:"keyID,omitempty"`
+	// Counterparty is the counterparty for the key
+	Counterparty string `json:"counterparty,omitempty"`
+	// Privileged is a flag to return a privileged key
+	Privileged bool `json:"privileged,omitempty"`
+	// ForSelf is a flag to return a key for self
+	ForSelf bool `json:"forSelf,omitempty"`
+}
+
+// PaymentRemittance contains payment metadata
+type PaymentRemittance struct {
+	DerivationPrefix  string `json:"derivationPrefix"`
+	DerivationSuffix  string `json:"derivationSuffix"`
+	SenderIdentityKey string `json:"senderIdentityKey"`
+}
+
+// InternalizeOutput describes an output to process
+type InternalizeOutput struct {
+	OutputIndex       int                `json:"outputIndex"`
+	Protocol          string             `json:"protocol"`
+	PaymentRemittance *PaymentRemittance `json:"paymentRemittance,omitempty"`
+}
+
+// InternalizeActionArgs contains parameters for internalizing a transaction
+type InternalizeActionArgs struct {
+	Tx          []byte              `json:"tx"`
+	Outputs     []InternalizeOutput `json:"outputs"`
+	Description string              `json:"description"`
+	Labels      []string            `json:"labels,omitempty"`
+}
+
+// InternalizeActionResult represents the result
+type InternalizeActionResult struct {
+	Accepted bool `json:"accepted"`
+}
+
+// CreateActionOutput describes an output of a transaction created by the wallet
+type CreateActionOutput struct {
+	LockingScript      string `json:"lockingScript"`
+	Satoshis           uint64 `json:"satoshis"`
+	OutputDescription  string `json:"outputDescription"`
+	CustomInstructions string `json:"customInstructions,omitempty"`
+}
+
+// CreateActionArgs contains parameters for creating a transaction funded by the wallet
+type CreateActionArgs struct {
+	Description string               `json:"description"`
+	Outputs     []CreateActionOutput `json:"outputs"`
+	Labels      []string             `json:"labels,omitempty"`
+}
+
+// CreateActionResult represents the result
+type CreateActionResult struct {
+	TxID string `json:"txid"`
+	Tx   []byte `json:"tx,omitempty"`
+}
+
+// EncryptionArgs base struct with common arguments for encryption operations
+type EncryptionArgs struct {
+	ProtocolID       Protocol
+	KeyID            string
+	Counterparty     Counterparty
+	Privileged       bool
+	PrivilegedReason string
+	SeekPermission   bool
+}
+
+// GetPublicKeyArgs defines parameters for GetPublicKey
+type GetPublicKeyArgs struct {
+	EncryptionArgs
+	IdentityKey bool
+	ForSelf     bool
+}
+
+// GetPublicKeyResult defines the result of GetPublicKey
+type GetPublicKeyResult struct {
+	PublicKey *ec.PublicKey `json:"publicKey"`
+}
+
+// CreateSignatureArgs defines parameters for CreateSignature, exactly one of Data and HashToDirectlySign is set
+// (see Hash). Signing Data and signing its SHA-256 hash as HashToDirectlySign produce the same signature.
+type CreateSignatureArgs struct {
+	EncryptionArgs
+	// Data is hashed with SHA-256 by the wallet
+	Data []byte
+	// HashToDirectlySign is a SHA-256 hash signed as is, so large payloads are hashed once by the caller
+	// instead of being passed to the wallet
+	HashToDirectlySign []byte
+}
+
+// CreateSignatureResult defines the result of CreateSignature
+type CreateSignatureResult struct {
+	Signature ec.Signature
+}
+
+// VerifySignatureArgs defines parameters for VerifySignature, exactly one of Data and HashToDirectlyVerify is set
+// (see Hash), like the Data and HashToDirectlySign of the CreateSignatureArgs of the signature
+type VerifySignatureArgs struct {
+	EncryptionArgs
+	// Data is hashed with SHA-256 by the wallet
+	Data []byte
+	// HashToDirectlyVerify is a SHA-256 hash verified as is
+	HashToDirectlyVerify []byte
+	Signature            ec.Signature
+	ForSelf              bool
+}
+
+// VerifySignatureResult defines the result of VerifySignature
+type VerifySignatureResult struct {
+	Valid bool
+}
+
+// EncryptArgs defines parameters for Encrypt
+type EncryptArgs struct {
+	EncryptionArgs
+	Plaintext []byte
+}
+
+// EncryptResult defines the result of Encrypt
+type EncryptResult struct {
+	Ciphertext []byte
+}
+
+// DecryptArgs defines parameters for Decrypt
+type DecryptArgs struct {
+	EncryptionArgs
+	Ciphertext []byte
+}
+
+// DecryptResult defines the result of Decrypt
+type DecryptResult struct {
+	Plaintext []byte
+}
+
+// RevealCounterpartyKeyLinkageArgs defines parameters for RevealCounterpartyKeyLinkage
+type RevealCounterpartyKeyLinkageArgs struct {
+	Counterparty     *ec.PublicKey
+	Verifier         *ec.PublicKey
+	Privileged       bool
+	PrivilegedReason string
+}
+
+// RevealCounterpartyKeyLinkageResult defines the result of RevealCounterpartyKeyLinkage
+type RevealCounterpartyKeyLinkageResult struct {
+	Prover           *ec.PublicKey
+	Verifier         *ec.PublicKey
+	Counterparty     *ec.PublicKey
+	RevelationTime   string
+	EncryptedLinkage []byte
+	// EncryptedLinkageProof is the CounterpartyLinkageProof of the linkage, encrypted for the verifier
+	EncryptedLinkageProof []byte
+}
+
+// RevealSpecificKeyLinkageArgs defines parameters for RevealSpecificKeyLinkage
+type RevealSpecificKeyLinkageArgs struct {
+	EncryptionArgs
+	Verifier *ec.PublicKey
+}
+
+// RevealSpecificKeyLinkageResult defines the result of RevealSpecificKeyLinkage
+type RevealSpecificKeyLinkageResult struct {
+	Prover           *ec.PublicKey
+	Verifier         *ec.PublicKey
+	Counterparty     *ec.PublicKey
+	ProtocolID       Protocol
+	KeyID            string
+	EncryptedLinkage []byte
+	// EncryptedLinkageProof is the proof of the linkage of ProofType, encrypted for the verifier
+	EncryptedLinkageProof []byte
+	ProofType             byte
+}
+
+// Network is the BSV network a wallet operates on
+type Network string
+
+// Networks reported by GetNetwork
+const (
+	NetworkMainnet Network = "mainnet"
+	NetworkTestnet Network = "testnet"
+	NetworkRegtest Network = "regtest"
+)
+
+// GetHeightResult defines the result of GetHeight
+type GetHeightResult struct {
+	Height uint32 `json:"height"`
+}
+
+// GetNetworkResult defines the result of GetNetwork
+type GetNetworkResult struct {
+	Network Network `json:"network"`
+}
+
+// GetVersionResult defines the result of GetVersion, the version has the form "vendor-MAJOR.MINOR.PATCH"
+type GetVersionResult struct {
+	Version string `json:"version"`
+}
+
+// SecurityLevel defines the access control level for wallet operations.
+// It determines how strictly the wallet enforces user confirmation for operations.
+type SecurityLevel int
+
+// Security levels
+var (
+	SecurityLevelSilent                  SecurityLevel = 0
+	SecurityLevelEveryApp                SecurityLevel = 1
+	SecurityLevelEveryAppAndCounterparty SecurityLevel = 2
+)
+
+// Protocol defines a protocol with its security level and name.
+// The security level determines how strictly the wallet enforces user confirmation.
+type Protocol struct {
+	SecurityLevel SecurityLevel
+	Protocol      string
+}
+
+// Protocols with their respective security levels
+var (
+	// DefaultAuthProtocol is the default protocol for authentication messages.
+	DefaultAuthProtocol = Protocol{SecurityLevel: SecurityLevelEveryAppAndCounterparty, Protocol: "auth message signature"}
+	// DefaultEncryptionProtocol is the default protocol for encryption of general message payloads.
+	DefaultEncryptionProtocol = Protocol{SecurityLevel: SecurityLevelEveryAppAndCounterparty, Protocol: "auth message encryption"}
+	// CounterpartyLinkageRevelationProtocol is the protocol used to encrypt revealed counterparty linkage for the verifier.
+	CounterpartyLinkageRevelationProtocol = Protocol{SecurityLevel: SecurityLevelEveryAppAndCounterparty, Protocol: "counterparty linkage revelation"}
+	// PaymentProtocol is the protocol of keys receiving BRC-29 payments, derived with the "<prefix> <suffix>" key ID.
+	PaymentProtocol = Protocol{SecurityLevel: SecurityLevelEveryAppAndCounterparty, Protocol: "3241645161d8"}
+	// CertificateSignatureProtocol is the protocol of certifier signatures over certificates, keyed by the certificate type and serial number.
+	CertificateSignatureProtocol = Protocol{SecurityLevel: SecurityLevelEveryAppAndCounterparty, Protocol: "certificate signature"}
+	// PaymentTermsProtocol is the protocol of signatures over payment terms, keyed by the derivation prefix of the terms.
+	PaymentTermsProtocol = Protocol{SecurityLevel: SecurityLevelEveryAppAndCounterparty, Protocol: "payment terms signature"}
+)
+
+// SpecificLinkageRevelationProtocol returns the protocol used to encrypt revealed linkage of a key derived with the given protocol.
+func SpecificLinkageRevelationProtocol(protocol Protocol) Protocol {
+	return Protocol{
+		SecurityLevel: SecurityLevelEveryAppAndCounterparty,
+		Protocol:      fmt.Sprintf("specific linkage revelation %d %s", protocol.SecurityLevel, protocol.Protocol),
+	}
+}
+
+// CounterpartyType defines the type of counterparty for operation.
+type CounterpartyType int
+
+const (
+	// CounterpartyUninitialized is used when the counterparty type is not set.
+	CounterpartyUninitialized CounterpartyType = 0
+	// CounterpartyTypeAnyone is used when the counterparty is anyone.
+	CounterpartyTypeAnyone CounterpartyType = 1
+	// CounterpartyTypeSelf is used when the counterparty is self.
+	CounterpartyTypeSelf CounterpartyType = 2
+	// CounterpartyTypeOther is used when the counterparty is other.
+	CounterpartyTypeOther CounterpartyType = 3
+)
+
+// Counterparty represents a counterparty in a protocol.
+type Counterparty struct {
+	Type         CounterpartyType
+	Counterparty *ec.PublicKey
+}
+
+var regexOnlyLettersNumbersSpaces = regexp.MustCompile(`^[a-z0-9 ]+$`)
@@ -0,0 +1,70 @@
+// Code generated by gen-fixtures; DO NOT EDIT.
+
+package wallettest
+
+// Constants for expected return values
+const (
+	// Seed is the seed the fixtures are derived from, seeded mock wallets of the "server" and "client" identities
+	// return the same keys and nonces as the fixtures
+	Seed = "go-bsv-middleware"
+
+	// MockNonce is the expected nonce
+	MockNonce = "bHNfKPF90vx51T1qZE7cImU5iP0LL1XEZ6bV3Oh9mFs="
+)
+
+// Nonces and identities for testing, derived from Seed
+var (
+	DefaultNonces = []string{
+		"wIDf9PxkwhHpHxbjlWgwQU5DsJyaSo+QfJ8234Bu+ig=",
+		"abY+BKl9AcAnqrLYHNzY6meg6VtWuFlaRfLll6jyrtw=",
+		"50zQHQO+tkdFJn7x869rEMu+DsJ29lb1LqOx44jyU0s=",
+		"Qldyv47ZZaHjW572DH0kLBp0Keplb/TLUE6zBzzfg0s=",
+		"ROdU/Znhws2eh18a3KEiNfWpbwAdFX/WP394js0OK2c=",
+		"i+hCepn3NTal6oSUN8toCWcYhue3ZwK5YhDz1JJe0/A=",
+		"ZqbuNUiXkVVnLrcfMqhkBcRf5wK5nUhYJZuFqN6hwt8=",
+		"72CQM3UgunqFSXoNbJ0IGsHhV08kjWuZa6Xr1zMaWI4=",
+		"iRkopa3SLtFNJzTPYglW6IHzyQc5hAWTe33DiB95Ubs=",
+		"OIuMjP07S4YT+zH+Hymo3+i+rNLdKDUzFDqJDhxLSl4=",
+		"EHyS98rNZg/F3MpRAlb5alc6qmVRDeewz9R1PJX86qE=",
+		"ONvnZSdQhX4DidX9bH9ogxkTyljMNWNLrwvLtZ5GJ1E=",
+		"CEqm+9K5ktC9kbmdABxTQOss8PhwNldm9DoL1dBNTSU=",
+		"JrfRVueICP+gH6IN2bYtvUKaT6ggH+riWV+i4pq5cag=",
+		"lGD+AagjnZkgoxDQSDb/yz+0a0YmZqO532qP81xmZrY=",
+		"tmhdx5fYlHeHDgixTad9joYy9BUbnJPZ1jwrGQ21vVk=",
+		"+JW37bHaTsV07e5MApl430EqRNvpTVK4iZVezQeiCpU=",
+		"eJXKpPOaKcH3vo+qpt0rlPOJqy8XoxU1vw4GquZuX0k=",
+		"ZJgbJHa/HwlYA70eGLXxR7bsnNr94qOHy8B/MQoMoao=",
+		"gISipBURuY628jlZNsGmmPktFTrUr5Pzq9qw9qW8tXw=",
+	}
+
+	ClientNonces = []string{
+		"LVhocBkicTtwV/D0hWluTo8sO2QOLm4+/WlrCQyMdLk=",
+		"asciJdbfQg28lmsKHDuweM4GF3geJcrTnJRfZq1vJNI=",
+		"OoTlrAXvrPVNStLRIuEHEJg347KOqtlsIEY20cXJ190=",
+		"yOvbw9bJU5CVKFcyVQc5GfFt31BICkxIflUF9z9+0jU=",
+		"86j9CHPUl7C8cC/lvqga4aveKeRal41U8z8X4zIFWCw=",
+		"AAp6HyiIFeV74dw8/5a//3CfAYchcCmETunI3eqUxjE=",
+		"NC0BR6E/bLl9iIGt3Fhpn/CvsjA4vzWmw6qYwEP3r/M=",
+		"M+NAaXtlRXawGrla5FcTLPd1xmNn6jrnX2xFV3Gp15A=",
+		"zoSfU/6ufLK9e9xhR5KdM7F4t/HxoA8SGvFyq/kZZG8=",
+		"IiIe+0qfTUuwo46tQRYUZh6X9EtXcLKpnX38iR8F190=",
+		"Jve9+0WbF0mFjyKPnZSpoTtQ7x4SvPrV+84Km6KMr9M=",
+		"hPSBUw+kxzXpBoGJ1x3KOHmjBhXY/As0WImkaev8wqA=",
+		"FlIc5yFRuStBgsl63bulu1xy0/wEA/VtnGF5s/treDQ=",
+		"CQ4457Ktlm0ni4YSP4jh+1jiBgpd12rGqTGT47fGBSU=",
+		"YrBuW9gnTJwNKKr96eNd8/Jro5nzx84onmcomn57YSM=",
+		"kKqkmSjowqUmdM/bAoClKGgqXYseHN6sIt5t6l0ZOwo=",
+		"6Q+Saz76NQ1WvUVGydrE/PwinjbzeCBA4+wlq4K2z24=",
+		"Or800OYDf3fExcsNspDjnvA7dkY55lrkpmkx0WvrLIE=",
+		"PRg2uCpSkzAZ5zMwVYJJdWB0U8UR30lZKzUlpPDuVbk=",
+		"cMfjNePGYdR26IWqngI2y8WYd8eZ3IDbTCng/WO37wc=",
+	}
+
+	ServerIdentityKey    = "028916ef5c5688006b467df6f2c8453bb488bf9852dd54ceb449bf04948dccb8db"
+	ClientIdentityKey    = "03c29fb81abd5375275bc97ef89139aa909aa605c4917314e02aaa14f60282ac9c"
+	CertifierIdentityKey = "03b8da7e1e5c6c5310a25d0fad51e68423603eaeee43ee04b3605bf6a261c26515"
+
+	ServerPrivateKeyHex    = "3aab0a34b35f898c5880317038d5bc114a3a58c80192a9cb556fd99efe6542ff"
+	ClientPrivateKeyHex    = "24f9f6134f1a40331f5db438d609e71e7d8c9fa62c52b82e0c2fc88461f2c972"
+	CertifierPrivateKeyHex = "3115d15fc2b161a76f163f9c27c8fcce847d0d96076cdc52ac29437722547ee2"
+)
@@ -0,0 +1,3 @@
+package wallettest
+
+//go:generate go run ../../../cmd/gen-fixtures -go fixtures.go -json ../../../../test/fixtures/golden.json
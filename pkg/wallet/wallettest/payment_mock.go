@@ -0,0 +1,79 @@
+package wallettest
+
+import (
+	"context"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// MockCreateActionTxID is the transaction ID returned by CreateAction of the mock payment wallet
+const MockCreateActionTxID = "0000000000000000000000000000000000000000000000000000000000000001"
+
+// MockPaymentWallet implements wallet.PaymentInterface for testing
+type MockPaymentWallet struct {
+	*Wallet
+
+	InternalizeActionCalled bool
+	InternalizeActionArgs   wallet.InternalizeActionArgs
+	InternalizeActionResult wallet.InternalizeActionResult
+	InternalizeActionError  error
+
+	CreateActionCalled bool
+	CreateActionArgs   wallet.CreateActionArgs
+	CreateActionResult wallet.CreateActionResult
+	CreateActionError  error
+}
+
+// NewMockPaymentWallet creates a new payment-capable mock wallet with given nonces if provided,
+// the nonces are used as derivation prefixes of the payment terms
+func NewMockPaymentWallet(key *ec.PrivateKey, nonces ...string) *MockPaymentWallet {
+	return &MockPaymentWallet{
+		Wallet: NewMockWallet(key, nonces...).(*Wallet),
+		InternalizeActionResult: wallet.InternalizeActionResult{
+			Accepted: true,
+		},
+		CreateActionResult: wallet.CreateActionResult{
+			TxID: MockCreateActionTxID,
+		},
+	}
+}
+
+// InternalizeAction implements wallet.PaymentInterface
+func (m *MockPaymentWallet) InternalizeAction(ctx context.Context, args wallet.InternalizeActionArgs) (wallet.InternalizeActionResult, error) {
+	m.InternalizeActionCalled = true
+	m.InternalizeActionArgs = args
+
+	if m.InternalizeActionError != nil {
+		return wallet.InternalizeActionResult{}, m.InternalizeActionError
+	}
+
+	return m.InternalizeActionResult, nil
+}
+
+// SetInternalizeActionError configures error response
+func (m *MockPaymentWallet) SetInternalizeActionError(err error) {
+	m.InternalizeActionError = err
+}
+
+// SetInternalizeActionResult configures result
+func (m *MockPaymentWallet) SetInternalizeActionResult(result wallet.InternalizeActionResult) {
+	m.InternalizeActionResult = result
+}
+
+// CreateAction implements wallet.PaymentInterface
+func (m *MockPaymentWallet) CreateAction(ctx context.Context, args wallet.CreateActionArgs) (wallet.CreateActionResult, error) {
+	m.CreateActionCalled = true
+	m.CreateActionArgs = args
+
+	if m.CreateActionError != nil {
+		return wallet.CreateActionResult{}, m.CreateActionError
+	}
+
+	return m.CreateActionResult, nil
+}
+
+// SetCreateActionError configures error response
+func (m *MockPaymentWallet) SetCreateActionError(err error) {
+	m.CreateActionError = err
+}
@@ -0,0 +1,59 @@
+package wallettest
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// randomNonceSize is the number of bytes of the nonces created by the mock wallets of NewRandomMockWallet
+const randomNonceSize = 32
+
+// NewRandomPrivateKey creates a private key with the entropy of the random source, e.g. a hardware RNG,
+// or a seeded source for deterministic tests. A nil source uses crypto/rand.
+// The same bytes of the source always yield the same key.
+func NewRandomPrivateKey(random io.Reader) (*ec.PrivateKey, error) {
+	if random == nil {
+		random = rand.Reader
+	}
+
+	b := make([]byte, 32)
+	for {
+		if _, err := io.ReadFull(random, b); err != nil {
+			return nil, fmt.Errorf("failed to read random bytes of private key, %w", err)
+		}
+
+		// bytes outside the range of the curve order are discarded instead of reduced, so keys are uniform
+		d := new(big.Int).SetBytes(b)
+		if d.Sign() > 0 && d.Cmp(ec.S256().N) < 0 {
+			key, _ := ec.PrivateKeyFromBytes(b)
+			return key, nil
+		}
+	}
+}
+
+// NewRandomMockWallet creates a mock wallet like NewMockWallet whose nonces are read from the random source
+// instead of fixtures. A nil source uses crypto/rand.
+func NewRandomMockWallet(privateKey *ec.PrivateKey, random io.Reader) wallet.WalletInterface {
+	if random == nil {
+		random = rand.Reader
+	}
+
+	w := NewMockWallet(privateKey).(*Wallet)
+	w.random = random
+	return w
+}
+
+// randomNonce reads a nonce from the random source of the wallet
+func (m *Wallet) randomNonce() (string, error) {
+	b := make([]byte, randomNonceSize)
+	if _, err := io.ReadFull(m.random, b); err != nil {
+		return "", fmt.Errorf("failed to read random bytes of nonce, %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
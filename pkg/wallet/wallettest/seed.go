@@ -0,0 +1,49 @@
+package wallettest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// SeededPrivateKey returns the private key of the named identity derived from the seed
+func SeededPrivateKey(seed, name string) *ec.PrivateKey {
+	key, _ := ec.PrivateKeyFromBytes(deriveFromSeed(seed, "identity "+name))
+	return key
+}
+
+// SeededNonce returns the i-th nonce of the named identity derived from the seed
+func SeededNonce(seed, name string, i int) string {
+	return base64.StdEncoding.EncodeToString(deriveFromSeed(seed, fmt.Sprintf("nonce %s %d", name, i)))
+}
+
+// SeededNonces returns the first count nonces of the named identity derived from the seed
+func SeededNonces(seed, name string, count int) []string {
+	nonces := make([]string, 0, count)
+	for i := range count {
+		nonces = append(nonces, SeededNonce(seed, name, i))
+	}
+	return nonces
+}
+
+// NewSeededMockWallet creates a mock wallet of the named identity whose key and nonces are derived from the seed,
+// so tests get reproducible values which are still valid keys, nonces and signatures.
+// A wallet with the same seed and name always returns the same sequence of nonces.
+func NewSeededMockWallet(seed, name string) wallet.WalletInterface {
+	w := NewMockWallet(SeededPrivateKey(seed, name)).(*Wallet)
+	w.nonceSource = func(i int) string {
+		return SeededNonce(seed, name, i)
+	}
+	return w
+}
+
+// deriveFromSeed returns 32 bytes derived from the seed for the given label
+func deriveFromSeed(seed, label string) []byte {
+	mac := hmac.New(sha256.New, []byte(seed))
+	mac.Write([]byte(label))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,508 @@
+// Package wallettest provides the mock wallets of tests, examples and the development profile of the auth middleware,
+// and the fixtures generated by cmd/gen-fixtures. The mock wallets derive keys and sign like a real wallet, so their
+// signatures verify with any implementation of pkg/wallet, but they hold the private key in memory and are not meant
+// for production.
+package wallettest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+)
+
+// MockWalletVersion is the version reported by the mock wallet.
+const MockWalletVersion = "mock-0.1.0"
+
+// minCiphertextLength is the length of the IV and authentication tag prepended and appended by AES-GCM.
+const minCiphertextLength = 32 + 16
+
+// Wallet provides a simple mock implementation of wallet.WalletInterface.
+type Wallet struct {
+	keyDeriver *wallet.KeyDeriver
+	// privilegedKeyDeriver derives the keys of privileged operations, nil when the wallet has no privileged keyring
+	privilegedKeyDeriver *wallet.KeyDeriver
+	// permission stands for the prompt of an interactive wallet, nil grants every operation
+	permission  PermissionFunc
+	validNonces map[string]bool
+	nonces      []string
+	nonceSource func(i int) string
+	// random is the source of the nonces of NewRandomMockWallet
+	random     io.Reader
+	nonceCount int
+	height     uint32
+	network    wallet.Network
+}
+
+// NewMockWallet creates a new mock wallet with given privateKey and nonces if provided.
+// Keys are derived from the private key like in a real wallet and signatures are deterministic (RFC 6979) ECDSA signatures,
+// so they can be verified cryptographically by the counterparty.
+func NewMockWallet(privateKey *ec.PrivateKey, nonces ...string) wallet.WalletInterface {
+	return &Wallet{
+		validNonces: make(map[string]bool),
+		nonces:      append([]string(nil), nonces...),
+		keyDeriver:  wallet.NewKeyDeriver(privateKey),
+		network:     wallet.NetworkMainnet,
+	}
+}
+
+// NewPrivilegedMockWallet creates a mock wallet like NewMockWallet with a separate privileged keyring,
+// operations with Privileged set use the privileged key instead of the everyday key.
+func NewPrivilegedMockWallet(privateKey, privilegedKey *ec.PrivateKey, nonces ...string) wallet.WalletInterface {
+	w := NewMockWallet(privateKey, nonces...).(*Wallet)
+	w.privilegedKeyDeriver = wallet.NewKeyDeriver(privilegedKey)
+	return w
+}
+
+// PermissionFunc decides whether the originator may perform the operation, like the user prompted by an interactive wallet
+type PermissionFunc func(originator string, args wallet.EncryptionArgs) bool
+
+// NewInteractiveMockWallet creates a mock wallet like NewMockWallet which asks the permission function
+// before signing, encrypting or decrypting with SeekPermission set.
+func NewInteractiveMockWallet(privateKey *ec.PrivateKey, permission PermissionFunc, nonces ...string) wallet.WalletInterface {
+	w := NewMockWallet(privateKey, nonces...).(*Wallet)
+	w.permission = permission
+	return w
+}
+
+// seekPermission asks for permission of operations with SeekPermission set
+func (w *Wallet) seekPermission(originator string, args wallet.EncryptionArgs) error {
+	if !args.SeekPermission || w.permission == nil {
+		return nil
+	}
+	if !w.permission(originator, args) {
+		return wallet.ErrPermissionDenied
+	}
+	return nil
+}
+
+// deriver returns the key deriver of the keyring selected by the privileged flag
+func (w *Wallet) deriver(args wallet.EncryptionArgs) (*wallet.KeyDeriver, error) {
+	if !args.Privileged {
+		return w.keyDeriver, nil
+	}
+	if w.privilegedKeyDeriver == nil {
+		return nil, wallet.ErrPrivilegedKeyUnavailable
+	}
+	return w.privilegedKeyDeriver, nil
+}
+
+// GetPublicKey retrieves the public key based on the provided arguments.
+func (m *Wallet) GetPublicKey(args *wallet.GetPublicKeyArgs, _ string) (*wallet.GetPublicKeyResult, error) {
+	if args == nil {
+		return nil, wallet.ErrArgsRequired
+	}
+	keyDeriver, err := m.deriver(args.EncryptionArgs)
+	if err != nil {
+		return nil, err
+	}
+	if args.IdentityKey {
+		return &wallet.GetPublicKeyResult{
+			PublicKey: keyDeriver.IdentityKey(),
+		}, nil
+	}
+
+	if args.ProtocolID.Protocol == "" || args.KeyID == "" {
+		return nil, errors.New("protocolID and keyID are required if identityKey is false or undefined")
+	}
+
+	// Handle default counterparty (self)
+	counterparty := args.Counterparty
+	if counterparty.Type == wallet.CounterpartyUninitialized {
+		counterparty = wallet.Counterparty{
+			Type: wallet.CounterpartyTypeSelf,
+		}
+	}
+
+	pubKey, err := keyDeriver.DerivePublicKey(
+		args.ProtocolID,
+		args.KeyID,
+		counterparty,
+		args.ForSelf,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &wallet.GetPublicKeyResult{
+		PublicKey: pubKey,
+	}, nil
+}
+
+// CreateSignature creates a digital signature for the given arguments
+func (w *Wallet) CreateSignature(args *wallet.CreateSignatureArgs, originator string) (*wallet.CreateSignatureResult, error) {
+	if args == nil {
+		return nil, wallet.ErrArgsRequired
+	}
+	if err := w.seekPermission(originator, args.EncryptionArgs); err != nil {
+		return nil, err
+	}
+	hash, err := args.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	counterparty := args.Counterparty
+	if counterparty.Type == wallet.CounterpartyUninitialized {
+		counterparty = wallet.Counterparty{
+			Type: wallet.CounterpartyTypeAnyone,
+		}
+	}
+
+	keyDeriver, err := w.deriver(args.EncryptionArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	privKey, err := keyDeriver.DerivePrivateKey(
+		args.ProtocolID,
+		args.KeyID,
+		counterparty,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive private key: %w", err)
+	}
+
+	signature, err := privKey.Sign(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signature: %w", err)
+	}
+
+	return &wallet.CreateSignatureResult{
+		Signature: *signature,
+	}, nil
+}
+
+// VerifySignature checks the validity of a cryptographic signature.
+// It verifies that the signature was created using the expected protocol and key ID.
+func (w *Wallet) VerifySignature(args *wallet.VerifySignatureArgs) (*wallet.VerifySignatureResult, error) {
+	if args == nil {
+		return nil, wallet.ErrArgsRequired
+	}
+	hash, err := args.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	counterparty := args.Counterparty
+	if counterparty.Type == wallet.CounterpartyUninitialized {
+		counterparty = wallet.Counterparty{
+			Type: wallet.CounterpartyTypeSelf,
+		}
+	}
+
+	keyDeriver, err := w.deriver(args.EncryptionArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	pubKey, err := keyDeriver.DerivePublicKey(
+		args.ProtocolID,
+		args.KeyID,
+		counterparty,
+		args.ForSelf,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive public key: %w", err)
+	}
+
+	valid := args.Signature.Verify(hash, pubKey)
+	if !valid {
+		return nil, wallet.ErrSignatureInvalid
+	}
+
+	return &wallet.VerifySignatureResult{
+		Valid: valid,
+	}, nil
+}
+
+// Encrypt encrypts the plaintext with a symmetric key derived for the given arguments.
+func (w *Wallet) Encrypt(args *wallet.EncryptArgs, originator string) (*wallet.EncryptResult, error) {
+	if args == nil {
+		return nil, wallet.ErrArgsRequired
+	}
+	if err := w.seekPermission(originator, args.EncryptionArgs); err != nil {
+		return nil, err
+	}
+
+	key, err := w.deriveSymmetricKey(args.EncryptionArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := key.Encrypt(args.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt data: %w", err)
+	}
+
+	return &wallet.EncryptResult{
+		Ciphertext: ciphertext,
+	}, nil
+}
+
+// Decrypt decrypts the ciphertext with a symmetric key derived for the given arguments.
+func (w *Wallet) Decrypt(args *wallet.DecryptArgs, originator string) (*wallet.DecryptResult, error) {
+	if args == nil {
+		return nil, wallet.ErrArgsRequired
+	}
+	if err := w.seekPermission(originator, args.EncryptionArgs); err != nil {
+		return nil, err
+	}
+	if len(args.Ciphertext) < minCiphertextLength {
+		return nil, errors.New("args.ciphertext is too short")
+	}
+
+	key, err := w.deriveSymmetricKey(args.EncryptionArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := key.Decrypt(args.Ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data: %w", err)
+	}
+
+	return &wallet.DecryptResult{
+		Plaintext: plaintext,
+	}, nil
+}
+
+func (w *Wallet) deriveSymmetricKey(args wallet.EncryptionArgs) (*ec.SymmetricKey, error) {
+	counterparty := args.Counterparty
+	if counterparty.Type == wallet.CounterpartyUninitialized {
+		counterparty = wallet.Counterparty{
+			Type: wallet.CounterpartyTypeSelf,
+		}
+	}
+
+	keyDeriver, err := w.deriver(args)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := keyDeriver.DeriveSymmetricKey(args.ProtocolID, args.KeyID, counterparty)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive symmetric key: %w", err)
+	}
+
+	return key, nil
+}
+
+// RevealCounterpartyKeyLinkage reveals the shared secret with the counterparty and its CounterpartyLinkageProof,
+// encrypted for the verifier.
+func (w *Wallet) RevealCounterpartyKeyLinkage(args *wallet.RevealCounterpartyKeyLinkageArgs, originator string) (*wallet.RevealCounterpartyKeyLinkageResult, error) {
+	if args == nil {
+		return nil, wallet.ErrArgsRequired
+	}
+	if args.Counterparty == nil || args.Verifier == nil {
+		return nil, errors.New("args.counterparty and args.verifier are required")
+	}
+
+	keyDeriver, err := w.deriver(wallet.EncryptionArgs{Privileged: args.Privileged})
+	if err != nil {
+		return nil, err
+	}
+
+	linkage, err := keyDeriver.RevealCounterpartySecret(wallet.Counterparty{
+		Type:         wallet.CounterpartyTypeOther,
+		Counterparty: args.Counterparty,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to reveal counterparty secret: %w", err)
+	}
+
+	proof, err := keyDeriver.ProveCounterpartySecret(args.Counterparty, linkage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prove counterparty linkage: %w", err)
+	}
+
+	revelationTime := time.Now().UTC().Format(time.RFC3339)
+	encryption := wallet.EncryptionArgs{
+		ProtocolID: wallet.CounterpartyLinkageRevelationProtocol,
+		KeyID:      revelationTime,
+		Counterparty: wallet.Counterparty{
+			Type:         wallet.CounterpartyTypeOther,
+			Counterparty: args.Verifier,
+		},
+		Privileged:       args.Privileged,
+		PrivilegedReason: args.PrivilegedReason,
+	}
+
+	encrypted, err := w.Encrypt(&wallet.EncryptArgs{EncryptionArgs: encryption, Plaintext: linkage.Compressed()}, originator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt linkage: %w", err)
+	}
+	encryptedProof, err := w.Encrypt(&wallet.EncryptArgs{EncryptionArgs: encryption, Plaintext: proof.Bytes()}, originator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt linkage proof: %w", err)
+	}
+
+	return &wallet.RevealCounterpartyKeyLinkageResult{
+		Prover:                keyDeriver.IdentityKey(),
+		Verifier:              args.Verifier,
+		Counterparty:          args.Counterparty,
+		RevelationTime:        revelationTime,
+		EncryptedLinkage:      encrypted.Ciphertext,
+		EncryptedLinkageProof: encryptedProof.Ciphertext,
+	}, nil
+}
+
+// RevealSpecificKeyLinkage reveals the offset of a single derived key and its SpecificLinkageProof,
+// encrypted for the verifier.
+func (w *Wallet) RevealSpecificKeyLinkage(args *wallet.RevealSpecificKeyLinkageArgs, originator string) (*wallet.RevealSpecificKeyLinkageResult, error) {
+	if args == nil {
+		return nil, wallet.ErrArgsRequired
+	}
+	if args.Verifier == nil {
+		return nil, errors.New("args.verifier is required")
+	}
+	if args.Counterparty.Type != wallet.CounterpartyTypeOther || args.Counterparty.Counterparty == nil {
+		return nil, errors.New("args.counterparty must be a specific public key")
+	}
+
+	keyDeriver, err := w.deriver(wallet.EncryptionArgs{Privileged: args.Privileged})
+	if err != nil {
+		return nil, err
+	}
+
+	linkage, err := keyDeriver.RevealSpecificSecret(args.Counterparty, args.ProtocolID, args.KeyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reveal specific secret: %w", err)
+	}
+
+	proof, err := keyDeriver.ProveSpecificSecret(args.Counterparty, args.ProtocolID, args.KeyID, args.Verifier)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prove specific linkage: %w", err)
+	}
+
+	encryption := wallet.EncryptionArgs{
+		ProtocolID: wallet.SpecificLinkageRevelationProtocol(args.ProtocolID),
+		KeyID:      args.KeyID,
+		Counterparty: wallet.Counterparty{
+			Type:         wallet.CounterpartyTypeOther,
+			Counterparty: args.Verifier,
+		},
+		Privileged:       args.Privileged,
+		PrivilegedReason: args.PrivilegedReason,
+	}
+
+	encrypted, err := w.Encrypt(&wallet.EncryptArgs{EncryptionArgs: encryption, Plaintext: linkage}, originator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt linkage: %w", err)
+	}
+	encryptedProof, err := w.Encrypt(&wallet.EncryptArgs{EncryptionArgs: encryption, Plaintext: proof.Bytes()}, originator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt linkage proof: %w", err)
+	}
+
+	return &wallet.RevealSpecificKeyLinkageResult{
+		Prover:                keyDeriver.IdentityKey(),
+		Verifier:              args.Verifier,
+		Counterparty:          args.Counterparty.Counterparty,
+		ProtocolID:            args.ProtocolID,
+		KeyID:                 args.KeyID,
+		EncryptedLinkage:      encrypted.Ciphertext,
+		EncryptedLinkageProof: encryptedProof.Ciphertext,
+		ProofType:             wallet.SpecificLinkageProofSchnorr,
+	}, nil
+}
+
+// CreateNonce generates a deterministic nonce.
+func (m *Wallet) CreateNonce(ctx context.Context) (string, error) {
+	if ctx.Err() != nil {
+		return "", fmt.Errorf("ctx err: %w", ctx.Err())
+	}
+
+	newNonce := MockNonce
+
+	if m.random != nil {
+		nonce, err := m.randomNonce()
+		if err != nil {
+			return "", err
+		}
+		newNonce = nonce
+	} else if m.nonceSource != nil {
+		newNonce = m.nonceSource(m.nonceCount)
+		m.nonceCount++
+	} else if len(m.nonces) != 0 {
+		newNonce = m.nonces[0]
+		m.nonces = m.nonces[1:]
+
+		if len(m.nonces) == 0 {
+			m.nonces = append([]string(nil), DefaultNonces...)
+		}
+	}
+
+	m.validNonces[newNonce] = true
+	return newNonce, nil
+}
+
+// VerifyNonce checks if the nonce exists.
+func (m *Wallet) VerifyNonce(ctx context.Context, nonce string) (bool, error) {
+	if ctx.Err() != nil {
+		return false, fmt.Errorf("ctx err: %w", ctx.Err())
+	}
+
+	_, exists := m.validNonces[nonce]
+	return exists, nil
+}
+
+// GetHeight returns the configured height, zero by default.
+func (m *Wallet) GetHeight(ctx context.Context, _ string) (*wallet.GetHeightResult, error) {
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("ctx err: %w", ctx.Err())
+	}
+
+	return &wallet.GetHeightResult{Height: m.height}, nil
+}
+
+// GetNetwork returns the configured network, mainnet by default.
+func (m *Wallet) GetNetwork(ctx context.Context, _ string) (*wallet.GetNetworkResult, error) {
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("ctx err: %w", ctx.Err())
+	}
+
+	return &wallet.GetNetworkResult{Network: m.network}, nil
+}
+
+// GetVersion returns MockWalletVersion.
+func (m *Wallet) GetVersion(ctx context.Context, _ string) (*wallet.GetVersionResult, error) {
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("ctx err: %w", ctx.Err())
+	}
+
+	return &wallet.GetVersionResult{Version: MockWalletVersion}, nil
+}
+
+// SetHeight configures the height returned by GetHeight.
+func (m *Wallet) SetHeight(height uint32) {
+	m.height = height
+}
+
+// SetNetwork configures the network returned by GetNetwork.
+func (m *Wallet) SetNetwork(network wallet.Network) {
+	m.network = network
+}
+
+// ListCertificates returns an empty list.
+func (m *Wallet) ListCertificates(ctx context.Context, certifiers []string, types []string) ([]certificates.Certificate, error) {
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("ctx err: %w", ctx.Err())
+	}
+
+	return []certificates.Certificate{}, nil
+}
+
+// ProveCertificate returns an empty map.
+func (m *Wallet) ProveCertificate(ctx context.Context, certificate certificates.Certificate, verifier string, fieldsToReveal []string) (map[string]string, error) {
+	if ctx.Err() != nil {
+		return nil, fmt.Errorf("ctx err: %w", ctx.Err())
+	}
+
+	return map[string]string{}, nil
+}
@@ -0,0 +1,186 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultMaxConcurrentRequests bounds the requests ServeRequests handles at once when no other limit is set
+const DefaultMaxConcurrentRequests = 16
+
+// Request errors
+var (
+	ErrRequestFailed   = errors.New("websocket request failed")
+	ErrRequesterClosed = errors.New("websocket requester closed")
+)
+
+// Envelope carries a request or its response over a connection shared by concurrent requests, the response
+// carries the ID of its request. Responses of failed requests carry the error instead of a payload.
+type Envelope struct {
+	ID      string `json:"id"`
+	Payload []byte `json:"payload,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// MessageConn is a connection exchanging messages, e.g. a Conn or a socket authenticated by the handshake
+// of the WebSocket transport, whose context carries the identity of the peer
+type MessageConn interface {
+	ReadMessage() (int, []byte, error)
+	WriteMessage(messageType int, data []byte) error
+	Context() context.Context
+	Close() error
+}
+
+// Requester multiplexes requests over a connection, matching responses to their requests by the correlation ID
+// of their envelopes, see ServeRequests for the peer.
+// The requester reads the connection until it is closed, messages which are not envelopes are dropped.
+type Requester struct {
+	conn   MessageConn
+	nextID atomic.Uint64
+
+	mu      sync.Mutex
+	pending map[string]chan Envelope
+	err     error
+	done    chan struct{}
+}
+
+// NewRequester starts reading the connection, which is not read by anyone else afterwards
+func NewRequester(conn MessageConn) *Requester {
+	r := &Requester{conn: conn, pending: make(map[string]chan Envelope), done: make(chan struct{})}
+	go r.read()
+	return r
+}
+
+// Request sends the payload and waits for the response of the peer, the request is abandoned when the context is
+// done. A request the peer failed is reported with ErrRequestFailed.
+func (r *Requester) Request(ctx context.Context, payload []byte) ([]byte, error) {
+	id := strconv.FormatUint(r.nextID.Add(1), 10)
+	response := make(chan Envelope, 1)
+
+	r.mu.Lock()
+	if r.err != nil {
+		r.mu.Unlock()
+		return nil, r.err
+	}
+	r.pending[id] = response
+	r.mu.Unlock()
+	defer r.forget(id)
+
+	data, err := json.Marshal(Envelope{ID: id, Payload: payload})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request, %w", err)
+	}
+	if err := r.conn.WriteMessage(TextMessage, data); err != nil {
+		return nil, fmt.Errorf("failed to send request, %w", err)
+	}
+
+	select {
+	case envelope := <-response:
+		if envelope.Error != "" {
+			return nil, fmt.Errorf("%w: %s", ErrRequestFailed, envelope.Error)
+		}
+		return envelope.Payload, nil
+	case <-r.done:
+		return nil, r.closedErr()
+	case <-ctx.Done():
+		return nil, ctx.Err() //nolint:wrapcheck // the error of the context is returned as is
+	}
+}
+
+// Close closes the connection, pending requests fail with ErrRequesterClosed
+func (r *Requester) Close() error {
+	err := r.conn.Close()
+	<-r.done
+	return err
+}
+
+// read delivers the responses received over the connection to their pending requests until the connection fails
+func (r *Requester) read() {
+	for {
+		_, message, err := r.conn.ReadMessage()
+		if err != nil {
+			r.mu.Lock()
+			r.err = fmt.Errorf("%w: %w", ErrRequesterClosed, err)
+			r.mu.Unlock()
+			close(r.done)
+			return
+		}
+
+		var envelope Envelope
+		if json.Unmarshal(message, &envelope) != nil || envelope.ID == "" {
+			continue
+		}
+
+		r.mu.Lock()
+		response, ok := r.pending[envelope.ID]
+		delete(r.pending, envelope.ID)
+		r.mu.Unlock()
+		if ok {
+			response <- envelope
+		}
+	}
+}
+
+func (r *Requester) forget(id string) {
+	r.mu.Lock()
+	delete(r.pending, id)
+	r.mu.Unlock()
+}
+
+func (r *Requester) closedErr() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// ServeRequests answers the requests of a Requester received over the connection until it is closed, each request
+// is handled in a goroutine of its own with the context of the connection. At most maxConcurrent requests are handled
+// at once, DefaultMaxConcurrentRequests when it is not positive, further requests are not read until one of them
+// is answered. Errors of the handler are sent to the requester as the error of the response.
+// It returns the error which ended the connection, ErrClosed when the peer closed it.
+func ServeRequests(conn MessageConn, maxConcurrent int, handle func(ctx context.Context, payload []byte) ([]byte, error)) error {
+	if maxConcurrent <= 0 {
+		maxConcurrent = DefaultMaxConcurrentRequests
+	}
+	slots := make(chan struct{}, maxConcurrent)
+
+	var handlers sync.WaitGroup
+	defer handlers.Wait()
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		var request Envelope
+		if json.Unmarshal(message, &request) != nil || request.ID == "" {
+			continue
+		}
+
+		slots <- struct{}{}
+		handlers.Add(1)
+		go func() {
+			defer handlers.Done()
+			defer func() { <-slots }()
+
+			response := Envelope{ID: request.ID}
+			payload, err := handle(conn.Context(), request.Payload)
+			if err != nil {
+				response.Error = err.Error()
+			} else {
+				response.Payload = payload
+			}
+
+			data, err := json.Marshal(response)
+			if err == nil {
+				_ = conn.WriteMessage(TextMessage, data)
+			}
+		}()
+	}
+}
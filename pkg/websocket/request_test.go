@@ -0,0 +1,156 @@
+package websocket_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequester_Request(t *testing.T) {
+	// serve connects a requester to a peer answering at most maxConcurrent of its requests at once with the handler
+	serve := func(t *testing.T, maxConcurrent int, handle func(ctx context.Context, payload []byte) ([]byte, error)) *websocket.Requester {
+		clientSide, serverSide := net.Pipe()
+		server := websocket.NewConn(context.Background(), serverSide, bufio.NewReader(serverSide), false)
+		go func() { _ = websocket.ServeRequests(server, maxConcurrent, handle) }()
+
+		requester := websocket.NewRequester(websocket.NewConn(context.Background(), clientSide, nil, true))
+		t.Cleanup(func() { _ = requester.Close() })
+		return requester
+	}
+
+	t.Run("concurrent requests receive their own responses", func(t *testing.T) {
+		// given
+		requester := serve(t, 0, func(_ context.Context, payload []byte) ([]byte, error) {
+			// the first requests are answered last
+			delay, err := time.ParseDuration(string(payload))
+			if err != nil {
+				return nil, err
+			}
+			time.Sleep(delay)
+			return []byte("after " + string(payload)), nil
+		})
+
+		// when
+		var wg sync.WaitGroup
+		responses := make([][]byte, 5)
+		errs := make([]error, 5)
+		for i := range responses {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				responses[i], errs[i] = requester.Request(context.Background(), fmt.Appendf(nil, "%dms", 50-10*i))
+			}()
+		}
+		wg.Wait()
+
+		// then
+		for i := range responses {
+			require.NoError(t, errs[i])
+			require.Equal(t, fmt.Sprintf("after %dms", 50-10*i), string(responses[i]))
+		}
+	})
+
+	t.Run("requests above the concurrency limit wait for a slot", func(t *testing.T) {
+		// given
+		var (
+			mu                 sync.Mutex
+			inFlight, observed int
+		)
+		requester := serve(t, 2, func(_ context.Context, payload []byte) ([]byte, error) {
+			mu.Lock()
+			inFlight++
+			observed = max(observed, inFlight)
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+			return payload, nil
+		})
+
+		// when
+		var wg sync.WaitGroup
+		errs := make([]error, 6)
+		for i := range errs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, errs[i] = requester.Request(context.Background(), []byte("call"))
+			}()
+		}
+		wg.Wait()
+
+		// then
+		for _, err := range errs {
+			require.NoError(t, err)
+		}
+		require.Equal(t, 2, observed)
+	})
+
+	t.Run("request failed by the peer returns its error", func(t *testing.T) {
+		// given
+		requester := serve(t, 0, func(context.Context, []byte) ([]byte, error) {
+			return nil, errors.New("unknown method")
+		})
+
+		// when
+		_, err := requester.Request(context.Background(), []byte("call"))
+
+		// then
+		require.ErrorIs(t, err, websocket.ErrRequestFailed)
+		require.ErrorContains(t, err, "unknown method")
+	})
+
+	t.Run("request is abandoned when its context is done", func(t *testing.T) {
+		// given
+		release := make(chan struct{})
+		defer close(release)
+		requester := serve(t, 0, func(context.Context, []byte) ([]byte, error) {
+			<-release
+			return nil, nil
+		})
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		// when
+		_, err := requester.Request(ctx, []byte("call"))
+
+		// then
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("pending requests fail when the connection closes", func(t *testing.T) {
+		// given
+		received, release := make(chan struct{}), make(chan struct{})
+		defer close(release)
+		requester := serve(t, 0, func(context.Context, []byte) ([]byte, error) {
+			close(received)
+			<-release
+			return nil, nil
+		})
+
+		// when
+		result := make(chan error, 1)
+		go func() {
+			_, err := requester.Request(context.Background(), []byte("call"))
+			result <- err
+		}()
+		<-received
+		require.NoError(t, requester.Close())
+
+		// then
+		require.ErrorIs(t, <-result, websocket.ErrRequesterClosed)
+		_, err := requester.Request(context.Background(), []byte("again"))
+		require.ErrorIs(t, err, websocket.ErrRequesterClosed)
+	})
+}
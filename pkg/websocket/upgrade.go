@@ -0,0 +1,136 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Upgrade errors
+var (
+	ErrNotUpgrade    = errors.New("request is not a websocket upgrade")
+	ErrUpgradeFailed = errors.New("websocket upgrade failed")
+)
+
+// IsUpgradeRequest reports whether the request asks to upgrade its connection to WebSocket
+func IsUpgradeRequest(req *http.Request) bool {
+	if req.Method != http.MethodGet || !strings.EqualFold(req.Header.Get("Upgrade"), "websocket") {
+		return false
+	}
+
+	for _, value := range req.Header.Values("Connection") {
+		for _, token := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Upgrade takes over the connection of the request and answers it with 101 Switching Protocols, the headers set on
+// the response writer are sent with the response. The connection is taken over with http.ResponseController, so
+// a response writer implementing Hijack, like the one of the auth middleware, can sign the response first.
+// The context of the request is the context of the connection.
+func Upgrade(w http.ResponseWriter, req *http.Request) (*Conn, error) {
+	if !IsUpgradeRequest(req) {
+		return nil, ErrNotUpgrade
+	}
+	if req.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, fmt.Errorf("%w: unsupported version %q", ErrNotUpgrade, req.Header.Get("Sec-WebSocket-Version"))
+	}
+	key := req.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, fmt.Errorf("%w: missing key", ErrNotUpgrade)
+	}
+
+	w.Header().Set("Upgrade", "websocket")
+	w.Header().Set("Connection", "Upgrade")
+	w.Header().Set("Sec-WebSocket-Accept", AcceptKey(key))
+
+	conn, brw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, fmt.Errorf("failed to take over the connection, %w", err)
+	}
+
+	var response bytes.Buffer
+	response.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+	if err := w.Header().Write(&response); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to write upgrade response, %w", err)
+	}
+	response.WriteString("\r\n")
+	if _, err := conn.Write(response.Bytes()); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("failed to write upgrade response, %w", err)
+	}
+
+	return NewConn(req.Context(), conn, brw.Reader, false), nil
+}
+
+// Dial opens a WebSocket connection to the URL, ws and wss URLs are dialed over http and https.
+// The header is sent along with the upgrade request, e.g. Origin, the context only bounds the upgrade.
+func Dial(ctx context.Context, url string, header http.Header) (*Conn, error) {
+	req, key, err := NewUpgradeRequest(ctx, url, header)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send upgrade request, %w", err)
+	}
+
+	return ClientConn(context.WithoutCancel(ctx), response, key)
+}
+
+// NewUpgradeRequest creates the request upgrading to WebSocket at the URL with a new key, ws and wss URLs are
+// requested over http and https. The header is sent along with the upgrade request.
+func NewUpgradeRequest(ctx context.Context, url string, header http.Header) (*http.Request, string, error) {
+	switch {
+	case strings.HasPrefix(url, "ws://"):
+		url = "http://" + strings.TrimPrefix(url, "ws://")
+	case strings.HasPrefix(url, "wss://"):
+		url = "https://" + strings.TrimPrefix(url, "wss://")
+	}
+
+	key, err := NewKey()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create websocket key, %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create upgrade request, %w", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+
+	return req, key, nil
+}
+
+// ClientConn creates the connection of the client from the response to an upgrade request sent with the key,
+// the response body is closed when the server did not switch protocols
+func ClientConn(ctx context.Context, response *http.Response, key string) (*Conn, error) {
+	if response.StatusCode != http.StatusSwitchingProtocols {
+		_ = response.Body.Close()
+		return nil, fmt.Errorf("%w: server responded with status %d", ErrUpgradeFailed, response.StatusCode)
+	}
+
+	rwc, ok := response.Body.(io.ReadWriteCloser)
+	if !ok || response.Header.Get("Sec-WebSocket-Accept") != AcceptKey(key) {
+		_ = response.Body.Close()
+		return nil, fmt.Errorf("%w: invalid upgrade response", ErrUpgradeFailed)
+	}
+
+	return NewConn(ctx, rwc, nil, true), nil
+}
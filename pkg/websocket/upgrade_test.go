@@ -0,0 +1,92 @@
+package websocket_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDial(t *testing.T) {
+	// given
+	upgradeErrs := make(chan error, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("X-Upgraded-By", "test")
+		conn, err := websocket.Upgrade(w, req)
+		upgradeErrs <- err
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		messageType, message, err := conn.ReadMessage()
+		if err == nil {
+			_ = conn.WriteMessage(messageType, message)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("connection echoes messages after the dial context is done", func(t *testing.T) {
+		// given
+		ctx, cancel := context.WithCancel(context.Background())
+		conn, err := websocket.Dial(ctx, "ws://"+strings.TrimPrefix(server.URL, "http://"), nil)
+		require.NoError(t, err)
+		require.NoError(t, <-upgradeErrs)
+		defer func() { _ = conn.Close() }()
+		cancel()
+
+		// when
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+		messageType, message, err := conn.ReadMessage()
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, websocket.TextMessage, messageType)
+		require.Equal(t, "hello", string(message))
+	})
+
+	t.Run("headers of the response writer are sent with the upgrade response", func(t *testing.T) {
+		// given
+		req, key, err := websocket.NewUpgradeRequest(context.Background(), server.URL, nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, <-upgradeErrs)
+		conn, err := websocket.ClientConn(context.Background(), response, key)
+
+		// then
+		require.NoError(t, err)
+		require.NoError(t, conn.Close())
+		require.Equal(t, "test", response.Header.Get("X-Upgraded-By"))
+	})
+
+	t.Run("request without upgrade is rejected", func(t *testing.T) {
+		// when
+		response, err := http.Get(server.URL)
+		require.NoError(t, err)
+		defer func() { _ = response.Body.Close() }()
+
+		// then
+		require.ErrorIs(t, <-upgradeErrs, websocket.ErrNotUpgrade)
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+	})
+
+	t.Run("dial of an endpoint which does not upgrade fails", func(t *testing.T) {
+		// given
+		plain := httptest.NewServer(http.NotFoundHandler())
+		defer plain.Close()
+
+		// when
+		_, err := websocket.Dial(context.Background(), "ws://"+strings.TrimPrefix(plain.URL, "http://"), nil)
+
+		// then
+		require.ErrorIs(t, err, websocket.ErrUpgradeFailed)
+	})
+}
@@ -0,0 +1,288 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // SHA-1 is mandated by RFC 6455 for the accept key
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// Message types
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+)
+
+const (
+	continuationFrame = 0
+	closeFrame        = 8
+	pingFrame         = 9
+	pongFrame         = 10
+)
+
+// DefaultReadLimit is the maximum size of received messages when no other limit is set
+const DefaultReadLimit = 1 << 20
+
+// acceptGUID is appended to the key of the client to compute the accept key, see RFC 6455 section 1.3
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket errors
+var (
+	ErrClosed          = errors.New("websocket connection closed")
+	ErrProtocol        = errors.New("websocket protocol violation")
+	ErrMessageTooLarge = errors.New("websocket message exceeds the read limit")
+)
+
+// AcceptKey returns the Sec-WebSocket-Accept value of the Sec-WebSocket-Key sent by the client
+func AcceptKey(key string) string {
+	hash := sha1.Sum([]byte(key + acceptGUID)) //nolint:gosec // mandated by RFC 6455
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// NewKey returns a random Sec-WebSocket-Key
+func NewKey() (string, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// Conn is a WebSocket connection. Messages can be read by one goroutine and written by many.
+// Pings are answered while reading, a close frame of the peer is answered and reported as ErrClosed.
+type Conn struct {
+	ctx       context.Context
+	rwc       io.ReadWriteCloser
+	r         *bufio.Reader
+	client    bool
+	readLimit int
+	intercept func(messageType int, message []byte) bool
+
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+}
+
+// NewConn creates a connection over the upgraded stream, r reads data buffered during the upgrade and may be nil.
+// Frames sent by clients are masked, the context is returned by Context.
+func NewConn(ctx context.Context, rwc io.ReadWriteCloser, r *bufio.Reader, client bool) *Conn {
+	if r == nil {
+		r = bufio.NewReader(rwc)
+	}
+	return &Conn{ctx: ctx, rwc: rwc, r: r, client: client, readLimit: DefaultReadLimit}
+}
+
+// Context returns the context of the connection, for connections upgraded by the auth middleware it carries
+// the identity of the peer
+func (c *Conn) Context() context.Context {
+	return c.ctx
+}
+
+// SetReadLimit sets the maximum size of received messages
+func (c *Conn) SetReadLimit(limit int) {
+	c.readLimit = limit
+}
+
+// SetInterceptor sets a function called with every received message before it is returned by ReadMessage,
+// messages it handles (e.g. heartbeats of the auth protocol) are not returned. It has to be set before reading.
+func (c *Conn) SetInterceptor(intercept func(messageType int, message []byte) bool) {
+	c.intercept = intercept
+}
+
+// ReadMessage returns the next text or binary message
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	messageType := 0
+	var message []byte
+
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			if errors.Is(err, ErrProtocol) || errors.Is(err, ErrMessageTooLarge) {
+				_ = c.Close()
+			}
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case pingFrame:
+			if err := c.writeFrame(pongFrame, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case pongFrame:
+			continue
+		case closeFrame:
+			c.closeOnce.Do(func() {
+				_ = c.writeFrame(closeFrame, payload[:min(len(payload), 2)])
+				_ = c.rwc.Close()
+			})
+			return 0, nil, ErrClosed
+		case continuationFrame:
+			if messageType == 0 {
+				_ = c.Close()
+				return 0, nil, fmt.Errorf("%w: continuation frame without message", ErrProtocol)
+			}
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				_ = c.Close()
+				return 0, nil, fmt.Errorf("%w: message frame within fragmented message", ErrProtocol)
+			}
+			messageType = int(opcode)
+		default:
+			_ = c.Close()
+			return 0, nil, fmt.Errorf("%w: unknown opcode %d", ErrProtocol, opcode)
+		}
+
+		if len(message)+len(payload) > c.readLimit {
+			_ = c.Close()
+			return 0, nil, ErrMessageTooLarge
+		}
+		message = append(message, payload...)
+
+		if !fin {
+			continue
+		}
+		if c.intercept != nil && c.intercept(messageType, message) {
+			messageType, message = 0, nil
+			continue
+		}
+		return messageType, message, nil
+	}
+}
+
+// WriteMessage sends a text or binary message in a single frame
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return fmt.Errorf("unsupported message type %d", messageType)
+	}
+	return c.writeFrame(byte(messageType), data)
+}
+
+// Close sends a normal closure frame and closes the connection
+func (c *Conn) Close() error {
+	err := ErrClosed
+	c.closeOnce.Do(func() {
+		_ = c.writeFrame(closeFrame, binary.BigEndian.AppendUint16(nil, 1000))
+		err = c.rwc.Close()
+	})
+	if errors.Is(err, ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+func (c *Conn) readFrame() (bool, byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return false, 0, nil, c.readError(err)
+	}
+
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0f
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, fmt.Errorf("%w: reserved bits set", ErrProtocol)
+	}
+
+	// clients mask their frames, servers do not
+	masked := header[1]&0x80 != 0
+	if masked == c.client {
+		return false, 0, nil, fmt.Errorf("%w: unexpected masking", ErrProtocol)
+	}
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err := io.ReadFull(c.r, extended[:]); err != nil {
+			return false, 0, nil, c.readError(err)
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err := io.ReadFull(c.r, extended[:]); err != nil {
+			return false, 0, nil, c.readError(err)
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+
+	if opcode >= closeFrame && (!fin || length > 125) {
+		return false, 0, nil, fmt.Errorf("%w: invalid control frame", ErrProtocol)
+	}
+	if length > uint64(c.readLimit) {
+		return false, 0, nil, ErrMessageTooLarge
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+			return false, 0, nil, c.readError(err)
+		}
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return false, 0, nil, c.readError(err)
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// readError reports connections closed without a close frame, or closed by Close, as ErrClosed
+func (c *Conn) readError(err error) error {
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.ErrClosedPipe) || errors.Is(err, net.ErrClosed) {
+		return fmt.Errorf("%w: %w", ErrClosed, err)
+	}
+	return err
+}
+
+func (c *Conn) writeFrame(opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	frame := []byte{0x80 | opcode}
+
+	maskBit := byte(0)
+	if c.client {
+		maskBit = 0x80
+	}
+
+	switch n := len(payload); {
+	case n <= 125:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xffff:
+		frame = binary.BigEndian.AppendUint16(append(frame, maskBit|126), uint16(n)) //nolint:gosec // n fits in uint16
+	default:
+		frame = binary.BigEndian.AppendUint64(append(frame, maskBit|127), uint64(n)) //nolint:gosec // n is never negative
+	}
+
+	if c.client {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+		for i := range payload {
+			frame[start+i] ^= mask[i%4]
+		}
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	if _, err := c.rwc.Write(frame); err != nil {
+		return fmt.Errorf("failed to write websocket frame, %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package websocket_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/websocket"
+	"github.com/stretchr/testify/require"
+)
+
+// frame returns an unmasked server frame
+func frame(fin bool, opcode byte, payload string) []byte {
+	first := opcode
+	if fin {
+		first |= 0x80
+	}
+	return append([]byte{first, byte(len(payload))}, payload...)
+}
+
+func TestConn_ReadMessage(t *testing.T) {
+	t.Run("fragmented message is reassembled and pings are answered", func(t *testing.T) {
+		// given
+		clientSide, serverSide := net.Pipe()
+		conn := websocket.NewConn(context.Background(), clientSide, nil, true)
+
+		go func() {
+			_, _ = serverSide.Write(frame(false, websocket.TextMessage, "hel"))
+			_, _ = serverSide.Write(frame(true, 9, "ping"))
+			_, _ = serverSide.Write(frame(true, 0, "lo"))
+		}()
+		pong := make(chan []byte, 1)
+		go func() {
+			header := make([]byte, 6)
+			_, _ = io.ReadFull(serverSide, header)
+			payload := make([]byte, header[1]&0x7f)
+			_, _ = io.ReadFull(serverSide, payload)
+			for i := range payload {
+				payload[i] ^= header[2+i%4]
+			}
+			pong <- append(header[:1], payload...)
+		}()
+
+		// when
+		messageType, message, err := conn.ReadMessage()
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, websocket.TextMessage, messageType)
+		require.Equal(t, "hello", string(message))
+		require.Equal(t, append([]byte{0x80 | 10}, "ping"...), <-pong)
+	})
+
+	t.Run("message above the read limit is rejected", func(t *testing.T) {
+		// given
+		clientSide, serverSide := net.Pipe()
+		conn := websocket.NewConn(context.Background(), clientSide, nil, true)
+		conn.SetReadLimit(4)
+
+		go func() {
+			_, _ = serverSide.Write(frame(true, websocket.BinaryMessage, "too large"))
+			_, _ = io.Copy(io.Discard, serverSide)
+		}()
+
+		// when
+		_, _, err := conn.ReadMessage()
+
+		// then
+		require.ErrorIs(t, err, websocket.ErrMessageTooLarge)
+	})
+
+	t.Run("unmasked frame of a client is rejected", func(t *testing.T) {
+		// given
+		clientSide, serverSide := net.Pipe()
+		conn := websocket.NewConn(context.Background(), serverSide, nil, false)
+
+		go func() {
+			_, _ = clientSide.Write(frame(true, websocket.TextMessage, "hello"))
+			_, _ = io.Copy(io.Discard, clientSide)
+		}()
+
+		// when
+		_, _, err := conn.ReadMessage()
+
+		// then
+		require.ErrorIs(t, err, websocket.ErrProtocol)
+	})
+}
+
+func TestConn_RoundTrip(t *testing.T) {
+	// given
+	clientSide, serverSide := net.Pipe()
+	client := websocket.NewConn(context.Background(), clientSide, nil, true)
+	server := websocket.NewConn(context.Background(), serverSide, bufio.NewReader(serverSide), false)
+	large := make([]byte, 70000)
+
+	go func() {
+		for {
+			messageType, message, err := server.ReadMessage()
+			if err != nil {
+				return
+			}
+			_ = server.WriteMessage(messageType, message)
+		}
+	}()
+
+	for _, message := range [][]byte{[]byte("hello"), large[:300], large} {
+		// when
+		require.NoError(t, client.WriteMessage(websocket.BinaryMessage, message))
+		messageType, reply, err := client.ReadMessage()
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, websocket.BinaryMessage, messageType)
+		require.Equal(t, message, reply)
+	}
+
+	// when
+	go func() { _ = client.Close() }()
+	_, _, err := client.ReadMessage()
+
+	// then
+	require.ErrorIs(t, err, websocket.ErrClosed)
+}
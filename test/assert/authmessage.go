@@ -1,29 +1,88 @@
 package assert
 
 import (
-	"encoding/hex"
+	"encoding/base64"
+	"encoding/json"
 	"testing"
 
-	walletFixtures "github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet/test"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 	"github.com/stretchr/testify/require"
 )
 
 var (
-	initialResponseSignature   = []byte("3044022001b11522b8effc5ee836914d3d4bdb87e95164246fb7515ef355a3fa96c558f20220537130fb596f55476d308cd18ef9b2238b04aa828d7393082288b5872f7f1c90")
 	initialResponseAuthMessage = transport.AuthMessage{
 		Version:      "0.1",
 		MessageType:  "initialResponse",
-		IdentityKey:  walletFixtures.ServerIdentityKey,
-		InitialNonce: walletFixtures.DefaultNonces[0],
-		YourNonce:    &walletFixtures.ClientNonces[0],
-		Signature:    &initialResponseSignature,
+		IdentityKey:  wallettest.ServerIdentityKey,
+		InitialNonce: wallettest.DefaultNonces[0],
+		YourNonce:    &wallettest.ClientNonces[0],
 	}
 )
 
-// InitialResponseAuthMessage asserts that the given AuthMessage is equal to the expected initial response AuthMessage.
-func InitialResponseAuthMessage(t *testing.T, msg *transport.AuthMessage) {
+// InitialResponseAuthMessage asserts that the given AuthMessage is equal to the expected initial response AuthMessage
+// and that its signature over the nonces is valid for the server identity key, verified with the wallet of the client.
+func InitialResponseAuthMessage(t *testing.T, clientWallet wallet.WalletInterface, msg *transport.AuthMessage) {
+	t.Helper()
+
 	compareAuthMessage(t, &initialResponseAuthMessage, msg)
+
+	require.NotNil(t, msg.Signature, "initial response must be signed")
+	InitialResponseSignature(t, clientWallet, msg)
+}
+
+// InitialResponseSignature asserts that the signature of the initial response is valid for the identity key of the response.
+// The server signs the concatenated client and server nonces for the client, so it is verified with the wallet of the client.
+func InitialResponseSignature(t *testing.T, clientWallet wallet.WalletInterface, msg *transport.AuthMessage) {
+	t.Helper()
+
+	require.NotNil(t, msg.Signature, "initial response must be signed")
+	require.NotNil(t, msg.YourNonce, "initial response must contain the client nonce")
+
+	combined := *msg.YourNonce + msg.InitialNonce
+	verifySignature(t, clientWallet, msg.IdentityKey, combined, []byte(base64.StdEncoding.EncodeToString([]byte(combined))), *msg.Signature)
+}
+
+// verifySignature verifies a DER signature created with the auth protocol by the signer identity key for the verifier wallet
+func verifySignature(t *testing.T, verifier wallet.WalletInterface, signerIdentityKey, keyID string, data, signature []byte) {
+	t.Helper()
+	require.True(t, isValidSignature(t, verifier, signerIdentityKey, keyID, data, signature), "signature is not valid")
+}
+
+func isValidSignature(t *testing.T, verifier wallet.WalletInterface, signerIdentityKey, keyID string, data, signature []byte) bool {
+	t.Helper()
+
+	signerKey, err := ec.PublicKeyFromString(signerIdentityKey)
+	require.NoError(t, err, "invalid signer identity key")
+
+	sig, err := ec.ParseSignature(signature)
+	require.NoError(t, err, "signature is not a valid DER signature")
+
+	result, err := verifier.VerifySignature(&wallet.VerifySignatureArgs{
+		EncryptionArgs: wallet.EncryptionArgs{
+			ProtocolID: wallet.DefaultAuthProtocol,
+			KeyID:      keyID,
+			Counterparty: wallet.Counterparty{
+				Type:         wallet.CounterpartyTypeOther,
+				Counterparty: signerKey,
+			},
+		},
+		Data:      data,
+		Signature: *sig,
+	})
+	// the wallet reports signatures which do not match the data as an error
+	return err == nil && result.Valid
+}
+
+// compareRequestedCertificates compares the sets as encoded, nil and empty certifiers and types are sent the same way
+func compareRequestedCertificates(t *testing.T, expected, actual transport.RequestedCertificateSet) {
+	expectedJSON, err := json.Marshal(expected)
+	require.NoError(t, err)
+	actualJSON, err := json.Marshal(actual)
+	require.NoError(t, err)
+	require.JSONEq(t, string(expectedJSON), string(actualJSON))
 }
 
 func compareAuthMessage(t *testing.T, expected, actual *transport.AuthMessage) {
@@ -31,22 +90,12 @@ func compareAuthMessage(t *testing.T, expected, actual *transport.AuthMessage) {
 	require.Equal(t, expected.MessageType, actual.MessageType)
 	require.Equal(t, expected.IdentityKey, actual.IdentityKey)
 	require.Equal(t, expected.InitialNonce, actual.InitialNonce)
-	require.Equal(t, expected.RequestedCertificates, actual.RequestedCertificates)
+	compareRequestedCertificates(t, expected.RequestedCertificates, actual.RequestedCertificates)
 
 	comparePointers(t, expected.Nonce, actual.Nonce)
 	comparePointers(t, expected.YourNonce, actual.YourNonce)
 	comparePointers(t, expected.Payload, actual.Payload)
 	comparePointers(t, expected.Certificates, actual.Certificates)
-
-	if expected.Signature != nil {
-		hexStr := string(*expected.Signature)
-
-		s, err := hex.DecodeString(hexStr)
-		require.NoError(t, err)
-
-		encoded := []byte(hex.EncodeToString(s))
-		require.Equal(t, *expected.Signature, encoded)
-	}
 }
 
 func comparePointers(t *testing.T, expected, actual any) {
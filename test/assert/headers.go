@@ -4,7 +4,7 @@ import (
 	"net/http"
 	"testing"
 
-	walletFixtures "github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet/test"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
 	"github.com/stretchr/testify/require"
 )
 
@@ -12,8 +12,8 @@ var (
 	initialResponseHeaders = map[string]string{
 		"x-bsv-auth-version":      "0.1",
 		"x-bsv-auth-message-type": "initialResponse",
-		"x-bsv-auth-identity-key": walletFixtures.ServerIdentityKey,
-		"x-bsv-auth-your-nonce":   walletFixtures.ClientNonces[0],
+		"x-bsv-auth-identity-key": wallettest.ServerIdentityKey,
+		"x-bsv-auth-your-nonce":   wallettest.ClientNonces[0],
 	}
 )
 
@@ -22,7 +22,7 @@ func InitialResponseHeaders(t *testing.T, response *http.Response) {
 	for key, value := range initialResponseHeaders {
 		require.Equal(t, value, response.Header.Get(key))
 	}
-	require.NotNil(t, response.Header.Get("x-bsv-auth-signature"))
+	require.NotEmpty(t, response.Header.Get("x-bsv-auth-signature"))
 }
 
 // GeneralResponseHeaders checks if the response headers are correct for the general response.
@@ -31,16 +31,16 @@ func GeneralResponseHeaders(t *testing.T, response *http.Response, requestNumber
 		require.Equal(t, value, response.Header.Get(key))
 	}
 
-	require.NotNil(t, response.Header.Get("x-bsv-auth-request-id"))
-	require.NotNil(t, response.Header.Get("x-bsv-auth-signature"))
+	require.NotEmpty(t, response.Header.Get("x-bsv-auth-request-id"))
+	require.NotEmpty(t, response.Header.Get("x-bsv-auth-signature"))
 }
 
 func getGeneralResponseHeaders(i int) map[string]string {
 	return map[string]string{
 		"x-bsv-auth-version":      "0.1",
 		"x-bsv-auth-message-type": "general",
-		"x-bsv-auth-identity-key": walletFixtures.ServerIdentityKey,
-		"x-bsv-auth-your-nonce":   walletFixtures.ClientNonces[0],
-		"x-bsv-auth-nonce":        walletFixtures.DefaultNonces[1+i*2],
+		"x-bsv-auth-identity-key": wallettest.ServerIdentityKey,
+		"x-bsv-auth-your-nonce":   wallettest.ClientNonces[0],
+		"x-bsv-auth-nonce":        wallettest.DefaultNonces[1+i],
 	}
 }
@@ -1,11 +1,13 @@
 package assert
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"testing"
 
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
 	"github.com/stretchr/testify/require"
 )
 
@@ -23,38 +25,82 @@ func NotAuthorized(t *testing.T, res *http.Response) {
 
 // MissingRequestIDError checks if the response body contains the "missing request ID" error.
 func MissingRequestIDError(t *testing.T, res *http.Response) {
-	errString := readBody(t, res)
-	require.Equal(t, "missing request ID", errString)
+	errResponse := readErrorResponse(t, res)
+	require.Equal(t, transport.ErrCodeMissingRequestID, errResponse.Code)
+	require.Equal(t, "missing request ID", errResponse.Description)
 }
 
 // UnableToVerifySignatureError checks if the response body contains the "unable to verify signature" error.
 func UnableToVerifySignatureError(t *testing.T, res *http.Response) {
-	errString := readBody(t, res)
-	require.Contains(t, errString, "unable to verify signature")
+	errResponse := readErrorResponse(t, res)
+	require.Equal(t, transport.ErrCodeInvalidSignature, errResponse.Code)
+	require.Contains(t, errResponse.Description, "unable to verify signature")
 }
 
 // SessionNotFoundError check if the response body contain the "session not found" error.
 func SessionNotFoundError(t *testing.T, res *http.Response) {
-	errString := readBody(t, res)
-	require.Equal(t, "session not found", errString)
+	errResponse := readErrorResponse(t, res)
+	require.Equal(t, transport.ErrCodeSessionNotFound, errResponse.Code)
+	require.Equal(t, "session not found", errResponse.Description)
+}
+
+// IdentityKeyMismatchError check if the response body contain the "identity key does not match session" error.
+func IdentityKeyMismatchError(t *testing.T, res *http.Response) {
+	errResponse := readErrorResponse(t, res)
+	require.Equal(t, transport.ErrCodeIdentityKeyMismatch, errResponse.Code)
+	require.Equal(t, "identity key does not match session", errResponse.Description)
 }
 
 // SessionNotAuthenticatedError check if the response body contain the "session not authenticated" error.
 func SessionNotAuthenticatedError(t *testing.T, res *http.Response) {
-	errString := readBody(t, res)
-	require.Equal(t, "session not authenticated", errString)
+	errResponse := readErrorResponse(t, res)
+	require.Equal(t, transport.ErrCodeSessionNotAuthenticated, errResponse.Code)
+	require.Equal(t, "session not authenticated", errResponse.Description)
 }
 
 // MissingHeaderError check if the response body contain the "missing X header" error.
 func MissingHeaderError(t *testing.T, res *http.Response, header string) {
-	errString := readBody(t, res)
-	require.Equal(t, fmt.Sprintf("missing %s header", header), errString)
+	errResponse := readErrorResponse(t, res)
+	require.Equal(t, transport.ErrCodeMissingHeader, errResponse.Code)
+	require.Equal(t, fmt.Sprintf("missing %s header", header), errResponse.Description)
 }
 
 // InvalidHeaderError check if the response body contain the "invalid X header" error.
 func InvalidHeaderError(t *testing.T, res *http.Response, header string) {
-	errString := readBody(t, res)
-	require.Equal(t, fmt.Sprintf("invalid %s header", header), errString)
+	errResponse := readErrorResponse(t, res)
+	require.Equal(t, transport.ErrCodeInvalidHeader, errResponse.Code)
+	require.Equal(t, fmt.Sprintf("invalid %s header", header), errResponse.Description)
+}
+
+// RequestReplayedError check if the response body contain the "request ID already used" error.
+func RequestReplayedError(t *testing.T, res *http.Response) {
+	errResponse := readErrorResponse(t, res)
+	require.Equal(t, transport.ErrCodeRequestReplayed, errResponse.Code)
+	require.Equal(t, "request ID already used", errResponse.Description)
+}
+
+// CertificateRevokedError check if the response body contain the "certificate revoked" error.
+func CertificateRevokedError(t *testing.T, res *http.Response) {
+	errResponse := readErrorResponse(t, res)
+	require.Equal(t, transport.ErrCodeCertificateRevoked, errResponse.Code)
+	require.Contains(t, errResponse.Description, "certificate revoked")
+	require.Len(t, errResponse.Certificates, 1)
+	require.Equal(t, transport.ErrCodeCertificateRevoked, errResponse.Certificates[0].Code)
+}
+
+// ErrorResponseCode checks the response status code and the error code of the response body.
+func ErrorResponseCode(t *testing.T, res *http.Response, status int, code string) {
+	require.NotNil(t, res)
+	require.Equal(t, status, res.StatusCode)
+	errResponse := readErrorResponse(t, res)
+	require.Equal(t, code, errResponse.Code)
+}
+
+func readErrorResponse(t *testing.T, res *http.Response) transport.ErrorResponse {
+	var errResponse transport.ErrorResponse
+	require.NoError(t, json.Unmarshal([]byte(readBody(t, res)), &errResponse))
+	require.Equal(t, "error", errResponse.Status)
+	return errResponse
 }
 
 func readBody(t *testing.T, res *http.Response) string {
@@ -0,0 +1,62 @@
+package assert
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/stretchr/testify/require"
+)
+
+// SignedGeneralResponse asserts that the general response answers the signed request and that its signature
+// over the request ID, status and body is valid for the server identity key.
+// The payload is reconstructed like a BRC-104 client does and the signature is verified with the wallet of the client,
+// for which the server derives its signing key. The response body is restored, so it can still be read afterwards.
+func SignedGeneralResponse(t *testing.T, clientWallet wallet.WalletInterface, res *http.Response, serverIdentityKey string, req *http.Request) {
+	t.Helper()
+	require.NotNil(t, res)
+
+	requestID := res.Header.Get("x-bsv-auth-request-id")
+	require.NotEmpty(t, requestID, "response must echo the request ID")
+	require.Equal(t, req.Header.Get("x-bsv-auth-request-id"), requestID, "response must answer the signed request")
+
+	require.True(t, isValidGeneralResponseSignature(t, clientWallet, res, serverIdentityKey), "response signature is not valid")
+}
+
+// TamperedGeneralResponse asserts that the signature of the general response is not valid for the server identity key,
+// e.g. after its status or body was modified on the way to the client.
+func TamperedGeneralResponse(t *testing.T, clientWallet wallet.WalletInterface, res *http.Response, serverIdentityKey string) {
+	t.Helper()
+	require.NotNil(t, res)
+
+	require.False(t, isValidGeneralResponseSignature(t, clientWallet, res, serverIdentityKey), "tampered response signature must not be valid")
+}
+
+func isValidGeneralResponseSignature(t *testing.T, clientWallet wallet.WalletInterface, res *http.Response, serverIdentityKey string) bool {
+	t.Helper()
+
+	require.Equal(t, "general", res.Header.Get("x-bsv-auth-message-type"))
+	require.Equal(t, serverIdentityKey, res.Header.Get("x-bsv-auth-identity-key"))
+
+	nonce := res.Header.Get("x-bsv-auth-nonce")
+	yourNonce := res.Header.Get("x-bsv-auth-your-nonce")
+	require.NotEmpty(t, nonce, "response must contain the server nonce")
+	require.NotEmpty(t, yourNonce, "response must contain the client nonce")
+
+	signature, err := hex.DecodeString(res.Header.Get("x-bsv-auth-signature"))
+	require.NoError(t, err, "signature header must be hex encoded")
+
+	body, err := io.ReadAll(res.Body)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	res.Body = io.NopCloser(bytes.NewReader(body))
+
+	payload, err := utils.BuildSignedResponsePayload(res.Header.Get("x-bsv-auth-request-id"), res.StatusCode, res.Header, body)
+	require.NoError(t, err)
+
+	return isValidSignature(t, clientWallet, serverIdentityKey, nonce+" "+yourNonce, payload, signature)
+}
@@ -0,0 +1,101 @@
+// Package fixtures exposes the golden fixtures generated by cmd/gen-fixtures,
+// the same golden.json file is meant to be consumed by client implementations in other languages.
+package fixtures
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+)
+
+//go:embed golden.json
+var golden []byte
+
+// Identity is a test identity with its private key and identity (public) key, both hex encoded
+type Identity struct {
+	PrivateKey  string `json:"privateKey"`
+	IdentityKey string `json:"identityKey"`
+}
+
+// Handshake is the transcript of a handshake between the client and the server identities,
+// the messages are the exact bodies exchanged with the /.well-known/auth endpoint
+type Handshake struct {
+	InitialRequest  json.RawMessage   `json:"initialRequest"`
+	InitialResponse json.RawMessage   `json:"initialResponse"`
+	ResponseHeaders map[string]string `json:"responseHeaders"`
+}
+
+// BodylessRequest is a general request sent without body along with the payload covered by its signature.
+// Absent bodies are written as authcore.AbsentLength, POST, PUT, PATCH and DELETE requests with a JSON content type
+// are signed with the body "{}" though, see authcore.DefaultRequestBody.
+type BodylessRequest struct {
+	Name   string `json:"name"`
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	// Headers are the request headers set by the caller
+	Headers map[string]string `json:"headers,omitempty"`
+	// AuthHeaders are the x-bsv-auth headers signed by the client for the session of the handshake transcript,
+	// a fresh client wallet signs every request, so all of them use the first of ClientNonces
+	AuthHeaders map[string]string `json:"authHeaders"`
+	// Payload is the hex encoded payload covered by the signature
+	Payload string `json:"payload"`
+}
+
+// CanonicalJSONVector is a JSON document along with its canonical encoding, see authcore.Canonicalize
+type CanonicalJSONVector struct {
+	Name string `json:"name"`
+	// Input is the JSON document as sent by a peer
+	Input string `json:"input"`
+	// Canonical is the canonical encoding of the document covered by signatures
+	Canonical string `json:"canonical"`
+}
+
+// URLVector is the target of a request along with the canonical percent-encoding of its path and query
+// covered by signatures, see authcore.CanonicalPath
+type URLVector struct {
+	Name string `json:"name"`
+	// Target is the path and query as sent by a peer
+	Target string `json:"target"`
+	// Path and Query are the canonical path and query written into the signed payload
+	Path  string `json:"path"`
+	Query string `json:"query"`
+}
+
+// Golden is the set of fixtures derived from a single seed
+type Golden struct {
+	Seed      string   `json:"seed"`
+	Server    Identity `json:"server"`
+	Client    Identity `json:"client"`
+	Certifier Identity `json:"certifier"`
+
+	MockNonce    string   `json:"mockNonce"`
+	ServerNonces []string `json:"serverNonces"`
+	ClientNonces []string `json:"clientNonces"`
+
+	// Certificates are issued by the certifier to the client, signed with wallet.CertificateSignatureProtocol
+	// over the JSON encoding of the certificate with an empty signature
+	Certificates []certificates.VerifiableCertificate `json:"certificates"`
+	// CertificatesPayload is the canonical JSON encoding of Certificates covered by the signature of a certificateResponse
+	CertificatesPayload string `json:"certificatesPayload"`
+	// CanonicalJSON are the vectors of the canonical JSON encoding of signed payloads
+	CanonicalJSON []CanonicalJSONVector `json:"canonicalJSON"`
+	// URLs are the vectors of the canonical percent-encoding of signed paths and queries
+	URLs []URLVector `json:"urls"`
+
+	Handshake Handshake `json:"handshake"`
+
+	BodylessRequests []BodylessRequest `json:"bodylessRequests"`
+	// EmptyContentDigest is the SHA-256 Content-Digest header of an empty body
+	EmptyContentDigest string `json:"emptyContentDigest"`
+}
+
+// Load returns the golden fixtures
+func Load() (*Golden, error) {
+	var g Golden
+	if err := json.Unmarshal(golden, &g); err != nil {
+		return nil, fmt.Errorf("failed to decode golden fixtures, %w", err)
+	}
+	return &g, nil
+}
@@ -0,0 +1,126 @@
+package integrationtests
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+// accessLogBuffer collects the JSON access log lines written by the server
+type accessLogBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *accessLogBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *accessLogBuffer) lines(t *testing.T) []map[string]any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var lines []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(b.buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		lines = append(lines, entry)
+	}
+	return lines
+}
+
+func TestAuthMiddleware_AccessLog(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	logs := &accessLogBuffer{}
+	accessLogger := slog.New(slog.NewJSONHandler(logs, nil))
+
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(),
+		mocks.WithAccessLogger(accessLogger),
+		mocks.WithRoutePolicies(map[string]auth.RoutePolicy{"/health": {Exempt: true}})).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware()).
+		WithHandler("/health", mocks.PingHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	clientWallet := mocks.CreateClientMockWallet()
+	clientIdentityKey, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+
+	// when
+	response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+	authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+	require.NoError(t, err)
+	require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request))
+	response, err = server.SendGeneralRequest(t, request)
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+
+	request, err = http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+	require.NoError(t, err)
+	response, err = server.SendGeneralRequest(t, request)
+	require.NoError(t, err)
+	assert.NotAuthorized(t, response)
+
+	request, err = http.NewRequest(http.MethodGet, server.URL()+"/health", nil)
+	require.NoError(t, err)
+	response, err = server.SendGeneralRequest(t, request)
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+
+	// then
+	lines := logs.lines(t)
+	require.Len(t, lines, 4)
+
+	handshake, authenticated, rejected, exempt := lines[0], lines[1], lines[2], lines[3]
+
+	require.Equal(t, "request", handshake["msg"])
+	require.Equal(t, "INFO", handshake["level"])
+	require.Equal(t, auth.AccessOutcomeHandshake, handshake["outcome"])
+	require.Equal(t, auth.HandshakePath, handshake["path"])
+	require.Positive(t, handshake["bytesIn"])
+	require.Positive(t, handshake["bytesOut"])
+
+	require.Equal(t, auth.AccessOutcomeAuthenticated, authenticated["outcome"])
+	require.Equal(t, clientIdentityKey.PublicKey.ToDERHex(), authenticated["identityKey"])
+	require.Equal(t, "/ping", authenticated["path"])
+	require.InDelta(t, http.StatusOK, authenticated["status"], 0)
+	require.InDelta(t, len("Pong!"), authenticated["bytesOut"], 0)
+	for _, stage := range []string{"verify", "handler", "sign", "total"} {
+		require.Contains(t, authenticated, stage)
+	}
+
+	require.Equal(t, auth.AccessOutcomeRejected, rejected["outcome"])
+	require.Equal(t, transport.ErrCodeMissingRequestID, rejected["code"])
+	require.InDelta(t, http.StatusUnauthorized, rejected["status"], 0)
+	require.NotContains(t, rejected, "identityKey")
+
+	require.Equal(t, auth.AccessOutcomeExempt, exempt["outcome"])
+	require.InDelta(t, http.StatusOK, exempt["status"], 0)
+}
@@ -0,0 +1,178 @@
+package integrationtests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+// accountStore is an account resolver backed by a map, counting its lookups
+type accountStore struct {
+	mu       sync.Mutex
+	accounts map[string]string
+	lookups  int
+	err      error
+}
+
+func (s *accountStore) ResolveAccount(_ context.Context, identityKey string) (any, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lookups++
+	if s.err != nil {
+		return nil, s.err
+	}
+	account, ok := s.accounts[identityKey]
+	if !ok {
+		return nil, auth.ErrAccountNotFound
+	}
+	return account, nil
+}
+
+func (s *accountStore) set(identityKey, account string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if account != "" {
+		s.accounts[identityKey] = account
+	}
+	s.err = err
+}
+
+func (s *accountStore) lookupCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lookups
+}
+
+func TestAuthMiddleware_AccountResolver(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	clientWallet := mocks.CreateClientMockWallet()
+	clientIdentityKey, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+	identityKey := clientIdentityKey.PublicKey.ToDERHex()
+
+	setup := func(t *testing.T, store *accountStore, negativeCacheTTL time.Duration) (*mocks.MockHTTPServer, func(t *testing.T) *http.Response) {
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(),
+			mocks.WithAccountResolver(store, negativeCacheTTL)).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/account", mocks.AccountHandler().WithAuthMiddleware())
+		t.Cleanup(server.Close)
+
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+
+		send := func(t *testing.T) *http.Response {
+			request, err := http.NewRequest(http.MethodGet, server.URL()+"/account", nil)
+			require.NoError(t, err)
+			require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request))
+			response, err := server.SendGeneralRequest(t, request)
+			require.NoError(t, err)
+			return response
+		}
+		return server, send
+	}
+
+	readAccount := func(t *testing.T, response *http.Response) string {
+		t.Helper()
+		assert.ResponseOK(t, response)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+		return string(body)
+	}
+
+	t.Run("account is resolved once and cached", func(t *testing.T) {
+		// given
+		store := &accountStore{accounts: map[string]string{identityKey: "account-1"}}
+		_, send := setup(t, store, 0)
+
+		// when
+		first := readAccount(t, send(t))
+		second := readAccount(t, send(t))
+
+		// then
+		require.Equal(t, "account-1", first)
+		require.Equal(t, "account-1", second)
+		require.Equal(t, 1, store.lookupCount())
+	})
+
+	t.Run("identity without account is passed to the handler and cached", func(t *testing.T) {
+		// given
+		store := &accountStore{accounts: map[string]string{}}
+		_, send := setup(t, store, 0)
+
+		// when
+		first := send(t)
+		second := send(t)
+
+		// then
+		require.Equal(t, http.StatusNotFound, first.StatusCode)
+		require.Equal(t, http.StatusNotFound, second.StatusCode)
+		require.Equal(t, 1, store.lookupCount())
+	})
+
+	t.Run("invalidated identity is resolved again", func(t *testing.T) {
+		// given
+		store := &accountStore{accounts: map[string]string{}}
+		server, send := setup(t, store, time.Hour)
+		require.Equal(t, http.StatusNotFound, send(t).StatusCode)
+
+		// when
+		store.set(identityKey, "account-2", nil)
+		server.AuthMiddleware().InvalidateAccount(identityKey)
+
+		// then
+		require.Equal(t, "account-2", readAccount(t, send(t)))
+		require.Equal(t, 2, store.lookupCount())
+	})
+
+	t.Run("negative caching can be disabled", func(t *testing.T) {
+		// given
+		store := &accountStore{accounts: map[string]string{}}
+		_, send := setup(t, store, -1)
+
+		// when
+		send(t)
+		send(t)
+
+		// then
+		require.Equal(t, 2, store.lookupCount())
+	})
+
+	t.Run("resolver failure is rejected with 503 and not cached", func(t *testing.T) {
+		// given
+		store := &accountStore{accounts: map[string]string{identityKey: "account-3"}, err: errors.New("database unavailable")}
+		_, send := setup(t, store, 0)
+
+		// when
+		response := send(t)
+
+		// then
+		assert.ErrorResponseCode(t, response, http.StatusServiceUnavailable, transport.ErrCodeAccountUnavailable)
+
+		store.set("", "", nil)
+		require.Equal(t, "account-3", readAccount(t, send(t)))
+		require.Equal(t, 2, store.lookupCount())
+	})
+}
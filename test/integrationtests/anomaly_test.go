@@ -0,0 +1,154 @@
+package integrationtests
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_Anomalies(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	clientWallet := mocks.CreateClientMockWallet()
+	clientIdentity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+	identityKey := clientIdentity.PublicKey.ToDERHex()
+
+	// newSession returns a server applying the policy and a session of the client
+	newSession := func(t *testing.T, policy auth.AnomalyPolicy) (*mocks.MockHTTPServer, *transport.AuthMessage) {
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(),
+			mocks.WithAnomalyPolicy(policy)).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+		t.Cleanup(server.Close)
+
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+
+		return server, authMessage
+	}
+
+	// send sends a request to /ping over the session, with headers modified by the options
+	send := func(t *testing.T, server *mocks.MockHTTPServer, authMessage *transport.AuthMessage, opts ...func(m map[string]string)) *http.Response {
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+		require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request, opts...))
+
+		response, err := server.SendGeneralRequest(t, request)
+		require.NoError(t, err)
+		return response
+	}
+
+	t.Run("identity reaching the threshold is locked out", func(t *testing.T) {
+		// given
+		var mu sync.Mutex
+		var anomalies []auth.Anomaly
+		server, authMessage := newSession(t, auth.AnomalyPolicy{
+			IdentityThreshold: 3,
+			IdentityLockout:   10 * time.Minute,
+			OnAnomaly: func(anomaly auth.Anomaly) time.Duration {
+				mu.Lock()
+				defer mu.Unlock()
+				anomalies = append(anomalies, anomaly)
+				return anomaly.Lockout
+			},
+		})
+
+		// when
+		for range 3 {
+			assert.NotAuthorized(t, send(t, server, authMessage, mocks.WithWrongSignature))
+		}
+		response := send(t, server, authMessage)
+
+		// then
+		require.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+		require.Equal(t, "600", response.Header.Get("Retry-After"))
+		require.Equal(t, []auth.Anomaly{{Kind: auth.AnomalyKindIdentity, Key: identityKey, Failures: 3, Lockout: 10 * time.Minute}}, anomalies)
+	})
+
+	t.Run("anomaly is only reported without lockout", func(t *testing.T) {
+		// given
+		reported := 0
+		server, authMessage := newSession(t, auth.AnomalyPolicy{
+			IdentityThreshold: 2,
+			OnAnomaly: func(anomaly auth.Anomaly) time.Duration {
+				reported++
+				return anomaly.Lockout
+			},
+		})
+
+		// when
+		for range 2 {
+			assert.NotAuthorized(t, send(t, server, authMessage, mocks.WithWrongSignature))
+		}
+		response := send(t, server, authMessage)
+
+		// then
+		assert.ResponseOK(t, response)
+		require.Equal(t, 1, reported)
+	})
+
+	t.Run("verified request ends the streak", func(t *testing.T) {
+		// given
+		server, authMessage := newSession(t, auth.AnomalyPolicy{IdentityThreshold: 2, IdentityLockout: time.Minute})
+
+		// when
+		assert.NotAuthorized(t, send(t, server, authMessage, mocks.WithWrongSignature))
+		assert.ResponseOK(t, send(t, server, authMessage))
+		assert.NotAuthorized(t, send(t, server, authMessage, mocks.WithWrongSignature))
+		response := send(t, server, authMessage)
+
+		// then
+		assert.ResponseOK(t, response)
+	})
+
+	t.Run("address reaching the threshold is locked out of handshakes", func(t *testing.T) {
+		// given
+		server, _ := newSession(t, auth.AnomalyPolicy{AddressThreshold: 2, AddressLockout: time.Minute})
+
+		// when
+		for range 2 {
+			request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+			require.NoError(t, err)
+			response, err := server.SendGeneralRequest(t, request)
+			require.NoError(t, err)
+			assert.NotAuthorized(t, response)
+		}
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+	})
+
+	t.Run("policy hook overrides the lockout", func(t *testing.T) {
+		// given
+		server, authMessage := newSession(t, auth.AnomalyPolicy{
+			IdentityThreshold: 1,
+			IdentityLockout:   time.Minute,
+			OnAnomaly:         func(auth.Anomaly) time.Duration { return 0 },
+		})
+
+		// when
+		assert.NotAuthorized(t, send(t, server, authMessage, mocks.WithWrongSignature))
+		response := send(t, server, authMessage)
+
+		// then
+		assert.ResponseOK(t, response)
+	})
+}
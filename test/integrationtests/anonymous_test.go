@@ -0,0 +1,133 @@
+package integrationtests
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_AnonymousSessions(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	certificateRequirements := &transport.RequestedCertificateSet{
+		Certifiers: []string{trustedCertifier},
+		Types:      map[string][]string{ageVerificationType: {"age"}},
+	}
+	onCertificatesReceived := func(_ string, _ *[]certificates.VerifiableCertificate, _ *http.Request, _ http.ResponseWriter, next func()) {
+		next()
+	}
+
+	setup := func(t *testing.T, clientWallet wallet.WalletInterface, opts ...func(s *mocks.MockHTTPServer) *mocks.MockHTTPServer) (*transport.AuthMessage, func(t *testing.T, path string) *http.Response) {
+		opts = append(opts, mocks.WithCertificateRequirements(certificateRequirements, onCertificatesReceived))
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), opts...).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/anonymous", mocks.AnonymousHandler().WithAuthMiddleware()).
+			WithHandler("/admin", mocks.AnonymousHandler().WithAuthMiddleware())
+		t.Cleanup(server.Close)
+
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+
+		send := func(t *testing.T, path string) *http.Response {
+			request, err := http.NewRequest(http.MethodGet, server.URL()+path, nil)
+			require.NoError(t, err)
+			require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request))
+			response, err := server.SendGeneralRequest(t, request)
+			require.NoError(t, err)
+			return response
+		}
+		return authMessage, send
+	}
+
+	readBody := func(t *testing.T, response *http.Response) string {
+		t.Helper()
+		assert.ResponseOK(t, response)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+		return string(body)
+	}
+
+	anyoneKey, _ := wallet.AnyoneKey()
+	anyoneWallet := func() wallet.WalletInterface {
+		return wallettest.NewMockWallet(anyoneKey, wallettest.DefaultNonces...)
+	}
+
+	t.Run("anonymous session is not asked for certificates and is marked in the context", func(t *testing.T) {
+		// given
+		authMessage, send := setup(t, anyoneWallet(), mocks.WithAnonymousAccess(auth.AnonymousPolicy{}))
+
+		// when
+		response := send(t, "/anonymous")
+
+		// then
+		require.Empty(t, authMessage.RequestedCertificates.Certifiers)
+		require.Empty(t, authMessage.RequestedCertificates.Types)
+		require.Equal(t, "true", readBody(t, response))
+	})
+
+	t.Run("identified session still requires certificates", func(t *testing.T) {
+		// given
+		_, send := setup(t, mocks.CreateClientMockWallet(), mocks.WithAnonymousAccess(auth.AnonymousPolicy{}))
+
+		// when
+		response := send(t, "/anonymous")
+
+		// then
+		assert.NotAuthorized(t, response)
+	})
+
+	t.Run("anyone key requires certificates without anonymous access", func(t *testing.T) {
+		// given
+		authMessage, send := setup(t, anyoneWallet())
+
+		// when
+		response := send(t, "/anonymous")
+
+		// then
+		require.NotEmpty(t, authMessage.RequestedCertificates.Types)
+		assert.NotAuthorized(t, response)
+	})
+
+	t.Run("anonymous requests over the rate limit are rejected with 429", func(t *testing.T) {
+		// given
+		_, send := setup(t, anyoneWallet(), mocks.WithAnonymousAccess(auth.AnonymousPolicy{RateLimit: 2}))
+		require.Equal(t, "true", readBody(t, send(t, "/anonymous")))
+		require.Equal(t, "true", readBody(t, send(t, "/anonymous")))
+
+		// when
+		response := send(t, "/anonymous")
+
+		// then
+		assert.ErrorResponseCode(t, response, http.StatusTooManyRequests, transport.ErrCodeRateLimited)
+		require.Equal(t, "60", response.Header.Get("Retry-After"))
+	})
+
+	t.Run("route denying anonymous sessions responds with 403", func(t *testing.T) {
+		// given
+		_, send := setup(t, anyoneWallet(),
+			mocks.WithAnonymousAccess(auth.AnonymousPolicy{}),
+			mocks.WithRoutePolicies(map[string]auth.RoutePolicy{"/admin": {DenyAnonymous: true}}))
+
+		// when
+		response := send(t, "/admin")
+
+		// then
+		assert.ErrorResponseCode(t, response, http.StatusForbidden, transport.ErrCodeAnonymousNotAllowed)
+	})
+}
@@ -0,0 +1,155 @@
+package integrationtests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_Authorizer(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	serverIdentityKey := key.PubKey().ToDERHex()
+
+	clientIdentityKey, err := mocks.CreateClientMockWallet().GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+
+	// send authenticates a session with a server consulting the authorizer and sends a request to the ping endpoint
+	send := func(t *testing.T, authorizer auth.Authorizer) *http.Response {
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), mocks.WithAuthorizer(authorizer)).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+		t.Cleanup(server.Close)
+
+		clientWallet := mocks.CreateClientMockWallet()
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+
+		return ping(t, server, clientWallet, authMessage)
+	}
+
+	t.Run("allowed request is passed to the handler with the auth result", func(t *testing.T) {
+		// given
+		var input auth.AuthorizationInput
+		authorizer := auth.AuthorizerFunc(func(_ context.Context, in auth.AuthorizationInput) (auth.Decision, error) {
+			input = in
+			return auth.Decision{Allow: true}, nil
+		})
+
+		// when
+		response := send(t, authorizer)
+
+		// then
+		assert.ResponseOK(t, response)
+		require.True(t, input.Auth.Authenticated)
+		require.Equal(t, clientIdentityKey.PublicKey.ToDERHex(), input.Auth.IdentityKey)
+		require.Equal(t, http.MethodGet, input.Request.Method)
+		require.Equal(t, "/ping", input.Request.Path)
+	})
+
+	t.Run("denied request is rejected with the reason of the policy", func(t *testing.T) {
+		// given
+		authorizer := auth.AuthorizerFunc(func(context.Context, auth.AuthorizationInput) (auth.Decision, error) {
+			return auth.Decision{Reason: "ping is reserved for operators"}, nil
+		})
+
+		// when
+		response := send(t, authorizer)
+
+		// then
+		require.Equal(t, http.StatusForbidden, response.StatusCode)
+		require.Equal(t, "general", response.Header.Get("x-bsv-auth-message-type"))
+		require.Equal(t, serverIdentityKey, response.Header.Get("x-bsv-auth-identity-key"))
+		var errResponse transport.ErrorResponse
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&errResponse))
+		require.NoError(t, response.Body.Close())
+		require.Equal(t, transport.ErrCodeForbidden, errResponse.Code)
+		require.Equal(t, "ping is reserved for operators", errResponse.Description)
+	})
+
+	t.Run("failed evaluation is rejected as unavailable", func(t *testing.T) {
+		// given
+		authorizer := auth.AuthorizerFunc(func(context.Context, auth.AuthorizationInput) (auth.Decision, error) {
+			return auth.Decision{}, errors.New("policy engine unreachable")
+		})
+
+		// when
+		response := send(t, authorizer)
+
+		// then
+		assert.ErrorResponseCode(t, response, http.StatusServiceUnavailable, transport.ErrCodeAuthorizationUnavailable)
+	})
+
+	opaResults := map[string]struct {
+		result string
+		status int
+		code   string
+	}{
+		"boolean allow": {
+			result: `{"result":true}`,
+			status: http.StatusOK,
+		},
+		"boolean deny": {
+			result: `{"result":false}`,
+			status: http.StatusForbidden,
+			code:   transport.ErrCodeForbidden,
+		},
+		"decision document deny": {
+			result: `{"result":{"allow":false,"reason":"denied by rego"}}`,
+			status: http.StatusForbidden,
+			code:   transport.ErrCodeForbidden,
+		},
+		"undefined result": {
+			result: `{}`,
+			status: http.StatusForbidden,
+			code:   transport.ErrCodeForbidden,
+		},
+		"unexpected result": {
+			result: `{"result":"yes"}`,
+			status: http.StatusServiceUnavailable,
+			code:   transport.ErrCodeAuthorizationUnavailable,
+		},
+	}
+
+	for name, test := range opaResults {
+		t.Run("OPA "+name, func(t *testing.T) {
+			// given
+			var query struct {
+				Input auth.AuthorizationInput `json:"input"`
+			}
+			opa := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&query))
+				_, _ = w.Write([]byte(test.result))
+			}))
+			defer opa.Close()
+
+			// when
+			response := send(t, &auth.OPAAuthorizer{URL: opa.URL + "/v1/data/httpapi/authz"})
+
+			// then
+			require.Equal(t, "/ping", query.Input.Request.Path)
+			require.Equal(t, clientIdentityKey.PublicKey.ToDERHex(), query.Input.Auth.IdentityKey)
+			if test.code == "" {
+				assert.ResponseOK(t, response)
+				return
+			}
+			assert.ErrorResponseCode(t, response, test.status, test.code)
+		})
+	}
+}
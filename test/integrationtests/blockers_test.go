@@ -0,0 +1,276 @@
+package integrationtests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+// lockedBuffer is a buffer the middleware writes to while the test reads it
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestAuthMiddleware_Blockers(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	clientWallet := mocks.CreateClientMockWallet()
+	clientIdentity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+	identityKey := clientIdentity.PublicKey.ToDERHex()
+
+	newServer := func(t *testing.T, policy auth.BlockPolicy) *mocks.MockHTTPServer {
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(),
+			mocks.WithBlockPolicy(policy)).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	handshake := func(t *testing.T, server *mocks.MockHTTPServer) (*http.Response, *transport.AuthMessage) {
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+		require.NoError(t, err)
+		if response.StatusCode != http.StatusOK {
+			return response, nil
+		}
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+		return response, authMessage
+	}
+
+	ping := func(t *testing.T, server *mocks.MockHTTPServer, authMessage *transport.AuthMessage, opts ...func(m map[string]string)) *http.Response {
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+		require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request, opts...))
+		response, err := server.SendGeneralRequest(t, request)
+		require.NoError(t, err)
+		return response
+	}
+
+	t.Run("verification failures are reported in the fail2ban format", func(t *testing.T) {
+		// given
+		var log lockedBuffer
+		server := newServer(t, auth.BlockPolicy{Sink: auth.NewFail2banSink(&log), VerificationFailures: 3})
+		response, authMessage := handshake(t, server)
+		assert.ResponseOK(t, response)
+
+		// when
+		for range 3 {
+			assert.NotAuthorized(t, ping(t, server, authMessage, mocks.WithWrongSignature))
+		}
+
+		// then
+		lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+		require.Len(t, lines, 1)
+		failRegex := regexp.MustCompile(strings.Replace(auth.Fail2banFailRegex, "<HOST>", `(?P<host>\S+)`, 1))
+		match := failRegex.FindStringSubmatch(lines[0])
+		require.NotNil(t, match, lines[0])
+		require.Equal(t, "127.0.0.1", match[1])
+		require.Contains(t, lines[0], "reason="+auth.BlockReasonVerificationFailures)
+		require.Contains(t, lines[0], "identity="+identityKey)
+		require.Contains(t, lines[0], "count=3 window=60s")
+	})
+
+	t.Run("handshake flood is reported in the CrowdSec format", func(t *testing.T) {
+		// given
+		var log lockedBuffer
+		server := newServer(t, auth.BlockPolicy{Sink: auth.NewCrowdSecSink(&log), Handshakes: 5})
+
+		// when
+		for range 5 {
+			response, _ := handshake(t, server)
+			assert.ResponseOK(t, response)
+		}
+
+		// then
+		var line map[string]any
+		require.NoError(t, json.Unmarshal([]byte(log.String()), &line))
+		require.Equal(t, auth.BlockerProgram, line["program"])
+		require.Equal(t, auth.BlockReasonHandshakeFlood, line["reason"])
+		require.Equal(t, "127.0.0.1", line["source_ip"])
+		require.NotContains(t, line, "identity_key")
+		require.EqualValues(t, 5, line["count"])
+		require.EqualValues(t, 60, line["window_seconds"])
+	})
+
+	t.Run("peers below the thresholds are not reported", func(t *testing.T) {
+		// given
+		var mu sync.Mutex
+		var reported []auth.BlockEvent
+		server := newServer(t, auth.BlockPolicy{
+			Sink: auth.BlockSinkFunc(func(event auth.BlockEvent) {
+				mu.Lock()
+				defer mu.Unlock()
+				reported = append(reported, event)
+			}),
+			VerificationFailures: 2,
+			Handshakes:           2,
+		})
+
+		// when
+		response, authMessage := handshake(t, server)
+		assert.ResponseOK(t, response)
+		assert.NotAuthorized(t, ping(t, server, authMessage, mocks.WithWrongSignature))
+		assert.ResponseOK(t, ping(t, server, authMessage))
+
+		// then
+		mu.Lock()
+		defer mu.Unlock()
+		require.Empty(t, reported)
+	})
+
+	t.Run("blocked addresses are rejected until the feed lifts the block", func(t *testing.T) {
+		// given
+		blocklist, err := auth.NewBlocklist("10.0.0.0/8")
+		require.NoError(t, err)
+		server := newServer(t, auth.BlockPolicy{Blocklist: blocklist})
+		response, authMessage := handshake(t, server)
+		assert.ResponseOK(t, response)
+		admin := httptest.NewServer(server.AuthMiddleware().BlocklistHandler())
+		defer admin.Close()
+
+		// when
+		status := putBlocklist(t, admin.URL, "# decisions\n203.0.113.7 ban\n127.0.0.0/8\n")
+
+		// then
+		require.Equal(t, http.StatusNoContent, status)
+		response = ping(t, server, authMessage)
+		require.Equal(t, http.StatusForbidden, response.StatusCode)
+		var errResponse transport.ErrorResponse
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&errResponse))
+		require.Equal(t, transport.ErrCodeBlocked, errResponse.Code)
+
+		response, _ = handshake(t, server)
+		require.Equal(t, http.StatusForbidden, response.StatusCode)
+
+		listed, err := http.Get(admin.URL)
+		require.NoError(t, err)
+		body, err := io.ReadAll(listed.Body)
+		require.NoError(t, err)
+		require.NoError(t, listed.Body.Close())
+		require.Equal(t, "127.0.0.0/8\n203.0.113.7\n", string(body))
+
+		// when
+		status = putBlocklist(t, admin.URL, "")
+
+		// then
+		require.Equal(t, http.StatusNoContent, status)
+		assert.ResponseOK(t, ping(t, server, authMessage))
+	})
+
+	t.Run("invalid feed leaves the blocklist unchanged", func(t *testing.T) {
+		// given
+		server := newServer(t, auth.BlockPolicy{})
+		admin := httptest.NewServer(server.AuthMiddleware().BlocklistHandler())
+		defer admin.Close()
+		require.Equal(t, http.StatusNoContent, putBlocklist(t, admin.URL, "192.0.2.1\n"))
+
+		// when
+		status := putBlocklist(t, admin.URL, "192.0.2.2\nnot-an-address\n")
+
+		// then
+		require.Equal(t, http.StatusBadRequest, status)
+		require.Equal(t, []string{"192.0.2.1/32"}, prefixStrings(server.AuthMiddleware().Blocklist()))
+	})
+}
+
+func TestBlocklist(t *testing.T) {
+	tests := map[string]struct {
+		entries  []string
+		blocked  []string
+		allowed  []string
+		expected error
+	}{
+		"addresses and networks": {
+			entries: []string{"192.0.2.1", "198.51.100.0/24", "2001:db8::/32"},
+			blocked: []string{"192.0.2.1", "198.51.100.77", "2001:db8::1", "::ffff:192.0.2.1"},
+			allowed: []string{"192.0.2.2", "198.51.101.1", "2001:db9::1"},
+		},
+		"IPv4-mapped entries block the IPv4 address": {
+			entries: []string{"::ffff:192.0.2.1", "::ffff:198.51.100.0/120"},
+			blocked: []string{"192.0.2.1", "198.51.100.1"},
+		},
+		"invalid address": {
+			entries:  []string{"192.0.2.256"},
+			expected: auth.ErrInvalidBlocklist,
+		},
+		"invalid network": {
+			entries:  []string{"192.0.2.0/33"},
+			expected: auth.ErrInvalidBlocklist,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// when
+			blocklist, err := auth.NewBlocklist(test.entries...)
+
+			// then
+			if test.expected != nil {
+				require.ErrorIs(t, err, test.expected)
+				return
+			}
+			require.NoError(t, err)
+			for _, addr := range test.blocked {
+				require.True(t, blocklist.Contains(netipAddr(t, addr)), addr)
+			}
+			for _, addr := range test.allowed {
+				require.False(t, blocklist.Contains(netipAddr(t, addr)), addr)
+			}
+		})
+	}
+}
+
+func putBlocklist(t *testing.T, url, feed string) int {
+	request, err := http.NewRequest(http.MethodPut, url, strings.NewReader(feed))
+	require.NoError(t, err)
+	response, err := http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+	return response.StatusCode
+}
+
+func prefixStrings(blocklist *auth.Blocklist) []string {
+	var list []string
+	for _, prefix := range blocklist.List() {
+		list = append(list, prefix.String())
+	}
+	return list
+}
+
+func netipAddr(t *testing.T, s string) netip.Addr {
+	addr, err := netip.ParseAddr(s)
+	require.NoError(t, err)
+	return addr
+}
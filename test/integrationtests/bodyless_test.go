@@ -0,0 +1,78 @@
+package integrationtests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_BodylessMethods(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	serverIdentityKey := key.PubKey().ToDERHex()
+
+	tests := map[string]struct {
+		serverOptions []func(s *mocks.MockHTTPServer) *mocks.MockHTTPServer
+		send          func(c *client.Client, ctx context.Context, path string) (*http.Response, error)
+		body          string
+		encrypted     bool
+	}{
+		"GET": {
+			send: (*client.Client).Get,
+			body: http.MethodGet,
+		},
+		"HEAD": {
+			send: (*client.Client).Head,
+			body: "",
+		},
+		"DELETE": {
+			send: (*client.Client).Delete,
+			body: http.MethodDelete,
+		},
+		"HEAD with payload padding and encryption": {
+			serverOptions: []func(s *mocks.MockHTTPServer) *mocks.MockHTTPServer{mocks.WithPayloadPadding, mocks.WithPayloadEncryption},
+			send:          (*client.Client).Head,
+			body:          "",
+			encrypted:     true,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), test.serverOptions...).
+				WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+				WithHandler("/items/1", mocks.BodylessHandler().WithAuthMiddleware())
+			defer server.Close()
+
+			clientWallet := mocks.CreateClientMockWallet()
+			authClient, err := client.New(client.Config{
+				Wallet:            clientWallet,
+				BaseURL:           server.URL(),
+				PayloadEncryption: test.encrypted,
+				PayloadPadding:    test.encrypted,
+			})
+			require.NoError(t, err)
+
+			// when
+			response, err := test.send(authClient, context.Background(), "/items/1")
+			require.NoError(t, err)
+
+			// then
+			assert.ResponseOK(t, response)
+			assert.SignedGeneralResponse(t, clientWallet, response, serverIdentityKey, response.Request)
+			body, err := io.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.NoError(t, response.Body.Close())
+			require.Equal(t, test.body, string(body))
+		})
+	}
+}
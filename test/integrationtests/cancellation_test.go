@@ -0,0 +1,68 @@
+package integrationtests
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_ClientDisconnect(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	serverWallet := &hangingWallet{WalletInterface: mocks.CreateServerMockWallet(key), release: make(chan struct{})}
+	defer close(serverWallet.release)
+
+	var handlerCalls atomic.Int32
+	logs := &accessLogBuffer{}
+	server := mocks.CreateMockHTTPServer(serverWallet, session.NewMemoryManager(),
+		mocks.WithWalletTimeouts(transport.WalletTimeouts{VerifySignature: time.Minute}),
+		mocks.WithAccessLogger(slog.New(slog.NewJSONHandler(logs, nil)))).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/ping", mocks.CountingHandler(&handlerCalls).WithAuthMiddleware())
+	defer server.Close()
+
+	clientWallet := mocks.CreateClientMockWallet()
+	response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+	authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+	require.NoError(t, err)
+
+	serverWallet.verifySignature.Store(true)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL()+"/ping", nil)
+	require.NoError(t, err)
+	require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request))
+
+	// when
+	_, err = http.DefaultClient.Do(request)
+
+	// then
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Eventually(t, func() bool { return len(logs.lines(t)) == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	canceled := logs.lines(t)[1]
+	require.Equal(t, auth.AccessOutcomeCanceled, canceled["outcome"])
+	require.Equal(t, transport.ErrCodeRequestCanceled, canceled["code"])
+	// the response was neither signed nor handled
+	require.Equal(t, map[string]any{
+		"noncesCreated": 0.0, "noncesVerified": 1.0, "signaturesCreated": 0.0, "signaturesVerified": 1.0,
+		"encryptions": 0.0, "decryptions": 0.0,
+	}, canceled["wallet"])
+	require.Zero(t, handlerCalls.Load())
+}
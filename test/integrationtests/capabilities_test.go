@@ -0,0 +1,70 @@
+package integrationtests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_Capabilities(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	clientWallet := mocks.CreateClientMockWallet()
+	clientIdentity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		serverOptions []func(s *mocks.MockHTTPServer) *mocks.MockHTTPServer
+		config        client.Config
+		expected      []transport.Capability
+	}{
+		"features supported by both sides are enabled": {
+			serverOptions: []func(s *mocks.MockHTTPServer) *mocks.MockHTTPServer{mocks.WithPayloadEncryption, mocks.WithPayloadPadding},
+			config:        client.Config{PayloadEncryption: true, PayloadPadding: true},
+			expected:      []transport.Capability{transport.CapabilityHeartbeat, transport.CapabilityTrailerSignature, transport.CapabilityPayloadEncryption, transport.CapabilityPayloadPadding},
+		},
+		"features not requested by the client are disabled": {
+			serverOptions: []func(s *mocks.MockHTTPServer) *mocks.MockHTTPServer{mocks.WithPayloadEncryption, mocks.WithPayloadPadding},
+			config:        client.Config{PayloadPadding: true},
+			expected:      []transport.Capability{transport.CapabilityHeartbeat, transport.CapabilityTrailerSignature, transport.CapabilityPayloadPadding},
+		},
+		"features not supported by the server are disabled": {
+			config:   client.Config{PayloadPadding: true},
+			expected: []transport.Capability{transport.CapabilityHeartbeat, transport.CapabilityTrailerSignature},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			sessionManager := session.NewMemoryManager()
+			server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), sessionManager, test.serverOptions...).
+				WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+			defer server.Close()
+
+			test.config.Wallet, test.config.BaseURL = clientWallet, server.URL()
+			authClient, err := client.New(test.config)
+			require.NoError(t, err)
+
+			// when
+			err = authClient.Handshake(context.Background())
+
+			// then
+			require.NoError(t, err)
+			require.Equal(t, test.expected, authClient.Capabilities())
+
+			peerSession := sessionManager.GetSession(clientIdentity.PublicKey.ToDERHex())
+			require.NotNil(t, peerSession)
+			require.Equal(t, test.expected, peerSession.Capabilities)
+		})
+	}
+}
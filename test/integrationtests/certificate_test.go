@@ -9,19 +9,27 @@ import (
 	"strconv"
 	"testing"
 
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet"
-	walletFixtures "github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet/test"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/chaintracker"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
 	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
 	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	"github.com/bsv-blockchain/go-sdk/chainhash"
 	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 	"github.com/stretchr/testify/require"
 )
 
-const trustedCertifier = "02certifieridentitykey00000000000000000000000000000000000000000000000"
+const (
+	trustedCertifier = "0279be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798"
+	// ageVerificationType is the base64 type ID of the age verification certificates
+	ageVerificationType = "9ZkJfGmbXcggy2CL1Eb8wX1hv2WwVnGf4TTZ1FHrPFU="
+)
 
 func TestAuthMiddleware_CertificateHandling(t *testing.T) {
-	key, err := ec.PrivateKeyFromHex(walletFixtures.ServerPrivateKeyHex)
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
 	require.NoError(t, err)
 	sessionManager := mocks.NewMockableSessionManager()
 	serverWallet := mocks.CreateServerMockWallet(key)
@@ -30,11 +38,11 @@ func TestAuthMiddleware_CertificateHandling(t *testing.T) {
 		certificateRequirements := &transport.RequestedCertificateSet{
 			Certifiers: []string{trustedCertifier},
 			Types: map[string][]string{
-				"age-verification": {"age", "country"},
+				ageVerificationType: {"age", "country"},
 			},
 		}
 
-		onCertificatesReceived := func(senderPublicKey string, certs *[]wallet.VerifiableCertificate, req *http.Request, res http.ResponseWriter, next func()) {
+		onCertificatesReceived := func(senderPublicKey string, certs *[]certificates.VerifiableCertificate, req *http.Request, res http.ResponseWriter, next func()) {
 
 			if certs != nil && len(*certs) > 0 && next != nil {
 				next()
@@ -64,8 +72,8 @@ func TestAuthMiddleware_CertificateHandling(t *testing.T) {
 
 		require.NotNil(t, authMessage.RequestedCertificates, "RequestedCertificates should not be nil")
 		require.NotEmpty(t, authMessage.RequestedCertificates.Types, "Certificate types should not be empty")
-		require.Contains(t, authMessage.RequestedCertificates.Types, "age-verification",
-			"Certificate types should contain age-verification")
+		require.Contains(t, authMessage.RequestedCertificates.Types, ageVerificationType,
+			"Certificate types should contain the age verification type")
 		require.Contains(t, authMessage.RequestedCertificates.Certifiers, trustedCertifier,
 			"Certifiers should contain the trusted certifier")
 	})
@@ -74,11 +82,11 @@ func TestAuthMiddleware_CertificateHandling(t *testing.T) {
 		certificateRequirements := &transport.RequestedCertificateSet{
 			Certifiers: []string{trustedCertifier},
 			Types: map[string][]string{
-				"age-verification": {"age", "country"},
+				ageVerificationType: {"age", "country"},
 			},
 		}
 
-		onCertificatesReceived := func(senderPublicKey string, certs *[]wallet.VerifiableCertificate, req *http.Request, res http.ResponseWriter, next func()) {
+		onCertificatesReceived := func(senderPublicKey string, certs *[]certificates.VerifiableCertificate, req *http.Request, res http.ResponseWriter, next func()) {
 
 			if certs != nil && len(*certs) > 0 && next != nil {
 				next()
@@ -117,12 +125,12 @@ func TestAuthMiddleware_CertificateHandling(t *testing.T) {
 		certificateRequirements := &transport.RequestedCertificateSet{
 			Certifiers: []string{trustedCertifier},
 			Types: map[string][]string{
-				"age-verification": {"age", "country"},
+				ageVerificationType: {"age", "country"},
 			},
 		}
 
 		var receivedCertificateFlag bool
-		onCertificatesReceived := func(senderPublicKey string, certs *[]wallet.VerifiableCertificate, req *http.Request, res http.ResponseWriter, next func()) {
+		onCertificatesReceived := func(senderPublicKey string, certs *[]certificates.VerifiableCertificate, req *http.Request, res http.ResponseWriter, next func()) {
 			receivedCertificateFlag = true
 
 			if certs != nil && len(*certs) > 0 && next != nil {
@@ -150,10 +158,10 @@ func TestAuthMiddleware_CertificateHandling(t *testing.T) {
 		clientIdentityKey, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
 		require.NoError(t, err)
 
-		certificates := []wallet.VerifiableCertificate{
+		certs := []certificates.VerifiableCertificate{
 			{
-				Certificate: wallet.Certificate{
-					Type:         "age-verification",
+				Certificate: certificates.Certificate{
+					Type:         ageVerificationType,
 					SerialNumber: "12345",
 					Subject:      clientIdentityKey.PublicKey.ToDERHex(),
 					Certifier:    trustedCertifier,
@@ -178,10 +186,10 @@ func TestAuthMiddleware_CertificateHandling(t *testing.T) {
 			IdentityKey:  clientIdentityKey.PublicKey.ToDERHex(),
 			Nonce:        &nonce,
 			YourNonce:    &authMessage.InitialNonce,
-			Certificates: &certificates,
+			Certificates: &certs,
 		}
 
-		certBytes, err := json.Marshal(certificates)
+		certBytes, err := authcore.CertificatesPayload(certs)
 		require.NoError(t, err)
 
 		serverKey, err := ec.PublicKeyFromString(authMessage.IdentityKey)
@@ -219,6 +227,7 @@ func TestAuthMiddleware_CertificateHandling(t *testing.T) {
 
 		require.Equal(t, http.StatusOK, certResponse.StatusCode, "Certificate submission should return 200 OK")
 		require.True(t, receivedCertificateFlag, "Certificate received callback should be called")
+		require.Equal(t, certs, sessionManager.GetSession(authMessage.InitialNonce).Certificates)
 
 		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
 		require.NoError(t, err)
@@ -236,11 +245,11 @@ func TestAuthMiddleware_InvalidCertificateHandling(t *testing.T) {
 	certificateRequirements := &transport.RequestedCertificateSet{
 		Certifiers: []string{trustedCertifier},
 		Types: map[string][]string{
-			"age-verification": {"age", "country"},
+			ageVerificationType: {"age", "country"},
 		},
 	}
 
-	onCertificatesReceived := func(senderPublicKey string, certs *[]wallet.VerifiableCertificate, req *http.Request, res http.ResponseWriter, next func()) {
+	onCertificatesReceived := func(senderPublicKey string, certs *[]certificates.VerifiableCertificate, req *http.Request, res http.ResponseWriter, next func()) {
 		if certs == nil || len(*certs) == 0 {
 			res.Header().Set("Content-Type", "text/plain")
 			res.WriteHeader(http.StatusForbidden)
@@ -257,7 +266,7 @@ func TestAuthMiddleware_InvalidCertificateHandling(t *testing.T) {
 			return
 		}
 
-		if cert.Certificate.Type != "age-verification" {
+		if cert.Certificate.Type != ageVerificationType {
 			res.Header().Set("Content-Type", "text/plain")
 			res.WriteHeader(http.StatusForbidden)
 			res.Write([]byte("Wrong certificate type"))
@@ -284,7 +293,7 @@ func TestAuthMiddleware_InvalidCertificateHandling(t *testing.T) {
 	}
 
 	sessionManager := mocks.NewMockableSessionManager()
-	key, err := ec.PrivateKeyFromHex(walletFixtures.ServerPrivateKeyHex)
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
 	require.NoError(t, err)
 	serverWallet := mocks.CreateServerMockWallet(key)
 	server := mocks.CreateMockHTTPServer(serverWallet, sessionManager, mocks.WithLogger, mocks.WithCertificateRequirements(certificateRequirements, onCertificatesReceived)).
@@ -299,15 +308,15 @@ func TestAuthMiddleware_InvalidCertificateHandling(t *testing.T) {
 
 	testCases := []struct {
 		name           string
-		certificates   []wallet.VerifiableCertificate
+		certificates   []certificates.VerifiableCertificate
 		expectedStatus int
 	}{
 		{
 			name: "wrong certifier",
-			certificates: []wallet.VerifiableCertificate{
+			certificates: []certificates.VerifiableCertificate{
 				{
-					Certificate: wallet.Certificate{
-						Type:         "age-verification",
+					Certificate: certificates.Certificate{
+						Type:         ageVerificationType,
 						SerialNumber: "12345",
 						Subject:      clientIdentityKey.PublicKey.ToDERHex(),
 						Certifier:    "wrong-certifier-key",
@@ -322,9 +331,9 @@ func TestAuthMiddleware_InvalidCertificateHandling(t *testing.T) {
 		},
 		{
 			name: "wrong certificate type",
-			certificates: []wallet.VerifiableCertificate{
+			certificates: []certificates.VerifiableCertificate{
 				{
-					Certificate: wallet.Certificate{
+					Certificate: certificates.Certificate{
 						Type:         "wrong-type",
 						SerialNumber: "12345",
 						Subject:      clientIdentityKey.PublicKey.ToDERHex(),
@@ -340,10 +349,10 @@ func TestAuthMiddleware_InvalidCertificateHandling(t *testing.T) {
 		},
 		{
 			name: "missing age field",
-			certificates: []wallet.VerifiableCertificate{
+			certificates: []certificates.VerifiableCertificate{
 				{
-					Certificate: wallet.Certificate{
-						Type:         "age-verification",
+					Certificate: certificates.Certificate{
+						Type:         ageVerificationType,
 						SerialNumber: "12345",
 						Subject:      clientIdentityKey.PublicKey.ToDERHex(),
 						Certifier:    trustedCertifier,
@@ -358,10 +367,10 @@ func TestAuthMiddleware_InvalidCertificateHandling(t *testing.T) {
 		},
 		{
 			name: "underage certificate",
-			certificates: []wallet.VerifiableCertificate{
+			certificates: []certificates.VerifiableCertificate{
 				{
-					Certificate: wallet.Certificate{
-						Type:         "age-verification",
+					Certificate: certificates.Certificate{
+						Type:         ageVerificationType,
 						SerialNumber: "12345",
 						Subject:      clientIdentityKey.PublicKey.ToDERHex(),
 						Certifier:    trustedCertifier,
@@ -376,7 +385,7 @@ func TestAuthMiddleware_InvalidCertificateHandling(t *testing.T) {
 		},
 		{
 			name:           "empty certificates",
-			certificates:   []wallet.VerifiableCertificate{},
+			certificates:   []certificates.VerifiableCertificate{},
 			expectedStatus: http.StatusForbidden,
 		},
 	}
@@ -398,3 +407,86 @@ func TestAuthMiddleware_InvalidCertificateHandling(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthMiddleware_CertificateRevocation(t *testing.T) {
+	// given
+	certificateRequirements := &transport.RequestedCertificateSet{
+		Certifiers: []string{trustedCertifier},
+		Types: map[string][]string{
+			ageVerificationType: {"age", "country"},
+		},
+	}
+
+	onCertificatesReceived := func(senderPublicKey string, certs *[]certificates.VerifiableCertificate, req *http.Request, res http.ResponseWriter, next func()) {
+		next()
+	}
+
+	revokedTxID := chainhash.DoubleHashH([]byte("revoked"))
+	validTxID := chainhash.DoubleHashH([]byte("valid"))
+	tracker := chaintracker.NewInMemory()
+	tracker.MarkSpent(revokedTxID, 0)
+
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	serverWallet := mocks.CreateServerMockWallet(key)
+	server := mocks.CreateMockHTTPServer(serverWallet, mocks.NewMockableSessionManager(),
+		mocks.WithCertificateRequirements(certificateRequirements, onCertificatesReceived),
+		mocks.WithRevocationTracker(tracker)).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	tests := map[string]struct {
+		revocationOutpoint string
+		expectedStatus     int
+		revoked            bool
+	}{
+		"certificate with unspent revocation outpoint is accepted": {
+			revocationOutpoint: validTxID.String() + ".0",
+			expectedStatus:     http.StatusOK,
+		},
+		"certificate with spent revocation outpoint is rejected": {
+			revocationOutpoint: revokedTxID.String() + ".0",
+			expectedStatus:     http.StatusUnauthorized,
+			revoked:            true,
+		},
+		"certificate with malformed revocation outpoint is rejected": {
+			revocationOutpoint: "not-an-outpoint",
+			expectedStatus:     http.StatusUnauthorized,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			clientWallet := mocks.CreateClientMockWallet()
+			clientIdentityKey, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+			require.NoError(t, err)
+
+			certificates := []certificates.VerifiableCertificate{
+				{
+					Certificate: certificates.Certificate{
+						Type:               ageVerificationType,
+						SerialNumber:       name,
+						Subject:            clientIdentityKey.PublicKey.ToDERHex(),
+						Certifier:          trustedCertifier,
+						RevocationOutpoint: test.revocationOutpoint,
+						Fields: map[string]any{
+							"age":     "21",
+							"country": "Switzerland",
+						},
+					},
+				},
+			}
+
+			// when
+			response, err := server.SendCertificateResponse(t, clientWallet, &certificates)
+
+			// then
+			require.NoError(t, err)
+			require.Equal(t, test.expectedStatus, response.StatusCode)
+			if test.revoked {
+				assert.CertificateRevokedError(t, response)
+			}
+		})
+	}
+}
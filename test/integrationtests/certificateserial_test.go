@@ -0,0 +1,177 @@
+package integrationtests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_CertificateSerialNumbers(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	certificateRequirements := transport.NewRequestedCertificateSet(trustedCertifier).AddType(ageVerificationType, "age")
+
+	// newServer returns a server which accepts all certificates and counts the callback calls
+	newServer := func(callbackCalls *int) (*mocks.MockHTTPServer, *mocks.MockableSessionManager) {
+		onCertificatesReceived := func(_ string, _ *[]certificates.VerifiableCertificate, _ *http.Request, _ http.ResponseWriter, next func()) {
+			*callbackCalls++
+			next()
+		}
+
+		sessionManager := mocks.NewMockableSessionManager()
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), sessionManager,
+			mocks.WithCertificateRequirements(certificateRequirements, onCertificatesReceived)).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+		return server, sessionManager
+	}
+
+	handshake := func(t *testing.T, server *mocks.MockHTTPServer, clientWallet wallet.WalletInterface) *transport.AuthMessage {
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+		return authMessage
+	}
+
+	certificate := func(t *testing.T, clientWallet wallet.WalletInterface, serialNumber, age string) certificates.VerifiableCertificate {
+		identityKey, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+		require.NoError(t, err)
+
+		return certificates.VerifiableCertificate{
+			Certificate: certificates.Certificate{
+				Type:         ageVerificationType,
+				SerialNumber: serialNumber,
+				Subject:      identityKey.PublicKey.ToDERHex(),
+				Certifier:    trustedCertifier,
+				Fields:       map[string]any{"age": age},
+				Signature:    "mocksignature",
+			},
+			Keyring: map[string]string{"age": "mockkey"},
+		}
+	}
+
+	t.Run("repeated identical submission is idempotent", func(t *testing.T) {
+		// given
+		var callbackCalls int
+		server, sessionManager := newServer(&callbackCalls)
+		defer server.Close()
+
+		clientWallet := mocks.CreateClientMockWallet()
+		authMessage := handshake(t, server, clientWallet)
+		certs := []certificates.VerifiableCertificate{certificate(t, clientWallet, "serial-1", "21")}
+
+		response, err := server.SendSessionCertificateResponse(t, clientWallet, authMessage, &certs)
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+
+		// when
+		response, err = server.SendSessionCertificateResponse(t, clientWallet, authMessage, &certs)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		require.Equal(t, 1, callbackCalls)
+		require.Equal(t, certs, sessionManager.GetSession(authMessage.InitialNonce).Certificates)
+	})
+
+	t.Run("modified certificate with accepted serial number is rejected", func(t *testing.T) {
+		// given
+		var callbackCalls int
+		server, sessionManager := newServer(&callbackCalls)
+		defer server.Close()
+
+		clientWallet := mocks.CreateClientMockWallet()
+		authMessage := handshake(t, server, clientWallet)
+		accepted := []certificates.VerifiableCertificate{certificate(t, clientWallet, "serial-1", "17")}
+
+		response, err := server.SendSessionCertificateResponse(t, clientWallet, authMessage, &accepted)
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+
+		// when
+		modified := []certificates.VerifiableCertificate{certificate(t, clientWallet, "serial-1", "21")}
+		response, err = server.SendSessionCertificateResponse(t, clientWallet, authMessage, &modified)
+
+		// then
+		require.NoError(t, err)
+		assert.ErrorResponseCode(t, response, http.StatusUnauthorized, transport.ErrCodeCertificateConflict)
+		require.Equal(t, 1, callbackCalls)
+		require.Equal(t, accepted, sessionManager.GetSession(authMessage.InitialNonce).Certificates)
+	})
+
+	t.Run("modified certificate is rejected in a new session", func(t *testing.T) {
+		// given
+		var callbackCalls int
+		server, _ := newServer(&callbackCalls)
+		defer server.Close()
+
+		clientWallet := mocks.CreateClientMockWallet()
+		accepted := []certificates.VerifiableCertificate{certificate(t, clientWallet, "serial-1", "17")}
+		response, err := server.SendSessionCertificateResponse(t, clientWallet, handshake(t, server, clientWallet), &accepted)
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+
+		// when
+		modified := []certificates.VerifiableCertificate{certificate(t, clientWallet, "serial-1", "21")}
+		response, err = server.SendSessionCertificateResponse(t, clientWallet, handshake(t, server, clientWallet), &modified)
+
+		// then
+		require.NoError(t, err)
+		assert.ErrorResponseCode(t, response, http.StatusUnauthorized, transport.ErrCodeCertificateConflict)
+		require.Equal(t, 1, callbackCalls)
+	})
+
+	t.Run("conflicting serial numbers in one submission are rejected", func(t *testing.T) {
+		// given
+		var callbackCalls int
+		server, _ := newServer(&callbackCalls)
+		defer server.Close()
+
+		clientWallet := mocks.CreateClientMockWallet()
+		certs := []certificates.VerifiableCertificate{
+			certificate(t, clientWallet, "serial-1", "17"),
+			certificate(t, clientWallet, "serial-1", "21"),
+		}
+
+		// when
+		response, err := server.SendSessionCertificateResponse(t, clientWallet, handshake(t, server, clientWallet), &certs)
+
+		// then
+		require.NoError(t, err)
+		assert.ErrorResponseCode(t, response, http.StatusUnauthorized, transport.ErrCodeCertificateConflict)
+		require.Zero(t, callbackCalls)
+	})
+
+	t.Run("new serial number is accepted", func(t *testing.T) {
+		// given
+		var callbackCalls int
+		server, _ := newServer(&callbackCalls)
+		defer server.Close()
+
+		clientWallet := mocks.CreateClientMockWallet()
+		authMessage := handshake(t, server, clientWallet)
+		first := []certificates.VerifiableCertificate{certificate(t, clientWallet, "serial-1", "17")}
+		response, err := server.SendSessionCertificateResponse(t, clientWallet, authMessage, &first)
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+
+		// when
+		second := []certificates.VerifiableCertificate{certificate(t, clientWallet, "serial-2", "21")}
+		response, err = server.SendSessionCertificateResponse(t, clientWallet, authMessage, &second)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		require.Equal(t, 2, callbackCalls)
+	})
+}
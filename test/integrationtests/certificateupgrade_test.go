@@ -0,0 +1,159 @@
+package integrationtests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_CertificateUpgrade(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	initialRequirements := transport.NewRequestedCertificateSet(trustedCertifier).AddType(ageVerificationType, "age")
+	upgradedRequirements := transport.NewRequestedCertificateSet(trustedCertifier).AddType(ageVerificationType, "age", "country")
+
+	// newAuthenticatedSession returns a server and a session which was authenticated under the initial requirements
+	newAuthenticatedSession := func(t *testing.T) (*mocks.MockHTTPServer, wallet.WalletInterface, *transport.AuthMessage) {
+		onCertificatesReceived := func(_ string, _ *[]certificates.VerifiableCertificate, _ *http.Request, _ http.ResponseWriter, next func()) {
+			next()
+		}
+
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(),
+			mocks.WithCertificateRequirements(initialRequirements, onCertificatesReceived)).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+
+		clientWallet := mocks.CreateClientMockWallet()
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+
+		sendCertificates(t, server, clientWallet, authMessage, "serial-1", ageFields)
+		assert.ResponseOK(t, ping(t, server, clientWallet, authMessage))
+
+		return server, clientWallet, authMessage
+	}
+
+	t.Run("ignore keeps existing sessions and challenges new ones", func(t *testing.T) {
+		// given
+		server, clientWallet, authMessage := newAuthenticatedSession(t)
+		defer server.Close()
+
+		// when
+		err := server.AuthMiddleware().UpdateCertificateRequirements(upgradedRequirements, transport.CertificateUpgradeIgnore)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, ping(t, server, clientWallet, authMessage))
+
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(mocks.CreateClientMockWallet()).AuthMessage())
+		require.NoError(t, err)
+		newSession, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+		require.Equal(t, *upgradedRequirements, newSession.RequestedCertificates)
+	})
+
+	t.Run("rechallenge requests the new certificates on the next request", func(t *testing.T) {
+		// given
+		server, clientWallet, authMessage := newAuthenticatedSession(t)
+		defer server.Close()
+
+		// when
+		err := server.AuthMiddleware().UpdateCertificateRequirements(upgradedRequirements, transport.CertificateUpgradeRechallenge)
+
+		// then
+		require.NoError(t, err)
+		response := ping(t, server, clientWallet, authMessage)
+		require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+		var errResponse transport.ErrorResponse
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&errResponse))
+		require.NoError(t, response.Body.Close())
+		require.Equal(t, transport.ErrCodeCertificatesRequired, errResponse.Code)
+		require.Equal(t, upgradedRequirements, errResponse.RequestedCertificates)
+
+		sendCertificates(t, server, clientWallet, authMessage, "serial-2", ageFields)
+		assert.ResponseOK(t, ping(t, server, clientWallet, authMessage))
+	})
+
+	t.Run("revoke ends existing sessions on the next request", func(t *testing.T) {
+		// given
+		server, clientWallet, authMessage := newAuthenticatedSession(t)
+		defer server.Close()
+
+		// when
+		err := server.AuthMiddleware().UpdateCertificateRequirements(upgradedRequirements, transport.CertificateUpgradeRevoke)
+
+		// then
+		require.NoError(t, err)
+		response := ping(t, server, clientWallet, authMessage)
+		assert.NotAuthorized(t, response)
+		assert.SessionNotFoundError(t, response)
+	})
+
+	t.Run("requirements cannot be set without certificates callback", func(t *testing.T) {
+		// given
+		middleware, err := auth.New(auth.Config{Wallet: mocks.CreateServerMockWallet(key), SessionManager: session.NewMemoryManager()})
+		require.NoError(t, err)
+
+		// when
+		err = middleware.UpdateCertificateRequirements(upgradedRequirements, transport.CertificateUpgradeRechallenge)
+
+		// then
+		require.ErrorIs(t, err, auth.ErrCertificatesCallbackRequired)
+	})
+}
+
+// ageFields are the fields of the age certificates sent by sendCertificates
+var ageFields = map[string]any{"age": "21", "country": "Switzerland"}
+
+// sendCertificates sends an age certificate with the given serial number and fields over the session and expects it to be accepted
+func sendCertificates(t *testing.T, server *mocks.MockHTTPServer, clientWallet wallet.WalletInterface, authMessage *transport.AuthMessage, serialNumber string, fields map[string]any) {
+	identityKey, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+
+	keyring := make(map[string]string, len(fields))
+	for name := range fields {
+		keyring[name] = "mockkey"
+	}
+
+	certs := []certificates.VerifiableCertificate{{
+		Certificate: certificates.Certificate{
+			Type:         ageVerificationType,
+			SerialNumber: serialNumber,
+			Subject:      identityKey.PublicKey.ToDERHex(),
+			Certifier:    trustedCertifier,
+			Fields:       fields,
+			Signature:    "mocksignature",
+		},
+		Keyring: keyring,
+	}}
+
+	response, err := server.SendSessionCertificateResponse(t, clientWallet, authMessage, &certs)
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+}
+
+// ping sends an authenticated request to the ping endpoint over the session
+func ping(t *testing.T, server *mocks.MockHTTPServer, clientWallet wallet.WalletInterface, authMessage *transport.AuthMessage) *http.Response {
+	request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+	require.NoError(t, err)
+	require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request))
+
+	response, err := server.SendGeneralRequest(t, request)
+	require.NoError(t, err)
+	return response
+}
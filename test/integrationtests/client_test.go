@@ -0,0 +1,1156 @@
+package integrationtests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/payment"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_IdentityPinning(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	otherKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager()).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	t.Run("handshake succeeds with pinned server identity", func(t *testing.T) {
+		// given
+		authClient, err := client.New(client.Config{
+			Wallet:             mocks.CreateClientMockWallet(),
+			BaseURL:            server.URL(),
+			PinnedIdentityKeys: []string{otherKey.PubKey().ToDERHex(), key.PubKey().ToDERHex()},
+		})
+		require.NoError(t, err)
+
+		// when
+		err = authClient.Handshake(context.Background())
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, key.PubKey().ToDERHex(), authClient.ServerIdentityKey())
+	})
+
+	t.Run("handshake fails with different server identity", func(t *testing.T) {
+		// given
+		authClient, err := client.New(client.Config{
+			Wallet:             mocks.CreateClientMockWallet(),
+			BaseURL:            server.URL(),
+			PinnedIdentityKeys: []string{otherKey.PubKey().ToDERHex()},
+		})
+		require.NoError(t, err)
+
+		// when
+		err = authClient.Handshake(context.Background())
+
+		// then
+		require.ErrorIs(t, err, client.ErrServerIdentityNotPinned)
+		require.Empty(t, authClient.ServerIdentityKey())
+	})
+
+	t.Run("request is authenticated after implicit handshake", func(t *testing.T) {
+		// given
+		authClient, err := client.New(client.Config{
+			Wallet:             mocks.CreateClientMockWallet(),
+			BaseURL:            server.URL(),
+			PinnedIdentityKeys: []string{key.PubKey().ToDERHex()},
+		})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Do(request)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+	})
+}
+
+func TestClient_PayloadEncryption(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), mocks.WithPayloadEncryption).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/echo", mocks.EchoHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	authClient, err := client.New(client.Config{
+		Wallet:            mocks.CreateClientMockWallet(),
+		BaseURL:           server.URL(),
+		PayloadEncryption: true,
+	})
+	require.NoError(t, err)
+
+	plaintext := []byte(`{"secret":"value"}`)
+	request, err := http.NewRequest(http.MethodPost, server.URL()+"/echo", bytes.NewReader(plaintext))
+	require.NoError(t, err)
+
+	// when
+	response, err := authClient.Do(request)
+
+	// then
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, body)
+}
+
+func TestClient_ResponseSignature(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), mocks.WithServerInfoHeader).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	// proxy rewrites the responses of the server to general requests after they were signed
+	proxy := func(modify func(response *http.Response)) string {
+		target, err := url.Parse(server.URL())
+		require.NoError(t, err)
+		reverseProxy := httputil.NewSingleHostReverseProxy(target)
+		reverseProxy.ModifyResponse = func(response *http.Response) error {
+			if response.Request.URL.Path != "/.well-known/auth" {
+				modify(response)
+			}
+			return nil
+		}
+		proxyServer := httptest.NewServer(reverseProxy)
+		t.Cleanup(proxyServer.Close)
+		return proxyServer.URL
+	}
+
+	tests := map[string]struct {
+		modify      func(response *http.Response)
+		expectedErr error
+	}{
+		"signed response is accepted": {
+			modify: func(*http.Response) {},
+		},
+		"unsigned response is rejected": {
+			modify: func(response *http.Response) {
+				response.Header.Del("x-bsv-auth-signature")
+			},
+			expectedErr: client.ErrResponseNotSigned,
+		},
+		"response with tampered body is rejected": {
+			modify: func(response *http.Response) {
+				response.Body = io.NopCloser(strings.NewReader("tampered"))
+				response.ContentLength = int64(len("tampered"))
+				response.Header.Set("Content-Length", strconv.Itoa(len("tampered")))
+			},
+			expectedErr: client.ErrInvalidResponseSignature,
+		},
+		"response with tampered status is rejected": {
+			modify: func(response *http.Response) {
+				response.StatusCode = http.StatusAccepted
+			},
+			expectedErr: client.ErrInvalidResponseSignature,
+		},
+		"response with tampered server header is rejected": {
+			modify: func(response *http.Response) {
+				response.Header.Set(utils.ServerInfoHeader, "ts-sdk/1.0.0")
+			},
+			expectedErr: client.ErrInvalidResponseSignature,
+		},
+		"response to another request is rejected": {
+			modify: func(response *http.Response) {
+				response.Header.Set("x-bsv-auth-request-id", "b3RoZXIgcmVxdWVzdA==")
+			},
+			expectedErr: client.ErrInvalidResponseSignature,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			baseURL := proxy(test.modify)
+			authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: baseURL})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodGet, baseURL+"/ping", nil)
+			require.NoError(t, err)
+
+			// when
+			response, err := authClient.Do(request)
+
+			// then
+			if test.expectedErr != nil {
+				require.ErrorIs(t, err, test.expectedErr)
+				require.Nil(t, response)
+				return
+			}
+			require.NoError(t, err)
+			assert.ResponseOK(t, response)
+		})
+	}
+}
+
+func TestClient_Interaction(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), mocks.WithPayloadEncryption).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/echo", mocks.EchoHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL())
+	require.NoError(t, err)
+	origin := serverURL.Scheme + "://" + serverURL.Host
+
+	send := func(t *testing.T, authClient *client.Client) (*http.Response, error) {
+		request, err := http.NewRequest(http.MethodPost, server.URL()+"/echo", bytes.NewReader([]byte(`{"secret":"value"}`)))
+		require.NoError(t, err)
+		return authClient.Do(request)
+	}
+
+	t.Run("origin is approved once before the first signed request", func(t *testing.T) {
+		// given
+		var approvals []client.Origin
+		authClient, err := client.New(client.Config{
+			Wallet:  mocks.CreateClientMockWallet(),
+			BaseURL: server.URL(),
+			Interaction: client.InteractionFunc(func(_ context.Context, origin client.Origin) (bool, error) {
+				approvals = append(approvals, origin)
+				return true, nil
+			}),
+		})
+		require.NoError(t, err)
+
+		// when
+		first, firstErr := send(t, authClient)
+		second, secondErr := send(t, authClient)
+
+		// then
+		require.NoError(t, firstErr)
+		require.NoError(t, secondErr)
+		assert.ResponseOK(t, first)
+		assert.ResponseOK(t, second)
+		require.Equal(t, []client.Origin{{Origin: origin, ServerIdentityKey: key.PubKey().ToDERHex()}}, approvals)
+	})
+
+	t.Run("declined origin is not signed for", func(t *testing.T) {
+		// given
+		var approvals atomic.Int32
+		authClient, err := client.New(client.Config{
+			Wallet:  mocks.CreateClientMockWallet(),
+			BaseURL: server.URL(),
+			Interaction: client.InteractionFunc(func(context.Context, client.Origin) (bool, error) {
+				return approvals.Add(1) > 1, nil
+			}),
+		})
+		require.NoError(t, err)
+
+		// when
+		response, err := send(t, authClient)
+
+		// then
+		require.Nil(t, response)
+		require.ErrorIs(t, err, client.ErrOriginNotApproved)
+
+		// when
+		response, err = send(t, authClient)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+	})
+
+	t.Run("wallet seeks permission for the origin of the request", func(t *testing.T) {
+		// given
+		var originators []string
+		var granted atomic.Bool
+		clientWallet := wallettest.NewInteractiveMockWallet(wallettest.SeededPrivateKey(wallettest.Seed, "client"),
+			func(originator string, _ wallet.EncryptionArgs) bool {
+				originators = append(originators, originator)
+				return granted.Load()
+			}, wallettest.SeededNonces(wallettest.Seed, "client", 8)...)
+
+		authClient, err := client.New(client.Config{
+			Wallet:            clientWallet,
+			BaseURL:           server.URL(),
+			PayloadEncryption: true,
+			SeekPermission:    true,
+		})
+		require.NoError(t, err)
+
+		// when
+		response, err := send(t, authClient)
+
+		// then
+		require.Nil(t, response)
+		require.ErrorIs(t, err, wallet.ErrPermissionDenied)
+
+		// when
+		granted.Store(true)
+		response, err = send(t, authClient)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		for _, originator := range originators {
+			require.Equal(t, origin, originator)
+		}
+	})
+}
+
+func TestClient_TrustOnFirstUse(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	otherKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager()).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL())
+	require.NoError(t, err)
+
+	t.Run("records server identity on first contact", func(t *testing.T) {
+		// given
+		store := client.NewMemoryIdentityStore()
+		authClient, err := client.New(client.Config{
+			Wallet:        mocks.CreateClientMockWallet(),
+			BaseURL:       server.URL(),
+			IdentityStore: store,
+		})
+		require.NoError(t, err)
+
+		// when
+		err = authClient.Handshake(context.Background())
+
+		// then
+		require.NoError(t, err)
+		recorded, ok := store.Get(serverURL.Host)
+		require.True(t, ok)
+		require.Equal(t, key.PubKey().ToDERHex(), recorded)
+	})
+
+	t.Run("fails and alerts on identity change until rotation is accepted", func(t *testing.T) {
+		// given
+		store := client.NewMemoryIdentityStore()
+		require.NoError(t, store.Set(serverURL.Host, otherKey.PubKey().ToDERHex()))
+
+		var changedTo string
+		authClient, err := client.New(client.Config{
+			Wallet:        mocks.CreateClientMockWallet(),
+			BaseURL:       server.URL(),
+			IdentityStore: store,
+			OnIdentityChanged: func(_, _, identityKey string) {
+				changedTo = identityKey
+			},
+		})
+		require.NoError(t, err)
+
+		// when
+		err = authClient.Handshake(context.Background())
+
+		// then
+		require.ErrorIs(t, err, client.ErrServerIdentityChanged)
+		require.Equal(t, key.PubKey().ToDERHex(), changedTo)
+
+		// when
+		require.NoError(t, authClient.AcceptIdentityRotation(changedTo))
+		err = authClient.Handshake(context.Background())
+
+		// then
+		require.NoError(t, err)
+	})
+
+	t.Run("accepting rotation requires identity store", func(t *testing.T) {
+		// given
+		authClient, err := client.New(client.Config{
+			Wallet:  mocks.CreateClientMockWallet(),
+			BaseURL: server.URL(),
+		})
+		require.NoError(t, err)
+
+		// when
+		err = authClient.AcceptIdentityRotation(key.PubKey().ToDERHex())
+
+		// then
+		require.ErrorIs(t, err, client.ErrIdentityStoreNotConfigured)
+	})
+}
+
+func TestClient_CertificateProvider(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	certificateRequirements := transport.NewRequestedCertificateSet(trustedCertifier).AddType(ageVerificationType, "age")
+	onCertificatesReceived := func(_ string, _ *[]certificates.VerifiableCertificate, _ *http.Request, _ http.ResponseWriter, next func()) {
+		next()
+	}
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(),
+		mocks.WithCertificateRequirements(certificateRequirements, onCertificatesReceived)).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	ping := func(t *testing.T, authClient *client.Client) (*http.Response, error) {
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+		return authClient.Do(request)
+	}
+
+	t.Run("requested certificates are sent in the handshake", func(t *testing.T) {
+		// given
+		clientWallet := mocks.CreateClientMockWallet()
+		identityKey, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+		require.NoError(t, err)
+
+		var requested transport.RequestedCertificateSet
+		authClient, err := client.New(client.Config{
+			Wallet:  clientWallet,
+			BaseURL: server.URL(),
+			CertificateProvider: client.CertificateProviderFunc(func(_ context.Context, set transport.RequestedCertificateSet, serverIdentityKey string) ([]certificates.VerifiableCertificate, error) {
+				require.Equal(t, key.PubKey().ToDERHex(), serverIdentityKey)
+				requested = set
+				return []certificates.VerifiableCertificate{{
+					Certificate: certificates.Certificate{
+						Type:         ageVerificationType,
+						SerialNumber: "serial-1",
+						Subject:      identityKey.PublicKey.ToDERHex(),
+						Certifier:    trustedCertifier,
+						Fields:       map[string]any{"age": "21"},
+						Signature:    "mocksignature",
+					},
+					Keyring: map[string]string{"age": "mockkey"},
+				}}, nil
+			}),
+		})
+		require.NoError(t, err)
+
+		// when
+		response, err := ping(t, authClient)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		require.Equal(t, *certificateRequirements, requested)
+	})
+
+	t.Run("failing provider fails the handshake", func(t *testing.T) {
+		// given
+		providerErr := errors.New("no certificates")
+		authClient, err := client.New(client.Config{
+			Wallet:  mocks.CreateClientMockWallet(),
+			BaseURL: server.URL(),
+			CertificateProvider: client.CertificateProviderFunc(func(context.Context, transport.RequestedCertificateSet, string) ([]certificates.VerifiableCertificate, error) {
+				return nil, providerErr
+			}),
+		})
+		require.NoError(t, err)
+
+		// when
+		_, err = ping(t, authClient)
+
+		// then
+		require.ErrorIs(t, err, providerErr)
+	})
+
+	t.Run("client without provider is rejected", func(t *testing.T) {
+		// given
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL()})
+		require.NoError(t, err)
+
+		// when
+		_, err = ping(t, authClient)
+
+		// then
+		require.ErrorIs(t, err, client.ErrCertificateRequired)
+	})
+
+	t.Run("missing certificates are acquired before the handshake resumes", func(t *testing.T) {
+		// given
+		clientWallet := mocks.CreateClientMockWallet()
+		identityKey, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+		require.NoError(t, err)
+
+		var acquired []certificates.VerifiableCertificate
+		var missing transport.RequestedCertificateSet
+		authClient, err := client.New(client.Config{
+			Wallet:  clientWallet,
+			BaseURL: server.URL(),
+			CertificateProvider: client.CertificateProviderFunc(func(context.Context, transport.RequestedCertificateSet, string) ([]certificates.VerifiableCertificate, error) {
+				return acquired, nil
+			}),
+			CertificateAcquirer: client.CertificateAcquirerFunc(func(_ context.Context, set transport.RequestedCertificateSet, serverIdentityKey string) error {
+				require.Equal(t, key.PubKey().ToDERHex(), serverIdentityKey)
+				missing = set
+				acquired = []certificates.VerifiableCertificate{{
+					Certificate: certificates.Certificate{
+						Type:         ageVerificationType,
+						SerialNumber: "serial-1",
+						Subject:      identityKey.PublicKey.ToDERHex(),
+						Certifier:    trustedCertifier,
+						Fields:       map[string]any{"age": "21"},
+						Signature:    "mocksignature",
+					},
+					Keyring: map[string]string{"age": "mockkey"},
+				}}
+				return nil
+			}),
+		})
+		require.NoError(t, err)
+
+		// when
+		response, err := ping(t, authClient)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		require.Equal(t, *certificateRequirements, missing)
+	})
+
+	t.Run("failing acquirer fails the handshake", func(t *testing.T) {
+		// given
+		acquirerErr := errors.New("acquisition canceled by user")
+		authClient, err := client.New(client.Config{
+			Wallet:  mocks.CreateClientMockWallet(),
+			BaseURL: server.URL(),
+			CertificateAcquirer: client.CertificateAcquirerFunc(func(context.Context, transport.RequestedCertificateSet, string) error {
+				return acquirerErr
+			}),
+		})
+		require.NoError(t, err)
+
+		// when
+		_, err = ping(t, authClient)
+
+		// then
+		require.ErrorIs(t, err, acquirerErr)
+	})
+}
+
+func TestClient_ServerErrors(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	t.Run("certificate required error contains requested certificates", func(t *testing.T) {
+		// given
+		certificateRequirements := &transport.RequestedCertificateSet{
+			Certifiers: []string{trustedCertifier},
+			Types:      transport.RequestedCertificateTypeIDAndFieldList{ageVerificationType: {"age"}},
+		}
+		onCertificatesReceived := func(_ string, _ *[]certificates.VerifiableCertificate, _ *http.Request, _ http.ResponseWriter, next func()) {
+			next()
+		}
+
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(),
+			mocks.WithCertificateRequirements(certificateRequirements, onCertificatesReceived)).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+		defer server.Close()
+
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL()})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Do(request)
+
+		// then
+		require.Nil(t, response)
+		require.ErrorIs(t, err, client.ErrCertificateRequired)
+
+		var certErr *client.CertificateRequiredError
+		require.ErrorAs(t, err, &certErr)
+		require.Equal(t, *certificateRequirements, certErr.Missing)
+	})
+
+	t.Run("session expired error resets the session", func(t *testing.T) {
+		// given
+		sessionManager := session.NewMemoryManager()
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), sessionManager).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+		defer server.Close()
+
+		clientWallet := mocks.CreateClientMockWallet()
+		authClient, err := client.New(client.Config{Wallet: clientWallet, BaseURL: server.URL()})
+		require.NoError(t, err)
+		require.NoError(t, authClient.Handshake(context.Background()))
+
+		identity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+		require.NoError(t, err)
+		sessionManager.RemoveSession(*sessionManager.GetSession(identity.PublicKey.ToDERHex()))
+
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Do(request)
+
+		// then
+		require.Nil(t, response)
+		require.ErrorIs(t, err, client.ErrSessionExpired)
+		require.Empty(t, authClient.ServerIdentityKey())
+
+		// when
+		request, err = http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+		response, err = authClient.Do(request)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+	})
+}
+
+func TestClient_Payment(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	const price = 500
+
+	newServer := func(paymentWallet *wallettest.MockPaymentWallet) *mocks.MockHTTPServer {
+		return mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), mocks.WithPayment(paymentWallet, price)).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/ping", mocks.PingHandler().WithPaymentMiddleware().WithAuthMiddleware())
+	}
+
+	t.Run("payment required error without payer", func(t *testing.T) {
+		// given
+		server := newServer(wallettest.NewMockPaymentWallet(key))
+		defer server.Close()
+
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL()})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Do(request)
+
+		// then
+		require.Nil(t, response)
+		require.ErrorIs(t, err, client.ErrPaymentRequired)
+
+		var paymentErr *client.PaymentRequiredError
+		require.ErrorAs(t, err, &paymentErr)
+		require.Equal(t, price, paymentErr.Terms.SatoshisRequired)
+	})
+
+	t.Run("payer pays and request is retried", func(t *testing.T) {
+		// given
+		paymentWallet := wallettest.NewMockPaymentWallet(key)
+		server := newServer(paymentWallet)
+		defer server.Close()
+
+		var paidTerms payment.PaymentTerms
+		payer := client.PayerFunc(func(_ context.Context, terms payment.PaymentTerms, serverIdentityKey string) (*payment.Payment, error) {
+			require.Equal(t, key.PubKey().ToDERHex(), serverIdentityKey)
+			paidTerms = terms
+			return mocks.CreateMockPayment(mocks.CreateClientMockWallet(), terms, serverIdentityKey)
+		})
+
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL(), Payer: payer})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Do(request)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		require.Equal(t, price, paidTerms.SatoshisRequired)
+		require.Equal(t, "500", response.Header.Get(payment.HeaderSatoshisPaid))
+		require.True(t, paymentWallet.InternalizeActionCalled)
+	})
+
+	payer := client.PayerFunc(func(_ context.Context, terms payment.PaymentTerms, serverIdentityKey string) (*payment.Payment, error) {
+		return mocks.CreateMockPayment(mocks.CreateClientMockWallet(), terms, serverIdentityKey)
+	})
+
+	t.Run("payment above per-request limit is not made", func(t *testing.T) {
+		// given
+		paymentWallet := wallettest.NewMockPaymentWallet(key)
+		server := newServer(paymentWallet)
+		defer server.Close()
+
+		authClient, err := client.New(client.Config{
+			Wallet:        mocks.CreateClientMockWallet(),
+			BaseURL:       server.URL(),
+			Payer:         payer,
+			PaymentLimits: client.PaymentLimits{MaxPerRequest: price - 1},
+		})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Do(request)
+
+		// then
+		require.Nil(t, response)
+		require.ErrorIs(t, err, client.ErrPaymentLimitExceeded)
+		require.False(t, paymentWallet.InternalizeActionCalled)
+	})
+
+	t.Run("payments stop at per-host limit", func(t *testing.T) {
+		// given
+		server := newServer(wallettest.NewMockPaymentWallet(key))
+		defer server.Close()
+
+		authClient, err := client.New(client.Config{
+			Wallet:        mocks.CreateClientMockWallet(),
+			BaseURL:       server.URL(),
+			Payer:         payer,
+			PaymentLimits: client.PaymentLimits{MaxPerHost: price + price/2},
+		})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+		response, err := authClient.Do(request)
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+
+		request, err = http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err = authClient.Do(request)
+
+		// then
+		require.Nil(t, response)
+		require.ErrorIs(t, err, client.ErrPaymentLimitExceeded)
+		require.Equal(t, price, authClient.SpentSatoshis(request.URL.Host))
+	})
+
+	t.Run("terms not signed by the server identity are not paid", func(t *testing.T) {
+		// given
+		otherKey, err := ec.NewPrivateKey()
+		require.NoError(t, err)
+		paymentWallet := wallettest.NewMockPaymentWallet(otherKey)
+		server := newServer(paymentWallet)
+		defer server.Close()
+
+		var payerCalled bool
+		authClient, err := client.New(client.Config{
+			Wallet:  mocks.CreateClientMockWallet(),
+			BaseURL: server.URL(),
+			Payer: client.PayerFunc(func(_ context.Context, terms payment.PaymentTerms, serverIdentityKey string) (*payment.Payment, error) {
+				payerCalled = true
+				return mocks.CreateMockPayment(mocks.CreateClientMockWallet(), terms, serverIdentityKey)
+			}),
+		})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Do(request)
+
+		// then
+		require.Nil(t, response)
+		require.ErrorIs(t, err, client.ErrInvalidTermsSignature)
+		require.False(t, payerCalled)
+		require.False(t, paymentWallet.InternalizeActionCalled)
+	})
+
+	t.Run("client clock ahead of the server beyond the terms TTL requires re-quote without paying", func(t *testing.T) {
+		// given
+		paymentWallet := wallettest.NewMockPaymentWallet(key)
+		server := newServer(paymentWallet)
+		defer server.Close()
+
+		var payerCalled bool
+		authClient, err := client.New(client.Config{
+			Wallet:  mocks.CreateClientMockWallet(),
+			BaseURL: server.URL(),
+			Clock:   func() time.Time { return time.Now().Add(time.Hour) },
+			Payer: client.PayerFunc(func(_ context.Context, terms payment.PaymentTerms, serverIdentityKey string) (*payment.Payment, error) {
+				payerCalled = true
+				return mocks.CreateMockPayment(mocks.CreateClientMockWallet(), terms, serverIdentityKey)
+			}),
+		})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Do(request)
+
+		// then
+		require.Nil(t, response)
+		require.ErrorIs(t, err, client.ErrRequoteRequired)
+		require.ErrorIs(t, err, client.ErrPaymentTermsExpired)
+		require.False(t, payerCalled)
+		require.Zero(t, authClient.SpentSatoshis(request.URL.Host))
+	})
+
+	t.Run("terms expiring on the server while the client pays require re-quote", func(t *testing.T) {
+		// given
+		const ttl = time.Minute
+		serverClock := mocks.NewClock(time.Now())
+		paymentWallet := wallettest.NewMockPaymentWallet(key)
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(),
+			mocks.WithPaymentOptions(payment.Options{
+				Wallet:   paymentWallet,
+				TermsTTL: ttl,
+				Clock:    serverClock.Now,
+				CalculateRequestPrice: func(r *http.Request) (int, error) {
+					return price, nil
+				},
+			})).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/ping", mocks.PingHandler().WithPaymentMiddleware().WithAuthMiddleware())
+		defer server.Close()
+
+		authClient, err := client.New(client.Config{
+			Wallet:  mocks.CreateClientMockWallet(),
+			BaseURL: server.URL(),
+			// the client clock lags behind, so it still considers the terms valid
+			Clock: func() time.Time { return time.Now().Add(-time.Hour) },
+			Payer: client.PayerFunc(func(_ context.Context, terms payment.PaymentTerms, serverIdentityKey string) (*payment.Payment, error) {
+				serverClock.Advance(ttl + time.Second)
+				return mocks.CreateMockPayment(mocks.CreateClientMockWallet(), terms, serverIdentityKey)
+			}),
+		})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Do(request)
+
+		// then
+		require.Nil(t, response)
+		require.ErrorIs(t, err, client.ErrRequoteRequired)
+
+		var serverErr *client.ServerError
+		require.ErrorAs(t, err, &serverErr)
+		require.Equal(t, payment.ErrCodeTermsExpired, serverErr.Code)
+		require.False(t, paymentWallet.InternalizeActionCalled)
+		require.Zero(t, authClient.SpentSatoshis(request.URL.Host))
+	})
+
+	t.Run("payment redeeming terms below changed price requires re-quote", func(t *testing.T) {
+		// given
+		paymentWallet := wallettest.NewMockPaymentWallet(key)
+		var priceRequests atomic.Int32
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(),
+			mocks.WithPaymentOptions(payment.Options{
+				Wallet: paymentWallet,
+				CalculateRequestPrice: func(r *http.Request) (int, error) {
+					// the price rises after the terms were issued
+					return price + 100*int(priceRequests.Add(1)-1), nil
+				},
+			})).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/ping", mocks.PingHandler().WithPaymentMiddleware().WithAuthMiddleware())
+		defer server.Close()
+
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL(), Payer: payer})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Do(request)
+
+		// then
+		require.Nil(t, response)
+		require.ErrorIs(t, err, client.ErrRequoteRequired)
+
+		var serverErr *client.ServerError
+		require.ErrorAs(t, err, &serverErr)
+		require.Equal(t, payment.ErrCodeTermsOutdated, serverErr.Code)
+		require.False(t, paymentWallet.InternalizeActionCalled)
+		require.Zero(t, authClient.SpentSatoshis(request.URL.Host))
+	})
+
+	t.Run("payment rejected by approval callback", func(t *testing.T) {
+		// given
+		paymentWallet := wallettest.NewMockPaymentWallet(key)
+		server := newServer(paymentWallet)
+		defer server.Close()
+
+		var approvalTerms payment.PaymentTerms
+		authClient, err := client.New(client.Config{
+			Wallet:  mocks.CreateClientMockWallet(),
+			BaseURL: server.URL(),
+			Payer:   payer,
+			ApprovePayment: func(_ context.Context, terms payment.PaymentTerms) bool {
+				approvalTerms = terms
+				return false
+			},
+		})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Do(request)
+
+		// then
+		require.Nil(t, response)
+		require.ErrorIs(t, err, client.ErrPaymentNotApproved)
+		require.Equal(t, price, approvalTerms.SatoshisRequired)
+		require.False(t, paymentWallet.InternalizeActionCalled)
+		require.Zero(t, authClient.SpentSatoshis(request.URL.Host))
+	})
+}
+
+func TestClient_Quote(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	const price = 500
+
+	newServer := func(paymentWallet *wallettest.MockPaymentWallet, enableQuotes bool, calls *atomic.Int32) *mocks.MockHTTPServer {
+		return mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(),
+			mocks.WithPaymentOptions(payment.Options{
+				Wallet:       paymentWallet,
+				EnableQuotes: enableQuotes,
+				CalculateRequestPrice: func(r *http.Request) (int, error) {
+					if r.URL.Path == "/free" {
+						return 0, nil
+					}
+					return price, nil
+				},
+			})).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/paid", mocks.CountingHandler(calls).WithPaymentMiddleware().WithAuthMiddleware()).
+			WithHandler("/free", mocks.CountingHandler(calls).WithPaymentMiddleware().WithAuthMiddleware())
+	}
+
+	t.Run("quote returns terms without executing the request and can be paid", func(t *testing.T) {
+		// given
+		var calls atomic.Int32
+		paymentWallet := wallettest.NewMockPaymentWallet(key)
+		server := newServer(paymentWallet, true, &calls)
+		defer server.Close()
+
+		payer := client.PayerFunc(func(_ context.Context, terms payment.PaymentTerms, serverIdentityKey string) (*payment.Payment, error) {
+			return mocks.CreateMockPayment(mocks.CreateClientMockWallet(), terms, serverIdentityKey)
+		})
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL(), Payer: payer})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/paid", nil)
+		require.NoError(t, err)
+
+		// when
+		terms, err := authClient.Quote(request)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, price, terms.SatoshisRequired)
+		require.NotEmpty(t, terms.DerivationPrefix)
+		require.Zero(t, calls.Load())
+		require.False(t, paymentWallet.InternalizeActionCalled)
+
+		// when
+		request, err = http.NewRequest(http.MethodGet, server.URL()+"/paid", nil)
+		require.NoError(t, err)
+		response, err := authClient.Do(request)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		require.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("quote of free request", func(t *testing.T) {
+		// given
+		var calls atomic.Int32
+		server := newServer(wallettest.NewMockPaymentWallet(key), true, &calls)
+		defer server.Close()
+
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL()})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/free", nil)
+		require.NoError(t, err)
+
+		// when
+		terms, err := authClient.Quote(request)
+
+		// then
+		require.NoError(t, err)
+		require.Zero(t, terms.SatoshisRequired)
+		require.Zero(t, calls.Load())
+	})
+
+	t.Run("server without quotes", func(t *testing.T) {
+		// given
+		var calls atomic.Int32
+		server := newServer(wallettest.NewMockPaymentWallet(key), false, &calls)
+		defer server.Close()
+
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL()})
+		require.NoError(t, err)
+
+		// when
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/paid", nil)
+		require.NoError(t, err)
+		terms, err := authClient.Quote(request)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, price, terms.SatoshisRequired)
+
+		// when
+		request, err = http.NewRequest(http.MethodGet, server.URL()+"/free", nil)
+		require.NoError(t, err)
+		_, err = authClient.Quote(request)
+
+		// then
+		require.ErrorIs(t, err, client.ErrQuotesNotSupported)
+	})
+}
+
+func TestClient_Call(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	const price = 500
+
+	// the terms of every payment need their own derivation prefix, redeemed terms are rejected
+	paymentWallet := wallettest.NewMockPaymentWallet(key, wallettest.DefaultNonces...)
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), mocks.WithPayment(paymentWallet, price)).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/ping", mocks.PingHandler().WithPaymentMiddleware().WithAuthMiddleware())
+	defer server.Close()
+
+	serverURL, err := url.Parse(server.URL())
+	require.NoError(t, err)
+
+	payer := client.PayerFunc(func(_ context.Context, terms payment.PaymentTerms, serverIdentityKey string) (*payment.Payment, error) {
+		return mocks.CreateMockPayment(mocks.CreateClientMockWallet(), terms, serverIdentityKey)
+	})
+
+	tests := map[string]struct {
+		endpoint client.Endpoint
+		err      error
+	}{
+		"payment of the declared price is made": {
+			endpoint: client.Endpoint{Method: http.MethodGet, Path: "/ping", Price: price},
+		},
+		"payment of a dynamic price is made": {
+			endpoint: client.Endpoint{Method: http.MethodGet, Path: "/ping", DynamicPrice: true},
+		},
+		"payment above the declared price is refused": {
+			endpoint: client.Endpoint{Method: http.MethodGet, Path: "/ping", Price: price - 1},
+			err:      client.ErrUnexpectedPrice,
+		},
+		"payment of a free endpoint is refused": {
+			endpoint: client.Endpoint{Method: http.MethodGet, Path: "/ping"},
+			err:      client.ErrUnexpectedPrice,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL(), Payer: payer})
+			require.NoError(t, err)
+
+			// when
+			response, err := authClient.Call(context.Background(), test.endpoint, nil, nil)
+
+			// then
+			if test.err != nil {
+				require.ErrorIs(t, err, test.err)
+				require.Zero(t, authClient.SpentSatoshis(serverURL.Host))
+				return
+			}
+			require.NoError(t, err)
+			assert.ResponseOK(t, response)
+			require.Equal(t, price, authClient.SpentSatoshis(serverURL.Host))
+		})
+	}
+
+	t.Run("certificate prompt is called once per endpoint demanding certificates", func(t *testing.T) {
+		// given
+		endpoint := client.Endpoint{
+			Method:       http.MethodGet,
+			Path:         "/{name}",
+			Certificates: transport.NewRequestedCertificateSet(trustedCertifier).AddType(ageVerificationType, "age"),
+			Price:        price,
+		}
+
+		var prompts []client.Endpoint
+		declined := true
+		authClient, err := client.New(client.Config{
+			Wallet:  mocks.CreateClientMockWallet(),
+			BaseURL: server.URL(),
+			Payer:   payer,
+			CertificatePrompt: func(_ context.Context, endpoint client.Endpoint) error {
+				prompts = append(prompts, endpoint)
+				if declined {
+					return errors.New("declined")
+				}
+				return nil
+			},
+		})
+		require.NoError(t, err)
+
+		// when
+		_, err = authClient.Call(context.Background(), endpoint, map[string]string{"name": "ping"}, nil)
+
+		// then
+		require.EqualError(t, err, "declined")
+
+		// when
+		declined = false
+		for range 2 {
+			response, err := authClient.Call(context.Background(), endpoint, map[string]string{"name": "ping"}, nil)
+			require.NoError(t, err)
+			assert.ResponseOK(t, response)
+		}
+
+		// then
+		require.Len(t, prompts, 2)
+		require.Equal(t, endpoint, prompts[0])
+	})
+}
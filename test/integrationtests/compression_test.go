@@ -0,0 +1,146 @@
+package integrationtests
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+// bodyRecorder records the bodies of the requests sent over the wire
+type bodyRecorder struct {
+	mu     sync.Mutex
+	bodies []string
+}
+
+func (r *bodyRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+
+		r.mu.Lock()
+		r.bodies = append(r.bodies, string(body))
+		r.mu.Unlock()
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func (r *bodyRecorder) contains(s string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, body := range r.bodies {
+		if strings.Contains(body, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestAuthMiddleware_CertificateCompression(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	certificateRequirements := transport.NewRequestedCertificateSet(trustedCertifier).AddType(ageVerificationType, "age")
+	onCertificatesReceived := func(_ string, _ *[]certificates.VerifiableCertificate, _ *http.Request, _ http.ResponseWriter, next func()) {
+		next()
+	}
+	newServer := func(t *testing.T, opts ...func(s *mocks.MockHTTPServer) *mocks.MockHTTPServer) *mocks.MockHTTPServer {
+		opts = append(opts, mocks.WithCertificateRequirements(certificateRequirements, onCertificatesReceived))
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), opts...).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	// newClient returns a client disclosing an age certificate with the field value and the recorder of its requests
+	newClient := func(t *testing.T, server *mocks.MockHTTPServer, age string) (*client.Client, *bodyRecorder) {
+		clientWallet := mocks.CreateClientMockWallet()
+		identityKey, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+		require.NoError(t, err)
+
+		recorder := &bodyRecorder{}
+		authClient, err := client.New(client.Config{
+			Wallet:                 clientWallet,
+			BaseURL:                server.URL(),
+			HTTPClient:             &http.Client{Transport: recorder},
+			CertificateCompression: transport.GzipCompression,
+			CertificateProvider: client.CertificateProviderFunc(func(context.Context, transport.RequestedCertificateSet, string) ([]certificates.VerifiableCertificate, error) {
+				return []certificates.VerifiableCertificate{{
+					Certificate: certificates.Certificate{
+						Type:         ageVerificationType,
+						SerialNumber: "serial-1",
+						Subject:      identityKey.PublicKey.ToDERHex(),
+						Certifier:    trustedCertifier,
+						Fields:       map[string]any{"age": age},
+						Signature:    "mocksignature",
+					},
+					Keyring: map[string]string{"age": "mockkey"},
+				}}, nil
+			}),
+		})
+		require.NoError(t, err)
+		return authClient, recorder
+	}
+
+	t.Run("certificates are compressed when the server offers the compression", func(t *testing.T) {
+		// given
+		server := newServer(t, mocks.WithCertificateCompression(0, transport.GzipCompression, transport.DeflateCompression))
+		authClient, recorder := newClient(t, server, "21")
+
+		// when
+		response, err := authClient.Get(context.Background(), "/ping")
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		require.True(t, recorder.contains(`"compressedCertificates"`))
+	})
+
+	t.Run("certificates are sent uncompressed when the server does not offer the compression", func(t *testing.T) {
+		// given
+		server := newServer(t)
+		authClient, recorder := newClient(t, server, "21")
+
+		// when
+		response, err := authClient.Get(context.Background(), "/ping")
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		require.False(t, recorder.contains(`"compressedCertificates"`))
+	})
+
+	t.Run("certificates decompressing beyond the maximum size are rejected", func(t *testing.T) {
+		// given
+		server := newServer(t, mocks.WithCertificateCompression(1<<10, transport.GzipCompression))
+		authClient, _ := newClient(t, server, strings.Repeat("2", 1<<20))
+
+		// when
+		_, err := authClient.Get(context.Background(), "/ping")
+
+		// then
+		var serverErr *client.ServerError
+		require.ErrorAs(t, err, &serverErr)
+		require.Equal(t, http.StatusRequestEntityTooLarge, serverErr.StatusCode)
+		require.Equal(t, transport.ErrCodeMessageTooLarge, serverErr.Code)
+	})
+}
@@ -0,0 +1,112 @@
+package integrationtests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+// rekeyingWallet signs with another key ID than requested, so its signatures do not verify
+type rekeyingWallet struct {
+	wallet.WalletInterface
+}
+
+func (w rekeyingWallet) CreateSignature(args *wallet.CreateSignatureArgs, originator string) (*wallet.CreateSignatureResult, error) {
+	rekeyed := *args
+	rekeyed.KeyID += " forged"
+	return w.WalletInterface.CreateSignature(&rekeyed, originator)
+}
+
+func TestAuthMiddleware_CoSigning(t *testing.T) {
+	serverKey, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	tenantKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	tenantWallet := wallettest.NewMockWallet(tenantKey)
+	tenantIdentityKey := tenantKey.PubKey().ToDERHex()
+
+	tests := map[string]struct {
+		coSigner         wallet.WalletInterface
+		encryption       bool
+		trustedCoSigners []string
+		expectedErr      error
+	}{
+		"co-signed response is verified": {
+			coSigner: tenantWallet,
+		},
+		"co-signed encrypted response is verified": {
+			coSigner:   tenantWallet,
+			encryption: true,
+		},
+		"response of trusted co-signer is verified": {
+			coSigner:         tenantWallet,
+			trustedCoSigners: []string{tenantIdentityKey},
+		},
+		"response of untrusted co-signer is rejected": {
+			coSigner:         tenantWallet,
+			trustedCoSigners: []string{wallettest.ClientIdentityKey},
+			expectedErr:      client.ErrCoSignerNotTrusted,
+		},
+		"invalid co-signature is rejected": {
+			coSigner:    rekeyingWallet{WalletInterface: tenantWallet},
+			expectedErr: client.ErrInvalidCoSignature,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			opts := []func(s *mocks.MockHTTPServer) *mocks.MockHTTPServer{
+				mocks.WithCoSigner(func(*http.Request) wallet.WalletInterface { return test.coSigner }),
+			}
+			if test.encryption {
+				opts = append(opts, mocks.WithPayloadEncryption)
+			}
+			server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(serverKey), session.NewMemoryManager(), opts...).
+				WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+				WithHandler("/echo", mocks.EchoHandler().WithAuthMiddleware())
+			defer server.Close()
+
+			authClient, err := client.New(client.Config{
+				Wallet:            mocks.CreateClientMockWallet(),
+				BaseURL:           server.URL(),
+				PayloadEncryption: test.encryption,
+				TrustedCoSigners:  test.trustedCoSigners,
+			})
+			require.NoError(t, err)
+
+			request, err := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL()+"/echo", strings.NewReader("co-signed"))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", "text/plain")
+
+			// when
+			response, err := authClient.Do(request)
+
+			// then
+			if test.expectedErr != nil {
+				require.ErrorIs(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.ResponseOK(t, response)
+			require.Equal(t, tenantIdentityKey, response.Header.Get(utils.CoSignerIdentityKeyHeader))
+			require.NotEmpty(t, response.Header.Get(utils.CoSignatureHeader))
+
+			body, err := io.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.NoError(t, response.Body.Close())
+			require.Equal(t, "co-signed", string(body))
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package integrationtests
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+// authRecorder is an authorizer allowing every request, which records the AuthResult of the last one
+type authRecorder struct {
+	mu   sync.Mutex
+	last auth.AuthResult
+}
+
+func (r *authRecorder) Authorize(_ context.Context, input auth.AuthorizationInput) (auth.Decision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.last = input.Auth
+	return auth.Decision{Allow: true}, nil
+}
+
+func (r *authRecorder) result() auth.AuthResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
+
+func TestAuthMiddleware_Delegation(t *testing.T) {
+	serverKey, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	principalKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	agentKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	principalIdentityKey := principalKey.PubKey().ToDERHex()
+	agentIdentityKey := agentKey.PubKey().ToDERHex()
+
+	delegation, err := transport.SignDelegation(context.Background(), wallettest.NewMockWallet(principalKey), agentIdentityKey, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+
+	get := func(t *testing.T, authClient *client.Client, url string) (*http.Response, error) {
+		request, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url+"/", nil)
+		require.NoError(t, err)
+		return authClient.Do(request)
+	}
+
+	t.Run("agent authenticates on behalf of the principal", func(t *testing.T) {
+		// given
+		recorder := &authRecorder{}
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(serverKey), session.NewMemoryManager(),
+			mocks.WithDelegations(transport.DelegationPolicy{}), mocks.WithAuthorizer(recorder)).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+		defer server.Close()
+
+		authClient, err := client.New(client.Config{Wallet: wallettest.NewMockWallet(agentKey), BaseURL: server.URL(), Delegation: delegation})
+		require.NoError(t, err)
+
+		// when
+		response, err := get(t, authClient, server.URL())
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		require.Equal(t, agentIdentityKey, recorder.result().IdentityKey)
+		require.Equal(t, principalIdentityKey, recorder.result().Principal)
+	})
+
+	t.Run("delegation is ignored without delegation policy", func(t *testing.T) {
+		// given
+		recorder := &authRecorder{}
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(serverKey), session.NewMemoryManager(),
+			mocks.WithAuthorizer(recorder)).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+		defer server.Close()
+
+		authClient, err := client.New(client.Config{Wallet: wallettest.NewMockWallet(agentKey), BaseURL: server.URL(), Delegation: delegation})
+		require.NoError(t, err)
+
+		// when
+		response, err := get(t, authClient, server.URL())
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		require.Equal(t, agentIdentityKey, recorder.result().IdentityKey)
+		require.Empty(t, recorder.result().Principal)
+	})
+
+	t.Run("delegation of another agent is rejected in the handshake", func(t *testing.T) {
+		// given
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(serverKey), session.NewMemoryManager(),
+			mocks.WithDelegations(transport.DelegationPolicy{})).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+		defer server.Close()
+
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL(), Delegation: delegation})
+		require.NoError(t, err)
+
+		// when
+		_, err = get(t, authClient, server.URL())
+
+		// then
+		require.ErrorContains(t, err, "handshake failed with status 401")
+	})
+
+	t.Run("revoked delegation rejects the requests of the session", func(t *testing.T) {
+		// given
+		var revoked atomic.Bool
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(serverKey), session.NewMemoryManager(),
+			mocks.WithDelegations(transport.DelegationPolicy{
+				IsRevoked: func(_ context.Context, d transport.Delegation) bool {
+					return revoked.Load() && d.Agent == agentIdentityKey
+				},
+			})).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+		defer server.Close()
+
+		authClient, err := client.New(client.Config{Wallet: wallettest.NewMockWallet(agentKey), BaseURL: server.URL(), Delegation: delegation})
+		require.NoError(t, err)
+		response, err := get(t, authClient, server.URL())
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+
+		// when
+		revoked.Store(true)
+		_, err = get(t, authClient, server.URL())
+
+		// then
+		var serverErr *client.ServerError
+		require.ErrorAs(t, err, &serverErr)
+		require.Equal(t, transport.ErrCodeInvalidDelegation, serverErr.Code)
+	})
+}
@@ -0,0 +1,85 @@
+package integrationtests
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+// dataRecordingWallet records the size of the largest data passed to the signature operations of the wallet
+// and whether hashes were passed instead
+type dataRecordingWallet struct {
+	wallet.WalletInterface
+	mu      sync.Mutex
+	maxData int
+	hashed  int
+}
+
+func (w *dataRecordingWallet) record(data, hash []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.maxData = max(w.maxData, len(data))
+	if len(hash) > 0 {
+		w.hashed++
+	}
+}
+
+func (w *dataRecordingWallet) CreateSignature(args *wallet.CreateSignatureArgs, originator string) (*wallet.CreateSignatureResult, error) {
+	w.record(args.Data, args.HashToDirectlySign)
+	return w.WalletInterface.CreateSignature(args, originator)
+}
+
+func (w *dataRecordingWallet) VerifySignature(args *wallet.VerifySignatureArgs) (*wallet.VerifySignatureResult, error) {
+	w.record(args.Data, args.HashToDirectlyVerify)
+	return w.WalletInterface.VerifySignature(args)
+}
+
+func TestAuthMiddleware_DirectHash(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	serverWallet := &dataRecordingWallet{WalletInterface: mocks.CreateServerMockWallet(key)}
+	server := mocks.CreateMockHTTPServer(serverWallet, session.NewMemoryManager()).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/echo", mocks.EchoHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	clientWallet := &dataRecordingWallet{WalletInterface: mocks.CreateClientMockWallet()}
+	authClient, err := client.New(client.Config{Wallet: clientWallet, BaseURL: server.URL()})
+	require.NoError(t, err)
+
+	body := bytes.Repeat([]byte("large body "), 2*authcore.DirectHashThreshold/10)
+	request, err := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL()+"/echo", bytes.NewReader(body))
+	require.NoError(t, err)
+	request.Header.Set("Content-Type", "text/plain")
+
+	// when
+	response, err := authClient.Do(request)
+
+	// then
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+	echoed, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+	require.Equal(t, body, echoed)
+
+	for name, w := range map[string]*dataRecordingWallet{"server": serverWallet, "client": clientWallet} {
+		require.LessOrEqual(t, w.maxData, authcore.DirectHashThreshold, "%s wallet received the large payload", name)
+		require.Positive(t, w.hashed, "%s wallet received no hashes", name)
+	}
+}
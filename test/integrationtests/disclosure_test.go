@@ -0,0 +1,151 @@
+package integrationtests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_StrictDisclosure(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	certificateRequirements := transport.NewRequestedCertificateSet(trustedCertifier).AddType(ageVerificationType, "age")
+
+	// newServer returns a server with strict disclosure which accepts all certificates and counts the callback calls
+	newServer := func(callbackCalls *int) *mocks.MockHTTPServer {
+		onCertificatesReceived := func(_ string, _ *[]certificates.VerifiableCertificate, _ *http.Request, _ http.ResponseWriter, next func()) {
+			*callbackCalls++
+			next()
+		}
+
+		return mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), nil,
+			mocks.WithCertificateRequirements(certificateRequirements, onCertificatesReceived), mocks.WithStrictDisclosure).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+	}
+
+	// sendCertificates sends a certificate with serial number serial-<n> for the nth keyring
+	sendCertificates := func(t *testing.T, server *mocks.MockHTTPServer, keyrings ...map[string]string) *http.Response {
+		clientWallet := mocks.CreateClientMockWallet()
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+
+		identityKey, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+		require.NoError(t, err)
+		certs := make([]certificates.VerifiableCertificate, 0, len(keyrings))
+		for i, keyring := range keyrings {
+			certs = append(certs, certificates.VerifiableCertificate{
+				Certificate: certificates.Certificate{
+					Type:         ageVerificationType,
+					SerialNumber: fmt.Sprintf("serial-%d", i+1),
+					Subject:      identityKey.PublicKey.ToDERHex(),
+					Certifier:    trustedCertifier,
+					Fields:       map[string]any{"age": "21", "name": "Alice"},
+					Signature:    "mocksignature",
+				},
+				Keyring: keyring,
+			})
+		}
+
+		response, err = server.SendSessionCertificateResponse(t, clientWallet, authMessage, &certs)
+		require.NoError(t, err)
+		return response
+	}
+
+	t.Run("certificate disclosing the requested fields is accepted", func(t *testing.T) {
+		// given
+		var callbackCalls int
+		server := newServer(&callbackCalls)
+		defer server.Close()
+
+		// when
+		response := sendCertificates(t, server, map[string]string{"age": "mockkey"})
+
+		// then
+		assert.ResponseOK(t, response)
+		require.Equal(t, 1, callbackCalls)
+	})
+
+	tests := map[string]struct {
+		keyring  map[string]string
+		code     string
+		expected transport.DisclosureError
+	}{
+		"under disclosure": {
+			keyring:  map[string]string{},
+			code:     transport.ErrCodeCertificateUnderDisclosed,
+			expected: transport.DisclosureError{Type: ageVerificationType, SerialNumber: "serial-1", Missing: []string{"age"}},
+		},
+		"over disclosure": {
+			keyring:  map[string]string{"age": "mockkey", "name": "mockkey"},
+			code:     transport.ErrCodeCertificateOverDisclosed,
+			expected: transport.DisclosureError{Type: ageVerificationType, SerialNumber: "serial-1", Unrequested: []string{"name"}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name+" is rejected with the offending fields", func(t *testing.T) {
+			// given
+			var callbackCalls int
+			server := newServer(&callbackCalls)
+			defer server.Close()
+
+			// when
+			response := sendCertificates(t, server, test.keyring)
+
+			// then
+			require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+			var errResponse transport.ErrorResponse
+			require.NoError(t, json.NewDecoder(response.Body).Decode(&errResponse))
+			require.NoError(t, response.Body.Close())
+			require.Equal(t, test.code, errResponse.Code)
+			require.Equal(t, &test.expected, errResponse.Disclosure)
+			require.Equal(t, certificateRequirements, errResponse.RequestedCertificates)
+			require.Zero(t, callbackCalls)
+		})
+	}
+
+	t.Run("every rejected certificate is listed", func(t *testing.T) {
+		// given
+		var callbackCalls int
+		server := newServer(&callbackCalls)
+		defer server.Close()
+
+		// when
+		response := sendCertificates(t, server,
+			map[string]string{},
+			map[string]string{"age": "mockkey"},
+			map[string]string{"age": "mockkey", "name": "mockkey"})
+
+		// then
+		require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+		var errResponse transport.ErrorResponse
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&errResponse))
+		require.NoError(t, response.Body.Close())
+		require.Equal(t, transport.ErrCodeCertificateUnderDisclosed, errResponse.Code)
+		require.Len(t, errResponse.Certificates, 2)
+
+		underDisclosed, overDisclosed := errResponse.Certificates[0], errResponse.Certificates[1]
+		require.Equal(t, 0, underDisclosed.Index)
+		require.Equal(t, "serial-1", underDisclosed.SerialNumber)
+		require.Equal(t, transport.ErrCodeCertificateUnderDisclosed, underDisclosed.Code)
+		require.Equal(t, 2, overDisclosed.Index)
+		require.Equal(t, "serial-3", overDisclosed.SerialNumber)
+		require.Equal(t, transport.ErrCodeCertificateOverDisclosed, overDisclosed.Code)
+		require.Contains(t, overDisclosed.Reason, "name")
+		require.Zero(t, callbackCalls)
+	})
+}
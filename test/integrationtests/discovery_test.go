@@ -0,0 +1,47 @@
+package integrationtests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_DiscoveryDocument(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), mocks.WithPayloadEncryption).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	// when
+	response, err := http.Get(server.URL() + auth.DiscoveryPath)
+
+	// then
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+	require.Equal(t, "application/json", response.Header.Get("Content-Type"))
+
+	var document auth.DiscoveryDocument
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&document))
+	require.NoError(t, response.Body.Close())
+
+	require.Equal(t, key.PubKey().ToDERHex(), document.IdentityKey)
+	require.Equal(t, []string{transport.AuthVersion}, document.AuthVersions)
+	require.Equal(t, auth.HandshakePath, document.HandshakePath)
+	require.True(t, document.PayloadEncryption)
+	require.Equal(t, []transport.Capability{transport.CapabilityHeartbeat, transport.CapabilityDigestBLAKE3, transport.CapabilityTrailerSignature, transport.CapabilityPayloadEncryption}, document.Capabilities)
+	require.False(t, document.AllowUnauthenticated)
+	require.Nil(t, document.RequestedCertificates)
+	require.Nil(t, document.Payment)
+}
@@ -0,0 +1,144 @@
+package integrationtests
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_PayloadEncryption(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	plaintext := []byte(`{"secret":"value"}`)
+
+	t.Run("encrypted request and response bodies when negotiated", func(t *testing.T) {
+		// given
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), mocks.WithPayloadEncryption).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/echo", mocks.EchoHandler().WithAuthMiddleware())
+		defer server.Close()
+
+		clientWallet := mocks.CreateClientMockWallet()
+		initialRequest := mocks.PrepareInitialRequestBody(clientWallet)
+		initialRequest.PayloadEncryption = true
+
+		response, err := server.SendNonGeneralRequest(t, initialRequest.AuthMessage())
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+		require.True(t, authMessage.PayloadEncryption)
+
+		url := server.URL() + "/echo"
+		headers, body, err := utils.PrepareEncryptedGeneralRequest(clientWallet, authMessage, utils.RequestData{
+			Method: http.MethodPost,
+			URL:    url,
+			Body:   plaintext,
+		})
+		require.NoError(t, err)
+		require.NotEqual(t, plaintext, body)
+
+		request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+		require.NoError(t, err)
+		for k, v := range headers {
+			request.Header.Set(k, v)
+		}
+
+		// when
+		response, err = server.SendGeneralRequest(t, request)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		assert.SignedGeneralResponse(t, clientWallet, response, key.PubKey().ToDERHex(), request)
+
+		responseBody, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+		require.NotEqual(t, plaintext, responseBody)
+
+		decrypted, err := utils.DecryptResponseBody(clientWallet, response.Header, responseBody)
+		require.NoError(t, err)
+		require.Equal(t, plaintext, decrypted)
+	})
+
+	t.Run("plain bodies when server does not enable encryption", func(t *testing.T) {
+		// given
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager()).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/echo", mocks.EchoHandler().WithAuthMiddleware())
+		defer server.Close()
+
+		clientWallet := mocks.CreateClientMockWallet()
+		initialRequest := mocks.PrepareInitialRequestBody(clientWallet)
+		initialRequest.PayloadEncryption = true
+
+		response, err := server.SendNonGeneralRequest(t, initialRequest.AuthMessage())
+		require.NoError(t, err)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+		require.False(t, authMessage.PayloadEncryption)
+		require.ErrorIs(t, utils.VerifyInitialResponse(clientWallet, initialRequest.AuthMessage(), authMessage), utils.ErrPayloadEncryptionNotGranted)
+
+		url := server.URL() + "/echo"
+		headers, err := utils.PrepareGeneralRequestHeaders(clientWallet, authMessage, utils.RequestData{
+			Method: http.MethodPost,
+			URL:    url,
+			Body:   plaintext,
+		})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(plaintext))
+		require.NoError(t, err)
+		for k, v := range headers {
+			request.Header.Set(k, v)
+		}
+
+		// when
+		response, err = server.SendGeneralRequest(t, request)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		assert.SignedGeneralResponse(t, clientWallet, response, key.PubKey().ToDERHex(), request)
+
+		responseBody, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+		require.Equal(t, plaintext, responseBody)
+	})
+
+	t.Run("granted encryption is covered by the initial response signature", func(t *testing.T) {
+		// given
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), mocks.WithPayloadEncryption).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+		defer server.Close()
+
+		clientWallet := mocks.CreateClientMockWallet()
+		initialRequest := mocks.PrepareInitialRequestBody(clientWallet)
+		initialRequest.PayloadEncryption = true
+
+		response, err := server.SendNonGeneralRequest(t, initialRequest.AuthMessage())
+		require.NoError(t, err)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+		require.NoError(t, utils.VerifyInitialResponse(clientWallet, initialRequest.AuthMessage(), authMessage))
+
+		// when
+		downgraded := *authMessage
+		downgraded.SetCapabilities(nil)
+		initialRequest.PayloadEncryption = false
+
+		// then
+		require.Error(t, utils.VerifyInitialResponse(clientWallet, initialRequest.AuthMessage(), &downgraded))
+	})
+}
@@ -0,0 +1,186 @@
+package integrationtests
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/events"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/metering"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/payment"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+// eventRecorder records the topic, type and identity key of the events published on the bus
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []events.Event
+}
+
+func (r *eventRecorder) subscribe(bus *events.Bus, topics ...events.Topic) {
+	for _, topic := range topics {
+		bus.Subscribe(topic, func(_ context.Context, event events.Event) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.events = append(r.events, event)
+		})
+	}
+}
+
+// received returns the events of the topic and type
+func (r *eventRecorder) received(topic events.Topic, eventType string) []events.Event {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.DeleteFunc(slices.Clone(r.events), func(e events.Event) bool {
+		return e.Topic != topic || e.Type != eventType
+	})
+}
+
+func TestAuthMiddleware_Events(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	const price = 500
+
+	bus := events.NewBus(nil)
+	recorder := &eventRecorder{}
+	recorder.subscribe(bus, events.TopicAuth, events.TopicSession, events.TopicPayment)
+
+	meter, err := metering.New(metering.Options{})
+	require.NoError(t, err)
+	meter.Subscribe(bus)
+
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(),
+		mocks.WithEvents(bus),
+		mocks.WithPaymentOptions(payment.Options{
+			Wallet:                wallettest.NewMockPaymentWallet(key),
+			CalculateRequestPrice: func(*http.Request) (int, error) { return price, nil },
+			Events:                bus,
+		})).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/ping", mocks.PingHandler().WithPaymentMiddleware().WithAuthMiddleware())
+	defer server.Close()
+
+	clientWallet := mocks.CreateClientMockWallet()
+	clientIdentity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+	identityKey := clientIdentity.PublicKey.ToDERHex()
+
+	authClient, err := client.New(client.Config{
+		Wallet:  clientWallet,
+		BaseURL: server.URL(),
+		Payer: client.PayerFunc(func(_ context.Context, terms payment.PaymentTerms, serverIdentityKey string) (*payment.Payment, error) {
+			return mocks.CreateMockPayment(mocks.CreateClientMockWallet(), terms, serverIdentityKey)
+		}),
+	})
+	require.NoError(t, err)
+
+	// when
+	response, err := authClient.Get(context.Background(), "/ping")
+
+	// then
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+
+	sessions := recorder.received(events.TopicSession, events.TypeSessionCreated)
+	require.Len(t, sessions, 1)
+	require.Equal(t, identityKey, sessions[0].IdentityKey)
+	require.True(t, sessions[0].Data.(session.PeerSession).IsAuthenticated)
+
+	payments := recorder.received(events.TopicPayment, events.TypePaymentAccepted)
+	require.Len(t, payments, 1)
+	require.Equal(t, identityKey, payments[0].IdentityKey)
+	require.Equal(t, price, payments[0].Data.(payment.PaymentInfo).SatoshisPaid)
+
+	// the request events are published once the responses were written
+	require.Eventually(t, func() bool {
+		return len(recorder.received(events.TopicAuth, events.TypeRequest)) == 3
+	}, time.Second, 10*time.Millisecond)
+	var outcomes []string
+	for _, event := range recorder.received(events.TopicAuth, events.TypeRequest) {
+		outcomes = append(outcomes, event.Data.(auth.RequestEvent).Outcome)
+	}
+	require.Equal(t, []string{auth.AccessOutcomeHandshake, auth.AccessOutcomeAuthenticated, auth.AccessOutcomeAuthenticated}, outcomes)
+
+	// the payment required response and the paid request are metered
+	usage := meter.Usage()
+	require.Len(t, usage, 1)
+	require.Equal(t, identityKey, usage[0].IdentityKey)
+	require.Equal(t, int64(2), usage[0].Requests)
+	require.Equal(t, int64(price), usage[0].SatoshisPaid)
+}
+
+func TestAuthMiddleware_EventsRedactCertificates(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	policy := &transport.CertificateRedactionPolicy{
+		Fields:  map[string]transport.RedactionMode{"age": transport.RedactionNone},
+		Default: transport.RedactionRemove,
+	}
+	bus := events.NewBus(auth.EventRedactor(policy))
+	recorder := &eventRecorder{}
+	recorder.subscribe(bus, events.TopicSession)
+
+	var received []certificates.VerifiableCertificate
+	onCertificatesReceived := func(_ string, certs *[]certificates.VerifiableCertificate, _ *http.Request, _ http.ResponseWriter, next func()) {
+		received = *certs
+		next()
+	}
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), nil,
+		mocks.WithEvents(bus),
+		mocks.WithCertificateRequirements(transport.NewRequestedCertificateSet(trustedCertifier).AddType(ageVerificationType, "age", "name"), onCertificatesReceived)).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	clientWallet := mocks.CreateClientMockWallet()
+	identityKey, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+
+	response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+	require.NoError(t, err)
+	authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+	require.NoError(t, err)
+	certificates := []certificates.VerifiableCertificate{{
+		Certificate: certificates.Certificate{
+			Type:         ageVerificationType,
+			SerialNumber: "serial-1",
+			Subject:      identityKey.PublicKey.ToDERHex(),
+			Certifier:    trustedCertifier,
+			Fields:       map[string]any{"age": "21", "name": "Alice"},
+			Signature:    "mocksignature",
+		},
+		Keyring: map[string]string{"age": "mockkey", "name": "mockkey"},
+	}}
+
+	// when
+	response, err = server.SendSessionCertificateResponse(t, clientWallet, authMessage, &certificates)
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+
+	// then
+	require.Equal(t, "Alice", received[0].Fields["name"], "the callback receives the certificates unredacted")
+
+	authenticated := recorder.received(events.TopicSession, events.TypeSessionAuthenticated)
+	require.Len(t, authenticated, 1)
+	session := authenticated[0].Data.(session.PeerSession)
+	require.Len(t, session.Certificates, 1)
+	require.Equal(t, "21", session.Certificates[0].Fields["age"])
+	require.Equal(t, "[REDACTED]", session.Certificates[0].Fields["name"])
+	require.Equal(t, "[REDACTED]", session.Certificates[0].Keyring["name"])
+}
@@ -0,0 +1,168 @@
+package integrationtests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_AbortedCertificateExchange(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	clientWallet := mocks.CreateClientMockWallet()
+	clientIdentity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+	identityKey := clientIdentity.PublicKey.ToDERHex()
+
+	certificateRequirements := transport.NewRequestedCertificateSet(trustedCertifier).AddType(ageVerificationType, "age")
+	certs := []certificates.VerifiableCertificate{{
+		Certificate: certificates.Certificate{
+			Type:         ageVerificationType,
+			SerialNumber: "serial-1",
+			Subject:      identityKey,
+			Certifier:    trustedCertifier,
+			Fields:       map[string]any{"age": "21"},
+			Signature:    "mocksignature",
+		},
+		Keyring: map[string]string{"age": "mockkey"},
+	}}
+
+	newServer := func(t *testing.T, onCertificatesReceived transport.OnCertificatesReceivedFunc) (*mocks.MockHTTPServer, *session.MemoryManager) {
+		if onCertificatesReceived == nil {
+			onCertificatesReceived = func(_ string, _ *[]certificates.VerifiableCertificate, _ *http.Request, _ http.ResponseWriter, next func()) {
+				next()
+			}
+		}
+		sessionManager := session.NewMemoryManager()
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), sessionManager,
+			mocks.WithCertificateRequirements(certificateRequirements, onCertificatesReceived)).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+		t.Cleanup(server.Close)
+		return server, sessionManager
+	}
+
+	// handshake returns the initial response requesting certificates
+	handshake := func(t *testing.T, server *mocks.MockHTTPServer) *transport.AuthMessage {
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+		return authMessage
+	}
+
+	// requireAborted checks the response carries the ExchangeAborted message of the server with the reason
+	requireAborted := func(t *testing.T, response *http.Response, reason transport.AbortReason) {
+		require.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+
+		var errResponse transport.ErrorResponse
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&errResponse))
+		require.Equal(t, transport.ErrCodeExchangeAborted, errResponse.Code)
+		require.NotNil(t, errResponse.Aborted)
+		require.Equal(t, key.PubKey().ToDERHex(), errResponse.Aborted.IdentityKey)
+
+		verified, err := transport.VerifyExchangeAborted(clientWallet, errResponse.Aborted)
+		require.NoError(t, err)
+		require.Equal(t, reason, verified)
+	}
+
+	t.Run("shutdown aborts pending exchanges with a signed message", func(t *testing.T) {
+		// given
+		server, sessionManager := newServer(t, nil)
+		authMessage := handshake(t, server)
+
+		// when
+		aborted := server.AuthMiddleware().Shutdown()
+		response, err := server.SendSessionCertificateResponse(t, clientWallet, authMessage, &certs)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, 1, aborted)
+		requireAborted(t, response, transport.AbortReasonShutdown)
+		require.False(t, sessionManager.HasSession(authMessage.InitialNonce))
+	})
+
+	t.Run("policy change aborts pending exchanges", func(t *testing.T) {
+		// given
+		server, _ := newServer(t, nil)
+		authMessage := handshake(t, server)
+
+		// when
+		err := server.AuthMiddleware().UpdateCertificateRequirements(certificateRequirements, transport.CertificateUpgradeRechallenge)
+		require.NoError(t, err)
+		response, err := server.SendSessionCertificateResponse(t, clientWallet, authMessage, &certs)
+
+		// then
+		require.NoError(t, err)
+		requireAborted(t, response, transport.AbortReasonPolicyChanged)
+	})
+
+	t.Run("policy change ignoring existing sessions keeps pending exchanges", func(t *testing.T) {
+		// given
+		server, _ := newServer(t, nil)
+		authMessage := handshake(t, server)
+
+		// when
+		err := server.AuthMiddleware().UpdateCertificateRequirements(certificateRequirements, transport.CertificateUpgradeIgnore)
+		require.NoError(t, err)
+		response, err := server.SendSessionCertificateResponse(t, clientWallet, authMessage, &certs)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+	})
+
+	t.Run("exchange aborted while the certificates are verified is not authenticated", func(t *testing.T) {
+		// given
+		var server *mocks.MockHTTPServer
+		server, sessionManager := newServer(t, func(_ string, _ *[]certificates.VerifiableCertificate, _ *http.Request, _ http.ResponseWriter, next func()) {
+			server.AuthMiddleware().Shutdown()
+			next()
+		})
+		authMessage := handshake(t, server)
+
+		// when
+		response, err := server.SendSessionCertificateResponse(t, clientWallet, authMessage, &certs)
+
+		// then
+		require.NoError(t, err)
+		requireAborted(t, response, transport.AbortReasonShutdown)
+		require.False(t, sessionManager.HasSession(authMessage.InitialNonce))
+	})
+
+	t.Run("client reports the verified reason of the aborted exchange", func(t *testing.T) {
+		// given
+		server, _ := newServer(t, nil)
+		authClient, err := client.New(client.Config{
+			Wallet:  clientWallet,
+			BaseURL: server.URL(),
+			CertificateProvider: client.CertificateProviderFunc(func(context.Context, transport.RequestedCertificateSet, string) ([]certificates.VerifiableCertificate, error) {
+				server.AuthMiddleware().Shutdown()
+				return certs, nil
+			}),
+		})
+		require.NoError(t, err)
+
+		// when
+		err = authClient.Handshake(context.Background())
+
+		// then
+		var abortedErr *transport.ExchangeAbortedError
+		require.ErrorAs(t, err, &abortedErr)
+		require.ErrorIs(t, err, transport.ErrExchangeAborted)
+		require.Equal(t, transport.AbortReasonShutdown, abortedErr.Reason)
+	})
+}
@@ -0,0 +1,123 @@
+package integrationtests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_FeatureFlags(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	clientWallet := mocks.CreateClientMockWallet()
+	clientIdentity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+	identityKey := clientIdentity.PublicKey.ToDERHex()
+
+	newServer := func(t *testing.T, opts ...func(s *mocks.MockHTTPServer) *mocks.MockHTTPServer) *mocks.MockHTTPServer {
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), opts...).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	// capabilities returns the capabilities negotiated by a new client
+	capabilities := func(t *testing.T, server *mocks.MockHTTPServer) []transport.Capability {
+		authClient, err := client.New(client.Config{Wallet: clientWallet, BaseURL: server.URL()})
+		require.NoError(t, err)
+		require.NoError(t, authClient.Handshake(context.Background()))
+		return authClient.Capabilities()
+	}
+
+	tests := map[string]struct {
+		rules    map[transport.Flag]transport.FlagRule
+		expected []transport.Capability
+	}{
+		"flags without a rule are enabled": {
+			expected: []transport.Capability{transport.CapabilityHeartbeat, transport.CapabilityTrailerSignature},
+		},
+		"disabled flag is not negotiated": {
+			rules:    map[transport.Flag]transport.FlagRule{transport.FlagHeartbeat: {}},
+			expected: []transport.Capability{transport.CapabilityTrailerSignature},
+		},
+		"flag is negotiated with its cohort": {
+			rules:    map[transport.Flag]transport.FlagRule{transport.FlagHeartbeat: {Cohort: []string{identityKey}}},
+			expected: []transport.Capability{transport.CapabilityHeartbeat, transport.CapabilityTrailerSignature},
+		},
+		"flag is not negotiated outside its cohort": {
+			rules:    map[transport.Flag]transport.FlagRule{transport.FlagTrailerSignature: {Cohort: []string{transport.AnyoneIdentityKey}}},
+			expected: []transport.Capability{transport.CapabilityHeartbeat},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			server := newServer(t, mocks.WithFeatureFlags(test.rules))
+
+			// then
+			require.Equal(t, test.expected, capabilities(t, server))
+		})
+	}
+
+	t.Run("admin API toggles flags for the following handshakes", func(t *testing.T) {
+		// given
+		server := newServer(t)
+		admin := httptest.NewServer(server.AuthMiddleware().FeatureFlagsHandler())
+		defer admin.Close()
+
+		// when
+		disable, err := http.NewRequest(http.MethodPut, admin.URL+"?flag="+string(transport.FlagHeartbeat), strings.NewReader(`{"percent":0}`))
+		require.NoError(t, err)
+		response, err := http.DefaultClient.Do(disable)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+
+		// then
+		require.Equal(t, http.StatusNoContent, response.StatusCode)
+		require.Equal(t, []transport.Capability{transport.CapabilityTrailerSignature}, capabilities(t, server))
+
+		listed, err := http.Get(admin.URL)
+		require.NoError(t, err)
+		var rules map[transport.Flag]transport.FlagRule
+		require.NoError(t, json.NewDecoder(listed.Body).Decode(&rules))
+		require.NoError(t, listed.Body.Close())
+		require.Equal(t, map[transport.Flag]transport.FlagRule{transport.FlagHeartbeat: {}}, rules)
+
+		// when
+		enable, err := http.NewRequest(http.MethodDelete, admin.URL+"?flag="+string(transport.FlagHeartbeat), nil)
+		require.NoError(t, err)
+		response, err = http.DefaultClient.Do(enable)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+
+		// then
+		require.Equal(t, http.StatusNoContent, response.StatusCode)
+		require.Equal(t, []transport.Capability{transport.CapabilityHeartbeat, transport.CapabilityTrailerSignature}, capabilities(t, server))
+	})
+
+	t.Run("invalid flag rule is rejected", func(t *testing.T) {
+		// when
+		_, err := auth.New(auth.Config{
+			Wallet:       mocks.CreateServerMockWallet(key),
+			FeatureFlags: map[transport.Flag]transport.FlagRule{transport.FlagHeartbeat: {Percent: 101}},
+		})
+
+		// then
+		require.ErrorIs(t, err, transport.ErrInvalidFlagRule)
+	})
+}
@@ -0,0 +1,142 @@
+package integrationtests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_AllowlistExchange(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	serverKey := key.PubKey().ToDERHex()
+
+	gatewayWallet := mocks.CreateClientMockWallet()
+	gatewayKey := wallettest.SeededPrivateKey(wallettest.Seed, "client").PubKey().ToDERHex()
+	billingWallet := wallettest.NewSeededMockWallet(wallettest.Seed, "billing")
+	billingKey := wallettest.SeededPrivateKey(wallettest.Seed, "billing").PubKey().ToDERHex()
+	ledgerKey := wallettest.SeededPrivateKey(wallettest.Seed, "ledger").PubKey().ToDERHex()
+
+	certificateRequirements := transport.NewRequestedCertificateSet(trustedCertifier).AddType(ageVerificationType, "age")
+	onCertificatesReceived := func(_ string, _ *[]certificates.VerifiableCertificate, _ *http.Request, _ http.ResponseWriter, next func()) {
+		next()
+	}
+
+	// setUp returns a server of the deployment offering the ledger service, federated with the partners
+	setUp := func(t *testing.T, partners ...string) (*mocks.MockHTTPServer, *transport.TrustRegistry) {
+		registry, err := transport.NewTrustRegistry(transport.FederationAllowlist{
+			Organization:      "ledger inc",
+			ServiceKeys:       []string{ledgerKey},
+			CertificatePolicy: certificateRequirements,
+		}, partners...)
+		require.NoError(t, err)
+
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(),
+			mocks.WithTrustRegistry(registry), mocks.WithCertificateRequirements(certificateRequirements, onCertificatesReceived)).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+		t.Cleanup(server.Close)
+		return server, registry
+	}
+
+	// partnerRegistry returns the registry of the deployment offering the billing service
+	partnerRegistry := func(t *testing.T, partners ...string) *transport.TrustRegistry {
+		registry, err := transport.NewTrustRegistry(transport.FederationAllowlist{
+			Organization: "billing corp",
+			ServiceKeys:  []string{billingKey},
+		}, partners...)
+		require.NoError(t, err)
+		return registry
+	}
+
+	newClient := func(t *testing.T, server *mocks.MockHTTPServer) *client.Client {
+		authClient, err := client.New(client.Config{Wallet: gatewayWallet, BaseURL: server.URL()})
+		require.NoError(t, err)
+		return authClient
+	}
+
+	t.Run("partners pin each other's allowlists", func(t *testing.T) {
+		// given
+		server, serverRegistry := setUp(t, gatewayKey)
+		registry := partnerRegistry(t, serverKey)
+
+		// when
+		allowlist, err := newClient(t, server).ExchangeAllowlist(context.Background(), registry)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, []string{ledgerKey}, allowlist.ServiceKeys)
+		require.Equal(t, *certificateRequirements, *allowlist.CertificatePolicy)
+		require.True(t, registry.Trusts(ledgerKey))
+
+		partner, ok := serverRegistry.Partner(gatewayKey)
+		require.True(t, ok)
+		require.Equal(t, "billing corp", partner.Allowlist.Organization)
+		require.True(t, serverRegistry.Trusts(billingKey))
+	})
+
+	t.Run("pinned service skips certificate requests", func(t *testing.T) {
+		// given
+		server, _ := setUp(t, gatewayKey)
+		_, err := newClient(t, server).ExchangeAllowlist(context.Background(), partnerRegistry(t, serverKey))
+		require.NoError(t, err)
+
+		// when
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(billingWallet).AuthMessage())
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+		require.Empty(t, authMessage.RequestedCertificates.Types)
+	})
+
+	t.Run("exchange of a peer which is not a partner is rejected", func(t *testing.T) {
+		// given
+		server, serverRegistry := setUp(t)
+
+		// when
+		_, err := newClient(t, server).ExchangeAllowlist(context.Background(), partnerRegistry(t, serverKey))
+
+		// then
+		var serverErr *client.ServerError
+		require.ErrorAs(t, err, &serverErr)
+		require.Equal(t, transport.ErrCodeFederationNotAllowed, serverErr.Code)
+		require.False(t, serverRegistry.Trusts(billingKey))
+	})
+
+	t.Run("server which is not a partner of the client is not sent the allowlist", func(t *testing.T) {
+		// given
+		server, serverRegistry := setUp(t, gatewayKey)
+
+		// when
+		_, err := newClient(t, server).ExchangeAllowlist(context.Background(), partnerRegistry(t))
+
+		// then
+		require.ErrorIs(t, err, transport.ErrFederationNotAllowed)
+		require.False(t, serverRegistry.Trusts(billingKey))
+	})
+
+	t.Run("server without trust registry does not negotiate the exchange", func(t *testing.T) {
+		// given
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager()).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+		defer server.Close()
+
+		// when
+		_, err := newClient(t, server).ExchangeAllowlist(context.Background(), partnerRegistry(t, serverKey))
+
+		// then
+		require.ErrorIs(t, err, client.ErrCapabilityNotNegotiated)
+	})
+}
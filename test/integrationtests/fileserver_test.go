@@ -0,0 +1,148 @@
+package integrationtests
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_FileServer(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	serverIdentityKey := key.PubKey().ToDERHex()
+
+	// larger than the copy buffer of http.ServeContent, so the file is written in several chunks
+	content := bytes.Repeat([]byte("0123456789abcdef"), 8192)
+	digest := sha256.Sum256(content)
+	expectedDigest := "sha-256=:" + base64.StdEncoding.EncodeToString(digest[:]) + ":"
+
+	files := http.FS(fstest.MapFS{"data.bin": &fstest.MapFile{Data: content}})
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager()).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/data.bin", mocks.FileHandler(files).WithAuthMiddleware()).
+		WithHandler("/missing.bin", mocks.FileHandler(files).WithAuthMiddleware())
+	defer server.Close()
+
+	clientWallet := mocks.CreateClientMockWallet()
+	response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+	authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+	require.NoError(t, err)
+
+	get := func(t *testing.T, path, rangeHeader string) (*http.Request, *http.Response, []byte) {
+		request, err := http.NewRequest(http.MethodGet, server.URL()+path, nil)
+		require.NoError(t, err)
+		require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request))
+		if rangeHeader != "" {
+			request.Header.Set("Range", rangeHeader)
+		}
+
+		response, err := server.SendGeneralRequest(t, request)
+		require.NoError(t, err)
+		assert.SignedGeneralResponse(t, clientWallet, response, serverIdentityKey, request)
+
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+		response.Body = io.NopCloser(bytes.NewReader(body))
+		return request, response, body
+	}
+
+	t.Run("complete file is signed with its digest", func(t *testing.T) {
+		// when
+		_, response, body := get(t, "/data.bin", "")
+
+		// then
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, content, body)
+		require.Equal(t, expectedDigest, response.Header.Get(utils.ContentDigestHeader))
+	})
+
+	t.Run("single range is signed with its content range", func(t *testing.T) {
+		// when
+		_, response, body := get(t, "/data.bin", "bytes=10-19")
+
+		// then
+		require.Equal(t, http.StatusPartialContent, response.StatusCode)
+		require.Equal(t, content[10:20], body)
+		require.Equal(t, "bytes 10-19/131072", response.Header.Get(utils.ContentRangeHeader))
+		require.Equal(t, expectedDigest, response.Header.Get(utils.ContentDigestHeader))
+	})
+
+	t.Run("tampered content range is detected", func(t *testing.T) {
+		// given
+		_, response, _ := get(t, "/data.bin", "bytes=10-19")
+
+		// when
+		response.Header.Set(utils.ContentRangeHeader, "bytes 20-29/131072")
+
+		// then
+		assert.TamperedGeneralResponse(t, clientWallet, response, serverIdentityKey)
+	})
+
+	t.Run("multiple ranges are answered with the complete file", func(t *testing.T) {
+		// when
+		_, response, body := get(t, "/data.bin", "bytes=0-9,20-29")
+
+		// then
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, content, body)
+	})
+
+	t.Run("session which negotiated BLAKE3 receives a BLAKE3 digest", func(t *testing.T) {
+		// given
+		blake3Wallet := mocks.CreateClientMockWallet()
+		initialRequest := mocks.PrepareInitialRequestBody(blake3Wallet).AuthMessage()
+		initialRequest.SetCapabilities([]transport.Capability{transport.CapabilityDigestBLAKE3})
+		response, err := server.SendNonGeneralRequest(t, initialRequest)
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		blake3Session, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+		require.Contains(t, blake3Session.Capabilities, transport.CapabilityDigestBLAKE3)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/data.bin", nil)
+		require.NoError(t, err)
+		require.NoError(t, mocks.PrepareGeneralRequestHeaders(blake3Wallet, blake3Session, request))
+
+		// when
+		response, err = server.SendGeneralRequest(t, request)
+		require.NoError(t, err)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+
+		// then
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		digest := response.Header.Get(utils.ContentDigestHeader)
+		require.True(t, strings.HasPrefix(digest, "blake3=:"))
+		require.NoError(t, transport.VerifyContentDigest(digest, body, transport.DigestBLAKE3))
+		// a SHA-256 digest is not accepted by a session which negotiated BLAKE3
+		require.ErrorIs(t, transport.VerifyContentDigest(expectedDigest, body, transport.DigestBLAKE3), transport.ErrContentDigestMismatch)
+	})
+
+	t.Run("missing file has no digest", func(t *testing.T) {
+		// when
+		_, response, _ := get(t, "/missing.bin", "")
+
+		// then
+		require.Equal(t, http.StatusNotFound, response.StatusCode)
+		require.Empty(t, response.Header.Get(utils.ContentDigestHeader))
+	})
+}
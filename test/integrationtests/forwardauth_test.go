@@ -0,0 +1,279 @@
+package integrationtests
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_ForwardAuth(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	body := []byte(`{"item":"coffee"}`)
+
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager()).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithForwardAuth("/forward-auth")
+	defer server.Close()
+
+	clientWallet := mocks.CreateClientMockWallet()
+	initialRequest := mocks.PrepareInitialRequestBody(clientWallet)
+	response, err := server.SendNonGeneralRequest(t, initialRequest.AuthMessage())
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+	authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+	require.NoError(t, err)
+
+	clientIdentity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+
+	forwardAuthRequest := func(t *testing.T, method, uri string) *http.Request {
+		headers, err := utils.PrepareGeneralRequestHeaders(clientWallet, authMessage, utils.RequestData{
+			Method: http.MethodPost,
+			URL:    "http://upstream.local/orders?table=4",
+			Body:   body,
+		})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/forward-auth", bytes.NewReader(body))
+		require.NoError(t, err)
+		for k, v := range headers {
+			request.Header.Set(k, v)
+		}
+		request.Header.Set(auth.ForwardedMethodHeader, method)
+		request.Header.Set(auth.ForwardedURIHeader, uri)
+		return request
+	}
+
+	t.Run("authenticated forwarded request returns identity key", func(t *testing.T) {
+		// given
+		request := forwardAuthRequest(t, http.MethodPost, "/orders?table=4")
+
+		// when
+		response, err := server.SendGeneralRequest(t, request)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		require.Equal(t, clientIdentity.PublicKey.ToDERHex(), response.Header.Get(auth.ForwardAuthIdentityKeyHeader))
+	})
+
+	t.Run("forwarded request with different URI is rejected", func(t *testing.T) {
+		// given
+		request := forwardAuthRequest(t, http.MethodPost, "/orders?table=5")
+
+		// when
+		response, err := server.SendGeneralRequest(t, request)
+
+		// then
+		require.NoError(t, err)
+		assert.NotAuthorized(t, response)
+		assert.UnableToVerifySignatureError(t, response)
+		require.Empty(t, response.Header.Get(auth.ForwardAuthIdentityKeyHeader))
+	})
+
+	t.Run("request without forwarded headers is rejected", func(t *testing.T) {
+		// given
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/forward-auth", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := server.SendGeneralRequest(t, request)
+
+		// then
+		require.NoError(t, err)
+		assert.NotAuthorized(t, response)
+		require.Empty(t, response.Header.Get(auth.ForwardAuthIdentityKeyHeader))
+	})
+}
+
+func TestAuthMiddleware_ForwardAuthPolicies(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	// forwardAuth authenticates a session with a server configured with the options, prepares the server once
+	// the session is authenticated and sends a forwarded request
+	forwardAuth := func(t *testing.T, prepare func(server *mocks.MockHTTPServer), opts ...func(s *mocks.MockHTTPServer) *mocks.MockHTTPServer) *http.Response {
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), opts...).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithForwardAuth("/forward-auth")
+		t.Cleanup(server.Close)
+
+		clientWallet := mocks.CreateClientMockWallet()
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+		prepare(server)
+
+		headers, err := utils.PrepareGeneralRequestHeaders(clientWallet, authMessage, utils.RequestData{
+			Method: http.MethodGet,
+			URL:    "http://upstream.local/admin",
+		})
+		require.NoError(t, err)
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/forward-auth", nil)
+		require.NoError(t, err)
+		for k, v := range headers {
+			request.Header.Set(k, v)
+		}
+		request.Header.Set(auth.ForwardedMethodHeader, http.MethodGet)
+		request.Header.Set(auth.ForwardedURIHeader, "/admin")
+
+		response, err = server.SendGeneralRequest(t, request)
+		require.NoError(t, err)
+		return response
+	}
+
+	t.Run("request denied by the authorizer is rejected", func(t *testing.T) {
+		// given
+		var path string
+		authorizer := auth.AuthorizerFunc(func(_ context.Context, in auth.AuthorizationInput) (auth.Decision, error) {
+			path = in.Request.Path
+			return auth.Decision{Reason: "admin is reserved for operators"}, nil
+		})
+
+		// when
+		response := forwardAuth(t, func(*mocks.MockHTTPServer) {}, mocks.WithAuthorizer(authorizer))
+
+		// then
+		assert.ErrorResponseCode(t, response, http.StatusForbidden, transport.ErrCodeForbidden)
+		require.Empty(t, response.Header.Get(auth.ForwardAuthIdentityKeyHeader))
+		require.Equal(t, "/admin", path)
+	})
+
+	t.Run("request during maintenance is rejected", func(t *testing.T) {
+		// given
+		maintenance := func(server *mocks.MockHTTPServer) {
+			server.AuthMiddleware().StartMaintenance(time.Minute)
+		}
+
+		// when
+		response := forwardAuth(t, maintenance)
+
+		// then
+		require.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+		require.Empty(t, response.Header.Get(auth.ForwardAuthIdentityKeyHeader))
+	})
+
+	t.Run("request of a blocklisted address is rejected", func(t *testing.T) {
+		// given
+		blockLoopback := func(server *mocks.MockHTTPServer) {
+			require.NoError(t, server.AuthMiddleware().Blocklist().Replace([]string{"127.0.0.0/8", "::1"}))
+		}
+
+		// when
+		response := forwardAuth(t, blockLoopback)
+
+		// then
+		assert.ErrorResponseCode(t, response, http.StatusForbidden, transport.ErrCodeBlocked)
+		require.Empty(t, response.Header.Get(auth.ForwardAuthIdentityKeyHeader))
+	})
+
+	t.Run("request of a locked out address is rejected", func(t *testing.T) {
+		// given
+		failForwardedRequests := func(server *mocks.MockHTTPServer) {
+			for range 2 {
+				request, err := http.NewRequest(http.MethodGet, server.URL()+"/forward-auth", nil)
+				require.NoError(t, err)
+				request.Header.Set(auth.ForwardedMethodHeader, http.MethodGet)
+				request.Header.Set(auth.ForwardedURIHeader, "/admin")
+
+				response, err := server.SendGeneralRequest(t, request)
+				require.NoError(t, err)
+				assert.NotAuthorized(t, response)
+			}
+		}
+
+		// when
+		response := forwardAuth(t, failForwardedRequests, mocks.WithAnomalyPolicy(auth.AnomalyPolicy{AddressThreshold: 2, AddressLockout: time.Minute}))
+
+		// then
+		require.Equal(t, http.StatusTooManyRequests, response.StatusCode)
+		require.Empty(t, response.Header.Get(auth.ForwardAuthIdentityKeyHeader))
+	})
+}
+
+func TestAuthMiddleware_ForwardAuthUpstreamHeaders(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	cfg := auth.ForwardAuthConfig{
+		Headers: []auth.UpstreamHeader{
+			{Name: "X-User-Key", Attribute: auth.AttributeIdentityKey},
+			{Name: "X-User-Country", Attribute: "certificate." + ageVerificationType + ".country"},
+			{Name: "X-User-Email", Attribute: "certificate.email.address"},
+		},
+		HMACKey: []byte("upstream-secret"),
+	}
+
+	sessionManager := session.NewMemoryManager()
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), sessionManager, mocks.WithForwardAuthConfig(cfg)).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithForwardAuth("/forward-auth")
+	defer server.Close()
+
+	clientWallet := mocks.CreateClientMockWallet()
+	initialRequest := mocks.PrepareInitialRequestBody(clientWallet)
+	response, err := server.SendNonGeneralRequest(t, initialRequest.AuthMessage())
+	require.NoError(t, err)
+	authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+	require.NoError(t, err)
+
+	peerSession := sessionManager.GetSession(authMessage.InitialNonce)
+	require.NotNil(t, peerSession)
+	peerSession.Certificates = []certificates.VerifiableCertificate{{
+		Certificate: certificates.Certificate{
+			Type:   ageVerificationType,
+			Fields: map[string]any{"age": "21", "country": "Switzerland"},
+		},
+	}}
+	sessionManager.UpdateSession(*peerSession)
+
+	headers, err := utils.PrepareGeneralRequestHeaders(clientWallet, authMessage, utils.RequestData{
+		Method: http.MethodGet,
+		URL:    "http://upstream.local/profile",
+	})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodGet, server.URL()+"/forward-auth", nil)
+	require.NoError(t, err)
+	for k, v := range headers {
+		request.Header.Set(k, v)
+	}
+	request.Header.Set(auth.ForwardedMethodHeader, http.MethodGet)
+	request.Header.Set(auth.ForwardedURIHeader, "/profile")
+
+	clientIdentity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+
+	// when
+	response, err = server.SendGeneralRequest(t, request)
+
+	// then
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+	require.Equal(t, clientIdentity.PublicKey.ToDERHex(), response.Header.Get("X-User-Key"))
+	require.Equal(t, "Switzerland", response.Header.Get("X-User-Country"))
+	require.Empty(t, response.Header.Get("X-User-Email"))
+	require.Empty(t, response.Header.Get(auth.ForwardAuthIdentityKeyHeader))
+	require.NoError(t, cfg.VerifyUpstreamHeaders(response.Header, time.Minute))
+
+	response.Header.Set("X-User-Country", "Narnia")
+	require.ErrorIs(t, cfg.VerifyUpstreamHeaders(response.Header, time.Minute), auth.ErrInvalidUpstreamSignature)
+}
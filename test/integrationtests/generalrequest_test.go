@@ -4,10 +4,10 @@ import (
 	"net/http"
 	"testing"
 
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/sessionmanager"
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet"
-	walletFixtures "github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet/test"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
 	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
 	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
 	"github.com/stretchr/testify/require"
@@ -132,8 +132,13 @@ func TestAuthMiddleware_GeneralRequest_SessionManager(t *testing.T) {
 		err = mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request)
 		require.NoError(t, err)
 
+		clientIdentityKey := wallettest.ClientIdentityKey
 		serverWallet.OnVerifyNonceOnce(true, nil)
-		sessionManager.OnGetSessionOnce(authMessage.InitialNonce, &sessionmanager.PeerSession{IsAuthenticated: false})
+		sessionManager.OnGetSessionOnce(authMessage.InitialNonce, &session.PeerSession{
+			IsAuthenticated: false,
+			SessionNonce:    &authMessage.InitialNonce,
+			PeerIdentityKey: &clientIdentityKey,
+		})
 
 		// when
 		response, err := server.SendGeneralRequest(t, request)
@@ -317,8 +322,8 @@ func prepareInitialRequest(
 
 	// given
 	initialRequest := mocks.PrepareInitialRequestBody(clientWallet)
-	serverWallet.OnCreateNonceOnce(walletFixtures.DefaultNonces[0], nil)
-	serverWallet.OnCreateSignatureOnce(prepareExampleSignature(t), nil)
+	serverWallet.OnCreateNonceOnce(wallettest.DefaultNonces[0], nil)
+	serverWallet.OnCreateSignatureBySignerOnce(prepareExampleSigner(t))
 	serverWallet.OnGetPublicKeyOnce(prepareExampleIdentityKey(t), nil)
 
 	// when
@@ -331,7 +336,7 @@ func prepareInitialRequest(
 
 	authMessage, err := mocks.MapBodyToAuthMessage(t, response)
 	require.NoError(t, err)
-	assert.InitialResponseAuthMessage(t, authMessage)
+	assert.InitialResponseAuthMessage(t, clientWallet, authMessage)
 
 	return authMessage
 }
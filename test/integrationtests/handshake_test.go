@@ -4,7 +4,7 @@ import (
 	"errors"
 	"testing"
 
-	walletFixtures "github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet/test"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
 	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
 	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
 	"github.com/stretchr/testify/require"
@@ -21,8 +21,8 @@ func TestHandshakeHappyPath(t *testing.T) {
 	clientWallet := mocks.CreateClientMockWallet()
 	initialRequest := mocks.PrepareInitialRequestBody(clientWallet)
 
-	serverWallet.OnCreateNonceOnce(walletFixtures.DefaultNonces[0], nil)
-	serverWallet.OnCreateSignatureOnce(prepareExampleSignature(t), nil)
+	serverWallet.OnCreateNonceOnce(wallettest.DefaultNonces[0], nil)
+	serverWallet.OnCreateSignatureBySignerOnce(prepareExampleSigner(t))
 	serverWallet.OnGetPublicKeyOnce(prepareExampleIdentityKey(t), nil)
 
 	// when
@@ -35,7 +35,7 @@ func TestHandshakeHappyPath(t *testing.T) {
 
 	authMessage, err := mocks.MapBodyToAuthMessage(t, response)
 	require.NoError(t, err)
-	assert.InitialResponseAuthMessage(t, authMessage)
+	assert.InitialResponseAuthMessage(t, clientWallet, authMessage)
 
 	session := sessionManager.GetSession(initialRequest.IdentityKey)
 	require.NotNil(t, session, "Session should have been created with client's identity key")
@@ -156,8 +156,8 @@ func TestReplayAttack(t *testing.T) {
 	initialRequest := mocks.PrepareInitialRequestBody(clientWallet)
 
 	// First request should succeed
-	serverWallet.OnCreateNonceOnce(walletFixtures.DefaultNonces[0], nil)
-	serverWallet.OnCreateSignatureOnce(prepareExampleSignature(t), nil)
+	serverWallet.OnCreateNonceOnce(wallettest.DefaultNonces[0], nil)
+	serverWallet.OnCreateSignatureBySignerOnce(prepareExampleSigner(t))
 	serverWallet.OnGetPublicKeyOnce(prepareExampleIdentityKey(t), nil)
 
 	// when
@@ -0,0 +1,128 @@
+package integrationtests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/websocket"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_Heartbeat(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	clientWallet := mocks.CreateClientMockWallet()
+	clientIdentity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+	identityKey := clientIdentity.PublicKey.ToDERHex()
+
+	// setUp returns a server with an idle session of the client
+	setUp := func(t *testing.T, cfg client.Config) (*mocks.MockHTTPServer, *session.MemoryManager, *client.Client) {
+		sessionManager := session.NewMemoryManager()
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), sessionManager).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/ws", mocks.WebSocketHandler().WithAuthMiddleware())
+		t.Cleanup(server.Close)
+
+		cfg.Wallet, cfg.BaseURL = clientWallet, server.URL()
+		authClient, err := client.New(cfg)
+		require.NoError(t, err)
+		require.NoError(t, authClient.Handshake(context.Background()))
+
+		peerSession := sessionManager.GetSession(identityKey)
+		require.NotNil(t, peerSession)
+		peerSession.LastUpdate = time.Now().Add(-time.Hour)
+		sessionManager.UpdateSession(*peerSession)
+
+		return server, sessionManager, authClient
+	}
+
+	t.Run("heartbeat over HTTP refreshes the session", func(t *testing.T) {
+		// given
+		_, sessionManager, authClient := setUp(t, client.Config{})
+
+		// when
+		err := authClient.Heartbeat(context.Background())
+
+		// then
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now(), sessionManager.GetSession(identityKey).LastUpdate, time.Minute)
+	})
+
+	t.Run("heartbeat over the socket is acknowledged without reaching the handler", func(t *testing.T) {
+		// given
+		_, sessionManager, authClient := setUp(t, client.Config{})
+		conn, err := authClient.DialWebSocket(context.Background(), "/ws")
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+
+		peerSession := sessionManager.GetSession(identityKey)
+		peerSession.LastUpdate = time.Now().Add(-time.Hour)
+		sessionManager.UpdateSession(*peerSession)
+
+		// when
+		require.NoError(t, authClient.HeartbeatWebSocket(context.Background(), conn))
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("hello")))
+		_, reply, err := conn.ReadMessage()
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, identityKey+": hello", string(reply))
+		require.WithinDuration(t, time.Now(), sessionManager.GetSession(identityKey).LastUpdate, time.Minute)
+	})
+
+	t.Run("stale heartbeat is rejected", func(t *testing.T) {
+		// given
+		_, sessionManager, authClient := setUp(t, client.Config{Clock: func() time.Time { return time.Now().Add(-10 * time.Minute) }})
+
+		// when
+		err := authClient.Heartbeat(context.Background())
+
+		// then
+		var serverErr *client.ServerError
+		require.ErrorAs(t, err, &serverErr)
+		require.Equal(t, transport.ErrCodeStaleHeartbeat, serverErr.Code)
+		require.True(t, sessionManager.GetSession(identityKey).LastUpdate.Before(time.Now().Add(-time.Minute)))
+	})
+
+	t.Run("replayed heartbeat is rejected", func(t *testing.T) {
+		// given
+		server, sessionManager, _ := setUp(t, client.Config{})
+		peerSession := sessionManager.GetSession(identityKey)
+		heartbeat, err := transport.SignHeartbeat(context.Background(), clientWallet, key.PubKey().ToDERHex(), *peerSession.SessionNonce, time.Now())
+		require.NoError(t, err)
+
+		response, err := server.SendNonGeneralRequest(t, heartbeat)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+
+		// when
+		response, err = server.SendNonGeneralRequest(t, heartbeat)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+	})
+
+	t.Run("heartbeat of a removed session reports it expired", func(t *testing.T) {
+		// given
+		_, sessionManager, authClient := setUp(t, client.Config{})
+		sessionManager.RemoveSession(*sessionManager.GetSession(identityKey))
+
+		// when
+		err := authClient.Heartbeat(context.Background())
+
+		// then
+		require.ErrorIs(t, err, client.ErrSessionExpired)
+	})
+}
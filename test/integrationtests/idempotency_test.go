@@ -0,0 +1,189 @@
+package integrationtests
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_ReplayedRequestIsRejected(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager()).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	clientWallet := mocks.CreateClientMockWallet()
+	initialRequest := mocks.PrepareInitialRequestBody(clientWallet)
+	response, err := server.SendNonGeneralRequest(t, initialRequest.AuthMessage())
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+	authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+	require.NoError(t, err)
+
+	url := server.URL() + "/ping"
+	headers, err := utils.PrepareGeneralRequestHeaders(clientWallet, authMessage, utils.RequestData{
+		Method: http.MethodGet,
+		URL:    url,
+	})
+	require.NoError(t, err)
+
+	newRequest := func() *http.Request {
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		for k, v := range headers {
+			request.Header.Set(k, v)
+		}
+		return request
+	}
+
+	response, err = server.SendGeneralRequest(t, newRequest())
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+
+	// when
+	response, err = server.SendGeneralRequest(t, newRequest())
+
+	// then
+	require.NoError(t, err)
+	assert.NotAuthorized(t, response)
+	assert.RequestReplayedError(t, response)
+}
+
+func TestAuthMiddleware_IdempotencyKeys(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	newServer := func(calls *atomic.Int32, opts ...func(s *mocks.MockHTTPServer) *mocks.MockHTTPServer) *mocks.MockHTTPServer {
+		return mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), opts...).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/orders", mocks.CountingHandler(calls).WithAuthMiddleware())
+	}
+
+	send := func(t *testing.T, authClient *client.Client, url, idempotencyKey, body string) (*http.Response, error) {
+		request, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		require.NoError(t, err)
+		request.Header.Set(auth.IdempotencyKeyHeader, idempotencyKey)
+		return authClient.Do(request)
+	}
+
+	t.Run("retry with idempotency key returns the first response", func(t *testing.T) {
+		// given
+		var calls atomic.Int32
+		server := newServer(&calls, mocks.WithIdempotencyKeys)
+		defer server.Close()
+
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL()})
+		require.NoError(t, err)
+
+		first, err := send(t, authClient, server.URL()+"/orders", "order-1", `{"item":"coffee"}`)
+		require.NoError(t, err)
+		assert.ResponseOK(t, first)
+		firstBody, err := io.ReadAll(first.Body)
+		require.NoError(t, err)
+
+		// when
+		retry, err := send(t, authClient, server.URL()+"/orders", "order-1", `{"item":"coffee"}`)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, retry)
+		retryBody, err := io.ReadAll(retry.Body)
+		require.NoError(t, err)
+		require.Equal(t, firstBody, retryBody)
+		require.Equal(t, "true", retry.Header.Get(auth.IdempotentReplayHeader))
+		require.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("idempotency key reused for a different request", func(t *testing.T) {
+		// given
+		var calls atomic.Int32
+		server := newServer(&calls, mocks.WithIdempotencyKeys)
+		defer server.Close()
+
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL()})
+		require.NoError(t, err)
+
+		response, err := send(t, authClient, server.URL()+"/orders", "order-1", `{"item":"coffee"}`)
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+
+		// when
+		response, err = send(t, authClient, server.URL()+"/orders", "order-1", `{"item":"tea"}`)
+
+		// then
+		require.Nil(t, response)
+		var serverErr *client.ServerError
+		require.True(t, errors.As(err, &serverErr))
+		require.Equal(t, http.StatusUnprocessableEntity, serverErr.StatusCode)
+		require.Equal(t, transport.ErrCodeIdempotencyKeyMismatch, serverErr.Code)
+		require.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("request whose handler panicked can be retried", func(t *testing.T) {
+		// given
+		var calls atomic.Int32
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), mocks.WithIdempotencyKeys).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/orders", mocks.PanicOnceHandler(&calls).WithAuthMiddleware())
+		defer server.Close()
+
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL()})
+		require.NoError(t, err)
+
+		_, err = send(t, authClient, server.URL()+"/orders", "order-1", `{"item":"coffee"}`)
+		require.Error(t, err)
+
+		// when
+		retry, err := send(t, authClient, server.URL()+"/orders", "order-1", `{"item":"coffee"}`)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, retry)
+		body, err := io.ReadAll(retry.Body)
+		require.NoError(t, err)
+		require.Equal(t, "call 2", string(body))
+	})
+
+	t.Run("idempotency key is ignored when extension is disabled", func(t *testing.T) {
+		// given
+		var calls atomic.Int32
+		server := newServer(&calls)
+		defer server.Close()
+
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL()})
+		require.NoError(t, err)
+
+		response, err := send(t, authClient, server.URL()+"/orders", "order-1", `{"item":"coffee"}`)
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+
+		// when
+		response, err = send(t, authClient, server.URL()+"/orders", "order-1", `{"item":"coffee"}`)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.Equal(t, "call 2", string(body))
+		require.Empty(t, response.Header.Get(auth.IdempotentReplayHeader))
+	})
+}
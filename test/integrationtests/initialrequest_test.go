@@ -3,8 +3,8 @@ package integrationtests
 import (
 	"testing"
 
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet"
-	walletFixtures "github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet/test"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
 	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
 	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
 	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
@@ -25,8 +25,8 @@ func TestAuthMiddleware_InitialRequest_HappyPath(t *testing.T) {
 	t.Run("call initial request", func(t *testing.T) {
 		// given
 		initialRequest := mocks.PrepareInitialRequestBody(clientWallet)
-		serverWallet.OnCreateNonceOnce(walletFixtures.DefaultNonces[0], nil)
-		serverWallet.OnCreateSignatureOnce(prepareExampleSignature(t), nil)
+		serverWallet.OnCreateNonceOnce(wallettest.DefaultNonces[0], nil)
+		serverWallet.OnCreateSignatureBySignerOnce(prepareExampleSigner(t))
 		serverWallet.OnGetPublicKeyOnce(prepareExampleIdentityKey(t), nil)
 
 		// when
@@ -39,22 +39,19 @@ func TestAuthMiddleware_InitialRequest_HappyPath(t *testing.T) {
 
 		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
 		require.NoError(t, err)
-		assert.InitialResponseAuthMessage(t, authMessage)
+		assert.InitialResponseAuthMessage(t, clientWallet, authMessage)
 	})
 
 }
 
-func prepareExampleSignature(t *testing.T) *wallet.CreateSignatureResult {
-	key, err := ec.PrivateKeyFromHex(walletFixtures.ServerPrivateKeyHex)
+func prepareExampleSigner(t *testing.T) wallet.WalletInterface {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
 	require.NoError(t, err)
-	signature, err := key.Sign([]byte("test signature"))
-	require.NoError(t, err)
-
-	return &wallet.CreateSignatureResult{Signature: *signature}
+	return wallettest.NewMockWallet(key)
 }
 
 func prepareExampleIdentityKey(t *testing.T) *wallet.GetPublicKeyResult {
-	key, err := ec.PrivateKeyFromHex(walletFixtures.ServerPrivateKeyHex)
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
 	require.NoError(t, err)
 	return &wallet.GetPublicKeyResult{
 		PublicKey: key.PubKey(),
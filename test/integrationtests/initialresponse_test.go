@@ -0,0 +1,79 @@
+package integrationtests
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_InitialResponseHook(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	type tenantHint struct {
+		Tenant   string   `json:"tenant"`
+		Features []string `json:"features"`
+	}
+
+	t.Run("extensions set by the hook are received by the client", func(t *testing.T) {
+		// given
+		var hookSession session.PeerSession
+		var hookErr error
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), nil,
+			mocks.WithInitialResponseHook(func(_ context.Context, peerSession session.PeerSession, msg *transport.AuthMessage) {
+				hookSession = peerSession
+				hookErr = msg.SetExtension("tenantHint", tenantHint{Tenant: "acme", Features: []string{"beta"}})
+			})).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+		defer server.Close()
+
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL()})
+		require.NoError(t, err)
+
+		// when
+		err = authClient.Handshake(context.Background())
+
+		// then
+		require.NoError(t, err)
+		require.NoError(t, hookErr)
+		var hint tenantHint
+		found, err := authClient.HandshakeExtension("tenantHint", &hint)
+		require.NoError(t, err)
+		require.True(t, found)
+		require.Equal(t, tenantHint{Tenant: "acme", Features: []string{"beta"}}, hint)
+		require.NotNil(t, hookSession.PeerIdentityKey)
+		require.NotNil(t, hookSession.SessionNonce)
+	})
+
+	t.Run("fields of the handshake changed by the hook are not sent", func(t *testing.T) {
+		// given
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), nil,
+			mocks.WithInitialResponseHook(func(_ context.Context, _ session.PeerSession, msg *transport.AuthMessage) {
+				msg.IdentityKey = "tampered"
+				msg.Capabilities = nil
+			})).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+		defer server.Close()
+
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL()})
+		require.NoError(t, err)
+
+		// when
+		err = authClient.Handshake(context.Background())
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, key.PubKey().ToDERHex(), authClient.ServerIdentityKey())
+		require.Equal(t, []transport.Capability{transport.CapabilityHeartbeat, transport.CapabilityTrailerSignature}, authClient.Capabilities())
+		found, err := authClient.HandshakeExtension("tenantHint", &tenantHint{})
+		require.NoError(t, err)
+		require.False(t, found)
+	})
+}
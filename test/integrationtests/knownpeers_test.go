@@ -0,0 +1,153 @@
+package integrationtests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_KnownPeers(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	clientWallet := mocks.CreateClientMockWallet()
+	clientIdentity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+	identityKey := clientIdentity.PublicKey.ToDERHex()
+
+	certificateRequirements := transport.NewRequestedCertificateSet(trustedCertifier).AddType(ageVerificationType, "age")
+	onCertificatesReceived := func(_ string, _ *[]certificates.VerifiableCertificate, _ *http.Request, _ http.ResponseWriter, next func()) {
+		next()
+	}
+	newServer := func(t *testing.T, opts ...func(s *mocks.MockHTTPServer) *mocks.MockHTTPServer) *mocks.MockHTTPServer {
+		opts = append(opts, mocks.WithCertificateRequirements(certificateRequirements, onCertificatesReceived))
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), opts...).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	// handshake returns the initial response to the client
+	handshake := func(t *testing.T, server *mocks.MockHTTPServer) *transport.AuthMessage {
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+		return authMessage
+	}
+
+	ping := func(t *testing.T, server *mocks.MockHTTPServer, authMessage *transport.AuthMessage, opts ...func(m map[string]string)) *http.Response {
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+		require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request, opts...))
+		response, err := server.SendGeneralRequest(t, request)
+		require.NoError(t, err)
+		return response
+	}
+
+	t.Run("known peer skips certificate requests", func(t *testing.T) {
+		// given
+		server := newServer(t, mocks.WithKnownPeers(transport.KnownPeer{IdentityKey: identityKey, Name: "billing"}))
+
+		// when
+		authMessage := handshake(t, server)
+		response := ping(t, server, authMessage)
+
+		// then
+		require.Empty(t, authMessage.RequestedCertificates.Types)
+		assert.ResponseOK(t, response)
+	})
+
+	t.Run("unknown peer is requested certificates", func(t *testing.T) {
+		// given
+		server := newServer(t)
+
+		// when
+		authMessage := handshake(t, server)
+		response := ping(t, server, authMessage)
+
+		// then
+		require.Equal(t, *certificateRequirements, authMessage.RequestedCertificates)
+		assert.NotAuthorized(t, response)
+	})
+
+	t.Run("known peer is exempt from identity lockouts", func(t *testing.T) {
+		// given
+		server := newServer(t,
+			mocks.WithKnownPeers(transport.KnownPeer{IdentityKey: identityKey}),
+			mocks.WithAnomalyPolicy(auth.AnomalyPolicy{IdentityThreshold: 2, IdentityLockout: 10 * time.Minute}))
+		authMessage := handshake(t, server)
+
+		// when
+		for range 2 {
+			assert.NotAuthorized(t, ping(t, server, authMessage, mocks.WithWrongSignature))
+		}
+		response := ping(t, server, authMessage)
+
+		// then
+		assert.ResponseOK(t, response)
+	})
+
+	t.Run("admin API registers and removes known peers", func(t *testing.T) {
+		// given
+		server := newServer(t)
+		admin := httptest.NewServer(server.AuthMiddleware().KnownPeersHandler())
+		defer admin.Close()
+
+		// when
+		register, err := http.NewRequest(http.MethodPut, admin.URL, strings.NewReader(`{"identityKey":"`+identityKey+`","name":"billing"}`))
+		require.NoError(t, err)
+		response, err := http.DefaultClient.Do(register)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+
+		// then
+		require.Equal(t, http.StatusNoContent, response.StatusCode)
+		require.Empty(t, handshake(t, server).RequestedCertificates.Types)
+
+		listed, err := http.Get(admin.URL)
+		require.NoError(t, err)
+		var peers []transport.KnownPeer
+		require.NoError(t, json.NewDecoder(listed.Body).Decode(&peers))
+		require.NoError(t, listed.Body.Close())
+		require.Equal(t, []transport.KnownPeer{{IdentityKey: identityKey, Name: "billing"}}, peers)
+
+		// when
+		remove, err := http.NewRequest(http.MethodDelete, admin.URL+"?identityKey="+identityKey, nil)
+		require.NoError(t, err)
+		response, err = http.DefaultClient.Do(remove)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+
+		// then
+		require.Equal(t, http.StatusNoContent, response.StatusCode)
+		require.Equal(t, *certificateRequirements, handshake(t, server).RequestedCertificates)
+	})
+
+	t.Run("invalid known peer is rejected", func(t *testing.T) {
+		// when
+		_, err := auth.New(auth.Config{
+			Wallet:     mocks.CreateServerMockWallet(key),
+			KnownPeers: []transport.KnownPeer{{IdentityKey: "not a key"}},
+		})
+
+		// then
+		require.ErrorIs(t, err, transport.ErrInvalidKnownPeer)
+	})
+}
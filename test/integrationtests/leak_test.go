@@ -0,0 +1,219 @@
+package integrationtests
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/websocket"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	// leakCycles is the number of peers cycled through handshakes, requests and expired sessions
+	leakCycles = 2000
+	// leakWindow is the window of the replay guard, idempotency cache, anomaly and block counters
+	leakWindow = 100 * time.Millisecond
+	// heapTolerance absorbs the noise of the allocator, state retained for every cycled peer exceeds it
+	// from about 64 bytes per peer, e.g. request IDs the replay guard never prunes
+	heapTolerance = 128 << 10
+)
+
+// TestAuthMiddleware_NoLeaks cycles thousands of peers through handshakes, signed requests, idempotent requests,
+// rejected requests and WebSockets, expires their sessions and asserts the heap and the goroutines return to their
+// baseline, so the windowed state of the middleware is dropped and its background work ends
+func TestAuthMiddleware_NoLeaks(t *testing.T) {
+	if testing.Short() {
+		t.Skip("cycles thousands of peers")
+	}
+
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	sessions := session.NewMemoryManager()
+
+	middleware, err := auth.New(auth.Config{
+		Wallet:            newStatelessNonceWallet(t, key),
+		SessionManager:    sessions,
+		Logger:            slog.New(slog.DiscardHandler),
+		ReplayWindow:      leakWindow,
+		IdempotencyKeyTTL: leakWindow,
+		WalletTimeouts:    transport.WalletTimeouts{CreateNonce: time.Second, CreateSignature: time.Second, VerifySignature: time.Second},
+		Anomalies:         &auth.AnomalyPolicy{IdentityThreshold: 10, AddressThreshold: 1 << 20, Window: leakWindow},
+		Blocking:          &auth.BlockPolicy{Sink: auth.NewFail2banSink(io.Discard), VerificationFailures: 1 << 20, Handshakes: 1 << 20, Window: leakWindow},
+	})
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.Handle("/ws", auth.WebSocketHandler(func(conn *websocket.Conn) {
+		for {
+			messageType, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := conn.WriteMessage(messageType, message); err != nil {
+				return
+			}
+		}
+	}))
+	mux.HandleFunc("/", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	server := httptest.NewServer(middleware.Handler(mux))
+	defer server.Close()
+
+	httpTransport := &http.Transport{}
+	httpClient := &http.Client{Transport: httpTransport}
+
+	cycle := func(t *testing.T, i int) {
+		peerKey, err := ec.NewPrivateKey()
+		require.NoError(t, err)
+		peerWallet := wallettest.NewRandomMockWallet(peerKey, nil)
+		authClient, err := client.New(client.Config{Wallet: peerWallet, BaseURL: server.URL, HTTPClient: httpClient})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodPost, server.URL+"/items", strings.NewReader(`{"item":1}`))
+		require.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set(auth.IdempotencyKeyHeader, fmt.Sprintf("cycle-%d", i))
+		response, err := authClient.Do(request)
+		require.NoError(t, err)
+		_, _ = io.Copy(io.Discard, response.Body)
+		require.NoError(t, response.Body.Close())
+
+		if i%10 == 0 {
+			conn, err := authClient.DialWebSocket(context.Background(), "/ws")
+			require.NoError(t, err)
+			require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("ping")))
+			_, _, err = conn.ReadMessage()
+			require.NoError(t, err)
+			require.NoError(t, conn.Close())
+		}
+
+		if i%5 == 0 {
+			// a request signed for a session the server never created counts towards the anomaly and block windows
+			unknown, err := http.NewRequest(http.MethodGet, server.URL+"/items", nil)
+			require.NoError(t, err)
+			unknown.Header.Set("x-bsv-auth-version", "0.1")
+			unknown.Header.Set("x-bsv-auth-identity-key", peerKey.PubKey().ToDERHex())
+			unknown.Header.Set("x-bsv-auth-nonce", base64.StdEncoding.EncodeToString(make([]byte, 32)))
+			unknown.Header.Set("x-bsv-auth-your-nonce", base64.StdEncoding.EncodeToString(make([]byte, 32)))
+			unknown.Header.Set("x-bsv-auth-request-id", base64.StdEncoding.EncodeToString(make([]byte, 32)))
+			unknown.Header.Set("x-bsv-auth-signature", "00")
+			response, err := httpClient.Do(unknown)
+			require.NoError(t, err)
+			_, _ = io.Copy(io.Discard, response.Body)
+			require.NoError(t, response.Body.Close())
+			require.GreaterOrEqual(t, response.StatusCode, http.StatusBadRequest)
+		}
+
+		// the session expires, the store drops it
+		for peerSession := sessions.GetSession(peerKey.PubKey().ToDERHex()); peerSession != nil; peerSession = sessions.GetSession(peerKey.PubKey().ToDERHex()) {
+			sessions.RemoveSession(*peerSession)
+		}
+	}
+
+	// expire lets the windows pass and sends one more cycle, so windowed state is pruned on its next use
+	expire := func(t *testing.T, i int) {
+		time.Sleep(2 * leakWindow)
+		cycle(t, i)
+		httpTransport.CloseIdleConnections()
+		server.CloseClientConnections()
+	}
+
+	// warm up pools, caches and lazily started goroutines before the baseline is taken
+	for i := range 50 {
+		cycle(t, i)
+	}
+	expire(t, -1)
+	baselineHeap, baselineGoroutines := heapAndGoroutines(t, 0)
+
+	// when
+	for i := range leakCycles {
+		cycle(t, i)
+	}
+	expire(t, -2)
+
+	// then
+	require.Empty(t, sessions.Sessions())
+	// goroutines ending with closed connections are given time to return, require.Eventually would add its own
+	heap, goroutines := heapAndGoroutines(t, 0)
+	for deadline := time.Now().Add(5 * time.Second); goroutines > baselineGoroutines && time.Now().Before(deadline); {
+		time.Sleep(50 * time.Millisecond)
+		heap, goroutines = heapAndGoroutines(t, 0)
+	}
+	_, goroutines = heapAndGoroutines(t, baselineGoroutines)
+	require.LessOrEqual(t, goroutines, baselineGoroutines, "goroutines did not return to the baseline")
+	require.LessOrEqual(t, heap, baselineHeap+heapTolerance,
+		"heap grew by %d bytes over %d cycles", int64(heap)-int64(baselineHeap), leakCycles)
+}
+
+// heapAndGoroutines returns the live heap after a garbage collection and the number of goroutines,
+// goroutines above the expected number are logged to point at the leak
+func heapAndGoroutines(t *testing.T, expectedGoroutines int) (uint64, int) {
+	runtime.GC()
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	goroutines := runtime.NumGoroutine()
+	if expectedGoroutines > 0 && goroutines > expectedGoroutines {
+		buf := make([]byte, 1<<20)
+		t.Logf("%d goroutines, expected %d:\n%s", goroutines, expectedGoroutines, buf[:runtime.Stack(buf, true)])
+	}
+	return stats.HeapAlloc, goroutines
+}
+
+// statelessNonceWallet creates nonces verified by their HMAC like a BRC-100 wallet does,
+// the mock wallet remembers every nonce it created, which would be reported as a leak of the middleware
+type statelessNonceWallet struct {
+	wallet.WalletInterface
+	secret []byte
+}
+
+func newStatelessNonceWallet(t *testing.T, key *ec.PrivateKey) *statelessNonceWallet {
+	secret := make([]byte, 32)
+	_, err := rand.Read(secret)
+	require.NoError(t, err)
+	return &statelessNonceWallet{WalletInterface: wallettest.NewRandomMockWallet(key, nil), secret: secret}
+}
+
+func (w *statelessNonceWallet) CreateNonce(context.Context) (string, error) {
+	nonce := make([]byte, 16, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(append(nonce, w.mac(nonce)...)), nil
+}
+
+func (w *statelessNonceWallet) VerifyNonce(_ context.Context, nonce string) (bool, error) {
+	decoded, err := base64.StdEncoding.DecodeString(nonce)
+	if err != nil || len(decoded) != 32 {
+		return false, nil
+	}
+	return hmac.Equal(decoded[16:], w.mac(decoded[:16])), nil
+}
+
+func (w *statelessNonceWallet) mac(nonce []byte) []byte {
+	h := hmac.New(sha256.New, w.secret)
+	h.Write(nonce)
+	return h.Sum(nil)[:16]
+}
@@ -0,0 +1,114 @@
+package integrationtests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_Maintenance(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	newServer := func() *mocks.MockHTTPServer {
+		return mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager()).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+	}
+
+	t.Run("protected route responds with signed 503 while handshake keeps working", func(t *testing.T) {
+		// given
+		server := newServer()
+		defer server.Close()
+		server.AuthMiddleware().StartMaintenance(90 * time.Second)
+
+		clientWallet := mocks.CreateClientMockWallet()
+		initialRequest := mocks.PrepareInitialRequestBody(clientWallet)
+		response, err := server.SendNonGeneralRequest(t, initialRequest.AuthMessage())
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+
+		url := server.URL() + "/ping"
+		headers, err := utils.PrepareGeneralRequestHeaders(clientWallet, authMessage, utils.RequestData{
+			Method: http.MethodGet,
+			URL:    url,
+		})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		for k, v := range headers {
+			request.Header.Set(k, v)
+		}
+
+		// when
+		response, err = server.SendGeneralRequest(t, request)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+		require.Equal(t, "90", response.Header.Get("Retry-After"))
+		assert.SignedGeneralResponse(t, clientWallet, response, key.PubKey().ToDERHex(), request)
+		require.NoError(t, response.Body.Close())
+	})
+
+	t.Run("client receives maintenance error and recovers after maintenance stops", func(t *testing.T) {
+		// given
+		server := newServer()
+		defer server.Close()
+
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL()})
+		require.NoError(t, err)
+		require.NoError(t, authClient.Handshake(context.Background()))
+
+		server.AuthMiddleware().StartMaintenance(time.Minute)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+		response, err := authClient.Do(request)
+		require.Nil(t, response)
+		require.ErrorIs(t, err, client.ErrServerMaintenance)
+
+		var serverErr *client.ServerError
+		require.True(t, errors.As(err, &serverErr))
+		require.Equal(t, transport.ErrCodeMaintenance, serverErr.Code)
+		require.Equal(t, time.Minute, serverErr.RetryAfter)
+
+		// when
+		server.AuthMiddleware().StopMaintenance()
+
+		// then
+		request, err = http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+		response, err = authClient.Do(request)
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+	})
+
+	t.Run("maintenance ends after its duration", func(t *testing.T) {
+		// given
+		server := newServer()
+		defer server.Close()
+
+		// when
+		server.AuthMiddleware().StartMaintenance(time.Millisecond)
+		time.Sleep(5 * time.Millisecond)
+
+		// then
+		require.Zero(t, server.AuthMiddleware().MaintenanceRemaining())
+	})
+}
@@ -0,0 +1,238 @@
+package integrationtests
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	httptransport "github.com/bsv-blockchain/go-bsv-middleware/pkg/transport/http"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMalformedHandshake(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager()).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	clientWallet := mocks.CreateClientMockWallet()
+
+	message := func(modify func(rb *mocks.RequestBody)) func(t *testing.T) []byte {
+		return func(t *testing.T) []byte {
+			initialRequest := mocks.PrepareInitialRequestBody(clientWallet)
+			modify(initialRequest)
+			body, err := json.Marshal(initialRequest.AuthMessage())
+			require.NoError(t, err)
+			return body
+		}
+	}
+
+	raw := func(body string) func(t *testing.T) []byte {
+		return func(*testing.T) []byte {
+			return []byte(body)
+		}
+	}
+
+	// certificateResponse answers a handshake of the client, so the message passes the nonce and session checks
+	certificateResponse := func(modify func(msg *transport.AuthMessage)) func(t *testing.T) []byte {
+		return func(t *testing.T) []byte {
+			initialRequest := mocks.PrepareInitialRequestBody(clientWallet)
+			response, err := server.SendNonGeneralRequest(t, initialRequest.AuthMessage())
+			require.NoError(t, err)
+			initialResponse, err := mocks.MapBodyToAuthMessage(t, response)
+			require.NoError(t, err)
+
+			nonce := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("certificate response nonce", 2)))
+			signature := []byte{0x30}
+			msg := &transport.AuthMessage{
+				Version:      transport.AuthVersion,
+				MessageType:  transport.CertificateResponse,
+				IdentityKey:  initialRequest.IdentityKey,
+				Nonce:        &nonce,
+				YourNonce:    &initialResponse.InitialNonce,
+				Certificates: &[]certificates.VerifiableCertificate{},
+				Signature:    &signature,
+			}
+			modify(msg)
+			body, err := json.Marshal(msg)
+			require.NoError(t, err)
+			return body
+		}
+	}
+
+	tests := map[string]struct {
+		body   func(t *testing.T) []byte
+		status int
+		code   string
+	}{
+		"missing identity key": {
+			body:   message(func(rb *mocks.RequestBody) { rb.WithoutIdentityKey() }),
+			status: http.StatusUnauthorized,
+			code:   transport.ErrCodeMissingRequiredFields,
+		},
+		"missing initial nonce": {
+			body:   message(func(rb *mocks.RequestBody) { rb.WithoutInitialNonce() }),
+			status: http.StatusUnauthorized,
+			code:   transport.ErrCodeMissingRequiredFields,
+		},
+		"missing identity key and initial nonce": {
+			body:   message(func(rb *mocks.RequestBody) { rb.WithoutIdentityKeyAndNonce() }),
+			status: http.StatusUnauthorized,
+			code:   transport.ErrCodeMissingRequiredFields,
+		},
+		"identity key which is not hex": {
+			body:   message(func(rb *mocks.RequestBody) { rb.IdentityKey = "not-a-public-key" }),
+			status: http.StatusUnauthorized,
+			code:   transport.ErrCodeInvalidIdentityKey,
+		},
+		"truncated identity key": {
+			body:   message(func(rb *mocks.RequestBody) { rb.IdentityKey = rb.IdentityKey[:32] }),
+			status: http.StatusUnauthorized,
+			code:   transport.ErrCodeInvalidIdentityKey,
+		},
+		"identity key which is not on the curve": {
+			body:   message(func(rb *mocks.RequestBody) { rb.IdentityKey = "02" + strings.Repeat("00", 32) }),
+			status: http.StatusUnauthorized,
+			code:   transport.ErrCodeInvalidIdentityKey,
+		},
+		"invalid base64 nonce": {
+			body:   message(func(rb *mocks.RequestBody) { rb.WithInvalidNonceFormat() }),
+			status: http.StatusUnauthorized,
+			code:   transport.ErrCodeInvalidNonce,
+		},
+		"oversized nonce": {
+			body: message(func(rb *mocks.RequestBody) {
+				rb.InitialNonce = base64.StdEncoding.EncodeToString(make([]byte, httptransport.MaxNonceSize+1))
+			}),
+			status: http.StatusUnauthorized,
+			code:   transport.ErrCodeInvalidNonce,
+		},
+		"one byte nonce": {
+			body:   message(func(rb *mocks.RequestBody) { rb.InitialNonce = base64.StdEncoding.EncodeToString([]byte{0x01}) }),
+			status: http.StatusUnauthorized,
+			code:   transport.ErrCodeInvalidNonce,
+		},
+		"nonce shorter than minimum": {
+			body: message(func(rb *mocks.RequestBody) {
+				rb.InitialNonce = base64.StdEncoding.EncodeToString([]byte("short nonce"))
+			}),
+			status: http.StatusUnauthorized,
+			code:   transport.ErrCodeInvalidNonce,
+		},
+		"degenerate nonce": {
+			body: message(func(rb *mocks.RequestBody) {
+				rb.InitialNonce = base64.StdEncoding.EncodeToString(make([]byte, 32))
+			}),
+			status: http.StatusUnauthorized,
+			code:   transport.ErrCodeInvalidNonce,
+		},
+		"wrong version": {
+			body:   message(func(rb *mocks.RequestBody) { rb.WithWrongVersion() }),
+			status: http.StatusUnauthorized,
+			code:   transport.ErrCodeUnsupportedVersion,
+		},
+		"missing version": {
+			body:   message(func(rb *mocks.RequestBody) { rb.Version = "" }),
+			status: http.StatusUnauthorized,
+			code:   transport.ErrCodeUnsupportedVersion,
+		},
+		"unsupported message type": {
+			body:   message(func(rb *mocks.RequestBody) { rb.MessageType = "unknown" }),
+			status: http.StatusUnauthorized,
+			code:   transport.ErrCodeUnsupportedMessageType,
+		},
+		"general message": {
+			body:   raw(`{"version":"0.1","messageType":"general","identityKey":"` + key.PubKey().ToDERHex() + `"}`),
+			status: http.StatusUnauthorized,
+			code:   transport.ErrCodeUnsupportedMessageType,
+		},
+		"oversized message": {
+			body: message(func(rb *mocks.RequestBody) {
+				rb.InitialNonce = strings.Repeat("A", httptransport.MaxAuthMessageSize)
+			}),
+			status: http.StatusRequestEntityTooLarge,
+			code:   transport.ErrCodeMessageTooLarge,
+		},
+		"message nested too deeply": {
+			body: raw(`{"version":"0.1","messageType":"initialRequest","extension":` +
+				strings.Repeat("[", httptransport.MaxAuthMessageDepth) + strings.Repeat("]", httptransport.MaxAuthMessageDepth) + `}`),
+			status: http.StatusBadRequest,
+			code:   transport.ErrCodeMessageTooComplex,
+		},
+		"message with too many fields": {
+			body: raw(`{"version":"0.1","messageType":"initialRequest","extension":[` +
+				strings.TrimSuffix(strings.Repeat(`{"a":1},`, httptransport.MaxAuthMessageFields), ",") + `]}`),
+			status: http.StatusBadRequest,
+			code:   transport.ErrCodeMessageTooComplex,
+		},
+		"too many certificates": {
+			body: raw(`{"version":"0.1","messageType":"certificateResponse","certificates":[` +
+				strings.TrimSuffix(strings.Repeat(`{},`, httptransport.MaxCertificates+1), ",") + `]}`),
+			status: http.StatusRequestEntityTooLarge,
+			code:   transport.ErrCodeMessageTooLarge,
+		},
+		"certificate response without your nonce": {
+			body:   raw(`{"version":"0.1","messageType":"certificateResponse","identityKey":"` + key.PubKey().ToDERHex() + `","nonce":"AAAA","signature":"MA==","certificates":[]}`),
+			status: http.StatusBadRequest,
+			code:   transport.ErrCodeMalformedMessage,
+		},
+		"certificate response without signature": {
+			body:   certificateResponse(func(msg *transport.AuthMessage) { msg.Signature = nil }),
+			status: http.StatusBadRequest,
+			code:   transport.ErrCodeMalformedMessage,
+		},
+		"empty body": {
+			body:   raw(""),
+			status: http.StatusBadRequest,
+			code:   transport.ErrCodeMalformedMessage,
+		},
+		"invalid JSON": {
+			body:   raw(`{"version":"0.1","messageType":"initialRequest"`),
+			status: http.StatusBadRequest,
+			code:   transport.ErrCodeMalformedMessage,
+		},
+		"JSON array": {
+			body:   raw(`[]`),
+			status: http.StatusBadRequest,
+			code:   transport.ErrCodeMalformedMessage,
+		},
+		"identity key of invalid type": {
+			body:   raw(`{"version":"0.1","messageType":"initialRequest","identityKey":123,"initialNonce":"AAAA"}`),
+			status: http.StatusBadRequest,
+			code:   transport.ErrCodeMalformedMessage,
+		},
+		"initial nonce of invalid type": {
+			body:   raw(`{"version":"0.1","messageType":"initialRequest","identityKey":"02","initialNonce":{"nonce":"AAAA"}}`),
+			status: http.StatusBadRequest,
+			code:   transport.ErrCodeMalformedMessage,
+		},
+		"payload encryption of invalid type": {
+			body:   raw(`{"version":"0.1","messageType":"initialRequest","payloadEncryption":"yes"}`),
+			status: http.StatusBadRequest,
+			code:   transport.ErrCodeMalformedMessage,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// when
+			response, err := server.SendRawNonGeneralRequest(t, test.body(t))
+
+			// then
+			require.NoError(t, err)
+			assert.ErrorResponseCode(t, response, test.status, test.code)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package integrationtests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_MultipartUploads(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), nil).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/upload", mocks.UploadHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	fields := map[string]string{"title": "report", "visibility": "private"}
+	file := mocks.MultipartFile{Field: "document", Name: "report.pdf", Content: []byte("%PDF-1.7 binary\x00\x01\x02")}
+
+	// handshake returns a function creating signed upload requests of a new session
+	handshake := func(t *testing.T) func() *http.Request {
+		clientWallet := mocks.CreateClientMockWallet()
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+		require.NoError(t, err)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+
+		return func() *http.Request {
+			req, err := mocks.NewMultipartRequest(server.URL()+"/upload", fields, file)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer token")
+			req.Header.Set("X-Bsv-Upload-Id", "upload-1")
+			require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, req))
+			return req
+		}
+	}
+
+	readSummary := func(t *testing.T, response *http.Response) mocks.UploadSummary {
+		var summary mocks.UploadSummary
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&summary))
+		require.NoError(t, response.Body.Close())
+		return summary
+	}
+
+	t.Run("upload signed with the mocks is verified", func(t *testing.T) {
+		// given
+		upload := handshake(t)
+
+		for range 5 {
+			// when
+			response, err := server.SendGeneralRequest(t, upload())
+
+			// then
+			require.NoError(t, err)
+			require.Equal(t, http.StatusOK, response.StatusCode)
+			summary := readSummary(t, response)
+			require.Equal(t, fields, summary.Fields)
+			require.Equal(t, file, summary.Files["document"])
+		}
+	})
+
+	t.Run("upload with rewritten content-type parameters is verified", func(t *testing.T) {
+		// given
+		upload := handshake(t)
+		req := upload()
+		req.Header.Set("Content-Type", req.Header.Get("Content-Type")+"; charset=utf-8")
+
+		// when
+		response, err := server.SendGeneralRequest(t, req)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, file, readSummary(t, response).Files["document"])
+	})
+
+	t.Run("upload with a different media type is rejected", func(t *testing.T) {
+		// given
+		upload := handshake(t)
+		req := upload()
+		req.Header.Set("Content-Type", "multipart/mixed; boundary=other")
+
+		// when
+		response, err := server.SendGeneralRequest(t, req)
+
+		// then
+		require.NoError(t, err)
+		assert.UnableToVerifySignatureError(t, response)
+	})
+
+	t.Run("upload sent by the client is verified", func(t *testing.T) {
+		// given
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL()})
+		require.NoError(t, err)
+		req, err := mocks.NewMultipartRequest(server.URL()+"/upload", fields, file)
+		require.NoError(t, err)
+		req = req.WithContext(context.Background())
+		req.Header.Set("X-Bsv-Upload-Id", "upload-1")
+
+		// when
+		response, err := authClient.Do(req)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		summary := readSummary(t, response)
+		require.Equal(t, fields, summary.Fields)
+		require.Equal(t, file, summary.Files["document"])
+	})
+}
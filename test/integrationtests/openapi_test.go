@@ -0,0 +1,99 @@
+package integrationtests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_OpenAPIDocument(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	serverRequirements := transport.NewRequestedCertificateSet(trustedCertifier).AddType(ageVerificationType, "age")
+	routeRequirements := transport.NewRequestedCertificateSet(trustedCertifier).AddType(ageVerificationType, "age", "country")
+
+	routes := auth.NewRouteRegistry(auth.OpenAPIInfo{Title: "Shop", Version: "1.0.0"})
+	require.NoError(t, routes.Declare("GET /items/{id}", auth.RouteRequirements{Anonymous: true}))
+	require.NoError(t, routes.Declare("POST /orders", auth.RouteRequirements{
+		Certificates: routeRequirements,
+		Payment:      &auth.PaymentRequirement{Price: 100},
+	}))
+	require.NoError(t, routes.Declare("GET /files/{path...}", auth.RouteRequirements{Authentication: auth.AuthenticationNone}))
+
+	onCertificatesReceived := func(_ string, _ *[]certificates.VerifiableCertificate, _ *http.Request, _ http.ResponseWriter, next func()) {
+		next()
+	}
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(),
+		mocks.WithRoutes(routes), mocks.WithCertificateRequirements(serverRequirements, onCertificatesReceived)).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	t.Run("document lists the requirements of the declared routes", func(t *testing.T) {
+		// when
+		response, err := http.Get(server.URL() + auth.OpenAPIPath)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		require.Equal(t, "application/json", response.Header.Get("Content-Type"))
+
+		var document auth.OpenAPIDocument
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&document))
+		require.NoError(t, response.Body.Close())
+
+		require.Equal(t, "3.1.0", document.OpenAPI)
+		require.Equal(t, "Shop", document.Info.Title)
+		require.Equal(t, key.PubKey().ToDERHex(), document.Extension.IdentityKey)
+		require.Equal(t, auth.HandshakePath, document.Extension.HandshakePath)
+
+		item := document.Paths["/items/{id}"]["get"]
+		require.Equal(t, auth.AuthenticationRequired, item.Extension.Authentication)
+		require.True(t, item.Extension.Anonymous)
+		require.Equal(t, serverRequirements, item.Extension.Certificates)
+		require.Contains(t, item.Responses, "401")
+
+		order := document.Paths["/orders"]["post"]
+		require.Equal(t, routeRequirements, order.Extension.Certificates)
+		require.Equal(t, 100, order.Extension.Payment.Price)
+		require.Contains(t, order.Responses, "402")
+
+		files := document.Paths["/files/{path}"]["get"]
+		require.Equal(t, auth.AuthenticationNone, files.Extension.Authentication)
+		require.Nil(t, files.Extension.Certificates)
+		require.NotContains(t, files.Responses, "401")
+	})
+
+	t.Run("discovery document links the OpenAPI document", func(t *testing.T) {
+		// when
+		response, err := http.Get(server.URL() + auth.DiscoveryPath)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		var document auth.DiscoveryDocument
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&document))
+		require.NoError(t, response.Body.Close())
+		require.Equal(t, auth.OpenAPIPath, document.OpenAPIPath)
+	})
+
+	t.Run("invalid declarations are rejected", func(t *testing.T) {
+		for pattern, requirements := range map[string]auth.RouteRequirements{
+			"/orders":     {},
+			"GET orders":  {},
+			"GET /orders": {Authentication: "sometimes"},
+		} {
+			require.ErrorIs(t, routes.Declare(pattern, requirements), auth.ErrInvalidRouteDeclaration, pattern)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package integrationtests
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+// proxyTransport sends every request to the target server, like a reverse proxy serving the public origin of the server
+type proxyTransport struct {
+	target *url.URL
+}
+
+func (p proxyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	proxied := req.Clone(req.Context())
+	proxied.URL.Scheme = p.target.Scheme
+	proxied.URL.Host = p.target.Host
+	return http.DefaultTransport.RoundTrip(proxied)
+}
+
+func TestAuthMiddleware_OriginBinding(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	const (
+		origin      = "https://api.example.com"
+		otherOrigin = "https://other.example.com"
+	)
+
+	newServer := func(t *testing.T, originBinding transport.OriginBinding) *mocks.MockHTTPServer {
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(),
+			mocks.WithOriginBinding(originBinding)).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	// send signs a request bound to the origin, modify changes the headers after signing
+	send := func(t *testing.T, server *mocks.MockHTTPServer, boundOrigin string, modify func(headers map[string]string)) *http.Response {
+		clientWallet := mocks.CreateClientMockWallet()
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+
+		requestURL := server.URL() + "/ping"
+		headers, err := utils.PrepareGeneralRequestHeaders(clientWallet, authMessage, utils.RequestData{
+			Method: http.MethodGet,
+			URL:    requestURL,
+			Origin: boundOrigin,
+		})
+		require.NoError(t, err)
+		if modify != nil {
+			modify(headers)
+		}
+
+		request, err := http.NewRequest(http.MethodGet, requestURL, nil)
+		require.NoError(t, err)
+		for k, v := range headers {
+			request.Header.Set(k, v)
+		}
+		response, err = server.SendGeneralRequest(t, request)
+		require.NoError(t, err)
+		return response
+	}
+
+	t.Run("client binds requests to the origin accepted by the server", func(t *testing.T) {
+		// given
+		server := newServer(t, transport.OriginBinding{Origins: []string{origin}, Required: true})
+		target, err := url.Parse(server.URL())
+		require.NoError(t, err)
+
+		authClient, err := client.New(client.Config{
+			Wallet:     mocks.CreateClientMockWallet(),
+			BaseURL:    origin,
+			HTTPClient: &http.Client{Transport: proxyTransport{target: target}},
+			BindOrigin: true,
+		})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, origin+"/ping", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Do(request)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+	})
+
+	t.Run("request bound to another origin is rejected", func(t *testing.T) {
+		// given
+		server := newServer(t, transport.OriginBinding{Origins: []string{origin}})
+
+		// when
+		response := send(t, server, otherOrigin, nil)
+
+		// then
+		assert.ErrorResponseCode(t, response, http.StatusUnauthorized, transport.ErrCodeOriginNotAccepted)
+	})
+
+	t.Run("signature bound to another origin cannot be replayed with the origin of the server", func(t *testing.T) {
+		// given
+		server := newServer(t, transport.OriginBinding{Origins: []string{origin, otherOrigin + "/"}})
+
+		// when
+		response := send(t, server, otherOrigin, func(headers map[string]string) {
+			headers[utils.OriginHeader] = origin
+		})
+
+		// then
+		assert.NotAuthorized(t, response)
+		assert.UnableToVerifySignatureError(t, response)
+	})
+
+	t.Run("unbound request is rejected when binding is required", func(t *testing.T) {
+		// given
+		server := newServer(t, transport.OriginBinding{Origins: []string{origin}, Required: true})
+
+		// when
+		response := send(t, server, "", nil)
+
+		// then
+		assert.ErrorResponseCode(t, response, http.StatusUnauthorized, transport.ErrCodeOriginBindingRequired)
+	})
+
+	t.Run("unbound request is accepted when binding is optional", func(t *testing.T) {
+		// given
+		server := newServer(t, transport.OriginBinding{Origins: []string{origin}})
+
+		// when
+		response := send(t, server, "", nil)
+
+		// then
+		assert.ResponseOK(t, response)
+	})
+}
@@ -0,0 +1,180 @@
+package integrationtests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+// sizeRecorder records the sizes of the bodies of the last request and response sent over the wire
+type sizeRecorder struct {
+	mu       sync.Mutex
+	request  int
+	response int
+}
+
+func (r *sizeRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	response, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(response.Body)
+	_ = response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	response.Body = io.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.request, r.response = int(req.ContentLength), len(body)
+	return response, nil
+}
+
+func TestAuthMiddleware_PayloadPadding(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	body := []byte(`{"diagnosis":"confidential"}`)
+
+	tests := map[string]struct {
+		serverOptions []func(s *mocks.MockHTTPServer) *mocks.MockHTTPServer
+		encryption    bool
+		padded        bool
+	}{
+		"padding negotiated": {
+			serverOptions: []func(s *mocks.MockHTTPServer) *mocks.MockHTTPServer{mocks.WithPayloadPadding},
+			padded:        true,
+		},
+		"padding negotiated with payload encryption": {
+			serverOptions: []func(s *mocks.MockHTTPServer) *mocks.MockHTTPServer{mocks.WithPayloadPadding, mocks.WithPayloadEncryption},
+			encryption:    true,
+			padded:        true,
+		},
+		"server without padding": {
+			padded: false,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), test.serverOptions...).
+				WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+				WithHandler("/echo", mocks.EchoHandler().WithAuthMiddleware())
+			defer server.Close()
+
+			sizes := &sizeRecorder{}
+			authClient, err := client.New(client.Config{
+				Wallet:            mocks.CreateClientMockWallet(),
+				BaseURL:           server.URL(),
+				HTTPClient:        &http.Client{Transport: sizes},
+				PayloadEncryption: test.encryption,
+				PayloadPadding:    true,
+			})
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodPost, server.URL()+"/echo", bytes.NewReader(body))
+			require.NoError(t, err)
+
+			// when
+			response, err := authClient.Do(request)
+
+			// then
+			require.NoError(t, err)
+			assert.ResponseOK(t, response)
+
+			received, err := io.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.Equal(t, body, received)
+
+			sizes.mu.Lock()
+			defer sizes.mu.Unlock()
+			if !test.padded {
+				require.Equal(t, len(body), sizes.request)
+				require.Equal(t, len(body), sizes.response)
+				return
+			}
+			if !test.encryption {
+				require.Equal(t, transport.MinPaddedSize, sizes.request)
+				require.Equal(t, transport.MinPaddedSize, sizes.response)
+			}
+			require.GreaterOrEqual(t, sizes.request, transport.MinPaddedSize)
+			require.GreaterOrEqual(t, sizes.response, transport.MinPaddedSize)
+		})
+	}
+
+	t.Run("granted padding is covered by the initial response signature", func(t *testing.T) {
+		// given
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), mocks.WithPayloadPadding).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+		defer server.Close()
+
+		clientWallet := mocks.CreateClientMockWallet()
+		initialRequest := mocks.PrepareInitialRequestBody(clientWallet).AuthMessage()
+		initialRequest.PayloadPadding = true
+
+		response, err := server.SendNonGeneralRequest(t, initialRequest)
+		require.NoError(t, err)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+		require.NoError(t, utils.VerifyInitialResponse(clientWallet, initialRequest, authMessage))
+
+		// when
+		downgraded := *authMessage
+		downgraded.SetCapabilities(nil)
+
+		// then
+		require.Error(t, utils.VerifyInitialResponse(clientWallet, initialRequest, &downgraded))
+	})
+
+	t.Run("unpadded body of a session with padding is rejected", func(t *testing.T) {
+		// given
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), mocks.WithPayloadPadding).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/echo", mocks.EchoHandler().WithAuthMiddleware())
+		defer server.Close()
+
+		clientWallet := mocks.CreateClientMockWallet()
+		initialRequest := mocks.PrepareInitialRequestBody(clientWallet).AuthMessage()
+		initialRequest.PayloadPadding = true
+		response, err := server.SendNonGeneralRequest(t, initialRequest)
+		require.NoError(t, err)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+		require.True(t, authMessage.PayloadPadding)
+
+		request, err := http.NewRequest(http.MethodPost, server.URL()+"/echo", bytes.NewReader(body))
+		require.NoError(t, err)
+		headers, err := utils.PrepareGeneralRequestHeaders(clientWallet, authMessage, utils.RequestData{Request: request})
+		require.NoError(t, err)
+		for k, v := range headers {
+			request.Header.Set(k, v)
+		}
+		request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// when
+		response, err = server.SendGeneralRequest(t, request)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+		var errResponse transport.ErrorResponse
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&errResponse))
+		require.Equal(t, transport.ErrCodeInvalidPadding, errResponse.Code)
+	})
+}
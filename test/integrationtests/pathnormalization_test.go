@@ -0,0 +1,108 @@
+package integrationtests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_PathNormalization(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	// newServer returns a server behind a proxy which cleans the path when rewrite is set
+	newServer := func(t *testing.T, normalization authcore.PathNormalization, rewrite bool) string {
+		middleware, err := auth.New(auth.Config{
+			Wallet:            mocks.CreateServerMockWallet(key),
+			SessionManager:    session.NewMemoryManager(),
+			PathNormalization: normalization,
+		})
+		require.NoError(t, err)
+
+		handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if rewrite && req.URL.Path != auth.HandshakePath {
+				req.URL.Path = path.Clean(req.URL.Path)
+				req.URL.RawPath = ""
+			}
+			handler.ServeHTTP(w, req)
+		}))
+		t.Cleanup(server.Close)
+		return server.URL
+	}
+
+	tests := map[string]struct {
+		client   authcore.PathNormalization
+		server   authcore.PathNormalization
+		path     string
+		rewrite  bool
+		verified bool
+	}{
+		"strict path which is not rewritten": {
+			client: authcore.PathStrict, server: authcore.PathStrict, path: "/items//1/", verified: true,
+		},
+		"strict path rewritten by the proxy": {
+			client: authcore.PathStrict, server: authcore.PathStrict, path: "/items//1/", rewrite: true,
+		},
+		"lenient path rewritten by the proxy": {
+			client: authcore.PathLenient, server: authcore.PathLenient, path: "/items//./1/", rewrite: true, verified: true,
+		},
+		"strict client of a lenient server": {
+			client: authcore.PathStrict, server: authcore.PathLenient, path: "/items//1/", verified: true,
+		},
+		"strict client of a lenient server rewritten by the proxy": {
+			client: authcore.PathStrict, server: authcore.PathLenient, path: "/items//1/", rewrite: true,
+		},
+		"lenient client of a strict server with a normal path": {
+			client: authcore.PathLenient, server: authcore.PathStrict, path: "/items/1", verified: true,
+		},
+		"lenient client of a strict server": {
+			client: authcore.PathLenient, server: authcore.PathStrict, path: "/items/1/",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			url := newServer(t, test.server, test.rewrite)
+			authClient, err := client.New(client.Config{
+				Wallet:            mocks.CreateClientMockWallet(),
+				BaseURL:           url,
+				PathNormalization: test.client,
+			})
+			require.NoError(t, err)
+
+			request, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url+test.path, nil)
+			require.NoError(t, err)
+
+			// when
+			response, err := authClient.Do(request)
+
+			// then
+			if !test.verified {
+				var serverErr *client.ServerError
+				require.ErrorAs(t, err, &serverErr)
+				require.Equal(t, transport.ErrCodeInvalidSignature, serverErr.Code)
+				return
+			}
+			require.NoError(t, err)
+			assert.ResponseOK(t, response)
+			require.NoError(t, response.Body.Close())
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package integrationtests
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+// reasonRecordingWallet records the privileged reasons of the signatures created by the wallet
+type reasonRecordingWallet struct {
+	wallet.WalletInterface
+	mu      sync.Mutex
+	reasons []string
+}
+
+func (w *reasonRecordingWallet) CreateSignature(args *wallet.CreateSignatureArgs, originator string) (*wallet.CreateSignatureResult, error) {
+	w.mu.Lock()
+	w.reasons = append(w.reasons, args.PrivilegedReason)
+	w.mu.Unlock()
+	return w.WalletInterface.CreateSignature(args, originator)
+}
+
+func TestAuthMiddleware_PrivilegedKeys(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	privilegedKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+	privilegedIdentityKey := privilegedKey.PubKey().ToDERHex()
+	plaintext := []byte(`{"secret":"value"}`)
+
+	serverWallet := &reasonRecordingWallet{
+		WalletInterface: wallettest.NewPrivilegedMockWallet(key, privilegedKey, wallettest.DefaultNonces...),
+	}
+	server := mocks.CreateMockHTTPServer(serverWallet, session.NewMemoryManager(), mocks.WithPayloadEncryption,
+		mocks.WithPrivilegedKeys(transport.PrivilegedKeys{Enabled: true, Reason: "Sign API responses"})).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/echo", mocks.EchoHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	clientWallet := mocks.CreateClientMockWallet()
+	initialRequest := mocks.PrepareInitialRequestBody(clientWallet)
+	initialRequest.PayloadEncryption = true
+
+	response, err := server.SendNonGeneralRequest(t, initialRequest.AuthMessage())
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+	authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+	require.NoError(t, err)
+	require.Equal(t, privilegedIdentityKey, authMessage.IdentityKey)
+
+	url := server.URL() + "/echo"
+	headers, body, err := utils.PrepareEncryptedGeneralRequest(clientWallet, authMessage, utils.RequestData{
+		Method: http.MethodPost,
+		URL:    url,
+		Body:   plaintext,
+	})
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	require.NoError(t, err)
+	for k, v := range headers {
+		request.Header.Set(k, v)
+	}
+
+	// when
+	response, err = server.SendGeneralRequest(t, request)
+
+	// then
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+	assert.SignedGeneralResponse(t, clientWallet, response, privilegedIdentityKey, request)
+
+	responseBody, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+	decrypted, err := utils.DecryptResponseBody(clientWallet, response.Header, responseBody)
+	require.NoError(t, err)
+	require.Equal(t, plaintext, decrypted)
+
+	serverWallet.mu.Lock()
+	defer serverWallet.mu.Unlock()
+	require.NotEmpty(t, serverWallet.reasons)
+	for _, reason := range serverWallet.reasons {
+		require.Equal(t, "Sign API responses", reason)
+	}
+}
+
+func TestClient_SocketPrivilegedKeys(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	privilegedKey, err := ec.NewPrivateKey()
+	require.NoError(t, err)
+
+	serverWallet := &reasonRecordingWallet{
+		WalletInterface: wallettest.NewPrivilegedMockWallet(key, privilegedKey, wallettest.DefaultNonces...),
+	}
+	server := mocks.CreateMockSocketServer(serverWallet, session.NewMemoryManager(),
+		mocks.WithSocketPrivilegedKeys(transport.PrivilegedKeys{Enabled: true, Reason: "Sign socket messages"}))
+	defer server.Close()
+
+	// when
+	socket, err := client.DialSocket(context.Background(), server.URL(), mocks.CreateClientMockWallet())
+	require.NoError(t, err)
+	defer func() { _ = socket.Close() }()
+	response, err := socket.Request(context.Background(), []byte("ping"))
+
+	// then
+	require.NoError(t, err)
+	require.NotEmpty(t, response)
+	require.Equal(t, privilegedKey.PubKey().ToDERHex(), socket.ServerIdentityKey())
+
+	serverWallet.mu.Lock()
+	defer serverWallet.mu.Unlock()
+	require.NotEmpty(t, serverWallet.reasons)
+	for _, reason := range serverWallet.reasons {
+		require.Equal(t, "Sign socket messages", reason)
+	}
+}
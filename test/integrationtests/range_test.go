@@ -0,0 +1,122 @@
+package integrationtests
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_PartialContent(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	serverIdentityKey := key.PubKey().ToDERHex()
+
+	// larger than the copy buffer of http.ServeContent, so the ranges are written in several chunks
+	content := bytes.Repeat([]byte("0123456789abcdef"), 8192)
+
+	newSession := func(t *testing.T, encrypted bool, opts ...func(s *mocks.MockHTTPServer) *mocks.MockHTTPServer) (*mocks.MockHTTPServer, func(t *testing.T, rangeHeader string) (*http.Request, *http.Response)) {
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), opts...).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/download", mocks.RangeHandler(content).WithAuthMiddleware())
+
+		clientWallet := mocks.CreateClientMockWallet()
+		initialRequest := mocks.PrepareInitialRequestBody(clientWallet)
+		initialRequest.PayloadEncryption = encrypted
+		response, err := server.SendNonGeneralRequest(t, initialRequest.AuthMessage())
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+
+		download := func(t *testing.T, rangeHeader string) (*http.Request, *http.Response) {
+			request, err := http.NewRequest(http.MethodGet, server.URL()+"/download", nil)
+			require.NoError(t, err)
+			require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request))
+			request.Header.Set("Range", rangeHeader)
+
+			response, err := server.SendGeneralRequest(t, request)
+			require.NoError(t, err)
+			assert.SignedGeneralResponse(t, clientWallet, response, serverIdentityKey, request)
+			return request, response
+		}
+
+		return server, download
+	}
+
+	t.Run("partial content is signed over the range bytes and content range", func(t *testing.T) {
+		// given
+		server, download := newSession(t, false)
+		defer server.Close()
+
+		// when
+		_, response := download(t, "bytes=40000-70000")
+
+		// then
+		require.Equal(t, http.StatusPartialContent, response.StatusCode)
+		require.Equal(t, "bytes 40000-70000/131072", response.Header.Get(utils.ContentRangeHeader))
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+		require.Equal(t, content[40000:70001], body)
+	})
+
+	t.Run("resumed download reassembles the content", func(t *testing.T) {
+		// given
+		server, download := newSession(t, false)
+		defer server.Close()
+
+		// when
+		var assembled []byte
+		for _, rangeHeader := range []string{"bytes=0-65535", "bytes=65536-"} {
+			_, response := download(t, rangeHeader)
+			require.Equal(t, http.StatusPartialContent, response.StatusCode)
+			body, err := io.ReadAll(response.Body)
+			require.NoError(t, err)
+			require.NoError(t, response.Body.Close())
+			assembled = append(assembled, body...)
+		}
+
+		// then
+		require.Equal(t, content, assembled)
+	})
+
+	t.Run("modified content range is detected", func(t *testing.T) {
+		// given
+		server, download := newSession(t, false)
+		defer server.Close()
+		_, response := download(t, "bytes=0-99")
+
+		// when
+		response.Header.Set(utils.ContentRangeHeader, "bytes 100-199/131072")
+
+		// then
+		assert.TamperedGeneralResponse(t, mocks.CreateClientMockWallet(), response, serverIdentityKey)
+	})
+
+	t.Run("encrypted partial content decrypts to the range bytes", func(t *testing.T) {
+		// given
+		server, download := newSession(t, true, mocks.WithPayloadEncryption)
+		defer server.Close()
+
+		// when
+		_, response := download(t, "bytes=100-199")
+
+		// then
+		require.Equal(t, http.StatusPartialContent, response.StatusCode)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+		decrypted, err := utils.DecryptResponseBody(mocks.CreateClientMockWallet(), response.Header, body)
+		require.NoError(t, err)
+		require.Equal(t, content[100:200], decrypted)
+	})
+}
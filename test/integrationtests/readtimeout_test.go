@@ -0,0 +1,124 @@
+package integrationtests
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+// slowReader returns its content in two halves with a pause between them
+type slowReader struct {
+	halves [][]byte
+	pause  time.Duration
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if len(r.halves) == 0 {
+		return 0, io.EOF
+	}
+	if len(r.halves) == 1 {
+		time.Sleep(r.pause)
+	}
+	n := copy(p, r.halves[0])
+	r.halves[0] = r.halves[0][n:]
+	if len(r.halves[0]) == 0 {
+		r.halves = r.halves[1:]
+	}
+	return n, nil
+}
+
+func TestAuthMiddleware_ReadTimeouts(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	timeouts := transport.ReadTimeouts{
+		Header:  100 * time.Millisecond,
+		Body:    100 * time.Millisecond,
+		Tracker: transport.NewRequestTracker(),
+	}
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), mocks.WithReadTimeouts(timeouts)).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/echo", mocks.EchoHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	clientWallet := mocks.CreateClientMockWallet()
+	response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+	authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+	require.NoError(t, err)
+
+	echo := func(t *testing.T, pause time.Duration) *http.Response {
+		body := []byte(`{"message":"sent in two halves"}`)
+		request, err := http.NewRequest(http.MethodPost, server.URL()+"/echo", bytes.NewReader(body))
+		require.NoError(t, err)
+		request.Header.Set("Content-Type", "application/json")
+		require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request))
+
+		request.Body = io.NopCloser(&slowReader{halves: [][]byte{body[:10], body[10:]}, pause: pause})
+		response, err := server.SendGeneralRequest(t, request)
+		require.NoError(t, err)
+		return response
+	}
+
+	rawRequest := func(t *testing.T, pause time.Duration) *http.Response {
+		conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL(), "http://"))
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = conn.Close() })
+
+		_, err = conn.Write([]byte("GET /echo HTTP/1.1\r\nHost: localhost\r\n"))
+		require.NoError(t, err)
+		time.Sleep(pause)
+		_, err = conn.Write([]byte("X-Bsv-Auth-Request-Id: AAAA\r\n\r\n"))
+		require.NoError(t, err)
+
+		response, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		require.NoError(t, err)
+		return response
+	}
+
+	t.Run("body sent within the timeout is accepted", func(t *testing.T) {
+		// when
+		response := echo(t, 0)
+
+		// then
+		assert.ResponseOK(t, response)
+	})
+
+	t.Run("body trickling in after the timeout is rejected with 408", func(t *testing.T) {
+		// when
+		response := echo(t, 300*time.Millisecond)
+
+		// then
+		assert.ErrorResponseCode(t, response, http.StatusRequestTimeout, transport.ErrCodeReadTimeout)
+	})
+
+	t.Run("headers received within the timeout pass to verification", func(t *testing.T) {
+		// when
+		response := rawRequest(t, 0)
+
+		// then
+		assert.ErrorResponseCode(t, response, http.StatusUnauthorized, transport.ErrCodeMissingHeader)
+	})
+
+	t.Run("headers trickling in after the timeout are rejected with 408", func(t *testing.T) {
+		// when
+		response := rawRequest(t, 300*time.Millisecond)
+
+		// then
+		assert.ErrorResponseCode(t, response, http.StatusRequestTimeout, transport.ErrCodeReadTimeout)
+	})
+}
@@ -0,0 +1,171 @@
+package integrationtests
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_Reference(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	serverRequirements := transport.NewRequestedCertificateSet(trustedCertifier).AddType(ageVerificationType, "age")
+	routeRequirements := transport.NewRequestedCertificateSet(trustedCertifier).AddType(ageVerificationType, "age", "country")
+
+	routes := auth.NewRouteRegistry(auth.OpenAPIInfo{Title: "Shop", Version: "1.0.0"})
+	require.NoError(t, routes.Declare("GET /items/{id}", auth.RouteRequirements{Anonymous: true}))
+	require.NoError(t, routes.Declare("POST /orders", auth.RouteRequirements{
+		Certificates: routeRequirements,
+		Payment:      &auth.PaymentRequirement{Price: 100},
+	}))
+
+	middleware, err := auth.New(auth.Config{
+		Wallet:                wallettest.NewRandomMockWallet(key, nil),
+		SessionManager:        session.NewMemoryManager(),
+		Logger:                slog.New(slog.DiscardHandler),
+		CertificatesToRequest: serverRequirements,
+		OnCertificatesReceived: func(_ string, _ *[]certificates.VerifiableCertificate, _ *http.Request, _ http.ResponseWriter, next func()) {
+			next()
+		},
+		PaymentHints:    &auth.PaymentHints{Version: "1.0", Network: "mainnet", DefaultPrice: 10},
+		RoutePolicies:   map[string]auth.RoutePolicy{"GET /health": {Exempt: true}, "/public/": {AllowUnauthenticated: true}},
+		AnonymousAccess: &auth.AnonymousPolicy{RateLimit: 100, RateLimitWindow: time.Minute},
+		Tiers: &auth.TierPolicy{
+			Tiers: []auth.Tier{
+				{Name: "free", RateLimit: 60, FreeRequests: 10, FreeRequestsWindow: time.Hour},
+				{Name: "<enterprise>"},
+			},
+			Resolver:    auth.CertificateFieldTiers(ageVerificationType, "plan", map[string]string{"enterprise": "<enterprise>"}),
+			DefaultTier: "free",
+		},
+		Routes: routes,
+	})
+	require.NoError(t, err)
+	admin := httptest.NewServer(middleware.ReferenceHandler())
+	defer admin.Close()
+
+	get := func(t *testing.T, url, accept string) *http.Response {
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		if accept != "" {
+			request.Header.Set("Accept", accept)
+		}
+		response, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		return response
+	}
+
+	t.Run("JSON reference reflects the configuration", func(t *testing.T) {
+		// when
+		response := get(t, admin.URL, "")
+
+		// then
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		require.Equal(t, "application/json", response.Header.Get("Content-Type"))
+		var reference auth.Reference
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&reference))
+		require.NoError(t, response.Body.Close())
+
+		require.Equal(t, key.PubKey().ToDERHex(), reference.IdentityKey)
+		require.Equal(t, auth.HandshakePath, reference.HandshakePath)
+		require.Equal(t, serverRequirements, reference.RequestedCertificates)
+		require.Equal(t, 10, reference.Payment.DefaultPrice)
+
+		require.Equal(t, []auth.ReferenceRoute{
+			{Method: http.MethodGet, Path: "/items/{id}", Requirements: auth.RouteRequirements{
+				Authentication: auth.AuthenticationRequired, Anonymous: true, Certificates: serverRequirements,
+			}},
+			{Method: http.MethodPost, Path: "/orders", Requirements: auth.RouteRequirements{
+				Authentication: auth.AuthenticationRequired, Certificates: routeRequirements, Payment: &auth.PaymentRequirement{Price: 100},
+			}},
+		}, reference.Routes)
+		require.Equal(t, []auth.ReferenceRoutePolicy{
+			{Pattern: "/public/", AllowUnauthenticated: true},
+			{Pattern: "GET /health", Exempt: true},
+		}, reference.RoutePolicies)
+
+		require.Equal(t, &auth.ReferenceRateLimit{Requests: 100, WindowSeconds: 60}, reference.AnonymousRateLimit)
+		require.Equal(t, "free", reference.DefaultTier)
+		require.Equal(t, []auth.ReferenceTier{
+			{Name: "<enterprise>"},
+			{
+				Name:         "free",
+				RateLimit:    &auth.ReferenceRateLimit{Requests: 60, WindowSeconds: int(auth.DefaultTierRateLimitWindow / time.Second)},
+				FreeRequests: &auth.ReferenceRateLimit{Requests: 10, WindowSeconds: 3600},
+			},
+		}, reference.Tiers)
+	})
+
+	t.Run("reference follows changes of the live configuration", func(t *testing.T) {
+		// given
+		require.NoError(t, routes.Declare("DELETE /orders/{id}", auth.RouteRequirements{Payment: &auth.PaymentRequirement{Dynamic: true}}))
+		require.NoError(t, middleware.UpdateCertificateRequirements(routeRequirements, transport.CertificateUpgradeIgnore))
+		t.Cleanup(func() {
+			require.NoError(t, middleware.UpdateCertificateRequirements(serverRequirements, transport.CertificateUpgradeIgnore))
+		})
+
+		// when
+		reference, err := middleware.Reference()
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, routeRequirements, reference.RequestedCertificates)
+		require.Len(t, reference.Routes, 3)
+		require.Equal(t, "/orders/{id}", reference.Routes[2].Path)
+		require.True(t, reference.Routes[2].Requirements.Payment.Dynamic)
+		require.Equal(t, routeRequirements, reference.Routes[0].Requirements.Certificates)
+	})
+
+	t.Run("HTML reference is served to browsers", func(t *testing.T) {
+		for name, request := range map[string]struct{ url, accept string }{
+			"accept header": {url: admin.URL, accept: "text/html,application/xhtml+xml,*/*;q=0.8"},
+			"format query":  {url: admin.URL + "?format=html"},
+		} {
+			t.Run(name, func(t *testing.T) {
+				// when
+				response := get(t, request.url, request.accept)
+
+				// then
+				require.Equal(t, http.StatusOK, response.StatusCode)
+				require.Equal(t, "text/html; charset=utf-8", response.Header.Get("Content-Type"))
+				body, err := io.ReadAll(response.Body)
+				require.NoError(t, err)
+				require.NoError(t, response.Body.Close())
+
+				page := string(body)
+				require.Contains(t, page, key.PubKey().ToDERHex())
+				require.Contains(t, page, "<code>/orders</code>")
+				require.Contains(t, page, "100 satoshis")
+				require.Contains(t, page, "60 requests per 60s")
+				require.Contains(t, page, "tier free (default)")
+				require.Contains(t, page, "tier &lt;enterprise&gt;", "configured values are escaped")
+				require.NotContains(t, page, "<enterprise>")
+			})
+		}
+	})
+
+	t.Run("methods other than GET are not allowed", func(t *testing.T) {
+		// when
+		response, err := http.Post(admin.URL, "application/json", strings.NewReader("{}"))
+
+		// then
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+		require.Equal(t, http.StatusMethodNotAllowed, response.StatusCode)
+		require.Equal(t, "GET, HEAD", response.Header.Get("Allow"))
+	})
+}
@@ -0,0 +1,133 @@
+package integrationtests
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_ReportOnly(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	logs := &accessLogBuffer{}
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(),
+		mocks.WithReportOnly,
+		mocks.WithAccessLogger(slog.New(slog.NewJSONHandler(logs, nil))),
+		mocks.WithAnomalyPolicy(auth.AnomalyPolicy{IdentityThreshold: 1, IdentityLockout: time.Minute})).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	clientWallet := mocks.CreateClientMockWallet()
+	response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+	authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+	require.NoError(t, err)
+
+	send := func(t *testing.T, opts ...func(m map[string]string)) *http.Response {
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+		if opts != nil {
+			require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request, opts...))
+		}
+		response, err := server.SendGeneralRequest(t, request)
+		require.NoError(t, err)
+		return response
+	}
+
+	// when
+	unauthenticated := send(t)
+	wrongSignature := send(t, mocks.WithWrongSignature)
+	authenticated := send(t, func(map[string]string) {})
+
+	// then
+	for _, response := range []*http.Response{unauthenticated, wrongSignature} {
+		assert.ResponseOK(t, response)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.Equal(t, "Pong!", string(body))
+		require.Empty(t, response.Header.Get("x-bsv-auth-signature"))
+	}
+
+	// failed verification does not lock out the identity
+	assert.ResponseOK(t, authenticated)
+	require.NotEmpty(t, authenticated.Header.Get("x-bsv-auth-signature"))
+
+	lines := logs.lines(t)
+	require.Len(t, lines, 4)
+	require.Equal(t, auth.AccessOutcomeReportOnly, lines[1]["outcome"])
+	require.Equal(t, transport.ErrCodeMissingRequestID, lines[1]["code"])
+	require.Equal(t, auth.AccessOutcomeReportOnly, lines[2]["outcome"])
+	require.NotEmpty(t, lines[2]["code"])
+	require.Equal(t, auth.AccessOutcomeAuthenticated, lines[3]["outcome"])
+}
+
+func TestAuthMiddleware_Rollout(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	clientWallet := mocks.CreateClientMockWallet()
+	clientIdentity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+	identityKey := clientIdentity.PublicKey.ToDERHex()
+
+	tests := map[string]struct {
+		rollout        auth.EnforcementRollout
+		expectedStatus int
+	}{
+		"identity in the cohort is rejected": {
+			rollout:        auth.EnforcementRollout{Cohort: []string{identityKey}},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"identity outside the rollout is passed": {
+			rollout:        auth.EnforcementRollout{Cohort: []string{transport.AnyoneIdentityKey}},
+			expectedStatus: http.StatusOK,
+		},
+		"full rollout rejects every identity": {
+			rollout:        auth.EnforcementRollout{Percent: 100},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(),
+				mocks.WithRollout(test.rollout)).
+				WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+				WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+			defer server.Close()
+
+			response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+			require.NoError(t, err)
+			assert.ResponseOK(t, response)
+			authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+			require.NoError(t, err)
+
+			request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+			require.NoError(t, err)
+			require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request, mocks.WithWrongSignature))
+
+			// when
+			response, err = server.SendGeneralRequest(t, request)
+
+			// then
+			require.NoError(t, err)
+			require.Equal(t, test.expectedStatus, response.StatusCode)
+		})
+	}
+}
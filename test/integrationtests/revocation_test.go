@@ -0,0 +1,127 @@
+package integrationtests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_Revocations(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	clientWallet := mocks.CreateClientMockWallet()
+	clientIdentity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+	identityKey := clientIdentity.PublicKey.ToDERHex()
+
+	get := func(t *testing.T, authClient *client.Client, url string) error {
+		request, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url+"/", nil)
+		require.NoError(t, err)
+		response, err := authClient.Do(request)
+		if err != nil {
+			return err
+		}
+		assert.ResponseOK(t, response)
+		return response.Body.Close()
+	}
+
+	admin := func(t *testing.T, handler http.Handler, method, query, body string) int {
+		server := httptest.NewServer(handler)
+		defer server.Close()
+		request, err := http.NewRequest(method, server.URL+query, strings.NewReader(body))
+		require.NoError(t, err)
+		response, err := http.DefaultClient.Do(request)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+		return response.StatusCode
+	}
+
+	requireRevoked := func(t *testing.T, err error) {
+		var serverErr *client.ServerError
+		require.ErrorAs(t, err, &serverErr)
+		require.Equal(t, transport.ErrCodeRevoked, serverErr.Code)
+	}
+
+	t.Run("revoked identity is cut off on its next request", func(t *testing.T) {
+		// given
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager()).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+		defer server.Close()
+		revocations := server.AuthMiddleware().RevocationsHandler()
+
+		authClient, err := client.New(client.Config{Wallet: clientWallet, BaseURL: server.URL()})
+		require.NoError(t, err)
+		require.NoError(t, get(t, authClient, server.URL()))
+
+		// when
+		status := admin(t, revocations, http.MethodPut, "", `{"kind":"identity","value":"`+identityKey+`","reason":"key leaked"}`)
+
+		// then
+		require.Equal(t, http.StatusNoContent, status)
+		requireRevoked(t, get(t, authClient, server.URL()))
+
+		newClient, err := client.New(client.Config{Wallet: clientWallet, BaseURL: server.URL()})
+		require.NoError(t, err)
+		require.ErrorContains(t, get(t, newClient, server.URL()), "handshake failed with status 401")
+
+		// when
+		status = admin(t, revocations, http.MethodDelete, "?kind=identity&value="+identityKey, "")
+
+		// then
+		require.Equal(t, http.StatusNoContent, status)
+		require.NoError(t, get(t, newClient, server.URL()))
+	})
+
+	t.Run("revoked session is cut off while new sessions are accepted", func(t *testing.T) {
+		// given
+		sessionManager := session.NewMemoryManager()
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), sessionManager).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+		defer server.Close()
+
+		authClient, err := client.New(client.Config{Wallet: clientWallet, BaseURL: server.URL()})
+		require.NoError(t, err)
+		require.NoError(t, get(t, authClient, server.URL()))
+		peerSession := sessionManager.GetSession(identityKey)
+		require.NotNil(t, peerSession)
+
+		// when
+		err = server.AuthMiddleware().Revocations().Revoke(context.Background(), transport.Revocation{
+			RevocationSubject: transport.RevocationSubject{Kind: transport.RevokedSession, Value: *peerSession.SessionNonce},
+		})
+		require.NoError(t, err)
+
+		// then
+		requireRevoked(t, get(t, authClient, server.URL()))
+		require.False(t, sessionManager.HasSession(*peerSession.SessionNonce))
+
+		newClient, err := client.New(client.Config{Wallet: clientWallet, BaseURL: server.URL()})
+		require.NoError(t, err)
+		require.NoError(t, get(t, newClient, server.URL()))
+	})
+
+	t.Run("admin API rejects invalid revocations", func(t *testing.T) {
+		// given
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager())
+		defer server.Close()
+		revocations := server.AuthMiddleware().RevocationsHandler()
+
+		// then
+		require.Equal(t, http.StatusBadRequest, admin(t, revocations, http.MethodPut, "", `{"kind":"identity","value":"key"}`))
+		require.Equal(t, http.StatusNotFound, admin(t, revocations, http.MethodDelete, "?kind=identity&value="+identityKey, ""))
+		require.Equal(t, http.StatusMethodNotAllowed, admin(t, revocations, http.MethodPost, "", ""))
+	})
+}
@@ -0,0 +1,98 @@
+package integrationtests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_RoutePolicies(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	policies := map[string]auth.RoutePolicy{
+		"GET /public/{id}":  {Exempt: true},
+		"GET /public/admin": {},
+		"/ping":             {AllowUnauthenticated: true},
+	}
+
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), mocks.WithRoutePolicies(policies)).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/public/", mocks.PingHandler().WithAuthMiddleware()).
+		WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{
+			name:           "exempt route is served without auth headers",
+			method:         http.MethodGet,
+			path:           "/public/42",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "exemption applies only to the pattern method",
+			method:         http.MethodPost,
+			path:           "/public/42",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "more specific pattern overrides exemption",
+			method:         http.MethodGet,
+			path:           "/public/admin",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "route allowing unauthenticated requests is served without auth headers",
+			method:         http.MethodGet,
+			path:           "/ping",
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// given
+			request, err := http.NewRequest(tc.method, server.URL()+tc.path, nil)
+			require.NoError(t, err)
+
+			// when
+			response, err := server.SendGeneralRequest(t, request)
+
+			// then
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedStatus, response.StatusCode)
+			require.Empty(t, response.Header.Get("x-bsv-auth-signature"))
+			require.NoError(t, response.Body.Close())
+		})
+	}
+
+	t.Run("authenticated request to route allowing unauthenticated requests is signed", func(t *testing.T) {
+		// given
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL()})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Do(request)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		require.NotEmpty(t, response.Header.Get("x-bsv-auth-signature"))
+	})
+}
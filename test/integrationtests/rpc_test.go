@@ -0,0 +1,157 @@
+package integrationtests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_RPC(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	clientWallet := mocks.CreateClientMockWallet()
+	clientIdentity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+	identityKey := clientIdentity.PublicKey.ToDERHex()
+
+	connectRequest := func(t *testing.T, url string, body []byte) *http.Request {
+		request, err := http.NewRequest(http.MethodPost, url+mocks.GreetConnectPath, bytes.NewReader(body))
+		require.NoError(t, err)
+		request.Header.Set("Content-Type", "application/proto")
+		request.Header.Set("Connect-Protocol-Version", "1")
+		return request
+	}
+
+	for name, encryption := range map[string]bool{"plain session": false, "session with payload encryption": true} {
+		t.Run("Connect unary call of the "+name+" is authenticated over its protobuf body", func(t *testing.T) {
+			// given
+			options := []func(s *mocks.MockHTTPServer) *mocks.MockHTTPServer{}
+			if encryption {
+				options = append(options, mocks.WithPayloadEncryption)
+			}
+			server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), options...).
+				WithHandler("/", mocks.GreetHandler().WithRPCMiddleware())
+			defer server.Close()
+
+			authClient, err := client.New(client.Config{Wallet: clientWallet, BaseURL: server.URL(), PayloadEncryption: encryption})
+			require.NoError(t, err)
+
+			// when
+			response, err := authClient.Do(connectRequest(t, server.URL(), mocks.EncodeGreetRequest("Alice")))
+
+			// then
+			require.NoError(t, err)
+			assert.ResponseOK(t, response)
+			require.Equal(t, "application/proto", response.Header.Get("Content-Type"))
+
+			body, err := io.ReadAll(response.Body)
+			require.NoError(t, err)
+			greeting, err := mocks.DecodeGreetResponse(body)
+			require.NoError(t, err)
+			require.Equal(t, mocks.GreetResponse{Greeting: "Hello, Alice", IdentityKey: identityKey}, greeting)
+		})
+	}
+
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager()).
+		WithHandler("/", mocks.GreetHandler().WithRPCMiddleware())
+	defer server.Close()
+
+	t.Run("protobuf body other than the signed one is rejected", func(t *testing.T) {
+		// given
+		initialRequest := mocks.PrepareInitialRequestBody(clientWallet)
+		response, err := server.SendNonGeneralRequest(t, initialRequest.AuthMessage())
+		require.NoError(t, err)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+
+		request := connectRequest(t, server.URL(), mocks.EncodeGreetRequest("Mallory"))
+		headers, err := utils.PrepareGeneralRequestHeaders(clientWallet, authMessage, utils.RequestData{
+			Method:  http.MethodPost,
+			URL:     request.URL.String(),
+			Headers: map[string]string{"Content-Type": "application/proto"},
+			Body:    mocks.EncodeGreetRequest("Alice"),
+		})
+		require.NoError(t, err)
+		for k, v := range headers {
+			request.Header.Set(k, v)
+		}
+
+		// when
+		response, err = server.SendGeneralRequest(t, request)
+
+		// then
+		require.NoError(t, err)
+		assert.NotAuthorized(t, response)
+	})
+
+	t.Run("grpc-gateway request passes the identity as metadata and drops forged metadata", func(t *testing.T) {
+		// given
+		authClient, err := client.New(client.Config{Wallet: clientWallet, BaseURL: server.URL()})
+		require.NoError(t, err)
+		request, err := http.NewRequest(http.MethodGet, server.URL()+mocks.GreetGatewayPath+"Alice", nil)
+		require.NoError(t, err)
+		request.Header.Set(auth.GatewayMetadataPrefix+auth.ForwardAuthIdentityKeyHeader, wallettest.ServerIdentityKey)
+
+		// when
+		response, err := authClient.Do(request)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+
+		var greeting mocks.GreetResponse
+		require.NoError(t, json.NewDecoder(response.Body).Decode(&greeting))
+		require.Equal(t, mocks.GreetResponse{Greeting: "Hello, Alice", IdentityKey: identityKey}, greeting)
+	})
+
+	t.Run("gRPC over HTTP/2 is rejected", func(t *testing.T) {
+		// given
+		request, err := http.NewRequest(http.MethodPost, server.URL()+mocks.GreetConnectPath, bytes.NewReader(nil))
+		require.NoError(t, err)
+		request.Header.Set("Content-Type", "application/grpc+proto")
+
+		// when
+		response, err := server.SendGeneralRequest(t, request)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnsupportedMediaType, response.StatusCode)
+	})
+
+	t.Run("protocol is derived from the content type", func(t *testing.T) {
+		tests := map[string]string{
+			"application/proto":           "",
+			"application/json":            "",
+			"application/connect+proto":   auth.RPCProtocolConnect,
+			"application/grpc-web+proto":  auth.RPCProtocolGRPCWeb,
+			"application/grpc-web-text":   auth.RPCProtocolGRPCWeb,
+			"application/grpc":            auth.RPCProtocolGRPC,
+			"application/grpc+proto":      auth.RPCProtocolGRPC,
+			"application/grpc; charset=x": auth.RPCProtocolGRPC,
+		}
+
+		for contentType, expected := range tests {
+			t.Run(contentType, func(t *testing.T) {
+				request, err := http.NewRequest(http.MethodPost, server.URL(), nil)
+				require.NoError(t, err)
+				request.Header.Set("Content-Type", contentType)
+
+				require.Equal(t, expected, auth.RPCProtocol(request))
+			})
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package integrationtests
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_ServerInfoHeader(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	serverIdentityKey := key.PubKey().ToDERHex()
+
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), mocks.WithServerInfoHeader).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	clientWallet := mocks.CreateClientMockWallet()
+	response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+	authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+	require.NoError(t, err)
+
+	send := func(t *testing.T) (*http.Request, *http.Response) {
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+		require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request))
+		response, err := server.SendGeneralRequest(t, request)
+		require.NoError(t, err)
+		return request, response
+	}
+
+	t.Run("general response carries the signed server info", func(t *testing.T) {
+		// when
+		request, response := send(t)
+
+		// then
+		assert.ResponseOK(t, response)
+		serverInfo := response.Header.Get(utils.ServerInfoHeader)
+		require.Equal(t, auth.ServerInfo(), serverInfo)
+		require.True(t, strings.HasPrefix(serverInfo, auth.ImplementationName+"/"))
+		assert.SignedGeneralResponse(t, clientWallet, response, serverIdentityKey, request)
+		require.NoError(t, response.Body.Close())
+	})
+
+	t.Run("tampered server info is detected", func(t *testing.T) {
+		// given
+		request, response := send(t)
+		assert.SignedGeneralResponse(t, clientWallet, response, serverIdentityKey, request)
+
+		// when
+		response.Header.Set(utils.ServerInfoHeader, "ts-sdk/1.0.0")
+
+		// then
+		assert.TamperedGeneralResponse(t, clientWallet, response, serverIdentityKey)
+		require.NoError(t, response.Body.Close())
+	})
+
+	t.Run("removed server info is detected", func(t *testing.T) {
+		// given
+		request, response := send(t)
+		assert.SignedGeneralResponse(t, clientWallet, response, serverIdentityKey, request)
+
+		// when
+		response.Header.Del(utils.ServerInfoHeader)
+
+		// then
+		assert.TamperedGeneralResponse(t, clientWallet, response, serverIdentityKey)
+		require.NoError(t, response.Body.Close())
+	})
+}
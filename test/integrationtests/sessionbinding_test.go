@@ -0,0 +1,139 @@
+package integrationtests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_SessionBinding(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	serverIdentityKey := key.PubKey().ToDERHex()
+
+	sessionManager := session.NewMemoryManager()
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), sessionManager).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	url := server.URL() + "/ping"
+
+	handshake := func(t *testing.T, w wallet.WalletInterface) (*transport.AuthMessage, string) {
+		initialRequest := mocks.PrepareInitialRequestBody(w)
+		response, err := server.SendNonGeneralRequest(t, initialRequest.AuthMessage())
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+		return authMessage, initialRequest.InitialNonce
+	}
+
+	signedRequest := func(t *testing.T, signer wallet.WalletInterface, peerSession *transport.AuthMessage) *http.Request {
+		headers, err := utils.PrepareGeneralRequestHeaders(signer, peerSession, utils.RequestData{Method: http.MethodGet, URL: url})
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, url, nil)
+		require.NoError(t, err)
+		for k, v := range headers {
+			request.Header.Set(k, v)
+		}
+		return request
+	}
+
+	identityKey := func(t *testing.T, w wallet.WalletInterface) string {
+		result, err := w.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+		require.NoError(t, err)
+		return result.PublicKey.ToDERHex()
+	}
+
+	victimWallet := mocks.CreateClientMockWallet()
+	attackerWallet := wallettest.NewSeededMockWallet(wallettest.Seed, "attacker")
+	victimSession, _ := handshake(t, victimWallet)
+	attackerSession, _ := handshake(t, attackerWallet)
+
+	t.Run("nonce of another peer's session is rejected", func(t *testing.T) {
+		// given
+		request := signedRequest(t, attackerWallet, victimSession)
+
+		// when
+		response, err := server.SendGeneralRequest(t, request)
+
+		// then
+		require.NoError(t, err)
+		assert.NotAuthorized(t, response)
+		assert.IdentityKeyMismatchError(t, response)
+	})
+
+	t.Run("identity of another peer over own session is rejected", func(t *testing.T) {
+		// given
+		request := signedRequest(t, attackerWallet, attackerSession)
+		request.Header.Set("x-bsv-auth-identity-key", identityKey(t, victimWallet))
+
+		// when
+		response, err := server.SendGeneralRequest(t, request)
+
+		// then
+		require.NoError(t, err)
+		assert.NotAuthorized(t, response)
+		assert.IdentityKeyMismatchError(t, response)
+	})
+
+	t.Run("signature of another identity over the session of the claimed peer is rejected", func(t *testing.T) {
+		// given
+		request := signedRequest(t, attackerWallet, victimSession)
+		request.Header.Set("x-bsv-auth-identity-key", identityKey(t, victimWallet))
+
+		// when
+		response, err := server.SendGeneralRequest(t, request)
+
+		// then
+		require.NoError(t, err)
+		assert.NotAuthorized(t, response)
+		assert.UnableToVerifySignatureError(t, response)
+	})
+
+	t.Run("victim session keeps working", func(t *testing.T) {
+		// given
+		request := signedRequest(t, victimWallet, victimSession)
+
+		// when
+		response, err := server.SendGeneralRequest(t, request)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		assert.SignedGeneralResponse(t, victimWallet, response, serverIdentityKey, request)
+		require.NoError(t, response.Body.Close())
+	})
+
+	t.Run("response is signed for the session of the request when the peer has concurrent sessions", func(t *testing.T) {
+		// given
+		peerWallet := wallettest.NewSeededMockWallet(wallettest.Seed, "concurrent")
+		firstSession, firstNonce := handshake(t, peerWallet)
+		_, secondNonce := handshake(t, peerWallet)
+		require.NotEqual(t, firstNonce, secondNonce)
+
+		request := signedRequest(t, peerWallet, firstSession)
+
+		// when
+		response, err := server.SendGeneralRequest(t, request)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		require.Equal(t, firstNonce, response.Header.Get("x-bsv-auth-your-nonce"))
+		assert.SignedGeneralResponse(t, peerWallet, response, serverIdentityKey, request)
+		require.NoError(t, response.Body.Close())
+	})
+}
@@ -0,0 +1,106 @@
+package integrationtests
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_SessionKeys(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	var server *mocks.MockHTTPServer
+	middleware := func() *auth.Middleware { return server.AuthMiddleware() }
+	server = mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), nil).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/notes", mocks.SessionKeyHandler(middleware, "notes").WithAuthMiddleware())
+	defer server.Close()
+
+	// send posts the ciphertext to the handler encrypting its response with the session key of the notes label
+	send := func(t *testing.T, authClient *client.Client, ciphertext []byte) *http.Response {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL()+"/notes", bytes.NewReader(ciphertext))
+		require.NoError(t, err)
+		response, err := authClient.Do(req)
+		require.NoError(t, err)
+		return response
+	}
+
+	t.Run("data encrypted with the session key of the client is decrypted by the server and vice versa", func(t *testing.T) {
+		// given
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL()})
+		require.NoError(t, err)
+		require.NoError(t, authClient.Handshake(context.Background()))
+
+		sessionKey, err := authClient.SessionKey("notes")
+		require.NoError(t, err)
+		ciphertext, err := sessionKey.Encrypt([]byte("secret"))
+		require.NoError(t, err)
+
+		// when
+		response := send(t, authClient, ciphertext)
+
+		// then
+		require.Equal(t, http.StatusOK, response.StatusCode)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+		plaintext, err := sessionKey.Decrypt(body)
+		require.NoError(t, err)
+		require.Equal(t, "echo: secret", string(plaintext))
+	})
+
+	t.Run("data encrypted with the key of another label cannot be decrypted", func(t *testing.T) {
+		// given
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL()})
+		require.NoError(t, err)
+		require.NoError(t, authClient.Handshake(context.Background()))
+
+		sessionKey, err := authClient.SessionKey("messages")
+		require.NoError(t, err)
+		ciphertext, err := sessionKey.Encrypt([]byte("secret"))
+		require.NoError(t, err)
+
+		// when
+		response := send(t, authClient, ciphertext)
+
+		// then
+		require.NoError(t, response.Body.Close())
+		require.Equal(t, http.StatusBadRequest, response.StatusCode)
+	})
+
+	t.Run("client without session has no session key", func(t *testing.T) {
+		// given
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL()})
+		require.NoError(t, err)
+
+		// when
+		_, err = authClient.SessionKey("notes")
+
+		// then
+		require.ErrorIs(t, err, client.ErrNoSession)
+	})
+
+	t.Run("invalid label is rejected", func(t *testing.T) {
+		// given
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL()})
+		require.NoError(t, err)
+		require.NoError(t, authClient.Handshake(context.Background()))
+
+		// when
+		_, err = authClient.SessionKey("Notes!")
+
+		// then
+		require.ErrorIs(t, err, transport.ErrInvalidSessionKey)
+	})
+}
@@ -0,0 +1,343 @@
+package integrationtests
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	wstransport "github.com/bsv-blockchain/go-bsv-middleware/pkg/transport/websocket"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/websocket"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_SocketRequests(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	sessionManager := session.NewMemoryManager()
+	server := mocks.CreateMockSocketServer(mocks.CreateServerMockWallet(key), sessionManager)
+	defer server.Close()
+
+	clientWallet := mocks.CreateClientMockWallet()
+	clientIdentity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+
+	// when
+	socket, err := client.DialSocket(context.Background(), server.URL(), clientWallet)
+	require.NoError(t, err)
+	defer func() { _ = socket.Close() }()
+
+	var wg sync.WaitGroup
+	responses := make([][]byte, 10)
+	errs := make([]error, 10)
+	for i := range responses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i], errs[i] = socket.Request(context.Background(), []byte(strconv.Itoa(i)))
+		}()
+	}
+	wg.Wait()
+
+	// then
+	require.Equal(t, key.PubKey().ToDERHex(), socket.ServerIdentityKey())
+	for i := range responses {
+		require.NoError(t, errs[i])
+		require.Equal(t, clientIdentity.PublicKey.ToDERHex()+": "+strconv.Itoa(i), string(responses[i]))
+	}
+
+	session := sessionManager.GetSession(clientIdentity.PublicKey.ToDERHex())
+	require.NotNil(t, session)
+	require.True(t, session.IsAuthenticated)
+}
+
+func TestClient_ConnectionScopedSocket(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	sessionManager := session.NewMemoryManager()
+	policy := session.ReauthenticationPolicy{MaxMessages: 3}
+	server := mocks.CreateMockSocketServer(mocks.CreateServerMockWallet(key), sessionManager, mocks.WithConnectionScopedSessions(policy))
+	defer server.Close()
+
+	clientWallet := mocks.CreateClientMockWallet()
+	clientIdentity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+
+	// when
+	socket, err := client.DialSocket(context.Background(), server.URL(), clientWallet)
+	require.NoError(t, err)
+
+	responses := make([]string, 10)
+	for i := range responses {
+		response, err := socket.Request(context.Background(), []byte(strconv.Itoa(i)))
+		require.NoError(t, err)
+		responses[i] = string(response)
+	}
+
+	// then
+	for i, response := range responses {
+		require.Equal(t, clientIdentity.PublicKey.ToDERHex()+": "+strconv.Itoa(i), response)
+	}
+
+	session := sessionManager.GetSession(clientIdentity.PublicKey.ToDERHex())
+	require.NotNil(t, session)
+	require.True(t, session.ConnectionScoped)
+	require.Less(t, session.MessageCount, 10)
+
+	require.NoError(t, socket.Close())
+	require.Eventually(t, func() bool {
+		return sessionManager.GetSession(clientIdentity.PublicKey.ToDERHex()) == nil
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestClient_SocketHeartbeat(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		opts []func(cfg *wstransport.Config)
+	}{
+		"per-message session": {},
+		"connection-scoped session": {
+			opts: []func(cfg *wstransport.Config){mocks.WithConnectionScopedSessions(session.ReauthenticationPolicy{MaxMessages: 3})},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			sessionManager := session.NewMemoryManager()
+			server := mocks.CreateMockSocketServer(mocks.CreateServerMockWallet(key), sessionManager, test.opts...)
+			defer server.Close()
+
+			clientWallet := mocks.CreateClientMockWallet()
+			clientIdentity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+			require.NoError(t, err)
+
+			socket, err := client.DialSocket(context.Background(), server.URL(), clientWallet)
+			require.NoError(t, err)
+			defer func() { _ = socket.Close() }()
+
+			session := sessionManager.GetSession(clientIdentity.PublicKey.ToDERHex())
+			require.NotNil(t, session)
+			authenticatedAt := session.LastUpdate
+
+			// when
+			err = socket.Heartbeat()
+
+			// then
+			require.NoError(t, err)
+			require.Eventually(t, func() bool {
+				session := sessionManager.GetSession(clientIdentity.PublicKey.ToDERHex())
+				return session != nil && session.LastUpdate.After(authenticatedAt)
+			}, time.Second, 10*time.Millisecond)
+
+			response, err := socket.Request(context.Background(), []byte("after heartbeat"))
+			require.NoError(t, err)
+			require.Equal(t, clientIdentity.PublicKey.ToDERHex()+": after heartbeat", string(response))
+		})
+	}
+}
+
+func TestSocketTransport_ConnectionScopedMessages(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	policy := session.ReauthenticationPolicy{MaxMessages: 1, Timeout: 100 * time.Millisecond}
+	server := mocks.CreateMockSocketServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), mocks.WithConnectionScopedSessions(policy))
+	defer server.Close()
+	unchallenged := mocks.CreateMockSocketServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), mocks.WithConnectionScopedSessions(session.ReauthenticationPolicy{}))
+	defer unchallenged.Close()
+
+	// connect performs the handshake on a raw socket and returns a signer of the scoped messages of the client
+	connect := func(t *testing.T, server *mocks.MockSocketServer) (*websocket.Conn, func(sequence uint64, payload []byte) transport.AuthMessage) {
+		conn, err := websocket.Dial(context.Background(), server.URL(), nil)
+		require.NoError(t, err)
+
+		clientWallet := mocks.CreateClientMockWallet()
+		initialRequest := mocks.PrepareInitialRequestBody(clientWallet)
+		data, err := json.Marshal(initialRequest.AuthMessage())
+		require.NoError(t, err)
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, data))
+
+		_, data, err = conn.ReadMessage()
+		require.NoError(t, err)
+		var initialResponse transport.AuthMessage
+		require.NoError(t, json.Unmarshal(data, &initialResponse))
+		require.True(t, initialResponse.ConnectionScoped)
+
+		serverIdentityKey, err := ec.PublicKeyFromString(initialResponse.IdentityKey)
+		require.NoError(t, err)
+
+		sign := func(sequence uint64, payload []byte) transport.AuthMessage {
+			signature, err := clientWallet.CreateSignature(&wallet.CreateSignatureArgs{
+				EncryptionArgs: wallet.EncryptionArgs{
+					ProtocolID: wallet.DefaultAuthProtocol,
+					KeyID:      fmt.Sprintf("%s %s", initialRequest.InitialNonce, initialResponse.InitialNonce),
+					Counterparty: wallet.Counterparty{
+						Type:         wallet.CounterpartyTypeOther,
+						Counterparty: serverIdentityKey,
+					},
+				},
+				Data: append(binary.BigEndian.AppendUint64(nil, sequence), payload...),
+			}, "")
+			require.NoError(t, err)
+
+			serialized := signature.Signature.Serialize()
+			return transport.AuthMessage{
+				Version:     transport.AuthVersion,
+				MessageType: transport.General,
+				IdentityKey: initialRequest.IdentityKey,
+				Payload:     &payload,
+				Signature:   &serialized,
+			}
+		}
+		return conn, sign
+	}
+
+	write := func(t *testing.T, conn *websocket.Conn, message transport.AuthMessage) {
+		data, err := json.Marshal(message)
+		require.NoError(t, err)
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, data))
+	}
+
+	// readUntilClosed returns the types of the messages read until the server closes the connection
+	readUntilClosed := func(t *testing.T, conn *websocket.Conn) []transport.MessageType {
+		var messageTypes []transport.MessageType
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				require.ErrorIs(t, err, websocket.ErrClosed)
+				return messageTypes
+			}
+			var message transport.AuthMessage
+			require.NoError(t, json.Unmarshal(data, &message))
+			messageTypes = append(messageTypes, message.MessageType)
+		}
+	}
+
+	payload := []byte(`{"id":"1","payload":"Y2FsbA=="}`)
+
+	t.Run("unanswered reauthentication request closes the connection", func(t *testing.T) {
+		// given
+		conn, sign := connect(t, server)
+		defer func() { _ = conn.Close() }()
+
+		// when
+		write(t, conn, sign(0, payload))
+
+		// then
+		require.ElementsMatch(t, []transport.MessageType{transport.General, transport.ReauthenticationRequest}, readUntilClosed(t, conn))
+	})
+
+	t.Run("replayed message closes the connection", func(t *testing.T) {
+		// given
+		conn, sign := connect(t, unchallenged)
+		defer func() { _ = conn.Close() }()
+		message := sign(0, payload)
+		write(t, conn, message)
+		_, _, err := conn.ReadMessage()
+		require.NoError(t, err)
+
+		// when
+		write(t, conn, message)
+
+		// then
+		require.Empty(t, readUntilClosed(t, conn))
+	})
+
+	t.Run("reauthentication response without a challenge closes the connection", func(t *testing.T) {
+		// given
+		conn, sign := connect(t, server)
+		defer func() { _ = conn.Close() }()
+		message := sign(0, payload)
+		message.MessageType = transport.ReauthenticationResponse
+
+		// when
+		write(t, conn, message)
+
+		// then
+		require.Empty(t, readUntilClosed(t, conn))
+	})
+}
+
+func TestSocketTransport_RejectsUnauthenticatedMessages(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	server := mocks.CreateMockSocketServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager())
+	defer server.Close()
+
+	// send writes the message to a raw socket and returns the error of the next read
+	send := func(t *testing.T, conn *websocket.Conn, message transport.AuthMessage) error {
+		data, err := json.Marshal(message)
+		require.NoError(t, err)
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, data))
+		_, _, err = conn.ReadMessage()
+		return err
+	}
+
+	t.Run("general message before the handshake closes the connection", func(t *testing.T) {
+		// given
+		conn, err := websocket.Dial(context.Background(), server.URL(), nil)
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+
+		initialRequest := mocks.PrepareInitialRequestBody(mocks.CreateClientMockWallet())
+		message := *initialRequest.AuthMessage()
+		message.MessageType = transport.General
+
+		// when
+		err = send(t, conn, message)
+
+		// then
+		require.ErrorIs(t, err, websocket.ErrClosed)
+	})
+
+	t.Run("general message with an invalid signature closes the connection", func(t *testing.T) {
+		// given
+		conn, err := websocket.Dial(context.Background(), server.URL(), nil)
+		require.NoError(t, err)
+		defer func() { _ = conn.Close() }()
+
+		clientWallet := mocks.CreateClientMockWallet()
+		initialRequest := mocks.PrepareInitialRequestBody(clientWallet)
+		data, err := json.Marshal(initialRequest.AuthMessage())
+		require.NoError(t, err)
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, data))
+
+		_, data, err = conn.ReadMessage()
+		require.NoError(t, err)
+		var initialResponse transport.AuthMessage
+		require.NoError(t, json.Unmarshal(data, &initialResponse))
+
+		nonce, err := clientWallet.CreateNonce(context.Background())
+		require.NoError(t, err)
+		payload := []byte(`{"id":"1","payload":"Y2FsbA=="}`)
+		signature := []byte("invalid signature")
+
+		// when
+		err = send(t, conn, transport.AuthMessage{
+			Version:     transport.AuthVersion,
+			MessageType: transport.General,
+			IdentityKey: initialRequest.IdentityKey,
+			Nonce:       &nonce,
+			YourNonce:   &initialResponse.InitialNonce,
+			Payload:     &payload,
+			Signature:   &signature,
+		})
+
+		// then
+		require.ErrorIs(t, err, websocket.ErrClosed)
+	})
+}
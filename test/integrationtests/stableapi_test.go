@@ -0,0 +1,110 @@
+package integrationtests
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/sessionmanager"
+	temporarywallet "github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStableAPI asserts applications can use the middleware with the stable packages and their test helpers alone,
+// without importing pkg/temporary
+func TestStableAPI(t *testing.T) {
+	// given
+	sessions := session.NewMemoryManager()
+	middleware, err := auth.New(auth.Config{
+		Wallet:         wallettest.NewSeededMockWallet("stable-api", "server"),
+		SessionManager: sessions,
+		Logger:         slog.New(slog.DiscardHandler),
+	})
+	require.NoError(t, err)
+	server := httptest.NewServer(middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+	defer server.Close()
+
+	clientWallet := wallettest.NewSeededMockWallet("stable-api", "client")
+	authClient, err := client.New(client.Config{Wallet: clientWallet, BaseURL: server.URL})
+	require.NoError(t, err)
+
+	// when
+	request, err := http.NewRequest(http.MethodGet, server.URL+"/ping", nil)
+	require.NoError(t, err)
+	response, err := authClient.Do(request)
+
+	// then
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	identity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+	peerSession := sessions.GetSession(identity.PublicKey.ToDERHex())
+	require.NotNil(t, peerSession)
+	require.True(t, peerSession.IsAuthenticated)
+	require.Empty(t, peerSession.Certificates)
+
+	// when
+	target := session.NewMemoryManager()
+	report, err := session.Migrate(sessions, target, session.MigrationOptions{})
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, session.MigrationReport{Copied: 1}, report)
+	require.True(t, target.HasSession(identity.PublicKey.ToDERHex()))
+}
+
+// TestDeprecatedAPI asserts the deprecated pkg/temporary packages remain interchangeable with the stable packages
+func TestDeprecatedAPI(t *testing.T) {
+	// given
+	sessions := sessionmanager.NewSessionManager()
+	middleware, err := auth.New(auth.Config{
+		Wallet:         temporarywallet.NewSeededMockWallet("deprecated-api", "server"),
+		SessionManager: sessions,
+		Logger:         slog.New(slog.DiscardHandler),
+	})
+	require.NoError(t, err)
+	server := httptest.NewServer(middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})))
+	defer server.Close()
+
+	var clientWallet wallet.WalletInterface = temporarywallet.NewSeededMockWallet("deprecated-api", "client")
+	authClient, err := client.New(client.Config{Wallet: clientWallet, BaseURL: server.URL})
+	require.NoError(t, err)
+
+	// when
+	request, err := http.NewRequest(http.MethodGet, server.URL+"/ping", nil)
+	require.NoError(t, err)
+	response, err := authClient.Do(request)
+
+	// then
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+	require.Equal(t, http.StatusOK, response.StatusCode)
+
+	identity, err := clientWallet.GetPublicKey(&temporarywallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+	peerSession := sessions.GetSession(identity.PublicKey.ToDERHex())
+	require.NotNil(t, peerSession)
+	require.True(t, peerSession.IsAuthenticated)
+	require.ErrorIs(t, temporarywallet.ErrPermissionDenied, wallet.ErrPermissionDenied)
+
+	// when
+	target := session.NewMemoryManager()
+	report, err := sessionmanager.Migrate(sessions, target, sessionmanager.MigrationOptions{})
+
+	// then
+	require.NoError(t, err)
+	require.Equal(t, session.MigrationReport{Copied: 1}, report)
+	require.True(t, target.HasSession(identity.PublicKey.ToDERHex()))
+}
@@ -0,0 +1,147 @@
+package integrationtests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+// bodyTamperer flips the last byte of every body chunk of responses to the path on the way to the client
+type bodyTamperer struct {
+	path string
+}
+
+func (b bodyTamperer) RoundTrip(req *http.Request) (*http.Response, error) {
+	response, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if req.URL.Path == b.path {
+		response.Body = &tamperedBody{ReadCloser: response.Body}
+	}
+	return response, nil
+}
+
+type tamperedBody struct {
+	io.ReadCloser
+}
+
+func (b *tamperedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		p[n-1] ^= 0xff
+	}
+	return n, err
+}
+
+func TestAuthMiddleware_StreamedResponses(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	chunks := []string{"first chunk,", "second chunk,", "last chunk"}
+	content := strings.Join(chunks, "")
+
+	newServer := func(t *testing.T, opts ...func(s *mocks.MockHTTPServer) *mocks.MockHTTPServer) *mocks.MockHTTPServer {
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), opts...).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/download", mocks.StreamHandler(chunks...).WithAuthMiddleware())
+		t.Cleanup(server.Close)
+		return server
+	}
+
+	t.Run("flushed response is streamed with a signature trailer", func(t *testing.T) {
+		// given
+		server := newServer(t)
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL()})
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Get(context.Background(), "/download")
+		require.NoError(t, err)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, response.Body.Close())
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, content, string(body))
+		require.Equal(t, []string{"chunked"}, response.TransferEncoding)
+		require.Empty(t, response.Header.Get("x-bsv-auth-signature"))
+		require.NotEmpty(t, response.Trailer.Get("x-bsv-auth-signature"))
+		require.Contains(t, authClient.Capabilities(), transport.CapabilityTrailerSignature)
+	})
+
+	t.Run("tampered streamed body fails verification", func(t *testing.T) {
+		// given
+		server := newServer(t)
+		authClient, err := client.New(client.Config{
+			Wallet:     mocks.CreateClientMockWallet(),
+			BaseURL:    server.URL(),
+			HTTPClient: &http.Client{Transport: bodyTamperer{path: "/download"}},
+		})
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Get(context.Background(), "/download")
+		require.NoError(t, err)
+		_, err = io.ReadAll(response.Body)
+		require.NoError(t, response.Body.Close())
+
+		// then
+		require.ErrorIs(t, err, client.ErrInvalidTrailerSignature)
+	})
+
+	t.Run("response is buffered for sessions with payload padding", func(t *testing.T) {
+		// given
+		server := newServer(t, mocks.WithPayloadPadding)
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL(), PayloadPadding: true})
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Get(context.Background(), "/download")
+		require.NoError(t, err)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, response.Body.Close())
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, content, string(body))
+		require.NotEmpty(t, response.Header.Get("x-bsv-auth-signature"))
+		require.Empty(t, response.Trailer)
+	})
+
+	t.Run("response is buffered for peers without the capability", func(t *testing.T) {
+		// given
+		server := newServer(t)
+		clientWallet := mocks.CreateClientMockWallet()
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+		require.NoError(t, err)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/download", nil)
+		require.NoError(t, err)
+		require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request))
+
+		// when
+		response, err = server.SendGeneralRequest(t, request)
+		require.NoError(t, err)
+
+		// then
+		assert.ResponseOK(t, response)
+		assert.SignedGeneralResponse(t, clientWallet, response, key.PubKey().ToDERHex(), request)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.Equal(t, content, string(body))
+		require.Empty(t, response.Trailer)
+	})
+}
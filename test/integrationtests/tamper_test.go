@@ -0,0 +1,206 @@
+package integrationtests
+
+import (
+	"bytes"
+	"io"
+	"maps"
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+// signedRequest is a general request which can be modified after it was signed
+type signedRequest struct {
+	method  string
+	path    string
+	query   string
+	headers map[string]string
+	body    []byte
+}
+
+func TestGeneralRequest_TamperMatrix(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	serverIdentityKey := key.PubKey().ToDERHex()
+
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager()).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/echo", mocks.EchoHandler().WithAuthMiddleware()).
+		WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	clientWallet := mocks.CreateClientMockWallet()
+	initialRequest := mocks.PrepareInitialRequestBody(clientWallet)
+	response, err := server.SendNonGeneralRequest(t, initialRequest.AuthMessage())
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+	authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+	require.NoError(t, err)
+
+	// sign signs a fresh request, so every mutation is verified against its own request ID
+	sign := func(t *testing.T) *signedRequest {
+		r := &signedRequest{
+			method:  http.MethodPost,
+			path:    "/echo",
+			query:   "item=1",
+			headers: map[string]string{"x-bsv-custom": "original"},
+			body:    []byte(`{"amount":100}`),
+		}
+
+		authHeaders, err := utils.PrepareGeneralRequestHeaders(clientWallet, authMessage, utils.RequestData{
+			Method:  r.method,
+			URL:     server.URL() + r.path + "?" + r.query,
+			Headers: maps.Clone(r.headers),
+			Body:    r.body,
+		})
+		require.NoError(t, err)
+		maps.Copy(r.headers, authHeaders)
+
+		return r
+	}
+
+	send := func(t *testing.T, r *signedRequest) (*http.Request, *http.Response) {
+		url := server.URL() + r.path
+		if r.query != "" {
+			url += "?" + r.query
+		}
+
+		request, err := http.NewRequest(r.method, url, bytes.NewReader(r.body))
+		require.NoError(t, err)
+		for k, v := range r.headers {
+			request.Header.Set(k, v)
+		}
+
+		response, err := server.SendGeneralRequest(t, request)
+		require.NoError(t, err)
+		return request, response
+	}
+
+	t.Run("untampered request is verified and its response signed", func(t *testing.T) {
+		// when
+		request, response := send(t, sign(t))
+
+		// then
+		assert.ResponseOK(t, response)
+		assert.SignedGeneralResponse(t, clientWallet, response, serverIdentityKey, request)
+		require.NoError(t, response.Body.Close())
+	})
+
+	// every element of the signed payload, a mutation must fail the signature verification
+	payloadMutations := map[string]func(r *signedRequest){
+		"method":           func(r *signedRequest) { r.method = http.MethodPut },
+		"path":             func(r *signedRequest) { r.path = "/ping" },
+		"query value":      func(r *signedRequest) { r.query = "item=2" },
+		"query removed":    func(r *signedRequest) { r.query = "" },
+		"query added":      func(r *signedRequest) { r.query += "&admin=true" },
+		"header value":     func(r *signedRequest) { r.headers["x-bsv-custom"] = "modified" },
+		"header removed":   func(r *signedRequest) { delete(r.headers, "x-bsv-custom") },
+		"header added":     func(r *signedRequest) { r.headers["authorization"] = "Bearer token" },
+		"content type set": func(r *signedRequest) { r.headers["content-type"] = "text/plain" },
+		"body byte":        func(r *signedRequest) { r.body[len(r.body)-2] ^= 0x01 },
+		"body truncated":   func(r *signedRequest) { r.body = r.body[:len(r.body)-1] },
+		"body removed":     func(r *signedRequest) { r.body = nil },
+		"body appended":    func(r *signedRequest) { r.body = append(r.body, ' ') },
+		"request ID":       func(r *signedRequest) { r.headers["x-bsv-auth-request-id"] = wallettest.DefaultNonces[1] },
+		"nonce":            func(r *signedRequest) { r.headers["x-bsv-auth-nonce"] = wallettest.DefaultNonces[2] },
+		"signature": func(r *signedRequest) {
+			r.headers["x-bsv-auth-signature"] = flipLastHexDigit(r.headers["x-bsv-auth-signature"])
+		},
+	}
+
+	for name, mutate := range payloadMutations {
+		t.Run("tampered "+name+" fails signature verification", func(t *testing.T) {
+			// given
+			r := sign(t)
+			mutate(r)
+
+			// when
+			_, response := send(t, r)
+
+			// then
+			assert.NotAuthorized(t, response)
+			assert.UnableToVerifySignatureError(t, response)
+		})
+	}
+
+	// auth headers which are not signed, they are bound to the session instead
+	headerMutations := map[string]func(r *signedRequest){
+		"version":    func(r *signedRequest) { r.headers["x-bsv-auth-version"] = "0.2" },
+		"your nonce": func(r *signedRequest) { r.headers["x-bsv-auth-your-nonce"] = wallettest.DefaultNonces[3] },
+	}
+
+	for name, mutate := range headerMutations {
+		t.Run("tampered "+name+" is rejected", func(t *testing.T) {
+			// given
+			r := sign(t)
+			mutate(r)
+
+			// when
+			_, response := send(t, r)
+
+			// then
+			assert.NotAuthorized(t, response)
+			require.NoError(t, response.Body.Close())
+		})
+	}
+
+	t.Run("tampered response status is detected", func(t *testing.T) {
+		// given
+		request, response := send(t, sign(t))
+		assert.SignedGeneralResponse(t, clientWallet, response, serverIdentityKey, request)
+
+		// when
+		response.StatusCode = http.StatusCreated
+
+		// then
+		assert.TamperedGeneralResponse(t, clientWallet, response, serverIdentityKey)
+		require.NoError(t, response.Body.Close())
+	})
+
+	t.Run("tampered response body byte is detected", func(t *testing.T) {
+		// given
+		request, response := send(t, sign(t))
+		assert.SignedGeneralResponse(t, clientWallet, response, serverIdentityKey, request)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+
+		// when
+		body[0] ^= 0x01
+		response.Body = io.NopCloser(bytes.NewReader(body))
+
+		// then
+		assert.TamperedGeneralResponse(t, clientWallet, response, serverIdentityKey)
+		require.NoError(t, response.Body.Close())
+	})
+
+	t.Run("response for another request is detected", func(t *testing.T) {
+		// given
+		request, response := send(t, sign(t))
+		assert.SignedGeneralResponse(t, clientWallet, response, serverIdentityKey, request)
+
+		// when
+		response.Header.Set("x-bsv-auth-request-id", wallettest.DefaultNonces[1])
+
+		// then
+		assert.TamperedGeneralResponse(t, clientWallet, response, serverIdentityKey)
+		require.NoError(t, response.Body.Close())
+	})
+}
+
+// flipLastHexDigit changes the last digit of a hex string, keeping it valid hex
+func flipLastHexDigit(s string) string {
+	last := s[len(s)-1]
+	replacement := byte('0')
+	if last == '0' {
+		replacement = '1'
+	}
+	return s[:len(s)-1] + string(replacement)
+}
@@ -0,0 +1,158 @@
+package integrationtests
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_Tarpit(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	clientWallet := mocks.CreateClientMockWallet()
+	clientIdentity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+	identityKey := clientIdentity.PublicKey.ToDERHex()
+
+	abusive := func(_ context.Context, key string) bool { return key == identityKey }
+	const delay = 50 * time.Millisecond
+
+	// newSession returns a server with the options and a session of the client
+	newSession := func(t *testing.T, opts ...func(s *mocks.MockHTTPServer) *mocks.MockHTTPServer) (*mocks.MockHTTPServer, *transport.AuthMessage) {
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), opts...).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+		t.Cleanup(server.Close)
+
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+
+		return server, authMessage
+	}
+
+	t.Run("request of an abusive identity is delayed, answered with a signed fake response and audited", func(t *testing.T) {
+		// given
+		logs := &accessLogBuffer{}
+		server, authMessage := newSession(t, mocks.WithTarpit(auth.TarpitPolicy{
+			IsAbusive:   abusive,
+			Delay:       delay,
+			AuditLogger: slog.New(slog.NewJSONHandler(logs, nil)),
+		}))
+
+		// when
+		start := time.Now()
+		response := ping(t, server, clientWallet, authMessage)
+
+		// then
+		require.GreaterOrEqual(t, time.Since(start), delay)
+		assert.ResponseOK(t, response)
+		require.NotEmpty(t, response.Header.Get("x-bsv-auth-signature"))
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.Equal(t, "{}", string(body))
+
+		lines := logs.lines(t)
+		require.Len(t, lines, 1)
+		require.Equal(t, "WARN", lines[0]["level"])
+		require.Equal(t, auth.TarpitReasonAbusive, lines[0]["reason"])
+		require.Equal(t, identityKey, lines[0]["identityKey"])
+		require.Equal(t, "/ping", lines[0]["path"])
+	})
+
+	t.Run("request denied by the authorizer is sent to the tarpit handler", func(t *testing.T) {
+		// given
+		server, authMessage := newSession(t,
+			mocks.WithAuthorizer(auth.AuthorizerFunc(func(context.Context, auth.AuthorizationInput) (auth.Decision, error) {
+				return auth.Decision{}, nil
+			})),
+			mocks.WithTarpit(auth.TarpitPolicy{
+				Denied: true,
+				Delay:  delay,
+				Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					w.WriteHeader(http.StatusNotFound)
+				}),
+			}))
+
+		// when
+		response := ping(t, server, clientWallet, authMessage)
+
+		// then
+		require.Equal(t, http.StatusNotFound, response.StatusCode)
+	})
+
+	t.Run("locked out request is sent to the tarpit instead of 429", func(t *testing.T) {
+		// given
+		server, authMessage := newSession(t,
+			mocks.WithAnomalyPolicy(auth.AnomalyPolicy{IdentityThreshold: 1, IdentityLockout: time.Minute}),
+			mocks.WithTarpit(auth.TarpitPolicy{LockedOut: true, Delay: delay}))
+
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+		require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request, mocks.WithWrongSignature))
+		response, err := server.SendGeneralRequest(t, request)
+		require.NoError(t, err)
+		assert.NotAuthorized(t, response)
+
+		// when
+		response = ping(t, server, clientWallet, authMessage)
+
+		// then
+		assert.ResponseOK(t, response)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.Equal(t, "{}", string(body))
+	})
+
+	t.Run("request over the concurrency of the tarpit is rejected", func(t *testing.T) {
+		// given
+		entered, release := make(chan struct{}), make(chan struct{})
+		server, authMessage := newSession(t, mocks.WithTarpit(auth.TarpitPolicy{
+			IsAbusive:   abusive,
+			Delay:       time.Millisecond,
+			Concurrency: 1,
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				close(entered)
+				<-release
+			}),
+		}))
+
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+		require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request))
+
+		held := make(chan error)
+		go func() {
+			response, err := http.DefaultClient.Do(request)
+			if err == nil {
+				_ = response.Body.Close()
+			}
+			held <- err
+		}()
+		<-entered
+
+		// when
+		response := ping(t, server, clientWallet, authMessage)
+		close(release)
+
+		// then
+		require.Equal(t, http.StatusForbidden, response.StatusCode)
+		require.NoError(t, <-held)
+	})
+}
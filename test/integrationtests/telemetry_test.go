@@ -0,0 +1,123 @@
+package integrationtests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_Telemetry(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	var snapshots []auth.SessionSnapshot
+	sinkErr := errors.New("sink unavailable")
+	failSink := true
+	lines := &bytes.Buffer{}
+
+	telemetry, err := auth.NewSessionTelemetry(auth.TelemetryOptions{
+		Sinks: []auth.TelemetrySink{
+			auth.TelemetrySinkFunc(func(_ context.Context, snapshot auth.SessionSnapshot) error {
+				if failSink {
+					return sinkErr
+				}
+				snapshots = append(snapshots, snapshot)
+				return nil
+			}),
+			auth.NewJSONTelemetrySink(lines),
+		},
+		Salt:   []byte("salt"),
+		Locate: func(addr netip.Addr) string { return map[bool]string{true: "loopback"}[addr.IsLoopback()] },
+	})
+	require.NoError(t, err)
+
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(),
+		mocks.WithTelemetry(telemetry),
+		mocks.WithRoutePolicies(map[string]auth.RoutePolicy{"/health": {Exempt: true}})).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware()).
+		WithHandler("/health", mocks.PingHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	clientWallet := mocks.CreateClientMockWallet()
+	clientIdentity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+	identityKey := clientIdentity.PublicKey.ToDERHex()
+
+	// when
+	response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+	authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+	require.NoError(t, err)
+	require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request))
+	response, err = server.SendGeneralRequest(t, request)
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+
+	request, err = http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+	require.NoError(t, err)
+	require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request, mocks.WithWrongSignature))
+	response, err = server.SendGeneralRequest(t, request)
+	require.NoError(t, err)
+	assert.NotAuthorized(t, response)
+
+	request, err = http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+	require.NoError(t, err)
+	response, err = server.SendGeneralRequest(t, request)
+	require.NoError(t, err)
+	assert.NotAuthorized(t, response)
+
+	request, err = http.NewRequest(http.MethodGet, server.URL()+"/health", nil)
+	require.NoError(t, err)
+	response, err = server.SendGeneralRequest(t, request)
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+
+	// then
+	require.ErrorIs(t, telemetry.Flush(context.Background()), sinkErr)
+	failSink = false
+	require.NoError(t, telemetry.Flush(context.Background()))
+	require.Len(t, snapshots, 1)
+
+	snapshot := snapshots[0]
+	require.EqualValues(t, 4, snapshot.Requests)
+	require.EqualValues(t, 2, snapshot.Failures)
+	require.EqualValues(t, 1, snapshot.Handshakes)
+	require.Equal(t, transport.WalletCalls{NoncesCreated: 2, NoncesVerified: 1, SignaturesCreated: 2, SignaturesVerified: 1}, snapshot.WalletCalls)
+	require.Equal(t, []auth.TelemetryCount{{Key: "127.0.0.0/24", Requests: 4, Failures: 2, FailureRate: 0.5, WalletCalls: 6}}, snapshot.Networks)
+	require.Equal(t, []auth.TelemetryCount{{Key: "loopback", Requests: 4, Failures: 2, FailureRate: 0.5, WalletCalls: 6}}, snapshot.Locations)
+	require.Equal(t, []auth.TelemetryCount{{Key: identityKey[:auth.DefaultTelemetryIdentityPrefix], Requests: 2, Failures: 1, FailureRate: 0.5, WalletCalls: 4}}, snapshot.IdentityPrefixes)
+
+	require.Len(t, snapshot.Identities, 1)
+	require.Equal(t, auth.TelemetryCount{Key: snapshot.Identities[0].Key, Requests: 2, Failures: 1, FailureRate: 0.5, WalletCalls: 4}, snapshot.Identities[0])
+	require.NotContains(t, snapshot.Identities[0].Key, identityKey)
+
+	var exported auth.SessionSnapshot
+	require.NoError(t, json.Unmarshal(bytes.TrimSpace(lines.Bytes()), &exported))
+	require.Equal(t, snapshot.Networks, exported.Networks)
+	require.NotContains(t, lines.String(), identityKey)
+
+	next := telemetry.Snapshot()
+	require.Zero(t, next.Requests)
+	require.Empty(t, next.Networks)
+	require.Equal(t, snapshot.PeriodEnd, next.PeriodStart)
+}
@@ -0,0 +1,126 @@
+package integrationtests
+
+import (
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_Tiers(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	certificateRequirements := transport.NewRequestedCertificateSet(trustedCertifier).AddType(ageVerificationType, "plan")
+	tiers := auth.TierPolicy{
+		Tiers: []auth.Tier{
+			{Name: "basic", RateLimit: 1},
+			{Name: "enterprise", RateLimit: 10, FreeRequests: 2},
+		},
+		Resolver:    auth.CertificateFieldTiers(ageVerificationType, "plan", map[string]string{"enterprise": "enterprise"}),
+		DefaultTier: "basic",
+	}
+
+	// newSession returns a server deriving tiers and a session authenticated with a certificate of the given plan
+	newSession := func(t *testing.T, plan string) (*mocks.MockHTTPServer, *mocks.MockableSessionManager, wallet.WalletInterface, *transport.AuthMessage) {
+		onCertificatesReceived := func(_ string, _ *[]certificates.VerifiableCertificate, _ *http.Request, _ http.ResponseWriter, next func()) {
+			next()
+		}
+
+		sessionManager := mocks.NewMockableSessionManager()
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), sessionManager,
+			mocks.WithCertificateRequirements(certificateRequirements, onCertificatesReceived),
+			mocks.WithTiers(tiers),
+			mocks.WithPayment(wallettest.NewMockPaymentWallet(key), 100)).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/tier", mocks.TierHandler().WithAuthMiddleware()).
+			WithHandler("/paid", mocks.TierHandler().WithPaymentMiddleware().WithAuthMiddleware())
+		t.Cleanup(server.Close)
+
+		clientWallet := mocks.CreateClientMockWallet()
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+
+		sendCertificates(t, server, clientWallet, authMessage, "serial-1", map[string]any{"plan": plan})
+
+		return server, sessionManager, clientWallet, authMessage
+	}
+
+	// send sends an authenticated request to the path over the session
+	send := func(t *testing.T, server *mocks.MockHTTPServer, clientWallet wallet.WalletInterface, authMessage *transport.AuthMessage, path string) *http.Response {
+		request, err := http.NewRequest(http.MethodGet, server.URL()+path, nil)
+		require.NoError(t, err)
+		require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request))
+
+		response, err := server.SendGeneralRequest(t, request)
+		require.NoError(t, err)
+		return response
+	}
+
+	t.Run("tier is resolved from the certificate field and stored on the session", func(t *testing.T) {
+		// given
+		server, sessionManager, clientWallet, authMessage := newSession(t, "enterprise")
+
+		// when
+		response := send(t, server, clientWallet, authMessage, "/tier")
+
+		// then
+		assert.ResponseOK(t, response)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+		require.Equal(t, "enterprise", string(body))
+
+		session := sessionManager.GetSession(authMessage.InitialNonce)
+		require.NotNil(t, session)
+		require.Equal(t, "enterprise", session.Tier)
+	})
+
+	t.Run("peers without matching field get the default tier and its rate limit", func(t *testing.T) {
+		// given
+		server, _, clientWallet, authMessage := newSession(t, "free")
+		assert.ResponseOK(t, send(t, server, clientWallet, authMessage, "/tier"))
+
+		// when
+		response := send(t, server, clientWallet, authMessage, "/tier")
+
+		// then
+		assert.ErrorResponseCode(t, response, http.StatusTooManyRequests, transport.ErrCodeRateLimited)
+		require.NotEmpty(t, response.Header.Get("Retry-After"))
+	})
+
+	t.Run("free requests of the tier are served without payment", func(t *testing.T) {
+		// given
+		server, _, clientWallet, authMessage := newSession(t, "enterprise")
+
+		// when
+		first := send(t, server, clientWallet, authMessage, "/paid")
+		second := send(t, server, clientWallet, authMessage, "/paid")
+		third := send(t, server, clientWallet, authMessage, "/paid")
+
+		// then
+		assert.ResponseOK(t, first)
+		assert.ResponseOK(t, second)
+		require.Equal(t, http.StatusPaymentRequired, third.StatusCode)
+	})
+
+	t.Run("tier policy without resolver is rejected", func(t *testing.T) {
+		// when
+		_, err := auth.New(auth.Config{Wallet: mocks.CreateServerMockWallet(key), Tiers: &auth.TierPolicy{Tiers: tiers.Tiers}})
+
+		// then
+		require.ErrorIs(t, err, auth.ErrInvalidTierPolicy)
+	})
+}
@@ -0,0 +1,135 @@
+package integrationtests
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/utils"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_TokenMinting(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	hmacKey := []byte("downstream-secret")
+
+	minting := auth.TokenMinting{
+		Signer:   auth.HS256Signer(hmacKey, "gateway-1"),
+		Issuer:   "bsv-gateway",
+		Audience: "orders",
+		TTL:      time.Minute,
+		Claims: func(result auth.AuthResult) map[string]any {
+			return map[string]any{"scope": "orders:read", "sub": "overridden"}
+		},
+		Header: "Authorization",
+	}
+
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), mocks.WithTokenMinting(minting)).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/token", mocks.TokenHandler().WithAuthMiddleware()).
+		WithForwardAuth("/forward-auth")
+	defer server.Close()
+
+	clientWallet := mocks.CreateClientMockWallet()
+	response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+	authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+	require.NoError(t, err)
+
+	clientIdentity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+
+	// verifyToken checks the HS256 signature of the bearer token and returns its header and claims
+	verifyToken := func(t *testing.T, bearer string) (map[string]any, map[string]any) {
+		token, ok := strings.CutPrefix(bearer, "Bearer ")
+		require.True(t, ok)
+		parts := strings.Split(token, ".")
+		require.Len(t, parts, 3)
+
+		mac := hmac.New(sha256.New, hmacKey)
+		mac.Write([]byte(parts[0] + "." + parts[1]))
+		require.Equal(t, base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), parts[2])
+
+		var header, claims map[string]any
+		for i, v := range []*map[string]any{&header, &claims} {
+			decoded, err := base64.RawURLEncoding.DecodeString(parts[i])
+			require.NoError(t, err)
+			require.NoError(t, json.Unmarshal(decoded, v))
+		}
+		return header, claims
+	}
+
+	t.Run("token is minted for the handler and forwarded in the request header", func(t *testing.T) {
+		// given
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/token", nil)
+		require.NoError(t, err)
+		require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request))
+
+		// when
+		response, err := server.SendGeneralRequest(t, request)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		bearer, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+
+		header, claims := verifyToken(t, string(bearer))
+		require.Equal(t, "HS256", header["alg"])
+		require.Equal(t, "gateway-1", header["kid"])
+		require.Equal(t, clientIdentity.PublicKey.ToDERHex(), claims["sub"])
+		require.Equal(t, "bsv-gateway", claims["iss"])
+		require.Equal(t, "orders", claims["aud"])
+		require.Equal(t, "orders:read", claims["scope"])
+		require.InDelta(t, time.Minute.Seconds(), claims["exp"].(float64)-claims["iat"].(float64), 0)
+	})
+
+	t.Run("ForwardAuth response carries the token", func(t *testing.T) {
+		// given
+		headers, err := utils.PrepareGeneralRequestHeaders(clientWallet, authMessage, utils.RequestData{
+			Method: http.MethodGet,
+			URL:    "http://upstream.local/orders",
+		})
+		require.NoError(t, err)
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/forward-auth", nil)
+		require.NoError(t, err)
+		for k, v := range headers {
+			request.Header.Set(k, v)
+		}
+		request.Header.Set(auth.ForwardedMethodHeader, http.MethodGet)
+		request.Header.Set(auth.ForwardedURIHeader, "/orders")
+
+		// when
+		response, err := server.SendGeneralRequest(t, request)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		_, claims := verifyToken(t, response.Header.Get("Authorization"))
+		require.Equal(t, clientIdentity.PublicKey.ToDERHex(), claims["sub"])
+	})
+
+	t.Run("token minting without signer is rejected", func(t *testing.T) {
+		// when
+		_, err := auth.New(auth.Config{Wallet: mocks.CreateServerMockWallet(key), TokenMinting: &auth.TokenMinting{Issuer: "bsv-gateway"}})
+
+		// then
+		require.ErrorIs(t, err, auth.ErrInvalidTokenMinting)
+	})
+}
@@ -0,0 +1,125 @@
+package integrationtests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_URLEncoding(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	// newServer returns a server behind a proxy which re-encodes the URL of general requests with rewrite
+	newServer := func(t *testing.T, rewrite func(u *url.URL)) string {
+		middleware, err := auth.New(auth.Config{
+			Wallet:         mocks.CreateServerMockWallet(key),
+			SessionManager: session.NewMemoryManager(),
+		})
+		require.NoError(t, err)
+
+		handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if rewrite != nil && req.URL.Path != auth.HandshakePath {
+				rewrite(req.URL)
+			}
+			handler.ServeHTTP(w, req)
+		}))
+		t.Cleanup(server.Close)
+		return server.URL
+	}
+
+	tests := map[string]struct {
+		target   string
+		rewrite  func(u *url.URL)
+		verified bool
+	}{
+		"emoji in path and query": {
+			target:   "/files/\U0001F600?mood=\U0001F600",
+			verified: true,
+		},
+		"non-ASCII characters": {
+			target:   "/café/naïve?city=Zürich",
+			verified: true,
+		},
+		"reserved characters": {
+			target:   "/a!$&'()*+,;=:@b?next=/a?b&c=d",
+			verified: true,
+		},
+		"lower-case hex digits upper-cased by the proxy": {
+			target: "/caf%c3%a9?q=%e2%9c%93",
+			rewrite: func(u *url.URL) {
+				u.RawPath = "/caf%C3%A9"
+				u.RawQuery = "q=%E2%9C%93"
+			},
+			verified: true,
+		},
+		"unreserved characters decoded by the proxy": {
+			target: "/%7Euser/%41?a=%61",
+			rewrite: func(u *url.URL) {
+				u.Path, u.RawPath = "/~user/A", ""
+				u.RawQuery = "a=a"
+			},
+			verified: true,
+		},
+		"non-ASCII characters encoded by the proxy": {
+			target: "/café",
+			rewrite: func(u *url.URL) {
+				u.RawPath = "/caf%c3%a9"
+			},
+			verified: true,
+		},
+		"encoded slash decoded by the proxy": {
+			target: "/a%2Fb",
+			rewrite: func(u *url.URL) {
+				u.Path, u.RawPath = "/a/b", ""
+			},
+		},
+		"plus decoded as space by the proxy": {
+			target: "/search?q=a+b",
+			rewrite: func(u *url.URL) {
+				u.RawQuery = "q=a%20b"
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			serverURL := newServer(t, test.rewrite)
+			authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: serverURL})
+			require.NoError(t, err)
+
+			request, err := http.NewRequestWithContext(context.Background(), http.MethodGet, serverURL+test.target, nil)
+			require.NoError(t, err)
+
+			// when
+			response, err := authClient.Do(request)
+
+			// then
+			if !test.verified {
+				var serverErr *client.ServerError
+				require.ErrorAs(t, err, &serverErr)
+				require.Equal(t, transport.ErrCodeInvalidSignature, serverErr.Code)
+				return
+			}
+			require.NoError(t, err)
+			assert.ResponseOK(t, response)
+			require.NoError(t, response.Body.Close())
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package integrationtests
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_VerificationReports(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	certificateRequirements := transport.NewRequestedCertificateSet(trustedCertifier).AddType(ageVerificationType, "age")
+
+	// exchange sends a certificate revealing the fields of the keyring to a server publishing verification reports
+	exchange := func(t *testing.T, accept bool, keyring map[string]string, opts ...func(s *mocks.MockHTTPServer) *mocks.MockHTTPServer) ([]transport.VerificationReport, *accessLogBuffer) {
+		var mu sync.Mutex
+		var reports []transport.VerificationReport
+		logs := &accessLogBuffer{}
+
+		onCertificatesReceived := func(_ string, _ *[]certificates.VerifiableCertificate, _ *http.Request, _ http.ResponseWriter, next func()) {
+			if accept {
+				next()
+			}
+		}
+		opts = append(opts,
+			mocks.WithCertificateRequirements(certificateRequirements, onCertificatesReceived),
+			mocks.WithVerificationReports(auth.VerificationReportPolicy{
+				OnReport: func(_ context.Context, report transport.VerificationReport) {
+					mu.Lock()
+					defer mu.Unlock()
+					reports = append(reports, report)
+				},
+				AuditLogger: slog.New(slog.NewJSONHandler(logs, nil)),
+			}))
+		server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), nil, opts...).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware())
+		defer server.Close()
+
+		clientWallet := mocks.CreateClientMockWallet()
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+
+		identityKey, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+		require.NoError(t, err)
+		certs := []certificates.VerifiableCertificate{{
+			Certificate: certificates.Certificate{
+				Type:         ageVerificationType,
+				SerialNumber: "serial-1",
+				Subject:      identityKey.PublicKey.ToDERHex(),
+				Certifier:    trustedCertifier,
+				Fields:       map[string]any{"age": "21", "name": "Alice"},
+				Signature:    "mocksignature",
+			},
+			Keyring: keyring,
+		}}
+
+		response, err = server.SendSessionCertificateResponse(t, clientWallet, authMessage, &certs)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+
+		mu.Lock()
+		defer mu.Unlock()
+		return reports, logs
+	}
+
+	t.Run("accepted exchange is reported with its disclosure and checks", func(t *testing.T) {
+		// when
+		reports, logs := exchange(t, true, map[string]string{"age": "mockkey"})
+
+		// then
+		require.Len(t, reports, 1)
+		report := reports[0]
+		require.Equal(t, transport.VerificationAccepted, report.Outcome)
+		require.Empty(t, report.Error)
+		require.Equal(t, []transport.VerificationCheck{
+			{Name: transport.CheckSignature, Passed: true},
+			{Name: transport.CheckSerialNumbers, Passed: true},
+			{Name: transport.CheckCallback, Passed: true},
+		}, report.Checks)
+		require.Equal(t, []transport.CertificateDisclosure{{
+			Type:         ageVerificationType,
+			SerialNumber: "serial-1",
+			Certifier:    trustedCertifier,
+			Requested:    []string{"age"},
+			Disclosed:    []string{"age"},
+			Decrypted:    []string{},
+			Checks: []transport.VerificationCheck{
+				{Name: transport.CheckCertifier, Passed: true},
+				{Name: transport.CheckDisclosure, Passed: true},
+			},
+		}}, report.Certificates)
+
+		lines := logs.lines(t)
+		require.Len(t, lines, 1)
+		require.Equal(t, "Certificate exchange verified", lines[0]["msg"])
+		require.Equal(t, transport.VerificationAccepted, lines[0]["outcome"])
+		require.NotContains(t, lines[0]["report"], "Alice")
+	})
+
+	t.Run("over disclosure is reported without rejecting the exchange", func(t *testing.T) {
+		// when
+		reports, _ := exchange(t, true, map[string]string{"age": "mockkey", "name": "mockkey"})
+
+		// then
+		require.Len(t, reports, 1)
+		require.Equal(t, transport.VerificationAccepted, reports[0].Outcome)
+		require.Equal(t, []string{"age", "name"}, reports[0].Certificates[0].Disclosed)
+		disclosureCheck := reports[0].Certificates[0].Checks[1]
+		require.False(t, disclosureCheck.Passed)
+		require.Contains(t, disclosureCheck.Error, "name")
+	})
+
+	t.Run("exchange rejected by strict disclosure is reported", func(t *testing.T) {
+		// when
+		reports, _ := exchange(t, true, map[string]string{}, mocks.WithStrictDisclosure)
+
+		// then
+		require.Len(t, reports, 1)
+		require.Equal(t, transport.VerificationRejected, reports[0].Outcome)
+		require.NotEmpty(t, reports[0].Error)
+		require.Equal(t, transport.CheckDisclosure, reports[0].Checks[1].Name)
+		require.False(t, reports[0].Checks[1].Passed)
+	})
+
+	t.Run("exchange not accepted by the callback is reported as pending", func(t *testing.T) {
+		// when
+		reports, _ := exchange(t, false, map[string]string{"age": "mockkey"})
+
+		// then
+		require.Len(t, reports, 1)
+		require.Equal(t, transport.VerificationPending, reports[0].Outcome)
+		require.False(t, reports[0].Checks[2].Passed)
+	})
+}
@@ -0,0 +1,118 @@
+package integrationtests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	httptransport "github.com/bsv-blockchain/go-bsv-middleware/pkg/transport/http"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+// verifyingHandler is a custom server verifying requests and signing responses without the auth middleware
+func verifyingHandler(t *testing.T, tr transport.TransportInterface) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/.well-known/auth" {
+			if err := tr.HandleNonGeneralRequest(req, w); err != nil {
+				http.Error(w, transport.ErrorCode(err), transport.ErrorStatus(err))
+			}
+			return
+		}
+
+		result, err := transport.VerifyRequest(req.Context(), req, transport.VerifyOptions{Transport: tr})
+		if err != nil {
+			http.Error(w, transport.ErrorCode(err), transport.ErrorStatus(err))
+			return
+		}
+
+		header := http.Header{}
+		header.Set("Content-Type", "text/plain")
+		body, err := transport.SignResponse(req.Context(), result, http.StatusOK, header, []byte("hello "+result.IdentityKey))
+		require.NoError(t, err)
+
+		for key, values := range header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(body)
+	}
+}
+
+func TestVerifyRequest_CustomServer(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+	serverIdentityKey := key.PubKey().ToDERHex()
+
+	tr := httptransport.New(httptransport.Config{
+		Wallet:         mocks.CreateServerMockWallet(key),
+		SessionManager: session.NewMemoryManager(),
+	})
+	server := httptest.NewServer(verifyingHandler(t, tr))
+	defer server.Close()
+
+	clientWallet := mocks.CreateClientMockWallet()
+	handshake, err := json.Marshal(mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+	require.NoError(t, err)
+	response, err := http.Post(server.URL+"/.well-known/auth", "application/json", bytes.NewReader(handshake))
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+	authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+	require.NoError(t, err)
+
+	t.Run("verified request is answered with a signed response", func(t *testing.T) {
+		// given
+		request, err := http.NewRequest(http.MethodGet, server.URL+"/ping", nil)
+		require.NoError(t, err)
+		require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request))
+
+		// when
+		response, err := http.DefaultClient.Do(request)
+
+		// then
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		assert.SignedGeneralResponse(t, clientWallet, response, serverIdentityKey, request)
+
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+		identityKey, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+		require.NoError(t, err)
+		require.Equal(t, "hello "+identityKey.PublicKey.ToDERHex(), string(body))
+	})
+
+	t.Run("unsigned request is rejected", func(t *testing.T) {
+		// when
+		response, err := http.Get(server.URL + "/ping")
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUnauthorized, response.StatusCode)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		require.NoError(t, response.Body.Close())
+		require.Contains(t, string(body), transport.ErrCodeMissingRequestID)
+	})
+}
+
+func TestVerifyRequest_WithoutTransport(t *testing.T) {
+	// given
+	request := httptest.NewRequest(http.MethodGet, "/ping", nil)
+
+	// when
+	_, err := transport.VerifyRequest(request.Context(), request, transport.VerifyOptions{})
+
+	// then
+	require.ErrorIs(t, err, transport.ErrTransportRequired)
+}
@@ -0,0 +1,70 @@
+package integrationtests
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_WalletCalls(t *testing.T) {
+	// given
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	logs := &accessLogBuffer{}
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(),
+		mocks.WithAccessLogger(slog.New(slog.NewJSONHandler(logs, nil)))).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/calls", mocks.WalletCallsHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	clientWallet := mocks.CreateClientMockWallet()
+
+	// when
+	response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+	authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodGet, server.URL()+"/calls", nil)
+	require.NoError(t, err)
+	require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request))
+	response, err = server.SendGeneralRequest(t, request)
+	require.NoError(t, err)
+	assert.ResponseOK(t, response)
+
+	var handlerCalls transport.WalletCalls
+	require.NoError(t, json.NewDecoder(response.Body).Decode(&handlerCalls))
+	require.NoError(t, response.Body.Close())
+
+	// then
+	// the handler runs before the response is signed
+	require.Equal(t, transport.WalletCalls{NoncesVerified: 1, SignaturesVerified: 1}, handlerCalls)
+
+	lines := logs.lines(t)
+	require.Len(t, lines, 2)
+
+	handshake, authenticated := lines[0], lines[1]
+	require.Equal(t, auth.AccessOutcomeHandshake, handshake["outcome"])
+	require.Equal(t, map[string]any{
+		"noncesCreated": 1.0, "noncesVerified": 0.0, "signaturesCreated": 1.0, "signaturesVerified": 0.0,
+		"encryptions": 0.0, "decryptions": 0.0,
+	}, handshake["wallet"])
+
+	require.Equal(t, auth.AccessOutcomeAuthenticated, authenticated["outcome"])
+	require.Equal(t, map[string]any{
+		"noncesCreated": 1.0, "noncesVerified": 1.0, "signaturesCreated": 1.0, "signaturesVerified": 1.0,
+		"encryptions": 0.0, "decryptions": 0.0,
+	}, authenticated["wallet"])
+}
@@ -0,0 +1,143 @@
+package integrationtests
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+// hangingWallet blocks the selected operations until the test finishes, like an unresponsive remote wallet
+type hangingWallet struct {
+	wallet.WalletInterface
+	release         chan struct{}
+	createNonce     atomic.Bool
+	createSignature atomic.Bool
+	verifySignature atomic.Bool
+}
+
+func (w *hangingWallet) CreateNonce(ctx context.Context) (string, error) {
+	if w.createNonce.Load() {
+		<-w.release
+	}
+	return w.WalletInterface.CreateNonce(ctx)
+}
+
+func (w *hangingWallet) CreateSignature(args *wallet.CreateSignatureArgs, originator string) (*wallet.CreateSignatureResult, error) {
+	if w.createSignature.Load() {
+		<-w.release
+	}
+	return w.WalletInterface.CreateSignature(args, originator)
+}
+
+func (w *hangingWallet) VerifySignature(args *wallet.VerifySignatureArgs) (*wallet.VerifySignatureResult, error) {
+	if w.verifySignature.Load() {
+		<-w.release
+	}
+	return w.WalletInterface.VerifySignature(args)
+}
+
+func TestAuthMiddleware_WalletTimeouts(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	timeouts := transport.WalletTimeouts{
+		CreateNonce:     20 * time.Millisecond,
+		CreateSignature: 20 * time.Millisecond,
+		VerifySignature: 20 * time.Millisecond,
+	}
+
+	setup := func(t *testing.T) (*mocks.MockHTTPServer, *hangingWallet) {
+		serverWallet := &hangingWallet{WalletInterface: mocks.CreateServerMockWallet(key), release: make(chan struct{})}
+		t.Cleanup(func() { close(serverWallet.release) })
+
+		server := mocks.CreateMockHTTPServer(serverWallet, session.NewMemoryManager(), mocks.WithWalletTimeouts(timeouts)).
+			WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+			WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware())
+		t.Cleanup(server.Close)
+
+		return server, serverWallet
+	}
+
+	handshake := func(t *testing.T, server *mocks.MockHTTPServer, clientWallet wallet.WalletInterface) *transport.AuthMessage {
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(clientWallet).AuthMessage())
+		require.NoError(t, err)
+		assert.ResponseOK(t, response)
+		authMessage, err := mocks.MapBodyToAuthMessage(t, response)
+		require.NoError(t, err)
+		return authMessage
+	}
+
+	ping := func(t *testing.T, server *mocks.MockHTTPServer, clientWallet wallet.WalletInterface, authMessage *transport.AuthMessage) *http.Response {
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+		require.NoError(t, err)
+		require.NoError(t, mocks.PrepareGeneralRequestHeaders(clientWallet, authMessage, request))
+		response, err := server.SendGeneralRequest(t, request)
+		require.NoError(t, err)
+		return response
+	}
+
+	t.Run("handshake with hanging nonce creation is rejected with 503", func(t *testing.T) {
+		// given
+		server, serverWallet := setup(t)
+		serverWallet.createNonce.Store(true)
+
+		// when
+		response, err := server.SendNonGeneralRequest(t, mocks.PrepareInitialRequestBody(mocks.CreateClientMockWallet()).AuthMessage())
+
+		// then
+		require.NoError(t, err)
+		assert.ErrorResponseCode(t, response, http.StatusServiceUnavailable, transport.ErrCodeWalletTimeout)
+	})
+
+	t.Run("general request with hanging signature verification is rejected with 503", func(t *testing.T) {
+		// given
+		server, serverWallet := setup(t)
+		clientWallet := mocks.CreateClientMockWallet()
+		authMessage := handshake(t, server, clientWallet)
+		serverWallet.verifySignature.Store(true)
+
+		// when
+		response := ping(t, server, clientWallet, authMessage)
+
+		// then
+		assert.ErrorResponseCode(t, response, http.StatusServiceUnavailable, transport.ErrCodeWalletTimeout)
+	})
+
+	t.Run("general request with hanging response signing is rejected with 503", func(t *testing.T) {
+		// given
+		server, serverWallet := setup(t)
+		clientWallet := mocks.CreateClientMockWallet()
+		authMessage := handshake(t, server, clientWallet)
+		serverWallet.createSignature.Store(true)
+
+		// when
+		response := ping(t, server, clientWallet, authMessage)
+
+		// then
+		assert.ErrorResponseCode(t, response, http.StatusServiceUnavailable, transport.ErrCodeWalletTimeout)
+	})
+
+	t.Run("responsive wallet is not affected by the timeouts", func(t *testing.T) {
+		// given
+		server, _ := setup(t)
+		clientWallet := mocks.CreateClientMockWallet()
+		authMessage := handshake(t, server, clientWallet)
+
+		// when
+		response := ping(t, server, clientWallet, authMessage)
+
+		// then
+		assert.ResponseOK(t, response)
+	})
+}
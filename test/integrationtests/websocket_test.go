@@ -0,0 +1,189 @@
+package integrationtests
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/client"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/websocket"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/assert"
+	"github.com/bsv-blockchain/go-bsv-middleware/test/mocks"
+	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthMiddleware_WebSocket(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	clientWallet := mocks.CreateClientMockWallet()
+	clientIdentity, err := clientWallet.GetPublicKey(&wallet.GetPublicKeyArgs{IdentityKey: true}, "")
+	require.NoError(t, err)
+
+	for name, encryption := range map[string]bool{"plain session": false, "session with payload encryption": true} {
+		t.Run("socket of the "+name+" carries the identity of the peer alongside REST requests", func(t *testing.T) {
+			// given
+			options := []func(s *mocks.MockHTTPServer) *mocks.MockHTTPServer{}
+			if encryption {
+				options = append(options, mocks.WithPayloadEncryption)
+			}
+			server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(), options...).
+				WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+				WithHandler("/ping", mocks.PingHandler().WithAuthMiddleware()).
+				WithHandler("/ws", mocks.WebSocketHandler().WithAuthMiddleware())
+			defer server.Close()
+
+			authClient, err := client.New(client.Config{Wallet: clientWallet, BaseURL: server.URL(), PayloadEncryption: encryption})
+			require.NoError(t, err)
+
+			// when
+			conn, err := authClient.DialWebSocket(context.Background(), "/ws")
+
+			// then
+			require.NoError(t, err)
+			defer func() { _ = conn.Close() }()
+
+			for _, message := range []string{"hello", "again"} {
+				require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(message)))
+				messageType, reply, err := conn.ReadMessage()
+				require.NoError(t, err)
+				require.Equal(t, websocket.TextMessage, messageType)
+				require.Equal(t, clientIdentity.PublicKey.ToDERHex()+": "+message, string(reply))
+			}
+
+			// when
+			request, err := http.NewRequest(http.MethodGet, server.URL()+"/ping", nil)
+			require.NoError(t, err)
+			response, err := authClient.Do(request)
+
+			// then
+			require.NoError(t, err)
+			assert.ResponseOK(t, response)
+		})
+	}
+
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager()).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/ws", mocks.WebSocketHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	t.Run("upgrade without auth headers is rejected", func(t *testing.T) {
+		// given
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ws", nil)
+		require.NoError(t, err)
+		request.Header.Set("Connection", "Upgrade")
+		request.Header.Set("Upgrade", "websocket")
+		request.Header.Set("Sec-WebSocket-Version", "13")
+		request.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+
+		// when
+		response, err := server.SendGeneralRequest(t, request)
+
+		// then
+		require.NoError(t, err)
+		assert.NotAuthorized(t, response)
+	})
+
+	t.Run("authenticated request without upgrade is answered with 426 Upgrade Required", func(t *testing.T) {
+		// given
+		authClient, err := client.New(client.Config{Wallet: mocks.CreateClientMockWallet(), BaseURL: server.URL()})
+		require.NoError(t, err)
+		request, err := http.NewRequest(http.MethodGet, server.URL()+"/ws", nil)
+		require.NoError(t, err)
+
+		// when
+		response, err := authClient.Do(request)
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, http.StatusUpgradeRequired, response.StatusCode)
+		require.Equal(t, "websocket", response.Header.Get("Upgrade"))
+	})
+
+	t.Run("accept key of RFC 6455", func(t *testing.T) {
+		require.Equal(t, "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=", websocket.AcceptKey("dGhlIHNhbXBsZSBub25jZQ=="))
+	})
+}
+
+func TestAuthMiddleware_WebSocketSignedHeaders(t *testing.T) {
+	key, err := ec.PrivateKeyFromHex(wallettest.ServerPrivateKeyHex)
+	require.NoError(t, err)
+
+	server := mocks.CreateMockHTTPServer(mocks.CreateServerMockWallet(key), session.NewMemoryManager(),
+		mocks.WithWebSocketOrigins("https://app.example.com")).
+		WithHandler("/", mocks.IndexHandler().WithAuthMiddleware()).
+		WithHandler("/ws", mocks.WebSocketHandler().WithAuthMiddleware())
+	defer server.Close()
+
+	tests := map[string]struct {
+		header   http.Header
+		injected http.Header
+		expected string
+	}{
+		"signed allowed origin": {
+			header: http.Header{"Origin": {"https://app.example.com"}, "Sec-WebSocket-Protocol": {"chat", "superchat"}},
+		},
+		"without origin": {},
+		"signed origin which is not allowed": {
+			header:   http.Header{"Origin": {"https://evil.example.com"}},
+			expected: transport.ErrCodeWebSocketOriginNotAllowed,
+		},
+		"origin added after signing": {
+			injected: http.Header{"Origin": {"https://evil.example.com"}},
+			expected: transport.ErrCodeInvalidSignature,
+		},
+		"origin replaced after signing": {
+			header:   http.Header{"Origin": {"https://app.example.com"}},
+			injected: http.Header{"Origin": {"https://evil.example.com"}},
+			expected: transport.ErrCodeInvalidSignature,
+		},
+		"subprotocol replaced after signing": {
+			header:   http.Header{"Origin": {"https://app.example.com"}, "Sec-WebSocket-Protocol": {"chat"}},
+			injected: http.Header{"Sec-WebSocket-Protocol": {"admin"}},
+			expected: transport.ErrCodeInvalidSignature,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// given
+			authClient, err := client.New(client.Config{
+				Wallet:     mocks.CreateClientMockWallet(),
+				BaseURL:    server.URL(),
+				HTTPClient: &http.Client{Transport: headerInjector(test.injected)},
+			})
+			require.NoError(t, err)
+
+			// when
+			conn, err := authClient.DialWebSocketWithHeader(context.Background(), "/ws", test.header)
+
+			// then
+			if test.expected != "" {
+				var serverErr *client.ServerError
+				require.ErrorAs(t, err, &serverErr)
+				require.Equal(t, test.expected, serverErr.Code)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, conn.Close())
+		})
+	}
+}
+
+// headerInjector sets the headers on upgrade requests after they are signed, like a page of a foreign origin would
+type headerInjector http.Header
+
+func (h headerInjector) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("Sec-WebSocket-Key") != "" {
+		req = req.Clone(req.Context())
+		for name, values := range h {
+			req.Header[name] = values
+		}
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
@@ -11,12 +11,20 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/authcore"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/chaintracker"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/events"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/sessionmanager"
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/payment"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/websocket"
 	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 	"github.com/stretchr/testify/require"
 )
@@ -30,11 +38,50 @@ type MockHTTPServer struct {
 	authMiddleware          *auth.Middleware
 	certificateRequirements *transport.RequestedCertificateSet
 	onCertificatesReceived  transport.OnCertificatesReceivedFunc
+	encryptPayloads         bool
+	padPayloads             bool
+	strictDisclosure        bool
+	reportOnly              bool
+	rollout                 *auth.EnforcementRollout
+	verificationReports     *auth.VerificationReportPolicy
+	onInitialResponse       func(ctx context.Context, session session.PeerSession, msg *transport.AuthMessage)
+	paymentMiddleware       *payment.Middleware
+	idempotencyKeyTTL       time.Duration
+	routePolicies           map[string]auth.RoutePolicy
+	forwardAuth             auth.ForwardAuthConfig
+	revocationTracker       chaintracker.Interface
+	accessLogger            *slog.Logger
+	telemetry               *auth.SessionTelemetry
+	anomalies               *auth.AnomalyPolicy
+	blocking                *auth.BlockPolicy
+	knownPeers              []transport.KnownPeer
+	featureFlags            map[transport.Flag]transport.FlagRule
+	coSigner                func(req *http.Request) wallet.WalletInterface
+	delegations             *transport.DelegationPolicy
+	webSocketOrigins        []string
+	trustRegistry           *transport.TrustRegistry
+	compressions            []transport.CertificateCompression
+	maxCertificatesSize     int
+	events                  *events.Bus
+	tarpit                  *auth.TarpitPolicy
+	walletTimeouts          transport.WalletTimeouts
+	readTimeouts            transport.ReadTimeouts
+	privilegedKeys          transport.PrivilegedKeys
+	originBinding           transport.OriginBinding
+	serverInfoHeader        bool
+	accountResolver         auth.AccountResolver
+	accountNegativeCacheTTL time.Duration
+	anonymousAccess         *auth.AnonymousPolicy
+	authorizer              auth.Authorizer
+	tiers                   *auth.TierPolicy
+	tokenMinting            *auth.TokenMinting
+	routes                  *auth.RouteRegistry
 }
 
 // MockHTTPHandler is a mock HTTP handler used in tests
 type MockHTTPHandler struct {
 	useAuthMiddleware    bool
+	useRPCMiddleware     bool
 	usePaymentMiddleware bool
 	h                    http.Handler
 }
@@ -42,7 +89,7 @@ type MockHTTPHandler struct {
 // CreateMockHTTPServer creates a new mock HTTP server
 func CreateMockHTTPServer(
 	wallet wallet.WalletInterface,
-	sessionManager sessionmanager.SessionManagerInterface,
+	sessionManager session.Manager,
 	opts ...func(s *MockHTTPServer) *MockHTTPServer) *MockHTTPServer {
 
 	mux := http.NewServeMux()
@@ -54,7 +101,12 @@ func CreateMockHTTPServer(
 
 	mockServer.createMiddleware(wallet, sessionManager)
 
-	s := httptest.NewServer(mux)
+	s := httptest.NewUnstartedServer(mux)
+	if tracker := mockServer.readTimeouts.Tracker; tracker != nil {
+		s.Listener = tracker.Listener(s.Listener)
+		s.Config.ConnState = tracker.ConnState
+	}
+	s.Start()
 	mockServer.server = s
 
 	return mockServer
@@ -62,20 +114,37 @@ func CreateMockHTTPServer(
 
 // WithHandler adds a custom handler to the server
 func (s *MockHTTPServer) WithHandler(path string, handler *MockHTTPHandler) *MockHTTPServer {
-	// TODO: uncomment when payment middleware implemented
-	//if handler.usePaymentMiddleware {
-	//	handler.h = s.paymentMiddleware.Handler(handler.h)
-	//}
+	if handler.usePaymentMiddleware {
+		if s.paymentMiddleware == nil {
+			panic("payment middleware is not configured, use WithPayment option")
+		}
+		handler.h = s.paymentMiddleware.Handler(handler.h)
+	}
 
 	if handler.useAuthMiddleware {
 		handler.h = s.authMiddleware.Handler(handler.h)
 	}
 
+	if handler.useRPCMiddleware {
+		handler.h = s.authMiddleware.RPCHandler(handler.h)
+	}
+
 	s.mux.Handle(path, handler.h)
 
 	return s
 }
 
+// WithForwardAuth exposes the ForwardAuth handler of the auth middleware under the given path
+func (s *MockHTTPServer) WithForwardAuth(path string) *MockHTTPServer {
+	s.mux.Handle(path, s.authMiddleware.ForwardAuthHandler())
+	return s
+}
+
+// AuthMiddleware returns the auth middleware used by the server
+func (s *MockHTTPServer) AuthMiddleware() *auth.Middleware {
+	return s.authMiddleware
+}
+
 // Close closes the server
 func (s *MockHTTPServer) Close() {
 	s.server.Close()
@@ -88,13 +157,18 @@ func (s *MockHTTPServer) URL() string {
 
 // SendNonGeneralRequest sends a non-general request to the server
 func (s *MockHTTPServer) SendNonGeneralRequest(t *testing.T, msg *transport.AuthMessage) (*http.Response, error) {
-	authURL := s.URL() + "/.well-known/auth"
-	authMethod := "POST"
-
 	dataBytes, err := json.Marshal(msg)
 	require.Nil(t, err)
 
-	response := prepareAndCallRequest(t, authMethod, authURL, nil, dataBytes)
+	return s.SendRawNonGeneralRequest(t, dataBytes)
+}
+
+// SendRawNonGeneralRequest sends the body as is to the handshake endpoint, e.g. to send malformed messages
+func (s *MockHTTPServer) SendRawNonGeneralRequest(t *testing.T, body []byte) (*http.Response, error) {
+	authURL := s.URL() + "/.well-known/auth"
+	authMethod := "POST"
+
+	response := prepareAndCallRequest(t, authMethod, authURL, nil, body)
 
 	return response, nil
 }
@@ -109,7 +183,7 @@ func (s *MockHTTPServer) SendGeneralRequest(t *testing.T, request *http.Request)
 }
 
 // SendCertificateResponse sends a certificate response to the server
-func (s *MockHTTPServer) SendCertificateResponse(t *testing.T, clientWallet wallet.WalletInterface, certificates *[]wallet.VerifiableCertificate) (*http.Response, error) {
+func (s *MockHTTPServer) SendCertificateResponse(t *testing.T, clientWallet wallet.WalletInterface, certs *[]certificates.VerifiableCertificate) (*http.Response, error) {
 	initialRequest := PrepareInitialRequestBody(clientWallet)
 	response, err := s.SendNonGeneralRequest(t, initialRequest.AuthMessage())
 	require.NoError(t, err)
@@ -117,6 +191,11 @@ func (s *MockHTTPServer) SendCertificateResponse(t *testing.T, clientWallet wall
 	authMessage, err := MapBodyToAuthMessage(t, response)
 	require.NoError(t, err)
 
+	return s.SendSessionCertificateResponse(t, clientWallet, authMessage, certs)
+}
+
+// SendSessionCertificateResponse sends a certificate response for the session established by the initial response
+func (s *MockHTTPServer) SendSessionCertificateResponse(t *testing.T, clientWallet wallet.WalletInterface, authMessage *transport.AuthMessage, certs *[]certificates.VerifiableCertificate) (*http.Response, error) {
 	nonce, err := clientWallet.CreateNonce(context.Background())
 	require.NoError(t, err)
 
@@ -129,10 +208,10 @@ func (s *MockHTTPServer) SendCertificateResponse(t *testing.T, clientWallet wall
 		IdentityKey:  identityKey.PublicKey.ToDERHex(),
 		Nonce:        &nonce,
 		YourNonce:    &authMessage.InitialNonce,
-		Certificates: certificates,
+		Certificates: certs,
 	}
 
-	certBytes, err := json.Marshal(*certificates)
+	certBytes, err := authcore.CertificatesPayload(*certs)
 	require.NoError(t, err)
 
 	serverKey, err := ec.PublicKeyFromString(authMessage.IdentityKey)
@@ -170,18 +249,58 @@ func (s *MockHTTPServer) SendCertificateResponse(t *testing.T, clientWallet wall
 	return resp, nil
 }
 
-func (s *MockHTTPServer) createMiddleware(wallet wallet.WalletInterface, sessionManager sessionmanager.SessionManagerInterface) {
+func (s *MockHTTPServer) createMiddleware(wallet wallet.WalletInterface, sessionManager session.Manager) {
 	if s.logger == nil {
 		s.logger = slog.New(slog.DiscardHandler)
 	}
+	if sessionManager == nil {
+		sessionManager = session.NewMemoryManager()
+	}
 
 	opts := auth.Config{
-		AllowUnauthenticated:   s.allowUnauthenticated,
-		Logger:                 s.logger,
-		Wallet:                 wallet,
-		CertificatesToRequest:  s.certificateRequirements,
-		OnCertificatesReceived: s.onCertificatesReceived,
-		SessionManager:         sessionManager,
+		AllowUnauthenticated:    s.allowUnauthenticated,
+		Logger:                  s.logger,
+		Wallet:                  wallet,
+		CertificatesToRequest:   s.certificateRequirements,
+		OnCertificatesReceived:  s.onCertificatesReceived,
+		SessionManager:          sessionManager,
+		EncryptPayloads:         s.encryptPayloads,
+		PadPayloads:             s.padPayloads,
+		StrictDisclosure:        s.strictDisclosure,
+		ReportOnly:              s.reportOnly,
+		Rollout:                 s.rollout,
+		VerificationReports:     s.verificationReports,
+		OnInitialResponse:       s.onInitialResponse,
+		IdempotencyKeyTTL:       s.idempotencyKeyTTL,
+		RoutePolicies:           s.routePolicies,
+		ForwardAuth:             s.forwardAuth,
+		RevocationTracker:       s.revocationTracker,
+		AccessLogger:            s.accessLogger,
+		Telemetry:               s.telemetry,
+		Anomalies:               s.anomalies,
+		Blocking:                s.blocking,
+		KnownPeers:              s.knownPeers,
+		FeatureFlags:            s.featureFlags,
+		CoSigner:                s.coSigner,
+		Delegations:             s.delegations,
+		WebSocketOrigins:        s.webSocketOrigins,
+		TrustRegistry:           s.trustRegistry,
+		CertificateCompressions: s.compressions,
+		MaxCertificatesSize:     s.maxCertificatesSize,
+		Events:                  s.events,
+		Tarpit:                  s.tarpit,
+		WalletTimeouts:          s.walletTimeouts,
+		ReadTimeouts:            s.readTimeouts,
+		PrivilegedKeys:          s.privilegedKeys,
+		OriginBinding:           s.originBinding,
+		ServerInfoHeader:        s.serverInfoHeader,
+		AccountResolver:         s.accountResolver,
+		AccountNegativeCacheTTL: s.accountNegativeCacheTTL,
+		AnonymousAccess:         s.anonymousAccess,
+		Authorizer:              s.authorizer,
+		Tiers:                   s.tiers,
+		TokenMinting:            s.tokenMinting,
+		Routes:                  s.routes,
 	}
 
 	var err error
@@ -197,6 +316,12 @@ func (h *MockHTTPHandler) WithAuthMiddleware() *MockHTTPHandler {
 	return h
 }
 
+// WithRPCMiddleware adds the RPC handler of the auth middleware to the server
+func (h *MockHTTPHandler) WithRPCMiddleware() *MockHTTPHandler {
+	h.useRPCMiddleware = true
+	return h
+}
+
 // WithPaymentMiddleware adds payment middleware to the server
 func (h *MockHTTPHandler) WithPaymentMiddleware() *MockHTTPHandler {
 	h.usePaymentMiddleware = true
@@ -224,12 +349,536 @@ func PingHandler() *MockHTTPHandler {
 	}
 }
 
+// AccountHandler is a mock HTTP handler which responds with the account resolved for the peer
+func AccountHandler() *MockHTTPHandler {
+	return &MockHTTPHandler{
+		h: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			account, ok := auth.GetAccountFromContext(r.Context())
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			if _, err := fmt.Fprintf(w, "%v", account); err != nil {
+				fmt.Println("Failed to write response")
+			}
+		}),
+	}
+}
+
+// WalletCallsHandler is a mock HTTP handler which responds with the wallet calls of the request as JSON
+func WalletCallsHandler() *MockHTTPHandler {
+	return &MockHTTPHandler{
+		h: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls, ok := auth.GetWalletCallsFromContext(r.Context())
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			if err := json.NewEncoder(w).Encode(calls); err != nil {
+				fmt.Println("Failed to write response")
+			}
+		}),
+	}
+}
+
+// AnonymousHandler is a mock HTTP handler which responds whether the request was sent by an anonymous session
+func AnonymousHandler() *MockHTTPHandler {
+	return &MockHTTPHandler{
+		h: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			if _, err := fmt.Fprintf(w, "%t", auth.IsAnonymousFromContext(r.Context())); err != nil {
+				fmt.Println("Failed to write response")
+			}
+		}),
+	}
+}
+
+// TierHandler is a mock HTTP handler which responds with the name of the tier of the peer
+func TierHandler() *MockHTTPHandler {
+	return &MockHTTPHandler{
+		h: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tier, _ := auth.GetTierFromContext(r.Context())
+			w.WriteHeader(http.StatusOK)
+			if _, err := w.Write([]byte(tier.Name)); err != nil {
+				fmt.Println("Failed to write response")
+			}
+		}),
+	}
+}
+
+// TokenHandler is a mock HTTP handler which responds with the Authorization header carrying the minted token,
+// or with 500 when the header does not carry the token of the context
+func TokenHandler() *MockHTTPHandler {
+	return &MockHTTPHandler{
+		h: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, _ := auth.GetTokenFromContext(r.Context())
+			if r.Header.Get("Authorization") != "Bearer "+token {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			if _, err := w.Write([]byte(r.Header.Get("Authorization"))); err != nil {
+				fmt.Println("Failed to write response")
+			}
+		}),
+	}
+}
+
+// EchoHandler is a mock HTTP handler which responds with the request body
+func EchoHandler() *MockHTTPHandler {
+	return &MockHTTPHandler{
+		h: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			if _, err := w.Write(body); err != nil {
+				fmt.Println("Failed to write response")
+			}
+		}),
+	}
+}
+
+// BodylessHandler is a mock HTTP handler which responds with the request method,
+// requests arriving with a body or chunked are rejected with 400 Bad Request
+func BodylessHandler() *MockHTTPHandler {
+	return &MockHTTPHandler{
+		h: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength != 0 || len(r.TransferEncoding) > 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+			if _, err := w.Write([]byte(r.Method)); err != nil {
+				fmt.Println("Failed to write response")
+			}
+		}),
+	}
+}
+
+// StreamHandler is a mock HTTP handler which writes the chunks of the response body and flushes after each of them
+func StreamHandler(chunks ...string) *MockHTTPHandler {
+	return &MockHTTPHandler{
+		h: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			for _, chunk := range chunks {
+				if _, err := w.Write([]byte(chunk)); err != nil {
+					fmt.Println("Failed to write response")
+					return
+				}
+				if err := http.NewResponseController(w).Flush(); err != nil {
+					fmt.Println("Failed to flush response")
+				}
+			}
+		}),
+	}
+}
+
+// SessionKeyHandler is a mock HTTP handler which decrypts the request body with the session key of the label
+// and responds with the plaintext prefixed with "echo: ", encrypted with the same session key
+func SessionKeyHandler(middleware func() *auth.Middleware, label string) *MockHTTPHandler {
+	return &MockHTTPHandler{
+		h: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			sessionKey, err := middleware().SessionKey(r, label)
+			if err != nil {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			plaintext, err := sessionKey.Decrypt(body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			ciphertext, err := sessionKey.Encrypt(append([]byte("echo: "), plaintext...))
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			if _, err := w.Write(ciphertext); err != nil {
+				fmt.Println("Failed to write response")
+			}
+		}),
+	}
+}
+
+// WebSocketHandler is a mock HTTP handler which upgrades to WebSocket and echoes every message prefixed with the identity key of the peer
+func WebSocketHandler() *MockHTTPHandler {
+	return &MockHTTPHandler{
+		h: auth.WebSocketHandler(func(conn *websocket.Conn) {
+			identityKey, _ := auth.GetIdentityFromContext(conn.Context())
+			for {
+				messageType, message, err := conn.ReadMessage()
+				if err != nil {
+					return
+				}
+				if err := conn.WriteMessage(messageType, append([]byte(identityKey+": "), message...)); err != nil {
+					return
+				}
+			}
+		}),
+	}
+}
+
+// FileHandler is a mock HTTP handler which serves the files of the file system with auth.FileServer
+func FileHandler(fsys http.FileSystem) *MockHTTPHandler {
+	return &MockHTTPHandler{h: auth.FileServer(fsys)}
+}
+
+// RangeHandler is a mock HTTP handler which serves the content with http.ServeContent, supporting Range requests
+func RangeHandler(content []byte) *MockHTTPHandler {
+	return &MockHTTPHandler{
+		h: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.ServeContent(w, r, "content.bin", time.Time{}, bytes.NewReader(content))
+		}),
+	}
+}
+
+// CountingHandler is a mock HTTP handler which counts its calls and responds with the call number
+func CountingHandler(calls *atomic.Int32) *MockHTTPHandler {
+	return &MockHTTPHandler{
+		h: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			call := calls.Add(1)
+			w.WriteHeader(http.StatusOK)
+			if _, err := fmt.Fprintf(w, "call %d", call); err != nil {
+				fmt.Println("Failed to write response")
+			}
+		}),
+	}
+}
+
+// PanicOnceHandler panics on its first call and responds like CountingHandler afterwards
+func PanicOnceHandler(calls *atomic.Int32) *MockHTTPHandler {
+	counting := CountingHandler(calls).h
+	return &MockHTTPHandler{
+		h: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.CompareAndSwap(0, 1) {
+				panic("handler failed")
+			}
+			counting.ServeHTTP(w, r)
+		}),
+	}
+}
+
 // WithAllowUnauthenticated is a MockHTTPServer optional setting which sets allowUnauthenticated flag to true
 func WithAllowUnauthenticated(s *MockHTTPServer) *MockHTTPServer {
 	s.allowUnauthenticated = true
 	return s
 }
 
+// WithPayloadEncryption is a MockHTTPServer optional setting which enables encryption of general message bodies
+func WithPayloadEncryption(s *MockHTTPServer) *MockHTTPServer {
+	s.encryptPayloads = true
+	return s
+}
+
+// WithPayloadPadding is a MockHTTPServer optional setting which enables padding of general message bodies
+func WithPayloadPadding(s *MockHTTPServer) *MockHTTPServer {
+	s.padPayloads = true
+	return s
+}
+
+// WithStrictDisclosure is a MockHTTPServer optional setting which rejects certificates not disclosing exactly the requested fields
+func WithStrictDisclosure(s *MockHTTPServer) *MockHTTPServer {
+	s.strictDisclosure = true
+	return s
+}
+
+// WithReportOnly is a MockHTTPServer optional setting which passes requests failing verification to the handler
+func WithReportOnly(s *MockHTTPServer) *MockHTTPServer {
+	s.reportOnly = true
+	return s
+}
+
+// WithRollout is a MockHTTPServer optional setting which enforces verification in report-only mode for the rollout
+func WithRollout(rollout auth.EnforcementRollout) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.reportOnly = true
+		s.rollout = &rollout
+		return s
+	}
+}
+
+// WithVerificationReports is a MockHTTPServer optional setting which publishes verification reports of certificate exchanges
+func WithVerificationReports(policy auth.VerificationReportPolicy) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.verificationReports = &policy
+		return s
+	}
+}
+
+// WithInitialResponseHook is a MockHTTPServer optional setting which calls the hook with every initialResponse
+func WithInitialResponseHook(hook func(ctx context.Context, peerSession session.PeerSession, msg *transport.AuthMessage)) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.onInitialResponse = hook
+		return s
+	}
+}
+
+// WithIdempotencyKeys is a MockHTTPServer optional setting which enables the idempotency key extension
+func WithIdempotencyKeys(s *MockHTTPServer) *MockHTTPServer {
+	s.idempotencyKeyTTL = time.Minute
+	return s
+}
+
+// WithRoutePolicies is a MockHTTPServer optional setting which sets up per-route authentication policies
+func WithRoutePolicies(policies map[string]auth.RoutePolicy) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.routePolicies = policies
+		return s
+	}
+}
+
+// WithForwardAuthConfig is a MockHTTPServer optional setting which declares the upstream headers of the ForwardAuth mode
+func WithForwardAuthConfig(cfg auth.ForwardAuthConfig) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.forwardAuth = cfg
+		return s
+	}
+}
+
+// WithRevocationTracker is a MockHTTPServer optional setting which rejects certificates with a spent revocation outpoint
+func WithRevocationTracker(tracker chaintracker.Interface) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.revocationTracker = tracker
+		return s
+	}
+}
+
+// WithPrivilegedKeys is a MockHTTPServer optional setting which makes the auth middleware use the privileged keyring of the wallet
+func WithPrivilegedKeys(privilegedKeys transport.PrivilegedKeys) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.privilegedKeys = privilegedKeys
+		return s
+	}
+}
+
+// WithOriginBinding is a MockHTTPServer optional setting which sets up the origins general requests may be bound to
+func WithOriginBinding(originBinding transport.OriginBinding) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.originBinding = originBinding
+		return s
+	}
+}
+
+// WithWalletTimeouts is a MockHTTPServer optional setting which limits the wallet operations of the auth middleware
+func WithWalletTimeouts(timeouts transport.WalletTimeouts) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.walletTimeouts = timeouts
+		return s
+	}
+}
+
+// WithReadTimeouts is a MockHTTPServer optional setting which limits how long peers may take to send requests,
+// the listener of the server is tracked by the Tracker of the timeouts
+func WithReadTimeouts(timeouts transport.ReadTimeouts) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.readTimeouts = timeouts
+		return s
+	}
+}
+
+// WithServerInfoHeader is a MockHTTPServer optional setting which adds the signed x-bsv-auth-server header to general responses
+func WithServerInfoHeader(s *MockHTTPServer) *MockHTTPServer {
+	s.serverInfoHeader = true
+	return s
+}
+
+// WithAccountResolver is a MockHTTPServer optional setting which maps identity keys to accounts with the given resolver
+func WithAccountResolver(resolver auth.AccountResolver, negativeCacheTTL time.Duration) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.accountResolver = resolver
+		s.accountNegativeCacheTTL = negativeCacheTTL
+		return s
+	}
+}
+
+// WithAnonymousAccess is a MockHTTPServer optional setting which enables anonymous sessions with the given policy
+func WithAnonymousAccess(policy auth.AnonymousPolicy) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.anonymousAccess = &policy
+		return s
+	}
+}
+
+// WithAuthorizer is a MockHTTPServer optional setting which consults the authorizer for every request passed to the handler
+func WithAuthorizer(authorizer auth.Authorizer) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.authorizer = authorizer
+		return s
+	}
+}
+
+// WithTiers is a MockHTTPServer optional setting which derives quota tiers of peers with the given policy
+func WithTiers(policy auth.TierPolicy) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.tiers = &policy
+		return s
+	}
+}
+
+// WithTokenMinting is a MockHTTPServer optional setting which mints tokens for authenticated requests with the given config
+func WithTokenMinting(cfg auth.TokenMinting) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.tokenMinting = &cfg
+		return s
+	}
+}
+
+// WithRoutes is a MockHTTPServer optional setting which publishes the requirements declared in the registry
+func WithRoutes(routes *auth.RouteRegistry) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.routes = routes
+		return s
+	}
+}
+
+// WithPayment is a MockHTTPServer optional setting which sets up payment middleware charging the given price for every request
+func WithPayment(paymentWallet wallet.PaymentInterface, price int) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		var err error
+		s.paymentMiddleware, err = payment.New(payment.Options{
+			Wallet: paymentWallet,
+			CalculateRequestPrice: func(_ *http.Request) (int, error) {
+				return price, nil
+			},
+		})
+		if err != nil {
+			panic("failed to create payment middleware")
+		}
+		return s
+	}
+}
+
+// WithPaymentOptions is a MockHTTPServer optional setting which sets up payment middleware with the given options
+func WithPaymentOptions(opts payment.Options) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		var err error
+		s.paymentMiddleware, err = payment.New(opts)
+		if err != nil {
+			panic("failed to create payment middleware")
+		}
+		return s
+	}
+}
+
+// WithAccessLogger is a MockHTTPServer optional setting which enables the access log of the auth middleware
+func WithAccessLogger(logger *slog.Logger) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.accessLogger = logger
+		return s
+	}
+}
+
+// WithTelemetry is a MockHTTPServer optional setting which records the requests in the session telemetry
+func WithTelemetry(telemetry *auth.SessionTelemetry) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.telemetry = telemetry
+		return s
+	}
+}
+
+// WithAnomalyPolicy is a MockHTTPServer optional setting which tracks verification failures and locks out peers
+func WithAnomalyPolicy(policy auth.AnomalyPolicy) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.anomalies = &policy
+		return s
+	}
+}
+
+// WithKnownPeers is a MockHTTPServer optional setting which pre-registers known peers
+func WithKnownPeers(peers ...transport.KnownPeer) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.knownPeers = peers
+		return s
+	}
+}
+
+// WithFeatureFlags is a MockHTTPServer optional setting which gates experimental capabilities with the flag rules
+func WithFeatureFlags(rules map[transport.Flag]transport.FlagRule) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.featureFlags = rules
+		return s
+	}
+}
+
+// WithBlockPolicy is a MockHTTPServer optional setting which reports block-worthy peers and enforces a blocklist
+func WithBlockPolicy(policy auth.BlockPolicy) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.blocking = &policy
+		return s
+	}
+}
+
+// WithDelegations is a MockHTTPServer optional setting which accepts agent keys authenticating with a delegation
+func WithDelegations(policy transport.DelegationPolicy) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.delegations = &policy
+		return s
+	}
+}
+
+// WithWebSocketOrigins is a MockHTTPServer optional setting which allows WebSockets opened by pages of the origins only
+func WithWebSocketOrigins(origins ...string) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.webSocketOrigins = origins
+		return s
+	}
+}
+
+// WithCoSigner is a MockHTTPServer optional setting which co-signs responses with the wallet the function returns
+func WithCoSigner(coSigner func(req *http.Request) wallet.WalletInterface) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.coSigner = coSigner
+		return s
+	}
+}
+
+// WithTrustRegistry is a MockHTTPServer optional setting which accepts allowlist exchanges of federation partners
+func WithTrustRegistry(registry *transport.TrustRegistry) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.trustRegistry = registry
+		return s
+	}
+}
+
+// WithCertificateCompression is a MockHTTPServer optional setting which accepts compressed certificates up to the maximum size
+func WithCertificateCompression(maxSize int, compressions ...transport.CertificateCompression) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.compressions = compressions
+		s.maxCertificatesSize = maxSize
+		return s
+	}
+}
+
+// WithEvents is a MockHTTPServer optional setting which publishes the events of the auth middleware on the bus
+func WithEvents(bus *events.Bus) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.events = bus
+		return s
+	}
+}
+
+// WithTarpit is a MockHTTPServer optional setting which sends requests of abusive peers to the tarpit
+func WithTarpit(policy auth.TarpitPolicy) func(s *MockHTTPServer) *MockHTTPServer {
+	return func(s *MockHTTPServer) *MockHTTPServer {
+		s.tarpit = &policy
+		return s
+	}
+}
+
 // WithLogger is a MockHTTPServer optional setting which  sets up logger for the server
 func WithLogger(s *MockHTTPServer) *MockHTTPServer {
 	logHandler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug})
@@ -0,0 +1,91 @@
+package mocks
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"maps"
+	"mime/multipart"
+	"net/http"
+	"slices"
+)
+
+// MultipartFile is a file uploaded with a multipart/form-data request
+type MultipartFile struct {
+	Field   string
+	Name    string
+	Content []byte
+}
+
+// UploadSummary is the response of the UploadHandler
+type UploadSummary struct {
+	Fields map[string]string `json:"fields"`
+	// Files maps the form fields of the uploaded files to their names and contents
+	Files map[string]MultipartFile `json:"files"`
+}
+
+// NewMultipartRequest creates a POST request with a multipart/form-data body of the fields and files,
+// the fields are written in the order of their names. The request is signed with PrepareGeneralRequestHeaders like any other.
+func NewMultipartRequest(url string, fields map[string]string, files ...MultipartFile) (*http.Request, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		if err := writer.WriteField(name, fields[name]); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range files {
+		part, err := writer.CreateFormFile(file.Field, file.Name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write(file.Content); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req, nil
+}
+
+// UploadHandler is a mock HTTP handler which parses multipart/form-data uploads and responds with an UploadSummary
+func UploadHandler() *MockHTTPHandler {
+	return &MockHTTPHandler{
+		h: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseMultipartForm(1 << 20); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			summary := UploadSummary{Fields: map[string]string{}, Files: map[string]MultipartFile{}}
+			for name, values := range r.MultipartForm.Value {
+				summary.Fields[name] = values[0]
+			}
+			for field, headers := range r.MultipartForm.File {
+				file, err := headers[0].Open()
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				content, err := io.ReadAll(file)
+				_ = file.Close()
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				summary.Files[field] = MultipartFile{Field: field, Name: headers[0].Filename, Content: content}
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(summary)
+		}),
+	}
+}
@@ -0,0 +1,41 @@
+package mocks
+
+import (
+	"fmt"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/payment"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-sdk/transaction"
+)
+
+// CreateMockPayment creates a payment of the payer wallet for the terms, paying the required satoshis
+// to the key derived from the server identity key. The input of the transaction is not signed.
+func CreateMockPayment(payerWallet wallet.WalletInterface, terms payment.PaymentTerms, serverIdentityKey string) (*payment.Payment, error) {
+	const derivationSuffix = "suffix"
+
+	lockingScript, err := payment.DerivedLockingScript(payerWallet, serverIdentityKey, terms.DerivationPrefix, derivationSuffix, false)
+	if err != nil {
+		return nil, err
+	}
+
+	satoshis := uint64(terms.SatoshisRequired) //nolint:gosec // required satoshis are never negative
+
+	source := transaction.NewTransaction()
+	source.AddOutput(&transaction.TransactionOutput{Satoshis: satoshis, LockingScript: lockingScript})
+
+	tx := transaction.NewTransaction()
+	tx.AddInputFromTx(source, 0, nil)
+	tx.AddOutput(&transaction.TransactionOutput{Satoshis: satoshis, LockingScript: lockingScript})
+
+	beef, err := tx.AtomicBEEF(false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payment transaction, %w", err)
+	}
+
+	return &payment.Payment{
+		ModeID:           "bsv-direct",
+		DerivationPrefix: terms.DerivationPrefix,
+		DerivationSuffix: derivationSuffix,
+		Transaction:      beef,
+	}, nil
+}
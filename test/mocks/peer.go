@@ -3,8 +3,9 @@ package mocks
 import (
 	"errors"
 
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/sessionmanager"
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/peer"
 	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
 	"github.com/stretchr/testify/mock"
 )
@@ -38,7 +39,7 @@ func (m *MockablePeer) RequestCertificates(certificatesToRequest transport.Reque
 }
 
 // GetAuthenticatedSession mocks retrieving an authenticated session
-func (m *MockablePeer) GetAuthenticatedSession(identityKey string, maxWaitTime int) (*sessionmanager.PeerSession, error) {
+func (m *MockablePeer) GetAuthenticatedSession(identityKey string, maxWaitTime int) (*session.PeerSession, error) {
 	if !isExpectedMockCall(m.ExpectedCalls, "GetAuthenticatedSession", identityKey, maxWaitTime) {
 		return nil, errors.New("unexpected call to GetAuthenticatedSession")
 	}
@@ -47,15 +48,15 @@ func (m *MockablePeer) GetAuthenticatedSession(identityKey string, maxWaitTime i
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
-	return args.Get(0).(*sessionmanager.PeerSession), args.Error(1)
+	return args.Get(0).(*session.PeerSession), args.Error(1)
 }
 
 // SendCertificateResponse mocks sending certificates to a peer
-func (m *MockablePeer) SendCertificateResponse(verifierIdentityKey string, certificates []wallet.VerifiableCertificate) error {
-	if !isExpectedMockCall(m.ExpectedCalls, "SendCertificateResponse", verifierIdentityKey, certificates) {
+func (m *MockablePeer) SendCertificateResponse(verifierIdentityKey string, certs []certificates.VerifiableCertificate) error {
+	if !isExpectedMockCall(m.ExpectedCalls, "SendCertificateResponse", verifierIdentityKey, certs) {
 		return errors.New("unexpected call to SendCertificateResponse")
 	}
-	args := m.Called(verifierIdentityKey, certificates)
+	args := m.Called(verifierIdentityKey, certs)
 	return args.Error(0)
 }
 
@@ -77,7 +78,7 @@ func (m *MockablePeer) StopListeningForGeneralMessages(callbackID int) {
 }
 
 // ListenForCertificatesReceived mocks registering a callback for certificates
-func (m *MockablePeer) ListenForCertificatesReceived(callback func(senderPublicKey string, certs []wallet.VerifiableCertificate)) int {
+func (m *MockablePeer) ListenForCertificatesReceived(callback func(senderPublicKey string, certs []certificates.VerifiableCertificate)) int {
 	if !isExpectedMockCall(m.ExpectedCalls, "ListenForCertificatesReceived", mock.Anything) {
 		return -1
 	}
@@ -110,6 +111,15 @@ func (m *MockablePeer) StopListeningForCertificatesRequested(callbackID int) {
 	m.Called(callbackID)
 }
 
+// QueueStats mocks retrieving the metrics of the message queues
+func (m *MockablePeer) QueueStats(identityKey string) (peer.QueueStats, peer.QueueStats) {
+	if !isExpectedMockCall(m.ExpectedCalls, "QueueStats", identityKey) {
+		return peer.QueueStats{}, peer.QueueStats{}
+	}
+	args := m.Called(identityKey)
+	return args.Get(0).(peer.QueueStats), args.Get(1).(peer.QueueStats)
+}
+
 // OnToPeerOnce sets up a one-time expectation for ToPeer
 func (m *MockablePeer) OnToPeerOnce(message []byte, identityKey string, maxWaitTime int, err error) *mock.Call {
 	return m.On("ToPeer", message, identityKey, maxWaitTime).Return(err).Once()
@@ -121,13 +131,13 @@ func (m *MockablePeer) OnRequestCertificatesOnce(certificatesToRequest transport
 }
 
 // OnGetAuthenticatedSessionOnce sets up a one-time expectation for GetAuthenticatedSession
-func (m *MockablePeer) OnGetAuthenticatedSessionOnce(identityKey string, maxWaitTime int, session *sessionmanager.PeerSession, err error) *mock.Call {
-	return m.On("GetAuthenticatedSession", identityKey, maxWaitTime).Return(session, err).Once()
+func (m *MockablePeer) OnGetAuthenticatedSessionOnce(identityKey string, maxWaitTime int, peerSession *session.PeerSession, err error) *mock.Call {
+	return m.On("GetAuthenticatedSession", identityKey, maxWaitTime).Return(peerSession, err).Once()
 }
 
 // OnSendCertificateResponseOnce sets up a one-time expectation for SendCertificateResponse
-func (m *MockablePeer) OnSendCertificateResponseOnce(verifierIdentityKey string, certificates []wallet.VerifiableCertificate, err error) *mock.Call {
-	return m.On("SendCertificateResponse", verifierIdentityKey, certificates).Return(err).Once()
+func (m *MockablePeer) OnSendCertificateResponseOnce(verifierIdentityKey string, certs []certificates.VerifiableCertificate, err error) *mock.Call {
+	return m.On("SendCertificateResponse", verifierIdentityKey, certs).Return(err).Once()
 }
 
 // OnListenForGeneralMessagesOnce sets up a one-time expectation for ListenForGeneralMessages
@@ -160,14 +170,19 @@ func (m *MockablePeer) OnStopListeningForCertificatesRequestedOnce(callbackID in
 	return m.On("StopListeningForCertificatesRequested", callbackID).Once()
 }
 
+// OnQueueStatsOnce sets up a one-time expectation for QueueStats
+func (m *MockablePeer) OnQueueStatsOnce(identityKey string, incoming, outgoing peer.QueueStats) *mock.Call {
+	return m.On("QueueStats", identityKey).Return(incoming, outgoing).Once()
+}
+
 // SimulateIncomingGeneralMessage provides a helper to simulate callbacks for testing
 func (m *MockablePeer) SimulateIncomingGeneralMessage(senderPublicKey string, payload []byte, callback func(string, []byte)) {
 	callback(senderPublicKey, payload)
 }
 
 // SimulateIncomingCertificates provides a helper to simulate callbacks for testing
-func (m *MockablePeer) SimulateIncomingCertificates(senderPublicKey string, certificates []wallet.VerifiableCertificate, callback func(string, []wallet.VerifiableCertificate)) {
-	callback(senderPublicKey, certificates)
+func (m *MockablePeer) SimulateIncomingCertificates(senderPublicKey string, certs []certificates.VerifiableCertificate, callback func(string, []certificates.VerifiableCertificate)) {
+	callback(senderPublicKey, certs)
 }
 
 // SimulateIncomingCertificateRequest provides a helper to simulate callbacks for testing
@@ -0,0 +1,132 @@
+package mocks
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+)
+
+// Routes of the sample Greet service
+const (
+	// GreetConnectPath is the Connect route of the Greet method, requests and responses are protobuf messages
+	GreetConnectPath = "/greet.v1.GreetService/Greet"
+	// GreetGatewayPath is the grpc-gateway route of the Greet method, responses are JSON
+	GreetGatewayPath = "/v1/greet/"
+)
+
+// GreetResponse is the response message of the sample Greet service
+type GreetResponse struct {
+	Greeting    string `json:"greeting"`
+	IdentityKey string `json:"identityKey"`
+}
+
+// EncodeGreetRequest encodes the GreetRequest message { string name = 1; } in the protobuf wire format
+func EncodeGreetRequest(name string) []byte {
+	return appendStringField(nil, 1, name)
+}
+
+// DecodeGreetResponse decodes the GreetResponse message { string greeting = 1; string identity_key = 2; }
+func DecodeGreetResponse(data []byte) (GreetResponse, error) {
+	fields, err := decodeStringFields(data)
+	if err != nil {
+		return GreetResponse{}, err
+	}
+	return GreetResponse{Greeting: fields[1], IdentityKey: fields[2]}, nil
+}
+
+// GreetHandler is a sample Greet service served with the Connect protocol and with grpc-gateway.
+// The Connect handler reads the identity from the request context, the gateway handler simulates grpc-gateway
+// forwarding the Grpc-Metadata-* headers as metadata to a gRPC service which reads the identity from the metadata.
+func GreetHandler() *MockHTTPHandler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("POST "+GreetConnectPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/proto" {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fields, err := decodeStringFields(body)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":"invalid_argument","message":"invalid GreetRequest"}`))
+			return
+		}
+
+		identityKey, _ := auth.GetIdentityFromContext(r.Context())
+		w.Header().Set("Content-Type", "application/proto")
+		_, _ = w.Write(appendStringField(appendStringField(nil, 1, "Hello, "+fields[1]), 2, identityKey))
+	})
+
+	mux.HandleFunc("GET "+GreetGatewayPath+"{name}", func(w http.ResponseWriter, r *http.Request) {
+		md := make(map[string][]string)
+		for name, values := range r.Header {
+			if key, ok := strings.CutPrefix(name, auth.GatewayMetadataPrefix); ok {
+				md[strings.ToLower(key)] = values
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(GreetResponse{
+			Greeting:    "Hello, " + r.PathValue("name"),
+			IdentityKey: auth.HeaderFromMetadata(md).Get(auth.ForwardAuthIdentityKeyHeader),
+		})
+	})
+
+	return &MockHTTPHandler{h: mux}
+}
+
+func appendStringField(data []byte, field int, value string) []byte {
+	data = appendVarint(data, uint64(field)<<3|2) //nolint:gosec // field numbers are positive
+	data = appendVarint(data, uint64(len(value)))
+	return append(data, value...)
+}
+
+func appendVarint(data []byte, value uint64) []byte {
+	for value >= 0x80 {
+		data = append(data, byte(value)|0x80)
+		value >>= 7
+	}
+	return append(data, byte(value))
+}
+
+// decodeStringFields decodes a protobuf message whose fields are all strings
+func decodeStringFields(data []byte) (map[int]string, error) {
+	fields := make(map[int]string)
+	for len(data) > 0 {
+		tag, n := readVarint(data)
+		if n == 0 || tag&7 != 2 {
+			return nil, errors.New("unsupported protobuf field")
+		}
+		data = data[n:]
+
+		length, n := readVarint(data)
+		if n == 0 || uint64(len(data)-n) < length {
+			return nil, errors.New("truncated protobuf field")
+		}
+		fields[int(tag>>3)] = string(data[n : n+int(length)]) //nolint:gosec // bounded by the length of the data
+		data = data[n+int(length):]                           //nolint:gosec // bounded by the length of the data
+	}
+	return fields, nil
+}
+
+func readVarint(data []byte) (uint64, int) {
+	var value uint64
+	for i := 0; i < len(data) && i < 10; i++ {
+		value |= uint64(data[i]&0x7f) << (7 * i)
+		if data[i] < 0x80 {
+			return value, i + 1
+		}
+	}
+	return 0, 0
+}
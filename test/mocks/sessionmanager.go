@@ -3,7 +3,7 @@ package mocks
 import (
 	"sync"
 
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/sessionmanager"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -12,57 +12,57 @@ type MockableSessionManager struct {
 	mock.Mock
 
 	mu                    sync.Mutex
-	sessions              map[string]sessionmanager.PeerSession
+	sessions              map[string]session.PeerSession
 	identityKeyToSessions map[string][]string
 }
 
 // NewMockableSessionManager creates a new instance of MockableSessionManager
 func NewMockableSessionManager() *MockableSessionManager {
 	return &MockableSessionManager{
-		sessions:              make(map[string]sessionmanager.PeerSession),
+		sessions:              make(map[string]session.PeerSession),
 		identityKeyToSessions: make(map[string][]string),
 	}
 }
 
 // AddSession return mocked value or add a session to the manager.
-func (m *MockableSessionManager) AddSession(session sessionmanager.PeerSession) {
-	if isExpectedMockCall(m.ExpectedCalls, "AddSession", session) {
-		m.Called(session)
+func (m *MockableSessionManager) AddSession(peerSession session.PeerSession) {
+	if isExpectedMockCall(m.ExpectedCalls, "AddSession", peerSession) {
+		m.Called(peerSession)
 		return
 	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if session.SessionNonce != nil {
-		m.sessions[*session.SessionNonce] = session
+	if peerSession.SessionNonce != nil {
+		m.sessions[*peerSession.SessionNonce] = peerSession
 	}
-	if session.PeerIdentityKey != nil {
-		sessionNonces, exists := m.identityKeyToSessions[*session.PeerIdentityKey]
+	if peerSession.PeerIdentityKey != nil {
+		sessionNonces, exists := m.identityKeyToSessions[*peerSession.PeerIdentityKey]
 		if exists {
-			m.identityKeyToSessions[*session.PeerIdentityKey] = append(sessionNonces, *session.SessionNonce)
+			m.identityKeyToSessions[*peerSession.PeerIdentityKey] = append(sessionNonces, *peerSession.SessionNonce)
 			return
 		}
 
-		m.identityKeyToSessions[*session.PeerIdentityKey] = []string{*session.SessionNonce}
+		m.identityKeyToSessions[*peerSession.PeerIdentityKey] = []string{*peerSession.SessionNonce}
 	}
 }
 
 // UpdateSession return mocked value or update a session to the manager.
-func (m *MockableSessionManager) UpdateSession(session sessionmanager.PeerSession) {
-	if isExpectedMockCall(m.ExpectedCalls, "UpdateSession", session) {
-		m.Called(session)
+func (m *MockableSessionManager) UpdateSession(peerSession session.PeerSession) {
+	if isExpectedMockCall(m.ExpectedCalls, "UpdateSession", peerSession) {
+		m.Called(peerSession)
 		return
 	}
 
-	m.AddSession(session)
+	m.AddSession(peerSession)
 }
 
 // GetSession return mocked value or get a session from the manager.
-func (m *MockableSessionManager) GetSession(identifier string) *sessionmanager.PeerSession {
+func (m *MockableSessionManager) GetSession(identifier string) *session.PeerSession {
 	if isExpectedMockCall(m.ExpectedCalls, "GetSession", identifier) {
 		args := m.Called(identifier)
-		if s, ok := args.Get(0).(*sessionmanager.PeerSession); ok {
+		if s, ok := args.Get(0).(*session.PeerSession); ok {
 			return s
 		}
 		return nil
@@ -71,13 +71,13 @@ func (m *MockableSessionManager) GetSession(identifier string) *sessionmanager.P
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if session, ok := m.sessions[identifier]; ok {
-		return &session
+	if peerSession, ok := m.sessions[identifier]; ok {
+		return &peerSession
 	}
 
 	if nonces, ok := m.identityKeyToSessions[identifier]; ok && len(nonces) > 0 {
-		if session, ok := m.sessions[nonces[0]]; ok {
-			return &session
+		if peerSession, ok := m.sessions[nonces[0]]; ok {
+			return &peerSession
 		}
 	}
 
@@ -85,20 +85,20 @@ func (m *MockableSessionManager) GetSession(identifier string) *sessionmanager.P
 }
 
 // RemoveSession return mocked value or remove a session from the manager.
-func (m *MockableSessionManager) RemoveSession(session sessionmanager.PeerSession) {
-	if isExpectedMockCall(m.ExpectedCalls, "RemoveSession", session) {
-		m.Called(session)
+func (m *MockableSessionManager) RemoveSession(peerSession session.PeerSession) {
+	if isExpectedMockCall(m.ExpectedCalls, "RemoveSession", peerSession) {
+		m.Called(peerSession)
 		return
 	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	if session.SessionNonce != nil {
-		delete(m.sessions, *session.SessionNonce)
+	if peerSession.SessionNonce != nil {
+		delete(m.sessions, *peerSession.SessionNonce)
 	}
-	if session.PeerIdentityKey != nil {
-		delete(m.identityKeyToSessions, *session.PeerIdentityKey)
+	if peerSession.PeerIdentityKey != nil {
+		delete(m.identityKeyToSessions, *peerSession.PeerIdentityKey)
 	}
 }
 
@@ -118,23 +118,23 @@ func (m *MockableSessionManager) HasSession(identifier string) bool {
 }
 
 // OnAddSessionOnce sets up a one-time expectation for the AddSession method.
-func (m *MockableSessionManager) OnAddSessionOnce(session sessionmanager.PeerSession) *mock.Call {
-	return m.On("AddSession", session).Once()
+func (m *MockableSessionManager) OnAddSessionOnce(peerSession session.PeerSession) *mock.Call {
+	return m.On("AddSession", peerSession).Once()
 }
 
 // OnUpdateSessionOnce sets up a one-time expectation for the UpdateSession method.
-func (m *MockableSessionManager) OnUpdateSessionOnce(session sessionmanager.PeerSession) *mock.Call {
-	return m.On("UpdateSession", session).Once()
+func (m *MockableSessionManager) OnUpdateSessionOnce(peerSession session.PeerSession) *mock.Call {
+	return m.On("UpdateSession", peerSession).Once()
 }
 
 // OnGetSessionOnce sets up a one-time expectation for the GetSession method.
-func (m *MockableSessionManager) OnGetSessionOnce(identifier string, session *sessionmanager.PeerSession) *mock.Call {
-	return m.On("GetSession", identifier).Return(session).Once()
+func (m *MockableSessionManager) OnGetSessionOnce(identifier string, peerSession *session.PeerSession) *mock.Call {
+	return m.On("GetSession", identifier).Return(peerSession).Once()
 }
 
 // OnRemoveSessionOnce sets up a one-time expectation for the RemoveSession method.
-func (m *MockableSessionManager) OnRemoveSessionOnce(session sessionmanager.PeerSession) *mock.Call {
-	return m.On("RemoveSession", session).Once()
+func (m *MockableSessionManager) OnRemoveSessionOnce(peerSession session.PeerSession) *mock.Call {
+	return m.On("RemoveSession", peerSession).Once()
 }
 
 // OnHasSessionOnce sets up a one-time expectation for the HasSession method.
@@ -147,6 +147,6 @@ func (m *MockableSessionManager) Clear() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	m.sessions = make(map[string]sessionmanager.PeerSession)
+	m.sessions = make(map[string]session.PeerSession)
 	m.identityKeyToSessions = make(map[string][]string)
 }
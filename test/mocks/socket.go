@@ -0,0 +1,80 @@
+package mocks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/auth"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/session"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/transport"
+	wstransport "github.com/bsv-blockchain/go-bsv-middleware/pkg/transport/websocket"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/websocket"
+)
+
+// MockSocketServer is a mock server accepting WebSocket connections with the WebSocket transport, it answers
+// the requests of the peers with their identity and the payload of the request
+type MockSocketServer struct {
+	server *httptest.Server
+}
+
+// CreateMockSocketServer creates a new mock WebSocket server
+func CreateMockSocketServer(
+	wallet wallet.WalletInterface,
+	sessionManager session.Manager,
+	opts ...func(cfg *wstransport.Config),
+) *MockSocketServer {
+	cfg := wstransport.Config{Wallet: wallet, SessionManager: sessionManager}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	socketTransport := wstransport.New(cfg)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		conn, err := socketTransport.Accept(w, req)
+		if errors.Is(err, websocket.ErrNotUpgrade) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		_ = websocket.ServeRequests(conn, 0, func(ctx context.Context, payload []byte) ([]byte, error) {
+			identityKey, _ := auth.GetIdentityFromContext(ctx)
+			return append([]byte(identityKey+": "), payload...), nil
+		})
+	})
+
+	return &MockSocketServer{server: httptest.NewServer(handler)}
+}
+
+// WithConnectionScopedSessions binds the sessions of the server to their connection and re-challenges the peers
+// according to the policy
+func WithConnectionScopedSessions(policy session.ReauthenticationPolicy) func(cfg *wstransport.Config) {
+	return func(cfg *wstransport.Config) {
+		cfg.ConnectionScoped = true
+		cfg.Reauthentication = policy
+	}
+}
+
+// WithSocketPrivilegedKeys makes the server use the privileged keyring of the wallet
+func WithSocketPrivilegedKeys(privilegedKeys transport.PrivilegedKeys) func(cfg *wstransport.Config) {
+	return func(cfg *wstransport.Config) {
+		cfg.PrivilegedKeys = privilegedKeys
+	}
+}
+
+// URL returns the WebSocket URL of the server
+func (s *MockSocketServer) URL() string {
+	return "ws://" + strings.TrimPrefix(s.server.URL, "http://")
+}
+
+// Close closes the server
+func (s *MockSocketServer) Close() {
+	s.server.Close()
+}
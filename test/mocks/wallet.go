@@ -4,24 +4,21 @@ import (
 	"context"
 	"errors"
 
-	"github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet"
-	walletFixtures "github.com/bsv-blockchain/go-bsv-middleware/pkg/temporary/wallet/test"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/certificates"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet"
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/wallet/wallettest"
 	ec "github.com/bsv-blockchain/go-sdk/primitives/ec"
 	"github.com/stretchr/testify/mock"
 )
 
 // CreateServerMockWallet returns a mock wallet for server with predefined keys.
 func CreateServerMockWallet(key *ec.PrivateKey) wallet.WalletInterface {
-	return wallet.NewMockWallet(key, walletFixtures.DefaultNonces...)
+	return wallettest.NewMockWallet(key, wallettest.DefaultNonces...)
 }
 
-// CreateClientMockWallet returns a mock wallet for server with predefined nonces.
+// CreateClientMockWallet returns a mock wallet of the client fixture identity, its nonces start with the client fixture nonces.
 func CreateClientMockWallet() wallet.WalletInterface {
-	key, err := ec.PrivateKeyFromHex(walletFixtures.ClientPrivateKeyHex)
-	if err != nil {
-		panic(err)
-	}
-	return wallet.NewMockWallet(key, walletFixtures.ClientNonces...)
+	return wallettest.NewSeededMockWallet(wallettest.Seed, "client")
 }
 
 // MockableWallet is a mock implementation of the WalletInterface.
@@ -49,6 +46,9 @@ func (m *MockableWallet) CreateSignature(args *wallet.CreateSignatureArgs, origi
 		return nil, errors.New("unexpected call to CreateSignature")
 	}
 	call := m.Called(args, originator)
+	if signer, ok := call.Get(0).(wallet.WalletInterface); ok {
+		return signer.CreateSignature(args, originator)
+	}
 	return call.Get(0).(*wallet.CreateSignatureResult), call.Error(1)
 }
 
@@ -61,6 +61,42 @@ func (m *MockableWallet) VerifySignature(args *wallet.VerifySignatureArgs) (*wal
 	return call.Get(0).(*wallet.VerifySignatureResult), call.Error(1)
 }
 
+// Encrypt return mocked ciphertext value.
+func (m *MockableWallet) Encrypt(args *wallet.EncryptArgs, originator string) (*wallet.EncryptResult, error) {
+	if !isExpectedMockCall(m.ExpectedCalls, "Encrypt", args, originator) {
+		return nil, errors.New("unexpected call to Encrypt")
+	}
+	call := m.Called(args, originator)
+	return call.Get(0).(*wallet.EncryptResult), call.Error(1)
+}
+
+// Decrypt return mocked plaintext value.
+func (m *MockableWallet) Decrypt(args *wallet.DecryptArgs, originator string) (*wallet.DecryptResult, error) {
+	if !isExpectedMockCall(m.ExpectedCalls, "Decrypt", args, originator) {
+		return nil, errors.New("unexpected call to Decrypt")
+	}
+	call := m.Called(args, originator)
+	return call.Get(0).(*wallet.DecryptResult), call.Error(1)
+}
+
+// RevealCounterpartyKeyLinkage return mocked linkage value.
+func (m *MockableWallet) RevealCounterpartyKeyLinkage(args *wallet.RevealCounterpartyKeyLinkageArgs, originator string) (*wallet.RevealCounterpartyKeyLinkageResult, error) {
+	if !isExpectedMockCall(m.ExpectedCalls, "RevealCounterpartyKeyLinkage", args, originator) {
+		return nil, errors.New("unexpected call to RevealCounterpartyKeyLinkage")
+	}
+	call := m.Called(args, originator)
+	return call.Get(0).(*wallet.RevealCounterpartyKeyLinkageResult), call.Error(1)
+}
+
+// RevealSpecificKeyLinkage return mocked linkage value.
+func (m *MockableWallet) RevealSpecificKeyLinkage(args *wallet.RevealSpecificKeyLinkageArgs, originator string) (*wallet.RevealSpecificKeyLinkageResult, error) {
+	if !isExpectedMockCall(m.ExpectedCalls, "RevealSpecificKeyLinkage", args, originator) {
+		return nil, errors.New("unexpected call to RevealSpecificKeyLinkage")
+	}
+	call := m.Called(args, originator)
+	return call.Get(0).(*wallet.RevealSpecificKeyLinkageResult), call.Error(1)
+}
+
 // CreateNonce return mocked nonce value.
 func (m *MockableWallet) CreateNonce(ctx context.Context) (string, error) {
 	if !isExpectedMockCall(m.ExpectedCalls, "CreateNonce", ctx) {
@@ -79,17 +115,44 @@ func (m *MockableWallet) VerifyNonce(ctx context.Context, nonce string) (bool, e
 	return call.Bool(0), call.Error(1)
 }
 
+// GetHeight return mocked height value.
+func (m *MockableWallet) GetHeight(ctx context.Context, originator string) (*wallet.GetHeightResult, error) {
+	if !isExpectedMockCall(m.ExpectedCalls, "GetHeight", ctx, originator) {
+		return nil, errors.New("unexpected call to GetHeight")
+	}
+	call := m.Called(ctx, originator)
+	return call.Get(0).(*wallet.GetHeightResult), call.Error(1)
+}
+
+// GetNetwork return mocked network value.
+func (m *MockableWallet) GetNetwork(ctx context.Context, originator string) (*wallet.GetNetworkResult, error) {
+	if !isExpectedMockCall(m.ExpectedCalls, "GetNetwork", ctx, originator) {
+		return nil, errors.New("unexpected call to GetNetwork")
+	}
+	call := m.Called(ctx, originator)
+	return call.Get(0).(*wallet.GetNetworkResult), call.Error(1)
+}
+
+// GetVersion return mocked version value.
+func (m *MockableWallet) GetVersion(ctx context.Context, originator string) (*wallet.GetVersionResult, error) {
+	if !isExpectedMockCall(m.ExpectedCalls, "GetVersion", ctx, originator) {
+		return nil, errors.New("unexpected call to GetVersion")
+	}
+	call := m.Called(ctx, originator)
+	return call.Get(0).(*wallet.GetVersionResult), call.Error(1)
+}
+
 // ListCertificates return mocked certificate list value.
-func (m *MockableWallet) ListCertificates(ctx context.Context, certifiers []string, types []string) ([]wallet.Certificate, error) {
+func (m *MockableWallet) ListCertificates(ctx context.Context, certifiers []string, types []string) ([]certificates.Certificate, error) {
 	if !isExpectedMockCall(m.ExpectedCalls, "ListCertificates", ctx, certifiers, types) {
 		return nil, errors.New("unexpected call to ListCertificates")
 	}
 	call := m.Called(ctx, certifiers, types)
-	return call.Get(0).([]wallet.Certificate), call.Error(1)
+	return call.Get(0).([]certificates.Certificate), call.Error(1)
 }
 
 // ProveCertificate return mocked certificate proof value.
-func (m *MockableWallet) ProveCertificate(ctx context.Context, cert wallet.Certificate, verifier string, fieldsToReveal []string) (map[string]string, error) {
+func (m *MockableWallet) ProveCertificate(ctx context.Context, cert certificates.Certificate, verifier string, fieldsToReveal []string) (map[string]string, error) {
 	if !isExpectedMockCall(m.ExpectedCalls, "ProveCertificate", ctx, cert, verifier, fieldsToReveal) {
 		return nil, errors.New("unexpected call to ProveCertificate")
 	}
@@ -107,11 +170,37 @@ func (m *MockableWallet) OnCreateSignatureOnce(result *wallet.CreateSignatureRes
 	return m.On("CreateSignature", mock.Anything, mock.Anything).Return(result, err).Once()
 }
 
+// OnCreateSignatureBySignerOnce sets up a one-time expectation for CreateSignature which is signed by the signer wallet,
+// so the signature can be verified like one of a real wallet.
+func (m *MockableWallet) OnCreateSignatureBySignerOnce(signer wallet.WalletInterface) *mock.Call {
+	return m.On("CreateSignature", mock.Anything, mock.Anything).Return(signer, nil).Once()
+}
+
 // OnVerifySignatureOnce sets up a one-time expectation for VerifySignature.
 func (m *MockableWallet) OnVerifySignatureOnce(result *wallet.VerifySignatureResult, err error) *mock.Call {
 	return m.On("VerifySignature", mock.Anything).Return(result, err).Once()
 }
 
+// OnEncryptOnce sets up a one-time expectation for Encrypt.
+func (m *MockableWallet) OnEncryptOnce(result *wallet.EncryptResult, err error) *mock.Call {
+	return m.On("Encrypt", mock.Anything, mock.Anything).Return(result, err).Once()
+}
+
+// OnDecryptOnce sets up a one-time expectation for Decrypt.
+func (m *MockableWallet) OnDecryptOnce(result *wallet.DecryptResult, err error) *mock.Call {
+	return m.On("Decrypt", mock.Anything, mock.Anything).Return(result, err).Once()
+}
+
+// OnRevealCounterpartyKeyLinkageOnce sets up a one-time expectation for RevealCounterpartyKeyLinkage.
+func (m *MockableWallet) OnRevealCounterpartyKeyLinkageOnce(result *wallet.RevealCounterpartyKeyLinkageResult, err error) *mock.Call {
+	return m.On("RevealCounterpartyKeyLinkage", mock.Anything, mock.Anything).Return(result, err).Once()
+}
+
+// OnRevealSpecificKeyLinkageOnce sets up a one-time expectation for RevealSpecificKeyLinkage.
+func (m *MockableWallet) OnRevealSpecificKeyLinkageOnce(result *wallet.RevealSpecificKeyLinkageResult, err error) *mock.Call {
+	return m.On("RevealSpecificKeyLinkage", mock.Anything, mock.Anything).Return(result, err).Once()
+}
+
 // OnCreateNonceOnce sets up a one-time expectation for CreateNonce.
 func (m *MockableWallet) OnCreateNonceOnce(nonce string, err error) *mock.Call {
 	return m.On("CreateNonce", mock.Anything).Return(nonce, err).Once()
@@ -122,12 +211,27 @@ func (m *MockableWallet) OnVerifyNonceOnce(isValid bool, err error) *mock.Call {
 	return m.On("VerifyNonce", mock.Anything, mock.Anything).Return(isValid, err).Once()
 }
 
+// OnGetHeightOnce sets up a one-time expectation for GetHeight.
+func (m *MockableWallet) OnGetHeightOnce(result *wallet.GetHeightResult, err error) *mock.Call {
+	return m.On("GetHeight", mock.Anything, mock.Anything).Return(result, err).Once()
+}
+
+// OnGetNetworkOnce sets up a one-time expectation for GetNetwork.
+func (m *MockableWallet) OnGetNetworkOnce(result *wallet.GetNetworkResult, err error) *mock.Call {
+	return m.On("GetNetwork", mock.Anything, mock.Anything).Return(result, err).Once()
+}
+
+// OnGetVersionOnce sets up a one-time expectation for GetVersion.
+func (m *MockableWallet) OnGetVersionOnce(result *wallet.GetVersionResult, err error) *mock.Call {
+	return m.On("GetVersion", mock.Anything, mock.Anything).Return(result, err).Once()
+}
+
 // OnListCertificatesOnce sets up a one-time expectation for ListCertificates.
-func (m *MockableWallet) OnListCertificatesOnce(certifiers, types []string, certs []wallet.Certificate, err error) *mock.Call {
+func (m *MockableWallet) OnListCertificatesOnce(certifiers, types []string, certs []certificates.Certificate, err error) *mock.Call {
 	return m.On("ListCertificates", mock.Anything, certifiers, types).Return(certs, err).Once()
 }
 
 // OnProveCertificateOnce sets up a one-time expectation for ProveCertificate.
-func (m *MockableWallet) OnProveCertificateOnce(cert wallet.Certificate, verifier string, fieldsToReveal []string, result map[string]string, err error) *mock.Call {
+func (m *MockableWallet) OnProveCertificateOnce(cert certificates.Certificate, verifier string, fieldsToReveal []string, result map[string]string, err error) *mock.Call {
 	return m.On("ProveCertificate", mock.Anything, cert, verifier, fieldsToReveal).Return(result, err).Once()
 }
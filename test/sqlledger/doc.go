@@ -0,0 +1,3 @@
+// Package sqlledger tests payment.SQLLedger against SQLite. It is a module of its own,
+// so the SQL driver is not a dependency of the middleware.
+package sqlledger
@@ -0,0 +1,174 @@
+package sqlledger_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/bsv-blockchain/go-bsv-middleware/pkg/middleware/payment"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+// immediate transactions take the write lock when they begin, so concurrent debits wait for each other
+const immediateTransactions = "?_txlock=immediate&_pragma=busy_timeout(5000)"
+
+func newLedger(t *testing.T, params string) *payment.SQLLedger {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "ledger.db")+params)
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, db.Close()) })
+
+	ledger, err := payment.NewSQLLedger(db, payment.SQLLedgerOptions{})
+	require.NoError(t, err)
+	require.NoError(t, ledger.CreateTable(context.Background()))
+	return ledger
+}
+
+func TestSQLLedger(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("credits and debits update balance and history", func(t *testing.T) {
+		// given
+		ledger := newLedger(t, immediateTransactions)
+
+		// when
+		_, err := ledger.Credit(ctx, "alice", 100, "payment-1")
+		require.NoError(t, err)
+		_, err = ledger.Debit(ctx, "alice", 30, "refund-1")
+		require.NoError(t, err)
+		_, err = ledger.Credit(ctx, "bob", 5, "payment-2")
+		require.NoError(t, err)
+
+		// then
+		balance, err := ledger.Balance(ctx, "alice")
+		require.NoError(t, err)
+		require.Equal(t, int64(70), balance)
+
+		history, err := ledger.History(ctx, "alice")
+		require.NoError(t, err)
+		require.Len(t, history, 2)
+		require.Equal(t, payment.EntryKindCredit, history[0].Kind)
+		require.Equal(t, "payment-1", history[0].Reference)
+		require.Equal(t, payment.EntryKindDebit, history[1].Kind)
+		require.Equal(t, int64(30), history[1].Satoshis)
+		require.NotEqual(t, history[0].ID, history[1].ID)
+	})
+
+	t.Run("credit of an already credited reference is a no-op", func(t *testing.T) {
+		// given
+		ledger := newLedger(t, immediateTransactions)
+		first, err := ledger.Credit(ctx, "alice", 100, "payment-1")
+		require.NoError(t, err)
+
+		// when
+		second, err := ledger.Credit(ctx, "alice", 100, "payment-1")
+
+		// then
+		require.NoError(t, err)
+		require.Equal(t, first.ID, second.ID)
+		balance, err := ledger.Balance(ctx, "alice")
+		require.NoError(t, err)
+		require.Equal(t, int64(100), balance)
+		history, err := ledger.History(ctx, "alice")
+		require.NoError(t, err)
+		require.Len(t, history, 1)
+	})
+
+	t.Run("rejects debit above balance", func(t *testing.T) {
+		// given
+		ledger := newLedger(t, immediateTransactions)
+		_, err := ledger.Credit(ctx, "alice", 10, "payment-1")
+		require.NoError(t, err)
+
+		// when
+		_, err = ledger.Debit(ctx, "alice", 11, "refund-1")
+
+		// then
+		require.ErrorIs(t, err, payment.ErrInsufficientBalance)
+		balance, err := ledger.Balance(ctx, "alice")
+		require.NoError(t, err)
+		require.Equal(t, int64(10), balance)
+	})
+
+	// deferred transactions of WAL databases fail with SQLITE_BUSY_SNAPSHOT when another debit committed since they
+	// read the balance, so they are retried
+	for name, params := range map[string]string{
+		"immediate transactions":         immediateTransactions,
+		"deferred transactions with WAL": "?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)",
+	} {
+		t.Run("concurrent debits do not overdraw the account with "+name, func(t *testing.T) {
+			// given
+			const debits = 20
+			ledger := newLedger(t, params)
+			_, err := ledger.Credit(ctx, "alice", 50, "payment-1")
+			require.NoError(t, err)
+
+			// when
+			var wg sync.WaitGroup
+			errs := make(chan error, debits)
+			for i := range debits {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := ledger.Debit(ctx, "alice", 10, fmt.Sprintf("refund-%d", i))
+					errs <- err
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			// then
+			var accepted int
+			for err := range errs {
+				if err == nil {
+					accepted++
+					continue
+				}
+				require.ErrorIs(t, err, payment.ErrInsufficientBalance)
+			}
+			require.Equal(t, 5, accepted)
+
+			balance, err := ledger.Balance(ctx, "alice")
+			require.NoError(t, err)
+			require.Equal(t, int64(0), balance)
+		})
+	}
+}
+
+func TestIsSerializationFailure(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		want bool
+	}{
+		"postgres serialization failure": {err: sqlStateError("40001"), want: true},
+		"postgres deadlock":              {err: sqlStateError("40P01"), want: true},
+		"postgres unique violation":      {err: sqlStateError("23505")},
+		"sqlite busy":                    {err: codeError(5), want: true},
+		"sqlite busy snapshot":           {err: codeError(517), want: true},
+		"sqlite constraint":              {err: codeError(19)},
+		"wrapped sqlite busy":            {err: fmt.Errorf("failed to commit, %w", codeError(5)), want: true},
+		"other error":                    {err: errors.New("connection refused")},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, test.want, payment.IsSerializationFailure(test.err))
+		})
+	}
+}
+
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "sql state " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+type codeError int
+
+func (e codeError) Error() string { return fmt.Sprintf("result code %d", int(e)) }
+func (e codeError) Code() int     { return int(e) }